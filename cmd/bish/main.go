@@ -7,23 +7,59 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/mattn/go-runewidth"
+	"github.com/robottwo/bishop/internal/abbr"
 	"github.com/robottwo/bishop/internal/analytics"
+	"github.com/robottwo/bishop/internal/arglimit"
 	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/bench"
+	"github.com/robottwo/bishop/internal/captures"
 	"github.com/robottwo/bishop/internal/coach"
 	"github.com/robottwo/bishop/internal/completion"
 	"github.com/robottwo/bishop/internal/config"
+	"github.com/robottwo/bishop/internal/containers"
 	"github.com/robottwo/bishop/internal/core"
+	"github.com/robottwo/bishop/internal/dataview"
+	"github.com/robottwo/bishop/internal/devenv"
+	"github.com/robottwo/bishop/internal/diskusage"
+	"github.com/robottwo/bishop/internal/doctor"
+	"github.com/robottwo/bishop/internal/dotfiles"
 	"github.com/robottwo/bishop/internal/environment"
 	"github.com/robottwo/bishop/internal/evaluate"
+	"github.com/robottwo/bishop/internal/fastsearch"
+	"github.com/robottwo/bishop/internal/fleet"
+	"github.com/robottwo/bishop/internal/git"
 	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/httpreq"
+	"github.com/robottwo/bishop/internal/i18n"
+	"github.com/robottwo/bishop/internal/jobs"
+	"github.com/robottwo/bishop/internal/later"
+	"github.com/robottwo/bishop/internal/migrate"
+	"github.com/robottwo/bishop/internal/opener"
+	"github.com/robottwo/bishop/internal/outputfmt"
+	"github.com/robottwo/bishop/internal/pathfmt"
+	"github.com/robottwo/bishop/internal/pkgmgr"
+	"github.com/robottwo/bishop/internal/ports"
+	"github.com/robottwo/bishop/internal/procpick"
+	"github.com/robottwo/bishop/internal/rctriage"
+	"github.com/robottwo/bishop/internal/scriptlint"
+	"github.com/robottwo/bishop/internal/startup"
 	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/termreset"
+	"github.com/robottwo/bishop/internal/timer"
+	"github.com/robottwo/bishop/internal/tldr"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/internal/utils"
 	"github.com/robottwo/bishop/internal/wizard"
+	"github.com/robottwo/bishop/internal/wsl"
+	"github.com/robottwo/bishop/pkg/shellinput"
 	"go.uber.org/zap"
 	"golang.org/x/term"
 	"mvdan.cc/sh/v3/expand"
@@ -40,6 +76,7 @@ var loginShell = flag.Bool("l", false, "run as a login shell")
 var rcFile = flag.String("rcfile", "", "use a custom rc file instead of ~/.bishrc")
 var strictConfig = flag.Bool("strict-config", false, "fail fast if configuration files contain errors (like bash 'set -e')")
 var setupFlag = flag.Bool("setup", false, "run the setup wizard")
+var reportFlag = flag.Bool("report", false, "print per-statement progress and a timing report when running scripts")
 
 var helpFlag bool
 var versionFlag bool
@@ -76,11 +113,22 @@ func init() {
 // 3. Command execution: bish -c "command"
 // 4. Interactive shell: bish (when stdin is a terminal)
 // 5. Script execution: bish script.sh
+// 6. Reported script execution: bish run --report script.sh
+// 7. Dotfiles bootstrap: bish init-dotfiles
+// 8. Rc file migration: bish migrate ~/.zshrc
+// 9. Memory report: bish doctor
+// 10. Terminal recovery: bish fix-terminal
 //
 // After initialization, it delegates to the run() function which handles
 // the actual execution based on the detected mode and handles exit codes.
 func main() {
 	flag.Parse()
+	parseSubcommand()
+	i18n.SetLocale(i18n.DetectLocale(os.Getenv))
+
+	if code, ok := runToolSubcommand(); ok {
+		os.Exit(code)
+	}
 
 	if versionFlag {
 		fmt.Printf("bish version %s\n", BUILD_VERSION)
@@ -92,73 +140,107 @@ func main() {
 		return
 	}
 
-	// Initialize the history manager
-	historyManager, err := initializeHistoryManager()
-	if err != nil {
-		panic(fmt.Sprintf("failed to initialize history manager: %v", err))
-	}
-	defer func() {
-		if err := historyManager.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to close history manager: %v\n", err)
-		}
-	}()
-
-	// Initialize the analytics manager
-	analyticsManager, err := initializeAnalyticsManager()
-	if err != nil {
-		panic(fmt.Sprintf("failed to initialize analytics manager: %v", err))
+	// Initialize in steps that run as soon as those they need are done, so
+	// that opening the databases overlaps
+	var (
+		historyManager    *history.HistoryManager
+		analyticsManager  *analytics.AnalyticsManager
+		completionManager *completion.CompletionManager
+		todoStore         *todo.Store
+		stderrCapturer    = core.NewStderrCapturer(os.Stderr)
+		runner            *interp.Runner
+		logger            *zap.Logger
+		coachManager      *coach.CoachManager
+	)
+	timings, err := startup.Run([]startup.Step{
+		{Name: "history", Run: func() (err error) {
+			if historyManager, err = initializeHistoryManager(); err != nil {
+				return fmt.Errorf("failed to initialize history manager: %w", err)
+			}
+			return nil
+		}},
+		{Name: "analytics", Run: func() (err error) {
+			if analyticsManager, err = initializeAnalyticsManager(); err != nil {
+				return fmt.Errorf("failed to initialize analytics manager: %w", err)
+			}
+			return nil
+		}},
+		{Name: "completion", Run: func() error {
+			completionManager = initializeCompletionManager()
+			return nil
+		}},
+		{Name: "devenv", Run: func() error {
+			// Detected once and kept, so detect them while the databases open
+			devenv.Current()
+			wsl.Detected()
+			return nil
+		}},
+		{Name: "todo", After: []string{"history"}, Run: func() (err error) {
+			todoStore, err = todo.NewStore(historyManager.GetDB())
+			return err
+		}},
+		{Name: "runner", After: []string{"history", "analytics", "completion", "devenv", "todo"}, Run: func() (err error) {
+			runner, err = initializeRunner(analyticsManager, historyManager, completionManager, todoStore, stderrCapturer)
+			// Register session config override getter so environment package can access config overrides
+			environment.SetSessionConfigOverrideGetter(config.GetSessionOverride)
+			return err
+		}},
+		{Name: "wizard", After: []string{"runner"}, Run: func() error {
+			// Run setup wizard if needed or requested
+			if *setupFlag || (term.IsTerminal(int(os.Stdin.Fd())) && *command == "" && flag.NArg() == 0 && wizard.NeedsSetup()) {
+				if err := wizard.RunWizard(runner, historyManager); err != nil {
+					fmt.Fprintf(os.Stderr, "Setup wizard failed: %v\n", err)
+				}
+			}
+			return nil
+		}},
+		{Name: "logger", After: []string{"wizard"}, Run: func() (err error) {
+			logger, err = initializeLogger(runner)
+			return err
+		}},
+		{Name: "coach", After: []string{"logger"}, Run: func() (err error) {
+			// Initialize the coach manager (uses same database as history)
+			if coachManager, err = coach.NewCoachManager(historyManager.GetDB(), historyManager, runner, logger); err != nil {
+				logger.Warn("failed to initialize coach manager", zap.Error(err))
+				// Coach is optional, continue without it
+				coachManager = nil
+			}
+			return nil
+		}},
+	})
+	if historyManager != nil {
+		defer func() {
+			if err := historyManager.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to close history manager: %v\n", err)
+			}
+		}()
 	}
-	defer func() {
-		if err := analyticsManager.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to close analytics manager: %v\n", err)
-		}
-	}()
-
-	// Initialize the completion manager
-	completionManager := initializeCompletionManager()
-
-	// Initialize the stderr capturer
-	stderrCapturer := core.NewStderrCapturer(os.Stderr)
-
-	// Initialize the shell interpreter
-	runner, err := initializeRunner(analyticsManager, historyManager, completionManager, stderrCapturer)
-	if err != nil {
-		panic(err)
+	if analyticsManager != nil {
+		defer func() {
+			if err := analyticsManager.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to close analytics manager: %v\n", err)
+			}
+		}()
 	}
-
-	// Register session config override getter so environment package can access config overrides
-	environment.SetSessionConfigOverrideGetter(config.GetSessionOverride)
-
-	// Run setup wizard if needed or requested
-	if *setupFlag || (term.IsTerminal(int(os.Stdin.Fd())) && *command == "" && flag.NArg() == 0 && wizard.NeedsSetup()) {
-		if err := wizard.RunWizard(runner); err != nil {
-			fmt.Fprintf(os.Stderr, "Setup wizard failed: %v\n", err)
-		}
+	if logger != nil {
+		defer func() {
+			_ = logger.Sync() // Flush any buffered log entries
+		}()
 	}
-
-	// Initialize the logger
-	logger, err := initializeLogger(runner)
 	if err != nil {
 		panic(err)
 	}
-	defer func() {
-		_ = logger.Sync() // Flush any buffered log entries
-	}()
 
 	analyticsManager.Logger = logger
 
 	logger.Info("-------- new bish session --------", zap.Any("args", os.Args))
-
-	// Initialize the coach manager (uses same database as history)
-	coachManager, err := coach.NewCoachManager(historyManager.GetDB(), historyManager, runner, logger)
-	if err != nil {
-		logger.Warn("failed to initialize coach manager", zap.Error(err))
-		// Coach is optional, continue without it
-		coachManager = nil
+	for _, timing := range timings {
+		logger.Debug("startup step", zap.String("step", timing.Name), zap.Duration("start", timing.Start), zap.Duration("took", timing.Duration))
 	}
 
 	// Start running
-	err = run(runner, historyManager, analyticsManager, completionManager, coachManager, logger, stderrCapturer)
+	err = run(runner, historyManager, analyticsManager, completionManager, coachManager, todoStore, logger, stderrCapturer)
+	bash.RemoveSourceShims()
 
 	// Handle exit status
 	if code, ok := interp.IsExitStatus(err); ok {
@@ -177,11 +259,24 @@ func run(
 	analyticsManager *analytics.AnalyticsManager,
 	completionManager *completion.CompletionManager,
 	coachManager *coach.CoachManager,
+	todoStore *todo.Store,
 	logger *zap.Logger,
 	stderrCapturer *core.StderrCapturer,
 ) error {
 	ctx := context.Background()
 
+	// bish migrate ~/.zshrc
+	if isToolSubcommand("migrate") {
+		newAdvisor := func() migrate.Advisor {
+			llmClient, modelConfig := utils.GetLLMClient(runner, utils.FastModel)
+			return migrate.NewLLMAdvisor(llmClient, modelConfig)
+		}
+		if code := migrate.RunCommand(ctx, flag.Args()[1:], newAdvisor, os.Stdout, os.Stderr); code != 0 {
+			return interp.NewExitStatus(uint8(code))
+		}
+		return nil
+	}
+
 	// bish -c "echo hello"
 	if *command != "" {
 		return bash.RunBashScriptFromReader(ctx, runner, strings.NewReader(*command), "bish")
@@ -190,12 +285,26 @@ func run(
 	// bish
 	if flag.NArg() == 0 {
 		if term.IsTerminal(int(os.Stdin.Fd())) {
-			return core.RunInteractiveShell(ctx, runner, historyManager, analyticsManager, completionManager, coachManager, logger, stderrCapturer)
+			return core.RunInteractiveShell(ctx, runner, historyManager, analyticsManager, completionManager, coachManager, todoStore, logger, stderrCapturer)
 		}
 
 		return bash.RunBashScriptFromReader(ctx, runner, os.Stdin, "bish")
 	}
 
+	// bish run --report script.sh
+	if *reportFlag {
+		for _, filePath := range flag.Args() {
+			report, err := bash.RunBashScriptFileWithReport(ctx, runner, filePath, os.Stderr)
+			if report != nil {
+				report.Write(os.Stderr)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	// bish script.sh
 	for _, filePath := range flag.Args() {
 		if err := bash.RunBashScriptFromFile(ctx, runner, filePath); err != nil {
@@ -206,14 +315,60 @@ func run(
 	return nil
 }
 
+// parseSubcommand handles the optional "run" subcommand, which accepts the same
+// flags as the top-level command after it (e.g. bish run --report script.sh).
+// A file named "run" in the current directory is still treated as a script.
+func parseSubcommand() {
+	if flag.NArg() == 0 || flag.Arg(0) != "run" {
+		return
+	}
+	if _, err := os.Stat("run"); err == nil {
+		return
+	}
+	// flag.CommandLine exits on parse errors, so the error can be ignored here
+	_ = flag.CommandLine.Parse(flag.Args()[1:])
+}
+
+// isToolSubcommand reports whether the first argument is the given subcommand.
+// As with "run", a script of the same name in the current directory takes
+// precedence.
+func isToolSubcommand(name string) bool {
+	if flag.NArg() == 0 || flag.Arg(0) != name {
+		return false
+	}
+	_, err := os.Stat(name)
+	return err != nil
+}
+
+// runToolSubcommand runs subcommands that need neither a shell nor the user's
+// configuration, such as "bish init-dotfiles".
+func runToolSubcommand() (int, bool) {
+	switch {
+	case isToolSubcommand("init-dotfiles"):
+		return dotfiles.RunCommand(flag.Args()[1:], dotfiles.Options{RcPath: *rcFile}, os.Stdin, os.Stdout, os.Stderr), true
+	case isToolSubcommand("doctor"):
+		return doctor.RunCommand(flag.Args()[1:], doctor.Options{HistoryPath: core.HistoryFile()}, os.Stdout, os.Stderr), true
+	case isToolSubcommand("fix-terminal"):
+		return termreset.RunCommand(flag.Args()[1:], termreset.Terminal(), os.Stdout, os.Stderr), true
+	}
+	return 0, false
+}
+
 func printUsage() {
 	// Header
-	fmt.Println(styles.AGENT_QUESTION("Usage:") + " bish [flags] [script]")
-	fmt.Printf("\nA modern, POSIX-compatible, Generative Shell. Version: %s\n", BUILD_VERSION)
+	usageHeading := i18n.T("usage.heading")
+	fmt.Println(styles.AGENT_QUESTION(usageHeading) + " bish [flags] [script]")
+	fmt.Println(strings.Repeat(" ", runewidth.StringWidth(usageHeading)+1) + "bish run --report <script>")
+	fmt.Println(strings.Repeat(" ", runewidth.StringWidth(usageHeading)+1) + "bish init-dotfiles [--non-interactive]")
+	fmt.Println(strings.Repeat(" ", runewidth.StringWidth(usageHeading)+1) + "bish migrate [--ai] [-o file] <rc file>")
+	fmt.Println(strings.Repeat(" ", runewidth.StringWidth(usageHeading)+1) + "bish doctor")
+	fmt.Println(strings.Repeat(" ", runewidth.StringWidth(usageHeading)+1) + "bish fix-terminal [--hard]")
+	fmt.Println()
+	fmt.Println(i18n.T("usage.description", BUILD_VERSION))
 	fmt.Println()
 
 	// Flags
-	fmt.Println(styles.AGENT_QUESTION("Options:"))
+	fmt.Println(styles.AGENT_QUESTION(i18n.T("usage.options")))
 
 	// We want to group aliases like -h and -help together
 	// Map to track which flags we've already printed
@@ -269,11 +424,11 @@ func printUsage() {
 	})
 
 	fmt.Println()
-	fmt.Println(styles.AGENT_QUESTION("Key Features:"))
-	fmt.Printf("  %-28s %s\n", "# <message>", "Chat with the agent")
-	fmt.Printf("  %-28s %s\n", "#!<control>", "Agent controls (e.g., #!config, #!new)")
-	fmt.Printf("  %-28s %s\n", "#?", "Magic Fix: Analyze and fix the last error")
-	fmt.Printf("  %-28s %s\n", "#/<macro>", "Run a chat macro (e.g., #/gitdiff)")
+	fmt.Println(styles.AGENT_QUESTION(i18n.T("usage.key_features")))
+	fmt.Printf("  %-28s %s\n", "# <message>", i18n.T("usage.feature.chat"))
+	fmt.Printf("  %-28s %s\n", "#!<control>", i18n.T("usage.feature.control"))
+	fmt.Printf("  %-28s %s\n", "#?", i18n.T("usage.feature.magic_fix"))
+	fmt.Printf("  %-28s %s\n", "#/<macro>", i18n.T("usage.feature.macro"))
 }
 
 // newCompressedSink creates a new compressed sink from a URL.
@@ -436,11 +591,13 @@ func initializeAnalyticsManager() (*analytics.AnalyticsManager, error) {
 }
 
 func initializeCompletionManager() *completion.CompletionManager {
-	return completion.NewCompletionManager()
+	completionManager := completion.NewCompletionManager()
+	completionManager.EnableBashCompletion(completion.DefaultBashCompletionDirs())
+	return completionManager
 }
 
 // initializeRunner loads the shell configuration files and sets up the interpreter.
-func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManager *history.HistoryManager, completionManager *completion.CompletionManager, stderrCapturer *core.StderrCapturer) (*interp.Runner, error) {
+func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManager *history.HistoryManager, completionManager *completion.CompletionManager, todoStore *todo.Store, stderrCapturer *core.StderrCapturer) (*interp.Runner, error) {
 	shellPath, err := os.Executable()
 	if err != nil {
 		panic(err)
@@ -456,6 +613,21 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 
 	var runner *interp.Runner
 
+	// recordCommand adds the commands that builtins such as pk perform on the
+	// user's behalf to history, that tv hands back to be scripted, or the
+	// results of bench
+	recordCommand := func(command string, exitCode int) {
+		if entry, err := historyManager.StartCommand(command, environment.GetPwd(runner), ""); err == nil {
+			_, _ = historyManager.FinishCommand(entry, exitCode)
+		}
+	}
+
+	// patchScript has the LLM fix the findings lint --fix picks
+	patchScript := func(ctx context.Context, source string, findings []scriptlint.Finding) (string, error) {
+		llmClient, modelConfig := utils.GetLLMClient(runner, utils.SlowModel)
+		return scriptlint.Patch(ctx, llmClient, modelConfig, source, findings)
+	}
+
 	// Create interpreter with all necessary configuration in a single call
 	runner, err = interp.New(
 		interp.Interactive(true),
@@ -464,12 +636,41 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 		interp.ExecHandlers(
 			core.NewAutocdExecHandler(), // Must be first to intercept path-like commands
 			bash.NewCdCommandHandler(),
+			bash.NewDirStackCommandHandler(),
+			bash.NewInCommandHandler(),
 			bash.NewTypesetCommandHandler(),
+			bash.NewFnedCommandHandler(bash.DefaultFunctionsDir(), filepath.Join(core.HomeDir(), ".bishrc")),
+			bash.NewCompatCommandHandler(),
 			bash.SetBuiltinHandler(),
 			analytics.NewAnalyticsCommandHandler(analyticsManager),
 			evaluate.NewEvaluateCommandHandler(analyticsManager),
 			history.NewHistoryCommandHandler(historyManager),
+			history.NewFcCommandHandler(historyManager, history.DefaultReruns),
 			completion.NewCompleteCommandHandler(completionManager),
+			completion.NewCompgenCommandHandler(func() *interp.Runner { return runner }),
+			pathfmt.NewPathCommandHandler(),
+			git.NewGitCommandHandler(),
+			tldr.NewTldrCommandHandler(tldr.DefaultCacheDir()),
+			httpreq.NewReqCommandHandler(httpreq.DefaultHistory),
+			todo.NewTodoCommandHandler(todoStore),
+			abbr.NewAbbrCommandHandler(abbr.DefaultPath()),
+			fleet.NewFleetCommandHandler(fleet.DefaultGroupsPath(), fleet.DefaultSSHConfigPath(), fleet.SSH),
+			pkgmgr.NewPkgCommandHandler(),
+			ports.NewPortsCommandHandler(containers.Exec),
+			opener.NewOpenCommandHandler(opener.Current()),
+			procpick.NewPkCommandHandler(procpick.Run, recordCommand),
+			diskusage.NewDuvCommandHandler(diskusage.Run, recordCommand),
+			dataview.NewTvCommandHandler(dataview.Run, recordCommand),
+			captures.NewCapturesCommandHandler(captures.DefaultStore),
+			jobs.NewJobsCommandHandler(jobs.DefaultTable),
+			later.NewLaterCommandHandler(later.DefaultQueue),
+			timer.NewTimerCommandHandler(timer.DefaultClock),
+			bench.NewBenchCommandHandler(bench.RunShell, recordCommand),
+			scriptlint.NewLintCommandHandler(patchScript),
+			fastsearch.NewFastSearchHandler(fastsearch.DefaultAdvisor),
+			arglimit.NewArgLimitHandler(arglimit.DefaultGuard),          // Checks the commands as they will run
+			outputfmt.NewFormatOutputHandler(outputfmt.DefaultRecorder), // Hands the jobs it formats to the next handler
+			jobs.NewExecHandler(jobs.DefaultTable),                      // Must be last: runs external commands as jobs
 		),
 	)
 	if err != nil {
@@ -497,6 +698,19 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 		panic(err)
 	}
 
+	envs := devenv.Current()
+	environment.SetDevEnv(runner, devenv.Names(envs))
+
+	if wsl.Detected() {
+		// Paste from and copy kills to the Windows clipboard, and open links
+		// in the Windows browser unless the user picked one
+		shellinput.ReadClipboard = wsl.ReadClipboard
+		shellinput.WriteClipboard = wsl.WriteClipboard
+		if _, ok := os.LookupEnv("BROWSER"); !ok {
+			runner.Vars["BROWSER"] = expand.Variable{Kind: expand.String, Str: wsl.Browser(), Exported: true}
+		}
+	}
+
 	var configFiles []string
 
 	// If custom rcfile is provided, use it instead of the default ones
@@ -507,6 +721,9 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 			filepath.Join(core.HomeDir(), ".bishrc"),
 			filepath.Join(core.HomeDir(), ".bishenv"),
 		}
+		// Overlays such as ~/.bishrc.nix adjust the config for the
+		// environment bish runs in
+		configFiles = append(configFiles, devenv.OverlayFiles(core.HomeDir(), envs)...)
 
 		// Check if this is a login shell
 		if *loginShell || strings.HasPrefix(os.Args[0], "-") {
@@ -521,11 +738,33 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 		}
 	}
 
+	// An unreadable list of ignored errors just means nothing is ignored
+	ignoredRCErrors, _ := rctriage.LoadIgnored(rctriage.DefaultIgnoredPath())
+
 	for _, configFile := range configFiles {
 		if stat, err := os.Stat(configFile); err == nil && stat.Size() > 0 {
-			if err := bash.RunBashScriptFromFile(context.Background(), runner, configFile); err != nil {
+			// Show which file is loading if it takes a while, but only on a terminal
+			var progress io.Writer
+			if term.IsTerminal(int(os.Stderr.Fd())) {
+				progress = os.Stderr
+			}
+			timeout := environment.GetRCTimeout(runner)
+			if err := bash.RunConfigFile(context.Background(), runner, configFile, timeout, progress); err != nil {
+				issues := rctriage.FromError(configFile, err)
+				if !*strictConfig {
+					// Errors hidden with #!triage ignore are not reported again
+					if issues = ignoredRCErrors.Filter(issues); len(issues) == 0 {
+						continue
+					}
+				}
+
 				// Enhanced error reporting with context
-				fmt.Fprintf(os.Stderr, "Configuration file %s contains errors: %v\n", configFile, err)
+				var timeoutErr *bash.RCTimeoutError
+				if errors.As(err, &timeoutErr) {
+					fmt.Fprintf(os.Stderr, "bish: loading %s took too long: %v\n", configFile, err)
+				} else {
+					fmt.Fprintf(os.Stderr, "Configuration file %s contains errors: %v\n", configFile, err)
+				}
 
 				if *strictConfig {
 					// In strict mode (like bash 'set -e'), fail fast on configuration errors
@@ -533,6 +772,8 @@ func initializeRunner(analyticsManager *analytics.AnalyticsManager, historyManag
 				}
 				// In permissive mode (default), continue despite configuration errors
 				// This maintains backward compatibility while providing better visibility
+				// and lists them again above the first prompt for triage
+				rctriage.Record(issues...)
 			}
 			// Configuration loaded successfully in permissive mode
 		}
@@ -0,0 +1,94 @@
+// Package abbr implements fish-style abbreviations: words that expand into
+// longer commands as they are typed, so that what runs is visible and lands
+// in history in full, unlike aliases.
+package abbr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Abbreviations maps an abbreviation to the text it expands into.
+type Abbreviations map[string]string
+
+// abbreviationsFile is the format of the abbreviations file:
+//
+//	abbreviations:
+//	  gco: git checkout
+//	  gp: git push
+type abbreviationsFile struct {
+	Abbreviations Abbreviations `yaml:"abbreviations"`
+}
+
+// DefaultPath returns where abbreviations are stored.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "abbreviations.yaml")
+}
+
+// Load reads the abbreviations at path. A missing file has none.
+func Load(path string) (Abbreviations, error) {
+	if path == "" {
+		return Abbreviations{}, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Abbreviations{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file abbreviationsFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Abbreviations == nil {
+		file.Abbreviations = Abbreviations{}
+	}
+	return file.Abbreviations, nil
+}
+
+// Save writes abbrs to path.
+func (abbrs Abbreviations) Save(path string) error {
+	if path == "" {
+		return fmt.Errorf("no place to store abbreviations")
+	}
+	content, err := yaml.Marshal(abbreviationsFile{Abbreviations: abbrs})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// Names returns the abbreviations in order.
+func (abbrs Abbreviations) Names() []string {
+	names := make([]string, 0, len(abbrs))
+	for name := range abbrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the expansion of word, if it is an abbreviation.
+func (abbrs Abbreviations) Lookup(word string) (string, bool) {
+	expansion, ok := abbrs[word]
+	return expansion, ok
+}
+
+// ValidName reports whether name can be an abbreviation: a single word
+// without quotes or shell operators, which could never be typed as one.
+func ValidName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\n'\"`\\|&;()<>$=")
+}
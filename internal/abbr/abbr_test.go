@@ -0,0 +1,49 @@
+package abbr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	abbrs, err := Load(filepath.Join(t.TempDir(), "abbreviations.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, abbrs)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bish", "abbreviations.yaml")
+	abbrs := Abbreviations{"gco": "git checkout", "k": "kubectl"}
+	require.NoError(t, abbrs.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, abbrs, loaded)
+	assert.Equal(t, []string{"gco", "k"}, loaded.Names())
+
+	expansion, ok := loaded.Lookup("gco")
+	assert.True(t, ok)
+	assert.Equal(t, "git checkout", expansion)
+	_, ok = loaded.Lookup("git")
+	assert.False(t, ok)
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abbreviations.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("abbreviations: [oops"), 0o644))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, path)
+}
+
+func TestValidName(t *testing.T) {
+	assert.True(t, ValidName("gco"))
+	assert.True(t, ValidName("k8s-get"))
+	assert.False(t, ValidName(""))
+	assert.False(t, ValidName("git co"))
+	assert.False(t, ValidName("a|b"))
+	assert.False(t, ValidName("x=1"))
+}
@@ -0,0 +1,104 @@
+package abbr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const usage = "Usage: abbr [--show]\n" +
+	"       abbr [-a|--add] NAME EXPANSION...\n" +
+	"       abbr -e|--erase NAME...\n" +
+	"       abbr -l|--list"
+
+// NewAbbrCommandHandler creates an ExecHandler for the abbr builtin, which
+// manages the abbreviations stored at path. An abbreviation typed as a
+// command expands in the line when followed by space or Enter.
+func NewAbbrCommandHandler(path string) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "abbr" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			abbrs, err := Load(path)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "abbr: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+
+			args = args[1:]
+			action := "--add"
+			switch {
+			case len(args) == 0:
+				action = "--show"
+			case strings.HasPrefix(args[0], "-"):
+				action, args = args[0], args[1:]
+			}
+
+			switch action {
+			case "-h", "--help":
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+
+			case "-s", "--show":
+				for _, name := range abbrs.Names() {
+					fmt.Fprintf(hc.Stdout, "abbr -a %s %s\n", quote(name), quote(abbrs[name]))
+				}
+				return nil
+
+			case "-l", "--list":
+				for _, name := range abbrs.Names() {
+					fmt.Fprintln(hc.Stdout, name)
+				}
+				return nil
+
+			case "-a", "--add":
+				if len(args) < 2 {
+					fmt.Fprintf(hc.Stderr, "abbr: %s requires a name and an expansion\n%s\n", action, usage)
+					return interp.NewExitStatus(2)
+				}
+				if !ValidName(args[0]) {
+					fmt.Fprintf(hc.Stderr, "abbr: invalid abbreviation name %q\n", args[0])
+					return interp.NewExitStatus(2)
+				}
+				abbrs[args[0]] = strings.Join(args[1:], " ")
+
+			case "-e", "--erase":
+				if len(args) == 0 {
+					fmt.Fprintf(hc.Stderr, "abbr: %s requires a name\n%s\n", action, usage)
+					return interp.NewExitStatus(2)
+				}
+				for _, name := range args {
+					if _, ok := abbrs[name]; !ok {
+						fmt.Fprintf(hc.Stderr, "abbr: no such abbreviation: %s\n", name)
+						return interp.NewExitStatus(1)
+					}
+					delete(abbrs, name)
+				}
+
+			default:
+				fmt.Fprintf(hc.Stderr, "abbr: unknown option %s\n%s\n", action, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			if err := abbrs.Save(path); err != nil {
+				fmt.Fprintf(hc.Stderr, "abbr: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+}
+
+func quote(s string) string {
+	quoted, err := syntax.Quote(s, syntax.LangBash)
+	if err != nil {
+		return s
+	}
+	return quoted
+}
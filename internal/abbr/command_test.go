@@ -0,0 +1,70 @@
+package abbr
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runAbbr(t *testing.T, path, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewAbbrCommandHandler(path)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestAbbrCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abbreviations.yaml")
+
+	_, _, err := runAbbr(t, path, `abbr -a gco git checkout; abbr gp 'git push'`)
+	require.NoError(t, err)
+	abbrs, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Abbreviations{"gco": "git checkout", "gp": "git push"}, abbrs)
+
+	out, _, err := runAbbr(t, path, "abbr")
+	require.NoError(t, err)
+	assert.Equal(t, "abbr -a gco 'git checkout'\nabbr -a gp 'git push'\n", out)
+
+	out, _, err = runAbbr(t, path, "abbr --list")
+	require.NoError(t, err)
+	assert.Equal(t, "gco\ngp\n", out)
+
+	_, _, err = runAbbr(t, path, "abbr --erase gp")
+	require.NoError(t, err)
+	out, _, err = runAbbr(t, path, "abbr -l")
+	require.NoError(t, err)
+	assert.Equal(t, "gco\n", out)
+}
+
+func TestAbbrCommandErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abbreviations.yaml")
+
+	_, stderr, err := runAbbr(t, path, "abbr -e missing")
+	assert.Error(t, err)
+	assert.Equal(t, "abbr: no such abbreviation: missing\n", stderr)
+
+	_, stderr, err = runAbbr(t, path, "abbr -a gco")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "requires a name and an expansion")
+
+	_, stderr, err = runAbbr(t, path, "abbr -a 'g co' git checkout")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "invalid abbreviation name")
+}
@@ -96,6 +96,9 @@ Whenever you are working in a git repository:
 * You can use "git grep" command through the bash tool to help locate relevant code snippets
 # You can use "git ls-files | grep <filename>" to find files by name
 
+Whenever you are unsure about a command's syntax or options:
+* Use the "tldr" tool to look up curated usage examples before running or suggesting the command
+
 Whenever you are writing test cases:
 * Always read the function or code snippet you are trying to test before writing the test case
 * After writing the test case, try to run it and ensure it passes
@@ -105,11 +108,25 @@ Whenever you are trying to create a git commit:
 * Always use "git diff" or "git diff --staged" through the bash tool to 
   understand the changes you are committing before coming up with the commit message
 * Make sure commit messages are concise and descriptive of the changes made
-
+` + agent.webSearchInstructions() + `
 # Latest Context
 ` + agent.contextText
 }
 
+// webSearchInstructions returns system prompt guidance for the web_search tool,
+// or "" when the tool is not available.
+func (agent *Agent) webSearchInstructions() string {
+	if !tools.WebSearchEnabled(agent.runner, agent.logger) {
+		return ""
+	}
+	return `
+Whenever you use the web_search tool:
+* Prefer it for questions about recent tool versions, release notes or error messages you don't recognize
+* Summarize what the results say instead of pasting them
+* Cite every result you rely on as [n] and end your response with a "Sources:" list of the cited URLs
+`
+}
+
 func (agent *Agent) ResetChat() {
 	agent.lastRequestPromptTokens = 0
 	agent.lastRequestCompletionTokens = 0
@@ -197,14 +214,7 @@ func (agent *Agent) Chat(prompt string) (<-chan string, error) {
 			request := openai.ChatCompletionRequest{
 				Model:    agent.llmModelConfig.ModelId,
 				Messages: agent.messages,
-				Tools: []openai.Tool{
-					tools.BashToolDefinition,
-					tools.ViewFileToolDefinition,
-					tools.ViewDirectoryToolDefinition,
-					tools.CreateFileToolDefinition,
-					tools.EditFileToolDefinition,
-					tools.GrepFileToolDefinition,
-				},
+				Tools:    agent.availableTools(),
 			}
 			if agent.llmModelConfig.Temperature != nil {
 				request.Temperature = float32(*agent.llmModelConfig.Temperature)
@@ -277,6 +287,25 @@ func (agent *Agent) Chat(prompt string) (<-chan string, error) {
 	return responseChannel, nil
 }
 
+// availableTools returns the tools offered to the model. Network tools are only
+// included when they are configured and not disabled.
+func (agent *Agent) availableTools() []openai.Tool {
+	agentTools := []openai.Tool{
+		tools.BashToolDefinition,
+		tools.ViewFileToolDefinition,
+		tools.ViewDirectoryToolDefinition,
+		tools.CreateFileToolDefinition,
+		tools.EditFileToolDefinition,
+		tools.GrepFileToolDefinition,
+		tools.TldrToolDefinition,
+		tools.OpenToolDefinition,
+	}
+	if tools.WebSearchEnabled(agent.runner, agent.logger) {
+		agentTools = append(agentTools, tools.WebSearchToolDefinition)
+	}
+	return agentTools
+}
+
 func (agent *Agent) flush(message string, channel chan<- string) {
 	if message != "" && message != agent.lastMessage {
 		channel <- message
@@ -322,6 +351,15 @@ func (agent *Agent) handleToolCall(toolCall openai.ToolCall, responseChannel cha
 	case tools.GrepFileToolDefinition.Function.Name:
 		// grep_file
 		toolResponse = tools.GrepFileTool(agent.runner, agent.logger, params)
+	case tools.TldrToolDefinition.Function.Name:
+		// tldr
+		toolResponse = tools.TldrTool(agent.runner, agent.logger, params)
+	case tools.OpenToolDefinition.Function.Name:
+		// open
+		toolResponse = tools.OpenTool(agent.runner, agent.logger, params)
+	case tools.WebSearchToolDefinition.Function.Name:
+		// web_search
+		toolResponse = tools.WebSearchTool(agent.runner, agent.logger, params)
 	}
 
 	agent.messages = append(agent.messages, openai.ChatCompletionMessage{
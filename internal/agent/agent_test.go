@@ -41,6 +41,24 @@ func TestResetChat(t *testing.T) {
 	assert.Contains(t, agent.messages[0].Content, "You are Bishop", "Expected system message to contain the latest context")
 }
 
+func TestSystemMessageWebSearchInstructions(t *testing.T) {
+	logger := zap.NewNop()
+	systemMessage := func(vars map[string]string) string {
+		runner, _ := interp.New(interp.StdIO(nil, nil, nil))
+		runner.Reset()
+		for name, value := range vars {
+			runner.Vars[name] = expand.Variable{Kind: expand.String, Str: value}
+		}
+		agent := &Agent{runner: runner, logger: logger, messages: []openai.ChatCompletionMessage{{Role: "system"}}}
+		agent.updateSystemMessage()
+		return agent.messages[0].Content
+	}
+
+	assert.NotContains(t, systemMessage(nil), "web_search")
+	assert.Contains(t, systemMessage(map[string]string{"BISH_WEB_SEARCH_BACKEND": "searxng"}), "Whenever you use the web_search tool")
+	assert.NotContains(t, systemMessage(map[string]string{"BISH_WEB_SEARCH_BACKEND": "searxng", "BISH_AGENT_NETWORK_TOOLS": "0"}), "web_search")
+}
+
 func TestPruneMessages(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/robottwo/bishop/internal/bash"
 	"github.com/robottwo/bishop/internal/environment"
 	"github.com/robottwo/bishop/internal/history"
 	"github.com/robottwo/bishop/internal/utils"
@@ -195,6 +196,7 @@ func BashTool(runner *interp.Runner, historyManager *history.HistoryManager, log
 		logger.Error("LLM bash tool received invalid command", zap.Error(err))
 		return failedToolResponse(fmt.Sprintf("`%s` is not a valid bash command: %s", command, err))
 	}
+	bash.Rewrite(prog)
 
 	// Always display the command first for consistent behavior
 	printCommandPrompt(environment.GetAgentPrompt(runner) + command)
@@ -256,7 +258,7 @@ func BashTool(runner *interp.Runner, historyManager *history.HistoryManager, log
 
 	historyEntry, _ := historyManager.StartCommand(command, environment.GetPwd(runner), sessionID)
 
-	err = runner.Run(context.Background(), prog)
+	err = bash.Run(context.Background(), runner, prog)
 
 	exitCode := 0
 	if err != nil {
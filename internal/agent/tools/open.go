@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/opener"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+var OpenToolDefinition = openai.Tool{
+	Type: "function",
+	Function: &openai.FunctionDefinition{
+		Name:        "open",
+		Description: "Open a file or URL for the user in its default application (browser, PDF viewer, image viewer...), or a file at a line in the user's editor. Use it when the user asks to see something, e.g. a generated report or a page.",
+		Parameters: utils.GenerateJsonSchema(struct {
+			Target string `json:"target" description:"A URL, a path, or a path followed by :line or :line:column" required:"true"`
+		}{}),
+	},
+}
+
+// openPlatform is the system files are opened on. Tests override it.
+var openPlatform = opener.Current
+
+// runOpenCommand runs the command that opens a target. Tests override it.
+var runOpenCommand = func(command []string) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func OpenTool(runner *interp.Runner, logger *zap.Logger, params map[string]any) string {
+	target, ok := params["target"].(string)
+	if !ok || strings.TrimSpace(target) == "" {
+		logger.Error("The open tool failed to parse parameter 'target'")
+		return failedToolResponse("The open tool failed to parse parameter 'target'")
+	}
+
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	getenv := func(name string) string {
+		return runner.Vars[name].String()
+	}
+	command, err := openPlatform().Command(opener.Parse(target, environment.GetPwd(runner), exists), getenv)
+	if err != nil {
+		return failedToolResponse(err.Error())
+	}
+
+	printToolMessage(fmt.Sprintf("%s: I'd like to open:", environment.GetAgentName(runner)))
+	printToolPath(target)
+	confirmResponse := userConfirmation(logger, runner, "Open it?", "", false)
+	if confirmResponse == "n" {
+		return failedToolResponse("User declined this request")
+	} else if confirmResponse != "y" {
+		return failedToolResponse(fmt.Sprintf("User declined this request: %s", confirmResponse))
+	}
+
+	if err := runOpenCommand(command); err != nil {
+		logger.Error("open tool failed", zap.Strings("command", command), zap.Error(err))
+		return failedToolResponse(fmt.Sprintf("Error running %s: %s", strings.Join(command, " "), err))
+	}
+	return fmt.Sprintf("Opened %s with %s", target, command[0])
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/opener"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestOpenTool(t *testing.T) {
+	runner, _ := interp.New()
+	logger := zap.NewNop()
+
+	origPlatform, origRun, origConfirmation := openPlatform, runOpenCommand, userConfirmation
+	defer func() { openPlatform, runOpenCommand, userConfirmation = origPlatform, origRun, origConfirmation }()
+	openPlatform = func() opener.Platform {
+		return opener.Platform{GOOS: "darwin", LookPath: func(string) (string, error) { return "", errors.New("unused") }}
+	}
+	var ran [][]string
+	runOpenCommand = func(command []string) error {
+		ran = append(ran, command)
+		return nil
+	}
+
+	userConfirmation = func(*zap.Logger, *interp.Runner, string, string, bool) string { return "y" }
+	result := OpenTool(runner, logger, map[string]any{"target": "https://example.com/report"})
+	assert.Equal(t, "Opened https://example.com/report with open", result)
+	assert.Equal(t, [][]string{{"open", "https://example.com/report"}}, ran)
+
+	userConfirmation = func(*zap.Logger, *interp.Runner, string, string, bool) string { return "n" }
+	result = OpenTool(runner, logger, map[string]any{"target": "/tmp/report.html"})
+	assert.Contains(t, result, "User declined this request")
+	assert.Len(t, ran, 1)
+
+	result = OpenTool(runner, logger, map[string]any{})
+	assert.Contains(t, result, "failed to parse parameter 'target'")
+}
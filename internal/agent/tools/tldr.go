@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/tldr"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+var TldrToolDefinition = openai.Tool{
+	Type: "function",
+	Function: &openai.FunctionDefinition{
+		Name:        "tldr",
+		Description: "Look up the tldr page for a command: a short, curated list of common usage examples. Works offline. Use it to check command syntax and options before suggesting or running a command.",
+		Parameters: utils.GenerateJsonSchema(struct {
+			Command string `json:"command" description:"Command name, optionally followed by a subcommand, e.g. 'tar' or 'git commit'" required:"true"`
+		}{}),
+	},
+}
+
+// tldrCacheDir is where downloaded tldr pages are read from. Tests override it.
+var tldrCacheDir = tldr.DefaultCacheDir()
+
+func TldrTool(runner *interp.Runner, logger *zap.Logger, params map[string]any) string {
+	command, ok := params["command"].(string)
+	if !ok || strings.TrimSpace(command) == "" {
+		logger.Error("The tldr tool failed to parse parameter 'command'")
+		return failedToolResponse("The tldr tool failed to parse parameter 'command'")
+	}
+
+	printToolMessage(fmt.Sprintf("%s: I'm looking up examples for:", environment.GetAgentName(runner)))
+	printToolPath(command)
+
+	name, page, ok := tldr.NewStore(tldrCacheDir).LookupCommand(strings.Fields(command))
+	if !ok {
+		return fmt.Sprintf("No tldr page found for %q.", command)
+	}
+	return tldr.FormatForPrompt(name, page)
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestTldrTool(t *testing.T) {
+	tldrCacheDir = t.TempDir()
+	runner, _ := interp.New()
+	logger := zap.NewNop()
+
+	result := TldrTool(runner, logger, map[string]any{"command": "git commit"})
+	assert.Contains(t, result, `<tldr_page command="git-commit">`)
+	assert.Contains(t, result, "git commit --amend")
+
+	result = TldrTool(runner, logger, map[string]any{"command": "no-such-command"})
+	assert.Equal(t, `No tldr page found for "no-such-command".`, result)
+
+	result = TldrTool(runner, logger, map[string]any{})
+	assert.Contains(t, result, "failed to parse parameter 'command'")
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+var WebSearchToolDefinition = openai.Tool{
+	Type: "function",
+	Function: &openai.FunctionDefinition{
+		Name:        "web_search",
+		Description: "Search the web and return a numbered list of results with titles, URLs and snippets. Use this for questions about recent tool versions, release notes or unfamiliar error messages. Cite the results you rely on as [n] in your response.",
+		Parameters: utils.GenerateJsonSchema(struct {
+			Query string `json:"query" description:"The search query" required:"true"`
+			Count int    `json:"count" description:"Optional. Number of results to return, between 1 and 10. Default is 5." required:"false"`
+		}{}),
+	},
+}
+
+const (
+	defaultWebSearchCount = 5
+	maxWebSearchCount     = 10
+	maxWebSearchSnippet   = 300
+
+	defaultBraveSearchURL  = "https://api.search.brave.com/res/v1/web/search"
+	defaultSerperSearchURL = "https://google.serper.dev/search"
+)
+
+var webSearchHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+type webSearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// WebSearchEnabled reports whether the web_search tool should be offered to the
+// model, i.e. a backend is configured and network tools are not disabled.
+func WebSearchEnabled(runner *interp.Runner, logger *zap.Logger) bool {
+	return environment.GetWebSearchBackend(runner, logger) != ""
+}
+
+func WebSearchTool(runner *interp.Runner, logger *zap.Logger, params map[string]any) string {
+	backend := environment.GetWebSearchBackend(runner, logger)
+	if backend == "" {
+		return failedToolResponse("Web search is disabled. Set BISH_WEB_SEARCH_BACKEND and BISH_AGENT_NETWORK_TOOLS to enable it.")
+	}
+
+	query, ok := params["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		logger.Error("The web_search tool failed to parse parameter 'query'")
+		return failedToolResponse("The web_search tool failed to parse parameter 'query'")
+	}
+
+	count := defaultWebSearchCount
+	if countVal, exists := params["count"]; exists {
+		if countFloat, ok := countVal.(float64); ok {
+			count = int(countFloat)
+		}
+	}
+	count = max(1, min(count, maxWebSearchCount))
+
+	printToolMessage(fmt.Sprintf("%s: I'm searching the web for:", environment.GetAgentName(runner)))
+	printToolPath(query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), webSearchHTTPClient.Timeout)
+	defer cancel()
+
+	baseURL := environment.GetWebSearchURL(runner)
+	apiKey := environment.GetWebSearchAPIKey(runner)
+
+	var results []webSearchResult
+	var err error
+	switch backend {
+	case environment.WebSearchBackendSearxng:
+		results, err = searchSearxng(ctx, baseURL, query)
+	case environment.WebSearchBackendBrave:
+		results, err = searchBrave(ctx, baseURL, apiKey, query, count)
+	case environment.WebSearchBackendSerper:
+		results, err = searchSerper(ctx, baseURL, apiKey, query, count)
+	}
+	if err != nil {
+		logger.Error("web_search tool failed", zap.String("backend", backend), zap.Error(err))
+		return failedToolResponse(fmt.Sprintf("Web search failed: %s", err))
+	}
+
+	if len(results) > count {
+		results = results[:count]
+	}
+	if len(results) == 0 {
+		return "No results found."
+	}
+
+	for i, result := range results {
+		printToolPath(fmt.Sprintf("[%d] %s - %s", i+1, result.Title, result.URL))
+	}
+
+	return formatWebSearchResults(results)
+}
+
+// formatWebSearchResults numbers the results so the model can cite them as [n].
+func formatWebSearchResults(results []webSearchResult) string {
+	var sb strings.Builder
+	for i, result := range results {
+		sb.WriteString(fmt.Sprintf("[%d] %s\n%s\n", i+1, result.Title, result.URL))
+		if result.Snippet != "" {
+			sb.WriteString(result.Snippet + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// cleanSnippet strips markup from a search snippet and limits its length.
+func cleanSnippet(s string) string {
+	s = html.UnescapeString(htmlTagPattern.ReplaceAllString(s, ""))
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxWebSearchSnippet {
+		s = string(runes[:maxWebSearchSnippet]) + "..."
+	}
+	return s
+}
+
+func searchSearxng(ctx context.Context, baseURL, query string) ([]webSearchResult, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("BISH_WEB_SEARCH_URL must point to a SearXNG instance")
+	}
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/search?" + url.Values{
+		"q":      {query},
+		"format": {"json"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doWebSearchRequest(req, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]webSearchResult, 0, len(response.Results))
+	for _, r := range response.Results {
+		results = append(results, webSearchResult{Title: r.Title, URL: r.URL, Snippet: cleanSnippet(r.Content)})
+	}
+	return results, nil
+}
+
+func searchBrave(ctx context.Context, baseURL, apiKey, query string, count int) ([]webSearchResult, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("BISH_WEB_SEARCH_API_KEY is required for the brave backend")
+	}
+	if baseURL == "" {
+		baseURL = defaultBraveSearchURL
+	}
+	endpoint := baseURL + "?" + url.Values{
+		"q":     {query},
+		"count": {fmt.Sprintf("%d", count)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", apiKey)
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := doWebSearchRequest(req, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]webSearchResult, 0, len(response.Web.Results))
+	for _, r := range response.Web.Results {
+		results = append(results, webSearchResult{Title: cleanSnippet(r.Title), URL: r.URL, Snippet: cleanSnippet(r.Description)})
+	}
+	return results, nil
+}
+
+func searchSerper(ctx context.Context, baseURL, apiKey, query string, count int) ([]webSearchResult, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("BISH_WEB_SEARCH_API_KEY is required for the serper backend")
+	}
+	if baseURL == "" {
+		baseURL = defaultSerperSearchURL
+	}
+	body, err := json.Marshal(map[string]any{"q": query, "num": count})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", apiKey)
+
+	var response struct {
+		Organic []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"organic"`
+	}
+	if err := doWebSearchRequest(req, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]webSearchResult, 0, len(response.Organic))
+	for _, r := range response.Organic {
+		results = append(results, webSearchResult{Title: r.Title, URL: r.Link, Snippet: cleanSnippet(r.Snippet)})
+	}
+	return results, nil
+}
+
+func doWebSearchRequest(req *http.Request, out any) error {
+	resp, err := webSearchHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("search backend returned %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode search response: %w", err)
+	}
+	return nil
+}
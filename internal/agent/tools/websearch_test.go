@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func newWebSearchRunner(t *testing.T, vars map[string]string) *interp.Runner {
+	runner, err := interp.New()
+	assert.NoError(t, err)
+	runner.Vars = make(map[string]expand.Variable)
+	for k, v := range vars {
+		runner.Vars[k] = expand.Variable{Kind: expand.String, Str: v}
+	}
+	return runner
+}
+
+func TestWebSearchToolDefinition(t *testing.T) {
+	assert.Equal(t, openai.ToolType("function"), WebSearchToolDefinition.Type)
+	assert.Equal(t, "web_search", WebSearchToolDefinition.Function.Name)
+	parameters, ok := WebSearchToolDefinition.Function.Parameters.(*jsonschema.Definition)
+	assert.True(t, ok, "Parameters should be of type *jsonschema.Definition")
+	assert.Equal(t, jsonschema.DataType("string"), parameters.Properties["query"].Type)
+	assert.Equal(t, jsonschema.DataType("integer"), parameters.Properties["count"].Type)
+	assert.Equal(t, []string{"query"}, parameters.Required)
+}
+
+func TestWebSearchEnabled(t *testing.T) {
+	logger := zap.NewNop()
+
+	assert.False(t, WebSearchEnabled(newWebSearchRunner(t, nil), logger))
+	assert.True(t, WebSearchEnabled(newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND": "searxng",
+	}), logger))
+	assert.False(t, WebSearchEnabled(newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND":  "searxng",
+		"BISH_AGENT_NETWORK_TOOLS": "false",
+	}), logger))
+	assert.False(t, WebSearchEnabled(newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND": "altavista",
+	}), logger))
+}
+
+func TestWebSearchToolDisabled(t *testing.T) {
+	runner := newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND":  "brave",
+		"BISH_AGENT_NETWORK_TOOLS": "0",
+	})
+	result := WebSearchTool(runner, zap.NewNop(), map[string]any{"query": "go 1.24 release notes"})
+	assert.Contains(t, result, "<bish_tool_call_error>Web search is disabled")
+}
+
+func TestWebSearchToolSearxng(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "bubbletea latest version", r.URL.Query().Get("q"))
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]string{
+				{"title": "Releases", "url": "https://example.com/releases", "content": "v1.2.4 &amp; <b>more</b>"},
+				{"title": "Docs", "url": "https://example.com/docs", "content": ""},
+				{"title": "Extra", "url": "https://example.com/extra", "content": "ignored"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	runner := newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND": "searxng",
+		"BISH_WEB_SEARCH_URL":     server.URL + "/",
+	})
+	result := WebSearchTool(runner, zap.NewNop(), map[string]any{"query": "bubbletea latest version", "count": 2.0})
+	assert.Equal(t, "[1] Releases\nhttps://example.com/releases\nv1.2.4 & more\n\n[2] Docs\nhttps://example.com/docs", result)
+}
+
+func TestWebSearchToolBrave(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Subscription-Token"))
+		assert.Equal(t, "3", r.URL.Query().Get("count"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"web": map[string]any{
+				"results": []map[string]string{
+					{"title": "Error <strong>EADDRINUSE</strong>", "url": "https://example.com/a", "description": "Port in use"},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	runner := newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND": "brave",
+		"BISH_WEB_SEARCH_URL":     server.URL,
+		"BISH_WEB_SEARCH_API_KEY": "secret",
+	})
+	result := WebSearchTool(runner, zap.NewNop(), map[string]any{"query": "EADDRINUSE", "count": 3.0})
+	assert.Equal(t, "[1] Error EADDRINUSE\nhttps://example.com/a\nPort in use", result)
+}
+
+func TestWebSearchToolSerper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-API-KEY"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "kubectl 1.31", body["q"])
+		assert.Equal(t, 5.0, body["num"])
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"organic": []map[string]string{
+				{"title": "Kubernetes v1.31", "link": "https://example.com/k8s", "snippet": "Released"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	runner := newWebSearchRunner(t, map[string]string{
+		"BISH_WEB_SEARCH_BACKEND": "serper",
+		"BISH_WEB_SEARCH_URL":     server.URL,
+		"BISH_WEB_SEARCH_API_KEY": "secret",
+	})
+	result := WebSearchTool(runner, zap.NewNop(), map[string]any{"query": "kubectl 1.31"})
+	assert.Equal(t, "[1] Kubernetes v1.31\nhttps://example.com/k8s\nReleased", result)
+}
+
+func TestWebSearchToolErrors(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("Missing query", func(t *testing.T) {
+		runner := newWebSearchRunner(t, map[string]string{"BISH_WEB_SEARCH_BACKEND": "searxng"})
+		result := WebSearchTool(runner, logger, map[string]any{})
+		assert.Contains(t, result, "failed to parse parameter 'query'")
+	})
+
+	t.Run("Missing API key", func(t *testing.T) {
+		runner := newWebSearchRunner(t, map[string]string{"BISH_WEB_SEARCH_BACKEND": "serper"})
+		result := WebSearchTool(runner, logger, map[string]any{"query": "x"})
+		assert.Contains(t, result, "BISH_WEB_SEARCH_API_KEY is required")
+	})
+
+	t.Run("Backend error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		runner := newWebSearchRunner(t, map[string]string{
+			"BISH_WEB_SEARCH_BACKEND": "searxng",
+			"BISH_WEB_SEARCH_URL":     server.URL,
+		})
+		result := WebSearchTool(runner, logger, map[string]any{"query": "x"})
+		assert.Contains(t, result, "Web search failed: search backend returned 429")
+		assert.Contains(t, result, "rate limited")
+	})
+
+	t.Run("No results", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"results": []}`))
+		}))
+		defer server.Close()
+
+		runner := newWebSearchRunner(t, map[string]string{
+			"BISH_WEB_SEARCH_BACKEND": "searxng",
+			"BISH_WEB_SEARCH_URL":     server.URL,
+		})
+		assert.Equal(t, "No results found.", WebSearchTool(runner, logger, map[string]any{"query": "x"}))
+	})
+}
@@ -0,0 +1,64 @@
+// Package arghistory recalls the arguments previously passed to a command,
+// such as the pod names given to "kubectl logs", from the user's history.
+package arghistory
+
+import (
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// SampleSize is how many recent history entries are considered.
+const SampleSize = 2000
+
+// Prefix returns the command being typed in line, normalized to end with a
+// single space so that "kubectl logs" does not match "kubectl logsfoo". It
+// returns "" if nothing has been typed.
+func Prefix(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return ""
+	}
+	return trimmed + " "
+}
+
+// Complete returns the lines formed by prefix and each distinct set of
+// arguments that followed it in commands, which are ordered most recent
+// first. Multi-line commands are skipped.
+func Complete(prefix string, commands []string) []string {
+	if prefix == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	var lines []string
+	for _, command := range commands {
+		if !strings.HasPrefix(command, prefix) || strings.Contains(command, "\n") {
+			continue
+		}
+		args := strings.TrimSpace(command[len(prefix):])
+		if args == "" || seen[args] {
+			continue
+		}
+		seen[args] = true
+		lines = append(lines, prefix+args)
+	}
+	return lines
+}
+
+// Lookup returns line completed with the arguments previously passed to the
+// command it starts with, most recent first.
+func Lookup(historyManager *history.HistoryManager, line string) []string {
+	prefix := Prefix(line)
+	if historyManager == nil || prefix == "" {
+		return nil
+	}
+	entries, err := historyManager.GetRecentEntriesByPrefix(prefix, SampleSize)
+	if err != nil {
+		return nil
+	}
+	commands := make([]string, len(entries))
+	for i, entry := range entries {
+		commands[i] = entry.Command
+	}
+	return Complete(prefix, commands)
+}
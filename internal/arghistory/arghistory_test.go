@@ -0,0 +1,45 @@
+package arghistory
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "kubectl logs ", Prefix("kubectl logs"))
+	assert.Equal(t, "kubectl logs ", Prefix("  kubectl logs   "))
+	assert.Equal(t, "", Prefix("   "))
+}
+
+func TestComplete(t *testing.T) {
+	commands := []string{
+		"kubectl logs -f web-1",
+		"kubectl get pods",
+		"kubectl logs worker",
+		"kubectl logs -f web-1",
+		"kubectl logsfoo",
+		"kubectl logs ",
+		"kubectl logs db\nkubectl logs cache",
+	}
+	assert.Equal(t, []string{"kubectl logs -f web-1", "kubectl logs worker"}, Complete("kubectl logs ", commands))
+	assert.Nil(t, Complete("kubectl describe ", commands))
+	assert.Nil(t, Complete("", commands))
+}
+
+func TestLookup(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+
+	for _, command := range []string{"kubectl logs web", "kubectl get pods", "kubectl logs worker"} {
+		_, err := historyManager.StartCommand(command, "/tmp", "session")
+		require.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, []string{"kubectl logs web", "kubectl logs worker"}, Lookup(historyManager, "kubectl logs"))
+	assert.Nil(t, Lookup(historyManager, "git push"))
+	assert.Nil(t, Lookup(historyManager, ""))
+	assert.Nil(t, Lookup(nil, "kubectl logs"))
+}
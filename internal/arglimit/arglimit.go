@@ -0,0 +1,158 @@
+// Package arglimit guards against commands whose arguments exceed what the
+// system lets exec pass, which fail with "Argument list too long" once a
+// glob or command substitution expands to too many files.
+package arglimit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// nearShare is how much of the limit a command takes before it is warned
+// about.
+const nearShare = 0.9
+
+// Limits are what exec allows for a command.
+type Limits struct {
+	// Total bounds the arguments and environment together, as ARG_MAX.
+	Total int
+	// PerArg bounds each argument, or is 0 if only Total does.
+	PerArg int
+}
+
+// Usage is what the arguments of a command take.
+type Usage struct {
+	// Size is the bytes of the arguments and environment, as Size counts.
+	Size int
+	// Longest is the bytes of the longest argument.
+	Longest int
+}
+
+// Size returns the bytes exec takes for args and env: each string, the NUL
+// ending it and the pointer to it.
+func Size(args, env []string) Usage {
+	var usage Usage
+	for _, arg := range args {
+		usage.Size += len(arg) + 1 + pointerSize
+		usage.Longest = max(usage.Longest, len(arg))
+	}
+	for _, value := range env {
+		usage.Size += len(value) + 1 + pointerSize
+	}
+	return usage
+}
+
+// Over reports whether exec would refuse usage.
+func (l Limits) Over(usage Usage) bool {
+	return usage.Size > l.Total || l.PerArg > 0 && usage.Longest >= l.PerArg
+}
+
+// Near reports whether usage is close to the limit, but within it.
+func (l Limits) Near(usage Usage) bool {
+	return !l.Over(usage) && float64(usage.Size) >= nearShare*float64(l.Total)
+}
+
+// Guard remembers the last command it refused to run, so that the shell can
+// offer a rewrite of the line it was on.
+type Guard struct {
+	limits Limits
+
+	mu      sync.Mutex
+	refused string
+}
+
+// DefaultGuard is the guard of the interactive shell.
+var DefaultGuard = NewGuard(SystemLimits())
+
+func NewGuard(limits Limits) *Guard {
+	return &Guard{limits: limits}
+}
+
+// Refused returns the name of the last command the guard refused to run,
+// and forgets it.
+func (g *Guard) Refused() (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	name := g.refused
+	g.refused = ""
+	return name, name != ""
+}
+
+func (g *Guard) refuse(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refused = name
+}
+
+// NewArgLimitHandler creates an ExecHandler that refuses to run an external
+// command whose arguments exceed the limits of exec, before it fails with
+// "Argument list too long", and warns about one whose arguments come close
+// to them. It must come after the handlers that rewrite commands and before
+// the one that runs them.
+func NewArgLimitHandler(guard *Guard) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			// Builtins and commands run in the shell take any arguments
+			if len(args) < 2 {
+				return next(ctx, args)
+			}
+			hc := interp.HandlerCtx(ctx)
+			if _, err := interp.LookPathDir(hc.Dir, hc.Env, args[0]); err != nil {
+				return next(ctx, args)
+			}
+
+			usage := Size(args, exported(hc.Env))
+			name := filepath.Base(args[0])
+			switch {
+			case guard.limits.Over(usage):
+				guard.refuse(name)
+				if guard.limits.PerArg > 0 && usage.Longest >= guard.limits.PerArg {
+					fmt.Fprintf(hc.Stderr, "bish: not running %s: an argument of %s is longer than the %s the system allows for one\n",
+						name, formatSize(usage.Longest), formatSize(guard.limits.PerArg))
+				} else {
+					fmt.Fprintf(hc.Stderr, "bish: not running %s: its %d arguments take %s, more than the %s the system allows, so it would fail with \"Argument list too long\"\n",
+						name, len(args)-1, formatSize(usage.Size), formatSize(guard.limits.Total))
+				}
+				// The status of a command that could not be executed
+				return interp.NewExitStatus(126)
+			case guard.limits.Near(usage):
+				fmt.Fprintf(hc.Stderr, "bish: the %d arguments of %s take %s of the %s the system allows; a few more would fail with \"Argument list too long\"\n",
+					len(args)-1, name, formatSize(usage.Size), formatSize(guard.limits.Total))
+			}
+			return next(ctx, args)
+		}
+	}
+}
+
+// exported returns the variables of env that commands get, as name=value,
+// if they count toward the limit.
+func exported(env expand.Environ) []string {
+	if !countsEnv {
+		return nil
+	}
+	var vars []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		if vr.Exported && vr.IsSet() {
+			vars = append(vars, name+"="+vr.String())
+		}
+		return true
+	})
+	return vars
+}
+
+// formatSize formats bytes in bytes, KiB or MiB.
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%d KiB", bytes>>10)
+	default:
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+}
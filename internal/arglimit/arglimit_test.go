@@ -0,0 +1,98 @@
+package arglimit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{Total: 1000, PerArg: 100}
+	assert.False(t, limits.Over(Usage{Size: 800, Longest: 10}))
+	assert.False(t, limits.Near(Usage{Size: 800, Longest: 10}))
+	assert.True(t, limits.Near(Usage{Size: 950, Longest: 10}))
+	assert.True(t, limits.Over(Usage{Size: 1001, Longest: 10}))
+	assert.True(t, limits.Over(Usage{Size: 500, Longest: 100}))
+	assert.False(t, limits.Near(Usage{Size: 1001}))
+
+	usage := Size([]string{"rm", "a.log"}, []string{"HOME=/root"})
+	assert.Equal(t, 3+6+11+3*pointerSize, usage.Size)
+	assert.Equal(t, 5, usage.Longest)
+}
+
+// toolDir returns a PATH with a script named tool, which prints how many
+// arguments it got.
+func toolDir(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool"), []byte("#!/bin/sh\necho ran $#\n"), 0o755))
+	return dir
+}
+
+func runGuarded(t *testing.T, guard *Guard, dir, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("PATH="+dir)),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewArgLimitHandler(guard)),
+	)
+	require.NoError(t, err)
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestArgLimitHandler(t *testing.T) {
+	dir := toolDir(t)
+	guard := NewGuard(Limits{Total: 1000})
+
+	stdout, stderr, err := runGuarded(t, guard, dir, "tool a b c")
+	require.NoError(t, err)
+	assert.Equal(t, "ran 3\n", stdout)
+	assert.Empty(t, stderr)
+	_, refused := guard.Refused()
+	assert.False(t, refused)
+
+	// Too many arguments are not passed to exec
+	many := `set --; while [ $# -lt 200 ]; do set -- "$@" x; done; `
+	stdout, stderr, err = runGuarded(t, guard, dir, many+`tool "$@"`)
+	assert.Equal(t, interp.NewExitStatus(126), err)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "bish: not running tool: its 200 arguments take")
+	name, refused := guard.Refused()
+	assert.True(t, refused)
+	assert.Equal(t, "tool", name)
+	_, refused = guard.Refused()
+	assert.False(t, refused)
+
+	// Builtins take any arguments
+	stdout, _, err = runGuarded(t, guard, dir, many+`echo "$@" >/dev/null; echo ok`)
+	require.NoError(t, err)
+	assert.Equal(t, "ok\n", stdout)
+
+	// Close to the limit, the command runs with a warning
+	arg := strings.Repeat("x", 800)
+	size := Size([]string{"tool", arg}, []string{"PATH=" + dir}).Size
+	guard = NewGuard(Limits{Total: size + 10})
+	stdout, stderr, err = runGuarded(t, guard, dir, "tool "+arg)
+	require.NoError(t, err)
+	assert.Equal(t, "ran 1\n", stdout)
+	assert.Contains(t, stderr, "a few more would fail")
+
+	// An argument too long for exec is refused whatever the total
+	guard = NewGuard(Limits{Total: 1 << 20, PerArg: 500})
+	_, stderr, err = runGuarded(t, guard, dir, "tool "+arg)
+	assert.Equal(t, interp.NewExitStatus(126), err)
+	assert.Contains(t, stderr, "an argument of 800 bytes is longer than the 500 bytes the system allows for one")
+}
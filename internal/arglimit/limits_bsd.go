@@ -0,0 +1,18 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package arglimit
+
+import "golang.org/x/sys/unix"
+
+const (
+	pointerSize = 8
+	countsEnv   = true
+)
+
+// SystemLimits returns the limit of execve, the kern.argmax sysctl.
+func SystemLimits() Limits {
+	if argmax, err := unix.SysctlUint32("kern.argmax"); err == nil && argmax > 0 {
+		return Limits{Total: int(argmax)}
+	}
+	return Limits{Total: 256 << 10}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package arglimit
+
+import "golang.org/x/sys/unix"
+
+const (
+	pointerSize = 8
+	countsEnv   = true
+)
+
+// SystemLimits returns the limits of execve: a quarter of the stack size,
+// at least 128 KiB and at most 6 MiB, and 128 KiB for each argument.
+func SystemLimits() Limits {
+	limits := Limits{Total: 6 << 20, PerArg: 128 << 10}
+	var stack unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_STACK, &stack); err == nil && stack.Cur != unix.RLIM_INFINITY {
+		limits.Total = int(min(max(stack.Cur/4, 128<<10), 6<<20))
+	}
+	return limits
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package arglimit
+
+const (
+	pointerSize = 8
+	countsEnv   = true
+)
+
+// SystemLimits returns 256 KiB, less than the ARG_MAX of most systems.
+func SystemLimits() Limits {
+	return Limits{Total: 256 << 10}
+}
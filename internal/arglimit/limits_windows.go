@@ -0,0 +1,15 @@
+//go:build windows
+
+package arglimit
+
+const (
+	// A command line is one string, with a space between arguments
+	pointerSize = 0
+	countsEnv   = false
+)
+
+// SystemLimits returns the limit of CreateProcess, 32767 characters of
+// command line; the environment is apart.
+func SystemLimits() Limits {
+	return Limits{Total: 32767}
+}
@@ -0,0 +1,96 @@
+package arglimit
+
+import (
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Rewrite returns line with the command name that had too many arguments
+// rewritten to read them from a pipe, so that no exec gets them all:
+//
+//	rm *.log             → printf '%s\0' *.log | xargs -0 rm
+//	cp *.jpg backup/     → printf '%s\n' *.jpg | while IFS= read -r f; do cp "$f" backup/; done
+//
+// The arguments piped are those from the first to the last word that
+// expands to many, such as a glob or a command substitution. xargs runs the
+// command in batches when the arguments are last; otherwise it runs once
+// for each.
+func Rewrite(line, name string) (string, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return "", false
+	}
+
+	var call *syntax.CallExpr
+	first, last := -1, -1
+	syntax.Walk(file, func(node syntax.Node) bool {
+		c, ok := node.(*syntax.CallExpr)
+		if !ok || call != nil {
+			return call == nil
+		}
+		if len(c.Args) < 2 || filepath.Base(c.Args[0].Lit()) != name {
+			return true
+		}
+		for i, word := range c.Args[1:] {
+			if expandsToMany(word) {
+				if first < 0 {
+					first = i + 1
+				}
+				last = i + 1
+			}
+		}
+		if first >= 0 {
+			call = c
+		}
+		return call == nil
+	})
+	if call == nil {
+		return "", false
+	}
+
+	start, end := int(call.Pos().Offset()), int(call.End().Offset())
+	listStart, listEnd := int(call.Args[first].Pos().Offset()), int(call.Args[last].End().Offset())
+	nameStart := int(call.Args[0].Pos().Offset())
+	list := line[listStart:listEnd]
+	suffix := strings.TrimSpace(line[listEnd:end])
+
+	var rewritten string
+	if suffix == "" {
+		rewritten = "printf '%s\\0' " + list + " | " + line[start:nameStart] + "xargs -0 " +
+			strings.TrimSpace(line[nameStart:listStart])
+	} else {
+		rewritten = "printf '%s\\n' " + list + " | while IFS= read -r f; do " +
+			line[start:listStart] + `"$f" ` + suffix + "; done"
+	}
+	return line[:start] + rewritten + line[end:], true
+}
+
+// expandsToMany reports whether word may expand to many arguments: an
+// unquoted glob, brace expansion, command substitution or parameter, or
+// "$@" and "${array[@]}".
+func expandsToMany(word *syntax.Word) bool {
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			if strings.ContainsAny(part.Value, "*?[") ||
+				strings.Contains(part.Value, "{") && (strings.Contains(part.Value, ",") || strings.Contains(part.Value, "..")) {
+				return true
+			}
+		case *syntax.CmdSubst, *syntax.ParamExp:
+			return true
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				param, ok := inner.(*syntax.ParamExp)
+				if !ok {
+					continue
+				}
+				if index, ok := param.Index.(*syntax.Word); param.Param.Value == "@" || ok && index.Lit() == "@" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package arglimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		line, name string
+		want       string
+	}{
+		{"rm *.log", "rm", `printf '%s\0' *.log | xargs -0 rm`},
+		{"rm -f -- build/*.o build/*.a", "rm", `printf '%s\0' build/*.o build/*.a | xargs -0 rm -f --`},
+		{"cp *.jpg backup/", "cp", `printf '%s\n' *.jpg | while IFS= read -r f; do cp "$f" backup/; done`},
+		{"cd out && LC_ALL=C grep -l TODO $(find . -name '*.go') | wc -l", "grep",
+			`cd out && printf '%s\0' $(find . -name '*.go') | LC_ALL=C xargs -0 grep -l TODO | wc -l`},
+		{`/bin/ls -d "${dirs[@]}"`, "ls", `printf '%s\0' "${dirs[@]}" | xargs -0 /bin/ls -d`},
+		{"touch file{1..100000}.txt", "touch", `printf '%s\0' file{1..100000}.txt | xargs -0 touch`},
+	}
+	for _, tt := range tests {
+		got, ok := Rewrite(tt.line, tt.name)
+		assert.True(t, ok, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+
+	// Nothing that expands, or another command
+	for _, line := range []string{"rm a b c", "ls *.log", "rm 'a*'", "rm ("} {
+		_, ok := Rewrite(line, "rm")
+		assert.False(t, ok, line)
+	}
+}
@@ -2,6 +2,7 @@ package bash
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -130,8 +131,6 @@ func preprocessWithParsing(input string) string {
 
 	// Helper function to transform command starting at position
 	transformCommandAt := func(pos int) (string, int) {
-		// Log for debugging (can be disabled in production)
-		fmt.Printf("Transforming at pos %d: %.30s\n", pos, input[pos:])
 		// Find the command name and flag
 		start := pos
 		for start < len(input) && isWhitespace(input[start]) {
@@ -389,6 +388,22 @@ func isWhitespace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
+// ErrUnsupported is returned by Run for features the interpreter does not
+// support.
+var ErrUnsupported = errors.New("not supported")
+
+// Run runs node with runner. The interpreter panics on some features it does
+// not support, like redirecting file descriptors above 2; Run returns those
+// as errors instead of crashing the shell.
+func Run(ctx context.Context, runner *interp.Runner, node syntax.Node) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrUnsupported, r)
+		}
+	}()
+	return runner.Run(ctx, node)
+}
+
 func RunBashScriptFromReader(ctx context.Context, runner *interp.Runner, reader io.Reader, name string) error {
 	// Read all input first
 	content, err := io.ReadAll(reader)
@@ -403,7 +418,8 @@ func RunBashScriptFromReader(ctx context.Context, runner *interp.Runner, reader
 	if err != nil {
 		return err
 	}
-	return runner.Run(ctx, prog)
+	Rewrite(prog)
+	return Run(ctx, runner, prog)
 }
 
 func RunBashScriptFromFile(ctx context.Context, runner *interp.Runner, filePath string) error {
@@ -434,8 +450,9 @@ func RunBashCommandInSubShell(ctx context.Context, runner *interp.Runner, comman
 	if err != nil {
 		return "", "", err
 	}
+	Rewrite(prog)
 
-	err = subShell.Run(ctx, prog)
+	err = Run(ctx, subShell, prog)
 	if err != nil {
 		return "", "", err
 	}
@@ -461,8 +478,9 @@ func RunBashCommand(ctx context.Context, runner *interp.Runner, command string)
 	if err != nil {
 		return "", "", err
 	}
+	Rewrite(prog)
 
-	err = runner.Run(ctx, prog)
+	err = Run(ctx, runner, prog)
 	if err != nil {
 		return "", "", err
 	}
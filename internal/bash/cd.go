@@ -43,7 +43,13 @@ func NewCdCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerF
 
 			// Handle bish_cd_hook - called after builtin cd to sync external state
 			if commandName == "bish_cd_hook" {
-				return handleCdHook(args)
+				if err := handleCdHook(args); err != nil {
+					return err
+				}
+				// Show what is in the new directory if BISH_CD_LISTING is on
+				hc := interp.HandlerCtx(ctx)
+				printDirListing(hc.Stdout, args[1], hc.Env)
+				return nil
 			}
 
 			// Handle 'cd' and 'bish_cd' commands on all platforms
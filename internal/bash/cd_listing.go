@@ -0,0 +1,209 @@
+package bash
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/git"
+	"golang.org/x/term"
+	"mvdan.cc/sh/v3/expand"
+)
+
+const (
+	// maxListingScan caps how many entries are read from a directory, so
+	// that cd into a huge one stays quick
+	maxListingScan = 1000
+	// maxListingNames is how many names the listing shows at most
+	maxListingNames     = 12
+	defaultListingWidth = 80
+)
+
+var (
+	listingDirStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+	listingBranchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	listingDimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+)
+
+// readmeNames are the files whose first line the listing shows, in order of
+// preference.
+var readmeNames = []string{"README.md", "README", "README.txt", "README.rst", "readme.md"}
+
+// cdListingEnabled reports whether BISH_CD_LISTING asks for a listing after
+// each cd.
+func cdListingEnabled(env expand.Environ) bool {
+	value := strings.ToLower(env.Get("BISH_CD_LISTING").String())
+	return value == "1" || value == "true"
+}
+
+// listingTerminal returns the terminal w writes to; the listing is only for
+// people, not for scripts whose output is captured.
+func listingTerminal(w io.Writer) (*os.File, bool) {
+	f, ok := w.(*os.File)
+	return f, ok && term.IsTerminal(int(f.Fd()))
+}
+
+// printDirListing prints the listing of dir after cd when BISH_CD_LISTING is
+// enabled and out is a terminal.
+func printDirListing(out io.Writer, dir string, env expand.Environ) {
+	if !cdListingEnabled(env) {
+		return
+	}
+	f, ok := listingTerminal(out)
+	if !ok {
+		return
+	}
+	width := defaultListingWidth
+	if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 {
+		width = w
+	}
+	branch := ""
+	if repo := git.FindRepo(dir, func(name string) string { return env.Get(name).String() }); repo != nil {
+		branch = repo.Branch()
+	}
+	_, _ = fmt.Fprint(out, dirListing(dir, branch, width))
+}
+
+// dirListing returns a compact summary of dir to show after cd: the git
+// branch and entry counts, the first entries with directories first, and
+// the first line of its README. Lines are cut to width.
+func dirListing(dir, branch string, width int) string {
+	f, err := os.Open(dir)
+	if err != nil {
+		return ""
+	}
+	entries, err := f.ReadDir(maxListingScan + 1)
+	_ = f.Close()
+	if err != nil && err != io.EOF && len(entries) == 0 {
+		return ""
+	}
+	truncated := len(entries) > maxListingScan
+	if truncated {
+		entries = entries[:maxListingScan]
+	}
+
+	var dirs, files []string
+	hidden := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			hidden++
+			continue
+		}
+		if isDirEntry(dir, entry) {
+			dirs = append(dirs, name)
+		} else {
+			files = append(files, name)
+		}
+	}
+	readme := ""
+	for _, name := range readmeNames {
+		if slices.Contains(files, name) {
+			readme = firstLine(filepath.Join(dir, name))
+			break
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+
+	var sb strings.Builder
+	var summary []string
+	if branch != "" {
+		summary = append(summary, listingBranchStyle.Render("⎇ "+branch))
+	}
+	counts := fmt.Sprintf("%s, %s", plural(len(dirs), "dir"), plural(len(files), "file"))
+	if hidden > 0 {
+		counts += fmt.Sprintf(" (%d hidden)", hidden)
+	}
+	if truncated {
+		counts = fmt.Sprintf("over %d entries", maxListingScan)
+	}
+	summary = append(summary, listingDimStyle.Render(counts))
+	sb.WriteString(strings.Join(summary, listingDimStyle.Render(" • ")) + "\n")
+
+	if names := listingNames(dirs, files, width); names != "" {
+		sb.WriteString(names + "\n")
+	}
+	if readme != "" {
+		sb.WriteString(listingDimStyle.Render(cut(readme, width)) + "\n")
+	}
+	return sb.String()
+}
+
+// listingNames lays out the first directories and files on one line that
+// fits width, and says how many more there are.
+func listingNames(dirs, files []string, width int) string {
+	names := append(append([]string{}, dirs...), files...)
+	var shown []string
+	used := 0
+	for i, name := range names {
+		if i < len(dirs) {
+			name += "/"
+		}
+		more := ""
+		if i+1 < len(names) {
+			more = fmt.Sprintf("  … %d more", len(names)-i-1)
+		}
+		if i == maxListingNames || used+len([]rune(name))+len([]rune(more)) > width {
+			break
+		}
+		used += len([]rune(name)) + 2
+		if i < len(dirs) {
+			name = listingDirStyle.Render(name)
+		}
+		shown = append(shown, name)
+	}
+	line := strings.Join(shown, "  ")
+	if more := len(names) - len(shown); more > 0 {
+		line += listingDimStyle.Render(fmt.Sprintf("  … %d more", more))
+	}
+	return strings.TrimSpace(line)
+}
+
+// isDirEntry reports whether entry is a directory or a link to one.
+func isDirEntry(dir string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink != 0 {
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		return err == nil && info.IsDir()
+	}
+	return entry.IsDir()
+}
+
+// firstLine returns the first line of text in path, without markdown
+// heading marks.
+func firstLine(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < 20 && scanner.Scan(); i++ {
+		line := strings.TrimSpace(strings.TrimLeft(scanner.Text(), "#="))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func cut(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(0, width-1)]) + "…"
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
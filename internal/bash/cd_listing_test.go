@@ -0,0 +1,49 @@
+package bash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+)
+
+func TestDirListing(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("\n# Bishop, the generative shell\n\nMore.\n"), 0644))
+
+	assert.Equal(t, "⎇ main • 2 dirs, 2 files (1 hidden)\ndocs/  src/  README.md  go.mod\nBishop, the generative shell\n", dirListing(dir, "main", 80))
+
+	// Names that do not fit are counted
+	assert.Equal(t, "2 dirs, 2 files (1 hidden)\ndocs/  src/  … 2 more\nBishop, the generative …\n", dirListing(dir, "", 24))
+}
+
+func TestDirListingEmpty(t *testing.T) {
+	assert.Equal(t, "0 dirs, 0 files\n", dirListing(t.TempDir(), "", 80))
+	assert.Equal(t, "", dirListing(filepath.Join(t.TempDir(), "missing"), "", 80))
+}
+
+func TestDirListingCapsHugeDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i <= maxListingScan; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%04d", i)), nil, 0644))
+	}
+
+	listing := dirListing(dir, "", 80)
+	assert.Contains(t, listing, "over 1000 entries\n")
+	assert.Contains(t, listing, "more\n")
+}
+
+func TestCdListingEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"1": true, "true": true, "TRUE": true, "0": false, "": false, "no": false} {
+		env := expand.ListEnviron("BISH_CD_LISTING=" + value)
+		assert.Equal(t, want, cdListingEnabled(env), value)
+	}
+}
@@ -0,0 +1,327 @@
+package bash
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Some bash features that rc files and tool init scripts rely on are missing
+// from the interpreter, and handlers cannot set variables. Rewrite turns them
+// into calls of bish_* commands, see compat_commands.go; those that need to
+// assign print the assignments, which are then evaluated:
+//   - [[ x =~ re ]] sets BASH_REMATCH
+//   - printf -v name assigns to name instead of printing, and printf
+//     supports %q
+//   - shopt -q and shopt -p, which crash or are missing in the interpreter
+//   - type -P looks commands up in PATH
+//   - unset 'name[key]' removes an array element
+//   - base#number constants in arithmetic
+//   - source skips what the interpreter cannot run, see source.go
+//   - fg and bg, which the interpreter refuses as unimplemented, are run by
+//     the job table, see internal/jobs
+//   - pushd, popd and dirs keep their stack per session and change
+//     directory through bish_cd_hook, see dirstack.go
+//   - in DIR -- CMD runs CMD in a subshell in DIR, see pinned.go
+
+// validAssignTarget matches what printf -v accepts: a name, optionally with
+// an array index.
+var validAssignTarget = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[^]]*\])?$`)
+
+// baseConstant matches an arithmetic constant in another base, e.g. 16#ff.
+var baseConstant = regexp.MustCompile(`^([0-9]+)#([0-9A-Za-z@_]+)$`)
+
+// Rewrite adapts node to the interpreter before it runs.
+func Rewrite(node syntax.Node) {
+	if node == nil {
+		return
+	}
+	RewriteDeclarations(node)
+	rewriteBashisms(node)
+}
+
+// rewriteBashisms rewrites the commands of node that the interpreter does not
+// support, see above.
+func rewriteBashisms(node syntax.Node) {
+	syntax.Walk(node, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Stmt:
+			rewriteStmt(node)
+		case *syntax.ArithmExp:
+			rewriteBaseConstant(node.X)
+		case *syntax.ArithmCmd:
+			rewriteBaseConstant(node.X)
+		case *syntax.CStyleLoop:
+			rewriteBaseConstant(node.Init)
+			rewriteBaseConstant(node.Cond)
+			rewriteBaseConstant(node.Post)
+		case *syntax.BinaryArithm:
+			rewriteBaseConstant(node.X)
+			rewriteBaseConstant(node.Y)
+		case *syntax.UnaryArithm:
+			rewriteBaseConstant(node.X)
+		case *syntax.ParenArithm:
+			rewriteBaseConstant(node.X)
+		}
+		return true
+	})
+}
+
+func rewriteStmt(stmt *syntax.Stmt) {
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.TestClause:
+		if test, ok := cmd.X.(*syntax.BinaryTest); ok && test.Op == syntax.TsReMatch {
+			stmt.Cmd = evalOutputOf(rematchCall(test))
+		}
+	case *syntax.CallExpr:
+		switch commandName(cmd) {
+		case "source", ".":
+			rewriteSource(stmt, cmd)
+		case "printf":
+			if len(cmd.Args) >= 4 && cmd.Args[1].Lit() == "-v" {
+				stmt.Cmd = evalOutputOf(callWith("bish_printf", cmd.Args[1:]))
+			} else if len(cmd.Args) >= 2 && strings.Contains(staticText(cmd.Args[1]), "%q") {
+				stmt.Cmd = callWith("bish_printf", cmd.Args[1:])
+			}
+		case "shopt":
+			if flag := shoptQueryFlag(cmd); flag != "" {
+				stmt.Cmd = shoptQuery(cmd, flag)
+			}
+		case "type":
+			if len(cmd.Args) >= 2 && cmd.Args[1].Lit() == "-P" {
+				stmt.Cmd = callWith("bish_type", cmd.Args[1:])
+			}
+		case "unset":
+			if unsetsElements(cmd) {
+				stmt.Cmd = evalOutputOf(callWith("bish_unset", cmd.Args[1:]))
+			}
+		case "fg", "bg", "dirs":
+			stmt.Cmd = callWith("bish_"+commandName(cmd), cmd.Args[1:])
+		case "pushd", "popd", "in":
+			stmt.Cmd = evalOutputOf(callWith("bish_"+commandName(cmd), cmd.Args[1:]))
+		}
+	}
+}
+
+// rewriteBaseConstant replaces a constant like 16#ff in expr by its decimal
+// value.
+func rewriteBaseConstant(expr syntax.ArithmExpr) {
+	word, ok := expr.(*syntax.Word)
+	if !ok || len(word.Parts) != 1 {
+		return
+	}
+	lit, ok := word.Parts[0].(*syntax.Lit)
+	if !ok {
+		return
+	}
+	match := baseConstant.FindStringSubmatch(lit.Value)
+	if match == nil {
+		return
+	}
+	base, err := strconv.Atoi(match[1])
+	if err != nil || base < 2 || base > 64 {
+		return
+	}
+	var value int64
+	for _, ch := range match[2] {
+		var digit int64
+		switch {
+		case ch >= '0' && ch <= '9':
+			digit = int64(ch - '0')
+		case ch >= 'a' && ch <= 'z':
+			digit = int64(ch-'a') + 10
+		case ch >= 'A' && ch <= 'Z':
+			// Bash treats upper and lower case alike up to base 36
+			digit = int64(ch-'A') + 10
+			if base > 36 {
+				digit += 26
+			}
+		case ch == '@':
+			digit = 62
+		case ch == '_':
+			digit = 63
+		}
+		if digit >= int64(base) {
+			return
+		}
+		value = value*int64(base) + digit
+	}
+	lit.Value = strconv.FormatInt(value, 10)
+}
+
+// unsetsElements reports whether cmd unsets array elements, e.g.
+// unset 'map[key]'. Unsetting functions is left alone.
+func unsetsElements(cmd *syntax.CallExpr) bool {
+	elements := false
+	for _, arg := range cmd.Args[1:] {
+		if arg.Lit() == "-f" {
+			return false
+		}
+		if strings.Contains(staticText(arg), "[") {
+			elements = true
+		}
+	}
+	return elements
+}
+
+// staticText returns the parts of word that are known before it is
+// expanded, e.g. "a[]" for 'a['"$key"].
+func staticText(word *syntax.Word) string {
+	var text strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			text.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			text.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					text.WriteString(lit.Value)
+				}
+			}
+		}
+	}
+	return text.String()
+}
+
+// commandName returns the literal name of the command cmd runs, if any.
+func commandName(cmd *syntax.CallExpr) string {
+	if len(cmd.Args) == 0 {
+		return ""
+	}
+	return cmd.Args[0].Lit()
+}
+
+func litWord(value string) *syntax.Word {
+	return &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: value}}}
+}
+
+// callWith returns a call of name with args.
+func callWith(name string, args []*syntax.Word) *syntax.CallExpr {
+	return &syntax.CallExpr{Args: append([]*syntax.Word{litWord(name)}, args...)}
+}
+
+// evalOutputOf returns eval "$(call)".
+func evalOutputOf(call *syntax.CallExpr) *syntax.CallExpr {
+	return &syntax.CallExpr{Args: []*syntax.Word{
+		litWord("eval"),
+		{Parts: []syntax.WordPart{&syntax.DblQuoted{Parts: []syntax.WordPart{
+			&syntax.CmdSubst{Stmts: []*syntax.Stmt{{Cmd: call}}},
+		}}}},
+	}}
+}
+
+// rematchCall returns the bish_rematch call for x =~ re. The regex is passed
+// in pieces prefixed with "r:" for the parts to use as a regex and "q:" for
+// the quoted parts to match literally, like bash does.
+func rematchCall(test *syntax.BinaryTest) *syntax.CallExpr {
+	subject, _ := test.X.(*syntax.Word)
+	regex, _ := test.Y.(*syntax.Word)
+	args := []*syntax.Word{unsplitWord("", subject), litWord("--")}
+	if regex != nil {
+		for _, part := range regex.Parts {
+			switch part := part.(type) {
+			case *syntax.Lit:
+				args = append(args, &syntax.Word{Parts: []syntax.WordPart{&syntax.SglQuoted{Value: "r:" + part.Value}}})
+			case *syntax.SglQuoted, *syntax.DblQuoted:
+				args = append(args, &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: "q:"}, part}})
+			default:
+				args = append(args, unsplitWord("r:", &syntax.Word{Parts: []syntax.WordPart{part}}))
+			}
+		}
+	}
+	return callWith("bish_rematch", args)
+}
+
+// unsplitWord returns word prefixed with prefix, expanded the way words are
+// inside [[ ]]: without field splitting or globbing.
+func unsplitWord(prefix string, word *syntax.Word) *syntax.Word {
+	result := &syntax.Word{Parts: []syntax.WordPart{&syntax.SglQuoted{Value: prefix}}}
+	if word == nil {
+		return result
+	}
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			result.Parts = append(result.Parts, &syntax.SglQuoted{Value: unescapeLit(part.Value)})
+		case *syntax.SglQuoted, *syntax.DblQuoted:
+			result.Parts = append(result.Parts, part)
+		default:
+			result.Parts = append(result.Parts, &syntax.DblQuoted{Parts: []syntax.WordPart{part}})
+		}
+	}
+	return result
+}
+
+// unescapeLit removes the backslashes of an unquoted literal.
+func unescapeLit(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// shoptQueryFlag returns -q or -p if cmd queries options in a way the
+// interpreter does not support. Setting options quietly, as in shopt -qs,
+// just drops the -q.
+func shoptQueryFlag(cmd *syntax.CallExpr) string {
+	var query string
+	setting := false
+	args := cmd.Args[:1]
+	for _, arg := range cmd.Args[1:] {
+		flag := arg.Lit()
+		if !strings.HasPrefix(flag, "-") || len(flag) < 2 {
+			args = append(args, arg)
+			continue
+		}
+		rest := ""
+		for _, ch := range flag[1:] {
+			switch ch {
+			case 'q', 'p':
+				query = "-" + string(ch)
+			case 's', 'u':
+				setting = true
+				rest += string(ch)
+			default:
+				rest += string(ch)
+			}
+		}
+		if rest != "" {
+			args = append(args, litWord("-"+rest))
+		}
+	}
+	if query == "" {
+		return ""
+	}
+	cmd.Args = args
+	if setting && query == "-q" {
+		return ""
+	}
+	return query
+}
+
+// shoptQuery returns bish_shopt flag "$(shopt args 2>&1)", which answers the
+// query from the interpreter's listing of the options.
+func shoptQuery(cmd *syntax.CallExpr, flag string) *syntax.CallExpr {
+	listing := &syntax.Stmt{
+		Cmd: cmd,
+		Redirs: []*syntax.Redirect{{
+			Op:   syntax.DplOut,
+			N:    &syntax.Lit{Value: "2"},
+			Word: litWord("1"),
+		}},
+	}
+	return callWith("bish_shopt", []*syntax.Word{
+		litWord(flag),
+		{Parts: []syntax.WordPart{&syntax.DblQuoted{Parts: []syntax.WordPart{
+			&syntax.CmdSubst{Stmts: []*syntax.Stmt{listing}},
+		}}}},
+	})
+}
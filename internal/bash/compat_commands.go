@@ -0,0 +1,374 @@
+package bash
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// NewCompatCommandHandler creates a new ExecHandler for the bish_* commands
+// Rewrite uses.
+func NewCompatCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+
+			switch args[0] {
+			case "bish_rematch":
+				hc := interp.HandlerCtx(ctx)
+				return rematch(hc.Stdout, args[1:])
+			case "bish_printf":
+				hc := interp.HandlerCtx(ctx)
+				return printfCommand(hc.Stdout, hc.Stderr, hc.Env, args[1:])
+			case "bish_shopt":
+				hc := interp.HandlerCtx(ctx)
+				return shoptAnswer(hc.Stdout, hc.Stderr, args[1:])
+			case "bish_type":
+				hc := interp.HandlerCtx(ctx)
+				return typePath(hc.Stdout, hc.Dir, hc.Env, args[1:])
+			case "bish_unset":
+				hc := interp.HandlerCtx(ctx)
+				return unsetElements(hc.Stdout, hc.Env, args[1:])
+			case "bish_declare":
+				hc := interp.HandlerCtx(ctx)
+				return declareArrays(hc.Stdout, hc.Env, args[1:])
+			case "bish_source":
+				hc := interp.HandlerCtx(ctx)
+				return prepareSource(hc.Stdout, hc.Dir, hc.Env, args[1:])
+			case "bish_source_done":
+				hc := interp.HandlerCtx(ctx)
+				return finishSource(hc.Stderr, args[1:])
+			case "bish_skip":
+				hc := interp.HandlerCtx(ctx)
+				return recordSkip(hc.Stderr, args[1:])
+			default:
+				return next(ctx, args)
+			}
+		}
+	}
+}
+
+// rematch prints the BASH_REMATCH assignment for subject -- pieces..., and a
+// command that exits 1 if there is no match or 2 if the regex is invalid.
+func rematch(out io.Writer, args []string) error {
+	if len(args) < 2 || args[1] != "--" {
+		return fmt.Errorf("usage: bish_rematch subject -- regex...")
+	}
+	subject := args[0]
+
+	var pattern strings.Builder
+	for _, piece := range args[2:] {
+		if quoted, ok := strings.CutPrefix(piece, "q:"); ok {
+			pattern.WriteString(regexp.QuoteMeta(quoted))
+		} else {
+			pattern.WriteString(strings.TrimPrefix(piece, "r:"))
+		}
+	}
+
+	// Bash uses POSIX extended regexes, which match leftmost-longest; fall
+	// back to Go's syntax for the extensions glibc also supports, like \w
+	re, err := regexp.CompilePOSIX(pattern.String())
+	if err != nil {
+		re, err = regexp.Compile(pattern.String())
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(out, "(exit 2)")
+		return nil
+	}
+
+	match := re.FindStringSubmatch(subject)
+	if match == nil {
+		_, _ = fmt.Fprintln(out, "BASH_REMATCH=(); false")
+		return nil
+	}
+	quoted := make([]string, len(match))
+	for i, group := range match {
+		quoted[i] = shellQuote(group)
+	}
+	_, _ = fmt.Fprintf(out, "BASH_REMATCH=(%s)\n", strings.Join(quoted, " "))
+	return nil
+}
+
+// printfCommand runs printf [-v name] format args.... With -v, it prints
+// the assignment to make instead.
+func printfCommand(out, errOut io.Writer, env expand.Environ, args []string) error {
+	name := ""
+	if len(args) >= 2 && args[0] == "-v" {
+		name, args = args[1], args[2:]
+		if !validAssignTarget.MatchString(name) {
+			_, _ = fmt.Fprintf(errOut, "printf: `%s': not a valid identifier\n", name)
+			_, _ = fmt.Fprintln(out, "(exit 2)")
+			return nil
+		}
+	}
+	if len(args) == 0 {
+		_, _ = fmt.Fprintln(errOut, "usage: printf [-v var] format [arguments]")
+		return interp.NewExitStatus(2)
+	}
+
+	value, err := formatPrintf(env, args[0], args[1:])
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "printf: %v\n", err)
+		if name != "" {
+			_, _ = fmt.Fprintln(out, "(exit 1)")
+			return nil
+		}
+		return interp.NewExitStatus(1)
+	}
+
+	if name == "" {
+		_, _ = io.WriteString(out, value)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "%s=%s\n", name, shellQuote(value))
+	return nil
+}
+
+// formatPrintf formats like printf, reusing the format until the arguments
+// run out. On top of the interpreter's formatting, it supports %q.
+func formatPrintf(env expand.Environ, format string, args []string) (string, error) {
+	cfg := &expand.Config{Env: env}
+	var value strings.Builder
+	for {
+		iterFormat, iterArgs, consumed := quoteConversions(format, args)
+		s, _, err := expand.Format(cfg, iterFormat, iterArgs)
+		if err != nil {
+			return "", err
+		}
+		value.WriteString(s)
+		if consumed == 0 || consumed >= len(args) {
+			break
+		}
+		args = args[consumed:]
+	}
+	return value.String(), nil
+}
+
+// quoteConversions replaces the %q conversions in format by their quoted
+// arguments, for one use of format. It returns the new format, the arguments
+// of the other conversions, and how many arguments the use consumes.
+func quoteConversions(format string, args []string) (string, []string, int) {
+	var sb strings.Builder
+	var rest []string
+	next := 0
+	arg := func() (string, bool) {
+		if next >= len(args) {
+			next++
+			return "", false
+		}
+		next++
+		return args[next-1], true
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			sb.WriteByte(format[i])
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			sb.WriteString("%%")
+			i++
+			continue
+		}
+
+		// Flags, width and precision, then the conversion character
+		j := i + 1
+		var stars int
+		for j < len(format) && strings.IndexByte("-+ #0123456789.*", format[j]) >= 0 {
+			if format[j] == '*' {
+				stars++
+			}
+			j++
+		}
+		if j >= len(format) {
+			sb.WriteString(format[i:])
+			break
+		}
+
+		if format[j] == 'q' && stars == 0 {
+			value, _ := arg()
+			sb.WriteString(strings.ReplaceAll(shellQuote(value), "%", "%%"))
+		} else {
+			for k := 0; k <= stars; k++ {
+				if value, ok := arg(); ok {
+					rest = append(rest, value)
+				}
+			}
+			sb.WriteString(format[i : j+1])
+		}
+		i = j
+	}
+	return sb.String(), rest, min(next, len(args))
+}
+
+// shellQuote quotes s for the shell to read back, like printf %q.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	quoted, err := syntax.Quote(s, syntax.LangBash)
+	if err != nil {
+		// Only NUL bytes cannot be quoted, and bash drops them too
+		quoted, _ = syntax.Quote(strings.ReplaceAll(s, "\x00", ""), syntax.LangBash)
+	}
+	return quoted
+}
+
+// shoptAnswer answers shopt -q or shopt -p from the interpreter's listing of
+// the options, whose lines look like "name\ton".
+func shoptAnswer(out, errOut io.Writer, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: bish_shopt -q|-p listing")
+	}
+	flag, listing := args[0], args[1]
+
+	status := 0
+	for _, line := range strings.Split(listing, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			// An error, e.g. for an unknown option
+			_, _ = fmt.Fprintln(errOut, line)
+			status = 1
+			continue
+		}
+		on := fields[1] == "on"
+		if !on {
+			status = 1
+		}
+		if flag == "-p" {
+			mode := "-u"
+			if on {
+				mode = "-s"
+			}
+			_, _ = fmt.Fprintf(out, "shopt %s %s\n", mode, fields[0])
+		}
+	}
+	if flag == "-p" {
+		// shopt -p only fails for unknown options
+		status = 0
+		if strings.Contains(listing, "invalid option name") {
+			status = 1
+		}
+	}
+	if status != 0 {
+		return interp.NewExitStatus(uint8(status))
+	}
+	return nil
+}
+
+// typePath prints the path of each command like type -P does, and fails if
+// one is not found.
+func typePath(out io.Writer, dir string, env expand.Environ, args []string) error {
+	var err error
+	for _, name := range args[1:] {
+		path, lookErr := interp.LookPathDir(dir, env, name)
+		if lookErr != nil {
+			err = interp.NewExitStatus(1)
+			continue
+		}
+		_, _ = fmt.Fprintln(out, path)
+	}
+	return err
+}
+
+// unsetElements prints the commands that unset args, which may name array
+// elements like map[key]. The interpreter's arrays are not sparse, so the
+// elements of an indexed array after an unset one move down.
+func unsetElements(out io.Writer, env expand.Environ, args []string) error {
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			names = append(names, arg)
+			continue
+		}
+		name, key, ok := strings.Cut(strings.TrimSuffix(arg, "]"), "[")
+		if !ok || !strings.HasSuffix(arg, "]") {
+			names = append(names, shellQuote(arg))
+			continue
+		}
+
+		vr := env.Get(name)
+		declare := "declare -g"
+		if vr.Local {
+			declare = "declare"
+		}
+		if vr.Kind == expand.Associative || vr.Kind == expand.Indexed {
+			if key == "@" || key == "*" {
+				names = append(names, name)
+				continue
+			}
+		}
+		switch vr.Kind {
+		case expand.Associative:
+			var elems []string
+			for k, v := range vr.Map {
+				if k != key {
+					elems = append(elems, "["+shellQuote(k)+"]="+shellQuote(v))
+				}
+			}
+			sort.Strings(elems)
+			_, _ = fmt.Fprintf(out, "%s -A %s=(%s)\n", declare, name, strings.Join(elems, " "))
+		case expand.Indexed:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(vr.List) {
+				continue
+			}
+			elems := make([]string, 0, len(vr.List)-1)
+			for i, v := range vr.List {
+				if i != index {
+					elems = append(elems, shellQuote(v))
+				}
+			}
+			_, _ = fmt.Fprintf(out, "%s -a %s=(%s)\n", declare, name, strings.Join(elems, " "))
+		case expand.String:
+			if key == "0" || key == "@" || key == "*" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) > 0 {
+		_, _ = fmt.Fprintf(out, "unset %s\n", strings.Join(names, " "))
+	}
+	return nil
+}
+
+// declareArrays prints the declarations for variant flags... names..., which
+// declare arrays without values. Names that are not arrays of the declared
+// type yet are assigned an empty one, as the interpreter would not remember
+// the type otherwise; existing arrays keep their elements.
+func declareArrays(out io.Writer, env expand.Environ, args []string) error {
+	variant, args := args[0], args[1:]
+	var flags []string
+	for len(args) > 0 && (strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[0], "+")) {
+		flags = append(flags, args[0])
+		args = args[1:]
+	}
+	kind := expand.Indexed
+	if slices.Contains(flags, "-A") {
+		kind = expand.Associative
+	}
+
+	declaration := strings.Join(append([]string{variant}, flags...), " ")
+	for _, name := range args {
+		if env.Get(name).Kind == kind {
+			_, _ = fmt.Fprintf(out, "%s %s\n", declaration, name)
+		} else {
+			_, _ = fmt.Fprintf(out, "%s %s=()\n", declaration, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// compatCase is a bash snippet and what bash prints for it, stdout and
+// stderr combined.
+type compatCase struct {
+	name   string
+	script string
+	want   string
+	// divergence explains why bish still prints something else; the case is
+	// skipped
+	divergence string
+}
+
+// compatCases are idioms from rc files, oh-my-bash, nvm and pyenv init
+// scripts that once behaved differently in bish.
+var compatCases = []compatCase{
+	// Arithmetic and C-style loops
+	{name: "c-style for", script: `for ((i=0;i<3;i++)); do echo $i; done`, want: "0\n1\n2\n"},
+	{name: "c-style for counting down", script: `n=3; for ((i=n; i>0; i-=1)); do printf '%s ' $i; done; echo`, want: "3 2 1 \n"},
+	{name: "long c-style for", script: `s=0; for ((i=0;i<20000;i++)); do s=$((s+i)); done; echo $s`, want: "199990000\n"},
+	{name: "arithmetic command", script: `x=5; ((x++)); ((x+=2)); echo $x`, want: "8\n"},
+	{name: "while arithmetic", script: `i=0; while (( i < 3 )); do (( i++ )); done; echo $i`, want: "3\n"},
+	{name: "arithmetic operators", script: `echo $(( 2**10 )) $(( 7 % 3 )) $(( 1 << 4 ))`, want: "1024 1 16\n"},
+	{name: "base constants", script: `echo $((16#ff)) $((8#17)) $((2#101)) $((36#Z)) $((64#_))`, want: "255 15 5 35 63\n"},
+	{name: "base constant in c-style for", script: `for ((i=2#10; i<16#4; i++)); do echo $i; done`, want: "2\n3\n"},
+	{name: "base constant is not arithmetic elsewhere", script: `echo 16#ff`, want: "16#ff\n"},
+
+	// [[ =~ ]] and BASH_REMATCH
+	{name: "regex captures", script: `[[ "v1.2.3" =~ ^v([0-9]+)\.([0-9]+) ]] && echo "${BASH_REMATCH[1]} ${BASH_REMATCH[2]}"`, want: "1 2\n"},
+	{name: "regex from variable", script: `re='^[a-z]+$'; [[ abc =~ $re ]] && echo yes`, want: "yes\n"},
+	{name: "regex whole match", script: `[[ abc =~ b ]] && echo "${BASH_REMATCH[0]}"`, want: "b\n"},
+	{name: "quoted regex is literal", script: `[[ "a.c" =~ "a.c" ]] && echo lit; [[ "abc" =~ "a.c" ]] || echo nolit`, want: "lit\nnolit\n"},
+	{name: "unmatched group", script: `[[ foo =~ ^(f)(x)? ]]; echo "${#BASH_REMATCH[@]}:${BASH_REMATCH[2]}:"`, want: "3::\n"},
+	{name: "negated regex", script: `! [[ foo =~ bar ]] && echo neg`, want: "neg\n"},
+	{name: "invalid regex", script: `re="("; [[ foo =~ $re ]]; echo $?`, want: "2\n"},
+	{name: "regex in function", script: `f() { [[ $1 =~ ^([0-9]+)$ ]] || return 1; echo "num ${BASH_REMATCH[1]}"; }; f 42; f x || echo notnum`, want: "num 42\nnotnum\n"},
+
+	// Parameter expansion
+	{name: "pattern substitution", script: `x=a-b-c; echo ${x//-/_} ${x/-/_} ${x#*-} ${x##*-} ${x%-*} ${x%%-*}`, want: "a_b_c a_b-c b-c c a-b a\n"},
+	{name: "case modification", script: `s="Hello World"; echo "${s,,}" "${s//o/0}" ${s^^}`, want: "hello world Hell0 W0rld HELLO WORLD\n"},
+	{name: "substrings", script: `x=hello; echo ${#x} ${x:1:2} ${x: -2} ${x::2}`, want: "5 el lo he\n"},
+	{name: "defaults", script: `: "${Y:=default}"; echo $Y ${UNSET_VAR:-fallback} ${UNSET_VAR-}`, want: "default fallback\n"},
+	{name: "version prefix", script: `NVM_VER="v18.17.0"; echo "${NVM_VER#v}" "${NVM_VER%%.*}"`, want: "18.17.0 v18\n"},
+
+	// Arrays
+	{name: "array slices and keys", script: `arr=(a b c); echo ${#arr[@]} ${arr[@]:1} "${!arr[@]}"`, want: "3 b c 0 1 2\n"},
+	{name: "array append", script: `declare -a list; list+=(one); list+=(two); echo "${list[@]}"`, want: "one two\n"},
+	{name: "associative array declared bare", script: `declare -A m; m[a]=1; m[b]=2; echo "${!m[@]}" | tr ' ' '\n' | sort | tr '\n' ' '; echo`, want: "a b \n"},
+	{name: "associative array redeclared", script: `declare -A m=([x]=1); declare -A m; echo ${m[x]:-gone}`, want: "1\n"},
+	{name: "local array", script: `f() { local -a a=(1 2); echo ${a[1]}; }; f`, want: "2\n"},
+	{name: "unset associative element", script: `declare -A m=([a]=1 [b]=2); unset 'm[a]'; echo "${!m[@]}" ${m[b]}`, want: "b 2\n"},
+	{name: "unset associative element with variable key", script: `declare -A m=([a]=1 [b]=2); k=b; unset "m[$k]"; echo "${!m[@]}"`, want: "a\n"},
+	{name: "unset last element", script: `x=(a b c); unset 'x[2]'; echo "${x[@]}" ${#x[@]}`, want: "a b 2\n"},
+	{name: "unset local associative element", script: `f() { local -A m=([a]=1 [b]=2); unset 'm[b]'; echo "${!m[@]}"; }; f; echo "g${m[a]}"`, want: "a\ng\n"},
+	{name: "unset middle element", script: `x=(a b c); unset 'x[1]'; echo "${x[@]}" ${#x[@]} ${x[2]}`, want: "a c 2 c\n",
+		divergence: "the interpreter's indexed arrays are not sparse, so c moves to index 1"},
+
+	// printf
+	{name: "printf -v", script: `printf -v out '%03d' 7; echo $out`, want: "007\n"},
+	{name: "printf -v reuses the format", script: `printf -v v '%s-%s,' a b c d; echo "$v"`, want: "a-b,c-d,\n"},
+	{name: "printf -v into array element", script: `printf -v 'arr[2]' '%d' 5; echo ${arr[2]}`, want: "5\n"},
+	{name: "printf -v keeps newlines", script: `printf -v v 'line\n'; echo "[$v]"`, want: "[line\n]\n"},
+	{name: "printf %q round trip", script: `eval "$(printf 'export PYENV_ROOT=%q\n' "/opt/py env")"; echo "$PYENV_ROOT"`, want: "/opt/py env\n"},
+	{name: "printf %q empty", script: `printf '[%q]\n' ''`, want: "['']\n"},
+
+	// shopt
+	{name: "shopt -q", script: `shopt -q nullglob && echo on || echo off; shopt -s nullglob; shopt -q nullglob && echo on`, want: "off\non\n"},
+	{name: "shopt -p", script: `shopt -s nullglob; shopt -p nullglob globstar`, want: "shopt -s nullglob\nshopt -u globstar\n"},
+	{name: "shopt -qs", script: `shopt -qs globstar; shopt -q globstar && echo on`, want: "on\n"},
+	{name: "shopt -q unknown option", script: `shopt -q nosuchopt 2>/dev/null; echo $?`, want: "1\n"},
+
+	// Functions and commands
+	{name: "declare -F name", script: `__git_ps1() { :; }; declare -F __git_ps1 && echo defined`, want: "__git_ps1\ndefined\n"},
+	{name: "declare -f missing", script: `declare -f nosuch >/dev/null; echo $?`, want: "1\n"},
+	{name: "declare -F guard", script: `declare -F nvm_foo >/dev/null || echo nofunc`, want: "nofunc\n"},
+	{name: "type -P", script: `_omb_util_command_exists() { type -P "$1" &>/dev/null; }; _omb_util_command_exists sh && echo has; type -P no-such-cmd || echo missing`, want: "has\nmissing\n"},
+	{name: "command -v guard", script: `command -v no-such-cmd >/dev/null 2>&1 || echo missing`, want: "missing\n"},
+	{name: "local IFS split", script: `f() { local IFS=:; set -- $1; echo $#; }; f a:b:c`, want: "3\n"},
+	{name: "read here-string", script: `str="key=value"; IFS='=' read -r k v <<< "$str"; echo $k $v`, want: "key value\n"},
+	{name: "mapfile", script: `mapfile -t lines < <(printf 'a\nb\n'); echo ${#lines[@]}`, want: "2\n"},
+	{name: "brace expansion", script: `echo {a,b}{1,2}; for i in {1..3}; do echo -n $i; done; echo`, want: "a1 a2 b1 b2\n123\n"},
+	{name: "trap on exit", script: `trap 'echo bye' EXIT; echo hi`, want: "hi\nbye\n"},
+
+	// rc file idioms
+	{name: "pathmunge", script: `pathmunge() { case ":${PATH}:" in *:"$1":*) ;; *) PATH="$1:$PATH";; esac; }; pathmunge /opt/x; pathmunge /opt/x; echo "${PATH%%:*}"`, want: "/opt/x\n"},
+	{name: "prompt command append", script: `if [[ -z "${PROMPT_COMMAND-}" ]]; then PROMPT_COMMAND="x"; else PROMPT_COMMAND="$PROMPT_COMMAND;x"; fi; echo $PROMPT_COMMAND`, want: "x\n"},
+	{name: "nvm version check", script: `nvm_is_version_installed() { [ -n "${1-}" ] && [ -x "$1/bin/node" ]; }; nvm_is_version_installed /none || echo no`, want: "no\n"},
+	{name: "pyenv init eval", script: `eval "$(echo 'export PYENV_SHELL=bash')"; echo $PYENV_SHELL`, want: "bash\n"},
+	{name: "interactive check", script: `case "$-" in *i*) echo interactive;; *) echo non;; esac`, want: "non\n"},
+	{name: "bash version check", script: `[ -n "$BASH_VERSION" ] && echo bash || echo nobash`, want: "bash\n",
+		divergence: "bish does not claim to be bash, so rc files do not run bash-only code it may not support"},
+	{name: "PIPESTATUS", script: `true | false; echo ${PIPESTATUS[@]}`, want: "0 1\n",
+		divergence: "the interpreter does not set PIPESTATUS"},
+	{name: "extra file descriptors", script: `exec 3>&1; echo ok >&3`, want: "ok\n",
+		divergence: "the interpreter only supports file descriptors 0 to 2"},
+}
+
+// runCompatScript runs script like bish does and returns its output, stdout
+// and stderr combined.
+func runCompatScript(t *testing.T, script string) string {
+	t.Helper()
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("PATH=/usr/bin:/bin", "HOME=/home/test")),
+		interp.StdIO(nil, &out, &out),
+		interp.ExecHandlers(NewTypesetCommandHandler(), NewCompatCommandHandler()),
+	)
+	assert.NoError(t, err)
+	SetTypesetRunner(runner)
+
+	_ = RunBashScriptFromReader(context.Background(), runner, strings.NewReader(script), "compat")
+	return out.String()
+}
+
+func TestBashCompat(t *testing.T) {
+	for _, tc := range compatCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.divergence != "" {
+				t.Skip(tc.divergence)
+			}
+			assert.Equal(t, tc.want, runCompatScript(t, tc.script))
+		})
+	}
+}
+
+// TestBashCompatCasesMatchBash checks the expectations of the suite against
+// bash itself, where it is installed.
+func TestBashCompatCasesMatchBash(t *testing.T) {
+	bashPath, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	for _, tc := range compatCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command(bashPath, "--norc", "--noprofile", "-c", tc.script)
+			cmd.Env = []string{"PATH=/usr/bin:/bin", "HOME=/home/test"}
+			out, _ := cmd.CombinedOutput()
+			assert.Equal(t, tc.want, string(out))
+		})
+	}
+}
+
+func TestRunRecoversFromInterpreterPanics(t *testing.T) {
+	out := runCompatScript(t, "exec 3>&1; echo ok >&3")
+	assert.Empty(t, out)
+
+	runner, err := interp.New(interp.StdIO(nil, nil, nil))
+	assert.NoError(t, err)
+	err = RunBashScriptFromReader(context.Background(), runner, strings.NewReader("exec 3>&1"), "compat")
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
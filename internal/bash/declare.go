@@ -0,0 +1,346 @@
+package bash
+
+import (
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// The interpreter knows the -x, -r, -a, -A and -n attributes of
+// declare/typeset/local, but only as separate flags, and it knows neither -i
+// nor the -p listing. RewriteDeclarations fills these gaps in the parsed
+// program before it runs.
+
+// The interpreter has no integer attribute, so -i is kept in a marker
+// variable next to the variable it applies to, declared the same way, such as
+// with local in a function, so that it goes out of scope with it. Assignments
+// to names that may be integer variables check the marker when they run, and
+// are evaluated arithmetically if it is set, like bash does; unset clears it.
+
+// integerMarkerPrefix starts the names of the markers of integer variables.
+const integerMarkerPrefix = "__bish_int_"
+
+// integerMarker returns the name of the marker of the integer attribute of
+// the variable name.
+func integerMarker(name string) string {
+	return integerMarkerPrefix + name
+}
+
+// IsIntegerVar reports whether the variable name has the integer attribute
+// in env.
+func IsIntegerVar(env expand.Environ, name string) bool {
+	return env.Get(integerMarker(name)).String() != ""
+}
+
+// integerCandidates holds the names that were declared with -i anywhere in
+// the input so far. Only assignments to them check for the attribute, so
+// that other assignments run as they are; it does not say whether a
+// variable is an integer, which is up to its marker.
+var (
+	integerCandidatesMu sync.RWMutex
+	integerCandidates   = map[string]bool{}
+)
+
+func isIntegerCandidate(name string) bool {
+	integerCandidatesMu.RLock()
+	defer integerCandidatesMu.RUnlock()
+	return integerCandidates[name]
+}
+
+func addIntegerCandidate(name string) {
+	integerCandidatesMu.Lock()
+	defer integerCandidatesMu.Unlock()
+	integerCandidates[name] = true
+}
+
+// RewriteDeclarations rewrites the declarations in node that the interpreter
+// does not support:
+//   - declare -p, export -p, readonly -p and bare export/readonly become
+//     bish_typeset listings
+//   - combined flags like -rx are split into -r -x
+//   - -i and +i set and clear the marker of the variables, and the values
+//     assigned with -i are wrapped in $((...))
+//   - later assignments to these variables are wrapped in $((...)) if the
+//     marker is set when they run
+//   - unset also unsets the markers
+//
+// Assignments are only rewritten once a declaration with -i of the variable
+// has been parsed, so, like aliases, a function defined before that treats
+// the variable as a string.
+func RewriteDeclarations(node syntax.Node) {
+	if node == nil {
+		return
+	}
+	// Rewritten after the walk, in the order of the input, so that the
+	// walk does not go into the statements that replace them
+	var stmts []*syntax.Stmt
+	syntax.Walk(node, func(node syntax.Node) bool {
+		if stmt, ok := node.(*syntax.Stmt); ok {
+			stmts = append(stmts, stmt)
+		}
+		return true
+	})
+	for _, stmt := range stmts {
+		switch cmd := stmt.Cmd.(type) {
+		case *syntax.DeclClause:
+			if listing := declListing(cmd); listing != nil {
+				stmt.Cmd = listing
+			} else if call := arrayDeclaration(cmd); call != nil {
+				stmt.Cmd = evalOutputOf(call)
+			} else if markers := rewriteDeclFlags(cmd); len(markers) > 0 {
+				stmt.Cmd = &syntax.Block{Stmts: []*syntax.Stmt{
+					{Cmd: cmd},
+					{Cmd: &syntax.DeclClause{Variant: cmd.Variant, Args: markers}},
+				}}
+			}
+		case *syntax.CallExpr:
+			if len(cmd.Args) == 0 {
+				if block := integerAssigns(cmd); block != nil {
+					stmt.Cmd = block
+				}
+			} else if cmd.Args[0].Lit() == "unset" {
+				unsetMarkers(cmd)
+			}
+		}
+	}
+}
+
+// declFlags returns the flags of decl, e.g. "rx" for "-r -x", the names it
+// declares, and whether it has any argument that is not a flag.
+func declFlags(decl *syntax.DeclClause) (flags string, names []string, hasOperands bool) {
+	for _, as := range decl.Args {
+		if flag, ok := declFlag(as); ok {
+			if strings.HasPrefix(flag, "-") {
+				flags += flag[1:]
+			}
+			continue
+		}
+		hasOperands = true
+		if as.Name != nil {
+			names = append(names, as.Name.Value)
+		} else if as.Value != nil {
+			names = append(names, as.Value.Lit())
+		}
+	}
+	return flags, names, hasOperands
+}
+
+// declFlag returns the flag as holds, if it is an option like -rx or +i.
+func declFlag(as *syntax.Assign) (string, bool) {
+	if as.Name != nil || as.Value == nil {
+		return "", false
+	}
+	flag := as.Value.Lit()
+	if len(flag) < 2 || (flag[0] != '-' && flag[0] != '+') {
+		return "", false
+	}
+	return flag, true
+}
+
+// declListing returns the bish_typeset command that lists what decl asks
+// for, or nil if decl is not a listing.
+func declListing(decl *syntax.DeclClause) *syntax.CallExpr {
+	flags, names, hasOperands := declFlags(decl)
+	switch decl.Variant.Value {
+	case "export":
+		if hasOperands && !strings.Contains(flags, "p") {
+			return nil
+		}
+		flags = strings.ReplaceAll(flags, "p", "") + "x"
+	case "readonly":
+		if hasOperands && !strings.Contains(flags, "p") {
+			return nil
+		}
+		flags = strings.ReplaceAll(flags, "p", "") + "r"
+	case "declare", "typeset", "local":
+		if !strings.Contains(flags, "p") {
+			return nil
+		}
+		flags = strings.ReplaceAll(flags, "p", "")
+	default:
+		return nil
+	}
+
+	args := []string{"bish_typeset", "-p" + flags}
+	args = append(args, names...)
+	call := &syntax.CallExpr{}
+	for _, arg := range args {
+		call.Args = append(call.Args, &syntax.Word{Parts: []syntax.WordPart{
+			&syntax.Lit{ValuePos: decl.Pos(), ValueEnd: decl.Pos(), Value: arg},
+		}})
+	}
+	return call
+}
+
+// arrayDeclaration returns the bish_declare call for decl if it only
+// declares arrays without values, or nil.
+func arrayDeclaration(decl *syntax.DeclClause) *syntax.CallExpr {
+	flags, names, _ := declFlags(decl)
+	if !strings.ContainsAny(flags, "aA") || len(names) == 0 {
+		return nil
+	}
+	for _, as := range decl.Args {
+		if _, ok := declFlag(as); !ok && (as.Name == nil || !as.Naked) {
+			return nil
+		}
+	}
+
+	rewriteDeclFlags(decl)
+	args := []*syntax.Word{litWord(decl.Variant.Value)}
+	for _, as := range decl.Args {
+		if as.Name != nil {
+			args = append(args, litWord(as.Name.Value))
+		} else {
+			args = append(args, as.Value)
+		}
+	}
+	return callWith("bish_declare", args)
+}
+
+// rewriteDeclFlags splits combined flags, drops -i and +i, and makes the
+// values assigned by an integer declaration arithmetic. It returns the
+// assignments that set or clear the markers of the variables it declares.
+func rewriteDeclFlags(decl *syntax.DeclClause) []*syntax.Assign {
+	var integer, notInteger bool
+	args := make([]*syntax.Assign, 0, len(decl.Args))
+	for _, as := range decl.Args {
+		flag, ok := declFlag(as)
+		if !ok {
+			args = append(args, as)
+			continue
+		}
+		for _, ch := range flag[1:] {
+			switch {
+			case ch == 'i' && flag[0] == '-':
+				integer = true
+			case ch == 'i':
+				notInteger = true
+			default:
+				args = append(args, &syntax.Assign{Naked: true, Value: &syntax.Word{Parts: []syntax.WordPart{
+					&syntax.Lit{ValuePos: as.Pos(), ValueEnd: as.End(), Value: string(flag[0]) + string(ch)},
+				}}})
+			}
+		}
+	}
+	decl.Args = args
+	if !integer && !notInteger {
+		return nil
+	}
+
+	var markers []*syntax.Assign
+	for _, as := range decl.Args {
+		if flag, ok := declFlag(as); ok && flag == "-g" {
+			// The markers are global too
+			markers = append(markers, as)
+		}
+		if as.Name == nil {
+			continue
+		}
+		marker := &syntax.Assign{Name: &syntax.Lit{Value: integerMarker(as.Name.Value)}, Value: litWord("")}
+		if integer {
+			addIntegerCandidate(as.Name.Value)
+			marker.Value = litWord("1")
+			if arith := integerAssign(as); arith != nil {
+				*as = *arith
+			}
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// integerAssigns returns the assignments of call, which assigns without
+// running a command, with those to possible integer variables made
+// arithmetic if they are integer variables when they run, or nil if there is
+// none of those.
+func integerAssigns(call *syntax.CallExpr) *syntax.Block {
+	found := false
+	for _, as := range call.Assigns {
+		if as.Name != nil && isIntegerCandidate(as.Name.Value) && integerAssign(as) != nil {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	// In order, as the values may refer to the variables assigned before
+	block := &syntax.Block{}
+	for _, as := range call.Assigns {
+		plain := &syntax.Stmt{Cmd: &syntax.CallExpr{Assigns: []*syntax.Assign{as}}}
+		arith := integerAssign(as)
+		if arith == nil || !isIntegerCandidate(as.Name.Value) {
+			block.Stmts = append(block.Stmts, plain)
+			continue
+		}
+		// if [[ -n ${marker-} ]]; then name=$((value)); else name=value; fi
+		test := &syntax.TestClause{X: &syntax.UnaryTest{
+			Op: syntax.TsNempStr,
+			X: &syntax.Word{Parts: []syntax.WordPart{&syntax.ParamExp{
+				Param: &syntax.Lit{Value: integerMarker(as.Name.Value)},
+				Exp:   &syntax.Expansion{Op: syntax.DefaultUnset},
+			}}},
+		}}
+		block.Stmts = append(block.Stmts, &syntax.Stmt{Cmd: &syntax.IfClause{
+			Cond: []*syntax.Stmt{{Cmd: test}},
+			Then: []*syntax.Stmt{{Cmd: &syntax.CallExpr{Assigns: []*syntax.Assign{arith}}}},
+			Else: &syntax.IfClause{Then: []*syntax.Stmt{plain}},
+		}})
+	}
+	return block
+}
+
+// integerAssign returns as with its value wrapped in $((...)), as bash
+// assigns to an integer variable; name+=value becomes
+// name=$((name + (value))). It is nil if as does not assign a string.
+func integerAssign(as *syntax.Assign) *syntax.Assign {
+	if as.Name == nil || as.Naked || as.Value == nil || as.Index != nil || as.Array != nil {
+		return nil
+	}
+
+	expr := arithmOf(as.Value)
+	if as.Append {
+		expr = &syntax.BinaryArithm{
+			Op: syntax.Add,
+			X:  &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: as.Name.Value}}},
+			Y:  &syntax.ParenArithm{X: expr},
+		}
+	}
+	return &syntax.Assign{Name: as.Name, Value: &syntax.Word{Parts: []syntax.WordPart{&syntax.ArithmExp{
+		Left:  as.Value.Pos(),
+		Right: as.Value.End(),
+		X:     expr,
+	}}}}
+}
+
+// unsetMarkers makes unset of variables also unset their markers.
+func unsetMarkers(call *syntax.CallExpr) {
+	var markers []*syntax.Word
+	for _, arg := range call.Args[1:] {
+		name := arg.Lit()
+		if name == "-f" {
+			return
+		}
+		if isIntegerCandidate(name) {
+			markers = append(markers, litWord(integerMarker(name)))
+		}
+	}
+	call.Args = append(call.Args, markers...)
+}
+
+// arithmOf parses word as an arithmetic expression, like bash does with the
+// value of an integer variable. Words that do not parse, e.g. because they are
+// quoted, are evaluated as a single operand.
+func arithmOf(word *syntax.Word) syntax.ArithmExpr {
+	var sb strings.Builder
+	if err := syntax.NewPrinter().Print(&sb, word); err != nil {
+		return word
+	}
+	expr, err := syntax.NewParser().Arithmetic(strings.NewReader(sb.String()))
+	if err != nil || expr == nil {
+		return word
+	}
+	return expr
+}
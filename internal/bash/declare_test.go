@@ -0,0 +1,119 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// runDeclareScript runs script with the typeset handler and returns its
+// stdout and stderr.
+func runDeclareScript(t *testing.T, script string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("HOME=/home/test")),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewTypesetCommandHandler()),
+	)
+	require.NoError(t, err)
+	SetTypesetRunner(runner)
+
+	err = RunBashScriptFromReader(context.Background(), runner, strings.NewReader(script), "test")
+	return stdout.String(), stderr.String(), err
+}
+
+func TestDeclareCombinedFlags(t *testing.T) {
+	stdout, stderr, err := runDeclareScript(t, `declare -rx COMBINED="a b"; echo "$COMBINED"; COMBINED=c`)
+	assert.Error(t, err)
+	assert.Equal(t, "a b\n", stdout)
+	assert.Contains(t, stderr, "readonly variable")
+}
+
+func TestDeclareInteger(t *testing.T) {
+	stdout, _, err := runDeclareScript(t, `
+declare -i COUNTER=2*3
+echo $COUNTER
+COUNTER+=4
+echo $COUNTER
+COUNTER=COUNTER/2
+echo $COUNTER
+declare -p COUNTER
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "6\n10\n5\ndeclare -i COUNTER=\"5\"\n", stdout)
+
+	stdout, _, err = runDeclareScript(t, "declare -i COUNTER=1\ndeclare +i COUNTER\nCOUNTER=1+1\necho $COUNTER")
+	require.NoError(t, err)
+	assert.Equal(t, "1+1\n", stdout)
+}
+
+func TestDeclareIntegerScope(t *testing.T) {
+	stdout, _, err := runDeclareScript(t, `
+f() { local -i n=1+1; echo $n; }
+f
+n=hello
+echo $n
+declare -p n
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "2\nhello\ndeclare -- n=\"hello\"\n", stdout)
+
+	stdout, _, err = runDeclareScript(t, `
+declare -i m=3
+unset m
+m=1+1
+echo $m
+declare -p
+`)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "1+1\n"))
+	assert.Contains(t, stdout, "declare -- m=\"1+1\"\n")
+	assert.NotContains(t, stdout, integerMarkerPrefix)
+}
+
+func TestDeclarePrint(t *testing.T) {
+	stdout, _, err := runDeclareScript(t, `
+PLAIN='say "hi" to $USER'
+declare -rx FROZEN=1
+declare -a LIST=(one "two words")
+declare -A MAP=([key]=value)
+declare -p PLAIN FROZEN LIST MAP
+`)
+	require.NoError(t, err)
+	assert.Equal(t, `declare -- PLAIN="say \"hi\" to \$USER"
+declare -rx FROZEN="1"
+declare -a LIST=([0]="one" [1]="two words")
+declare -A MAP=([key]="value" )
+`, stdout)
+}
+
+func TestDeclarePrintNotFound(t *testing.T) {
+	stdout, stderr, err := runDeclareScript(t, `declare -p MISSING_VAR; echo "status $?"`)
+	require.NoError(t, err)
+	assert.Equal(t, "status 1\n", stdout)
+	assert.Equal(t, "declare: MISSING_VAR: not found\n", stderr)
+}
+
+func TestExportAndReadonlyListing(t *testing.T) {
+	stdout, _, err := runDeclareScript(t, `
+export EXPORTED=yes
+export UNSET_EXPORT
+readonly LOCKED=1
+NOT_EXPORTED=no
+export -p
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "declare -x EXPORTED=\"yes\"\ndeclare -x HOME=\"/home/test\"\ndeclare -x UNSET_EXPORT\n", stdout)
+
+	stdout, _, err = runDeclareScript(t, "readonly LOCKED=1\nexport LOCKED_EXPORT=1\nreadonly -p")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "declare -r LOCKED=\"1\"\n")
+	assert.NotContains(t, stdout, "LOCKED_EXPORT")
+}
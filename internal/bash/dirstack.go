@@ -0,0 +1,259 @@
+package bash
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// dirStack holds the directories saved by pushd for the session. The top of
+// the stack, entry 0, is always the current directory, so it is not kept
+// here and a plain cd replaces it as in bash.
+var (
+	dirStack   []string
+	dirStackMu sync.Mutex
+)
+
+// NewDirStackCommandHandler creates an ExecHandler middleware for pushd, popd
+// and dirs, which Rewrite turns into bish_pushd, bish_popd and bish_dirs as
+// the interpreter runs its own versions first. Handlers cannot change the
+// shell's directory, so bish_pushd and bish_popd print the builtin cd and
+// bish_cd_hook to evaluate, which keeps $PWD and the prompt in step as cd
+// does, or (exit N) if there is nothing to change to.
+func NewDirStackCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+
+			switch args[0] {
+			case "bish_pushd":
+				hc := interp.HandlerCtx(ctx)
+				return pushd(hc.Stdout, hc.Stderr, hc.Dir, args[1:])
+			case "bish_popd":
+				hc := interp.HandlerCtx(ctx)
+				return popd(hc.Stdout, hc.Stderr, hc.Dir, args[1:])
+			case "bish_dirs":
+				hc := interp.HandlerCtx(ctx)
+				return dirs(hc.Stdout, hc.Stderr, hc.Dir, hc.Env, args[1:])
+			default:
+				return next(ctx, args)
+			}
+		}
+	}
+}
+
+// pushd saves dir, the current directory, and prints the change to the
+// directory pushd [-n] [DIR | +N | -N] goes to.
+func pushd(out, errOut io.Writer, dir string, args []string) error {
+	noChange := len(args) > 0 && args[0] == "-n"
+	if noChange {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return dirStackError(out, errOut, 2, "pushd: too many arguments")
+	}
+
+	dirStackMu.Lock()
+	defer dirStackMu.Unlock()
+	stack := append([]string{dir}, dirStack...)
+
+	switch {
+	case len(args) == 0:
+		if len(dirStack) == 0 {
+			return dirStackError(out, errOut, 1, "pushd: no other directory")
+		}
+		if !noChange {
+			stack[0], stack[1] = stack[1], stack[0]
+		}
+	case isStackIndex(args[0]):
+		n, ok := stackIndex(args[0], len(stack))
+		if !ok {
+			return dirStackError(out, errOut, 1, "pushd: "+args[0]+": directory stack index out of range")
+		}
+		if !noChange {
+			stack = append(stack[n:], stack[:n]...)
+		}
+	default:
+		target := resolveDir(dir, args[0])
+		if noChange {
+			stack = slices.Insert(stack, 1, target)
+			break
+		}
+		if info, err := os.Stat(target); err != nil {
+			return dirStackError(out, errOut, 1, "pushd: "+args[0]+": No such file or directory")
+		} else if !info.IsDir() {
+			return dirStackError(out, errOut, 1, "pushd: "+args[0]+": Not a directory")
+		}
+		stack = append([]string{target}, stack...)
+	}
+
+	dirStack = stack[1:]
+	return changeDir(out, dir, stack[0])
+}
+
+// popd removes an entry from the stack and prints the change to the
+// directory popd [-n] [+N | -N] goes to.
+func popd(out, errOut io.Writer, dir string, args []string) error {
+	noChange := len(args) > 0 && args[0] == "-n"
+	if noChange {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return dirStackError(out, errOut, 2, "popd: too many arguments")
+	}
+
+	dirStackMu.Lock()
+	defer dirStackMu.Unlock()
+	if len(dirStack) == 0 {
+		return dirStackError(out, errOut, 1, "popd: directory stack empty")
+	}
+	stack := append([]string{dir}, dirStack...)
+
+	n := 0
+	if noChange {
+		n = 1
+	}
+	if len(args) == 1 {
+		if !isStackIndex(args[0]) {
+			return dirStackError(out, errOut, 2, "popd: "+args[0]+": invalid argument")
+		}
+		var ok bool
+		if n, ok = stackIndex(args[0], len(stack)); !ok {
+			return dirStackError(out, errOut, 1, "popd: "+args[0]+": directory stack index out of range")
+		}
+	}
+	stack = slices.Delete(stack, n, n+1)
+
+	dirStack = stack[1:]
+	return changeDir(out, dir, stack[0])
+}
+
+// changeDir prints the commands that go from dir to target, if they differ,
+// and show the stack.
+func changeDir(out io.Writer, dir, target string) error {
+	if target != dir {
+		_, _ = fmt.Fprintf(out, "builtin cd %s && bish_cd_hook \"$PWD\" && ", shellQuote(target))
+	}
+	_, _ = fmt.Fprintln(out, "bish_dirs")
+	return nil
+}
+
+// dirStackError reports msg and prints the exit status to evaluate.
+func dirStackError(out, errOut io.Writer, status int, msg string) error {
+	_, _ = fmt.Fprintln(errOut, msg)
+	_, _ = fmt.Fprintf(out, "(exit %d)\n", status)
+	return nil
+}
+
+// dirs prints the stack, with dir on top, as dirs [-clpv] [+N | -N] does.
+func dirs(out, errOut io.Writer, dir string, env expand.Environ, args []string) error {
+	const usage = "dirs: usage: dirs [-clpv] [+N] [-N]"
+	long, perLine, numbered := false, false, false
+	index := ""
+	for _, arg := range args {
+		switch {
+		case isStackIndex(arg):
+			index = arg
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, flag := range arg[1:] {
+				switch flag {
+				case 'c':
+					dirStackMu.Lock()
+					dirStack = nil
+					dirStackMu.Unlock()
+					return nil
+				case 'l':
+					long = true
+				case 'p':
+					perLine = true
+				case 'v':
+					perLine, numbered = true, true
+				default:
+					_, _ = fmt.Fprintf(errOut, "dirs: -%c: invalid option\n%s\n", flag, usage)
+					return interp.NewExitStatus(2)
+				}
+			}
+		default:
+			_, _ = fmt.Fprintln(errOut, usage)
+			return interp.NewExitStatus(2)
+		}
+	}
+
+	dirStackMu.Lock()
+	stack := append([]string{dir}, dirStack...)
+	dirStackMu.Unlock()
+	if !long {
+		home := env.Get("HOME").String()
+		for i, entry := range stack {
+			stack[i] = abbreviateHome(entry, home)
+		}
+	}
+
+	switch {
+	case index != "":
+		n, ok := stackIndex(index, len(stack))
+		if !ok {
+			_, _ = fmt.Fprintf(errOut, "dirs: %s: directory stack index out of range\n", index)
+			return interp.NewExitStatus(1)
+		}
+		_, _ = fmt.Fprintln(out, stack[n])
+	case numbered:
+		for i, entry := range stack {
+			_, _ = fmt.Fprintf(out, "%2d  %s\n", i, entry)
+		}
+	case perLine:
+		for _, entry := range stack {
+			_, _ = fmt.Fprintln(out, entry)
+		}
+	default:
+		_, _ = fmt.Fprintln(out, strings.Join(stack, " "))
+	}
+	return nil
+}
+
+// isStackIndex reports whether arg is a +N or -N stack index.
+func isStackIndex(arg string) bool {
+	if len(arg) < 2 || (arg[0] != '+' && arg[0] != '-') {
+		return false
+	}
+	_, err := strconv.Atoi(arg[1:])
+	return err == nil
+}
+
+// stackIndex returns the position in a stack of size entries of +N, counted
+// from the top, or -N, counted from the bottom.
+func stackIndex(arg string, size int) (int, bool) {
+	n, err := strconv.Atoi(arg[1:])
+	if err != nil || n < 0 || n >= size {
+		return 0, false
+	}
+	if arg[0] == '-' {
+		n = size - 1 - n
+	}
+	return n, true
+}
+
+// abbreviateHome shows dir under home starting with ~, as dirs does.
+func abbreviateHome(dir, home string) string {
+	if home == "" || home == "/" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return dir
+}
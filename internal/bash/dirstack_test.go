@@ -0,0 +1,179 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// newDirStackRunner starts a runner in a home directory with the
+// subdirectories a and b, and an empty directory stack.
+func newDirStackRunner(t *testing.T) (*interp.Runner, string) {
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(home, "a"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(home, "b"), 0755))
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	require.NoError(t, os.Chdir(home))
+	t.Setenv("HOME", home)
+
+	dynamicEnv := environment.NewDynamicEnviron()
+	dynamicEnv.UpdateSystemEnv()
+	r, err := interp.New(interp.Env(dynamicEnv), interp.ExecHandlers(NewCdCommandHandler(), NewDirStackCommandHandler()))
+	require.NoError(t, err)
+	SetCdRunner(r)
+	t.Cleanup(func() { SetCdRunner(nil) })
+
+	dirStack = nil
+	t.Cleanup(func() { dirStack = nil })
+	return r, home
+}
+
+// runDirStack runs command as the shell would and returns what it printed.
+func runDirStack(t *testing.T, r *interp.Runner, command string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	interp.StdIO(nil, &stdout, &stderr)(r)
+	prog, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	require.NoError(t, err)
+	Rewrite(prog)
+	err = Run(context.Background(), r, prog)
+	return strings.TrimSuffix(stdout.String(), "\n"), strings.TrimSuffix(stderr.String(), "\n"), err
+}
+
+func TestPushdPopd(t *testing.T) {
+	r, home := newDirStackRunner(t)
+
+	stdout, _, err := runDirStack(t, r, "pushd a")
+	require.NoError(t, err)
+	assert.Equal(t, "~/a ~", stdout)
+	assert.Equal(t, filepath.Join(home, "a"), r.Dir)
+
+	stdout, _, err = runDirStack(t, r, "pushd ../b")
+	require.NoError(t, err)
+	assert.Equal(t, "~/b ~/a ~", stdout)
+
+	// $PWD and the process directory follow, as after cd
+	stdout, _, err = runDirStack(t, r, `echo "$PWD"`)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "b"), stdout)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "b"), wd)
+
+	stdout, _, err = runDirStack(t, r, "pushd")
+	require.NoError(t, err)
+	assert.Equal(t, "~/a ~/b ~", stdout)
+
+	stdout, _, err = runDirStack(t, r, "popd")
+	require.NoError(t, err)
+	assert.Equal(t, "~/b ~", stdout)
+	assert.Equal(t, filepath.Join(home, "b"), r.Dir)
+
+	stdout, _, err = runDirStack(t, r, "popd")
+	require.NoError(t, err)
+	assert.Equal(t, "~", stdout)
+	assert.Equal(t, home, r.Dir)
+
+	_, stderr, err := runDirStack(t, r, "popd")
+	assert.Error(t, err)
+	assert.Equal(t, "popd: directory stack empty", stderr)
+}
+
+func TestPushdRotate(t *testing.T) {
+	r, home := newDirStackRunner(t)
+	_, _, err := runDirStack(t, r, "pushd a; pushd ../b")
+	require.NoError(t, err)
+
+	stdout, _, err := runDirStack(t, r, "pushd +2")
+	require.NoError(t, err)
+	assert.Equal(t, "~ ~/b ~/a", stdout)
+	assert.Equal(t, home, r.Dir)
+
+	stdout, _, err = runDirStack(t, r, "pushd -0")
+	require.NoError(t, err)
+	assert.Equal(t, "~/a ~ ~/b", stdout)
+
+	stdout, _, err = runDirStack(t, r, "popd +1")
+	require.NoError(t, err)
+	assert.Equal(t, "~/a ~/b", stdout)
+	assert.Equal(t, filepath.Join(home, "a"), r.Dir)
+
+	_, stderr, err := runDirStack(t, r, "pushd +5")
+	assert.Error(t, err)
+	assert.Equal(t, "pushd: +5: directory stack index out of range", stderr)
+}
+
+func TestPushdNoChange(t *testing.T) {
+	r, home := newDirStackRunner(t)
+
+	stdout, _, err := runDirStack(t, r, "pushd -n a")
+	require.NoError(t, err)
+	assert.Equal(t, "~ ~/a", stdout)
+	assert.Equal(t, home, r.Dir)
+
+	stdout, _, err = runDirStack(t, r, "popd -n")
+	require.NoError(t, err)
+	assert.Equal(t, "~", stdout)
+	assert.Equal(t, home, r.Dir)
+}
+
+func TestPushdMissingDirectory(t *testing.T) {
+	r, home := newDirStackRunner(t)
+
+	_, stderr, err := runDirStack(t, r, "pushd missing")
+	assert.Error(t, err)
+	assert.Equal(t, "pushd: missing: No such file or directory", stderr)
+	assert.Equal(t, home, r.Dir)
+
+	_, stderr, err = runDirStack(t, r, "pushd")
+	assert.Error(t, err)
+	assert.Equal(t, "pushd: no other directory", stderr)
+}
+
+func TestDirs(t *testing.T) {
+	r, home := newDirStackRunner(t)
+	_, _, err := runDirStack(t, r, "pushd a; pushd ../b")
+	require.NoError(t, err)
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"dirs", "~/b ~/a ~"},
+		{"dirs -l", strings.Join([]string{filepath.Join(home, "b"), filepath.Join(home, "a"), home}, " ")},
+		{"dirs -p", "~/b\n~/a\n~"},
+		{"dirs -v", " 0  ~/b\n 1  ~/a\n 2  ~"},
+		{"dirs +1", "~/a"},
+		{"dirs -0", "~"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			stdout, _, err := runDirStack(t, r, tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stdout)
+		})
+	}
+
+	// cd replaces the top of the stack
+	stdout, _, err := runDirStack(t, r, `builtin cd .. && bish_cd_hook "$PWD"; dirs`)
+	require.NoError(t, err)
+	assert.Equal(t, "~ ~/a ~", stdout)
+
+	_, _, err = runDirStack(t, r, "dirs -c")
+	require.NoError(t, err)
+	stdout, _, err = runDirStack(t, r, "dirs")
+	require.NoError(t, err)
+	assert.Equal(t, "~", stdout)
+}
@@ -0,0 +1,199 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/dotfiles"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const fnedUsage = "Usage: fned [-s] name"
+
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_:.-]*$`)
+
+// DefaultFunctionsDir returns where fned -s saves functions.
+func DefaultFunctionsDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "bish", "functions")
+}
+
+// NewFnedCommandHandler creates an ExecHandler for the fned builtin, which
+// opens the definition of a shell function in $VISUAL or $EDITOR, a new one
+// if it is not defined, and defines it again once saved. With -s the
+// function is also saved to functionsDir, which bishrc is made to source so
+// that the function is there in every session.
+func NewFnedCommandHandler(functionsDir, bishrc string) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "fned" {
+				return next(ctx, args)
+			}
+			if globalRunner == nil {
+				return fmt.Errorf("fned: runner not initialized")
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			save := false
+			args = args[1:]
+			if len(args) > 0 && (args[0] == "-s" || args[0] == "--save") {
+				save, args = true, args[1:]
+			}
+			if len(args) != 1 || !functionNamePattern.MatchString(args[0]) {
+				fmt.Fprintln(hc.Stderr, fnedUsage)
+				return interp.NewExitStatus(2)
+			}
+			name := args[0]
+			savedPath := filepath.Join(functionsDir, name+".sh")
+
+			original := functionSource(globalRunner, name, savedPath)
+			file, err := os.CreateTemp("", "fned-"+name+"-*.sh")
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			keep := false
+			defer func() {
+				if !keep {
+					_ = os.Remove(file.Name())
+				}
+			}()
+			_, err = file.WriteString(original)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+
+			editor := hc.Env.Get("VISUAL").String()
+			if editor == "" {
+				editor = hc.Env.Get("EDITOR").String()
+			}
+			if strings.TrimSpace(editor) == "" {
+				editor = "vi"
+			}
+			if err := next(ctx, append(strings.Fields(editor), file.Name())); err != nil {
+				if _, ok := interp.IsExitStatus(err); ok {
+					fmt.Fprintf(hc.Stderr, "fned: %s failed; %s is unchanged\n", editor, name)
+				}
+				return err
+			}
+
+			edited, err := os.ReadFile(file.Name())
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if string(edited) == original && !save {
+				fmt.Fprintf(hc.Stdout, "%s is unchanged\n", name)
+				return nil
+			}
+			body, err := parseFunction(edited, name)
+			if err != nil {
+				keep = true
+				fmt.Fprintf(hc.Stderr, "fned: %v; your changes are in %s\n", err, file.Name())
+				return interp.NewExitStatus(1)
+			}
+			if globalRunner.Funcs == nil {
+				globalRunner.Funcs = map[string]*syntax.Stmt{}
+			}
+			globalRunner.Funcs[name] = body
+
+			if !save {
+				fmt.Fprintf(hc.Stdout, "Defined %s\n", name)
+				return nil
+			}
+			if err := os.MkdirAll(functionsDir, 0o755); err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if err := os.WriteFile(savedPath, edited, 0o644); err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if err := ensureFunctionsSourced(bishrc, functionsDir); err != nil {
+				fmt.Fprintf(hc.Stderr, "fned: saved %s, but %v\n", savedPath, err)
+				return interp.NewExitStatus(1)
+			}
+			fmt.Fprintf(hc.Stdout, "Defined %s and saved it to %s\n", name, savedPath)
+			return nil
+		}
+	}
+}
+
+// functionSource returns the text to edit for the function name: the file
+// it was saved to if it is still what is defined, since that keeps its
+// comments, else its definition, or a new function if there is none.
+func functionSource(runner *interp.Runner, name string, savedPath string) string {
+	body := runner.Funcs[name]
+	saved, err := os.ReadFile(savedPath)
+	if err == nil {
+		savedBody, parseErr := parseFunction(saved, name)
+		if parseErr == nil && (body == nil || printNode(savedBody) == printNode(body)) {
+			return string(saved)
+		}
+	}
+	if body == nil {
+		return name + "() {\n\t\n}\n"
+	}
+	return name + "() " + printNode(body) + "\n"
+}
+
+func printNode(node syntax.Node) string {
+	var buf bytes.Buffer
+	_ = syntax.NewPrinter().Print(&buf, node)
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// parseFunction returns the body of the function name that source defines,
+// which must be all it does.
+func parseFunction(source []byte, name string) (*syntax.Stmt, error) {
+	file, err := syntax.NewParser().Parse(bytes.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Stmts) == 1 {
+		if decl, ok := file.Stmts[0].Cmd.(*syntax.FuncDecl); ok && decl.Name.Value == name {
+			return decl.Body, nil
+		}
+	}
+	return nil, errors.New("the file must define the function " + name + " and nothing else")
+}
+
+// ensureFunctionsSourced adds the lines that source the functions in dir to
+// bishrc, in a managed section, unless it sources them already.
+func ensureFunctionsSourced(bishrc, dir string) error {
+	shown := dir
+	if home, err := os.UserHomeDir(); err == nil {
+		if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+			shown = "$HOME/" + filepath.ToSlash(rest)
+		}
+	}
+
+	content, err := os.ReadFile(bishrc)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read %s: %w", bishrc, err)
+	}
+	if strings.Contains(string(content), shown) {
+		return nil
+	}
+
+	snippet := "# Functions saved with fned -s\n" +
+		"for _bish_fn in \"" + shown + "\"/*.sh; do\n" +
+		"  if [ -r \"$_bish_fn\" ]; then source \"$_bish_fn\"; fi\n" +
+		"done\n" +
+		"unset _bish_fn"
+	if _, err := dotfiles.SetBlock(bishrc, "fned", snippet); err != nil {
+		return fmt.Errorf("cannot update %s: %w", bishrc, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// newFnedRunner returns a runner with the fned builtin, where *edit stands
+// in for the editor.
+func newFnedRunner(t *testing.T, functionsDir, bishrc string, edit *func(path string) error) *interp.Runner {
+	t.Helper()
+	editor := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if args[0] != "myedit" {
+				return next(ctx, args)
+			}
+			if err := (*edit)(args[1]); err != nil {
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("EDITOR=myedit")),
+		interp.ExecHandlers(NewFnedCommandHandler(functionsDir, bishrc), editor),
+	)
+	require.NoError(t, err)
+	SetTypesetRunner(runner)
+	t.Cleanup(func() { SetTypesetRunner(nil) })
+	return runner
+}
+
+func runFned(t *testing.T, runner *interp.Runner, script string) (string, string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	interp.StdIO(nil, &out, &errOut)(runner)
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return out.String(), errOut.String(), err
+}
+
+func TestFnedRedefines(t *testing.T) {
+	dir := t.TempDir()
+	var opened string
+	edit := func(path string) error {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		opened = string(content)
+		return os.WriteFile(path, []byte("greet() {\n\techo \"hello, $1\"\n}\n"), 0o600)
+	}
+	runner := newFnedRunner(t, filepath.Join(dir, "functions"), filepath.Join(dir, ".bishrc"), &edit)
+	stdout, _, err := runFned(t, runner, "greet() { echo hi; }\nfned greet\ngreet world")
+	require.NoError(t, err)
+	assert.Equal(t, "greet() { echo hi; }\n", opened)
+	assert.Equal(t, "Defined greet\nhello, world\n", stdout)
+
+	// Nothing is saved without -s
+	_, err = os.Stat(filepath.Join(dir, "functions"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A function that is not defined yet starts empty
+	_, _, err = runFned(t, runner, "fned fresh")
+	assert.Error(t, err)
+	assert.Equal(t, "fresh() {\n\t\n}\n", opened)
+}
+
+func TestFnedSaves(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	functionsDir := filepath.Join(home, ".config", "bish", "functions")
+	bishrc := filepath.Join(home, ".bishrc")
+	require.NoError(t, os.WriteFile(bishrc, []byte("export EDITOR=vim\n"), 0o644))
+
+	saved := "# Makes a directory and goes into it\nmkcd() {\n\tmkdir -p \"$1\" && cd \"$1\"\n}\n"
+	edit := func(path string) error {
+		return os.WriteFile(path, []byte(saved), 0o600)
+	}
+	runner := newFnedRunner(t, functionsDir, bishrc, &edit)
+	stdout, _, err := runFned(t, runner, "fned -s mkcd")
+	require.NoError(t, err)
+	path := filepath.Join(functionsDir, "mkcd.sh")
+	assert.Equal(t, "Defined mkcd and saved it to "+path+"\n", stdout)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, string(content))
+
+	rc, err := os.ReadFile(bishrc)
+	require.NoError(t, err)
+	assert.Regexp(t, `^export EDITOR=vim\n\n# >>> bish:fned [0-9a-f]{8} >>>\n# Functions saved with fned -s\n`, string(rc))
+	assert.Contains(t, string(rc), "for _bish_fn in \"$HOME/.config/bish/functions\"/*.sh; do\n")
+
+	// Editing it again opens the saved file, comments and all, and the
+	// lines sourcing it are added once
+	var opened string
+	edit = func(path string) error {
+		content, err := os.ReadFile(path)
+		opened = string(content)
+		return err
+	}
+	_, _, err = runFned(t, runner, "fned -s mkcd")
+	require.NoError(t, err)
+	assert.Equal(t, saved, opened)
+	again, err := os.ReadFile(bishrc)
+	require.NoError(t, err)
+	assert.Equal(t, string(rc), string(again))
+
+	// A new session sources it from the rc file
+	fresh, err := interp.New(interp.Env(expand.ListEnviron("HOME="+home)), interp.StdIO(nil, nil, nil))
+	require.NoError(t, err)
+	file, err := syntax.NewParser().Parse(bytes.NewReader(again), "")
+	require.NoError(t, err)
+	require.NoError(t, fresh.Run(context.Background(), file))
+	assert.NotNil(t, fresh.Funcs["mkcd"])
+}
+
+func TestFnedErrors(t *testing.T) {
+	dir := t.TempDir()
+	var edit func(path string) error
+	runner := newFnedRunner(t, dir, filepath.Join(dir, ".bishrc"), &edit)
+	_, stderr, err := runFned(t, runner, "fned")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "Usage: fned [-s] name")
+
+	// A syntax error keeps the function as it was, and the changes
+	edit = func(path string) error {
+		return os.WriteFile(path, []byte("greet() {\n\techo hello\n"), 0o600)
+	}
+	stdout, stderr, err := runFned(t, runner, "greet() { echo hi; }\nfned greet || greet")
+	require.NoError(t, err)
+	assert.Equal(t, "hi\n", stdout)
+	_, kept, found := strings.Cut(strings.TrimSpace(stderr), "your changes are in ")
+	require.True(t, found, stderr)
+	content, err := os.ReadFile(kept)
+	require.NoError(t, err)
+	assert.Equal(t, "greet() {\n\techo hello\n", string(content))
+	_ = os.Remove(kept)
+
+	edit = func(path string) error {
+		return os.WriteFile(path, []byte("other() { :; }\n"), 0o600)
+	}
+	_, stderr, err = runFned(t, runner, "fned greet")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "the file must define the function greet and nothing else")
+	if _, kept, found := strings.Cut(strings.TrimSpace(stderr), "your changes are in "); found {
+		_ = os.Remove(kept)
+	}
+
+	edit = func(string) error { return nil }
+	stdout, _, err = runFned(t, runner, "fned greet")
+	require.NoError(t, err)
+	assert.Equal(t, "greet is unchanged\n", stdout)
+
+	edit = func(string) error { return os.ErrInvalid }
+	_, stderr, err = runFned(t, runner, "fned greet")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "fned: myedit failed; greet is unchanged")
+}
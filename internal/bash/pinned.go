@@ -0,0 +1,96 @@
+package bash
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// NewInCommandHandler creates an ExecHandler middleware for in DIR -- CMD...,
+// which runs CMD in DIR without changing the shell's directory. Rewrite turns
+// in into bish_in, which prints a subshell that changes to DIR and runs CMD,
+// so that functions and aliases work and `in DIR -- CMD &` runs in the
+// background like any other command.
+func NewInCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "bish_in" {
+				return next(ctx, args)
+			}
+			hc := interp.HandlerCtx(ctx)
+			return runIn(hc.Stdout, hc.Stderr, hc.Dir, args[1:])
+		}
+	}
+}
+
+// runIn prints the subshell that runs in DIR [--] CMD... from dir, or
+// (exit N) if DIR is not a directory.
+func runIn(out, errOut io.Writer, dir string, args []string) error {
+	target, command, ok := splitPinned(args)
+	if !ok || len(command) == 0 {
+		return dirStackError(out, errOut, 2, "in: usage: in DIR -- COMMAND [ARG...]")
+	}
+	target = resolveDir(dir, target)
+	if info, err := os.Stat(target); err != nil {
+		return dirStackError(out, errOut, 1, "in: "+args[0]+": No such file or directory")
+	} else if !info.IsDir() {
+		return dirStackError(out, errOut, 1, "in: "+args[0]+": Not a directory")
+	}
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	_, _ = fmt.Fprintf(out, "(builtin cd %s && %s)\n", shellQuote(target), strings.Join(quoted, " "))
+	return nil
+}
+
+// splitPinned splits the arguments of in into the directory and the command,
+// which may follow a --.
+func splitPinned(args []string) (string, []string, bool) {
+	if len(args) == 0 {
+		return "", nil, false
+	}
+	command := args[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	return args[0], command, true
+}
+
+// resolveDir returns target, relative to dir if it is not absolute, cleaned.
+func resolveDir(dir, target string) string {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return filepath.Clean(target)
+}
+
+// PinnedDir returns the directory stmt runs in: DIR if it is an
+// in DIR -- CMD... command and DIR exists, or dir otherwise. It is what
+// history records for the command. stmt must not have been rewritten yet.
+func PinnedDir(stmt *syntax.Stmt, dir string, env expand.Environ) string {
+	if stmt == nil {
+		return dir
+	}
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) < 3 || commandName(call) != "in" {
+		return dir
+	}
+	target, err := expand.Literal(&expand.Config{Env: env}, call.Args[1])
+	if err != nil || target == "" {
+		return dir
+	}
+	target = resolveDir(dir, target)
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return dir
+	}
+	return target
+}
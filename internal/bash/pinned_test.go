@@ -0,0 +1,97 @@
+package bash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestIn(t *testing.T) {
+	_, home := newDirStackRunner(t)
+	r, err := interp.New(interp.Env(expand.ListEnviron("HOME="+home)), interp.Dir(home),
+		interp.ExecHandlers(NewInCommandHandler()))
+	require.NoError(t, err)
+
+	stdout, _, err := runDirStack(t, r, "in a -- pwd")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "a"), stdout)
+	assert.Equal(t, home, r.Dir)
+
+	// Without --, and with functions and arguments that need quoting
+	stdout, _, err = runDirStack(t, r, `greet() { echo "$1 from $PWD"; }; in ~/b greet 'it'"'"'s me'`)
+	require.NoError(t, err)
+	assert.Equal(t, "it's me from "+filepath.Join(home, "b"), stdout)
+
+	// The exit status is the command's
+	_, _, err = runDirStack(t, r, "in a -- false")
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), status)
+	assert.Equal(t, home, r.Dir)
+}
+
+func TestInErrors(t *testing.T) {
+	_, home := newDirStackRunner(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "file"), nil, 0644))
+	r, err := interp.New(interp.Dir(home), interp.ExecHandlers(NewInCommandHandler()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		command string
+		status  uint8
+		stderr  string
+	}{
+		{"in missing -- ls", 1, "in: missing: No such file or directory"},
+		{"in file -- ls", 1, "in: file: Not a directory"},
+		{"in a --", 2, "in: usage: in DIR -- COMMAND [ARG...]"},
+		{"in", 2, "in: usage: in DIR -- COMMAND [ARG...]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			_, stderr, err := runDirStack(t, r, tt.command)
+			status, ok := interp.IsExitStatus(err)
+			require.True(t, ok, err)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.stderr, stderr)
+		})
+	}
+}
+
+func TestPinnedDir(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"home/proj/api", "src/web", "work/app", "work/api"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	env := expand.ListEnviron("HOME="+filepath.Join(root, "home"), "SRC="+filepath.Join(root, "src"))
+	workDir := filepath.Join(root, "work/app")
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"in ~/proj/api -- make test", filepath.Join(root, "home/proj/api")},
+		{"in $SRC/web npm test &", filepath.Join(root, "src/web")},
+		{"in ../api -- make", filepath.Join(root, "work/api")},
+		{"in missing -- make", workDir},
+		{"make test", workDir},
+		{"in api", workDir},
+		{"echo in api", workDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			var stmt *syntax.Stmt
+			err := syntax.NewParser().Stmts(strings.NewReader(tt.command), func(s *syntax.Stmt) bool {
+				stmt = s
+				return false
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, PinnedDir(stmt, workDir, env))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// rcProgressDelay is how long a config file may take to load before the
+	// loading indicator shows up.
+	rcProgressDelay = 500 * time.Millisecond
+	// rcProgressInterval is how often the loading indicator is refreshed.
+	rcProgressInterval = 200 * time.Millisecond
+)
+
+// RCTimeoutError is returned by RunConfigFile when a config file takes longer
+// than its time limit. The statement that was running is interrupted and the
+// rest of the file is skipped.
+type RCTimeoutError struct {
+	File    string
+	Line    uint
+	Source  string
+	Timeout time.Duration
+}
+
+func (e *RCTimeoutError) Error() string {
+	return fmt.Sprintf("%s:%d: timed out after %s, skipped the rest of the file: %s", e.File, e.Line, e.Timeout, e.Source)
+}
+
+// ConfigError is an error in a config file, with the line it happened on.
+type ConfigError struct {
+	File string
+	Line uint
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// RunConfigFile runs the config file at filePath one top-level statement at a
+// time, like RunBashScriptFromFile, and interrupts it once it has run for
+// longer than timeout; a timeout of 0 means no limit. If loading takes a
+// while, an indicator naming the file and the line being run is written to
+// progress, which may be nil.
+//
+// Statements the interpreter does not support are reported as ConfigErrors
+// and skipped, so that the rest of the file still loads.
+func RunConfigFile(ctx context.Context, runner *interp.Runner, filePath string, timeout time.Duration, progress io.Writer) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	processedContent := PreprocessTypesetCommands(string(content))
+	prog, err := syntax.NewParser().Parse(strings.NewReader(processedContent), filePath)
+	var parseErr syntax.ParseError
+	if errors.As(err, &parseErr) {
+		return &ConfigError{File: filePath, Line: parseErr.Pos.Line(), Err: errors.New(parseErr.Text)}
+	} else if err != nil {
+		return err
+	}
+	Rewrite(prog)
+
+	// The time limit applies to the statements themselves. The context they
+	// run in is not cancelled once they return, since the background jobs
+	// they start, such as an agent started with cmd &, outlive loading.
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	var line atomic.Uint32
+	stopIndicator := startLoadingIndicator(progress, filePath, &line)
+	defer stopIndicator()
+
+	var errs []error
+	var runErr error
+	for _, stmt := range prog.Stmts {
+		line.Store(uint32(stmt.Pos().Line()))
+		var timedOut bool
+		timedOut, runErr = runBeforeDeadline(ctx, runner, stmt, deadline)
+		if errors.Is(runErr, ErrUnsupported) {
+			errs = append(errs, &ConfigError{File: filePath, Line: stmt.Pos().Line(), Err: runErr})
+			runErr = nil
+			continue
+		}
+		if timedOut {
+			return errors.Join(append(errs, &RCTimeoutError{
+				File:    filePath,
+				Line:    stmt.Pos().Line(),
+				Source:  statementSource(processedContent, stmt),
+				Timeout: timeout,
+			})...)
+		}
+		if runErr != nil {
+			runErr = &ConfigError{File: filePath, Line: stmt.Pos().Line(), Err: runErr}
+			if _, ok := interp.IsExitStatus(runErr); !ok || ShouldExitOnError() {
+				break
+			}
+		}
+		if runner.Exited() {
+			break
+		}
+	}
+	return errors.Join(append(errs, runErr)...)
+}
+
+// runBeforeDeadline runs stmt, and interrupts it if it is still running at
+// deadline, unless deadline is zero. It reports whether it interrupted it.
+func runBeforeDeadline(ctx context.Context, runner *interp.Runner, stmt *syntax.Stmt, deadline time.Time) (bool, error) {
+	if deadline.IsZero() {
+		return false, Run(ctx, runner, stmt)
+	}
+	// Jobs the statement starts with & run under stmtCtx and must outlive
+	// it, so stmtCtx is not cancelled when the statement returns. It is
+	// detached from ctx instead, with ctx and the deadline cancelling it
+	// only while the statement runs, so that nothing holds on to it after.
+	var timedOut atomic.Bool
+	stmtCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	timer := time.AfterFunc(time.Until(deadline), func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+	err := Run(stmtCtx, runner, stmt)
+	return err != nil && timedOut.Load(), err
+}
+
+// startLoadingIndicator shows which line of name is loading on progress once
+// loading takes longer than rcProgressDelay. The returned function removes
+// the indicator again.
+func startLoadingIndicator(progress io.Writer, name string, line *atomic.Uint32) func() {
+	if progress == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		timer := time.NewTimer(rcProgressDelay)
+		defer timer.Stop()
+		shown := false
+		for {
+			select {
+			case <-done:
+				if shown {
+					_, _ = fmt.Fprint(progress, "\r\033[K")
+				}
+				return
+			case <-timer.C:
+				shown = true
+				_, _ = fmt.Fprintf(progress, "\r\033[Kbish: loading %s:%d (%s)", name, line.Load(), formatReportDuration(time.Since(start)))
+				timer.Reset(rcProgressInterval)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,98 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runConfig(t *testing.T, content string, timeout time.Duration, progress io.Writer) (string, string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".bishrc")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	var out bytes.Buffer
+	runner, err := interp.New(interp.StdIO(nil, &out, &out))
+	assert.NoError(t, err)
+	err = RunConfigFile(context.Background(), runner, path, timeout, progress)
+	return path, out.String(), err
+}
+
+func TestRunConfigFile(t *testing.T) {
+	_, out, err := runConfig(t, "greet() { echo hi; }\nfalse\ngreet\n", time.Second, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi\n", out)
+}
+
+func TestRunConfigFileTimeout(t *testing.T) {
+	var progress bytes.Buffer
+	path, out, err := runConfig(t, "echo before\nwhile :; do :; done\necho after\n", 800*time.Millisecond, &progress)
+
+	var timeoutErr *RCTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, uint(2), timeoutErr.Line)
+	assert.Equal(t, "while :; do :; done", timeoutErr.Source)
+	assert.Contains(t, err.Error(), path+":2: timed out after 800ms")
+	assert.Equal(t, "before\n", out)
+
+	assert.Contains(t, progress.String(), "bish: loading "+path+":2")
+	assert.Regexp(t, "\r\033\\[K$", progress.String())
+}
+
+func TestRunConfigFileFastHasNoIndicator(t *testing.T) {
+	var progress bytes.Buffer
+	_, _, err := runConfig(t, "x=1\n", time.Second, &progress)
+	assert.NoError(t, err)
+	assert.Empty(t, progress.String())
+}
+
+func TestRunConfigFileErrorsHaveLines(t *testing.T) {
+	path, out, err := runConfig(t, "exec 3>&1\necho loaded\nfalse\n", time.Second, nil)
+	assert.Equal(t, "loaded\n", out)
+
+	var configErr *ConfigError
+	assert.True(t, errors.As(err, &configErr))
+	assert.Equal(t, uint(1), configErr.Line)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.Contains(t, err.Error(), path+":3: exit status 1")
+
+	path, _, err = runConfig(t, "echo ok\nif then\n", time.Second, nil)
+	assert.True(t, errors.As(err, &configErr))
+	assert.Equal(t, path, configErr.File)
+	assert.Equal(t, uint(2), configErr.Line)
+}
+
+func TestRunConfigFileKeepsBackgroundJobs(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	_, _, err := runConfig(t, "(sleep 0.2; echo > "+marker+") &\n", time.Second, nil)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(marker)
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond, "jobs started with & outlive loading")
+}
+
+func TestRunBeforeDeadlineFollowsContext(t *testing.T) {
+	runner, err := interp.New(interp.StdIO(nil, io.Discard, io.Discard))
+	assert.NoError(t, err)
+	file, err := syntax.NewParser().Parse(strings.NewReader("while :; do :; done"), "")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	timedOut, err := runBeforeDeadline(ctx, runner, file.Stmts[0], time.Now().Add(time.Minute))
+	assert.Error(t, err)
+	assert.False(t, timedOut, "cancelled by ctx, not the deadline")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -0,0 +1,224 @@
+package bash
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxReportSourceWidth caps how much of a statement's source is echoed in
+// progress lines and the final report.
+const maxReportSourceWidth = 60
+
+// StatementResult records the outcome of a single top-level statement executed
+// by RunBashScriptWithReport.
+type StatementResult struct {
+	Index    int // 1-based position of the statement in the script
+	Line     uint
+	Source   string
+	Duration time.Duration
+	ExitCode int
+	// Retry is true when the same statement already failed earlier in the run.
+	Retry bool
+}
+
+// ScriptReport summarizes a script run executed statement by statement.
+type ScriptReport struct {
+	Name       string
+	Total      int
+	Elapsed    time.Duration
+	Statements []StatementResult
+}
+
+// RunBashScriptFileWithReport runs the script at filePath like RunBashScriptFromFile,
+// printing progress to progress and returning a report of the run.
+func RunBashScriptFileWithReport(ctx context.Context, runner *interp.Runner, filePath string, progress io.Writer) (*ScriptReport, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return RunBashScriptWithReport(ctx, runner, f, filePath, progress)
+}
+
+// RunBashScriptWithReport executes a script one top-level statement at a time so
+// that each statement can be timed. Before each statement a progress line with its
+// index, the total count and the elapsed time is written to progress.
+//
+// Execution follows the same rules as RunBashScriptFromReader: failing statements
+// do not stop the run unless 'set -e' is active, and 'exit' ends it. The returned
+// error is the exit status of the last statement executed.
+func RunBashScriptWithReport(ctx context.Context, runner *interp.Runner, reader io.Reader, name string, progress io.Writer) (*ScriptReport, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	processedContent := PreprocessTypesetCommands(string(content))
+
+	prog, err := syntax.NewParser().Parse(strings.NewReader(processedContent), name)
+	if err != nil {
+		return nil, err
+	}
+	Rewrite(prog)
+
+	report := &ScriptReport{
+		Name:  name,
+		Total: len(prog.Stmts),
+	}
+
+	failed := make(map[string]bool)
+	start := time.Now()
+
+	var runErr error
+	for i, stmt := range prog.Stmts {
+		source := statementSource(processedContent, stmt)
+		if progress != nil {
+			_, _ = fmt.Fprintf(progress, "[%d/%d] %s  %s\n", i+1, report.Total, formatReportDuration(time.Since(start)), source)
+		}
+
+		stmtStart := time.Now()
+		runErr = Run(ctx, runner, stmt)
+		result := StatementResult{
+			Index:    i + 1,
+			Line:     stmt.Pos().Line(),
+			Source:   source,
+			Duration: time.Since(stmtStart),
+			Retry:    failed[source],
+		}
+
+		if runErr != nil {
+			status, ok := interp.IsExitStatus(runErr)
+			if !ok {
+				// Not an exit status (e.g. context cancellation); abort the run.
+				result.ExitCode = -1
+				report.Statements = append(report.Statements, result)
+				report.Elapsed = time.Since(start)
+				return report, runErr
+			}
+			result.ExitCode = int(status)
+		}
+		report.Statements = append(report.Statements, result)
+
+		if result.ExitCode != 0 {
+			failed[source] = true
+			if progress != nil {
+				_, _ = fmt.Fprintf(progress, "[%d/%d] failed with exit code %d after %s\n", i+1, report.Total, result.ExitCode, formatReportDuration(result.Duration))
+			}
+			if ShouldExitOnError() {
+				break
+			}
+		}
+
+		if runner.Exited() {
+			break
+		}
+	}
+
+	report.Elapsed = time.Since(start)
+	return report, runErr
+}
+
+// Failures returns the statements that exited with a non-zero status, in execution order.
+func (r *ScriptReport) Failures() []StatementResult {
+	var failures []StatementResult
+	for _, s := range r.Statements {
+		if s.ExitCode != 0 {
+			failures = append(failures, s)
+		}
+	}
+	return failures
+}
+
+// Retries returns the statements that re-ran a statement which had already failed.
+func (r *ScriptReport) Retries() []StatementResult {
+	var retries []StatementResult
+	for _, s := range r.Statements {
+		if s.Retry {
+			retries = append(retries, s)
+		}
+	}
+	return retries
+}
+
+// Slowest returns up to n statements ordered by decreasing duration.
+func (r *ScriptReport) Slowest(n int) []StatementResult {
+	sorted := make([]StatementResult, len(r.Statements))
+	copy(sorted, r.Statements)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// Write prints a human-readable end-of-run summary to w.
+func (r *ScriptReport) Write(w io.Writer) {
+	failures := r.Failures()
+	retries := r.Retries()
+
+	_, _ = fmt.Fprintf(w, "\n==== bish run report: %s ====\n", r.Name)
+	_, _ = fmt.Fprintf(w, "Statements: %d of %d executed, %d failed, %d retried\n",
+		len(r.Statements), r.Total, len(failures), len(retries))
+	_, _ = fmt.Fprintf(w, "Total time: %s\n", formatReportDuration(r.Elapsed))
+
+	if slowest := r.Slowest(5); len(slowest) > 0 {
+		_, _ = fmt.Fprintln(w, "\nSlowest statements:")
+		for _, s := range slowest {
+			_, _ = fmt.Fprintf(w, "  %8s  line %-4d %s\n", formatReportDuration(s.Duration), s.Line, s.Source)
+		}
+	}
+
+	if len(failures) > 0 {
+		_, _ = fmt.Fprintln(w, "\nFailures:")
+		for _, s := range failures {
+			_, _ = fmt.Fprintf(w, "  exit %-3d  line %-4d %s\n", s.ExitCode, s.Line, s.Source)
+		}
+	}
+
+	if len(retries) > 0 {
+		_, _ = fmt.Fprintln(w, "\nRetried statements:")
+		for _, s := range retries {
+			_, _ = fmt.Fprintf(w, "  exit %-3d  line %-4d %s\n", s.ExitCode, s.Line, s.Source)
+		}
+	}
+}
+
+// statementSource returns a single-line, truncated rendering of a statement's
+// source text for display.
+func statementSource(content string, stmt *syntax.Stmt) string {
+	start, end := int(stmt.Pos().Offset()), int(stmt.End().Offset())
+	if start < 0 || end > len(content) || start >= end {
+		return ""
+	}
+
+	source := strings.Join(strings.Fields(content[start:end]), " ")
+	runes := []rune(source)
+	if len(runes) > maxReportSourceWidth {
+		source = string(runes[:maxReportSourceWidth-3]) + "..."
+	}
+	return source
+}
+
+// formatReportDuration renders durations with a precision suited to the magnitude.
+func formatReportDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(10 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
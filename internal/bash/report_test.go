@@ -0,0 +1,95 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestRunBashScriptWithReport(t *testing.T) {
+	var out, progress bytes.Buffer
+	r, err := interp.New(interp.StdIO(nil, &out, &out))
+	require.NoError(t, err)
+
+	script := "echo one\nfalse\necho two\nfalse\n"
+	report, err := RunBashScriptWithReport(context.Background(), r, strings.NewReader(script), "test.sh", &progress)
+	require.NotNil(t, report)
+
+	status, ok := interp.IsExitStatus(err)
+	assert.True(t, ok, "expected exit status error from last statement")
+	assert.Equal(t, uint8(1), status)
+
+	assert.Equal(t, "one\ntwo\n", out.String())
+	assert.Equal(t, 4, report.Total)
+	require.Len(t, report.Statements, 4)
+	assert.Equal(t, uint(2), report.Statements[1].Line)
+	assert.Equal(t, "false", report.Statements[1].Source)
+
+	failures := report.Failures()
+	require.Len(t, failures, 2)
+	assert.Equal(t, 2, failures[0].Index)
+	assert.Equal(t, 4, failures[1].Index)
+
+	retries := report.Retries()
+	require.Len(t, retries, 1)
+	assert.Equal(t, 4, retries[0].Index)
+
+	assert.Contains(t, progress.String(), "[1/4]")
+	assert.Contains(t, progress.String(), "[4/4]")
+	assert.Contains(t, progress.String(), "failed with exit code 1")
+
+	var summary bytes.Buffer
+	report.Write(&summary)
+	assert.Contains(t, summary.String(), "4 of 4 executed, 2 failed, 1 retried")
+	assert.Contains(t, summary.String(), "Slowest statements:")
+}
+
+func TestRunBashScriptWithReport_StopsOnExit(t *testing.T) {
+	var out bytes.Buffer
+	r, err := interp.New(interp.StdIO(nil, &out, &out))
+	require.NoError(t, err)
+
+	script := "echo before\nexit 3\necho after\n"
+	report, err := RunBashScriptWithReport(context.Background(), r, strings.NewReader(script), "exit.sh", nil)
+	require.NotNil(t, report)
+
+	status, ok := interp.IsExitStatus(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(3), status)
+	assert.Equal(t, "before\n", out.String())
+	assert.Len(t, report.Statements, 2)
+	assert.Equal(t, 3, report.Total)
+}
+
+func TestScriptReport_Slowest(t *testing.T) {
+	report := &ScriptReport{
+		Statements: []StatementResult{
+			{Index: 1, Duration: 10},
+			{Index: 2, Duration: 30},
+			{Index: 3, Duration: 20},
+		},
+	}
+
+	slowest := report.Slowest(2)
+	require.Len(t, slowest, 2)
+	assert.Equal(t, 2, slowest[0].Index)
+	assert.Equal(t, 3, slowest[1].Index)
+	assert.Len(t, report.Slowest(10), 3)
+}
+
+func TestStatementSourceTruncation(t *testing.T) {
+	long := "echo " + strings.Repeat("x", 100)
+	r, err := interp.New(interp.StdIO(nil, &bytes.Buffer{}, &bytes.Buffer{}))
+	require.NoError(t, err)
+
+	report, err := RunBashScriptWithReport(context.Background(), r, strings.NewReader(long), "long.sh", nil)
+	require.NoError(t, err)
+	require.Len(t, report.Statements, 1)
+	assert.Len(t, []rune(report.Statements[0].Source), maxReportSourceWidth)
+	assert.True(t, strings.HasSuffix(report.Statements[0].Source, "..."))
+}
@@ -0,0 +1,370 @@
+package bash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Scripts written for bash, like bash_completion or nvm.sh, are sourced from
+// rc files but use constructs the interpreter cannot run, and a single one
+// used to abort the whole file. Rewrite turns source F into
+//
+//	{ builtin source "$(bish_source F)"; bish_source_done $?; }
+//
+// bish_source parses F up to its first syntax error, replaces what cannot run
+// by bish_skip calls and returns the path of the result. bish_source_done
+// then reports what was skipped, once per file.
+
+// unsupportedBuiltins are the bash builtins neither the interpreter nor bish
+// implement. Sourced scripts mostly use them for interactive niceties, so they
+// are skipped rather than failing.
+var unsupportedBuiltins = map[string]bool{
+	"bind":    true,
+	"caller":  true,
+	"compopt": true,
+	"disown":  true,
+	"enable":  true,
+	"help":    true,
+	"logout":  true,
+	"suspend": true,
+	"times":   true,
+	"ulimit":  true,
+	"umask":   true,
+}
+
+// supportedShoptOptions are the shopt options the interpreter implements.
+var supportedShoptOptions = map[string]bool{
+	"expand_aliases": true,
+	"globstar":       true,
+	"nocaseglob":     true,
+	"nullglob":       true,
+}
+
+// sourceRecord collects what was skipped while sourcing a file.
+type sourceRecord struct {
+	path  string
+	kinds []string
+	skips map[string]int
+}
+
+func (rec *sourceRecord) skip(kind string) {
+	if rec.skips[kind] == 0 {
+		rec.kinds = append(rec.kinds, kind)
+	}
+	rec.skips[kind]++
+}
+
+// summary returns the one line report of rec, or "" if nothing was skipped.
+func (rec *sourceRecord) summary() string {
+	if len(rec.kinds) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(rec.kinds))
+	for _, kind := range rec.kinds {
+		if count := rec.skips[kind]; count > 1 {
+			parts = append(parts, fmt.Sprintf("%s (%d)", kind, count))
+		} else {
+			parts = append(parts, kind)
+		}
+	}
+	return fmt.Sprintf("bish: %s: skipped unsupported %s", rec.path, strings.Join(parts, ", "))
+}
+
+// sourceStack holds a record per file being sourced, innermost last.
+// warnedSkips holds the kinds already warned about outside of a source.
+var (
+	sourceMu    sync.Mutex
+	sourceStack []*sourceRecord
+	warnedSkips = map[string]bool{}
+)
+
+func pushSource(path string) *sourceRecord {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	rec := &sourceRecord{path: path, skips: map[string]int{}}
+	sourceStack = append(sourceStack, rec)
+	return rec
+}
+
+func popSource() *sourceRecord {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	if len(sourceStack) == 0 {
+		return nil
+	}
+	rec := sourceStack[len(sourceStack)-1]
+	sourceStack = sourceStack[:len(sourceStack)-1]
+	return rec
+}
+
+// rewriteSource turns source F args into the bish_source block above.
+func rewriteSource(stmt *syntax.Stmt, cmd *syntax.CallExpr) {
+	if len(cmd.Args) < 2 || len(cmd.Assigns) > 0 {
+		return
+	}
+	path := &syntax.Word{Parts: []syntax.WordPart{&syntax.DblQuoted{Parts: []syntax.WordPart{
+		&syntax.CmdSubst{Stmts: []*syntax.Stmt{{Cmd: callWith("bish_source", cmd.Args[1:2])}}},
+	}}}}
+	source := callWith("builtin", append([]*syntax.Word{litWord("source"), path}, cmd.Args[2:]...))
+	done := callWith("bish_source_done", []*syntax.Word{{Parts: []syntax.WordPart{
+		&syntax.ParamExp{Short: true, Param: &syntax.Lit{Value: "?"}},
+	}}})
+	stmt.Cmd = &syntax.Block{Stmts: []*syntax.Stmt{{Cmd: source}, {Cmd: done}}}
+}
+
+// prepareSource prints the path of the runnable version of the file name
+// refers to. If it cannot be read, name is printed as is so that source
+// reports the error.
+func prepareSource(out io.Writer, dir string, env expand.Environ, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: bish_source file")
+	}
+	name := args[0]
+	path := sourcePath(dir, env, name)
+	rec := pushSource(name)
+
+	prepared, err := shimSourceFile(path, rec)
+	if err != nil {
+		prepared = name
+	}
+	_, _ = fmt.Fprintln(out, prepared)
+	return nil
+}
+
+// sourcePath resolves name like bash's source does: names without a slash
+// are looked up in PATH first.
+func sourcePath(dir string, env expand.Environ, name string) string {
+	if !strings.Contains(name, "/") {
+		for _, elem := range filepath.SplitList(env.Get("PATH").String()) {
+			if elem == "" {
+				continue
+			}
+			candidate := filepath.Join(elem, name)
+			if !filepath.IsAbs(candidate) {
+				candidate = filepath.Join(dir, candidate)
+			}
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				return candidate
+			}
+		}
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// shimRoot returns the directory the shims of this process are written to.
+// It is made anew with a random name, so that no other user of the machine
+// can have made it first and swap the scripts that are sourced from it.
+var shimRoot = sync.OnceValues(func() (string, error) {
+	shimRootMade.Store(true)
+	return os.MkdirTemp("", "bish-source-")
+})
+
+// shimRootMade tells whether shimRoot was called, so that cleaning up does
+// not make the directory only to remove it.
+var shimRootMade atomic.Bool
+
+// RemoveSourceShims deletes the shims written while sourcing files. The
+// shell calls it on exit.
+func RemoveSourceShims() {
+	if !shimRootMade.Load() {
+		return
+	}
+	if root, err := shimRoot(); err == nil {
+		_ = os.RemoveAll(root)
+	}
+}
+
+// shimSourceFile writes the statements of path that parse, with the
+// unsupported ones skipped, to a file in a private temp directory and
+// returns its path. It is named after path so that errors still point at the script.
+func shimSourceFile(path string, rec *sourceRecord) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	file := &syntax.File{Name: path}
+	err = syntax.NewParser().Stmts(strings.NewReader(string(content)), func(stmt *syntax.Stmt) bool {
+		file.Stmts = append(file.Stmts, stmt)
+		return true
+	})
+	var parseErr syntax.ParseError
+	if errors.As(err, &parseErr) {
+		rec.skip(fmt.Sprintf("syntax from line %d on", parseErr.Pos.Line()))
+	} else if err != nil {
+		return "", err
+	}
+
+	shimUnsupported(file)
+	Rewrite(file)
+
+	var script strings.Builder
+	if err := syntax.NewPrinter().Print(&script, file); err != nil {
+		return "", err
+	}
+	root, err := shimRoot()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path + "\x00" + script.String()))
+	shimDir := filepath.Join(root, hex.EncodeToString(sum[:8]))
+	if err := os.MkdirAll(shimDir, 0o700); err != nil {
+		return "", err
+	}
+	shimPath := filepath.Join(shimDir, filepath.Base(path))
+	if err := os.WriteFile(shimPath, []byte(script.String()), 0o600); err != nil {
+		return "", err
+	}
+	return shimPath, nil
+}
+
+// shimUnsupported replaces the statements of node that the interpreter
+// cannot run by bish_skip calls:
+//   - unsupported builtins
+//   - shopt options the interpreter does not know
+//   - redirections of file descriptors other than 0, 1 and 2, which crash it
+//   - wait for a specific job, which becomes a plain wait
+func shimUnsupported(node syntax.Node) {
+	syntax.Walk(node, func(node syntax.Node) bool {
+		stmt, ok := node.(*syntax.Stmt)
+		if !ok {
+			return true
+		}
+		if fd := unsupportedRedirect(stmt); fd != "" {
+			stmt.Cmd = skipCall("redirection of fd " + fd)
+			stmt.Redirs = nil
+			return false
+		}
+		cmd, ok := stmt.Cmd.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		switch name := commandName(cmd); {
+		case unsupportedBuiltins[name]:
+			stmt.Cmd = skipCall(name)
+		case name == "shopt":
+			shimShopt(stmt, cmd)
+		case name == "wait" && len(cmd.Args) > 1:
+			cmd.Args = cmd.Args[:1]
+			stmt.Cmd = &syntax.Block{Stmts: []*syntax.Stmt{{Cmd: skipCall("wait for a job")}, {Cmd: cmd}}}
+		}
+		return true
+	})
+}
+
+// unsupportedRedirect returns the file descriptor of the first redirection
+// of stmt the interpreter cannot do, or "".
+func unsupportedRedirect(stmt *syntax.Stmt) string {
+	for _, rd := range stmt.Redirs {
+		if rd.N != nil && rd.N.Value != "0" && rd.N.Value != "1" && rd.N.Value != "2" {
+			return rd.N.Value
+		}
+		target := rd.Word.Lit()
+		switch rd.Op {
+		case syntax.DplOut:
+			if target != "1" && target != "2" && target != "-" {
+				return fdName(target)
+			}
+		case syntax.DplIn:
+			if target != "-" {
+				return fdName(target)
+			}
+		}
+	}
+	return ""
+}
+
+// fdName names the file descriptor a duplication like >&3- refers to.
+func fdName(target string) string {
+	if target == "" {
+		return "set at runtime"
+	}
+	return strings.TrimSuffix(target, "-")
+}
+
+// shimShopt drops the options the interpreter does not know from a shopt -s
+// or -u and skips them.
+func shimShopt(stmt *syntax.Stmt, cmd *syntax.CallExpr) {
+	var unknown []string
+	args := cmd.Args[:1]
+	options := 0
+	for _, arg := range cmd.Args[1:] {
+		name := arg.Lit()
+		switch {
+		case strings.HasPrefix(name, "-"):
+			args = append(args, arg)
+		case name == "" || supportedShoptOptions[name]:
+			args = append(args, arg)
+			options++
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return
+	}
+	skip := &syntax.Stmt{Cmd: skipCall("shopt " + strings.Join(unknown, " "))}
+	if options == 0 {
+		stmt.Cmd = skip.Cmd
+		return
+	}
+	cmd.Args = args
+	stmt.Cmd = &syntax.Block{Stmts: []*syntax.Stmt{skip, {Cmd: cmd}}}
+}
+
+// skipCall returns bish_skip 'kind'.
+func skipCall(kind string) *syntax.CallExpr {
+	return callWith("bish_skip", []*syntax.Word{{Parts: []syntax.WordPart{&syntax.SglQuoted{Value: kind}}}})
+}
+
+// recordSkip counts a skipped construct against the file being sourced.
+// Outside of a source, e.g. in a function defined by a sourced script, it
+// warns once per kind instead.
+func recordSkip(errOut io.Writer, args []string) error {
+	kind := strings.Join(args, " ")
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	if len(sourceStack) > 0 {
+		sourceStack[len(sourceStack)-1].skip(kind)
+		return nil
+	}
+	if !warnedSkips[kind] {
+		warnedSkips[kind] = true
+		_, _ = fmt.Fprintf(errOut, "bish: %s is not supported, skipped\n", kind)
+	}
+	return nil
+}
+
+// finishSource reports what was skipped in the file sourced last and exits
+// with the status of the source.
+func finishSource(errOut io.Writer, args []string) error {
+	if rec := popSource(); rec != nil {
+		if summary := rec.summary(); summary != "" {
+			_, _ = fmt.Fprintln(errOut, summary)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	status, err := strconv.Atoi(args[0])
+	if err != nil || status == 0 {
+		return nil
+	}
+	return interp.NewExitStatus(uint8(status))
+}
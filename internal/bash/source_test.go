@@ -0,0 +1,111 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func writeSourced(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "init.sh")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestSourceSkipsUnsupportedBuiltins(t *testing.T) {
+	path := writeSourced(t, `
+bind '"\e[A": history-search-backward'
+shopt -s extglob nullglob
+compopt -o nospace
+compopt -o filenames
+exec 3>&1
+echo hidden >&3
+wait %1
+greet() { echo "hello $1"; }
+LOADED=yes
+`)
+	out := runCompatScript(t, "source "+path+" && greet world; echo $LOADED; shopt nullglob")
+	assert.Contains(t, out, "hello world\nyes\nnullglob\ton\n")
+	assert.Contains(t, out, "bish: "+path+": skipped unsupported bind, shopt extglob, compopt (2), redirection of fd 3 (2), wait for a job\n")
+	assert.NotContains(t, out, "hidden")
+}
+
+func TestSourceKeepsStatementsBeforeSyntaxError(t *testing.T) {
+	path := writeSourced(t, "BEFORE=1\nif then fi\nAFTER=1\n")
+	out := runCompatScript(t, ". "+path+"; echo ${BEFORE:-unset} ${AFTER:-unset}")
+	assert.Contains(t, out, "bish: "+path+": skipped unsupported syntax from line 2 on\n")
+	assert.Contains(t, out, "1 unset\n")
+}
+
+func TestSourceWithoutSkipsIsQuiet(t *testing.T) {
+	path := writeSourced(t, "echo \"args: $*\"\nreturn 3\n")
+	out := runCompatScript(t, "source "+path+" a b; echo status $?")
+	assert.Equal(t, "args: a b\nstatus 3\n", out)
+}
+
+func TestSourceRunsFc(t *testing.T) {
+	// fc is a bish command now, so sourced files reach it like any other
+	fc := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if args[0] != "fc" {
+				return next(ctx, args)
+			}
+			hc := interp.HandlerCtx(ctx)
+			_, _ = hc.Stdout.Write([]byte("fc ran: " + strings.Join(args[1:], " ") + "\n"))
+			return nil
+		}
+	}
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("PATH=/usr/bin:/bin", "HOME=/home/test")),
+		interp.StdIO(nil, &out, &out),
+		interp.ExecHandlers(fc, NewTypesetCommandHandler(), NewCompatCommandHandler()),
+	)
+	assert.NoError(t, err)
+	SetTypesetRunner(runner)
+
+	path := writeSourced(t, "fc -l -5\nLOADED=yes\n")
+	_ = RunBashScriptFromReader(context.Background(), runner, strings.NewReader("source "+path+"; echo $LOADED"), "source")
+	assert.Equal(t, "fc ran: -l -5\nyes\n", out.String())
+}
+
+func TestSourceMissingFile(t *testing.T) {
+	out := runCompatScript(t, "source /nonexistent/file.sh; echo status $?")
+	assert.Contains(t, out, "/nonexistent/file.sh")
+	assert.Contains(t, out, "status 1\n")
+}
+
+func TestSourceShimIsPrivate(t *testing.T) {
+	path := writeSourced(t, "bind -x '\"\\C-t\": fzf'\nFOO=1\n")
+	shim, err := shimSourceFile(path, &sourceRecord{})
+	assert.NoError(t, err)
+	root, err := shimRoot()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(shim, root+string(filepath.Separator)))
+	info, err := os.Stat(root)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm(), "other users cannot write the shims")
+	assert.NotEqual(t, filepath.Join(os.TempDir(), "bish-source"), root, "the directory is not one another user can make first")
+}
+
+func TestRemoveSourceShims(t *testing.T) {
+	path := writeSourced(t, "FOO=1\n")
+	shim, err := shimSourceFile(path, &sourceRecord{})
+	assert.NoError(t, err)
+	root, err := shimRoot()
+	assert.NoError(t, err)
+
+	RemoveSourceShims()
+	_, err = os.Stat(shim)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(root)
+	assert.True(t, os.IsNotExist(err))
+}
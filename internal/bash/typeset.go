@@ -3,18 +3,15 @@ package bash
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
+	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
 )
 
-// For testing purposes
-var typesetPrintf = func(format string, a ...any) (int, error) {
-	return fmt.Printf(format, a...)
-}
-
 // Global runner reference that can be set after initialization
 // NOTE: This global variable pattern is intentionally used here due to the constraints
 // of the interp.ExecHandlerFunc signature, which doesn't allow passing additional context.
@@ -51,17 +48,27 @@ func NewTypesetCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHan
 			}
 
 			// Now we have access to the runner, so we can implement the real functionality
-			return handleTypesetCommand(globalRunner, args)
+			out, errOut, env := handlerIO(ctx)
+			return handleTypesetCommand(globalRunner, env, out, errOut, args)
 		}
 	}
 }
 
-func handleTypesetCommand(runner *interp.Runner, args []string) error {
+// handlerIO returns where a handler should write its output and errors, and
+// the variables it sees.
+func handlerIO(ctx context.Context) (out, errOut io.Writer, env expand.Environ) {
+	hc := interp.HandlerCtx(ctx)
+	return hc.Stdout, hc.Stderr, hc.Env
+}
+
+func handleTypesetCommand(runner *interp.Runner, env expand.Environ, out, errOut io.Writer, args []string) error {
 	// Parse options - skip the command name (args[0])
 	var (
-		listFunctions     bool // -f: list function definitions
-		listFunctionNames bool // -F: list function names only
-		listVariables     bool // -p: list variables with attributes
+		listFunctions     bool   // -f: list function definitions
+		listFunctionNames bool   // -F: list function names only
+		listVariables     bool   // -p: list variables with attributes
+		attributes        string // -aAinrx: only list variables with these attributes
+		names             []string
 	)
 
 	// If no options provided, default to listing variables
@@ -73,7 +80,8 @@ func handleTypesetCommand(runner *interp.Runner, args []string) error {
 	for i := 1; i < len(args); i++ {
 		arg := args[i]
 		if !strings.HasPrefix(arg, "-") {
-			// Non-option argument, stop parsing options
+			// Non-option arguments are the variables to list
+			names = args[i:]
 			break
 		}
 
@@ -86,6 +94,8 @@ func handleTypesetCommand(runner *interp.Runner, args []string) error {
 				listFunctionNames = true
 			case 'p':
 				listVariables = true
+			case 'a', 'A', 'i', 'n', 'r', 'x':
+				attributes += string(ch)
 			default:
 				return fmt.Errorf("typeset: -%c: invalid option", ch)
 			}
@@ -99,33 +109,50 @@ func handleTypesetCommand(runner *interp.Runner, args []string) error {
 
 	// Handle function listing
 	if listFunctions {
-		return printFunctionDefinitions(runner)
+		return printFunctionDefinitions(runner, out, names)
 	}
 
 	if listFunctionNames {
-		return printFunctionNames(runner)
+		return printFunctionNames(runner, out, names)
 	}
 
 	// Handle variable listing
 	if listVariables {
-		return printVariables(runner)
+		return printVariables(env, out, errOut, attributes, names)
 	}
 
 	return nil
 }
 
-// printFunctionDefinitions prints all function definitions in bash-compatible format
-func printFunctionDefinitions(runner *interp.Runner) error {
-	if runner.Funcs == nil {
-		return nil
+// functionNames returns the sorted names of all functions, or those of
+// requested that are defined. Like in bash, it is an error if one of
+// requested is not.
+func functionNames(runner *interp.Runner, requested []string) ([]string, error) {
+	if len(requested) > 0 {
+		var names []string
+		var err error
+		for _, name := range requested {
+			if runner.Funcs[name] == nil {
+				err = interp.NewExitStatus(1)
+				continue
+			}
+			names = append(names, name)
+		}
+		return names, err
 	}
 
-	// Get all function names and sort them
 	names := make([]string, 0, len(runner.Funcs))
 	for name := range runner.Funcs {
 		names = append(names, name)
 	}
 	sort.Strings(names)
+	return names, nil
+}
+
+// printFunctionDefinitions prints the function definitions in bash-compatible
+// format; all of them, or the named ones.
+func printFunctionDefinitions(runner *interp.Runner, out io.Writer, requested []string) error {
+	names, err := functionNames(runner, requested)
 
 	// Print each function definition
 	for _, name := range names {
@@ -135,19 +162,19 @@ func printFunctionDefinitions(runner *interp.Runner) error {
 		}
 
 		// Format: function_name () { body }
-		_, _ = typesetPrintf("%s () {\n", name)
+		_, _ = fmt.Fprintf(out, "%s () {\n", name)
 
 		// Print the function body
-		printFunctionBody(fn)
+		printFunctionBody(out, fn)
 
-		_, _ = typesetPrintf("}\n")
+		_, _ = fmt.Fprintf(out, "}\n")
 	}
 
-	return nil
+	return err
 }
 
 // printFunctionBody prints the statements in a function body
-func printFunctionBody(fn *syntax.Stmt) {
+func printFunctionBody(out io.Writer, fn *syntax.Stmt) {
 	if fn == nil {
 		return
 	}
@@ -161,64 +188,167 @@ func printFunctionBody(fn *syntax.Stmt) {
 	lines := strings.Split(buf.String(), "\n")
 	for _, line := range lines {
 		if line != "" {
-			_, _ = typesetPrintf("    %s\n", line)
+			_, _ = fmt.Fprintf(out, "    %s\n", line)
 		}
 	}
 }
 
-// printFunctionNames prints just the function names (one per line)
-func printFunctionNames(runner *interp.Runner) error {
-	if runner.Funcs == nil {
-		return nil
-	}
-
-	// Get all function names and sort them
-	names := make([]string, 0, len(runner.Funcs))
-	for name := range runner.Funcs {
-		names = append(names, name)
-	}
-	sort.Strings(names)
+// printFunctionNames prints just the function names (one per line). Like
+// bash, it prints the named functions bare, e.g. for declare -F name.
+func printFunctionNames(runner *interp.Runner, out io.Writer, requested []string) error {
+	names, err := functionNames(runner, requested)
 
 	// Print each function name
 	for _, name := range names {
-		_, _ = typesetPrintf("declare -f %s\n", name)
+		if len(requested) > 0 {
+			_, _ = fmt.Fprintf(out, "%s\n", name)
+		} else {
+			_, _ = fmt.Fprintf(out, "declare -f %s\n", name)
+		}
 	}
 
-	return nil
+	return err
 }
 
-// printVariables prints all variables with their values in bash-compatible format
-func printVariables(runner *interp.Runner) error {
-	if runner.Vars == nil {
-		return nil
+// printVariables prints variables the way bash's declare -p does. If names
+// is empty, all variables with all of attributes are printed; otherwise the
+// named ones are, and like in bash the exit status is 1 if one is not set.
+func printVariables(env expand.Environ, out, errOut io.Writer, attributes string, names []string) error {
+	if len(names) > 0 {
+		var err error
+		for _, name := range names {
+			vr := env.Get(name)
+			if !vr.IsSet() && !vr.Exported && !vr.ReadOnly && !IsIntegerVar(env, name) {
+				_, _ = fmt.Fprintf(errOut, "declare: %s: not found\n", name)
+				err = interp.NewExitStatus(1)
+				continue
+			}
+			_, _ = fmt.Fprintln(out, declaration(env, name, vr))
+		}
+		return err
 	}
 
-	// Collect all variable names
-	var names []string
-	for name := range runner.Vars {
+	vars := map[string]expand.Variable{}
+	env.Each(func(name string, vr expand.Variable) bool {
+		if integer, ok := strings.CutPrefix(name, integerMarkerPrefix); ok {
+			if _, seen := vars[integer]; !seen && vr.String() != "" {
+				vars[integer] = env.Get(integer)
+			}
+			return true
+		}
+		vars[name] = vr
+		return true
+	})
+
+	names = make([]string, 0, len(vars))
+	for name, vr := range vars {
+		if !syntax.ValidName(name) || (!vr.IsSet() && !vr.Exported && !vr.ReadOnly && !IsIntegerVar(env, name)) {
+			continue
+		}
+		if !hasAttributes(variableAttributes(env, name, vr), attributes) {
+			continue
+		}
 		names = append(names, name)
 	}
 	sort.Strings(names)
 
-	// Print each variable
 	for _, name := range names {
-		vr, ok := runner.Vars[name]
-		if !ok {
-			continue
+		_, _ = fmt.Fprintln(out, declaration(env, name, vars[name]))
+	}
+	return nil
+}
+
+// hasAttributes reports whether have contains all the attribute letters of
+// want.
+func hasAttributes(have, want string) bool {
+	for _, ch := range want {
+		if !strings.ContainsRune(have, ch) {
+			return false
 		}
+	}
+	return true
+}
 
-		value := vr.String()
+// variableAttributes returns the attribute letters of a variable, in the
+// order bash prints them.
+func variableAttributes(env expand.Environ, name string, vr expand.Variable) string {
+	var attributes string
+	switch vr.Kind {
+	case expand.Indexed:
+		attributes += "a"
+	case expand.Associative:
+		attributes += "A"
+	}
+	if IsIntegerVar(env, name) {
+		attributes += "i"
+	}
+	if vr.Kind == expand.NameRef {
+		attributes += "n"
+	}
+	if vr.ReadOnly {
+		attributes += "r"
+	}
+	if vr.Exported {
+		attributes += "x"
+	}
+	return attributes
+}
 
-		// Determine if the variable is exported
-		exported := vr.Exported
+// declaration formats a variable as bash's declare -p does, e.g.
+// declare -rx NAME="value" or declare -a LIST=([0]="a" [1]="b").
+func declaration(env expand.Environ, name string, vr expand.Variable) string {
+	attributes := variableAttributes(env, name, vr)
+	if attributes == "" {
+		attributes = "-"
+	}
+	decl := "declare -" + attributes + " " + name
+
+	switch vr.Kind {
+	case expand.String, expand.NameRef:
+		return decl + "=" + doubleQuote(vr.Str)
+	case expand.Indexed:
+		elems := make([]string, len(vr.List))
+		for i, elem := range vr.List {
+			elems[i] = fmt.Sprintf("[%d]=%s", i, doubleQuote(elem))
+		}
+		return decl + "=(" + strings.Join(elems, " ") + ")"
+	case expand.Associative:
+		keys := make([]string, 0, len(vr.Map))
+		for key := range vr.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		for _, key := range keys {
+			fmt.Fprintf(&sb, "[%s]=%s ", associativeKey(key), doubleQuote(vr.Map[key]))
+		}
+		return decl + "=(" + sb.String() + ")"
+	default:
+		// Declared with attributes but no value
+		return decl
+	}
+}
 
-		// Format the output
-		if exported {
-			_, _ = typesetPrintf("declare -x %s=%q\n", name, value)
-		} else {
-			_, _ = typesetPrintf("declare -- %s=%q\n", name, value)
+// doubleQuote quotes s the way bash does in declare -p output.
+func doubleQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\', '$', '`':
+			sb.WriteByte('\\')
 		}
+		sb.WriteRune(r)
 	}
+	sb.WriteByte('"')
+	return sb.String()
+}
 
-	return nil
+// associativeKey quotes the key of an associative array element if bash
+// would.
+func associativeKey(key string) string {
+	if key != "" && !strings.ContainsAny(key, " \t\n\"'\\$`[]*?") {
+		return key
+	}
+	return doubleQuote(key)
 }
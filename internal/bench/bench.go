@@ -0,0 +1,218 @@
+// Package bench measures how long shell commands take, in the manner of
+// hyperfine: each command runs a few times after warmup runs, and the mean,
+// standard deviation and range of the runs are reported and compared.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRuns and DefaultWarmup are how many times each command runs,
+	// after how many runs that are not measured.
+	DefaultRuns   = 10
+	DefaultWarmup = 1
+)
+
+// RunFunc runs command in dir with env, and returns how long it took and
+// its exit code.
+type RunFunc func(ctx context.Context, command, dir string, env []string) (time.Duration, int, error)
+
+// RunShell runs command with sh -c, discarding its output.
+func RunShell(ctx context.Context, command, dir string, env []string) (time.Duration, int, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return elapsed, exitErr.ExitCode(), nil
+	}
+	return elapsed, 0, err
+}
+
+// Benchmark is a command to measure, with the values of the parameters it
+// was made from.
+type Benchmark struct {
+	Command    string
+	Parameters map[string]string
+}
+
+// Result is what was measured of a benchmark.
+type Result struct {
+	Benchmark
+	Times     []time.Duration
+	ExitCodes []int
+}
+
+// Mean returns the mean of the times.
+func (r Result) Mean() time.Duration {
+	if len(r.Times) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, t := range r.Times {
+		total += t
+	}
+	return total / time.Duration(len(r.Times))
+}
+
+// StdDev returns the standard deviation of the times, as a sample of all
+// the runs the command could make.
+func (r Result) StdDev() time.Duration {
+	if len(r.Times) < 2 {
+		return 0
+	}
+	mean := float64(r.Mean())
+	var sum float64
+	for _, t := range r.Times {
+		sum += (float64(t) - mean) * (float64(t) - mean)
+	}
+	return time.Duration(math.Sqrt(sum / float64(len(r.Times)-1)))
+}
+
+// Min, Max and Median return the shortest, longest and median times.
+func (r Result) Min() time.Duration { return r.sorted(0) }
+func (r Result) Max() time.Duration { return r.sorted(len(r.Times) - 1) }
+func (r Result) Median() time.Duration {
+	n := len(r.Times)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return r.sorted(n / 2)
+	}
+	return (r.sorted(n/2-1) + r.sorted(n/2)) / 2
+}
+
+func (r Result) sorted(i int) time.Duration {
+	if len(r.Times) == 0 {
+		return 0
+	}
+	times := append([]time.Duration(nil), r.Times...)
+	sort.Slice(times, func(a, b int) bool { return times[a] < times[b] })
+	return times[i]
+}
+
+// Parameter is a parameter swept over values, which replace {Name} in the
+// commands.
+type Parameter struct {
+	Name   string
+	Values []string
+}
+
+// Expand returns the benchmarks of commands for each combination of the
+// values of params, in order.
+func Expand(commands []string, params []Parameter) []Benchmark {
+	var benchmarks []Benchmark
+	for _, command := range commands {
+		combinations := []map[string]string{{}}
+		for _, param := range params {
+			if !strings.Contains(command, "{"+param.Name+"}") {
+				continue
+			}
+			var next []map[string]string
+			for _, combination := range combinations {
+				for _, value := range param.Values {
+					values := map[string]string{param.Name: value}
+					for name, v := range combination {
+						values[name] = v
+					}
+					next = append(next, values)
+				}
+			}
+			combinations = next
+		}
+		for _, values := range combinations {
+			expanded := command
+			for name, value := range values {
+				expanded = strings.ReplaceAll(expanded, "{"+name+"}", value)
+			}
+			b := Benchmark{Command: expanded}
+			if len(values) > 0 {
+				b.Parameters = values
+			}
+			benchmarks = append(benchmarks, b)
+		}
+	}
+	return benchmarks
+}
+
+// Config is how benchmarks run.
+type Config struct {
+	Runs   int
+	Warmup int
+	// IgnoreFailure keeps measuring a command that exits with an error
+	IgnoreFailure bool
+	Dir           string
+	Env           []string
+}
+
+// Measure runs b as configured and returns its times. It stops at the
+// first run that fails unless failures are ignored.
+func Measure(ctx context.Context, run RunFunc, b Benchmark, config Config) (Result, error) {
+	result := Result{Benchmark: b}
+	for i := 0; i < config.Warmup+config.Runs; i++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		elapsed, code, err := run(ctx, b.Command, config.Dir, config.Env)
+		if err != nil {
+			return result, err
+		}
+		if code != 0 && !config.IgnoreFailure {
+			return result, fmt.Errorf("%s exited with %d; use -i to measure it anyway", b.Command, code)
+		}
+		if i < config.Warmup {
+			continue
+		}
+		result.Times = append(result.Times, elapsed)
+		result.ExitCodes = append(result.ExitCodes, code)
+	}
+	return result, nil
+}
+
+// FormatDuration formats d with the unit that suits scale, the mean of the
+// times it is shown with: µs, ms or s.
+func FormatDuration(d, scale time.Duration) string {
+	switch {
+	case scale < time.Millisecond:
+		return fmt.Sprintf("%.1f µs", float64(d)/float64(time.Microsecond))
+	case scale < time.Second:
+		return fmt.Sprintf("%.1f ms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%.3f s", d.Seconds())
+}
+
+// Fastest returns the index of the result with the lowest mean.
+func Fastest(results []Result) int {
+	fastest := 0
+	for i, r := range results {
+		if r.Mean() < results[fastest].Mean() {
+			fastest = i
+		}
+	}
+	return fastest
+}
+
+// Ratio returns how many times slower r is than fastest, with the
+// uncertainty that follows from their standard deviations.
+func Ratio(r, fastest Result) (float64, float64) {
+	mean, base := float64(r.Mean()), float64(fastest.Mean())
+	if base == 0 {
+		return 0, 0
+	}
+	ratio := mean / base
+	if mean == 0 {
+		return ratio, 0
+	}
+	relative := math.Hypot(float64(r.StdDev())/mean, float64(fastest.StdDev())/base)
+	return ratio, ratio * relative
+}
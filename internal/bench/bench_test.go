@@ -0,0 +1,93 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ms(n float64) time.Duration {
+	return time.Duration(n * float64(time.Millisecond))
+}
+
+func TestResultStatistics(t *testing.T) {
+	r := Result{Times: []time.Duration{ms(12), ms(10), ms(14), ms(10), ms(14)}}
+	assert.Equal(t, ms(12), r.Mean())
+	assert.Equal(t, ms(10), r.Min())
+	assert.Equal(t, ms(14), r.Max())
+	assert.Equal(t, ms(12), r.Median())
+	assert.Equal(t, ms(2), r.StdDev())
+	assert.Equal(t, []time.Duration{ms(12), ms(10), ms(14), ms(10), ms(14)}, r.Times, "the times keep their order")
+
+	assert.Equal(t, ms(11), Result{Times: []time.Duration{ms(10), ms(12)}}.Median())
+	assert.Equal(t, time.Duration(0), Result{Times: []time.Duration{ms(10)}}.StdDev())
+	assert.Equal(t, time.Duration(0), Result{}.Mean())
+}
+
+func TestExpand(t *testing.T) {
+	benchmarks := Expand(
+		[]string{"sleep {t}", "xz -{level} -T{threads} f", "true"},
+		[]Parameter{{Name: "t", Values: []string{"0.1", "0.2"}}, {Name: "level", Values: []string{"1", "9"}}, {Name: "threads", Values: []string{"2"}}},
+	)
+	var commands []string
+	for _, b := range benchmarks {
+		commands = append(commands, b.Command)
+	}
+	assert.Equal(t, []string{"sleep 0.1", "sleep 0.2", "xz -1 -T2 f", "xz -9 -T2 f", "true"}, commands)
+	assert.Equal(t, map[string]string{"level": "9", "threads": "2"}, benchmarks[3].Parameters)
+	assert.Nil(t, benchmarks[4].Parameters)
+}
+
+func TestMeasure(t *testing.T) {
+	var runs int
+	run := func(ctx context.Context, command, dir string, env []string) (time.Duration, int, error) {
+		runs++
+		return ms(float64(runs)), 0, nil
+	}
+	r, err := Measure(context.Background(), run, Benchmark{Command: "true"}, Config{Runs: 3, Warmup: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, runs)
+	assert.Equal(t, []time.Duration{ms(3), ms(4), ms(5)}, r.Times, "warmup runs are not measured")
+	assert.Equal(t, []int{0, 0, 0}, r.ExitCodes)
+
+	failing := func(ctx context.Context, command, dir string, env []string) (time.Duration, int, error) {
+		return ms(1), 3, nil
+	}
+	_, err = Measure(context.Background(), failing, Benchmark{Command: "false"}, Config{Runs: 3})
+	assert.EqualError(t, err, "false exited with 3; use -i to measure it anyway")
+	r, err = Measure(context.Background(), failing, Benchmark{Command: "false"}, Config{Runs: 3, IgnoreFailure: true})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 3, 3}, r.ExitCodes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Measure(ctx, run, Benchmark{Command: "true"}, Config{Runs: 3})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunShell(t *testing.T) {
+	elapsed, code, err := RunShell(context.Background(), "exit 4", t.TempDir(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, code)
+	assert.Positive(t, elapsed)
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "850.0 µs", FormatDuration(850*time.Microsecond, 850*time.Microsecond))
+	assert.Equal(t, "102.3 ms", FormatDuration(ms(102.3), ms(102.3)))
+	assert.Equal(t, "0.5 ms", FormatDuration(500*time.Microsecond, ms(102.3)))
+	assert.Equal(t, "1.500 s", FormatDuration(1500*time.Millisecond, time.Second))
+}
+
+func TestRatio(t *testing.T) {
+	fast := Result{Times: []time.Duration{ms(10), ms(10)}}
+	slow := Result{Times: []time.Duration{ms(20), ms(20)}}
+	assert.Equal(t, 0, Fastest([]Result{fast, slow}))
+	assert.Equal(t, 1, Fastest([]Result{slow, fast}))
+	ratio, uncertainty := Ratio(slow, fast)
+	assert.InDelta(t, 2.0, ratio, 1e-9)
+	assert.InDelta(t, 0.0, uncertainty, 1e-9)
+}
@@ -0,0 +1,243 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: bench [-r | --runs <n>] [-w | --warmup <n>] [-i | --ignore-failure]\n" +
+	"             [-P | --parameter-scan <name> <min> <max>] [-L | --parameter-list <name> <a,b,...>]\n" +
+	"             [--export-json <file>] [--export-markdown <file>] <command>..."
+
+// maxRuns bounds the runs of each command, warmup included.
+const maxRuns = 10000
+
+// RecordFunc adds a summary of the benchmarks to history.
+type RecordFunc func(command string, exitCode int)
+
+// options are the parsed arguments of bench.
+type options struct {
+	config         Config
+	commands       []string
+	params         []Parameter
+	exportJSON     string
+	exportMarkdown string
+}
+
+// NewBenchCommandHandler creates an ExecHandler for the bench builtin, which
+// compares how long commands take, such as bench 'grep -r foo .' 'rg foo'.
+// Each command runs with run, a warmup run first and then 10 measured runs,
+// and {name} in commands is replaced by the values of the parameters swept
+// with -P and -L. The runs themselves are not added to history; a summary
+// of the results is, with record.
+func NewBenchCommandHandler(run RunFunc, record RecordFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "bench" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) == 2 && (args[1] == "-h" || args[1] == "--help") {
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+			}
+			opts, err := parseArgs(args[1:])
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "bench: %v\n%s\n", err, usage)
+				return interp.NewExitStatus(2)
+			}
+			opts.config.Dir = hc.Dir
+			opts.config.Env = execEnv(hc.Env)
+
+			results, err := runAll(ctx, hc.Stdout, run, opts)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "bench: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			WriteSummary(hc.Stdout, results)
+			if record != nil {
+				record(Summary(results), 0)
+			}
+			if err := export(hc.Dir, opts, results); err != nil {
+				fmt.Fprintf(hc.Stderr, "bench: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+}
+
+// runAll measures each benchmark of opts, printing its results as it goes.
+func runAll(ctx context.Context, w io.Writer, run RunFunc, opts options) ([]Result, error) {
+	var results []Result
+	for i, b := range Expand(opts.commands, opts.params) {
+		fmt.Fprintf(w, "Benchmark %d: %s\n", i+1, b.Command)
+		result, err := Measure(ctx, run, b, opts.config)
+		if err != nil {
+			return nil, err
+		}
+		WriteResult(w, result)
+		fmt.Fprintln(w)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// export writes results to the files named with --export-json and
+// --export-markdown, relative to dir.
+func export(dir string, opts options, results []Result) error {
+	exports := []struct {
+		path  string
+		write func(io.Writer, []Result) error
+	}{
+		{opts.exportJSON, ExportJSON},
+		{opts.exportMarkdown, ExportMarkdown},
+	}
+	for _, e := range exports {
+		if e.path == "" {
+			continue
+		}
+		path := e.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		err = e.write(f, results)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.path, err)
+		}
+	}
+	return nil
+}
+
+// parseArgs parses the arguments of bench.
+func parseArgs(args []string) (options, error) {
+	opts := options{config: Config{Runs: DefaultRuns, Warmup: DefaultWarmup}}
+	// values returns the n arguments that follow the option at i
+	values := func(i, n int) ([]string, error) {
+		if i+n >= len(args) {
+			return nil, fmt.Errorf("%s needs %d argument(s)", args[i], n)
+		}
+		return args[i+1 : i+1+n], nil
+	}
+	count := func(arg, value string, least int) (int, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < least || n > maxRuns {
+			return 0, fmt.Errorf("invalid %s: %s", arg, value)
+		}
+		return n, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var err error
+		switch arg {
+		case "-r", "--runs", "-w", "--warmup", "--export-json", "--export-markdown":
+			var v []string
+			if v, err = values(i, 1); err != nil {
+				return options{}, err
+			}
+			i++
+			switch arg {
+			case "-r", "--runs":
+				opts.config.Runs, err = count(arg, v[0], 1)
+			case "-w", "--warmup":
+				opts.config.Warmup, err = count(arg, v[0], 0)
+			case "--export-json":
+				opts.exportJSON = v[0]
+			case "--export-markdown":
+				opts.exportMarkdown = v[0]
+			}
+		case "-i", "--ignore-failure":
+			opts.config.IgnoreFailure = true
+		case "-P", "--parameter-scan":
+			var v []string
+			if v, err = values(i, 3); err != nil {
+				return options{}, err
+			}
+			i += 3
+			var param Parameter
+			if param, err = scan(v[0], v[1], v[2]); err == nil {
+				opts.params = append(opts.params, param)
+			}
+		case "-L", "--parameter-list":
+			var v []string
+			if v, err = values(i, 2); err != nil {
+				return options{}, err
+			}
+			i += 2
+			opts.params = append(opts.params, Parameter{Name: v[0], Values: strings.Split(v[1], ",")})
+		case "--":
+			opts.commands = append(opts.commands, args[i+1:]...)
+			i = len(args)
+		default:
+			if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+				return options{}, fmt.Errorf("unknown option %s", arg)
+			}
+			opts.commands = append(opts.commands, arg)
+		}
+		if err != nil {
+			return options{}, err
+		}
+	}
+	if len(opts.commands) == 0 {
+		return options{}, fmt.Errorf("missing command")
+	}
+	for _, param := range opts.params {
+		used := false
+		for _, command := range opts.commands {
+			used = used || strings.Contains(command, "{"+param.Name+"}")
+		}
+		if !used {
+			return options{}, fmt.Errorf("no command uses {%s}", param.Name)
+		}
+	}
+	return opts, nil
+}
+
+// maxScanValues bounds the values of a parameter scan.
+const maxScanValues = 100
+
+// scan returns the parameter that takes the integers from first to last.
+func scan(name, first, last string) (Parameter, error) {
+	from, errFrom := strconv.Atoi(first)
+	to, errTo := strconv.Atoi(last)
+	if errFrom != nil || errTo != nil || to < from {
+		return Parameter{}, fmt.Errorf("invalid range for %s: %s to %s", name, first, last)
+	}
+	if to-from >= maxScanValues {
+		return Parameter{}, fmt.Errorf("too many values for %s: at most %d", name, maxScanValues)
+	}
+	param := Parameter{Name: name}
+	for v := from; v <= to; v++ {
+		param.Values = append(param.Values, strconv.Itoa(v))
+	}
+	return param, nil
+}
+
+// execEnv returns the exported variables of env, for the commands run.
+func execEnv(env expand.Environ) []string {
+	var list []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		if vr.IsSet() && vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+		return true
+	})
+	return list
+}
@@ -0,0 +1,110 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// fakeRun takes as many milliseconds as the length of the command, and
+// remembers the commands it ran.
+type fakeRun struct {
+	commands []string
+	dir      string
+}
+
+func (f *fakeRun) run(ctx context.Context, command, dir string, env []string) (time.Duration, int, error) {
+	f.commands = append(f.commands, command)
+	f.dir = dir
+	return ms(float64(len(command))), 0, nil
+}
+
+func runBench(t *testing.T, run RunFunc, record RecordFunc, dir, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.Dir(dir),
+		interp.ExecHandlers(NewBenchCommandHandler(run, record)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestBenchCommand(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeRun{}
+	var recorded []string
+	record := func(command string, exitCode int) { recorded = append(recorded, command) }
+
+	out, _, err := runBench(t, fake.run, record, dir, `bench -r 3 -w 0 'sleep 1' 'sleep 10' --export-json out.json --export-markdown out.md`)
+	require.NoError(t, err)
+	assert.Len(t, fake.commands, 6)
+	assert.Equal(t, dir, fake.dir)
+	assert.Contains(t, out, "Benchmark 1: sleep 1\n  Time (mean ± σ):       7.0 ms ± 0.0 ms\n")
+	assert.Contains(t, out, "Benchmark 2: sleep 10\n")
+	assert.Contains(t, out, "  'sleep 1' ran\n    1.14 ± 0.00 times faster than 'sleep 10'\n")
+	assert.Equal(t, []string{"# bench: 'sleep 1' 7.0 ms ± 0.0 ms, 'sleep 10' 8.0 ms ± 0.0 ms"}, recorded)
+	assert.FileExists(t, filepath.Join(dir, "out.json"))
+	markdown, err := os.ReadFile(filepath.Join(dir, "out.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(markdown), "| `sleep 1` | 7.0 ± 0.0 | 7.0 | 7.0 | 1.00 |")
+
+	// Parameters sweep over values, with a warmup run of each by default
+	fake.commands = nil
+	_, _, err = runBench(t, fake.run, nil, dir, `bench -r 1 -P n 1 3 -L mode fast,slow 'run {n} {mode}'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"run 1 fast", "run 1 fast", "run 1 slow", "run 1 slow",
+		"run 2 fast", "run 2 fast", "run 2 slow", "run 2 slow",
+		"run 3 fast", "run 3 fast", "run 3 slow", "run 3 slow",
+	}, fake.commands)
+}
+
+func TestBenchCommandErrors(t *testing.T) {
+	fake := &fakeRun{}
+	tests := []struct {
+		script string
+		stderr string
+	}{
+		{`bench`, "bench: missing command\n"},
+		{`bench -r 0 true`, "bench: invalid -r: 0\n"},
+		{`bench -r`, "bench: -r needs 1 argument(s)\n"},
+		{`bench --fast true`, "bench: unknown option --fast\n"},
+		{`bench -P n 3 1 'sleep {n}'`, "bench: invalid range for n: 3 to 1\n"},
+		{`bench -L n 1,2 true`, "bench: no command uses {n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			_, stderr, err := runBench(t, fake.run, nil, t.TempDir(), tt.script)
+			assert.Error(t, err)
+			assert.True(t, strings.HasPrefix(stderr, tt.stderr), stderr)
+		})
+	}
+	assert.Empty(t, fake.commands)
+
+	out, _, err := runBench(t, fake.run, nil, t.TempDir(), `bench --help`)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "Usage: bench"))
+
+	failing := func(ctx context.Context, command, dir string, env []string) (time.Duration, int, error) {
+		return ms(1), 1, nil
+	}
+	_, stderr, err := runBench(t, failing, nil, t.TempDir(), `bench false`)
+	assert.Error(t, err)
+	assert.Equal(t, "bench: false exited with 1; use -i to measure it anyway\n", stderr)
+}
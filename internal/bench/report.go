@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// barWidth is how wide the bar of the slowest command is.
+	barWidth = 30
+	// maxLabelWidth bounds the commands labelling the bars.
+	maxLabelWidth = 30
+)
+
+// WriteResult prints the statistics of a benchmark.
+func WriteResult(w io.Writer, r Result) {
+	mean := r.Mean()
+	fmt.Fprintf(w, "  Time (mean ± σ):   %10s ± %s\n", FormatDuration(mean, mean), FormatDuration(r.StdDev(), mean))
+	fmt.Fprintf(w, "  Range (min … max): %10s … %s    %d runs\n", FormatDuration(r.Min(), mean), FormatDuration(r.Max(), mean), len(r.Times))
+	failed := 0
+	for _, code := range r.ExitCodes {
+		if code != 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(w, "  Warning: %d of the runs exited with an error\n", failed)
+	}
+}
+
+// WriteSummary prints a bar chart of the means of results and how much
+// faster the fastest command was than the others.
+func WriteSummary(w io.Writer, results []Result) {
+	if len(results) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Summary")
+	slowest := results[0].Mean()
+	labelWidth := 0
+	for _, r := range results {
+		slowest = max(slowest, r.Mean())
+		labelWidth = max(labelWidth, utf8.RuneCountInString(label(r.Command)))
+	}
+	for _, r := range results {
+		bar := 1
+		if slowest > 0 {
+			bar = max(1, int(float64(r.Mean())/float64(slowest)*barWidth+0.5))
+		}
+		name := label(r.Command)
+		fmt.Fprintf(w, "  %s%s  %s%s %s\n", name, strings.Repeat(" ", labelWidth-utf8.RuneCountInString(name)),
+			strings.Repeat("█", bar), strings.Repeat(" ", barWidth-bar), FormatDuration(r.Mean(), r.Mean()))
+	}
+	if len(results) < 2 {
+		return
+	}
+	fastest := Fastest(results)
+	fmt.Fprintf(w, "  '%s' ran\n", results[fastest].Command)
+	for i, r := range results {
+		if i == fastest {
+			continue
+		}
+		ratio, uncertainty := Ratio(r, results[fastest])
+		fmt.Fprintf(w, "    %.2f ± %.2f times faster than '%s'\n", ratio, uncertainty, r.Command)
+	}
+}
+
+// label returns command cut to maxLabelWidth.
+func label(command string) string {
+	if runes := []rune(command); len(runes) > maxLabelWidth {
+		return string(runes[:maxLabelWidth-1]) + "…"
+	}
+	return command
+}
+
+// Summary returns results on one line, for history, e.g.
+// "# bench: 'sleep 0.1' 102.3 ms ± 1.2 ms, 'sleep 0.2' 203.1 ms ± 0.8 ms".
+func Summary(results []Result) string {
+	var parts []string
+	for _, r := range results {
+		mean := r.Mean()
+		parts = append(parts, fmt.Sprintf("'%s' %s ± %s", r.Command, FormatDuration(mean, mean), FormatDuration(r.StdDev(), mean)))
+	}
+	return "# bench: " + strings.Join(parts, ", ")
+}
+
+// jsonResult is a result as exported to JSON, with times in seconds like
+// hyperfine exports them.
+type jsonResult struct {
+	Command    string            `json:"command"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Mean       float64           `json:"mean"`
+	StdDev     float64           `json:"stddev"`
+	Median     float64           `json:"median"`
+	Min        float64           `json:"min"`
+	Max        float64           `json:"max"`
+	Times      []float64         `json:"times"`
+	ExitCodes  []int             `json:"exit_codes"`
+}
+
+// ExportJSON writes results as JSON.
+func ExportJSON(w io.Writer, results []Result) error {
+	exported := struct {
+		Results []jsonResult `json:"results"`
+	}{Results: []jsonResult{}}
+	for _, r := range results {
+		times := make([]float64, len(r.Times))
+		for i, t := range r.Times {
+			times[i] = t.Seconds()
+		}
+		exported.Results = append(exported.Results, jsonResult{
+			Command:    r.Command,
+			Parameters: r.Parameters,
+			Mean:       r.Mean().Seconds(),
+			StdDev:     r.StdDev().Seconds(),
+			Median:     r.Median().Seconds(),
+			Min:        r.Min().Seconds(),
+			Max:        r.Max().Seconds(),
+			Times:      times,
+			ExitCodes:  r.ExitCodes,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exported)
+}
+
+// ExportMarkdown writes results as a Markdown table, in the unit that suits
+// the fastest command.
+func ExportMarkdown(w io.Writer, results []Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+	fastest := results[Fastest(results)]
+	unit, scale := "s", float64(time.Second)
+	switch {
+	case fastest.Mean() < time.Millisecond:
+		unit, scale = "µs", float64(time.Microsecond)
+	case fastest.Mean() < time.Second:
+		unit, scale = "ms", float64(time.Millisecond)
+	}
+	in := func(d time.Duration) string {
+		return fmt.Sprintf("%.1f", float64(d)/scale)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "| Command | Mean [%s] | Min [%s] | Max [%s] | Relative |\n", unit, unit, unit)
+	b.WriteString("|:---|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		ratio, uncertainty := Ratio(r, fastest)
+		relative := fmt.Sprintf("%.2f ± %.2f", ratio, uncertainty)
+		if r.Mean() == fastest.Mean() {
+			relative = "1.00"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s ± %s | %s | %s | %s |\n", strings.ReplaceAll(r.Command, "|", `\|`),
+			in(r.Mean()), in(r.StdDev()), in(r.Min()), in(r.Max()), relative)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,76 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func results() []Result {
+	return []Result{
+		{Benchmark: Benchmark{Command: "sleep 0.2"}, Times: []time.Duration{ms(200), ms(202)}, ExitCodes: []int{0, 0}},
+		{Benchmark: Benchmark{Command: "sleep 0.1", Parameters: map[string]string{"t": "0.1"}}, Times: []time.Duration{ms(100), ms(102)}, ExitCodes: []int{0, 1}},
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	var out bytes.Buffer
+	WriteResult(&out, results()[1])
+	assert.Equal(t, "  Time (mean ± σ):     101.0 ms ± 1.4 ms\n"+
+		"  Range (min … max):   100.0 ms … 102.0 ms    2 runs\n"+
+		"  Warning: 1 of the runs exited with an error\n", out.String())
+}
+
+func TestWriteSummary(t *testing.T) {
+	var out bytes.Buffer
+	WriteSummary(&out, results())
+	assert.Equal(t, "Summary\n"+
+		"  sleep 0.2  ██████████████████████████████ 201.0 ms\n"+
+		"  sleep 0.1  ███████████████                101.0 ms\n"+
+		"  'sleep 0.1' ran\n"+
+		"    1.99 ± 0.03 times faster than 'sleep 0.2'\n", out.String())
+
+	out.Reset()
+	WriteSummary(&out, results()[:1])
+	assert.NotContains(t, out.String(), "faster")
+}
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, "# bench: 'sleep 0.2' 201.0 ms ± 1.4 ms, 'sleep 0.1' 101.0 ms ± 1.4 ms", Summary(results()))
+}
+
+func TestExportJSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, ExportJSON(&out, results()))
+	var exported struct {
+		Results []struct {
+			Command    string            `json:"command"`
+			Parameters map[string]string `json:"parameters"`
+			Mean       float64           `json:"mean"`
+			Min        float64           `json:"min"`
+			Times      []float64         `json:"times"`
+			ExitCodes  []int             `json:"exit_codes"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &exported))
+	require.Len(t, exported.Results, 2)
+	second := exported.Results[1]
+	assert.Equal(t, "sleep 0.1", second.Command)
+	assert.Equal(t, map[string]string{"t": "0.1"}, second.Parameters)
+	assert.InDelta(t, 0.101, second.Mean, 1e-9)
+	assert.InDelta(t, 0.1, second.Min, 1e-9)
+	assert.Equal(t, []int{0, 1}, second.ExitCodes)
+}
+
+func TestExportMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, ExportMarkdown(&out, results()))
+	assert.Equal(t, "| Command | Mean [ms] | Min [ms] | Max [ms] | Relative |\n"+
+		"|:---|---:|---:|---:|---:|\n"+
+		"| `sleep 0.2` | 201.0 ± 1.4 | 200.0 | 202.0 | 1.99 ± 0.03 |\n"+
+		"| `sleep 0.1` | 101.0 ± 1.4 | 100.0 | 102.0 | 1.00 |\n", out.String())
+}
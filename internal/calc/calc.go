@@ -0,0 +1,502 @@
+// Package calc evaluates the arithmetic and unit conversion expressions typed
+// on "= ..." lines, such as "= 3*(7+2)" or "= 5GiB in MB", without an LLM or
+// an external bc.
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Prefix starts a calculator line in the shell.
+const Prefix = "="
+
+// Result is the value of an expression and the unit it is expressed in, if
+// any.
+type Result struct {
+	Value float64
+	Unit  string
+}
+
+// String formats the result with at most 12 significant digits, so that
+// floating point noise such as 0.30000000000000004 is hidden.
+func (r Result) String() string {
+	if r.Unit == "" {
+		return FormatNumber(r.Value)
+	}
+	return FormatNumber(r.Value) + " " + r.Unit
+}
+
+// FormatNumber formats value as a plain decimal, switching to exponent
+// notation for very large or very small magnitudes. Integers below 1e21 are
+// shown exactly.
+func FormatNumber(value float64) string {
+	if value == 0 {
+		return "0"
+	}
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 {
+		return big.NewFloat(value).Text('f', 0)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 12, 64), 64)
+	if abs := math.Abs(rounded); abs >= 1e15 || abs < 1e-9 {
+		return strconv.FormatFloat(rounded, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// IsExpression reports whether line is a calculator line, i.e. starts with
+// "=".
+func IsExpression(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), Prefix)
+}
+
+// Evaluate computes expr. Names other than functions and the constants pi and
+// e are looked up in vars, with or without a leading "$" and ignoring case, so
+// "ans", "ANS" and "$ANS" all refer to vars["ANS"]. An expression may end with
+// "in <unit>" or "to <unit>" to convert the result.
+func Evaluate(expr string, vars map[string]float64) (Result, error) {
+	expr = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr), Prefix))
+	if expr == "" {
+		return Result{}, errors.New("empty expression")
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return Result{}, err
+	}
+
+	p := &parser{tokens: tokens, vars: vars}
+	q, err := p.parseExpr()
+	if err != nil {
+		return Result{}, err
+	}
+
+	if tok := p.peek(); tok.kind == tokIdent && (tok.text == "in" || tok.text == "to") {
+		p.pos++
+		target := p.next()
+		if target.kind != tokIdent {
+			return Result{}, fmt.Errorf("expected a unit after %q", tok.text)
+		}
+		to, ok := lookupUnit(target.text)
+		if !ok {
+			return Result{}, fmt.Errorf("unknown unit %q", target.text)
+		}
+		if q, err = q.convert(to); err != nil {
+			return Result{}, err
+		}
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return Result{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+
+	if math.IsNaN(q.value) || math.IsInf(q.value, 0) {
+		return Result{}, errors.New("result is not a finite number")
+	}
+	result := Result{Value: q.value}
+	if q.unit != nil {
+		result.Unit = q.unit.name
+	}
+	return result, nil
+}
+
+// quantity is a value with an optional unit.
+type quantity struct {
+	value float64
+	unit  *unit
+}
+
+func (q quantity) String() string {
+	if q.unit == nil {
+		return FormatNumber(q.value)
+	}
+	return FormatNumber(q.value) + " " + q.unit.name
+}
+
+func (q quantity) convert(to *unit) (quantity, error) {
+	if q.unit == nil {
+		return quantity{}, fmt.Errorf("cannot convert %s to %s: it has no unit", q, to.name)
+	}
+	if q.unit.dimension != to.dimension {
+		return quantity{}, fmt.Errorf("cannot convert %s (%s) to %s (%s)", q.unit.name, q.unit.dimension, to.name, to.dimension)
+	}
+	base := q.unit.toBase(q.value)
+	value := to.fromBase(base)
+	// Offsets leave rounding noise where the result should be zero, as in
+	// 32 F to C
+	if math.Abs(value*to.factor) < 1e-9*math.Abs(base) {
+		value = 0
+	}
+	return quantity{value: value, unit: to}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			text, value, err := scanNumber(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, number: value})
+			i += len([]rune(text))
+		case unicode.IsLetter(r) || r == '_' || r == '$' || r == '°':
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i])})
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			tokens = append(tokens, token{kind: tokOperator, text: "^"})
+			i += 2
+		case strings.ContainsRune("+-*/%^()", r):
+			tokens = append(tokens, token{kind: tokOperator, text: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// scanNumber reads a decimal number with optional "_" separators and
+// exponent, or a 0x, 0o or 0b prefixed integer.
+func scanNumber(runes []rune) (string, float64, error) {
+	if len(runes) > 2 && runes[0] == '0' && strings.ContainsRune("xXoObB", runes[1]) {
+		end := 2
+		for end < len(runes) && (unicode.IsDigit(runes[end]) || strings.ContainsRune("abcdefABCDEF_", runes[end])) {
+			end++
+		}
+		text := string(runes[:end])
+		value, err := strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 0, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid number %q", text)
+		}
+		return text, float64(value), nil
+	}
+
+	end := 0
+	for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.' || runes[end] == '_') {
+		end++
+	}
+	if end < len(runes) && (runes[end] == 'e' || runes[end] == 'E') {
+		exp := end + 1
+		if exp < len(runes) && (runes[exp] == '+' || runes[exp] == '-') {
+			exp++
+		}
+		if exp < len(runes) && unicode.IsDigit(runes[exp]) {
+			end = exp
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+		}
+	}
+	text := string(runes[:end])
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid number %q", text)
+	}
+	return text, value, nil
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+var functions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"exp":   math.Exp,
+	"ln":    math.Log,
+	"log":   math.Log10,
+	"log2":  math.Log2,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number [ unit ] | name | name "(" expr ")" | "(" expr ")"
+type parser struct {
+	tokens []token
+	pos    int
+	vars   map[string]float64
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOperator(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseExpr() (quantity, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return quantity{}, err
+	}
+	for {
+		op, ok := p.isOperator("+", "-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return quantity{}, err
+		}
+		if left, err = addQuantities(left, right, op == "-"); err != nil {
+			return quantity{}, err
+		}
+	}
+}
+
+func (p *parser) parseTerm() (quantity, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return quantity{}, err
+	}
+	for {
+		op, ok := p.isOperator("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return quantity{}, err
+		}
+		switch op {
+		case "*":
+			left, err = multiplyQuantities(left, right)
+		case "/":
+			left, err = divideQuantities(left, right)
+		case "%":
+			if left.unit != nil || right.unit != nil {
+				return quantity{}, errors.New("% only works on plain numbers")
+			}
+			if right.value == 0 {
+				return quantity{}, errors.New("division by zero")
+			}
+			left = quantity{value: math.Mod(left.value, right.value)}
+		}
+		if err != nil {
+			return quantity{}, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (quantity, error) {
+	if op, ok := p.isOperator("+", "-"); ok {
+		p.pos++
+		q, err := p.parseUnary()
+		if err != nil {
+			return quantity{}, err
+		}
+		if op == "-" {
+			q.value = -q.value
+		}
+		return q, nil
+	}
+	return p.parsePower()
+}
+
+func (p *parser) parsePower() (quantity, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return quantity{}, err
+	}
+	if _, ok := p.isOperator("^"); !ok {
+		return base, nil
+	}
+	p.pos++
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return quantity{}, err
+	}
+	if base.unit != nil || exponent.unit != nil {
+		return quantity{}, errors.New("^ only works on plain numbers")
+	}
+	return quantity{value: math.Pow(base.value, exponent.value)}, nil
+}
+
+func (p *parser) parsePrimary() (quantity, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		q := quantity{value: tok.number}
+		if u, ok := p.unitSuffix(); ok {
+			q.unit = u
+			p.pos++
+		}
+		return q, nil
+
+	case tokIdent:
+		name := strings.ToLower(tok.text)
+		if fn, ok := functions[name]; ok {
+			if _, ok := p.isOperator("("); !ok {
+				return quantity{}, fmt.Errorf("%s needs an argument, as in %s(2)", name, name)
+			}
+			p.pos++
+			arg, err := p.parseExpr()
+			if err != nil {
+				return quantity{}, err
+			}
+			if _, ok := p.isOperator(")"); !ok {
+				return quantity{}, errors.New("missing )")
+			}
+			p.pos++
+			if arg.unit != nil {
+				return quantity{}, fmt.Errorf("%s only works on plain numbers", name)
+			}
+			return quantity{value: fn(arg.value)}, nil
+		}
+		if value, ok := constants[name]; ok {
+			return quantity{value: value}, nil
+		}
+		if value, ok := p.lookupVar(tok.text); ok {
+			return quantity{value: value}, nil
+		}
+		return quantity{}, fmt.Errorf("unknown name %q", tok.text)
+
+	case tokOperator:
+		if tok.text == "(" {
+			q, err := p.parseExpr()
+			if err != nil {
+				return quantity{}, err
+			}
+			if _, ok := p.isOperator(")"); !ok {
+				return quantity{}, errors.New("missing )")
+			}
+			p.pos++
+			return q, nil
+		}
+		return quantity{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return quantity{}, errors.New("unexpected end of expression")
+}
+
+// unitSuffix returns the unit following a number, if any. A trailing
+// "in <unit>" is a conversion rather than inches, so "5 in cm" fails while
+// "5 in in cm" converts five inches.
+func (p *parser) unitSuffix() (*unit, bool) {
+	tok := p.peek()
+	if tok.kind != tokIdent {
+		return nil, false
+	}
+	u, ok := lookupUnit(tok.text)
+	if !ok {
+		return nil, false
+	}
+	if tok.text == "in" && p.tokens[p.pos+1].kind == tokIdent && p.tokens[p.pos+2].kind == tokEOF {
+		return nil, false
+	}
+	return u, true
+}
+
+func (p *parser) lookupVar(name string) (float64, bool) {
+	name = strings.TrimPrefix(name, "$")
+	if value, ok := p.vars[name]; ok {
+		return value, true
+	}
+	value, ok := p.vars[strings.ToUpper(name)]
+	return value, ok
+}
+
+func addQuantities(left, right quantity, subtract bool) (quantity, error) {
+	if subtract {
+		right.value = -right.value
+	}
+	switch {
+	case left.unit == nil && right.unit == nil:
+		return quantity{value: left.value + right.value}, nil
+	case left.unit == nil || right.unit == nil:
+		return quantity{}, fmt.Errorf("cannot add %s and %s", left, right)
+	case left.unit.dimension == dimTemperature && left.unit != right.unit:
+		return quantity{}, fmt.Errorf("cannot add temperatures in %s and %s", left.unit.name, right.unit.name)
+	}
+	if right.unit.dimension != left.unit.dimension {
+		return quantity{}, fmt.Errorf("cannot add %s (%s) and %s (%s)", left.unit.name, left.unit.dimension, right.unit.name, right.unit.dimension)
+	}
+	// Convert the right-hand side into the left-hand unit
+	converted := right.value * right.unit.factor / left.unit.factor
+	return quantity{value: left.value + converted, unit: left.unit}, nil
+}
+
+func multiplyQuantities(left, right quantity) (quantity, error) {
+	if left.unit != nil && right.unit != nil {
+		return quantity{}, fmt.Errorf("cannot multiply %s by %s", left.unit.name, right.unit.name)
+	}
+	u := left.unit
+	if u == nil {
+		u = right.unit
+	}
+	return quantity{value: left.value * right.value, unit: u}, nil
+}
+
+func divideQuantities(left, right quantity) (quantity, error) {
+	if right.value == 0 {
+		return quantity{}, errors.New("division by zero")
+	}
+	switch {
+	case right.unit == nil:
+		return quantity{value: left.value / right.value, unit: left.unit}, nil
+	case left.unit == nil:
+		return quantity{}, fmt.Errorf("cannot divide a plain number by %s", right.unit.name)
+	case left.unit.dimension != right.unit.dimension || left.unit.dimension == dimTemperature:
+		return quantity{}, fmt.Errorf("cannot divide %s by %s", left.unit.name, right.unit.name)
+	}
+	// A ratio of compatible quantities is a plain number, e.g. 1GiB / 1MiB
+	return quantity{value: left.value * left.unit.factor / (right.value * right.unit.factor)}, nil
+}
@@ -0,0 +1,90 @@
+package calc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]float64{"ANS": 27}
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"= 3*(7+2)", "27"},
+		{"=1+2*3", "7"},
+		{"= 2^3^2", "512"},
+		{"= 2**10", "1024"},
+		{"= -2^2", "-4"},
+		{"= 10 % 4", "2"},
+		{"= 7 / 2", "3.5"},
+		{"= 0.1 + 0.2", "0.3"},
+		{"= 1_000_000 * 3", "3000000"},
+		{"= 0xff + 0b1", "256"},
+		{"= 1.5e3", "1500"},
+		{"= sqrt(16) + abs(-2)", "6"},
+		{"= round(pi * 100) / 100", "3.14"},
+		{"= ans + 1", "28"},
+		{"= $ANS * 2", "54"},
+		{"= 2^64", "18446744073709551616"},
+		{"= 1 / 3e12", "3.33333333333e-13"},
+		{"= 5GiB in MB", "5368.70912 MB"},
+		{"= 5 GiB to MiB", "5120 MiB"},
+		{"= 1.5 gb in mb", "1500 MB"},
+		{"= 1GiB / 1MiB", "1024"},
+		{"= 2GB + 500MB", "2.5 GB"},
+		{"= 100Mbit in MB", "12.5 MB"},
+		{"= 5 in in cm", "12.7 cm"},
+		{"= 3 mi to km", "4.828032 km"},
+		{"= 100 C in F", "212 F"},
+		{"= 32 °F in celsius", "0 C"},
+		{"= 90min in h", "1.5 h"},
+		{"= 2 * 3 lb in kg", "2.72155422 kg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := Evaluate(tt.expr, vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.String())
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		message string
+	}{
+		{"=", "empty expression"},
+		{"= 1 / 0", "division by zero"},
+		{"= (1 + 2", "missing )"},
+		{"= 1 +", "unexpected end of expression"},
+		{"= 2 3", `unexpected "3"`},
+		{"= foo + 1", `unknown name "foo"`},
+		{"= ans", `unknown name "ans"`},
+		{"= 1 & 2", `unexpected character '&'`},
+		{"= 5 GiB in km", "cannot convert GiB (data) to km (length)"},
+		{"= 5 in cm", "cannot convert 5 to cm: it has no unit"},
+		{"= 5 MB in parsecs", `unknown unit "parsecs"`},
+		{"= 1 MB + 1", "cannot add 1 MB and 1"},
+		{"= 2 kg * 3 kg", "cannot multiply kg by kg"},
+		{"= sqrt(-1)", "result is not a finite number"},
+		{"= sqrt 4", "sqrt needs an argument, as in sqrt(2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Evaluate(tt.expr, nil)
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+}
+
+func TestIsExpression(t *testing.T) {
+	assert.True(t, IsExpression("= 1+1"))
+	assert.True(t, IsExpression("  =2*3"))
+	assert.False(t, IsExpression("echo = 1"))
+	assert.False(t, IsExpression("# = 1"))
+}
@@ -0,0 +1,107 @@
+package calc
+
+import "strings"
+
+// dimension groups units that can be converted into each other.
+type dimension string
+
+const (
+	dimData        dimension = "data"
+	dimLength      dimension = "length"
+	dimMass        dimension = "mass"
+	dimTime        dimension = "time"
+	dimTemperature dimension = "temperature"
+)
+
+// unit converts to the base unit of its dimension as value*factor + offset.
+// Only temperatures have an offset.
+type unit struct {
+	name      string
+	dimension dimension
+	factor    float64
+	offset    float64
+}
+
+func (u *unit) toBase(value float64) float64 {
+	return value*u.factor + u.offset
+}
+
+func (u *unit) fromBase(value float64) float64 {
+	return (value - u.offset) / u.factor
+}
+
+// unitTable lists the supported units with their aliases. Lookups try the
+// exact spelling first and fall back to a case-insensitive match, so "mb"
+// finds MB; on collisions the earlier entry wins.
+var unitTable = []struct {
+	unit    unit
+	aliases []string
+}{
+	{unit{"B", dimData, 1, 0}, []string{"B", "byte", "bytes"}},
+	{unit{"KB", dimData, 1e3, 0}, []string{"KB", "kB"}},
+	{unit{"MB", dimData, 1e6, 0}, []string{"MB"}},
+	{unit{"GB", dimData, 1e9, 0}, []string{"GB"}},
+	{unit{"TB", dimData, 1e12, 0}, []string{"TB"}},
+	{unit{"PB", dimData, 1e15, 0}, []string{"PB"}},
+	{unit{"KiB", dimData, 1 << 10, 0}, []string{"KiB"}},
+	{unit{"MiB", dimData, 1 << 20, 0}, []string{"MiB"}},
+	{unit{"GiB", dimData, 1 << 30, 0}, []string{"GiB"}},
+	{unit{"TiB", dimData, 1 << 40, 0}, []string{"TiB"}},
+	{unit{"PiB", dimData, 1 << 50, 0}, []string{"PiB"}},
+	{unit{"bit", dimData, 1.0 / 8, 0}, []string{"bit", "bits"}},
+	{unit{"Kbit", dimData, 1e3 / 8, 0}, []string{"Kbit", "kbit"}},
+	{unit{"Mbit", dimData, 1e6 / 8, 0}, []string{"Mbit"}},
+	{unit{"Gbit", dimData, 1e9 / 8, 0}, []string{"Gbit"}},
+
+	{unit{"mm", dimLength, 1e-3, 0}, []string{"mm"}},
+	{unit{"cm", dimLength, 1e-2, 0}, []string{"cm"}},
+	{unit{"m", dimLength, 1, 0}, []string{"m", "meter", "meters", "metre", "metres"}},
+	{unit{"km", dimLength, 1e3, 0}, []string{"km"}},
+	{unit{"in", dimLength, 0.0254, 0}, []string{"in", "inch", "inches"}},
+	{unit{"ft", dimLength, 0.3048, 0}, []string{"ft", "foot", "feet"}},
+	{unit{"yd", dimLength, 0.9144, 0}, []string{"yd", "yard", "yards"}},
+	{unit{"mi", dimLength, 1609.344, 0}, []string{"mi", "mile", "miles"}},
+
+	{unit{"mg", dimMass, 1e-3, 0}, []string{"mg"}},
+	{unit{"g", dimMass, 1, 0}, []string{"g", "gram", "grams"}},
+	{unit{"kg", dimMass, 1e3, 0}, []string{"kg"}},
+	{unit{"oz", dimMass, 28.349523125, 0}, []string{"oz", "ounce", "ounces"}},
+	{unit{"lb", dimMass, 453.59237, 0}, []string{"lb", "lbs", "pound", "pounds"}},
+
+	{unit{"ms", dimTime, 1e-3, 0}, []string{"ms"}},
+	{unit{"s", dimTime, 1, 0}, []string{"s", "sec", "secs", "second", "seconds"}},
+	{unit{"min", dimTime, 60, 0}, []string{"min", "mins", "minute", "minutes"}},
+	{unit{"h", dimTime, 3600, 0}, []string{"h", "hr", "hrs", "hour", "hours"}},
+	{unit{"d", dimTime, 86400, 0}, []string{"d", "day", "days"}},
+	{unit{"wk", dimTime, 7 * 86400, 0}, []string{"wk", "week", "weeks"}},
+
+	{unit{"K", dimTemperature, 1, 0}, []string{"K", "kelvin"}},
+	{unit{"C", dimTemperature, 1, 273.15}, []string{"C", "°C", "degC", "celsius"}},
+	{unit{"F", dimTemperature, 5.0 / 9, 273.15 - 32*5.0/9}, []string{"F", "°F", "degF", "fahrenheit"}},
+}
+
+var (
+	unitsExact = map[string]*unit{}
+	unitsFold  = map[string]*unit{}
+)
+
+func init() {
+	for i := range unitTable {
+		u := &unitTable[i].unit
+		for _, alias := range unitTable[i].aliases {
+			unitsExact[alias] = u
+			if _, ok := unitsFold[strings.ToLower(alias)]; !ok {
+				unitsFold[strings.ToLower(alias)] = u
+			}
+		}
+	}
+}
+
+// lookupUnit finds a unit by name or alias.
+func lookupUnit(name string) (*unit, bool) {
+	if u, ok := unitsExact[name]; ok {
+		return u, true
+	}
+	u, ok := unitsFold[strings.ToLower(name)]
+	return u, ok
+}
@@ -0,0 +1,157 @@
+// Package captures keeps the output of commands run as `cmd |> name` for the
+// rest of the session, so that later commands can read it as @name without
+// running cmd again, and the agent can be shown it.
+package captures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCaptureSize caps how much of a command's output is kept. The start
+	// is kept, as a file redirect would have it.
+	maxCaptureSize = 16 << 20
+	// maxAttachedSize caps how much of a capture is shown to the agent. The
+	// end is kept since that is where errors and results usually are.
+	maxAttachedSize = 16 * 1024
+)
+
+// validName matches the names captures can be given.
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Capture is the output of a command kept under a name.
+type Capture struct {
+	Name    string
+	Command string
+	Data    []byte
+	// Truncated is set if the output was longer than could be kept
+	Truncated bool
+	Time      time.Time
+	// Path is the file holding Data, which @name stands for in commands
+	Path string
+}
+
+// Summary describes the capture on one line for the captures builtin.
+func (c Capture) Summary() string {
+	size := fmt.Sprintf("%d bytes", len(c.Data))
+	if c.Truncated {
+		size += ", truncated"
+	}
+	return fmt.Sprintf("@%-12s %s  %s  (%s)", c.Name, c.Time.Format("15:04:05"), c.Command, size)
+}
+
+// Store keeps the captures of a session by name, each in a file of dir.
+type Store struct {
+	mu       sync.Mutex
+	dir      string
+	captures map[string]Capture
+}
+
+// DefaultStore is the session-wide store used by the |> operator, @name
+// references and the captures builtin.
+var DefaultStore = NewStore(filepath.Join(os.TempDir(), fmt.Sprintf("bish-captures-%d", os.Getpid())))
+
+// NewStore creates a Store keeping its files in dir, which is created when
+// the first capture is saved.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, captures: map[string]Capture{}}
+}
+
+// Save keeps data as the output of command under name, replacing any
+// earlier capture of that name.
+func (s *Store) Save(name, command string, data []byte, truncated bool) (Capture, error) {
+	if !validName.MatchString(name) {
+		return Capture{}, fmt.Errorf("invalid capture name %q", name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return Capture{}, err
+	}
+	c := Capture{
+		Name:      name,
+		Command:   command,
+		Data:      data,
+		Truncated: truncated,
+		Time:      time.Now(),
+		Path:      filepath.Join(s.dir, name),
+	}
+	if err := os.WriteFile(c.Path, data, 0o600); err != nil {
+		return Capture{}, err
+	}
+	s.captures[name] = c
+	return c, nil
+}
+
+// Get returns the capture named name.
+func (s *Store) Get(name string) (Capture, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.captures[name]
+	return c, ok
+}
+
+// List returns all captures by name.
+func (s *Store) List() []Capture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Capture, 0, len(s.captures))
+	for _, c := range s.captures {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Remove deletes the capture named name and its file.
+func (s *Store) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.captures[name]
+	if ok {
+		delete(s.captures, name)
+		_ = os.Remove(c.Path)
+	}
+	return ok
+}
+
+// Clear deletes all captures and their files, e.g. when the shell exits.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captures = map[string]Capture{}
+	_ = os.RemoveAll(s.dir)
+}
+
+// Buffer collects a command's output for a capture, up to maxCaptureSize.
+type Buffer struct {
+	mu        sync.Mutex
+	data      []byte
+	truncated bool
+}
+
+// Write never fails, so that the command's output to the terminal is not cut
+// short.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	room := maxCaptureSize - len(b.data)
+	if len(p) > room {
+		b.truncated = true
+	}
+	b.data = append(b.data, p[:max(0, min(len(p), room))]...)
+	return len(p), nil
+}
+
+// Save keeps what was written to b in s as the output of command.
+func (b *Buffer) Save(s *Store, name, command string) (Capture, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return s.Save(name, command, b.data, b.truncated)
+}
@@ -0,0 +1,160 @@
+package captures
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "captures")
+	s := NewStore(dir)
+
+	c, err := s.Save("build", "make", []byte("ok\n"), false)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "build"), c.Path)
+	data, err := os.ReadFile(c.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "ok\n", string(data))
+
+	_, err = s.Save("../etc", "make", nil, false)
+	assert.Error(t, err)
+
+	_, err = s.Save("all", "ls", []byte("a b\n"), false)
+	require.NoError(t, err)
+	list := s.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "all", list[0].Name)
+	assert.Equal(t, "build", list[1].Name)
+
+	assert.True(t, s.Remove("build"))
+	assert.False(t, s.Remove("build"))
+	assert.NoFileExists(t, c.Path)
+
+	s.Clear()
+	assert.Empty(t, s.List())
+	assert.NoDirExists(t, dir)
+}
+
+func TestBuffer(t *testing.T) {
+	b := &Buffer{data: make([]byte, maxCaptureSize-2)}
+	n, err := b.Write([]byte("abcd"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.True(t, b.truncated)
+	assert.Equal(t, "ab", string(b.data[maxCaptureSize-2:]))
+
+	s := NewStore(t.TempDir())
+	c, err := (&Buffer{}).Save(s, "empty", "true")
+	require.NoError(t, err)
+	assert.False(t, c.Truncated)
+	assert.Empty(t, c.Data)
+}
+
+func TestParseOperator(t *testing.T) {
+	tests := []struct {
+		line    string
+		command string
+		name    string
+		ok      bool
+	}{
+		{"make |> build", "make", "build", true},
+		{"kubectl get pods -A|>pods", "kubectl get pods -A", "pods", true},
+		{"grep x file | sort |> sorted", "grep x file | sort", "sorted", true},
+		{"make | > build", "", "", false},
+		{"make > build", "", "", false},
+		{"make |> build.log", "", "", false},
+		{"echo '|> build'", "", "", false},
+		{"make |> build &", "", "", false},
+		{"make |> build; ls", "", "", false},
+	}
+	for _, tt := range tests {
+		command, name, ok := ParseOperator(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.command, command, tt.line)
+		assert.Equal(t, tt.name, name, tt.line)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "with space"))
+	c, err := s.Save("build", "make", []byte("error: x\n"), false)
+	require.NoError(t, err)
+	quoted, err := syntax.Quote(c.Path, syntax.LangBash)
+	require.NoError(t, err)
+
+	assert.Equal(t, "grep err "+quoted, Expand("grep err @build", s))
+	assert.Equal(t, "wc -l $(cat "+quoted+")", Expand("wc -l $(cat @build)", s))
+	assert.Equal(t, "ssh user@build", Expand("ssh user@build", s))
+	assert.Equal(t, "cat @other", Expand("cat @other", s))
+	assert.Equal(t, "echo '@build'", Expand("echo '@build'", s))
+	assert.Equal(t, "echo (", Expand("echo (", s))
+}
+
+func TestAttach(t *testing.T) {
+	s := NewStore(t.TempDir())
+	_, err := s.Save("pods", "kubectl get pods", []byte("web-1 CrashLoopBackOff\n"), false)
+	require.NoError(t, err)
+
+	message := Attach("why is a pod in @pods failing? (see @pods, @nope)", s)
+	assert.True(t, strings.HasPrefix(message, "why is a pod in @pods failing?"))
+	assert.Equal(t, 1, strings.Count(message, "web-1 CrashLoopBackOff"))
+	assert.Contains(t, message, "@pods is the output of `kubectl get pods`")
+	assert.NotContains(t, message, "@nope is")
+
+	assert.Equal(t, "mail me@pods.com", Attach("mail me@pods.com", s))
+}
+
+func runCaptures(t *testing.T, s *Store, script string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &out, &out),
+		interp.ExecHandlers(NewCapturesCommandHandler(s)),
+	)
+	require.NoError(t, err)
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return out.String(), err
+}
+
+func TestCapturesBuiltin(t *testing.T) {
+	s := NewStore(t.TempDir())
+	out, err := runCaptures(t, s, "captures")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No captures yet")
+
+	c, err := s.Save("build", "make", []byte("done\n"), false)
+	require.NoError(t, err)
+
+	out, err = runCaptures(t, s, "captures list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "@build")
+	assert.Contains(t, out, "make")
+
+	out, err = runCaptures(t, s, "captures show @build")
+	require.NoError(t, err)
+	assert.Equal(t, "done\n", out)
+
+	out, err = runCaptures(t, s, "captures path build")
+	require.NoError(t, err)
+	assert.Equal(t, c.Path+"\n", out)
+
+	_, err = runCaptures(t, s, "captures clear nope")
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), status)
+
+	_, err = runCaptures(t, s, "captures clear build")
+	require.NoError(t, err)
+	assert.Empty(t, s.List())
+}
@@ -0,0 +1,87 @@
+package captures
+
+import (
+	"context"
+	"fmt"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: captures [list]\n" +
+	"       captures show NAME\n" +
+	"       captures path NAME\n" +
+	"       captures clear [NAME...]"
+
+// NewCapturesCommandHandler creates an ExecHandler for the captures builtin,
+// which lists, prints and clears the output kept with `cmd |> name`.
+func NewCapturesCommandHandler(s *Store) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "captures" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			args = args[1:]
+			if len(args) == 0 || args[0] == "list" || args[0] == "ls" {
+				list := s.List()
+				if len(list) == 0 {
+					fmt.Fprintln(hc.Stdout, "No captures yet. Run a command as `cmd |> name` to keep its output as @name.")
+				}
+				for _, c := range list {
+					fmt.Fprintln(hc.Stdout, c.Summary())
+				}
+				return nil
+			}
+
+			switch args[0] {
+			case "-h", "--help", "help":
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+			case "show", "path":
+				if len(args) != 2 {
+					fmt.Fprintln(hc.Stderr, usage)
+					return interp.NewExitStatus(2)
+				}
+				c, ok := s.Get(trimAt(args[1]))
+				if !ok {
+					fmt.Fprintf(hc.Stderr, "captures: no capture named %s\n", args[1])
+					return interp.NewExitStatus(1)
+				}
+				if args[0] == "path" {
+					fmt.Fprintln(hc.Stdout, c.Path)
+				} else {
+					_, _ = hc.Stdout.Write(c.Data)
+				}
+				return nil
+			case "clear", "rm":
+				if len(args) == 1 {
+					s.Clear()
+					return nil
+				}
+				status := 0
+				for _, name := range args[1:] {
+					if !s.Remove(trimAt(name)) {
+						fmt.Fprintf(hc.Stderr, "captures: no capture named %s\n", name)
+						status = 1
+					}
+				}
+				if status != 0 {
+					return interp.NewExitStatus(uint8(status))
+				}
+				return nil
+			default:
+				fmt.Fprintf(hc.Stderr, "captures: unknown subcommand %s\n%s\n", args[0], usage)
+				return interp.NewExitStatus(2)
+			}
+		}
+	}
+}
+
+// trimAt lets captures be named with or without their @.
+func trimAt(name string) string {
+	if len(name) > 1 && name[0] == '@' {
+		return name[1:]
+	}
+	return name
+}
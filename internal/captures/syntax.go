@@ -0,0 +1,108 @@
+package captures
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ParseOperator splits `cmd |> name` into cmd and name. The shell would read
+// |> as a pipe into a redirect to the file name, so the operator has to be
+// taken off the line before it runs. ok is false for any other line.
+func ParseOperator(line string) (command, name string, ok bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return "", "", false
+	}
+	pipe, isBinary := file.Stmts[0].Cmd.(*syntax.BinaryCmd)
+	if !isBinary || pipe.Op != syntax.Pipe || file.Stmts[0].Background || len(file.Stmts[0].Redirs) > 0 {
+		return "", "", false
+	}
+	// The right side must be nothing but "> name", right after the pipe
+	target := pipe.Y
+	if target.Cmd != nil || len(target.Redirs) != 1 || target.Background || target.Negated {
+		return "", "", false
+	}
+	redirect := target.Redirs[0]
+	if redirect.Op != syntax.RdrOut || redirect.N != nil || redirect.OpPos.Offset() != pipe.OpPos.Offset()+1 {
+		return "", "", false
+	}
+	name = redirect.Word.Lit()
+	if !validName.MatchString(name) {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:pipe.X.End().Offset()]), name, true
+}
+
+// Expand replaces the words @name in line that name a capture in s with the
+// path of the file holding it, so that e.g. `grep err @build` reads the
+// captured output. Other words, such as user@host, are left alone, as is the
+// line if it does not parse.
+func Expand(line string, s *Store) string {
+	if !strings.Contains(line, "@") {
+		return line
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return line
+	}
+	type replacement struct {
+		start, end int
+		path       string
+	}
+	var replacements []replacement
+	syntax.Walk(file, func(node syntax.Node) bool {
+		word, ok := node.(*syntax.Word)
+		if !ok {
+			return true
+		}
+		name, found := strings.CutPrefix(word.Lit(), "@")
+		c, ok := s.Get(name)
+		if !found || !ok {
+			// Look into command substitutions and the like
+			return true
+		}
+		if path, err := syntax.Quote(c.Path, syntax.LangBash); err == nil {
+			replacements = append(replacements, replacement{int(word.Pos().Offset()), int(word.End().Offset()), path})
+		}
+		return false
+	})
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	for _, r := range replacements {
+		line = line[:r.start] + r.path + line[r.end:]
+	}
+	return line
+}
+
+// mentionPattern matches @name mentions in a chat message.
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(])@([A-Za-z_][A-Za-z0-9_-]*)`)
+
+// Attach appends the captures in s that message mentions as @name to it, so
+// that the agent sees their output.
+func Attach(message string, s *Store) string {
+	seen := map[string]bool{}
+	var sb strings.Builder
+	sb.WriteString(message)
+	for _, match := range mentionPattern.FindAllStringSubmatch(message, -1) {
+		name := match[1]
+		c, ok := s.Get(name)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		data, note := c.Data, ""
+		if len(data) > maxAttachedSize {
+			data = data[len(data)-maxAttachedSize:]
+			note = fmt.Sprintf(" Only its last %d bytes are shown.", maxAttachedSize)
+		}
+		text := strings.TrimRight(string(data), "\n")
+		if text == "" {
+			text = "(no output)"
+		}
+		fmt.Fprintf(&sb, "\n\n@%s is the output of `%s`, captured with |>.%s\n```\n%s\n```", name, c.Command, note, text)
+	}
+	return sb.String()
+}
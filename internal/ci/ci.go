@@ -0,0 +1,148 @@
+// Package ci follows the CI runs of the branch checked out in the shell: the
+// latest run of GitHub Actions, through the gh command, or of GitLab CI,
+// through its API. The shell shows its status in the border, tells when a
+// run finishes and gives the agent the log of a failing job.
+package ci
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// State is how far a run got.
+type State string
+
+const (
+	Pending   State = "pending"
+	Success   State = "success"
+	Failure   State = "failure"
+	Cancelled State = "cancelled"
+)
+
+// Run is a CI run of a branch.
+type Run struct {
+	ID     string
+	Name   string
+	Branch string
+	State  State
+	URL    string
+}
+
+// Provider reads the CI runs of a repository.
+type Provider interface {
+	// LatestRun returns the latest run of branch, and false if it has none.
+	LatestRun(ctx context.Context, branch string) (Run, bool, error)
+	// FailedLog returns the end of the log of the jobs of run that failed.
+	FailedLog(ctx context.Context, run Run) (string, error)
+}
+
+// Remote is where a repository is hosted.
+type Remote struct {
+	Host string
+	// Path is the path of the repository on the host, e.g. owner/name
+	Path string
+}
+
+// scpRemote matches remotes written as in git@github.com:owner/name.git.
+var scpRemote = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ParseRemote parses the URL of a git remote, such as
+// https://github.com/owner/name.git, ssh://git@gitlab.com/group/name or
+// git@github.com:owner/name.git.
+func ParseRemote(remote string) (Remote, bool) {
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil || u.Hostname() == "" {
+			return Remote{}, false
+		}
+		host, path = u.Hostname(), u.Path
+	} else if m := scpRemote.FindStringSubmatch(remote); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return Remote{}, false
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if !strings.Contains(path, "/") {
+		return Remote{}, false
+	}
+	return Remote{Host: strings.ToLower(host), Path: path}, true
+}
+
+// IsGitLabHost reports whether $GITLAB_TOKEN may be sent to host: gitlab.com
+// and the hosts listed in $BISH_GITLAB_HOSTS, separated by commas or spaces.
+// Other hosts could be anyone's, whatever their name.
+func IsGitLabHost(host string, getenv func(string) string) bool {
+	if host == "gitlab.com" {
+		return true
+	}
+	hosts := strings.FieldsFunc(getenv("BISH_GITLAB_HOSTS"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, allowed := range hosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderFor returns the provider for the runs of the repository at
+// remote: gh for GitHub, when it is installed, and the API of GitLab hosts,
+// per IsGitLabHost, with the token in $GITLAB_TOKEN. It returns false for
+// other hosts.
+func ProviderFor(remote Remote, getenv func(string) string, lookPath func(string) (string, error)) (Provider, bool) {
+	switch {
+	case remote.Host == "github.com":
+		if _, err := lookPath("gh"); err != nil {
+			return nil, false
+		}
+		return &github{repo: remote.Path, run: runCommand}, true
+	case IsGitLabHost(remote.Host, getenv):
+		token := getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, false
+		}
+		return &gitlab{baseURL: "https://" + remote.Host, project: remote.Path, token: token}, true
+	}
+	return nil, false
+}
+
+const (
+	// maxLogLines and maxLogBytes bound the log excerpt of a failed run.
+	maxLogLines = 80
+	maxLogBytes = 8000
+	// requestTimeout bounds each call to a CI service.
+	requestTimeout = 15 * time.Second
+)
+
+// ansiEscape matches the color sequences of CI logs.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// logExcerpt returns the last lines of log, where failures are reported,
+// without colors or carriage-return progress.
+func logExcerpt(log string) string {
+	log = ansiEscape.ReplaceAllString(log, "")
+	lines := strings.Split(strings.TrimRight(log, "\n"), "\n")
+	for i, line := range lines {
+		// Keep what a terminal would show of lines redrawn with \r
+		if j := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = strings.TrimRight(line, "\r ")
+	}
+	if len(lines) > maxLogLines {
+		lines = lines[len(lines)-maxLogLines:]
+	}
+	excerpt := strings.Join(lines, "\n")
+	if len(excerpt) > maxLogBytes {
+		excerpt = excerpt[len(excerpt)-maxLogBytes:]
+		if i := strings.IndexByte(excerpt, '\n'); i >= 0 {
+			excerpt = excerpt[i+1:]
+		}
+	}
+	return excerpt
+}
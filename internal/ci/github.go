@@ -0,0 +1,108 @@
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commandFunc runs a program and returns its output.
+type commandFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand runs name, with what it printed on stderr as the error.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// github reads the runs of GitHub Actions with gh, which is already signed
+// in for most people who use GitHub from the terminal.
+type github struct {
+	// repo is owner/name
+	repo string
+	run  commandFunc
+}
+
+// githubRun is a run as gh run list --json prints it.
+type githubRun struct {
+	DatabaseID   int64  `json:"databaseId"`
+	Status       string `json:"status"`
+	Conclusion   string `json:"conclusion"`
+	WorkflowName string `json:"workflowName"`
+	URL          string `json:"url"`
+}
+
+func (g *github) LatestRun(ctx context.Context, branch string) (Run, bool, error) {
+	out, err := g.run(ctx, "gh", "run", "list", "--repo", g.repo, "--branch", branch, "--limit", "1",
+		"--json", "databaseId,status,conclusion,workflowName,url")
+	if err != nil {
+		return Run{}, false, fmt.Errorf("gh run list: %w", err)
+	}
+	var runs []githubRun
+	if err := json.Unmarshal(out, &runs); err != nil {
+		return Run{}, false, fmt.Errorf("gh run list: %w", err)
+	}
+	if len(runs) == 0 {
+		return Run{}, false, nil
+	}
+	r := runs[0]
+	return Run{
+		ID:     strconv.FormatInt(r.DatabaseID, 10),
+		Name:   r.WorkflowName,
+		Branch: branch,
+		State:  githubState(r.Status, r.Conclusion),
+		URL:    r.URL,
+	}, true, nil
+}
+
+// githubState maps the status and conclusion of a GitHub run to a State.
+func githubState(status, conclusion string) State {
+	if status != "completed" {
+		return Pending
+	}
+	switch conclusion {
+	case "success", "neutral":
+		return Success
+	case "cancelled", "skipped", "stale":
+		return Cancelled
+	case "action_required":
+		return Pending
+	}
+	return Failure
+}
+
+func (g *github) FailedLog(ctx context.Context, run Run) (string, error) {
+	out, err := g.run(ctx, "gh", "run", "view", run.ID, "--repo", g.repo, "--log-failed")
+	if err != nil {
+		return "", fmt.Errorf("gh run view: %w", err)
+	}
+	// gh prints each line as job, step and a timestamped message separated
+	// by tabs; the job and the message are what matter
+	lines := strings.Split(string(out), "\n")
+	for i, line := range lines {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		message := parts[2]
+		if timestamp, rest, ok := strings.Cut(message, " "); ok {
+			if _, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+				message = rest
+			}
+		}
+		lines[i] = parts[0] + ": " + message
+	}
+	return logExcerpt(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,60 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubLatestRun(t *testing.T) {
+	var called []string
+	g := &github{repo: "owner/name", run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		called = append([]string{name}, args...)
+		return []byte(`[{"databaseId":42,"status":"completed","conclusion":"failure","workflowName":"CI","url":"https://github.com/owner/name/actions/runs/42"}]`), nil
+	}}
+
+	run, found, err := g.LatestRun(context.Background(), "main")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Run{ID: "42", Name: "CI", Branch: "main", State: Failure, URL: "https://github.com/owner/name/actions/runs/42"}, run)
+	assert.Equal(t, "gh run list --repo owner/name --branch main --limit 1 --json databaseId,status,conclusion,workflowName,url", strings.Join(called, " "))
+}
+
+func TestGitHubLatestRunNone(t *testing.T) {
+	g := &github{repo: "owner/name", run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("[]"), nil
+	}}
+	_, found, err := g.LatestRun(context.Background(), "main")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	g.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, errors.New("To get started with GitHub CLI, please run: gh auth login")
+	}
+	_, _, err = g.LatestRun(context.Background(), "main")
+	assert.ErrorContains(t, err, "gh auth login")
+}
+
+func TestGitHubState(t *testing.T) {
+	assert.Equal(t, Pending, githubState("in_progress", ""))
+	assert.Equal(t, Pending, githubState("queued", ""))
+	assert.Equal(t, Success, githubState("completed", "success"))
+	assert.Equal(t, Failure, githubState("completed", "failure"))
+	assert.Equal(t, Failure, githubState("completed", "timed_out"))
+	assert.Equal(t, Cancelled, githubState("completed", "cancelled"))
+}
+
+func TestGitHubFailedLog(t *testing.T) {
+	g := &github{repo: "owner/name", run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, "run view 42 --repo owner/name --log-failed", strings.Join(args, " "))
+		return []byte("test\tRun go test\t2024-05-01T10:00:00.1234567Z --- FAIL: TestParse (0.00s)\n" +
+			"test\tRun go test\t2024-05-01T10:00:00.2234567Z FAIL\n"), nil
+	}}
+	log, err := g.FailedLog(context.Background(), Run{ID: "42"})
+	require.NoError(t, err)
+	assert.Equal(t, "test: --- FAIL: TestParse (0.00s)\ntest: FAIL", log)
+}
@@ -0,0 +1,125 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// maxResponseBytes bounds what is read of a reply, job logs included.
+const maxResponseBytes = 32 << 20
+
+// gitlab reads the pipelines of GitLab CI through the API of the host.
+type gitlab struct {
+	baseURL string
+	// project is the path of the project, e.g. group/name
+	project string
+	token   string
+	client  *http.Client
+}
+
+// gitlabPipeline and gitlabJob are what the API returns for pipelines and
+// jobs.
+type gitlabPipeline struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	WebURL string `json:"web_url"`
+}
+
+type gitlabJob struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (g *gitlab) LatestRun(ctx context.Context, branch string) (Run, bool, error) {
+	var pipelines []gitlabPipeline
+	query := url.Values{"ref": {branch}, "per_page": {"1"}}
+	if err := g.get(ctx, "/pipelines?"+query.Encode(), &pipelines); err != nil {
+		return Run{}, false, err
+	}
+	if len(pipelines) == 0 {
+		return Run{}, false, nil
+	}
+	p := pipelines[0]
+	return Run{
+		ID:     strconv.FormatInt(p.ID, 10),
+		Name:   "pipeline #" + strconv.FormatInt(p.ID, 10),
+		Branch: branch,
+		State:  gitlabState(p.Status),
+		URL:    p.WebURL,
+	}, true, nil
+}
+
+// gitlabState maps the status of a GitLab pipeline to a State.
+func gitlabState(status string) State {
+	switch status {
+	case "success":
+		return Success
+	case "failed":
+		return Failure
+	case "canceled", "skipped":
+		return Cancelled
+	}
+	// created, waiting_for_resource, preparing, pending, running, manual
+	// and scheduled pipelines are still to finish
+	return Pending
+}
+
+func (g *gitlab) FailedLog(ctx context.Context, run Run) (string, error) {
+	var jobs []gitlabJob
+	if err := g.get(ctx, "/pipelines/"+run.ID+"/jobs?scope[]=failed", &jobs); err != nil {
+		return "", err
+	}
+	if len(jobs) == 0 {
+		return "", fmt.Errorf("no failed jobs in %s", run.Name)
+	}
+	job := jobs[0]
+	trace, err := g.request(ctx, "/jobs/"+strconv.FormatInt(job.ID, 10)+"/trace")
+	if err != nil {
+		return "", err
+	}
+	return job.Name + ":\n" + logExcerpt(string(trace)), nil
+}
+
+// get requests path in the API of the project and decodes the JSON reply.
+func (g *gitlab) get(ctx context.Context, path string, v any) error {
+	body, err := g.request(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("gitlab: %w", err)
+	}
+	return nil
+}
+
+// request requests path in the API of the project.
+func (g *gitlab) request(ctx context.Context, path string) ([]byte, error) {
+	endpoint := g.baseURL + "/api/v4/projects/" + url.PathEscape(g.project) + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	client := g.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitlab: %s", resp.Status)
+	}
+	return body, nil
+}
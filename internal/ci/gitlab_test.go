@@ -0,0 +1,67 @@
+package ci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGitLabServer(t *testing.T) *gitlab {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.RequestURI() {
+		case "/api/v4/projects/group%2Fname/pipelines?per_page=1&ref=main":
+			_, _ = w.Write([]byte(`[{"id":7,"status":"failed","web_url":"https://gitlab.example.com/group/name/-/pipelines/7"}]`))
+		case "/api/v4/projects/group%2Fname/pipelines?per_page=1&ref=new":
+			_, _ = w.Write([]byte(`[]`))
+		case "/api/v4/projects/group%2Fname/pipelines/7/jobs?scope[]=failed":
+			_, _ = w.Write([]byte(`[{"id":99,"name":"unit-tests"}]`))
+		case "/api/v4/projects/group%2Fname/jobs/99/trace":
+			_, _ = w.Write([]byte("\x1b[0KRunning tests\n\x1b[31;1mFAIL test_parse\x1b[0m\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &gitlab{baseURL: server.URL, project: "group/name", token: "secret", client: server.Client()}
+}
+
+func TestGitLabLatestRun(t *testing.T) {
+	g := newGitLabServer(t)
+
+	run, found, err := g.LatestRun(context.Background(), "main")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Run{ID: "7", Name: "pipeline #7", Branch: "main", State: Failure, URL: "https://gitlab.example.com/group/name/-/pipelines/7"}, run)
+
+	_, found, err = g.LatestRun(context.Background(), "new")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	g.token = "wrong"
+	_, _, err = g.LatestRun(context.Background(), "main")
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestGitLabFailedLog(t *testing.T) {
+	g := newGitLabServer(t)
+	log, err := g.FailedLog(context.Background(), Run{ID: "7", Name: "pipeline #7"})
+	require.NoError(t, err)
+	assert.Equal(t, "unit-tests:\nRunning tests\nFAIL test_parse", log)
+}
+
+func TestGitLabState(t *testing.T) {
+	assert.Equal(t, Pending, gitlabState("running"))
+	assert.Equal(t, Pending, gitlabState("created"))
+	assert.Equal(t, Success, gitlabState("success"))
+	assert.Equal(t, Failure, gitlabState("failed"))
+	assert.Equal(t, Cancelled, gitlabState("canceled"))
+}
@@ -0,0 +1,137 @@
+package ci
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// idleInterval is how often the latest run of a branch is checked, and
+	// pendingInterval how often while it has not finished.
+	idleInterval    = time.Minute
+	pendingInterval = 15 * time.Second
+	// maxFailures caps the backoff after failed checks, which wait twice as
+	// long after each failure.
+	maxFailures = 4
+)
+
+// Target is a branch whose runs are followed.
+type Target struct {
+	Remote   Remote
+	Branch   string
+	Provider Provider
+}
+
+func (t Target) key() string {
+	return t.Remote.Host + "/" + t.Remote.Path + "@" + t.Branch
+}
+
+// watched is what is known about the latest run of a target.
+type watched struct {
+	run       Run
+	found     bool
+	checkedAt time.Time
+	checking  bool
+	failures  int
+}
+
+// Watcher keeps the latest run of each branch the shell is on, checking it
+// in the background, and collects the runs that finish meanwhile.
+type Watcher struct {
+	mu       sync.Mutex
+	targets  map[string]*watched
+	finished []Run
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// OnFinish is called, without the lock, when a run seen pending has
+	// finished
+	OnFinish func(Run)
+}
+
+// NewWatcher creates a watcher that knows no runs yet.
+func NewWatcher() *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{targets: make(map[string]*watched), ctx: ctx, cancel: cancel}
+}
+
+// DefaultWatcher follows the branches of the interactive shell.
+var DefaultWatcher = NewWatcher()
+
+// Latest returns the last known run of t without waiting, and false if
+// none is known yet. If the run was last checked long enough before now, it
+// is checked again in the background.
+func (w *Watcher) Latest(t Target, now time.Time) (Run, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	target, ok := w.targets[t.key()]
+	if !ok {
+		target = &watched{}
+		w.targets[t.key()] = target
+	}
+	interval := idleInterval
+	if target.found && target.run.State == Pending {
+		interval = pendingInterval
+	}
+	if !target.checking && !now.Before(target.checkedAt.Add(interval<<target.failures)) {
+		target.checking = true
+		go w.check(t, target)
+	}
+	return target.run, target.found
+}
+
+// check reads the latest run of t. A run seen pending that has now finished
+// is added to those TakeFinished returns.
+func (w *Watcher) check(t Target, target *watched) {
+	ctx, cancel := context.WithTimeout(w.ctx, requestTimeout)
+	defer cancel()
+	run, found, err := t.Provider.LatestRun(ctx, t.Branch)
+
+	w.mu.Lock()
+	target.checking = false
+	target.checkedAt = time.Now()
+	if err != nil {
+		target.failures = min(target.failures+1, maxFailures)
+		w.mu.Unlock()
+		return
+	}
+	target.failures = 0
+	finished := found && run.State != Pending && target.found && target.run.State == Pending
+	if finished {
+		w.finished = append(w.finished, run)
+	}
+	target.run, target.found = run, found
+	onFinish := w.OnFinish
+	w.mu.Unlock()
+
+	if finished && onFinish != nil {
+		onFinish(run)
+	}
+}
+
+// TakeFinished returns the runs that finished since it was last called.
+func (w *Watcher) TakeFinished() []Run {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	finished := w.finished
+	w.finished = nil
+	return finished
+}
+
+// Stop stops the checks in progress and keeps new ones from succeeding.
+func (w *Watcher) Stop() {
+	w.cancel()
+}
+
+// Symbol returns how the border status shows the state of a run.
+func (r Run) Symbol() string {
+	switch r.State {
+	case Success:
+		return "✓"
+	case Failure:
+		return "✗"
+	case Cancelled:
+		return "⊘"
+	}
+	return "…"
+}
@@ -0,0 +1,96 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider returns the runs it is given, counting the checks.
+type fakeProvider struct {
+	mu     sync.Mutex
+	run    Run
+	err    error
+	checks int
+}
+
+func (f *fakeProvider) LatestRun(ctx context.Context, branch string) (Run, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checks++
+	return f.run, f.run.ID != "", f.err
+}
+
+func (f *fakeProvider) FailedLog(ctx context.Context, run Run) (string, error) {
+	return "", nil
+}
+
+func (f *fakeProvider) set(run Run, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.run, f.err = run, err
+}
+
+func (f *fakeProvider) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.checks
+}
+
+func TestWatcher(t *testing.T) {
+	provider := &fakeProvider{run: Run{ID: "1", Name: "CI", Branch: "main", State: Pending}}
+	target := Target{Remote: Remote{Host: "github.com", Path: "o/n"}, Branch: "main", Provider: provider}
+	w := NewWatcher()
+	defer w.Stop()
+	finished := make(chan Run, 1)
+	w.OnFinish = func(run Run) { finished <- run }
+
+	// The first call only starts the check
+	now := time.Now()
+	_, ok := w.Latest(target, now)
+	assert.False(t, ok)
+	require.Eventually(t, func() bool { _, ok := w.Latest(target, now); return ok }, time.Second, 5*time.Millisecond)
+	run, _ := w.Latest(target, now)
+	assert.Equal(t, Pending, run.State)
+	assert.Equal(t, 1, provider.count())
+
+	// A pending run is checked more often, and its end is reported once
+	provider.set(Run{ID: "1", Name: "CI", Branch: "main", State: Failure}, nil)
+	w.Latest(target, time.Now().Add(pendingInterval))
+	assert.Equal(t, Failure, (<-finished).State)
+	require.Eventually(t, func() bool { run, _ := w.Latest(target, now); return run.State == Failure }, time.Second, 5*time.Millisecond)
+	taken := w.TakeFinished()
+	require.Len(t, taken, 1)
+	assert.Equal(t, "1", taken[0].ID)
+	assert.Empty(t, w.TakeFinished())
+
+	// A finished run is checked less often, and not reported again
+	w.Latest(target, time.Now().Add(pendingInterval))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 2, provider.count())
+	assert.Empty(t, w.TakeFinished())
+}
+
+func TestWatcherFailedCheck(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("offline")}
+	target := Target{Remote: Remote{Host: "github.com", Path: "o/n"}, Branch: "main", Provider: provider}
+	w := NewWatcher()
+	defer w.Stop()
+
+	now := time.Now()
+	w.Latest(target, now)
+	require.Eventually(t, func() bool { return provider.count() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// After a failure, the next check waits twice as long
+	w.Latest(target, time.Now().Add(idleInterval))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, provider.count())
+	w.Latest(target, time.Now().Add(2*idleInterval))
+	require.Eventually(t, func() bool { return provider.count() == 2 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,163 @@
+// Package cmdchain learns which command usually follows another, such as
+// git commit after git add -A, from the order commands ran in each session.
+package cmdchain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+const (
+	// SampleSize is how many recent history entries are considered.
+	SampleSize = 5000
+
+	// A follow-up is suggested once the preceding commands have been seen
+	// followed by something at least minUses times, and by it in at least
+	// minShare of them.
+	minUses  = 3
+	minShare = 0.5
+)
+
+// Suggestion is the command that usually follows After.
+type Suggestion struct {
+	// Command is the follow-up. When the follow-up varies only in its
+	// arguments, as git commit -m does, it is the common start followed by
+	// a space, e.g. "git commit ".
+	Command string
+	// After is the preceding commands it follows, oldest first.
+	After []string
+	// Uses is how many times After was followed by Command, out of Total.
+	Uses  int
+	Total int
+}
+
+// Note tells where the suggestion comes from, for the assistant box.
+func (s Suggestion) Note() string {
+	return fmt.Sprintf("From your history: after `%s` you usually run `%s` next (%d of %d times)",
+		strings.Join(s.After, "; "), strings.TrimSpace(s.Command), s.Uses, s.Total)
+}
+
+// Suggest returns the usual follow-up to the last commands of a session,
+// learned from entries, which are ordered most recent first. recent are the
+// session's last commands, most recent first. A follow-up to the last two
+// commands is preferred to one of the last command alone.
+func Suggest(entries []history.HistoryEntry, recent []string) (Suggestion, bool) {
+	if len(recent) == 0 || recent[0] == "" {
+		return Suggestion{}, false
+	}
+	if len(entries) > SampleSize {
+		entries = entries[:SampleSize]
+	}
+	if len(recent) > 1 && recent[1] != "" {
+		if s, ok := suggest(entries, []string{recent[1], recent[0]}); ok {
+			return s, true
+		}
+	}
+	return suggest(entries, recent[:1])
+}
+
+// suggest finds the usual follow-up to after, oldest first.
+func suggest(entries []history.HistoryEntry, after []string) (Suggestion, bool) {
+	last := after[len(after)-1]
+	commands := map[string]int{}
+	stems := map[string]int{}
+	total := 0
+	// entries[i] ran right after entries[i+1], entries[i+2], ...
+	for i := 0; i+len(after) < len(entries); i++ {
+		if !follows(entries, i, after) {
+			continue
+		}
+		next := normalize(entries[i].Command)
+		if next == "" || next == last || strings.HasPrefix(next, "#") {
+			continue
+		}
+		total++
+		commands[next]++
+		stems[stem(next)]++
+	}
+	if total < minUses {
+		return Suggestion{}, false
+	}
+
+	if command, uses := top(commands); uses >= minUses && float64(uses) >= minShare*float64(total) {
+		return Suggestion{Command: command, After: after, Uses: uses, Total: total}, true
+	}
+	if prefix, uses := top(stems); uses >= minUses && float64(uses) >= minShare*float64(total) {
+		return Suggestion{Command: prefix + " ", After: after, Uses: uses, Total: total}, true
+	}
+	return Suggestion{}, false
+}
+
+// follows reports whether entries[i] ran right after the commands in after,
+// in the same session.
+func follows(entries []history.HistoryEntry, i int, after []string) bool {
+	for j := range after {
+		previous := entries[i+len(after)-j]
+		if previous.SessionID != entries[i].SessionID || normalize(previous.Command) != after[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// top returns the most frequent key in counts, the first in order on ties.
+func top(counts map[string]int) (string, int) {
+	best, uses := "", 0
+	for key, count := range counts {
+		if count > uses || (count == uses && key < best) {
+			best, uses = key, count
+		}
+	}
+	return best, uses
+}
+
+// normalize trims command, and drops multi-line commands, which are not
+// suggested.
+func normalize(command string) string {
+	command = strings.TrimSpace(command)
+	if strings.Contains(command, "\n") {
+		return ""
+	}
+	return command
+}
+
+// stem returns the command name followed by its subcommand, if it has one,
+// e.g. "git commit" for git commit -m "fix".
+func stem(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) > 1 && isWord(fields[1]) {
+		return fields[0] + " " + fields[1]
+	}
+	return fields[0]
+}
+
+// isWord reports whether field is a plain word such as a subcommand rather
+// than a flag, path or quoted argument.
+func isWord(field string) bool {
+	if field == "" || field[0] == '-' {
+		return false
+	}
+	for _, r := range field {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Recent returns the last commands run in the session, most recent first,
+// from entries ordered most recent first.
+func Recent(entries []history.HistoryEntry, sessionID string, n int) []string {
+	var recent []string
+	for _, entry := range entries {
+		if len(recent) == n {
+			break
+		}
+		if entry.SessionID == sessionID {
+			recent = append(recent, normalize(entry.Command))
+		}
+	}
+	return recent
+}
@@ -0,0 +1,114 @@
+package cmdchain
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// session returns the entries of one session that ran commands in order,
+// most recent first, as history returns them.
+func session(id string, commands ...string) []history.HistoryEntry {
+	entries := make([]history.HistoryEntry, len(commands))
+	for i, command := range commands {
+		entries[len(commands)-1-i] = history.HistoryEntry{Command: command, SessionID: id}
+	}
+	return entries
+}
+
+func sessions(all ...[]history.HistoryEntry) []history.HistoryEntry {
+	var entries []history.HistoryEntry
+	for i := len(all) - 1; i >= 0; i-- {
+		entries = append(entries, all[i]...)
+	}
+	return entries
+}
+
+func TestSuggestFullCommand(t *testing.T) {
+	entries := sessions(
+		session("a", "make", "make test", "ls"),
+		session("b", "make", "make test"),
+		session("c", "make", "make test", "make"),
+	)
+	s, ok := Suggest(entries, []string{"make"})
+	require.True(t, ok)
+	assert.Equal(t, "make test", s.Command)
+	assert.Equal(t, []string{"make"}, s.After)
+	assert.Equal(t, 3, s.Uses)
+	assert.Equal(t, 3, s.Total)
+}
+
+func TestSuggestCommonStart(t *testing.T) {
+	entries := sessions(
+		session("a", "git add -A", `git commit -m "one"`),
+		session("b", "git add -A", `git commit -m "two"`),
+		session("c", "git add -A", `git commit -m "three"`, "git add -A", "git status"),
+	)
+	s, ok := Suggest(entries, []string{"git add -A"})
+	require.True(t, ok)
+	assert.Equal(t, "git commit ", s.Command)
+	assert.Equal(t, 3, s.Uses)
+	assert.Equal(t, 4, s.Total)
+	assert.Equal(t, "From your history: after `git add -A` you usually run `git commit` next (3 of 4 times)", s.Note())
+}
+
+func TestSuggestPrefersTheLastTwoCommands(t *testing.T) {
+	entries := sessions(
+		session("a", "cd api", "make", "make test"),
+		session("b", "cd api", "make", "make test"),
+		session("c", "cd api", "make", "make test"),
+		session("d", "make", "make install"),
+		session("e", "make", "make install"),
+		session("f", "make", "make install"),
+		session("g", "make", "make install"),
+	)
+	s, ok := Suggest(entries, []string{"make", "cd api"})
+	require.True(t, ok)
+	assert.Equal(t, "make test", s.Command)
+	assert.Equal(t, []string{"cd api", "make"}, s.After)
+
+	s, ok = Suggest(entries, []string{"make", "ls"})
+	require.True(t, ok)
+	assert.Equal(t, "make install", s.Command)
+}
+
+func TestSuggestNeedsAHabit(t *testing.T) {
+	// Seen twice only
+	entries := sessions(session("a", "make", "make test"), session("b", "make", "make test"))
+	_, ok := Suggest(entries, []string{"make"})
+	assert.False(t, ok)
+
+	// No clear favourite
+	entries = sessions(
+		session("a", "make", "make test"),
+		session("b", "make", "ls"),
+		session("c", "make", "git status"),
+	)
+	_, ok = Suggest(entries, []string{"make"})
+	assert.False(t, ok)
+
+	// Commands in different sessions do not follow each other
+	entries = sessions(session("a", "make"), session("b", "make test"), session("c", "make"), session("d", "make test"),
+		session("e", "make"), session("f", "make test"))
+	_, ok = Suggest(entries, []string{"make"})
+	assert.False(t, ok)
+
+	_, ok = Suggest(entries, nil)
+	assert.False(t, ok)
+}
+
+func TestRecent(t *testing.T) {
+	entries := sessions(session("mine", "ls", " make "), session("other", "vim"))
+	assert.Equal(t, []string{"make", "ls"}, Recent(entries, "mine", 2))
+	assert.Equal(t, []string{"make"}, Recent(entries, "mine", 1))
+	assert.Empty(t, Recent(entries, "new", 2))
+	assert.Equal(t, []string{"vim"}, Recent(entries, "other", 2))
+}
+
+func TestStem(t *testing.T) {
+	assert.Equal(t, "git commit", stem(`git commit -m "fix"`))
+	assert.Equal(t, "ls", stem("ls -la"))
+	assert.Equal(t, "cat", stem("cat /etc/hosts"))
+}
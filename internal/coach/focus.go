@@ -0,0 +1,87 @@
+package coach
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// RecordFocusSession looks at the commands sessionID ran between started
+// and ended, while a timer counted down, and shows what they were once, so
+// that the user can see how the time was spent. label is the label of the
+// timer.
+func (m *CoachManager) RecordFocusSession(label string, started, ended time.Time, sessionID string) {
+	if m.historyManager == nil {
+		return
+	}
+	entries, err := m.historyManager.GetSessionEntries(sessionID, started)
+	if err != nil {
+		return
+	}
+	var during []history.HistoryEntry
+	for _, entry := range entries {
+		if !entry.CreatedAt.After(ended) {
+			during = append(during, entry)
+		}
+	}
+	m.environmentHint = &CoachDisplayContent{
+		Type:     "focus",
+		Icon:     "⏱",
+		Title:    "Focus Session",
+		Content:  focusSummary(label, ended.Sub(started), during),
+		Priority: 9,
+	}
+}
+
+// focusSummary describes the commands run during a timer of the given
+// length: how many, how many failed and which were run most.
+func focusSummary(label string, length time.Duration, entries []history.HistoryEntry) string {
+	name := fmt.Sprintf("%d min", int(length.Round(time.Minute)/time.Minute))
+	if length < time.Minute {
+		name = fmt.Sprintf("%d s", int(length.Round(time.Second)/time.Second))
+	}
+	if label != "" {
+		name = fmt.Sprintf("%q (%s)", label, name)
+	}
+	if len(entries) == 0 {
+		return name + ": no commands run in this shell"
+	}
+
+	counts := make(map[string]int)
+	failed := 0
+	for _, entry := range entries {
+		counts[normalizeCommand(entry.Command)]++
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			failed++
+		}
+	}
+	var top []commandFreq
+	for cmd, count := range counts {
+		top = append(top, commandFreq{Command: cmd, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Command < top[j].Command
+	})
+	var most []string
+	for _, c := range top[:min(3, len(top))] {
+		most = append(most, fmt.Sprintf("%s ×%d", c.Command, c.Count))
+	}
+
+	summary := fmt.Sprintf("%s: %d commands", name, len(entries))
+	if len(entries) == 1 {
+		summary = fmt.Sprintf("%s: 1 command", name)
+	}
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	if len(top) == 1 {
+		return summary + ", all " + top[0].Command
+	}
+	return summary + ", mostly " + strings.Join(most, ", ")
+}
@@ -0,0 +1,26 @@
+package coach
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFocusSummary(t *testing.T) {
+	entry := func(command string, exitCode int32) history.HistoryEntry {
+		return history.HistoryEntry{Command: command, ExitCode: sql.NullInt32{Int32: exitCode, Valid: true}}
+	}
+
+	assert.Equal(t, `"review PR" (25 min): no commands run in this shell`, focusSummary("review PR", 25*time.Minute, nil))
+
+	entries := []history.HistoryEntry{
+		entry("git diff", 0), entry("go test ./...", 1), entry("git diff HEAD~1", 0),
+		entry("go test ./internal/...", 0), entry("git diff --stat", 0), entry("vim main.go", 0),
+		entry("ls", 0),
+	}
+	assert.Equal(t, "25 min: 7 commands, 1 failed, mostly git diff ×3, go test ×2, ls ×1", focusSummary("", 25*time.Minute, entries))
+	assert.Equal(t, "30 s: 1 command, all ls", focusSummary("", 30*time.Second, entries[6:]))
+}
@@ -11,7 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/robottwo/bishop/internal/devenv"
+	"github.com/robottwo/bishop/internal/environment"
 	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/pkgmgr"
+	"github.com/robottwo/bishop/internal/routines"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -42,6 +46,20 @@ type CoachManager struct {
 
 	// Pending notifications
 	pendingNotifications []CoachNotification
+
+	// devEnvs are the development environments the shell runs in, and
+	// environmentHint a suggestion for the last command that suits them,
+	// shown once
+	devEnvs         []devenv.Env
+	environmentHint *CoachDisplayContent
+
+	// pendingRoutine is the repeated sequence of commands last offered as a
+	// function, and offeredRoutines those offered so far this session
+	pendingRoutine  *routines.Routine
+	offeredRoutines map[string]bool
+
+	// lastPipelineTip is when the last tip on a pipeline was given
+	lastPipelineTip time.Time
 }
 
 // NewCoachManager creates a new coach manager
@@ -84,13 +102,15 @@ func NewCoachManager(db *gorm.DB, historyManager *history.HistoryManager, runner
 	}
 
 	manager := &CoachManager{
-		db:             db,
-		historyManager: historyManager,
-		runner:         runner,
-		logger:         zapLogger,
-		profile:        profile,
-		tipCache:       NewTipCache(50, 24*time.Hour),
-		sessionStart:   time.Now(),
+		db:              db,
+		historyManager:  historyManager,
+		runner:          runner,
+		logger:          zapLogger,
+		profile:         profile,
+		tipCache:        NewTipCache(50, 24*time.Hour),
+		sessionStart:    time.Now(),
+		devEnvs:         devenv.Current(),
+		offeredRoutines: map[string]bool{},
 	}
 
 	// Load today's stats
@@ -361,9 +381,82 @@ func (m *CoachManager) RecordCommand(command string, exitCode int, durationMs in
 	// Check achievements
 	m.checkAchievements(command, success, durationMs)
 
+	if hint := devenv.InstallHint(m.devEnvs, command); hint != "" {
+		m.environmentHint = &CoachDisplayContent{
+			Type:     "environment",
+			Icon:     "📦",
+			Title:    "Environment Tip",
+			Content:  hint,
+			Priority: 9,
+		}
+	} else if exitCode != 0 {
+		if pm, err := pkgmgr.Current(environment.GetPackageManager(m.runner)); err == nil {
+			if hint := pkgmgr.InstallHint(pm, command); hint != "" {
+				m.environmentHint = &CoachDisplayContent{
+					Type:     "environment",
+					Icon:     "📦",
+					Title:    "Package Manager Tip",
+					Content:  hint,
+					Priority: 9,
+				}
+			}
+		}
+	}
+
+	if success && m.environmentHint == nil {
+		m.checkRoutine()
+	}
+
 	m.lastCommandTime = now
 }
 
+// checkRoutine offers to turn the commands just typed into a shell function
+// when they are a sequence that has been typed by hand many times.
+func (m *CoachManager) checkRoutine() {
+	if m.historyManager == nil {
+		return
+	}
+	entries, err := m.historyManager.GetRecentEntriesByPrefix("", routines.SampleSize)
+	if err != nil {
+		return
+	}
+	// Builtins may record their own commands without a session
+	sessionID := ""
+	for _, entry := range entries {
+		if entry.SessionID != "" {
+			sessionID = entry.SessionID
+			break
+		}
+	}
+	r, ok := routines.Latest(entries, sessionID)
+	if !ok || m.offeredRoutines[r.Key()] {
+		return
+	}
+	m.offeredRoutines[r.Key()] = true
+	m.pendingRoutine = &r
+	m.environmentHint = &CoachDisplayContent{
+		Type:     "routine",
+		Icon:     "🔁",
+		Title:    "Routine Tip",
+		Content:  fmt.Sprintf("You've typed these %d commands together %d times: %s. Type #!routine to save them as a shell function", len(r.Steps), r.Count, strings.Join(r.Steps, " → ")),
+		Priority: 9,
+	}
+}
+
+// PendingRoutine returns the repeated sequence of commands last offered as a
+// shell function, if any.
+func (m *CoachManager) PendingRoutine() (routines.Routine, bool) {
+	if m.pendingRoutine == nil {
+		return routines.Routine{}, false
+	}
+	return *m.pendingRoutine, true
+}
+
+// ClearPendingRoutine forgets the routine offered last, once it was saved.
+func (m *CoachManager) ClearPendingRoutine() {
+	m.pendingRoutine = nil
+}
+
 // addXP adds XP with multipliers and checks for level up
 func (m *CoachManager) addXP(baseXP int, source string) {
 	reward := CalculateXPReward(baseXP, m.profile.CurrentStreak, m.profile.Prestige)
@@ -678,7 +771,13 @@ func (m *CoachManager) GetDisplayContent() *CoachDisplayContent {
 		}
 	}
 
-	// Priority 2: Near-complete challenges
+	// Priority 2: A suggestion for the environment, shown once
+	if hint := m.environmentHint; hint != nil {
+		m.environmentHint = nil
+		return hint
+	}
+
+	// Priority 3: Near-complete challenges
 	for _, c := range m.dailyChallenges {
 		if !c.Completed && c.Progress >= 0.8 {
 			def := getChallengeDefinition(c.ChallengeID)
@@ -695,7 +794,7 @@ func (m *CoachManager) GetDisplayContent() *CoachDisplayContent {
 		}
 	}
 
-	// Priority 3: Database tip (includes both static and LLM-generated tips)
+	// Priority 4: Database tip (includes both static and LLM-generated tips)
 	dbTip := m.GetRandomDatabaseTip()
 	if dbTip != nil {
 		return ConvertDatabaseTipToDisplay(dbTip)
@@ -0,0 +1,64 @@
+package coach
+
+import (
+	"time"
+
+	"github.com/robottwo/bishop/internal/pipelint"
+)
+
+const (
+	// pipelineTipInterval is how long the coach waits after a pipeline tip
+	// before it gives another in the same session.
+	pipelineTipInterval = 30 * time.Minute
+
+	// A pipeline rule is taught again at most once per pipelineRuleInterval,
+	// and no more than pipelineRuleLimit times in all.
+	pipelineRuleInterval = 7 * 24 * time.Hour
+	pipelineRuleLimit    = 3
+)
+
+// pipelineTipID is the ID under which tip history records the pipeline rule.
+func pipelineTipID(rule string) string {
+	return "pipeline:" + rule
+}
+
+// PipelineTip returns a one-line tip on running command more simply when it
+// is a pipeline such as cat file | grep pattern, or "". Tips are rationed so
+// that they never nag: one every half hour at most, and each rule is taught
+// once a week, three times in all.
+func (m *CoachManager) PipelineTip(command string) string {
+	return m.pipelineTip(command, time.Now())
+}
+
+func (m *CoachManager) pipelineTip(command string, now time.Time) string {
+	if !m.lastPipelineTip.IsZero() && now.Sub(m.lastPipelineTip) < pipelineTipInterval {
+		return ""
+	}
+	suggestion, ok := pipelint.Analyze(command)
+	if !ok {
+		return ""
+	}
+
+	var fresh []*CoachTipHistory
+	for _, rule := range suggestion.Rules {
+		record := &CoachTipHistory{}
+		err := m.db.Where("profile_id = ? AND tip_id = ?", m.profile.ID, pipelineTipID(rule)).First(record).Error
+		if err != nil {
+			record = &CoachTipHistory{ProfileID: m.profile.ID, TipID: pipelineTipID(rule)}
+		} else if record.ShownCount >= pipelineRuleLimit || now.Sub(record.ShownAt) < pipelineRuleInterval {
+			continue
+		}
+		fresh = append(fresh, record)
+	}
+	if len(fresh) == 0 {
+		return ""
+	}
+
+	for _, record := range fresh {
+		record.ShownCount++
+		record.ShownAt = now
+		m.db.Save(record)
+	}
+	m.lastPipelineTip = now
+	return suggestion.Message()
+}
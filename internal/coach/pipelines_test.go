@@ -0,0 +1,47 @@
+package coach
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestPipelineTip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	manager, err := NewCoachManager(db, &history.HistoryManager{}, &interp.Runner{}, zap.NewNop())
+	require.NoError(t, err)
+
+	now := time.Now()
+	assert.Equal(t, "", manager.pipelineTip("grep error log.txt", now))
+	assert.Contains(t, manager.pipelineTip("cat log.txt | grep error", now), "Try: grep error log.txt")
+
+	// One tip per half hour
+	assert.Equal(t, "", manager.pipelineTip("ls | grep test", now.Add(time.Minute)))
+	now = now.Add(time.Hour)
+	assert.Contains(t, manager.pipelineTip("ls | grep test", now), "ls -d *test*")
+
+	// A rule taught this week is not taught again, unless the pipeline has
+	// another
+	now = now.Add(time.Hour)
+	assert.Equal(t, "", manager.pipelineTip("cat a.txt | grep x", now))
+	assert.Contains(t, manager.pipelineTip("cat a.txt | grep x | wc -l", now), "grep -c x a.txt")
+
+	// nor more than three times in all
+	for week := 1; week <= 3; week++ {
+		now = now.Add(pipelineRuleInterval)
+		tip := manager.pipelineTip("cat a.txt | grep x", now)
+		if week < 3 {
+			assert.NotEmpty(t, tip, "week %d", week)
+		} else {
+			assert.Empty(t, tip, "week %d", week)
+		}
+	}
+}
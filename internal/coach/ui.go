@@ -5,9 +5,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/flaghabits"
+	"github.com/robottwo/bishop/internal/i18n"
 	"github.com/robottwo/bishop/internal/styles"
 )
 
+// boxWidth is the width inside the borders of the coach boxes.
+const boxWidth = 74
+
 // RenderDashboard renders the main coach dashboard
 func (m *CoachManager) RenderDashboard() string {
 	var sb strings.Builder
@@ -17,15 +24,15 @@ func (m *CoachManager) RenderDashboard() string {
 
 	// Header
 	sb.WriteString(styles.AGENT_MESSAGE("╔══════════════════════════════════════════════════════════════════════════╗\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  🎮 GSH PRODUCTIVITY COACH                                               ║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE(boxTitle(i18n.T("coach.title.dashboard"))))
 	sb.WriteString(styles.AGENT_MESSAGE("╠══════════════════════════════════════════════════════════════════════════╣\n"))
 
 	// Welcome and streak
 	streakStr := ""
 	if profile.CurrentStreak > 0 {
-		streakStr = fmt.Sprintf("🔥 %d-day streak!", profile.CurrentStreak)
+		streakStr = "🔥 " + i18n.T("coach.streak", profile.CurrentStreak)
 	}
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  Welcome back, %s! %s\n", profile.Username, padRight(streakStr, 30))))
+	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %s %s\n", i18n.T("coach.welcome", profile.Username), padRight(streakStr, 30))))
 
 	// Level and title
 	prestigeStr := ""
@@ -41,32 +48,32 @@ func (m *CoachManager) RenderDashboard() string {
 	xpCurrent := profile.TotalXP - XPForLevel(profile.Level)
 	progressBar := renderProgressBar(progress, 40)
 
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  LEVEL %d %s ⭐ %d / %d XP\n", profile.Level, padRight("", 30), xpCurrent, xpNeeded)))
+	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %s %s ⭐ %d / %d XP\n", i18n.T("coach.level", profile.Level), padRight("", 30), xpCurrent, xpNeeded)))
 	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %s %.1f%%\n", progressBar, progress*100)))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║══════════════════════════════════════════════════════════════════════════║\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Today's stats
-	sb.WriteString(styles.AGENT_MESSAGE("║  📊 TODAY'S PROGRESS\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  📊 " + i18n.T("coach.today_progress") + "\n"))
 	if stats != nil {
 		accuracy := 0.0
 		if stats.CommandsExecuted > 0 {
 			accuracy = float64(stats.CommandsSuccessful) / float64(stats.CommandsExecuted) * 100
 		}
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Commands: %d\n", stats.CommandsExecuted)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Accuracy: %.1f%%\n", accuracy)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Errors: %d\n", stats.CommandsFailed)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  └── XP Earned: %d\n", stats.XPEarned)))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.commands", stats.CommandsExecuted) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.accuracy", accuracy) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.errors", stats.CommandsFailed) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.xp_earned", stats.XPEarned) + "\n"))
 	} else {
-		sb.WriteString(styles.AGENT_MESSAGE("║  └── No activity yet today\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.no_activity") + "\n"))
 	}
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║══════════════════════════════════════════════════════════════════════════║\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Daily challenges
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  📋 DAILY CHALLENGES                              Resets in %s\n", formatDurationShort(TimeUntilDailyReset()))))
+	sb.WriteString(styles.AGENT_MESSAGE(challengesHeading("📋 "+i18n.T("coach.daily_challenges"), TimeUntilDailyReset())))
 	for _, challenge := range m.dailyChallenges {
 		def := getChallengeDefinition(challenge.ChallengeID)
 		if def == nil {
@@ -77,7 +84,7 @@ func (m *CoachManager) RenderDashboard() string {
 		progressStr := fmt.Sprintf("%.0f%%", challenge.Progress*100)
 		if challenge.Completed {
 			status = "✅"
-			progressStr = "DONE!"
+			progressStr = i18n.T("coach.done")
 		} else if challenge.Progress > 0 {
 			status = "🔄"
 		}
@@ -90,7 +97,7 @@ func (m *CoachManager) RenderDashboard() string {
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Weekly challenges
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  📅 WEEKLY CHALLENGES                            Resets in %s\n", formatDurationShort(TimeUntilWeeklyReset()))))
+	sb.WriteString(styles.AGENT_MESSAGE(challengesHeading("📅 "+i18n.T("coach.weekly_challenges"), TimeUntilWeeklyReset())))
 	for _, challenge := range m.weeklyChallenges {
 		def := getChallengeDefinition(challenge.ChallengeID)
 		if def == nil {
@@ -101,7 +108,7 @@ func (m *CoachManager) RenderDashboard() string {
 		progressStr := fmt.Sprintf("%.0f%%", challenge.Progress*100)
 		if challenge.Completed {
 			status = "✅"
-			progressStr = "DONE!"
+			progressStr = i18n.T("coach.done")
 		} else if challenge.Progress > 0 {
 			status = "🔄"
 		}
@@ -127,17 +134,17 @@ func (m *CoachManager) RenderStats() string {
 	stats := m.todayStats
 
 	sb.WriteString(styles.AGENT_MESSAGE("╔══════════════════════════════════════════════════════════════════════════╗\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  📊 DETAILED STATISTICS                                                  ║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE(boxTitle(i18n.T("coach.title.stats"))))
 	sb.WriteString(styles.AGENT_MESSAGE("╠══════════════════════════════════════════════════════════════════════════╣\n"))
 
 	// Profile stats
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  👤 PROFILE\n"))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Level: %d (%s)\n", profile.Level, profile.Title)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Total XP: %d\n", profile.TotalXP)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Current Streak: %d days\n", profile.CurrentStreak)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Longest Streak: %d days\n", profile.LongestStreak)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  └── Streak Freezes: %d available\n", profile.StreakFreezes)))
+	sb.WriteString(styles.AGENT_MESSAGE("║  👤 " + i18n.T("coach.profile") + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.level_title", profile.Level, profile.Title) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.total_xp", profile.TotalXP) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.current_streak", profile.CurrentStreak) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.longest_streak", profile.LongestStreak) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.streak_freezes", profile.StreakFreezes) + "\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Multipliers
@@ -145,32 +152,32 @@ func (m *CoachManager) RenderStats() string {
 	prestigeMult := PrestigeMultiplier(profile.Prestige)
 	totalMult := streakMult * prestigeMult
 
-	sb.WriteString(styles.AGENT_MESSAGE("║  ⚡ MULTIPLIERS\n"))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Streak Bonus: %.2fx\n", streakMult)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Prestige Bonus: %.2fx\n", prestigeMult)))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  └── Total: %.2fx XP\n", totalMult)))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ⚡ " + i18n.T("coach.multipliers") + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.streak_bonus", streakMult) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.prestige_bonus", prestigeMult) + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.total_multiplier", totalMult) + "\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Today's stats
 	if stats != nil {
-		sb.WriteString(styles.AGENT_MESSAGE("║  📈 TODAY\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  📈 " + i18n.T("coach.today") + "\n"))
 		accuracy := 0.0
 		if stats.CommandsExecuted > 0 {
 			accuracy = float64(stats.CommandsSuccessful) / float64(stats.CommandsExecuted) * 100
 		}
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Commands: %d\n", stats.CommandsExecuted)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Successful: %d\n", stats.CommandsSuccessful)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Failed: %d\n", stats.CommandsFailed)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Accuracy: %.1f%%\n", accuracy)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Pipelines Used: %d\n", stats.PipelinesUsed)))
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Aliases Used: %d\n", stats.AliasesUsed)))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.commands", stats.CommandsExecuted) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.successful", stats.CommandsSuccessful) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.failed", stats.CommandsFailed) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.accuracy", accuracy) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.pipelines_used", stats.PipelinesUsed) + "\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.aliases_used", stats.AliasesUsed) + "\n"))
 		if stats.AvgCommandTimeMs > 0 {
-			sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Avg Command Time: %dms\n", stats.AvgCommandTimeMs)))
+			sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.avg_command_time", stats.AvgCommandTimeMs) + "\n"))
 		}
 		if stats.FastestCommandMs > 0 {
-			sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Fastest Command: %dms\n", stats.FastestCommandMs)))
+			sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.fastest_command", stats.FastestCommandMs) + "\n"))
 		}
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  └── XP Earned: %d\n", stats.XPEarned)))
+		sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.xp_earned", stats.XPEarned) + "\n"))
 	}
 
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
@@ -184,7 +191,7 @@ func (m *CoachManager) RenderAchievements() string {
 	var sb strings.Builder
 
 	sb.WriteString(styles.AGENT_MESSAGE("╔══════════════════════════════════════════════════════════════════════════╗\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  🏆 ACHIEVEMENTS                                                          ║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE(boxTitle(i18n.T("coach.title.achievements"))))
 	sb.WriteString(styles.AGENT_MESSAGE("╠══════════════════════════════════════════════════════════════════════════╣\n"))
 
 	// Count unlocked
@@ -201,7 +208,7 @@ func (m *CoachManager) RenderAchievements() string {
 	}
 	total = len(AllAchievements)
 
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %d / %d Unlocked (%.0f%%)\n", unlocked, total, float64(unlocked)/float64(total)*100)))
+	sb.WriteString(styles.AGENT_MESSAGE("║  " + i18n.T("coach.unlocked_count", unlocked, total, float64(unlocked)/float64(total)*100) + "\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Group by category
@@ -211,14 +218,14 @@ func (m *CoachManager) RenderAchievements() string {
 	}
 
 	categoryNames := map[AchievementCategory]string{
-		CategoryStreak:       "🔥 STREAK",
-		CategoryMilestone:    "🏆 MILESTONE",
-		CategoryAccuracy:     "🎯 ACCURACY",
-		CategorySpeed:        "⚡ SPEED",
-		CategoryProductivity: "🛠️ PRODUCTIVITY",
-		CategoryLearning:     "📚 LEARNING",
-		CategoryGit:          "🌿 GIT",
-		CategorySpecial:      "🎪 SPECIAL",
+		CategoryStreak:       "🔥 " + i18n.T("coach.category.streak"),
+		CategoryMilestone:    "🏆 " + i18n.T("coach.category.milestone"),
+		CategoryAccuracy:     "🎯 " + i18n.T("coach.category.accuracy"),
+		CategorySpeed:        "⚡ " + i18n.T("coach.category.speed"),
+		CategoryProductivity: "🛠️ " + i18n.T("coach.category.productivity"),
+		CategoryLearning:     "📚 " + i18n.T("coach.category.learning"),
+		CategoryGit:          "🌿 " + i18n.T("coach.category.git"),
+		CategorySpecial:      "🎪 " + i18n.T("coach.category.special"),
 	}
 
 	for _, cat := range categories {
@@ -249,7 +256,7 @@ func (m *CoachManager) RenderAchievements() string {
 
 			if ua != nil && ua.UnlockedAt.Valid {
 				status = "✨"
-				progressStr = "UNLOCKED"
+				progressStr = i18n.T("coach.unlocked")
 			} else if ua != nil && ua.Progress > 0 {
 				status = "⏳"
 				progressStr = fmt.Sprintf("%.0f%%", ua.Progress*100)
@@ -273,12 +280,12 @@ func (m *CoachManager) RenderChallenges() string {
 	var sb strings.Builder
 
 	sb.WriteString(styles.AGENT_MESSAGE("╔══════════════════════════════════════════════════════════════════════════╗\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  🎯 CHALLENGES                                                            ║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE(boxTitle(i18n.T("coach.title.challenges"))))
 	sb.WriteString(styles.AGENT_MESSAGE("╠══════════════════════════════════════════════════════════════════════════╣\n"))
 
 	// Daily challenges
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  📋 DAILY CHALLENGES                         Resets in %s\n", formatDurationShort(TimeUntilDailyReset()))))
+	sb.WriteString(styles.AGENT_MESSAGE(challengesHeading("📋 "+i18n.T("coach.daily_challenges"), TimeUntilDailyReset())))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	for _, challenge := range m.dailyChallenges {
@@ -304,7 +311,7 @@ func (m *CoachManager) RenderChallenges() string {
 	// Weekly challenges
 	sb.WriteString(styles.AGENT_MESSAGE("║──────────────────────────────────────────────────────────────────────────║\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  📅 WEEKLY CHALLENGES                       Resets in %s\n", formatDurationShort(TimeUntilWeeklyReset()))))
+	sb.WriteString(styles.AGENT_MESSAGE(challengesHeading("📅 "+i18n.T("coach.weekly_challenges"), TimeUntilWeeklyReset())))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	for _, challenge := range m.weeklyChallenges {
@@ -334,6 +341,18 @@ func (m *CoachManager) RenderChallenges() string {
 
 // Helper functions
 
+// boxTitle is the title line of a box, padded to its right border.
+func boxTitle(title string) string {
+	return "║  " + title + strings.Repeat(" ", max(0, boxWidth-2-lipgloss.Width(title))) + "║\n"
+}
+
+// challengesHeading is the heading of a list of challenges with the time
+// left until they reset.
+func challengesHeading(heading string, untilReset time.Duration) string {
+	padding := strings.Repeat(" ", max(1, boxWidth-26-lipgloss.Width(heading)))
+	return "║  " + heading + padding + i18n.T("coach.resets_in", formatDurationShort(untilReset)) + "\n"
+}
+
 func renderProgressBar(progress float64, width int) string {
 	if progress < 0 {
 		progress = 0
@@ -355,6 +374,39 @@ func padRight(s string, length int) string {
 	return s + strings.Repeat(" ", length-len(s))
 }
 
+// renderFlagHabits lists the flags learned from history for the most used
+// commands.
+func (m *CoachManager) renderFlagHabits(sb *strings.Builder) {
+	if m.runner != nil && !environment.GetFlagLearning(m.runner) {
+		sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.flag_learning_off") + "\n"))
+		return
+	}
+	var habits []flaghabits.Habit
+	if m.historyManager != nil {
+		entries, err := m.historyManager.GetRecentEntries("", flaghabits.SampleSize)
+		if err == nil {
+			commands := make([]string, len(entries))
+			for i, entry := range entries {
+				commands[i] = entry.Command
+			}
+			habits = flaghabits.Learn(commands)
+		}
+	}
+	if len(habits) == 0 {
+		sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.no_flags_yet") + "\n"))
+		return
+	}
+
+	showCount := min(len(habits), 10)
+	for _, habit := range habits[:showCount] {
+		sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.flag_uses", truncate(habit.String(), 60), habit.Uses) + "\n"))
+	}
+	if len(habits) > showCount {
+		sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.more", len(habits)-showCount) + "\n"))
+	}
+	sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.stop_flag_learning") + "\n"))
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -398,7 +450,7 @@ func (m *CoachManager) RenderAllTips() string {
 	var sb strings.Builder
 
 	sb.WriteString(styles.AGENT_MESSAGE("╔══════════════════════════════════════════════════════════════════════════╗\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  💡 ALL TIPS                                                              ║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE(boxTitle(i18n.T("coach.title.tips"))))
 	sb.WriteString(styles.AGENT_MESSAGE("╠══════════════════════════════════════════════════════════════════════════╣\n"))
 
 	// Get all tips from database
@@ -416,7 +468,7 @@ func (m *CoachManager) RenderAllTips() string {
 		}
 	}
 
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  Total: %d tips (%d static, %d AI-generated)\n", len(tips), staticCount, llmCount)))
+	sb.WriteString(styles.AGENT_MESSAGE("║  " + i18n.T("coach.tips_total", len(tips), staticCount, llmCount) + "\n"))
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 
 	// Group by category
@@ -454,7 +506,7 @@ func (m *CoachManager) RenderAllTips() string {
 			icon = "📌"
 		}
 
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %s %s (%d tips)\n", icon, strings.ToUpper(cat), len(catTips))))
+		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  %s %s %s\n", icon, strings.ToUpper(cat), i18n.T("coach.tip_count", len(catTips)))))
 
 		// Show up to 5 tips per category
 		showCount := len(catTips)
@@ -470,25 +522,31 @@ func (m *CoachManager) RenderAllTips() string {
 			}
 			shownInfo := ""
 			if tip.ShownCount > 0 {
-				shownInfo = fmt.Sprintf(" (shown %dx)", tip.ShownCount)
+				shownInfo = " " + i18n.T("coach.shown_times", tip.ShownCount)
 			}
 			sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  │ %s%s%s\n", truncate(tip.Title+": "+tip.Content, 60), sourceTag, shownInfo)))
 		}
 
 		if len(catTips) > 5 {
-			sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  │ ... and %d more\n", len(catTips)-5)))
+			sb.WriteString(styles.AGENT_MESSAGE("║  │ " + i18n.T("coach.more", len(catTips)-5) + "\n"))
 		}
 		sb.WriteString(styles.AGENT_MESSAGE("║\n"))
 	}
 
+	// Show learned flag habits
+	sb.WriteString(styles.AGENT_MESSAGE("║──────────────────────────────────────────────────────────────────────────║\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  🏁 " + i18n.T("coach.usual_flags") + "\n"))
+	m.renderFlagHabits(&sb)
+	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
+
 	// Show tip generation status
 	sb.WriteString(styles.AGENT_MESSAGE("║──────────────────────────────────────────────────────────────────────────║\n"))
-	sb.WriteString(styles.AGENT_MESSAGE("║  📊 TIP GENERATION STATUS\n"))
-	sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  ├── Commands since last generation: %d / 1000\n", m.profile.CommandsSinceLastTipGen)))
+	sb.WriteString(styles.AGENT_MESSAGE("║  📊 " + i18n.T("coach.tip_generation") + "\n"))
+	sb.WriteString(styles.AGENT_MESSAGE("║  ├── " + i18n.T("coach.since_generation", m.profile.CommandsSinceLastTipGen) + "\n"))
 	if m.profile.LastTipGenTime.Valid {
-		sb.WriteString(styles.AGENT_MESSAGE(fmt.Sprintf("║  └── Last generated: %s\n", m.profile.LastTipGenTime.Time.Format("2006-01-02 15:04"))))
+		sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.last_generated", m.profile.LastTipGenTime.Time.Format("2006-01-02 15:04")) + "\n"))
 	} else {
-		sb.WriteString(styles.AGENT_MESSAGE("║  └── Last generated: Never\n"))
+		sb.WriteString(styles.AGENT_MESSAGE("║  └── " + i18n.T("coach.last_generated", i18n.T("coach.never")) + "\n"))
 	}
 
 	sb.WriteString(styles.AGENT_MESSAGE("║\n"))
@@ -0,0 +1,21 @@
+package coach
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoxTitleReachesTheBorder(t *testing.T) {
+	border := "╔" + strings.Repeat("═", boxWidth) + "╗"
+	for _, locale := range []string{"en", "es"} {
+		i18n.SetLocale(locale)
+		title := boxTitle(i18n.T("coach.title.dashboard"))
+		assert.Equal(t, lipgloss.Width(border), lipgloss.Width(strings.TrimSuffix(title, "\n")), locale)
+	}
+	i18n.SetLocale(i18n.DefaultLocale)
+	assert.Contains(t, boxTitle(i18n.T("coach.title.tips")), "ALL TIPS")
+}
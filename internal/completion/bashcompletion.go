@@ -0,0 +1,164 @@
+package completion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Many tools ship bash completion functions, installed where the
+// bash-completion package looks for them, one script per command. For the
+// commands no other completer knows, the manager sources the script of the
+// command in a subshell of the session and calls the function it registers
+// with complete -F, as bash would. The script runs again on every
+// completion, in a new subshell, so that nothing it defines ends up in the
+// session.
+
+// compoptStub stands in for compopt, which completion functions call but the
+// interpreter does not have. It only changes how bash inserts the words.
+var compoptStub, _ = syntax.NewParser().Parse(strings.NewReader("compopt() { :; }"), "")
+
+// DefaultBashCompletionDirs returns where bash-completion looks for the
+// completion scripts of commands: the user's directories first, then those
+// of the system.
+func DefaultBashCompletionDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("BASH_COMPLETION_USER_DIR"); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "completions"))
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "bash-completion", "completions"))
+	}
+	return append(dirs,
+		"/usr/local/share/bash-completion/completions",
+		"/usr/share/bash-completion/completions",
+		"/opt/homebrew/share/bash-completion/completions",
+		"/etc/bash_completion.d",
+	)
+}
+
+// bashCompletion holds the scripts found for commands.
+type bashCompletion struct {
+	dirs []string
+
+	// mu serializes the completions, which share specs
+	mu sync.Mutex
+	// scripts are by command, nil for those that have none
+	scripts map[string]*syntax.File
+	// sourcing is set while a script runs, so that the specs it registers
+	// go to specs rather than to the manager
+	sourcing atomic.Bool
+	specs    map[string]CompletionSpec
+}
+
+// EnableBashCompletion has the manager complete commands with the bash
+// completion scripts in dirs, and in those of BISH_BASH_COMPLETION_DIRS,
+// see BashCompletion.
+func (m *CompletionManager) EnableBashCompletion(dirs []string) {
+	m.bash = &bashCompletion{dirs: dirs, scripts: map[string]*syntax.File{}}
+}
+
+// BashCompletion completes the command line args, whose last word is the one
+// being completed, with the bash completion script of the command. It
+// reports false if bash completion is off, the command has no script, or it
+// gave no words.
+func (m *CompletionManager) BashCompletion(ctx context.Context, runner *interp.Runner, args []string, line string, pos int) ([]shellinput.CompletionCandidate, bool) {
+	if m.bash == nil || len(args) == 0 || !environment.GetBashCompletion(runner) {
+		return nil, false
+	}
+	m.bash.mu.Lock()
+	defer m.bash.mu.Unlock()
+
+	command := filepath.Base(args[0])
+	script, ok := m.bash.scripts[command]
+	if !ok {
+		script = findBashCompletion(command, append(environment.GetBashCompletionDirs(runner), m.bash.dirs...))
+		m.bash.scripts[command] = script
+	}
+	if script == nil {
+		return nil, false
+	}
+
+	sub := completionSubshell(runner)
+	if _, ok := sub.Funcs["compopt"]; !ok {
+		_ = sub.Run(ctx, compoptStub)
+	}
+
+	m.bash.specs = map[string]CompletionSpec{}
+	m.bash.sourcing.Store(true)
+	_ = sub.Run(ctx, script)
+	m.bash.sourcing.Store(false)
+	spec, ok := m.bash.specs[command]
+	if !ok {
+		return nil, false
+	}
+
+	var suggestions []shellinput.CompletionCandidate
+	var err error
+	if spec.Type == FunctionCompletion {
+		// The function runs in sub, where the script defined it
+		words := args
+		if strings.HasSuffix(line, " ") {
+			// A new word is being completed
+			words = append(words[:len(words):len(words)], "")
+		}
+		var replies []string
+		if replies, err = runCompletionFunction(ctx, sub, spec.Value, words, line, pos); err == nil {
+			suggestions = toCandidates(replies)
+		}
+	} else {
+		suggestions, err = m.ExecuteCompletion(ctx, sub, spec, args, line, pos)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return suggestions, len(suggestions) > 0
+}
+
+// addBashSpec keeps spec, registered by a script BashCompletion runs, and
+// reports whether it did.
+func (m *CompletionManager) addBashSpec(spec CompletionSpec) bool {
+	if m.bash == nil || !m.bash.sourcing.Load() {
+		return false
+	}
+	m.bash.specs[spec.Command] = spec
+	return true
+}
+
+// findBashCompletion returns the completion script of command in the first
+// of dirs that has one, parsed and adapted to the interpreter, or nil.
+// Scripts are named after the command, possibly with a .bash extension or
+// an underscore in front.
+func findBashCompletion(command string, dirs []string) *syntax.File {
+	for _, dir := range dirs {
+		for _, name := range []string{command, command + ".bash", "_" + command} {
+			path := filepath.Join(dir, name)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			file, err := syntax.NewParser().Parse(strings.NewReader(string(content)), path)
+			if err != nil {
+				return nil
+			}
+			bash.Rewrite(file)
+			return file
+		}
+	}
+	return nil
+}
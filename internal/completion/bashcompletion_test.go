@@ -0,0 +1,127 @@
+package completion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// mytoolCompletion is written the way the scripts of bash-completion are.
+const mytoolCompletion = `
+_mytool() {
+	local cur prev
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	case $prev in
+		--level)
+			COMPREPLY=( $(compgen -W "{1..3}" -- $cur) )
+			return 0
+			;;
+	esac
+	case $cur in
+		-*)
+			compopt -o nospace
+			COMPREPLY=( $(compgen -W "--level --help" -- $cur) )
+			return 0
+			;;
+	esac
+	local IFS=$'\n'
+	compopt -o filenames
+	COMPREPLY=( $(compgen -f -- "$cur") )
+}
+complete -o default -F _mytool mytool
+`
+
+func newBashCompletionRunner(t *testing.T, manager *CompletionManager, dir string) *interp.Runner {
+	var runner *interp.Runner
+	runner, err := interp.New(
+		interp.Dir(dir),
+		interp.ExecHandlers(
+			bash.NewCompatCommandHandler(),
+			NewCompleteCommandHandler(manager),
+			NewCompgenCommandHandler(func() *interp.Runner { return runner }),
+		),
+	)
+	require.NoError(t, err)
+	runner.Reset()
+	return runner
+}
+
+func bashCompletionValues(suggestions []shellinput.CompletionCandidate) []string {
+	values := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		values[i] = s.Value
+	}
+	return values
+}
+
+func TestBashCompletion(t *testing.T) {
+	scripts := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(scripts, "mytool"), []byte(mytoolCompletion), 0o644))
+	work := t.TempDir()
+	for _, name := range []string{"notes.txt", "todo.txt", ".hidden"} {
+		require.NoError(t, os.WriteFile(filepath.Join(work, name), nil, 0o644))
+	}
+
+	manager := NewCompletionManager()
+	manager.EnableBashCompletion([]string{filepath.Join(t.TempDir(), "missing"), scripts})
+	runner := newBashCompletionRunner(t, manager, work)
+
+	complete := func(line string) ([]string, bool) {
+		words := splitPreservingQuotes(line)
+		suggestions, ok := manager.BashCompletion(context.Background(), runner, words, line, len(line))
+		return bashCompletionValues(suggestions), ok
+	}
+
+	values, ok := complete("mytool --l")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"--level"}, values)
+
+	values, ok = complete("mytool --level ")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"1", "2", "3"}, values)
+
+	values, ok = complete("mytool ")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"notes.txt", "todo.txt"}, values)
+
+	values, ok = complete("/usr/local/bin/mytool t")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"todo.txt"}, values)
+
+	_, ok = complete("mytool x")
+	assert.False(t, ok, "no words falls back to the other completers")
+	_, ok = complete("othertool ")
+	assert.False(t, ok, "commands without a script are not completed")
+
+	_, registered := manager.GetSpec("mytool")
+	assert.False(t, registered, "the spec of the script stays out of the manager")
+	assert.NotContains(t, runner.Funcs, "_mytool", "the script does not define functions in the session")
+
+	runner.Vars["BISH_BASH_COMPLETION"] = expand.Variable{Kind: expand.String, Str: "0"}
+	_, ok = complete("mytool --l")
+	assert.False(t, ok)
+}
+
+func TestBashCompletionDirsVariable(t *testing.T) {
+	scripts := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(scripts, "mytool.bash"), []byte(mytoolCompletion), 0o644))
+
+	manager := NewCompletionManager()
+	manager.EnableBashCompletion(nil)
+	runner := newBashCompletionRunner(t, manager, t.TempDir())
+	runner.Vars["BISH_BASH_COMPLETION_DIRS"] = expand.Variable{Kind: expand.String, Str: strings.Join([]string{"", scripts}, string(os.PathListSeparator))}
+
+	suggestions, ok := manager.BashCompletion(context.Background(), runner, []string{"mytool", "--h"}, "mytool --h", 10)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"--help"}, bashCompletionValues(suggestions))
+}
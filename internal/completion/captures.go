@@ -0,0 +1,30 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/robottwo/bishop/internal/captures"
+	"github.com/robottwo/bishop/pkg/shellinput"
+)
+
+// captureStore is the store @name completions come from, replaced in tests.
+var captureStore = captures.DefaultStore
+
+// getCaptureCompletions completes @name references to the output kept with
+// `cmd |> name`.
+func (p *ShellCompletionProvider) getCaptureCompletions(word string) []shellinput.CompletionCandidate {
+	prefix, ok := strings.CutPrefix(word, "@")
+	if !ok {
+		return nil
+	}
+	var candidates []shellinput.CompletionCandidate
+	for _, c := range captureStore.List() {
+		if strings.HasPrefix(c.Name, prefix) {
+			candidates = append(candidates, shellinput.CompletionCandidate{
+				Value:       "@" + c.Name,
+				Description: "Output of " + c.Command,
+			})
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,30 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/captures"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestCaptureCompletions(t *testing.T) {
+	original := captureStore
+	defer func() { captureStore = original }()
+	captureStore = captures.NewStore(t.TempDir())
+	_, err := captureStore.Save("pods", "kubectl get pods", nil, false)
+	require.NoError(t, err)
+	_, err = captureStore.Save("build", "make", nil, false)
+	require.NoError(t, err)
+
+	runner, _ := interp.New()
+	provider := NewShellCompletionProvider(NewCompletionManager(), runner)
+
+	assert.Equal(t, []shellinput.CompletionCandidate{
+		{Value: "@pods", Description: "Output of kubectl get pods"},
+	}, provider.GetCompletions("grep web @po", 12))
+	assert.Len(t, provider.getCaptureCompletions("@"), 2)
+	assert.Nil(t, provider.getCaptureCompletions("user@po"))
+}
@@ -12,13 +12,14 @@ import (
 var printf = fmt.Printf
 
 // completeUsage provides the usage summary for the complete command
-const completeUsage = `Usage: complete [-pr] [-W wordlist] [-F function] [-C command] name
+const completeUsage = `Usage: complete [-pr] [-o option] [-W wordlist] [-F function] [-C command] name [name ...]
        complete -p [name]
        complete -r [name]
 
 Options:
   -p          Print existing completion specifications
   -r          Remove completion specification for name
+  -o option   Keep a bash completion option, such as nospace or filenames
   -W wordlist Use wordlist (space-separated words) for completion
   -F function Call function for generating completions
   -C command  Execute command for generating completions
@@ -71,7 +72,8 @@ func handleCompleteCommand(manager *CompletionManager, args []string) error {
 		wordList   string
 		function   string
 		commandCmd string
-		command    string
+		options    []string
+		commands   []string
 	)
 
 	for i := 0; i < len(args); i++ {
@@ -102,15 +104,25 @@ func handleCompleteCommand(manager *CompletionManager, args []string) error {
 			}
 			i++
 			commandCmd = args[i]
+		case "-o":
+			if i+1 >= len(args) {
+				return newUsageError("option -o requires an option name")
+			}
+			i++
+			options = append(options, args[i])
 		default:
 			if !strings.HasPrefix(arg, "-") {
-				command = arg
+				commands = append(commands, arg)
 				break
 			}
 			return newUsageError("unknown option: %s", arg)
 		}
 	}
 
+	command := ""
+	if len(commands) > 0 {
+		command = commands[0]
+	}
 	if command == "" && !printMode {
 		return newUsageError("no command specified")
 	}
@@ -121,38 +133,29 @@ func handleCompleteCommand(manager *CompletionManager, args []string) error {
 	}
 
 	if removeMode {
-		manager.RemoveSpec(command)
-		return nil
-	}
-
-	if wordList != "" {
-		manager.AddSpec(CompletionSpec{
-			Command: command,
-			Type:    WordListCompletion,
-			Value:   wordList,
-		})
+		for _, command := range commands {
+			manager.RemoveSpec(command)
+		}
 		return nil
 	}
 
-	if function != "" {
-		manager.AddSpec(CompletionSpec{
-			Command: command,
-			Type:    FunctionCompletion,
-			Value:   function,
-		})
-		return nil
+	var spec CompletionSpec
+	switch {
+	case wordList != "":
+		spec = CompletionSpec{Type: WordListCompletion, Value: wordList}
+	case function != "":
+		spec = CompletionSpec{Type: FunctionCompletion, Value: function}
+	case commandCmd != "":
+		spec = CompletionSpec{Type: CommandCompletion, Value: commandCmd}
+	default:
+		return newUsageError("missing completion action: use -W, -F, or -C")
 	}
-
-	if commandCmd != "" {
-		manager.AddSpec(CompletionSpec{
-			Command: command,
-			Type:    CommandCompletion,
-			Value:   commandCmd,
-		})
-		return nil
+	spec.Options = options
+	for _, command := range commands {
+		spec.Command = command
+		manager.AddSpec(spec)
 	}
-
-	return newUsageError("missing completion action: use -W, -F, or -C")
+	return nil
 }
 
 func printCompletionSpecs(manager *CompletionManager, command string) error {
@@ -172,12 +175,16 @@ func printCompletionSpecs(manager *CompletionManager, command string) error {
 }
 
 func printCompletionSpec(spec CompletionSpec) {
+	options := ""
+	for _, option := range spec.Options {
+		options += "-o " + option + " "
+	}
 	switch spec.Type {
 	case WordListCompletion:
-		_, _ = printf("complete -W %q %s\n", spec.Value, spec.Command)
+		_, _ = printf("complete %s-W %q %s\n", options, spec.Value, spec.Command)
 	case FunctionCompletion:
-		_, _ = printf("complete -F %s %s\n", spec.Value, spec.Command)
+		_, _ = printf("complete %s-F %s %s\n", options, spec.Value, spec.Command)
 	case CommandCompletion:
-		_, _ = printf("complete -C %q %s\n", spec.Value, spec.Command)
+		_, _ = printf("complete %s-C %q %s\n", options, spec.Value, spec.Command)
 	}
 }
@@ -3,13 +3,23 @@ package completion
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
+	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/pattern"
+	"mvdan.cc/sh/v3/syntax"
 )
 
-// NewCompgenCommandHandler creates a new ExecHandler for the compgen command
-func NewCompgenCommandHandler(runner *interp.Runner) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+// NewCompgenCommandHandler creates a new ExecHandler for the compgen command.
+// runner returns the session's runner, whose functions -F calls and
+// -A function lists.
+func NewCompgenCommandHandler(runner func() *interp.Runner) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 		return func(ctx context.Context, args []string) error {
 			if len(args) == 0 || args[0] != "compgen" {
@@ -17,21 +27,37 @@ func NewCompgenCommandHandler(runner *interp.Runner) func(next interp.ExecHandle
 			}
 
 			// Handle the compgen command
-			return handleCompgenCommand(ctx, runner, args[1:])
+			hc := interp.HandlerCtx(ctx)
+			return handleCompgenCommand(ctx, hc, runner(), args[1:])
 		}
 	}
 }
 
-func handleCompgenCommand(ctx context.Context, runner *interp.Runner, args []string) error {
+// compgenActions are the names -A takes for the kinds of words compgen
+// generates, with the options that stand for them.
+var compgenActions = map[string]string{
+	"command":   "c",
+	"directory": "d",
+	"file":      "f",
+	"function":  "",
+	"variable":  "v",
+}
+
+func handleCompgenCommand(ctx context.Context, hc interp.HandlerContext, runner *interp.Runner, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("compgen: no options specified")
 	}
 
 	// Parse options
 	var (
-		wordList    string
+		wordList     string
+		hasWordList  bool
 		functionName string
-		word        string // The word to generate completions for
+		actions      []string
+		prefix       string
+		suffix       string
+		filter       string
+		word         string // The word to generate completions for
 	)
 
 	for i := 0; i < len(args); i++ {
@@ -42,13 +68,53 @@ func handleCompgenCommand(ctx context.Context, runner *interp.Runner, args []str
 				return fmt.Errorf("option -W requires a word list")
 			}
 			i++
-			wordList = args[i]
+			wordList, hasWordList = args[i], true
 		case "-F":
 			if i+1 >= len(args) {
 				return fmt.Errorf("option -F requires a function name")
 			}
 			i++
 			functionName = args[i]
+		case "-A":
+			if i+1 >= len(args) {
+				return fmt.Errorf("option -A requires an action")
+			}
+			i++
+			if _, ok := compgenActions[args[i]]; !ok {
+				return fmt.Errorf("compgen: unsupported action: %s", args[i])
+			}
+			actions = append(actions, args[i])
+		case "-c", "-d", "-f", "-v":
+			for action, option := range compgenActions {
+				if "-"+option == arg {
+					actions = append(actions, action)
+				}
+			}
+		case "-P", "-S", "-X":
+			if i+1 >= len(args) {
+				return fmt.Errorf("option %s requires an argument", arg)
+			}
+			i++
+			switch arg {
+			case "-P":
+				prefix = args[i]
+			case "-S":
+				suffix = args[i]
+			case "-X":
+				filter = args[i]
+			}
+		case "-o":
+			// Options such as filenames or nospace only change how bash
+			// inserts the words
+			if i+1 >= len(args) {
+				return fmt.Errorf("option -o requires an option name")
+			}
+			i++
+		case "--":
+			if i+1 < len(args) {
+				word = args[i+1]
+			}
+			i = len(args)
 		default:
 			if !strings.HasPrefix(arg, "-") {
 				word = arg
@@ -59,42 +125,183 @@ func handleCompgenCommand(ctx context.Context, runner *interp.Runner, args []str
 	}
 
 	// Generate completions based on the options
-	if wordList != "" {
-		return generateWordListCompletions(word, wordList)
+	var words []string
+	for _, action := range actions {
+		words = append(words, actionCompletions(hc, runner, action, word)...)
+	}
+	if hasWordList {
+		words = append(words, wordListCompletions(hc, word, wordList)...)
 	}
-
 	if functionName != "" {
-		return generateFunctionCompletions(ctx, runner, functionName, word)
+		if runner == nil {
+			return fmt.Errorf("compgen: runner not initialized")
+		}
+		completions, err := NewCompletionFunction(functionName, runner).Execute(ctx, []string{word})
+		if err != nil {
+			return fmt.Errorf("failed to execute completion function: %w", err)
+		}
+		for _, completion := range completions {
+			if word == "" || strings.HasPrefix(completion, word) {
+				words = append(words, completion)
+			}
+		}
+	}
+	if len(actions) == 0 && !hasWordList && functionName == "" {
+		return fmt.Errorf("compgen: no completion type specified")
 	}
 
-	return fmt.Errorf("compgen: no completion type specified")
+	printWords(hc.Stdout, filterWords(words, filter), prefix, suffix)
+	return nil
 }
 
-func generateWordListCompletions(word string, wordList string) error {
-	words := strings.Fields(wordList)
-	for _, w := range words {
-		if word == "" || strings.HasPrefix(w, word) {
-			_, _ = printf("%s\n", w)
+// wordListCompletions returns the words of wordList that start with word.
+// Like bash, compgen expands the list, e.g. {0..255}, before splitting it.
+func wordListCompletions(hc interp.HandlerContext, word string, wordList string) []string {
+	var words []string
+	for _, field := range strings.Fields(wordList) {
+		for _, w := range expandWordListField(hc, field) {
+			if word == "" || strings.HasPrefix(w, word) {
+				words = append(words, w)
+			}
 		}
 	}
-	return nil
+	return words
+}
+
+// expandWordListField expands the braces and parameters of field, or
+// returns it as it is if it is not a valid word.
+func expandWordListField(hc interp.HandlerContext, field string) []string {
+	if !strings.ContainsAny(field, "{$") {
+		return []string{field}
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(field), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return []string{field}
+	}
+	call, ok := file.Stmts[0].Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) != 1 || len(call.Assigns) > 0 {
+		return []string{field}
+	}
+	fields, err := expand.Fields(&expand.Config{Env: hc.Env}, call.Args[0])
+	if err != nil {
+		return []string{field}
+	}
+	return fields
 }
 
-func generateFunctionCompletions(ctx context.Context, runner *interp.Runner, functionName string, word string) error {
-	// Create a completion function
-	fn := NewCompletionFunction(functionName, runner)
+// actionCompletions returns the words of the kind action that start with word.
+func actionCompletions(hc interp.HandlerContext, runner *interp.Runner, action string, word string) []string {
+	var words []string
+	switch action {
+	case "file", "directory":
+		words = pathCompletions(hc.Dir, word, action == "directory")
+	case "command":
+		seen := map[string]bool{}
+		for _, dir := range filepath.SplitList(hc.Env.Get("PATH").String()) {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if seen[name] || !strings.HasPrefix(name, word) {
+					continue
+				}
+				if info, err := entry.Info(); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+					seen[name] = true
+					words = append(words, name)
+				}
+			}
+		}
+		for _, name := range functionNames(runner, word) {
+			if !seen[name] {
+				words = append(words, name)
+			}
+		}
+	case "function":
+		words = functionNames(runner, word)
+	case "variable":
+		hc.Env.Each(func(name string, vr expand.Variable) bool {
+			if vr.IsSet() && strings.HasPrefix(name, word) {
+				words = append(words, name)
+			}
+			return true
+		})
+	}
+	slices.Sort(words)
+	return words
+}
 
-	// Execute the function with the word as argument
-	completions, err := fn.Execute(ctx, []string{word})
+// pathCompletions returns the paths that start with word, relative to dir,
+// as bash's compgen -f and -d do: without a slash after directories, and
+// hidden files only if word names them.
+func pathCompletions(dir string, word string, onlyDirs bool) []string {
+	parent, base := filepath.Split(word)
+	readDir := parent
+	if readDir == "" {
+		readDir = "."
+	}
+	if !filepath.IsAbs(readDir) {
+		readDir = filepath.Join(dir, readDir)
+	}
+	entries, err := os.ReadDir(readDir)
 	if err != nil {
-		return fmt.Errorf("failed to execute completion function: %w", err)
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if onlyDirs {
+			info, err := os.Stat(filepath.Join(readDir, name))
+			if err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		paths = append(paths, parent+name)
 	}
+	return paths
+}
 
-	// Print the completions
-	for _, completion := range completions {
-		if word == "" || strings.HasPrefix(completion, word) {
-			_, _ = printf("%s\n", completion)
+// functionNames returns the shell functions of runner that start with word.
+func functionNames(runner *interp.Runner, word string) []string {
+	if runner == nil {
+		return nil
+	}
+	var names []string
+	for name := range runner.Funcs {
+		if strings.HasPrefix(name, word) {
+			names = append(names, name)
 		}
 	}
-	return nil
-}
\ No newline at end of file
+	slices.Sort(names)
+	return names
+}
+
+// filterWords drops the words that match the pattern of -X, or keep only
+// those if it starts with !.
+func filterWords(words []string, filter string) []string {
+	if filter == "" {
+		return words
+	}
+	keep := strings.HasPrefix(filter, "!")
+	expr, err := pattern.Regexp(strings.TrimPrefix(filter, "!"), pattern.EntireString)
+	if err != nil {
+		return words
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return words
+	}
+	return slices.DeleteFunc(words, func(w string) bool {
+		return re.MatchString(w) != keep
+	})
+}
+
+func printWords(out io.Writer, words []string, prefix, suffix string) {
+	for _, w := range words {
+		_, _ = fmt.Fprintf(out, "%s%s%s\n", prefix, w, suffix)
+	}
+}
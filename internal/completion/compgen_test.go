@@ -1,8 +1,9 @@
 package completion
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -11,10 +12,6 @@ import (
 )
 
 func TestCompgenCommand(t *testing.T) {
-	// Save and restore printf
-	oldPrintf := printf
-	defer func() { printf = oldPrintf }()
-
 	tests := []struct {
 		name          string
 		args          []string
@@ -58,6 +55,38 @@ func TestCompgenCommand(t *testing.T) {
 			`,
 			want: []string{"bar", "baz"},
 		},
+		{
+			name: "word list expanded before filtering",
+			args: []string{"compgen", "-W", "{8..11}", "--", "1"},
+			want: []string{"10", "11"},
+		},
+		{
+			name: "word list with prefix and suffix",
+			args: []string{"compgen", "-P", "<", "-S", ">", "-W", "foo bar", "--", "f"},
+			want: []string{"<foo>"},
+		},
+		{
+			name: "word list filtered with -X",
+			args: []string{"compgen", "-X", "!*a*", "-W", "foo bar baz"},
+			want: []string{"bar", "baz"},
+		},
+		{
+			name:        "variables",
+			args:        []string{"compgen", "-A", "variable", "BISH_TEST_"},
+			setupScript: "BISH_TEST_ONE=1 BISH_TEST_TWO=2",
+			want:        []string{"BISH_TEST_ONE", "BISH_TEST_TWO"},
+		},
+		{
+			name:        "functions",
+			args:        []string{"compgen", "-A", "function", "_my"},
+			setupScript: "_my_a() { :; }; _my_b() { :; }; other() { :; }",
+			want:        []string{"_my_a", "_my_b"},
+		},
+		{
+			name: "command substitution",
+			args: []string{"eval", `COMPREPLY=($(compgen -W "start stop status" -- st)); echo "${COMPREPLY[@]}"`},
+			want: []string{"start", "stop", "status"},
+		},
 		{
 			name:          "missing -W argument",
 			args:          []string{"compgen", "-W"},
@@ -92,16 +121,14 @@ func TestCompgenCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a buffer to capture output
-			var output []string
-			printf = func(format string, a ...interface{}) (n int, err error) {
-				output = append(output, strings.TrimSpace(fmt.Sprintf(format, a...)))
-				return 0, nil
-			}
-
-			// Create a new runner
+			// Create a new runner that captures what compgen prints
+			var stdout bytes.Buffer
 			parser := syntax.NewParser()
-			runner, err := interp.New()
+			var runner *interp.Runner
+			runner, err := interp.New(
+				interp.StdIO(nil, &stdout, io.Discard),
+				interp.ExecHandlers(NewCompgenCommandHandler(func() *interp.Runner { return runner })),
+			)
 			if err != nil {
 				t.Fatalf("failed to create runner: %v", err)
 			}
@@ -117,12 +144,17 @@ func TestCompgenCommand(t *testing.T) {
 				}
 			}
 
-			// Create the handler
-			handler := NewCompgenCommandHandler(runner)
-			execHandler := handler(nil)
-
 			// Run the command
-			err = execHandler(context.Background(), tt.args)
+			words := make([]string, len(tt.args))
+			for i, arg := range tt.args {
+				words[i] = quote(arg)
+			}
+			file, err := parser.Parse(strings.NewReader(strings.Join(words, " ")), "")
+			if err != nil {
+				t.Fatalf("failed to parse command: %v", err)
+			}
+			err = runner.Run(context.Background(), file)
+			output := strings.Fields(stdout.String())
 
 			// Check error
 			if tt.wantErr {
@@ -153,4 +185,3 @@ func TestCompgenCommand(t *testing.T) {
 		})
 	}
 }
-
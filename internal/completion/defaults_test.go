@@ -118,7 +118,7 @@ func TestGitCompleter_Subcommands(t *testing.T) {
 	completer := &GitCompleter{}
 
 	// Test subcommands (empty args, line doesn't matter for subcommand completion)
-	got := completer.GetCompletions([]string{}, "git ")
+	got := completer.GetCompletions("", noEnv, []string{}, "git ")
 
 	expected := []string{"checkout", "commit", "add", "push", "pull", "status"}
 	for _, exp := range expected {
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/robottwo/bishop/internal/wsl"
 	"github.com/robottwo/bishop/pkg/shellinput"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -56,8 +57,19 @@ func formatFileDisplay(name string, entry os.DirEntry) string {
 	return style.Render(name) + indicator
 }
 
+// isWSL reports whether Windows paths should be converted; tests replace it.
+var isWSL = wsl.Detected
+
 // getFileCompletions is the default implementation of file completion
 var getFileCompletions fileCompleter = func(prefix string, currentDirectory string) []shellinput.CompletionCandidate {
+	// Under WSL, Windows paths such as C:\Users\me complete as the paths
+	// they are mounted at
+	if isWSL() {
+		if linuxPath, ok := wsl.ToLinux(prefix); ok {
+			prefix = linuxPath
+		}
+	}
+
 	if prefix == "" {
 		// If prefix is empty, use current directory
 		entries, err := os.ReadDir(currentDirectory)
@@ -193,3 +193,19 @@ func TestFileCompletions(t *testing.T) {
 		})
 	}
 }
+
+func TestFileCompletionsConvertWindowsPathsUnderWSL(t *testing.T) {
+	original := isWSL
+	isWSL = func() bool { return true }
+	t.Cleanup(func() { isWSL = original })
+
+	tmpDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "report.pdf"), nil, 0644))
+
+	// The distribution's own files as Windows names them
+	windowsPath := `\\wsl$\Ubuntu` + strings.ReplaceAll(tmpDir, "/", `\`) + `\rep`
+	results := getFileCompletions(windowsPath, "/")
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, filepath.Join(tmpDir, "report.pdf"), results[0].Value)
+	}
+}
@@ -1,8 +1,10 @@
 package completion
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"mvdan.cc/sh/v3/expand"
@@ -24,50 +26,125 @@ func NewCompletionFunction(name string, runner *interp.Runner) *CompletionFuncti
 	}
 }
 
-// Execute runs the completion function with the given arguments
+// Execute runs the completion function with the given arguments, the last
+// of which is the word being completed. It runs in a subshell, so that the
+// COMP_* variables and COMPREPLY do not end up in the session, and what it
+// prints is discarded.
 func (f *CompletionFunction) Execute(ctx context.Context, args []string) ([]string, error) {
+	line := strings.Join(args, " ")
+	return runCompletionFunction(ctx, completionSubshell(f.Runner), f.Name, args, line, len(line))
+}
+
+// completionSubshell returns a subshell of runner to complete in, with no
+// input and its output discarded.
+func completionSubshell(runner *interp.Runner) *interp.Runner {
+	sub := runner.Subshell()
+	_ = interp.StdIO(nil, io.Discard, io.Discard)(sub)
+	return sub
+}
+
+// runCompletionFunction calls the function name in runner the way bash does
+// for complete -F: with COMP_WORDS, COMP_CWORD, COMP_LINE and COMP_POINT set
+// and the command, the word being completed and the one before as
+// arguments. It returns what the function put in COMPREPLY.
+func runCompletionFunction(ctx context.Context, runner *interp.Runner, name string, args []string, line string, point int) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = quote(arg)
+	}
+	current, previous := args[len(args)-1], ""
+	if len(args) > 1 {
+		previous = args[len(args)-2]
+	}
 	script := fmt.Sprintf(`
-		# Set up completion environment
-		COMP_LINE=%q
+		COMP_LINE=%s
 		COMP_POINT=%d
 		COMP_WORDS=(%s)
 		COMP_CWORD=%d
-
-		# Initialize empty COMPREPLY
+		COMP_KEY=9
+		COMP_TYPE=9
 		COMPREPLY=()
-
-		# Call the completion function
-		%s
+		%s %s %s %s
 	`,
-		strings.Join(args, " "),
-		len(strings.Join(args, " ")),
-		strings.Join(args, " "),
+		quote(line),
+		point,
+		strings.Join(words, " "),
 		len(args)-1,
-		f.Name,
+		quote(name), quote(args[0]), quote(current), quote(previous),
 	)
 
-	// Parse and execute the script
 	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse completion script: %w", err)
 	}
-
-	if err := f.Runner.Run(ctx, file); err != nil {
-		return nil, fmt.Errorf("failed to execute completion function: %w", err)
+	if err := runner.Run(ctx, file); err != nil {
+		if _, ok := interp.IsExitStatus(err); !ok {
+			return nil, fmt.Errorf("failed to execute completion function: %w", err)
+		}
 	}
 
 	// Get COMPREPLY from the runner's variables
-	compreply, ok := f.Runner.Vars["COMPREPLY"]
-	if !ok {
+	compreply, ok := runner.Vars["COMPREPLY"]
+	if !ok || compreply.Kind != expand.Indexed {
 		return []string{}, nil
 	}
+	return compreply.List, nil
+}
 
-	if compreply.Kind != expand.Indexed {
-		return []string{}, nil
+// runCompletionCommand runs command in runner the way bash does for
+// complete -C: with COMP_LINE, COMP_POINT, COMP_KEY and COMP_TYPE exported
+// and the command, the word being completed and the one before as
+// arguments. command may be a program, such as aws_completer, or a shell
+// function. It returns the lines it printed, as they are.
+func runCompletionCommand(ctx context.Context, runner *interp.Runner, command string, args []string, line string, point int) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current, previous := args[len(args)-1], ""
+	if len(args) > 1 {
+		previous = args[len(args)-2]
+	}
+	// Like bash, the command is run as it was given, so that it may have
+	// arguments of its own
+	script := fmt.Sprintf(`
+		export COMP_LINE=%s COMP_POINT=%d COMP_KEY=9 COMP_TYPE=9
+		%s %s %s %s
+	`,
+		quote(line),
+		point,
+		command, quote(args[0]), quote(current), quote(previous),
+	)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse completion command: %w", err)
+	}
+	var out bytes.Buffer
+	_ = interp.StdIO(nil, &out, io.Discard)(runner)
+	if err := runner.Run(ctx, file); err != nil {
+		if _, ok := interp.IsExitStatus(err); !ok {
+			return nil, fmt.Errorf("failed to execute completion command: %w", err)
+		}
 	}
 
-	// Get all elements of the array
-	results := compreply.List
-	return results, nil
+	var words []string
+	for _, word := range strings.Split(out.String(), "\n") {
+		if word = strings.TrimSuffix(word, "\r"); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words, nil
 }
 
+// quote quotes s as a single shell word.
+func quote(s string) string {
+	quoted, err := syntax.Quote(s, syntax.LangBash)
+	if err != nil {
+		// Only strings with NUL bytes cannot be quoted
+		return "''"
+	}
+	return quoted
+}
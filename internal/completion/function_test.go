@@ -3,10 +3,13 @@ package completion
 import (
 	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
 )
@@ -81,4 +84,46 @@ _test_completion() {
 		assert.NoError(t, err)
 		assert.Equal(t, []string{"foo", "bar", "baz"}, results)
 	})
-}
\ No newline at end of file
+}
+
+func TestCompletionCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	dir := t.TempDir()
+	completer := filepath.Join(dir, "tool_completer")
+	script := "#!" + sh + `
+# Completes the way aws_completer does, from the line alone
+case "$COMP_LINE" in
+	*" s3 "*) printf 's3://bucket\nls\n' ;;
+	*) printf 's3\nec2\n' ;;
+esac
+echo "pwd=$PWD args=$1,$2,$3 region=$AWS_REGION"
+`
+	require.NoError(t, os.WriteFile(completer, []byte(script), 0o755))
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(`
+_tool_complete() { echo "$1:$2:$3"; echo "$COMP_LINE@$COMP_POINT"; }
+export AWS_REGION=eu-west-1
+`), "")
+	require.NoError(t, err)
+	runner, err := interp.New(interp.Dir(dir))
+	require.NoError(t, err)
+	require.NoError(t, runner.Run(context.Background(), file))
+
+	manager := NewCompletionManager()
+	complete := func(command, line string) []string {
+		spec := CompletionSpec{Command: "tool", Type: CommandCompletion, Value: command}
+		suggestions, err := manager.ExecuteCompletion(context.Background(), runner, spec, splitPreservingQuotes(line), line, len(line))
+		require.NoError(t, err)
+		return bashCompletionValues(suggestions)
+	}
+
+	assert.Equal(t, []string{"s3", "ec2", "pwd=" + dir + " args=tool,e,tool region=eu-west-1"}, complete(completer, "tool e"),
+		"the program runs in the session's directory and environment")
+	assert.Equal(t, []string{"s3://bucket", "ls", "pwd=" + dir + " args=tool,,s3 region=eu-west-1"}, complete(completer, "tool s3 "),
+		"a new word is completed after a space")
+	assert.Equal(t, []string{"tool:b:a", "tool a b@8"}, complete("_tool_complete", "tool a b"), "functions can complete")
+	assert.NotContains(t, runner.Vars, "COMP_LINE", "the variables stay out of the session")
+}
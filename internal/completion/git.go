@@ -1,16 +1,75 @@
 package completion
 
 import (
+	"context"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/robottwo/bishop/internal/git"
 	"github.com/robottwo/bishop/pkg/shellinput"
 )
 
-// GitCompleter handles built-in completion for git
-type GitCompleter struct{}
+const (
+	// gitCacheTTL is how long the refs and the status of a repository are
+	// reused, so that the completions of one Tab press, and those that
+	// follow it while the menu is open, run git once.
+	gitCacheTTL = 3 * time.Second
+	// gitTimeout bounds a single git run, so that a huge repository does
+	// not hold up completion.
+	gitTimeout = 2 * time.Second
+)
+
+// GitCompleter handles built-in completion for git: its subcommands, and the
+// branches, tags, remotes and changed files of the repository the shell is
+// in, which it reads with git and caches per repository.
+type GitCompleter struct {
+	mu    sync.Mutex
+	repos map[string]*gitRepoCache
+	// run runs git with args in repo and returns its output
+	run func(ctx context.Context, repo *git.Repo, args ...string) (string, error)
+	now func() time.Time
+}
+
+// gitRepoCache is what is known of one repository.
+type gitRepoCache struct {
+	mu       sync.Mutex
+	refs     *gitRefs
+	refsAt   time.Time
+	files    []gitFile
+	filesAt  time.Time
+	hasFiles bool
+}
+
+// gitRefs are the refs and remotes of a repository.
+type gitRefs struct {
+	branches []gitRef
+	// remoteBranches are named remote/branch
+	remoteBranches []gitRef
+	tags           []gitRef
+	remotes        []string
+}
 
-func (g *GitCompleter) GetCompletions(args []string, line string) []shellinput.CompletionCandidate {
+type gitRef struct {
+	name    string
+	subject string
+}
+
+// gitFile is a changed file, with its status as git status --porcelain
+// shows it: in the index, x, and in the work tree, y.
+type gitFile struct {
+	// path is relative to the top of the work tree
+	path string
+	x, y byte
+}
+
+// GetCompletions completes git args, where dir is the directory the shell is
+// in and getenv looks up its variables.
+func (g *GitCompleter) GetCompletions(dir string, getenv func(string) string, args []string, line string) []shellinput.CompletionCandidate {
 	if len(args) == 0 {
 		// Complete git subcommands
 		commands := []struct {
@@ -54,132 +113,392 @@ func (g *GitCompleter) GetCompletions(args []string, line string) []shellinput.C
 	// current word being completed is the last one in args
 	// BUT if line ends with space, we're completing a new empty word
 	currentWord := ""
-	if len(args) > 1 {
-		currentWord = args[len(args)-1]
+	words := args[1:]
+	if len(words) > 0 {
+		currentWord = words[len(words)-1]
+		words = words[:len(words)-1]
 	}
 	// If line ends with space, we're starting a new word (empty prefix)
 	if len(line) > 0 && line[len(line)-1] == ' ' {
 		currentWord = ""
+		words = args[1:]
+	}
+	if strings.HasPrefix(currentWord, "-") {
+		// Options are left to the other completers
+		return nil
+	}
+
+	repo := git.FindRepo(dir, getenv)
+	if repo == nil {
+		return nil
+	}
+	// The options and arguments before the word being completed
+	position, afterDashes, staged := 0, false, false
+	for _, word := range words {
+		switch {
+		case afterDashes:
+			position++
+		case word == "--":
+			afterDashes = true
+		case word == "--staged" || word == "--cached" || word == "-S":
+			staged = true
+		case !strings.HasPrefix(word, "-"):
+			position++
+		}
 	}
 
 	switch subcommand {
-	case "checkout", "switch", "merge", "rebase":
-		return g.completeBranches(currentWord)
-	case "add", "rm", "restore":
-		return g.completeFiles(currentWord)
+	case "checkout":
+		if afterDashes {
+			return g.completeFiles(repo, dir, currentWord, worktreeChanged)
+		}
+		return append(g.completeBranches(repo, currentWord), g.completeTags(repo, currentWord)...)
+	case "switch":
+		return g.completeBranches(repo, currentWord)
+	case "merge", "rebase", "cherry-pick", "log", "reset", "show", "branch":
+		if subcommand == "reset" && afterDashes {
+			return g.completeFiles(repo, dir, currentWord, indexChanged)
+		}
+		return g.completeRefs(repo, currentWord)
+	case "tag":
+		return g.completeTags(repo, currentWord)
+	case "push", "pull", "fetch":
+		// git push <remote> <branch>...
+		if position == 0 {
+			return g.completeRemotes(repo, currentWord)
+		}
+		if subcommand == "push" {
+			return g.completeLocalBranches(repo, currentWord)
+		}
+		return g.completeRemoteBranches(repo, words, currentWord)
+	case "add":
+		return g.completeFiles(repo, dir, currentWord, addable)
+	case "rm":
+		return g.completeFiles(repo, dir, currentWord, tracked)
+	case "restore":
+		if staged {
+			return g.completeFiles(repo, dir, currentWord, indexChanged)
+		}
+		return g.completeFiles(repo, dir, currentWord, worktreeChanged)
+	case "diff":
+		if staged {
+			return g.completeFiles(repo, dir, currentWord, indexChanged)
+		}
+		return g.completeFiles(repo, dir, currentWord, worktreeChanged)
 	}
 
 	return nil
 }
 
-func (g *GitCompleter) completeBranches(prefix string) []shellinput.CompletionCandidate {
-	// Run git branch --all with format to get both local and remote branch names
-	// Format: branch_name|commit_subject
-	cmd := exec.Command("git", "branch", "--all", "--format=%(refname:short)|%(contents:subject)")
-	out, err := cmd.Output()
-	if err != nil {
+// Filters of changed files.
+func addable(f gitFile) bool         { return f.y != ' ' && f.y != '!' }
+func worktreeChanged(f gitFile) bool { return f.y != ' ' && f.y != '?' && f.y != '!' }
+func indexChanged(f gitFile) bool    { return f.x != ' ' && f.x != '?' && f.x != '!' }
+func tracked(f gitFile) bool         { return f.x != '?' && f.x != '!' }
+
+// completeBranches completes the branches to check out: the local ones, and
+// those of remotes by the name a local branch tracking them would have.
+func (g *GitCompleter) completeBranches(repo *git.Repo, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
 		return nil
 	}
-
-	var candidates []shellinput.CompletionCandidate
-	seenBranches := make(map[string]bool) // Track branches we've already added
-	lines := strings.Split(string(out), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
+	candidates := branchCandidates(refs.branches, prefix, "")
+	seen := make(map[string]bool) // Local branches win over remote ones
+	for _, branch := range refs.branches {
+		seen[branch.name] = true
+	}
+	for _, branch := range refs.remoteBranches {
+		remote, name, _ := strings.Cut(branch.name, "/")
+		if seen[name] || !strings.HasPrefix(name, prefix) {
 			continue
 		}
+		seen[name] = true
+		candidates = append(candidates, shellinput.CompletionCandidate{
+			Value:       name,
+			Description: remoteDescription(remote, branch.subject),
+		})
+	}
+	return candidates
+}
 
-		// Split by the delimiter to get branch name and commit message
-		parts := strings.SplitN(line, "|", 2)
-		branchName := parts[0]
-		commitMsg := ""
-		if len(parts) > 1 {
-			commitMsg = parts[1]
-			// Truncate long commit messages
-			if len(commitMsg) > 80 {
-				commitMsg = commitMsg[:77] + "..."
-			}
+// completeLocalBranches completes the local branches, such as those to push.
+func (g *GitCompleter) completeLocalBranches(repo *git.Repo, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
+		return nil
+	}
+	return branchCandidates(refs.branches, prefix, "")
+}
+
+// completeRemoteBranches completes the branches of the remote named in
+// words, such as those to pull.
+func (g *GitCompleter) completeRemoteBranches(repo *git.Repo, words []string, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
+		return nil
+	}
+	remote := ""
+	for _, word := range words {
+		if !strings.HasPrefix(word, "-") {
+			remote = word
+			break
 		}
+	}
+	var candidates []shellinput.CompletionCandidate
+	for _, branch := range refs.remoteBranches {
+		name, ok := strings.CutPrefix(branch.name, remote+"/")
+		if ok && strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, shellinput.CompletionCandidate{
+				Value:       name,
+				Description: remoteDescription(remote, branch.subject),
+			})
+		}
+	}
+	return candidates
+}
 
-		// Skip HEAD pointer entries (e.g., "origin/HEAD")
-		if strings.HasSuffix(branchName, "/HEAD") {
-			continue
+// completeRefs completes what names a commit: local branches, remote
+// branches by their full name, and tags.
+func (g *GitCompleter) completeRefs(repo *git.Repo, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
+		return nil
+	}
+	candidates := branchCandidates(refs.branches, prefix, "")
+	for _, branch := range refs.remoteBranches {
+		remote, _, _ := strings.Cut(branch.name, "/")
+		if strings.HasPrefix(branch.name, prefix) {
+			candidates = append(candidates, shellinput.CompletionCandidate{
+				Value:       branch.name,
+				Description: remoteDescription(remote, branch.subject),
+			})
 		}
+	}
+	return append(candidates, g.completeTags(repo, prefix)...)
+}
 
-		// Check if this is a remote branch (contains a slash like "origin/branch-name")
-		isRemote := false
-		displayName := branchName
-		if idx := strings.Index(branchName, "/"); idx != -1 {
-			isRemote = true
-			// Extract just the branch name without remote prefix for checkout convenience
-			// e.g., "origin/feature-branch" -> "feature-branch"
-			displayName = branchName[idx+1:]
+func (g *GitCompleter) completeTags(repo *git.Repo, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
+		return nil
+	}
+	return branchCandidates(refs.tags, prefix, "[tag] ")
+}
+
+func (g *GitCompleter) completeRemotes(repo *git.Repo, prefix string) []shellinput.CompletionCandidate {
+	refs := g.refs(repo)
+	if refs == nil {
+		return nil
+	}
+	var candidates []shellinput.CompletionCandidate
+	for _, remote := range refs.remotes {
+		if strings.HasPrefix(remote, prefix) {
+			candidates = append(candidates, shellinput.CompletionCandidate{
+				Value:       remote,
+				Description: repo.RemoteURL(remote),
+			})
 		}
+	}
+	return candidates
+}
 
-		// Skip if we've already added this branch name (prefer local over remote)
-		if seenBranches[displayName] {
-			continue
+func branchCandidates(refs []gitRef, prefix, label string) []shellinput.CompletionCandidate {
+	var candidates []shellinput.CompletionCandidate
+	for _, ref := range refs {
+		if strings.HasPrefix(ref.name, prefix) {
+			candidates = append(candidates, shellinput.CompletionCandidate{
+				Value:       ref.name,
+				Description: label + ref.subject,
+			})
 		}
+	}
+	return candidates
+}
 
-		if strings.HasPrefix(displayName, prefix) {
-			description := commitMsg
-			if isRemote {
-				// Extract remote name for the description
-				remoteName := branchName[:strings.Index(branchName, "/")]
-				if commitMsg != "" {
-					description = "[" + remoteName + "] " + commitMsg
-				} else {
-					description = "[" + remoteName + "]"
-				}
-			}
+func remoteDescription(remote, subject string) string {
+	if subject == "" {
+		return "[" + remote + "]"
+	}
+	return "[" + remote + "] " + subject
+}
 
+// completeFiles completes the changed files that match, by their path from
+// dir.
+func (g *GitCompleter) completeFiles(repo *git.Repo, dir, prefix string, match func(gitFile) bool) []shellinput.CompletionCandidate {
+	files, ok := g.files(repo)
+	if !ok {
+		// Let the shell fall back to standard file completion
+		return nil
+	}
+	var candidates []shellinput.CompletionCandidate
+	for _, file := range files {
+		if !match(file) {
+			continue
+		}
+		path, err := filepath.Rel(dir, filepath.Join(repo.Root, file.path))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(path, prefix) {
 			candidates = append(candidates, shellinput.CompletionCandidate{
-				Value:       displayName,
-				Description: description,
+				Value:       path,
+				Description: fileDescription(file),
 			})
-			seenBranches[displayName] = true
 		}
 	}
 	return candidates
 }
 
-func (g *GitCompleter) completeFiles(prefix string) []shellinput.CompletionCandidate {
-	// For 'add', 'rm', etc., we usually want modified files or all files.
-	// 'git status --porcelain' gives status of files.
-	// Or just rely on file completion fallback if prefix looks like path.
-	// Let's try git status for modified files which are most likely targets.
+func fileDescription(f gitFile) string {
+	switch {
+	case f.x == '?':
+		return "Untracked file"
+	case f.x == 'U' || f.y == 'U' || (f.x == 'A' && f.y == 'A') || (f.x == 'D' && f.y == 'D'):
+		return "Conflicted file"
+	case f.y == 'D':
+		return "Deleted file"
+	case f.y != ' ':
+		return "Modified file"
+	case f.x == 'A':
+		return "Added file"
+	case f.x == 'R':
+		return "Renamed file"
+	case f.x == 'D':
+		return "Deleted file"
+	}
+	return "Staged file"
+}
 
-	cmd := exec.Command("git", "status", "--porcelain")
-	out, err := cmd.Output()
+// cache returns the cache of repo.
+func (g *GitCompleter) cache(repo *git.Repo) *gitRepoCache {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.repos == nil {
+		g.repos = map[string]*gitRepoCache{}
+	}
+	key := repo.Root + "\x00" + repo.GitDir
+	cache, ok := g.repos[key]
+	if !ok {
+		cache = &gitRepoCache{}
+		g.repos[key] = cache
+	}
+	return cache
+}
+
+func (g *GitCompleter) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
+func (g *GitCompleter) git(repo *git.Repo, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	if g.run != nil {
+		return g.run(ctx, repo, args...)
+	}
+	return runGit(ctx, repo, args...)
+}
+
+// refs returns the refs of repo, or nil if they cannot be read.
+func (g *GitCompleter) refs(repo *git.Repo) *gitRefs {
+	cache := g.cache(repo)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.refs != nil && g.clock().Sub(cache.refsAt) < gitCacheTTL {
+		return cache.refs
+	}
+	out, err := g.git(repo, "for-each-ref", "--format=%(refname)%00%(contents:subject)",
+		"refs/heads", "refs/remotes", "refs/tags")
 	if err != nil {
-		// Fallback to simple file completion from disk?
-		// Actually, let's return nothing and let the shell fall back to standard file completion
-		// if we can't find specific git files.
-		// BUT, if we return non-nil empty list, it might stop fallback.
-		// If we return nil, it falls back.
 		return nil
 	}
+	refs := parseRefs(out)
+	if out, err := g.git(repo, "remote"); err == nil {
+		refs.remotes = strings.Fields(out)
+	}
+	cache.refs, cache.refsAt = refs, g.clock()
+	return refs
+}
 
-	var candidates []shellinput.CompletionCandidate
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		if len(line) > 3 {
-			// XY PATH
-			path := line[3:]
-			if strings.HasPrefix(path, prefix) {
-				candidates = append(candidates, shellinput.CompletionCandidate{
-					Value:       path,
-					Description: "Modified file",
-				})
+// files returns the changed files of repo, and whether they could be read.
+func (g *GitCompleter) files(repo *git.Repo) ([]gitFile, bool) {
+	cache := g.cache(repo)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.hasFiles && g.clock().Sub(cache.filesAt) < gitCacheTTL {
+		return cache.files, true
+	}
+	args := []string{"status", "--porcelain", "-z"}
+	if repo.Kind == git.KindBare {
+		// Bare repositories track a few files in a directory full of others
+		args = append(args, "--untracked-files=no")
+	} else {
+		args = append(args, "--untracked-files=all")
+	}
+	out, err := g.git(repo, args...)
+	if err != nil {
+		return nil, false
+	}
+	cache.files, cache.filesAt, cache.hasFiles = parseStatusZ(out), g.clock(), true
+	return cache.files, true
+}
+
+// parseRefs parses the output of git for-each-ref with the refname and the
+// subject separated by a NUL.
+func parseRefs(out string) *gitRefs {
+	refs := &gitRefs{}
+	for _, line := range strings.Split(out, "\n") {
+		name, subject, _ := strings.Cut(line, "\x00")
+		// Truncate long commit messages
+		if len(subject) > 80 {
+			subject = subject[:77] + "..."
+		}
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			refs.branches = append(refs.branches, gitRef{strings.TrimPrefix(name, "refs/heads/"), subject})
+		case strings.HasPrefix(name, "refs/remotes/"):
+			name = strings.TrimPrefix(name, "refs/remotes/")
+			// Skip HEAD pointer entries (e.g., "origin/HEAD")
+			if !strings.HasSuffix(name, "/HEAD") && strings.Contains(name, "/") {
+				refs.remoteBranches = append(refs.remoteBranches, gitRef{name, subject})
 			}
+		case strings.HasPrefix(name, "refs/tags/"):
+			refs.tags = append(refs.tags, gitRef{strings.TrimPrefix(name, "refs/tags/"), subject})
 		}
 	}
+	return refs
+}
 
-	// Also allow standard files if we have few candidates?
-	// The user might want to add a new untracked file (which shows up in porcelain with ??)
-	// So porcelain covers untracked files too.
+// parseStatusZ parses the output of git status --porcelain -z, where each
+// entry is "XY path" and renames and copies are followed by the path they
+// come from, which is left out.
+func parseStatusZ(out string) []gitFile {
+	var files []gitFile
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		file := gitFile{path: entry[3:], x: entry[0], y: entry[1]}
+		if file.x == 'R' || file.x == 'C' {
+			i++
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
 
-	return candidates
+// runGit runs git with args in repo. It takes no optional locks, so that it
+// does not get in the way of the user's own git commands.
+func runGit(ctx context.Context, repo *git.Repo, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append(repo.GitArgs(), args...)...)
+	cmd.Dir = repo.Root
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	out, err := cmd.Output()
+	return string(out), err
 }
@@ -0,0 +1,120 @@
+package completion
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/git"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noEnv(string) string { return "" }
+
+// gitRepo creates a repository with a main and a feature branch, a tag, an
+// origin remote with a branch only it has, and changes of every kind.
+func gitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	remote := t.TempDir()
+	run := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t",
+			"GIT_COMMITTER_EMAIL=t@t", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0o644))
+	}
+
+	run(remote, "init", "-q", "--bare", "-b", "main")
+	run(root, "init", "-q", "-b", "main")
+	write("README.md", "hello")
+	write("src/main.go", "package main")
+	write("old.txt", "old")
+	run(root, "add", ".")
+	run(root, "commit", "-q", "-m", "Initial commit")
+	run(root, "tag", "v1.0")
+	run(root, "branch", "feature")
+	run(root, "remote", "add", "origin", remote)
+	run(root, "push", "-q", "origin", "main", "main:release")
+	run(root, "fetch", "-q", "origin")
+
+	write("README.md", "changed")
+	write("src/new.go", "package main")
+	write("staged.txt", "staged")
+	run(root, "add", "staged.txt")
+	run(root, "mv", "old.txt", "renamed.txt")
+	return root
+}
+
+func values(candidates []shellinput.CompletionCandidate) []string {
+	var values []string
+	for _, c := range candidates {
+		values = append(values, c.Value)
+	}
+	return values
+}
+
+func TestGitCompleterRepository(t *testing.T) {
+	root := gitRepo(t)
+	completer := &GitCompleter{}
+	complete := func(dir, line string) []string {
+		words := splitPreservingQuotes(line)
+		return values(completer.GetCompletions(dir, noEnv, words[1:], line))
+	}
+
+	assert.Equal(t, []string{"feature", "main", "release", "v1.0"}, complete(root, "git checkout "),
+		"branches of remotes complete by their local name")
+	assert.Equal(t, []string{"feature"}, complete(root, "git switch f"))
+	assert.Equal(t, []string{"origin"}, complete(root, "git push "))
+	assert.Equal(t, []string{"origin"}, complete(root, "git push -u o"))
+	assert.Equal(t, []string{"feature", "main"}, complete(root, "git push origin "))
+	assert.Equal(t, []string{"main", "release"}, complete(root, "git pull origin "))
+	assert.Equal(t, []string{"origin/main", "origin/release"}, complete(root, "git merge origin/"))
+	assert.Equal(t, []string{"README.md", "src/new.go"}, complete(root, "git add "),
+		"only files with changes to add complete")
+	assert.Equal(t, []string{"../README.md", "new.go"}, complete(filepath.Join(root, "src"), "git add "),
+		"paths are relative to the current directory")
+	assert.Equal(t, []string{"renamed.txt", "staged.txt"}, complete(root, "git restore --staged "))
+	assert.Equal(t, []string{"README.md"}, complete(root, "git restore "))
+	assert.Nil(t, complete(root, "git add --"), "options are left to the other completers")
+	assert.Nil(t, complete(t.TempDir(), "git checkout "), "nothing completes outside a repository")
+}
+
+func TestGitCompleterCache(t *testing.T) {
+	root := gitRepo(t)
+	now := time.Now()
+	runs := 0
+	completer := &GitCompleter{
+		now: func() time.Time { return now },
+		run: func(ctx context.Context, repo *git.Repo, args ...string) (string, error) {
+			runs++
+			return runGit(ctx, repo, args...)
+		},
+	}
+
+	completer.GetCompletions(root, noEnv, []string{"checkout", ""}, "git checkout ")
+	first := runs
+	completer.GetCompletions(root, noEnv, []string{"checkout", "m"}, "git checkout m")
+	completer.GetCompletions(filepath.Join(root, "src"), noEnv, []string{"merge", ""}, "git merge ")
+	assert.Equal(t, first, runs, "the refs of a repository are cached")
+
+	now = now.Add(gitCacheTTL)
+	completer.GetCompletions(root, noEnv, []string{"checkout", ""}, "git checkout ")
+	assert.Equal(t, 2*first, runs, "the refs are read again once old")
+
+	completer.GetCompletions(root, noEnv, []string{"add", ""}, "git add ")
+	completer.GetCompletions(root, noEnv, []string{"add", "R"}, "git add R")
+	assert.Equal(t, 2*first+1, runs, "the status is cached too")
+}
@@ -12,3 +12,9 @@ type CompletionManagerInterface interface {
 	GetSpec(command string) (CompletionSpec, bool)
 	ExecuteCompletion(ctx context.Context, runner *interp.Runner, spec CompletionSpec, args []string, line string, pos int) ([]shellinput.CompletionCandidate, error)
 }
+
+// BashCompleter is implemented by completion managers that can complete
+// commands with the bash completion scripts installed for them.
+type BashCompleter interface {
+	BashCompletion(ctx context.Context, runner *interp.Runner, args []string, line string, pos int) ([]shellinput.CompletionCandidate, bool)
+}
@@ -23,6 +23,9 @@ const (
 	FunctionCompletion CompletionType = "F"
 	// CommandCompletion represents command based completion (-C option)
 	CommandCompletion CompletionType = "C"
+	// ExternalCompletion represents a completer for all commands, such as
+	// carapace, whose output may have descriptions or be JSON
+	ExternalCompletion CompletionType = "external"
 )
 
 // CompletionSpec represents a completion specification for a command
@@ -36,6 +39,8 @@ type CompletionSpec struct {
 // CompletionManager manages command completion specifications
 type CompletionManager struct {
 	specs map[string]CompletionSpec
+	// bash completes with bash completion scripts, if enabled
+	bash *bashCompletion
 }
 
 // NewCompletionManager creates a new CompletionManager
@@ -47,6 +52,9 @@ func NewCompletionManager() *CompletionManager {
 
 // AddSpec adds or updates a completion specification
 func (m *CompletionManager) AddSpec(spec CompletionSpec) {
+	if m.addBashSpec(spec) {
+		return
+	}
 	m.specs[spec.Command] = spec
 }
 
@@ -89,8 +97,11 @@ func (m *CompletionManager) ExecuteCompletion(ctx context.Context, runner *inter
 		return completions, nil
 
 	case FunctionCompletion:
-		fn := NewCompletionFunction(spec.Value, runner)
-		strs, err := fn.Execute(ctx, args)
+		if strings.HasSuffix(line, " ") {
+			// A new word is being completed
+			args = append(args[:len(args):len(args)], "")
+		}
+		strs, err := runCompletionFunction(ctx, completionSubshell(runner), spec.Value, args, line, pos)
 		if err != nil {
 			return nil, err
 		}
@@ -100,9 +111,23 @@ func (m *CompletionManager) ExecuteCompletion(ctx context.Context, runner *inter
 		}
 		return completions, nil
 
-	case CommandCompletion:
+	case ExternalCompletion:
 		return m.RunExternalCompleter(ctx, spec.Value, args, line, pos)
 
+	case CommandCompletion:
+		if runner == nil {
+			return m.RunExternalCompleter(ctx, spec.Value, args, line, pos)
+		}
+		if strings.HasSuffix(line, " ") {
+			// A new word is being completed
+			args = append(args[:len(args):len(args)], "")
+		}
+		strs, err := runCompletionCommand(ctx, completionSubshell(runner), spec.Value, args, line, pos)
+		if err != nil {
+			return nil, err
+		}
+		return toCandidates(strs), nil
+
 	default:
 		return nil, fmt.Errorf("unsupported completion type: %s", spec.Type)
 	}
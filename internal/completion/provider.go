@@ -87,6 +87,17 @@ func (p *ShellCompletionProvider) GetCompletions(line string, pos int) []shellin
 		return make([]shellinput.CompletionCandidate, 0)
 	}
 
+	// Complete variable names, such as $LAST_OUT_FILE, and @name captures
+	// wherever they are typed
+	if !strings.HasSuffix(truncatedLine, " ") {
+		if suggestions := p.getVariableCompletions(words[len(words)-1]); len(suggestions) > 0 {
+			return suggestions
+		}
+		if suggestions := p.getCaptureCompletions(words[len(words)-1]); len(suggestions) > 0 {
+			return suggestions
+		}
+	}
+
 	// Get the command (first word)
 	command := words[0]
 
@@ -107,7 +118,8 @@ func (p *ShellCompletionProvider) GetCompletions(line string, pos int) []shellin
 		if len(words) > 1 {
 			gitArgs = words[1:]
 		}
-		if suggestions := p.gitCompleter.GetCompletions(gitArgs, truncatedLine); len(suggestions) > 0 {
+		getenv := func(name string) string { return p.Runner.Vars[name].String() }
+		if suggestions := p.gitCompleter.GetCompletions(environment.GetPwd(p.Runner), getenv, gitArgs, truncatedLine); len(suggestions) > 0 {
 			return suggestions
 		}
 	}
@@ -131,6 +143,13 @@ func (p *ShellCompletionProvider) GetCompletions(line string, pos int) []shellin
 		return suggestions
 	}
 
+	// Bash completion scripts installed with the command
+	if bashCompleter, ok := p.CompletionManager.(BashCompleter); ok && (len(words) > 1 || strings.HasSuffix(truncatedLine, " ")) {
+		if suggestions, found := bashCompleter.BashCompletion(context.Background(), p.Runner, words, truncatedLine, pos); found {
+			return suggestions
+		}
+	}
+
 	// 3. Global Programmable Fallback (BISH_COMPLETION_COMMAND or Auto-Discovery)
 	globalCompleter := os.Getenv("BISH_COMPLETION_COMMAND")
 	if globalCompleter == "" {
@@ -144,7 +163,7 @@ func (p *ShellCompletionProvider) GetCompletions(line string, pos int) []shellin
 		// Create a temporary spec for the global completer
 		globalSpec := CompletionSpec{
 			Command: command,
-			Type:    CommandCompletion,
+			Type:    ExternalCompletion,
 			Value:   globalCompleter,
 		}
 
@@ -638,12 +657,21 @@ func (p *ShellCompletionProvider) getBuiltinCommandCompletions(prefix string) []
 	builtinCommands := []string{
 		"config",
 		"coach",
+		"enter",
 		"fix",
+		"fleet",
+		"focus",
 		"help",
 		"new",
+		"present",
+		"quiet",
+		"recap",
 		"reload-subagents",
+		"routine",
 		"subagents",
 		"tokens",
+		"triage",
+		"wrapup",
 	}
 
 	var completions []string
@@ -771,7 +799,7 @@ func (p *ShellCompletionProvider) GetHelpInfo(line string, pos int) string {
 
 // getBuiltinCommandHelp returns help information for built-in commands
 func (p *ShellCompletionProvider) getBuiltinCommandHelp(command string) string {
-	helpText := "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations"
+	helpText := "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!focus [task|end]** - Declare what you are working on\n• **#!recap** - Summarize the last session in this project\n• **#!wrapup** - Summarize this session into the project journal\n• **#!routine** - Save a repeated sequence of commands as a function\n• **#!present [on|off]** - Mask secrets while screen-sharing\n• **#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n• **#!enter [name]** - Open a shell in a running container\n• **#!fleet** - Ask the agent to diagnose the last fleet run\n• **#!quiet [duration|off]** - Hide idle summaries and tips for a while\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations"
 
 	switch command {
 	case "help":
@@ -790,11 +818,29 @@ func (p *ShellCompletionProvider) getBuiltinCommandHelp(command string) string {
 		return "**#!reload-subagents** - Reload subagent configurations from disk\n\nRefreshes the subagent configurations by rescanning the .claude/agents/ and .roo/modes/ directories."
 	case "coach":
 		return "**#!coach [subcommand]** - Productivity coach dashboard\n\nSubcommands:\n• **#!coach** or **#!coach dashboard** - View main dashboard\n• **#!coach stats** - View detailed statistics\n• **#!coach achievements** - Browse achievements\n• **#!coach challenges** - View active challenges\n• **#!coach tips** - View all tips\n• **#!coach reset-tips** - Regenerate tips from history"
+	case "recap":
+		return "**#!recap** - Summarize the last session in this project\n\nAt startup, the assistant box shows a recap of the last session in the project of the current directory: its last commands, the last failure and pending TODOs, built from history without the LLM (set BISH_STARTUP_RECAP=0 to turn it off). **#!recap** asks the slow model to summarize that session: what you were doing, whether it looks finished and what to do next."
+	case "wrapup":
+		return "**#!wrapup** - Summarize this session into the project journal\n\nSummarizes what was done since the session started, or since the last wrap-up, with the slow model: key commands, failures fixed and directories touched. The summary is printed and appended to .bish/journal.md at the root of the project, where the next session's agent (and your teammates) can pick it up. The same happens when the shell exits if BISH_WRAPUP_ON_EXIT=1."
+	case "routine":
+		return "**#!routine** - Save a repeated sequence of commands as a function\n\nWhen you type the same three or four commands one after the other several times, e.g. creating a branch, pushing it and opening a pull request, the coach offers to turn them into a shell function. The parts that changed between runs become its parameters. **#!routine** shows the function, named by the fast model unless AI is paused, and once you confirm appends it to the functions section of ~/.bishrc, defines it in the running shell and completes its first parameter with the values you used before."
+	case "triage":
+		return "**#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n\nErrors your config files (~/.bishrc, ~/.bishenv, ...) report while bish starts are listed once above the first prompt. Without arguments, lists them again.\n• **#!triage edit N** - Open the file at the line of error N in $EDITOR\n• **#!triage fix N** - Ask the agent how to fix error N\n• **#!triage ignore N** - Stop reporting error N, until its line changes"
+	case "enter":
+		return "**#!enter [name]** - Open a shell in a running container\n\nWithout arguments, lists the containers running under docker, podman and kubectl. **#!enter web** opens an interactive shell (bash if the container has it, sh otherwise) in the container named web, or matching the ID prefix or identity such as kubectl:staging/api-7f9c. The shell's prompt is marked with ⬢ and the container, BISH_CONTAINER is set in it, and when you exit, the commands you ran there in bash are added to history with their exit codes, tagged with the container."
+	case "fleet":
+		return "**#!fleet** - Ask the agent to diagnose the last fleet run\n\nThe fleet builtin runs a command on a group of hosts over ssh, e.g. **fleet web uptime**. When it fails on some of them, **#!fleet** hands the failed hosts and their output to the agent, which groups them by cause and suggests what to run next. Groups are configured in ~/.config/bish/fleet.yaml; patterns such as **web-*** match the hosts of ~/.ssh/config."
+	case "present":
+		return "**#!present [on|off]** - Mask secrets while screen-sharing\n\nTurns presentation mode on or off for the session (without arguments, toggles it). While it is on, the values of variables that look like secrets, such as GITHUB_TOKEN or OPENAI_API_KEY, are masked in the prompt, history, agent responses, the assistant box and the config UI, and predictions that would reveal them are hidden. Set BISH_PRESENTATION_MODE=1 to turn it on by default."
+	case "quiet":
+		return "**#!quiet [duration|off]** - Hide idle summaries and tips for a while\n\nUseful when screen-sharing: **#!quiet 45m** hides idle summaries, coach tips and notifications, and failure hints for 45 minutes (an hour if no duration is given), with the time left shown in the border status. Add **--no-ai** to also pause predictions and other AI calls. **#!quiet off** ends it early; without arguments during quiet mode, shows the time left."
+	case "focus":
+		return "**#!focus [task|end]** - Declare what you are working on\n\nWith a task, e.g. **#!focus fixing the billing cron**, the task is added to the context for predictions and chat, tagged on the history entries of the commands you run and shown in the border status. Switching to another task or running **#!focus end** prints a summary of the work done on the previous one. Without arguments, shows the current focus."
 	case "":
 		return helpText
 	default:
 		// Check for partial matches
-		builtinCommands := []string{"help", "fix", "config", "new", "tokens", "subagents", "reload-subagents", "coach"}
+		builtinCommands := []string{"help", "fix", "config", "new", "tokens", "subagents", "reload-subagents", "coach", "focus", "quiet", "present", "recap", "triage", "fleet", "enter", "wrapup", "routine"}
 		for _, cmd := range builtinCommands {
 			if strings.HasPrefix(cmd, command) {
 				// Partial match, show general help
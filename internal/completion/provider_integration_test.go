@@ -267,8 +267,8 @@ func TestShellCompletionProvider_BuiltinCompletion_Integration(t *testing.T) {
 			name:          "builtin completion with #! prefix",
 			line:          "#!",
 			pos:           2,
-			expectedCount: 8,
-			shouldContain: []string{"#!config", "#!coach", "#!fix", "#!help", "#!new", "#!reload-subagents", "#!subagents", "#!tokens"},
+			expectedCount: 17,
+			shouldContain: []string{"#!config", "#!coach", "#!enter", "#!fix", "#!fleet", "#!focus", "#!help", "#!new", "#!present", "#!quiet", "#!recap", "#!reload-subagents", "#!routine", "#!subagents", "#!tokens", "#!triage", "#!wrapup"},
 		},
 		{
 			name:             "builtin completion with 'n' prefix",
@@ -282,8 +282,8 @@ func TestShellCompletionProvider_BuiltinCompletion_Integration(t *testing.T) {
 			name:             "builtin completion with 't' prefix",
 			line:             "#!t",
 			pos:              3,
-			expectedCount:    1,
-			shouldContain:    []string{"#!tokens"},
+			expectedCount:    2,
+			shouldContain:    []string{"#!tokens", "#!triage"},
 			shouldNotContain: []string{"#!new"},
 		},
 		{
@@ -476,7 +476,7 @@ func TestShellCompletionProvider_HelpInfo_Integration(t *testing.T) {
 			name:     "help for #! prefix",
 			line:     "#!",
 			pos:      2,
-			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
+			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!focus [task|end]** - Declare what you are working on\n• **#!recap** - Summarize the last session in this project\n• **#!wrapup** - Summarize this session into the project journal\n• **#!routine** - Save a repeated sequence of commands as a function\n• **#!present [on|off]** - Mask secrets while screen-sharing\n• **#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n• **#!enter [name]** - Open a shell in a running container\n• **#!fleet** - Ask the agent to diagnose the last fleet run\n• **#!quiet [duration|off]** - Hide idle summaries and tips for a while\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
 		},
 		{
 			name:     "help for #!new command",
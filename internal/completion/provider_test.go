@@ -351,6 +351,7 @@ func TestGetCompletions(t *testing.T) {
 			},
 			expected: []shellinput.CompletionCandidate{
 				{Value: "#!tokens"},
+				{Value: "#!triage"},
 			},
 		},
 		{
@@ -365,14 +366,16 @@ func TestGetCompletions(t *testing.T) {
 			},
 		},
 		{
-			name: "reload-subagents completion with 'r' prefix",
+			name: "builtin command completion with 'r' prefix",
 			line: "#!r",
 			pos:  3,
 			setup: func() {
-				// No setup needed - should match builtin reload command
+				// No setup needed - should match the builtin commands starting with r
 			},
 			expected: []shellinput.CompletionCandidate{
+				{Value: "#!recap"},
 				{Value: "#!reload-subagents"},
+				{Value: "#!routine"},
 			},
 		},
 		{
@@ -504,7 +507,7 @@ func TestGetHelpInfo(t *testing.T) {
 			name:     "help for #! empty",
 			line:     "#!",
 			pos:      2,
-			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
+			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!focus [task|end]** - Declare what you are working on\n• **#!recap** - Summarize the last session in this project\n• **#!wrapup** - Summarize this session into the project journal\n• **#!routine** - Save a repeated sequence of commands as a function\n• **#!present [on|off]** - Mask secrets while screen-sharing\n• **#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n• **#!enter [name]** - Open a shell in a running container\n• **#!fleet** - Ask the agent to diagnose the last fleet run\n• **#!quiet [duration|off]** - Hide idle summaries and tips for a while\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
 		},
 		{
 			name:     "help for #!new",
@@ -528,13 +531,13 @@ func TestGetHelpInfo(t *testing.T) {
 			name:     "help for partial #!n (matches new)",
 			line:     "#!n",
 			pos:      3,
-			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
+			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!focus [task|end]** - Declare what you are working on\n• **#!recap** - Summarize the last session in this project\n• **#!wrapup** - Summarize this session into the project journal\n• **#!routine** - Save a repeated sequence of commands as a function\n• **#!present [on|off]** - Mask secrets while screen-sharing\n• **#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n• **#!enter [name]** - Open a shell in a running container\n• **#!fleet** - Ask the agent to diagnose the last fleet run\n• **#!quiet [duration|off]** - Hide idle summaries and tips for a while\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
 		},
 		{
 			name:     "help for partial #!t (matches tokens)",
 			line:     "#!t",
 			pos:      3,
-			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
+			expected: "**Agent Controls** - Built-in commands for managing the agent\n\nAvailable commands:\n• **#!help** - Show help information\n• **#!fix** - Ask AI to fix the last failed command\n• **#!new** - Start a new chat session\n• **#!tokens** - Show token usage statistics\n• **#!config** - Open the configuration menu\n• **#!coach [subcommand]** - Productivity coach\n• **#!focus [task|end]** - Declare what you are working on\n• **#!recap** - Summarize the last session in this project\n• **#!wrapup** - Summarize this session into the project journal\n• **#!routine** - Save a repeated sequence of commands as a function\n• **#!present [on|off]** - Mask secrets while screen-sharing\n• **#!triage [edit|fix|ignore N]** - Deal with config file errors from startup\n• **#!enter [name]** - Open a shell in a running container\n• **#!fleet** - Ask the agent to diagnose the last fleet run\n• **#!quiet [duration|off]** - Hide idle summaries and tips for a while\n• **#!subagents [name]** - List or show subagent details\n• **#!reload-subagents** - Reload subagent configurations",
 		},
 		{
 			name:     "help for #!subagents",
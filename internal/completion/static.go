@@ -12,10 +12,13 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// StaticCompleter handles static word lists for common commands
+// StaticCompleter handles static word lists for common commands. They are
+// loaded the first time one is needed, so that a shell that never completes
+// them does not keep them in memory.
 type StaticCompleter struct {
 	completions map[string][]shellinput.CompletionCandidate
 	mu          sync.RWMutex
+	load        sync.Once
 }
 
 // UserCompletionConfig represents user-defined completion configuration
@@ -30,12 +33,18 @@ type UserCompletion struct {
 }
 
 func NewStaticCompleter() *StaticCompleter {
-	sc := &StaticCompleter{
+	return &StaticCompleter{
 		completions: make(map[string][]shellinput.CompletionCandidate),
 	}
-	sc.registerDefaults()
-	sc.loadUserCompletions()
-	return sc
+}
+
+// ensureLoaded loads the built-in completions, then the user's, unless they
+// are loaded already.
+func (s *StaticCompleter) ensureLoaded() {
+	s.load.Do(func() {
+		s.registerDefaults()
+		s.loadUserCompletions()
+	})
 }
 
 func (s *StaticCompleter) registerDefaults() {
@@ -50,16 +59,21 @@ func (s *StaticCompleter) registerDefaults() {
 
 	// Register all loaded completions
 	for command, userCompletions := range completions {
-		s.RegisterUserCommand(command, userCompletions)
+		s.register(command, userCompletions)
 	}
 }
 
 // RegisterUserCommand allows users to register custom command completions at runtime
 func (s *StaticCompleter) RegisterUserCommand(command string, subcommands []UserCompletion) {
+	s.ensureLoaded()
+	s.register(command, subcommands)
+}
+
+func (s *StaticCompleter) register(command string, subcommands []UserCompletion) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var candidates []shellinput.CompletionCandidate
+	candidates := make([]shellinput.CompletionCandidate, 0, len(subcommands))
 	for _, sub := range subcommands {
 		candidates = append(candidates, shellinput.CompletionCandidate{
 			Value:       sub.Value,
@@ -134,7 +148,7 @@ func (s *StaticCompleter) loadCompletionsFromFile(path string) error {
 
 	// Register user-defined completions
 	for command, completions := range config.Commands {
-		s.RegisterUserCommand(command, completions)
+		s.register(command, completions)
 	}
 
 	return nil
@@ -142,11 +156,13 @@ func (s *StaticCompleter) loadCompletionsFromFile(path string) error {
 
 // ReloadUserCompletions reloads user-defined completions from config files
 func (s *StaticCompleter) ReloadUserCompletions() {
+	s.ensureLoaded()
 	s.loadUserCompletions()
 }
 
 // GetCompletions returns completion suggestions for a command
 func (s *StaticCompleter) GetCompletions(command string, args []string) []shellinput.CompletionCandidate {
+	s.ensureLoaded()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -174,6 +190,7 @@ func (s *StaticCompleter) GetCompletions(command string, args []string) []shelli
 
 // GetRegisteredCommands returns a sorted list of all commands that have static completions
 func (s *StaticCompleter) GetRegisteredCommands() []string {
+	s.ensureLoaded()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -187,6 +204,7 @@ func (s *StaticCompleter) GetRegisteredCommands() []string {
 
 // HasCommand returns true if the command has registered completions
 func (s *StaticCompleter) HasCommand(command string) bool {
+	s.ensureLoaded()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
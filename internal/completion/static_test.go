@@ -650,3 +650,20 @@ func TestStaticCompleter_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestStaticCompleter_LoadsOnFirstUse(t *testing.T) {
+	sc := NewStaticCompleter()
+	if len(sc.completions) != 0 {
+		t.Fatalf("expected no completions before first use, got %d", len(sc.completions))
+	}
+	if !sc.HasCommand("docker") {
+		t.Fatal("expected docker completions once used")
+	}
+
+	// A command registered before first use is not replaced by the defaults
+	sc = NewStaticCompleter()
+	sc.RegisterUserCommand("docker", []UserCompletion{{Value: "mine"}})
+	if completions := sc.GetCompletions("docker", nil); len(completions) != 1 || completions[0].Value != "mine" {
+		t.Fatalf("expected the registered docker completions, got %v", completions)
+	}
+}
@@ -0,0 +1,72 @@
+package completion
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"mvdan.cc/sh/v3/expand"
+)
+
+// shellVariables describes the variables the shell sets after each command.
+var shellVariables = map[string]string{
+	"LAST_CMD":      "The last command run",
+	"LAST_DUR":      "How long the last command took, e.g. 1.25s",
+	"LAST_OUT_FILE": "File with the stdout of the last command (BISH_CAPTURE_OUTPUT=1)",
+	"ANS":           "The result of the last = calculation",
+}
+
+// variableReference matches a $NAME or ${NAME being typed at the end of a word.
+var variableReference = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// getVariableCompletions completes the name of the shell variable referenced
+// at the end of word, such as grep err "$LAST_O. Each completion is the whole
+// word, as the current word is what gets replaced.
+func (p *ShellCompletionProvider) getVariableCompletions(word string) []shellinput.CompletionCandidate {
+	match := variableReference.FindStringSubmatchIndex(word)
+	if match == nil || p.Runner == nil {
+		return nil
+	}
+	namePrefix := ""
+	if match[2] >= 0 {
+		namePrefix = word[match[2]:match[3]]
+	}
+	wordPrefix := word[:len(word)-len(namePrefix)]
+	closing := ""
+	if strings.HasSuffix(wordPrefix, "{") {
+		closing = "}"
+	}
+
+	names := map[string]bool{}
+	if p.Runner.Env != nil {
+		p.Runner.Env.Each(func(name string, vr expand.Variable) bool {
+			names[name] = true
+			return true
+		})
+	}
+	for name, vr := range p.Runner.Vars {
+		if vr.IsSet() {
+			names[name] = true
+		}
+	}
+
+	var candidates []shellinput.CompletionCandidate
+	for name := range names {
+		if !strings.HasPrefix(name, namePrefix) {
+			continue
+		}
+		description, ok := shellVariables[name]
+		if !ok {
+			description = "Shell Variable"
+		}
+		candidates = append(candidates, shellinput.CompletionCandidate{
+			Value:       wordPrefix + name + closing,
+			Description: description,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Value < candidates[j].Value
+	})
+	return candidates
+}
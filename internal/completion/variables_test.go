@@ -0,0 +1,58 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestVariableCompletions(t *testing.T) {
+	runner, _ := interp.New(interp.Env(expand.ListEnviron("LANG=C")))
+	runner.Vars = map[string]expand.Variable{
+		"LAST_CMD":      {Kind: expand.String, Str: "make"},
+		"LAST_OUT_FILE": {Kind: expand.String, Str: "/tmp/bish-out"},
+	}
+	provider := NewShellCompletionProvider(NewCompletionManager(), runner)
+
+	tests := []struct {
+		name     string
+		line     string
+		expected []shellinput.CompletionCandidate
+	}{
+		{
+			name: "bare reference",
+			line: "grep err $LAST_",
+			expected: []shellinput.CompletionCandidate{
+				{Value: "$LAST_CMD", Description: shellVariables["LAST_CMD"]},
+				{Value: "$LAST_OUT_FILE", Description: shellVariables["LAST_OUT_FILE"]},
+			},
+		},
+		{
+			name: "quoted reference",
+			line: `grep err "$LAST_O`,
+			expected: []shellinput.CompletionCandidate{
+				{Value: `"$LAST_OUT_FILE`, Description: shellVariables["LAST_OUT_FILE"]},
+			},
+		},
+		{
+			name: "braces",
+			line: "echo ${LA",
+			expected: []shellinput.CompletionCandidate{
+				{Value: "${LANG}", Description: "Shell Variable"},
+				{Value: "${LAST_CMD}", Description: shellVariables["LAST_CMD"]},
+				{Value: "${LAST_OUT_FILE}", Description: shellVariables["LAST_OUT_FILE"]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, provider.GetCompletions(tt.line, len(tt.line)))
+		})
+	}
+
+	assert.Nil(t, provider.getVariableCompletions("price$5"))
+	assert.Nil(t, provider.getVariableCompletions("LAST_"))
+}
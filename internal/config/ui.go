@@ -11,6 +11,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/i18n"
+	"github.com/robottwo/bishop/internal/redact"
 	"github.com/robottwo/bishop/internal/wizard"
 	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
@@ -46,6 +48,12 @@ func GetSessionOverride(key string) (string, bool) {
 	return val, ok
 }
 
+// SetSessionOverride sets a config value for the rest of the session without
+// saving it, as if it had been changed in the config UI.
+func SetSessionOverride(key, value string) {
+	sessionConfigOverrides[key] = value
+}
+
 type model struct {
 	runner        *interp.Runner
 	list          list.Model
@@ -115,27 +123,27 @@ func initialModel(runner *interp.Runner) model {
 	// Define submenu items for slow model (chat/agent)
 	slowModelSettings := []settingItem{
 		{
-			title:       "Provider",
-			description: "LLM provider to use",
+			title:       i18n.T("config.provider.title"),
+			description: i18n.T("config.provider.description"),
 			envVar:      "BISH_SLOW_MODEL_PROVIDER",
 			itemType:    typeList,
 			options:     []string{"ollama", "openai", "openrouter"},
 		},
 		{
-			title:       "API Key",
-			description: "API key for the provider",
+			title:       i18n.T("config.api_key.title"),
+			description: i18n.T("config.api_key.description"),
 			envVar:      "BISH_SLOW_MODEL_API_KEY",
 			itemType:    typeText,
 		},
 		{
-			title:       "Model ID",
-			description: "Model identifier (e.g., qwen2.5:32b)",
+			title:       i18n.T("config.model_id.title"),
+			description: i18n.T("config.model_id.description", "qwen2.5:32b"),
 			envVar:      "BISH_SLOW_MODEL_ID",
 			itemType:    typeText,
 		},
 		{
-			title:       "Base URL",
-			description: "API endpoint URL (optional override)",
+			title:       i18n.T("config.base_url.title"),
+			description: i18n.T("config.base_url.description"),
 			envVar:      "BISH_SLOW_MODEL_BASE_URL",
 			itemType:    typeText,
 		},
@@ -144,27 +152,27 @@ func initialModel(runner *interp.Runner) model {
 	// Define submenu items for fast model (completion/suggestions)
 	fastModelSettings := []settingItem{
 		{
-			title:       "Provider",
-			description: "LLM provider to use",
+			title:       i18n.T("config.provider.title"),
+			description: i18n.T("config.provider.description"),
 			envVar:      "BISH_FAST_MODEL_PROVIDER",
 			itemType:    typeList,
 			options:     []string{"ollama", "openai", "openrouter"},
 		},
 		{
-			title:       "API Key",
-			description: "API key for the provider",
+			title:       i18n.T("config.api_key.title"),
+			description: i18n.T("config.api_key.description"),
 			envVar:      "BISH_FAST_MODEL_API_KEY",
 			itemType:    typeText,
 		},
 		{
-			title:       "Model ID",
-			description: "Model identifier (e.g., qwen2.5)",
+			title:       i18n.T("config.model_id.title"),
+			description: i18n.T("config.model_id.description", "qwen2.5"),
 			envVar:      "BISH_FAST_MODEL_ID",
 			itemType:    typeText,
 		},
 		{
-			title:       "Base URL",
-			description: "API endpoint URL (optional override)",
+			title:       i18n.T("config.base_url.title"),
+			description: i18n.T("config.base_url.description"),
 			envVar:      "BISH_FAST_MODEL_BASE_URL",
 			itemType:    typeText,
 		},
@@ -172,51 +180,256 @@ func initialModel(runner *interp.Runner) model {
 
 	// Direct settings (no submenu)
 	assistantHeightSetting := settingItem{
-		title:       "Assistant Height",
-		description: "Height of the bottom assistant box",
+		title:       i18n.T("config.assistant_height.title"),
+		description: i18n.T("config.assistant_height.description"),
 		envVar:      "BISH_ASSISTANT_HEIGHT",
 		itemType:    typeText,
 	}
 	safetyChecksSetting := settingItem{
-		title:       "Safety Checks",
-		description: "Enable/Disable approved command checks (session only)",
+		title:       i18n.T("config.safety_checks.title"),
+		description: i18n.T("config.safety_checks.description"),
 		envVar:      "BISH_AGENT_APPROVED_BASH_COMMAND_REGEX",
 		itemType:    typeToggle,
 	}
 	defaultToYesSetting := settingItem{
-		title:       "Default to Yes",
-		description: "Prompts default to Yes when Enter is pressed",
+		title:       i18n.T("config.default_to_yes.title"),
+		description: i18n.T("config.default_to_yes.description"),
 		envVar:      "BISH_DEFAULT_TO_YES",
 		itemType:    typeToggle,
 	}
+	historySharingSetting := settingItem{
+		title:       i18n.T("config.history_sharing.title"),
+		description: i18n.T("config.history_sharing.description"),
+		envVar:      "BISH_HISTORY_SHARING",
+		itemType:    typeList,
+		options:     []string{"prompt", "live", "isolated"},
+	}
+	historyScopeSetting := settingItem{
+		title:       i18n.T("config.history_scope.title"),
+		description: i18n.T("config.history_scope.description"),
+		envVar:      "BISH_HISTORY_SCOPE",
+		itemType:    typeList,
+		options:     []string{"directory", "session", "global"},
+	}
+	pathStyleSetting := settingItem{
+		title:       i18n.T("config.path_style.title"),
+		description: i18n.T("config.path_style.description"),
+		envVar:      "BISH_PATH_STYLE",
+		itemType:    typeList,
+		options:     []string{"auto", "full", "home", "fish", "git"},
+	}
+	renderProfileSetting := settingItem{
+		title:       i18n.T("config.render_profile.title"),
+		description: i18n.T("config.render_profile.description"),
+		envVar:      "BISH_RENDER_PROFILE",
+		itemType:    typeList,
+		options:     []string{"auto", "full", "reduced", "minimal"},
+	}
+	terminalRecoverySetting := settingItem{
+		title:       i18n.T("config.terminal_recovery.title"),
+		description: i18n.T("config.terminal_recovery.description"),
+		envVar:      "BISH_TERMINAL_RECOVERY",
+		itemType:    typeToggle,
+	}
+	pathCorrectionSetting := settingItem{
+		title:       i18n.T("config.path_correction.title"),
+		description: i18n.T("config.path_correction.description"),
+		envVar:      "BISH_PATH_CORRECTION",
+		itemType:    typeList,
+		options:     []string{"prefill", "hint", "off"},
+	}
+	autoPairSetting := settingItem{
+		title:       i18n.T("config.autopair.title"),
+		description: i18n.T("config.autopair.description"),
+		envVar:      "BISH_AUTOPAIR",
+		itemType:    typeToggle,
+	}
+	flagLearningSetting := settingItem{
+		title:       i18n.T("config.flag_learning.title"),
+		description: i18n.T("config.flag_learning.description"),
+		envVar:      "BISH_FLAG_LEARNING",
+		itemType:    typeToggle,
+	}
+	pipelineTipsSetting := settingItem{
+		title:       i18n.T("config.pipeline_tips.title"),
+		description: i18n.T("config.pipeline_tips.description"),
+		envVar:      "BISH_PIPELINE_TIPS",
+		itemType:    typeToggle,
+	}
+	fastSearchSetting := settingItem{
+		title:       i18n.T("config.fast_search.title"),
+		description: i18n.T("config.fast_search.description"),
+		envVar:      "BISH_FAST_SEARCH",
+		itemType:    typeList,
+		options:     []string{"offer", "auto", "off"},
+	}
+	presentationModeSetting := settingItem{
+		title:       i18n.T("config.presentation_mode.title"),
+		description: i18n.T("config.presentation_mode.description"),
+		envVar:      "BISH_PRESENTATION_MODE",
+		itemType:    typeToggle,
+	}
+	formatOutputSetting := settingItem{
+		title:       i18n.T("config.format_output.title"),
+		description: i18n.T("config.format_output.description"),
+		envVar:      "BISH_FORMAT_OUTPUT",
+		itemType:    typeToggle,
+	}
+	tableOutputSetting := settingItem{
+		title:       i18n.T("config.table_output.title"),
+		description: i18n.T("config.table_output.description"),
+		envVar:      "BISH_TABLE_OUTPUT",
+		itemType:    typeToggle,
+	}
+	cdListingSetting := settingItem{
+		title:       i18n.T("config.cd_listing.title"),
+		description: i18n.T("config.cd_listing.description"),
+		envVar:      "BISH_CD_LISTING",
+		itemType:    typeToggle,
+	}
+	ciStatusSetting := settingItem{
+		title:       i18n.T("config.ci_status.title"),
+		description: i18n.T("config.ci_status.description"),
+		envVar:      "BISH_CI_STATUS",
+		itemType:    typeToggle,
+	}
+	ticketProviderSetting := settingItem{
+		title:       i18n.T("config.ticket_provider.title"),
+		description: i18n.T("config.ticket_provider.description"),
+		envVar:      "BISH_TICKET_PROVIDER",
+		itemType:    typeList,
+		options:     []string{"off", "auto", "github", "gitlab", "jira"},
+	}
+	timerActivitySetting := settingItem{
+		title:       i18n.T("config.timer_activity.title"),
+		description: i18n.T("config.timer_activity.description"),
+		envVar:      "BISH_TIMER_ACTIVITY",
+		itemType:    typeToggle,
+	}
+	networkToolsSetting := settingItem{
+		title:       i18n.T("config.network_tools.title"),
+		description: i18n.T("config.network_tools.description"),
+		envVar:      "BISH_AGENT_NETWORK_TOOLS",
+		itemType:    typeToggle,
+	}
 
 	// Top-level menu items
 	items := []list.Item{
 		menuItem{
-			title:       "Configure Slow Model",
-			description: "Chat and agent operations",
+			title:       i18n.T("config.slow_model.title"),
+			description: i18n.T("config.slow_model.description"),
 			submenu:     slowModelSettings,
 		},
 		menuItem{
-			title:       "Configure Fast Model",
-			description: "Auto-completion and suggestions",
+			title:       i18n.T("config.fast_model.title"),
+			description: i18n.T("config.fast_model.description"),
 			submenu:     fastModelSettings,
 		},
 		menuItem{
-			title:       "Assistant Height",
-			description: "Height of the bottom assistant box",
+			title:       i18n.T("config.assistant_height.title"),
+			description: i18n.T("config.assistant_height.description"),
 			setting:     &assistantHeightSetting,
 		},
 		menuItem{
-			title:       "Safety Checks",
-			description: "Enable/Disable approved command checks (session only)",
+			title:       i18n.T("config.safety_checks.title"),
+			description: i18n.T("config.safety_checks.description"),
 			setting:     &safetyChecksSetting,
 		},
 		menuItem{
-			title:       "Default to Yes",
-			description: "Prompts default to Yes when Enter is pressed",
+			title:       i18n.T("config.default_to_yes.title"),
+			description: i18n.T("config.default_to_yes.description"),
 			setting:     &defaultToYesSetting,
 		},
+		menuItem{
+			title:       i18n.T("config.history_sharing.title"),
+			description: i18n.T("config.history_sharing.description"),
+			setting:     &historySharingSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.history_scope.title"),
+			description: i18n.T("config.history_scope.description"),
+			setting:     &historyScopeSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.path_style.title"),
+			description: i18n.T("config.path_style.description"),
+			setting:     &pathStyleSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.render_profile.title"),
+			description: i18n.T("config.render_profile.description"),
+			setting:     &renderProfileSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.terminal_recovery.title"),
+			description: i18n.T("config.terminal_recovery.description"),
+			setting:     &terminalRecoverySetting,
+		},
+		menuItem{
+			title:       i18n.T("config.path_correction.title"),
+			description: i18n.T("config.path_correction.description"),
+			setting:     &pathCorrectionSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.autopair.title"),
+			description: i18n.T("config.autopair.description"),
+			setting:     &autoPairSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.flag_learning.title"),
+			description: i18n.T("config.flag_learning.description"),
+			setting:     &flagLearningSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.pipeline_tips.title"),
+			description: i18n.T("config.pipeline_tips.description"),
+			setting:     &pipelineTipsSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.fast_search.title"),
+			description: i18n.T("config.fast_search.description"),
+			setting:     &fastSearchSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.presentation_mode.title"),
+			description: i18n.T("config.presentation_mode.description"),
+			setting:     &presentationModeSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.format_output.title"),
+			description: i18n.T("config.format_output.description"),
+			setting:     &formatOutputSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.table_output.title"),
+			description: i18n.T("config.table_output.description"),
+			setting:     &tableOutputSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.cd_listing.title"),
+			description: i18n.T("config.cd_listing.description"),
+			setting:     &cdListingSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.ci_status.title"),
+			description: i18n.T("config.ci_status.description"),
+			setting:     &ciStatusSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.ticket_provider.title"),
+			description: i18n.T("config.ticket_provider.description"),
+			setting:     &ticketProviderSetting,
+		},
+		menuItem{
+			title:       i18n.T("config.timer_activity.title"),
+			description: i18n.T("config.timer_activity.description"),
+			setting:     &timerActivitySetting,
+		},
+		menuItem{
+			title:       i18n.T("config.network_tools.title"),
+			description: i18n.T("config.network_tools.description"),
+			setting:     &networkToolsSetting,
+		},
 	}
 
 	delegate := list.NewDefaultDelegate()
@@ -300,10 +513,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				newValue := m.textInput.Value()
 				savedPath, err := saveConfig(m.activeSetting.envVar, newValue, m.runner)
 				if err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save %s: %v", m.activeSetting.envVar, err)
+					m.errorMsg = i18n.T("config.save_failed", m.activeSetting.envVar, err)
 					return m, nil
 				}
-				m.savedMsg = fmt.Sprintf("Saved to %s", savedPath)
+				m.savedMsg = i18n.T("config.saved_to", savedPath)
 				if m.activeSubmenu != nil {
 					m.state = stateSubmenu
 				} else {
@@ -336,10 +549,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					newValue := string(i)
 					savedPath, err := saveConfig(m.activeSetting.envVar, newValue, m.runner)
 					if err != nil {
-						m.errorMsg = fmt.Sprintf("Failed to save %s: %v", m.activeSetting.envVar, err)
+						m.errorMsg = i18n.T("config.save_failed", m.activeSetting.envVar, err)
 						return m, nil
 					}
-					m.savedMsg = fmt.Sprintf("Saved to %s", savedPath)
+					m.savedMsg = i18n.T("config.saved_to", savedPath)
 					if m.activeSubmenu != nil {
 						m.state = stateSubmenu
 					} else {
@@ -419,6 +632,13 @@ func (m *model) handleSettingAction(s *settingItem) tea.Cmd {
 			} else {
 				newVal = `[".*"]`
 			}
+		} else if s.envVar == "BISH_AGENT_NETWORK_TOOLS" {
+			// Network tools are enabled unless explicitly turned off
+			if environment.GetNetworkToolsEnabled(m.runner) {
+				newVal = "false"
+			} else {
+				newVal = "true"
+			}
 		} else {
 			// Handle both "true"/"false" and "1"/"0" formats
 			if curr == "true" || curr == "1" {
@@ -429,12 +649,12 @@ func (m *model) handleSettingAction(s *settingItem) tea.Cmd {
 		}
 		savedPath, err := saveConfig(s.envVar, newVal, m.runner)
 		if err != nil {
-			m.errorMsg = fmt.Sprintf("Failed to save %s: %v", s.envVar, err)
+			m.errorMsg = i18n.T("config.save_failed", s.envVar, err)
 		} else if savedPath == "" {
 			// Session-only setting (like safety checks)
-			m.savedMsg = "Saved (session only)"
+			m.savedMsg = i18n.T("config.saved_session")
 		} else {
-			m.savedMsg = fmt.Sprintf("Saved to %s", savedPath)
+			m.savedMsg = i18n.T("config.saved_to", savedPath)
 		}
 		return nil
 	}
@@ -445,13 +665,17 @@ func (m *model) handleSettingAction(s *settingItem) tea.Cmd {
 			items[idx] = simpleItem(opt)
 		}
 		m.selectionList.SetItems(items)
-		m.selectionList.Title = "Select " + s.title
+		m.selectionList.Title = i18n.T("config.select_title", s.title)
 		m.state = stateSelection
 		return nil
 	}
 
 	// typeText
 	m.textInput.SetValue(getEnv(m.runner, s.envVar))
+	m.textInput.EchoMode = textinput.EchoNormal
+	if environment.GetPresentationMode(m.runner) && redact.IsSecretName(s.envVar) {
+		m.textInput.EchoMode = textinput.EchoPassword
+	}
 	m.state = stateEditing
 	return nil
 }
@@ -478,57 +702,63 @@ func (m model) View() string {
 
 	switch m.state {
 	case stateEditing:
-		title = fmt.Sprintf("Edit %s", m.activeSetting.title)
-		helpText = "Enter: Save | Esc: Cancel | q: Quit"
+		title = i18n.T("config.edit_title", m.activeSetting.title)
+		helpText = i18n.T("config.help.editing")
 		content.WriteString("\n" + m.textInput.View() + "\n")
 	case stateSelection:
-		title = "Select " + m.activeSetting.title
-		helpText = "↑/↓: Navigate | Enter: Select | Esc: Back | q: Quit"
+		title = i18n.T("config.select_title", m.activeSetting.title)
+		helpText = i18n.T("config.help.selection")
 		content.WriteString(m.selectionList.View())
 	case stateSubmenu:
 		title = m.activeSubmenu.title
-		helpText = "↑/↓: Navigate | Enter: Edit | Esc: Back | q: Quit"
+		helpText = i18n.T("config.help.submenu")
 		// Update submenu descriptions with current values
 		items := m.submenuList.Items()
 		for i, item := range items {
 			if s, ok := item.(settingItem); ok {
-				val := getEnv(m.runner, s.envVar)
+				val := m.displayValue(s.envVar)
 				if val == "" {
-					val = "(not set)"
+					val = i18n.T("config.not_set")
 				}
-				s.description = fmt.Sprintf("Current: %s", val)
+				s.description = i18n.T("config.current", val)
 				items[i] = s
 			}
 		}
 		m.submenuList.SetItems(items)
 		content.WriteString(m.submenuList.View())
 	default:
-		title = "Config Menu"
-		helpText = "↑/↓: Navigate | Enter: Select | q: Quit"
+		title = i18n.T("config.title")
+		helpText = i18n.T("config.help.list")
 		// Update main menu descriptions with current values for direct settings
 		items := m.list.Items()
 		for i, item := range items {
 			if mi, ok := item.(menuItem); ok {
 				if mi.setting != nil {
-					val := getEnv(m.runner, mi.setting.envVar)
+					val := m.displayValue(mi.setting.envVar)
 					switch mi.setting.envVar {
 					case "BISH_AGENT_APPROVED_BASH_COMMAND_REGEX":
 						if strings.Contains(val, `".*"`) || strings.Contains(val, `".+"`) {
-							val = "Disabled for this session"
+							val = i18n.T("config.safety_disabled")
 						} else {
-							val = "Enabled"
+							val = i18n.T("config.safety_enabled")
 						}
 					case "BISH_DEFAULT_TO_YES":
 						if val == "1" || val == "true" {
-							val = "Yes (prompts show [Y/n])"
+							val = i18n.T("config.default_yes")
+						} else {
+							val = i18n.T("config.default_no")
+						}
+					case "BISH_AGENT_NETWORK_TOOLS":
+						if environment.GetNetworkToolsEnabled(m.runner) {
+							val = i18n.T("config.enabled")
 						} else {
-							val = "No (prompts show [y/N])"
+							val = i18n.T("config.disabled")
 						}
 					}
 					if val == "" {
-						val = "(not set)"
+						val = i18n.T("config.not_set")
 					}
-					mi.description = fmt.Sprintf("Current: %s", val)
+					mi.description = i18n.T("config.current", val)
 					items[i] = mi
 				}
 			}
@@ -541,7 +771,7 @@ func (m model) View() string {
 	var boxContent strings.Builder
 
 	// Header with centered title
-	titlePadding := (availableWidth - len(title)) / 2
+	titlePadding := (availableWidth - lipgloss.Width(title)) / 2
 	if titlePadding < 0 {
 		titlePadding = 0
 	}
@@ -608,6 +838,16 @@ func getEnv(runner *interp.Runner, key string) string {
 	return ""
 }
 
+// displayValue returns the value of key to show in the menus, masked if it is
+// a secret and presentation mode is on.
+func (m model) displayValue(key string) string {
+	val := getEnv(m.runner, key)
+	if val != "" && environment.GetPresentationMode(m.runner) && redact.IsSecretName(key) {
+		return redact.Mask
+	}
+	return val
+}
+
 func saveConfig(key, value string, runner *interp.Runner) (savedPath string, err error) {
 	// Handle Safety Checks specially - only affects current session, not persisted
 	// Uses BISH_SAFETY_CHECKS_DISABLED flag which is checked in GetApprovedBashCommandRegex
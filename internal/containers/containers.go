@@ -0,0 +1,205 @@
+// Package containers finds running docker, podman and kubernetes containers
+// and builds the commands that open an interactive shell in one of them.
+package containers
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtime is a tool that runs containers.
+type Runtime string
+
+const (
+	Docker  Runtime = "docker"
+	Podman  Runtime = "podman"
+	Kubectl Runtime = "kubectl"
+)
+
+// Container is a running container, or a kubernetes pod.
+type Container struct {
+	Runtime Runtime
+	ID      string
+	Name    string
+	Image   string
+	// Namespace is the kubernetes namespace of a pod
+	Namespace string
+}
+
+// Identity names the container in history and prompts, e.g. docker:web or
+// kubectl:staging/api-7f9c.
+func (c Container) Identity() string {
+	if c.Namespace != "" {
+		return fmt.Sprintf("%s:%s/%s", c.Runtime, c.Namespace, c.Name)
+	}
+	return fmt.Sprintf("%s:%s", c.Runtime, c.Name)
+}
+
+// RunFunc runs a command and returns its standard output.
+type RunFunc func(ctx context.Context, name string, args ...string) (string, error)
+
+// Exec runs a command with os/exec.
+func Exec(ctx context.Context, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", err
+	}
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	return string(out), err
+}
+
+// listTimeout bounds each runtime's listing, so that one whose daemon or
+// cluster does not answer does not hold up the others.
+const listTimeout = 5 * time.Second
+
+// listArgs are the commands that list the running containers of each
+// runtime, one per line with tab separated fields.
+var listArgs = map[Runtime][]string{
+	Docker: {"ps", "--format", "{{.ID}}\t{{.Names}}\t{{.Image}}"},
+	Podman: {"ps", "--format", "{{.ID}}\t{{.Names}}\t{{.Image}}"},
+	Kubectl: {"get", "pods", "--all-namespaces", "--field-selector=status.phase=Running", "--no-headers",
+		"-o", "custom-columns=UID:.metadata.uid,NAMESPACE:.metadata.namespace,NAME:.metadata.name,IMAGE:.spec.containers[0].image"},
+}
+
+// List returns the running containers of every runtime that is installed and
+// reachable. Runtimes that fail are left out.
+func List(ctx context.Context, run RunFunc) []Container {
+	var containers []Container
+	for _, runtime := range []Runtime{Docker, Podman, Kubectl} {
+		listCtx, cancel := context.WithTimeout(ctx, listTimeout)
+		out, err := run(listCtx, string(runtime), listArgs[runtime]...)
+		cancel()
+		if err != nil {
+			continue
+		}
+		containers = append(containers, parseList(runtime, out)...)
+	}
+	return containers
+}
+
+func parseList(runtime Runtime, out string) []Container {
+	var containers []Container
+	for _, line := range strings.Split(out, "\n") {
+		if runtime == Kubectl {
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				continue
+			}
+			containers = append(containers, Container{Runtime: runtime, ID: fields[0], Namespace: fields[1], Name: fields[2], Image: fields[3]})
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		containers = append(containers, Container{Runtime: runtime, ID: fields[0], Name: fields[1], Image: fields[2]})
+	}
+	return containers
+}
+
+// Find returns the container query refers to: its name, identity or a
+// prefix of its ID.
+func Find(containers []Container, query string) (Container, error) {
+	var matches []Container
+	for _, c := range containers {
+		if c.Name == query || c.Identity() == query || (len(query) >= 4 && strings.HasPrefix(c.ID, query)) ||
+			(c.Namespace != "" && c.Namespace+"/"+c.Name == query) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Container{}, fmt.Errorf("no running container %s", query)
+	case 1:
+		return matches[0], nil
+	default:
+		identities := make([]string, len(matches))
+		for i, c := range matches {
+			identities[i] = c.Identity()
+		}
+		return Container{}, fmt.Errorf("%s is ambiguous: %s", query, strings.Join(identities, ", "))
+	}
+}
+
+// shellScript starts the best shell the container has. bash gets an rc file
+// that loads the usual ones; sh reads it through ENV.
+const shellScript = `rc="$BISH_HISTFILE.rc"
+printf '%s\n' "$BISH_ENTER_RC" > "$rc" 2>/dev/null
+if command -v bash >/dev/null 2>&1; then exec bash --rcfile "$rc" -i; fi
+ENV="$rc" exec sh -i`
+
+// enterRC marks the prompt with the container and, at each prompt that
+// follows a new command, appends its exit status and line to the history
+// file bish reads back when the shell exits.
+const enterRC = `[ -f /etc/bash.bashrc ] && . /etc/bash.bashrc
+[ -f "$HOME/.bashrc" ] && . "$HOME/.bashrc"
+PS1="[⬢ $BISH_CONTAINER] ${PS1:-\$ }"
+__bish_history() {
+	local status=$? line
+	line=$(HISTTIMEFORMAT= builtin history 1)
+	if [ -n "${__bish_last+x}" ] && [ "$line" != "$__bish_last" ]; then
+		line=${line#*[0-9]  }
+		printf '%s\t%s\n' "$status" "${line//$'\n'/ }" >> "$BISH_HISTFILE"
+	fi
+	__bish_last=$(HISTTIMEFORMAT= builtin history 1)
+}
+PROMPT_COMMAND="__bish_history${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+`
+
+// runtimeArgs returns the arguments that run a command in c, up to the
+// command itself.
+func runtimeArgs(c Container, interactive bool) []string {
+	var args []string
+	if c.Runtime == Kubectl {
+		args = []string{"exec", "-n", c.Namespace}
+		if interactive {
+			args = append(args, "-it")
+		}
+		return append(args, c.Name, "--")
+	}
+	args = []string{"exec"}
+	if interactive {
+		args = append(args, "-it")
+	}
+	return append(args, c.ID)
+}
+
+// ShellArgs returns the command that opens an interactive shell in c, which
+// exports BISH_CONTAINER and writes its history to histFile.
+func ShellArgs(c Container, histFile string) []string {
+	args := append([]string{string(c.Runtime)}, runtimeArgs(c, true)...)
+	return append(args, "env",
+		"BISH_CONTAINER="+c.Identity(),
+		"BISH_HISTFILE="+histFile,
+		"BISH_ENTER_RC="+enterRC,
+		"sh", "-c", shellScript)
+}
+
+// Command is a command run in the shell of a container.
+type Command struct {
+	Line     string
+	ExitCode int
+}
+
+// History reads back the commands the shell in c wrote to histFile, and
+// removes it along with the rc file.
+func History(ctx context.Context, run RunFunc, c Container, histFile string) ([]Command, error) {
+	args := append(runtimeArgs(c, false), "sh", "-c", `cat "$1" 2>/dev/null; rm -f "$1" "$1.rc"`, "sh", histFile)
+	out, err := run(ctx, string(c.Runtime), args...)
+	if err != nil {
+		return nil, err
+	}
+	var commands []Command
+	for _, line := range strings.Split(out, "\n") {
+		status, command, ok := strings.Cut(line, "\t")
+		code, err := strconv.Atoi(status)
+		if command = strings.TrimSpace(command); !ok || err != nil || command == "" {
+			continue
+		}
+		commands = append(commands, Command{Line: command, ExitCode: code})
+	}
+	return commands, nil
+}
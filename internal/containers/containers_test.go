@@ -0,0 +1,104 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeRuntimes(ctx context.Context, name string, args ...string) (string, error) {
+	switch name {
+	case "docker":
+		return "3f2a1b9c0d1e\tweb\tnginx:1.25\n9a8b7c6d5e4f\tdb\tpostgres:16\n", nil
+	case "kubectl":
+		return "0c1d-uid   staging   api-7f9c   registry/api:2\n1e2f-uid   prod      api-7f9c   registry/api:1\n", nil
+	default:
+		return "", errors.New("not installed")
+	}
+}
+
+func TestList(t *testing.T) {
+	running := List(context.Background(), fakeRuntimes)
+	require.Len(t, running, 4)
+	assert.Equal(t, Container{Runtime: Docker, ID: "3f2a1b9c0d1e", Name: "web", Image: "nginx:1.25"}, running[0])
+	assert.Equal(t, Container{Runtime: Kubectl, ID: "0c1d-uid", Name: "api-7f9c", Image: "registry/api:2", Namespace: "staging"}, running[2])
+	assert.Equal(t, "docker:web", running[0].Identity())
+	assert.Equal(t, "kubectl:staging/api-7f9c", running[2].Identity())
+}
+
+func TestListBoundsEachRuntime(t *testing.T) {
+	var deadlines []time.Time
+	List(context.Background(), func(ctx context.Context, name string, args ...string) (string, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok, name)
+		deadlines = append(deadlines, deadline)
+		return "", nil
+	})
+	assert.Len(t, deadlines, 3)
+}
+
+func TestFind(t *testing.T) {
+	running := List(context.Background(), fakeRuntimes)
+
+	for _, query := range []string{"web", "docker:web", "3f2a"} {
+		c, err := Find(running, query)
+		require.NoError(t, err, query)
+		assert.Equal(t, "web", c.Name)
+	}
+
+	c, err := Find(running, "prod/api-7f9c")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", c.Namespace)
+
+	_, err = Find(running, "api-7f9c")
+	assert.ErrorContains(t, err, "ambiguous: kubectl:staging/api-7f9c, kubectl:prod/api-7f9c")
+
+	_, err = Find(running, "3f")
+	assert.ErrorContains(t, err, "no running container 3f")
+}
+
+func TestShellArgs(t *testing.T) {
+	docker := Container{Runtime: Docker, ID: "3f2a1b9c0d1e", Name: "web"}
+	args := ShellArgs(docker, "/tmp/h")
+	assert.Equal(t, []string{"docker", "exec", "-it", "3f2a1b9c0d1e", "env", "BISH_CONTAINER=docker:web", "BISH_HISTFILE=/tmp/h"}, args[:7])
+
+	pod := Container{Runtime: Kubectl, Name: "api-7f9c", Namespace: "staging"}
+	args = ShellArgs(pod, "/tmp/h")
+	assert.Equal(t, []string{"kubectl", "exec", "-n", "staging", "-it", "api-7f9c", "--", "env"}, args[:8])
+}
+
+// TestShellRecordsHistory runs the in-container part of the shell command
+// locally: the commands typed in it must end up in the history file.
+func TestShellRecordsHistory(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	dir := t.TempDir()
+	histFile := filepath.Join(dir, "history")
+	args := ShellArgs(Container{Runtime: Docker, ID: "local", Name: "local"}, histFile)[4:]
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = []string{"HOME=" + dir, "PATH=" + os.Getenv("PATH")}
+	cmd.Stdin = strings.NewReader("echo \"inside $BISH_CONTAINER\"\n\nls /nonexistent\nexit 0\n")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "inside docker:local")
+
+	commands, err := History(context.Background(), func(ctx context.Context, name string, args ...string) (string, error) {
+		// Run the command meant for the container locally
+		out, err := exec.CommandContext(ctx, args[2], args[3:]...).Output()
+		return string(out), err
+	}, Container{Runtime: Docker, ID: "local"}, histFile)
+	require.NoError(t, err)
+	assert.Equal(t, []Command{{Line: "echo \"inside $BISH_CONTAINER\""}, {Line: "ls /nonexistent", ExitCode: 2}}, commands)
+	assert.NoFileExists(t, histFile)
+	assert.NoFileExists(t, histFile+".rc")
+}
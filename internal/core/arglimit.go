@@ -0,0 +1,13 @@
+package core
+
+import "github.com/robottwo/bishop/internal/arglimit"
+
+// suggestArgLimitRewrite returns line rewritten to pipe the arguments of the
+// command that was refused for having too many, if one was.
+func suggestArgLimitRewrite(line string) (string, bool) {
+	name, ok := arglimit.DefaultGuard.Refused()
+	if !ok {
+		return "", false
+	}
+	return arglimit.Rewrite(line, name)
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/calc"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// evaluateCalculation evaluates a "= expr" line, records it in history and
+// stores the numeric result in $ANS for the next command. It returns the text
+// to print and the exit code of the line.
+func evaluateCalculation(ctx context.Context, line string, historyManager *history.HistoryManager, runner *interp.Runner, sessionID string) (string, int) {
+	vars := map[string]float64{}
+	if ans, err := strconv.ParseFloat(runner.Vars["ANS"].String(), 64); err == nil {
+		vars["ANS"] = ans
+	}
+
+	entry, _ := historyManager.StartCommand(line, environment.GetPwd(runner), sessionID)
+	result, err := calc.Evaluate(line, vars)
+	if err != nil {
+		_, _ = historyManager.FinishCommand(entry, 1)
+		return fmt.Sprintf("bish: calc: %s", err), 1
+	}
+	_, _ = historyManager.FinishCommand(entry, 0)
+
+	_, _, _ = bash.RunBashCommand(ctx, runner, "ANS="+calc.FormatNumber(result.Value))
+	return result.String(), 0
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestEvaluateCalculation(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	runner, err := interp.New()
+	require.NoError(t, err)
+	ctx := context.Background()
+	// ans reads $ANS as the next command expands it
+	ans := func() string {
+		stdout, _, err := bash.RunBashCommand(ctx, runner, "echo $ANS")
+		require.NoError(t, err)
+		return stdout
+	}
+
+	output, exitCode := evaluateCalculation(ctx, "= 3*(7+2)", historyManager, runner, "session")
+	assert.Equal(t, "27", output)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "27\n", ans())
+
+	output, exitCode = evaluateCalculation(ctx, "= ans * 2 GiB in MiB", historyManager, runner, "session")
+	assert.Equal(t, "55296 MiB", output)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "55296\n", ans())
+
+	// Errors leave $ANS alone
+	output, exitCode = evaluateCalculation(ctx, "= 1 / 0", historyManager, runner, "session")
+	assert.Equal(t, "bish: calc: division by zero", output)
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, "55296\n", ans())
+
+	entries, err := historyManager.GetAllEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "= 1 / 0", entries[0].Command)
+	assert.Equal(t, "= 3*(7+2)", entries[2].Command)
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/robottwo/bishop/internal/ci"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/git"
+	"github.com/robottwo/bishop/internal/notify"
+	"github.com/robottwo/bishop/internal/statusline"
+	"github.com/robottwo/bishop/internal/ticket"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// ciTarget returns the branch checked out in the repository the shell is
+// in, for reading its CI runs, if the repository is hosted where they can be
+// read.
+func ciTarget(runner *interp.Runner) (ci.Target, bool) {
+	getenv := func(name string) string { return runner.Vars[name].String() }
+	repo := git.FindRepo(environment.GetPwd(runner), getenv)
+	if repo == nil {
+		return ci.Target{}, false
+	}
+	branch := repo.Branch()
+	if branch == "" || branch == "detached" {
+		return ci.Target{}, false
+	}
+	remote, ok := ci.ParseRemote(repo.RemoteURL("origin"))
+	if !ok {
+		return ci.Target{}, false
+	}
+	provider, ok := ci.ProviderFor(remote, getenv, exec.LookPath)
+	if !ok {
+		return ci.Target{}, false
+	}
+	return ci.Target{Remote: remote, Branch: branch, Provider: provider}, true
+}
+
+// statusSegments returns what the border status shows after the resources:
+// the state of the latest CI run of the branch when BISH_CI_STATUS is on,
+// the ticket named in the branch when BISH_TICKET_PROVIDER is set, and the
+// output of the configured status commands. It is nil if there is
+// nothing to show.
+func statusSegments(runner *interp.Runner, logger *zap.Logger) func() []string {
+	segments, err := statusline.Load(statusline.DefaultPath())
+	if err != nil {
+		logger.Warn("error loading status segments", zap.Error(err))
+	} else if len(segments) > 0 {
+		statusline.DefaultProvider.Configure(segments)
+	}
+	target, watchCI := ci.Target{}, false
+	if environment.GetCIStatus(runner) {
+		target, watchCI = ciTarget(runner)
+	}
+	ticketOf, showTicket := ticket.Target{}, false
+	if environment.GetTicketProvider(runner, logger) != ticket.ProviderOff {
+		var err error
+		ticketOf, err = ticketTarget(runner, logger, "")
+		showTicket = err == nil
+	}
+	if len(segments) == 0 && !watchCI && !showTicket {
+		return nil
+	}
+
+	return func() []string {
+		var values []string
+		if watchCI {
+			if run, ok := ci.DefaultWatcher.Latest(target, time.Now()); ok {
+				values = append(values, "CI "+run.Symbol())
+			}
+		}
+		if showTicket {
+			if summary := ticketSegment(ticketOf); summary != "" {
+				values = append(values, summary)
+			}
+		}
+		if len(segments) > 0 {
+			values = append(values, statusline.DefaultProvider.Values(time.Now())...)
+		}
+		return values
+	}
+}
+
+// ciFinished lets the user know right away that a run finished, even from
+// another window: with the terminal bell and a desktop notification.
+func ciFinished(run ci.Run) {
+	fmt.Fprint(os.Stderr, "\a")
+	notify.Send("CI "+ciOutcome(run), run.Name+" on "+run.Branch)
+}
+
+// reportCIRuns prints the CI runs that finished since the last prompt.
+func reportCIRuns(watcher *ci.Watcher) {
+	for _, run := range watcher.TakeFinished() {
+		message := fmt.Sprintf("%s %s %s on %s", run.Symbol(), run.Name, ciOutcome(run), run.Branch)
+		if run.URL != "" {
+			message += ": " + run.URL
+		}
+		if run.State == ci.Failure {
+			message += ". Type #? ci to see why."
+		}
+		printQuietMessage(message)
+	}
+}
+
+// ciOutcome describes how a run finished.
+func ciOutcome(run ci.Run) string {
+	switch run.State {
+	case ci.Success:
+		return "passed"
+	case ci.Failure:
+		return "failed"
+	case ci.Cancelled:
+		return "was cancelled"
+	}
+	return "is running"
+}
+
+// ciFailurePrompt asks the agent why the latest CI run of the branch failed,
+// with the end of the log of the failing job. It returns an error that
+// explains why there is nothing to ask, e.g. when the run passed.
+func ciFailurePrompt(ctx context.Context, runner *interp.Runner) (string, error) {
+	target, ok := ciTarget(runner)
+	if !ok {
+		return "", errors.New("no CI runs to read here: this needs a branch of a GitHub repository with gh installed, or of a GitLab one with $GITLAB_TOKEN set and, if self-hosted, its host in BISH_GITLAB_HOSTS")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	run, found, err := target.Provider.LatestRun(ctx, target.Branch)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("%s has no CI runs", target.Branch)
+	}
+	if run.State != ci.Failure {
+		return "", fmt.Errorf("the latest CI run of %s, %s, %s", target.Branch, run.Name, ciOutcome(run))
+	}
+	log, err := target.Provider.FailedLog(ctx, run)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("The latest CI run of the branch %s, %s, failed (%s). The log of the failing job ends with:\n```\n%s\n```\n\nExplain why it failed and suggest a fix.", run.Branch, run.Name, run.URL, log), nil
+}
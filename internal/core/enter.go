@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/containers"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// handleEnterControl implements #!enter. Without arguments it lists the
+// running containers; with one it opens an interactive shell in it, whose
+// prompt is marked with the container, and adds the commands run there to
+// history tagged with the container.
+func handleEnterControl(ctx context.Context, args string, runner *interp.Runner, historyManager *history.HistoryManager, sessionID string, logger *zap.Logger) {
+	running := containers.List(ctx, containers.Exec)
+	if args == "" {
+		if len(running) == 0 {
+			printEnterMessage("No running containers found with docker, podman or kubectl.")
+			return
+		}
+		var list strings.Builder
+		list.WriteString("Running containers (#!enter <name> opens a shell in one):\n")
+		for _, c := range running {
+			fmt.Fprintf(&list, "  %-40s %s\n", c.Identity(), c.Image)
+		}
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(list.String()) + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	container, err := containers.Find(running, args)
+	if err != nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	histFile := fmt.Sprintf("/tmp/.bish_history_%d", time.Now().UnixNano())
+	shellArgs := containers.ShellArgs(container, histFile)
+	printEnterMessage(fmt.Sprintf("Entering %s; exit the shell to come back.", container.Identity()))
+	cmd := exec.Command(shellArgs[0], shellArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		logger.Debug("container shell exited with an error", zap.String("container", container.Identity()), zap.Error(err))
+	}
+
+	commands, err := containers.History(ctx, containers.Exec, container, histFile)
+	if err != nil {
+		logger.Debug("could not read the container shell history", zap.String("container", container.Identity()), zap.Error(err))
+		printEnterMessage(fmt.Sprintf("Left %s.", container.Identity()))
+		return
+	}
+	recordContainerHistory(historyManager, container.Identity(), commands, environment.GetPwd(runner), sessionID, logger)
+	printEnterMessage(fmt.Sprintf("Left %s; %d commands added to history.", container.Identity(), len(commands)))
+}
+
+// recordContainerHistory adds the commands run in a container to history,
+// tagged with the container.
+func recordContainerHistory(historyManager *history.HistoryManager, container string, commands []containers.Command, directory, sessionID string, logger *zap.Logger) {
+	historyManager.SetContainer(container)
+	defer historyManager.SetContainer("")
+	for _, command := range commands {
+		entry, err := historyManager.StartCommand(command.Line, directory, sessionID)
+		if err == nil && entry != nil {
+			_, err = historyManager.FinishCommand(entry, command.ExitCode)
+		}
+		if err != nil {
+			logger.Warn("error recording container command", zap.String("container", container), zap.Error(err))
+			return
+		}
+	}
+}
+
+func printEnterMessage(message string) {
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
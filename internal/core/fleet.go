@@ -0,0 +1,20 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/fleet"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+)
+
+// handleFleetControl implements #!fleet, which returns the message that asks
+// the agent to diagnose the hosts the last fleet command failed on.
+func handleFleetControl() (chatMessage string) {
+	broadcast, ok := fleet.Last()
+	if !ok || len(broadcast.Failures()) == 0 {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: The last fleet run had no failures to diagnose.\n") + gline.RESET_CURSOR_COLUMN)
+		return ""
+	}
+	return fleet.DiagnosisPrompt(broadcast)
+}
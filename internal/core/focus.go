@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/focus"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// handleFocusControl implements #!focus. With a task it switches the focus to
+// it, "end" ends the focus and no argument shows the current one. Switching or
+// ending prints a summary of the work done on the previous task.
+func handleFocusControl(ctx context.Context, args string, state *ShellState, runner *interp.Runner, historyManager *history.HistoryManager, summarizer *focus.Summarizer, logger *zap.Logger) {
+	switch args {
+	case "":
+		if state.Focus == nil {
+			printFocusMessage("No focus set. Use #!focus <task> to declare what you are working on.")
+			return
+		}
+		printFocusMessage(fmt.Sprintf("Focused on %q for %s. Use #!focus end to stop.", state.Focus.Task, focus.FormatElapsed(time.Since(state.Focus.Started))))
+		return
+	case "end":
+		if state.Focus == nil {
+			printFocusMessage("No focus to end.")
+			return
+		}
+		endFocus(ctx, state, runner, historyManager, summarizer, logger)
+		return
+	}
+
+	if state.Focus != nil {
+		if state.Focus.Task == args {
+			printFocusMessage(fmt.Sprintf("Already focused on %q.", args))
+			return
+		}
+		endFocus(ctx, state, runner, historyManager, summarizer, logger)
+	}
+
+	state.Focus = &focus.Session{Task: args, Started: time.Now()}
+	historyManager.SetTask(args)
+	environment.SetFocus(runner, args)
+	printFocusMessage(fmt.Sprintf("Focused on %q. Commands are tagged with it until #!focus end.", args))
+}
+
+// endFocus clears the focus and prints a summary of the work done on it.
+func endFocus(ctx context.Context, state *ShellState, runner *interp.Runner, historyManager *history.HistoryManager, summarizer *focus.Summarizer, logger *zap.Logger) {
+	session := *state.Focus
+	state.Focus = nil
+	historyManager.SetTask("")
+	environment.SetFocus(runner, "")
+
+	printFocusMessage(fmt.Sprintf("Summarizing %q...", session.Task))
+	summary, err := summarizer.Summarize(ctx, session, time.Now())
+	if err != nil {
+		logger.Warn("error summarizing focus session", zap.String("task", session.Task), zap.Error(err))
+		if summary == "" {
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not summarize "+session.Task+": "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+			return
+		}
+	}
+	printFocusMessage(fmt.Sprintf("Ended focus on %q: %s", session.Task, summary))
+}
+
+func printFocusMessage(message string) {
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/focus"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestHandleFocusControl(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	runner, err := interp.New()
+	require.NoError(t, err)
+	runner.Vars = make(map[string]expand.Variable)
+	logger := zap.NewNop()
+	summarizer := focus.NewSummarizer(runner, historyManager, logger)
+	state := &ShellState{}
+	ctx := context.Background()
+
+	handleFocusControl(ctx, "", state, runner, historyManager, summarizer, logger)
+	assert.Nil(t, state.Focus)
+
+	handleFocusControl(ctx, "fixing the billing cron", state, runner, historyManager, summarizer, logger)
+	require.NotNil(t, state.Focus)
+	assert.Equal(t, "fixing the billing cron", state.Focus.Task)
+	assert.Equal(t, "fixing the billing cron", environment.GetFocus(runner))
+	entry, err := historyManager.StartCommand("crontab -l", "/", "session")
+	require.NoError(t, err)
+	assert.Equal(t, "fixing the billing cron", entry.Task)
+
+	// Switching starts a new session for the new task
+	require.NoError(t, historyManager.DeleteEntry(entry.ID))
+	started := state.Focus.Started
+	handleFocusControl(ctx, "reviewing PRs", state, runner, historyManager, summarizer, logger)
+	require.NotNil(t, state.Focus)
+	assert.Equal(t, "reviewing PRs", state.Focus.Task)
+	assert.False(t, state.Focus.Started.Before(started))
+	assert.Equal(t, "reviewing PRs", environment.GetFocus(runner))
+
+	handleFocusControl(ctx, "end", state, runner, historyManager, summarizer, logger)
+	assert.Nil(t, state.Focus)
+	assert.Equal(t, "", environment.GetFocus(runner))
+	entry, err = historyManager.StartCommand("ls", "/", "session")
+	require.NoError(t, err)
+	assert.Equal(t, "", entry.Task)
+}
@@ -0,0 +1,512 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// expansion is a command line with its history references expanded.
+type expansion struct {
+	line string
+	// expanded is true if the line had history references
+	expanded bool
+	// printOnly is true when the :p modifier asked for the line to be shown
+	// rather than run
+	printOnly bool
+}
+
+// expandHistory expands the history references in input as bash does:
+// events such as !!, !42, !-2, !grep and !?grep?, words of them such as
+// !!:2, !$, !^, !* and !:1-3, modifiers such as :h, :t, :r, :e, :q, :p and
+// :s/old/new/, and ^old^new for the previous command with old replaced.
+// Nothing is expanded in single quotes or after a backslash.
+func expandHistory(input string, historyManager *history.HistoryManager) (expansion, error) {
+	if !strings.ContainsAny(input, "!^") {
+		return expansion{line: input}, nil
+	}
+	e := &historyExpander{historyManager: historyManager}
+	return e.expand(input)
+}
+
+// historyExpander expands the history references of a line.
+type historyExpander struct {
+	historyManager *history.HistoryManager
+	// entries are the commands of history, newest first, loaded when a
+	// reference needs them
+	entries []history.HistoryEntry
+	loaded  bool
+
+	// lastSearch is the string of the last !?string? event, which the %
+	// word designator picks the word of
+	lastSearch string
+	// lastOld and lastNew are the last substitution, for :& and :s with
+	// nothing to replace
+	lastOld, lastNew string
+	hasSubstitution  bool
+}
+
+func (e *historyExpander) history() []history.HistoryEntry {
+	if !e.loaded {
+		e.loaded = true
+		if entries, err := e.historyManager.GetAllEntries(); err == nil {
+			e.entries = entries
+		}
+	}
+	return e.entries
+}
+
+func (e *historyExpander) expand(input string) (expansion, error) {
+	// ^old^new^ stands for !!:s^old^new^
+	if strings.HasPrefix(input, "^") {
+		input = "!!:s" + input
+	}
+
+	var result expansion
+	var sb strings.Builder
+	runes := []rune(input)
+	inSingleQuote, inDoubleQuote := false, false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' && !inDoubleQuote:
+			inSingleQuote = !inSingleQuote
+		case inSingleQuote:
+		case r == '"':
+			inDoubleQuote = !inDoubleQuote
+		case r == '\\':
+			sb.WriteRune(r)
+			if i+1 < len(runes) {
+				i++
+				r = runes[i]
+			}
+		case r == '!' && e.startsReference(runes, i):
+			text, end, printOnly, err := e.reference(runes, i, sb.String())
+			if err != nil {
+				return expansion{}, err
+			}
+			sb.WriteString(text)
+			result.expanded = true
+			result.printOnly = result.printOnly || printOnly
+			i = end - 1
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if !result.expanded {
+		return expansion{line: input}, nil
+	}
+	result.line = sb.String()
+	return result, nil
+}
+
+// startsReference reports whether the ! at i starts a history reference,
+// rather than being a literal ! as in [ a != b ], ! cmd, !(glob), $! or
+// ${!name}.
+func (e *historyExpander) startsReference(runes []rune, i int) bool {
+	if i+1 >= len(runes) {
+		return false
+	}
+	switch runes[i+1] {
+	case ' ', '\t', '\n', '=', '(', ')', ';', '&', '|', '<', '>', '\'', '"':
+		return false
+	}
+	if i > 0 && runes[i-1] == '$' {
+		return false
+	}
+	if i > 1 && runes[i-1] == '{' && runes[i-2] == '$' {
+		return false
+	}
+	return true
+}
+
+// reference expands the history reference starting at the ! at start,
+// with lineSoFar the expansion of what comes before it, for !#. It
+// returns the text of the reference and where it ends.
+func (e *historyExpander) reference(runes []rune, start int, lineSoFar string) (string, int, bool, error) {
+	i := start + 1
+	event, i, err := e.event(runes, i, lineSoFar)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	text := event
+	words, i, hasWords, err := e.wordDesignator(runes, i, event)
+	if err != nil {
+		return "", 0, false, err
+	}
+	if hasWords {
+		text = words
+	}
+
+	text, i, printOnly, err := e.modifiers(runes, i, text)
+	if err != nil {
+		return "", 0, false, err
+	}
+	return text, i, printOnly, nil
+}
+
+// event returns the command that the event designator at i names, and
+// where the designator ends.
+func (e *historyExpander) event(runes []rune, i int, lineSoFar string) (string, int, error) {
+	entries := e.history()
+	nth := func(n int, spec string) (string, error) {
+		if n < 1 || n > len(entries) {
+			return "", fmt.Errorf("%s: event not found", spec)
+		}
+		return entries[n-1].Command, nil
+	}
+
+	switch r := runes[i]; {
+	case r == '!':
+		command, err := nth(1, "!!")
+		return command, i + 1, err
+	case r == '#':
+		return lineSoFar, i + 1, nil
+	case strings.ContainsRune("^$*%:", r):
+		// A word designator of the previous command, as in !$
+		command, err := nth(1, "!"+string(r))
+		return command, i, err
+	case r == '-' && i+1 < len(runes) && isDigit(runes[i+1]):
+		end := digitsEnd(runes, i+1)
+		n, _ := strconv.Atoi(string(runes[i+1 : end]))
+		command, err := nth(n, "!"+string(runes[i:end]))
+		return command, end, err
+	case isDigit(r):
+		end := digitsEnd(runes, i)
+		spec := string(runes[i:end])
+		id, _ := strconv.ParseUint(spec, 10, 0)
+		for _, entry := range entries {
+			if uint64(entry.ID) == id {
+				return entry.Command, end, nil
+			}
+		}
+		return "", 0, fmt.Errorf("!%s: event not found", spec)
+	case r == '?':
+		end := i + 1
+		for end < len(runes) && runes[end] != '?' && runes[end] != '\n' {
+			end++
+		}
+		search := string(runes[i+1 : end])
+		if end < len(runes) && runes[end] == '?' {
+			end++
+		}
+		e.lastSearch = search
+		for _, entry := range entries {
+			if search != "" && strings.Contains(entry.Command, search) {
+				return entry.Command, end, nil
+			}
+		}
+		return "", 0, fmt.Errorf("!?%s: event not found", search)
+	}
+
+	end := i
+	for end < len(runes) && !strings.ContainsRune(" \t\n:;&|<>()'\"", runes[end]) {
+		end++
+	}
+	prefix := string(runes[i:end])
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Command, prefix) {
+			return entry.Command, end, nil
+		}
+	}
+	return "", 0, fmt.Errorf("!%s: event not found", prefix)
+}
+
+// wordDesignator returns the words of event that the word designator at i
+// picks, if there is one, and where it ends.
+func (e *historyExpander) wordDesignator(runes []rune, i int, event string) (string, int, bool, error) {
+	at := i
+	if at < len(runes) && runes[at] == ':' {
+		at++
+		if at >= len(runes) || !(isDigit(runes[at]) || strings.ContainsRune("^$*%-", runes[at])) {
+			return "", i, false, nil
+		}
+	} else if at >= len(runes) || !strings.ContainsRune("^$*%-", runes[at]) ||
+		(runes[at] == '-' && (at+1 >= len(runes) || !(isDigit(runes[at+1]) || strings.ContainsRune("^$", runes[at+1])))) {
+		return "", i, false, nil
+	}
+
+	words := historyWords(event)
+	last := len(words) - 1
+	bad := errors.New(string(runes[i:min(len(runes), at+1)]) + ": bad word specifier")
+	index := func() (int, bool) {
+		switch r := runes[at]; {
+		case isDigit(r):
+			end := digitsEnd(runes, at)
+			n, _ := strconv.Atoi(string(runes[at:end]))
+			at = end
+			return n, true
+		case r == '^':
+			at++
+			return 1, true
+		case r == '$':
+			at++
+			return last, true
+		case r == '%':
+			at++
+			for n, word := range words {
+				if e.lastSearch != "" && strings.Contains(word, e.lastSearch) {
+					return n, true
+				}
+			}
+			return -1, true
+		}
+		return 0, false
+	}
+
+	if runes[at] == '*' {
+		at++
+		return strings.Join(words[min(1, len(words)):], " "), at, true, nil
+	}
+	from, to := 0, 0
+	if runes[at] != '-' {
+		from, _ = index()
+		to = from
+	}
+	if at < len(runes) && runes[at] == '*' {
+		at++
+		to = last
+		if from == last+1 {
+			return "", at, true, nil
+		}
+	} else if at < len(runes) && runes[at] == '-' {
+		at++
+		var ok bool
+		if at < len(runes) {
+			to, ok = index()
+		}
+		if !ok {
+			// x- is x* without the last word
+			to = last - 1
+		}
+	}
+	if from < 0 || to > last || from > to {
+		return "", 0, false, bad
+	}
+	return strings.Join(words[from:to+1], " "), at, true, nil
+}
+
+// modifiers applies the modifiers at i, such as :h or :s/old/new/, to
+// text, and returns where they end and whether :p was among them.
+func (e *historyExpander) modifiers(runes []rune, i int, text string) (string, int, bool, error) {
+	printOnly := false
+	for i+1 < len(runes) && runes[i] == ':' {
+		at := i + 1
+		global, eachWord := false, false
+		switch runes[at] {
+		case 'g', 'a':
+			global = true
+			at++
+		case 'G':
+			eachWord = true
+			at++
+		}
+		if at >= len(runes) {
+			break
+		}
+		switch runes[at] {
+		case 'h':
+			if slash := strings.LastIndex(text, "/"); slash > 0 {
+				text = text[:slash]
+			} else if slash == 0 {
+				text = "/"
+			}
+		case 't':
+			text = text[strings.LastIndex(text, "/")+1:]
+		case 'r':
+			if dot := strings.LastIndex(text, "."); dot > strings.LastIndex(text, "/") {
+				text = text[:dot]
+			}
+		case 'e':
+			if dot := strings.LastIndex(text, "."); dot > strings.LastIndex(text, "/") {
+				text = text[dot:]
+			} else {
+				text = ""
+			}
+		case 'p':
+			printOnly = true
+		case 'q':
+			text = singleQuote(text)
+		case 'x':
+			var quoted []string
+			for _, word := range strings.Fields(text) {
+				quoted = append(quoted, singleQuote(word))
+			}
+			text = strings.Join(quoted, " ")
+		case 's', '&':
+			if runes[at] == 's' {
+				if at+1 >= len(runes) {
+					return "", 0, false, errors.New(":s: missing delimiter")
+				}
+				var old, replacement string
+				old, replacement, at = parseSubstitution(runes, at+1)
+				if old == "" {
+					old = e.lastOld
+					if old == "" {
+						old = e.lastSearch
+					}
+				}
+				if old == "" {
+					return "", 0, false, errors.New("no previous substitution")
+				}
+				e.lastOld, e.lastNew, e.hasSubstitution = old, replacement, true
+			} else {
+				if !e.hasSubstitution {
+					return "", 0, false, errors.New("no previous substitution")
+				}
+				at++
+			}
+			substituted := substitute(text, e.lastOld, e.lastNew, global, eachWord)
+			if substituted == text {
+				return "", 0, false, fmt.Errorf(":s/%s/%s/: substitution failed", e.lastOld, e.lastNew)
+			}
+			text = substituted
+			i = at
+			continue
+		default:
+			// Not a modifier; the : is part of the line
+			return text, i, printOnly, nil
+		}
+		i = at + 1
+	}
+	return text, i, printOnly, nil
+}
+
+// parseSubstitution reads old and new of :s from i, the delimiter, which
+// a backslash escapes. & in new stands for old. The last delimiter may be
+// left out at the end of the line.
+func parseSubstitution(runes []rune, i int) (string, string, int) {
+	delimiter := runes[i]
+	i++
+	read := func() string {
+		var sb strings.Builder
+		for ; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case r == '\\' && i+1 < len(runes) && (runes[i+1] == delimiter || runes[i+1] == '&'):
+				i++
+				sb.WriteString("\x00" + string(runes[i]))
+				continue
+			case r == delimiter:
+				i++
+				return sb.String()
+			}
+			sb.WriteRune(r)
+		}
+		return sb.String()
+	}
+	old := read()
+	replacement := read()
+	old = strings.ReplaceAll(old, "\x00", "")
+	// & is old, but for an escaped one
+	var sb strings.Builder
+	escaped := false
+	for _, r := range replacement {
+		switch {
+		case r == '\x00':
+			escaped = true
+			continue
+		case r == '&' && !escaped:
+			sb.WriteString(old)
+		default:
+			sb.WriteRune(r)
+		}
+		escaped = false
+	}
+	return old, sb.String(), i
+}
+
+// substitute replaces old with replacement in text: once, everywhere with
+// global, or once in each word with eachWord.
+func substitute(text, old, replacement string, global, eachWord bool) string {
+	switch {
+	case global:
+		return strings.ReplaceAll(text, old, replacement)
+	case eachWord:
+		words := strings.Fields(text)
+		for i, word := range words {
+			words[i] = strings.Replace(word, old, replacement, 1)
+		}
+		return strings.Join(words, " ")
+	default:
+		return strings.Replace(text, old, replacement, 1)
+	}
+}
+
+// historyWords splits command into words as history expansion sees them:
+// quoted strings stay whole and operators such as | and && are words of
+// their own.
+func historyWords(command string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case strings.ContainsRune("|&;<>()", r):
+			flush()
+			operator := string(r)
+			if i+1 < len(runes) && strings.Contains("|| && ;; >> << >& <& |& &>", string(runes[i:i+2])) && strings.ContainsRune("|&;<>", runes[i+1]) {
+				operator += string(runes[i+1])
+				i++
+			}
+			words = append(words, operator)
+		case r == '\'' || r == '"':
+			inWord = true
+			word.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				word.WriteRune(runes[i])
+				if runes[i] == '\\' && r == '"' && i+1 < len(runes) {
+					i++
+					word.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					break
+				}
+			}
+		case r == '\\':
+			inWord = true
+			word.WriteRune(r)
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// singleQuote quotes text in single quotes, as :q does.
+func singleQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func digitsEnd(runes []rune, i int) int {
+	for i < len(runes) && isDigit(runes[i]) {
+		i++
+	}
+	return i
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/robottwo/bishop/internal/history"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExpandHistory(t *testing.T) {
@@ -32,34 +33,40 @@ func TestExpandHistory(t *testing.T) {
 	}
 
 	// Test !!
-	out, expanded := expandHistory("!!", hm)
-	assert.True(t, expanded)
-	assert.Equal(t, "echo hello", out)
+	out, err := expandHistory("!!", hm)
+	require.NoError(t, err)
+	assert.True(t, out.expanded)
+	assert.Equal(t, "echo hello", out.line)
 
 	// Test !$
-	out, expanded = expandHistory("!$", hm)
-	assert.True(t, expanded)
-	assert.Equal(t, "hello", out)
+	out, err = expandHistory("!$", hm)
+	require.NoError(t, err)
+	assert.True(t, out.expanded)
+	assert.Equal(t, "hello", out.line)
 
 	// Test mixed
-	out, expanded = expandHistory("echo !!", hm)
-	assert.True(t, expanded)
-	assert.Equal(t, "echo echo hello", out)
+	out, err = expandHistory("echo !!", hm)
+	require.NoError(t, err)
+	assert.True(t, out.expanded)
+	assert.Equal(t, "echo echo hello", out.line)
 
 	// Test quotes
-	out, expanded = expandHistory("'!!'", hm)
-	assert.False(t, expanded)
-	assert.Equal(t, "'!!'", out)
+	out, err = expandHistory("'!!'", hm)
+	require.NoError(t, err)
+	assert.False(t, out.expanded)
+	assert.Equal(t, "'!!'", out.line)
 
 	// Test double quotes (should expand in our simplified logic)
-	out, expanded = expandHistory("\"!!\"", hm)
-	assert.True(t, expanded)
-	assert.Equal(t, "\"echo hello\"", out)
+	out, err = expandHistory("\"!!\"", hm)
+	require.NoError(t, err)
+	assert.True(t, out.expanded)
+	assert.Equal(t, "\"echo hello\"", out.line)
 
 	// Test escaped
-	out, expanded = expandHistory("\\!!", hm)
-	assert.False(t, expanded)
-	assert.Equal(t, "\\!!", out)
+	out, err = expandHistory("\\!!", hm)
+	require.NoError(t, err)
+	assert.False(t, out.expanded)
+	assert.Equal(t, "\\!!", out.line)
 
 	// Test !$ with multiple args
 	_, err = hm.StartCommand("ls -la /tmp", "/tmp", "session-1")
@@ -67,7 +74,105 @@ func TestExpandHistory(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Now last command is "ls -la /tmp"
-	out, expanded = expandHistory("!$", hm)
-	assert.True(t, expanded)
-	assert.Equal(t, "/tmp", out)
+	out, err = expandHistory("!$", hm)
+	require.NoError(t, err)
+	assert.True(t, out.expanded)
+	assert.Equal(t, "/tmp", out.line)
+}
+
+func TestExpandHistoryDesignators(t *testing.T) {
+	hm, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	for _, command := range []string{
+		"grep -rn TODO src/main.go",                       // 1
+		"tar czf /backup/site.tar.gz 'my site' | tee log", // 2
+		"git commit -m \"fix the build\"",                 // 3
+		"vim /etc/nginx/nginx.conf",                       // 4
+	} {
+		_, err := hm.StartCommand(command, "/", "s1")
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Events
+		{"!1", "grep -rn TODO src/main.go"},
+		{"!-2", "git commit -m \"fix the build\""},
+		{"!gr", "grep -rn TODO src/main.go"},
+		{"sudo !!", "sudo vim /etc/nginx/nginx.conf"},
+		{"!?site?", "tar czf /backup/site.tar.gz 'my site' | tee log"},
+		{"echo a !#", "echo a echo a "},
+		// Words
+		{"!!:0", "vim"},
+		{"!grep:2", "TODO"},
+		{"!tar:1-3", "czf /backup/site.tar.gz 'my site'"},
+		{"!tar:3-", "'my site' | tee"},
+		{"!tar:-1", "tar czf"},
+		{"!tar:4*", "| tee log"},
+		{"echo !git:$", "echo \"fix the build\""},
+		{"echo !^", "echo /etc/nginx/nginx.conf"},
+		{"cat !*", "cat /etc/nginx/nginx.conf"},
+		{"cat !:1", "cat /etc/nginx/nginx.conf"},
+		{"echo !?TOD?%", "echo TODO"},
+		{"echo !-3*", "echo czf /backup/site.tar.gz 'my site' | tee log"},
+		// Modifiers
+		{"cd !$:h", "cd /etc/nginx"},
+		{"echo !$:t", "echo nginx.conf"},
+		{"echo !$:t:r", "echo nginx"},
+		{"echo !$:e", "echo .conf"},
+		{"echo !tar:2:h:h", "echo /"},
+		{"echo !$:q", "echo '/etc/nginx/nginx.conf'"},
+		{"!!:s/nginx/apache/", "vim /etc/apache/nginx.conf"},
+		{"!!:gs/nginx/apache/", "vim /etc/apache/apache.conf"},
+		{"!!:s/vim/& -R/", "vim -R /etc/nginx/nginx.conf"},
+		{"!grep:s:TODO:FIXME", "grep -rn FIXME src/main.go"},
+		{"!!:x", "'vim' '/etc/nginx/nginx.conf'"},
+		// Quick substitution
+		{"^nginx^apache", "vim /etc/apache/nginx.conf"},
+		{"^nginx^apache^:t", "nginx.conf"},
+		// Literal !
+		{"[ a != b ] && echo ok", "[ a != b ] && echo ok"},
+		{"if ! true; then echo no; fi", "if ! true; then echo no; fi"},
+		{"echo $! ${!prefix*} !(x)", "echo $! ${!prefix*} !(x)"},
+		{"echo 'wow!!' \"!\"", "echo 'wow!!' \"!\""},
+		{"echo hi!", "echo hi!"},
+		{"grep ^foo file", "grep ^foo file"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			out, err := expandHistory(test.input, hm)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, out.line)
+			assert.Equal(t, test.input != test.want, out.expanded)
+			assert.False(t, out.printOnly)
+		})
+	}
+
+	out, err := expandHistory("!!:s/vim/less/:p", hm)
+	require.NoError(t, err)
+	assert.True(t, out.printOnly)
+	assert.Equal(t, "less /etc/nginx/nginx.conf", out.line)
+
+	for input, message := range map[string]string{
+		"!docker":      "!docker: event not found",
+		"!42":          "!42: event not found",
+		"!-9":          "!-9: event not found",
+		"!?nothing?":   "!?nothing: event not found",
+		"!!:5":         "bad word specifier",
+		"^apache^x":    "substitution failed",
+		"!!:&":         "no previous substitution",
+		"echo !!:3-1":  "bad word specifier",
+		"echo !grep:9": "bad word specifier",
+	} {
+		_, err := expandHistory(input, hm)
+		assert.ErrorContains(t, err, message, input)
+	}
+}
+
+func TestHistoryWords(t *testing.T) {
+	assert.Equal(t, []string{"a", "'b c'", "\"d \\\" e\"", "|", "f", "&&", "g", ">>", "h", ";"},
+		historyWords(`a 'b c' "d \" e" | f&&g >>h;`))
+	assert.Empty(t, historyWords("  "))
 }
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"go.uber.org/zap"
+)
+
+const (
+	// historyMaintenanceDelay leaves the first prompts of a session to the
+	// user before maintenance runs
+	historyMaintenanceDelay = time.Minute
+	// historyMaintenanceInterval is how often a long session runs it again
+	historyMaintenanceInterval = 6 * time.Hour
+)
+
+// startHistoryMaintenance maintains the history database in the background
+// for as long as ctx lasts, a minute into the session and every few hours
+// after, so that it stays small and quick to search as it grows. Failures
+// are only logged.
+func startHistoryMaintenance(ctx context.Context, historyManager *history.HistoryManager, logger *zap.Logger) {
+	go func() {
+		timer := time.NewTimer(historyMaintenanceDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			started := time.Now()
+			result, err := historyManager.Maintain(ctx)
+			if err != nil {
+				logger.Warn("error maintaining the history database", zap.Error(err))
+			} else {
+				logger.Debug("maintained the history database",
+					zap.Int("freedPages", result.FreedPages),
+					zap.Bool("vacuumed", result.Vacuumed),
+					zap.Duration("took", time.Since(started)))
+			}
+			timer.Reset(historyMaintenanceInterval)
+		}
+	}()
+}
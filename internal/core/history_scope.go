@@ -0,0 +1,55 @@
+package core
+
+import (
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/pkg/shellinput"
+)
+
+// historyScopes maps the values of BISH_HISTORY_SCOPE to the scopes of the
+// prompt's history.
+var historyScopes = map[string]shellinput.HistoryFilterMode{
+	environment.HistoryScopeDirectory: shellinput.HistoryFilterDirectory,
+	environment.HistoryScopeSession:   shellinput.HistoryFilterSession,
+	environment.HistoryScopeGlobal:    shellinput.HistoryFilterAll,
+}
+
+// historyScopeName returns the BISH_HISTORY_SCOPE value of scope.
+func historyScopeName(scope shellinput.HistoryFilterMode) string {
+	for name, s := range historyScopes {
+		if s == scope {
+			return name
+		}
+	}
+	return environment.HistoryScopeDirectory
+}
+
+// scopedHistory returns the last limit commands of scope, newest first, for
+// Up/Down. An isolated session leaves out those other shells recorded after
+// sessionStart.
+func scopedHistory(historyManager *history.HistoryManager, scope shellinput.HistoryFilterMode, directory string, sessionID string, sessionStart time.Time, isolated bool, limit int) ([]string, error) {
+	if scope != shellinput.HistoryFilterDirectory {
+		directory = ""
+	}
+	var entries []history.HistoryEntry
+	var err error
+	switch {
+	case scope == shellinput.HistoryFilterSession:
+		entries, err = historyManager.GetRecentEntriesInSession(sessionID, limit)
+	case isolated:
+		entries, err = historyManager.GetRecentSessionEntries(directory, sessionID, sessionStart, limit)
+	default:
+		entries, err = historyManager.GetRecentEntries(directory, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commands := make([]string, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		commands[len(entries)-1-i] = entries[i].Command
+	}
+	return commands, nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedHistory(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	defer func() { _ = historyManager.Close() }()
+
+	for _, command := range []struct{ command, directory, session string }{
+		{"make build", "/project", "other"},
+		{"ls", "/tmp", "me"},
+		{"make test", "/project", "me"},
+	} {
+		_, err := historyManager.StartCommand(command.command, command.directory, command.session)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	commands, err := scopedHistory(historyManager, shellinput.HistoryFilterDirectory, "/project", "me", time.Now(), false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make test", "make build"}, commands)
+
+	commands, err = scopedHistory(historyManager, shellinput.HistoryFilterSession, "/project", "me", time.Now(), false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make test", "ls"}, commands)
+
+	commands, err = scopedHistory(historyManager, shellinput.HistoryFilterAll, "/project", "me", time.Now(), false, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make test", "ls"}, commands)
+}
+
+func TestHistoryScopeName(t *testing.T) {
+	for name, scope := range historyScopes {
+		assert.Equal(t, name, historyScopeName(scope))
+	}
+	assert.Equal(t, environment.HistoryScopeGlobal, historyScopeName(shellinput.HistoryFilterAll))
+}
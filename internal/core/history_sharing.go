@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/pkg/gline"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"go.uber.org/zap"
+)
+
+// historyPollInterval is how often the prompt checks for commands recorded by
+// other shells when BISH_HISTORY_SHARING is "live".
+const historyPollInterval = 2 * time.Second
+
+// toHistoryItems converts history entries into items for the rich history search.
+func toHistoryItems(entries []history.HistoryEntry) []shellinput.HistoryItem {
+	items := make([]shellinput.HistoryItem, len(entries))
+	for i, entry := range entries {
+		items[i] = shellinput.HistoryItem{
+			Command:   entry.Command,
+			Directory: entry.Directory,
+			Timestamp: entry.CreatedAt,
+			SessionID: entry.SessionID,
+		}
+	}
+	return items
+}
+
+// loadHistoryEntries reads the entries the prompt works from, newest first:
+// all of history or, for an isolated session, only what it should see of it,
+// its own commands and anything recorded before it started.
+func loadHistoryEntries(historyManager *history.HistoryManager, isolated bool, sessionID string, sessionStart time.Time) ([]history.HistoryEntry, error) {
+	var entries []history.HistoryEntry
+	err := historyManager.EachEntry(func(entry history.HistoryEntry) bool {
+		if !isolated || entry.SessionID == sessionID || entry.CreatedAt.Before(sessionStart) {
+			entries = append(entries, entry)
+		}
+		return true
+	})
+	return entries, err
+}
+
+// latestHistoryID returns the highest entry ID in entries, or 0 if empty.
+func latestHistoryID(entries []history.HistoryEntry) uint {
+	var latest uint
+	for _, entry := range entries {
+		if entry.ID > latest {
+			latest = entry.ID
+		}
+	}
+	return latest
+}
+
+// newHistoryPoller returns a gline.HistoryPoller that reports commands recorded
+// by other sessions after the entry with ID lastSeenID.
+func newHistoryPoller(historyManager *history.HistoryManager, sessionID string, lastSeenID uint, logger *zap.Logger) gline.HistoryPoller {
+	return func(ctx context.Context) []shellinput.HistoryItem {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		entries, err := historyManager.GetEntriesAfterID(lastSeenID, sessionID)
+		if err != nil {
+			logger.Debug("error polling shared history", zap.Error(err))
+			return nil
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		lastSeenID = max(lastSeenID, latestHistoryID(entries))
+		return toHistoryItems(entries)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadHistoryEntries(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	defer func() { _ = historyManager.Close() }()
+
+	_, err = historyManager.StartCommand("theirs before", "/", "other")
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	sessionStart := time.Now()
+	_, err = historyManager.StartCommand("mine", "/", "me")
+	require.NoError(t, err)
+	_, err = historyManager.StartCommand("theirs after", "/", "other")
+	require.NoError(t, err)
+
+	entries, err := loadHistoryEntries(historyManager, true, "me", sessionStart)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "mine", entries[0].Command)
+	assert.Equal(t, "theirs before", entries[1].Command)
+
+	entries, err = loadHistoryEntries(historyManager, false, "me", sessionStart)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "theirs after", entries[0].Command)
+}
+
+func TestHistoryPollerReturnsOnlyNewEntriesFromOtherSessions(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	defer func() { _ = historyManager.Close() }()
+
+	existing, err := historyManager.StartCommand("echo existing", "/", "other")
+	require.NoError(t, err)
+
+	poll := newHistoryPoller(historyManager, "me", existing.ID, zap.NewNop())
+	assert.Empty(t, poll(context.Background()))
+
+	_, err = historyManager.StartCommand("echo mine", "/", "me")
+	require.NoError(t, err)
+	_, err = historyManager.StartCommand("echo theirs", "/", "other")
+	require.NoError(t, err)
+
+	items := poll(context.Background())
+	require.Len(t, items, 1)
+	assert.Equal(t, "echo theirs", items[0].Command)
+	assert.Equal(t, "other", items[0].SessionID)
+
+	// Entries already reported are not returned again
+	assert.Empty(t, poll(context.Background()))
+}
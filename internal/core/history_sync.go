@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// historySyncTimeout bounds the sync a shell starts with.
+const historySyncTimeout = 2 * time.Minute
+
+// startHistorySync merges history with the one at BISH_HISTORY_SYNC_URL in
+// the background, if it is set, so that the commands of other machines are
+// there for search and predictions. Failures are only logged; history sync
+// reports them.
+func startHistorySync(runner *interp.Runner, historyManager *history.HistoryManager, logger *zap.Logger) {
+	rawURL := environment.GetHistorySyncURL(runner)
+	if rawURL == "" {
+		return
+	}
+	remote, err := history.NewSyncRemote(rawURL)
+	if err != nil {
+		logger.Warn("invalid BISH_HISTORY_SYNC_URL", zap.Error(err))
+		return
+	}
+	go func() {
+		key, err := history.LoadSyncKey(history.DefaultSyncKeyPath())
+		if err != nil {
+			logger.Warn("error loading the history sync key", zap.Error(err))
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), historySyncTimeout)
+		defer cancel()
+		result, err := historyManager.Sync(ctx, remote, key)
+		if err != nil {
+			logger.Warn("error syncing history", zap.Error(err))
+			return
+		}
+		logger.Debug("synced history", zap.Int("pulled", result.Pulled), zap.Int("pushed", result.Pushed))
+	}()
+}
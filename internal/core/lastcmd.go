@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// outputCaptureLimit caps how much of a command's stdout is kept in
+// $LAST_OUT_FILE. The start is kept, as a file redirect would have it.
+const outputCaptureLimit = 16 << 20
+
+// outputCapture copies a command's stdout to a file. The file is written
+// next to $LAST_OUT_FILE and only replaces it once the command is done, so
+// that a command reading $LAST_OUT_FILE sees the output of the one before.
+type outputCapture struct {
+	mu      sync.Mutex
+	file    *os.File
+	path    string
+	written int
+}
+
+// lastOutputPath is the file $LAST_OUT_FILE names for the session.
+func lastOutputPath(sessionID string) string {
+	return filepath.Join(os.TempDir(), "bish-out-"+sessionID)
+}
+
+// startOutputCapture starts copying stdout for the file at path.
+func startOutputCapture(path string) (*outputCapture, error) {
+	file, err := os.OpenFile(path+".next", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &outputCapture{file: file, path: path}, nil
+}
+
+// Write copies p to the file up to outputCaptureLimit. It never fails, so
+// that the command's output to the terminal is not cut short.
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := outputCaptureLimit - c.written; room > 0 {
+		n, _ := c.file.Write(p[:min(len(p), room)])
+		c.written += n
+	}
+	return len(p), nil
+}
+
+// finish closes the file and makes it the one at path.
+func (c *outputCapture) finish() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(c.file.Name(), c.path)
+}
+
+// setLastCommandVars sets $LAST_CMD and $LAST_DUR for the command that just
+// ran, and $LAST_OUT_FILE if its output was captured.
+func setLastCommandVars(ctx context.Context, runner *interp.Runner, command string, duration time.Duration, outputFile string) {
+	assigns := []string{
+		"LAST_CMD=" + quoteValue(command),
+		"LAST_DUR=" + quoteValue(duration.Round(time.Millisecond).String()),
+	}
+	if outputFile != "" {
+		assigns = append(assigns, "LAST_OUT_FILE="+quoteValue(outputFile))
+	}
+	_, _, _ = bash.RunBashCommand(ctx, runner, strings.Join(assigns, " "))
+}
+
+// quoteValue quotes value to be assigned as it is.
+func quoteValue(value string) string {
+	quoted, err := syntax.Quote(value, syntax.LangBash)
+	if err != nil {
+		// Only NUL bytes cannot be quoted, and no variable can hold them
+		quoted, _ = syntax.Quote(strings.ReplaceAll(value, "\x00", ""), syntax.LangBash)
+	}
+	return quoted
+}
+
+// removeLastOutput deletes the session's $LAST_OUT_FILE when the shell exits.
+func removeLastOutput(sessionID string) {
+	path := lastOutputPath(sessionID)
+	_ = os.Remove(path)
+	_ = os.Remove(path + ".next")
+}
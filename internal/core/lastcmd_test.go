@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestOutputCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.WriteFile(path, []byte("previous\n"), 0o600))
+
+	capture, err := startOutputCapture(path)
+	require.NoError(t, err)
+	_, err = capture.Write([]byte("current\n"))
+	require.NoError(t, err)
+
+	// A command reading the file while it runs sees the previous output
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous\n", string(data))
+
+	require.NoError(t, capture.finish())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "current\n", string(data))
+	assert.NoFileExists(t, path+".next")
+}
+
+func TestOutputCaptureLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	capture, err := startOutputCapture(path)
+	require.NoError(t, err)
+	capture.written = outputCaptureLimit - 3
+
+	n, err := capture.Write([]byte("abcdef"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	require.NoError(t, capture.finish())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(data))
+}
+
+func TestSetLastCommandVars(t *testing.T) {
+	runner, err := interp.New()
+	require.NoError(t, err)
+	ctx := context.Background()
+	// expand expands word in a later command
+	expand := func(word string) string {
+		stdout, _, err := bash.RunBashCommand(ctx, runner, "printf '%s' "+word)
+		require.NoError(t, err)
+		return stdout
+	}
+
+	setLastCommandVars(ctx, runner, "make test", 1234567*time.Microsecond, "")
+	assert.Equal(t, "cmd=make test dur=1.235s out=", expand(`"cmd=$LAST_CMD dur=$LAST_DUR out=$LAST_OUT_FILE"`))
+
+	// The command is kept as it was typed, whatever it quotes
+	command := `echo "it's $HOME" 'a\b' $(date) ` + "`id`"
+	setLastCommandVars(ctx, runner, command, time.Second, "/tmp/bish-out-1")
+	assert.Equal(t, command, expand(`"$LAST_CMD"`))
+	assert.Equal(t, "/tmp/bish-out-1", expand(`"$LAST_OUT_FILE"`))
+}
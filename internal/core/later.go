@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/later"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// queuedPrelude runs before a queued command in its subshell. bish's cd
+// changes the process directory through bish_cd_hook, which a command
+// running alongside the shell must not do, so the hook does nothing there.
+const queuedPrelude = "bish_cd_hook() { :; }\nbuiltin cd %s"
+
+// prepareQueued returns how the commands queued with later run: each in a
+// subshell of runner, copied at the prompt, in the directory it was queued
+// from and without the terminal's input. History records them as queued.
+func prepareQueued(runner *interp.Runner, historyManager *history.HistoryManager, sessionID string, logger *zap.Logger) later.Prepare {
+	return func(entry later.Entry) later.Run {
+		subshell := runner.Subshell()
+		interp.StdIO(nil, os.Stdout, os.Stderr)(subshell)
+		return func(ctx context.Context) int {
+			dir, err := syntax.Quote(entry.Dir, syntax.LangBash)
+			var prelude *syntax.File
+			if err == nil {
+				prelude, err = syntax.NewParser().Parse(strings.NewReader(fmt.Sprintf(queuedPrelude, dir)), "")
+			}
+			if err == nil {
+				err = subshell.Run(ctx, prelude)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "later: [%d] cannot run in %s\n", entry.ID, entry.Dir)
+				return 1
+			}
+			prog, err := syntax.NewParser().Parse(strings.NewReader(entry.Command), "")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "later: [%d] %v\n", entry.ID, err)
+				return 2
+			}
+			bash.Rewrite(prog)
+
+			historyEntry, err := historyManager.StartQueuedCommand(entry.Command, entry.Dir, sessionID)
+			if err != nil {
+				logger.Warn("error recording queued command", zap.Error(err))
+			}
+			exitCode := 0
+			if err := bash.Run(ctx, subshell, prog); err != nil {
+				exitCode = -1
+				if status, ok := interp.IsExitStatus(err); ok {
+					exitCode = int(status)
+				}
+			}
+			if historyEntry != nil {
+				_, _ = historyManager.FinishCommand(historyEntry, exitCode)
+			}
+			return exitCode
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/robottwo/bishop/internal/history"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// observedOutputLimit caps how much of a rerun command's output is sent to
+// the agent. The end of the output is kept since that is where errors and
+// results usually are.
+const observedOutputLimit = 16 * 1024
+
+// attachOutput points the runner's output at the terminal, capturing stderr,
+// copying stdout to stdoutCopy and both streams to observer if they are set.
+// Helpers such as bash.RunBashCommand reset the output to os.Stdout and
+// os.Stderr, so this is done before every command.
+func attachOutput(runner *interp.Runner, stderrCapturer *StderrCapturer, observer, stdoutCopy io.Writer) {
+	var stdout io.Writer = os.Stdout
+	var stderr io.Writer = stderrCapturer
+	if stdoutCopy != nil {
+		stdout = io.MultiWriter(stdout, stdoutCopy)
+	}
+	if observer != nil {
+		stdout = io.MultiWriter(stdout, observer)
+		stderr = io.MultiWriter(stderr, observer)
+	}
+	_ = interp.StdIO(os.Stdin, stdout, stderr)(runner)
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	mu        sync.Mutex
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if excess := len(b.data) - b.limit; excess > 0 {
+		b.data = append(b.data[:0], b.data[excess:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// parseObserveCommand reports whether a chat message (the line without its
+// leading #) is "#!", which reruns the last command under the agent's
+// observation, and returns any instructions typed after it.
+func parseObserveCommand(chatMessage string) (string, bool) {
+	rest, ok := strings.CutPrefix(chatMessage, "#!")
+	if !ok || (rest != "" && rest[0] != ' ') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// commandToObserve returns the last command run in this shell, or the most
+// recent history entry in a new session.
+func commandToObserve(state *ShellState, historyManager *history.HistoryManager) string {
+	if state.LastCommand != "" {
+		return state.LastCommand
+	}
+	entries, err := historyManager.GetRecentEntries("", 1)
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1].Command
+}
+
+// observationPrompt asks the agent to summarize a command it watched.
+func observationPrompt(command string, exitCode int, output *tailBuffer, instructions string) string {
+	text := output.String()
+	if strings.TrimSpace(text) == "" {
+		text = "(no output)"
+	}
+	note := ""
+	if output.truncated {
+		note = fmt.Sprintf(" Only the last %d bytes of the output are shown.", observedOutputLimit)
+	}
+
+	prompt := fmt.Sprintf("I re-ran the command `%s` so you could observe it. It exited with code %d.%s\nIts output was:\n```\n%s\n```\n\nSummarize what happened in a few sentences, pointing out errors, warnings and notable results. Do not run any commands.",
+		command, exitCode, note, strings.TrimRight(text, "\n"))
+	if instructions != "" {
+		prompt += "\n\n" + instructions
+	}
+	return prompt
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestParseObserveCommand(t *testing.T) {
+	tests := []struct {
+		message      string
+		instructions string
+		ok           bool
+	}{
+		{"#!", "", true},
+		{"#! focus on warnings ", "focus on warnings", true},
+		{"#!git", "", false},
+		{"#git status", "", false},
+		{"!fix", "", false},
+	}
+	for _, tt := range tests {
+		instructions, ok := parseObserveCommand(tt.message)
+		assert.Equal(t, tt.ok, ok, tt.message)
+		assert.Equal(t, tt.instructions, instructions, tt.message)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	buffer := &tailBuffer{limit: 8}
+	_, _ = buffer.Write([]byte("hello"))
+	assert.Equal(t, "hello", buffer.String())
+	assert.False(t, buffer.truncated)
+
+	n, err := buffer.Write([]byte(" world"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, "lo world", buffer.String())
+	assert.True(t, buffer.truncated)
+}
+
+func TestCommandToObserve(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+
+	state := &ShellState{}
+	assert.Equal(t, "", commandToObserve(state, historyManager))
+
+	_, err = historyManager.StartCommand("make test", "/tmp", "other-session")
+	require.NoError(t, err)
+	assert.Equal(t, "make test", commandToObserve(state, historyManager))
+
+	state.LastCommand = "go build ./..."
+	assert.Equal(t, "go build ./...", commandToObserve(state, historyManager))
+}
+
+func TestObservationPrompt(t *testing.T) {
+	output := &tailBuffer{limit: observedOutputLimit}
+	_, _ = output.Write([]byte("ok 3 tests\nFAIL 1 test\n"))
+	prompt := observationPrompt("make test", 2, output, "which test failed?")
+	assert.Contains(t, prompt, "`make test`")
+	assert.Contains(t, prompt, "exited with code 2.\n")
+	assert.Contains(t, prompt, "```\nok 3 tests\nFAIL 1 test\n```")
+	assert.True(t, strings.HasSuffix(prompt, "\n\nwhich test failed?"))
+
+	prompt = observationPrompt("true", 0, &tailBuffer{limit: 4, truncated: true}, "")
+	assert.Contains(t, prompt, "(no output)")
+	assert.Contains(t, prompt, "Only the last 16384 bytes")
+}
+
+func TestAttachOutput(t *testing.T) {
+	runner, err := interp.New()
+	require.NoError(t, err)
+	capturer := NewStderrCapturer(&bytes.Buffer{})
+	observer := &tailBuffer{limit: observedOutputLimit}
+
+	// Keep the test's stdout clean
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer func() { _ = devNull.Close() }()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	attachOutput(runner, capturer, observer, nil)
+	capturer.StartCapture()
+	file, err := syntax.NewParser().Parse(strings.NewReader("echo out; echo err >&2"), "")
+	require.NoError(t, err)
+	require.NoError(t, runner.Run(context.Background(), file))
+
+	assert.Equal(t, "err\n", capturer.StopCapture())
+	assert.Equal(t, "out\nerr\n", observer.String())
+}
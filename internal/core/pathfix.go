@@ -0,0 +1,18 @@
+package core
+
+import (
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/pathfix"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// suggestPathCorrection returns the last command with misspelled paths
+// corrected if it failed because a path it names does not exist. cd and pushd
+// are checked even without an error message, since they fail silently.
+func suggestPathCorrection(state *ShellState, runner *interp.Runner, logger *zap.Logger) (string, bool) {
+	if state.LastExitCode == 0 || environment.GetPathCorrection(runner, logger) == environment.PathCorrectionOff {
+		return "", false
+	}
+	return pathfix.Suggest(state.LastCommand, state.LastStderr, environment.GetPwd(runner), environment.GetHomeDir(runner))
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestSuggestPathCorrection(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Notes.txt"), nil, 0o644))
+
+	runner, err := interp.New(interp.Dir(dir))
+	require.NoError(t, err)
+	runner.Vars = make(map[string]expand.Variable)
+	logger := zap.NewNop()
+
+	state := &ShellState{
+		LastCommand:  "cat notes.txt",
+		LastExitCode: 1,
+		LastStderr:   "cat: notes.txt: No such file or directory\n",
+	}
+	corrected, ok := suggestPathCorrection(state, runner, logger)
+	assert.True(t, ok)
+	assert.Equal(t, "cat Notes.txt", corrected)
+
+	runner.Vars["BISH_PATH_CORRECTION"] = expand.Variable{Kind: expand.String, Str: "off"}
+	_, ok = suggestPathCorrection(state, runner, logger)
+	assert.False(t, ok)
+
+	runner.Vars["BISH_PATH_CORRECTION"] = expand.Variable{Kind: expand.String, Str: "hint"}
+	state.LastExitCode = 0
+	_, ok = suggestPathCorrection(state, runner, logger)
+	assert.False(t, ok)
+}
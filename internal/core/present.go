@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/robottwo/bishop/internal/config"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// handlePresentControl implements #!present, which turns presentation mode
+// on or off for the rest of the session. Without arguments it toggles it.
+func handlePresentControl(args string, runner *interp.Runner) {
+	var on bool
+	switch args {
+	case "":
+		on = !environment.GetPresentationMode(runner)
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Usage: #!present [on|off]\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	value := strconv.FormatBool(on)
+	config.SetSessionOverride("BISH_PRESENTATION_MODE", value)
+	runner.Vars["BISH_PRESENTATION_MODE"] = expand.Variable{Kind: expand.String, Str: value, Exported: true}
+
+	message := "bish: Presentation mode off.\n"
+	if on {
+		message = "bish: Presentation mode on: secrets are masked in the prompt, history, assistant box and config UI.\n"
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(message) + gline.RESET_CURSOR_COLUMN)
+}
+
+// redactFunc returns the function that masks secrets in text the shell shows,
+// which leaves it unchanged unless presentation mode is on.
+func redactFunc(runner *interp.Runner) func(string) string {
+	if !environment.GetPresentationMode(runner) {
+		return func(s string) string { return s }
+	}
+	return environment.GetSecretRedactor(runner).Redact
+}
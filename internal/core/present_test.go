@@ -0,0 +1,36 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestHandlePresentControl(t *testing.T) {
+	runner, err := interp.New()
+	require.NoError(t, err)
+	runner.Vars = make(map[string]expand.Variable)
+	runner.Vars["GITHUB_TOKEN"] = expand.Variable{Kind: expand.String, Str: "ghp_abcdef123456"}
+	line := "git clone https://ghp_abcdef123456@github.com/org/repo"
+
+	assert.Equal(t, line, redactFunc(runner)(line))
+
+	handlePresentControl("", runner)
+	assert.True(t, environment.GetPresentationMode(runner))
+	assert.Equal(t, "git clone https://"+redact.Mask+"@github.com/org/repo", redactFunc(runner)(line))
+
+	handlePresentControl("on", runner)
+	assert.True(t, environment.GetPresentationMode(runner))
+
+	handlePresentControl("off", runner)
+	assert.False(t, environment.GetPresentationMode(runner))
+	assert.Equal(t, line, redactFunc(runner)(line))
+
+	handlePresentControl("maybe", runner)
+	assert.False(t, environment.GetPresentationMode(runner))
+}
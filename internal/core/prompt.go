@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/pathfmt"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const (
+	// promptWait is how long a prompt that is out of date is waited for
+	// before the fast prompt is shown until it is ready
+	promptWait = 50 * time.Millisecond
+	// promptTimeout bounds a run of BISH_UPDATE_PROMPT
+	promptTimeout = 2 * time.Second
+)
+
+// promptKey is what a prompt depends on. Once any of it changes, the prompt
+// is out of date.
+type promptKey struct {
+	dir      string
+	exitCode string
+	// env is a hash of the exported variables
+	env uint64
+}
+
+// promptCache renders the prompt of each line. BISH_UPDATE_PROMPT, which
+// may run slow commands such as git or starship, runs in the background. A
+// line starts with the last prompt if the directory, the exit code and the
+// environment are the same as when it was rendered; otherwise with the new
+// one if it renders within promptWait, or else with a fast prompt of the
+// directory. The line editor then shows the prompt Generate renders once it
+// is done, which also catches what the key does not, such as a new branch.
+type promptCache struct {
+	runner *interp.Runner
+	logger *zap.Logger
+	// generate renders the prompt; tests replace it
+	generate func(ctx context.Context) string
+
+	mu     sync.Mutex
+	prompt string
+	key    promptKey
+	valid  bool
+	// pending is the run of generate in flight, if any
+	pending *promptRun
+}
+
+// promptRun is a run of generate.
+type promptRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	prompt string
+}
+
+func newPromptCache(runner *interp.Runner, logger *zap.Logger) *promptCache {
+	return &promptCache{
+		runner: runner,
+		logger: logger,
+		generate: func(ctx context.Context) string {
+			return environment.GetPrompt(ctx, runner, logger)
+		},
+	}
+}
+
+// Current returns the prompt to show for a new line.
+func (c *promptCache) Current() string {
+	key := currentPromptKey(c.runner)
+	c.mu.Lock()
+	if c.valid && key == c.key {
+		prompt := c.prompt
+		c.mu.Unlock()
+		return prompt
+	}
+	run := c.start()
+	c.mu.Unlock()
+
+	select {
+	case <-run.done:
+		if run.prompt != "" {
+			return run.prompt
+		}
+	case <-time.After(promptWait):
+	}
+	return fastPrompt(c.runner, c.logger)
+}
+
+// Generate renders the prompt again, or waits for the run in flight, and
+// returns it, or "" if ctx ends first. It is the prompt generator of the
+// line editor, which shows what it returns in place of the prompt Current
+// returned.
+func (c *promptCache) Generate(ctx context.Context) string {
+	c.mu.Lock()
+	run := c.pending
+	if run == nil {
+		run = c.start()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-run.done:
+		return run.prompt
+	case <-ctx.Done():
+		return ""
+	}
+}
+
+// Wait stops the run in flight, if any, and waits for it, so that commands
+// do not run in the interpreter at the same time.
+func (c *promptCache) Wait() {
+	c.mu.Lock()
+	run := c.pending
+	c.mu.Unlock()
+	if run != nil {
+		run.cancel()
+		<-run.done
+	}
+}
+
+// start runs generate in the background. The caller holds mu.
+func (c *promptCache) start() *promptRun {
+	if c.pending != nil {
+		return c.pending
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), promptTimeout)
+	run := &promptRun{cancel: cancel, done: make(chan struct{})}
+	c.pending = run
+	go func() {
+		defer cancel()
+		prompt := c.generate(ctx)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if ctx.Err() == nil {
+			run.prompt = prompt
+			// The key is taken once the prompt is rendered, since rendering
+			// it may export variables
+			c.prompt, c.key, c.valid = prompt, currentPromptKey(c.runner), true
+		}
+		c.pending = nil
+		close(run.done)
+	}()
+	return run
+}
+
+// currentPromptKey returns what the prompt depends on now.
+func currentPromptKey(runner *interp.Runner) promptKey {
+	key := promptKey{
+		dir:      environment.GetPwd(runner),
+		exitCode: runner.Vars["BISH_LAST_COMMAND_EXIT_CODE"].String(),
+	}
+	for name, variable := range runner.Vars {
+		if !variable.Exported || name == "PWD" || name == "OLDPWD" || name == "_" {
+			continue
+		}
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(name))
+		_, _ = hash.Write([]byte{0})
+		_, _ = hash.Write([]byte(variable.String()))
+		// Summed so that the order of the map does not matter
+		key.env += hash.Sum64()
+	}
+	return key
+}
+
+// fastPrompt returns a prompt that takes no time to render: the directory
+// and a marker, red after a failed command.
+func fastPrompt(runner *interp.Runner, logger *zap.Logger) string {
+	dir := environment.GetPwd(runner)
+	home := runner.Vars["HOME"].String()
+	style := environment.GetPathStyle(runner, logger)
+	var gitRoot string
+	if style == pathfmt.StyleGit {
+		gitRoot = pathfmt.FindGitRoot(dir)
+	}
+	marker := ">"
+	if code := runner.Vars["BISH_LAST_COMMAND_EXIT_CODE"]; code.Kind == expand.String && code.Str != "" && code.Str != "0" {
+		marker = "\033[31m>\033[0m"
+	}
+	return pathfmt.Shorten(dir, home, gitRoot, style) + " " + marker + " "
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/pkg/shellinput"
+)
+
+// promptHistory keeps the history entries the prompt works from between
+// prompts, newest first. Each prompt only reads the entries recorded since
+// the one before; history is read again in full only when it changed in
+// other ways, such as entries deleted, imported or synced.
+type promptHistory struct {
+	historyManager *history.HistoryManager
+	sessionID      string
+	sessionStart   time.Time
+
+	// mu guards the entries from pages of the history search that are
+	// still loading when the prompt closes
+	mu       sync.Mutex
+	loaded   bool
+	isolated bool
+	entries  newestFirst[history.HistoryEntry]
+	// items are the first entries made into items for the rich history
+	// search, as it asks for them
+	items *newestFirst[shellinput.HistoryItem]
+	// count and latestID are the size of history when entries were read
+	count    int64
+	latestID uint
+}
+
+func newPromptHistory(historyManager *history.HistoryManager, sessionID string, sessionStart time.Time) *promptHistory {
+	return &promptHistory{historyManager: historyManager, sessionID: sessionID, sessionStart: sessionStart}
+}
+
+// Entries returns the entries, all of history or, if isolated, only what the
+// session should see of it.
+func (h *promptHistory) Entries(isolated bool) ([]history.HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count, latestID, err := h.historyManager.Size()
+	if err != nil {
+		return nil, err
+	}
+	if h.loaded && isolated == h.isolated {
+		if count == h.count && latestID == h.latestID {
+			return h.entries.Slice(), nil
+		}
+		if latestID > h.latestID {
+			added, err := h.historyManager.GetEntriesAfterID(h.latestID, "")
+			if err != nil {
+				return nil, err
+			}
+			if int64(len(added)) == count-h.count && h.newest(added) {
+				h.prepend(added)
+				h.count, h.latestID = count, latestID
+				return h.entries.Slice(), nil
+			}
+		}
+	}
+
+	entries, err := loadHistoryEntries(h.historyManager, isolated, h.sessionID, h.sessionStart)
+	if err != nil {
+		return nil, err
+	}
+	h.loaded, h.isolated, h.items = true, isolated, nil
+	h.entries = newestFirst[history.HistoryEntry]{list: entries}
+	h.count, h.latestID = count, latestID
+	return entries, nil
+}
+
+// newest reports whether added, ordered newest first, all started after the
+// entries there are, so that they can go in front of them.
+func (h *promptHistory) newest(added []history.HistoryEntry) bool {
+	entries := h.entries.Slice()
+	return len(entries) == 0 || len(added) == 0 || !added[len(added)-1].CreatedAt.Before(entries[0].CreatedAt)
+}
+
+// prepend adds the entries added since the last prompt in front.
+func (h *promptHistory) prepend(added []history.HistoryEntry) {
+	if h.isolated {
+		added = slices.DeleteFunc(added, func(entry history.HistoryEntry) bool {
+			return entry.SessionID != h.sessionID && !entry.CreatedAt.Before(h.sessionStart)
+		})
+	}
+	if len(added) == 0 {
+		return
+	}
+	h.entries.Prepend(added)
+	if h.items != nil {
+		h.items.Prepend(toHistoryItems(added))
+	}
+}
+
+// Page returns up to limit items for the rich history search from offset
+// on, and how many there are in all. Entries are made into items as they are
+// first asked for. It is safe to call while the prompt is open.
+func (h *promptHistory) Page(offset, limit int) ([]shellinput.HistoryItem, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := h.entries.Slice()
+	if h.items == nil {
+		h.items = &newestFirst[shellinput.HistoryItem]{}
+	}
+	end := min(offset+limit, len(entries))
+	if made := len(h.items.Slice()); made < end {
+		h.items.Append(toHistoryItems(entries[made:end]))
+	}
+	if offset >= end {
+		return nil, len(entries)
+	}
+	return h.items.Slice()[offset:end], len(entries)
+}
+
+// newestFirst is a list that grows at the front. Room is kept before the
+// first element, so that putting the few commands of each prompt in front
+// of all of history does not copy it. Slices returned before stay valid.
+type newestFirst[T any] struct {
+	list []T
+	// start is where the list begins in list
+	start int
+}
+
+// Slice returns the list.
+func (l *newestFirst[T]) Slice() []T {
+	return l.list[l.start:]
+}
+
+// Append puts added at the end of the list.
+func (l *newestFirst[T]) Append(added []T) {
+	l.list = append(l.list, added...)
+}
+
+// Prepend puts added in front of the list.
+func (l *newestFirst[T]) Prepend(added []T) {
+	if len(added) > l.start {
+		length := len(l.list) - l.start
+		room := max(len(added), length/4, 64)
+		list := make([]T, room+length)
+		copy(list[room:], l.list[l.start:])
+		l.list, l.start = list, room
+	}
+	l.start -= len(added)
+	copy(l.list[l.start:], added)
+}
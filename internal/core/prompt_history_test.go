@@ -0,0 +1,99 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commandsOf(entries []history.HistoryEntry) []string {
+	commands := make([]string, len(entries))
+	for i, entry := range entries {
+		commands[i] = entry.Command
+	}
+	return commands
+}
+
+func TestPromptHistory(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	defer func() { _ = historyManager.Close() }()
+	run := func(command, session string) *history.HistoryEntry {
+		entry, err := historyManager.StartCommand(command, "/", session)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return entry
+	}
+
+	run("one", "me")
+	prompt := newPromptHistory(historyManager, "me", time.Now())
+	entries, err := prompt.Entries(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one"}, commandsOf(entries))
+	items, total := prompt.Page(0, 10)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "one", items[0].Command)
+
+	// Commands run since are put in front, of the items too
+	run("two", "me")
+	run("three", "other")
+	previous := entries
+	entries, err = prompt.Entries(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three", "two", "one"}, commandsOf(entries))
+	assert.Equal(t, []string{"one"}, commandsOf(previous), "earlier entries stay valid")
+	items, total = prompt.Page(0, 1)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"three"}, []string{items[0].Command})
+	items, _ = prompt.Page(1, 10)
+	assert.Equal(t, "two", items[0].Command)
+	assert.Len(t, items, 2)
+	items, _ = prompt.Page(3, 10)
+	assert.Empty(t, items)
+
+	// A deleted entry makes history read again
+	two := entries[1]
+	require.NoError(t, historyManager.DeleteEntry(two.ID))
+	entries, err = prompt.Entries(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three", "one"}, commandsOf(entries))
+	_, total = prompt.Page(0, 10)
+	assert.Equal(t, 2, total)
+
+	// As does an entry added that is older than the others, as imported
+	// ones are
+	_, err = historyManager.Import("bash", []history.ImportedCommand{{Command: "old", Time: time.Unix(1000, 0)}})
+	require.NoError(t, err)
+	entries, err = prompt.Entries(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three", "one", "old"}, commandsOf(entries))
+
+	// An isolated session does not see the commands of others that started
+	// after it
+	entries, err = prompt.Entries(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "old"}, commandsOf(entries))
+	run("four", "other")
+	run("five", "me")
+	entries, err = prompt.Entries(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"five", "one", "old"}, commandsOf(entries))
+}
+
+func TestNewestFirst(t *testing.T) {
+	list := newestFirst[int]{list: []int{3, 4}}
+	for i := 2; i > 0; i-- {
+		list.Prepend([]int{i})
+	}
+	before := list.Slice()
+	list.Prepend([]int{-1, 0})
+	assert.Equal(t, []int{-1, 0, 1, 2, 3, 4}, list.Slice())
+	assert.Equal(t, []int{1, 2, 3, 4}, before)
+
+	large := make([]int, 100)
+	list.Prepend(large)
+	assert.Len(t, list.Slice(), 106)
+}
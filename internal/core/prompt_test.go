@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func newTestPromptCache(t *testing.T, generate func(ctx context.Context) string) (*promptCache, *interp.Runner, *atomic.Int32) {
+	dir := t.TempDir()
+	runner, err := interp.New(interp.Dir(dir))
+	require.NoError(t, err)
+	runner.Reset()
+	runner.Vars["HOME"] = expand.Variable{Kind: expand.String, Str: dir}
+
+	var runs atomic.Int32
+	cache := newPromptCache(runner, zap.NewNop())
+	cache.generate = func(ctx context.Context) string {
+		runs.Add(1)
+		return generate(ctx)
+	}
+	return cache, runner, &runs
+}
+
+func TestPromptCacheInvalidation(t *testing.T) {
+	cache, runner, runs := newTestPromptCache(t, func(ctx context.Context) string {
+		return "full> "
+	})
+
+	assert.Equal(t, "full> ", cache.Current())
+	assert.Equal(t, int32(1), runs.Load())
+
+	assert.Equal(t, "full> ", cache.Current(), "an up to date prompt is not rendered again")
+	assert.Equal(t, int32(1), runs.Load())
+
+	runner.Dir = t.TempDir()
+	cache.Current()
+	assert.Equal(t, int32(2), runs.Load(), "changing directory renders the prompt again")
+
+	runner.Vars["BISH_LAST_COMMAND_EXIT_CODE"] = expand.Variable{Kind: expand.String, Str: "1"}
+	cache.Current()
+	assert.Equal(t, int32(3), runs.Load(), "a new exit code renders the prompt again")
+
+	runner.Vars["VIRTUAL_ENV"] = expand.Variable{Kind: expand.String, Str: "/venv", Exported: true}
+	cache.Current()
+	assert.Equal(t, int32(4), runs.Load(), "exporting a variable renders the prompt again")
+
+	runner.Vars["scratch"] = expand.Variable{Kind: expand.String, Str: "x"}
+	cache.Current()
+	assert.Equal(t, int32(4), runs.Load(), "variables that are not exported do not matter")
+
+	assert.Equal(t, "full> ", cache.Generate(context.Background()), "the line editor always renders it again")
+	assert.Equal(t, int32(5), runs.Load())
+}
+
+func TestPromptCacheSlowPrompt(t *testing.T) {
+	release := make(chan struct{})
+	cache, runner, runs := newTestPromptCache(t, func(ctx context.Context) string {
+		select {
+		case <-release:
+			return "full> "
+		case <-ctx.Done():
+			return ""
+		}
+	})
+	runner.Vars["BISH_LAST_COMMAND_EXIT_CODE"] = expand.Variable{Kind: expand.String, Str: "2"}
+
+	prompt := cache.Current()
+	assert.Equal(t, "~ \033[31m>\033[0m ", prompt, "the fast prompt shows until the prompt is rendered")
+
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Equal(t, "full> ", cache.Generate(ctx))
+	assert.Equal(t, int32(1), runs.Load(), "the line editor waits for the run in flight")
+	assert.Equal(t, "full> ", cache.Current())
+}
+
+func TestPromptCacheWait(t *testing.T) {
+	cache, _, _ := newTestPromptCache(t, func(ctx context.Context) string {
+		<-ctx.Done()
+		return "late> "
+	})
+
+	assert.Equal(t, "~ > ", cache.Current())
+	cache.Wait()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	assert.Nil(t, cache.pending, "Wait stops the run in flight")
+	assert.False(t, cache.valid, "a stopped run is not cached")
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/focus"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+)
+
+const (
+	// defaultQuietDuration is how long #!quiet without a duration lasts.
+	defaultQuietDuration = time.Hour
+	// maxQuietDuration is the longest quiet mode that can be started at once.
+	maxQuietDuration = 24 * time.Hour
+)
+
+// quiet reports whether quiet mode is on at now.
+func (s *ShellState) quiet(now time.Time) bool {
+	return now.Before(s.QuietUntil)
+}
+
+// aiPaused reports whether LLM calls are paused by quiet mode at now.
+func (s *ShellState) aiPaused(now time.Time) bool {
+	return s.QuietNoAI && s.quiet(now)
+}
+
+// handleQuietControl implements #!quiet. It starts quiet mode for the given
+// duration (an hour by default), "--no-ai" also pauses LLM calls and "off"
+// ends it. Without arguments during quiet mode it shows the time left.
+func handleQuietControl(args string, state *ShellState, now time.Time) {
+	switch args {
+	case "off", "end":
+		if !state.quiet(now) {
+			printQuietMessage("Quiet mode is not on.")
+			return
+		}
+		endQuiet(state)
+		printQuietMessage("Quiet mode ended.")
+		return
+	case "":
+		if state.quiet(now) {
+			printQuietMessage(fmt.Sprintf("Quiet for another %s. Use #!quiet off to end it.", focus.FormatElapsed(state.QuietUntil.Sub(now))))
+			return
+		}
+	}
+
+	duration, noAI, err := parseQuietArgs(args)
+	if err != nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: "+err.Error()+"\nUsage: #!quiet [duration] [--no-ai] | #!quiet off\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	state.QuietUntil = now.Add(duration)
+	state.QuietNoAI = noAI
+	message := fmt.Sprintf("Quiet for %s: idle summaries, coach tips and hints are hidden", focus.FormatElapsed(duration))
+	if noAI {
+		message += " and AI is paused"
+	}
+	printQuietMessage(message + ". Use #!quiet off to end it early.")
+}
+
+// checkQuietExpired ends quiet mode once its time is up, letting the user know.
+func checkQuietExpired(state *ShellState, now time.Time) {
+	if state.QuietUntil.IsZero() || state.quiet(now) {
+		return
+	}
+	endQuiet(state)
+	printQuietMessage("Quiet mode is over.")
+}
+
+func endQuiet(state *ShellState) {
+	state.QuietUntil = time.Time{}
+	state.QuietNoAI = false
+}
+
+// parseQuietArgs parses the arguments of #!quiet: an optional duration such as
+// "45m", "1h30m" or a number of minutes, and an optional --no-ai flag.
+func parseQuietArgs(args string) (time.Duration, bool, error) {
+	duration, noAI := defaultQuietDuration, false
+	for _, arg := range strings.Fields(args) {
+		if arg == "--no-ai" {
+			noAI = true
+			continue
+		}
+		parsed, err := time.ParseDuration(arg)
+		if err != nil {
+			minutes, convErr := strconv.Atoi(arg)
+			if convErr != nil {
+				return 0, false, fmt.Errorf("invalid duration %q", arg)
+			}
+			parsed = time.Duration(minutes) * time.Minute
+		}
+		if parsed < time.Minute || parsed > maxQuietDuration {
+			return 0, false, fmt.Errorf("duration must be between 1m and %s", focus.FormatElapsed(maxQuietDuration))
+		}
+		duration = parsed
+	}
+	return duration, noAI, nil
+}
+
+func printQuietMessage(message string) {
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuietArgs(t *testing.T) {
+	tests := []struct {
+		args     string
+		duration time.Duration
+		noAI     bool
+		wantErr  bool
+	}{
+		{args: "", duration: time.Hour},
+		{args: "45m", duration: 45 * time.Minute},
+		{args: "1h30m --no-ai", duration: 90 * time.Minute, noAI: true},
+		{args: "--no-ai 20", duration: 20 * time.Minute, noAI: true},
+		{args: "soon", wantErr: true},
+		{args: "30s", wantErr: true},
+		{args: "48h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			duration, noAI, err := parseQuietArgs(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.duration, duration)
+			assert.Equal(t, tt.noAI, noAI)
+		})
+	}
+}
+
+func TestHandleQuietControl(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	state := &ShellState{}
+
+	handleQuietControl("45m --no-ai", state, now)
+	assert.Equal(t, now.Add(45*time.Minute), state.QuietUntil)
+	assert.True(t, state.quiet(now))
+	assert.True(t, state.aiPaused(now))
+	assert.False(t, state.quiet(now.Add(45*time.Minute)))
+
+	// Checking shows the time left without changing anything
+	handleQuietControl("", state, now.Add(10*time.Minute))
+	assert.Equal(t, now.Add(45*time.Minute), state.QuietUntil)
+
+	handleQuietControl("off", state, now.Add(10*time.Minute))
+	assert.False(t, state.quiet(now))
+	assert.False(t, state.QuietNoAI)
+
+	handleQuietControl("", state, now)
+	assert.Equal(t, now.Add(defaultQuietDuration), state.QuietUntil)
+	assert.False(t, state.aiPaused(now))
+
+	// An invalid duration leaves the current quiet mode alone
+	handleQuietControl("later", state, now)
+	assert.Equal(t, now.Add(defaultQuietDuration), state.QuietUntil)
+
+	checkQuietExpired(state, now.Add(30*time.Minute))
+	assert.False(t, state.QuietUntil.IsZero())
+	checkQuietExpired(state, now.Add(2*time.Hour))
+	assert.True(t, state.QuietUntil.IsZero())
+}
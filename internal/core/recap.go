@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/recap"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// buildRecap returns the recap of the last session in the project of the
+// current directory, or nil if there is none.
+func buildRecap(runner *interp.Runner, historyManager *history.HistoryManager, todoStore *todo.Store, sessionID string, logger *zap.Logger) *recap.Recap {
+	project := todo.ProjectFor(environment.GetPwd(runner))
+	r, err := recap.Build(historyManager, todoStore, project, sessionID)
+	if err != nil {
+		logger.Warn("error building recap", zap.String("project", project), zap.Error(err))
+		return nil
+	}
+	return r
+}
+
+// handleRecapControl implements #!recap, which asks the slow model to
+// summarize the last session in the current project.
+func handleRecapControl(ctx context.Context, runner *interp.Runner, historyManager *history.HistoryManager, todoStore *todo.Store, sessionID string, logger *zap.Logger) {
+	r := buildRecap(runner, historyManager, todoStore, sessionID, logger)
+	if r == nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: No earlier session in this project.\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Recapping the last session in "+r.Project+"...\n") + gline.RESET_CURSOR_COLUMN)
+	summary, err := recap.Enrich(ctx, runner, r, logger)
+	if err != nil {
+		logger.Warn("error enriching recap", zap.Error(err))
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not summarize the last session: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+summary+"\n") + gline.RESET_CURSOR_COLUMN)
+}
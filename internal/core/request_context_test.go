@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/httpreq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedRequestContext(t *testing.T) {
+	original := httpreq.DefaultHistory
+	t.Cleanup(func() { httpreq.DefaultHistory = original })
+	httpreq.DefaultHistory = httpreq.NewHistory(5)
+
+	assert.Equal(t, "", failedRequestContext("req https://example.com"))
+
+	httpreq.DefaultHistory.Add(httpreq.Exchange{
+		Request:    httpreq.Request{Method: "GET", URL: "https://example.com/missing"},
+		StatusCode: 404,
+		Status:     "404 Not Found",
+	})
+	assert.Contains(t, failedRequestContext("req https://example.com/missing"), "404 Not Found")
+	assert.Equal(t, "", failedRequestContext("curl https://example.com/missing"))
+	assert.Equal(t, "", failedRequestContext(""))
+}
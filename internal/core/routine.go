@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/coach"
+	"github.com/robottwo/bishop/internal/dotfiles"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/routines"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// routineSection is the managed section of ~/.bishrc that saved routines are
+// appended to.
+const routineSection = "functions"
+
+// handleRoutineControl implements #!routine, which turns the sequence of
+// commands the coach last noticed being repeated into a shell function. The
+// fast model names it unless AI is paused, and once confirmed it is appended
+// to ~/.bishrc and defined in the running shell along with its completion.
+func handleRoutineControl(ctx context.Context, runner *interp.Runner, coachManager *coach.CoachManager, aiPaused bool, logger *zap.Logger) {
+	if coachManager == nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Coach not initialized\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	r, ok := coachManager.PendingRoutine()
+	if !ok {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: No repeated sequence of commands noticed yet.\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	proposal := routines.DefaultProposal(r)
+	if !aiPaused {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Naming the function...\n") + gline.RESET_CURSOR_COLUMN)
+		if p, err := routines.Propose(ctx, runner, r, logger); err != nil {
+			logger.Warn("error naming routine function", zap.Error(err))
+		} else {
+			proposal = p
+		}
+	}
+	proposal = proposal.Sanitize(r, func(name string) bool {
+		if runner.Funcs[name] != nil {
+			return true
+		}
+		_, err := exec.LookPath(name)
+		return err == nil
+	})
+	snippet := routines.Function(r, proposal)
+
+	rcPath := filepath.Join(environment.GetHomeDir(runner), ".bishrc")
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("\n"+snippet+"\nAdd this function to "+rcPath+"? [y/N] ") + gline.RESET_CURSOR_COLUMN)
+	char, err := readSingleKey(logger)
+	if err != nil {
+		logger.Error("failed to read key", zap.Error(err))
+		return
+	}
+	fmt.Println()
+	if char != 'y' && char != 'Y' {
+		return
+	}
+
+	if err := appendRoutine(rcPath, snippet); err != nil {
+		logger.Warn("error saving routine function", zap.String("path", rcPath), zap.Error(err))
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not save the function: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	coachManager.ClearPendingRoutine()
+	if err := bash.RunBashScriptFromReader(ctx, runner, strings.NewReader(snippet), "routine"); err != nil {
+		logger.Warn("error defining routine function", zap.Error(err))
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Saved "+proposal.Name+" to "+rcPath+".\n") + gline.RESET_CURSOR_COLUMN)
+}
+
+// appendRoutine adds snippet to the functions section of the rc file at path,
+// creating the file or the section as needed.
+func appendRoutine(path, snippet string) error {
+	_, err := dotfiles.AppendToBlock(path, routineSection, snippet)
+	return err
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/schedule"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/utils"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// runScheduleFlow drafts a crontab entry or systemd timer from request, lets
+// the user review it in a form and installs it once confirmed.
+func runScheduleFlow(ctx context.Context, request string, runner *interp.Runner, logger *zap.Logger) {
+	spec := schedule.Spec{Description: request, Target: schedule.TargetCron}
+	if request != "" {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Drafting schedule...\n") + gline.RESET_CURSOR_COLUMN)
+		llmClient, modelConfig := utils.GetLLMClient(runner, utils.FastModel)
+		drafted, err := schedule.Draft(ctx, llmClient, modelConfig, request)
+		if err != nil {
+			logger.Warn("error drafting schedule", zap.Error(err))
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not draft the schedule, please fill in the form: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		} else {
+			spec = drafted
+		}
+	}
+
+	unitDir := schedule.DefaultUnitDir()
+	confirmed, ok, err := schedule.RunForm(spec, unitDir)
+	if err != nil {
+		logger.Error("error running schedule form", zap.Error(err))
+		return
+	}
+	if !ok {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Schedule cancelled\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	message, err := schedule.Install(ctx, confirmed, unitDir)
+	if err != nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
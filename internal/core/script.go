@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/scriptgen"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/utils"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// runScriptFlow has the agent write a script and its test for request, lets
+// the user preview them and pick where they go, and writes them once
+// confirmed.
+func runScriptFlow(ctx context.Context, request string, runner *interp.Runner, logger *zap.Logger) {
+	if request == "" {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Describe the script, e.g. #/script archive the logs older than a week\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Writing the script...\n") + gline.RESET_CURSOR_COLUMN)
+	llmClient, modelConfig := utils.GetLLMClient(runner, utils.SlowModel)
+	script, lint, err := scriptgen.Draft(ctx, llmClient, modelConfig, request)
+	if err != nil {
+		logger.Warn("error drafting script", zap.Error(err))
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not write the script: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	pwd := environment.GetPwd(runner)
+	path, ok, err := scriptgen.RunForm(script, lint, pwd)
+	if err != nil {
+		logger.Error("error running script preview", zap.Error(err))
+		return
+	}
+	if !ok {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Script cancelled\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		path = filepath.Join(environment.GetHomeDir(runner), rest)
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(pwd, path)
+	}
+	message, err := scriptgen.Write(script, path)
+	if err != nil {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
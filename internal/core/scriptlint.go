@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/scriptlint"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// scriptLintTimeout bounds how long linting may hold up a command.
+const scriptLintTimeout = 3 * time.Second
+
+// lintScripts prints what linting finds in the local scripts that line
+// sources or runs, each once until it changes.
+func lintScripts(ctx context.Context, line string, runner *interp.Runner, logger *zap.Logger) {
+	if !environment.GetScriptLint(runner) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, scriptLintTimeout)
+	defer cancel()
+
+	pwd := environment.GetPwd(runner)
+	for _, target := range scriptlint.Scripts(line, pwd) {
+		findings, linter, ok := scriptlint.DefaultLinter.Check(ctx, target)
+		if !ok {
+			continue
+		}
+		logger.Debug("script lint findings", zap.String("path", target.Path), zap.Int("count", len(findings)))
+		path := target.Path
+		if rel, err := filepath.Rel(pwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+scriptlint.Report(path, findings, linter)) + gline.RESET_CURSOR_COLUMN)
+	}
+}
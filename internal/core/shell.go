@@ -2,35 +2,65 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"os/signal"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/robottwo/bishop/internal/abbr"
 	"github.com/robottwo/bishop/internal/agent"
 	"github.com/robottwo/bishop/internal/analytics"
+	"github.com/robottwo/bishop/internal/arghistory"
 	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/calc"
+	"github.com/robottwo/bishop/internal/captures"
+	"github.com/robottwo/bishop/internal/ci"
+	"github.com/robottwo/bishop/internal/cmdchain"
 	"github.com/robottwo/bishop/internal/coach"
 	"github.com/robottwo/bishop/internal/completion"
 	"github.com/robottwo/bishop/internal/config"
+	"github.com/robottwo/bishop/internal/containers"
+	"github.com/robottwo/bishop/internal/devenv"
 	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/flaghabits"
+	"github.com/robottwo/bishop/internal/focus"
+	"github.com/robottwo/bishop/internal/git"
 	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/httpreq"
+	"github.com/robottwo/bishop/internal/i18n"
 	"github.com/robottwo/bishop/internal/idle"
+	"github.com/robottwo/bishop/internal/jobs"
+	"github.com/robottwo/bishop/internal/journal"
+	"github.com/robottwo/bishop/internal/keybindings"
+	"github.com/robottwo/bishop/internal/later"
+	"github.com/robottwo/bishop/internal/nextcmd"
+	"github.com/robottwo/bishop/internal/outputfmt"
+	"github.com/robottwo/bishop/internal/pipebuild"
+	"github.com/robottwo/bishop/internal/ports"
 	"github.com/robottwo/bishop/internal/predict"
+	"github.com/robottwo/bishop/internal/progpreview"
 	"github.com/robottwo/bishop/internal/rag"
 	"github.com/robottwo/bishop/internal/rag/retrievers"
+	"github.com/robottwo/bishop/internal/regextest"
+	"github.com/robottwo/bishop/internal/statusline"
 	"github.com/robottwo/bishop/internal/styles"
 	"github.com/robottwo/bishop/internal/subagent"
+	"github.com/robottwo/bishop/internal/termreset"
 	"github.com/robottwo/bishop/internal/termtitle"
+	"github.com/robottwo/bishop/internal/ticket"
+	"github.com/robottwo/bishop/internal/timer"
+	"github.com/robottwo/bishop/internal/todo"
 	"github.com/robottwo/bishop/internal/wizard"
+	"github.com/robottwo/bishop/internal/wsl"
 	"github.com/robottwo/bishop/pkg/gline"
 	"github.com/robottwo/bishop/pkg/shellinput"
 	"go.uber.org/zap"
 	"golang.org/x/term"
+	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
 )
@@ -42,11 +72,24 @@ func RunInteractiveShell(
 	analyticsManager *analytics.AnalyticsManager,
 	completionManager *completion.CompletionManager,
 	coachManager *coach.CoachManager,
+	todoStore *todo.Store,
 	logger *zap.Logger,
 	stderrCapturer *StderrCapturer,
 ) error {
 	// Generate session ID
 	sessionID := uuid.New().String()
+	sessionStart := time.Now()
+	promptEntries := newPromptHistory(historyManager, sessionID, sessionStart)
+	defer removeLastOutput(sessionID)
+	defer captures.DefaultStore.Clear()
+	defer jobs.DefaultTable.HangUp()
+	defer later.DefaultQueue.Stop()
+	timer.DefaultClock.OnEnd = timerAlert(logger)
+	defer timer.DefaultClock.Stop()
+	defer statusline.DefaultProvider.Stop()
+	ci.DefaultWatcher.OnFinish = ciFinished
+	defer ci.DefaultWatcher.Stop()
+	defer ticket.DefaultCache.Stop()
 
 	state := &ShellState{}
 	contextProvider := &rag.ContextProvider{
@@ -57,13 +100,20 @@ func RunInteractiveShell(
 			retrievers.GitStatusContextRetriever{Runner: runner, Logger: logger},
 			retrievers.ConciseHistoryContextRetriever{Runner: runner, Logger: logger, HistoryManager: historyManager},
 			retrievers.VerboseHistoryContextRetriever{Runner: runner, Logger: logger, HistoryManager: historyManager},
+			retrievers.FocusContextRetriever{Runner: runner},
+			retrievers.JournalContextRetriever{Runner: runner},
+			retrievers.DevEnvContextRetriever{Envs: devenv.Current()},
+			retrievers.PackageManagerContextRetriever{Runner: runner},
+			retrievers.RecentFilesContextRetriever{Runner: runner},
 		},
 	}
 	predictor := &predict.PredictRouter{
 		PrefixPredictor:    predict.NewLLMPrefixPredictor(runner, historyManager, logger),
 		NullStatePredictor: predict.NewLLMNullStatePredictor(runner, logger),
 	}
+	historyPredictor := predict.NewHistoryPredictor(historyManager)
 	explainer := predict.NewLLMExplainer(runner, logger)
+	nextCommandRanker := predict.NewLLMNextCommandRanker(runner, logger)
 	agent := agent.NewAgent(runner, historyManager, logger, sessionID)
 
 	// Set up subagent integration
@@ -76,6 +126,14 @@ func RunInteractiveShell(
 	// Set up idle summary generator
 	idleSummaryGenerator := idle.NewSummaryGenerator(runner, historyManager, logger)
 
+	idleSummaryGenerator.SetTodoStore(todoStore)
+
+	// Set up the summarizer for #!focus sessions
+	focusSummarizer := focus.NewSummarizer(runner, historyManager, logger)
+
+	// Set up the summarizer for #!wrapup and the summary on exit
+	sessionSummarizer := journal.NewSummarizer(runner, historyManager, logger)
+
 	// Set up terminal title manager
 	termTitleManager := termtitle.NewManager(runner, logger)
 
@@ -89,56 +147,175 @@ func RunInteractiveShell(
 		}
 	}()
 
-	// Initialize cached prompt before entering the loop
-	cachedPrompt := environment.GetPrompt(context.Background(), runner, logger)
-	logger.Debug("initial prompt cached", zap.String("prompt", cachedPrompt))
+	// prompts renders the prompt of each line, again only once the
+	// directory, the exit code or the environment changed
+	prompts := newPromptCache(runner, logger)
+
+	// pendingInput pre-fills the next prompt, e.g. with a corrected command
+	var pendingInput string
+
+	// startupRecap is shown in the assistant box instead of the coach on the
+	// first prompt, to resume where the last session in this project left off
+	var startupRecap string
+	if environment.GetStartupRecap(runner) {
+		if r := buildRecap(runner, historyManager, todoStore, sessionID, logger); r != nil {
+			startupRecap = r.String(time.Now())
+		}
+	}
+
+	startHistorySync(runner, historyManager, logger)
+	startHistoryMaintenance(ctx, historyManager, logger)
+
+	// Config file errors found at startup are listed once, right above the
+	// first prompt, so that they do not scroll away
+	printTriagePanel()
 
+shellLoop:
 	for {
+		// Report the background jobs that finished or stopped, as bash does
+		jobs.DefaultTable.Notify(os.Stderr)
+		// and those queued with later, the next of which can start now that
+		// the command line has finished
+		later.DefaultQueue.Notify(os.Stderr)
+		later.DefaultQueue.Resume(prepareQueued(runner, historyManager, sessionID, logger))
+
+		// Report the timers that ended, and keep quiet mode on while one
+		// started with --quiet runs
+		reportTimers(timer.DefaultClock, runner, coachManager, sessionID)
+		syncTimerQuiet(timer.DefaultClock, state, time.Now())
+		// and the CI runs of the branch that finished
+		reportCIRuns(ci.DefaultWatcher)
+		checkQuietExpired(state, time.Now())
+		quiet, aiPaused := state.quiet(time.Now()), state.aiPaused(time.Now())
+		redactText := redactFunc(runner)
+
 		ragContext := contextProvider.GetContext()
 		logger.Debug("context updated", zap.Any("context", ragContext))
 
 		predictor.UpdateContext(ragContext)
 		explainer.UpdateContext(ragContext)
+		nextCommandRanker.UpdateContext(ragContext)
 		agent.UpdateContext(ragContext)
 
-		// Fetch recent entries for standard history (Up/Down) - scoped to current directory for now, or generally recent
-		// Note: GetRecentEntries reverses the list (oldest first) so standard history navigation works correctly
+		// Fetch recent entries for standard history (Up/Down), in the scope of
+		// BISH_HISTORY_SCOPE, the current directory by default
 		historySize := environment.GetHistorySize(runner, logger)
-		historyEntries, err := historyManager.GetRecentEntries(environment.GetPwd(runner), historySize)
+		historySharing := environment.GetHistorySharing(runner, logger)
+		historyIgnore, err := history.ParseIgnorePatterns(environment.GetHistIgnore(runner))
 		if err != nil {
-			logger.Warn("error getting recent history entries", zap.Error(err))
-			historyEntries = []history.HistoryEntry{}
+			logger.Warn("error parsing BISH_HISTIGNORE", zap.Error(err))
 		}
-
-		historyCommands := make([]string, len(historyEntries))
-		for i := len(historyEntries) - 1; i >= 0; i-- {
-			historyCommands[len(historyEntries)-1-i] = historyEntries[i].Command
+		historyManager.SetIgnorePatterns(historyIgnore)
+		historyManager.SetScrubSecrets(environment.GetHistoryRedact(runner))
+		historyScope := historyScopes[environment.GetHistoryScope(runner, logger)]
+		loadHistory := func(scope shellinput.HistoryFilterMode) []string {
+			commands, err := scopedHistory(historyManager, scope, environment.GetPwd(runner), sessionID, sessionStart,
+				historySharing == environment.HistorySharingIsolated, historySize)
+			if err != nil {
+				logger.Warn("error getting recent history entries", zap.Error(err))
+				return []string{}
+			}
+			return commands
 		}
+		historyCommands := loadHistory(historyScope)
 
-		// Fetch all entries for rich search (Ctrl+R)
-		allHistoryEntries, err := historyManager.GetAllEntries()
+		// Fetch all entries for rich search (Ctrl+R), only those recorded
+		// since the last prompt once read
+		allHistoryEntries, err := promptEntries.Entries(historySharing == environment.HistorySharingIsolated)
 		if err != nil {
 			logger.Warn("error getting all history entries", zap.Error(err))
 			allHistoryEntries = []history.HistoryEntry{}
 		}
 
-		richHistory := make([]shellinput.HistoryItem, len(allHistoryEntries))
-		for i, entry := range allHistoryEntries {
-			richHistory[i] = shellinput.HistoryItem{
-				Command:   entry.Command,
-				Directory: entry.Directory,
-				Timestamp: entry.CreatedAt,
-				SessionID: entry.SessionID,
-			}
-		}
-
 		// Read input
 		options := gline.NewOptions()
 		options.AssistantHeight = environment.GetAssistantHeight(runner, logger)
+		options.AutoPair = environment.GetAutoPair(runner)
+		options.PredictionCandidates = environment.GetPredictionCandidates(runner, logger)
+		options.LocalPredictor = historyPredictor
+		if wsl.Detected() {
+			options.PasteFilter = wsl.ConvertPastedPath
+		}
+		options.PathStyle = environment.GetPathStyle(runner, logger)
+		options.RenderProfile, _ = gline.ParseRenderProfile(environment.GetRenderProfile(runner, logger))
 		options.CompletionProvider = completionProvider
-		options.RichHistory = richHistory
+		options.RichHistoryPager = promptEntries.Page
 		options.CurrentDirectory = environment.GetPwd(runner)
 		options.CurrentSessionID = sessionID
+		options.HistoryScope = historyScope
+		options.ScopedHistory = func(scope shellinput.HistoryFilterMode) []string {
+			// The scope picked with Alt+H lasts for the session
+			config.SetSessionOverride("BISH_HISTORY_SCOPE", historyScopeName(scope))
+			return loadHistory(scope)
+		}
+		options.Focus = environment.GetFocus(runner)
+		if quiet {
+			options.QuietUntil = state.QuietUntil
+		}
+		if _, ok := timer.DefaultClock.Current(); ok {
+			options.Timer = timerStatus(timer.DefaultClock)
+		}
+		options.OutputToggle = outputfmt.DefaultRecorder.Toggle
+		options.PipelineBuilder = func(line string) (string, bool, error) {
+			return pipebuild.Run(line, pipebuild.ShellRunner(environment.GetPwd(runner), runner.Env))
+		}
+		options.RegexTester = func(line string, cursor int) (string, bool, error) {
+			return regextest.Run(line, cursor, pipebuild.ShellRunner(environment.GetPwd(runner), runner.Env))
+		}
+		previewer := progpreview.New(pipebuild.ShellRunner(environment.GetPwd(runner), runner.Env))
+		options.ProgramPreview = previewer.Preview
+		if !aiPaused {
+			options.ProgramWriter = func(ctx context.Context, line string, cursor int) (string, error) {
+				return previewer.Propose(ctx, runner, line, cursor)
+			}
+		}
+		if environment.GetPresentationMode(runner) {
+			options.Redact = redactText
+		}
+		if environment.GetFlagLearning(runner) {
+			options.UsualFlags = func(line string) (string, bool) {
+				return flaghabits.Apply(line, func(name string) (flaghabits.Habit, bool) {
+					return flaghabits.Lookup(historyManager, name)
+				})
+			}
+		}
+		if abbreviations, err := abbr.Load(abbr.DefaultPath()); err != nil {
+			logger.Warn("error loading abbreviations", zap.Error(err))
+		} else if len(abbreviations) > 0 {
+			options.Abbreviations = abbreviations.Lookup
+		}
+		if keyMap, err := keybindings.Load(keybindings.DefaultPath()); err != nil {
+			logger.Warn("error loading key bindings", zap.Error(err))
+		} else {
+			options.KeyMap = &keyMap
+		}
+		options.StatusSegments = statusSegments(runner, logger)
+		options.ArgHistory = func(line string) []string {
+			return arghistory.Lookup(historyManager, line)
+		}
+		situation := nextcmd.Situation{
+			Directory: options.CurrentDirectory,
+			Last:      nextcmd.LastCommand(allHistoryEntries, sessionID),
+		}
+		options.NextCommands = func() []string {
+			situation.Now = time.Now()
+			return nextcmd.Rank(allHistoryEntries, situation, nextcmd.MaxItems)
+		}
+		if environment.GetNextCommandRerank(runner) && !aiPaused {
+			options.RerankNextCommands = nextCommandRanker.Rerank
+		}
+		if environment.GetFollowUpSuggestions(runner) {
+			if followUp, ok := cmdchain.Suggest(allHistoryEntries, cmdchain.Recent(allHistoryEntries, sessionID, 2)); ok {
+				options.FollowUp = followUp.Command
+				options.FollowUpNote = followUp.Note()
+			}
+		}
+		options.InitialValue = pendingInput
+		pendingInput = ""
+		if historySharing == environment.HistorySharingLive {
+			options.HistoryPoller = newHistoryPoller(historyManager, sessionID, latestHistoryID(allHistoryEntries), logger)
+			options.HistoryPollInterval = historyPollInterval
+		}
 
 		// Populate context for border status
 		options.User = environment.GetUser(runner)
@@ -147,18 +324,18 @@ func RunInteractiveShell(
 		// Configure idle summary
 		idleTimeout := environment.GetIdleSummaryTimeout(runner, logger)
 		options.IdleSummaryTimeout = idleTimeout
-		if idleTimeout > 0 {
+		if idleTimeout > 0 && !quiet {
 			options.IdleSummaryGenerator = idleSummaryGenerator.GenerateSummary
 		}
 
 		// Configure async prompt generation (follows IdleSummaryGenerator pattern above)
-		options.PromptGenerator = func(ctx context.Context) string {
-			return environment.GetPrompt(ctx, runner, logger)
-		}
+		options.PromptGenerator = prompts.Generate
 
 		// Get coach startup content for the Assistant Box
 		var coachContent string
-		if coachManager != nil {
+		if startupRecap != "" {
+			coachContent, startupRecap = startupRecap, ""
+		} else if coachManager != nil && !quiet {
 			if content := coachManager.GetDisplayContent(); content != nil {
 				coachContent = content.Icon + " " + content.Title
 				if content.Content != "" {
@@ -170,7 +347,24 @@ func RunInteractiveShell(
 			}
 		}
 
-		line, newPrompt, err := gline.Gline(cachedPrompt, historyCommands, coachContent, predictor, explainer, analyticsManager, logger, options)
+		// Predictions and explanations call the LLM, so they are left out
+		// while #!quiet --no-ai is on
+		var linePredictor gline.Predictor = predictor
+		var lineExplainer gline.Explainer = explainer
+		if aiPaused {
+			linePredictor, lineExplainer = nil, nil
+		}
+
+		// Whatever the last program left on, such as mouse reporting or
+		// colors, must not end up in the prompt
+		if environment.GetTerminalRecovery(runner) {
+			termreset.Sanitize(os.Stdout)
+		}
+
+		line, _, err := gline.Gline(prompts.Current(), historyCommands, coachContent, linePredictor, lineExplainer, analyticsManager, logger, options)
+
+		// The prompt must not run in the interpreter along with the line
+		prompts.Wait()
 
 		logger.Debug("received command", zap.String("line", line))
 
@@ -178,17 +372,12 @@ func RunInteractiveShell(
 			if err == gline.ErrInterrupted {
 				// User pressed Ctrl+C, restart loop with fresh prompt
 				logger.Debug("input interrupted by user")
-				// Store the returned prompt for next iteration
-				cachedPrompt = newPrompt
 				continue
 			}
 			logger.Error("error reading input through gline", zap.Error(err))
 			return err
 		}
 
-		// Store the returned prompt for next iteration
-		cachedPrompt = newPrompt
-
 		// Handle agent chat and macros
 		if strings.HasPrefix(line, "#") {
 			chatMessage := strings.TrimSpace(line[1:])
@@ -223,7 +412,7 @@ func RunInteractiveShell(
 					agent.PrintTokenStats()
 					continue
 				case "setup":
-					if err := wizard.RunWizard(runner); err != nil {
+					if err := wizard.RunWizard(runner, historyManager); err != nil {
 						logger.Error("error running setup wizard", zap.Error(err))
 						fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Error running setup: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
 					}
@@ -239,6 +428,56 @@ func RunInteractiveShell(
 					environment.SyncVariablesToEnv(runner)
 					continue
 				default:
+					if command, args, _ := strings.Cut(control, " "); command == "focus" {
+						handleFocusControl(ctx, strings.TrimSpace(args), state, runner, historyManager, focusSummarizer, logger)
+						continue
+					} else if command == "quiet" {
+						handleQuietControl(strings.TrimSpace(args), state, time.Now())
+						continue
+					} else if command == "recap" {
+						if aiPaused {
+							printQuietMessage("AI is paused; use #!quiet off to get a recap.")
+							continue
+						}
+						handleRecapControl(ctx, runner, historyManager, todoStore, sessionID, logger)
+						continue
+					} else if command == "wrapup" {
+						if aiPaused {
+							printQuietMessage("AI is paused; use #!quiet off to wrap up the session.")
+							continue
+						}
+						wrapUpSession(ctx, state, sessionID, sessionStart, runner, sessionSummarizer, logger, false)
+						continue
+					} else if command == "routine" {
+						handleRoutineControl(ctx, runner, coachManager, aiPaused, logger)
+						continue
+					} else if command == "present" {
+						handlePresentControl(strings.TrimSpace(args), runner)
+						continue
+					} else if command == "triage" {
+						message := handleTriageControl(strings.TrimSpace(args), logger)
+						if message == "" {
+							continue
+						}
+						// Fix N asks the agent below
+						chatMessage = message
+						break
+					} else if command == "enter" {
+						handleEnterControl(ctx, strings.TrimSpace(args), runner, historyManager, sessionID, logger)
+						continue
+					} else if command == "fleet" {
+						if aiPaused {
+							printQuietMessage("AI is paused; use #!quiet off to diagnose the fleet run.")
+							continue
+						}
+						message := handleFleetControl()
+						if message == "" {
+							continue
+						}
+						chatMessage = message
+						break
+					}
+
 					// Handle coach command with subcommands
 					if strings.HasPrefix(control, "coach") {
 						if coachManager == nil {
@@ -276,6 +515,23 @@ func RunInteractiveShell(
 				}
 			}
 
+			// Everything below talks to the agent
+			if aiPaused {
+				printQuietMessage(fmt.Sprintf("AI is paused for another %s. Use #!quiet off to resume it.", focus.FormatElapsed(time.Until(state.QuietUntil))))
+				continue
+			}
+
+			// Handle #? ci, which asks why the CI run of the branch failed
+			if args, ok := strings.CutPrefix(chatMessage, "?"); ok && strings.TrimSpace(args) == "ci" {
+				prompt, err := ciFailurePrompt(ctx, runner)
+				if err != nil {
+					printQuietMessage(err.Error() + ".")
+					continue
+				}
+				// Continue with the regular agent chat below
+				chatMessage = prompt
+			}
+
 			// Handle magic fix
 			if chatMessage == "?" {
 				if state.LastExitCode == 0 {
@@ -284,6 +540,12 @@ func RunInteractiveShell(
 				}
 
 				prompt := fmt.Sprintf("The command `%s` failed with exit code %d.\nThe stderr output was:\n%s\n\nExplain why it failed and suggest a fix. Do not execute the fix yet. Provide the fixed command in a markdown code block.", state.LastCommand, state.LastExitCode, state.LastStderr)
+				if exchangeContext := failedRequestContext(state.LastCommand); exchangeContext != "" {
+					prompt += "\n\nThe command was an HTTP request made with the req builtin. The full exchange was:\n" + exchangeContext
+				}
+				if portContext := addressInUseContext(ctx, state.LastCommand, state.LastStderr); portContext != "" {
+					prompt += "\n\nThe port the command wanted is already taken:\n" + portContext
+				}
 
 				chatChannel, err := agent.Chat(prompt)
 				if err != nil {
@@ -294,7 +556,7 @@ func RunInteractiveShell(
 				var fullResponse strings.Builder
 				for message := range chatChannel {
 					fullResponse.WriteString(message)
-					fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+					fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+redactText(message)+"\n") + gline.RESET_CURSOR_COLUMN)
 				}
 
 				// Display token usage summary
@@ -303,13 +565,9 @@ func RunInteractiveShell(
 				}
 
 				// Extract code block
-				responseStr := fullResponse.String()
-				codeBlockRegex := regexp.MustCompile("(?s)```(?:bash|sh|zsh)?\\s+(.*?)\\s+```")
-				matches := codeBlockRegex.FindAllStringSubmatch(responseStr, -1)
-
-				var fixedCmd string
-				if len(matches) > 0 {
-					fixedCmd = strings.TrimSpace(matches[len(matches)-1][1])
+				fixedCmd := extractFixedCommand(fullResponse.String())
+				if fixedCmd != "" {
+					fixedCmd = correctForUserland(agent, fixedCmd, redactText, logger)
 				}
 
 				if fixedCmd != "" {
@@ -323,7 +581,7 @@ func RunInteractiveShell(
 							promptText = "Run this fix? [Y/n/e/i] "
 						}
 
-						fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("\nCommand: "+fixedCmd+"\n") + gline.RESET_CURSOR_COLUMN)
+						fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("\nCommand: "+redactText(fixedCmd)+"\n") + gline.RESET_CURSOR_COLUMN)
 						fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(promptText) + gline.RESET_CURSOR_COLUMN)
 
 						// Read single key in raw mode (terminal state restored via defer)
@@ -341,7 +599,7 @@ func RunInteractiveShell(
 
 						// Handle 'e' - edit in external editor
 						if char == 'e' || char == 'E' {
-							editedCmd, err := openInEditor(fixedCmd)
+							editedCmd, err := gline.EditInEditor(fixedCmd)
 							if err != nil {
 								logger.Error("failed to open editor", zap.Error(err))
 								fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Failed to open editor: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
@@ -362,8 +620,15 @@ func RunInteractiveShell(
 							// Create options with the fix command pre-filled
 							editOptions := gline.NewOptions()
 							editOptions.AssistantHeight = environment.GetAssistantHeight(runner, logger)
+							editOptions.AutoPair = environment.GetAutoPair(runner)
+							if wsl.Detected() {
+								editOptions.PasteFilter = wsl.ConvertPastedPath
+							}
+							editOptions.PathStyle = environment.GetPathStyle(runner, logger)
 							editOptions.CompletionProvider = completionProvider
-							editOptions.RichHistory = richHistory
+							editOptions.Abbreviations = options.Abbreviations
+							editOptions.KeyMap = options.KeyMap
+							editOptions.RichHistoryPager = promptEntries.Page
 							editOptions.CurrentDirectory = environment.GetPwd(runner)
 							editOptions.CurrentSessionID = sessionID
 							editOptions.User = environment.GetUser(runner)
@@ -395,7 +660,7 @@ func RunInteractiveShell(
 							environment.SyncVariablesToEnv(runner)
 							if shouldExit {
 								logger.Debug("exiting...")
-								return nil
+								break shellLoop
 							}
 							break magicFixLoop
 						}
@@ -421,7 +686,7 @@ func RunInteractiveShell(
 
 							if shouldExit {
 								logger.Debug("exiting...")
-								return nil
+								break shellLoop
 							}
 							break magicFixLoop
 						}
@@ -433,11 +698,59 @@ func RunInteractiveShell(
 				continue
 			}
 
+			// Handle ##!, which reruns the last command while the agent
+			// watches its output and then summarizes it
+			if instructions, ok := parseObserveCommand(chatMessage); ok {
+				command := commandToObserve(state, historyManager)
+				if command == "" {
+					fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: No previous command to observe.\n") + gline.RESET_CURSOR_COLUMN)
+					continue
+				}
+				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Re-running under observation: "+command+"\n") + gline.RESET_CURSOR_COLUMN)
+
+				output := &tailBuffer{limit: observedOutputLimit}
+				state.Observer = output
+				shouldExit, err := executeCommand(ctx, command, historyManager, coachManager, runner, logger, state, stderrCapturer, sessionID)
+				state.Observer = nil
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
+				}
+				termTitleManager.RecordCommand(command)
+				environment.SyncVariablesToEnv(runner)
+				if shouldExit {
+					logger.Debug("exiting...")
+					break shellLoop
+				}
+
+				// Continue with the regular agent chat below
+				chatMessage = observationPrompt(command, state.LastExitCode, output, instructions)
+			}
+
 			// Handle macros
 			if strings.HasPrefix(chatMessage, "/") {
 				macroName := strings.TrimSpace(strings.TrimPrefix(chatMessage, "/"))
+
+				// #/schedule is a built-in flow rather than a user-defined macro
+				if command, request, _ := strings.Cut(macroName, " "); command == "schedule" {
+					runScheduleFlow(ctx, strings.TrimSpace(request), runner, logger)
+					continue
+				}
+				// as is #/script
+				if command, request, _ := strings.Cut(macroName, " "); command == "script" {
+					runScriptFlow(ctx, strings.TrimSpace(request), runner, logger)
+					continue
+				}
+
+				// #/ticket asks the agent about the ticket of the branch
 				macros := environment.GetAgentMacros(runner, logger)
-				if message, ok := macros[macroName]; ok {
+				if command, ref, _ := strings.Cut(macroName, " "); command == "ticket" {
+					prompt, err := ticketPrompt(ctx, runner, logger, ref)
+					if err != nil {
+						printQuietMessage(err.Error() + ".")
+						continue
+					}
+					chatMessage = prompt
+				} else if message, ok := macros[macroName]; ok {
 					chatMessage = message
 				} else {
 					logger.Warn("macro not found", zap.String("macro", macroName))
@@ -446,6 +759,9 @@ func RunInteractiveShell(
 				}
 			}
 
+			// Show the agent the output of captures mentioned as @name
+			chatMessage = captures.Attach(chatMessage, captures.DefaultStore)
+
 			// Check for subagent commands first
 			handled, chatChannel, subagent, err := subagentIntegration.HandleCommand(chatMessage)
 			if handled {
@@ -461,7 +777,7 @@ func RunInteractiveShell(
 				// Handle subagent response with subagent identification
 				for message := range chatChannel {
 					prefix := fmt.Sprintf("bish [%s]: ", subagent.Name)
-					fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(prefix+message+"\n") + gline.RESET_CURSOR_COLUMN)
+					fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(prefix+redactText(message)+"\n") + gline.RESET_CURSOR_COLUMN)
 				}
 				continue
 			}
@@ -473,8 +789,10 @@ func RunInteractiveShell(
 				continue
 			}
 
+			var fullResponse strings.Builder
 			for message := range chatChannel {
-				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+				fullResponse.WriteString(message + "\n")
+				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+redactText(message)+"\n") + gline.RESET_CURSOR_COLUMN)
 			}
 
 			// Display token usage summary
@@ -482,6 +800,9 @@ func RunInteractiveShell(
 				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(tokenSummary+"\n") + gline.RESET_CURSOR_COLUMN)
 			}
 
+			// Offer to keep anything the agent advised doing later as a TODO
+			suggestLaterTodos(fullResponse.String(), todoStore, runner)
+
 			continue
 		}
 
@@ -490,21 +811,61 @@ func RunInteractiveShell(
 			continue
 		}
 
+		// Handle calculator lines such as "= 3*(7+2)" or "= 5GiB in MB"
+		if calc.IsExpression(line) {
+			output, exitCode := evaluateCalculation(ctx, line, historyManager, runner, sessionID)
+			state.LastExitCode = exitCode
+			if exitCode != 0 {
+				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR(output+"\n") + gline.RESET_CURSOR_COLUMN)
+			} else {
+				fmt.Print(gline.RESET_CURSOR_COLUMN + output + "\n" + gline.RESET_CURSOR_COLUMN)
+			}
+			continue
+		}
+
 		// Note: Autocd is now handled by the AutocdExecHandler in the command execution chain
 		// This allows builtins and commands to take precedence naturally
 
+		// Summarize the issues in the scripts the line sources or runs
+		lintScripts(ctx, line, runner, logger)
+
 		// Execute the command
 		shouldExit, err := executeCommand(ctx, line, historyManager, coachManager, runner, logger, state, stderrCapturer, sessionID)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
 		}
 
-		// Show helpful hint when command fails (only once per session)
-		if state.LastExitCode != 0 && !state.FixHintShown {
+		// Offer a rewrite of a command refused for having too many
+		// arguments, or a corrected command when a path it names almost
+		// exists, otherwise show helpful hint when command fails (only once
+		// per session), which a job stopped with Ctrl+Z has not
+		if rewritten, ok := suggestArgLimitRewrite(line); ok {
+			pendingInput = rewritten
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: To pass the arguments through a pipe instead: "+rewritten+" (press Enter to run it)\n") + gline.RESET_CURSOR_COLUMN)
+		} else if corrected, ok := suggestPathCorrection(state, runner, logger); ok {
+			message := "bish: Did you mean: " + corrected + "\n"
+			if environment.GetPathCorrection(runner, logger) == environment.PathCorrectionPrefill {
+				pendingInput = corrected
+				message = "bish: Did you mean: " + corrected + " (press Enter to run it)\n"
+			}
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(message) + gline.RESET_CURSOR_COLUMN)
+		} else if state.LastExitCode != 0 && state.LastExitCode != int(jobs.StoppedStatus) && !state.FixHintShown && !quiet {
 			state.FixHintShown = true
 			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("Tip: Use #? or #!fix to ask the AI to help fix this error\n") + gline.RESET_CURSOR_COLUMN)
 		}
 
+		// Suggest a TODO for a command that keeps failing
+		if suggestion, ok := suggestTodoForFailures(state, todoStore, runner); ok && !quiet {
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(fmt.Sprintf("bish: This command has failed %d times in a row. To come back to it later, run: %s\n", todo.RepeatedFailures, suggestion)) + gline.RESET_CURSOR_COLUMN)
+		}
+
+		// Point out a simpler way to run the pipeline that just succeeded
+		if coachManager != nil && state.LastExitCode == 0 && !quiet && environment.GetPipelineTips(runner) {
+			if tip := coachManager.PipelineTip(line); tip != "" {
+				fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("Coach: "+tip+"\n") + gline.RESET_CURSOR_COLUMN)
+			}
+		}
+
 		// Record command for terminal title updates
 		termTitleManager.RecordCommand(line)
 
@@ -517,6 +878,10 @@ func RunInteractiveShell(
 		}
 	}
 
+	if environment.GetWrapUpOnExit(runner) && !state.aiPaused(time.Now()) {
+		wrapUpSession(ctx, state, sessionID, sessionStart, runner, sessionSummarizer, logger, true)
+	}
+
 	return nil
 }
 
@@ -542,69 +907,24 @@ func readSingleKey(logger *zap.Logger) (byte, error) {
 	return buf[0], nil
 }
 
-// openInEditor opens the given command in an external editor and returns the edited result.
-// It uses $EDITOR, $VISUAL, or falls back to vi/vim/nano.
-func openInEditor(command string) (string, error) {
-	// Determine editor to use
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = os.Getenv("VISUAL")
-	}
-	if editor == "" {
-		// Try common editors
-		for _, e := range []string{"vi", "vim", "nano"} {
-			if _, err := exec.LookPath(e); err == nil {
-				editor = e
-				break
-			}
-		}
-	}
-	if editor == "" {
-		return "", fmt.Errorf("no editor found (set $EDITOR)")
-	}
-
-	// Create temp file with the command
-	tmpFile, err := os.CreateTemp("", "bish-fix-*.sh")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
-	}
-	tmpPath := tmpFile.Name()
-	defer func() { _ = os.Remove(tmpPath) }()
-
-	if _, err := tmpFile.WriteString(command); err != nil {
-		_ = tmpFile.Close()
-		return "", fmt.Errorf("failed to write to temp file: %w", err)
-	}
-	if err := tmpFile.Close(); err != nil {
-		return "", fmt.Errorf("failed to close temp file: %w", err)
-	}
-
-	// Run the editor
-	cmd := exec.Command(editor, tmpPath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("editor exited with error: %w", err)
-	}
-
-	// Read the edited content
-	content, err := os.ReadFile(tmpPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read edited file: %w", err)
-	}
-
-	// Return trimmed content (remove trailing newlines but preserve internal structure)
-	return strings.TrimSpace(string(content)), nil
-}
-
 func executeCommand(ctx context.Context, input string, historyManager *history.HistoryManager, coachManager *coach.CoachManager, runner *interp.Runner, logger *zap.Logger, state *ShellState, stderrCapturer *StderrCapturer, sessionID string) (bool, error) {
 	// History expansion
-	expandedInput, expanded := expandHistory(input, historyManager)
-	if expanded {
-		input = expandedInput
+	expanded, err := expandHistory(input, historyManager)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bish: %v\n", err)
+		state.LastExitCode = 1
+		return false, nil
+	}
+	if expanded.expanded {
+		input = expanded.line
 		fmt.Fprintln(os.Stderr, input)
+		// :p shows the line and keeps it in history, for !! to run
+		if expanded.printOnly {
+			if entry, err := historyManager.StartCommand(input, environment.GetPwd(runner), sessionID); err == nil && entry != nil {
+				_, _ = historyManager.FinishCommand(entry, 0)
+			}
+			return false, nil
+		}
 	}
 
 	// Pre-process input to transform typeset/declare -f/-F/-p commands to bish_typeset
@@ -638,8 +958,16 @@ func executeCommand(ctx context.Context, input string, historyManager *history.H
 
 	input = processedInput
 
+	// `cmd |> name` keeps the output of cmd as @name, which later commands
+	// read from a file; history keeps the line as typed
+	toRun, captureName, capturing := captures.ParseOperator(input)
+	if !capturing {
+		toRun = input
+	}
+	toRun = captures.Expand(toRun, captures.DefaultStore)
+
 	var prog *syntax.Stmt
-	err := syntax.NewParser().Stmts(strings.NewReader(input), func(stmt *syntax.Stmt) bool {
+	err = syntax.NewParser().Stmts(strings.NewReader(toRun), func(stmt *syntax.Stmt) bool {
 		prog = stmt
 		return false
 	})
@@ -651,18 +979,65 @@ func executeCommand(ctx context.Context, input string, historyManager *history.H
 		logger.Error("error parsing command", zap.String("command", input), zap.Error(err))
 		return false, err
 	}
+	// History keeps the directory the command ran in, which differs from the
+	// prompt's for in DIR -- CMD
+	vars := expand.FuncEnviron(func(name string) string { return runner.Vars[name].String() })
+	directory := bash.PinnedDir(prog, environment.GetPwd(runner), vars)
+	bash.Rewrite(prog)
 
-	historyEntry, _ := historyManager.StartCommand(input, environment.GetPwd(runner), sessionID)
+	historyEntry, _ := historyManager.StartCommand(input, directory, sessionID)
 
 	state.LastCommand = input
+	var capture *outputCapture
+	var captureBuffer *captures.Buffer
 	if stderrCapturer != nil {
+		var stdoutCopies []io.Writer
+		if environment.GetCaptureOutput(runner) {
+			if capture, err = startOutputCapture(lastOutputPath(sessionID)); err != nil {
+				logger.Warn("failed to capture command output", zap.Error(err))
+			} else {
+				stdoutCopies = append(stdoutCopies, capture)
+			}
+		}
+		if capturing {
+			captureBuffer = &captures.Buffer{}
+			stdoutCopies = append(stdoutCopies, captureBuffer)
+		}
+		var stdoutCopy io.Writer
+		if len(stdoutCopies) > 0 {
+			stdoutCopy = io.MultiWriter(stdoutCopies...)
+		}
+		attachOutput(runner, stderrCapturer, state.Observer, stdoutCopy)
 		stderrCapturer.StartCapture()
 	}
 
+	// The command runs as a job that Ctrl+Z suspends, or that the shell does
+	// not wait for if it is a `cmd &`
+	jobCtx := jobs.DefaultTable.Foreground(ctx, input)
+	if jobs.Detachable(prog, runner) {
+		prog.Background = false
+		jobCtx = jobs.DefaultTable.Background(ctx, input)
+	}
+
+	startDir := environment.GetPwd(runner)
 	startTime := time.Now()
-	err = runner.Run(ctx, prog)
+	err = bash.Run(jobCtx, runner, prog)
 	exited := runner.Exited()
 
+	// The command may have changed the repository it ran in, or cd'd into
+	// another one; refresh the git status shown at the next prompt
+	git.DefaultStatusCache.Invalidate(startDir)
+	git.DefaultStatusCache.Invalidate(environment.GetPwd(runner))
+	// A full-screen program that crashed or was killed leaves the terminal
+	// in raw mode, and usually in its alternate screen
+	if environment.GetTerminalRecovery(runner) && termreset.Recover(os.Stdin, os.Stdout) {
+		logger.Info("restored the terminal after an unclean exit", zap.String("command", input))
+		fmt.Fprintln(os.Stderr, "bish: the command left the terminal in raw mode; restored it")
+	}
+	if errors.Is(err, bash.ErrUnsupported) {
+		fmt.Fprintf(os.Stderr, "bish: %v\n", err)
+	}
+
 	if stderrCapturer != nil {
 		state.LastStderr = stderrCapturer.StopCapture()
 	}
@@ -672,6 +1047,23 @@ func executeCommand(ctx context.Context, input string, historyManager *history.H
 	durationMs := endTime.Sub(startTime).Milliseconds()
 	_, _, _ = bash.RunBashCommand(ctx, runner, fmt.Sprintf("BISH_LAST_COMMAND_DURATION_MS=%d", durationMs))
 
+	var outputFile string
+	if capture != nil {
+		if finishErr := capture.finish(); finishErr != nil {
+			logger.Warn("failed to save command output", zap.Error(finishErr))
+		} else {
+			outputFile = capture.path
+		}
+	}
+	setLastCommandVars(ctx, runner, input, endTime.Sub(startTime), outputFile)
+
+	if captureBuffer != nil {
+		if _, saveErr := captureBuffer.Save(captures.DefaultStore, captureName, toRun); saveErr != nil {
+			logger.Warn("failed to save capture", zap.String("name", captureName), zap.Error(saveErr))
+			fmt.Fprintf(os.Stderr, "bish: could not keep the output as @%s: %v\n", captureName, saveErr)
+		}
+	}
+
 	var exitCode int
 	if err != nil {
 		status, ok := interp.IsExitStatus(err)
@@ -694,123 +1086,52 @@ func executeCommand(ctx context.Context, input string, historyManager *history.H
 		coachManager.RecordCommand(input, exitCode, durationMs)
 	}
 
-	return exited, nil
-}
-
-func expandHistory(input string, historyManager *history.HistoryManager) (string, bool) {
-	// Quick check
-	if !strings.Contains(input, "!") {
-		return input, false
-	}
-
-	entries, err := historyManager.GetAllEntries()
-	if err != nil || len(entries) == 0 {
-		return input, false
-	}
-	lastEntry := entries[0]
-	lastCmd := lastEntry.Command
-
-	// Get last argument
-	lastArg := shellinput.GetLastArgument(lastCmd)
-
-	var sb strings.Builder
-	expanded := false
-	inSingleQuote := false
-
-	runes := []rune(input)
-	for i := 0; i < len(runes); i++ {
-		r := runes[i]
-
-		if r == '\'' {
-			inSingleQuote = !inSingleQuote
-			sb.WriteRune(r)
-			continue
+	// Run what fc edited or substituted, now that its line is done, in
+	// place of the fc command in history
+	if reruns := history.DefaultReruns.Take(); len(reruns) > 0 && !exited {
+		if historyEntry != nil {
+			_ = historyManager.DeleteEntry(historyEntry.ID)
 		}
-
-		if inSingleQuote {
-			sb.WriteRune(r)
-			continue
-		}
-
-		if r == '\\' {
-			sb.WriteRune(r)
-			if i+1 < len(runes) {
-				sb.WriteRune(runes[i+1])
-				i++
+		for _, rerun := range reruns {
+			if exited, err = executeCommand(ctx, rerun, historyManager, coachManager, runner, logger, state, stderrCapturer, sessionID); exited || err != nil {
+				return exited, err
 			}
-			continue
-		}
-
-		// Check for !!
-		if r == '!' && i+1 < len(runes) && runes[i+1] == '!' {
-			sb.WriteString(lastCmd)
-			expanded = true
-			i++ // Skip next !
-			continue
 		}
+	}
 
-		// Check for !$
-		if r == '!' && i+1 < len(runes) && runes[i+1] == '$' {
-			sb.WriteString(lastArg)
-			expanded = true
-			i++ // Skip next $
-			continue
-		}
+	return exited, nil
+}
 
-		sb.WriteRune(r)
+// failedRequestContext returns the redacted request and response of the last
+// req invocation if command ran req and that request failed, or "" otherwise.
+func failedRequestContext(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] != "req" {
+		return ""
 	}
+	exchange, ok := httpreq.DefaultHistory.LastFailure()
+	if !ok {
+		return ""
+	}
+	return exchange.Describe()
+}
 
-	return sb.String(), expanded
+// addressInUseContext tells what holds the port when a command failed because
+// its address was already in use, so the fix can stop it or pick another.
+func addressInUseContext(ctx context.Context, command, stderr string) string {
+	port := ports.AddressInUsePort(command, stderr)
+	if port == 0 {
+		return ""
+	}
+	listeners, err := ports.List(ctx, containers.Exec)
+	if err != nil {
+		return ""
+	}
+	return ports.Describe(listeners, port)
 }
 
 // printHelp displays help information about Bishop shell commands
 func printHelp() {
-	helpText := `
-Bishop Shell - AI-Powered Command Line
-
-AGENT COMMANDS
-  # <message>       Chat with the AI agent
-  #? or #!fix       Ask AI to explain and fix the last failed command
-  #/<macro>         Invoke a predefined agent macro
-
- AGENT CONTROLS
-   #!help            Show this help message
-   #!new             Reset the current chat session
-   #!setup           Run the setup wizard to configure API keys
-   #!tokens          Display token usage statistics
-   #!config          Open interactive configuration menu
-  #!coach           Open the coaching dashboard
-    #!coach stats        View your command statistics
-    #!coach achievements View your achievements
-    #!coach challenges   View active challenges
-    #!coach tips         View personalized tips
-    #!coach reset-tips   Regenerate tips from history
-
-SUBAGENTS
-  ##<name> <prompt> Chat with a specific subagent (e.g., ##git commit this)
-  ## <prompt>       Auto-select best subagent for your prompt
-  #:<mode> <prompt> Roo Code style invocation
-  Type '##' and press Tab to see available subagents
-
-MAGIC FIX OPTIONS
-  y/Y               Run the suggested fix
-  n/N               Cancel (any other key also cancels)
-  e/E               Edit the fix in your $EDITOR
-  i/I               Insert the fix into the prompt to edit inline
-
-HISTORY EXPANSION
-  !!                Repeat the last command
-  !$                Use the last argument from previous command
-
-KEYBOARD SHORTCUTS
-  Ctrl+R            Search command history
-  Ctrl+L            Clear screen
-  Ctrl+C            Cancel current input
-  Ctrl+D            Exit shell (on empty line)
-  Tab               Autocomplete commands/paths
-
-For more information, see the documentation at:
-  https://github.com/robottwo/bishop
-`
+	helpText := i18n.T("help.text")
 	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(helpText) + gline.RESET_CURSOR_COLUMN)
 }
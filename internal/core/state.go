@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"io"
 	"sync"
+	"time"
+
+	"github.com/robottwo/bishop/internal/focus"
 )
 
 // ShellState holds the state of the shell execution
@@ -12,6 +15,23 @@ type ShellState struct {
 	LastExitCode int
 	LastStderr   string
 	FixHintShown bool // Track if the #? fix hint has been shown this session
+	// Observer also receives the stdout and stderr of commands while set
+	Observer io.Writer
+	// Focus is the task declared with #!focus, if any
+	Focus *focus.Session
+	// FailingCommand failed the last FailureStreak times it was run in a row
+	FailingCommand string
+	FailureStreak  int
+	// QuietUntil is when the quiet mode started with #!quiet ends. While it
+	// lasts, idle summaries, coach toasts and hints are not shown, and
+	// QuietNoAI also pauses LLM calls.
+	QuietUntil time.Time
+	QuietNoAI  bool
+	// TimerQuietUntil is when the timer that turned quiet mode on ends, so
+	// that quiet mode ends with it if the timer is stopped early
+	TimerQuietUntil time.Time
+	// WrappedUpAt is when the session was last summarized with #!wrapup
+	WrappedUpAt time.Time
 }
 
 // StderrCapturer wraps an io.Writer and captures the output into a buffer
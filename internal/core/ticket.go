@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/ci"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/git"
+	"github.com/robottwo/bishop/internal/ticket"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// maxTicketBodyBytes bounds the description of a ticket given to the agent.
+const maxTicketBodyBytes = 8000
+
+// ticketTarget returns the ticket to read and its provider: the one named
+// by ref if it is not empty, e.g. PROJ-1234 or #567, and otherwise the one
+// named in the branch checked out in the repository the shell is in.
+func ticketTarget(runner *interp.Runner, logger *zap.Logger, ref string) (ticket.Target, error) {
+	setting := environment.GetTicketProvider(runner, logger)
+	if setting == ticket.ProviderOff {
+		return ticket.Target{}, errors.New("tickets are not read: set BISH_TICKET_PROVIDER to auto, github, gitlab or jira")
+	}
+	getenv := func(name string) string { return runner.Vars[name].String() }
+	repo := git.FindRepo(environment.GetPwd(runner), getenv)
+
+	var parsed ticket.Ref
+	var ok bool
+	if ref = strings.TrimSpace(ref); ref != "" {
+		if number := strings.TrimPrefix(ref, "#"); number != "" && strings.Trim(number, "0123456789") == "" {
+			parsed, ok = ticket.Ref{Kind: ticket.KindIssue, Key: number}, true
+		} else {
+			parsed, ok = ticket.Parse(ref)
+		}
+		if !ok {
+			return ticket.Target{}, fmt.Errorf("%s is not a ticket: use a key like PROJ-1234 or an issue number like #567", ref)
+		}
+	} else {
+		if repo == nil {
+			return ticket.Target{}, errors.New("not in a git repository: name the ticket, e.g. #/ticket PROJ-1234")
+		}
+		branch := repo.Branch()
+		if parsed, ok = ticket.Parse(branch); !ok {
+			return ticket.Target{}, fmt.Errorf("the branch %s names no ticket: name it, e.g. #/ticket PROJ-1234", branch)
+		}
+	}
+
+	var remote ci.Remote
+	if repo != nil {
+		remote, _ = ci.ParseRemote(repo.RemoteURL("origin"))
+	}
+	provider, source, ok := ticket.ProviderFor(setting, parsed, remote, getenv, exec.LookPath)
+	if !ok {
+		return ticket.Target{}, fmt.Errorf("no way to read %s with BISH_TICKET_PROVIDER=%s: Jira keys need BISH_JIRA_URL, GitHub issues gh and GitLab ones $GITLAB_TOKEN, with self-hosted GitLab hosts listed in BISH_GITLAB_HOSTS", parsed, setting)
+	}
+	return ticket.Target{Ref: parsed, Source: source, Provider: provider}, nil
+}
+
+// ticketSegment returns what the border status shows of the ticket of the
+// branch once it has been read, or "" until then.
+func ticketSegment(target ticket.Target) string {
+	t, ok := ticket.DefaultCache.Lookup(target, time.Now())
+	if !ok {
+		return ""
+	}
+	return t.Summary()
+}
+
+// ticketPrompt asks the agent to summarize the ticket named by ref, or the
+// one of the branch, and to propose a plan for it. It returns an error that
+// explains why there is no ticket to read.
+func ticketPrompt(ctx context.Context, runner *interp.Runner, logger *zap.Logger, ref string) (string, error) {
+	target, err := ticketTarget(runner, logger, ref)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	t, err := target.Provider.Fetch(ctx, target.Ref)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "I am working on the ticket %s: %s", t.Ref, t.Title)
+	var details []string
+	if t.State != "" {
+		details = append(details, t.State)
+	}
+	if t.URL != "" {
+		details = append(details, t.URL)
+	}
+	if len(details) > 0 {
+		fmt.Fprintf(&prompt, " (%s)", strings.Join(details, ", "))
+	}
+	body := strings.TrimSpace(t.Body)
+	if len(body) > maxTicketBodyBytes {
+		body = body[:maxTicketBodyBytes] + "\n[…]"
+	}
+	if body != "" {
+		fmt.Fprintf(&prompt, ". Its description is:\n```\n%s\n```\n", body)
+	} else {
+		prompt.WriteString(". It has no description.\n")
+	}
+	prompt.WriteString("\nSummarize the ticket in a few sentences, then propose a plan to do it in this repository: the steps, the files likely involved and how to check the result. Do not make any changes yet.")
+	return prompt.String(), nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robottwo/bishop/internal/coach"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/timer"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// timerAlert returns what happens when a timer ends, even while a command
+// runs: the terminal bell, a desktop notification and the command of the
+// timer.
+func timerAlert(logger *zap.Logger) func(timer.Timer) {
+	return func(t timer.Timer) {
+		fmt.Fprint(os.Stderr, "\a")
+		if err := timer.Alert(t); err != nil {
+			logger.Warn("timer command failed", zap.Error(err))
+		}
+	}
+}
+
+// timerStatus returns the label and end of the running timer of clock, for
+// the border status.
+func timerStatus(clock *timer.Clock) func() (string, time.Time) {
+	return func() (string, time.Time) {
+		t, ok := clock.Current()
+		if !ok {
+			return "", time.Time{}
+		}
+		if t.IsBreak {
+			return "break", t.Ends
+		}
+		return t.Label, t.Ends
+	}
+}
+
+// reportTimers prints the timers of clock that ended since the last prompt.
+// With BISH_TIMER_ACTIVITY on, the coach then sums up the commands run while
+// each counted down.
+func reportTimers(clock *timer.Clock, runner *interp.Runner, coachManager *coach.CoachManager, sessionID string) {
+	for _, t := range clock.TakeEnded() {
+		printQuietMessage(timerEndMessage(t))
+		if !t.IsBreak && coachManager != nil && environment.GetTimerActivity(runner) {
+			coachManager.RecordFocusSession(t.Label, t.Started, t.Ends, sessionID)
+		}
+	}
+}
+
+// timerEndMessage tells that t has ended.
+func timerEndMessage(t timer.Timer) string {
+	if t.IsBreak {
+		return "Break is over."
+	}
+	message := fmt.Sprintf("Time is up after %s.", timer.FormatRemaining(t.Ends.Sub(t.Started)))
+	if t.Label != "" {
+		message = fmt.Sprintf("Time is up for %s after %s.", t.Name(), timer.FormatRemaining(t.Ends.Sub(t.Started)))
+	}
+	if t.Break > 0 {
+		message += fmt.Sprintf(" Take a %s break.", timer.FormatRemaining(t.Break))
+	}
+	return message
+}
+
+// syncTimerQuiet keeps quiet mode on until the running timer ends if it was
+// started with --quiet, and ends quiet mode early with the timer if it is
+// stopped.
+func syncTimerQuiet(clock *timer.Clock, state *ShellState, now time.Time) {
+	if t, ok := clock.Current(); ok && t.Quiet {
+		// Once set, #!quiet off still ends quiet mode early
+		if !state.TimerQuietUntil.Equal(t.Ends) && (state.QuietUntil.Before(t.Ends) || state.QuietUntil.Equal(state.TimerQuietUntil)) {
+			state.QuietUntil = t.Ends
+			state.TimerQuietUntil = t.Ends
+		}
+		return
+	}
+	if state.TimerQuietUntil.IsZero() {
+		return
+	}
+	if state.quiet(now) && state.QuietUntil.Equal(state.TimerQuietUntil) {
+		endQuiet(state)
+		printQuietMessage("Quiet mode ended with the timer.")
+	}
+	state.TimerQuietUntil = time.Time{}
+}
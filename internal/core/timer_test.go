@@ -0,0 +1,48 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/timer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncTimerQuiet(t *testing.T) {
+	clock := timer.NewClock()
+	defer clock.Stop()
+	state := &ShellState{}
+	now := time.Now()
+
+	ends := now.Add(25 * time.Minute)
+	clock.Start(timer.Timer{Started: now, Ends: ends, Quiet: true})
+	syncTimerQuiet(clock, state, now)
+	assert.Equal(t, ends, state.QuietUntil)
+
+	// #!quiet off during the timer is not undone
+	endQuiet(state)
+	syncTimerQuiet(clock, state, now)
+	assert.True(t, state.QuietUntil.IsZero())
+
+	// Stopping the timer ends the quiet mode it started
+	state.TimerQuietUntil = time.Time{}
+	syncTimerQuiet(clock, state, now)
+	assert.Equal(t, ends, state.QuietUntil)
+	clock.Stop()
+	syncTimerQuiet(clock, state, now)
+	assert.True(t, state.QuietUntil.IsZero())
+	assert.True(t, state.TimerQuietUntil.IsZero())
+
+	// but not quiet mode started with #!quiet
+	state.QuietUntil = now.Add(time.Hour)
+	clock.Start(timer.Timer{Started: now, Ends: ends})
+	syncTimerQuiet(clock, state, now)
+	assert.Equal(t, now.Add(time.Hour), state.QuietUntil)
+}
+
+func TestTimerEndMessage(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, `Time is up for "review PR" after 25:00. Take a 5:00 break.`, timerEndMessage(timer.Timer{Label: "review PR", Started: now, Ends: now.Add(25 * time.Minute), Break: 5 * time.Minute}))
+	assert.Equal(t, "Time is up after 10:00.", timerEndMessage(timer.Timer{Started: now, Ends: now.Add(10 * time.Minute)}))
+	assert.Equal(t, "Break is over.", timerEndMessage(timer.Timer{IsBreak: true}))
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/pkg/gline"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxLaterSuggestions limits how many TODOs are suggested for one response.
+const maxLaterSuggestions = 3
+
+// suggestTodoForFailures counts how many times in a row the last command
+// failed and returns a todo command to come back to it once it has failed
+// todo.RepeatedFailures times. It is only suggested once per streak and not
+// if the same TODO is already pending.
+func suggestTodoForFailures(state *ShellState, store *todo.Store, runner *interp.Runner) (string, bool) {
+	command := strings.TrimSpace(state.LastCommand)
+	if state.LastExitCode == 0 || command == "" {
+		state.FailingCommand, state.FailureStreak = "", 0
+		return "", false
+	}
+	if command == state.FailingCommand {
+		state.FailureStreak++
+	} else {
+		state.FailingCommand, state.FailureStreak = command, 1
+	}
+
+	if store == nil || state.FailureStreak != todo.RepeatedFailures || strings.HasPrefix(command, "todo ") {
+		return "", false
+	}
+	text := todo.FailureText(command)
+	if store.HasPending(todo.ProjectFor(environment.GetPwd(runner)), text) {
+		return "", false
+	}
+	return todoAddCommand(text), true
+}
+
+// suggestLaterTodos prints a todo command for each thing the agent advised
+// doing later that is not already pending.
+func suggestLaterTodos(response string, store *todo.Store, runner *interp.Runner) {
+	if store == nil {
+		return
+	}
+	project := todo.ProjectFor(environment.GetPwd(runner))
+	suggested := 0
+	for _, task := range todo.LaterTasks(response) {
+		if suggested == maxLaterSuggestions {
+			break
+		}
+		if store.HasPending(project, task) {
+			continue
+		}
+		suggested++
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: To keep this for later, run: "+todoAddCommand(task)+"\n") + gline.RESET_CURSOR_COLUMN)
+	}
+}
+
+// todoAddCommand returns the todo command that adds text.
+func todoAddCommand(text string) string {
+	quoted, err := syntax.Quote(text, syntax.LangBash)
+	if err != nil {
+		quoted = "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+	}
+	return "todo add " + quoted
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestSuggestTodoForFailures(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	store, err := todo.NewStore(historyManager.GetDB())
+	require.NoError(t, err)
+	dir := t.TempDir()
+	runner, err := interp.New(interp.Dir(dir))
+	require.NoError(t, err)
+	runner.Vars = make(map[string]expand.Variable)
+
+	state := &ShellState{}
+	fail := func(command string) (string, bool) {
+		state.LastCommand, state.LastExitCode = command, 1
+		return suggestTodoForFailures(state, store, runner)
+	}
+
+	_, ok := fail("make deploy")
+	assert.False(t, ok)
+	_, ok = fail("make deploy")
+	assert.False(t, ok)
+	suggestion, ok := fail("make deploy")
+	require.True(t, ok)
+	assert.Equal(t, `todo add 'fix failing command: make deploy'`, suggestion)
+
+	// Only once per streak
+	_, ok = fail("make deploy")
+	assert.False(t, ok)
+
+	// A success or another command resets the streak
+	state.LastExitCode = 0
+	_, ok = suggestTodoForFailures(state, store, runner)
+	assert.False(t, ok)
+	fail("make deploy")
+	fail("make test")
+	_, ok = fail("make deploy")
+	assert.False(t, ok)
+
+	// Not suggested again while the TODO is pending
+	_, err = store.Add(todo.FailureText("make test"), todo.ProjectFor(dir), todo.SourceUser)
+	require.NoError(t, err)
+	fail("make test")
+	fail("make test")
+	_, ok = fail("make test")
+	assert.False(t, ok)
+}
+
+func TestTodoAddCommand(t *testing.T) {
+	assert.Equal(t, `todo add 'rotate prod certs'`, todoAddCommand("rotate prod certs"))
+	assert.Equal(t, `todo add "don't forget"`, todoAddCommand("don't forget"))
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/rctriage"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+)
+
+// printTriagePanel lists the config file errors found at startup, if any.
+func printTriagePanel() {
+	if issues := rctriage.Pending(); len(issues) > 0 {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR(rctriage.Panel(issues)) + gline.RESET_CURSOR_COLUMN)
+	}
+}
+
+// handleTriageControl implements #!triage, which lists the config file errors
+// found at startup and acts on them: edit N opens the file at the line in
+// $EDITOR, fix N returns the message that asks the agent to fix it, and
+// ignore N hides it in future sessions.
+func handleTriageControl(args string, logger *zap.Logger) (chatMessage string) {
+	action, number, _ := strings.Cut(args, " ")
+	if action == "" {
+		if len(rctriage.Pending()) == 0 {
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: No config file errors to triage.\n") + gline.RESET_CURSOR_COLUMN)
+			return ""
+		}
+		printTriagePanel()
+		return ""
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(number))
+	if err != nil || (action != "edit" && action != "fix" && action != "ignore") {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Usage: #!triage [edit|fix|ignore N]\n") + gline.RESET_CURSOR_COLUMN)
+		return ""
+	}
+	issue, ok := rctriage.Get(n)
+	if !ok {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR(fmt.Sprintf("bish: No config file error %d; #!triage lists them.\n", n)) + gline.RESET_CURSOR_COLUMN)
+		return ""
+	}
+
+	switch action {
+	case "edit":
+		editor, err := gline.FindEditor()
+		if err != nil {
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+			return ""
+		}
+		args := rctriage.EditorArgs(editor, issue)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			logger.Warn("error running editor", zap.Strings("args", args), zap.Error(err))
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Failed to open editor: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		}
+		return ""
+	case "fix":
+		return rctriage.FixPrompt(issue)
+	default:
+		ignored, err := rctriage.LoadIgnored(rctriage.DefaultIgnoredPath())
+		if err == nil {
+			err = ignored.Add(issue)
+		}
+		if err != nil {
+			logger.Warn("error ignoring config file error", zap.Error(err))
+			fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not ignore the error: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+			return ""
+		}
+		rctriage.Resolve(n)
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: Ignoring "+issue.Location()+" from now on.\n") + gline.RESET_CURSOR_COLUMN)
+		return ""
+	}
+}
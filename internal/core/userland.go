@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/agent"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/userland"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+)
+
+var codeBlockRegex = regexp.MustCompile("(?s)```(?:bash|sh|zsh)?\\s+(.*?)\\s+```")
+
+// extractFixedCommand returns the command in the last code block of an agent
+// response, or "".
+func extractFixedCommand(response string) string {
+	matches := codeBlockRegex.FindAllStringSubmatch(response, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(matches[len(matches)-1][1])
+}
+
+// correctForUserland asks the agent to rewrite a suggested fix that uses
+// GNU-only constructs when the system has BSD tools, as on macOS, and
+// returns the corrected command. It gives up after one round and returns
+// the command as it is then.
+func correctForUserland(a *agent.Agent, command string, redactText func(string) string, logger *zap.Logger) string {
+	land := userland.Current()
+	if land.Flavor != userland.BSD {
+		return command
+	}
+	issues := userland.Check(command)
+	if len(issues) == 0 {
+		return command
+	}
+
+	constructs := make([]string, len(issues))
+	for i, issue := range issues {
+		constructs[i] = issue.Construct
+	}
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE(fmt.Sprintf("bish: The fix uses GNU-only %s; asking for a BSD version.\n", strings.Join(constructs, ", "))) + gline.RESET_CURSOR_COLUMN)
+
+	chatChannel, err := a.Chat(userland.CorrectionPrompt(command, issues, land))
+	if err != nil {
+		logger.Error("error chatting with agent", zap.Error(err))
+		return command
+	}
+	var fullResponse strings.Builder
+	for message := range chatChannel {
+		fullResponse.WriteString(message)
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+redactText(message)+"\n") + gline.RESET_CURSOR_COLUMN)
+	}
+
+	corrected := extractFixedCommand(fullResponse.String())
+	if corrected == "" {
+		return command
+	}
+	if remaining := userland.Check(corrected); len(remaining) > 0 {
+		logger.Debug("corrected fix still uses GNU-only constructs", zap.String("command", corrected))
+	}
+	return corrected
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/journal"
+	"github.com/robottwo/bishop/internal/styles"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/pkg/gline"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// wrapUpTimeout bounds how long summarizing the session may take, so that
+// exiting the shell never hangs on the LLM.
+const wrapUpTimeout = 30 * time.Second
+
+// wrapUpSession implements #!wrapup and the summary on exit. It summarizes
+// the commands run since the session started, or since the last wrap-up,
+// prints the summary and appends it to the project journal. On exit, short
+// sessions are skipped silently.
+func wrapUpSession(ctx context.Context, state *ShellState, sessionID string, sessionStart time.Time, runner *interp.Runner, summarizer *journal.Summarizer, logger *zap.Logger, onExit bool) {
+	start := sessionStart
+	if state.WrappedUpAt.After(start) {
+		start = state.WrappedUpAt
+	}
+
+	if !onExit {
+		printWrapUpMessage("Wrapping up this session...")
+	}
+	ctx, cancel := context.WithTimeout(ctx, wrapUpTimeout)
+	defer cancel()
+	minCommands := 1
+	if onExit {
+		minCommands = journal.MinCommands
+	}
+	now := time.Now()
+	entry, err := summarizer.Summarize(ctx, sessionID, start, now, minCommands)
+	if err != nil {
+		logger.Warn("error summarizing session", zap.Error(err))
+	}
+
+	if onExit && entry.Activity.Commands < journal.MinCommands {
+		return
+	}
+	if entry.Activity.Commands == 0 {
+		printWrapUpMessage("Nothing to wrap up: no commands were run since the last wrap-up.")
+		return
+	}
+	if err != nil && entry.Summary == "" {
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not summarize the session: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+	}
+
+	message := "Session summary: " + entry.Activity.String()
+	if entry.Summary != "" {
+		message += "\n" + entry.Summary
+	}
+	printWrapUpMessage(message)
+
+	project := entry.Activity.Project
+	if project == "" {
+		project = todo.ProjectFor(environment.GetPwd(runner))
+	}
+	path, err := journal.Append(project, entry)
+	if err != nil {
+		logger.Warn("error writing session journal", zap.String("project", project), zap.Error(err))
+		fmt.Print(gline.RESET_CURSOR_COLUMN + styles.ERROR("bish: Could not write the journal: "+err.Error()+"\n") + gline.RESET_CURSOR_COLUMN)
+		return
+	}
+	state.WrappedUpAt = now
+	printWrapUpMessage("Added to " + path)
+}
+
+func printWrapUpMessage(message string) {
+	fmt.Print(gline.RESET_CURSOR_COLUMN + styles.AGENT_MESSAGE("bish: "+message+"\n") + gline.RESET_CURSOR_COLUMN)
+}
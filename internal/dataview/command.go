@@ -0,0 +1,95 @@
+package dataview
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: tv [-d DELIMITER] [-n] FILE\n" +
+	"  -d DELIMITER  separate fields with DELIMITER, e.g. ';' or '\\t'\n" +
+	"  -n            the first line is data, not column names"
+
+// ViewFunc shows t, read from path, and returns the command for what was
+// shown if the user asked for it.
+type ViewFunc func(t Table, path string) (string, error)
+
+// RecordFunc adds a command to history.
+type RecordFunc func(command string, exitCode int)
+
+// NewTvCommandHandler creates an ExecHandler for the tv builtin, which shows
+// a CSV, TSV or JSON Lines file as a table. When the user asks for the
+// awk, cut or jq command that prints what they are looking at, it is
+// printed and added to history with record, to be recalled and scripted.
+func NewTvCommandHandler(view ViewFunc, record RecordFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "tv" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			var opts Options
+			var path string
+			for i := 1; i < len(args); i++ {
+				switch arg := args[i]; {
+				case arg == "-h" || arg == "--help":
+					fmt.Fprintln(hc.Stdout, usage)
+					return nil
+				case arg == "-n" || arg == "--no-header":
+					opts.NoHeader = true
+				case arg == "-d" && i+1 < len(args):
+					i++
+					delimiter, err := ParseDelimiter(args[i])
+					if err != nil {
+						fmt.Fprintf(hc.Stderr, "tv: %v\n", err)
+						return interp.NewExitStatus(2)
+					}
+					opts.Delimiter = delimiter
+				case path == "" && (arg == "-" || arg[0] != '-'):
+					path = arg
+				default:
+					fmt.Fprintln(hc.Stderr, usage)
+					return interp.NewExitStatus(2)
+				}
+			}
+			if path == "" || path == "-" {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			file := path
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(hc.Dir, file)
+			}
+			t, err := Load(file, opts)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "tv: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if len(t.Header) == 0 {
+				fmt.Fprintf(hc.Stderr, "tv: %s is empty\n", path)
+				return interp.NewExitStatus(1)
+			}
+
+			command, err := view(t, path)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "tv: %v\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if command == "" {
+				return nil
+			}
+			fmt.Fprintln(hc.Stdout, command)
+			if caveat := Caveat(t); caveat != "" {
+				fmt.Fprintf(hc.Stderr, "tv: %s\n", caveat)
+			}
+			if record != nil {
+				record(command, 0)
+			}
+			return nil
+		}
+	}
+}
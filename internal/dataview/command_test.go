@@ -0,0 +1,76 @@
+package dataview
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runTv(t *testing.T, dir, script string, view ViewFunc) (history []string, output string, err error) {
+	t.Helper()
+	record := func(command string, exitCode int) {
+		history = append(history, command)
+	}
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &out, &out),
+		interp.Dir(dir),
+		interp.ExecHandlers(NewTvCommandHandler(view, record)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return history, out.String(), err
+}
+
+func TestTvPrintsAndRecordsCommand(t *testing.T) {
+	path := writeFile(t, "data.txt", "a;1\nb;2\n")
+	var shown Table
+	view := func(table Table, path string) (string, error) {
+		shown = table
+		return Command(table, path, View{Columns: []int{1}}), nil
+	}
+
+	history, output, err := runTv(t, filepath.Dir(path), "tv -n -d ';' data.txt", view)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "1"}, {"b", "2"}}, shown.Rows)
+	assert.Equal(t, "cut -d ';' -f 2 data.txt\n", output)
+	assert.Equal(t, []string{"cut -d ';' -f 2 data.txt"}, history)
+}
+
+func TestTvQuitWithoutCommand(t *testing.T) {
+	path := writeFile(t, "people.csv", "name\nAda\n")
+	view := func(Table, string) (string, error) { return "", nil }
+	history, output, err := runTv(t, t.TempDir(), "tv "+path, view)
+	require.NoError(t, err)
+	assert.Empty(t, output)
+	assert.Empty(t, history)
+}
+
+func TestTvErrors(t *testing.T) {
+	view := func(Table, string) (string, error) {
+		t.Fatal("the viewer should not be shown")
+		return "", nil
+	}
+	for script, want := range map[string]string{
+		"tv":                  "Usage: tv",
+		"tv -d ';;' x.csv":    `tv: invalid delimiter ";;"`,
+		"tv missing.csv":      "tv: open",
+		"tv a.csv b.csv":      "Usage: tv",
+		"tv --bogus data.csv": "Usage: tv",
+	} {
+		_, output, err := runTv(t, t.TempDir(), script, view)
+		_, isExit := interp.IsExitStatus(err)
+		assert.True(t, isExit, script)
+		assert.Contains(t, output, want, script)
+	}
+}
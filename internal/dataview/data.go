@@ -0,0 +1,271 @@
+// Package dataview implements the tv builtin, a viewer for CSV, TSV and
+// JSON Lines files that can hand back the awk, cut or jq command for what it
+// shows.
+package dataview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxRows is how many rows are loaded; the rest of a larger file is left
+// out of the view.
+const maxRows = 100000
+
+// Format is the kind of file shown.
+type Format int
+
+const (
+	CSV Format = iota
+	TSV
+	JSONL
+)
+
+// Table is a file loaded for viewing.
+type Table struct {
+	Format Format
+	// Delimiter separates the fields of CSV and TSV files
+	Delimiter rune
+	// HasHeader is set if the first line names the columns; otherwise they
+	// are numbered from 1, as awk numbers fields
+	HasHeader bool
+	Header    []string
+	Rows      [][]string
+	// Quoted is set if fields are quoted, which cut and awk do not
+	// understand
+	Quoted bool
+	// Truncated is set if the file had more than maxRows rows
+	Truncated bool
+}
+
+// Options are the tv flags that change how a file is read.
+type Options struct {
+	// Delimiter overrides the one the file name or contents suggest
+	Delimiter rune
+	NoHeader  bool
+}
+
+// DetectFormat picks the format of a file from its name, or from its first
+// line when the name does not tell.
+func DetectFormat(path string, firstLine []byte) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return TSV
+	case ".jsonl", ".ndjson":
+		return JSONL
+	case ".csv":
+		return CSV
+	}
+	switch line := bytes.TrimSpace(firstLine); {
+	case bytes.HasPrefix(line, []byte("{")):
+		return JSONL
+	case bytes.ContainsRune(line, '\t'):
+		return TSV
+	default:
+		return CSV
+	}
+}
+
+// Load reads the file at path.
+func Load(path string, opts Options) (Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Table{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	firstLine, _ := r.Peek(4096)
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	format := DetectFormat(path, firstLine)
+	if format == JSONL {
+		return readJSONL(r)
+	}
+	delimiter := ','
+	if format == TSV {
+		delimiter = '\t'
+	}
+	if opts.Delimiter != 0 {
+		delimiter = opts.Delimiter
+	}
+	return readDelimited(r, format, delimiter, !opts.NoHeader)
+}
+
+func readDelimited(r io.Reader, format Format, delimiter rune, header bool) (Table, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Table{}, err
+	}
+	read := splitLines(data, delimiter)
+	if format == CSV {
+		cr := csv.NewReader(bytes.NewReader(data))
+		cr.Comma = delimiter
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		read = cr.Read
+	}
+
+	t := Table{Format: format, Delimiter: delimiter, HasHeader: header, Quoted: format == CSV && bytes.ContainsRune(data, '"')}
+	width := 0
+	for {
+		record, err := read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Table{}, err
+		}
+		if header && t.Header == nil {
+			t.Header = record
+			width = len(record)
+			continue
+		}
+		if len(t.Rows) == maxRows {
+			t.Truncated = true
+			break
+		}
+		t.Rows = append(t.Rows, record)
+		width = max(width, len(record))
+	}
+	t.fill(width)
+	return t, nil
+}
+
+// splitLines returns a reader of the lines of data split at delimiter, as
+// TSV has no quoting: a quote is part of the field.
+func splitLines(data []byte, delimiter rune) func() ([]string, error) {
+	lines := strings.Split(string(data), "\n")
+	return func() ([]string, error) {
+		for len(lines) > 0 {
+			line := strings.TrimSuffix(lines[0], "\r")
+			lines = lines[1:]
+			if line != "" {
+				return strings.Split(line, string(delimiter)), nil
+			}
+		}
+		return nil, io.EOF
+	}
+}
+
+// fill names the columns that have no name in the header, and pads short
+// rows to width.
+func (t *Table) fill(width int) {
+	for i := len(t.Header); i < width; i++ {
+		t.Header = append(t.Header, strconv.Itoa(i+1))
+	}
+	for i, row := range t.Rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		t.Rows[i] = row
+	}
+}
+
+// readJSONL reads one JSON object per line. The columns are the keys of all
+// objects, in the order they are first seen.
+func readJSONL(r io.Reader) (Table, error) {
+	t := Table{Format: JSONL, HasHeader: true}
+	index := map[string]int{}
+	var objects []map[string]json.RawMessage
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(objects) == maxRows {
+			t.Truncated = true
+			break
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(text, &object); err != nil {
+			return Table{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, key := range keys(text) {
+			if _, ok := index[key]; !ok {
+				index[key] = len(t.Header)
+				t.Header = append(t.Header, key)
+			}
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		return Table{}, err
+	}
+
+	for _, object := range objects {
+		row := make([]string, len(t.Header))
+		for key, value := range object {
+			row[index[key]] = cellText(value)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+// keys returns the keys of the JSON object in data in the order they are
+// written, which decoding into a map loses.
+func keys(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+	var list []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return list
+		}
+		key, _ := token.(string)
+		list = append(list, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return list
+		}
+	}
+	return list
+}
+
+// cellText shows a JSON value in a cell: strings without their quotes, null
+// as nothing and anything else as compact JSON.
+func cellText(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	if string(value) == "null" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		return string(value)
+	}
+	return buf.String()
+}
+
+// ParseDelimiter reads the argument of -d, which may be a single character
+// or \t for a tab.
+func ParseDelimiter(s string) (rune, error) {
+	if s == `\t` || s == "tab" {
+		return '\t', nil
+	}
+	runes := []rune(s)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\n' || runes[0] == '\r' {
+		return 0, fmt.Errorf("invalid delimiter %q", s)
+	}
+	return runes[0], nil
+}
@@ -0,0 +1,71 @@
+package dataview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadCSV(t *testing.T) {
+	path := writeFile(t, "people.csv", "name,age,city\nAda,36,\"London, UK\"\nAlan,41\n")
+	table, err := Load(path, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, CSV, table.Format)
+	assert.Equal(t, ',', table.Delimiter)
+	assert.Equal(t, []string{"name", "age", "city"}, table.Header)
+	assert.Equal(t, [][]string{{"Ada", "36", "London, UK"}, {"Alan", "41", ""}}, table.Rows)
+	assert.True(t, table.Quoted)
+	assert.NotEmpty(t, Caveat(table))
+}
+
+func TestLoadWithoutHeader(t *testing.T) {
+	path := writeFile(t, "data.txt", "a;1\nb;2;x\n")
+	table, err := Load(path, Options{Delimiter: ';', NoHeader: true})
+	require.NoError(t, err)
+	assert.False(t, table.HasHeader)
+	assert.Equal(t, []string{"1", "2", "3"}, table.Header)
+	assert.Equal(t, [][]string{{"a", "1", ""}, {"b", "2", "x"}}, table.Rows)
+}
+
+func TestLoadTSV(t *testing.T) {
+	path := writeFile(t, "sizes", "file\tsize\n\"a\".txt\t12\n")
+	table, err := Load(path, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, TSV, table.Format)
+	assert.Equal(t, [][]string{{`"a".txt`, "12"}}, table.Rows)
+}
+
+func TestLoadJSONL(t *testing.T) {
+	path := writeFile(t, "events.jsonl", `{"level":"info","msg":"started","n":1}`+"\n\n"+`{"msg":"failed","level":"error","tags":["db"],"extra":null}`+"\n")
+	table, err := Load(path, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, JSONL, table.Format)
+	assert.Equal(t, []string{"level", "msg", "n", "tags", "extra"}, table.Header)
+	assert.Equal(t, [][]string{
+		{"info", "started", "1", "", ""},
+		{"error", "failed", "", `["db"]`, ""},
+	}, table.Rows)
+
+	_, err = Load(writeFile(t, "bad.jsonl", "{\"a\":1}\nnot json\n"), Options{})
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestParseDelimiter(t *testing.T) {
+	for input, want := range map[string]rune{`\t`: '\t', ";": ';', "|": '|'} {
+		got, err := ParseDelimiter(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseDelimiter(";;")
+	assert.Error(t, err)
+}
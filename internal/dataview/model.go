@@ -0,0 +1,402 @@
+package dataview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	headerStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+	columnStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true).Underline(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true)
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	matchStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+)
+
+const (
+	defaultRows  = 20
+	defaultWidth = 100
+	// maxCellWidth keeps one long column from pushing the others off screen
+	maxCellWidth = 40
+)
+
+// model shows a table with its header kept in place, and lets the user move
+// around it, search it, pick columns and get the command that prints what
+// is shown.
+type model struct {
+	table Table
+	path  string
+
+	widths  []int
+	numeric []bool
+
+	// rows are the indexes of the rows shown, all of them or those that
+	// match the search when filtering
+	rows      []int
+	cursor    int
+	offset    int
+	column    int
+	colOffset int
+
+	selected     []bool
+	onlySelected bool
+
+	search    string
+	input     string
+	searching bool
+	filtering bool
+	message   string
+
+	height int
+	width  int
+
+	// command is set when the user asks for the command for the view
+	command string
+}
+
+func newModel(t Table, path string) model {
+	m := model{
+		table:    t,
+		path:     path,
+		widths:   make([]int, len(t.Header)),
+		numeric:  make([]bool, len(t.Header)),
+		selected: make([]bool, len(t.Header)),
+		height:   defaultRows,
+		width:    defaultWidth,
+	}
+	for i, name := range t.Header {
+		m.widths[i] = min(maxCellWidth, len([]rune(name)))
+		m.numeric[i] = len(t.Rows) > 0
+		for _, row := range t.Rows {
+			m.widths[i] = max(m.widths[i], min(maxCellWidth, len([]rune(row[i]))))
+			if _, err := strconv.ParseFloat(row[i], 64); err != nil && row[i] != "" {
+				m.numeric[i] = false
+			}
+		}
+	}
+	m.updateRows()
+	return m
+}
+
+// columns are the indexes of the columns shown.
+func (m model) columns() []int {
+	var columns, all []int
+	for i, selected := range m.selected {
+		all = append(all, i)
+		if selected {
+			columns = append(columns, i)
+		}
+	}
+	if m.onlySelected && len(columns) > 0 {
+		return columns
+	}
+	return all
+}
+
+// view is what the model shows, for Command.
+func (m model) view() View {
+	v := View{Columns: m.columns()}
+	if m.filtering {
+		v.Filter = m.search
+	}
+	return v
+}
+
+// updateRows picks the rows to show after the search or filtering changed.
+func (m *model) updateRows() {
+	m.rows = nil
+	v := m.view()
+	for i, row := range m.table.Rows {
+		if v.matches(row) {
+			m.rows = append(m.rows, i)
+		}
+	}
+	m.cursor = max(0, min(m.cursor, len(m.rows)-1))
+	m.offset = min(m.offset, m.cursor)
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the title, header, status and help
+		m.height = max(3, msg.Height-5)
+		m.width = msg.Width
+		m = m.moveCursor(0)
+		m = m.scrollColumns()
+	case tea.KeyMsg:
+		if m.searching {
+			return m.handleSearchKey(msg)
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m = m.moveCursor(-1)
+	case "down", "j":
+		m = m.moveCursor(1)
+	case "pgup", "ctrl+b":
+		m = m.moveCursor(-m.height)
+	case "pgdown", "ctrl+f":
+		m = m.moveCursor(m.height)
+	case "home", "g":
+		m = m.moveCursor(-len(m.rows))
+	case "end", "G":
+		m = m.moveCursor(len(m.rows))
+	case "left", "h":
+		m.column = max(0, m.column-1)
+		m = m.scrollColumns()
+	case "right", "l":
+		m.column = min(len(m.columns())-1, m.column+1)
+		m = m.scrollColumns()
+	case " ":
+		if columns := m.columns(); len(columns) > 0 {
+			c := columns[m.column]
+			m.selected[c] = !m.selected[c]
+			if m.onlySelected {
+				m = m.setOnlySelected(true)
+			}
+		}
+	case "v":
+		m = m.setOnlySelected(!m.onlySelected)
+	case "/":
+		m.searching, m.input = true, ""
+	case "n":
+		m = m.findMatch(1)
+	case "N":
+		m = m.findMatch(-1)
+	case "f":
+		if m.search == "" && !m.filtering {
+			m.message = "Search with / first, then f shows only the matching rows"
+			break
+		}
+		m.filtering = !m.filtering
+		m.updateRows()
+	case "e":
+		m.command = Command(m.table, m.path, m.view())
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		m.search = m.input
+		if m.filtering {
+			m.updateRows()
+		}
+		m = m.findMatch(0)
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// setOnlySelected shows only the selected columns, or all of them again.
+func (m model) setOnlySelected(only bool) model {
+	current := -1
+	if columns := m.columns(); m.column < len(columns) {
+		current = columns[m.column]
+	}
+	m.onlySelected = only
+	m.column = 0
+	for i, c := range m.columns() {
+		if c == current {
+			m.column = i
+		}
+	}
+	return m.scrollColumns()
+}
+
+// findMatch moves to the next row containing the search, going down from
+// the row after the cursor for 1, up for -1, or from the cursor for 0.
+func (m model) findMatch(direction int) model {
+	if m.search == "" {
+		m.message = "Nothing to search for; start a search with /"
+		return m
+	}
+	v := View{Filter: m.search}
+	step := direction
+	if step == 0 {
+		step = 1
+	}
+	for i := 0; i < len(m.rows); i++ {
+		index := m.cursor + direction + i*step
+		index = ((index % len(m.rows)) + len(m.rows)) % len(m.rows)
+		if v.matches(m.table.Rows[m.rows[index]]) {
+			return m.moveCursor(index - m.cursor)
+		}
+	}
+	m.message = fmt.Sprintf("No rows match %q", m.search)
+	return m
+}
+
+func (m model) moveCursor(delta int) model {
+	if len(m.rows) == 0 {
+		return m
+	}
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	return m
+}
+
+// scrollColumns scrolls sideways so that the current column is on screen.
+func (m model) scrollColumns() model {
+	m.colOffset = min(m.colOffset, m.column)
+	columns := m.columns()
+	for m.colOffset < m.column && m.span(columns[m.colOffset:m.column+1]) > m.width {
+		m.colOffset++
+	}
+	return m
+}
+
+// span is the screen width that columns take up.
+func (m model) span(columns []int) int {
+	width := 1
+	for _, c := range columns {
+		width += m.widths[c] + 2
+	}
+	return width
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+	title := fmt.Sprintf("%s  %d rows × %d columns", m.path, len(m.table.Rows), len(m.table.Header))
+	if m.table.Truncated {
+		title += fmt.Sprintf(" (only the first %d rows)", maxRows)
+	}
+	sb.WriteString(titleStyle.Render(title) + "\n")
+
+	columns := m.columns()
+	visible := columns[min(m.colOffset, len(columns)):]
+	header := make([]string, 0, len(visible))
+	width := 1
+	for i, c := range visible {
+		if width+m.widths[c] > m.width && i > 0 {
+			visible = visible[:i]
+			break
+		}
+		width += m.widths[c] + 2
+		name := m.pad(c, m.table.Header[c])
+		switch {
+		case m.colOffset+i == m.column:
+			name = columnStyle.Render(name)
+		case m.selected[c]:
+			name = selectedStyle.Render(name)
+		default:
+			name = headerStyle.Render(name)
+		}
+		header = append(header, name)
+	}
+	sb.WriteString(" " + strings.Join(header, "  ") + "\n")
+
+	if len(m.rows) == 0 {
+		sb.WriteString(helpStyle.Render("  (no rows)") + "\n")
+	}
+	search := strings.ToLower(m.search)
+	end := min(len(m.rows), m.offset+m.height)
+	for i := m.offset; i < end; i++ {
+		row := m.table.Rows[m.rows[i]]
+		cells := make([]string, len(visible))
+		for j, c := range visible {
+			cells[j] = m.pad(c, row[c])
+			if search != "" && strings.Contains(strings.ToLower(row[c]), search) {
+				cells[j] = matchStyle.Render(cells[j])
+			}
+		}
+		prefix := " "
+		if i == m.cursor {
+			prefix = cursorStyle.Render("›")
+		}
+		sb.WriteString(prefix + strings.Join(cells, "  ") + "\n")
+	}
+
+	sb.WriteString(m.status() + "\n")
+	sb.WriteString(helpStyle.Render("↑↓←→: move • /: search • n/N: next/prev • f: filter • space: select column • v: only selected • e: print command • q: quit"))
+	return sb.String()
+}
+
+// pad fits text to the width of column c, numbers on the right.
+func (m model) pad(c int, text string) string {
+	width := m.widths[c]
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	padding := strings.Repeat(" ", width-len(runes))
+	if m.numeric[c] {
+		return padding + text
+	}
+	return text + padding
+}
+
+func (m model) status() string {
+	if m.searching {
+		return "/" + m.input + "█"
+	}
+	if m.message != "" {
+		return m.message
+	}
+	columns := m.columns()
+	parts := []string{fmt.Sprintf("row %d/%d", min(m.cursor+1, len(m.rows)), len(m.rows))}
+	if len(columns) > 0 {
+		parts = append(parts, fmt.Sprintf("column %d/%d %s", m.column+1, len(columns), m.table.Header[columns[m.column]]))
+	}
+	selected := 0
+	for _, s := range m.selected {
+		if s {
+			selected++
+		}
+	}
+	if selected > 0 {
+		parts = append(parts, fmt.Sprintf("%d selected", selected))
+	}
+	if m.search != "" {
+		label := "search"
+		if m.filtering {
+			label = "filter"
+		}
+		parts = append(parts, fmt.Sprintf("%s %q", label, m.search))
+	}
+	return helpStyle.Render(strings.Join(parts, " • "))
+}
+
+// Run shows t, read from path, and returns the command for what was shown
+// if the user asked for it.
+func Run(t Table, path string) (string, error) {
+	result, err := tea.NewProgram(newModel(t, path), tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", err
+	}
+	return result.(model).command, nil
+}
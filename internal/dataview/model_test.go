@@ -0,0 +1,83 @@
+package dataview
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTable() Table {
+	return Table{
+		Format:    CSV,
+		Delimiter: ',',
+		HasHeader: true,
+		Header:    []string{"name", "age", "city"},
+		Rows: [][]string{
+			{"Ada", "36", "London"},
+			{"Alan", "41", "Wilmslow"},
+			{"Grace", "85", "Arlington"},
+		},
+	}
+}
+
+func press(m model, keys ...string) model {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "right":
+			msg = tea.KeyMsg{Type: tea.KeyRight}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(model)
+	}
+	return m
+}
+
+func TestModelLayout(t *testing.T) {
+	m := newModel(testTable(), "people.csv")
+	assert.Equal(t, []int{5, 3, 9}, m.widths)
+	assert.Equal(t, []bool{false, true, false}, m.numeric)
+	assert.Equal(t, " 36", m.pad(1, "36"))
+	assert.Equal(t, "Ada  ", m.pad(0, "Ada"))
+	assert.Contains(t, m.View(), "people.csv  3 rows × 3 columns")
+}
+
+func TestModelSearch(t *testing.T) {
+	m := newModel(testTable(), "people.csv")
+	m = press(m, "/", "l", "o", "n", "enter")
+	assert.Equal(t, "lon", m.search)
+	assert.Equal(t, 0, m.cursor)
+
+	m = press(m, "/", "a", "r", "l", "enter")
+	assert.Equal(t, 2, m.cursor)
+	m = press(m, "n")
+	assert.Equal(t, 2, m.cursor, "the only match stays selected")
+
+	m = press(m, "f")
+	assert.Equal(t, []int{2}, m.rows)
+	assert.Equal(t, 0, m.cursor)
+	m = press(m, "f")
+	assert.Len(t, m.rows, 3)
+}
+
+func TestModelColumnsAndCommand(t *testing.T) {
+	m := newModel(testTable(), "people.csv")
+	m = press(m, " ", "right", "right", " ", "v")
+	assert.Equal(t, []int{0, 2}, m.columns())
+	assert.Equal(t, 1, m.column, "the current column stays current")
+
+	m = press(m, "e")
+	assert.Equal(t, "cut -d , -f 1,3 people.csv", m.command)
+
+	m = press(newModel(testTable(), "people.csv"), "/", "a", "d", "a", "enter", "f", "e")
+	require.NotEmpty(t, m.command)
+	assert.Equal(t, `awk -F , -v OFS=, 'NR == 1 || index(tolower($0), "ada")' people.csv`, m.command)
+}
@@ -0,0 +1,130 @@
+package dataview
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// View is what the viewer shows of a table: some of its columns, and
+// possibly only the rows matching a search.
+type View struct {
+	// Columns are the indexes of the columns shown, in order; none means all
+	Columns []int
+	// Filter, if not empty, keeps the rows containing it, ignoring case
+	Filter string
+}
+
+// matches reports whether row is kept by the view's filter.
+func (v View) matches(row []string) bool {
+	if v.Filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(strings.Join(row, " ")), strings.ToLower(v.Filter))
+}
+
+var jqIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Command returns a shell command that prints what v shows of t, read from
+// path: cut or awk for CSV and TSV, jq for JSON Lines.
+func Command(t Table, path string, v View) string {
+	file := quote(path)
+	if t.Format == JSONL {
+		return jqCommand(t, file, v)
+	}
+
+	all := len(v.Columns) == 0 || len(v.Columns) == len(t.Header)
+	delimiter := quote(string(t.Delimiter))
+	switch {
+	case v.Filter == "" && all:
+		return fmt.Sprintf("column -s %s -t %s", delimiter, file)
+	case v.Filter == "" && ascending(v.Columns):
+		fields := make([]string, len(v.Columns))
+		for i, c := range v.Columns {
+			fields[i] = strconv.Itoa(c + 1)
+		}
+		if t.Delimiter == '\t' {
+			return fmt.Sprintf("cut -f %s %s", strings.Join(fields, ","), file)
+		}
+		return fmt.Sprintf("cut -d %s -f %s %s", delimiter, strings.Join(fields, ","), file)
+	}
+
+	var program strings.Builder
+	if v.Filter != "" {
+		if t.HasHeader {
+			program.WriteString("NR == 1 || ")
+		}
+		fmt.Fprintf(&program, "index(tolower($0), %s)", awkString(strings.ToLower(v.Filter)))
+	}
+	if !all {
+		fields := make([]string, len(v.Columns))
+		for i, c := range v.Columns {
+			fields[i] = "$" + strconv.Itoa(c+1)
+		}
+		fmt.Fprintf(&program, " { print %s }", strings.Join(fields, ", "))
+	}
+	return fmt.Sprintf("awk -F %s -v OFS=%s %s %s", delimiter, delimiter, quote(strings.TrimSpace(program.String())), file)
+}
+
+func jqCommand(t Table, file string, v View) string {
+	var filters []string
+	if v.Filter != "" {
+		filters = append(filters, fmt.Sprintf("select(tostring | ascii_downcase | contains(%s))", jsonString(strings.ToLower(v.Filter))))
+	}
+	if len(v.Columns) > 0 && len(v.Columns) < len(t.Header) {
+		fields := make([]string, len(v.Columns))
+		for i, c := range v.Columns {
+			key := t.Header[c]
+			if jqIdentifier.MatchString(key) {
+				fields[i] = key
+			} else {
+				fields[i] = fmt.Sprintf("%s: .[%s]", jsonString(key), jsonString(key))
+			}
+		}
+		filters = append(filters, "{"+strings.Join(fields, ", ")+"}")
+	}
+	if len(filters) == 0 {
+		filters = []string{"."}
+	}
+	return fmt.Sprintf("jq -c %s %s", quote(strings.Join(filters, " | ")), file)
+}
+
+// Caveat explains why the command for t may not give the same result as
+// the view, or returns "" if it will.
+func Caveat(t Table) string {
+	if t.Format != JSONL && t.Quoted {
+		return "the file has quoted fields, which cut and awk split at any delimiter inside them"
+	}
+	return ""
+}
+
+func ascending(columns []int) bool {
+	for i := 1; i < len(columns); i++ {
+		if columns[i] <= columns[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+func awkString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func quote(s string) string {
+	quoted, err := syntax.Quote(s, syntax.LangBash)
+	if err != nil {
+		return s
+	}
+	return quoted
+}
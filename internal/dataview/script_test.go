@@ -0,0 +1,37 @@
+package dataview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	csv := Table{Format: CSV, Delimiter: ',', HasHeader: true, Header: []string{"name", "age", "city"}}
+	tsv := Table{Format: TSV, Delimiter: '\t', HasHeader: true, Header: []string{"file", "size"}}
+	jsonl := Table{Format: JSONL, HasHeader: true, Header: []string{"level", "msg", "user id"}}
+
+	tests := []struct {
+		name  string
+		table Table
+		view  View
+		want  string
+	}{
+		{"everything", csv, View{}, `column -s , -t 'my file.csv'`},
+		{"columns", csv, View{Columns: []int{0, 2}}, `cut -d , -f 1,3 'my file.csv'`},
+		{"tsv columns", tsv, View{Columns: []int{1}}, `cut -f 2 'my file.csv'`},
+		{"filter", csv, View{Filter: `Lon"don`}, `awk -F , -v OFS=, 'NR == 1 || index(tolower($0), "lon\"don")' 'my file.csv'`},
+		{"filter and columns", tsv, View{Columns: []int{1}, Filter: "x"}, `awk -F $'\t' -v OFS=$'\t' 'NR == 1 || index(tolower($0), "x") { print $2 }' 'my file.csv'`},
+		{"jsonl", jsonl, View{}, `jq -c . 'my file.csv'`},
+		{"jsonl columns and filter", jsonl, View{Columns: []int{0, 2}, Filter: "Err"}, `jq -c 'select(tostring | ascii_downcase | contains("err")) | {level, "user id": .["user id"]}' 'my file.csv'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Command(tt.table, "my file.csv", tt.view))
+		})
+	}
+
+	noHeader := csv
+	noHeader.HasHeader = false
+	assert.Equal(t, `awk -F , -v OFS=, 'index(tolower($0), "a") { print $1 }' f`, Command(noHeader, "f", View{Columns: []int{0}, Filter: "a"}))
+}
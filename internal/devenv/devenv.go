@@ -0,0 +1,181 @@
+// Package devenv detects the development environment the shell runs in: a
+// nix shell, a devcontainer, or a toolbox or distrobox container.
+package devenv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Kind is a kind of development environment.
+type Kind string
+
+const (
+	Nix          Kind = "nix"
+	Devcontainer Kind = "devcontainer"
+	Toolbox      Kind = "toolbox"
+	Distrobox    Kind = "distrobox"
+)
+
+// Env is a development environment the shell runs in.
+type Env struct {
+	Kind Kind
+	// Name is the name of the shell or container, if known
+	Name string
+}
+
+// String names the environment, e.g. nix:hello-shell or toolbox.
+func (e Env) String() string {
+	if e.Name == "" {
+		return string(e.Kind)
+	}
+	return string(e.Kind) + ":" + e.Name
+}
+
+// Detect returns the development environments the shell runs in, outermost
+// first, e.g. a nix shell inside a devcontainer. getenv looks up environment
+// variables and readFile reads marker files.
+func Detect(getenv func(string) string, readFile func(string) ([]byte, error)) []Env {
+	var envs []Env
+
+	switch {
+	case getenv("CODESPACES") == "true":
+		envs = append(envs, Env{Kind: Devcontainer, Name: getenv("CODESPACE_NAME")})
+	case getenv("REMOTE_CONTAINERS") == "true" || getenv("DEVCONTAINER") == "true":
+		envs = append(envs, Env{Kind: Devcontainer, Name: getenv("DEVCONTAINER_NAME")})
+	}
+
+	// Both toolbox and distrobox run podman containers, which describe
+	// themselves in /run/.containerenv
+	containerEnv, containerErr := readFile("/run/.containerenv")
+	if _, err := readFile("/run/.toolboxenv"); err == nil {
+		envs = append(envs, Env{Kind: Toolbox, Name: containerEnvName(containerEnv)})
+	} else if getenv("DISTROBOX_ENTER_PATH") != "" || (getenv("CONTAINER_ID") != "" && containerErr == nil) {
+		name := getenv("CONTAINER_ID")
+		if name == "" {
+			name = containerEnvName(containerEnv)
+		}
+		envs = append(envs, Env{Kind: Distrobox, Name: name})
+	}
+
+	if getenv("IN_NIX_SHELL") != "" {
+		// nix-shell and nix develop name the shell after its derivation
+		envs = append(envs, Env{Kind: Nix, Name: getenv("name")})
+	}
+	return envs
+}
+
+// containerEnvName returns the container name from /run/.containerenv, which
+// has lines like name="fedora-toolbox-40".
+func containerEnvName(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, "name="); ok {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+var current = sync.OnceValue(func() []Env {
+	return Detect(os.Getenv, os.ReadFile)
+})
+
+// Current returns the development environments bish runs in.
+func Current() []Env {
+	return current()
+}
+
+// Names joins the environments for the BISH_DEVENV variable, e.g.
+// "devcontainer nix:hello-shell".
+func Names(envs []Env) string {
+	names := make([]string, len(envs))
+	for i, env := range envs {
+		names[i] = env.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// OverlayFiles returns the rc files that configure bish for envs, such as
+// ~/.bishrc.nix, in the order they are loaded.
+func OverlayFiles(home string, envs []Env) []string {
+	files := make([]string, len(envs))
+	for i, env := range envs {
+		files[i] = filepath.Join(home, ".bishrc."+string(env.Kind))
+	}
+	return files
+}
+
+// Describe explains envs to the agent, with how software should be installed
+// in each of them.
+func Describe(envs []Env) string {
+	var lines []string
+	for _, env := range envs {
+		switch env.Kind {
+		case Nix:
+			lines = append(lines, "Running inside a nix shell ("+env.String()+"). Tools come from the nix environment: "+
+				"add missing packages to flake.nix or shell.nix and re-enter with nix develop, or try one with nix shell nixpkgs#<package>, "+
+				"rather than installing them with apt, brew or pip.")
+		case Devcontainer:
+			lines = append(lines, "Running inside a devcontainer ("+env.String()+"). Packages installed by hand are lost when the container is rebuilt: "+
+				"add them to .devcontainer/devcontainer.json (features) or its Dockerfile.")
+		case Toolbox:
+			lines = append(lines, "Running inside a toolbox container ("+env.String()+") that shares the home directory with the host. "+
+				"Install packages with the container's package manager (e.g. sudo dnf install); run host commands with flatpak-spawn --host.")
+		case Distrobox:
+			lines = append(lines, "Running inside a distrobox container ("+env.String()+") that shares the home directory with the host. "+
+				"Install packages with the container's package manager; run host commands with distrobox-host-exec.")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// systemInstallers are the package managers whose installs a nix shell or
+// devcontainer doesn't keep.
+var systemInstallers = map[string]string{
+	"apt":     "install",
+	"apt-get": "install",
+	"dnf":     "install",
+	"yum":     "install",
+	"pacman":  "-S",
+	"brew":    "install",
+	"apk":     "add",
+}
+
+// InstallHint suggests the way to install software that suits envs when
+// command installs a system package, or returns "".
+func InstallHint(envs []Env, command string) string {
+	fields := strings.Fields(command)
+	if len(fields) > 0 && fields[0] == "sudo" {
+		fields = fields[1:]
+	}
+	if len(fields) < 3 {
+		return ""
+	}
+	verb, ok := systemInstallers[fields[0]]
+	if !ok || fields[1] != verb {
+		return ""
+	}
+	pkg := ""
+	for _, field := range fields[2:] {
+		if !strings.HasPrefix(field, "-") {
+			pkg = field
+			break
+		}
+	}
+	if pkg == "" {
+		return ""
+	}
+
+	// The innermost environment decides
+	for i := len(envs) - 1; i >= 0; i-- {
+		switch envs[i].Kind {
+		case Nix:
+			return "You're in a nix shell: add " + pkg + " to flake.nix or shell.nix and re-enter with nix develop, or try it with nix shell nixpkgs#" + pkg
+		case Devcontainer:
+			return "You're in a devcontainer: " + fields[0] + " installs are lost on rebuild; add " + pkg + " to .devcontainer/devcontainer.json or its Dockerfile"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package devenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeEnv(vars map[string]string, files map[string]string) (func(string) string, func(string) ([]byte, error)) {
+	return func(name string) string { return vars[name] },
+		func(path string) ([]byte, error) {
+			content, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return []byte(content), nil
+		}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		files    map[string]string
+		expected []Env
+	}{
+		{name: "plain shell"},
+		{
+			name:     "nix develop",
+			vars:     map[string]string{"IN_NIX_SHELL": "impure", "name": "hello-shell"},
+			expected: []Env{{Kind: Nix, Name: "hello-shell"}},
+		},
+		{
+			name:     "nix shell in a codespace",
+			vars:     map[string]string{"CODESPACES": "true", "CODESPACE_NAME": "fluffy-goggles", "IN_NIX_SHELL": "pure"},
+			expected: []Env{{Kind: Devcontainer, Name: "fluffy-goggles"}, {Kind: Nix}},
+		},
+		{
+			name:     "vscode devcontainer",
+			vars:     map[string]string{"REMOTE_CONTAINERS": "true"},
+			expected: []Env{{Kind: Devcontainer}},
+		},
+		{
+			name:     "toolbox",
+			files:    map[string]string{"/run/.toolboxenv": "", "/run/.containerenv": "engine=\"podman\"\nname=\"fedora-toolbox-40\"\n"},
+			expected: []Env{{Kind: Toolbox, Name: "fedora-toolbox-40"}},
+		},
+		{
+			name:     "distrobox",
+			vars:     map[string]string{"CONTAINER_ID": "ubuntu-dev"},
+			files:    map[string]string{"/run/.containerenv": "name=\"ubuntu-dev\"\n"},
+			expected: []Env{{Kind: Distrobox, Name: "ubuntu-dev"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv, readFile := fakeEnv(tt.vars, tt.files)
+			assert.Equal(t, tt.expected, Detect(getenv, readFile))
+		})
+	}
+}
+
+func TestNamesAndOverlays(t *testing.T) {
+	envs := []Env{{Kind: Devcontainer}, {Kind: Nix, Name: "hello-shell"}}
+	assert.Equal(t, "devcontainer nix:hello-shell", Names(envs))
+	assert.Equal(t, []string{"/home/u/.bishrc.devcontainer", "/home/u/.bishrc.nix"}, OverlayFiles("/home/u", envs))
+	assert.Empty(t, Names(nil))
+}
+
+func TestInstallHint(t *testing.T) {
+	nix := []Env{{Kind: Devcontainer}, {Kind: Nix}}
+	assert.Equal(t, "You're in a nix shell: add ripgrep to flake.nix or shell.nix and re-enter with nix develop, or try it with nix shell nixpkgs#ripgrep",
+		InstallHint(nix, "sudo apt-get install -y ripgrep"))
+	assert.Contains(t, InstallHint([]Env{{Kind: Devcontainer}}, "apt install jq"), "add jq to .devcontainer/devcontainer.json")
+	assert.Empty(t, InstallHint([]Env{{Kind: Toolbox}}, "sudo dnf install jq"))
+	assert.Empty(t, InstallHint(nix, "apt list --installed"))
+	assert.Empty(t, InstallHint(nix, "apt-get install -y"))
+	assert.Empty(t, InstallHint(nil, "brew install jq"))
+}
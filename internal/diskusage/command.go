@@ -0,0 +1,69 @@
+package diskusage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/robottwo/bishop/internal/trash"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// ExploreFunc shows the explorer for a directory and returns the shell
+// commands equivalent to what was done in it.
+type ExploreFunc func(root string, trash TrashFunc) ([]string, error)
+
+// RecordFunc adds a command that duv performed to history.
+type RecordFunc func(command string, exitCode int)
+
+// NewDuvCommandHandler creates an ExecHandler for the duv builtin, which
+// explores the disk usage of a directory, the current one by default.
+// Deleted entries go to the trash unless the user asks otherwise, and the
+// du, mv and rm commands equivalent to what was done are recorded with
+// record.
+func NewDuvCommandHandler(explore ExploreFunc, record RecordFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "duv" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) > 2 {
+				fmt.Fprintln(hc.Stderr, "Usage: duv [directory]")
+				return interp.NewExitStatus(2)
+			}
+			root := hc.Dir
+			if len(args) == 2 {
+				if args[1] == "-h" || args[1] == "--help" {
+					fmt.Fprintln(hc.Stdout, "Usage: duv [directory]")
+					return nil
+				}
+				root = args[1]
+				if !filepath.IsAbs(root) {
+					root = filepath.Join(hc.Dir, root)
+				}
+			}
+
+			bin, err := trash.Default()
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "duv: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			commands, err := explore(root, func(path string) (string, error) {
+				return bin.Move(path, time.Now())
+			})
+			if record != nil {
+				for _, command := range commands {
+					record(command, 0)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "duv: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+}
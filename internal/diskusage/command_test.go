@@ -0,0 +1,62 @@
+package diskusage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runDuv(t *testing.T, dir, script string, explore ExploreFunc) (history []string, stderr string, err error) {
+	t.Helper()
+	record := func(command string, exitCode int) {
+		history = append(history, command)
+	}
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &out, &out),
+		interp.Dir(dir),
+		interp.ExecHandlers(NewDuvCommandHandler(explore, record)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return history, out.String(), err
+}
+
+func TestDuvRecordsCommands(t *testing.T) {
+	dir := t.TempDir()
+	var explored string
+	explore := func(root string, trash TrashFunc) ([]string, error) {
+		explored = root
+		return []string{"du -sh -- " + root, "rm -rf -- " + root + "/cache"}, nil
+	}
+
+	history, _, err := runDuv(t, dir, "duv projects", explore)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "projects"), explored)
+	assert.Equal(t, []string{"du -sh -- " + explored, "rm -rf -- " + explored + "/cache"}, history)
+
+	_, _, err = runDuv(t, dir, "duv", explore)
+	require.NoError(t, err)
+	assert.Equal(t, dir, explored)
+}
+
+func TestDuvError(t *testing.T) {
+	explore := func(string, TrashFunc) ([]string, error) {
+		return nil, errors.New("permission denied")
+	}
+	_, stderr, err := runDuv(t, t.TempDir(), "duv /root/secret", explore)
+	_, isExit := interp.IsExitStatus(err)
+	assert.True(t, isExit)
+	assert.Contains(t, stderr, "duv: permission denied")
+}
@@ -0,0 +1,333 @@
+package diskusage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+var (
+	titleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	dirStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	barStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+)
+
+const (
+	defaultRows     = 20
+	barWidth        = 12
+	progressTimeout = 100 * time.Millisecond
+)
+
+type scanDoneMsg struct {
+	root *Node
+	err  error
+}
+
+type progressMsg struct{}
+
+// TrashFunc moves a path to the trash and returns where it went.
+type TrashFunc func(path string) (string, error)
+
+// model scans a directory, then lets the user walk the tree, largest
+// entries first, and delete what is not needed.
+type model struct {
+	rootPath string
+	progress *Progress
+	ctx      context.Context
+	cancel   context.CancelFunc
+	root     *Node
+	err      error
+
+	dir        *Node
+	cursor     int
+	offset     int
+	rows       int
+	byName     bool
+	showHidden bool
+
+	// pending is the entry waiting for the user to confirm its deletion
+	pending   *Node
+	permanent bool
+	message   string
+
+	trash  TrashFunc
+	remove func(string) error
+	// commands are the shell equivalents of what was done, for history
+	commands []string
+}
+
+func newModel(rootPath string, trash TrashFunc) model {
+	ctx, cancel := context.WithCancel(context.Background())
+	return model{
+		rootPath: rootPath,
+		progress: &Progress{},
+		ctx:      ctx,
+		cancel:   cancel,
+		rows:     defaultRows,
+		trash:    trash,
+		remove:   os.RemoveAll,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.scan(), tickProgress())
+}
+
+func (m model) scan() tea.Cmd {
+	return func() tea.Msg {
+		root, err := Scan(m.ctx, m.rootPath, m.progress)
+		return scanDoneMsg{root: root, err: err}
+	}
+}
+
+func tickProgress() tea.Cmd {
+	return tea.Tick(progressTimeout, func(time.Time) tea.Msg { return progressMsg{} })
+}
+
+// entries are the entries of the current directory, as shown.
+func (m model) entries() []*Node {
+	return Entries(m.dir, m.byName, m.showHidden)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the title, a message and help
+		m.rows = max(3, msg.Height-5)
+		return m, nil
+	case progressMsg:
+		if m.root == nil && m.err == nil {
+			return m, tickProgress()
+		}
+		return m, nil
+	case scanDoneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.root, m.dir = msg.root, msg.root
+		m.commands = append(m.commands, "du -sh -- "+quote(m.root.Path))
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" || (m.dir == nil && (key == "q" || key == "esc")) {
+		return m.quit()
+	}
+	if m.dir == nil {
+		return m, nil
+	}
+
+	if m.pending != nil {
+		if key == "y" || key == "Y" {
+			m = m.delete(m.pending, m.permanent)
+		} else {
+			m.message = ""
+		}
+		m.pending = nil
+		return m, nil
+	}
+
+	entries := m.entries()
+	m.message = ""
+	switch key {
+	case "q", "esc":
+		return m.quit()
+	case "up", "k":
+		m = m.moveCursor(-1, len(entries))
+	case "down", "j":
+		m = m.moveCursor(1, len(entries))
+	case "enter", "right", "l":
+		if m.cursor < len(entries) && entries[m.cursor].Dir {
+			m.dir = entries[m.cursor]
+			m.cursor, m.offset = 0, 0
+		}
+	case "left", "h", "backspace":
+		if m.dir.Parent != nil {
+			child := m.dir
+			m.dir = m.dir.Parent
+			m.cursor, m.offset = 0, 0
+			for i, entry := range m.entries() {
+				if entry == child {
+					m = m.moveCursor(i, len(m.entries()))
+				}
+			}
+		}
+	case "s":
+		m.byName = !m.byName
+		m.cursor, m.offset = 0, 0
+	case ".":
+		m.showHidden = !m.showHidden
+		m.cursor, m.offset = 0, 0
+	case "d", "D":
+		if m.cursor < len(entries) {
+			m.pending = entries[m.cursor]
+			m.permanent = key == "D"
+		}
+	}
+	return m, nil
+}
+
+func (m model) quit() (tea.Model, tea.Cmd) {
+	m.cancel()
+	return m, tea.Quit
+}
+
+func (m model) moveCursor(delta, count int) model {
+	if count == 0 {
+		return m
+	}
+	m.cursor = max(0, min(count-1, m.cursor+delta))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.rows {
+		m.offset = m.cursor - m.rows + 1
+	}
+	return m
+}
+
+// delete moves node to the trash, or removes it for good when permanent,
+// and takes it out of the tree.
+func (m model) delete(node *Node, permanent bool) model {
+	if permanent {
+		if err := m.remove(node.Path); err != nil {
+			m.message = errorStyle.Render(err.Error())
+			return m
+		}
+		m.commands = append(m.commands, "rm -rf -- "+quote(node.Path))
+		m.message = fmt.Sprintf("Deleted %s, freeing %s", node.Name, FormatSize(node.Size))
+	} else {
+		dest, err := m.trash(node.Path)
+		if err != nil {
+			m.message = errorStyle.Render(err.Error())
+			return m
+		}
+		m.commands = append(m.commands, "mv -- "+quote(node.Path)+" "+quote(dest))
+		m.message = fmt.Sprintf("Moved %s to the trash, freeing %s", node.Name, FormatSize(node.Size))
+	}
+	Detach(node)
+	m = m.moveCursor(0, len(m.entries()))
+	return m
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+	if m.err != nil {
+		sb.WriteString(errorStyle.Render("duv: "+m.err.Error()) + "\n")
+		sb.WriteString(helpStyle.Render("q: quit") + "\n")
+		return sb.String()
+	}
+	if m.dir == nil {
+		sb.WriteString(titleStyle.Render("Scanning "+m.rootPath) + "\n")
+		sb.WriteString(fmt.Sprintf("%d files, %s\n", m.progress.Files.Load(), FormatSize(m.progress.Bytes.Load())))
+		sb.WriteString(helpStyle.Render("q: cancel") + "\n")
+		return sb.String()
+	}
+
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s  %s", m.dir.Path, FormatSize(m.dir.Size))) + "\n")
+	entries := m.entries()
+	if len(entries) == 0 {
+		sb.WriteString(helpStyle.Render("  (empty)") + "\n")
+	}
+	if m.dir.Err != nil {
+		sb.WriteString(errorStyle.Render("  "+m.dir.Err.Error()) + "\n")
+	}
+	var largest int64
+	for _, entry := range entries {
+		largest = max(largest, entry.Size)
+	}
+	end := min(len(entries), m.offset+m.rows)
+	for i := m.offset; i < end; i++ {
+		sb.WriteString(m.row(entries[i], largest, i == m.cursor) + "\n")
+	}
+
+	switch {
+	case m.pending != nil && m.permanent:
+		sb.WriteString(warnStyle.Render(fmt.Sprintf("Delete %s (%s) for good? It cannot be restored. y/n", m.pending.Path, FormatSize(m.pending.Size))) + "\n")
+	case m.pending != nil:
+		sb.WriteString(warnStyle.Render(fmt.Sprintf("Move %s (%s) to the trash? y/n", m.pending.Path, FormatSize(m.pending.Size))) + "\n")
+	case m.message != "":
+		sb.WriteString(m.message + "\n")
+	}
+
+	sortKey, hiddenKey := "name", "show"
+	if m.byName {
+		sortKey = "size"
+	}
+	if m.showHidden {
+		hiddenKey = "hide"
+	}
+	sb.WriteString(helpStyle.Render(fmt.Sprintf("↑↓: move • enter/←: open/up • s: sort by %s • .: %s hidden • d: trash • D: delete • q: quit", sortKey, hiddenKey)) + "\n")
+	return sb.String()
+}
+
+func (m model) row(node *Node, largest int64, selected bool) string {
+	filled := 0
+	if largest > 0 {
+		filled = int(float64(barWidth) * float64(node.Size) / float64(largest))
+	}
+	bar := barStyle.Render(strings.Repeat("█", filled)) + strings.Repeat(" ", barWidth-filled)
+	name := node.Name
+	if node.Dir {
+		name = dirStyle.Render(name + "/")
+	}
+	line := fmt.Sprintf("%9s [%s] %s", FormatSize(node.Size), bar, name)
+	if selected {
+		return cursorStyle.Render("›") + line
+	}
+	return " " + line
+}
+
+// FormatSize prints a size the way du -h does, e.g. 4.0K or 1.2G.
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	size, suffix := float64(bytes)/unit, "K"
+	for _, next := range []string{"M", "G", "T", "P"} {
+		if size < unit {
+			break
+		}
+		size, suffix = size/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", size, suffix)
+}
+
+func quote(path string) string {
+	quoted, err := syntax.Quote(path, syntax.LangBash)
+	if err != nil {
+		return path
+	}
+	return quoted
+}
+
+// Run shows the explorer for root and returns the shell commands that are
+// equivalent to what was done.
+func Run(root string, trash TrashFunc) ([]string, error) {
+	program := tea.NewProgram(newModel(root, trash), tea.WithAltScreen())
+	result, err := program.Run()
+	if err != nil {
+		return nil, err
+	}
+	m := result.(model)
+	if m.err != nil {
+		return m.commands, m.err
+	}
+	return m.commands, nil
+}
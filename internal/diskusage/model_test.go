@@ -0,0 +1,121 @@
+package diskusage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendKeys(m model, keys ...string) model {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "left":
+			msg = tea.KeyMsg{Type: tea.KeyLeft}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	return m
+}
+
+// scannedModel returns an explorer that has scanned root, with a trash that
+// records what it was given.
+func scannedModel(t *testing.T, root string, trashed *[]string) model {
+	t.Helper()
+	m := newModel(root, func(path string) (string, error) {
+		*trashed = append(*trashed, path)
+		return "/trash/" + filepath.Base(path), nil
+	})
+	node, err := Scan(context.Background(), root, m.progress)
+	require.NoError(t, err)
+	updated, _ := m.Update(scanDoneMsg{root: node})
+	return updated.(model)
+}
+
+func TestExplorerNavigates(t *testing.T) {
+	root := writeTree(t, map[string]int{"logs/app.log": 5000, "logs/old.log": 100, "notes.txt": 10, ".env": 20})
+	var trashed []string
+	m := scannedModel(t, root, &trashed)
+
+	view := m.View()
+	assert.Contains(t, view, root+"  5.0K")
+	assert.Contains(t, view, "logs/")
+	assert.Contains(t, view, "notes.txt")
+	assert.NotContains(t, view, ".env")
+
+	m = sendKeys(m, ".")
+	assert.Contains(t, m.View(), ".env")
+
+	m = sendKeys(m, "enter")
+	assert.Contains(t, m.View(), filepath.Join(root, "logs"))
+	assert.Contains(t, m.View(), "app.log")
+	m = sendKeys(m, "left")
+	assert.Equal(t, root, m.dir.Path)
+	assert.Equal(t, "logs", m.entries()[m.cursor].Name)
+}
+
+func TestExplorerTrashesAndDeletes(t *testing.T) {
+	root := writeTree(t, map[string]int{"logs/app.log": 5000, "notes.txt": 10})
+	var trashed, removed []string
+	m := scannedModel(t, root, &trashed)
+	m.remove = func(path string) error {
+		removed = append(removed, path)
+		return nil
+	}
+
+	m = sendKeys(m, "d")
+	assert.Contains(t, m.View(), "Move "+filepath.Join(root, "logs")+" (4.9K) to the trash? y/n")
+	m = sendKeys(m, "n")
+	assert.Empty(t, trashed)
+
+	m = sendKeys(m, "d", "y")
+	assert.Equal(t, []string{filepath.Join(root, "logs")}, trashed)
+	assert.Contains(t, m.View(), "Moved logs to the trash, freeing 4.9K")
+	assert.Equal(t, int64(10), m.root.Size)
+
+	m = sendKeys(m, "D")
+	assert.Contains(t, m.View(), "for good? It cannot be restored.")
+	m = sendKeys(m, "y")
+	assert.Equal(t, []string{filepath.Join(root, "notes.txt")}, removed)
+	assert.Contains(t, m.View(), "(empty)")
+
+	assert.Equal(t, []string{
+		"du -sh -- " + root,
+		"mv -- " + filepath.Join(root, "logs") + " /trash/logs",
+		"rm -rf -- " + filepath.Join(root, "notes.txt"),
+	}, m.commands)
+}
+
+func TestExplorerReportsTrashErrors(t *testing.T) {
+	root := writeTree(t, map[string]int{"big": 10})
+	m := newModel(root, func(string) (string, error) { return "", errors.New("big is on another filesystem than the trash") })
+	node, err := Scan(context.Background(), root, m.progress)
+	require.NoError(t, err)
+	updated, _ := m.Update(scanDoneMsg{root: node})
+	m = sendKeys(updated.(model), "d", "y")
+	assert.Contains(t, m.View(), "another filesystem")
+	assert.Equal(t, int64(10), m.root.Size)
+}
+
+func TestExplorerScanning(t *testing.T) {
+	m := newModel("/somewhere", nil)
+	m.progress.Files.Store(42)
+	m.progress.Bytes.Store(2048)
+	assert.Contains(t, m.View(), "Scanning /somewhere")
+	assert.Contains(t, m.View(), "42 files, 2.0K")
+
+	updated, _ := m.Update(scanDoneMsg{err: errors.New("permission denied")})
+	assert.Contains(t, updated.View(), "duv: permission denied")
+}
@@ -0,0 +1,168 @@
+// Package diskusage implements duv, an interactive explorer of the disk
+// usage of a directory tree in the spirit of ncdu.
+package diskusage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Node is a file or directory in a scanned tree. Sizes are apparent sizes,
+// with directories the sum of their contents.
+type Node struct {
+	Name     string
+	Path     string
+	Size     int64
+	Dir      bool
+	Parent   *Node
+	Children []*Node
+	// Err is why the directory could not be read, if it couldn't
+	Err error
+}
+
+// Hidden reports whether the node is a dotfile.
+func (n *Node) Hidden() bool {
+	return strings.HasPrefix(n.Name, ".")
+}
+
+// Progress counts what a scan has seen so far; it is safe to read while the
+// scan runs.
+type Progress struct {
+	Files atomic.Int64
+	Bytes atomic.Int64
+}
+
+// Scan reads the tree under root, reading directories in parallel. Symbolic
+// links are counted but not followed.
+func Scan(ctx context.Context, root string, progress *Progress) (*Node, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{Name: abs, Path: abs, Size: info.Size(), Dir: info.IsDir()}
+	if !node.Dir {
+		return node, nil
+	}
+
+	s := &scanner{ctx: ctx, progress: progress, slots: make(chan struct{}, 4*runtime.NumCPU())}
+	s.scanDir(node)
+	s.wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sumSizes(node)
+	return node, nil
+}
+
+type scanner struct {
+	ctx      context.Context
+	progress *Progress
+	// slots bounds the goroutines reading directories; when none is free
+	// a directory is read by the goroutine that found it
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func (s *scanner) scanDir(dir *Node) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir.Path)
+	if err != nil {
+		dir.Err = err
+		return
+	}
+	dir.Children = make([]*Node, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		child := &Node{Name: entry.Name(), Path: filepath.Join(dir.Path, entry.Name()), Dir: entry.IsDir(), Parent: dir}
+		if !child.Dir {
+			child.Size = info.Size()
+			s.progress.Bytes.Add(child.Size)
+		}
+		s.progress.Files.Add(1)
+		dir.Children = append(dir.Children, child)
+	}
+	for _, child := range dir.Children {
+		if !child.Dir {
+			continue
+		}
+		select {
+		case s.slots <- struct{}{}:
+			s.wg.Add(1)
+			go func(child *Node) {
+				defer s.wg.Done()
+				defer func() { <-s.slots }()
+				s.scanDir(child)
+			}(child)
+		default:
+			s.scanDir(child)
+		}
+	}
+}
+
+// sumSizes sets the size of each directory to that of its contents.
+func sumSizes(node *Node) int64 {
+	if !node.Dir {
+		return node.Size
+	}
+	var total int64
+	for _, child := range node.Children {
+		total += sumSizes(child)
+	}
+	node.Size = total
+	return total
+}
+
+// Detach removes node from its parent, taking its size off every directory
+// above it.
+func Detach(node *Node) {
+	parent := node.Parent
+	if parent == nil {
+		return
+	}
+	for i, child := range parent.Children {
+		if child == node {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	for dir := parent; dir != nil; dir = dir.Parent {
+		dir.Size -= node.Size
+	}
+	node.Parent = nil
+}
+
+// Entries returns the children of dir to show: largest first, or by name,
+// and without dotfiles unless hidden is true.
+func Entries(dir *Node, byName, hidden bool) []*Node {
+	var entries []*Node
+	for _, child := range dir.Children {
+		if hidden || !child.Hidden() {
+			entries = append(entries, child)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if byName {
+			return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+		}
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
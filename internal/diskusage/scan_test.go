@@ -0,0 +1,99 @@
+package diskusage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates files of the given sizes under a temporary directory.
+func writeTree(t *testing.T, files map[string]int) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644))
+	}
+	return root
+}
+
+func child(t *testing.T, node *Node, name string) *Node {
+	t.Helper()
+	for _, c := range node.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("%s has no child %s", node.Path, name)
+	return nil
+}
+
+func TestScan(t *testing.T) {
+	root := writeTree(t, map[string]int{
+		"a.txt":            100,
+		"logs/one.log":     1000,
+		"logs/old/two.log": 2000,
+		".cache/blob":      500,
+		"empty/.keep":      0,
+	})
+	require.NoError(t, os.Symlink(filepath.Join(root, "logs"), filepath.Join(root, "link")))
+
+	progress := &Progress{}
+	node, err := Scan(context.Background(), root, progress)
+	require.NoError(t, err)
+	assert.True(t, node.Dir)
+	assert.Equal(t, int64(3600)+symlinkSize(t, filepath.Join(root, "link")), node.Size)
+	assert.Equal(t, int64(3000), child(t, node, "logs").Size)
+	assert.Equal(t, int64(2000), child(t, child(t, node, "logs"), "old").Size)
+	assert.False(t, child(t, node, "link").Dir, "symlinks are not followed")
+	assert.Equal(t, int64(10), progress.Files.Load())
+	assert.Same(t, node, child(t, node, "logs").Parent)
+}
+
+func symlinkSize(t *testing.T, path string) int64 {
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestScanCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Scan(ctx, writeTree(t, map[string]int{"a/b": 1}), &Progress{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestEntriesAndDetach(t *testing.T) {
+	root := writeTree(t, map[string]int{"big": 300, "small": 10, "Mid": 100, ".hidden": 1000, "dir/inner": 50})
+	node, err := Scan(context.Background(), root, &Progress{})
+	require.NoError(t, err)
+
+	names := func(nodes []*Node) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"big", "Mid", "dir", "small"}, names(Entries(node, false, false)))
+	assert.Equal(t, []string{".hidden", "big", "Mid", "dir", "small"}, names(Entries(node, false, true)))
+	assert.Equal(t, []string{"big", "dir", "Mid", "small"}, names(Entries(node, true, false)))
+
+	inner := child(t, child(t, node, "dir"), "inner")
+	Detach(inner)
+	assert.Equal(t, int64(0), child(t, node, "dir").Size)
+	assert.Equal(t, int64(1410), node.Size)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512B", FormatSize(512))
+	assert.Equal(t, "4.0K", FormatSize(4096))
+	assert.Equal(t, "1.5M", FormatSize(3<<19))
+	assert.Equal(t, "2.0G", FormatSize(2<<30))
+}
@@ -0,0 +1,133 @@
+// Package doctor implements "bish doctor", which reports on how bish is
+// doing on this machine, such as how much memory what it loads at each
+// prompt takes.
+package doctor
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/dustin/go-humanize"
+	"github.com/robottwo/bishop/internal/completion"
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// Options configures RunCommand.
+type Options struct {
+	// HistoryPath is the history database bish uses
+	HistoryPath string
+}
+
+// MemoryReport is how much memory bish takes, and what for.
+type MemoryReport struct {
+	// HistoryEntries are the commands the prompt loads, which take
+	// HistoryBytes
+	HistoryEntries int
+	HistoryBytes   uint64
+	// CompletionCommands are the commands with static completions, which
+	// take CompletionBytes once completed
+	CompletionCommands int
+	CompletionBytes    uint64
+	// HeapInUse is the memory in use once both are loaded, out of HeapSys
+	// taken from the system
+	HeapInUse, HeapSys uint64
+}
+
+// RunCommand implements "bish doctor" and returns its exit code.
+func RunCommand(args []string, opts Options, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: bish doctor")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Reports how much memory bish takes for history and completions.")
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	historyManager, err := history.NewHistoryManager(opts.HistoryPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "doctor: %v\n", err)
+		return 1
+	}
+	defer func() { _ = historyManager.Close() }()
+
+	report, err := MeasureMemory(historyManager)
+	if err != nil {
+		fmt.Fprintf(stderr, "doctor: %v\n", err)
+		return 1
+	}
+	WriteMemoryReport(stdout, report)
+	return 0
+}
+
+// MeasureMemory loads history as the prompt does, and the static
+// completions, and measures how much memory each takes.
+func MeasureMemory(historyManager *history.HistoryManager) (MemoryReport, error) {
+	var report MemoryReport
+	var entries []history.HistoryEntry
+	var err error
+	report.HistoryBytes = measure(func() any {
+		err = historyManager.EachEntry(func(entry history.HistoryEntry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+		return entries
+	})
+	if err != nil {
+		return MemoryReport{}, err
+	}
+	report.HistoryEntries = len(entries)
+
+	completer := completion.NewStaticCompleter()
+	report.CompletionBytes = measure(func() any {
+		report.CompletionCommands = len(completer.GetRegisteredCommands())
+		return completer
+	})
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	report.HeapInUse, report.HeapSys = stats.HeapInuse, stats.HeapSys
+	runtime.KeepAlive(entries)
+	runtime.KeepAlive(completer)
+	return report, nil
+}
+
+// measure returns how much more of the heap is in use once load is done,
+// keeping what it returns alive until then.
+func measure(load func() any) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	loaded := load()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(loaded)
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+// WriteMemoryReport prints report.
+func WriteMemoryReport(w io.Writer, report MemoryReport) {
+	fmt.Fprintln(w, "Memory")
+	fmt.Fprintf(w, "  %-20s %10s commands  %10s\n", "history", humanize.Comma(int64(report.HistoryEntries)),
+		humanize.Bytes(report.HistoryBytes))
+	fmt.Fprintf(w, "  %-20s %10s commands  %10s\n", "static completions", humanize.Comma(int64(report.CompletionCommands)),
+		humanize.Bytes(report.CompletionBytes))
+	fmt.Fprintf(w, "  %-20s %30s in use, %s from the system\n", "heap", humanize.Bytes(report.HeapInUse),
+		humanize.Bytes(report.HeapSys))
+}
@@ -0,0 +1,31 @@
+package doctor
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	historyManager, err := history.NewHistoryManager(path)
+	require.NoError(t, err)
+	for _, command := range []string{"ls", "make", "git status"} {
+		_, err := historyManager.StartCommand(command, "/src", "s1")
+		require.NoError(t, err)
+	}
+	require.NoError(t, historyManager.Close())
+
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(nil, Options{HistoryPath: path}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Memory\n")
+	assert.Regexp(t, `history\s+3 commands`, stdout.String())
+	assert.Regexp(t, `static completions\s+[1-9][0-9,]* commands`, stdout.String())
+
+	assert.Equal(t, 2, RunCommand([]string{"extra"}, Options{HistoryPath: path}, &stdout, &stderr))
+}
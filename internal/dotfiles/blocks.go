@@ -0,0 +1,208 @@
+// Package dotfiles generates a commented starter ~/.bishrc and keeps the
+// sections it generated up to date without touching the user's own edits.
+// Features of bish that write to rc files do it through SetBlock,
+// AppendToBlock and RemoveBlock, each in a managed section of its own, so
+// that doing it again changes nothing, the change shows as one section in a
+// diff, and it can be taken out without touching the rest of the file.
+package dotfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Managed sections are wrapped in marker comments. The begin marker records a
+// checksum of the generated content, so a section the user edited by hand can
+// be recognized and left alone when the file is regenerated:
+//
+//	# >>> bish:aliases 1a2b3c4d >>>
+//	alias ll='ls -l'
+//	# <<< bish:aliases <<<
+var beginMarker = regexp.MustCompile(`^# >>> bish:([a-z0-9-]+) ([0-9a-f]{8}) >>>$`)
+
+func endMarker(id string) string {
+	return "# <<< bish:" + id + " <<<"
+}
+
+// checksum returns a short hash of a section's content.
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// renderBlock wraps content in markers.
+func renderBlock(id, content string) string {
+	content = strings.TrimRight(content, "\n")
+	return fmt.Sprintf("# >>> bish:%s %s >>>\n%s\n%s\n", id, checksum(content), content, endMarker(id))
+}
+
+// block is a managed section found in an existing file.
+type block struct {
+	id       string
+	checksum string
+	content  string
+	// start and end are the line indexes of the begin and end markers
+	start, end int
+}
+
+// edited reports whether the content no longer matches what was generated.
+func (b block) edited() bool {
+	return checksum(b.content) != b.checksum
+}
+
+// findBlocks returns the managed sections in lines. Begin markers without a
+// matching end marker are ignored.
+func findBlocks(lines []string) []block {
+	var blocks []block
+	for i := 0; i < len(lines); i++ {
+		match := beginMarker.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if lines[j] == endMarker(match[1]) {
+				blocks = append(blocks, block{
+					id:       match[1],
+					checksum: match[2],
+					content:  strings.Join(lines[i+1:j], "\n"),
+					start:    i,
+					end:      j,
+				})
+				i = j
+				break
+			}
+		}
+	}
+	return blocks
+}
+
+// Report lists what Update did with each section.
+type Report struct {
+	Added     []string
+	Updated   []string
+	Unchanged []string
+	// Skipped lists sections that were edited by hand and left alone.
+	Skipped []string
+}
+
+// Update replaces the managed sections of existing with freshly generated
+// ones and appends sections that are missing. Text outside the markers is
+// never changed, and sections edited by hand are only replaced when force is
+// set.
+func Update(existing string, sections []Section, force bool) (string, Report) {
+	var report Report
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if existing == "" {
+		lines = nil
+	}
+
+	found := map[string]block{}
+	for _, b := range findBlocks(lines) {
+		if _, ok := found[b.id]; !ok {
+			found[b.id] = b
+		}
+	}
+
+	replacements := map[int]block{}
+	var appended []string
+	for _, section := range sections {
+		b, ok := found[section.ID]
+		switch {
+		case !ok:
+			appended = append(appended, renderBlock(section.ID, section.Content))
+			report.Added = append(report.Added, section.ID)
+		case b.content == strings.TrimRight(section.Content, "\n") && !b.edited():
+			report.Unchanged = append(report.Unchanged, section.ID)
+		case b.edited() && !force:
+			report.Skipped = append(report.Skipped, section.ID)
+		default:
+			b.content = section.Content
+			replacements[b.start] = b
+			report.Updated = append(report.Updated, section.ID)
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(lines); i++ {
+		if b, ok := replacements[i]; ok {
+			sb.WriteString(renderBlock(b.id, b.content))
+			i = b.end
+			continue
+		}
+		sb.WriteString(lines[i] + "\n")
+	}
+	for _, text := range appended {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(text)
+	}
+	return sb.String(), report
+}
+
+// AppendToSection adds text at the end of the managed section id of
+// existing, creating the section at the end of the file if there is none.
+// Unlike Update, it keeps whatever the section already holds, including the
+// user's edits.
+func AppendToSection(existing, id, text string) string {
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if existing == "" {
+		lines = nil
+	}
+	for _, b := range findBlocks(lines) {
+		if b.id != id {
+			continue
+		}
+		content := text
+		if b.content != "" {
+			content = b.content + "\n\n" + text
+		}
+		var sb strings.Builder
+		for _, line := range lines[:b.start] {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString(renderBlock(id, content))
+		for _, line := range lines[b.end+1:] {
+			sb.WriteString(line + "\n")
+		}
+		return sb.String()
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(renderBlock(id, text))
+	return sb.String()
+}
+
+// Remove takes the managed section id, markers and all, out of existing,
+// along with the blank line that separates it from what comes before.
+func Remove(existing, id string) string {
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	for _, b := range findBlocks(lines) {
+		if b.id != id {
+			continue
+		}
+		start := b.start
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+		var sb strings.Builder
+		for _, line := range lines[:start] {
+			sb.WriteString(line + "\n")
+		}
+		for _, line := range lines[b.end+1:] {
+			sb.WriteString(line + "\n")
+		}
+		return sb.String()
+	}
+	return existing
+}
@@ -0,0 +1,73 @@
+package dotfiles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateAppendsMissingSections(t *testing.T) {
+	existing := "export EDITOR=vim\n"
+	updated, report := Update(existing, []Section{{ID: "aliases", Content: "alias ll='ls -l'"}}, false)
+
+	assert.Equal(t, "export EDITOR=vim\n\n"+
+		"# >>> bish:aliases "+checksum("alias ll='ls -l'")+" >>>\n"+
+		"alias ll='ls -l'\n"+
+		"# <<< bish:aliases <<<\n", updated)
+	assert.Equal(t, []string{"aliases"}, report.Added)
+}
+
+func TestUpdateReplacesManagedSections(t *testing.T) {
+	existing := "# mine\n" +
+		renderBlock("aliases", "alias ll='ls -l'") +
+		"export EDITOR=vim\n"
+
+	updated, report := Update(existing, []Section{{ID: "aliases", Content: "alias ll='ls -lh'"}}, false)
+	assert.Equal(t, "# mine\n"+renderBlock("aliases", "alias ll='ls -lh'")+"export EDITOR=vim\n", updated)
+	assert.Equal(t, []string{"aliases"}, report.Updated)
+
+	// Regenerating the same content changes nothing
+	again, report := Update(updated, []Section{{ID: "aliases", Content: "alias ll='ls -lh'"}}, false)
+	assert.Equal(t, updated, again)
+	assert.Equal(t, []string{"aliases"}, report.Unchanged)
+}
+
+func TestUpdateKeepsEditedSections(t *testing.T) {
+	existing := renderBlock("aliases", "alias ll='ls -l'")
+	edited := strings.Replace(existing, "alias ll='ls -l'", "alias ll='ls -la'\nalias k=kubectl", 1)
+
+	updated, report := Update(edited, []Section{{ID: "aliases", Content: "alias ll='ls -lh'"}}, false)
+	assert.Equal(t, edited, updated)
+	assert.Equal(t, []string{"aliases"}, report.Skipped)
+
+	updated, report = Update(edited, []Section{{ID: "aliases", Content: "alias ll='ls -lh'"}}, true)
+	assert.Equal(t, renderBlock("aliases", "alias ll='ls -lh'"), updated)
+	assert.Equal(t, []string{"aliases"}, report.Updated)
+}
+
+func TestFindBlocksIgnoresUnterminatedMarkers(t *testing.T) {
+	lines := strings.Split("# >>> bish:prompt 00000000 >>>\nBISH_PROMPT='> '\n", "\n")
+	assert.Empty(t, findBlocks(lines))
+}
+
+func TestAppendToSection(t *testing.T) {
+	// A missing section is created at the end
+	updated := AppendToSection("export EDITOR=vim\n", "functions", "f() { :; }\n")
+	assert.Equal(t, "export EDITOR=vim\n\n"+renderBlock("functions", "f() { :; }"), updated)
+	assert.Equal(t, renderBlock("functions", "f() { :; }"), AppendToSection("", "functions", "f() { :; }"))
+
+	// An existing one grows in place, keeping what follows it
+	updated = AppendToSection(updated+"# mine\n", "functions", "g() { :; }")
+	assert.Equal(t, "export EDITOR=vim\n\n"+renderBlock("functions", "f() { :; }\n\ng() { :; }")+"# mine\n", updated)
+}
+
+func TestRemove(t *testing.T) {
+	existing := "export EDITOR=vim\n\n" + renderBlock("fned", "source x") + "# mine\n"
+	assert.Equal(t, "export EDITOR=vim\n# mine\n", Remove(existing, "fned"))
+	assert.Equal(t, existing, Remove(existing, "aliases"))
+
+	// Adding a section and removing it gives back the file
+	added, _ := Update("export EDITOR=vim\n", []Section{{ID: "fned", Content: "source x"}}, false)
+	assert.Equal(t, "export EDITOR=vim\n", Remove(added, "fned"))
+}
@@ -0,0 +1,267 @@
+package dotfiles
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Options configures RunCommand. Empty paths default to ~/.bishrc and the
+// config UI file.
+type Options struct {
+	RcPath       string
+	ConfigUIPath string
+}
+
+// RunCommand implements "bish init-dotfiles" and returns its exit code.
+func RunCommand(args []string, opts Options, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("init-dotfiles", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	nonInteractive := flags.Bool("non-interactive", false, "regenerate managed sections without asking")
+	themeName := flags.String("theme", "", "prompt theme: "+themeNames())
+	sectionList := flags.String("sections", "", "comma separated sections to generate: "+strings.Join(SectionIDs, ","))
+	force := flags.Bool("force", false, "also regenerate, or remove, sections that were edited by hand")
+	dryRun := flags.Bool("dry-run", false, "print the result instead of writing it")
+	list := flags.Bool("list", false, "list the managed sections of the file, including those other bish features wrote")
+	remove := flags.String("remove", "", "comma separated managed sections to take out of the file")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: bish init-dotfiles [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Generates a commented starter ~/.bishrc, or updates the sections it manages.")
+		fmt.Fprintln(stderr, "With --list or --remove, lists or takes out the sections bish manages.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if opts.RcPath == "" || opts.ConfigUIPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+			return 1
+		}
+		if opts.RcPath == "" {
+			opts.RcPath = filepath.Join(home, ".bishrc")
+		}
+		if opts.ConfigUIPath == "" {
+			opts.ConfigUIPath = filepath.Join(home, ".config", "bish", "config_ui")
+		}
+	}
+
+	if *list {
+		return listBlocks(stdout, stderr, opts.RcPath)
+	}
+	if *remove != "" {
+		return removeBlocks(stdout, stderr, opts.RcPath, strings.Split(*remove, ","), *force)
+	}
+
+	existingBytes, err := os.ReadFile(opts.RcPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+		return 1
+	}
+	exists := err == nil
+	existing := string(existingBytes)
+
+	models, err := LoadWizardChoices(opts.ConfigUIPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "init-dotfiles: reading wizard settings: %v\n", err)
+		return 1
+	}
+	choices := Choices{Theme: *themeName, Models: models, ConfigUIPath: opts.ConfigUIPath}
+
+	input := bufio.NewReader(stdin)
+	if choices.Theme == "" {
+		choices.Theme = CurrentTheme(existing)
+		if choices.Theme == "" {
+			choices.Theme = DefaultTheme
+		}
+		if !*nonInteractive {
+			choices.Theme = askTheme(input, stdout, choices.Theme)
+		}
+	}
+	if _, ok := FindTheme(choices.Theme); !ok {
+		fmt.Fprintf(stderr, "init-dotfiles: unknown theme %q (available: %s)\n", choices.Theme, themeNames())
+		return 2
+	}
+
+	var ids []string
+	if *sectionList != "" {
+		for _, id := range strings.Split(*sectionList, ",") {
+			ids = append(ids, strings.TrimSpace(id))
+		}
+	}
+
+	var content string
+	var report Report
+	if exists {
+		sections, err := Sections(choices, ids)
+		if err != nil {
+			fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+			return 2
+		}
+		content, report = Update(existing, sections, *force)
+	} else {
+		if content, err = Generate(choices); err != nil {
+			fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+			return 2
+		}
+		report.Added = SectionIDs
+	}
+
+	if *dryRun {
+		fmt.Fprint(stdout, content)
+		return 0
+	}
+
+	printReport(stdout, opts.RcPath, exists, report)
+	if content == existing {
+		fmt.Fprintf(stdout, "%s is up to date.\n", opts.RcPath)
+		return 0
+	}
+
+	if !*nonInteractive && !confirm(input, stdout, fmt.Sprintf("Write %s? [y/N] ", opts.RcPath)) {
+		fmt.Fprintln(stdout, "Nothing written.")
+		return 0
+	}
+
+	if exists {
+		backup := opts.RcPath + ".bak"
+		if err := os.WriteFile(backup, existingBytes, 0o644); err != nil {
+			fmt.Fprintf(stderr, "init-dotfiles: writing backup: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Saved the previous version to %s\n", backup)
+	}
+	if _, err := editRcFile(opts.RcPath, func(string) (string, error) { return content, nil }); err != nil {
+		fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Wrote %s. Start a new bish session to use it.\n", opts.RcPath)
+	return 0
+}
+
+// listBlocks prints the managed sections of the rc file at path.
+func listBlocks(stdout, stderr io.Writer, path string) int {
+	blocks, err := Blocks(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+		return 1
+	}
+	if len(blocks) == 0 {
+		fmt.Fprintf(stdout, "%s has no managed sections.\n", path)
+		return 0
+	}
+	for _, b := range blocks {
+		note := ""
+		if b.Edited {
+			note = "  (edited by hand)"
+		}
+		fmt.Fprintf(stdout, "%-12s line %d%s\n", b.ID, b.Line, note)
+	}
+	return 0
+}
+
+// removeBlocks takes the managed sections ids out of the rc file at path.
+func removeBlocks(stdout, stderr io.Writer, path string, ids []string, force bool) int {
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		removed, err := RemoveBlock(path, id, force)
+		if err != nil {
+			fmt.Fprintf(stderr, "init-dotfiles: %v\n", err)
+			if errors.Is(err, ErrEdited) {
+				fmt.Fprintln(stderr, "Use --force to remove it anyway.")
+			}
+			return 1
+		}
+		if removed {
+			fmt.Fprintf(stdout, "Removed section %s from %s.\n", id, path)
+		} else {
+			fmt.Fprintf(stdout, "%s has no section %s.\n", path, id)
+		}
+	}
+	return 0
+}
+
+func themeNames() string {
+	names := make([]string, len(Themes))
+	for i, theme := range Themes {
+		names[i] = theme.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// askTheme lets the user pick a theme by number, keeping current on an empty
+// answer or end of input.
+func askTheme(input *bufio.Reader, stdout io.Writer, current string) string {
+	fmt.Fprintln(stdout, "Prompt themes:")
+	defaultIndex := 1
+	for i, theme := range Themes {
+		fmt.Fprintf(stdout, "  %d) %-9s %s\n", i+1, theme.Name, theme.Description)
+		if theme.Name == current {
+			defaultIndex = i + 1
+		}
+	}
+	for {
+		fmt.Fprintf(stdout, "Choose a theme [%d]: ", defaultIndex)
+		line, err := input.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(stdout)
+			}
+			return Themes[defaultIndex-1].Name
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(Themes) {
+			return Themes[n-1].Name
+		}
+		if theme, ok := FindTheme(answer); ok {
+			return theme.Name
+		}
+		fmt.Fprintf(stdout, "Please enter a number between 1 and %d.\n", len(Themes))
+		if err != nil {
+			return Themes[defaultIndex-1].Name
+		}
+	}
+}
+
+func confirm(input *bufio.Reader, stdout io.Writer, prompt string) bool {
+	fmt.Fprint(stdout, prompt)
+	line, _ := input.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+func printReport(stdout io.Writer, path string, exists bool, report Report) {
+	if !exists {
+		fmt.Fprintf(stdout, "Creating %s with sections: %s\n", path, strings.Join(report.Added, ", "))
+		return
+	}
+	for _, line := range []struct {
+		label string
+		ids   []string
+	}{
+		{"Adding", report.Added},
+		{"Updating", report.Updated},
+		{"Unchanged", report.Unchanged},
+		{"Keeping your edits to", report.Skipped},
+	} {
+		if len(line.ids) > 0 {
+			fmt.Fprintf(stdout, "%s: %s\n", line.label, strings.Join(line.ids, ", "))
+		}
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Fprintln(stdout, "Use --force to regenerate edited sections.")
+	}
+}
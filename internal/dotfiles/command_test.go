@@ -0,0 +1,137 @@
+package dotfiles
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runInitDotfiles(t *testing.T, opts Options, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(args, opts, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func testOptions(t *testing.T) Options {
+	dir := t.TempDir()
+	return Options{RcPath: filepath.Join(dir, ".bishrc"), ConfigUIPath: filepath.Join(dir, "config_ui")}
+}
+
+func TestRunCommandInteractiveCreate(t *testing.T) {
+	opts := testOptions(t)
+
+	// Pick the third theme, then confirm
+	code, stdout, _ := runInitDotfiles(t, opts, "3\ny\n")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "1) minimal")
+	assert.Contains(t, stdout, "Creating "+opts.RcPath+" with sections: models, prompt, aliases, macros, subagents")
+
+	content, err := os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	assert.Equal(t, "git", CurrentTheme(string(content)))
+}
+
+func TestRunCommandInteractiveDecline(t *testing.T) {
+	opts := testOptions(t)
+
+	code, stdout, _ := runInitDotfiles(t, opts, "\nn\n")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Nothing written.")
+	_, err := os.Stat(opts.RcPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunCommandNonInteractiveRegenerate(t *testing.T) {
+	opts := testOptions(t)
+	code, _, _ := runInitDotfiles(t, opts, "", "--non-interactive", "--theme", "classic")
+	require.Equal(t, 0, code)
+
+	// The user edits the aliases section and adds their own settings
+	content, err := os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	edited := strings.Replace(string(content), "alias gd='git diff'", "alias gd='git diff --stat'", 1) + "export EDITOR=vim\n"
+	require.NoError(t, os.WriteFile(opts.RcPath, []byte(edited), 0o644))
+
+	// Wizard choices changed since the file was generated
+	require.NoError(t, os.WriteFile(opts.ConfigUIPath, []byte("export BISH_FAST_MODEL_PROVIDER='openai'\n"), 0o600))
+
+	code, stdout, _ := runInitDotfiles(t, opts, "", "--non-interactive")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Updating: models")
+	assert.Contains(t, stdout, "Keeping your edits to: aliases")
+
+	content, err = os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "#   BISH_FAST_MODEL_PROVIDER=openai")
+	assert.Contains(t, string(content), "alias gd='git diff --stat'")
+	assert.True(t, strings.HasSuffix(string(content), "export EDITOR=vim\n"))
+	assert.Equal(t, "classic", CurrentTheme(string(content)), "theme is kept when not given")
+
+	backup, err := os.ReadFile(opts.RcPath + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(backup))
+
+	code, stdout, _ = runInitDotfiles(t, opts, "", "--non-interactive", "--sections", "aliases", "--force")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Updating: aliases")
+	content, err = os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "alias gd='git diff'\n")
+
+	code, stdout, _ = runInitDotfiles(t, opts, "", "--non-interactive")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "is up to date")
+}
+
+func TestRunCommandAppendsToExistingFile(t *testing.T) {
+	opts := testOptions(t)
+	require.NoError(t, os.WriteFile(opts.RcPath, []byte("export EDITOR=vim\n"), 0o644))
+
+	code, stdout, _ := runInitDotfiles(t, opts, "", "--non-interactive", "--sections", "aliases")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Adding: aliases")
+
+	content, err := os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "export EDITOR=vim\n\n# >>> bish:aliases "))
+}
+
+func TestRunCommandDryRunAndErrors(t *testing.T) {
+	opts := testOptions(t)
+
+	code, stdout, _ := runInitDotfiles(t, opts, "", "--non-interactive", "--dry-run")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "# >>> bish:prompt ")
+	_, err := os.Stat(opts.RcPath)
+	assert.True(t, os.IsNotExist(err))
+
+	code, _, stderr := runInitDotfiles(t, opts, "", "--non-interactive", "--theme", "neon")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown theme "neon"`)
+}
+
+func TestRunCommandListAndRemove(t *testing.T) {
+	opts := testOptions(t)
+	code, stdout, _ := runInitDotfiles(t, opts, "", "--list")
+	require.Equal(t, 0, code)
+	assert.Equal(t, opts.RcPath+" has no managed sections.\n", stdout)
+
+	_, err := SetBlock(opts.RcPath, "fned", "source x")
+	require.NoError(t, err)
+	code, stdout, _ = runInitDotfiles(t, opts, "", "--list")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "fned         line 1\n", stdout)
+
+	code, stdout, _ = runInitDotfiles(t, opts, "", "--remove", "fned,aliases")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "Removed section fned from "+opts.RcPath+".\n"+opts.RcPath+" has no section aliases.\n", stdout)
+	content, err := os.ReadFile(opts.RcPath)
+	require.NoError(t, err)
+	assert.Empty(t, string(content))
+}
@@ -0,0 +1,120 @@
+package dotfiles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrEdited is returned when a managed section was edited by hand, in which
+// case it is left as it is.
+var ErrEdited = errors.New("edited by hand")
+
+// SetBlock makes the managed section id of the rc file at path hold content,
+// adding the section at the end of the file, which is created if needed. It
+// reports whether the file changed. A section edited by hand is left alone,
+// with an error wrapping ErrEdited.
+func SetBlock(path, id, content string) (bool, error) {
+	return editRcFile(path, func(existing string) (string, error) {
+		updated, report := Update(existing, []Section{{ID: id, Content: content}}, false)
+		if len(report.Skipped) > 0 {
+			return "", fmt.Errorf("%s: section %s was %w", path, id, ErrEdited)
+		}
+		return updated, nil
+	})
+}
+
+// AppendToBlock adds text at the end of the managed section id of the rc
+// file at path, keeping what the section holds, edits included. The file
+// and the section are created as needed.
+func AppendToBlock(path, id, text string) (bool, error) {
+	return editRcFile(path, func(existing string) (string, error) {
+		return AppendToSection(existing, id, text), nil
+	})
+}
+
+// RemoveBlock takes the managed section id out of the rc file at path, and
+// reports whether there was one. A section edited by hand is only removed
+// with force, and is otherwise left alone with an error wrapping ErrEdited.
+func RemoveBlock(path, id string, force bool) (bool, error) {
+	return editRcFile(path, func(existing string) (string, error) {
+		for _, b := range findBlocks(strings.Split(existing, "\n")) {
+			if b.id == id && b.edited() && !force {
+				return "", fmt.Errorf("%s: section %s was %w", path, id, ErrEdited)
+			}
+		}
+		return Remove(existing, id), nil
+	})
+}
+
+// BlockInfo describes a managed section of an rc file.
+type BlockInfo struct {
+	ID     string
+	Edited bool
+	// Line is where its begin marker is, from 1.
+	Line int
+}
+
+// Blocks returns the managed sections of the rc file at path, in order. A
+// missing file has none.
+func Blocks(path string) ([]BlockInfo, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var infos []BlockInfo
+	for _, b := range findBlocks(strings.Split(string(content), "\n")) {
+		infos = append(infos, BlockInfo{ID: b.id, Edited: b.edited(), Line: b.start + 1})
+	}
+	return infos, nil
+}
+
+// editRcFile rewrites the rc file at path with what edit makes of its
+// content, unless that is the same. The file is replaced in one step, so
+// that a shell starting meanwhile sources either version, and a symlink to
+// it, such as one a dotfiles manager made, is kept.
+func editRcFile(path string, edit func(existing string) (string, error)) (bool, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0o644)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return false, err
+	}
+
+	updated, err := edit(string(existing))
+	if err != nil {
+		return false, err
+	}
+	if updated == string(existing) {
+		return false, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.WriteString(updated)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	return err == nil, err
+}
@@ -0,0 +1,82 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bishrc")
+	require.NoError(t, os.WriteFile(path, []byte("export EDITOR=vim\n"), 0o600))
+
+	changed, err := SetBlock(path, "config-ui", "source ~/.config/bish/config_ui")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export EDITOR=vim\n\n"+renderBlock("config-ui", "source ~/.config/bish/config_ui"), string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Setting it again changes nothing
+	changed, err = SetBlock(path, "config-ui", "source ~/.config/bish/config_ui")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// A section edited by hand is kept
+	edited := strings.Replace(string(content), "config_ui\n", "config_ui\n# mine\n", 1) + "alias k=kubectl\n"
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0o600))
+	_, err = SetBlock(path, "config-ui", "source elsewhere")
+	assert.ErrorIs(t, err, ErrEdited)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(content))
+
+	blocks, err := Blocks(path)
+	require.NoError(t, err)
+	assert.Equal(t, []BlockInfo{{ID: "config-ui", Edited: true, Line: 3}}, blocks)
+
+	// and only removed with force
+	_, err = RemoveBlock(path, "config-ui", false)
+	assert.ErrorIs(t, err, ErrEdited)
+	removed, err := RemoveBlock(path, "config-ui", true)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export EDITOR=vim\nalias k=kubectl\n", string(content))
+}
+
+func TestAppendToBlockFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "bishrc")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	require.NoError(t, os.WriteFile(target, []byte("export EDITOR=vim\n"), 0o644))
+	link := filepath.Join(dir, ".bishrc")
+	require.NoError(t, os.Symlink(target, link))
+
+	_, err := AppendToBlock(link, "functions", "f() { :; }")
+	require.NoError(t, err)
+	_, err = AppendToBlock(link, "functions", "g() { :; }")
+	require.NoError(t, err)
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "export EDITOR=vim\n\n"+renderBlock("functions", "f() { :; }\n\ng() { :; }"), string(content))
+
+	// A missing file is created
+	path := filepath.Join(dir, "new", ".bishrc")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	changed, err := AppendToBlock(path, "functions", "f() { :; }")
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
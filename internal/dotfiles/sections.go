@@ -0,0 +1,276 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Section is a managed part of the generated .bishrc.
+type Section struct {
+	ID      string
+	Content string
+}
+
+// SectionIDs lists all sections in the order they are written.
+var SectionIDs = []string{"models", "prompt", "aliases", "macros", "subagents"}
+
+// Theme is a prompt style offered by init-dotfiles.
+type Theme struct {
+	Name        string
+	Description string
+	script      string
+}
+
+// DefaultTheme is used when no theme was chosen.
+const DefaultTheme = "minimal"
+
+// Themes lists the available prompt themes.
+var Themes = []Theme{
+	{
+		Name:        "minimal",
+		Description: "shortened directory and a red marker after failed commands",
+		script: `function BISH_UPDATE_PROMPT() {
+  local marker=">"
+  [ "$BISH_LAST_COMMAND_EXIT_CODE" != "0" ] && marker="$(printf '\033[31m>\033[0m')"
+  BISH_PROMPT="$(bish_path) $marker "
+}`,
+	},
+	{
+		Name:        "classic",
+		Description: "user@host:directory$, like bash",
+		script: `BISH_PROMPT_HOST="$(hostname -s 2>/dev/null || hostname)"
+function BISH_UPDATE_PROMPT() {
+  BISH_PROMPT="$USER@$BISH_PROMPT_HOST:$(bish_path --style home)\$ "
+}`,
+	},
+	{
+		Name:        "git",
+		Description: "directory relative to the repository root and the current branch",
+		script: `function BISH_UPDATE_PROMPT() {
+  local branch
+  branch="$(git branch --show-current 2>/dev/null)"
+  if [ -n "$branch" ]; then
+    BISH_PROMPT="$(bish_path --style git) ($branch) > "
+  else
+    BISH_PROMPT="$(bish_path --style home) > "
+  fi
+}`,
+	},
+	{
+		Name:        "starship",
+		Description: "starship prompt if installed (https://starship.rs), minimal otherwise",
+		script: `if command -v starship >/dev/null 2>&1; then
+  function BISH_UPDATE_PROMPT() {
+    BISH_PROMPT="$(starship prompt --status="$BISH_LAST_COMMAND_EXIT_CODE" --cmd-duration="$BISH_LAST_COMMAND_DURATION_MS")"
+  }
+  STARSHIP_SHELL="bish"
+  STARSHIP_SESSION_KEY="$(starship session)"
+  BISH_PROMPT="$(starship prompt --status=0 --cmd-duration=0)"
+else
+  function BISH_UPDATE_PROMPT() {
+    BISH_PROMPT="$(bish_path) > "
+  }
+fi`,
+	},
+	{
+		Name:        "plain",
+		Description: "the built-in bish> prompt",
+		script:      `BISH_PROMPT="bish> "`,
+	},
+}
+
+// Script returns the shell code that sets up the theme's prompt.
+func (t Theme) Script() string {
+	return t.script
+}
+
+// FindTheme looks up a theme by name.
+func FindTheme(name string) (Theme, bool) {
+	for _, theme := range Themes {
+		if strings.EqualFold(theme.Name, name) {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+var themeLine = regexp.MustCompile(`(?m)^# Theme: ([a-z]+)`)
+
+// CurrentTheme returns the theme recorded in the prompt section of an
+// existing file, or "" if there is none.
+func CurrentTheme(existing string) string {
+	for _, b := range findBlocks(strings.Split(existing, "\n")) {
+		if b.id != "prompt" {
+			continue
+		}
+		if match := themeLine.FindStringSubmatch(b.content); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// Choices are the inputs to the generated sections.
+type Choices struct {
+	// Theme names the prompt theme.
+	Theme string
+	// Models holds the model settings chosen in the setup wizard, keyed by
+	// variable name. API keys are never included.
+	Models map[string]string
+	// ConfigUIPath is the file written by the setup wizard and config UI.
+	ConfigUIPath string
+}
+
+// modelKeys are the wizard settings described in the models section.
+var modelKeys = []string{
+	"BISH_FAST_MODEL_PROVIDER", "BISH_FAST_MODEL_ID", "BISH_FAST_MODEL_BASE_URL",
+	"BISH_SLOW_MODEL_PROVIDER", "BISH_SLOW_MODEL_ID", "BISH_SLOW_MODEL_BASE_URL",
+}
+
+var exportLine = regexp.MustCompile(`^\s*export\s+([A-Z_]+)='((?:[^']|'\\'')*)'\s*$`)
+
+// LoadWizardChoices reads the model settings from the config UI file. A
+// missing file yields no settings.
+func LoadWizardChoices(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, key := range modelKeys {
+		wanted[key] = true
+	}
+	choices := map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if match := exportLine.FindStringSubmatch(line); match != nil && wanted[match[1]] {
+			choices[match[1]] = strings.ReplaceAll(match[2], `'\''`, "'")
+		}
+	}
+	return choices, nil
+}
+
+// Sections generates the sections with the given ids, or all sections if ids
+// is empty. Unknown ids are an error.
+func Sections(choices Choices, ids []string) ([]Section, error) {
+	if len(ids) == 0 {
+		ids = SectionIDs
+	}
+	var sections []Section
+	for _, id := range ids {
+		var content string
+		switch id {
+		case "models":
+			content = modelsSection(choices)
+		case "prompt":
+			theme, ok := FindTheme(choices.Theme)
+			if !ok {
+				return nil, fmt.Errorf("unknown theme %q", choices.Theme)
+			}
+			content = promptSection(theme)
+		case "aliases":
+			content = aliasesSection
+		case "macros":
+			content = macrosSection
+		case "subagents":
+			content = subagentsSection
+		default:
+			return nil, fmt.Errorf("unknown section %q (available: %s)", id, strings.Join(SectionIDs, ", "))
+		}
+		sections = append(sections, Section{ID: id, Content: content})
+	}
+	return sections, nil
+}
+
+// Generate returns a complete starter .bishrc.
+func Generate(choices Choices) (string, error) {
+	sections, err := Sections(choices, nil)
+	if err != nil {
+		return "", err
+	}
+	content, _ := Update(header, sections, false)
+	return content + "\n" + footer, nil
+}
+
+const header = `# ~/.bishrc - Bishop shell user configuration
+# Generated by "bish init-dotfiles". Sourced after built-in defaults on every
+# bish startup.
+#
+# Sections between "# >>> bish:..." and "# <<< bish:..." markers are managed:
+# "bish init-dotfiles --non-interactive" regenerates them, but leaves any
+# section you have edited alone unless you pass --force. Delete the markers
+# around a section to take it over completely.
+`
+
+const footer = `# -------- Custom Settings --------
+# Add your own settings below. This part of the file is never regenerated.
+# See available options: bish --help or docs/CONFIGURATION.md
+`
+
+func modelsSection(choices Choices) string {
+	var sb strings.Builder
+	sb.WriteString("# -------- Models --------\n")
+	if len(choices.Models) == 0 {
+		sb.WriteString("# No models configured yet. Run \"bish --setup\" to choose them.\n")
+	} else {
+		sb.WriteString("# Chosen in the setup wizard (change with \"bish --setup\" or #!config):\n")
+		keys := make([]string, 0, len(choices.Models))
+		for key := range choices.Models {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&sb, "#   %s=%s\n", key, choices.Models[key])
+		}
+	}
+	path := choices.ConfigUIPath
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+"/") {
+		path = "~" + strings.TrimPrefix(path, home)
+	}
+	fmt.Fprintf(&sb, "[ -f %s ] && source %s", path, path)
+	return sb.String()
+}
+
+func promptSection(theme Theme) string {
+	return fmt.Sprintf(`# -------- Prompt --------
+# Theme: %s - %s
+# Choose another with "bish init-dotfiles --non-interactive --theme <name>".
+%s`, theme.Name, theme.Description, theme.script)
+}
+
+const aliasesSection = `# -------- Aliases --------
+alias ll='ls -lh'
+alias la='ls -lAh'
+alias ..='cd ..'
+alias ...='cd ../..'
+alias gs='git status -sb'
+alias gd='git diff'
+alias gl='git log --oneline --graph --decorate -20'`
+
+const macrosSection = `# -------- Agent Macros --------
+# Macros are shortcuts for agent messages, run with #/<name>. Setting
+# BISH_AGENT_MACROS replaces the built-in gitdiff, gitpush and gitreview
+# macros, so copy them over from .bishrc.default if you still want them.
+# BISH_AGENT_MACROS='{
+#   "explain": "explain what the last command did and whether its output shows a problem",
+#   "cleanup": "find large or temporary files in the current directory that are safe to delete, but do not delete anything",
+#   "todo": "list the TODO and FIXME comments in this project, grouped by file"
+# }'`
+
+const subagentsSection = `# -------- Subagents --------
+# Subagents are specialized agents you can ask with ##<name>. Each one is a
+# Markdown file in ~/.claude/agents/ (or .claude/agents/ in a project), e.g.
+# ~/.claude/agents/reviewer.md:
+#
+#   ---
+#   name: reviewer
+#   description: Reviews code changes for bugs and style problems
+#   tools: view_file, bash
+#   ---
+#   You are a careful code reviewer. Point out bugs first, then style issues.`
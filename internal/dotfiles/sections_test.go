@@ -0,0 +1,63 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWizardChoices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config_ui")
+	require.NoError(t, os.WriteFile(path, []byte(
+		"export BISH_FAST_MODEL_PROVIDER='ollama'\n"+
+			"export BISH_FAST_MODEL_API_KEY='secret'\n"+
+			"export BISH_SLOW_MODEL_ID='it'\\''s-a-model'\n"+
+			"export BISH_ASSISTANT_HEIGHT='5'\n"), 0o600))
+
+	choices, err := LoadWizardChoices(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"BISH_FAST_MODEL_PROVIDER": "ollama",
+		"BISH_SLOW_MODEL_ID":       "it's-a-model",
+	}, choices)
+
+	choices, err = LoadWizardChoices(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, choices)
+}
+
+func TestGenerate(t *testing.T) {
+	content, err := Generate(Choices{
+		Theme:        "git",
+		Models:       map[string]string{"BISH_FAST_MODEL_PROVIDER": "ollama"},
+		ConfigUIPath: "/etc/bish/config_ui",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, content, "# Generated by \"bish init-dotfiles\"")
+	assert.Contains(t, content, "#   BISH_FAST_MODEL_PROVIDER=ollama\n[ -f /etc/bish/config_ui ] && source /etc/bish/config_ui\n")
+	assert.Contains(t, content, "# Theme: git - ")
+	assert.Contains(t, content, "alias gs='git status -sb'")
+	assert.Contains(t, content, "# -------- Custom Settings --------")
+	assert.Equal(t, "git", CurrentTheme(content))
+
+	blocks := findBlocks(strings.Split(content, "\n"))
+	ids := make([]string, len(blocks))
+	for i, b := range blocks {
+		ids[i] = b.id
+		assert.False(t, b.edited(), b.id)
+	}
+	assert.Equal(t, SectionIDs, ids)
+}
+
+func TestSectionsErrors(t *testing.T) {
+	_, err := Sections(Choices{Theme: "neon"}, []string{"prompt"})
+	assert.EqualError(t, err, `unknown theme "neon"`)
+
+	_, err = Sections(Choices{Theme: DefaultTheme}, []string{"keys"})
+	assert.EqualError(t, err, `unknown section "keys" (available: models, prompt, aliases, macros, subagents)`)
+}
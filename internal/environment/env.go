@@ -14,8 +14,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/robottwo/bishop/internal/pathfmt"
+	"github.com/robottwo/bishop/internal/redact"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
 )
 
@@ -173,6 +176,198 @@ func GetHistorySize(runner *interp.Runner, logger *zap.Logger) int {
 	return int(historySize)
 }
 
+// History sharing modes control how commands from other running bish instances
+// show up in Up/Down navigation and Ctrl+R search.
+const (
+	// HistorySharingPrompt picks up other shells' commands each time a new prompt is shown.
+	HistorySharingPrompt = "prompt"
+	// HistorySharingLive also polls for other shells' commands while the prompt is open.
+	HistorySharingLive = "live"
+	// HistorySharingIsolated only shows this session's commands and those recorded before it started.
+	HistorySharingIsolated = "isolated"
+)
+
+// History scopes control which commands Up/Down go through and Ctrl+R search
+// starts with.
+const (
+	// HistoryScopeDirectory only has the commands run in the current directory.
+	HistoryScopeDirectory = "directory"
+	// HistoryScopeSession only has the commands run in this session.
+	HistoryScopeSession = "session"
+	// HistoryScopeGlobal has all commands.
+	HistoryScopeGlobal = "global"
+)
+
+// GetHistIgnore returns BISH_HISTIGNORE, the colon-separated patterns of
+// the commands kept out of history.
+func GetHistIgnore(runner *interp.Runner) string {
+	return runner.Vars["BISH_HISTIGNORE"].String()
+}
+
+// GetHistoryRedact returns whether the secrets in commands, such as AWS keys,
+// bearer tokens and the values of --password, are masked before commands
+// are saved to history. Defaults to true; set BISH_HISTORY_REDACT=0 to opt
+// out.
+func GetHistoryRedact(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_HISTORY_REDACT"].String()
+	if override, ok := getSessionConfigOverride("BISH_HISTORY_REDACT"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetScriptLint reports whether the scripts a command line sources or runs
+// are linted first, with a summary of what is found. Defaults to true; set
+// BISH_SCRIPT_LINT=0 to opt out.
+func GetScriptLint(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_SCRIPT_LINT"].String()
+	if override, ok := getSessionConfigOverride("BISH_SCRIPT_LINT"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetBashCompletion reports whether commands that no other completer knows
+// are completed with the bash completion scripts installed for them.
+// Defaults to true; set BISH_BASH_COMPLETION=0 to opt out.
+func GetBashCompletion(runner *interp.Runner) bool {
+	switch strings.ToLower(strings.TrimSpace(runner.Vars["BISH_BASH_COMPLETION"].String())) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetBashCompletionDirs returns the directories of BISH_BASH_COMPLETION_DIRS,
+// separated like PATH, where bash completion scripts are looked for before
+// the usual places.
+func GetBashCompletionDirs(runner *interp.Runner) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(runner.Vars["BISH_BASH_COMPLETION_DIRS"].String()) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// GetHistorySyncURL returns BISH_HISTORY_SYNC_URL, where history is synced
+// between machines, or "" if it is not.
+func GetHistorySyncURL(runner *interp.Runner) string {
+	return strings.TrimSpace(runner.Vars["BISH_HISTORY_SYNC_URL"].String())
+}
+
+// GetHistorySharing returns the configured BISH_HISTORY_SHARING mode.
+// Defaults to HistorySharingPrompt if not set or unrecognized.
+func GetHistorySharing(runner *interp.Runner, logger *zap.Logger) string {
+	mode := runner.Vars["BISH_HISTORY_SHARING"].String()
+	if override, ok := getSessionConfigOverride("BISH_HISTORY_SHARING"); ok {
+		mode = override
+	}
+
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case HistorySharingLive, HistorySharingIsolated, HistorySharingPrompt:
+		return mode
+	case "":
+		return HistorySharingPrompt
+	default:
+		logger.Debug("unknown BISH_HISTORY_SHARING mode, using default", zap.String("mode", mode))
+		return HistorySharingPrompt
+	}
+}
+
+// GetHistoryScope returns the configured BISH_HISTORY_SCOPE. Defaults to
+// HistoryScopeDirectory if not set or unrecognized.
+func GetHistoryScope(runner *interp.Runner, logger *zap.Logger) string {
+	scope := runner.Vars["BISH_HISTORY_SCOPE"].String()
+	if override, ok := getSessionConfigOverride("BISH_HISTORY_SCOPE"); ok {
+		scope = override
+	}
+
+	switch scope = strings.ToLower(strings.TrimSpace(scope)); scope {
+	case HistoryScopeDirectory, HistoryScopeSession, HistoryScopeGlobal:
+		return scope
+	case "":
+		return HistoryScopeDirectory
+	default:
+		logger.Debug("unknown BISH_HISTORY_SCOPE, using default", zap.String("scope", scope))
+		return HistoryScopeDirectory
+	}
+}
+
+// Render profiles control how often the line is redrawn, for terminals over
+// slow links.
+const (
+	// RenderProfileAuto picks the profile from the latency of the terminal.
+	RenderProfileAuto = "auto"
+	// RenderProfileFull redraws as things change, with animations.
+	RenderProfileFull = "full"
+	// RenderProfileReduced draws fewer frames, without animations.
+	RenderProfileReduced = "reduced"
+	// RenderProfileMinimal draws the fewest frames.
+	RenderProfileMinimal = "minimal"
+)
+
+// GetRenderProfile returns the configured BISH_RENDER_PROFILE. Defaults to
+// RenderProfileAuto if not set or unrecognized.
+func GetRenderProfile(runner *interp.Runner, logger *zap.Logger) string {
+	profile := runner.Vars["BISH_RENDER_PROFILE"].String()
+	if override, ok := getSessionConfigOverride("BISH_RENDER_PROFILE"); ok {
+		profile = override
+	}
+
+	switch profile = strings.ToLower(strings.TrimSpace(profile)); profile {
+	case RenderProfileAuto, RenderProfileFull, RenderProfileReduced, RenderProfileMinimal:
+		return profile
+	case "":
+		return RenderProfileAuto
+	default:
+		logger.Debug("unknown BISH_RENDER_PROFILE, using default", zap.String("profile", profile))
+		return RenderProfileAuto
+	}
+}
+
+// Path correction modes control what happens when a command fails because a
+// path it names almost exists.
+const (
+	// PathCorrectionPrefill puts the corrected command on the next prompt.
+	PathCorrectionPrefill = "prefill"
+	// PathCorrectionHint only prints the corrected command.
+	PathCorrectionHint = "hint"
+	// PathCorrectionOff disables path correction.
+	PathCorrectionOff = "off"
+)
+
+// GetPathCorrection returns the configured BISH_PATH_CORRECTION mode.
+// Defaults to PathCorrectionPrefill if not set or unrecognized.
+func GetPathCorrection(runner *interp.Runner, logger *zap.Logger) string {
+	mode := runner.Vars["BISH_PATH_CORRECTION"].String()
+	if override, ok := getSessionConfigOverride("BISH_PATH_CORRECTION"); ok {
+		mode = override
+	}
+
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case PathCorrectionPrefill, PathCorrectionHint, PathCorrectionOff:
+		return mode
+	case "":
+		return PathCorrectionPrefill
+	default:
+		logger.Debug("unknown BISH_PATH_CORRECTION mode, using default", zap.String("mode", mode))
+		return PathCorrectionPrefill
+	}
+}
+
 func GetLogLevel(runner *interp.Runner) zap.AtomicLevel {
 	logLevel, err := zap.ParseAtomicLevel(runner.Vars["BISH_LOG_LEVEL"].String())
 	if err != nil {
@@ -193,6 +388,348 @@ func GetDefaultToYes(runner *interp.Runner) bool {
 	return defaultToYes == "1" || defaultToYes == "true"
 }
 
+// GetAutoPair returns whether quotes and brackets should be closed automatically
+// as they are typed in the input line. Off by default; set BISH_AUTOPAIR=1 to
+// opt in.
+func GetAutoPair(runner *interp.Runner) bool {
+	autoPair := runner.Vars["BISH_AUTOPAIR"].String()
+	if override, ok := getSessionConfigOverride("BISH_AUTOPAIR"); ok {
+		autoPair = override
+	}
+	autoPair = strings.ToLower(autoPair)
+	return autoPair == "1" || autoPair == "true"
+}
+
+// GetFollowUpSuggestions returns whether the command that usually follows the
+// last one in history is suggested on the empty prompt. Defaults to true; set
+// BISH_FOLLOW_UP_SUGGESTIONS=0 to opt out.
+func GetFollowUpSuggestions(runner *interp.Runner) bool {
+	switch strings.ToLower(strings.TrimSpace(runner.Vars["BISH_FOLLOW_UP_SUGGESTIONS"].String())) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetNextCommandRerank returns whether the LLM reorders the commands of the
+// Ctrl+Space menu, which are otherwise ranked from history alone.
+func GetNextCommandRerank(runner *interp.Runner) bool {
+	rerank := strings.ToLower(runner.Vars["BISH_NEXT_COMMAND_RERANK"].String())
+	return rerank == "1" || rerank == "true"
+}
+
+// GetCaptureOutput returns whether the stdout of each command is copied to
+// the file $LAST_OUT_FILE names. Off by default, since commands then write to
+// a pipe rather than the terminal; set BISH_CAPTURE_OUTPUT=1 to opt in.
+func GetCaptureOutput(runner *interp.Runner) bool {
+	capture := strings.ToLower(runner.Vars["BISH_CAPTURE_OUTPUT"].String())
+	return capture == "1" || capture == "true"
+}
+
+// GetFlagLearning returns whether the flags usually passed to each command
+// are learned from history for Alt+U and predictions. Defaults to true; set
+// BISH_FLAG_LEARNING=0 to opt out.
+func GetFlagLearning(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_FLAG_LEARNING"].String()
+	if override, ok := getSessionConfigOverride("BISH_FLAG_LEARNING"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetPipelineTips returns whether the coach points out pipelines that can be
+// run more simply, such as cat file | grep pattern. Defaults to true; set
+// BISH_PIPELINE_TIPS=0 to opt out.
+func GetPipelineTips(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_PIPELINE_TIPS"].String()
+	if override, ok := getSessionConfigOverride("BISH_PIPELINE_TIPS"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// Fast search modes control what happens when a find or grep -r command that
+// fd or ripgrep can run faster is typed.
+const (
+	// FastSearchOffer prints the faster command, once per command in a
+	// session, and runs the one typed.
+	FastSearchOffer = "offer"
+	// FastSearchAuto runs the faster command instead, after printing both.
+	FastSearchAuto = "auto"
+	// FastSearchOff leaves find and grep alone.
+	FastSearchOff = "off"
+)
+
+// GetFastSearch returns the configured BISH_FAST_SEARCH mode.
+func GetFastSearch(runner *interp.Runner) string {
+	mode := runner.Vars["BISH_FAST_SEARCH"].String()
+	if override, ok := getSessionConfigOverride("BISH_FAST_SEARCH"); ok {
+		mode = override
+	}
+	return ParseFastSearch(mode)
+}
+
+// ParseFastSearch returns the fast search mode named by value. Defaults to
+// FastSearchOffer if empty or unrecognized; 0, false and no turn it off.
+func ParseFastSearch(value string) string {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case FastSearchAuto, FastSearchOff:
+		return value
+	case "0", "false", "no":
+		return FastSearchOff
+	default:
+		return FastSearchOffer
+	}
+}
+
+// GetStartupRecap returns whether a recap of the last session in the project
+// is shown in the assistant box at startup. Defaults to true; set
+// BISH_STARTUP_RECAP=0 to opt out.
+func GetStartupRecap(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_STARTUP_RECAP"].String()
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetTerminalRecovery returns whether the terminal is restored when a
+// program leaves it in raw mode, and what programs may leave on, such as
+// mouse reporting, is undone before every prompt. Defaults to true; set
+// BISH_TERMINAL_RECOVERY=0 to opt out.
+func GetTerminalRecovery(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_TERMINAL_RECOVERY"].String()
+	if override, ok := getSessionConfigOverride("BISH_TERMINAL_RECOVERY"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// GetTimerActivity returns whether the coach summarizes the commands run
+// while a timer counted down once it ends. Off by default; set
+// BISH_TIMER_ACTIVITY=1 to opt in.
+func GetTimerActivity(runner *interp.Runner) bool {
+	enabled := strings.ToLower(runner.Vars["BISH_TIMER_ACTIVITY"].String())
+	return enabled == "1" || enabled == "true"
+}
+
+// GetCIStatus returns whether the state of the latest CI run of the branch is
+// shown in the border status, and finished runs are announced. Off by
+// default, since it polls GitHub or GitLab; set BISH_CI_STATUS=1 to opt in.
+func GetCIStatus(runner *interp.Runner) bool {
+	enabled := strings.ToLower(runner.Vars["BISH_CI_STATUS"].String())
+	return enabled == "1" || enabled == "true"
+}
+
+// GetTicketProvider returns where the ticket named in the branch is read
+// from, per BISH_TICKET_PROVIDER: off, auto, github, gitlab or jira.
+// Defaults to off if not set or unrecognized, since it reads the ticket over
+// the network.
+func GetTicketProvider(runner *interp.Runner, logger *zap.Logger) string {
+	provider := runner.Vars["BISH_TICKET_PROVIDER"].String()
+	if override, ok := getSessionConfigOverride("BISH_TICKET_PROVIDER"); ok {
+		provider = override
+	}
+
+	switch provider = strings.ToLower(strings.TrimSpace(provider)); provider {
+	case "off", "auto", "github", "gitlab", "jira":
+		return provider
+	case "":
+		return "off"
+	default:
+		logger.Debug("unknown BISH_TICKET_PROVIDER, using off", zap.String("provider", provider))
+		return "off"
+	}
+}
+
+// defaultRCTimeout is how long each config file may take to load by default.
+const defaultRCTimeout = 10 * time.Second
+
+// GetRCTimeout returns how long each config file may take to load before it is
+// interrupted. BISH_RC_TIMEOUT is a duration like 30s or a number of seconds;
+// 0 disables the limit. Defaults to 10 seconds.
+func GetRCTimeout(runner *interp.Runner) time.Duration {
+	value := strings.TrimSpace(runner.Vars["BISH_RC_TIMEOUT"].String())
+	if value == "" {
+		return defaultRCTimeout
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+		return timeout
+	}
+	return defaultRCTimeout
+}
+
+// GetWrapUpOnExit returns whether the session is summarized into the project
+// journal when the shell exits, as #!wrapup does. Defaults to false, since
+// the journal is written into the work tree.
+func GetWrapUpOnExit(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_WRAPUP_ON_EXIT"].String()
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// GetPresentationMode returns whether presentation mode is on, in which
+// secrets are masked wherever the shell renders them. Defaults to false.
+func GetPresentationMode(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_PRESENTATION_MODE"].String()
+	if override, ok := getSessionConfigOverride("BISH_PRESENTATION_MODE"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// GetSecretRedactor returns a redactor for the values of the shell and
+// environment variables that look like secrets.
+func GetSecretRedactor(runner *interp.Runner) *redact.Redactor {
+	vars := map[string]string{}
+	if runner.Env != nil {
+		runner.Env.Each(func(name string, vr expand.Variable) bool {
+			vars[name] = vr.String()
+			return true
+		})
+	}
+	for name, vr := range runner.Vars {
+		vars[name] = vr.String()
+	}
+	return redact.New(vars)
+}
+
+// GetPathStyle returns the configured BISH_PATH_STYLE used to abbreviate the
+// current directory in the border status. Defaults to pathfmt.StyleAuto if not
+// set or unrecognized.
+func GetPathStyle(runner *interp.Runner, logger *zap.Logger) pathfmt.Style {
+	styleName := runner.Vars["BISH_PATH_STYLE"].String()
+	if override, ok := getSessionConfigOverride("BISH_PATH_STYLE"); ok {
+		styleName = override
+	}
+	if strings.TrimSpace(styleName) == "" {
+		return pathfmt.StyleAuto
+	}
+
+	style, ok := pathfmt.ParseStyle(styleName)
+	if !ok {
+		logger.Debug("unknown BISH_PATH_STYLE, using default", zap.String("style", styleName))
+	}
+	return style
+}
+
+// GetNetworkToolsEnabled returns whether agent tools that reach the network,
+// such as web search, may be offered to the model. Defaults to true; set
+// BISH_AGENT_NETWORK_TOOLS=false to disable all of them at once.
+func GetNetworkToolsEnabled(runner *interp.Runner) bool {
+	enabled := runner.Vars["BISH_AGENT_NETWORK_TOOLS"].String()
+	if override, ok := getSessionConfigOverride("BISH_AGENT_NETWORK_TOOLS"); ok {
+		enabled = override
+	}
+	switch strings.ToLower(strings.TrimSpace(enabled)) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// Supported BISH_WEB_SEARCH_BACKEND values.
+const (
+	WebSearchBackendSearxng = "searxng"
+	WebSearchBackendBrave   = "brave"
+	WebSearchBackendSerper  = "serper"
+)
+
+// GetWebSearchBackend returns the configured BISH_WEB_SEARCH_BACKEND, or ""
+// if web search is not configured, the backend is unknown, or network tools
+// are disabled.
+func GetWebSearchBackend(runner *interp.Runner, logger *zap.Logger) string {
+	if !GetNetworkToolsEnabled(runner) {
+		return ""
+	}
+	backend := runner.Vars["BISH_WEB_SEARCH_BACKEND"].String()
+	if override, ok := getSessionConfigOverride("BISH_WEB_SEARCH_BACKEND"); ok {
+		backend = override
+	}
+
+	switch backend = strings.ToLower(strings.TrimSpace(backend)); backend {
+	case WebSearchBackendSearxng, WebSearchBackendBrave, WebSearchBackendSerper, "":
+		return backend
+	default:
+		logger.Debug("unknown BISH_WEB_SEARCH_BACKEND, disabling web search", zap.String("backend", backend))
+		return ""
+	}
+}
+
+// GetWebSearchURL returns the BISH_WEB_SEARCH_URL used as the base URL of the
+// search backend. It is required for SearXNG and optional for hosted APIs.
+func GetWebSearchURL(runner *interp.Runner) string {
+	return strings.TrimSpace(runner.Vars["BISH_WEB_SEARCH_URL"].String())
+}
+
+// GetWebSearchAPIKey returns the BISH_WEB_SEARCH_API_KEY used by hosted search APIs.
+func GetWebSearchAPIKey(runner *interp.Runner) string {
+	return strings.TrimSpace(runner.Vars["BISH_WEB_SEARCH_API_KEY"].String())
+}
+
+// GetFocus returns the task declared with #!focus, or "" if there is none.
+func GetFocus(runner *interp.Runner) string {
+	return strings.TrimSpace(runner.Vars["BISH_FOCUS"].String())
+}
+
+// SetFocus records the task declared with #!focus in BISH_FOCUS so that
+// prompts and scripts can show it. An empty task clears it.
+func SetFocus(runner *interp.Runner, task string) {
+	if task == "" {
+		delete(runner.Vars, "BISH_FOCUS")
+		return
+	}
+	runner.Vars["BISH_FOCUS"] = expand.Variable{Kind: expand.String, Str: task, Exported: true}
+}
+
+// SetDevEnv records the development environments bish runs in, such as
+// "devcontainer nix:hello-shell", in BISH_DEVENV so that prompts and rc files
+// can adapt to them.
+func SetDevEnv(runner *interp.Runner, names string) {
+	if names == "" {
+		delete(runner.Vars, "BISH_DEVENV")
+		return
+	}
+	runner.Vars["BISH_DEVENV"] = expand.Variable{Kind: expand.String, Str: names, Exported: true}
+}
+
+// GetPackageManager returns the package manager named by BISH_PACKAGE_MANAGER,
+// or "" to use the detected one.
+func GetPackageManager(runner *interp.Runner) string {
+	return strings.TrimSpace(runner.Vars["BISH_PACKAGE_MANAGER"].String())
+}
+
 func GetPwd(runner *interp.Runner) string {
 	// Use runner.Dir as the authoritative source for current working directory
 	// This is what the mvdan.cc/sh interpreter uses internally.
@@ -355,6 +892,25 @@ func GetContextNumHistoryVerbose(runner *interp.Runner, logger *zap.Logger) int
 	return int(numHistoryVerbose)
 }
 
+// maxPredictionCandidates bounds BISH_PREDICTION_CANDIDATES.
+const maxPredictionCandidates = 10
+
+// GetPredictionCandidates returns how many predictions to request at once
+// for a partial command, which Alt+] and Alt+[ cycle through. Defaults to 3;
+// set BISH_PREDICTION_CANDIDATES=1 for a single prediction.
+func GetPredictionCandidates(runner *interp.Runner, logger *zap.Logger) int {
+	value := strings.TrimSpace(runner.Vars["BISH_PREDICTION_CANDIDATES"].String())
+	if value == "" {
+		return 3
+	}
+	candidates, err := strconv.Atoi(value)
+	if err != nil || candidates < 1 {
+		logger.Debug("error parsing BISH_PREDICTION_CANDIDATES", zap.String("value", value))
+		return 3
+	}
+	return min(candidates, maxPredictionCandidates)
+}
+
 // GetIdleSummaryTimeout returns the idle summary timeout in seconds.
 // Returns 0 if disabled, otherwise defaults to 60 seconds.
 func GetIdleSummaryTimeout(runner *interp.Runner, logger *zap.Logger) int {
@@ -682,6 +682,9 @@ func TestEnvironmentHelperFunctions(t *testing.T) {
 
 	numHistoryVerbose := GetContextNumHistoryVerbose(runner, logger)
 	assert.Equal(t, 30, numHistoryVerbose)
+
+	// The journal is only written into the work tree on exit when asked to
+	assert.False(t, GetWrapUpOnExit(runner))
 }
 
 func TestEnvironmentHelperFunctionsWithCustomValues(t *testing.T) {
@@ -1133,3 +1136,25 @@ func TestValidationError(t *testing.T) {
 	assert.Equal(t, "Invalid height: must be non-negative", err.Error())
 	assert.Equal(t, "BISH_ASSISTANT_HEIGHT", err.Field)
 }
+
+func TestSyncVariablesToEnvKeepsReadOnly(t *testing.T) {
+	runner, err := interp.New(interp.Env(expand.ListEnviron(os.Environ()...)))
+	assert.NoError(t, err)
+	if runner.Vars == nil {
+		runner.Vars = make(map[string]expand.Variable)
+	}
+
+	runner.Vars["BISH_PROMPT"] = expand.Variable{Kind: expand.String, Str: "ro> ", ReadOnly: true}
+	t.Setenv("BISH_PROMPT", "")
+
+	SyncVariablesToEnv(runner)
+
+	vr := runner.Env.Get("BISH_PROMPT")
+	assert.True(t, vr.ReadOnly, "readonly attribute should survive the sync")
+	assert.True(t, vr.Exported)
+	assert.Equal(t, "ro> ", vr.Str)
+
+	runner.Vars["BISH_PROMPT"] = expand.Variable{Kind: expand.String, Str: "rw> "}
+	SyncVariableToEnv(runner, "BISH_PROMPT")
+	assert.False(t, runner.Env.Get("BISH_PROMPT").ReadOnly)
+}
@@ -27,14 +27,18 @@ var bishVariableNames = []string{
 type DynamicEnviron struct {
 	systemEnv expand.Environ
 	bishVars  map[string]string
+	// readOnlyVars holds the BISH variables declared readonly, so that the
+	// attribute survives syncing them to the system environment
+	readOnlyVars map[string]bool
 }
 
 // NewDynamicEnviron creates a new DynamicEnviron that wraps the system environment
 // and adds BISH-specific variables
 func NewDynamicEnviron() *DynamicEnviron {
 	return &DynamicEnviron{
-		systemEnv: expand.ListEnviron(os.Environ()...),
-		bishVars:  make(map[string]string),
+		systemEnv:    expand.ListEnviron(os.Environ()...),
+		bishVars:     make(map[string]string),
+		readOnlyVars: make(map[string]bool),
 	}
 }
 
@@ -44,6 +48,7 @@ func (de *DynamicEnviron) Get(name string) expand.Variable {
 	if value, exists := de.bishVars[name]; exists {
 		return expand.Variable{
 			Exported: true,
+			ReadOnly: de.readOnlyVars[name],
 			Kind:     expand.String,
 			Str:      value,
 		}
@@ -71,6 +76,7 @@ func (de *DynamicEnviron) Each(fn func(name string, vr expand.Variable) bool) {
 	for name, value := range de.bishVars {
 		if !fn(name, expand.Variable{
 			Exported: true,
+			ReadOnly: de.readOnlyVars[name],
 			Kind:     expand.String,
 			Str:      value,
 		}) {
@@ -92,6 +98,23 @@ func (de *DynamicEnviron) UpdateBishVar(name, value string) {
 	de.bishVars[name] = value
 }
 
+// updateBishVariable updates a BISH variable in the dynamic environment,
+// keeping its attributes
+func (de *DynamicEnviron) updateBishVariable(name string, vr expand.Variable) {
+	de.bishVars[name] = vr.String()
+	if vr.ReadOnly {
+		de.readOnlyVars[name] = true
+	} else {
+		delete(de.readOnlyVars, name)
+	}
+}
+
+// removeBishVar removes a BISH variable from the dynamic environment
+func (de *DynamicEnviron) removeBishVar(name string) {
+	delete(de.bishVars, name)
+	delete(de.readOnlyVars, name)
+}
+
 // UpdateSystemEnv updates the system environment wrapper
 func (de *DynamicEnviron) UpdateSystemEnv() {
 	de.systemEnv = expand.ListEnviron(os.Environ()...)
@@ -114,12 +137,12 @@ func SyncVariablesToEnv(runner *interp.Runner) {
 			if err := os.Setenv(varName, value); err != nil {
 				return
 			}
-			dynamicEnv.UpdateBishVar(varName, value)
+			dynamicEnv.updateBishVariable(varName, varValue)
 			continue
 		}
 
 		_ = os.Unsetenv(varName)
-		delete(dynamicEnv.bishVars, varName)
+		dynamicEnv.removeBishVar(varName)
 	}
 
 	// Update the system environment in the dynamic environment
@@ -139,7 +162,7 @@ func SyncVariableToEnv(runner *interp.Runner, varName string) {
 
 		// Update in the dynamic environment
 		if dynamicEnv, ok := runner.Env.(*DynamicEnviron); ok {
-			dynamicEnv.UpdateBishVar(varName, value)
+			dynamicEnv.updateBishVariable(varName, varValue)
 		}
 		return
 	}
@@ -148,7 +171,7 @@ func SyncVariableToEnv(runner *interp.Runner, varName string) {
 		return
 	}
 	if dynamicEnv, ok := runner.Env.(*DynamicEnviron); ok {
-		delete(dynamicEnv.bishVars, varName)
+		dynamicEnv.removeBishVar(varName)
 	}
 }
 
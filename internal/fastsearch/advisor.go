@@ -0,0 +1,111 @@
+package fastsearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"golang.org/x/term"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Advisor remembers the commands it offered a faster form of, so that each
+// is offered once.
+type Advisor struct {
+	mu      sync.Mutex
+	offered map[string]bool
+}
+
+// DefaultAdvisor is the advisor of the interactive shell.
+var DefaultAdvisor = NewAdvisor()
+
+func NewAdvisor() *Advisor {
+	return &Advisor{offered: map[string]bool{}}
+}
+
+// offer reports whether the faster form of command is yet to be offered,
+// and marks it offered.
+func (a *Advisor) offer(command string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.offered[command] {
+		return false
+	}
+	a.offered[command] = true
+	return true
+}
+
+// NewFastSearchHandler creates an ExecHandler that advises running fd or rg
+// for the find and grep -r commands it can translate, when they are
+// installed and BISH_FAST_SEARCH is not off. Only commands writing to a
+// terminal are advised on; pipelines and scripts whose output is read get
+// the output of the command they asked for. It must come before the handler
+// that runs external commands.
+func NewFastSearchHandler(advisor *Advisor) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+			var tools []string
+			switch args[0] {
+			case "find":
+				tools = []string{"fd", "fdfind"}
+			case "grep", "egrep", "fgrep":
+				tools = []string{"rg"}
+			default:
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			currentMode := mode(hc.Env)
+			if currentMode == environment.FastSearchOff || !isTerminal(hc.Stdout) {
+				return next(ctx, args)
+			}
+			tool := lookPath(hc, tools)
+			if tool == "" {
+				return next(ctx, args)
+			}
+			faster, ok := Translate(args, tool, tool)
+			if !ok {
+				return next(ctx, args)
+			}
+
+			original, translated := Join(args), Join(faster)
+			if currentMode == environment.FastSearchAuto {
+				fmt.Fprintf(hc.Stderr, "bish: running %s for %s\n", translated, original)
+				return next(ctx, faster)
+			}
+			if advisor.offer(original) {
+				fmt.Fprintf(hc.Stderr, "bish: %s is a faster way to run %s (BISH_FAST_SEARCH=auto runs it instead)\n", translated, original)
+			}
+			return next(ctx, args)
+		}
+	}
+}
+
+// mode returns the BISH_FAST_SEARCH mode of env, which the config menu keeps
+// in the runner's variables.
+func mode(env expand.Environ) string {
+	return environment.ParseFastSearch(env.Get("BISH_FAST_SEARCH").String())
+}
+
+// lookPath returns the first of names that is installed, or "".
+func lookPath(hc interp.HandlerContext, names []string) string {
+	for _, name := range names {
+		if _, err := interp.LookPathDir(hc.Dir, hc.Env, name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// isTerminal is a variable so that tests can pretend a buffer is one.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,106 @@
+package fastsearch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// fakeTools returns a PATH of scripts named names, which print their name
+// and args.
+func fakeTools(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		script := "#!/bin/sh\necho " + name + " \"$@\"\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	return dir
+}
+
+func runAdvised(t *testing.T, advisor *Advisor, env []string, script string) (string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron(env...)),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewFastSearchHandler(advisor)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	require.NoError(t, runner.Run(context.Background(), file))
+	return stdout.String(), stderr.String()
+}
+
+func fakeTerminal(t *testing.T) {
+	original := isTerminal
+	isTerminal = func(w io.Writer) bool { _, ok := w.(*bytes.Buffer); return ok }
+	t.Cleanup(func() { isTerminal = original })
+}
+
+func TestFastSearchHandlerOffers(t *testing.T) {
+	fakeTerminal(t)
+	env := []string{"PATH=" + fakeTools(t, "find", "fd")}
+	advisor := NewAdvisor()
+
+	stdout, stderr := runAdvised(t, advisor, env, "find . -name '*.go'")
+	assert.Equal(t, "find . -name *.go\n", stdout)
+	assert.Equal(t, "bish: fd -H -I -g -s '*.go' . is a faster way to run find . -name '*.go' (BISH_FAST_SEARCH=auto runs it instead)\n", stderr)
+
+	// Offered once per command
+	_, stderr = runAdvised(t, advisor, env, "find . -name '*.go'")
+	assert.Empty(t, stderr)
+}
+
+func TestFastSearchHandlerAuto(t *testing.T) {
+	fakeTerminal(t)
+	env := []string{"PATH=" + fakeTools(t, "find", "fdfind"), "BISH_FAST_SEARCH=auto"}
+
+	stdout, stderr := runAdvised(t, NewAdvisor(), env, "find src -type f")
+	assert.Equal(t, "fdfind -H -I -t f . src\n", stdout)
+	assert.Equal(t, "bish: running fdfind -H -I -t f . src for find src -type f\n", stderr)
+}
+
+func TestFastSearchHandlerLeavesCommandsAlone(t *testing.T) {
+	fakeTerminal(t)
+	withFd := []string{"PATH=" + fakeTools(t, "find", "fd", "grep"), "BISH_FAST_SEARCH=auto"}
+
+	tests := []struct {
+		name   string
+		env    []string
+		script string
+		want   string
+	}{
+		{"off", []string{"PATH=" + fakeTools(t, "find", "fd"), "BISH_FAST_SEARCH=off"}, "find . -name x", "find . -name x\n"},
+		{"not installed", []string{"PATH=" + fakeTools(t, "grep"), "BISH_FAST_SEARCH=auto"}, "grep -r x .", "grep -r x .\n"},
+		{"untranslatable", withFd, "find . -name x -delete", "find . -name x -delete\n"},
+		{"only fd installed", withFd, "grep -r x .", "grep -r x .\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := runAdvised(t, NewAdvisor(), tt.env, tt.script)
+			assert.Equal(t, tt.want, stdout)
+			assert.Empty(t, stderr)
+		})
+	}
+}
+
+func TestFastSearchHandlerSkipsRedirectedOutput(t *testing.T) {
+	env := []string{"PATH=" + fakeTools(t, "find", "fd"), "BISH_FAST_SEARCH=auto"}
+
+	stdout, stderr := runAdvised(t, NewAdvisor(), env, "find . -name x")
+	assert.Equal(t, "find . -name x\n", stdout)
+	assert.Empty(t, stderr)
+}
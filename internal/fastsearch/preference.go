@@ -0,0 +1,91 @@
+package fastsearch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+const (
+	// SampleSize is how many recent history entries are considered.
+	SampleSize = 2000
+
+	// A fast tool is preferred once it was run at least minUses times, and
+	// at least as often as the command it replaces.
+	minUses = 3
+)
+
+// Preference is a fast tool run more often than the command it replaces.
+type Preference struct {
+	Tool    string
+	Instead string
+	// Uses and InsteadUses are how many of the sampled commands ran each.
+	Uses        int
+	InsteadUses int
+}
+
+// Note tells the predictor about the preference.
+func (p Preference) Note() string {
+	return fmt.Sprintf("I prefer `%s` to `%s` (%d times to %d lately), so predict %s commands rather than %s ones unless the prefix rules it out.",
+		p.Tool, p.Instead, p.Uses, p.InsteadUses, p.Tool, p.Instead)
+}
+
+// Learn returns the fast tools preferred in commands.
+func Learn(commands []string) []Preference {
+	counts := map[string]int{}
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "fd", "fdfind":
+			counts["fd"]++
+		case "rg":
+			counts["rg"]++
+		case "find":
+			counts["find"]++
+		case "grep", "egrep", "fgrep":
+			if recursive(fields[1:]) {
+				counts["grep -r"]++
+			}
+		}
+	}
+
+	var preferences []Preference
+	for _, pair := range [][2]string{{"fd", "find"}, {"rg", "grep -r"}} {
+		uses, insteadUses := counts[pair[0]], counts[pair[1]]
+		if uses >= minUses && uses >= insteadUses {
+			preferences = append(preferences, Preference{Tool: pair[0], Instead: pair[1], Uses: uses, InsteadUses: insteadUses})
+		}
+	}
+	return preferences
+}
+
+// recursive reports whether grep's args ask for a recursive search.
+func recursive(args []string) bool {
+	for _, arg := range args {
+		if arg == "--recursive" || arg == "--dereference-recursive" ||
+			len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.ContainsAny(arg, "rR") {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the fast tools preferred in the recent commands of history.
+func Lookup(historyManager *history.HistoryManager) []Preference {
+	if historyManager == nil {
+		return nil
+	}
+	entries, err := historyManager.GetRecentEntriesByPrefix("", SampleSize)
+	if err != nil {
+		return nil
+	}
+	commands := make([]string, len(entries))
+	for i, entry := range entries {
+		commands[i] = entry.Command
+	}
+	return Learn(commands)
+}
@@ -0,0 +1,35 @@
+package fastsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLearn(t *testing.T) {
+	commands := []string{
+		"fd -e go",
+		"fdfind readme",
+		"fd test src",
+		"find . -name '*.md'",
+		"rg TODO",
+		"grep -rn TODO .",
+		"grep -r FIXME",
+		"grep foo file.txt",
+		"ls | grep bar",
+	}
+
+	preferences := Learn(commands)
+	assert.Equal(t, []Preference{{Tool: "fd", Instead: "find", Uses: 3, InsteadUses: 1}}, preferences)
+	assert.Contains(t, preferences[0].Note(), "I prefer `fd` to `find`")
+
+	commands = append(commands, "rg -l main", "rg --files")
+	preferences = Learn(commands)
+	assert.Len(t, preferences, 2)
+	assert.Equal(t, Preference{Tool: "rg", Instead: "grep -r", Uses: 3, InsteadUses: 2}, preferences[1])
+}
+
+func TestLearnNeedsEnoughUses(t *testing.T) {
+	assert.Empty(t, Learn([]string{"fd a", "fd b"}))
+	assert.Empty(t, Learn([]string{"fd a", "fd b", "fd c", "find .", "find . -x", "find /", "find ~"}))
+}
@@ -0,0 +1,178 @@
+// Package fastsearch advises running fd and ripgrep instead of the find and
+// grep -r commands typed when they are installed, with the flags translated,
+// and learns from history which of them the user prefers.
+package fastsearch
+
+import (
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Translate returns the fd or rg command that does what the find or grep
+// command in args does, with fd's and rg's own name given by fd and rg. It
+// returns false for commands it does not know how to translate faithfully,
+// such as find with -exec or grep without -r. Hidden and git-ignored files
+// are searched too, as find and grep do.
+func Translate(args []string, fd, rg string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	switch args[0] {
+	case "find":
+		return translateFind(args[1:], fd)
+	case "grep", "egrep", "fgrep":
+		return translateGrep(args, rg)
+	}
+	return nil, false
+}
+
+// translateFind translates find [DIR...] with -name, -iname, -type,
+// -maxdepth and -mindepth.
+func translateFind(args []string, fd string) ([]string, bool) {
+	var dirs []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		dirs = append(dirs, args[0])
+		args = args[1:]
+	}
+
+	options := []string{fd, "-H", "-I"}
+	pattern := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-print" {
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, false
+		}
+		value := args[i+1]
+		i++
+		switch arg {
+		case "-name", "-iname":
+			if pattern != "" {
+				return nil, false
+			}
+			pattern = value
+			options = append(options, "-g")
+			if arg == "-iname" {
+				options = append(options, "-i")
+			} else {
+				options = append(options, "-s")
+			}
+		case "-type":
+			if value != "f" && value != "d" && value != "l" {
+				return nil, false
+			}
+			options = append(options, "-t", value)
+		case "-maxdepth":
+			options = append(options, "-d", value)
+		case "-mindepth":
+			options = append(options, "--min-depth", value)
+		default:
+			return nil, false
+		}
+	}
+
+	if pattern == "" && len(dirs) > 0 {
+		pattern = "."
+	}
+	if pattern != "" {
+		options = append(options, pattern)
+	}
+	return append(options, dirs...), true
+}
+
+// grepFlags are grep's flags that rg takes as they are.
+const grepFlags = "nilwvcoqx"
+
+// basicRegexOperator matches the operators of grep's basic regular
+// expressions, which rg reads differently.
+var basicRegexOperator = regexp.MustCompile(`\\[|(){}+?]`)
+
+// translateGrep translates grep -r with the flags rg shares, and the
+// --include, --exclude and --exclude-dir filters.
+func translateGrep(args []string, rg string) ([]string, bool) {
+	recursive, extended, fixed := false, args[0] == "egrep", args[0] == "fgrep"
+	options := []string{rg, "-uu"}
+	var patterns, operands []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			operands = append(operands, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--include="):
+			options = append(options, "-g", strings.TrimPrefix(arg, "--include="))
+		case strings.HasPrefix(arg, "--exclude="):
+			options = append(options, "-g", "!"+strings.TrimPrefix(arg, "--exclude="))
+		case strings.HasPrefix(arg, "--exclude-dir="):
+			options = append(options, "-g", "!"+strings.TrimPrefix(arg, "--exclude-dir=")+"/")
+		case arg == "-e" || arg == "-A" || arg == "-B" || arg == "-C" || arg == "-m":
+			if i+1 >= len(args) {
+				return nil, false
+			}
+			options = append(options, arg, args[i+1])
+			if arg == "-e" {
+				patterns = append(patterns, args[i+1])
+			}
+			i++
+		case len(arg) > 1 && arg[0] == '-' && arg[1] != '-':
+			for _, c := range arg[1:] {
+				switch {
+				case c == 'r' || c == 'R':
+					recursive = true
+				case c == 'E':
+					extended = true
+				case c == 'F':
+					fixed = true
+				case c == 'H':
+				case strings.ContainsRune(grepFlags, c):
+					options = append(options, "-"+string(c))
+				default:
+					return nil, false
+				}
+			}
+		case strings.HasPrefix(arg, "-"):
+			return nil, false
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	if len(patterns) == 0 && len(operands) > 0 {
+		patterns = operands[:1]
+	}
+	if !recursive || len(patterns) == 0 {
+		return nil, false
+	}
+	if fixed {
+		options = append(options, "-F")
+	} else if !extended {
+		for _, pattern := range patterns {
+			if basicRegexOperator.MatchString(pattern) {
+				return nil, false
+			}
+		}
+	}
+	for _, operand := range operands {
+		if strings.HasPrefix(operand, "-") {
+			options = append(options, "--")
+			break
+		}
+	}
+	return append(options, operands...), true
+}
+
+// Join returns args as a command line, quoting the words that need it.
+func Join(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		quoted, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			quoted = arg
+		}
+		words[i] = quoted
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,59 @@
+package fastsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"find by name", []string{"find", ".", "-name", "*.go"}, []string{"fd", "-H", "-I", "-g", "-s", "*.go", "."}},
+		{"find by name ignoring case", []string{"find", "-iname", "readme*"}, []string{"fd", "-H", "-I", "-g", "-i", "readme*"}},
+		{"find with type and depth", []string{"find", "src", "docs", "-type", "d", "-maxdepth", "2", "-print"}, []string{"fd", "-H", "-I", "-t", "d", "-d", "2", ".", "src", "docs"}},
+		{"find with min depth", []string{"find", ".", "-mindepth", "1", "-name", "x"}, []string{"fd", "-H", "-I", "--min-depth", "1", "-g", "-s", "x", "."}},
+		{"grep -rn", []string{"grep", "-rn", "TODO", "src"}, []string{"rg", "-uu", "-n", "TODO", "src"}},
+		{"grep with filters", []string{"grep", "-r", "--include=*.go", "--exclude-dir=vendor", "-A", "2", "func main", "."}, []string{"rg", "-uu", "-g", "*.go", "-g", "!vendor/", "-A", "2", "func main", "."}},
+		{"grep -rE", []string{"grep", "-rEi", "foo|bar"}, []string{"rg", "-uu", "-i", "foo|bar"}},
+		{"grep -rF", []string{"grep", "-rF", "a.b", "."}, []string{"rg", "-uu", "-F", "a.b", "."}},
+		{"fgrep", []string{"fgrep", "-r", "a.b"}, []string{"rg", "-uu", "-F", "a.b"}},
+		{"grep -e", []string{"grep", "-r", "-e", "one", "-e", "two", "dir"}, []string{"rg", "-uu", "-e", "one", "-e", "two", "dir"}},
+		{"grep after --", []string{"grep", "-r", "--", "-x", "."}, []string{"rg", "-uu", "--", "-x", "."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Translate(tt.args, "fd", "rg")
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTranslateRefuses(t *testing.T) {
+	for _, args := range [][]string{
+		{"find", ".", "-name", "*.tmp", "-delete"},
+		{"find", ".", "-exec", "rm", "{}", ";"},
+		{"find", ".", "-type", "s"},
+		{"find", ".", "-name", "a", "-name", "b"},
+		{"find", ".", "-name"},
+		{"grep", "TODO", "file.txt"},
+		{"grep", "-r", "foo\\|bar", "."},
+		{"grep", "-rP", "\\d+", "."},
+		{"grep", "-r", "--color=always", "x"},
+		{"grep", "-r"},
+		{"ls", "-la"},
+		{},
+	} {
+		_, ok := Translate(args, "fd", "rg")
+		assert.False(t, ok, "%q", args)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, `fd -H -I -g -s '*.go' .`, Join([]string{"fd", "-H", "-I", "-g", "-s", "*.go", "."}))
+	assert.Equal(t, `rg -uu 'func main'`, Join([]string{"rg", "-uu", "func main"}))
+}
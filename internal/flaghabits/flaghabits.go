@@ -0,0 +1,219 @@
+// Package flaghabits learns the flags a user almost always passes to a
+// command, such as -la for ls or -rn for grep, from their history.
+package flaghabits
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// SampleSize is how many recent history entries are considered.
+	SampleSize = 2000
+
+	// A flag is a habit once a command has been run at least minUses times
+	// and the flag was passed in at least minShare of them.
+	minUses  = 5
+	minShare = 0.7
+)
+
+// Habit is the set of flags usually passed to a command.
+type Habit struct {
+	Command string
+	Flags   []string
+	// Uses is how many of the sampled commands ran Command.
+	Uses int
+}
+
+// String returns the command with its usual flags, e.g. "ls -la".
+func (h Habit) String() string {
+	return h.Command + " " + strings.Join(h.Flags, " ")
+}
+
+// usage counts how often a command was run and with which flags.
+type usage struct {
+	uses  int
+	flags map[string]int
+	// order lists the flags in the order they were first seen.
+	order []string
+}
+
+// Learn returns the habits shown by commands, most used command first.
+func Learn(commands []string) []Habit {
+	usages := map[string]*usage{}
+	parser := syntax.NewParser()
+	for _, command := range commands {
+		file, err := parser.Parse(strings.NewReader(command), "")
+		if err != nil {
+			continue
+		}
+		syntax.Walk(file, func(node syntax.Node) bool {
+			call, ok := node.(*syntax.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			name := call.Args[0].Lit()
+			if name == "" {
+				return true
+			}
+			u := usages[name]
+			if u == nil {
+				u = &usage{flags: map[string]int{}}
+				usages[name] = u
+			}
+			u.uses++
+			seen := map[string]bool{}
+			for _, flag := range flags(call) {
+				if seen[flag] {
+					continue
+				}
+				seen[flag] = true
+				if u.flags[flag] == 0 {
+					u.order = append(u.order, flag)
+				}
+				u.flags[flag]++
+			}
+			return true
+		})
+	}
+
+	var habits []Habit
+	for name, u := range usages {
+		if u.uses < minUses {
+			continue
+		}
+		var usual []string
+		for _, flag := range u.order {
+			if float64(u.flags[flag]) >= minShare*float64(u.uses) {
+				usual = append(usual, flag)
+			}
+		}
+		if len(usual) == 0 {
+			continue
+		}
+		sort.SliceStable(usual, func(i, j int) bool {
+			return u.flags[usual[i]] > u.flags[usual[j]]
+		})
+		habits = append(habits, Habit{Command: name, Flags: usual, Uses: u.uses})
+	}
+	sort.Slice(habits, func(i, j int) bool {
+		if habits[i].Uses != habits[j].Uses {
+			return habits[i].Uses > habits[j].Uses
+		}
+		return habits[i].Command < habits[j].Command
+	})
+	return habits
+}
+
+// Lookup returns the habit for the named command learned from its recent
+// uses in history.
+func Lookup(historyManager *history.HistoryManager, name string) (Habit, bool) {
+	if historyManager == nil || name == "" {
+		return Habit{}, false
+	}
+	entries, err := historyManager.GetRecentEntriesByPrefix(name, SampleSize)
+	if err != nil {
+		return Habit{}, false
+	}
+	commands := make([]string, len(entries))
+	for i, entry := range entries {
+		commands[i] = entry.Command
+	}
+	for _, habit := range Learn(commands) {
+		if habit.Command == name {
+			return habit, true
+		}
+	}
+	return Habit{}, false
+}
+
+// CommandName returns the name of the last simple command in line, which is
+// the one being typed. Lines that do not parse yet fall back to their first
+// word.
+func CommandName(line string) string {
+	if call := lastCall(line); call != nil {
+		return call.Args[0].Lit()
+	}
+	if fields := strings.Fields(line); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// Apply adds the usual flags of the last simple command in line that it does
+// not already have, right after the command name. lookup returns the habit
+// for a command name. It returns false if there is nothing to add.
+func Apply(line string, lookup func(name string) (Habit, bool)) (string, bool) {
+	call := lastCall(line)
+	if call == nil {
+		return "", false
+	}
+	habit, ok := lookup(call.Args[0].Lit())
+	if !ok {
+		return "", false
+	}
+
+	present := map[string]bool{}
+	for _, flag := range flags(call) {
+		present[flag] = true
+	}
+	var missing []string
+	for _, flag := range habit.Flags {
+		if !present[flag] {
+			missing = append(missing, flag)
+		}
+	}
+	if len(missing) == 0 {
+		return "", false
+	}
+
+	offset := int(call.Args[0].End().Offset())
+	return line[:offset] + " " + strings.Join(missing, " ") + line[offset:], true
+}
+
+// lastCall returns the last simple command in line with a literal name, or
+// nil if line does not parse.
+func lastCall(line string) *syntax.CallExpr {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil
+	}
+	var last *syntax.CallExpr
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 && call.Args[0].Lit() != "" {
+			last = call
+		}
+		return true
+	})
+	return last
+}
+
+// flags returns the literal flags passed to call, stopping at "--".
+func flags(call *syntax.CallExpr) []string {
+	var result []string
+	for _, arg := range call.Args[1:] {
+		value := arg.Lit()
+		if value == "--" {
+			break
+		}
+		if isFlag(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// isFlag reports whether value looks like an option. Numbers such as the -20
+// in "head -20" are counts rather than habits and are not flags.
+func isFlag(value string) bool {
+	if len(value) < 2 || value[0] != '-' {
+		return false
+	}
+	if value[1] >= '0' && value[1] <= '9' {
+		return false
+	}
+	return value != "--"
+}
@@ -0,0 +1,108 @@
+package flaghabits
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func repeat(command string, n int) []string {
+	commands := make([]string, n)
+	for i := range commands {
+		commands[i] = command
+	}
+	return commands
+}
+
+func TestLearn(t *testing.T) {
+	var commands []string
+	commands = append(commands, repeat("ls -la", 6)...)
+	commands = append(commands, "ls -la src", "ls -l")
+	commands = append(commands, repeat("grep -rn TODO .", 4)...)
+	commands = append(commands, "git log | grep -n -r fix")
+	commands = append(commands, repeat("head -20 file", 5)...)
+	commands = append(commands, repeat("cat file", 5)...)
+	commands = append(commands, repeat("make -j8", 4)...)
+
+	habits := Learn(commands)
+	require.Len(t, habits, 2)
+	assert.Equal(t, Habit{Command: "ls", Flags: []string{"-la"}, Uses: 8}, habits[0])
+	assert.Equal(t, "grep", habits[1].Command)
+	assert.Equal(t, 5, habits[1].Uses)
+	assert.Equal(t, []string{"-rn"}, habits[1].Flags)
+}
+
+func TestLearnOrdersFlagsByUse(t *testing.T) {
+	var commands []string
+	commands = append(commands, repeat("rsync -a --progress src dst", 3)...)
+	commands = append(commands, repeat("rsync -a -v --progress src dst", 7)...)
+	commands = append(commands, "rsync -v -a src dst")
+
+	habits := Learn(commands)
+	require.Len(t, habits, 1)
+	assert.Equal(t, []string{"-a", "--progress", "-v"}, habits[0].Flags)
+	assert.Equal(t, "rsync -a --progress -v", habits[0].String())
+}
+
+func TestLearnIgnoresFlagsAfterDoubleDash(t *testing.T) {
+	habits := Learn(repeat("git checkout -- -file", 5))
+	assert.Empty(t, habits)
+}
+
+func TestApply(t *testing.T) {
+	habits := map[string]Habit{
+		"ls":   {Command: "ls", Flags: []string{"-la"}},
+		"grep": {Command: "grep", Flags: []string{"-r", "-n"}},
+	}
+	lookup := func(name string) (Habit, bool) {
+		habit, ok := habits[name]
+		return habit, ok
+	}
+
+	tests := []struct {
+		line     string
+		expected string
+		ok       bool
+	}{
+		{"ls", "ls -la", true},
+		{"ls src", "ls -la src", true},
+		{"grep -n TODO .", "grep -r -n TODO .", true},
+		{"cat notes | grep fix", "cat notes | grep -r -n fix", true},
+		{"ls -la", "", false},
+		{"cat notes", "", false},
+		{"grep \"unterminated", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		result, ok := Apply(tt.line, lookup)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.expected, result, tt.line)
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "grep", CommandName("cat notes | grep fix"))
+	assert.Equal(t, "grep", CommandName("grep \"unterminated"))
+	assert.Equal(t, "", CommandName("  "))
+}
+
+func TestLookup(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+
+	for _, command := range append(repeat("ls -la", 5), repeat("lsof -i", 5)...) {
+		_, err := historyManager.StartCommand(command, "/tmp", "session")
+		require.NoError(t, err)
+	}
+
+	habit, ok := Lookup(historyManager, "ls")
+	require.True(t, ok)
+	assert.Equal(t, []string{"-la"}, habit.Flags)
+
+	_, ok = Lookup(historyManager, "cat")
+	assert.False(t, ok)
+	_, ok = Lookup(nil, "ls")
+	assert.False(t, ok)
+}
@@ -0,0 +1,180 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const (
+	defaultParallel = 10
+	defaultWidth    = 100
+)
+
+const usage = "Usage: fleet [-p parallel] [-t timeout] <group|host,pattern,...> <command>...\n" +
+	"       fleet groups\n" +
+	"       fleet show <host>"
+
+// NewFleetCommandHandler creates an ExecHandler for the fleet builtin, which
+// runs a command on a group of hosts over ssh in parallel and prints a table
+// of the results. Groups are read from groupsPath; host patterns match the
+// Host entries of the ssh config at sshConfigPath. run runs the command on a
+// host.
+func NewFleetCommandHandler(groupsPath, sshConfigPath string, run RunFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "fleet" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			groups, err := LoadGroups(groupsPath)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "fleet: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+
+			args = args[1:]
+			if len(args) == 1 {
+				switch args[0] {
+				case "groups":
+					printGroups(hc.Stdout, groups)
+					return nil
+				case "help", "-h", "--help":
+					fmt.Fprintln(hc.Stdout, usage)
+					return nil
+				}
+			}
+			if len(args) == 2 && args[0] == "show" {
+				return showHost(hc.Stdout, hc.Stderr, args[1])
+			}
+
+			opts := Options{Parallel: defaultParallel, Run: run}
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				flag := args[0]
+				if flag == "--" {
+					args = args[1:]
+					break
+				}
+				if len(args) < 2 || (flag != "-p" && flag != "-t") {
+					fmt.Fprintf(hc.Stderr, "fleet: unknown option %s\n%s\n", flag, usage)
+					return interp.NewExitStatus(2)
+				}
+				value := args[1]
+				args = args[2:]
+				switch flag {
+				case "-p":
+					opts.Parallel, err = strconv.Atoi(value)
+					if err != nil || opts.Parallel < 1 {
+						fmt.Fprintf(hc.Stderr, "fleet: invalid parallelism %q\n", value)
+						return interp.NewExitStatus(2)
+					}
+				case "-t":
+					opts.Timeout, err = parseTimeout(value)
+					if err != nil {
+						fmt.Fprintf(hc.Stderr, "fleet: invalid timeout %q\n", value)
+						return interp.NewExitStatus(2)
+					}
+				}
+			}
+			if len(args) < 2 {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			target, command := args[0], strings.Join(args[1:], " ")
+			hosts, err := groups.Resolve(target, SSHHosts(sshConfigPath))
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "fleet: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+
+			if progress, ok := terminal(hc.Stderr); ok {
+				opts.Progress = func(done, total int, _ Result) {
+					fmt.Fprintf(progress, "\r\033[Kfleet: %d/%d hosts done", done, total)
+				}
+			}
+			results := Run(ctx, hosts, command, opts)
+			if opts.Progress != nil {
+				fmt.Fprint(hc.Stderr, "\r\033[K")
+			}
+
+			width := defaultWidth
+			if out, ok := terminal(hc.Stdout); ok {
+				if w, _, err := term.GetSize(int(out.Fd())); err == nil {
+					width = w
+				}
+			}
+			fmt.Fprintln(hc.Stdout, Table(results, width))
+			fmt.Fprintln(hc.Stdout, Summary(results))
+
+			broadcast := Broadcast{Target: target, Command: command, Results: results}
+			Record(broadcast)
+			if len(broadcast.Failures()) > 0 {
+				fmt.Fprintln(hc.Stdout, "fleet show <host> prints a host's full output; #!fleet asks the agent to diagnose the failures")
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+}
+
+// parseTimeout accepts seconds or a duration such as 30s or 2m.
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", value)
+	}
+	return timeout, nil
+}
+
+func printGroups(w io.Writer, groups Groups) {
+	if len(groups) == 0 {
+		fmt.Fprintf(w, "No host groups; add them to %s\n", DefaultGroupsPath())
+		return
+	}
+	for _, name := range groups.Names() {
+		fmt.Fprintf(w, "%s: %s\n", name, strings.Join(groups[name], " "))
+	}
+}
+
+// showHost prints the full output of host in the last broadcast.
+func showHost(stdout, stderr io.Writer, host string) error {
+	broadcast, ok := Last()
+	if !ok {
+		fmt.Fprintln(stderr, "fleet: no command has been run yet")
+		return interp.NewExitStatus(1)
+	}
+	for _, result := range broadcast.Results {
+		if result.Host != host {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s (%s): %s\n", result.Host, result.Status(), broadcast.Command)
+		if result.Err != nil {
+			fmt.Fprintf(stdout, "%s\n", result.Err)
+		}
+		fmt.Fprint(stdout, result.Output)
+		return nil
+	}
+	fmt.Fprintf(stderr, "fleet: %s was not part of the last run\n", host)
+	return interp.NewExitStatus(1)
+}
+
+// terminal returns w as a file if it is a terminal.
+func terminal(w io.Writer) (*os.File, bool) {
+	file, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return nil, false
+	}
+	return file, true
+}
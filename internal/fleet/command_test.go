@@ -0,0 +1,124 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// fakeHosts answers commands as if diskfull had no space left and down could
+// not be reached.
+func fakeHosts(ctx context.Context, host, command string) (string, int, error) {
+	switch host {
+	case "diskfull":
+		return "checking\ndf: No space left on device\n", 1, nil
+	case "down":
+		return "", 255, errors.New("ssh failed: Connection refused")
+	case "slow":
+		<-ctx.Done()
+		return "", -1, ctx.Err()
+	default:
+		return host + ": " + command + "\n", 0, nil
+	}
+}
+
+func runFleet(t *testing.T, groups, script string) (string, string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	groupsPath := filepath.Join(dir, "fleet.yaml")
+	require.NoError(t, os.WriteFile(groupsPath, []byte(groups), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewFleetCommandHandler(groupsPath, filepath.Join(dir, "ssh_config"), fakeHosts)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestFleetCommandAllOK(t *testing.T) {
+	stdout, _, err := runFleet(t, "groups:\n  web: [web1, web2]\n", "fleet web uptime -p")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "web1: uptime -p")
+	assert.Contains(t, stdout, "2 hosts: all ok")
+
+	broadcast, ok := Last()
+	require.True(t, ok)
+	assert.Equal(t, "uptime -p", broadcast.Command)
+	assert.Empty(t, broadcast.Failures())
+}
+
+func TestFleetCommandFailures(t *testing.T) {
+	stdout, _, err := runFleet(t, "groups:\n  all: [web1, diskfull, down, slow]\n", "fleet -t 50ms -p 2 all df -h")
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), status)
+	assert.Contains(t, stdout, "No space left on device")
+	assert.Contains(t, stdout, "exit 1")
+	assert.Contains(t, stdout, "timed out")
+	assert.Contains(t, stdout, "Connection refused")
+	assert.Contains(t, stdout, "4 hosts: 1 ok, 3 failed")
+
+	broadcast, ok := Last()
+	require.True(t, ok)
+	prompt := DiagnosisPrompt(broadcast)
+	assert.Contains(t, prompt, "I ran `df -h` across all")
+	assert.Contains(t, prompt, "It worked on: web1")
+	assert.Contains(t, prompt, "### diskfull (exit 1)")
+	assert.Contains(t, prompt, "df: No space left on device")
+	assert.Contains(t, prompt, "Error: ssh failed: Connection refused")
+
+	stdout, _, err = runFleet(t, "", "fleet show diskfull")
+	require.NoError(t, err)
+	assert.Equal(t, "diskfull (exit 1): df -h\nchecking\ndf: No space left on device\n", stdout)
+}
+
+func TestFleetCommandUsage(t *testing.T) {
+	stdout, _, err := runFleet(t, "groups:\n  web: [web1]\n", "fleet groups")
+	require.NoError(t, err)
+	assert.Equal(t, "web: web1\n", stdout)
+
+	_, stderr, err := runFleet(t, "", "fleet -x web uptime")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "unknown option -x")
+
+	_, stderr, err = runFleet(t, "", "fleet web")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "Usage: fleet")
+}
+
+func TestRunLimitsParallelism(t *testing.T) {
+	running, peak := 0, 0
+	ch := make(chan int, 1)
+	ch <- 0
+	results := Run(context.Background(), []string{"a", "b", "c", "d"}, "true", Options{
+		Parallel: 2,
+		Run: func(ctx context.Context, host, command string) (string, int, error) {
+			running = <-ch + 1
+			peak = max(peak, running)
+			ch <- running
+			time.Sleep(10 * time.Millisecond)
+			ch <- <-ch - 1
+			return "", 0, nil
+		},
+	})
+	assert.Len(t, results, 4)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, []string{results[0].Host, results[1].Host, results[2].Host, results[3].Host})
+	assert.Equal(t, 2, peak)
+}
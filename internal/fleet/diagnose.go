@@ -0,0 +1,93 @@
+package fleet
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// diagnosisOutputLines is how many lines of output of each failed host go
+// into the diagnosis prompt.
+const diagnosisOutputLines = 40
+
+// Broadcast is a command run across hosts, with its results.
+type Broadcast struct {
+	Target  string
+	Command string
+	Results []Result
+}
+
+// Failures returns the results of the hosts the command failed on.
+func (b Broadcast) Failures() []Result {
+	var failures []Result
+	for _, result := range b.Results {
+		if result.Failed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// last is the most recent broadcast, which #!fleet hands to the agent.
+var (
+	lastMu sync.Mutex
+	last   *Broadcast
+)
+
+// Record remembers broadcast as the most recent one.
+func Record(broadcast Broadcast) {
+	lastMu.Lock()
+	defer lastMu.Unlock()
+	last = &broadcast
+}
+
+// Last returns the most recent broadcast, if any.
+func Last() (Broadcast, bool) {
+	lastMu.Lock()
+	defer lastMu.Unlock()
+	if last == nil {
+		return Broadcast{}, false
+	}
+	return *last, true
+}
+
+// DiagnosisPrompt asks the agent to explain why the command failed on some
+// hosts, comparing their output with the hosts it worked on.
+func DiagnosisPrompt(b Broadcast) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "I ran `%s` across %s with fleet. %s.\n\n", b.Command, b.Target, Summary(b.Results))
+
+	var succeeded []string
+	for _, result := range b.Results {
+		if !result.Failed() {
+			succeeded = append(succeeded, result.Host)
+		}
+	}
+	if len(succeeded) > 0 {
+		fmt.Fprintf(&prompt, "It worked on: %s\n\n", strings.Join(succeeded, ", "))
+	}
+
+	prompt.WriteString("It failed on these hosts:\n")
+	for _, result := range b.Failures() {
+		fmt.Fprintf(&prompt, "\n### %s (%s)\n", result.Host, result.Status())
+		if result.Err != nil {
+			fmt.Fprintf(&prompt, "Error: %s\n", result.Err)
+		}
+		if output := lastLines(result.Output, diagnosisOutputLines); output != "" {
+			fmt.Fprintf(&prompt, "```\n%s\n```\n", output)
+		}
+	}
+
+	prompt.WriteString("\nGive a short cross-host diagnosis: group the hosts that failed the same way, " +
+		"say what each group's likely cause is and what sets those hosts apart from the ones that worked, " +
+		"and suggest the next command to run to confirm or fix it.")
+	return prompt.String()
+}
+
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
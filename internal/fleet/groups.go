@@ -0,0 +1,196 @@
+package fleet
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Groups maps the name of a host group to its hosts.
+type Groups map[string][]string
+
+// groupsFile is the format of the fleet config file:
+//
+//	groups:
+//	  web: [web1, web2]
+//	  db:
+//	    - db-primary
+//	    - db-replica
+type groupsFile struct {
+	Groups Groups `yaml:"groups"`
+}
+
+// DefaultGroupsPath returns where host groups are configured.
+func DefaultGroupsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "fleet.yaml")
+}
+
+// DefaultSSHConfigPath returns the user's ssh config.
+func DefaultSSHConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// LoadGroups reads the host groups at path. A missing file has no groups.
+func LoadGroups(path string) (Groups, error) {
+	if path == "" {
+		return Groups{}, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Groups{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file groupsFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Groups == nil {
+		file.Groups = Groups{}
+	}
+	return file.Groups, nil
+}
+
+// Names returns the group names in order.
+func (groups Groups) Names() []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the hosts target refers to: a group name, or a comma
+// separated list of hosts and patterns such as web-*, which match the Host
+// entries of the ssh config. Hosts are deduplicated and keep their order.
+func (groups Groups) Resolve(target string, sshHosts []string) ([]string, error) {
+	if hosts, ok := groups[target]; ok {
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("group %s has no hosts", target)
+		}
+		return dedupe(hosts), nil
+	}
+
+	var hosts []string
+	for _, item := range strings.Split(target, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if group, ok := groups[item]; ok {
+			hosts = append(hosts, group...)
+			continue
+		}
+		if !strings.ContainsAny(item, "*?[") {
+			hosts = append(hosts, item)
+			continue
+		}
+		matched := false
+		for _, host := range sshHosts {
+			if ok, _ := path.Match(item, host); ok {
+				hosts = append(hosts, host)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no host in the ssh config matches %s", item)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in %q", target)
+	}
+	return dedupe(hosts), nil
+}
+
+func dedupe(hosts []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, host := range hosts {
+		if !seen[host] {
+			seen[host] = true
+			unique = append(unique, host)
+		}
+	}
+	return unique
+}
+
+// SSHHosts returns the Host aliases of the ssh config at configPath and the
+// files it includes, leaving out patterns.
+func SSHHosts(configPath string) []string {
+	if configPath == "" {
+		return nil
+	}
+	var hosts []string
+	known := map[string]bool{}
+	readSSHConfig(configPath, filepath.Dir(configPath), map[string]bool{}, func(host string) {
+		if !known[host] {
+			known[host] = true
+			hosts = append(hosts, host)
+		}
+	})
+	return hosts
+}
+
+// readSSHConfig calls add for each Host alias in configPath. visited guards
+// against Include loops.
+func readSSHConfig(configPath, sshDir string, visited map[string]bool, add func(string)) {
+	if visited[configPath] {
+		return
+	}
+	visited[configPath] = true
+
+	file, err := os.Open(configPath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "host":
+			for _, host := range fields[1:] {
+				if !strings.ContainsAny(host, "*?!") {
+					add(host)
+				}
+			}
+		case "include":
+			for _, pattern := range fields[1:] {
+				if strings.HasPrefix(pattern, "~/") {
+					if home, err := os.UserHomeDir(); err == nil {
+						pattern = filepath.Join(home, pattern[2:])
+					}
+				}
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(sshDir, pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					readSSHConfig(match, sshDir, visited, add)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("groups:\n  web: [web1, web2]\n  db:\n    - db1\n"), 0o644))
+
+	groups, err := LoadGroups(path)
+	require.NoError(t, err)
+	assert.Equal(t, Groups{"web": {"web1", "web2"}, "db": {"db1"}}, groups)
+	assert.Equal(t, []string{"db", "web"}, groups.Names())
+
+	groups, err = LoadGroups(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	require.NoError(t, os.WriteFile(path, []byte("groups: [\n"), 0o644))
+	_, err = LoadGroups(path)
+	assert.ErrorContains(t, err, path)
+}
+
+func TestResolve(t *testing.T) {
+	groups := Groups{"web": {"web1", "web2"}, "empty": nil}
+	sshHosts := []string{"web1", "db-1", "db-2", "bastion"}
+
+	hosts, err := groups.Resolve("web", sshHosts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web1", "web2"}, hosts)
+
+	hosts, err = groups.Resolve("db-*,web,bastion,web1", sshHosts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-1", "db-2", "web1", "web2", "bastion"}, hosts)
+
+	_, err = groups.Resolve("cache-*", sshHosts)
+	assert.ErrorContains(t, err, "no host in the ssh config matches cache-*")
+
+	_, err = groups.Resolve("empty", sshHosts)
+	assert.ErrorContains(t, err, "has no hosts")
+}
+
+func TestSSHHosts(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "conf.d"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config"), []byte(
+		"# personal\nHost bastion jump\n  User me\nHost *.internal !skip\nInclude conf.d/*\nInclude config\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", "work"), []byte("host web1 bastion\n"), 0o644))
+
+	assert.Equal(t, []string{"bastion", "jump", "web1"}, SSHHosts(filepath.Join(dir, "config")))
+	assert.Empty(t, SSHHosts(filepath.Join(dir, "missing")))
+}
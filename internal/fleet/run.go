@@ -0,0 +1,206 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// maxOutput is how much of the output of each host is kept; the end is kept,
+// as that is where errors usually are.
+const maxOutput = 64 * 1024
+
+// Result is the outcome of a command on one host.
+type Result struct {
+	Host     string
+	ExitCode int
+	Output   string
+	// Err is set when the command could not be run at all, or timed out
+	Err      error
+	Duration time.Duration
+}
+
+// Failed reports whether the command failed on the host.
+func (r Result) Failed() bool {
+	return r.Err != nil || r.ExitCode != 0
+}
+
+// Status describes the outcome in a word or two.
+func (r Result) Status() string {
+	switch {
+	case errors.Is(r.Err, context.DeadlineExceeded):
+		return "timed out"
+	case r.Err != nil:
+		return "error"
+	case r.ExitCode != 0:
+		return fmt.Sprintf("exit %d", r.ExitCode)
+	default:
+		return "ok"
+	}
+}
+
+// RunFunc runs command on host and returns its combined output and exit
+// code.
+type RunFunc func(ctx context.Context, host, command string) (output string, exitCode int, err error)
+
+// Options control how a command is broadcast.
+type Options struct {
+	// Parallel is how many hosts run the command at once
+	Parallel int
+	// Timeout bounds the command on each host; zero means no limit
+	Timeout time.Duration
+	// Run runs the command on a host; it defaults to SSH
+	Run RunFunc
+	// Progress is called after each host finishes
+	Progress func(done, total int, result Result)
+}
+
+// Run runs command on every host and returns the results in host order.
+func Run(ctx context.Context, hosts []string, command string, opts Options) []Result {
+	if opts.Parallel < 1 {
+		opts.Parallel = 1
+	}
+	if opts.Run == nil {
+		opts.Run = SSH
+	}
+
+	results := make([]Result, len(hosts))
+	slots := make(chan struct{}, opts.Parallel)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			hostCtx, cancel := ctx, context.CancelFunc(func() {})
+			if opts.Timeout > 0 {
+				hostCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			}
+			start := time.Now()
+			output, exitCode, err := opts.Run(hostCtx, host, command)
+			if err == nil && hostCtx.Err() != nil {
+				err = hostCtx.Err()
+			}
+			cancel()
+
+			result := Result{
+				Host:     host,
+				ExitCode: exitCode,
+				Output:   tail(output, maxOutput),
+				Err:      err,
+				Duration: time.Since(start),
+			}
+			results[i] = result
+			if opts.Progress != nil {
+				mu.Lock()
+				done++
+				opts.Progress(done, len(hosts), result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// SSH runs command on host with the system ssh client. It never prompts, so
+// hosts need key or agent authentication.
+func SSH(ctx context.Context, host, command string) (string, int, error) {
+	cmd := exec.CommandContext(ctx, "ssh",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-T", host, "--", command)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		// ssh itself exits with 255 when it cannot connect
+		if exitErr.ExitCode() == 255 {
+			return output.String(), 255, fmt.Errorf("ssh failed: %s", lastLine(output.String()))
+		}
+		return output.String(), exitErr.ExitCode(), nil
+	}
+	return output.String(), -1, err
+}
+
+// Summary counts the results, e.g. "5 hosts: 4 ok, 1 failed".
+func Summary(results []Result) string {
+	failed := 0
+	for _, result := range results {
+		if result.Failed() {
+			failed++
+		}
+	}
+	noun := "hosts"
+	if len(results) == 1 {
+		noun = "host"
+	}
+	if failed == 0 {
+		return fmt.Sprintf("%d %s: all ok", len(results), noun)
+	}
+	return fmt.Sprintf("%d %s: %d ok, %d failed", len(results), noun, len(results)-failed, failed)
+}
+
+// Table renders the results with the last line of output of each host.
+func Table(results []Result, width int) string {
+	ok := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	bad := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+	outputWidth := width - 40
+	if outputWidth < 20 {
+		outputWidth = 20
+	}
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers("Host", "Status", "Time", "Output").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if row == table.HeaderRow || col != 1 {
+				return style
+			}
+			if results[row].Failed() {
+				return style.Inherit(bad)
+			}
+			return style.Inherit(ok)
+		})
+	for _, result := range results {
+		line := lastLine(result.Output)
+		if result.Err != nil && line == "" {
+			line = result.Err.Error()
+		}
+		if runes := []rune(line); len(runes) > outputWidth {
+			line = string(runes[:outputWidth-1]) + "…"
+		}
+		t.Row(result.Host, result.Status(), result.Duration.Round(100*time.Millisecond).String(), line)
+	}
+	return t.String()
+}
+
+func lastLine(output string) string {
+	output = strings.TrimRight(output, "\n")
+	if i := strings.LastIndexByte(output, '\n'); i >= 0 {
+		output = output[i+1:]
+	}
+	return strings.TrimSpace(output)
+}
+
+func tail(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	return "…" + output[len(output)-limit:]
+}
@@ -0,0 +1,149 @@
+// Package focus tracks the task declared with #!focus and summarizes the
+// work done on it when the focus switches or ends.
+package focus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// maxSummaryCommands limits how many commands are sent to the LLM; the
+// oldest are dropped first.
+const maxSummaryCommands = 200
+
+// Session is a period of work on one task.
+type Session struct {
+	Task    string
+	Started time.Time
+}
+
+// Summarizer summarizes focus sessions using the slow LLM model.
+type Summarizer struct {
+	runner         *interp.Runner
+	historyManager *history.HistoryManager
+	logger         *zap.Logger
+}
+
+// NewSummarizer creates a new focus session summarizer.
+func NewSummarizer(runner *interp.Runner, historyManager *history.HistoryManager, logger *zap.Logger) *Summarizer {
+	return &Summarizer{
+		runner:         runner,
+		historyManager: historyManager,
+		logger:         logger,
+	}
+}
+
+// Summarize returns a summary of the commands run during session. The first
+// line gives the numbers; the rest is written by the LLM and is left out if
+// it cannot be reached, in which case the error is returned with the numbers.
+func (s *Summarizer) Summarize(ctx context.Context, session Session, end time.Time) (string, error) {
+	entries, err := s.historyManager.GetTaskEntries(session.Task, session.Started)
+	if err != nil {
+		return "", fmt.Errorf("failed to get task commands: %w", err)
+	}
+
+	stats := Stats(entries, end.Sub(session.Started))
+	if len(entries) == 0 {
+		return stats, nil
+	}
+
+	client, modelConfig := utils.GetLLMClient(s.runner, utils.SlowModel)
+
+	systemPrompt := `You are a helpful assistant that summarizes shell activity.
+You will be given the task I declared I was working on and the shell commands I ran while working on it.
+Summarize what I did for the task in at most 4 short bullet points starting with "- ".
+Mention what seems to be done and what seems to be left, based on the commands and their exit codes.
+Do not repeat the commands verbatim unless they matter. Do not add a heading or closing remarks.`
+
+	request := openai.ChatCompletionRequest{
+		Model: modelConfig.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: summaryPrompt(session.Task, entries)},
+		},
+	}
+	if modelConfig.Temperature != nil {
+		request.Temperature = float32(*modelConfig.Temperature)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return stats, fmt.Errorf("failed to generate summary: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return stats, fmt.Errorf("no response from LLM")
+	}
+
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	s.logger.Debug("generated focus summary",
+		zap.String("task", session.Task),
+		zap.String("summary", summary),
+		zap.Int("command_count", len(entries)),
+	)
+	if summary == "" {
+		return stats, nil
+	}
+	return stats + "\n" + summary, nil
+}
+
+// Stats describes how many commands were run for a task, how many failed and
+// how long the work took, e.g. "12 commands (2 failed) in 35m".
+func Stats(entries []history.HistoryEntry, elapsed time.Duration) string {
+	failed := 0
+	for _, entry := range entries {
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			failed++
+		}
+	}
+
+	noun := "commands"
+	if len(entries) == 1 {
+		noun = "command"
+	}
+	stats := fmt.Sprintf("%d %s", len(entries), noun)
+	if failed > 0 {
+		stats += fmt.Sprintf(" (%d failed)", failed)
+	}
+	return stats + " in " + FormatElapsed(elapsed)
+}
+
+// FormatElapsed formats a duration in minutes, or hours and minutes.
+func FormatElapsed(elapsed time.Duration) string {
+	minutes := int(elapsed.Minutes())
+	if minutes < 1 {
+		return "less than a minute"
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+func summaryPrompt(task string, entries []history.HistoryEntry) string {
+	if len(entries) > maxSummaryCommands {
+		entries = entries[len(entries)-maxSummaryCommands:]
+	}
+
+	var commandList strings.Builder
+	for _, entry := range entries {
+		exitStatus := "✓"
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			exitStatus = fmt.Sprintf("✗(%d)", entry.ExitCode.Int32)
+		}
+		commandList.WriteString(fmt.Sprintf("[%s] %s %s\n",
+			entry.CreatedAt.Format("15:04:05"),
+			exitStatus,
+			entry.Command,
+		))
+	}
+
+	return fmt.Sprintf("<task>%s</task>\n\nCommands I ran for this task:\n\n%s", task, commandList.String())
+}
@@ -0,0 +1,62 @@
+package focus
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func entry(command string, exitCode int32) history.HistoryEntry {
+	return history.HistoryEntry{
+		Command:   command,
+		CreatedAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+		ExitCode:  sql.NullInt32{Int32: exitCode, Valid: true},
+	}
+}
+
+func TestStats(t *testing.T) {
+	entries := []history.HistoryEntry{entry("crontab -l", 0), entry("run-billing", 1), entry("vim billing.cron", 0)}
+	assert.Equal(t, "3 commands (1 failed) in 35m", Stats(entries, 35*time.Minute))
+	assert.Equal(t, "1 command in 1h05m", Stats(entries[:1], 65*time.Minute))
+	assert.Equal(t, "0 commands in less than a minute", Stats(nil, 10*time.Second))
+}
+
+func TestSummaryPrompt(t *testing.T) {
+	prompt := summaryPrompt("fix the billing cron", []history.HistoryEntry{entry("crontab -l", 0), entry("run-billing", 2)})
+	assert.Contains(t, prompt, "<task>fix the billing cron</task>")
+	assert.Contains(t, prompt, "[09:30:00] ✓ crontab -l\n")
+	assert.Contains(t, prompt, "[09:30:00] ✗(2) run-billing\n")
+
+	var entries []history.HistoryEntry
+	for i := 0; i < maxSummaryCommands+5; i++ {
+		entries = append(entries, entry("echo "+strings.Repeat("x", i), 0))
+	}
+	prompt = summaryPrompt("task", entries)
+	assert.Equal(t, maxSummaryCommands, strings.Count(prompt, "✓"))
+	assert.NotContains(t, prompt, "✓ echo \n")
+}
+
+func TestSummarizeWithoutCommands(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	runner, err := interp.New()
+	require.NoError(t, err)
+
+	historyManager.SetTask("other task")
+	_, err = historyManager.StartCommand("ls", "/", "session")
+	require.NoError(t, err)
+
+	summarizer := NewSummarizer(runner, historyManager, zap.NewNop())
+	started := time.Now().Add(-time.Minute)
+	summary, err := summarizer.Summarize(context.Background(), Session{Task: "fix the billing cron", Started: started}, started.Add(20*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "0 commands in 20m", summary)
+}
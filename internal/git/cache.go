@@ -0,0 +1,233 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// statusDebounce is how long a refresh waits for more invalidations, so
+	// that a burst of commands results in a single git status.
+	statusDebounce = 100 * time.Millisecond
+	// statusStaleAfter is how old a cached status may get before it is
+	// refreshed in the background, to pick up changes made outside the shell.
+	statusStaleAfter = 5 * time.Second
+	// statusRefreshTimeout bounds a single git status run.
+	statusRefreshTimeout = 30 * time.Second
+	// statusEvictAfter is how long a repository the shell has left stays
+	// cached before its goroutine is stopped.
+	statusEvictAfter = 10 * time.Minute
+)
+
+// StatusCache keeps the status of each repository the shell visits, so
+// that the prompt can show it instantly. A goroutine per repository runs git
+// status in the background whenever the status is invalidated or gets old.
+// Repositories not asked about for statusEvictAfter are dropped, and their
+// goroutine stopped.
+type StatusCache struct {
+	mu    sync.Mutex
+	repos map[string]*repoCache
+	// run produces the status of a repository
+	run        func(ctx context.Context, repo *Repo) *RepoStatus
+	evictAfter time.Duration
+}
+
+// DefaultStatusCache is the cache used by the prompt.
+var DefaultStatusCache = NewStatusCache()
+
+// NewStatusCache creates an empty StatusCache.
+func NewStatusCache() *StatusCache {
+	return &StatusCache{repos: map[string]*repoCache{}, run: repoStatus, evictAfter: statusEvictAfter}
+}
+
+// repoCache is the cached status of one repository.
+type repoCache struct {
+	repo *Repo
+	run  func(ctx context.Context, repo *Repo) *RepoStatus
+	// used is when the cache was last asked about the repository; it is
+	// guarded by StatusCache.mu
+	used time.Time
+	// stopped is closed when the goroutine has exited
+	stopped chan struct{}
+
+	mu      sync.Mutex
+	status  *RepoStatus
+	updated time.Time
+	// pending is true while a refresh is scheduled or running; done is
+	// closed when it completes
+	pending bool
+	done    chan struct{}
+	wake    chan struct{}
+	evicted bool
+}
+
+// Cached returns the last known status of the repository dir is in, without
+// waiting; it is nil if dir is not in a repository or its status is not known
+// yet. A refresh is started if there is none or it is old.
+func (c *StatusCache) Cached(dir string) *RepoStatus {
+	repo := c.repo(dir)
+	if repo == nil {
+		return nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.status == nil || time.Since(repo.updated) > statusStaleAfter {
+		repo.scheduleLocked()
+	}
+	return repo.status
+}
+
+// Refreshed waits for the refresh of the status of the repository dir is in,
+// if one is pending, and returns the latest status. It returns early with the
+// cached status when ctx is done.
+func (c *StatusCache) Refreshed(ctx context.Context, dir string) *RepoStatus {
+	repo := c.repo(dir)
+	if repo == nil {
+		return nil
+	}
+	repo.mu.Lock()
+	done := repo.done
+	pending := repo.pending
+	repo.mu.Unlock()
+
+	if pending {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.status
+}
+
+// Invalidate marks the status of the repository dir is in as out of date,
+// e.g. after a command ran. Invalidations close together are debounced into
+// one refresh.
+func (c *StatusCache) Invalidate(dir string) {
+	repo := c.repo(dir)
+	if repo == nil {
+		return
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.scheduleLocked()
+}
+
+// repo returns the cache of the repository dir is in, starting its
+// goroutine the first time, or nil if dir is not in a repository. It evicts
+// the repositories that have not been asked about for a while.
+func (c *StatusCache) repo(dir string) *repoCache {
+	found := FindRepo(dir, os.Getenv)
+	if found == nil {
+		return nil
+	}
+	key := found.Root + "\x00" + found.GitDir
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for other, repo := range c.repos {
+		if other != key && now.Sub(repo.used) > c.evictAfter {
+			delete(c.repos, other)
+			repo.evict()
+		}
+	}
+	if repo, ok := c.repos[key]; ok {
+		repo.used = now
+		return repo
+	}
+	repo := &repoCache{
+		repo:    found,
+		run:     c.run,
+		used:    now,
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+	c.repos[key] = repo
+	go repo.loop()
+	return repo
+}
+
+// evict stops the goroutine once it is done with the refresh it may be
+// running.
+func (repo *repoCache) evict() {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.evicted = true
+	close(repo.wake)
+}
+
+// scheduleLocked asks the goroutine for a refresh. repo.mu must be held.
+func (repo *repoCache) scheduleLocked() {
+	if repo.evicted {
+		return
+	}
+	if !repo.pending {
+		repo.pending = true
+		repo.done = make(chan struct{})
+	}
+	select {
+	case repo.wake <- struct{}{}:
+	default:
+		// A refresh is already scheduled
+	}
+}
+
+// loop refreshes the status whenever it is woken up, until it is evicted.
+func (repo *repoCache) loop() {
+	defer close(repo.stopped)
+	for range repo.wake {
+		time.Sleep(statusDebounce)
+		// Invalidations during the debounce are covered by this refresh
+		select {
+		case <-repo.wake:
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusRefreshTimeout)
+		status := repo.run(ctx, repo.repo)
+		cancel()
+
+		repo.mu.Lock()
+		if status != nil {
+			repo.status = status
+		}
+		repo.updated = time.Now()
+		// A new invalidation may have come in while git was running; then
+		// another refresh is already queued and keeps pending set
+		if len(repo.wake) == 0 {
+			repo.pending = false
+			close(repo.done)
+		}
+		repo.mu.Unlock()
+	}
+}
+
+// repoStatus runs git status. It takes no optional locks so that it does
+// not get in the way of the user's own git commands. The untracked cache and
+// filesystem monitor are left to the repository's own config: the cache is
+// never written back without the locks, and forcing the monitor on would
+// start a daemon in every repository the prompt visits. Untracked files are
+// left out for bare repositories, which track a few files in a directory full
+// of others.
+func repoStatus(ctx context.Context, repo *Repo) *RepoStatus {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	args := append(repo.GitArgs(), "status", "--porcelain=v2", "--branch")
+	if repo.Kind == KindBare {
+		args = append(args, "--untracked-files=no")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repo.Root
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseStatus(repo, string(out))
+}
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepo returns a directory that looks like a repository, and a cache
+// whose git status counts its runs.
+func fakeRepo(t *testing.T) (string, *StatusCache, *atomic.Int32) {
+	t.Helper()
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+
+	var runs atomic.Int32
+	cache := NewStatusCache()
+	cache.run = func(ctx context.Context, repo *Repo) *RepoStatus {
+		n := runs.Add(1)
+		return &RepoStatus{RepoName: repo.Name, Staged: int(n)}
+	}
+	return root, cache, &runs
+}
+
+func TestStatusCacheServesCachedStatus(t *testing.T) {
+	root, cache, runs := fakeRepo(t)
+	sub := filepath.Join(root, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0o755))
+
+	assert.Nil(t, cache.Cached(sub))
+	status := cache.Refreshed(context.Background(), sub)
+	assert.Equal(t, 1, status.Staged)
+
+	// Fresh statuses are served without running git again
+	assert.Same(t, status, cache.Cached(root))
+	assert.Same(t, status, cache.Refreshed(context.Background(), root))
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestStatusCacheDebouncesInvalidations(t *testing.T) {
+	root, cache, runs := fakeRepo(t)
+	for i := 0; i < 5; i++ {
+		cache.Invalidate(root)
+	}
+	status := cache.Refreshed(context.Background(), root)
+	assert.Equal(t, int32(1), runs.Load())
+	assert.Equal(t, 1, status.Staged)
+
+	cache.Invalidate(root)
+	assert.Equal(t, 2, cache.Refreshed(context.Background(), root).Staged)
+}
+
+func TestStatusCacheRefreshedReturnsCachedOnTimeout(t *testing.T) {
+	root, cache, _ := fakeRepo(t)
+	cache.Invalidate(root)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Nil(t, cache.Refreshed(ctx, root))
+}
+
+func TestStatusCacheEvictsIdleRepositories(t *testing.T) {
+	root, cache, _ := fakeRepo(t)
+	other := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(other, ".git"), 0o755))
+	cache.evictAfter = 50 * time.Millisecond
+
+	cache.Cached(root)
+	assert.NotNil(t, cache.Refreshed(context.Background(), root))
+	evicted := cache.repo(root)
+	time.Sleep(100 * time.Millisecond)
+
+	// Asking about another repository drops the idle one and stops its goroutine
+	cache.Cached(other)
+	select {
+	case <-evicted.stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the goroutine of the evicted repository is still running")
+	}
+	cache.mu.Lock()
+	assert.Len(t, cache.repos, 1)
+	cache.mu.Unlock()
+
+	// Coming back starts over
+	assert.Nil(t, cache.Cached(root))
+}
+
+func TestStatusCacheOutsideRepository(t *testing.T) {
+	cache := NewStatusCache()
+	dir := t.TempDir()
+	assert.Nil(t, cache.Cached(dir))
+	assert.Nil(t, cache.Refreshed(context.Background(), dir))
+	cache.Invalidate(dir)
+}
+
+func TestRepoStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "-b", "main")
+	cmd.Dir = root
+	assert.NoError(t, cmd.Run())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "new.txt"), []byte("x"), 0o644))
+
+	status := repoStatus(context.Background(), FindRepo(root, noEnv))
+	assert.NotNil(t, status)
+	assert.Equal(t, filepath.Base(root), status.RepoName)
+	assert.Equal(t, KindNormal, status.Kind)
+	assert.Equal(t, "main", status.Branch)
+	assert.Equal(t, 1, status.Unstaged)
+	assert.False(t, status.Clean)
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// NewGitCommandHandler creates an ExecHandler for the bish_git builtin, which
+// prints a field of the repository the current directory is in for use in
+// BISH_UPDATE_PROMPT:
+//
+//	BISH_PROMPT="$(bish_git worktree) > "
+//
+// Fields are repo, root, branch, worktree, kind and superproject. Nothing is
+// printed and the status is 1 outside a repository.
+func NewGitCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "bish_git" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) != 2 || args[1] == "-h" || args[1] == "--help" {
+				fmt.Fprintln(hc.Stdout, "Usage: bish_git repo|root|branch|worktree|kind|superproject")
+				return nil
+			}
+
+			repo := FindRepo(hc.Dir, func(name string) string {
+				return hc.Env.Get(name).String()
+			})
+			if repo == nil {
+				return interp.NewExitStatus(1)
+			}
+
+			var value string
+			switch args[1] {
+			case "repo":
+				value = repo.Name
+			case "root":
+				value = repo.Root
+			case "branch":
+				value = repo.Branch()
+			case "worktree":
+				value = repo.Worktree
+			case "kind":
+				value = string(repo.Kind)
+			case "superproject":
+				value = repo.Superproject
+			default:
+				return fmt.Errorf("bish_git: unknown field %q", args[1])
+			}
+			if value != "" {
+				fmt.Fprintln(hc.Stdout, value)
+			}
+			return nil
+		}
+	}
+}
+
+// headBranch reads the branch checked out in gitDir from its HEAD, without
+// running git, so that it is cheap enough for the prompt. It is "detached"
+// when HEAD is not a branch.
+func headBranch(gitDir string) string {
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		return "detached"
+	}
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runBishGit(t *testing.T, dir string, script string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	runner, err := interp.New(
+		interp.Dir(dir),
+		interp.Env(expand.ListEnviron("HOME="+t.TempDir())),
+		interp.StdIO(nil, &stdout, &stdout),
+		interp.ExecHandlers(NewGitCommandHandler()),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), err
+}
+
+func TestGitCommandPrintsWorktreeFields(t *testing.T) {
+	base := t.TempDir()
+	gitDir := filepath.Join(base, "project", ".git", "worktrees", "feature-x")
+	worktree := filepath.Join(base, "project-feature")
+	mkdirs(t, gitDir, worktree)
+	writeFile(t, filepath.Join(gitDir, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/feature/x\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+
+	out, err := runBishGit(t, worktree, `echo "$(bish_git repo) $(bish_git worktree) $(bish_git branch) $(bish_git kind)"`)
+	require.NoError(t, err)
+	assert.Equal(t, "project feature-x feature/x worktree\n", out)
+}
+
+func TestGitCommandOutsideRepository(t *testing.T) {
+	out, err := runBishGit(t, t.TempDir(), "bish_git branch || echo none")
+	require.NoError(t, err)
+	assert.Equal(t, "none\n", out)
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	_, err = runBishGit(t, root, "bish_git colour")
+	assert.ErrorContains(t, err, "unknown field")
+}
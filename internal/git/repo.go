@@ -0,0 +1,210 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RepoKind is how a directory's work tree is attached to its repository.
+type RepoKind string
+
+const (
+	// KindNormal is a work tree with a .git directory.
+	KindNormal RepoKind = "normal"
+	// KindWorktree is a linked worktree added with git worktree add.
+	KindWorktree RepoKind = "worktree"
+	// KindSubmodule is a submodule checked out inside another repository.
+	KindSubmodule RepoKind = "submodule"
+	// KindBare is a work tree managed through a separate bare repository,
+	// as dotfile managers do, e.g. git --git-dir=~/.cfg --work-tree=~.
+	KindBare RepoKind = "bare"
+)
+
+// dotfileGitDirs are where dotfile managers keep the bare repository that
+// tracks the home directory, relative to it.
+var dotfileGitDirs = []string{
+	".local/share/yadm/repo.git",
+	".cfg",
+	".dotfiles.git",
+	".dotfiles",
+	".dots",
+}
+
+// Repo is the repository a directory belongs to.
+type Repo struct {
+	Kind RepoKind
+	// Root is the top of the work tree
+	Root string
+	// GitDir is the git directory of the work tree, e.g.
+	// .git/worktrees/<name> for a linked worktree
+	GitDir string
+	// Name is the name of the repository: the main repository's for linked
+	// worktrees, the work tree's otherwise
+	Name string
+	// Worktree is the name of a linked worktree
+	Worktree string
+	// Superproject is the root of the repository a submodule is in
+	Superproject string
+}
+
+// FindRepo returns the repository dir belongs to, or nil. getenv looks up
+// GIT_DIR, GIT_WORK_TREE and HOME, which locate bare repositories that track
+// the home directory.
+func FindRepo(dir string, getenv func(string) string) *Repo {
+	if dir == "" {
+		return nil
+	}
+	if gitDir := getenv("GIT_DIR"); gitDir != "" {
+		root := getenv("GIT_WORK_TREE")
+		if root == "" {
+			root = dir
+		}
+		return bareRepo(absFrom(dir, gitDir), absFrom(dir, root))
+	}
+
+	dir = filepath.Clean(dir)
+	for current := dir; ; {
+		dotGit := filepath.Join(current, ".git")
+		if info, err := os.Lstat(dotGit); err == nil {
+			if info.IsDir() {
+				return &Repo{Kind: KindNormal, Root: current, GitDir: dotGit, Name: filepath.Base(current)}
+			}
+			return linkedRepo(current, dotGit)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return dotfilesRepo(dir, getenv("HOME"))
+}
+
+// linkedRepo returns the repository of the work tree at root, whose .git is a
+// file pointing at the git directory: a linked worktree or a submodule.
+func linkedRepo(root, dotGit string) *Repo {
+	repo := &Repo{Kind: KindNormal, Root: root, GitDir: dotGit, Name: filepath.Base(root)}
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return repo
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return repo
+	}
+	repo.GitDir = absFrom(root, strings.TrimSpace(gitDir))
+
+	// A linked worktree's git directory is <common>/worktrees/<name> and
+	// has a commondir file
+	if commonDir, err := os.ReadFile(filepath.Join(repo.GitDir, "commondir")); err == nil {
+		common := absFrom(repo.GitDir, strings.TrimSpace(string(commonDir)))
+		repo.Kind = KindWorktree
+		repo.Worktree = filepath.Base(repo.GitDir)
+		if filepath.Base(common) == ".git" {
+			repo.Name = filepath.Base(filepath.Dir(common))
+		} else {
+			repo.Name = strings.TrimSuffix(filepath.Base(common), ".git")
+		}
+		return repo
+	}
+
+	// A submodule's git directory is <superproject>/.git/modules/<path>
+	slashed := filepath.ToSlash(repo.GitDir)
+	if i := strings.Index(slashed, "/.git/modules/"); i >= 0 {
+		repo.Kind = KindSubmodule
+		repo.Superproject = filepath.FromSlash(slashed[:i])
+	}
+	return repo
+}
+
+// dotfilesRepo returns the bare repository of a dotfile manager that tracks
+// home, if dir is in home and there is one.
+func dotfilesRepo(dir, home string) *Repo {
+	if home == "" {
+		return nil
+	}
+	home = filepath.Clean(home)
+	if dir != home && !strings.HasPrefix(dir, home+string(filepath.Separator)) {
+		return nil
+	}
+	for _, candidate := range dotfileGitDirs {
+		gitDir := filepath.Join(home, candidate)
+		if isBareRepo(gitDir) {
+			return bareRepo(gitDir, home)
+		}
+	}
+	return nil
+}
+
+func bareRepo(gitDir, root string) *Repo {
+	name := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(gitDir), ".git"), ".")
+	if name == "repo" {
+		// yadm keeps it in yadm/repo.git
+		name = filepath.Base(filepath.Dir(gitDir))
+	}
+	return &Repo{Kind: KindBare, Root: root, GitDir: gitDir, Name: name}
+}
+
+// isBareRepo reports whether dir holds a repository without a work tree of
+// its own.
+func isBareRepo(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return os.IsNotExist(err)
+}
+
+// absFrom resolves path relative to dir.
+func absFrom(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// GitArgs returns the arguments that make git operate on repo from any
+// directory.
+func (repo *Repo) GitArgs() []string {
+	if repo.Kind == KindBare {
+		return []string{"--git-dir=" + repo.GitDir, "--work-tree=" + repo.Root}
+	}
+	return []string{"-C", repo.Root}
+}
+
+// Branch returns the branch checked out in repo, "detached" if none is, or
+// "" if it cannot be read.
+func (repo *Repo) Branch() string {
+	return headBranch(repo.GitDir)
+}
+
+// RemoteURL returns the URL of the remote name, such as origin, read from the
+// configuration of repo, or "" if it has no such remote.
+func (repo *Repo) RemoteURL(name string) string {
+	configDir := repo.GitDir
+	if commonDir, err := os.ReadFile(filepath.Join(repo.GitDir, "commondir")); err == nil {
+		configDir = absFrom(repo.GitDir, strings.TrimSpace(string(commonDir)))
+	}
+	config, err := os.ReadFile(filepath.Join(configDir, "config"))
+	if err != nil {
+		return ""
+	}
+	section := `[remote "` + name + `"]`
+	inSection := false
+	for _, line := range strings.Split(string(config), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inSection = line == section
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inSection && ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noEnv(string) string { return "" }
+
+func mkdirs(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		assert.NoError(t, os.MkdirAll(path, 0o755))
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestFindRepoNormal(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "project")
+	mkdirs(t, filepath.Join(root, ".git"), filepath.Join(root, "src"))
+
+	repo := FindRepo(filepath.Join(root, "src"), noEnv)
+	assert.Equal(t, &Repo{Kind: KindNormal, Root: root, GitDir: filepath.Join(root, ".git"), Name: "project"}, repo)
+	assert.Nil(t, FindRepo(t.TempDir(), noEnv))
+}
+
+func TestFindRepoLinkedWorktree(t *testing.T) {
+	base := t.TempDir()
+	main := filepath.Join(base, "project")
+	gitDir := filepath.Join(main, ".git", "worktrees", "feature-x")
+	worktree := filepath.Join(base, "project-feature")
+	mkdirs(t, gitDir, worktree)
+	writeFile(t, filepath.Join(gitDir, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+
+	repo := FindRepo(worktree, noEnv)
+	assert.Equal(t, KindWorktree, repo.Kind)
+	assert.Equal(t, worktree, repo.Root)
+	assert.Equal(t, "project", repo.Name)
+	assert.Equal(t, "feature-x", repo.Worktree)
+}
+
+func TestFindRepoSubmodule(t *testing.T) {
+	super := filepath.Join(t.TempDir(), "app")
+	sub := filepath.Join(super, "vendor", "lib")
+	mkdirs(t, filepath.Join(super, ".git", "modules", "vendor", "lib"), sub)
+	writeFile(t, filepath.Join(sub, ".git"), "gitdir: ../../.git/modules/vendor/lib\n")
+
+	repo := FindRepo(sub, noEnv)
+	assert.Equal(t, KindSubmodule, repo.Kind)
+	assert.Equal(t, sub, repo.Root)
+	assert.Equal(t, "lib", repo.Name)
+	assert.Equal(t, super, repo.Superproject)
+	assert.Equal(t, filepath.Join(super, ".git", "modules", "vendor", "lib"), repo.GitDir)
+}
+
+func TestFindRepoDotfilesBareRepo(t *testing.T) {
+	home := t.TempDir()
+	bare := filepath.Join(home, ".cfg")
+	mkdirs(t, filepath.Join(bare, "objects"), filepath.Join(bare, "refs"), filepath.Join(home, ".config", "nvim"))
+	writeFile(t, filepath.Join(bare, "HEAD"), "ref: refs/heads/main\n")
+	env := func(name string) string {
+		if name == "HOME" {
+			return home
+		}
+		return ""
+	}
+
+	repo := FindRepo(filepath.Join(home, ".config", "nvim"), env)
+	assert.Equal(t, &Repo{Kind: KindBare, Root: home, GitDir: bare, Name: "cfg"}, repo)
+	assert.Equal(t, []string{"--git-dir=" + bare, "--work-tree=" + home}, repo.GitArgs())
+	assert.Nil(t, FindRepo(t.TempDir(), env))
+}
+
+func TestFindRepoFromEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{"GIT_DIR": "/srv/dotfiles.git", "GIT_WORK_TREE": "/home/u"}
+	repo := FindRepo(dir, func(name string) string { return env[name] })
+	assert.Equal(t, &Repo{Kind: KindBare, Root: "/home/u", GitDir: "/srv/dotfiles.git", Name: "dotfiles"}, repo)
+}
+
+func TestRemoteURL(t *testing.T) {
+	base := t.TempDir()
+	main := filepath.Join(base, "project")
+	gitDir := filepath.Join(main, ".git", "worktrees", "feature-x")
+	worktree := filepath.Join(base, "project-feature")
+	mkdirs(t, gitDir, worktree)
+	writeFile(t, filepath.Join(main, ".git", "config"), `[core]
+	bare = false
+[remote "upstream"]
+	url = https://github.com/upstream/project.git
+[remote "origin"]
+	url = git@github.com:me/project.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`)
+	writeFile(t, filepath.Join(gitDir, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+
+	// Linked worktrees share the configuration of the main repository
+	for _, dir := range []string{main, worktree} {
+		repo := FindRepo(dir, noEnv)
+		assert.Equal(t, "git@github.com:me/project.git", repo.RemoteURL("origin"), dir)
+		assert.Equal(t, "https://github.com/upstream/project.git", repo.RemoteURL("upstream"), dir)
+		assert.Equal(t, "", repo.RemoteURL("fork"), dir)
+	}
+}
@@ -2,8 +2,7 @@ package git
 
 import (
 	"context"
-	"os/exec"
-	"path/filepath"
+	"os"
 	"strings"
 	"time"
 )
@@ -17,6 +16,11 @@ type RepoStatus struct {
 	Ahead    int
 	Behind   int
 	Conflict bool
+	// Root is the top of the work tree
+	Root string
+	Kind RepoKind
+	// Worktree is the name of a linked worktree
+	Worktree string
 }
 
 func GetStatus(dir string) *RepoStatus {
@@ -24,35 +28,24 @@ func GetStatus(dir string) *RepoStatus {
 }
 
 func GetStatusWithContext(ctx context.Context, dir string) *RepoStatus {
-	// check if git is installed
-	if _, err := exec.LookPath("git"); err != nil {
-		return nil
-	}
-
-	// check if inside a git repo
-	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-	repoPath := strings.TrimSpace(string(out))
-	repoName := filepath.Base(repoPath)
-
-	// Get status --porcelain=v2 --branch
-	cmd = exec.CommandContext(ctx, "git", "status", "--porcelain=v2", "--branch")
-	cmd.Dir = dir
-	out, err = cmd.Output()
-	if err != nil {
+	repo := FindRepo(dir, os.Getenv)
+	if repo == nil {
 		return nil
 	}
+	return repoStatus(ctx, repo)
+}
 
+// parseStatus parses the output of git status --porcelain=v2 --branch.
+func parseStatus(repo *Repo, out string) *RepoStatus {
 	status := &RepoStatus{
-		RepoName: repoName,
+		RepoName: repo.Name,
 		Clean:    true,
+		Root:     repo.Root,
+		Kind:     repo.Kind,
+		Worktree: repo.Worktree,
 	}
 
-	lines := strings.Split(string(out), "\n")
+	lines := strings.Split(out, "\n")
 	for _, line := range lines {
 		if line == "" {
 			continue
@@ -3,9 +3,14 @@ package history
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
 	"mvdan.cc/sh/v3/interp"
 )
 
@@ -32,19 +37,37 @@ func NewHistoryCommandHandler(historyManager *HistoryManager) func(next interp.E
 					if len(args) < 3 {
 						return fmt.Errorf("history -d requires an entry number")
 					}
-					offset, err := strconv.Atoi(args[2])
+					return deleteEntries(historyManager, args[2])
+
+				case "-w", "--write":
+					path, err := historyFile(ctx, "-w", args[2:])
 					if err != nil {
-						return fmt.Errorf("invalid history entry number: %s", args[2])
+						return err
 					}
-					id := uint(offset)
-					if err := historyManager.DeleteEntry(id); err != nil {
-						return fmt.Errorf("failed to delete history entry %d: %v", id, err)
+					return writeHistoryFile(historyManager, path)
+
+				case "-r", "--read":
+					path, err := historyFile(ctx, "-r", args[2:])
+					if err != nil {
+						return err
 					}
-					return nil
+					return readHistoryFile(historyManager, path)
 
 				case "-h", "--help":
 					printHistoryHelp()
 					return nil
+
+				case "import":
+					return importHistory(historyManager, args[2:])
+
+				case "export":
+					return exportHistory(ctx, historyManager, args[2:])
+
+				case "sync":
+					return syncHistory(ctx, historyManager, args[2:])
+
+				case "stats":
+					return statsHistory(ctx, historyManager, args[2:])
 				}
 			}
 
@@ -73,18 +96,326 @@ func NewHistoryCommandHandler(historyManager *HistoryManager) func(next interp.E
 	}
 }
 
+// deleteEntries deletes the entries that arg numbers, as bash does: one
+// entry, a range such as 10-15, or, when negative, counting back from the
+// last entry, -1 being the history -d command itself.
+func deleteEntries(historyManager *HistoryManager, arg string) error {
+	first, last, isRange := strings.Cut(arg, "-")
+	if first == "" {
+		back, err := strconv.Atoi(last)
+		if err != nil || back <= 0 {
+			return fmt.Errorf("invalid history entry number: %s", arg)
+		}
+		entries, err := historyManager.GetRecentEntries("", back)
+		if err != nil {
+			return err
+		}
+		if len(entries) < back {
+			return fmt.Errorf("history position out of range: %s", arg)
+		}
+		return historyManager.DeleteEntry(entries[0].ID)
+	}
+
+	start, err := strconv.ParseUint(first, 10, 0)
+	if err != nil {
+		return fmt.Errorf("invalid history entry number: %s", arg)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.ParseUint(last, 10, 0); err != nil || end < start {
+			return fmt.Errorf("invalid history entry range: %s", arg)
+		}
+	}
+	for id := start; id <= end; id++ {
+		if err := historyManager.DeleteEntry(uint(id)); err != nil && !isRange {
+			return fmt.Errorf("failed to delete history entry %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// historyFile returns the file of history -w and -r: the one in args, or
+// $HISTFILE.
+func historyFile(ctx context.Context, option string, args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		path := interp.HandlerCtx(ctx).Env.Get("HISTFILE").String()
+		if path == "" {
+			return "", fmt.Errorf("history %s: name a file, or set HISTFILE", option)
+		}
+		return path, nil
+	case 1:
+		return args[0], nil
+	default:
+		return "", fmt.Errorf("usage: history %s [file]", option)
+	}
+}
+
+// writeHistoryFile writes all of history to path as a bash history file,
+// as history -w does.
+func writeHistoryFile(historyManager *HistoryManager, path string) error {
+	entries, err := historyManager.GetAllEntries()
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("history -w: %w", err)
+	}
+	if err := Export(file, "bash", entries); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("history -w: %w", err)
+	}
+	return file.Close()
+}
+
+// readHistoryFile adds the commands of the bash history file at path, as
+// history -r does, but for those already in history.
+func readHistoryFile(historyManager *HistoryManager, path string) error {
+	commands, err := ReadHistoryFile("bash", path)
+	if err != nil {
+		return fmt.Errorf("history -r: %w", err)
+	}
+	if _, err := historyManager.Import("bash", commands); err != nil {
+		return fmt.Errorf("history -r: %w", err)
+	}
+	return nil
+}
+
+// importHistory imports the history of the shell and file in args, or of
+// every shell in ImportShells whose history file is found.
+func importHistory(historyManager *HistoryManager, args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: history import [bash|zsh|fish] [file]")
+	}
+	home, _ := os.UserHomeDir()
+	shells := ImportShells
+	if len(args) > 0 {
+		shells = args[:1]
+	}
+
+	imported := 0
+	for _, shell := range shells {
+		path := DefaultHistoryFile(shell, home)
+		if len(args) == 2 {
+			path = args[1]
+		}
+		if path == "" {
+			return fmt.Errorf("cannot import history from %s; use one of %s", shell, strings.Join(ImportShells, ", "))
+		}
+		commands, err := ReadHistoryFile(shell, path)
+		if os.IsNotExist(err) && len(args) == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s history: %w", shell, err)
+		}
+		result, err := historyManager.Import(shell, commands)
+		if err != nil {
+			return fmt.Errorf("failed to import %s history: %w", shell, err)
+		}
+		fmt.Printf("Imported %d commands from %s (%s), skipped %d duplicates\n", result.Imported, shell, path, result.Skipped)
+		imported++
+	}
+	if imported == 0 {
+		fmt.Println("No bash, zsh or fish history found")
+	}
+	return nil
+}
+
+// exportHistory writes all of history in the format given with --format, json
+// by default, to the file in args or to stdout.
+func exportHistory(ctx context.Context, historyManager *HistoryManager, args []string) error {
+	const usage = "usage: history export [--format json|csv|bash] [file]"
+	format := "json"
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--format" || arg == "-f":
+			if i+1 >= len(args) {
+				return fmt.Errorf(usage)
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return fmt.Errorf(usage)
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) > 1 || !slices.Contains(ExportFormats, format) {
+		return fmt.Errorf(usage)
+	}
+
+	entries, err := historyManager.GetAllEntries()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 || paths[0] == "-" {
+		return Export(interp.HandlerCtx(ctx).Stdout, format, entries)
+	}
+
+	file, err := os.Create(paths[0])
+	if err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	if err := Export(file, format, entries); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	fmt.Printf("Exported %d commands to %s\n", len(entries), paths[0])
+	return nil
+}
+
+// syncHistory merges history with the one at BISH_HISTORY_SYNC_URL, or with
+// key as the first of args, prints or sets the key it is encrypted with.
+func syncHistory(ctx context.Context, historyManager *HistoryManager, args []string) error {
+	const usage = "usage: history sync [key [KEY]]"
+	keyPath := DefaultSyncKeyPath()
+	if len(args) > 0 {
+		if args[0] != "key" || len(args) > 2 {
+			return fmt.Errorf(usage)
+		}
+		if len(args) == 2 {
+			key, err := ParseSyncKey(args[1])
+			if err != nil {
+				return err
+			}
+			if err := SaveSyncKey(keyPath, key); err != nil {
+				return fmt.Errorf("failed to save the history sync key: %w", err)
+			}
+			fmt.Printf("Saved the history sync key to %s\n", keyPath)
+			return nil
+		}
+		key, err := LoadSyncKey(keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load the history sync key: %w", err)
+		}
+		fmt.Println(FormatSyncKey(key))
+		fmt.Println("Run history sync key with it on your other machines to sync with them.")
+		return nil
+	}
+
+	rawURL := interp.HandlerCtx(ctx).Env.Get("BISH_HISTORY_SYNC_URL").String()
+	if rawURL == "" {
+		return fmt.Errorf("set BISH_HISTORY_SYNC_URL to where history is synced, such as a WebDAV URL")
+	}
+	remote, err := NewSyncRemote(rawURL)
+	if err != nil {
+		return err
+	}
+	key, err := LoadSyncKey(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load the history sync key: %w", err)
+	}
+	result, err := historyManager.Sync(ctx, remote, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Synced history: pulled %d commands, pushed %d\n", result.Pulled, result.Pushed)
+	return nil
+}
+
+// statsHistory shows the history stats dashboard, over the last --days days
+// if given, or prints the stats when not on a terminal.
+func statsHistory(ctx context.Context, historyManager *HistoryManager, args []string) error {
+	const usage = "usage: history stats [--days N]"
+	days := 0
+	for i := 0; i < len(args); i++ {
+		value := ""
+		switch arg := args[i]; {
+		case arg == "--days" || arg == "-n":
+			if i+1 >= len(args) {
+				return fmt.Errorf(usage)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--days="):
+			value = strings.TrimPrefix(arg, "--days=")
+		default:
+			return fmt.Errorf(usage)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf(usage)
+		}
+		days = n
+	}
+
+	periods := StatsPeriods
+	period := slices.IndexFunc(periods, func(p StatsPeriod) bool { return p.Days == days })
+	if period < 0 {
+		periods = append(slices.Clone(periods), StatsPeriod{Label: fmt.Sprintf("last %d days", days), Days: days})
+		period = len(periods) - 1
+	}
+	hc := interp.HandlerCtx(ctx)
+	if statsIsTerminal(hc.Stdin) && statsIsTerminal(hc.Stdout) {
+		return showStats(historyManager.Stats, periods, period)
+	}
+
+	p := periods[period]
+	stats, err := historyManager.Stats(p.Since(time.Now()))
+	if err != nil {
+		return err
+	}
+	output := RenderStats(stats, p.Label, 80)
+	if !statsIsTerminal(hc.Stdout) {
+		output = ansi.Strip(output)
+	}
+	_, err = fmt.Fprint(hc.Stdout, output)
+	return err
+}
+
+// statsIsTerminal reports whether the dashboard can use the terminal at f.
+// Tests override it.
+var statsIsTerminal = func(f any) bool {
+	file, ok := f.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
 func printHistoryHelp() {
 	help := []string{
 		"Usage: history [option] [n]",
+		"       history import [bash|zsh|fish] [file]",
+		"       history export [--format json|csv|bash] [file]",
+		"       history sync [key [KEY]]",
+		"       history stats [--days N]",
 		"Display or manipulate the history list.",
 		"",
 		"Options:",
 		"  -c, --clear    clear the history list",
-		"  -d, --delete   delete history entry at offset",
+		"  -d, --delete   delete history entry at offset; a range such as",
+		"                 10-15 deletes them all, and -1 the last entry",
+		"  -w, --write    write history to the file, or $HISTFILE",
+		"  -r, --read     add the commands of the file, or $HISTFILE",
 		"  -h, --help     display this help message",
 		"",
 		"If n is given, display only the last n entries.",
 		"If no options are given, display the history list with line numbers.",
+		"",
+		"history import adds the commands of another shell's history file,",
+		"of every shell found if none is given. Importing again only adds",
+		"the commands that are new.",
+		"",
+		"history export writes every command with its directory, exit code,",
+		"duration and session, as JSON by default, to the file or to stdout.",
+		"The bash format can be imported again.",
+		"",
+		"history sync merges history with the one at BISH_HISTORY_SYNC_URL,",
+		"encrypted with the key in ~/.config/bish/history_sync.key. history",
+		"sync key prints the key, and history sync key KEY sets it, so that",
+		"every machine syncing uses the same one.",
+		"",
+		"history stats shows the commands run most, the directories they",
+		"were run in, the busiest hours of the day, and how often each",
+		"command fails and how long it takes on average. r switches between",
+		"all time, the last 30 and 7 days and today; --days starts with the",
+		"last N days. When output is not a terminal the stats are printed.",
 	}
 	fmt.Println(strings.Join(help, "\n"))
 }
@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 func captureOutput(f func() error) (string, error) {
@@ -81,16 +85,42 @@ func TestHistoryCommand(t *testing.T) {
 			expectedOutputFn: func(entries []HistoryEntry) string {
 				return strings.Join([]string{
 					"Usage: history [option] [n]",
+					"       history import [bash|zsh|fish] [file]",
+					"       history export [--format json|csv|bash] [file]",
+					"       history sync [key [KEY]]",
+					"       history stats [--days N]",
 					"Display or manipulate the history list.",
 					"",
 					"Options:",
 					"  -c, --clear    clear the history list",
-					"  -d, --delete   delete history entry at offset",
+					"  -d, --delete   delete history entry at offset; a range such as",
+					"                 10-15 deletes them all, and -1 the last entry",
+					"  -w, --write    write history to the file, or $HISTFILE",
+					"  -r, --read     add the commands of the file, or $HISTFILE",
 					"  -h, --help     display this help message",
 					"",
 					"If n is given, display only the last n entries.",
 					"If no options are given, display the history list with line numbers.",
 					"",
+					"history import adds the commands of another shell's history file,",
+					"of every shell found if none is given. Importing again only adds",
+					"the commands that are new.",
+					"",
+					"history export writes every command with its directory, exit code,",
+					"duration and session, as JSON by default, to the file or to stdout.",
+					"The bash format can be imported again.",
+					"",
+					"history sync merges history with the one at BISH_HISTORY_SYNC_URL,",
+					"encrypted with the key in ~/.config/bish/history_sync.key. history",
+					"sync key prints the key, and history sync key KEY sets it, so that",
+					"every machine syncing uses the same one.",
+					"",
+					"history stats shows the commands run most, the directories they",
+					"were run in, the busiest hours of the day, and how often each",
+					"command fails and how long it takes on average. r switches between",
+					"all time, the last 30 and 7 days and today; --days starts with the",
+					"last N days. When output is not a terminal the stats are printed.",
+					"",
 				}, "\n")
 			},
 		},
@@ -277,3 +307,62 @@ func TestHistoryCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestHistoryBashFlags(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+	for _, command := range []string{"one", "two", "three", "four", "five"} {
+		_, err := historyManager.StartCommand(command, "/", "s1")
+		assert.NoError(t, err)
+	}
+	histfile := filepath.Join(t.TempDir(), "bash_history")
+
+	run := func(script string) error {
+		runner, err := interp.New(
+			interp.Env(expand.ListEnviron("HISTFILE="+histfile)),
+			interp.StdIO(nil, io.Discard, io.Discard),
+			interp.ExecHandlers(NewHistoryCommandHandler(historyManager)),
+		)
+		assert.NoError(t, err)
+		file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+		assert.NoError(t, err)
+		return runner.Run(context.Background(), file)
+	}
+	commands := func() []string {
+		entries, err := historyManager.GetRecentEntries("", 20)
+		assert.NoError(t, err)
+		var commands []string
+		for _, entry := range entries {
+			commands = append(commands, entry.Command)
+		}
+		return commands
+	}
+
+	// -d takes a range, or counts back from the last entry
+	assert.NoError(t, run("history -d 2-3"))
+	assert.Equal(t, []string{"one", "four", "five"}, commands())
+	assert.NoError(t, run("history -d -1"))
+	assert.Equal(t, []string{"one", "four"}, commands())
+	assert.Error(t, run("history -d -9"))
+	assert.Error(t, run("history -d 3-1"))
+
+	// -w writes $HISTFILE, which -r reads back
+	assert.NoError(t, run("history -w"))
+	data, err := os.ReadFile(histfile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "one\n")
+	assert.Contains(t, string(data), "four\n")
+
+	assert.NoError(t, historyManager.ResetHistory())
+	assert.NoError(t, run("history -r"))
+	assert.Equal(t, []string{"one", "four"}, commands())
+
+	// Reading the file again adds nothing
+	assert.NoError(t, run("history -r "+histfile))
+	assert.Equal(t, []string{"one", "four"}, commands())
+
+	runner, err := interp.New(interp.ExecHandlers(NewHistoryCommandHandler(historyManager)))
+	assert.NoError(t, err)
+	file, _ := syntax.NewParser().Parse(strings.NewReader("history -w"), "")
+	assert.ErrorContains(t, runner.Run(context.Background(), file), "set HISTFILE")
+}
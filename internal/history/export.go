@@ -0,0 +1,110 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormats are the formats history can be exported in. bash is the
+// history file format with HISTTIMEFORMAT set, which history import reads.
+var ExportFormats = []string{"json", "csv", "bash"}
+
+// ExportedEntry is a history entry as exported.
+type ExportedEntry struct {
+	ID        uint      `json:"id"`
+	Time      time.Time `json:"time"`
+	Command   string    `json:"command"`
+	Directory string    `json:"directory"`
+	// ExitCode and DurationMs are nil for commands that did not finish,
+	// and for imported ones.
+	ExitCode   *int32 `json:"exit_code"`
+	DurationMs *int64 `json:"duration_ms"`
+	Session    string `json:"session"`
+}
+
+// Duration returns how long the command of entry ran, from when it started
+// to when its exit code was saved, or false if it did not finish.
+func (entry HistoryEntry) Duration() (time.Duration, bool) {
+	if !entry.ExitCode.Valid || entry.UpdatedAt.Before(entry.CreatedAt) {
+		return 0, false
+	}
+	return entry.UpdatedAt.Sub(entry.CreatedAt), true
+}
+
+func newExportedEntry(entry HistoryEntry) ExportedEntry {
+	exported := ExportedEntry{
+		ID:        entry.ID,
+		Time:      entry.CreatedAt,
+		Command:   entry.Command,
+		Directory: entry.Directory,
+		Session:   entry.SessionID,
+	}
+	if entry.ExitCode.Valid {
+		exitCode := entry.ExitCode.Int32
+		exported.ExitCode = &exitCode
+	}
+	if duration, ok := entry.Duration(); ok {
+		ms := duration.Milliseconds()
+		exported.DurationMs = &ms
+	}
+	return exported
+}
+
+// Export writes entries to w in format, one of ExportFormats, oldest first.
+func Export(w io.Writer, format string, entries []HistoryEntry) error {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b HistoryEntry) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	switch format {
+	case "json":
+		exported := make([]ExportedEntry, len(entries))
+		for i, entry := range entries {
+			exported[i] = newExportedEntry(entry)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exported)
+
+	case "csv":
+		writer := csv.NewWriter(w)
+		_ = writer.Write([]string{"id", "time", "command", "directory", "exit_code", "duration_ms", "session"})
+		for _, entry := range entries {
+			exported := newExportedEntry(entry)
+			exitCode, durationMs := "", ""
+			if exported.ExitCode != nil {
+				exitCode = strconv.Itoa(int(*exported.ExitCode))
+			}
+			if exported.DurationMs != nil {
+				durationMs = strconv.FormatInt(*exported.DurationMs, 10)
+			}
+			_ = writer.Write([]string{
+				strconv.FormatUint(uint64(exported.ID), 10),
+				exported.Time.Format(time.RFC3339),
+				exported.Command,
+				exported.Directory,
+				exitCode,
+				durationMs,
+				exported.Session,
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case "bash":
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, "#%d\n%s\n", entry.CreatedAt.Unix(), entry.Command); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot export history as %s; use one of %s", format, strings.Join(ExportFormats, ", "))
+}
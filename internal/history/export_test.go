@@ -0,0 +1,111 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func exportedEntries() []HistoryEntry {
+	start := time.Unix(1700000000, 0).UTC()
+	return []HistoryEntry{
+		{
+			ID:        2,
+			CreatedAt: start.Add(time.Minute),
+			UpdatedAt: start.Add(time.Minute),
+			Command:   "echo \"a, b\"",
+			SessionID: "import-bash",
+		},
+		{
+			ID:        1,
+			CreatedAt: start,
+			UpdatedAt: start.Add(1500 * time.Millisecond),
+			Command:   "make test",
+			Directory: "/src",
+			SessionID: "s1",
+			ExitCode:  sql.NullInt32{Int32: 2, Valid: true},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Export(&out, "json", exportedEntries()))
+	assert.JSONEq(t, `[
+		{"id": 1, "time": "2023-11-14T22:13:20Z", "command": "make test", "directory": "/src", "exit_code": 2, "duration_ms": 1500, "session": "s1"},
+		{"id": 2, "time": "2023-11-14T22:14:20Z", "command": "echo \"a, b\"", "directory": "", "exit_code": null, "duration_ms": null, "session": "import-bash"}
+	]`, out.String())
+
+	out.Reset()
+	require.NoError(t, Export(&out, "csv", exportedEntries()))
+	assert.Equal(t, "id,time,command,directory,exit_code,duration_ms,session\n"+
+		"1,2023-11-14T22:13:20Z,make test,/src,2,1500,s1\n"+
+		"2,2023-11-14T22:14:20Z,\"echo \"\"a, b\"\"\",,,,import-bash\n", out.String())
+
+	out.Reset()
+	require.NoError(t, Export(&out, "bash", exportedEntries()))
+	assert.Equal(t, "#1700000000\nmake test\n#1700000060\necho \"a, b\"\n", out.String())
+
+	// The bash format reads back in
+	commands, err := ParseBashHistory(&out)
+	require.NoError(t, err)
+	assert.Equal(t, []ImportedCommand{
+		{Command: "make test", Time: time.Unix(1700000000, 0)},
+		{Command: "echo \"a, b\"", Time: time.Unix(1700000060, 0)},
+	}, commands)
+
+	assert.Error(t, Export(&out, "xml", nil))
+}
+
+func TestHistoryExportCommand(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	entry, err := historyManager.StartCommand("ls -la", "/tmp", "s1")
+	require.NoError(t, err)
+	_, err = historyManager.FinishCommand(entry, 0)
+	require.NoError(t, err)
+
+	run := func(script string) (string, error) {
+		var stdout bytes.Buffer
+		runner, err := interp.New(
+			interp.StdIO(nil, &stdout, &stdout),
+			interp.ExecHandlers(NewHistoryCommandHandler(historyManager)),
+		)
+		require.NoError(t, err)
+		file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+		require.NoError(t, err)
+		err = runner.Run(context.Background(), file)
+		return stdout.String(), err
+	}
+
+	out, err := run("history export --format csv")
+	require.NoError(t, err)
+	assert.Contains(t, out, ",ls -la,/tmp,0,")
+
+	out, err = run("history export --format=bash")
+	require.NoError(t, err)
+	assert.Equal(t, "ls -la\n", strings.SplitN(out, "\n", 2)[1])
+
+	path := filepath.Join(t.TempDir(), "history.json")
+	_, err = captureOutput(func() error {
+		_, err := run("history export " + path)
+		return err
+	})
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"command": "ls -la"`)
+
+	_, err = run("history export --format xml")
+	assert.Error(t, err)
+}
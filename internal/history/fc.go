@@ -0,0 +1,330 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const fcUsage = "Usage: fc [-r] [-e editor] [first [last]]\n" +
+	"       fc -l [-nr] [first [last]]\n" +
+	"       fc -s [old=new] [first]"
+
+// fcListed is how many commands fc -l lists by default.
+const fcListed = 16
+
+var negativeNumber = regexp.MustCompile(`^-[0-9]+$`)
+
+// Reruns are the commands fc edited or substituted. They run once the fc
+// command line is done, in the shell's own environment, as POSIX has it,
+// and replace the fc command in history.
+type Reruns struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+// DefaultReruns holds the commands of the fc builtin of the interactive shell.
+var DefaultReruns = &Reruns{}
+
+func (r *Reruns) add(commands ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, commands...)
+}
+
+// Take returns the commands to run, and forgets them.
+func (r *Reruns) Take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	commands := r.commands
+	r.commands = nil
+	return commands
+}
+
+// fcOptions are the options of an fc command line.
+type fcOptions struct {
+	list, numbers, reverse, substitute bool
+	editor                             string
+	operands                           []string
+}
+
+func parseFcArgs(args []string) (fcOptions, error) {
+	options := fcOptions{numbers: true}
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			args = args[1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || negativeNumber.MatchString(arg) {
+			break
+		}
+		args = args[1:]
+		for i, flag := range arg[1:] {
+			switch flag {
+			case 'l':
+				options.list = true
+			case 'n':
+				options.numbers = false
+			case 'r':
+				options.reverse = true
+			case 's':
+				options.substitute = true
+			case 'e':
+				// The editor is the rest of the option, or the next argument
+				if rest := arg[2+i:]; rest != "" {
+					options.editor = rest
+				} else if len(args) > 0 {
+					options.editor, args = args[0], args[1:]
+				} else {
+					return fcOptions{}, errors.New("-e needs an editor")
+				}
+			default:
+				return fcOptions{}, fmt.Errorf("invalid option -%c", flag)
+			}
+			if flag == 'e' {
+				break
+			}
+		}
+	}
+	// fc -e - runs the command again, as fc -s does
+	if options.editor == "-" {
+		options.editor, options.substitute = "", true
+	}
+	options.operands = args
+	return options, nil
+}
+
+// NewFcCommandHandler creates an ExecHandler for the POSIX fc builtin, on
+// history: fc -l lists commands, fc -s runs one again with old=new
+// substituted, and fc edits commands in $FCEDIT or $EDITOR and runs them.
+// Commands to run are added to reruns. The last entry of history is taken
+// to be the fc command itself, so -1 is the command before it.
+func NewFcCommandHandler(historyManager *HistoryManager, reruns *Reruns) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "fc" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			fail := func(status uint8, format string, a ...any) error {
+				fmt.Fprintf(hc.Stderr, "fc: "+format+"\n", a...)
+				return interp.NewExitStatus(status)
+			}
+			options, err := parseFcArgs(args[1:])
+			if err != nil {
+				return fail(2, "%s\n%s", err, fcUsage)
+			}
+
+			entries, err := historyManager.GetAllEntries()
+			if err != nil {
+				return fail(1, "%s", err)
+			}
+			// Oldest first, without the fc command itself
+			past := make([]HistoryEntry, 0, len(entries))
+			for i := len(entries) - 1; i > 0; i-- {
+				past = append(past, entries[i])
+			}
+			if len(past) == 0 {
+				return fail(1, "history is empty")
+			}
+
+			if options.substitute {
+				var substitutions []string
+				operands := options.operands
+				for len(operands) > 0 && strings.Contains(operands[0], "=") {
+					substitutions, operands = append(substitutions, operands[0]), operands[1:]
+				}
+				if len(operands) > 1 {
+					return fail(2, "too many arguments\n%s", fcUsage)
+				}
+				first := "-1"
+				if len(operands) == 1 {
+					first = operands[0]
+				}
+				index, err := findEntry(past, first)
+				if err != nil {
+					return fail(1, "%s", err)
+				}
+				command := past[index].Command
+				for _, substitution := range substitutions {
+					old, replacement, _ := strings.Cut(substitution, "=")
+					command = strings.Replace(command, old, replacement, 1)
+				}
+				fmt.Fprintln(hc.Stdout, command)
+				reruns.add(command)
+				return nil
+			}
+
+			if len(options.operands) > 2 {
+				return fail(2, "too many arguments\n%s", fcUsage)
+			}
+			first, last := "-1", ""
+			if options.list {
+				first, last = strconv.Itoa(-fcListed), "-1"
+			}
+			if len(options.operands) > 0 {
+				first, last = options.operands[0], options.operands[0]
+				if options.list {
+					last = "-1"
+				}
+			}
+			if len(options.operands) > 1 {
+				last = options.operands[1]
+			}
+			if last == "" {
+				last = first
+			}
+			from, err := findEntry(past, first)
+			if err != nil && options.list && negativeNumber.MatchString(first) {
+				// Listing more than there is lists all of it
+				from, err = 0, nil
+			}
+			if err != nil {
+				return fail(1, "%s", err)
+			}
+			to, err := findEntry(past, last)
+			if err != nil {
+				return fail(1, "%s", err)
+			}
+			selected := selectEntries(past, from, to, options.reverse)
+
+			if options.list {
+				for _, entry := range selected {
+					if options.numbers {
+						fmt.Fprintf(hc.Stdout, "%d\t%s\n", entry.ID, entry.Command)
+					} else {
+						fmt.Fprintf(hc.Stdout, "\t%s\n", entry.Command)
+					}
+				}
+				return nil
+			}
+
+			commands, err := editCommands(ctx, next, fcEditor(hc, options.editor), selected)
+			if err != nil {
+				return fail(1, "%s", err)
+			}
+			for _, command := range commands {
+				fmt.Fprintln(hc.Stdout, command)
+			}
+			reruns.add(commands...)
+			return nil
+		}
+	}
+}
+
+// findEntry returns the index in past of the entry that spec names: a
+// history number, a negative offset from the fc command, -1 being the
+// command before it, or the most recent command starting with spec.
+func findEntry(past []HistoryEntry, spec string) (int, error) {
+	if n, err := strconv.Atoi(spec); err == nil {
+		switch {
+		case n == 0:
+			// As in bash, 0 is the command before fc
+			return len(past) - 1, nil
+		case n < 0:
+			if -n > len(past) {
+				return 0, fmt.Errorf("%s: history specification out of range", spec)
+			}
+			return len(past) + n, nil
+		}
+		for i, entry := range past {
+			if entry.ID == uint(n) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%s: history specification out of range", spec)
+	}
+	for i := len(past) - 1; i >= 0; i-- {
+		if strings.HasPrefix(past[i].Command, spec) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: no command found", spec)
+}
+
+// selectEntries returns the entries from index from to index to, in that
+// order, which is from the last to the first if from comes later, and the
+// other way around with reverse.
+func selectEntries(past []HistoryEntry, from, to int, reverse bool) []HistoryEntry {
+	if from > to {
+		from, to = to, from
+		reverse = !reverse
+	}
+	selected := make([]HistoryEntry, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		selected = append(selected, past[i])
+	}
+	if reverse {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
+		}
+	}
+	return selected
+}
+
+// fcEditor returns the editor fc runs: the one given with -e, $FCEDIT,
+// $EDITOR, or ed, as POSIX has it.
+func fcEditor(hc interp.HandlerContext, editor string) string {
+	for _, candidate := range []string{editor, hc.Env.Get("FCEDIT").String(), hc.Env.Get("EDITOR").String()} {
+		if strings.TrimSpace(candidate) != "" {
+			return candidate
+		}
+	}
+	return "ed"
+}
+
+// editCommands writes the commands of entries to a file, runs editor on it
+// with next, and returns the commands in the file once the editor exits
+// successfully, each a complete statement.
+func editCommands(ctx context.Context, next interp.ExecHandlerFunc, editor string, entries []HistoryEntry) ([]string, error) {
+	file, err := os.CreateTemp("", "bish-fc-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	for _, entry := range entries {
+		if _, err := io.WriteString(file, entry.Command+"\n"); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := next(ctx, append(strings.Fields(editor), file.Name())); err != nil {
+		if _, ok := interp.IsExitStatus(err); ok {
+			return nil, fmt.Errorf("%s failed; not running the commands", editor)
+		}
+		return nil, err
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(string(edited))
+}
+
+// splitStatements splits source into its statements, each as written.
+func splitStatements(source string) ([]string, error) {
+	parsed, err := syntax.NewParser().Parse(strings.NewReader(source), "")
+	if err != nil {
+		return nil, err
+	}
+	var statements []string
+	for _, stmt := range parsed.Stmts {
+		statements = append(statements, source[stmt.Pos().Offset():stmt.End().Offset()])
+	}
+	return statements, nil
+}
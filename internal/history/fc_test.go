@@ -0,0 +1,133 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// runFc records commands in history, then the fc command line, as the
+// shell does before running it, and runs it with editor standing in for
+// the editor fc runs.
+func runFc(t *testing.T, commands []string, line string, editor func(path string) error) (reruns []string, stdout, stderr string, err error) {
+	t.Helper()
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	for _, command := range append(commands, line) {
+		_, err := historyManager.StartCommand(command, "/", "s1")
+		require.NoError(t, err)
+	}
+
+	runEditor := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			require.NotNil(t, editor, "fc ran %v", args)
+			assert.Equal(t, "myedit", args[0])
+			if err := editor(args[len(args)-1]); err != nil {
+				return interp.NewExitStatus(1)
+			}
+			return nil
+		}
+	}
+	queue := &Reruns{}
+	var out, errOut bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron()),
+		interp.StdIO(nil, &out, &errOut),
+		interp.ExecHandlers(NewFcCommandHandler(historyManager, queue), runEditor),
+	)
+	require.NoError(t, err)
+	file, err := syntax.NewParser().Parse(strings.NewReader("export EDITOR=myedit\n"+line), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return queue.Take(), out.String(), errOut.String(), err
+}
+
+var fcHistory = []string{"ls -la", "make build", "git status", "make test", "echo done"}
+
+func TestFcList(t *testing.T) {
+	_, stdout, _, err := runFc(t, fcHistory, "fc -l", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1\tls -la\n2\tmake build\n3\tgit status\n4\tmake test\n5\techo done\n", stdout)
+
+	_, stdout, _, err = runFc(t, fcHistory, "fc -l -2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "4\tmake test\n5\techo done\n", stdout)
+
+	_, stdout, _, err = runFc(t, fcHistory, "fc -lnr 2 4", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "\tmake test\n\tgit status\n\tmake build\n", stdout)
+
+	_, stdout, _, err = runFc(t, fcHistory, "fc -l 3 make", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "3\tgit status\n4\tmake test\n", stdout)
+
+	// A first after the last lists backwards
+	_, stdout, _, err = runFc(t, fcHistory, "fc -l git ls", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "3\tgit status\n2\tmake build\n1\tls -la\n", stdout)
+
+	_, _, stderr, err := runFc(t, fcHistory, "fc -l 42", nil)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "fc: 42: history specification out of range")
+	_, _, stderr, err = runFc(t, fcHistory, "fc -x", nil)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "fc: invalid option -x\nUsage: fc")
+}
+
+func TestFcSubstitute(t *testing.T) {
+	reruns, stdout, _, err := runFc(t, fcHistory, "fc -s", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo done"}, reruns)
+	assert.Equal(t, "echo done\n", stdout)
+
+	reruns, _, _, err = runFc(t, fcHistory, "fc -s test=install make", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make install"}, reruns)
+
+	reruns, _, _, err = runFc(t, fcHistory, "fc -s l=L -5", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ls -la"}, reruns)
+
+	reruns, _, _, err = runFc(t, fcHistory, "fc -e - git", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status"}, reruns)
+
+	_, _, stderr, err := runFc(t, fcHistory, "fc -s docker", nil)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "fc: docker: no command found")
+}
+
+func TestFcEdit(t *testing.T) {
+	var edited string
+	editor := func(path string) error {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		edited = string(content)
+		return os.WriteFile(path, []byte("make build &&\n  make test\nif true; then\n  echo ok\nfi\n"), 0o600)
+	}
+	reruns, stdout, _, err := runFc(t, fcHistory, "fc 2 4", editor)
+	require.NoError(t, err)
+	assert.Equal(t, "make build\ngit status\nmake test\n", edited)
+	assert.Equal(t, []string{"make build &&\n  make test", "if true; then\n  echo ok\nfi"}, reruns)
+	assert.Contains(t, stdout, "make build &&\n  make test\n")
+
+	// The previous command by default
+	_, _, _, err = runFc(t, fcHistory, "fc", editor)
+	require.NoError(t, err)
+	assert.Equal(t, "echo done\n", edited)
+
+	// Nothing runs when the editor fails
+	failing := func(string) error { return os.ErrInvalid }
+	reruns, _, stderr, err := runFc(t, fcHistory, "fc -1", failing)
+	assert.Error(t, err)
+	assert.Empty(t, reruns)
+	assert.Contains(t, stderr, "myedit failed; not running the commands")
+}
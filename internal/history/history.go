@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+	"unique"
 
 	"github.com/glebarez/sqlite"
 	"github.com/robottwo/bishop/pkg/reverse"
@@ -12,7 +16,19 @@ import (
 )
 
 type HistoryManager struct {
-	db *gorm.DB
+	db     *gorm.DB
+	writer *historyWriter
+
+	// task and container tag the entries started while they are set
+	taskMu    sync.Mutex
+	task      string
+	container string
+	// ignore keeps commands out of history; lastCommand is the last one
+	// started, for the & pattern
+	ignore      IgnorePatterns
+	lastCommand string
+	// keepSecrets stores commands as typed, rather than scrubbed of secrets
+	keepSecrets bool
 }
 
 type HistoryEntry struct {
@@ -24,6 +40,14 @@ type HistoryEntry struct {
 	Directory string `gorm:"index:idx_dir_created,priority:1"`
 	SessionID string `gorm:"index"`
 	ExitCode  sql.NullInt32
+	// Task is what the user declared they were working on with #!focus
+	Task string `gorm:"index"`
+	// Container identifies the container the command ran in, for commands
+	// run in a shell opened with #!enter
+	Container string `gorm:"index"`
+	// Queued is set for commands queued with the later builtin, which ran in
+	// the background once the commands before them had finished
+	Queued bool
 }
 
 func NewHistoryManager(dbFilePath string) (*HistoryManager, error) {
@@ -33,7 +57,11 @@ func NewHistoryManager(dbFilePath string) (*HistoryManager, error) {
 	// - synchronous(1): NORMAL mode for durability/performance balance
 	// - cache_size(-20000): 20MB cache to reduce NFS I/O operations
 	// - temp_store(2): MEMORY - keeps temp files out of NFS
-	connectionString := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=synchronous(1)&_pragma=cache_size(-20000)&_pragma=temp_store(2)", dbFilePath)
+	// - auto_vacuum(2): INCREMENTAL - lets Maintain give back free pages in small steps;
+	//   takes effect on new databases, and on old ones once Maintain vacuums them
+	// - _txlock=immediate: take the write lock when a transaction begins, so concurrent
+	//   shells wait in busy_timeout instead of failing on a stale read snapshot
+	connectionString := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=synchronous(1)&_pragma=cache_size(-20000)&_pragma=temp_store(2)&_pragma=auto_vacuum(2)&_txlock=immediate", dbFilePath)
 
 	db, err := gorm.Open(sqlite.Open(connectionString), &gorm.Config{})
 	if err != nil {
@@ -64,7 +92,8 @@ func NewHistoryManager(dbFilePath string) (*HistoryManager, error) {
 	}
 
 	return &HistoryManager{
-		db: db,
+		db:     db,
+		writer: newHistoryWriter(),
 	}, nil
 }
 
@@ -88,27 +117,79 @@ func (historyManager *HistoryManager) GetDB() *gorm.DB {
 	return historyManager.db
 }
 
+// SetTask tags the entries started from now on with task, or stops tagging
+// them if task is empty.
+func (historyManager *HistoryManager) SetTask(task string) {
+	historyManager.taskMu.Lock()
+	defer historyManager.taskMu.Unlock()
+	historyManager.task = task
+}
+
+// SetContainer tags the entries started from now on with the identity of a
+// container, or stops tagging them if container is empty.
+func (historyManager *HistoryManager) SetContainer(container string) {
+	historyManager.taskMu.Lock()
+	defer historyManager.taskMu.Unlock()
+	historyManager.container = container
+}
+
+func (historyManager *HistoryManager) currentTags() (task, container string) {
+	historyManager.taskMu.Lock()
+	defer historyManager.taskMu.Unlock()
+	return historyManager.task, historyManager.container
+}
+
+// StartCommand adds the entry of a command that is starting, or returns nil
+// if the command is kept out of history, as with BISH_HISTIGNORE. Secrets in
+// the command are masked unless SetScrubSecrets turned that off.
 func (historyManager *HistoryManager) StartCommand(command string, directory string, sessionID string) (*HistoryEntry, error) {
+	return historyManager.startCommand(command, directory, sessionID, false)
+}
+
+// StartQueuedCommand starts the entry of a command queued with the later
+// builtin, marked as such.
+func (historyManager *HistoryManager) StartQueuedCommand(command string, directory string, sessionID string) (*HistoryEntry, error) {
+	return historyManager.startCommand(command, directory, sessionID, true)
+}
+
+func (historyManager *HistoryManager) startCommand(command string, directory string, sessionID string, queued bool) (*HistoryEntry, error) {
+	if historyManager.ignored(command) {
+		return nil, nil
+	}
+	task, container := historyManager.currentTags()
 	entry := HistoryEntry{
-		Command:   command,
+		Command:   historyManager.scrub(command),
 		Directory: directory,
 		SessionID: sessionID,
+		Task:      task,
+		Container: container,
+		Queued:    queued,
 	}
 
-	result := historyManager.db.Create(&entry)
-	if result.Error != nil {
-		return nil, result.Error
+	err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+		// Reset the primary key so a retried insert is not mistaken for an update
+		entry.ID = 0
+		return db.Create(&entry).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &entry, nil
 }
 
 func (historyManager *HistoryManager) FinishCommand(entry *HistoryEntry, exitCode int) (*HistoryEntry, error) {
+	if entry == nil {
+		return nil, fmt.Errorf("cannot finish a history entry that was never started")
+	}
+
 	entry.ExitCode = sql.NullInt32{Int32: int32(exitCode), Valid: true}
 
-	result := historyManager.db.Save(entry)
-	if result.Error != nil {
-		return nil, result.Error
+	err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+		return db.Save(entry).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return entry, nil
@@ -139,12 +220,63 @@ func (historyManager *HistoryManager) GetAllEntries() ([]HistoryEntry, error) {
 	return entries, nil
 }
 
+// Size returns how many entries history has and the highest of their IDs,
+// which together change whenever entries are added or deleted.
+func (historyManager *HistoryManager) Size() (int64, uint, error) {
+	var size struct {
+		Count    int64
+		LatestID uint
+	}
+	err := historyManager.db.Model(&HistoryEntry{}).Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS latest_id").Scan(&size).Error
+	return size.Count, size.LatestID, err
+}
+
+// entryPageSize is how many entries EachEntry reads from the database at a
+// time.
+const entryPageSize = 2000
+
+// EachEntry calls fn with the entries, newest first, until it returns false.
+// They are read a page at a time, so that all of history is never in memory
+// at once unless fn keeps it, and with only the ID, start time, command,
+// directory and session set. The commands, directories and sessions share
+// the memory of those that are equal, as most of them repeat.
+func (historyManager *HistoryManager) EachEntry(fn func(HistoryEntry) bool) error {
+	var last *HistoryEntry
+	for {
+		var page []HistoryEntry
+		db := historyManager.db.Select("id", "created_at", "command", "directory", "session_id")
+		if last != nil {
+			db = db.Where("created_at < ? OR (created_at = ? AND id < ?)", last.CreatedAt, last.CreatedAt, last.ID)
+		}
+		if err := db.Order("created_at desc, id desc").Limit(entryPageSize).Find(&page).Error; err != nil {
+			return err
+		}
+		for _, entry := range page {
+			entry.Command = unique.Make(entry.Command).Value()
+			entry.Directory = unique.Make(entry.Directory).Value()
+			entry.SessionID = unique.Make(entry.SessionID).Value()
+			if !fn(entry) {
+				return nil
+			}
+		}
+		if len(page) < entryPageSize {
+			return nil
+		}
+		last = &page[len(page)-1]
+	}
+}
+
 func (historyManager *HistoryManager) DeleteEntry(id uint) error {
-	result := historyManager.db.Delete(&HistoryEntry{}, id)
-	if result.Error != nil {
+	var rowsAffected int64
+	err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+		result := db.Delete(&HistoryEntry{}, id)
+		rowsAffected = result.RowsAffected
 		return result.Error
+	})
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
+	if rowsAffected == 0 {
 		return fmt.Errorf("no history entry found with id %d", id)
 	}
 
@@ -152,12 +284,9 @@ func (historyManager *HistoryManager) DeleteEntry(id uint) error {
 }
 
 func (historyManager *HistoryManager) ResetHistory() error {
-	result := historyManager.db.Exec("DELETE FROM history_entries")
-	if result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+	return historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+		return db.Exec("DELETE FROM history_entries").Error
+	})
 }
 
 func (historyManager *HistoryManager) GetRecentEntriesByPrefix(prefix string, limit int) ([]HistoryEntry, error) {
@@ -185,3 +314,117 @@ func (historyManager *HistoryManager) GetEntriesSince(since time.Time) ([]Histor
 
 	return entries, nil
 }
+
+// GetEntriesAfterID returns entries with an ID greater than afterID that were not
+// recorded by excludeSessionID, ordered newest first. It is used to pick up
+// commands written by other bish instances since the last poll.
+func (historyManager *HistoryManager) GetEntriesAfterID(afterID uint, excludeSessionID string) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	db := historyManager.db.Where("id > ?", afterID)
+	if excludeSessionID != "" {
+		db = db.Where("session_id <> ?", excludeSessionID)
+	}
+	result := db.Order("created_at desc").Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// GetRecentSessionEntries works like GetRecentEntries but only returns entries
+// recorded by sessionID or created before sessionStart, so that commands run in
+// other shells after this one started stay out of its history.
+func (historyManager *HistoryManager) GetRecentSessionEntries(directory string, sessionID string, sessionStart time.Time, limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	db := historyManager.db.Where("session_id = ? OR created_at < ?", sessionID, sessionStart)
+	if directory != "" {
+		db = db.Where("directory = ?", directory)
+	}
+	result := db.Order("created_at desc").Limit(limit).Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reverse.Reverse(entries)
+	return entries, nil
+}
+
+// GetRecentEntriesInSession works like GetRecentEntries but only returns
+// entries recorded by sessionID, from any directory.
+func (historyManager *HistoryManager) GetRecentEntriesInSession(sessionID string, limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	result := historyManager.db.Where("session_id = ?", sessionID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reverse.Reverse(entries)
+	return entries, nil
+}
+
+// GetTaskEntries returns the entries tagged with task that were created after
+// since, ordered by creation time (oldest first).
+func (historyManager *HistoryManager) GetTaskEntries(task string, since time.Time) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	result := historyManager.db.Where("task = ? AND created_at >= ?", task, since).
+		Order("created_at asc").
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// GetSessionEntries returns the entries recorded by sessionID that were
+// created after since, ordered by creation time (oldest first).
+func (historyManager *HistoryManager) GetSessionEntries(sessionID string, since time.Time) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	result := historyManager.db.Where("session_id = ? AND created_at >= ?", sessionID, since).
+		Order("created_at asc").
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// GetLastSessionEntries returns the most recent entries, up to limit, of the
+// last session other than excludeSessionID that ran commands in directory or
+// below it. Only the commands run there are returned, oldest first.
+func (historyManager *HistoryManager) GetLastSessionEntries(directory string, excludeSessionID string, limit int) ([]HistoryEntry, error) {
+	directory = strings.TrimSuffix(directory, "/")
+	prefix := directory + "/"
+	inDirectory := historyManager.db.Where("directory = ? OR substr(directory, 1, ?) = ?", directory, utf8.RuneCountInString(prefix), prefix)
+
+	var last HistoryEntry
+	result := inDirectory.Session(&gorm.Session{}).
+		Where("session_id <> ?", excludeSessionID).
+		Order("created_at desc").
+		Limit(1).
+		Find(&last)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var entries []HistoryEntry
+	result = inDirectory.Session(&gorm.Session{}).
+		Where("session_id = ?", last.SessionID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reverse.Reverse(entries)
+	return entries, nil
+}
@@ -3,6 +3,8 @@ package history
 import (
 	"context"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -297,4 +299,253 @@ func TestGetRecentEntriesByPrefix(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Len(t, entries, 5)
 	})
-}
\ No newline at end of file
+}
+func TestGetEntriesAfterID(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	first, err := historyManager.StartCommand("echo first", "/", "session-1")
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("echo mine", "/", "session-1")
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("echo theirs", "/", "session-2")
+	assert.NoError(t, err)
+
+	entries, err := historyManager.GetEntriesAfterID(first.ID, "session-1")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "echo theirs", entries[0].Command)
+
+	entries, err = historyManager.GetEntriesAfterID(first.ID, "")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestGetRecentSessionEntries(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	_, err = historyManager.StartCommand("echo before", "/", "session-2")
+	assert.NoError(t, err)
+
+	sessionStart := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = historyManager.StartCommand("echo mine", "/", "session-1")
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("echo theirs", "/", "session-2")
+	assert.NoError(t, err)
+
+	entries, err := historyManager.GetRecentSessionEntries("/", "session-1", sessionStart, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "echo before", entries[0].Command)
+	assert.Equal(t, "echo mine", entries[1].Command)
+}
+
+func TestGetRecentEntriesInSession(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	for _, command := range []struct{ command, directory, session string }{
+		{"echo one", "/", "session-1"},
+		{"echo theirs", "/", "session-2"},
+		{"echo two", "/tmp", "session-1"},
+		{"echo three", "/", "session-1"},
+	} {
+		_, err = historyManager.StartCommand(command.command, command.directory, command.session)
+		assert.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	entries, err := historyManager.GetRecentEntriesInSession("session-1", 2)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "echo two", entries[0].Command)
+	assert.Equal(t, "echo three", entries[1].Command)
+}
+
+func TestGetTaskEntries(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = historyManager.StartCommand("echo untagged", "/", "session-1")
+	assert.NoError(t, err)
+
+	historyManager.SetTask("fix billing cron")
+	entry, err := historyManager.StartCommand("crontab -l", "/", "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "fix billing cron", entry.Task)
+	_, err = historyManager.StartCommand("tail /var/log/billing.log", "/", "session-1")
+	assert.NoError(t, err)
+
+	historyManager.SetTask("")
+	entry, err = historyManager.StartCommand("echo done", "/", "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", entry.Task)
+
+	entries, err := historyManager.GetTaskEntries("fix billing cron", start)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "crontab -l", entries[0].Command)
+	assert.Equal(t, "tail /var/log/billing.log", entries[1].Command)
+
+	entries, err = historyManager.GetTaskEntries("fix billing cron", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSetContainer(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	historyManager.SetTask("debug api")
+	historyManager.SetContainer("docker:api")
+	entry, err := historyManager.StartCommand("ps aux", "/", "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker:api", entry.Container)
+	assert.Equal(t, "debug api", entry.Task)
+
+	historyManager.SetContainer("")
+	entry, err = historyManager.StartCommand("docker logs api", "/", "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", entry.Container)
+}
+
+func TestStartQueuedCommand(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	entry, err := historyManager.StartQueuedCommand("make deploy", "/src", "session-1")
+	assert.NoError(t, err)
+	_, err = historyManager.FinishCommand(entry, 0)
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("ls", "/src", "session-1")
+	assert.NoError(t, err)
+
+	entries, err := historyManager.GetRecentEntries("/src", 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.True(t, entries[0].Queued)
+	assert.Equal(t, "make deploy", entries[0].Command)
+	assert.False(t, entries[1].Queued)
+}
+
+func TestGetSessionEntries(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = historyManager.StartCommand("make build", "/src", "session-1")
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("ls", "/tmp", "session-2")
+	assert.NoError(t, err)
+	_, err = historyManager.StartCommand("make test", "/src", "session-1")
+	assert.NoError(t, err)
+
+	entries, err := historyManager.GetSessionEntries("session-1", start)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "make build", entries[0].Command)
+	assert.Equal(t, "make test", entries[1].Command)
+}
+
+func TestGetLastSessionEntries(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	for _, e := range []struct{ command, directory, session string }{
+		{"make build", "/src/app", "old"},
+		{"git log", "/src/app/cmd", "last"},
+		{"ls", "/src/application", "last"},
+		{"make test", "/src/app", "last"},
+		{"vim main.go", "/src/app", "current"},
+		{"ls", "/tmp", "other"},
+	} {
+		_, err = historyManager.StartCommand(e.command, e.directory, e.session)
+		assert.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	entries, err := historyManager.GetLastSessionEntries("/src/app/", "current", 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "git log", entries[0].Command)
+	assert.Equal(t, "make test", entries[1].Command)
+
+	entries, err = historyManager.GetLastSessionEntries("/src/app", "current", 1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "make test", entries[0].Command)
+
+	entries, err = historyManager.GetLastSessionEntries("/srv", "current", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestEachEntry(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	// More than a page, half of them started at the same time
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var entries []HistoryEntry
+	for i := range entryPageSize + 10 {
+		createdAt := start
+		if i%2 == 0 {
+			createdAt = start.Add(time.Duration(i) * time.Second)
+		}
+		entries = append(entries, HistoryEntry{
+			Command: "make", Directory: "/src", SessionID: "s1", CreatedAt: createdAt, Task: "build",
+		})
+	}
+	assert.NoError(t, historyManager.db.CreateInBatches(entries, 500).Error)
+
+	var seen []HistoryEntry
+	assert.NoError(t, historyManager.EachEntry(func(entry HistoryEntry) bool {
+		seen = append(seen, entry)
+		return true
+	}))
+	assert.Len(t, seen, len(entries))
+	ids := map[uint]bool{}
+	for i, entry := range seen {
+		ids[entry.ID] = true
+		if i > 0 {
+			previous := seen[i-1]
+			assert.True(t, entry.CreatedAt.Before(previous.CreatedAt) ||
+				(entry.CreatedAt.Equal(previous.CreatedAt) && entry.ID < previous.ID), "entries must be newest first")
+		}
+	}
+	assert.Len(t, ids, len(entries), "no entry may be skipped or repeated across pages")
+	assert.Empty(t, seen[0].Task, "only the columns the prompt needs are read")
+	assert.Same(t, unsafe.StringData(seen[0].Directory), unsafe.StringData(seen[1].Directory))
+
+	count := 0
+	assert.NoError(t, historyManager.EachEntry(func(HistoryEntry) bool {
+		count++
+		return count < 3
+	}))
+	assert.Equal(t, 3, count)
+}
+
+func TestSize(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	assert.NoError(t, err)
+
+	count, latestID, err := historyManager.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, uint(0), latestID)
+
+	first, err := historyManager.StartCommand("ls", "/", "s1")
+	assert.NoError(t, err)
+	second, err := historyManager.StartCommand("pwd", "/", "s1")
+	assert.NoError(t, err)
+	assert.NoError(t, historyManager.DeleteEntry(first.ID))
+
+	count, latestID, err = historyManager.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, second.ID, latestID)
+}
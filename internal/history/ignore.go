@@ -0,0 +1,147 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IgnorePatterns are the commands kept out of history, parsed from a list
+// in the format of BISH_HISTIGNORE: patterns separated by colons, as in
+// bash's HISTIGNORE, each of which has to match the whole command. A
+// pattern is a glob, in which * and ? also match slashes, or a regular
+// expression between slashes, such as /^export .*TOKEN=/, which may match
+// part of the command. & matches a command the same as the one before it.
+type IgnorePatterns struct {
+	patterns   []*regexp.Regexp
+	duplicates bool
+}
+
+// ParseIgnorePatterns parses list. The patterns that are not valid are
+// reported in the error, and the others are still returned.
+func ParseIgnorePatterns(list string) (IgnorePatterns, error) {
+	var ignore IgnorePatterns
+	var errs []error
+	for _, pattern := range splitIgnoreList(list) {
+		if pattern == "&" {
+			ignore.duplicates = true
+			continue
+		}
+		var expression string
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expression = pattern[1 : len(pattern)-1]
+		} else {
+			expression = globToRegexp(pattern)
+		}
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid history ignore pattern %q: %w", pattern, err))
+			continue
+		}
+		ignore.patterns = append(ignore.patterns, re)
+	}
+	return ignore, errors.Join(errs...)
+}
+
+// splitIgnoreList splits list at the colons not escaped with a backslash.
+func splitIgnoreList(list string) []string {
+	var patterns []string
+	var current strings.Builder
+	for i := 0; i < len(list); i++ {
+		switch {
+		case list[i] == '\\' && i+1 < len(list) && list[i+1] == ':':
+			current.WriteByte(':')
+			i++
+		case list[i] == ':':
+			patterns = append(patterns, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(list[i])
+		}
+	}
+	patterns = append(patterns, current.String())
+
+	result := patterns[:0]
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
+
+// globToRegexp returns the regular expression that matches what glob does.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			} else {
+				sb.WriteString(`\\`)
+			}
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// Matches reports whether command is ignored, given the command before it.
+func (ignore IgnorePatterns) Matches(command string, previous string) bool {
+	command = strings.TrimSpace(command)
+	if ignore.duplicates && command == strings.TrimSpace(previous) {
+		return true
+	}
+	for _, re := range ignore.patterns {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIgnorePatterns keeps the commands that ignore matches out of the
+// entries started from now on.
+func (historyManager *HistoryManager) SetIgnorePatterns(ignore IgnorePatterns) {
+	historyManager.taskMu.Lock()
+	defer historyManager.taskMu.Unlock()
+	historyManager.ignore = ignore
+}
+
+// ignored reports whether command is kept out of history: when it starts
+// with a space, as with bash's HISTCONTROL=ignorespace, or matches the
+// ignore patterns. Otherwise it becomes the command the next is compared to.
+func (historyManager *HistoryManager) ignored(command string) bool {
+	historyManager.taskMu.Lock()
+	defer historyManager.taskMu.Unlock()
+	if strings.HasPrefix(command, " ") || strings.HasPrefix(command, "\t") {
+		return true
+	}
+	if historyManager.ignore.Matches(command, historyManager.lastCommand) {
+		return true
+	}
+	historyManager.lastCommand = command
+	return false
+}
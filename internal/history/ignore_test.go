@@ -0,0 +1,54 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	ignore, err := ParseIgnorePatterns(`ls:cd *: exit :/^export .*TOKEN=/:echo a\:b:git [!p]*:&`)
+	require.NoError(t, err)
+
+	for _, command := range []string{"ls", "cd /tmp/x", "exit", "export GH_TOKEN=abc", "echo a:b", "git status"} {
+		assert.True(t, ignore.Matches(command, ""), command)
+	}
+	for _, command := range []string{"ls -la", "cd", "export PATH=/bin", "git push", "echo a"} {
+		assert.False(t, ignore.Matches(command, ""), command)
+	}
+	assert.True(t, ignore.Matches("make test", "make test"))
+
+	// Invalid patterns are reported, and the others kept
+	ignore, err = ParseIgnorePatterns("/(/:pwd")
+	assert.Error(t, err)
+	assert.True(t, ignore.Matches("pwd", ""))
+
+	ignore, err = ParseIgnorePatterns("")
+	require.NoError(t, err)
+	assert.False(t, ignore.Matches("ls", "ls"))
+}
+
+func TestStartCommandIgnores(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	ignore, err := ParseIgnorePatterns("*secret*:&")
+	require.NoError(t, err)
+	historyManager.SetIgnorePatterns(ignore)
+
+	for _, command := range []string{"ls", " cat ~/.netrc", "echo secret", "ls", "pwd", "ls"} {
+		_, err := historyManager.StartCommand(command, "/", "s1")
+		require.NoError(t, err)
+	}
+	entry, err := historyManager.StartCommand(" hidden", "/", "s1")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	entries, err := historyManager.GetAllEntries()
+	require.NoError(t, err)
+	var commands []string
+	for _, entry := range entries {
+		commands = append(commands, entry.Command)
+	}
+	assert.Equal(t, []string{"ls", "pwd", "ls"}, commands)
+}
@@ -0,0 +1,290 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportShells are the shells whose history can be imported, in the order
+// they are imported by default.
+var ImportShells = []string{"bash", "zsh", "fish"}
+
+// ImportedCommand is a command read from the history file of another shell.
+type ImportedCommand struct {
+	Command string
+	// Time is when the command ran, or zero if the file does not say.
+	Time time.Time
+	// Estimated is set when Time was made up from the order of the file.
+	Estimated bool
+}
+
+// ImportResult counts the commands of an import.
+type ImportResult struct {
+	Imported int
+	// Skipped are duplicates, of each other or of commands already in
+	// history.
+	Skipped int
+}
+
+// DefaultHistoryFile returns where shell keeps its history by default, or ""
+// if shell is not one of ImportShells.
+func DefaultHistoryFile(shell string, home string) string {
+	switch shell {
+	case "bash":
+		return filepath.Join(home, ".bash_history")
+	case "zsh":
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zsh_history")
+		}
+		return filepath.Join(home, ".zsh_history")
+	case "fish":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "fish", "fish_history")
+	}
+	return ""
+}
+
+// ReadHistoryFile reads the commands in the history file of shell at path,
+// oldest first. Bash files only have times when HISTTIMEFORMAT was set; the
+// others are given times a second apart up to when the file was last written,
+// which keeps their order.
+func ReadHistoryFile(shell string, path string) ([]ImportedCommand, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var commands []ImportedCommand
+	switch shell {
+	case "bash":
+		commands, err = ParseBashHistory(file)
+	case "zsh":
+		commands, err = ParseZshHistory(file)
+	case "fish":
+		commands, err = ParseFishHistory(file)
+	default:
+		return nil, fmt.Errorf("cannot import history from %s; use one of %s", shell, strings.Join(ImportShells, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := file.Stat(); err == nil {
+		for i := range commands {
+			if commands[i].Time.IsZero() {
+				commands[i].Time = info.ModTime().Add(-time.Duration(len(commands)-1-i) * time.Second).Truncate(time.Second)
+				commands[i].Estimated = true
+			}
+		}
+	}
+	return commands, nil
+}
+
+// ParseBashHistory reads a bash history file, in which a line such as
+// #1700000000 gives the time of the command that follows.
+func ParseBashHistory(r io.Reader) ([]ImportedCommand, error) {
+	var commands []ImportedCommand
+	var when time.Time
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if seconds, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				when = time.Unix(seconds, 0)
+				continue
+			}
+		}
+		if strings.TrimSpace(line) != "" {
+			commands = append(commands, ImportedCommand{Command: line, Time: when})
+		}
+		when = time.Time{}
+	}
+	return commands, scanner.Err()
+}
+
+// ParseZshHistory reads a zsh history file, in the plain format or the
+// extended one of lines such as ": 1700000000:0;git status". A line ending
+// in a backslash goes on with the next one.
+func ParseZshHistory(r io.Reader) ([]ImportedCommand, error) {
+	var commands []ImportedCommand
+	var current *ImportedCommand
+	continued := false
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := unmetafy(scanner.Bytes())
+		if continued && current != nil {
+			current.Command += "\n" + line
+		} else {
+			command := ImportedCommand{Command: line}
+			if rest, ok := strings.CutPrefix(line, ": "); ok {
+				if header, text, ok := strings.Cut(rest, ";"); ok {
+					start, _, _ := strings.Cut(header, ":")
+					if seconds, err := strconv.ParseInt(start, 10, 64); err == nil {
+						command = ImportedCommand{Command: text, Time: time.Unix(seconds, 0)}
+					}
+				}
+			}
+			commands = append(commands, command)
+			current = &commands[len(commands)-1]
+		}
+		continued = strings.HasSuffix(current.Command, "\\")
+		if continued {
+			current.Command = strings.TrimSuffix(current.Command, "\\")
+		}
+	}
+
+	result := commands[:0]
+	for _, command := range commands {
+		if strings.TrimSpace(command.Command) != "" {
+			result = append(result, command)
+		}
+	}
+	return result, scanner.Err()
+}
+
+// zshMeta precedes the bytes zsh escapes in its history file, which are
+// stored xored with 32.
+const zshMeta = 0x83
+
+func unmetafy(line []byte) string {
+	if bytes.IndexByte(line, zshMeta) < 0 {
+		return string(line)
+	}
+	out := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] == zshMeta && i+1 < len(line) {
+			i++
+			out = append(out, line[i]^32)
+			continue
+		}
+		out = append(out, line[i])
+	}
+	return string(out)
+}
+
+// ParseFishHistory reads a fish history file, a list of items such as
+//
+//	- cmd: git status
+//	  when: 1700000000
+//
+// in which newlines and backslashes of commands are escaped.
+func ParseFishHistory(r io.Reader) ([]ImportedCommand, error) {
+	var commands []ImportedCommand
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if command, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			commands = append(commands, ImportedCommand{Command: unescapeFish(command)})
+			continue
+		}
+		if when, ok := strings.CutPrefix(line, "  when: "); ok && len(commands) > 0 {
+			if seconds, err := strconv.ParseInt(strings.TrimSpace(when), 10, 64); err == nil {
+				commands[len(commands)-1].Time = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return commands, scanner.Err()
+}
+
+func unescapeFish(command string) string {
+	var sb strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] == '\\' && i+1 < len(command) {
+			switch command[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(command[i])
+	}
+	return sb.String()
+}
+
+// newHistoryScanner scans the lines of a history file, which may be longer
+// than bufio's default limit.
+func newHistoryScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+// importBatchSize is how many entries are inserted at once.
+const importBatchSize = 500
+
+// Import adds commands read from the history file of shell, oldest first, to
+// history. A command is skipped when it is already in history at the same
+// time, so that importing a file again adds only what is new in it. Without
+// a known time, only its latest run is imported, unless it is in history
+// already. Imported entries have no directory or exit code, and the session
+// import-<shell>.
+func (historyManager *HistoryManager) Import(shell string, commands []ImportedCommand) (ImportResult, error) {
+	type key struct {
+		command string
+		when    int64
+	}
+	var existing []HistoryEntry
+	if err := historyManager.db.Select("command", "created_at").Find(&existing).Error; err != nil {
+		return ImportResult{}, err
+	}
+	seen := make(map[key]bool, len(existing)+len(commands))
+	known := make(map[string]bool, len(existing)+len(commands))
+	for _, entry := range existing {
+		seen[key{entry.Command, entry.CreatedAt.Unix()}] = true
+		known[entry.Command] = true
+	}
+
+	var result ImportResult
+	var entries []HistoryEntry
+	// Newest first, so that the latest run of a command is the one kept
+	for i := len(commands) - 1; i >= 0; i-- {
+		command := commands[i]
+		// Compared as stored, so that a scrubbed command is not imported again
+		stored := historyManager.scrub(command.Command)
+		k := key{stored, command.Time.Unix()}
+		if seen[k] || command.Estimated && known[stored] {
+			result.Skipped++
+			continue
+		}
+		seen[k] = true
+		known[stored] = true
+		entries = append(entries, HistoryEntry{
+			CreatedAt: command.Time,
+			UpdatedAt: command.Time,
+			Command:   stored,
+			SessionID: "import-" + shell,
+		})
+	}
+	if len(entries) == 0 {
+		return result, nil
+	}
+	slices.Reverse(entries)
+
+	err := historyManager.writer.do(historyManager.db, func(tx *gorm.DB) error {
+		return tx.CreateInBatches(entries, importBatchSize).Error
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result.Imported = len(entries)
+	return result, nil
+}
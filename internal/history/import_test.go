@@ -0,0 +1,126 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBashHistory(t *testing.T) {
+	commands, err := ParseBashHistory(strings.NewReader("ls -la\n#1700000000\ngit status\n\n# a comment\nmake\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []ImportedCommand{
+		{Command: "ls -la"},
+		{Command: "git status", Time: time.Unix(1700000000, 0)},
+		{Command: "# a comment"},
+		{Command: "make"},
+	}, commands)
+}
+
+func TestParseZshHistory(t *testing.T) {
+	history := ": 1700000000:0;git status\n" +
+		": 1700000060:3;for f in *; do\\\n  echo $f\\\ndone\n" +
+		"plain command\n" +
+		": 1700000120:0;echo c\xc4\x83\xa3t\n"
+	commands, err := ParseZshHistory(strings.NewReader(history))
+	require.NoError(t, err)
+	assert.Equal(t, []ImportedCommand{
+		{Command: "git status", Time: time.Unix(1700000000, 0)},
+		{Command: "for f in *; do\n  echo $f\ndone", Time: time.Unix(1700000060, 0)},
+		{Command: "plain command"},
+		{Command: "echo căt", Time: time.Unix(1700000120, 0)},
+	}, commands)
+}
+
+func TestParseFishHistory(t *testing.T) {
+	history := "- cmd: git status\n  when: 1700000000\n" +
+		"- cmd: echo a\\\\nb\\necho c\n  when: 1700000060\n  paths:\n    - a\n"
+	commands, err := ParseFishHistory(strings.NewReader(history))
+	require.NoError(t, err)
+	assert.Equal(t, []ImportedCommand{
+		{Command: "git status", Time: time.Unix(1700000000, 0)},
+		{Command: "echo a\\nb\necho c", Time: time.Unix(1700000060, 0)},
+	}, commands)
+}
+
+func TestReadHistoryFileEstimatesTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bash_history")
+	require.NoError(t, os.WriteFile(path, []byte("ls\n#1700000000\npwd\nmake\n"), 0o600))
+	modTime := time.Unix(1800000000, 0)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	commands, err := ReadHistoryFile("bash", path)
+	require.NoError(t, err)
+	assert.Equal(t, []ImportedCommand{
+		{Command: "ls", Time: modTime.Add(-2 * time.Second), Estimated: true},
+		{Command: "pwd", Time: time.Unix(1700000000, 0)},
+		{Command: "make", Time: modTime, Estimated: true},
+	}, commands)
+
+	_, err = ReadHistoryFile("tcsh", path)
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	entry, err := historyManager.StartCommand("make", "/tmp", "session")
+	require.NoError(t, err)
+	_, err = historyManager.FinishCommand(entry, 0)
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Second)
+	commands := []ImportedCommand{
+		{Command: "git status", Time: now.Add(-3 * time.Hour)},
+		{Command: "git status", Time: now.Add(-3 * time.Hour)},
+		{Command: "ls", Time: now.Add(-2 * time.Hour), Estimated: true},
+		{Command: "git status", Time: now.Add(-90 * time.Minute)},
+		{Command: "ls", Time: now.Add(-time.Hour), Estimated: true},
+		{Command: "make", Time: now.Add(-30 * time.Minute), Estimated: true},
+	}
+	result, err := historyManager.Import("zsh", commands)
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Imported: 3, Skipped: 3}, result)
+
+	entries, err := historyManager.GetRecentEntries("", 10)
+	require.NoError(t, err)
+	var imported []string
+	for _, e := range entries {
+		if e.SessionID == "import-zsh" {
+			imported = append(imported, e.Command+" "+e.CreatedAt.Local().Format(time.TimeOnly))
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"git status " + now.Add(-3*time.Hour).Format(time.TimeOnly),
+		"git status " + now.Add(-90*time.Minute).Format(time.TimeOnly),
+		"ls " + now.Add(-time.Hour).Format(time.TimeOnly),
+	}, imported)
+
+	// Importing again adds nothing
+	result, err = historyManager.Import("zsh", commands)
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Imported: 0, Skipped: 6}, result)
+}
+
+func TestHistoryImportCommand(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "fish_history")
+	require.NoError(t, os.WriteFile(path, []byte("- cmd: ls\n  when: 1700000000\n"), 0o600))
+
+	handler := NewHistoryCommandHandler(historyManager)(func(ctx context.Context, args []string) error { return nil })
+	output, err := captureOutput(func() error {
+		return handler(context.Background(), []string{"history", "import", "fish", path})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Imported 1 commands from fish ("+path+"), skipped 0 duplicates\n", output)
+
+	err = handler(context.Background(), []string{"history", "import", "tcsh", path})
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// vacuumChunk is how many free pages each incremental vacuum gives back,
+	// so that it holds the write lock for a few milliseconds only
+	vacuumChunk = 256
+	// vacuumThreshold is the share of free pages, and vacuumMinPages their
+	// least number, above which a database made before incremental vacuum
+	// is vacuumed in full, once, to turn it on
+	vacuumThreshold = 0.25
+	vacuumMinPages  = 256
+	// analysisLimit bounds how many rows ANALYZE reads of each index
+	analysisLimit = 1000
+)
+
+// autoVacuumIncremental is the value of PRAGMA auto_vacuum that keeps free
+// pages for PRAGMA incremental_vacuum to give back.
+const autoVacuumIncremental = 2
+
+// MaintenanceResult tells what Maintain did.
+type MaintenanceResult struct {
+	// FreedPages were given back to the file system
+	FreedPages int
+	// Vacuumed is set when the database was vacuumed in full, which turns on
+	// incremental vacuum for a database made before it was
+	Vacuumed bool
+}
+
+// Maintain keeps the database small and its queries fast as it grows: it
+// gives back the pages freed by deleted entries, refreshes the statistics
+// the query planner picks indexes with, and copies the write-ahead log into
+// the database. Each step is a short write, so that the commands other
+// shells record wait little for it. It stops between steps once ctx is done.
+func (historyManager *HistoryManager) Maintain(ctx context.Context) (MaintenanceResult, error) {
+	var result MaintenanceResult
+
+	var autoVacuum, pages, free int
+	if err := historyManager.pragma("auto_vacuum", &autoVacuum); err != nil {
+		return result, err
+	}
+	if err := historyManager.pragma("page_count", &pages); err != nil {
+		return result, err
+	}
+	if err := historyManager.pragma("freelist_count", &free); err != nil {
+		return result, err
+	}
+
+	if autoVacuum != autoVacuumIncremental {
+		// Incremental vacuum only takes effect on a new database, or with a
+		// full vacuum
+		if free >= vacuumMinPages && float64(free) >= vacuumThreshold*float64(pages) {
+			if err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+				if err := db.Exec(fmt.Sprintf("PRAGMA auto_vacuum=%d", autoVacuumIncremental)).Error; err != nil {
+					return err
+				}
+				return db.Exec("VACUUM").Error
+			}); err != nil {
+				return result, fmt.Errorf("vacuum: %w", err)
+			}
+			result.Vacuumed, result.FreedPages = true, free
+		}
+	} else {
+		for free > 0 {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			chunk := min(free, vacuumChunk)
+			if err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+				return db.Exec(fmt.Sprintf("PRAGMA incremental_vacuum(%d)", chunk)).Error
+			}); err != nil {
+				return result, fmt.Errorf("incremental vacuum: %w", err)
+			}
+			left := free
+			if err := historyManager.pragma("freelist_count", &left); err != nil {
+				return result, err
+			}
+			if left >= free {
+				break
+			}
+			result.FreedPages += free - left
+			free = left
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	// optimize runs ANALYZE on the tables whose statistics are out of date
+	if err := historyManager.writer.do(historyManager.db, func(db *gorm.DB) error {
+		if err := db.Exec(fmt.Sprintf("PRAGMA analysis_limit=%d", analysisLimit)).Error; err != nil {
+			return err
+		}
+		return db.Exec("PRAGMA optimize").Error
+	}); err != nil {
+		return result, fmt.Errorf("analyze: %w", err)
+	}
+
+	// A passive checkpoint copies what it can without waiting for readers
+	if err := historyManager.db.Exec("PRAGMA wal_checkpoint(PASSIVE)").Error; err != nil {
+		return result, fmt.Errorf("checkpoint: %w", err)
+	}
+	return result, nil
+}
+
+// pragma reads the value of the PRAGMA name into value.
+func (historyManager *HistoryManager) pragma(name string, value *int) error {
+	if err := historyManager.db.Raw("PRAGMA " + name).Row().Scan(value); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
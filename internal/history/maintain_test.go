@@ -0,0 +1,88 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fillAndTrim adds entries that take up many pages, then deletes them, so
+// that their pages are left free.
+func fillAndTrim(t *testing.T, historyManager *HistoryManager) {
+	var entries []HistoryEntry
+	for range 2000 {
+		entries = append(entries, HistoryEntry{Command: strings.Repeat("x", 500), Directory: "/src", SessionID: "s1"})
+	}
+	require.NoError(t, historyManager.db.CreateInBatches(entries, 500).Error)
+	require.NoError(t, historyManager.db.Exec("DELETE FROM history_entries WHERE id > 10").Error)
+}
+
+func TestMaintain(t *testing.T) {
+	historyManager, err := NewHistoryManager(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer historyManager.Close()
+
+	var autoVacuum int
+	require.NoError(t, historyManager.pragma("auto_vacuum", &autoVacuum))
+	assert.Equal(t, autoVacuumIncremental, autoVacuum, "new databases vacuum incrementally")
+
+	fillAndTrim(t, historyManager)
+	var free int
+	require.NoError(t, historyManager.pragma("freelist_count", &free))
+	require.Greater(t, free, vacuumChunk, "the test needs more free pages than a chunk")
+
+	result, err := historyManager.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Vacuumed)
+	assert.Equal(t, free, result.FreedPages)
+	require.NoError(t, historyManager.pragma("freelist_count", &free))
+	assert.Zero(t, free)
+
+	var analyzed int64
+	require.NoError(t, historyManager.db.Raw("SELECT count(*) FROM sqlite_stat1").Row().Scan(&analyzed))
+	assert.NotZero(t, analyzed, "the query planner has statistics")
+
+	entries, err := historyManager.GetAllEntries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestMaintainVacuumsOldDatabase(t *testing.T) {
+	historyManager, err := NewHistoryManager(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer historyManager.Close()
+
+	// As made before incremental vacuum
+	require.NoError(t, historyManager.db.Exec("PRAGMA auto_vacuum=0").Error)
+	require.NoError(t, historyManager.db.Exec("VACUUM").Error)
+
+	result, err := historyManager.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Vacuumed, "a database with few free pages is left as is")
+
+	fillAndTrim(t, historyManager)
+	result, err = historyManager.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Vacuumed)
+	assert.NotZero(t, result.FreedPages)
+
+	var autoVacuum int
+	require.NoError(t, historyManager.pragma("auto_vacuum", &autoVacuum))
+	assert.Equal(t, autoVacuumIncremental, autoVacuum)
+}
+
+func TestMaintainStopsWithContext(t *testing.T) {
+	historyManager, err := NewHistoryManager(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer historyManager.Close()
+
+	fillAndTrim(t, historyManager)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = historyManager.Maintain(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
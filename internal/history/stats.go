@@ -0,0 +1,168 @@
+package history
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// CommandStats sums up the runs of a program, such as git or make.
+type CommandStats struct {
+	Name  string
+	Count int
+	// Finished counts the runs with an exit code, Failures those among
+	// them that failed
+	Finished, Failures int
+	// timed counts the runs with a duration, which add up to duration
+	timed    int
+	duration time.Duration
+}
+
+// FailureRate returns the share of the finished runs that failed, from 0 to 1.
+func (c CommandStats) FailureRate() float64 {
+	if c.Finished == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Finished)
+}
+
+// AverageDuration returns how long a run took on average, or 0 if no run
+// was timed.
+func (c CommandStats) AverageDuration() time.Duration {
+	if c.timed == 0 {
+		return 0
+	}
+	return c.duration / time.Duration(c.timed)
+}
+
+// DirectoryStats counts the commands run in a directory.
+type DirectoryStats struct {
+	Directory string
+	Count     int
+}
+
+// Stats sums up history: what runs most, where, when, how often it fails
+// and how long it takes.
+type Stats struct {
+	Total, Finished, Failures int
+	// Commands are by program, the most run first
+	Commands []CommandStats
+	// Directories are the most used first
+	Directories []DirectoryStats
+	// Hours counts the commands started in each hour of the day, local time
+	Hours [24]int
+	// First and Last are when the oldest and newest commands started
+	First, Last time.Time
+}
+
+// FailureRate returns the share of the finished commands that failed.
+func (s Stats) FailureRate() float64 {
+	if s.Finished == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Finished)
+}
+
+// BusiestHour returns the hour of the day most commands started in.
+func (s Stats) BusiestHour() int {
+	busiest := 0
+	for hour, count := range s.Hours {
+		if count > s.Hours[busiest] {
+			busiest = hour
+		}
+	}
+	return busiest
+}
+
+// Stats sums up the history recorded since since, or all of it if since is
+// zero.
+func (historyManager *HistoryManager) Stats(since time.Time) (Stats, error) {
+	var entries []HistoryEntry
+	db := historyManager.db.Select("command", "directory", "exit_code", "created_at", "updated_at")
+	if !since.IsZero() {
+		db = db.Where("created_at >= ?", since)
+	}
+	if result := db.Find(&entries); result.Error != nil {
+		return Stats{}, result.Error
+	}
+	return ComputeStats(entries), nil
+}
+
+// ComputeStats sums up entries.
+func ComputeStats(entries []HistoryEntry) Stats {
+	var stats Stats
+	commands := map[string]*CommandStats{}
+	directories := map[string]int{}
+	for _, entry := range entries {
+		name := commandName(entry.Command)
+		if name == "" {
+			continue
+		}
+		stats.Total++
+		if stats.First.IsZero() || entry.CreatedAt.Before(stats.First) {
+			stats.First = entry.CreatedAt
+		}
+		if entry.CreatedAt.After(stats.Last) {
+			stats.Last = entry.CreatedAt
+		}
+		stats.Hours[entry.CreatedAt.Local().Hour()]++
+		if entry.Directory != "" {
+			directories[entry.Directory]++
+		}
+
+		command := commands[name]
+		if command == nil {
+			command = &CommandStats{Name: name}
+			commands[name] = command
+		}
+		command.Count++
+		if entry.ExitCode.Valid {
+			command.Finished++
+			stats.Finished++
+			if entry.ExitCode.Int32 != 0 {
+				command.Failures++
+				stats.Failures++
+			}
+		}
+		if duration, ok := entry.Duration(); ok {
+			command.timed++
+			command.duration += duration
+		}
+	}
+
+	for _, command := range commands {
+		stats.Commands = append(stats.Commands, *command)
+	}
+	slices.SortFunc(stats.Commands, func(a, b CommandStats) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for directory, count := range directories {
+		stats.Directories = append(stats.Directories, DirectoryStats{Directory: directory, Count: count})
+	}
+	slices.SortFunc(stats.Directories, func(a, b DirectoryStats) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Directory, b.Directory)
+	})
+	return stats
+}
+
+// commandName returns the program a command line runs: its first word,
+// after variable assignments and sudo, env, time, command or nohup.
+func commandName(command string) string {
+	for _, word := range strings.Fields(command) {
+		switch {
+		case strings.Contains(word, "=") && !strings.HasPrefix(word, "="):
+		case word == "sudo" || word == "env" || word == "time" || word == "command" || word == "nohup":
+		case strings.HasPrefix(word, "-"):
+			// Options of sudo and the like
+		default:
+			return word
+		}
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestCommandName(t *testing.T) {
+	for command, want := range map[string]string{
+		"git status":                "git",
+		"  make   test":             "make",
+		"FOO=1 BAR=2 go test ./...": "go",
+		"sudo -E systemctl stop":    "systemctl",
+		"env -i HOME=/tmp time ls":  "ls",
+		"":                          "",
+		"X=1":                       "",
+	} {
+		assert.Equal(t, want, commandName(command), command)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.Local)
+	}
+	entry := func(command, directory string, start time.Time, exit int32, took time.Duration) HistoryEntry {
+		return HistoryEntry{
+			Command:   command,
+			Directory: directory,
+			CreatedAt: start,
+			UpdatedAt: start.Add(took),
+			ExitCode:  sql.NullInt32{Int32: exit, Valid: exit >= 0},
+		}
+	}
+	stats := ComputeStats([]HistoryEntry{
+		entry("make test", "/src", at(9, 0), 0, 4*time.Second),
+		entry("make build", "/src", at(9, 5), 2, 2*time.Second),
+		entry("git status", "/src", at(14, 0), 0, time.Second),
+		entry("make test", "/tmp", at(9, 30), 0, 6*time.Second),
+		entry("vim notes", "/tmp", at(22, 0), -1, 0),
+		entry("   ", "/tmp", at(22, 0), 0, 0),
+	})
+
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, 4, stats.Finished)
+	assert.Equal(t, 1, stats.Failures)
+	assert.InDelta(t, 0.25, stats.FailureRate(), 1e-9)
+	assert.Equal(t, at(9, 0), stats.First)
+	assert.Equal(t, at(22, 0), stats.Last)
+	assert.Equal(t, 9, stats.BusiestHour())
+	assert.Equal(t, 3, stats.Hours[9])
+
+	require.Len(t, stats.Commands, 3)
+	first := stats.Commands[0]
+	assert.Equal(t, "make", first.Name)
+	assert.Equal(t, 3, first.Count)
+	assert.InDelta(t, 1.0/3, first.FailureRate(), 1e-9)
+	assert.Equal(t, 4*time.Second, first.AverageDuration())
+	assert.Equal(t, "git", stats.Commands[1].Name)
+	assert.Equal(t, "vim", stats.Commands[2].Name)
+	assert.Equal(t, time.Duration(0), stats.Commands[2].AverageDuration())
+
+	assert.Equal(t, []DirectoryStats{{"/src", 3}, {"/tmp", 2}}, stats.Directories)
+}
+
+func TestStatsPeriodSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	assert.True(t, StatsPeriod{Days: 0}.Since(now).IsZero())
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), StatsPeriod{Days: 1}.Since(now))
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), StatsPeriod{Days: 7}.Since(now))
+}
+
+func TestHistoryManagerStats(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	for _, command := range []string{"ls", "ls -la", "false"} {
+		entry, err := historyManager.StartCommand(command, "/tmp", "s1")
+		require.NoError(t, err)
+		exit := 0
+		if command == "false" {
+			exit = 1
+		}
+		_, err = historyManager.FinishCommand(entry, exit)
+		require.NoError(t, err)
+	}
+
+	stats, err := historyManager.Stats(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 1, stats.Failures)
+	assert.Equal(t, "ls", stats.Commands[0].Name)
+
+	stats, err = historyManager.Stats(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+}
+
+func TestStatsModel(t *testing.T) {
+	var loaded []time.Time
+	load := func(since time.Time) (Stats, error) {
+		loaded = append(loaded, since)
+		return ComputeStats([]HistoryEntry{{Command: "make", Directory: "/src", CreatedAt: time.Now()}}), nil
+	}
+	m := newStatsModel(load, StatsPeriods, 0)
+	assert.Contains(t, m.View(), "all time")
+	assert.Contains(t, m.View(), "make")
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	assert.Contains(t, next.View(), "last 30 days")
+	assert.Len(t, loaded, 2)
+	assert.False(t, loaded[1].IsZero())
+
+	_, cmd := next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+}
+
+func TestHistoryStatsCommand(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	entry, err := historyManager.StartCommand("git status", "/src", "s1")
+	require.NoError(t, err)
+	_, err = historyManager.FinishCommand(entry, 0)
+	require.NoError(t, err)
+
+	run := func(script string) (string, error) {
+		var stdout bytes.Buffer
+		runner, err := interp.New(
+			interp.StdIO(nil, &stdout, &stdout),
+			interp.ExecHandlers(NewHistoryCommandHandler(historyManager)),
+		)
+		require.NoError(t, err)
+		file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+		require.NoError(t, err)
+		err = runner.Run(context.Background(), file)
+		return stdout.String(), err
+	}
+
+	out, err := run("history stats")
+	require.NoError(t, err)
+	assert.Contains(t, out, "History stats · all time · 1 commands")
+	assert.Contains(t, out, "git")
+	assert.Contains(t, out, "/src")
+	assert.NotContains(t, out, "\x1b[")
+
+	out, err = run("history stats --days 14")
+	require.NoError(t, err)
+	assert.Contains(t, out, "last 14 days")
+
+	_, err = run("history stats --days x")
+	assert.Error(t, err)
+
+	original := statsIsTerminal
+	defer func() { statsIsTerminal = original }()
+	statsIsTerminal = func(any) bool { return true }
+	originalShow := showStats
+	defer func() { showStats = originalShow }()
+	var shown StatsPeriod
+	showStats = func(load func(time.Time) (Stats, error), periods []StatsPeriod, period int) error {
+		shown = periods[period]
+		return nil
+	}
+	_, err = run("history stats --days=7")
+	require.NoError(t, err)
+	assert.Equal(t, StatsPeriod{Label: "last 7 days", Days: 7}, shown)
+}
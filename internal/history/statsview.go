@@ -0,0 +1,229 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	statsTitleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	statsHeadingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	statsBarStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	statsFailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	statsDimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+)
+
+const (
+	// statsRows is how many commands and directories are shown
+	statsRows = 10
+	// statsBarWidth is the width of the longest bar
+	statsBarWidth = 20
+	// statsSideBySide is the width from which the commands and directories
+	// are shown side by side
+	statsSideBySide = 110
+)
+
+// StatsPeriod is a period history stats sums up.
+type StatsPeriod struct {
+	Label string
+	// Days is how many days back the period goes, 0 for all of history
+	Days int
+}
+
+// StatsPeriods are the periods the dashboard cycles through.
+var StatsPeriods = []StatsPeriod{
+	{Label: "all time", Days: 0},
+	{Label: "last 30 days", Days: 30},
+	{Label: "last 7 days", Days: 7},
+	{Label: "today", Days: 1},
+}
+
+// Since returns when the period that ends at now starts, or the zero time
+// for all of history. A period of days starts at midnight, days-1 days ago.
+func (p StatsPeriod) Since(now time.Time) time.Time {
+	if p.Days <= 0 {
+		return time.Time{}
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.AddDate(0, 0, 1-p.Days)
+}
+
+// statsModel is the history stats dashboard. r cycles through the periods.
+type statsModel struct {
+	load    func(since time.Time) (Stats, error)
+	now     func() time.Time
+	periods []StatsPeriod
+	period  int
+	stats   Stats
+	err     error
+	width   int
+}
+
+func newStatsModel(load func(since time.Time) (Stats, error), periods []StatsPeriod, period int) statsModel {
+	m := statsModel{load: load, now: time.Now, periods: periods, period: period, width: 80}
+	m.reload()
+	return m
+}
+
+func (m *statsModel) reload() {
+	m.stats, m.err = m.load(m.periods[m.period].Since(m.now()))
+}
+
+func (m statsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m statsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c", "enter":
+			return m, tea.Quit
+		case "r", "tab", "right", "l":
+			m.period = (m.period + 1) % len(m.periods)
+			m.reload()
+		case "shift+tab", "left", "h":
+			m.period = (m.period + len(m.periods) - 1) % len(m.periods)
+			m.reload()
+		}
+	}
+	return m, nil
+}
+
+func (m statsModel) View() string {
+	var sb strings.Builder
+	if m.err != nil {
+		sb.WriteString(statsFailStyle.Render("history stats: "+m.err.Error()) + "\n")
+	} else {
+		sb.WriteString(RenderStats(m.stats, m.periods[m.period].Label, m.width))
+	}
+	sb.WriteString("\n" + statsDimStyle.Render("r/←/→: period • q: quit"))
+	return sb.String()
+}
+
+// RenderStats lays out stats for a terminal width columns wide, with label
+// naming the period they cover.
+func RenderStats(stats Stats, label string, width int) string {
+	var sb strings.Builder
+	title := fmt.Sprintf("History stats · %s · %d commands", label, stats.Total)
+	if stats.Finished > 0 {
+		title += fmt.Sprintf(" · %.1f%% failed", 100*stats.FailureRate())
+	}
+	sb.WriteString(statsTitleStyle.Render(title) + "\n\n")
+	if stats.Total == 0 {
+		sb.WriteString("No commands in this period.\n")
+		return sb.String()
+	}
+
+	commands, directories := renderTopCommands(stats), renderTopDirectories(stats)
+	if width >= statsSideBySide {
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, commands, "    ", directories) + "\n")
+	} else {
+		sb.WriteString(commands + "\n\n" + directories + "\n")
+	}
+	sb.WriteString("\n" + renderHours(stats) + "\n")
+	return sb.String()
+}
+
+func renderTopCommands(stats Stats) string {
+	var sb strings.Builder
+	sb.WriteString(statsHeadingStyle.Render("Top commands") + "\n")
+	sb.WriteString(statsDimStyle.Render(fmt.Sprintf("%-14s %6s  %-*s %7s %9s", "", "runs", statsBarWidth, "", "failed", "average")) + "\n")
+	top := stats.Commands[:min(statsRows, len(stats.Commands))]
+	for _, command := range top {
+		failed := fmt.Sprintf("%6.1f%%", 100*command.FailureRate())
+		if command.Finished == 0 {
+			failed = fmt.Sprintf("%7s", "-")
+		} else if command.FailureRate() >= 0.25 {
+			failed = statsFailStyle.Render(failed)
+		}
+		average := "-"
+		if d := command.AverageDuration(); d > 0 {
+			average = formatStatsDuration(d)
+		}
+		fmt.Fprintf(&sb, "%-14s %6d  %s %s %9s\n", truncateStats(command.Name, 14), command.Count,
+			bar(command.Count, top[0].Count, statsBarWidth), failed, average)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderTopDirectories(stats Stats) string {
+	var sb strings.Builder
+	sb.WriteString(statsHeadingStyle.Render("Top directories") + "\n")
+	sb.WriteString(statsDimStyle.Render(fmt.Sprintf("%-32s %6s", "", "runs")) + "\n")
+	home, _ := os.UserHomeDir()
+	top := stats.Directories[:min(statsRows, len(stats.Directories))]
+	for _, directory := range top {
+		path := directory.Directory
+		if home != "" && (path == home || strings.HasPrefix(path, home+"/")) {
+			path = "~" + strings.TrimPrefix(path, home)
+		}
+		fmt.Fprintf(&sb, "%-32s %6d  %s\n", truncateStats(path, 32), directory.Count,
+			bar(directory.Count, top[0].Count, statsBarWidth/2))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderHours draws a column for each hour of the day, the busiest the
+// highest.
+func renderHours(stats Stats) string {
+	levels := []rune(" ▁▂▃▄▅▆▇█")
+	busiest := stats.Hours[stats.BusiestHour()]
+	var columns strings.Builder
+	for _, count := range stats.Hours {
+		level := 0
+		if busiest > 0 && count > 0 {
+			level = 1 + count*(len(levels)-2)/busiest
+		}
+		columns.WriteString(strings.Repeat(string(levels[level]), 2))
+	}
+	var sb strings.Builder
+	sb.WriteString(statsHeadingStyle.Render("Busiest hours") +
+		statsDimStyle.Render(fmt.Sprintf("  most commands at %02d:00", stats.BusiestHour())) + "\n")
+	sb.WriteString(statsBarStyle.Render(columns.String()) + "\n")
+	sb.WriteString(statsDimStyle.Render(fmt.Sprintf("%-12s%-12s%-12s%-12s", "0", "6", "12", "18")))
+	return sb.String()
+}
+
+// bar draws count as a bar, which is width long for max.
+func bar(count, max, width int) string {
+	length := 1
+	if max > 0 {
+		length = (count*width + max - 1) / max
+	}
+	return statsBarStyle.Render(strings.Repeat("█", length)) + strings.Repeat(" ", width-length)
+}
+
+func truncateStats(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func formatStatsDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// showStats runs the dashboard on the terminal. Tests override it.
+var showStats = func(load func(since time.Time) (Stats, error), periods []StatsPeriod, period int) error {
+	_, err := tea.NewProgram(newStatsModel(load, periods, period), tea.WithAltScreen()).Run()
+	return err
+}
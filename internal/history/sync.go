@@ -0,0 +1,301 @@
+package history
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// syncAttempts bounds the retries of a sync when another machine pushed
+// meanwhile.
+const syncAttempts = 3
+
+// syncMagic starts the history pushed, before the nonce and ciphertext.
+var syncMagic = []byte("bishsync1\n")
+
+// SyncedEntry is a history entry as pushed to the sync remote.
+type SyncedEntry struct {
+	Time      time.Time `json:"time"`
+	Updated   time.Time `json:"updated"`
+	Command   string    `json:"command"`
+	Directory string    `json:"directory"`
+	Session   string    `json:"session"`
+	ExitCode  *int32    `json:"exit_code,omitempty"`
+	Task      string    `json:"task,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Queued    bool      `json:"queued,omitempty"`
+}
+
+// syncKey identifies an entry across machines, which number entries each
+// their own way. Times are compared to the millisecond, which SQLite keeps.
+type syncKey struct {
+	session string
+	time    int64
+	command string
+}
+
+func (entry SyncedEntry) key() syncKey {
+	return syncKey{entry.Session, entry.Time.UnixMilli(), entry.Command}
+}
+
+func newSyncedEntry(entry HistoryEntry) SyncedEntry {
+	synced := SyncedEntry{
+		Time:      entry.CreatedAt,
+		Updated:   entry.UpdatedAt,
+		Command:   entry.Command,
+		Directory: entry.Directory,
+		Session:   entry.SessionID,
+		Task:      entry.Task,
+		Container: entry.Container,
+		Queued:    entry.Queued,
+	}
+	if entry.ExitCode.Valid {
+		exitCode := entry.ExitCode.Int32
+		synced.ExitCode = &exitCode
+	}
+	return synced
+}
+
+func (entry SyncedEntry) historyEntry() HistoryEntry {
+	historyEntry := HistoryEntry{
+		CreatedAt: entry.Time,
+		UpdatedAt: entry.Updated,
+		Command:   entry.Command,
+		Directory: entry.Directory,
+		SessionID: entry.Session,
+		Task:      entry.Task,
+		Container: entry.Container,
+		Queued:    entry.Queued,
+	}
+	if entry.ExitCode != nil {
+		historyEntry.ExitCode = sql.NullInt32{Int32: *entry.ExitCode, Valid: true}
+	}
+	return historyEntry
+}
+
+// SyncResult counts the entries a sync moved each way.
+type SyncResult struct {
+	// Pulled is the entries of other machines added to history.
+	Pulled int
+	// Pushed is the entries of this machine added to the remote.
+	Pushed int
+}
+
+// Sync merges history with the one pushed to remote, encrypted with key,
+// both ways. Entries are never changed or removed by a sync, only added to
+// each side, so machines syncing in any order end up with the same history:
+// one deleted locally comes back from the remote, while the other machines
+// still have it.
+func (historyManager *HistoryManager) Sync(ctx context.Context, remote SyncRemote, key []byte) (SyncResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := historyManager.syncOnce(ctx, remote, key)
+		if !errors.Is(err, ErrSyncConflict) || attempt == syncAttempts {
+			return result, err
+		}
+	}
+}
+
+func (historyManager *HistoryManager) syncOnce(ctx context.Context, remote SyncRemote, key []byte) (SyncResult, error) {
+	data, version, err := remote.Get(ctx)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("failed to fetch the synced history: %w", err)
+	}
+	var remoteEntries []SyncedEntry
+	if data != nil {
+		if remoteEntries, err = decodeSynced(data, key); err != nil {
+			return SyncResult{}, err
+		}
+	}
+
+	local, err := historyManager.GetAllEntries()
+	if err != nil {
+		return SyncResult{}, err
+	}
+	known := make(map[syncKey]bool, len(local)+len(remoteEntries))
+	merged := make([]SyncedEntry, 0, len(local)+len(remoteEntries))
+	for _, entry := range local {
+		synced := newSyncedEntry(entry)
+		known[synced.key()] = true
+		merged = append(merged, synced)
+	}
+
+	var result SyncResult
+	var pulled []HistoryEntry
+	onRemote := make(map[syncKey]bool, len(remoteEntries))
+	for _, entry := range remoteEntries {
+		onRemote[entry.key()] = true
+		if !known[entry.key()] {
+			known[entry.key()] = true
+			pulled = append(pulled, entry.historyEntry())
+			merged = append(merged, entry)
+		}
+	}
+	for _, entry := range merged {
+		if !onRemote[entry.key()] {
+			result.Pushed++
+		}
+	}
+
+	if len(pulled) > 0 {
+		err := historyManager.writer.do(historyManager.db, func(tx *gorm.DB) error {
+			return tx.CreateInBatches(pulled, importBatchSize).Error
+		})
+		if err != nil {
+			return SyncResult{}, err
+		}
+		result.Pulled = len(pulled)
+	}
+
+	if result.Pushed > 0 {
+		data, err := encodeSynced(merged, key)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if err := remote.Put(ctx, data, version); err != nil {
+			// What was pulled stays; the retry finds it known
+			return SyncResult{Pulled: result.Pulled}, fmt.Errorf("failed to push the synced history: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// encodeSynced returns entries, oldest first, as JSON compressed with gzip
+// and encrypted with AES-GCM.
+func encodeSynced(entries []SyncedEntry, key []byte) ([]byte, error) {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b SyncedEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	var plain bytes.Buffer
+	compressor := gzip.NewWriter(&plain)
+	if err := json.NewEncoder(compressor).Encode(entries); err != nil {
+		return nil, err
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	aead, err := newSyncCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	data := append(slices.Clone(syncMagic), nonce...)
+	return aead.Seal(data, nonce, plain.Bytes(), syncMagic), nil
+}
+
+// decodeSynced reads back what encodeSynced returned.
+func decodeSynced(data []byte, key []byte) ([]SyncedEntry, error) {
+	aead, err := newSyncCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, syncMagic) || len(data) < len(syncMagic)+aead.NonceSize() {
+		return nil, errors.New("the synced history is not in a format bish knows")
+	}
+	data = data[len(syncMagic):]
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], syncMagic)
+	if err != nil {
+		return nil, errors.New("cannot decrypt the synced history; was it pushed with another key? See history sync key")
+	}
+
+	decompressor, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, err
+	}
+	var entries []SyncedEntry
+	if err := json.NewDecoder(decompressor).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to read the synced history: %w", err)
+	}
+	return entries, nil
+}
+
+func newSyncCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history sync key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// DefaultSyncKeyPath returns where the key history is encrypted with for
+// syncing is kept.
+func DefaultSyncKeyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "history_sync.key")
+}
+
+// LoadSyncKey returns the key at path, creating a random one the first time
+// history is synced. The same key has to be set on every machine, with
+// SaveSyncKey.
+func LoadSyncKey(path string) ([]byte, error) {
+	text, err := os.ReadFile(path)
+	if err == nil {
+		return ParseSyncKey(string(text))
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, SaveSyncKey(path, key)
+}
+
+// SaveSyncKey writes key to path, readable only by the user.
+func SaveSyncKey(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(FormatSyncKey(key)+"\n"), 0o600)
+}
+
+// FormatSyncKey returns key as it is written and copied between machines.
+func FormatSyncKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseSyncKey reads a key written by FormatSyncKey.
+func ParseSyncKey(text string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("invalid history sync key: it should be the 44 characters history sync key prints")
+	}
+	return key, nil
+}
+
+// readAllLimited reads r, failing if it holds more than limit bytes.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("the synced history is larger than %d MiB", limit>>20)
+	}
+	return data, nil
+}
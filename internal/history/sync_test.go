@@ -0,0 +1,252 @@
+package history
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commandsOf(t *testing.T, historyManager *HistoryManager) []string {
+	t.Helper()
+	entries, err := historyManager.GetAllEntries()
+	require.NoError(t, err)
+	var commands []string
+	for _, entry := range entries {
+		commands = append(commands, entry.Command)
+	}
+	return commands
+}
+
+func TestSyncMergesMachines(t *testing.T) {
+	key := make([]byte, 32)
+	remote, err := NewSyncRemote(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	laptop, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	server, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+
+	entry, err := laptop.StartCommand("make test", "/src", "laptop-1")
+	require.NoError(t, err)
+	_, err = laptop.FinishCommand(entry, 2)
+	require.NoError(t, err)
+	_, err = server.StartCommand("systemctl status nginx", "/", "server-1")
+	require.NoError(t, err)
+
+	result, err := laptop.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{Pulled: 0, Pushed: 1}, result)
+
+	result, err = server.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{Pulled: 1, Pushed: 1}, result)
+
+	result, err = laptop.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{Pulled: 1, Pushed: 0}, result)
+
+	// Syncing again changes nothing
+	result, err = server.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{}, result)
+
+	assert.ElementsMatch(t, []string{"make test", "systemctl status nginx"}, commandsOf(t, laptop))
+	assert.ElementsMatch(t, commandsOf(t, laptop), commandsOf(t, server))
+
+	// What an entry carries comes along
+	entries, err := server.GetAllEntries()
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Command == "make test" {
+			assert.Equal(t, "/src", entry.Directory)
+			assert.Equal(t, "laptop-1", entry.SessionID)
+			assert.True(t, entry.ExitCode.Valid)
+			assert.EqualValues(t, 2, entry.ExitCode.Int32)
+		}
+	}
+
+	// Another key cannot read it
+	_, err = server.Sync(ctx, remote, make([]byte, 16))
+	assert.ErrorContains(t, err, "cannot decrypt")
+}
+
+// racedRemote is a remote another machine pushes to between the first Get
+// and Put.
+type racedRemote struct {
+	SyncRemote
+	raced bool
+	race  func()
+}
+
+func (r *racedRemote) Put(ctx context.Context, data []byte, version string) error {
+	if !r.raced {
+		r.raced = true
+		r.race()
+	}
+	return r.SyncRemote.Put(ctx, data, version)
+}
+
+func TestSyncRetriesConflicts(t *testing.T) {
+	key := make([]byte, 32)
+	fileRemote, err := NewSyncRemote(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	laptop, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	server, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	_, err = laptop.StartCommand("ls", "/", "laptop-1")
+	require.NoError(t, err)
+	_, err = server.StartCommand("uptime", "/", "server-1")
+	require.NoError(t, err)
+
+	remote := &racedRemote{SyncRemote: fileRemote, race: func() {
+		_, err := server.Sync(ctx, fileRemote, key)
+		require.NoError(t, err)
+	}}
+	result, err := laptop.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{Pulled: 1, Pushed: 1}, result)
+
+	_, err = server.Sync(ctx, fileRemote, key)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ls", "uptime"}, commandsOf(t, server))
+}
+
+func TestFileRemotePutsOneAtATime(t *testing.T) {
+	dir := t.TempDir()
+	remote, err := NewSyncRemote(filepath.Join(dir, "history"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, remote.Put(ctx, []byte("first"), ""))
+	_, version, err := remote.Get(ctx)
+	require.NoError(t, err)
+
+	// Of the machines pushing over the same version, only one wins
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = remote.Put(ctx, []byte("push "+strconv.Itoa(i)), version)
+		}()
+	}
+	wg.Wait()
+	won := 0
+	for _, err := range errs {
+		if err == nil {
+			won++
+		} else {
+			assert.ErrorIs(t, err, ErrSyncConflict)
+		}
+	}
+	assert.Equal(t, 1, won)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no lock or temp files are left behind")
+	assert.Equal(t, "history", entries[0].Name())
+}
+
+func TestFileRemoteTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path+".lock", nil, 0o600))
+	old := time.Now().Add(-2 * syncLockStale)
+	require.NoError(t, os.Chtimes(path+".lock", old, old))
+
+	remote, err := NewSyncRemote(path)
+	require.NoError(t, err)
+	require.NoError(t, remote.Put(context.Background(), []byte("data"), ""))
+	_, err = os.Stat(path + ".lock")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSyncOverHTTP(t *testing.T) {
+	var mu sync.Mutex
+	var stored []byte
+	version := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, password, _ := r.BasicAuth(); user != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		etag := `"` + strconv.Itoa(version) + `"`
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write(stored)
+		case http.MethodPut:
+			if match := r.Header.Get("If-Match"); match != "" && match != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			stored, _ = io.ReadAll(r.Body)
+			version++
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	key := make([]byte, 32)
+	ctx := context.Background()
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	_, err = historyManager.StartCommand("git pull", "/", "s1")
+	require.NoError(t, err)
+
+	remote, err := NewSyncRemote(server.URL[:7] + "me:secret@" + server.URL[7:] + "/history")
+	require.NoError(t, err)
+	result, err := historyManager.Sync(ctx, remote, key)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{Pushed: 1}, result)
+
+	// A PUT over a version that is not the latest is a conflict
+	data, got, err := remote.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `"1"`, got)
+	assert.ErrorIs(t, remote.Put(ctx, data, `"0"`), ErrSyncConflict)
+
+	// The password is not in messages
+	unauthorized, err := NewSyncRemote(server.URL[:7] + "me:wrong@" + server.URL[7:])
+	require.NoError(t, err)
+	_, err = historyManager.Sync(ctx, unauthorized, key)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "wrong")
+}
+
+func TestSyncKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bish", "history_sync.key")
+	key, err := LoadSyncKey(path)
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	again, err := LoadSyncKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	parsed, err := ParseSyncKey(FormatSyncKey(key) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+	_, err = ParseSyncKey("c2hvcnQ=")
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxSyncedSize bounds the history fetched from a remote.
+const maxSyncedSize = 256 << 20
+
+// syncHTTPTimeout bounds each request to an HTTP remote.
+const syncHTTPTimeout = 60 * time.Second
+
+// syncLockStale is how old the lock file of a file remote gets before it is
+// taken to be left over, and syncLockRetry how often it is tried meanwhile.
+const (
+	syncLockStale = 30 * time.Second
+	syncLockRetry = 50 * time.Millisecond
+)
+
+// ErrSyncConflict is returned by Put when another machine pushed since Get.
+var ErrSyncConflict = errors.New("the synced history changed meanwhile")
+
+// SyncRemote is where the encrypted history is pushed to be merged across
+// machines.
+type SyncRemote interface {
+	// Get returns what was last pushed and its version, or nil data if
+	// nothing was.
+	Get(ctx context.Context) (data []byte, version string, err error)
+	// Put pushes data in place of the version Get returned, failing with
+	// ErrSyncConflict if it is no longer the latest.
+	Put(ctx context.Context, data []byte, version string) error
+}
+
+// NewSyncRemote returns the remote at rawURL: a file, for a directory that
+// is synced by other means or mounted, such as an S3 bucket through
+// s3fs or rclone mount, or an http or https URL that takes GET and PUT,
+// such as a WebDAV share or a self-hosted endpoint. User and password in
+// the URL authenticate with HTTP basic authentication.
+func NewSyncRemote(rawURL string) (SyncRemote, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history sync URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return &httpRemote{url: rawURL, client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+	case "file":
+		return fileRemote{path: parsed.Path}, nil
+	case "":
+		return fileRemote{path: rawURL}, nil
+	default:
+		return nil, fmt.Errorf("cannot sync history to %s URLs; use a file path, or an http or https URL", parsed.Scheme)
+	}
+}
+
+// fileRemote is history pushed to a file. Its version is the hash of its
+// content.
+type fileRemote struct {
+	path string
+}
+
+func (r fileRemote) Get(ctx context.Context) ([]byte, string, error) {
+	file, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := readAllLimited(file, maxSyncedSize)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentVersion(data), nil
+}
+
+func (r fileRemote) Put(ctx context.Context, data []byte, version string) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return err
+	}
+	// Machines pushing at the same time check the version and replace the
+	// file one after the other
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, currentVersion, err := r.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if current != nil && currentVersion != version || current == nil && version != "" {
+		return ErrSyncConflict
+	}
+	// Written aside and renamed, so that a reader never sees half of it
+	temp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(temp.Name()) }()
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), r.path)
+}
+
+// lock takes the lock file next to the history, waiting for other pushes to
+// finish. A lock file older than syncLockStale is left over from a push that
+// never finished and is taken over.
+func (r fileRemote) lock(ctx context.Context) (unlock func(), err error) {
+	lockPath := r.path + ".lock"
+	for {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > syncLockStale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(syncLockRetry):
+		}
+	}
+}
+
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// httpRemote is history pushed to a URL. Its version is the ETag of the
+// response, with which a server that supports conditional requests refuses
+// a PUT that would overwrite what another machine pushed meanwhile.
+type httpRemote struct {
+	url    string
+	client *http.Client
+}
+
+func (r *httpRemote) Get(ctx context.Context) ([]byte, string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, "", nil
+	case response.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GET %s: %s", redactURL(r.url), response.Status)
+	}
+	data, err := readAllLimited(response.Body, maxSyncedSize)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, response.Header.Get("ETag"), nil
+}
+
+func (r *httpRemote) Put(ctx context.Context, data []byte, version string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, r.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	if version != "" {
+		request.Header.Set("If-Match", version)
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	_ = response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusPreconditionFailed:
+		return ErrSyncConflict
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return fmt.Errorf("PUT %s: %s", redactURL(r.url), response.Status)
+	}
+	return nil
+}
+
+// redactURL returns rawURL without the password in it, for messages.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
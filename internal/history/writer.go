@@ -0,0 +1,102 @@
+package history
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQLite primary result codes that indicate another connection holds a lock.
+// Extended result codes (e.g. SQLITE_BUSY_SNAPSHOT) share the low byte.
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// writeRetryPolicy controls how writes are retried when the database is locked
+// by another bish instance. busy_timeout already makes SQLite wait inside a single
+// statement, but some lock conflicts (such as upgrading a stale WAL read snapshot
+// to a write) return SQLITE_BUSY immediately, so writes are retried at this level too.
+type writeRetryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultWriteRetryPolicy = writeRetryPolicy{
+	maxAttempts: 8,
+	baseDelay:   10 * time.Millisecond,
+	maxDelay:    500 * time.Millisecond,
+}
+
+// historyWriter serializes all writes issued by one process so that a single
+// connection is ever competing for the SQLite write lock, and retries writes
+// with jittered exponential backoff when other processes hold it.
+type historyWriter struct {
+	mu     sync.Mutex
+	policy writeRetryPolicy
+	sleep  func(time.Duration)
+}
+
+func newHistoryWriter() *historyWriter {
+	return &historyWriter{
+		policy: defaultWriteRetryPolicy,
+		sleep:  time.Sleep,
+	}
+}
+
+// do runs op while holding the writer lock, retrying while it fails with a
+// busy/locked error. The last error is returned once attempts are exhausted.
+// A nil writer (e.g. a zero-value HistoryManager) runs op once without retries.
+func (w *historyWriter) do(db *gorm.DB, op func(tx *gorm.DB) error) error {
+	if w == nil {
+		return op(db)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < w.policy.maxAttempts; attempt++ {
+		err = op(db)
+		if err == nil || !isBusyError(err) {
+			return err
+		}
+		w.sleep(w.backoff(attempt))
+	}
+	return err
+}
+
+// backoff returns the delay before retry number attempt, doubling each time up
+// to maxDelay and adding up to 50% jitter so competing shells spread out.
+func (w *historyWriter) backoff(attempt int) time.Duration {
+	delay := w.policy.baseDelay << attempt
+	if delay <= 0 || delay > w.policy.maxDelay {
+		delay = w.policy.maxDelay
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay + jitter
+}
+
+// isBusyError reports whether err was caused by another connection holding a
+// lock on the database.
+func isBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		code := coded.Code() & 0xff
+		return code == sqliteBusy || code == sqliteLocked
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") ||
+		strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked")
+}
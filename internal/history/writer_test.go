@@ -0,0 +1,157 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type codedError struct{ code int }
+
+func (e codedError) Error() string { return fmt.Sprintf("sqlite error %d", e.code) }
+func (e codedError) Code() int     { return e.code }
+
+func TestIsBusyError(t *testing.T) {
+	assert.False(t, isBusyError(nil))
+	assert.False(t, isBusyError(errors.New("no such table")))
+	assert.True(t, isBusyError(errors.New("database is locked (5) (SQLITE_BUSY)")))
+	assert.True(t, isBusyError(codedError{code: sqliteBusy}))
+	assert.True(t, isBusyError(codedError{code: sqliteLocked}))
+	// SQLITE_BUSY_SNAPSHOT is an extended code of SQLITE_BUSY
+	assert.True(t, isBusyError(fmt.Errorf("wrapped: %w", codedError{code: 517})))
+	assert.False(t, isBusyError(codedError{code: 19}))
+}
+
+func TestHistoryWriterRetriesBusyErrors(t *testing.T) {
+	var sleeps []time.Duration
+	w := newHistoryWriter()
+	w.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	calls := 0
+	err := w.do(nil, func(*gorm.DB) error {
+		calls++
+		if calls < 3 {
+			return codedError{code: sqliteBusy}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, sleeps, 2)
+}
+
+func TestHistoryWriterGivesUpAfterMaxAttempts(t *testing.T) {
+	w := newHistoryWriter()
+	w.sleep = func(time.Duration) {}
+
+	calls := 0
+	err := w.do(nil, func(*gorm.DB) error {
+		calls++
+		return codedError{code: sqliteBusy}
+	})
+
+	assert.True(t, isBusyError(err))
+	assert.Equal(t, defaultWriteRetryPolicy.maxAttempts, calls)
+}
+
+func TestHistoryWriterDoesNotRetryOtherErrors(t *testing.T) {
+	w := newHistoryWriter()
+	w.sleep = func(time.Duration) { t.Fatal("unexpected retry") }
+
+	calls := 0
+	err := w.do(nil, func(*gorm.DB) error {
+		calls++
+		return errors.New("constraint failed")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestHistoryWriterBackoffIsBounded(t *testing.T) {
+	w := newHistoryWriter()
+	for attempt := 0; attempt < 64; attempt++ {
+		d := w.backoff(attempt)
+		assert.GreaterOrEqual(t, d, w.policy.baseDelay)
+		assert.LessOrEqual(t, d, w.policy.maxDelay+w.policy.maxDelay/2)
+	}
+}
+
+func TestFinishCommandWithoutEntry(t *testing.T) {
+	historyManager, err := NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	defer func() { _ = historyManager.Close() }()
+
+	_, err = historyManager.FinishCommand(nil, 0)
+	assert.Error(t, err)
+}
+
+// TestConcurrentShellsWriteHistory simulates many bish instances, each with its
+// own connection to the same history file, writing at the same time.
+func TestConcurrentShellsWriteHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping concurrency stress test in short mode")
+	}
+
+	const shells = 20
+	const commandsPerShell = 15
+
+	dbPath := filepath.Join(t.TempDir(), "history.db")
+
+	managers := make([]*HistoryManager, shells)
+	for i := range managers {
+		hm, err := NewHistoryManager(dbPath)
+		require.NoError(t, err)
+		managers[i] = hm
+	}
+	defer func() {
+		for _, hm := range managers {
+			_ = hm.Close()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, shells*commandsPerShell)
+	for i, hm := range managers {
+		wg.Add(1)
+		go func(shell int, hm *HistoryManager) {
+			defer wg.Done()
+			sessionID := fmt.Sprintf("session-%d", shell)
+			for j := 0; j < commandsPerShell; j++ {
+				entry, err := hm.StartCommand(fmt.Sprintf("echo %d-%d", shell, j), "/", sessionID)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if _, err := hm.FinishCommand(entry, j%2); err != nil {
+					errs <- err
+				}
+			}
+		}(i, hm)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("history write failed: %v", err)
+	}
+
+	entries, err := managers[0].GetAllEntries()
+	require.NoError(t, err)
+	assert.Len(t, entries, shells*commandsPerShell)
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		assert.True(t, entry.ExitCode.Valid, "entry %q was never finished", entry.Command)
+		assert.False(t, seen[entry.Command], "duplicate entry %q", entry.Command)
+		seen[entry.Command] = true
+	}
+}
@@ -0,0 +1,172 @@
+package httpreq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const (
+	requestTimeout  = 30 * time.Second
+	maxResponseBody = 10 << 20
+	usage           = "Usage: req [-v] [METHOD] URL [Header:value] [param==value] [field=value] [field:=json]\n" +
+		"       req --history\n" +
+		"       req --replay [n]"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+	failureStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+)
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// NewReqCommandHandler creates an ExecHandler for the req builtin. Every
+// exchange is recorded in history so it can be listed, replayed and handed
+// to the #? magic fix when it fails.
+func NewReqCommandHandler(history *History) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "req" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			verbose := false
+			args = args[1:]
+
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				switch args[0] {
+				case "-h", "--help":
+					fmt.Fprintln(hc.Stdout, usage)
+					return nil
+				case "-v", "--verbose":
+					verbose = true
+					args = args[1:]
+				case "-l", "--history":
+					for i, exchange := range history.List() {
+						fmt.Fprintf(hc.Stdout, "%3d  %s\n", i+1, exchange.Summary())
+					}
+					return nil
+				case "-r", "--replay":
+					n := 1
+					if len(args) > 1 {
+						parsed, err := strconv.Atoi(args[1])
+						if err != nil || parsed < 1 {
+							fmt.Fprintf(hc.Stderr, "req: invalid history entry %q\n", args[1])
+							return interp.NewExitStatus(2)
+						}
+						n = parsed
+					}
+					previous, ok := history.Get(n)
+					if !ok {
+						fmt.Fprintf(hc.Stderr, "req: no request %d in history\n", n)
+						return interp.NewExitStatus(1)
+					}
+					return send(ctx, hc, history, previous.Request, verbose)
+				default:
+					fmt.Fprintf(hc.Stderr, "req: unknown option %s\n%s\n", args[0], usage)
+					return interp.NewExitStatus(2)
+				}
+			}
+
+			request, err := ParseRequest(args)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "req: %s\n%s\n", err, usage)
+				return interp.NewExitStatus(2)
+			}
+			return send(ctx, hc, history, *request, verbose)
+		}
+	}
+}
+
+// send performs request, records the exchange and prints the response. The
+// status line and headers go to stderr so that stdout carries only the body
+// and can be piped.
+func send(ctx context.Context, hc interp.HandlerContext, history *History, request Request, verbose bool) error {
+	exchange := Exchange{Request: request, Time: time.Now()}
+	defer func() { history.Add(exchange) }()
+
+	httpReq, err := http.NewRequestWithContext(ctx, request.Method, request.URL, strings.NewReader(request.Body))
+	if err != nil {
+		exchange.Err = err.Error()
+		fmt.Fprintf(hc.Stderr, "req: %s\n", err)
+		return interp.NewExitStatus(1)
+	}
+	httpReq.Header = request.Headers.Clone()
+
+	if verbose {
+		fmt.Fprintln(hc.Stderr, headerStyle.Render(fmt.Sprintf("%s %s", request.Method, request.URL)))
+		for _, line := range headerLines(request.Headers, false) {
+			fmt.Fprintln(hc.Stderr, headerStyle.Render(line))
+		}
+		fmt.Fprintln(hc.Stderr)
+	}
+
+	resp, err := httpClient.Do(httpReq)
+	exchange.Duration = time.Since(exchange.Time)
+	if err != nil {
+		exchange.Err = err.Error()
+		fmt.Fprintln(hc.Stderr, failureStyle.Render("req: "+err.Error()))
+		return interp.NewExitStatus(1)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		exchange.Err = err.Error()
+		fmt.Fprintln(hc.Stderr, failureStyle.Render("req: reading response: "+err.Error()))
+		return interp.NewExitStatus(1)
+	}
+	exchange.StatusCode = resp.StatusCode
+	exchange.Status = resp.Status
+	exchange.Headers = resp.Header
+	exchange.Body = string(body)
+
+	statusLine := fmt.Sprintf("%s %s (%s)", resp.Proto, resp.Status, exchange.Duration.Round(time.Millisecond))
+	if exchange.Failed() {
+		fmt.Fprintln(hc.Stderr, failureStyle.Render(statusLine))
+	} else {
+		fmt.Fprintln(hc.Stderr, successStyle.Render(statusLine))
+	}
+	if verbose {
+		for _, line := range headerLines(resp.Header, false) {
+			fmt.Fprintln(hc.Stderr, headerStyle.Render(line))
+		}
+		fmt.Fprintln(hc.Stderr)
+	}
+
+	if output := formatBody(body); output != "" {
+		fmt.Fprintln(hc.Stdout, output)
+	}
+
+	if exchange.Failed() {
+		return interp.NewExitStatus(1)
+	}
+	return nil
+}
+
+// formatBody pretty-prints JSON bodies and returns others unchanged.
+func formatBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if json.Valid(trimmed) {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, trimmed, "", "  "); err == nil {
+			return pretty.String()
+		}
+	}
+	return strings.TrimRight(string(body), "\n")
+}
@@ -0,0 +1,164 @@
+package httpreq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runReq(t *testing.T, history *History, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewReqCommandHandler(history)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"method": r.Method,
+				"query":  r.URL.RawQuery,
+				"token":  r.Header.Get("X-Token"),
+				"body":   string(body),
+			})
+		case "/text":
+			_, _ = w.Write([]byte("plain text\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"name is required"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReqCommandPrettyPrintsJSON(t *testing.T) {
+	server := newTestServer(t)
+	history := NewHistory(5)
+
+	out, stderr, err := runReq(t, history, "req POST "+server.URL+"/echo X-Token:abc q==1 name=bob")
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "200 OK")
+	assert.Equal(t, `{
+  "body": "{\"name\": \"bob\"}",
+  "method": "POST",
+  "query": "q=1",
+  "token": "abc"
+}
+`, out)
+
+	out, _, err = runReq(t, history, "req "+server.URL+"/text")
+	require.NoError(t, err)
+	assert.Equal(t, "plain text\n", out)
+
+	assert.Len(t, history.List(), 2)
+}
+
+func TestReqCommandFailureIsRecorded(t *testing.T) {
+	server := newTestServer(t)
+	history := NewHistory(5)
+
+	out, stderr, err := runReq(t, history, "req POST "+server.URL+"/items Authorization:secret price:=3")
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), status)
+	assert.Contains(t, stderr, "422 Unprocessable Entity")
+	assert.Contains(t, out, `"error": "name is required"`)
+
+	failure, ok := history.LastFailure()
+	require.True(t, ok)
+	description := failure.Describe()
+	assert.Contains(t, description, "POST "+server.URL+"/items")
+	assert.Contains(t, description, "Authorization: <redacted>")
+	assert.NotContains(t, description, "secret")
+	assert.Contains(t, description, `{"price": 3}`)
+	assert.Contains(t, description, `{"error":"name is required"}`)
+}
+
+func TestReqCommandConnectionError(t *testing.T) {
+	server := newTestServer(t)
+	url := server.URL
+	server.Close()
+
+	history := NewHistory(5)
+	_, stderr, err := runReq(t, history, "req "+url)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "req: ")
+
+	failure, ok := history.LastFailure()
+	require.True(t, ok)
+	assert.Contains(t, failure.Describe(), "No response:")
+}
+
+func TestReqCommandHistoryAndReplay(t *testing.T) {
+	server := newTestServer(t)
+	history := NewHistory(5)
+
+	_, _, err := runReq(t, history, "req "+server.URL+"/echo first==1")
+	require.NoError(t, err)
+	_, _, err = runReq(t, history, "req "+server.URL+"/text")
+	require.NoError(t, err)
+
+	out, _, err := runReq(t, history, "req --history")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "GET "+server.URL+"/text → 200 OK")
+	assert.Contains(t, lines[1], "first=1")
+
+	out, _, err = runReq(t, history, "req --replay 2")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"query": "first=1"`)
+
+	_, stderr, err := runReq(t, history, "req --replay 9")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "no request 9 in history")
+}
+
+func TestReqCommandUsageErrors(t *testing.T) {
+	_, stderr, err := runReq(t, NewHistory(5), "req")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "missing URL")
+
+	out, _, err := runReq(t, NewHistory(5), "req --help")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Usage: req")
+}
+
+func TestHistoryKeepsMostRecent(t *testing.T) {
+	history := NewHistory(2)
+	for _, url := range []string{"a", "b", "c"} {
+		history.Add(Exchange{Request: Request{Method: "GET", URL: url}, StatusCode: 200})
+	}
+	list := history.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "c", list[0].Request.URL)
+	assert.Equal(t, "b", list[1].Request.URL)
+
+	_, ok := history.LastFailure()
+	assert.False(t, ok)
+}
@@ -0,0 +1,166 @@
+package httpreq
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHistorySize is how many exchanges DefaultHistory keeps.
+	defaultHistorySize = 20
+	// maxDescribedBody limits how much of a body Describe includes.
+	maxDescribedBody = 4096
+)
+
+// sensitiveHeaders are redacted when an exchange is described, so credentials
+// are not sent to the LLM along with the request context.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// Exchange is a completed req invocation: the request that was sent and the
+// response or transport error it produced.
+type Exchange struct {
+	Request    Request
+	StatusCode int
+	Status     string
+	Headers    http.Header
+	Body       string
+	Err        string
+	Duration   time.Duration
+	Time       time.Time
+}
+
+// Failed reports whether the request could not be sent or the server answered
+// with a non-2xx status.
+func (e Exchange) Failed() bool {
+	return e.Err != "" || e.StatusCode < 200 || e.StatusCode >= 300
+}
+
+// Summary returns a one-line description such as "GET https://x → 404 Not Found".
+func (e Exchange) Summary() string {
+	result := e.Status
+	if e.Err != "" {
+		result = "error: " + e.Err
+	}
+	return fmt.Sprintf("%s %s → %s (%s)", e.Request.Method, e.Request.URL, result, e.Duration.Round(time.Millisecond))
+}
+
+// Describe returns the full request and response for use as LLM context, with
+// credentials redacted and bodies truncated.
+func (e Exchange) Describe() string {
+	var sb strings.Builder
+	sb.WriteString("Request:\n")
+	fmt.Fprintf(&sb, "%s %s\n", e.Request.Method, e.Request.URL)
+	writeHeaders(&sb, e.Request.Headers)
+	if e.Request.Body != "" {
+		sb.WriteString("\n" + truncate(e.Request.Body) + "\n")
+	}
+
+	sb.WriteString("\nResponse:\n")
+	if e.Err != "" {
+		fmt.Fprintf(&sb, "No response: %s\n", e.Err)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%s (%s)\n", e.Status, e.Duration.Round(time.Millisecond))
+	writeHeaders(&sb, e.Headers)
+	if e.Body != "" {
+		sb.WriteString("\n" + truncate(e.Body) + "\n")
+	}
+	return sb.String()
+}
+
+func writeHeaders(sb *strings.Builder, headers http.Header) {
+	for _, line := range headerLines(headers, true) {
+		sb.WriteString(line + "\n")
+	}
+}
+
+// headerLines formats headers as sorted "Key: value" lines, optionally
+// redacting credentials.
+func headerLines(headers http.Header, redact bool) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			if redact && sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+				value = "<redacted>"
+			}
+			lines = append(lines, key+": "+value)
+		}
+	}
+	return lines
+}
+
+func truncate(s string) string {
+	if len(s) <= maxDescribedBody {
+		return s
+	}
+	return s[:maxDescribedBody] + fmt.Sprintf("\n... (%d more bytes)", len(s)-maxDescribedBody)
+}
+
+// History keeps the most recent exchanges of the session, newest first.
+type History struct {
+	mu        sync.Mutex
+	size      int
+	exchanges []Exchange
+}
+
+// DefaultHistory is the session-wide history used by the req builtin and the
+// magic fix.
+var DefaultHistory = NewHistory(defaultHistorySize)
+
+// NewHistory creates a History that keeps at most size exchanges.
+func NewHistory(size int) *History {
+	return &History{size: size}
+}
+
+// Add records an exchange as the most recent one.
+func (h *History) Add(e Exchange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.exchanges = append([]Exchange{e}, h.exchanges...)
+	if len(h.exchanges) > h.size {
+		h.exchanges = h.exchanges[:h.size]
+	}
+}
+
+// Get returns the n-th most recent exchange, starting at 1.
+func (h *History) Get(n int) (Exchange, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n < 1 || n > len(h.exchanges) {
+		return Exchange{}, false
+	}
+	return h.exchanges[n-1], true
+}
+
+// List returns all recorded exchanges, newest first.
+func (h *History) List() []Exchange {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Exchange(nil), h.exchanges...)
+}
+
+// LastFailure returns the most recent exchange if it failed.
+func (h *History) LastFailure() (Exchange, bool) {
+	last, ok := h.Get(1)
+	if !ok || !last.Failed() {
+		return Exchange{}, false
+	}
+	return last, true
+}
@@ -0,0 +1,156 @@
+// Package httpreq implements the req builtin, an httpie-style HTTP client:
+//
+//	req GET https://api.example.com/users page==2 Authorization:"Bearer $TOKEN"
+//	req POST :8080/items name=widget price:=9.99 tags:='["a","b"]'
+//
+// JSON responses are pretty-printed, recent requests are kept for replay and
+// the most recent failed exchange is made available to the #? magic fix.
+package httpreq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Request describes an HTTP request built from req arguments.
+type Request struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    string
+}
+
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// item separators in the order they are tried at each position; longer
+// separators come first so that "a:=1" is not read as header "a" with value "=1".
+var separators = []string{":=", "==", "=", ":"}
+
+// ParseRequest builds a Request from req arguments: an optional method, the URL
+// and any number of request items:
+//
+//	Header:value   request header
+//	param==value   query string parameter
+//	field=value    JSON string field
+//	field:=json    raw JSON field (numbers, booleans, arrays, objects)
+//
+// The method defaults to GET, or POST when the request has a body.
+func ParseRequest(args []string) (*Request, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing URL")
+	}
+
+	req := &Request{Headers: http.Header{}}
+	if methods[strings.ToUpper(args[0])] && len(args) > 1 {
+		req.Method = strings.ToUpper(args[0])
+		args = args[1:]
+	}
+
+	target, err := normalizeURL(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	query := target.Query()
+	fields := make(map[string]json.RawMessage)
+	var fieldOrder []string
+	for _, item := range args[1:] {
+		key, sep, value, ok := splitItem(item)
+		if !ok {
+			return nil, fmt.Errorf("invalid request item %q", item)
+		}
+		switch sep {
+		case ":":
+			req.Headers.Add(key, value)
+		case "==":
+			query.Add(key, value)
+		case "=", ":=":
+			raw := json.RawMessage(value)
+			if sep == "=" {
+				raw, _ = json.Marshal(value)
+			} else if !json.Valid(raw) {
+				return nil, fmt.Errorf("invalid JSON in %q", item)
+			}
+			if _, exists := fields[key]; !exists {
+				fieldOrder = append(fieldOrder, key)
+			}
+			fields[key] = raw
+		}
+	}
+	target.RawQuery = query.Encode()
+	req.URL = target.String()
+
+	if len(fields) > 0 {
+		req.Body = encodeFields(fields, fieldOrder)
+		if req.Headers.Get("Content-Type") == "" {
+			req.Headers.Set("Content-Type", "application/json")
+		}
+	}
+	if req.Headers.Get("Accept") == "" {
+		req.Headers.Set("Accept", "application/json, */*;q=0.5")
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+		if req.Body != "" {
+			req.Method = http.MethodPost
+		}
+	}
+	return req, nil
+}
+
+// normalizeURL expands the shorthands httpie accepts: ":8080/path" for
+// localhost and a missing scheme for plain http.
+func normalizeURL(raw string) (*url.URL, error) {
+	switch {
+	case strings.HasPrefix(raw, ":"):
+		raw = "http://localhost" + raw
+	case !strings.Contains(raw, "://"):
+		raw = "http://" + raw
+	}
+	target, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: missing host", raw)
+	}
+	return target, nil
+}
+
+// splitItem splits a request item at its first separator.
+func splitItem(item string) (key, sep, value string, ok bool) {
+	for i := 0; i < len(item); i++ {
+		for _, candidate := range separators {
+			if strings.HasPrefix(item[i:], candidate) {
+				if i == 0 {
+					return "", "", "", false
+				}
+				return item[:i], candidate, item[i+len(candidate):], true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+// encodeFields writes fields as a JSON object, keeping the order they were given in.
+func encodeFields(fields map[string]json.RawMessage, order []string) string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range order {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		encodedKey, _ := json.Marshal(key)
+		sb.Write(encodedKey)
+		sb.WriteString(": ")
+		sb.Write(fields[key])
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
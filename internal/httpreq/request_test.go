@@ -0,0 +1,65 @@
+package httpreq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequestItems(t *testing.T) {
+	req, err := ParseRequest([]string{
+		"https://api.example.com/users?sort=name",
+		"Authorization:Bearer a=b",
+		"page==2",
+		"name=widget",
+		"price:=9.99",
+		"tags:=[\"a\",\"b\"]",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "https://api.example.com/users?page=2&sort=name", req.URL)
+	assert.Equal(t, "Bearer a=b", req.Headers.Get("Authorization"))
+	assert.Equal(t, "application/json", req.Headers.Get("Content-Type"))
+	assert.Equal(t, `{"name": "widget", "price": 9.99, "tags": ["a","b"]}`, req.Body)
+}
+
+func TestParseRequestMethodAndDefaults(t *testing.T) {
+	req, err := ParseRequest([]string{"example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "GET", req.Method)
+	assert.Equal(t, "http://example.com", req.URL)
+	assert.Empty(t, req.Body)
+	assert.Contains(t, req.Headers.Get("Accept"), "application/json")
+
+	req, err = ParseRequest([]string{"delete", ":8080/items/1"})
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE", req.Method)
+	assert.Equal(t, "http://localhost:8080/items/1", req.URL)
+
+	// A lone method-like word is treated as the URL
+	req, err = ParseRequest([]string{"get"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://get", req.URL)
+}
+
+func TestParseRequestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "no URL", args: nil, err: "missing URL"},
+		{name: "bad item", args: []string{"example.com", "oops"}, err: `invalid request item "oops"`},
+		{name: "empty key", args: []string{"example.com", "=value"}, err: `invalid request item "=value"`},
+		{name: "bad JSON", args: []string{"example.com", "n:={"}, err: "invalid JSON"},
+		{name: "no host", args: []string{"http://"}, err: "missing host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRequest(tt.args)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
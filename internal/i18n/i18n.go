@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLocale is the locale whose catalog every other catalog falls back to.
+const DefaultLocale = "en"
+
+// Catalogs contains the embedded message catalogs, one YAML file per locale
+// named after its language tag (e.g. es.yaml or pt_BR.yaml). Each file is a
+// flat map from message key to text, where the text may contain fmt verbs.
+//
+//go:embed locales/*.yaml
+var Catalogs embed.FS
+
+var (
+	catalogs     map[string]map[string]string
+	catalogsOnce sync.Once
+	catalogsErr  error
+
+	currentLocale   = DefaultLocale
+	currentLocaleMu sync.RWMutex
+)
+
+// loadCatalogs parses all embedded catalogs once.
+func loadCatalogs() (map[string]map[string]string, error) {
+	catalogsOnce.Do(func() {
+		catalogs = make(map[string]map[string]string)
+		entries, err := Catalogs.ReadDir("locales")
+		if err != nil {
+			catalogsErr = err
+			return
+		}
+		for _, entry := range entries {
+			data, err := Catalogs.ReadFile(path.Join("locales", entry.Name()))
+			if err != nil {
+				catalogsErr = err
+				return
+			}
+			messages := make(map[string]string)
+			if err := yaml.Unmarshal(data, &messages); err != nil {
+				catalogsErr = fmt.Errorf("failed to parse catalog %s: %w", entry.Name(), err)
+				return
+			}
+			catalogs[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = messages
+		}
+	})
+	return catalogs, catalogsErr
+}
+
+// Available returns the locales that have a message catalog, sorted.
+func Available() []string {
+	loaded, _ := loadCatalogs()
+	locales := make([]string, 0, len(loaded))
+	for locale := range loaded {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// DetectLocale picks the locale to use from the environment, looking at
+// BISH_LANG first and then the POSIX LC_ALL, LC_MESSAGES and LANG variables.
+// A value such as "pt_BR.UTF-8" matches a pt_BR catalog if there is one and
+// a pt catalog otherwise. DefaultLocale is returned if nothing matches.
+func DetectLocale(getenv func(string) string) string {
+	for _, name := range []string{"BISH_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		// The first variable that is set decides, as in POSIX
+		if locale, ok := matchLocale(value); ok {
+			return locale
+		}
+		return DefaultLocale
+	}
+	return DefaultLocale
+}
+
+// matchLocale maps a POSIX locale name to an available catalog.
+func matchLocale(value string) (string, bool) {
+	// Strip the codeset and modifier: ja_JP.UTF-8@euro -> ja_JP
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	value = strings.ReplaceAll(value, "-", "_")
+	if value == "" || value == "C" || value == "POSIX" {
+		return DefaultLocale, true
+	}
+
+	loaded, _ := loadCatalogs()
+	if _, ok := loaded[value]; ok {
+		return value, true
+	}
+	language, _, _ := strings.Cut(value, "_")
+	language = strings.ToLower(language)
+	if _, ok := loaded[language]; ok {
+		return language, true
+	}
+	return "", false
+}
+
+// SetLocale selects the catalog used by T. Unknown locales fall back to
+// DefaultLocale.
+func SetLocale(locale string) {
+	if matched, ok := matchLocale(locale); ok {
+		locale = matched
+	} else {
+		locale = DefaultLocale
+	}
+
+	currentLocaleMu.Lock()
+	defer currentLocaleMu.Unlock()
+	currentLocale = locale
+}
+
+// Locale returns the currently selected locale.
+func Locale() string {
+	currentLocaleMu.RLock()
+	defer currentLocaleMu.RUnlock()
+	return currentLocale
+}
+
+// T returns the message for key in the current locale, formatted with args
+// using fmt.Sprintf. Messages missing from the current catalog fall back to
+// DefaultLocale, and unknown keys are returned as-is so they are easy to spot.
+func T(key string, args ...any) string {
+	message := lookup(Locale(), key)
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+func lookup(locale, key string) string {
+	loaded, _ := loadCatalogs()
+	if message, ok := loaded[locale][key]; ok {
+		return message
+	}
+	if message, ok := loaded[DefaultLocale][key]; ok {
+		return message
+	}
+	return key
+}
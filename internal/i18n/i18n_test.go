@@ -0,0 +1,133 @@
+package i18n
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{name: "nothing set", env: nil, expected: "en"},
+		{name: "LANG with codeset", env: map[string]string{"LANG": "es_ES.UTF-8"}, expected: "es"},
+		{name: "LANG with modifier", env: map[string]string{"LANG": "es_ES@euro"}, expected: "es"},
+		{name: "C locale", env: map[string]string{"LANG": "C"}, expected: "en"},
+		{name: "unknown language", env: map[string]string{"LANG": "xx_XX.UTF-8"}, expected: "en"},
+		{name: "LC_ALL overrides LANG", env: map[string]string{"LC_ALL": "C.UTF-8", "LANG": "es_MX.UTF-8"}, expected: "en"},
+		{name: "LC_MESSAGES overrides LANG", env: map[string]string{"LC_MESSAGES": "es_AR.UTF-8", "LANG": "en_US.UTF-8"}, expected: "es"},
+		{name: "BISH_LANG overrides everything", env: map[string]string{"BISH_LANG": "es", "LC_ALL": "en_US.UTF-8"}, expected: "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectLocale(envFrom(tt.env)))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	SetLocale("es_ES")
+	assert.Equal(t, "es", Locale())
+	assert.Equal(t, "Menú de configuración", T("config.title"))
+	assert.Equal(t, "Actual: 42", T("config.current", "42"))
+
+	SetLocale("en")
+	assert.Equal(t, "Config Menu", T("config.title"))
+
+	SetLocale("xx")
+	assert.Equal(t, DefaultLocale, Locale())
+}
+
+func TestTranslateFallsBack(t *testing.T) {
+	assert.Equal(t, "English only", lookupIn(t, map[string]map[string]string{
+		"en": {"only.en": "English only"},
+		"es": {},
+	}, "es", "only.en"))
+	assert.Equal(t, "no.such.key", T("no.such.key"))
+}
+
+// lookupIn runs lookup against temporary catalogs.
+func lookupIn(t *testing.T, temp map[string]map[string]string, locale, key string) string {
+	t.Helper()
+	_, err := loadCatalogs()
+	require.NoError(t, err)
+
+	saved := catalogs
+	catalogs = temp
+	defer func() { catalogs = saved }()
+	return lookup(locale, key)
+}
+
+var placeholderRe = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+// TestCatalogsAreConsistent checks every catalog against the English reference
+// so that translations cannot introduce unknown keys or break placeholders.
+func TestCatalogsAreConsistent(t *testing.T) {
+	loaded, err := loadCatalogs()
+	require.NoError(t, err)
+	require.Contains(t, Available(), DefaultLocale)
+	require.Contains(t, Available(), "es")
+
+	reference := loaded[DefaultLocale]
+	for locale, messages := range loaded {
+		for key, message := range messages {
+			englishMessage, ok := reference[key]
+			if !assert.True(t, ok, "%s.yaml defines unknown key %q", locale, key) {
+				continue
+			}
+			assert.Equal(t,
+				placeholderRe.FindAllString(englishMessage, -1),
+				placeholderRe.FindAllString(message, -1),
+				"%s.yaml has different placeholders for %q", locale, key)
+		}
+	}
+}
+
+var keyUseRe = regexp.MustCompile(`i18n\.T\("([^"]+)"[,)]`)
+
+// TestUsedKeysAreDefined checks that every key bish looks up is in the
+// English reference, so that none is shown to users as a raw key.
+func TestUsedKeysAreDefined(t *testing.T) {
+	loaded, err := loadCatalogs()
+	require.NoError(t, err)
+
+	used := 0
+	err = filepath.WalkDir("../..", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), ".") && path != "../.." {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range keyUseRe.FindAllStringSubmatch(string(source), -1) {
+			used++
+			assert.Contains(t, loaded[DefaultLocale], match[1], "%s uses an undefined key", path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Greater(t, used, 100)
+}
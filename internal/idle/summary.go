@@ -6,18 +6,24 @@ import (
 	"strings"
 	"time"
 
+	"github.com/robottwo/bishop/internal/environment"
 	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/todo"
 	"github.com/robottwo/bishop/internal/utils"
 	openai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"mvdan.cc/sh/v3/interp"
 )
 
+// maxSummaryTodos is how many pending TODOs are named in the summary.
+const maxSummaryTodos = 3
+
 // SummaryGenerator generates idle summaries using the slow LLM model
 type SummaryGenerator struct {
 	runner         *interp.Runner
 	historyManager *history.HistoryManager
 	logger         *zap.Logger
+	todoStore      *todo.Store
 }
 
 // NewSummaryGenerator creates a new idle summary generator
@@ -29,9 +35,68 @@ func NewSummaryGenerator(runner *interp.Runner, historyManager *history.HistoryM
 	}
 }
 
+// SetTodoStore makes the summary list the pending TODOs of the current
+// project. A nil store leaves them out.
+func (g *SummaryGenerator) SetTodoStore(store *todo.Store) {
+	g.todoStore = store
+}
+
 // GenerateSummary generates a 1-sentence summary of what the user was doing
-// based on commands from the last 5 minutes
+// based on commands from the last 5 minutes, followed by the pending TODOs of
+// the current project if there are any
 func (g *SummaryGenerator) GenerateSummary(ctx context.Context) (string, error) {
+	summary, err := g.generateActivitySummary(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	todos := g.pendingTodos()
+	switch {
+	case todos == "":
+		return summary, nil
+	case summary == "":
+		return todos, nil
+	}
+	return summary + "\n" + todos, nil
+}
+
+// pendingTodos describes the pending TODOs of the current project, or
+// returns "" if there are none.
+func (g *SummaryGenerator) pendingTodos() string {
+	if g.todoStore == nil || g.runner == nil {
+		return ""
+	}
+	todos, err := g.todoStore.List(todo.ProjectFor(environment.GetPwd(g.runner)), false)
+	if err != nil {
+		g.logger.Debug("failed to list pending TODOs for idle summary", zap.Error(err))
+		return ""
+	}
+	return formatPendingTodos(todos)
+}
+
+// formatPendingTodos lists up to maxSummaryTodos TODOs on one line.
+func formatPendingTodos(todos []todo.Todo) string {
+	if len(todos) == 0 {
+		return ""
+	}
+	var texts []string
+	for _, t := range todos[:min(len(todos), maxSummaryTodos)] {
+		texts = append(texts, t.Text)
+	}
+	line := fmt.Sprintf("📝 %d pending TODO", len(todos))
+	if len(todos) > 1 {
+		line += "s"
+	}
+	line += ": " + strings.Join(texts, "; ")
+	if len(todos) > maxSummaryTodos {
+		line += fmt.Sprintf(" (+%d more, see todo list)", len(todos)-maxSummaryTodos)
+	}
+	return line
+}
+
+// generateActivitySummary summarizes the commands from the last 5 minutes, or
+// returns "" if there were none.
+func (g *SummaryGenerator) generateActivitySummary(ctx context.Context) (string, error) {
 	// Get commands from the last 5 minutes
 	since := time.Now().Add(-5 * time.Minute)
 	entries, err := g.historyManager.GetEntriesSince(since)
@@ -3,6 +3,7 @@ package idle
 import (
 	"testing"
 
+	"github.com/robottwo/bishop/internal/todo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -32,3 +33,11 @@ func TestSummaryGenerator_Struct(t *testing.T) {
 	assert.Nil(t, generator.historyManager)
 	assert.NotNil(t, generator.logger)
 }
+
+func TestFormatPendingTodos(t *testing.T) {
+	assert.Equal(t, "", formatPendingTodos(nil))
+	assert.Equal(t, "📝 1 pending TODO: rotate prod certs", formatPendingTodos([]todo.Todo{{Text: "rotate prod certs"}}))
+
+	todos := []todo.Todo{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}, {Text: "e"}}
+	assert.Equal(t, "📝 5 pending TODOs: a; b; c (+2 more, see todo list)", formatPendingTodos(todos))
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// NewJobsCommandHandler creates an ExecHandler for the jobs, fg and bg
+// builtins, which list the jobs in t and resume them in the foreground or
+// the background. fg and bg arrive as bish_fg and bish_bg, see bash.Rewrite.
+func NewJobsCommandHandler(t *Table) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+			hc := interp.HandlerCtx(ctx)
+			switch args[0] {
+			case "jobs":
+				return t.list(hc, args[1:])
+			case "bish_fg":
+				if len(args) > 2 {
+					fmt.Fprintln(hc.Stderr, "Usage: fg [JOB]")
+					return interp.NewExitStatus(2)
+				}
+				t.mu.Lock()
+				job, err := t.find(specArg(args))
+				t.mu.Unlock()
+				if err != nil {
+					fmt.Fprintf(hc.Stderr, "fg: %v\n", err)
+					return interp.NewExitStatus(1)
+				}
+				fmt.Fprintln(hc.Stdout, job.Command)
+				return t.resume(job, hc.Stderr)
+			case "bish_bg":
+				return t.background(hc, args[1:])
+			default:
+				return next(ctx, args)
+			}
+		}
+	}
+}
+
+func specArg(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	return args[1]
+}
+
+// list prints the jobs, with their process IDs for -l or only those for -p.
+func (t *Table) list(hc interp.HandlerContext, args []string) error {
+	pids, only := false, false
+	for _, arg := range args {
+		switch arg {
+		case "-l":
+			pids = true
+		case "-p":
+			only = true
+		default:
+			fmt.Fprintln(hc.Stderr, "Usage: jobs [-l | -p]")
+			return interp.NewExitStatus(2)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := append([]*Job(nil), t.jobs...)
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	for _, job := range list {
+		switch {
+		case only:
+			if groups := job.groups(); len(groups) > 0 {
+				fmt.Fprintln(hc.Stdout, groups[0])
+			}
+		default:
+			fmt.Fprintln(hc.Stdout, t.describe(job, pids))
+		}
+	}
+	return nil
+}
+
+// background continues the stopped jobs that specs name, or the current job,
+// without waiting for them, as bg does.
+func (t *Table) background(hc interp.HandlerContext, specs []string) error {
+	if len(specs) == 0 {
+		specs = []string{""}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := 0
+	for _, spec := range specs {
+		job, err := t.find(spec)
+		if err != nil {
+			fmt.Fprintf(hc.Stderr, "bg: %v\n", err)
+			status = 1
+			continue
+		}
+		if job.state() != Stopped {
+			fmt.Fprintf(hc.Stderr, "bg: job %d already in background\n", job.ID)
+			continue
+		}
+		t.cont(job)
+		t.add(job)
+		fmt.Fprintf(hc.Stdout, "[%d]%s %s &\n", job.ID, t.mark(job), job.Command)
+	}
+	return exitStatus(status)
+}
+
+// Detachable reports whether stmt, as in `cmd &`, can be run as a
+// background job: a single external command, rather than a function or a
+// compound command that the interpreter would have to run alongside the
+// shell.
+func Detachable(stmt *syntax.Stmt, runner *interp.Runner) bool {
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || !stmt.Background || len(call.Args) == 0 || len(call.Assigns) > 0 || !supported {
+		return false
+	}
+	name := call.Args[0].Lit()
+	if name == "" || runner.Funcs[name] != nil {
+		return false
+	}
+	_, err := interp.LookPathDir(runner.Dir, runner.Env, name)
+	return err == nil
+}
@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// NewExecHandler creates an ExecHandler that runs the external commands of
+// jobs started with Foreground or Background under job control. Other
+// commands, and all of them where job control is not supported, go to next.
+func NewExecHandler(t *Table) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if !Controlled(ctx) || len(args) == 0 {
+				return next(ctx, args)
+			}
+			job := jobFrom(ctx)
+			hc := interp.HandlerCtx(ctx)
+			stdout := stdoutFrom(ctx, hc.Stdout)
+			path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+			if err != nil {
+				// Let the next handler report it
+				return next(ctx, args)
+			}
+
+			newCmd := func(foreground bool) *exec.Cmd {
+				cmd := &exec.Cmd{
+					Path:   path,
+					Args:   args,
+					Env:    execEnv(hc.Env),
+					Dir:    hc.Dir,
+					Stdin:  hc.Stdin,
+					Stdout: stdout,
+					Stderr: hc.Stderr,
+				}
+				if !foreground {
+					// The shell's copies of a command's output end with the
+					// command line, so a job outliving it writes to the
+					// terminal instead
+					if _, ok := stdout.(*os.File); !ok {
+						cmd.Stdout = os.Stdout
+					}
+					if _, ok := hc.Stderr.(*os.File); !ok {
+						cmd.Stderr = os.Stderr
+					}
+				}
+				return cmd
+			}
+			p, foreground, err := t.start(job, newCmd)
+			if err != nil {
+				fmt.Fprintln(hc.Stderr, err)
+				return interp.NewExitStatus(127)
+			}
+			if !foreground {
+				fmt.Fprintf(hc.Stderr, "[%d] %d\n", job.ID, p.pid)
+				return nil
+			}
+			return t.wait(job, p, hc.Stderr)
+		}
+	}
+}
+
+// start starts a process for job, in the job's process group, and hands it
+// the terminal if the shell waits for the job.
+func (t *Table) start(job *Job, newCmd func(foreground bool) *exec.Cmd) (*process, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	foreground := job.foreground
+	var tty *terminal
+	if foreground {
+		tty = t.terminal()
+	}
+	if tty != nil && len(job.procs) == 0 {
+		tty.save()
+	}
+	pgid := 0
+	if groups := job.groups(); len(groups) > 0 {
+		pgid = groups[0]
+	}
+
+	cmd := newCmd(foreground)
+	cmd.SysProcAttr = processAttr(pgid, tty)
+	err := cmd.Start()
+	if err != nil && pgid != 0 {
+		// The group cannot be joined once all of its processes have exited,
+		// as the start of a pipe may have
+		pgid = 0
+		cmd = newCmd(foreground)
+		cmd.SysProcAttr = processAttr(pgid, tty)
+		err = cmd.Start()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p := &process{cmd: cmd, pid: cmd.Process.Pid, pgid: pgid}
+	if p.pgid == 0 {
+		p.pgid = p.pid
+	}
+	job.procs = append(job.procs, p)
+	if !foreground {
+		t.add(job)
+	}
+	go t.watch(job, p)
+	return p, foreground, nil
+}
+
+// wait waits for p, a process of a foreground job, to exit or stop.
+func (t *Table) wait(job *Job, p *process, stderr io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for p.state == Running {
+		t.changed.Wait()
+	}
+	t.release(job, stderr)
+	if p.state == Stopped {
+		return interp.NewExitStatus(StoppedStatus)
+	}
+	return exitStatus(p.status)
+}
+
+// resume continues job in the foreground and waits for it to exit or stop
+// again, as fg does.
+func (t *Table) resume(job *Job, stderr io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job.foreground = true
+	if tty := t.terminal(); tty != nil {
+		tty.hand(job)
+	}
+	t.cont(job)
+	for job.running() {
+		t.changed.Wait()
+	}
+	stopped := job.state() == Stopped
+	status := job.status()
+	t.release(job, stderr)
+	if stopped {
+		return interp.NewExitStatus(StoppedStatus)
+	}
+	return exitStatus(status)
+}
+
+// cont sends SIGCONT to the stopped processes of job. t.mu must be held.
+func (t *Table) cont(job *Job) {
+	for _, p := range job.procs {
+		if p.state == Stopped {
+			// Until the process is seen to continue, waiting for the job
+			// would end at once
+			p.state = Running
+		}
+	}
+	for _, pgid := range job.groups() {
+		continueGroup(pgid)
+	}
+}
+
+func exitStatus(status int) error {
+	if status == 0 {
+		return nil
+	}
+	return interp.NewExitStatus(uint8(status))
+}
+
+func execEnv(env expand.Environ) []string {
+	var list []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		if vr.IsSet() && vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+		return true
+	})
+	return list
+}
@@ -0,0 +1,365 @@
+// Package jobs gives commands run at the prompt job control, as in bash:
+// each runs in a process group of its own that Ctrl+Z suspends, `cmd &`
+// runs it in the background, and the jobs, fg and bg builtins list and
+// resume them.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// State is what a job or one of its processes is doing.
+type State int
+
+const (
+	Running State = iota
+	Stopped
+	Done
+)
+
+func (s State) String() string {
+	switch s {
+	case Stopped:
+		return "Stopped"
+	case Done:
+		return "Done"
+	default:
+		return "Running"
+	}
+}
+
+// process is one program started for a job, such as one side of a pipe.
+type process struct {
+	cmd    *exec.Cmd
+	pid    int
+	pgid   int
+	state  State
+	status int
+}
+
+// Job is a command line typed at the prompt with the processes it started.
+// Jobs get an ID once they are stopped or put in the background.
+type Job struct {
+	ID      int
+	Command string
+
+	procs []*process
+	// foreground is set while the shell waits for the job
+	foreground bool
+	// seq orders jobs by when they were last stopped or backgrounded; the
+	// latest is the current job, %+
+	seq int
+	// modes are the terminal settings the job had when it was stopped
+	modes *term.State
+}
+
+// state is Done once all of the job's processes are, Stopped if any of them
+// is and Running otherwise.
+func (j *Job) state() State {
+	state := Done
+	for _, p := range j.procs {
+		switch p.state {
+		case Stopped:
+			return Stopped
+		case Running:
+			state = Running
+		}
+	}
+	return state
+}
+
+func (j *Job) running() bool {
+	for _, p := range j.procs {
+		if p.state == Running {
+			return true
+		}
+	}
+	return false
+}
+
+// status is the exit status of the job's last process, as for a pipeline.
+func (j *Job) status() int {
+	if len(j.procs) == 0 {
+		return 0
+	}
+	return j.procs[len(j.procs)-1].status
+}
+
+// groups lists the process groups of the job's processes that have not
+// exited.
+func (j *Job) groups() []int {
+	var groups []int
+	for _, p := range j.procs {
+		if p.state != Done && !slices.Contains(groups, p.pgid) {
+			groups = append(groups, p.pgid)
+		}
+	}
+	return groups
+}
+
+// Table holds the shell's jobs.
+type Table struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	jobs    []*Job
+	seq     int
+	// notes are the changes to background jobs to print at the next prompt
+	notes []string
+
+	ttyOnce sync.Once
+	tty     *terminal
+}
+
+// NewTable creates an empty job table.
+func NewTable() *Table {
+	t := &Table{}
+	t.changed = sync.NewCond(&t.mu)
+	return t
+}
+
+// DefaultTable holds the jobs of the interactive shell.
+var DefaultTable = NewTable()
+
+type jobKey struct{}
+
+// Foreground returns a context under which the external commands that
+// command runs are a job the shell waits for, and Ctrl+Z suspends.
+func (t *Table) Foreground(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, jobKey{}, &Job{Command: strings.TrimSpace(command), foreground: true})
+}
+
+// Background returns a context under which the external commands that
+// command runs are a job the shell does not wait for, as for `cmd &`.
+func (t *Table) Background(ctx context.Context, command string) context.Context {
+	command = strings.TrimSuffix(strings.TrimSpace(command), "&")
+	return context.WithValue(ctx, jobKey{}, &Job{Command: strings.TrimSpace(command)})
+}
+
+func jobFrom(ctx context.Context) *Job {
+	job, _ := ctx.Value(jobKey{}).(*Job)
+	return job
+}
+
+// Controlled reports whether NewExecHandler runs the external commands run
+// under ctx as jobs.
+func Controlled(ctx context.Context) bool {
+	return supported && jobFrom(ctx) != nil
+}
+
+type stdoutKey struct{}
+
+// WithStdout returns a context under which the external commands of jobs
+// write their output to w rather than to the shell's stdout, for handlers
+// that come before NewExecHandler and go through that output.
+func WithStdout(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, stdoutKey{}, w)
+}
+
+func stdoutFrom(ctx context.Context, stdout io.Writer) io.Writer {
+	if w, ok := ctx.Value(stdoutKey{}).(io.Writer); ok {
+		return w
+	}
+	return stdout
+}
+
+// terminal returns the terminal the shell controls, or nil if it does not
+// control one, in which case jobs are not handed the terminal.
+func (t *Table) terminal() *terminal {
+	t.ttyOnce.Do(func() {
+		t.tty = openTerminal()
+	})
+	return t.tty
+}
+
+// add gives job an ID and makes it the current job. t.mu must be held.
+func (t *Table) add(job *Job) {
+	if job.ID == 0 {
+		job.ID = 1
+		for _, other := range t.jobs {
+			job.ID = max(job.ID, other.ID+1)
+		}
+		t.jobs = append(t.jobs, job)
+	}
+	t.seq++
+	job.seq = t.seq
+}
+
+// remove takes job off the table. t.mu must be held.
+func (t *Table) remove(job *Job) {
+	for i, other := range t.jobs {
+		if other == job {
+			t.jobs = append(t.jobs[:i], t.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// mark returns + for the current job, - for the previous one and a space
+// for the rest. t.mu must be held.
+func (t *Table) mark(job *Job) string {
+	later := 0
+	for _, other := range t.jobs {
+		if other.seq > job.seq {
+			later++
+		}
+	}
+	switch later {
+	case 0:
+		return "+"
+	case 1:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// describe formats job the way bash's jobs builtin does, e.g.
+// "[1]+  Stopped                 vim notes.txt". t.mu must be held.
+func (t *Table) describe(job *Job, pids bool) string {
+	state := job.state().String()
+	if job.state() == Done && job.status() != 0 {
+		state = fmt.Sprintf("Exit %d", job.status())
+	}
+	command := job.Command
+	if job.state() == Running {
+		command += " &"
+	}
+	var pid string
+	if pids && len(job.procs) > 0 {
+		pid = fmt.Sprintf(" %d", job.procs[0].pid)
+	}
+	return fmt.Sprintf("[%d]%s%s  %-22s  %s", job.ID, t.mark(job), pid, state, command)
+}
+
+// find returns the job that spec names: %N or N for job N, %+, %% or
+// nothing for the current job, %- for the previous one, %name for the job
+// whose command starts with name and %?text for the one containing text.
+// t.mu must be held.
+func (t *Table) find(spec string) (*Job, error) {
+	byMark := func(mark string) (*Job, error) {
+		for _, job := range t.jobs {
+			if t.mark(job) == mark {
+				return job, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", map[string]string{"+": "current", "-": "previous"}[mark])
+	}
+
+	name := strings.TrimPrefix(spec, "%")
+	switch {
+	case name == "" || name == "+" || name == "%":
+		return byMark("+")
+	case name == "-":
+		return byMark("-")
+	}
+	var id int
+	if _, err := fmt.Sscanf(name, "%d", &id); err == nil && fmt.Sprint(id) == name {
+		for _, job := range t.jobs {
+			if job.ID == id {
+				return job, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+
+	var found *Job
+	for _, job := range t.jobs {
+		matches := strings.HasPrefix(job.Command, name)
+		if text, ok := strings.CutPrefix(name, "?"); ok {
+			matches = strings.Contains(job.Command, text)
+		}
+		if !matches {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s: ambiguous job spec", spec)
+		}
+		found = job
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+	return found, nil
+}
+
+// release hands the terminal back to the shell once none of the processes
+// of a foreground job runs any more. A stopped job is added to the table
+// and reported to w. t.mu must be held.
+func (t *Table) release(job *Job, w io.Writer) {
+	if !job.foreground || job.running() {
+		return
+	}
+	job.foreground = false
+	stopped := job.state() == Stopped
+	if tty := t.terminal(); tty != nil {
+		tty.reclaim(job, stopped)
+	}
+	if !stopped {
+		t.remove(job)
+		return
+	}
+	t.add(job)
+	fmt.Fprintf(w, "\n%s\n", t.describe(job, false))
+}
+
+// watch follows p until it exits, noting when a job that the shell is not
+// waiting for stops or finishes.
+func (t *Table) watch(job *Job, p *process) {
+	for {
+		state, status := waitProcess(p.pid)
+		if state == Done {
+			// Let the copies of its output finish; the process is already
+			// reaped, so the error is of no interest
+			_ = p.cmd.Wait()
+		}
+
+		t.mu.Lock()
+		before := job.state()
+		p.state, p.status = state, status
+		if after := job.state(); !job.foreground && job.ID != 0 && after != before && after != Running {
+			t.notes = append(t.notes, t.describe(job, false))
+			if after == Done {
+				t.remove(job)
+			}
+		}
+		t.changed.Broadcast()
+		t.mu.Unlock()
+
+		if state == Done {
+			return
+		}
+	}
+}
+
+// Notify writes out the background jobs that stopped or finished since it
+// was last called, as bash does before its prompt.
+func (t *Table) Notify(w io.Writer) {
+	t.mu.Lock()
+	notes := t.notes
+	t.notes = nil
+	t.mu.Unlock()
+	for _, note := range notes {
+		fmt.Fprintln(w, note)
+	}
+}
+
+// HangUp sends SIGHUP to the jobs left when the shell exits, continuing the
+// stopped ones so that they see it.
+func (t *Table) HangUp() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, job := range t.jobs {
+		stopped := job.state() == Stopped
+		for _, pgid := range job.groups() {
+			hangUpGroup(pgid, stopped)
+		}
+	}
+}
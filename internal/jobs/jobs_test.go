@@ -0,0 +1,210 @@
+//go:build !windows
+
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// output collects what commands print. Unlike a bytes.Buffer, it can be
+// written by the processes of a job while they are stopped and resumed.
+type output struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+func (o *output) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf.Reset()
+}
+
+// newTestTable returns a table that does not take over the terminal the
+// tests may run in.
+func newTestTable() *Table {
+	tbl := NewTable()
+	tbl.ttyOnce.Do(func() {})
+	return tbl
+}
+
+func newTestRunner(t *testing.T, tbl *Table, out *output) *interp.Runner {
+	runner, err := interp.New(
+		interp.StdIO(nil, out, out),
+		interp.ExecHandlers(NewJobsCommandHandler(tbl), NewExecHandler(tbl)),
+	)
+	require.NoError(t, err)
+	return runner
+}
+
+func run(ctx context.Context, t *testing.T, runner *interp.Runner, script string) error {
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	bash.Rewrite(file)
+	return runner.Run(ctx, file)
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	status, _ := interp.IsExitStatus(err)
+	return int(status)
+}
+
+func TestStopAndResumeInForeground(t *testing.T) {
+	tbl := newTestTable()
+	var out output
+	runner := newTestRunner(t, tbl, &out)
+
+	command := `sh -c 'kill -STOP $$; echo resumed'`
+	err := run(tbl.Foreground(context.Background(), command), t, runner, command)
+	assert.Equal(t, int(StoppedStatus), exitCode(err))
+	assert.Contains(t, out.String(), "[1]+  Stopped                 "+command)
+
+	out.Reset()
+	require.NoError(t, run(context.Background(), t, runner, "jobs"))
+	assert.Equal(t, "[1]+  Stopped                 "+command+"\n", out.String())
+
+	out.Reset()
+	require.NoError(t, run(context.Background(), t, runner, "fg %1"))
+	assert.Equal(t, command+"\nresumed\n", out.String())
+
+	out.Reset()
+	require.NoError(t, run(context.Background(), t, runner, "jobs"))
+	assert.Empty(t, out.String())
+}
+
+func TestResumeInBackground(t *testing.T) {
+	tbl := newTestTable()
+	var out output
+	runner := newTestRunner(t, tbl, &out)
+
+	command := `sh -c 'kill -STOP $$; exit 0'`
+	_ = run(tbl.Foreground(context.Background(), command), t, runner, command)
+
+	out.Reset()
+	require.NoError(t, run(context.Background(), t, runner, "bg"))
+	assert.Equal(t, "[1]+ "+command+" &\n", out.String())
+
+	var notes bytes.Buffer
+	require.Eventually(t, func() bool {
+		tbl.Notify(&notes)
+		return notes.Len() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "[1]+  Done                    "+command+"\n", notes.String())
+}
+
+func TestBackgroundJob(t *testing.T) {
+	tbl := newTestTable()
+	var out output
+	runner := newTestRunner(t, tbl, &out)
+
+	command := `sh -c 'exit 3'`
+	require.NoError(t, run(tbl.Background(context.Background(), command+" &"), t, runner, command))
+	assert.Regexp(t, `^\[1\] \d+\n$`, out.String())
+
+	var notes bytes.Buffer
+	require.Eventually(t, func() bool {
+		tbl.Notify(&notes)
+		return notes.Len() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "[1]+  Exit 3                  "+command+"\n", notes.String())
+}
+
+func TestPipelineJob(t *testing.T) {
+	tbl := newTestTable()
+	var out output
+	runner := newTestRunner(t, tbl, &out)
+
+	command := "printf 'b\\na\\n' | sort"
+	require.NoError(t, run(tbl.Foreground(context.Background(), command), t, runner, command))
+	assert.Equal(t, "a\nb\n", out.String())
+	assert.Empty(t, tbl.jobs)
+}
+
+func TestNoSuchJob(t *testing.T) {
+	tbl := newTestTable()
+	var out output
+	runner := newTestRunner(t, tbl, &out)
+
+	assert.Equal(t, 1, exitCode(run(context.Background(), t, runner, "fg")))
+	assert.Equal(t, 1, exitCode(run(context.Background(), t, runner, "bg %2")))
+	assert.Equal(t, "fg: current: no such job\nbg: %2: no such job\n", out.String())
+}
+
+func TestFind(t *testing.T) {
+	tbl := newTestTable()
+	vim := &Job{Command: "vim notes.txt", procs: []*process{{state: Stopped}}}
+	sleep := &Job{Command: "sleep 100", procs: []*process{{state: Running}}}
+	less := &Job{Command: "less notes.txt", procs: []*process{{state: Stopped}}}
+	for _, job := range []*Job{vim, sleep, less} {
+		tbl.add(job)
+	}
+
+	for spec, want := range map[string]*Job{
+		"":       less,
+		"%%":     less,
+		"%+":     less,
+		"%-":     sleep,
+		"2":      sleep,
+		"%1":     vim,
+		"%vim":   vim,
+		"%?100":  sleep,
+		"%sleep": sleep,
+	} {
+		got, err := tbl.find(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, got, spec)
+	}
+
+	_, err := tbl.find("%4")
+	assert.EqualError(t, err, "%4: no such job")
+	_, err = tbl.find("%?notes")
+	assert.EqualError(t, err, "%?notes: ambiguous job spec")
+
+	assert.Equal(t, "[1]   Stopped                 vim notes.txt", tbl.describe(vim, false))
+	assert.Equal(t, "[2]-  Running                 sleep 100 &", tbl.describe(sleep, false))
+}
+
+func TestDetachable(t *testing.T) {
+	var out output
+	runner := newTestRunner(t, newTestTable(), &out)
+	require.NoError(t, run(context.Background(), t, runner, "f() { sleep 1; }"))
+
+	for script, want := range map[string]bool{
+		"sleep 1 &":         true,
+		"sleep 1":           false,
+		"f &":               false,
+		"X=1 sleep 1 &":     false,
+		"{ sleep 1; } &":    false,
+		"no-such-command &": false,
+	} {
+		file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+		require.NoError(t, err)
+		assert.Equal(t, want, Detachable(file.Stmts[0], runner), fmt.Sprint(script))
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !windows
+
+package jobs
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+const supported = true
+
+// StoppedStatus is the exit status of a command that was stopped, as bash
+// gives it: 128 plus SIGTSTP.
+const StoppedStatus = 128 + uint8(syscall.SIGTSTP)
+
+// terminal is the terminal the shell hands to its foreground jobs.
+type terminal struct {
+	fd int
+	// pgid is the shell's process group
+	pgid int
+	// modes are the shell's terminal settings, restored when a job stops
+	modes *term.State
+}
+
+// openTerminal returns the shell's terminal if stdin is one and the shell is
+// in its foreground.
+func openTerminal() *terminal {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil
+	}
+	pgid, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil || pgid != unix.Getpgrp() {
+		return nil
+	}
+
+	// Taking the terminal back from a job would stop the shell with SIGTTOU.
+	// Ctrl+Z during a builtin must not stop it either; SIGTSTP is caught
+	// rather than ignored, as ignoring it would pass on to the jobs.
+	signal.Ignore(syscall.SIGTTOU)
+	tstp := make(chan os.Signal, 1)
+	signal.Notify(tstp, syscall.SIGTSTP)
+	go func() {
+		for range tstp {
+		}
+	}()
+	return &terminal{fd: fd, pgid: pgid}
+}
+
+// save keeps the shell's terminal settings before a job runs.
+func (tty *terminal) save() {
+	tty.modes, _ = term.GetState(tty.fd)
+}
+
+// hand gives the terminal to job, with the settings it had when it stopped.
+func (tty *terminal) hand(job *Job) {
+	tty.save()
+	if job.modes != nil {
+		_ = term.Restore(tty.fd, job.modes)
+	}
+	if groups := job.groups(); len(groups) > 0 {
+		_ = unix.IoctlSetPointerInt(tty.fd, unix.TIOCSPGRP, groups[0])
+	}
+}
+
+// reclaim takes the terminal back for the shell. A stopped job's terminal
+// settings are kept for fg, and the shell's put back.
+func (tty *terminal) reclaim(job *Job, stopped bool) {
+	_ = unix.IoctlSetPointerInt(tty.fd, unix.TIOCSPGRP, tty.pgid)
+	if stopped {
+		job.modes, _ = term.GetState(tty.fd)
+		if tty.modes != nil {
+			_ = term.Restore(tty.fd, tty.modes)
+		}
+	}
+}
+
+// processAttr puts a process in the group pgid, or a new one if pgid is 0,
+// and gives the group tty if it is not nil.
+func processAttr(pgid int, tty *terminal) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{Setpgid: true, Pgid: pgid}
+	if tty != nil {
+		attr.Foreground = true
+		attr.Ctty = tty.fd
+	}
+	return attr
+}
+
+// waitProcess waits for the process pid to stop, continue or exit, and
+// returns its state and, once it has exited, its exit status.
+func waitProcess(pid int) (State, int) {
+	for {
+		var status syscall.WaitStatus
+		_, err := syscall.Wait4(pid, &status, syscall.WUNTRACED|syscall.WCONTINUED, nil)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case err != nil:
+			return Done, 1
+		case status.Stopped():
+			return Stopped, 0
+		case status.Continued():
+			return Running, 0
+		case status.Signaled():
+			return Done, 128 + int(status.Signal())
+		default:
+			return Done, status.ExitStatus()
+		}
+	}
+}
+
+func continueGroup(pgid int) {
+	_ = syscall.Kill(-pgid, syscall.SIGCONT)
+}
+
+func hangUpGroup(pgid int, stopped bool) {
+	_ = syscall.Kill(-pgid, syscall.SIGHUP)
+	if stopped {
+		_ = syscall.Kill(-pgid, syscall.SIGCONT)
+	}
+}
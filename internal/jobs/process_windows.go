@@ -0,0 +1,36 @@
+//go:build windows
+
+package jobs
+
+import "syscall"
+
+// Windows has no process groups to suspend and resume, so commands run as
+// they would without job control.
+const supported = false
+
+// StoppedStatus is the exit status bash gives a stopped command.
+const StoppedStatus uint8 = 148
+
+type terminal struct{}
+
+func openTerminal() *terminal {
+	return nil
+}
+
+func (tty *terminal) save() {}
+
+func (tty *terminal) hand(job *Job) {}
+
+func (tty *terminal) reclaim(job *Job, stopped bool) {}
+
+func processAttr(pgid int, tty *terminal) *syscall.SysProcAttr {
+	return nil
+}
+
+func waitProcess(pid int) (State, int) {
+	return Done, 0
+}
+
+func continueGroup(pgid int) {}
+
+func hangUpGroup(pgid int, stopped bool) {}
@@ -0,0 +1,279 @@
+// Package journal summarizes a shell session when it ends, or on #!wrapup,
+// and appends the summary to a journal kept in the project, so that the next
+// session or a teammate can pick up where it left off.
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/focus"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// MinCommands is how many commands a session needs before it is wrapped up
+// on exit; shorter sessions are not worth a journal entry.
+const MinCommands = 3
+
+// maxSummaryCommands limits how many commands are sent to the LLM; the
+// oldest are dropped first.
+const maxSummaryCommands = 200
+
+// entryHeading starts each entry in a journal file.
+const entryHeading = "## "
+
+// Path returns the journal file of project.
+func Path(project string) string {
+	return filepath.Join(project, ".bish", "journal.md")
+}
+
+// Activity is what happened in a session, worked out from its history.
+type Activity struct {
+	Commands int
+	Failed   int
+	// Fixed are the commands that failed and later succeeded
+	Fixed []string
+	// Directories are the directories commands ran in, in the order they
+	// were first used
+	Directories []string
+	// Project is the project most of the commands ran in
+	Project string
+}
+
+// Summarize works out the activity of entries, which must be oldest first.
+func Summarize(entries []history.HistoryEntry) Activity {
+	var activity Activity
+	failing := map[string]bool{}
+	seenDirectories := map[string]bool{}
+	projectCounts := map[string]int{}
+	for _, entry := range entries {
+		activity.Commands++
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			activity.Failed++
+			failing[entry.Command] = true
+		} else if entry.ExitCode.Valid && failing[entry.Command] {
+			delete(failing, entry.Command)
+			activity.Fixed = append(activity.Fixed, entry.Command)
+		}
+
+		if entry.Directory == "" {
+			continue
+		}
+		if !seenDirectories[entry.Directory] {
+			seenDirectories[entry.Directory] = true
+			activity.Directories = append(activity.Directories, entry.Directory)
+		}
+		project := todo.ProjectFor(entry.Directory)
+		projectCounts[project]++
+		if projectCounts[project] > projectCounts[activity.Project] {
+			activity.Project = project
+		}
+	}
+	return activity
+}
+
+// String describes the activity in numbers, e.g.
+// "23 commands (2 failed, 1 fixed) in 3 directories".
+func (a Activity) String() string {
+	noun := "commands"
+	if a.Commands == 1 {
+		noun = "command"
+	}
+	stats := fmt.Sprintf("%d %s", a.Commands, noun)
+
+	var outcomes []string
+	if a.Failed > 0 {
+		outcomes = append(outcomes, fmt.Sprintf("%d failed", a.Failed))
+	}
+	if len(a.Fixed) > 0 {
+		outcomes = append(outcomes, fmt.Sprintf("%d fixed", len(a.Fixed)))
+	}
+	if len(outcomes) > 0 {
+		stats += " (" + strings.Join(outcomes, ", ") + ")"
+	}
+
+	switch len(a.Directories) {
+	case 0:
+		return stats
+	case 1:
+		return stats + " in " + a.Directories[0]
+	default:
+		return stats + fmt.Sprintf(" in %d directories", len(a.Directories))
+	}
+}
+
+// Entry is a session summary as written to the journal.
+type Entry struct {
+	Start    time.Time
+	End      time.Time
+	Activity Activity
+	// Summary is written by the LLM, and is empty if it could not be reached
+	Summary string
+}
+
+// Heading returns the first line of the entry, with when the session ran and
+// what it did in numbers.
+func (e Entry) Heading() string {
+	return fmt.Sprintf("%s%s (%s): %s", entryHeading, e.Start.Format("2006-01-02 15:04"), focus.FormatElapsed(e.End.Sub(e.Start)), e.Activity)
+}
+
+// Markdown returns the entry as it is appended to the journal.
+func (e Entry) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString(e.Heading() + "\n\n")
+	if e.Summary != "" {
+		sb.WriteString(e.Summary + "\n")
+	}
+	if len(e.Activity.Fixed) > 0 {
+		if e.Summary != "" {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Fixed: `" + strings.Join(e.Activity.Fixed, "`, `") + "`\n")
+	}
+	if len(e.Activity.Directories) > 1 {
+		sb.WriteString("Directories: " + strings.Join(e.Activity.Directories, ", ") + "\n")
+	}
+	return sb.String()
+}
+
+// Append adds entry to the end of the journal of project and returns the
+// path of the journal.
+func Append(project string, entry Entry) (string, error) {
+	path := Path(project)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	text := entry.Markdown()
+	if info.Size() > 0 {
+		text = "\n" + text
+	}
+	if _, err := f.WriteString(text); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Latest returns the last entry of the journal of project, or "" if there is
+// none.
+func Latest(project string) (string, error) {
+	data, err := os.ReadFile(Path(project))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(string(data))
+	if i := strings.LastIndex(text, "\n"+entryHeading); i >= 0 {
+		text = text[i+1:]
+	}
+	return text, nil
+}
+
+// Summarizer summarizes sessions using the slow LLM model.
+type Summarizer struct {
+	runner         *interp.Runner
+	historyManager *history.HistoryManager
+	logger         *zap.Logger
+}
+
+// NewSummarizer creates a new session summarizer.
+func NewSummarizer(runner *interp.Runner, historyManager *history.HistoryManager, logger *zap.Logger) *Summarizer {
+	return &Summarizer{
+		runner:         runner,
+		historyManager: historyManager,
+		logger:         logger,
+	}
+}
+
+// Summarize returns the journal entry for the commands run by sessionID since
+// start. The LLM is only asked for a summary if at least minCommands were run;
+// if it cannot be reached, the entry has no summary and the error is returned
+// with it.
+func (s *Summarizer) Summarize(ctx context.Context, sessionID string, start, end time.Time, minCommands int) (Entry, error) {
+	entries, err := s.historyManager.GetSessionEntries(sessionID, start)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to get session commands: %w", err)
+	}
+
+	entry := Entry{Start: start, End: end, Activity: Summarize(entries)}
+	if len(entries) == 0 || len(entries) < minCommands {
+		return entry, nil
+	}
+
+	client, modelConfig := utils.GetLLMClient(s.runner, utils.SlowModel)
+
+	systemPrompt := `You are a helpful assistant that writes handoff notes for shell sessions.
+You will be given the shell commands I ran in a session, with the directory and exit code of each.
+Write at most 5 short bullet points starting with "- " so that I, or a teammate, can pick up where I left off:
+what was done, which failures were fixed, and what seems unfinished or still failing.
+Do not repeat the commands verbatim unless they matter. Do not add a heading or closing remarks.`
+
+	request := openai.ChatCompletionRequest{
+		Model: modelConfig.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: summaryPrompt(entries)},
+		},
+	}
+	if modelConfig.Temperature != nil {
+		request.Temperature = float32(*modelConfig.Temperature)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return entry, fmt.Errorf("failed to generate summary: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return entry, fmt.Errorf("no response from LLM")
+	}
+
+	entry.Summary = strings.TrimSpace(resp.Choices[0].Message.Content)
+	s.logger.Debug("generated session summary",
+		zap.String("session", sessionID),
+		zap.String("summary", entry.Summary),
+		zap.Int("command_count", len(entries)),
+	)
+	return entry, nil
+}
+
+func summaryPrompt(entries []history.HistoryEntry) string {
+	if len(entries) > maxSummaryCommands {
+		entries = entries[len(entries)-maxSummaryCommands:]
+	}
+
+	var commandList strings.Builder
+	for _, entry := range entries {
+		exitStatus := "✓"
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			exitStatus = fmt.Sprintf("✗(%d)", entry.ExitCode.Int32)
+		}
+		commandList.WriteString(fmt.Sprintf("[%s] %s %s $ %s\n",
+			entry.CreatedAt.Format("15:04:05"),
+			exitStatus,
+			entry.Directory,
+			entry.Command,
+		))
+	}
+
+	return "Commands I ran this session:\n\n" + commandList.String()
+}
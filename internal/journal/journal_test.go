@@ -0,0 +1,108 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func entry(command, directory string, exitCode int32) history.HistoryEntry {
+	return history.HistoryEntry{
+		Command:   command,
+		Directory: directory,
+		CreatedAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+		ExitCode:  sql.NullInt32{Int32: exitCode, Valid: true},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "billing")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "cron"), 0o755))
+
+	activity := Summarize([]history.HistoryEntry{
+		entry("make test", repo, 2),
+		entry("vim cron/billing.go", filepath.Join(repo, "cron"), 0),
+		entry("make test", repo, 0),
+		entry("ls", "/tmp", 0),
+		entry("deploy", repo, 1),
+	})
+
+	assert.Equal(t, 5, activity.Commands)
+	assert.Equal(t, 2, activity.Failed)
+	assert.Equal(t, []string{"make test"}, activity.Fixed)
+	assert.Equal(t, []string{repo, filepath.Join(repo, "cron"), "/tmp"}, activity.Directories)
+	assert.Equal(t, repo, activity.Project)
+	assert.Equal(t, "5 commands (2 failed, 1 fixed) in 3 directories", activity.String())
+
+	assert.Equal(t, "1 command in /tmp", Summarize([]history.HistoryEntry{entry("ls", "/tmp", 0)}).String())
+	assert.Equal(t, "0 commands", Summarize(nil).String())
+}
+
+func TestAppendAndLatest(t *testing.T) {
+	project := t.TempDir()
+
+	latest, err := Latest(project)
+	require.NoError(t, err)
+	assert.Empty(t, latest)
+
+	start := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	first := Entry{
+		Start:    start,
+		End:      start.Add(40 * time.Minute),
+		Activity: Activity{Commands: 4, Failed: 1, Fixed: []string{"make test"}, Directories: []string{project}},
+		Summary:  "- Fixed the flaky billing test",
+	}
+	path, err := Append(project, first)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(project, ".bish", "journal.md"), path)
+
+	second := Entry{Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour), Activity: Activity{Commands: 3}}
+	_, err = Append(project, second)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## 2024-05-01 09:30 (40m): 4 commands (1 failed, 1 fixed) in "+project+"\n\n"+
+		"- Fixed the flaky billing test\n\nFixed: `make test`\n\n"+
+		"## 2024-05-02 09:30 (1h00m): 3 commands\n\n", string(data))
+
+	latest, err = Latest(project)
+	require.NoError(t, err)
+	assert.Equal(t, "## 2024-05-02 09:30 (1h00m): 3 commands", latest)
+}
+
+func TestSummaryPrompt(t *testing.T) {
+	prompt := summaryPrompt([]history.HistoryEntry{entry("make test", "/src", 2), entry("make test", "/src", 0)})
+	assert.Contains(t, prompt, "[09:30:00] ✗(2) /src $ make test\n")
+	assert.Contains(t, prompt, "[09:30:00] ✓ /src $ make test\n")
+}
+
+func TestSummarizeShortSessionWithoutLLM(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	runner, err := interp.New()
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Minute)
+	_, err = historyManager.StartCommand("ls", "/tmp", "session-1")
+	require.NoError(t, err)
+	_, err = historyManager.StartCommand("make", "/src", "session-2")
+	require.NoError(t, err)
+
+	summarizer := NewSummarizer(runner, historyManager, zap.NewNop())
+	entry, err := summarizer.Summarize(context.Background(), "session-1", start, start.Add(20*time.Minute), MinCommands)
+	require.NoError(t, err)
+	assert.Equal(t, 1, entry.Activity.Commands)
+	assert.Empty(t, entry.Summary)
+}
@@ -0,0 +1,89 @@
+// Package keybindings loads the key bindings of the input line that the user
+// configured, so that keys like Ctrl+R or Tab can be remapped without
+// rebuilding bish.
+package keybindings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"gopkg.in/yaml.v3"
+)
+
+// Keys are the keys bound to an action, written as one key or a list.
+type Keys []string
+
+// UnmarshalYAML accepts a single key as well as a list of keys.
+func (keys *Keys) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var k string
+		if err := node.Decode(&k); err != nil {
+			return err
+		}
+		*keys = Keys{k}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*keys = list
+	return nil
+}
+
+// keybindingsFile is the format of the key bindings file, which maps the
+// actions of shellinput.KeyMapActions to their keys:
+//
+//	bindings:
+//	  reverse_search: ctrl+s
+//	  complete: [tab, ctrl+i]
+//	  yank: []
+type keybindingsFile struct {
+	Bindings map[string]Keys `yaml:"bindings"`
+}
+
+// DefaultPath returns where the key bindings are configured.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "keybindings.yaml")
+}
+
+// Load returns the default key map with the bindings at path applied. A
+// missing file changes nothing.
+func Load(path string) (shellinput.KeyMap, error) {
+	keyMap := shellinput.DefaultKeyMap
+	if path == "" {
+		return keyMap, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return keyMap, nil
+	}
+	if err != nil {
+		return keyMap, err
+	}
+	var file keybindingsFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return keyMap, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Bind in order, so that a key bound to two actions goes to the same one
+	// every time
+	actions := make([]string, 0, len(file.Bindings))
+	for action := range file.Bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		if err := keyMap.Bind(action, file.Bindings[action]...); err != nil {
+			return shellinput.DefaultKeyMap, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return keyMap, nil
+}
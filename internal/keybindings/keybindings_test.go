@@ -0,0 +1,57 @@
+package keybindings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keybindings.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`bindings:
+  reverse_search: ctrl+s
+  history_sort: ctrl+r
+  complete: [tab, ctrl+i]
+  yank_pop: []
+`), 0o644))
+
+	keyMap, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ctrl+s"}, keyMap.ReverseSearch.Keys())
+	assert.Equal(t, []string{"ctrl+r"}, keyMap.HistorySort.Keys())
+	assert.Equal(t, []string{"tab", "ctrl+i"}, keyMap.Complete.Keys())
+	assert.False(t, keyMap.YankPop.Enabled())
+	assert.Equal(t, shellinput.DefaultKeyMap.LineStart.Keys(), keyMap.LineStart.Keys())
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	keyMap, err := Load(filepath.Join(t.TempDir(), "keybindings.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, shellinput.DefaultKeyMap.ReverseSearch.Keys(), keyMap.ReverseSearch.Keys())
+
+	keyMap, err = Load("")
+	require.NoError(t, err)
+	assert.Equal(t, shellinput.DefaultKeyMap.Complete.Keys(), keyMap.Complete.Keys())
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"bindings:\n  rewind: ctrl+z\n":      `unknown action "rewind"`,
+		"bindings:\n  complete: {tab: 1}\n":  "cannot unmarshal",
+		"bindings: [\n":                      "did not find expected node content",
+		"bindings:\n  complete: [tab, '']\n": "complete: empty key",
+	}
+	for content, want := range tests {
+		path := filepath.Join(dir, "keybindings.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), path)
+		assert.Contains(t, err.Error(), want)
+	}
+}
@@ -0,0 +1,107 @@
+package later
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const usage = "Usage: later <command>...\n" +
+	"       later -l | --list\n" +
+	"       later -c | --cancel [id...]"
+
+// NewLaterCommandHandler creates an ExecHandler for the later builtin, which
+// adds a command to q, to run in the background once the commands queued
+// before it have finished, lists the queue and cancels queued commands. The
+// arguments are joined into the command line, so later 'make && make install'
+// queues both.
+func NewLaterCommandHandler(q *Queue) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "later" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			args = args[1:]
+			if len(args) == 0 {
+				q.list(hc.Stdout)
+				return nil
+			}
+			switch args[0] {
+			case "-l", "--list":
+				if len(args) > 1 {
+					fmt.Fprintln(hc.Stderr, usage)
+					return interp.NewExitStatus(2)
+				}
+				q.list(hc.Stdout)
+				return nil
+			case "-c", "--cancel":
+				return q.cancel(hc.Stdout, hc.Stderr, args[1:])
+			case "-h", "--help":
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+			case "--":
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			command := strings.Join(args, " ")
+			if _, err := syntax.NewParser().Parse(strings.NewReader(command), ""); err != nil {
+				fmt.Fprintf(hc.Stderr, "later: %v\n", err)
+				return interp.NewExitStatus(2)
+			}
+			entry := q.Add(command, hc.Dir)
+			fmt.Fprintf(hc.Stdout, "[%d] Queued  %s\n", entry.ID, entry.Command)
+			return nil
+		}
+	}
+}
+
+// list prints the running command and those waiting.
+func (q *Queue) list(w io.Writer) {
+	for _, entry := range q.List() {
+		state := "Queued"
+		if entry.Running() {
+			state = "Running"
+		}
+		fmt.Fprintf(w, "[%d] %-7s  %s\n", entry.ID, state, entry.Command)
+	}
+}
+
+// cancel cancels the commands ids, or all of them without ids.
+func (q *Queue) cancel(out, errOut io.Writer, ids []string) error {
+	if len(ids) == 0 {
+		for _, entry := range q.List() {
+			ids = append(ids, strconv.Itoa(entry.ID))
+		}
+	}
+	status := 0
+	for _, arg := range ids {
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "%"))
+		if err != nil {
+			fmt.Fprintf(errOut, "later: %s: invalid id\n", arg)
+			status = 2
+			continue
+		}
+		entry, err := q.Cancel(id)
+		if err != nil {
+			fmt.Fprintf(errOut, "later: %v\n", err)
+			status = max(status, 1)
+			continue
+		}
+		fmt.Fprintf(out, "[%d] Cancelled  %s\n", entry.ID, entry.Command)
+	}
+	if status != 0 {
+		return interp.NewExitStatus(uint8(status))
+	}
+	return nil
+}
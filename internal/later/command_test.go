@@ -0,0 +1,73 @@
+package later
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runLater(t *testing.T, q *Queue, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.Dir("/tmp"),
+		interp.ExecHandlers(NewLaterCommandHandler(q)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestLaterCommand(t *testing.T) {
+	q := NewQueue()
+
+	out, _, err := runLater(t, q, `later make deploy; later 'make test && make lint'; later -- ls -l`)
+	require.NoError(t, err)
+	assert.Equal(t, "[1] Queued  make deploy\n[2] Queued  make test && make lint\n[3] Queued  ls -l\n", out)
+	assert.Equal(t, "/tmp", q.List()[0].Dir)
+
+	out, _, err = runLater(t, q, "later --list")
+	require.NoError(t, err)
+	assert.Equal(t, "[1] Queued   make deploy\n[2] Queued   make test && make lint\n[3] Queued   ls -l\n", out)
+
+	out, _, err = runLater(t, q, "later --cancel 2")
+	require.NoError(t, err)
+	assert.Equal(t, "[2] Cancelled  make test && make lint\n", out)
+
+	out, _, err = runLater(t, q, "later")
+	require.NoError(t, err)
+	assert.Equal(t, "[1] Queued   make deploy\n[3] Queued   ls -l\n", out)
+
+	out, _, err = runLater(t, q, "later -c")
+	require.NoError(t, err)
+	assert.Equal(t, "[1] Cancelled  make deploy\n[3] Cancelled  ls -l\n", out)
+	assert.Empty(t, q.List())
+}
+
+func TestLaterCommandErrors(t *testing.T) {
+	q := NewQueue()
+
+	_, stderr, err := runLater(t, q, "later 'echo (' ")
+	assert.Equal(t, interp.NewExitStatus(2), err)
+	assert.Contains(t, stderr, "later: 1:1:")
+
+	_, stderr, err = runLater(t, q, "later --cancel 4 x")
+	assert.Equal(t, interp.NewExitStatus(2), err)
+	assert.Equal(t, "later: 4: no such queued command\nlater: x: invalid id\n", stderr)
+
+	_, stderr, err = runLater(t, q, "later --")
+	assert.Equal(t, interp.NewExitStatus(2), err)
+	assert.Contains(t, stderr, "Usage: later")
+	assert.Empty(t, q.List())
+}
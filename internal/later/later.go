@@ -0,0 +1,182 @@
+// Package later queues commands, typed with the later builtin, to run one
+// after another in the background of the session, each once the commands
+// queued before it have finished.
+package later
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a queued command.
+type Entry struct {
+	ID      int
+	Command string
+	// Dir is the directory the command was queued from, which it runs in
+	Dir    string
+	Queued time.Time
+	// Started is when the command started running, zero until then
+	Started time.Time
+
+	run    Run
+	cancel context.CancelFunc
+}
+
+// Running reports whether the command has started.
+func (e Entry) Running() bool {
+	return !e.Started.IsZero()
+}
+
+// Run runs a queued command and returns its exit status.
+type Run func(ctx context.Context) int
+
+// Prepare returns the function that runs entry. It is called by Resume, at
+// the prompt, where the shell state the command runs with can be copied
+// safely.
+type Prepare func(entry Entry) Run
+
+// Queue holds the commands waiting to run and the one running, which comes
+// first.
+type Queue struct {
+	mu      sync.Mutex
+	entries []*Entry
+	nextID  int
+	working bool
+	// notes are the commands that finished, to print at the next prompt
+	notes []string
+}
+
+// NewQueue creates an empty queue.
+func NewQueue() *Queue {
+	return &Queue{nextID: 1}
+}
+
+// DefaultQueue holds the commands queued in the interactive shell.
+var DefaultQueue = NewQueue()
+
+// Add queues command, to run in dir, and returns its entry.
+func (q *Queue) Add(command, dir string) Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := &Entry{ID: q.nextID, Command: strings.TrimSpace(command), Dir: dir, Queued: time.Now()}
+	q.nextID++
+	q.entries = append(q.entries, entry)
+	return *entry
+}
+
+// List returns the queued commands in the order they run, the running one
+// first.
+func (q *Queue) List() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]Entry, len(q.entries))
+	for i, entry := range q.entries {
+		list[i] = *entry
+	}
+	return list
+}
+
+// Cancel removes the command id from the queue, stopping it if it is
+// running, and returns it.
+func (q *Queue) Cancel(id int) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, entry := range q.entries {
+		if entry.ID != id {
+			continue
+		}
+		if entry.cancel != nil {
+			// The worker notes it and moves on once the command has stopped
+			entry.cancel()
+			return *entry, nil
+		}
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		return *entry, nil
+	}
+	return Entry{}, fmt.Errorf("%d: no such queued command", id)
+}
+
+// Resume prepares the commands queued since it was last called and, unless
+// one is running, starts running them in order in the background. The shell
+// calls it at each prompt, after the command line it ran has finished.
+func (q *Queue) Resume(prepare Prepare) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, entry := range q.entries {
+		if entry.run == nil {
+			entry.run = prepare(*entry)
+		}
+	}
+	if !q.working && len(q.entries) > 0 {
+		q.working = true
+		go q.work()
+	}
+}
+
+// work runs the queued commands one after another until there are none
+// left that Resume has prepared.
+func (q *Queue) work() {
+	for {
+		q.mu.Lock()
+		if len(q.entries) == 0 || q.entries[0].run == nil {
+			q.working = false
+			q.mu.Unlock()
+			return
+		}
+		entry := q.entries[0]
+		ctx, cancel := context.WithCancel(context.Background())
+		entry.Started, entry.cancel = time.Now(), cancel
+		q.mu.Unlock()
+
+		status := entry.run(ctx)
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		q.mu.Lock()
+		q.entries = q.entries[1:]
+		q.notes = append(q.notes, describeFinished(*entry, status, cancelled, time.Since(entry.Started)))
+		q.mu.Unlock()
+	}
+}
+
+// Stop cancels the queued commands and stops the running one, when the
+// shell exits.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) > 0 && q.entries[0].cancel != nil {
+		q.entries[0].cancel()
+		q.entries = q.entries[:1]
+		return
+	}
+	q.entries = nil
+}
+
+// Notify writes out the queued commands that finished since it was last
+// called.
+func (q *Queue) Notify(w io.Writer) {
+	q.mu.Lock()
+	notes := q.notes
+	q.notes = nil
+	q.mu.Unlock()
+	for _, note := range notes {
+		fmt.Fprintln(w, note)
+	}
+}
+
+// describeFinished formats the note for a command that finished, e.g.
+// "later: [1] Exit 2    make test (took 3s)".
+func describeFinished(entry Entry, status int, cancelled bool, took time.Duration) string {
+	state := "Done"
+	switch {
+	case cancelled:
+		state = "Cancelled"
+	case status != 0:
+		state = fmt.Sprintf("Exit %d", status)
+	}
+	return fmt.Sprintf("later: [%d] %-9s  %s (took %s)", entry.ID, state, entry.Command, took.Round(time.Second))
+}
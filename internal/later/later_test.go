@@ -0,0 +1,129 @@
+package later
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder prepares commands that note when they run and wait to be
+// released, to see the order the queue runs them in.
+type recorder struct {
+	mu      sync.Mutex
+	ran     []string
+	release chan int
+}
+
+func newRecorder() *recorder {
+	return &recorder{release: make(chan int)}
+}
+
+func (r *recorder) prepare(entry Entry) Run {
+	return func(ctx context.Context) int {
+		r.mu.Lock()
+		r.ran = append(r.ran, entry.Command)
+		r.mu.Unlock()
+		select {
+		case status := <-r.release:
+			return status
+		case <-ctx.Done():
+			return 130
+		}
+	}
+}
+
+func (r *recorder) commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ran...)
+}
+
+// waitFor waits until the queue holds n commands.
+func waitFor(t *testing.T, q *Queue, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return len(q.List()) == n }, time.Second, time.Millisecond)
+}
+
+func TestQueueRunsInOrder(t *testing.T) {
+	q := NewQueue()
+	r := newRecorder()
+	q.Add("make build", "/src")
+	q.Add("make test", "/src")
+
+	q.Resume(r.prepare)
+	require.Eventually(t, func() bool { return len(r.commands()) == 1 }, time.Second, time.Millisecond)
+	list := q.List()
+	require.Len(t, list, 2)
+	assert.True(t, list[0].Running())
+	assert.False(t, list[1].Running())
+	assert.Equal(t, "/src", list[0].Dir)
+
+	r.release <- 0
+	require.Eventually(t, func() bool { return len(r.commands()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"make build", "make test"}, r.commands())
+
+	// A command queued meanwhile waits for the next prompt to be prepared
+	q.Add("make deploy", "/src")
+	r.release <- 2
+	waitFor(t, q, 1)
+	assert.Equal(t, []string{"make build", "make test"}, r.commands())
+
+	var notes bytes.Buffer
+	q.Notify(&notes)
+	assert.Regexp(t, `^later: \[1\] Done       make build \(took 0s\)\nlater: \[2\] Exit 2     make test \(took 0s\)\n$`, notes.String())
+	notes.Reset()
+	q.Notify(&notes)
+	assert.Empty(t, notes.String())
+
+	q.Resume(r.prepare)
+	require.Eventually(t, func() bool { return len(r.commands()) == 3 }, time.Second, time.Millisecond)
+	r.release <- 0
+	waitFor(t, q, 0)
+}
+
+func TestQueueCancel(t *testing.T) {
+	q := NewQueue()
+	r := newRecorder()
+	q.Add("sleep 100", "/")
+	q.Add("make test", "/")
+	q.Add("make deploy", "/")
+	q.Resume(r.prepare)
+	require.Eventually(t, func() bool { return len(r.commands()) == 1 }, time.Second, time.Millisecond)
+
+	entry, err := q.Cancel(3)
+	require.NoError(t, err)
+	assert.Equal(t, "make deploy", entry.Command)
+	_, err = q.Cancel(3)
+	assert.EqualError(t, err, "3: no such queued command")
+
+	// Cancelling the running command stops it and moves on
+	_, err = q.Cancel(1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(r.commands()) == 2 }, time.Second, time.Millisecond)
+	r.release <- 0
+	waitFor(t, q, 0)
+
+	var notes bytes.Buffer
+	q.Notify(&notes)
+	assert.Contains(t, notes.String(), "later: [1] Cancelled  sleep 100")
+	assert.Contains(t, notes.String(), "later: [2] Done       make test")
+	assert.NotContains(t, notes.String(), "make deploy")
+}
+
+func TestQueueStop(t *testing.T) {
+	q := NewQueue()
+	r := newRecorder()
+	q.Add("sleep 100", "/")
+	q.Add("make test", "/")
+	q.Resume(r.prepare)
+	require.Eventually(t, func() bool { return len(r.commands()) == 1 }, time.Second, time.Millisecond)
+
+	q.Stop()
+	waitFor(t, q, 0)
+	assert.Equal(t, []string{"sleep 100"}, r.commands())
+}
@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Advisor proposes a bish replacement for a block that could not be migrated.
+type Advisor interface {
+	Suggest(ctx context.Context, r Result) (string, error)
+}
+
+type suggestion struct {
+	Code        string `json:"code" description:"Replacement bash code that works in bish, or an empty string if there is none" required:"true"`
+	Explanation string `json:"explanation" description:"One sentence explaining the replacement or why there is none" required:"true"`
+}
+
+var suggestionSchema = utils.GenerateJsonSchema(suggestion{})
+
+// LLMAdvisor asks an LLM for suggestions.
+type LLMAdvisor struct {
+	client *openai.Client
+	config utils.LLMModelConfig
+}
+
+// NewLLMAdvisor returns an advisor that uses the given model.
+func NewLLMAdvisor(client *openai.Client, config utils.LLMModelConfig) *LLMAdvisor {
+	return &LLMAdvisor{client: client, config: config}
+}
+
+// Suggest returns replacement code preceded by a comment explaining it.
+func (a *LLMAdvisor) Suggest(ctx context.Context, r Result) (string, error) {
+	schema, err := suggestionSchema.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	systemMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+I am migrating my zsh or bash rc file to bish. Bish runs bash syntax through a Go interpreter, so zsh-only syntax, zsh modules, plugin managers and bash's PROMPT_COMMAND do not work.
+Bish has its own settings, including:
+* BISH_UPDATE_PROMPT: a function called before each prompt that sets BISH_PROMPT
+* bish_path [--style home|git|fish|full]: prints the current directory for prompts
+* BISH_AUTOCD, BISH_HISTORY_SIZE and BISH_HISTORY_SHARING (prompt, live or isolated)
+You will be given a block of my rc file that could not be migrated, enclosed in <block> tags, and the reason, enclosed in <reason> tags.
+
+# Instructions
+* Write bash code that achieves the same effect in bish, if possible
+* Keep it short, and leave the code empty if there is no reasonable equivalent
+
+# Response JSON Schema
+%s`, string(schema))
+
+	completionRequest := openai.ChatCompletionRequest{
+		Model: a.config.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: fmt.Sprintf("<block>%s</block>\n<reason>%s</reason>", r.Text, r.Reason)},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if a.config.Temperature != nil {
+		completionRequest.Temperature = float32(*a.config.Temperature)
+	}
+
+	completion, err := a.client.CreateChatCompletion(ctx, completionRequest)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("empty response from LLM")
+	}
+	return parseSuggestion(completion.Choices[0].Message.Content)
+}
+
+// parseSuggestion decodes the LLM response into the text shown in the
+// migrated file.
+func parseSuggestion(content string) (string, error) {
+	var s suggestion
+	if err := json.Unmarshal([]byte(content), &s); err != nil {
+		return "", fmt.Errorf("invalid response from LLM: %w", err)
+	}
+	var lines []string
+	if explanation := strings.TrimSpace(s.Explanation); explanation != "" {
+		lines = append(lines, "# "+explanation)
+	}
+	if code := strings.TrimSpace(s.Code); code != "" {
+		lines = append(lines, code)
+	}
+	return strings.Join(lines, "\n"), nil
+}
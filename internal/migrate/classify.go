@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Kind is what a block of an rc file does.
+type Kind string
+
+const (
+	KindBlank      Kind = "blank"
+	KindComment    Kind = "comment"
+	KindAlias      Kind = "alias"
+	KindExport     Kind = "export"
+	KindFunction   Kind = "function"
+	KindPlugin     Kind = "plugin"
+	KindPrompt     Kind = "prompt"
+	KindOption     Kind = "option"
+	KindKeybinding Kind = "keybinding"
+	KindCompletion Kind = "completion"
+	KindSource     Kind = "source"
+	KindEval       Kind = "eval"
+	KindOther      Kind = "other"
+)
+
+// pluginManagers are commands of zsh plugin managers.
+var pluginManagers = map[string]bool{
+	"antigen": true, "antibody": true, "sheldon": true, "zcomet": true,
+	"zgen": true, "zgenom": true, "zi": true, "zinit": true, "znap": true, "zplug": true,
+}
+
+// pluginVariables configure oh-my-zsh and similar frameworks.
+var pluginVariables = map[string]bool{
+	"plugins": true, "ZSH": true, "ZSH_CUSTOM": true, "ZSH_THEME": true,
+}
+
+// promptVariables hold prompt strings or prompt hooks.
+var promptVariables = map[string]bool{
+	"PS1": true, "PROMPT": true, "RPROMPT": true, "RPS1": true, "PROMPT_COMMAND": true,
+}
+
+var (
+	assignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\+?=`)
+	// functionStart matches "name() {" and "function name".
+	functionStart = regexp.MustCompile(`^(function\s+[^\s(]+|[A-Za-z_][A-Za-z0-9_.:-]*\s*\(\s*\))`)
+)
+
+// Classify returns the kind of a block.
+func Classify(b Block) Kind {
+	text := strings.TrimSpace(b.Text)
+	if text == "" {
+		return KindBlank
+	}
+	if isComment(text) {
+		return KindComment
+	}
+	if functionStart.MatchString(text) {
+		return KindFunction
+	}
+
+	fields := strings.Fields(text)
+	command := fields[0]
+	if name, ok := assignedName(text, fields); ok {
+		switch {
+		case promptVariables[name]:
+			return KindPrompt
+		case pluginVariables[name]:
+			return KindPlugin
+		}
+		return KindExport
+	}
+
+	switch {
+	case pluginManagers[command]:
+		return KindPlugin
+	case command == "source" || command == ".":
+		if strings.Contains(text, "oh-my-zsh.sh") || strings.Contains(text, "antigen.zsh") || strings.Contains(text, "zplug/init.zsh") {
+			return KindPlugin
+		}
+		if strings.Contains(text, "p10k") || strings.Contains(text, "powerlevel") {
+			return KindPrompt
+		}
+		return KindSource
+	case command == "eval":
+		switch {
+		case strings.Contains(text, "starship init") || strings.Contains(text, "oh-my-posh"):
+			return KindPrompt
+		case strings.Contains(text, "sheldon source"):
+			return KindPlugin
+		}
+		return KindEval
+	case command == "alias":
+		return KindAlias
+	case command == "promptinit" || command == "prompt":
+		return KindPrompt
+	case command == "compinit" || command == "bashcompinit" || command == "compdef" || command == "complete":
+		return KindCompletion
+	case command == "autoload" && strings.Contains(text, "compinit"):
+		return KindCompletion
+	case command == "autoload" && strings.Contains(text, "promptinit"):
+		return KindPrompt
+	case command == "setopt" || command == "unsetopt" || command == "shopt" || command == "autoload" ||
+		command == "zstyle" || command == "zmodload" || (command == "set" && len(fields) > 1):
+		return KindOption
+	case command == "bindkey" || command == "bind":
+		return KindKeybinding
+	}
+	return KindOther
+}
+
+func isComment(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// assignedName returns the variable set by an export, typeset or plain
+// assignment, or false if the block is something else. "A=1 command" runs a
+// command with A set and is not an assignment.
+func assignedName(text string, fields []string) (string, bool) {
+	file, err := parse(text)
+	if err == nil && len(file.Stmts) == 1 {
+		if call, ok := file.Stmts[0].Cmd.(*syntax.CallExpr); ok && len(call.Assigns) > 0 {
+			return call.Assigns[0].Name.Value, len(call.Args) == 0
+		}
+	}
+	if match := assignment.FindStringSubmatch(fields[0]); match != nil && err != nil {
+		return match[1], true
+	}
+
+	switch fields[0] {
+	case "export", "typeset", "declare", "readonly":
+	default:
+		return "", false
+	}
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "-") || strings.HasPrefix(field, "+") {
+			continue
+		}
+		name, _, _ := strings.Cut(field, "=")
+		return name, true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunCommand implements "bish migrate" and returns its exit code. newAdvisor
+// is only called when --ai is given.
+func RunCommand(ctx context.Context, args []string, newAdvisor func() Advisor, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "write the migrated file here instead of to standard output")
+	force := flags.Bool("force", false, "overwrite the -o file if it exists")
+	useAI := flags.Bool("ai", false, "ask the fast model for a suggestion for each block that could not be migrated")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: bish migrate [flags] <rc file>")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Converts a zsh or bash rc file such as ~/.zshrc into bish configuration and")
+		fmt.Fprintln(stderr, "reports what could not be migrated. The input file is never changed.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	source := flags.Arg(0)
+
+	content, err := os.ReadFile(source)
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate: %v\n", err)
+		return 1
+	}
+	if *output != "" && !*force {
+		if _, err := os.Stat(*output); err == nil {
+			fmt.Fprintf(stderr, "migrate: %s already exists, use --force to overwrite it\n", *output)
+			return 1
+		}
+	}
+
+	results := Migrate(string(content))
+	if *useAI {
+		advisor := newAdvisor()
+		for i, r := range results {
+			if r.Status != StatusUnconverted {
+				continue
+			}
+			fmt.Fprintf(stderr, "Asking for a suggestion for %s...\n", r.Lines())
+			suggestion, err := advisor.Suggest(ctx, r)
+			if err != nil {
+				fmt.Fprintf(stderr, "migrate: no suggestion for %s: %v\n", r.Lines(), err)
+				continue
+			}
+			results[i].Suggestion = suggestion
+		}
+	}
+
+	rendered := Render(source, results)
+	if *output == "" {
+		fmt.Fprint(stdout, rendered)
+		WriteReport(stderr, source, results)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(rendered), 0o644); err != nil {
+		fmt.Fprintf(stderr, "migrate: %v\n", err)
+		return 1
+	}
+	WriteReport(stdout, source, results)
+	fmt.Fprintf(stdout, "\nWrote %s. Review it, then copy what you need into ~/.bishrc.\n", *output)
+	return 0
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAdvisor struct {
+	asked []string
+}
+
+func (a *fakeAdvisor) Suggest(_ context.Context, r Result) (string, error) {
+	a.asked = append(a.asked, r.Lines())
+	if r.Kind == KindKeybinding {
+		return "", errors.New("no idea")
+	}
+	return "# use an alias instead\nalias G='grep'", nil
+}
+
+const testRc = `# settings
+alias ll='ls -l'
+alias -g G='| grep'
+setopt autocd
+bindkey -v
+compinit
+`
+
+func writeRc(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+	require.NoError(t, os.WriteFile(path, []byte(testRc), 0o644))
+	return path
+}
+
+func runMigrate(t *testing.T, advisor Advisor, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(context.Background(), args, func() Advisor { return advisor }, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunCommandToStdout(t *testing.T) {
+	path := writeRc(t)
+	code, stdout, stderr := runMigrate(t, nil, path)
+	require.Equal(t, 0, code)
+
+	assert.Contains(t, stdout, "# Migrated from "+path)
+	assert.Contains(t, stdout, "# settings\nalias ll='ls -l'\n")
+	assert.Contains(t, stdout, "# bish migrate: not migrated (line 3): global (-g) and suffix (-s) aliases only exist in zsh\n# alias -g G='| grep'\n")
+	assert.Contains(t, stdout, "# bish migrate: converted from line 4:\n#   setopt autocd\nBISH_AUTOCD=1\n")
+	assert.Contains(t, stdout, "# bish migrate: dropped (line 6): ")
+	assert.NoError(t, checkBash(stdout))
+
+	assert.Contains(t, stderr, "Migrated "+path+": 1 kept, 1 converted, 2 need attention, 1 dropped")
+	assert.Contains(t, stderr, "Needs attention:\n  line 3       [alias] alias -g G='| grep'\n")
+
+	// The input is left alone
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, testRc, string(content))
+}
+
+func TestRunCommandWithAdvisor(t *testing.T) {
+	path := writeRc(t)
+	advisor := &fakeAdvisor{}
+	out := filepath.Join(t.TempDir(), "bishrc")
+
+	code, stdout, stderr := runMigrate(t, advisor, "--ai", "-o", out, path)
+	require.Equal(t, 0, code)
+	assert.Equal(t, []string{"line 3", "line 5"}, advisor.asked)
+	assert.Contains(t, stderr, "no suggestion for line 5: no idea")
+	assert.Contains(t, stdout, "suggestion available in the migrated file")
+	assert.Contains(t, stdout, "Wrote "+out)
+
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "# bish migrate: suggestion:\n#   # use an alias instead\n#   alias G='grep'\n# alias -g G='| grep'\n")
+
+	// An existing output file is only replaced with --force
+	code, _, stderr = runMigrate(t, advisor, "-o", out, path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "already exists")
+	code, _, _ = runMigrate(t, advisor, "--force", "-o", out, path)
+	assert.Equal(t, 0, code)
+}
+
+func TestRunCommandErrors(t *testing.T) {
+	code, _, stderr := runMigrate(t, nil)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: bish migrate")
+
+	code, _, stderr = runMigrate(t, nil, filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "migrate: ")
+}
+
+func TestParseSuggestion(t *testing.T) {
+	text, err := parseSuggestion(`{"code": "alias G='grep'", "explanation": "Use an alias."}`)
+	require.NoError(t, err)
+	assert.Equal(t, "# Use an alias.\nalias G='grep'", text)
+
+	text, err = parseSuggestion(`{"code": "", "explanation": "There is no equivalent."}`)
+	require.NoError(t, err)
+	assert.Equal(t, "# There is no equivalent.", text)
+
+	_, err = parseSuggestion("not json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,336 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/dotfiles"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Status is what happened to a block during migration.
+type Status string
+
+const (
+	// StatusKept blocks are copied unchanged.
+	StatusKept Status = "kept"
+	// StatusConverted blocks are replaced by a bish equivalent.
+	StatusConverted Status = "converted"
+	// StatusUnconverted blocks need attention and are commented out.
+	StatusUnconverted Status = "unconverted"
+	// StatusDropped blocks are not needed in bish and are commented out.
+	StatusDropped Status = "dropped"
+)
+
+// Result is the migration of one block.
+type Result struct {
+	Block
+	Kind   Kind
+	Status Status
+	// Output is the bish code for kept and converted blocks.
+	Output string
+	// Reason explains why a block was not migrated, or what to check in a
+	// kept or converted one.
+	Reason string
+	// Suggestion is a replacement proposed by an Advisor for an unconverted
+	// block.
+	Suggestion string
+}
+
+// Migrate segments, classifies and converts an rc file.
+func Migrate(content string) []Result {
+	blocks := Segment(content)
+	results := make([]Result, len(blocks))
+	for i, b := range blocks {
+		results[i] = Convert(b)
+	}
+	return results
+}
+
+// Convert migrates a single block.
+func Convert(b Block) Result {
+	kind := Classify(b)
+	result := Result{Block: b, Kind: kind}
+	text := strings.TrimSpace(b.Text)
+	fields := strings.Fields(text)
+
+	switch kind {
+	case KindBlank, KindComment:
+		return result.keep()
+	case KindAlias:
+		if len(fields) > 1 && strings.HasPrefix(fields[1], "-") && strings.ContainsAny(fields[1], "gs") {
+			return result.unconverted("global (-g) and suffix (-s) aliases only exist in zsh")
+		}
+	case KindExport:
+		return convertExport(result, text, fields)
+	case KindPrompt:
+		return convertPrompt(result, text, fields)
+	case KindPlugin:
+		return convertPlugin(result, text, fields)
+	case KindOption:
+		return convertOption(result, fields)
+	case KindKeybinding:
+		return result.unconverted("bish key bindings are built in and cannot be changed from the rc file")
+	case KindCompletion:
+		if fields[0] != "complete" {
+			return result.dropped("bish has its own completion system, so zsh and bash completion setup is not needed")
+		}
+	case KindSource:
+		if len(fields) > 1 && strings.Contains(fields[1], "zsh") {
+			return result.unconverted("this sources zsh code, which bish cannot run; migrate that file with bish migrate too")
+		}
+		result.Reason = "make sure the sourced file only uses bash syntax"
+	case KindEval:
+		if strings.Contains(text, "init zsh") || strings.Contains(text, "hook zsh") || strings.Contains(text, "--zsh") ||
+			strings.Contains(text, "init bash") || strings.Contains(text, "hook bash") || strings.Contains(text, "--bash") {
+			return result.unconverted("shell integrations rely on zsh hooks or PROMPT_COMMAND, which bish does not run")
+		}
+	}
+
+	if err := checkBash(text); err != nil {
+		return result.unconverted(err.Error())
+	}
+	return result.keep()
+}
+
+func (r Result) keep() Result {
+	r.Status = StatusKept
+	r.Output = r.Text
+	return r
+}
+
+func (r Result) converted(output string) Result {
+	r.Status = StatusConverted
+	r.Output = output
+	return r
+}
+
+func (r Result) unconverted(reason string) Result {
+	r.Status = StatusUnconverted
+	r.Reason = reason
+	return r
+}
+
+func (r Result) dropped(reason string) Result {
+	r.Status = StatusDropped
+	r.Reason = reason
+	return r
+}
+
+// checkBash reports whether text is valid bash, which is what bish runs.
+func checkBash(text string) error {
+	if _, err := parse(text); err != nil {
+		return fmt.Errorf("not valid bash: %v", err)
+	}
+	return nil
+}
+
+// historyVariables configure the shell's history file, which bish replaces
+// with its own database.
+var historyVariables = map[string]bool{
+	"HISTFILE": true, "HISTFILESIZE": true, "SAVEHIST": true, "HISTCONTROL": true,
+	"HISTIGNORE": true, "HISTTIMEFORMAT": true,
+}
+
+func convertExport(result Result, text string, fields []string) Result {
+	name, _ := assignedName(text, fields)
+	switch {
+	case name == "HISTSIZE":
+		value, err := assignedValue(text)
+		if err != nil {
+			return result.unconverted("HISTSIZE corresponds to BISH_HISTORY_SIZE, but " + err.Error())
+		}
+		return result.converted("BISH_HISTORY_SIZE=" + syntaxQuote(value))
+	case historyVariables[name]:
+		return result.dropped("bish keeps history in its own database")
+	case name == "path":
+		return result.unconverted(`zsh keeps the path array in sync with PATH but bish does not; set PATH instead, e.g. export PATH="$HOME/bin:$PATH"`)
+	case name == "fpath":
+		return result.dropped("fpath lists zsh function directories")
+	}
+	if err := checkBash(text); err != nil {
+		return result.unconverted(err.Error())
+	}
+	return result.keep()
+}
+
+func convertPrompt(result Result, text string, fields []string) Result {
+	const themeHint = "; choose a bish prompt theme with bish init-dotfiles"
+	switch {
+	case fields[0] == "eval" && strings.Contains(text, "starship init"):
+		theme, _ := dotfiles.FindTheme("starship")
+		return result.converted(theme.Script())
+	case fields[0] == "eval":
+		return result.unconverted("this prompt engine has no bish integration; call it from BISH_UPDATE_PROMPT instead")
+	case fields[0] == "source" || fields[0] == ".":
+		return result.unconverted("powerlevel10k only runs in zsh" + themeHint)
+	case fields[0] == "prompt" || fields[0] == "promptinit" || fields[0] == "autoload":
+		return result.unconverted("zsh prompt themes only run in zsh" + themeHint)
+	}
+
+	name, _ := assignedName(text, fields)
+	switch name {
+	case "RPROMPT", "RPS1":
+		return result.unconverted("bish has no right-hand prompt")
+	case "PROMPT_COMMAND":
+		return result.unconverted("bish does not run PROMPT_COMMAND; put these commands in BISH_UPDATE_PROMPT, which runs before each prompt")
+	}
+	value, err := assignedValue(text)
+	if err != nil {
+		return result.unconverted("could not read the prompt: " + err.Error())
+	}
+	script, err := ConvertPrompt(value)
+	if err != nil {
+		return result.unconverted(err.Error())
+	}
+	return result.converted(script)
+}
+
+func convertPlugin(result Result, text string, fields []string) Result {
+	name, ok := assignedName(text, fields)
+	switch {
+	case ok && name == "plugins":
+		return result.unconverted("oh-my-zsh plugins only run in zsh; most of them define aliases you can copy into ~/.bishrc")
+	case ok && name == "ZSH_THEME":
+		return result.dropped("oh-my-zsh themes only run in zsh; choose a bish prompt theme with bish init-dotfiles")
+	case ok:
+		return result.dropped("only used by oh-my-zsh")
+	}
+	return result.unconverted("zsh plugin managers load zsh code, which bish cannot run")
+}
+
+// optionSettings maps shell options, lower-cased and without underscores, to
+// the bish setting for turning them on and off. An empty setting means bish
+// always behaves that way.
+var optionSettings = map[string][2]string{
+	"autocd":               {"BISH_AUTOCD=1", "BISH_AUTOCD=0"},
+	"sharehistory":         {"BISH_HISTORY_SHARING=live", "BISH_HISTORY_SHARING=isolated"},
+	"appendhistory":        {"", ""},
+	"histappend":           {"", ""},
+	"incappendhistory":     {"", ""},
+	"incappendhistorytime": {"", ""},
+}
+
+func convertOption(result Result, fields []string) Result {
+	var names []string
+	on := true
+	switch fields[0] {
+	case "setopt", "unsetopt":
+		on = fields[0] == "setopt"
+		names = fields[1:]
+	case "shopt", "set":
+		for _, field := range fields[1:] {
+			switch field {
+			case "-s", "-o":
+			case "-u", "+o":
+				on = false
+			default:
+				names = append(names, field)
+			}
+		}
+	default:
+		return result.dropped("zsh modules and styles have no effect in bish")
+	}
+
+	var settings, unknown []string
+	for _, name := range names {
+		key := strings.ToLower(strings.ReplaceAll(name, "_", ""))
+		value, ok := optionSettings[key]
+		enabled := on
+		if trimmed := strings.TrimPrefix(key, "no"); !ok && trimmed != key {
+			value, ok = optionSettings[trimmed]
+			enabled = !on
+		}
+		switch {
+		case !ok:
+			unknown = append(unknown, name)
+		case enabled && value[0] != "":
+			settings = append(settings, value[0])
+		case !enabled && value[1] != "":
+			settings = append(settings, value[1])
+		}
+	}
+
+	switch {
+	case len(settings) == 0 && len(unknown) == 0:
+		return result.dropped("bish already records each command in its history as it runs")
+	case len(settings) == 0:
+		return result.unconverted("no bish equivalent for " + strings.Join(unknown, ", "))
+	}
+	result = result.converted(strings.Join(settings, "\n"))
+	if len(unknown) > 0 {
+		result.Reason = "no bish equivalent for " + strings.Join(unknown, ", ")
+	}
+	return result
+}
+
+var errExpansion = errors.New("the value uses expansions, which cannot be converted automatically")
+
+// assignedValue returns the literal value of the first variable assigned in
+// text.
+func assignedValue(text string) (string, error) {
+	file, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	var assign *syntax.Assign
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if a, ok := node.(*syntax.Assign); ok && assign == nil {
+			assign = a
+		}
+		return assign == nil
+	})
+	if assign == nil {
+		return "", errors.New("no value is assigned")
+	}
+	if assign.Value == nil {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	for _, part := range assign.Value.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return "", errExpansion
+			}
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", errExpansion
+				}
+				sb.WriteString(unescapeDoubleQuoted(lit.Value))
+			}
+		default:
+			return "", errExpansion
+		}
+	}
+	return sb.String(), nil
+}
+
+// unescapeDoubleQuoted removes the backslashes that are special inside double
+// quotes.
+func unescapeDoubleQuoted(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\", s[i+1]) >= 0 {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// syntaxQuote quotes a value for use in an assignment.
+func syntaxQuote(value string) string {
+	quoted, err := syntax.Quote(value, syntax.LangBash)
+	if err != nil {
+		return "'" + value + "'"
+	}
+	return quoted
+}
@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func convertLine(text string) Result {
+	return Convert(Block{StartLine: 1, EndLine: 1, Text: text})
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Kind{
+		"":                               KindBlank,
+		"# hello":                        KindComment,
+		"alias ll='ls -l'":               KindAlias,
+		"export EDITOR=vim":              KindExport,
+		"EDITOR=vim":                     KindExport,
+		"EDITOR=vim git commit":          KindOther,
+		"typeset -U path":                KindExport,
+		"mkcd() { mkdir -p \"$1\"; }":    KindFunction,
+		"function zfn {\n}":              KindFunction,
+		"antigen bundle git":             KindPlugin,
+		"plugins=(git docker)":           KindPlugin,
+		"source $ZSH/oh-my-zsh.sh":       KindPlugin,
+		"PS1='\\u$ '":                    KindPrompt,
+		"export PROMPT_COMMAND=history":  KindPrompt,
+		"eval \"$(starship init zsh)\"":  KindPrompt,
+		"[[ -f ~/.p10k.zsh ]] || true":   KindOther,
+		"source ~/.p10k.zsh":             KindPrompt,
+		"setopt autocd":                  KindOption,
+		"shopt -s histappend":            KindOption,
+		"zstyle ':completion:*' menu on": KindOption,
+		"bindkey -v":                     KindKeybinding,
+		"autoload -Uz compinit":          KindCompletion,
+		"complete -W 'a b' foo":          KindCompletion,
+		"source ~/.aliases":              KindSource,
+		"eval \"$(direnv hook zsh)\"":    KindEval,
+		"echo hi":                        KindOther,
+	}
+	for text, want := range tests {
+		assert.Equal(t, want, Classify(Block{Text: text}), text)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		text   string
+		status Status
+		output string
+	}{
+		{"alias ll='ls -l'", StatusKept, "alias ll='ls -l'"},
+		{"alias -g G='| grep'", StatusUnconverted, ""},
+		{"export EDITOR=vim", StatusKept, "export EDITOR=vim"},
+		{"HISTSIZE=5000", StatusConverted, "BISH_HISTORY_SIZE=5000"},
+		{"export HISTFILE=~/.zsh_history", StatusDropped, ""},
+		{"path=($HOME/bin $path)", StatusUnconverted, ""},
+		{"setopt AUTO_CD", StatusConverted, "BISH_AUTOCD=1"},
+		{"unsetopt autocd", StatusConverted, "BISH_AUTOCD=0"},
+		{"setopt no_share_history", StatusConverted, "BISH_HISTORY_SHARING=isolated"},
+		{"shopt -s autocd", StatusConverted, "BISH_AUTOCD=1"},
+		{"setopt inc_append_history", StatusDropped, ""},
+		{"setopt extended_glob", StatusUnconverted, ""},
+		{"zmodload zsh/complist", StatusDropped, ""},
+		{"bindkey -e", StatusUnconverted, ""},
+		{"compinit", StatusDropped, ""},
+		{"complete -W 'a b' foo", StatusKept, "complete -W 'a b' foo"},
+		{"antigen apply", StatusUnconverted, ""},
+		{"ZSH_THEME=agnoster", StatusDropped, ""},
+		{"RPROMPT='%T'", StatusUnconverted, ""},
+		{"PROMPT_COMMAND='history -a'", StatusUnconverted, ""},
+		{"PROMPT='%(?.ok.fail) '", StatusUnconverted, ""},
+		{"PS1=\"$(git_prompt) \"", StatusUnconverted, ""},
+		{"eval \"$(zoxide init zsh)\"", StatusUnconverted, ""},
+		{"eval \"$(ssh-agent -s)\"", StatusKept, "eval \"$(ssh-agent -s)\""},
+		{"source ~/.zsh_aliases", StatusUnconverted, ""},
+		{"echo ${(U)name}", StatusUnconverted, ""},
+	}
+	for _, tt := range tests {
+		result := convertLine(tt.text)
+		assert.Equal(t, tt.status, result.Status, tt.text)
+		if tt.output != "" {
+			assert.Equal(t, tt.output, result.Output, tt.text)
+		}
+		if tt.status == StatusUnconverted || tt.status == StatusDropped {
+			assert.NotEmpty(t, result.Reason, tt.text)
+		}
+	}
+}
+
+func TestConvertMixedOptions(t *testing.T) {
+	result := convertLine("setopt autocd share_history hist_ignore_dups")
+	assert.Equal(t, StatusConverted, result.Status)
+	assert.Equal(t, "BISH_AUTOCD=1\nBISH_HISTORY_SHARING=live", result.Output)
+	assert.Equal(t, "no bish equivalent for hist_ignore_dups", result.Reason)
+}
+
+func TestConvertSource(t *testing.T) {
+	result := convertLine("source ~/.aliases")
+	assert.Equal(t, StatusKept, result.Status)
+	assert.Contains(t, result.Reason, "bash syntax")
+}
+
+func TestConvertStarship(t *testing.T) {
+	result := convertLine(`eval "$(starship init zsh)"`)
+	assert.Equal(t, StatusConverted, result.Status)
+	assert.Contains(t, result.Output, "starship prompt")
+	assert.NoError(t, checkBash(result.Output))
+}
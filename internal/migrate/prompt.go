@@ -0,0 +1,251 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// colorNumbers are the ANSI numbers of the named colors in zsh's %F{...}.
+var colorNumbers = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// promptSubstitution matches expansions that zsh's prompt_subst and bash
+// evaluate in prompt strings.
+var promptSubstitution = regexp.MustCompile("\\$[({A-Za-z_]|`")
+
+// promptBuilder collects the double-quoted value of BISH_PROMPT and the local
+// variables it needs.
+type promptBuilder struct {
+	value  strings.Builder
+	esc    bool
+	host   bool
+	marker string
+}
+
+// literal appends text that must appear as is.
+func (p *promptBuilder) literal(text string) {
+	for _, r := range text {
+		if r == '\\' || r == '$' || r == '"' || r == '`' {
+			p.value.WriteRune('\\')
+		}
+		p.value.WriteRune(r)
+	}
+}
+
+// expr appends a shell expansion such as ${USER}.
+func (p *promptBuilder) expr(text string) {
+	p.value.WriteString(text)
+}
+
+// sgr appends an escape sequence that sets a terminal attribute or color.
+func (p *promptBuilder) sgr(code string) {
+	p.esc = true
+	p.value.WriteString("${esc}[" + code + "m")
+}
+
+func (p *promptBuilder) script() string {
+	var sb strings.Builder
+	if p.host {
+		sb.WriteString("BISH_PROMPT_HOST=\"$(hostname -s 2>/dev/null || hostname)\"\n")
+	}
+	sb.WriteString("function BISH_UPDATE_PROMPT() {\n")
+	if p.esc {
+		sb.WriteString("  local esc=$'\\033'\n")
+	}
+	if p.marker != "" {
+		fmt.Fprintf(&sb, "  local marker='%s'\n", p.marker)
+		sb.WriteString("  [ \"$(id -u)\" = 0 ] && marker='#'\n")
+	}
+	fmt.Fprintf(&sb, "  BISH_PROMPT=\"%s\"\n}", p.value.String())
+	return sb.String()
+}
+
+// ConvertPrompt turns a zsh PROMPT or bash PS1 value into a BISH_UPDATE_PROMPT
+// function. Both zsh %-escapes and bash \-escapes are understood. Escapes that
+// have no simple equivalent, such as zsh's %(...) conditionals, are an error.
+func ConvertPrompt(value string) (string, error) {
+	if promptSubstitution.MatchString(value) {
+		return "", errors.New("the prompt uses variables or command substitutions; rewrite it in BISH_UPDATE_PROMPT")
+	}
+	var p promptBuilder
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if (r != '%' && r != '\\') || i == len(runes)-1 {
+			p.literal(string(r))
+			continue
+		}
+		i++
+		var err error
+		if r == '%' {
+			i, err = p.zshEscape(runes, i)
+		} else {
+			i, err = p.bashEscape(runes, i)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return p.script(), nil
+}
+
+// zshEscape handles the escape after a % at runes[i] and returns the index of
+// its last rune.
+func (p *promptBuilder) zshEscape(runes []rune, i int) (int, error) {
+	digits := ""
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		digits += string(runes[i])
+		i++
+	}
+	if i == len(runes) {
+		return i, fmt.Errorf("prompt ends in the middle of an escape")
+	}
+
+	switch c := runes[i]; c {
+	case 'n':
+		p.expr("${USER}")
+	case 'm':
+		p.host = true
+		p.expr("${BISH_PROMPT_HOST}")
+	case 'M':
+		p.expr("$(hostname)")
+	case '~':
+		if digits == "1" {
+			p.expr("$(basename \"$PWD\")")
+		} else if digits == "" {
+			p.expr("$(bish_path --style home)")
+		} else {
+			return i, fmt.Errorf("%%%s~ is not supported, only %%~ and %%1~", digits)
+		}
+	case 'd', '/':
+		if digits == "1" {
+			p.expr("$(basename \"$PWD\")")
+		} else if digits == "" {
+			p.expr("${PWD}")
+		} else {
+			return i, fmt.Errorf("%%%s%c is not supported", digits, c)
+		}
+	case 'c', '.', 'C':
+		p.expr("$(basename \"$PWD\")")
+	case '#':
+		p.marker = "%"
+		p.expr("${marker}")
+	case '?':
+		p.expr("${BISH_LAST_COMMAND_EXIT_CODE}")
+	case 'T':
+		p.expr("$(date +%H:%M)")
+	case '*':
+		p.expr("$(date +%H:%M:%S)")
+	case '%':
+		p.literal("%")
+	case ')':
+		p.literal(")")
+	case '{', '}':
+		// zero-width markers are not needed
+	case 'B':
+		p.sgr("1")
+	case 'b':
+		p.sgr("22")
+	case 'U':
+		p.sgr("4")
+	case 'u':
+		p.sgr("24")
+	case 'f':
+		p.sgr("39")
+	case 'k':
+		p.sgr("49")
+	case 'F', 'K':
+		color := digits
+		if i+1 < len(runes) && runes[i+1] == '{' {
+			end := i + 2
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return i, fmt.Errorf("unterminated %%%c{", c)
+			}
+			color = string(runes[i+2 : end])
+			i = end
+		}
+		code, err := colorCode(color, c == 'K')
+		if err != nil {
+			return i, err
+		}
+		p.sgr(code)
+	case '(':
+		return i, fmt.Errorf("conditional prompt escapes like %%(?..) are not supported")
+	default:
+		return i, fmt.Errorf("unsupported prompt escape %%%c", c)
+	}
+	return i, nil
+}
+
+// colorCode returns the SGR parameters for a zsh color name or number.
+func colorCode(color string, background bool) (string, error) {
+	base := 30
+	if background {
+		base = 40
+	}
+	if n, ok := colorNumbers[strings.ToLower(color)]; ok {
+		return strconv.Itoa(base + n), nil
+	}
+	if color == "default" {
+		return strconv.Itoa(base + 9), nil
+	}
+	n, err := strconv.Atoi(color)
+	if err != nil || n < 0 || n > 255 {
+		return "", fmt.Errorf("unsupported prompt color %q", color)
+	}
+	return fmt.Sprintf("%d;5;%d", base+8, n), nil
+}
+
+// bashEscape handles the escape after a backslash at runes[i] and returns the
+// index of its last rune.
+func (p *promptBuilder) bashEscape(runes []rune, i int) (int, error) {
+	switch c := runes[i]; c {
+	case 'u':
+		p.expr("${USER}")
+	case 'h':
+		p.host = true
+		p.expr("${BISH_PROMPT_HOST}")
+	case 'H':
+		p.expr("$(hostname)")
+	case 'w':
+		p.expr("$(bish_path --style home)")
+	case 'W':
+		p.expr("$(basename \"$PWD\")")
+	case '$':
+		p.marker = "$"
+		p.expr("${marker}")
+	case 'n':
+		p.literal("\n")
+	case 't':
+		p.expr("$(date +%H:%M:%S)")
+	case 'A':
+		p.expr("$(date +%H:%M)")
+	case 's':
+		p.literal("bish")
+	case '\\':
+		p.literal("\\")
+	case '[', ']':
+		// non-printing markers are not needed
+	case 'e':
+		p.esc = true
+		p.expr("${esc}")
+	case '0':
+		if i+2 < len(runes) && string(runes[i:i+3]) == "033" {
+			p.esc = true
+			p.expr("${esc}")
+			return i + 2, nil
+		}
+		return i, fmt.Errorf("unsupported prompt escape \\0")
+	default:
+		return i, fmt.Errorf("unsupported prompt escape \\%c", c)
+	}
+	return i, nil
+}
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// renderPrompt runs the converted script and returns the resulting prompt.
+func renderPrompt(t *testing.T, script string) string {
+	t.Helper()
+	file, err := parse(script + "\nBISH_UPDATE_PROMPT")
+	require.NoError(t, err)
+
+	runner, err := interp.New(interp.Env(expand.ListEnviron("USER=alice", "PATH=/usr/bin:/bin")), interp.Dir("/tmp"))
+	require.NoError(t, err)
+	require.NoError(t, runner.Run(context.Background(), file))
+	return runner.Vars["BISH_PROMPT"].String()
+}
+
+func TestConvertPromptZsh(t *testing.T) {
+	script, err := ConvertPrompt("%F{green}%n%f in %1~ %B%%%b%# ")
+	require.NoError(t, err)
+	assert.Contains(t, script, "function BISH_UPDATE_PROMPT() {")
+
+	prompt := renderPrompt(t, script)
+	marker := "%"
+	if strings.HasSuffix(prompt, "# ") {
+		marker = "#"
+	}
+	assert.Equal(t, "\x1b[32malice\x1b[39m in tmp \x1b[1m%\x1b[22m"+marker+" ", prompt)
+}
+
+func TestConvertPromptBash(t *testing.T) {
+	script, err := ConvertPrompt(`\[\e[34m\]\u\[\033[0m\]:\W "quoted" \\ $ `)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b[34malice\x1b[0m:tmp \"quoted\" \\ $ ", renderPrompt(t, script))
+}
+
+func TestConvertPromptHost(t *testing.T) {
+	script, err := ConvertPrompt(`%n@%m:%~ `)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(script, "BISH_PROMPT_HOST="))
+	assert.Contains(t, script, `BISH_PROMPT="${USER}@${BISH_PROMPT_HOST}:$(bish_path --style home) "`)
+	assert.NoError(t, checkBash(script))
+}
+
+func TestConvertPromptErrors(t *testing.T) {
+	for value, want := range map[string]string{
+		"%(?.a.b)":      "conditional",
+		"%F{nope}x":     "unsupported prompt color",
+		"%3~ ":          "%3~ is not supported",
+		`\v `:           `unsupported prompt escape \v`,
+		"$(git branch)": "command substitutions",
+		"%F{red":        "unterminated",
+	} {
+		_, err := ConvertPrompt(value)
+		if assert.Error(t, err, value) {
+			assert.Contains(t, err.Error(), want, value)
+		}
+	}
+}
+
+func TestColorCode(t *testing.T) {
+	code, err := colorCode("208", false)
+	require.NoError(t, err)
+	assert.Equal(t, "38;5;208", code)
+
+	code, err = colorCode("Blue", true)
+	require.NoError(t, err)
+	assert.Equal(t, "44", code)
+}
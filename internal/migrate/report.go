@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// marker starts every comment that migrate adds to the generated file.
+const marker = "# bish migrate: "
+
+// Render returns the migrated rc file. Kept and converted blocks are active,
+// with the original of converted blocks shown in a comment above them. Blocks
+// that were not migrated are commented out and annotated with the reason and
+// any suggestion.
+func Render(source string, results []Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Migrated from %s by \"bish migrate\".\n", source)
+	sb.WriteString("# Review the lines starting with \"" + strings.TrimSpace(marker) + "\" before using this file.\n\n")
+
+	for _, r := range results {
+		switch r.Status {
+		case StatusKept:
+			if r.Reason != "" {
+				sb.WriteString(marker + "note: " + r.Reason + "\n")
+			}
+			sb.WriteString(r.Output + "\n")
+		case StatusConverted:
+			sb.WriteString(marker + "converted from " + r.Lines() + ":\n")
+			sb.WriteString(commentOut(r.Text, "#   "))
+			if r.Reason != "" {
+				sb.WriteString(marker + "note: " + r.Reason + "\n")
+			}
+			sb.WriteString(r.Output + "\n")
+		case StatusUnconverted:
+			sb.WriteString(marker + "not migrated (" + r.Lines() + "): " + r.Reason + "\n")
+			if r.Suggestion != "" {
+				sb.WriteString(marker + "suggestion:\n")
+				sb.WriteString(commentOut(r.Suggestion, "#   "))
+			}
+			sb.WriteString(commentOut(r.Text, "# "))
+		case StatusDropped:
+			sb.WriteString(marker + "dropped (" + r.Lines() + "): " + r.Reason + "\n")
+			sb.WriteString(commentOut(r.Text, "# "))
+		}
+	}
+	return sb.String()
+}
+
+func commentOut(text, prefix string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		sb.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// WriteReport writes a summary of the migration, listing every block that was
+// converted, dropped or needs attention.
+func WriteReport(w io.Writer, source string, results []Result) {
+	counts := map[Status]int{}
+	for _, r := range results {
+		if r.Kind != KindBlank && r.Kind != KindComment {
+			counts[r.Status]++
+		}
+	}
+	fmt.Fprintf(w, "Migrated %s: %d kept, %d converted, %d need attention, %d dropped\n",
+		source, counts[StatusKept], counts[StatusConverted], counts[StatusUnconverted], counts[StatusDropped])
+
+	for _, group := range []struct {
+		title  string
+		status Status
+	}{
+		{"Converted", StatusConverted},
+		{"Needs attention", StatusUnconverted},
+		{"Dropped", StatusDropped},
+	} {
+		if counts[group.status] == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", group.title)
+		for _, r := range results {
+			if r.Status != group.status {
+				continue
+			}
+			fmt.Fprintf(w, "  %-12s [%s] %s\n", r.Lines(), r.Kind, summary(r.Text))
+			if r.Reason != "" {
+				fmt.Fprintf(w, "  %-12s %s\n", "", r.Reason)
+			}
+			if r.Suggestion != "" {
+				fmt.Fprintf(w, "  %-12s suggestion available in the migrated file\n", "")
+			}
+		}
+	}
+}
+
+// summary shortens a block to its first line.
+func summary(text string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+	const max = 60
+	if runes := []rune(line); len(runes) > max {
+		line = string(runes[:max-3]) + "..."
+		more = false
+	}
+	if more {
+		line += " ..."
+	}
+	return line
+}
@@ -0,0 +1,133 @@
+// Package migrate converts zsh and bash rc files into bish configuration and
+// reports what could not be converted.
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Block is a logical unit of an rc file: a single line, or a multi-line
+// construct such as a function, an if statement or a continued line.
+type Block struct {
+	// StartLine and EndLine are 1-based and inclusive.
+	StartLine int
+	EndLine   int
+	Text      string
+}
+
+// Lines returns "line N" or "lines N-M".
+func (b Block) Lines() string {
+	if b.StartLine == b.EndLine {
+		return fmt.Sprintf("line %d", b.StartLine)
+	}
+	return fmt.Sprintf("lines %d-%d", b.StartLine, b.EndLine)
+}
+
+// parse parses text as bash, the dialect bish understands.
+func parse(text string) (*syntax.File, error) {
+	return syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(text), "")
+}
+
+// Segment splits an rc file into blocks. Lines are joined while they end in a
+// backslash or the bash parser reports the statement as incomplete. Constructs that bash cannot
+// parse at all, such as zsh-only syntax inside a function, are grouped by
+// counting braces and block keywords instead.
+func Segment(content string) []Block {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		return nil
+	}
+
+	var blocks []Block
+	for i := 0; i < len(lines); {
+		end := i
+		for {
+			text := strings.Join(lines[i:end+1], "\n")
+			_, err := parse(text)
+			continued := strings.HasSuffix(lines[end], `\`)
+			if (err == nil && !continued) || end == len(lines)-1 {
+				break
+			}
+			if err != nil && !syntax.IsIncomplete(err) {
+				end = fallbackEnd(lines, i)
+				break
+			}
+			end++
+		}
+		blocks = append(blocks, Block{
+			StartLine: i + 1,
+			EndLine:   end + 1,
+			Text:      strings.Join(lines[i:end+1], "\n"),
+		})
+		i = end + 1
+	}
+	return blocks
+}
+
+// openers and closers are the words that open and close nested constructs.
+// Parentheses are left out since case patterns leave them unbalanced.
+var (
+	openers = map[string]bool{"{": true, "if": true, "case": true, "do": true}
+	closers = map[string]bool{"}": true, "fi": true, "esac": true, "done": true}
+)
+
+// fallbackEnd returns the last line of the construct starting at line start by
+// counting openers and closers, ignoring comments and quoted text.
+func fallbackEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		for _, word := range words(lines[i]) {
+			switch {
+			case openers[word]:
+				depth++
+			case closers[word]:
+				depth--
+			}
+		}
+		continued := strings.HasSuffix(strings.TrimSpace(lines[i]), `\`)
+		if depth <= 0 && !continued {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// words splits a line into shell words, dropping quoted text and comments and
+// separating braces and semicolons.
+func words(line string) []string {
+	var result []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			result = append(result, current.String())
+			current.Reset()
+		}
+	}
+
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '#' && current.Len() == 0:
+			flush()
+			return result
+		case r == ' ' || r == '\t' || r == ';':
+			flush()
+		case r == '{' || r == '}':
+			flush()
+			result = append(result, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return result
+}
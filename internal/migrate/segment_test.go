@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegment(t *testing.T) {
+	content := `# comment
+alias ll='ls -l'
+mkcd() {
+  mkdir -p "$1" && cd "$1"
+}
+export PATH=/a:\
+/b
+function zfn {
+  print -l ${(s/:/)PATH}
+}
+echo done
+`
+	blocks := Segment(content)
+	var ranges [][2]int
+	for _, b := range blocks {
+		ranges = append(ranges, [2]int{b.StartLine, b.EndLine})
+	}
+	assert.Equal(t, [][2]int{{1, 1}, {2, 2}, {3, 5}, {6, 7}, {8, 10}, {11, 11}}, ranges)
+	assert.Equal(t, "function zfn {\n  print -l ${(s/:/)PATH}\n}", blocks[4].Text)
+	assert.Equal(t, "lines 3-5", blocks[2].Lines())
+	assert.Equal(t, "line 1", blocks[0].Lines())
+
+	assert.Nil(t, Segment(""))
+}
+
+func TestSegmentFallbackIgnoresCasePatterns(t *testing.T) {
+	content := `f() {
+  case $1 in
+    a) print ${(U)1} ;;
+  esac
+}
+echo after`
+	blocks := Segment(content)
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, 5, blocks[0].EndLine)
+}
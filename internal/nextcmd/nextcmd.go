@@ -0,0 +1,90 @@
+// Package nextcmd ranks the commands a user is likely to run next on an
+// empty prompt, from how often each was run in the same directory, at the
+// same time of day and right after the last command.
+package nextcmd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// MaxItems is how many commands the menu offers, one per digit key.
+const MaxItems = 9
+
+// Weights of the signals a command is scored on. Each run of a command
+// counts once, plus the bonuses that apply to it; each time it followed the
+// last command counts followWeight more.
+const (
+	sameDirectoryWeight = 2
+	sameHourWeight      = 1
+	followWeight        = 5
+)
+
+// Situation is what the prediction is based on.
+type Situation struct {
+	Directory string
+	Now       time.Time
+	// Last is the command run just before, or "" at the start of a session
+	Last string
+}
+
+// Rank returns up to limit distinct commands from entries, which are ordered
+// most recent first, the likeliest first. Ties go to the most recent.
+func Rank(entries []history.HistoryEntry, situation Situation, limit int) []string {
+	scores := map[string]int{}
+	var order []string
+	for i, entry := range entries {
+		command := strings.TrimSpace(entry.Command)
+		if command == "" || strings.Contains(command, "\n") {
+			continue
+		}
+		if _, ok := scores[command]; !ok {
+			order = append(order, command)
+		}
+		score := 1
+		if situation.Directory != "" && entry.Directory == situation.Directory {
+			score += sameDirectoryWeight
+		}
+		if !situation.Now.IsZero() && hourDistance(entry.CreatedAt.Hour(), situation.Now.Hour()) <= 1 {
+			score += sameHourWeight
+		}
+		// entries[i+1] ran just before this one in the same session
+		if situation.Last != "" && i+1 < len(entries) && entries[i+1].SessionID == entry.SessionID &&
+			strings.TrimSpace(entries[i+1].Command) == situation.Last {
+			score += followWeight
+		}
+		scores[command] += score
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	return order
+}
+
+// LastCommand returns the most recent command of the session in entries,
+// which are ordered most recent first, or "" if it has not run any.
+func LastCommand(entries []history.HistoryEntry, sessionID string) string {
+	for _, entry := range entries {
+		if entry.SessionID == sessionID {
+			return strings.TrimSpace(entry.Command)
+		}
+	}
+	return ""
+}
+
+// hourDistance is how many hours apart two hours of the day are, going
+// around midnight.
+func hourDistance(a, b int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return min(d, 24-d)
+}
@@ -0,0 +1,74 @@
+package nextcmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func entry(command, dir string, hour int, session string) history.HistoryEntry {
+	return history.HistoryEntry{
+		Command:   command,
+		Directory: dir,
+		CreatedAt: time.Date(2026, 3, 2, hour, 0, 0, 0, time.UTC),
+		SessionID: session,
+	}
+}
+
+func TestRankPrefersFrequentCommandsInTheDirectory(t *testing.T) {
+	// Most recent first
+	entries := []history.HistoryEntry{
+		entry("ls", "/home", 3, "a"),
+		entry("make test", "/src", 3, "a"),
+		entry("make test", "/src", 3, "a"),
+		entry("ls", "/home", 3, "a"),
+		entry("ls", "/home", 3, "a"),
+	}
+	situation := Situation{Directory: "/src", Now: time.Date(2026, 3, 3, 15, 0, 0, 0, time.UTC)}
+	// make test: 2 runs with the directory bonus beats ls: 3 runs without
+	assert.Equal(t, []string{"make test", "ls"}, Rank(entries, situation, MaxItems))
+}
+
+func TestRankFollowsTheLastCommand(t *testing.T) {
+	entries := []history.HistoryEntry{
+		entry("git push", "/src", 9, "a"),
+		entry("git commit", "/src", 9, "a"),
+		entry("ls", "/tmp", 20, "b"),
+		entry("ls", "/tmp", 20, "b"),
+		entry("git commit", "/src", 9, "b"),
+	}
+	situation := Situation{Directory: "/src", Now: time.Date(2026, 3, 3, 9, 30, 0, 0, time.UTC), Last: "git commit"}
+	ranked := Rank(entries, situation, MaxItems)
+	// git push followed git commit; so did one ls, in another session
+	assert.Equal(t, []string{"git push", "git commit", "ls"}, ranked)
+}
+
+func TestRankSkipsMultilineAndLimits(t *testing.T) {
+	entries := []history.HistoryEntry{
+		entry("for i in 1 2\ndo echo $i\ndone", "/", 1, "a"),
+		entry("  ", "/", 1, "a"),
+		entry("a", "/", 1, "a"),
+		entry("b", "/", 1, "a"),
+		entry("c", "/", 1, "a"),
+	}
+	assert.Equal(t, []string{"a", "b"}, Rank(entries, Situation{}, 2))
+	assert.Empty(t, Rank(nil, Situation{}, MaxItems))
+}
+
+func TestLastCommand(t *testing.T) {
+	entries := []history.HistoryEntry{
+		entry("vim notes", "/", 1, "other"),
+		entry("git status ", "/", 1, "mine"),
+		entry("ls", "/", 1, "mine"),
+	}
+	assert.Equal(t, "git status", LastCommand(entries, "mine"))
+	assert.Equal(t, "", LastCommand(entries, "new"))
+}
+
+func TestHourDistance(t *testing.T) {
+	assert.Equal(t, 1, hourDistance(23, 0))
+	assert.Equal(t, 3, hourDistance(9, 12))
+	assert.Equal(t, 0, hourDistance(5, 5))
+}
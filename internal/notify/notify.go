@@ -0,0 +1,39 @@
+// Package notify shows desktop notifications, for events that happen while
+// the user may be looking at another window, such as a timer ending or a
+// CI run finishing.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// Command returns the command that shows a desktop notification on goos, or
+// nil if there is no way to: notify-send on Linux and the BSDs, osascript on
+// macOS. lookPath finds a program, as exec.LookPath does.
+func Command(goos string, lookPath func(string) (string, error), title, body string) []string {
+	has := func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	}
+	switch {
+	case goos == "darwin" && has("osascript"):
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(body), strconv.Quote(title))
+		return []string{"osascript", "-e", script}
+	case goos != "windows" && has("notify-send"):
+		return []string{"notify-send", "--app-name=bish", title, body}
+	}
+	return nil
+}
+
+// Send shows a desktop notification when the system has a way to. It
+// reports whether one was shown.
+func Send(title, body string) bool {
+	args := Command(runtime.GOOS, exec.LookPath, title, body)
+	if args == nil {
+		return false
+	}
+	return exec.Command(args[0], args[1:]...).Run() == nil
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	lookPath := func(found ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, f := range found {
+				if f == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	assert.Equal(t, []string{"notify-send", "--app-name=bish", "Timer done", "review PR"}, Command("linux", lookPath("notify-send"), "Timer done", "review PR"))
+	assert.Equal(t, []string{"osascript", "-e", `display notification "review \"PR\"" with title "Timer done"`}, Command("darwin", lookPath("osascript"), "Timer done", `review "PR"`))
+	assert.Nil(t, Command("linux", lookPath(), "Timer done", "review PR"))
+	assert.Nil(t, Command("windows", lookPath("notify-send"), "Timer done", "review PR"))
+}
@@ -0,0 +1,51 @@
+package opener
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// NewOpenCommandHandler creates an ExecHandler for the o and open builtins,
+// which open each argument with platform p: URLs and files in their default
+// application, file:line in $EDITOR. open with options is left to the
+// system's own open command, as on macOS.
+func NewOpenCommandHandler(p Platform) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || (args[0] != "o" && args[0] != "open") {
+				return next(ctx, args)
+			}
+			if args[0] == "open" && len(args) > 1 && strings.HasPrefix(args[1], "-") {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) == 1 {
+				fmt.Fprintf(hc.Stderr, "Usage: %s <path|url|file:line>...\n", args[0])
+				return interp.NewExitStatus(2)
+			}
+			exists := func(path string) bool {
+				_, err := os.Stat(path)
+				return err == nil
+			}
+			getenv := func(name string) string {
+				return hc.Env.Get(name).String()
+			}
+			for _, arg := range args[1:] {
+				command, err := p.Command(Parse(arg, hc.Dir, exists), getenv)
+				if err != nil {
+					fmt.Fprintf(hc.Stderr, "%s: %s\n", args[0], err)
+					return interp.NewExitStatus(1)
+				}
+				if err := next(ctx, command); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package opener
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runOpen(t *testing.T, p Platform, dir, script string) (ran [][]string, stderr string, err error) {
+	t.Helper()
+	record := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			ran = append(ran, args)
+			return nil
+		}
+	}
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &out, &out),
+		interp.Dir(dir),
+		interp.ExecHandlers(NewOpenCommandHandler(p), record),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return ran, out.String(), err
+}
+
+func TestOpenBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	p := Platform{GOOS: "linux", LookPath: lookPath("xdg-open")}
+
+	ran, _, err := runOpen(t, p, dir, "EDITOR=nano; o report.pdf https://example.com main.go:42")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"xdg-open", filepath.Join(dir, "report.pdf")},
+		{"xdg-open", "https://example.com"},
+		{"nano", "+42", filepath.Join(dir, "main.go")},
+	}, ran)
+}
+
+func TestOpenBuiltinOnMacOS(t *testing.T) {
+	dir := t.TempDir()
+	p := Platform{GOOS: "darwin", LookPath: lookPath()}
+
+	ran, _, err := runOpen(t, p, dir, "open -a Safari index.html; open notes.md")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"open", "-a", "Safari", "index.html"},
+		{"open", filepath.Join(dir, "notes.md")},
+	}, ran)
+}
+
+func TestOpenBuiltinErrors(t *testing.T) {
+	p := Platform{GOOS: "linux", LookPath: lookPath()}
+
+	_, stderr, err := runOpen(t, p, t.TempDir(), "o")
+	_, isExit := interp.IsExitStatus(err)
+	assert.True(t, isExit)
+	assert.Contains(t, stderr, "Usage: o <path|url|file:line>")
+
+	_, stderr, err = runOpen(t, p, t.TempDir(), "o file.txt")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "o: no program to open files with")
+}
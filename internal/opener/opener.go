@@ -0,0 +1,153 @@
+// Package opener opens files and URLs the way the platform does: with
+// xdg-open, open, wslview or explorer.exe, or with $EDITOR for a file:line.
+package opener
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/wsl"
+)
+
+// Target is what to open: a URL, or a file, possibly at a line.
+type Target struct {
+	URL  string
+	Path string
+	// Line and Column are 0 when not given
+	Line   int
+	Column int
+}
+
+var (
+	urlScheme  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://|^(mailto|tel):`)
+	lineSuffix = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?:?$`)
+)
+
+// Parse reads an argument of o: a URL, a path relative to dir, or a path
+// followed by :line or :line:column, as compilers and grep -n print them.
+// exists tells whether a path exists, so that a file whose name really ends
+// in :42 still opens as a file.
+func Parse(arg, dir string, exists func(string) bool) Target {
+	if urlScheme.MatchString(arg) {
+		return Target{URL: arg}
+	}
+	path := arg
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if exists(path) {
+		return Target{Path: path}
+	}
+	if m := lineSuffix.FindStringSubmatch(path); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		return Target{Path: m[1], Line: line, Column: column}
+	}
+	return Target{Path: path}
+}
+
+// Platform is what the opener needs to know about the system.
+type Platform struct {
+	GOOS string
+	WSL  bool
+	// Distro is the WSL distribution, from WSL_DISTRO_NAME
+	Distro   string
+	LookPath func(string) (string, error)
+}
+
+// Current describes the system bish runs on.
+func Current() Platform {
+	return Platform{GOOS: runtime.GOOS, WSL: wsl.Detected(), Distro: os.Getenv("WSL_DISTRO_NAME"), LookPath: exec.LookPath}
+}
+
+func (p Platform) has(name string) bool {
+	_, err := p.LookPath(name)
+	return err == nil
+}
+
+// Handler returns the command that opens target with its default
+// application.
+func (p Platform) Handler(target Target) ([]string, error) {
+	arg := target.URL
+	if arg == "" {
+		arg = target.Path
+	}
+	switch {
+	case p.GOOS == "darwin":
+		return []string{"open", arg}, nil
+	case p.GOOS == "windows":
+		// Not cmd /c start, which would run what cmd makes of the
+		// metacharacters in arg
+		return []string{"rundll32", "url.dll,FileProtocolHandler", arg}, nil
+	case p.WSL && p.has("wslview"):
+		return []string{"wslview", arg}, nil
+	case p.WSL:
+		if target.Path != "" {
+			arg = wsl.ToWindows(target.Path, p.Distro)
+		}
+		return []string{"explorer.exe", arg}, nil
+	case p.has("xdg-open"):
+		return []string{"xdg-open", arg}, nil
+	}
+	return nil, fmt.Errorf("no program to open files with: install xdg-utils")
+}
+
+// EditorCommand returns the command that opens path at line and column in
+// editor, e.g. "vim" or "code --wait", with the syntax that editor takes.
+func EditorCommand(editor, path string, line, column int) []string {
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	if line == 0 {
+		return append(args, path)
+	}
+	position := fmt.Sprintf("%s:%d", path, line)
+	if column > 0 {
+		position += fmt.Sprintf(":%d", column)
+	}
+	switch filepath.Base(args[0]) {
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return append(args, "--goto", position)
+	case "subl", "hx", "helix", "zed", "micro":
+		return append(args, position)
+	case "vim", "nvim", "vi", "gvim", "mvim":
+		if column > 0 {
+			return append(args, fmt.Sprintf("+call cursor(%d,%d)", line, column), path)
+		}
+		return append(args, fmt.Sprintf("+%d", line), path)
+	case "emacs", "emacsclient":
+		if column > 0 {
+			return append(args, fmt.Sprintf("+%d:%d", line, column), path)
+		}
+		return append(args, fmt.Sprintf("+%d", line), path)
+	default:
+		// nano, kak, joe and most others take +line
+		return append(args, fmt.Sprintf("+%d", line), path)
+	}
+}
+
+// Command returns the command that opens target: its editor for a file at
+// a line, its default application otherwise. getenv reads VISUAL and
+// EDITOR.
+func (p Platform) Command(target Target, getenv func(string) string) ([]string, error) {
+	if target.Line > 0 {
+		editor := getenv("VISUAL")
+		if editor == "" {
+			editor = getenv("EDITOR")
+		}
+		return EditorCommand(editor, target.Path, target.Line, target.Column), nil
+	}
+	return p.Handler(target)
+}
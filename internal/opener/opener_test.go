@@ -0,0 +1,91 @@
+package opener
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func existing(paths ...string) func(string) bool {
+	return func(path string) bool {
+		for _, p := range paths {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestParse(t *testing.T) {
+	none := existing()
+	assert.Equal(t, Target{URL: "https://example.com/a?b=1"}, Parse("https://example.com/a?b=1", "/work", none))
+	assert.Equal(t, Target{URL: "mailto:me@example.com"}, Parse("mailto:me@example.com", "/work", none))
+	assert.Equal(t, Target{Path: "/work/report.pdf"}, Parse("report.pdf", "/work", none))
+	assert.Equal(t, Target{Path: "/work/main.go", Line: 42}, Parse("main.go:42", "/work", none))
+	assert.Equal(t, Target{Path: "/src/main.go", Line: 42, Column: 7}, Parse("/src/main.go:42:7:", "/work", none))
+	assert.Equal(t, Target{Path: "/work/odd:12"}, Parse("odd:12", "/work", existing("/work/odd:12")))
+}
+
+func lookPath(available ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	file := Target{Path: "/home/me/report.pdf"}
+	url := Target{URL: "https://example.com"}
+	tests := []struct {
+		platform Platform
+		target   Target
+		want     []string
+	}{
+		{Platform{GOOS: "darwin", LookPath: lookPath()}, file, []string{"open", "/home/me/report.pdf"}},
+		{Platform{GOOS: "linux", LookPath: lookPath("xdg-open")}, url, []string{"xdg-open", "https://example.com"}},
+		{Platform{GOOS: "linux", WSL: true, LookPath: lookPath("wslview", "xdg-open")}, file, []string{"wslview", "/home/me/report.pdf"}},
+		{Platform{GOOS: "linux", WSL: true, Distro: "Ubuntu", LookPath: lookPath("xdg-open")}, file, []string{"explorer.exe", `\\wsl.localhost\Ubuntu\home\me\report.pdf`}},
+		{Platform{GOOS: "linux", WSL: true, LookPath: lookPath()}, url, []string{"explorer.exe", "https://example.com"}},
+		{Platform{GOOS: "windows", LookPath: lookPath()}, file, []string{"rundll32", "url.dll,FileProtocolHandler", "/home/me/report.pdf"}},
+	}
+	for _, tt := range tests {
+		command, err := tt.platform.Handler(tt.target)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, command)
+	}
+
+	_, err := Platform{GOOS: "linux", LookPath: lookPath()}.Handler(file)
+	assert.ErrorContains(t, err, "install xdg-utils")
+}
+
+func TestEditorCommand(t *testing.T) {
+	assert.Equal(t, []string{"vim", "+42", "main.go"}, EditorCommand("vim", "main.go", 42, 0))
+	assert.Equal(t, []string{"/usr/bin/nvim", "+call cursor(42,7)", "main.go"}, EditorCommand("/usr/bin/nvim", "main.go", 42, 7))
+	assert.Equal(t, []string{"code", "--wait", "--goto", "main.go:42:7"}, EditorCommand("code --wait", "main.go", 42, 7))
+	assert.Equal(t, []string{"hx", "main.go:42"}, EditorCommand("hx", "main.go", 42, 0))
+	assert.Equal(t, []string{"emacs", "+42:7", "main.go"}, EditorCommand("emacs", "main.go", 42, 7))
+	assert.Equal(t, []string{"nano", "+42", "main.go"}, EditorCommand("nano", "main.go", 42, 3))
+	assert.Equal(t, []string{"vi", "+3", "main.go"}, EditorCommand("", "main.go", 3, 0))
+	assert.Equal(t, []string{"vim", "main.go"}, EditorCommand("vim", "main.go", 0, 0))
+}
+
+func TestCommand(t *testing.T) {
+	p := Platform{GOOS: "linux", LookPath: lookPath("xdg-open")}
+	env := map[string]string{"EDITOR": "vim", "VISUAL": "code --wait"}
+	getenv := func(name string) string { return env[name] }
+
+	command, err := p.Command(Target{Path: "/src/main.go", Line: 10}, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "--wait", "--goto", "/src/main.go:10"}, command)
+
+	command, err = p.Command(Target{Path: "/src/main.go"}, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xdg-open", "/src/main.go"}, command)
+}
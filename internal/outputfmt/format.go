@@ -0,0 +1,146 @@
+// Package outputfmt pretty-prints and highlights JSON and YAML written by
+// commands to the terminal, similar to piping the output through jq or yq,
+// and shows columnar output such as that of ps as a table to sort.
+package outputfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies a structured output format.
+type Format int
+
+const (
+	// FormatNone means the output is not recognized as structured data.
+	FormatNone Format = iota
+	FormatJSON
+	FormatYAML
+)
+
+var (
+	keyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	literalStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	commentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+
+	jsonTokenPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(\s*:)?|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|true|false|null`)
+	yamlKeyPattern   = regexp.MustCompile(`^(\s*(?:- )?)([^\s#'"][^:#]*|"[^"]*"|'[^']*'):(\s|$)`)
+	yamlNumber       = regexp.MustCompile(`^-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+)
+
+// Detect reports whether data is a JSON document or, if yamlHint is set or the
+// data starts with a "---" document marker, a YAML mapping or sequence. Plain
+// text that merely happens to parse as a YAML scalar is not recognized.
+func Detect(data []byte, yamlHint bool) Format {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return FormatNone
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return FormatJSON
+	}
+	if !yamlHint && !bytes.HasPrefix(trimmed, []byte("---")) {
+		return FormatNone
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(trimmed, &node); err != nil || len(node.Content) == 0 {
+		return FormatNone
+	}
+	if kind := node.Content[0].Kind; kind != yaml.MappingNode && kind != yaml.SequenceNode {
+		return FormatNone
+	}
+	return FormatYAML
+}
+
+// Pretty returns data pretty-printed and highlighted according to format.
+// It returns false if data cannot be formatted.
+func Pretty(data []byte, format Format) (string, bool) {
+	trimmed := bytes.TrimSpace(data)
+	switch format {
+	case FormatJSON:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, trimmed, "", "  "); err != nil {
+			return "", false
+		}
+		return highlightJSON(indented.String()), true
+	case FormatYAML:
+		return highlightYAML(string(trimmed)), true
+	default:
+		return "", false
+	}
+}
+
+// highlightJSON colors keys, strings, numbers and literals in indented JSON.
+func highlightJSON(s string) string {
+	return jsonTokenPattern.ReplaceAllStringFunc(s, func(token string) string {
+		switch {
+		case strings.HasSuffix(token, ":") && strings.HasPrefix(token, `"`):
+			key := strings.TrimRight(strings.TrimSuffix(token, ":"), " \t")
+			return keyStyle.Render(key) + token[len(key):]
+		case strings.HasPrefix(token, `"`):
+			return stringStyle.Render(token)
+		case token == "true" || token == "false" || token == "null":
+			return literalStyle.Render(token)
+		default:
+			return numberStyle.Render(token)
+		}
+	})
+}
+
+// highlightYAML colors keys, comments and scalar values line by line. The
+// document is not re-encoded, so comments, anchors and key order are kept.
+func highlightYAML(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = commentStyle.Render(line)
+			continue
+		case trimmed == "---" || trimmed == "...":
+			lines[i] = commentStyle.Render(line)
+			continue
+		}
+
+		prefix, value := line, ""
+		if match := yamlKeyPattern.FindStringSubmatchIndex(line); match != nil {
+			keyEnd := match[5]
+			prefix = line[:match[4]] + keyStyle.Render(line[match[4]:keyEnd]) + ":"
+			value = line[keyEnd+1:]
+		} else if rest, ok := strings.CutPrefix(trimmed, "- "); ok {
+			indent := line[:len(line)-len(trimmed)]
+			prefix, value = indent+"- ", rest
+		} else {
+			continue
+		}
+		lines[i] = prefix + highlightYAMLValue(value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightYAMLValue(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return value
+	}
+	leading := value[:strings.Index(value, trimmed)]
+	switch {
+	case trimmed == "true" || trimmed == "false" || trimmed == "null" || trimmed == "~":
+		return leading + literalStyle.Render(trimmed)
+	case yamlNumber.MatchString(trimmed):
+		return leading + numberStyle.Render(trimmed)
+	case trimmed == "|" || trimmed == ">" || trimmed == "|-" || trimmed == ">-" ||
+		strings.HasPrefix(trimmed, "&") || strings.HasPrefix(trimmed, "*"):
+		return value
+	default:
+		return leading + stringStyle.Render(trimmed)
+	}
+}
@@ -0,0 +1,71 @@
+package outputfmt
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		yamlHint bool
+		expected Format
+	}{
+		{name: "json object", data: `{"a": 1}`, expected: FormatJSON},
+		{name: "json array with whitespace", data: "\n  [1, 2]\n", expected: FormatJSON},
+		{name: "invalid json", data: `{"a": `, expected: FormatNone},
+		{name: "plain text", data: "hello world", expected: FormatNone},
+		{name: "yaml without hint", data: "a: 1\nb: two\n", expected: FormatNone},
+		{name: "yaml with hint", data: "a: 1\nb: two\n", yamlHint: true, expected: FormatYAML},
+		{name: "yaml document marker", data: "---\n- a\n- b\n", expected: FormatYAML},
+		{name: "yaml scalar", data: "just text", yamlHint: true, expected: FormatNone},
+		{name: "empty", data: "  \n", expected: FormatNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Detect([]byte(tt.data), tt.yamlHint))
+		})
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	out, ok := Pretty([]byte(`{"name":"bish","tags":["a"],"n":1.5,"ok":true,"x":null}`), FormatJSON)
+	assert.True(t, ok)
+	assert.Equal(t, `{
+  "name": "bish",
+  "tags": [
+    "a"
+  ],
+  "n": 1.5,
+  "ok": true,
+  "x": null
+}`, out)
+
+	_, ok = Pretty([]byte(`{`), FormatJSON)
+	assert.False(t, ok)
+	_, ok = Pretty([]byte(`text`), FormatNone)
+	assert.False(t, ok)
+}
+
+func TestPrettyHighlightsTokens(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+
+	out, ok := Pretty([]byte(`{"key":"value","n":2}`), FormatJSON)
+	assert.True(t, ok)
+	assert.Contains(t, out, keyStyle.Render(`"key"`)+": "+stringStyle.Render(`"value"`))
+	assert.Contains(t, out, numberStyle.Render("2"))
+
+	out, ok = Pretty([]byte("# comment\nname: web\nreplicas: 3\nitems:\n  - enabled: true\n"), FormatYAML)
+	assert.True(t, ok)
+	assert.Contains(t, out, commentStyle.Render("# comment"))
+	assert.Contains(t, out, keyStyle.Render("name")+": "+stringStyle.Render("web"))
+	assert.Contains(t, out, numberStyle.Render("3"))
+	assert.Contains(t, out, "  - "+keyStyle.Render("enabled")+": "+literalStyle.Render("true"))
+}
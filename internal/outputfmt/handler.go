@@ -0,0 +1,369 @@
+package outputfmt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/robottwo/bishop/internal/jobs"
+	"golang.org/x/term"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const (
+	// DefaultCommands lists the commands whose output is formatted when
+	// BISH_FORMAT_OUTPUT_COMMANDS is not set. Like the table commands, an
+	// entry matches the command and its first arguments.
+	DefaultCommands = "curl,kubectl get,kubectl describe,aws,gcloud,az,jq,yq,cat,terraform"
+	// DefaultMaxBytes is the size cap used when BISH_FORMAT_OUTPUT_MAX_BYTES
+	// is not set. Larger output is shown unchanged.
+	DefaultMaxBytes = 1 << 20
+	// DefaultTableCommands lists the commands whose columnar output is shown
+	// as a table when BISH_TABLE_OUTPUT_COMMANDS is not set. An entry matches
+	// the command and its first arguments.
+	DefaultTableCommands = "ps,df,kubectl get,docker ps,podman ps"
+)
+
+// isTerminal reports whether w is an interactive terminal. Tests override it.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// isInputTerminal reports whether r is an interactive terminal, which the
+// table view reads keys from. Tests override it.
+var isInputTerminal = func(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Recorder remembers the most recent formatted output so it can be shown raw.
+type Recorder struct {
+	mu        sync.Mutex
+	raw       string
+	formatted string
+	showRaw   bool
+}
+
+// DefaultRecorder is the session-wide recorder used by the exec handler and
+// the raw output keybinding.
+var DefaultRecorder = &Recorder{}
+
+func (r *Recorder) record(raw, formatted string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.raw, r.formatted, r.showRaw = raw, formatted, false
+}
+
+// Toggle alternates between the raw and formatted form of the last formatted
+// output, starting with the raw form. It returns "" if nothing was formatted.
+func (r *Recorder) Toggle() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.raw == "" {
+		return ""
+	}
+	r.showRaw = !r.showRaw
+	if r.showRaw {
+		return strings.TrimRight(r.raw, "\n")
+	}
+	return r.formatted
+}
+
+// NewFormatOutputHandler creates an ExecHandler that pretty-prints and
+// highlights JSON and YAML written by the commands listed in
+// BISH_FORMAT_OUTPUT_COMMANDS, and shows the columnar output of those listed
+// in BISH_TABLE_OUTPUT_COMMANDS as a table to scroll and sort. Each is only
+// active when BISH_FORMAT_OUTPUT or BISH_TABLE_OUTPUT is enabled and stdout
+// is a terminal; redirected and piped output is never touched, and neither
+// are interactive commands such as kubectl exec -it. It must come
+// after the builtin handlers and right before jobs.NewExecHandler, to which
+// it hands the matching external commands run as jobs, with their output
+// going through it; it runs the others itself.
+func NewFormatOutputHandler(recorder *Recorder) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+			hc := interp.HandlerCtx(ctx)
+			if !isTerminal(hc.Stdout) || interactive(args, hc.Stdin) {
+				return next(ctx, args)
+			}
+			format := enabled(hc.Env) && commandListed(hc.Env, args)
+			table := tableEnabled(hc.Env) && tableCommandListed(hc.Env, args) && !watching(args) && isInputTerminal(hc.Stdin)
+			if !format && !table {
+				return next(ctx, args)
+			}
+
+			path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+			if err != nil {
+				return next(ctx, args)
+			}
+			if table {
+				return runTable(ctx, hc, next, path, args, format, recorder)
+			}
+
+			writer := NewWriter(hc.Stdout, maxBytes(hc.Env), hasYAMLHint(args))
+			runErr := run(ctx, hc, next, path, args, writer)
+
+			raw, formatted, err := writer.Finish()
+			if err != nil {
+				return err
+			}
+			if formatted != "" {
+				recorder.record(raw, formatted)
+			}
+			return exitStatus(runErr, hc.Stderr)
+		}
+	}
+}
+
+// runTable runs a command whose output may be a table. The output is held
+// back until the command is done, unless it grows past the size cap; then it
+// is shown in the table view if it is a table, or as it would have been
+// otherwise.
+func runTable(ctx context.Context, hc interp.HandlerContext, next interp.ExecHandlerFunc, path string, args []string, format bool, recorder *Recorder) error {
+	buffer := &tableBuffer{out: hc.Stdout, maxBytes: maxBytes(hc.Env)}
+	runErr := run(ctx, hc, next, path, args, buffer)
+	if buffer.passthrough {
+		return exitStatus(runErr, hc.Stderr)
+	}
+
+	data := buffer.buf.Bytes()
+	if t, ok := ParseTable(data); ok {
+		final, err := showTable(t, string(data), hc.Stdin, hc.Stdout)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(hc.Stdout, final+"\n"); err != nil {
+			return err
+		}
+		recorder.record(string(data), final)
+		return exitStatus(runErr, hc.Stderr)
+	}
+
+	if format {
+		writer := NewWriter(hc.Stdout, maxBytes(hc.Env), hasYAMLHint(args))
+		_, _ = writer.Write(data)
+		raw, formatted, err := writer.Finish()
+		if err != nil {
+			return err
+		}
+		if formatted != "" {
+			recorder.record(raw, formatted)
+		}
+	} else if _, err := hc.Stdout.Write(data); err != nil {
+		return err
+	}
+	return exitStatus(runErr, hc.Stderr)
+}
+
+// run runs the external command at path with its stdout going to stdout.
+// Commands run as jobs go to next, which runs them under job control.
+func run(ctx context.Context, hc interp.HandlerContext, next interp.ExecHandlerFunc, path string, args []string, stdout io.Writer) error {
+	if jobs.Controlled(ctx) {
+		return next(jobs.WithStdout(ctx, stdout), args)
+	}
+	cmd := exec.CommandContext(ctx, path, args[1:]...)
+	cmd.Args = args
+	cmd.Env = execEnv(hc.Env)
+	cmd.Dir = hc.Dir
+	cmd.Stdin = hc.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = hc.Stderr
+	return cmd.Run()
+}
+
+// tableBuffer holds back a command's output up to maxBytes, then writes it
+// out and passes the rest straight through.
+type tableBuffer struct {
+	out         io.Writer
+	maxBytes    int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (b *tableBuffer) Write(p []byte) (int, error) {
+	if b.passthrough {
+		return b.out.Write(p)
+	}
+	b.buf.Write(p)
+	if b.buf.Len() > b.maxBytes {
+		b.passthrough = true
+		if _, err := b.out.Write(b.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		b.buf.Reset()
+	}
+	return len(p), nil
+}
+
+func enabled(env expand.Environ) bool {
+	value := strings.ToLower(env.Get("BISH_FORMAT_OUTPUT").String())
+	return value == "1" || value == "true"
+}
+
+func tableEnabled(env expand.Environ) bool {
+	value := strings.ToLower(env.Get("BISH_TABLE_OUTPUT").String())
+	return value == "1" || value == "true"
+}
+
+// tableCommandListed reports whether args start with one of the commands in
+// BISH_TABLE_OUTPUT_COMMANDS, such as "kubectl get".
+func tableCommandListed(env expand.Environ, args []string) bool {
+	commands := env.Get("BISH_TABLE_OUTPUT_COMMANDS").String()
+	if !env.Get("BISH_TABLE_OUTPUT_COMMANDS").IsSet() {
+		commands = DefaultTableCommands
+	}
+	for _, command := range strings.Split(commands, ",") {
+		words := strings.Fields(command)
+		if len(words) > 0 && len(words) <= len(args) && slices.Equal(words, args[:len(words)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watching reports whether the command keeps printing, as kubectl get -w
+// does, so that its output cannot be held back until it is done.
+func watching(args []string) bool {
+	for _, arg := range args[1:] {
+		if arg == "-w" || arg == "--watch" || arg == "--watch-only" || strings.HasPrefix(arg, "--watch=") {
+			return true
+		}
+	}
+	return false
+}
+
+// commandListed reports whether args start with one of the commands in
+// BISH_FORMAT_OUTPUT_COMMANDS, such as "curl" or "kubectl get".
+func commandListed(env expand.Environ, args []string) bool {
+	commands := env.Get("BISH_FORMAT_OUTPUT_COMMANDS").String()
+	if !env.Get("BISH_FORMAT_OUTPUT_COMMANDS").IsSet() {
+		commands = DefaultCommands
+	}
+	for _, command := range strings.Split(commands, ",") {
+		words := strings.Fields(command)
+		if len(words) > 0 && len(words) <= len(args) && slices.Equal(words, args[:len(words)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// interactiveSubcommands are the subcommands, as of kubectl or docker, that
+// talk to the user and need the terminal as their stdout.
+var interactiveSubcommands = map[string]bool{
+	"attach": true,
+	"debug":  true,
+	"edit":   true,
+	"exec":   true,
+	"run":    true,
+}
+
+// interactive reports whether the command needs the terminal as its stdout,
+// which it cannot have when its output goes through the formatter: it runs
+// an interactive subcommand, asks for a terminal with -i or -t, or is cat
+// reading what is typed.
+func interactive(args []string, stdin io.Reader) bool {
+	operands := 0
+	for i, arg := range args[1:] {
+		if arg == "--" {
+			operands += len(args) - i - 2
+			break
+		}
+		if arg == "--stdin" || arg == "--tty" || strings.HasPrefix(arg, "--stdin=") || strings.HasPrefix(arg, "--tty=") {
+			return true
+		}
+		if shortFlags(arg) {
+			if args[0] != "cat" && strings.ContainsAny(arg, "it") {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			continue
+		}
+		if operands == 0 && interactiveSubcommands[arg] {
+			return true
+		}
+		if arg != "-" {
+			operands++
+		}
+	}
+	return args[0] == "cat" && operands == 0 && isInputTerminal(stdin)
+}
+
+// shortFlags reports whether arg is a few single letter flags run together,
+// as in -it, rather than a long flag with a single dash, as in -json.
+func shortFlags(arg string) bool {
+	if len(arg) < 2 || len(arg) > 4 || arg[0] != '-' {
+		return false
+	}
+	for _, r := range arg[1:] {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func maxBytes(env expand.Environ) int {
+	if value, err := strconv.Atoi(env.Get("BISH_FORMAT_OUTPUT_MAX_BYTES").String()); err == nil && value > 0 {
+		return value
+	}
+	return DefaultMaxBytes
+}
+
+// hasYAMLHint reports whether the arguments ask for YAML output, as in
+// "kubectl get pod -o yaml" or "cat config.yml".
+func hasYAMLHint(args []string) bool {
+	for _, arg := range args[1:] {
+		lower := strings.ToLower(arg)
+		if strings.Contains(lower, "yaml") || strings.HasSuffix(lower, ".yml") {
+			return true
+		}
+	}
+	return false
+}
+
+// execEnv builds the environment of an external command from the exported
+// shell variables, as the interpreter's default exec handler does.
+func execEnv(env expand.Environ) []string {
+	var list []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		if vr.IsSet() && vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+		return true
+	})
+	return list
+}
+
+func exitStatus(err error, stderr io.Writer) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := interp.IsExitStatus(err); ok {
+		return err
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return interp.NewExitStatus(uint8(code))
+		}
+		return interp.NewExitStatus(1)
+	}
+	fmt.Fprintln(stderr, err)
+	return interp.NewExitStatus(126)
+}
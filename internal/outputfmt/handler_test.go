@@ -0,0 +1,240 @@
+package outputfmt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/robottwo/bishop/internal/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runFormatted(t *testing.T, recorder *Recorder, env []string, script string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron(append([]string{"PATH=" + os.Getenv("PATH")}, env...)...)),
+		interp.StdIO(nil, &stdout, io.Discard),
+		interp.ExecHandlers(NewFormatOutputHandler(recorder)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), err
+}
+
+func fakeTerminal(t *testing.T) {
+	original := isTerminal
+	isTerminal = func(w io.Writer) bool { _, ok := w.(*bytes.Buffer); return ok }
+	t.Cleanup(func() { isTerminal = original })
+}
+
+func TestFormatOutputHandler(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	fakeTerminal(t)
+
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "data.json")
+	yamlFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"a":[1,2]}`), 0o644))
+	require.NoError(t, os.WriteFile(yamlFile, []byte("name: web\n"), 0o644))
+
+	recorder := &Recorder{}
+	enabledEnv := []string{"BISH_FORMAT_OUTPUT=1"}
+
+	out, err := runFormatted(t, recorder, enabledEnv, "cat "+jsonFile)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n", out)
+
+	// Alt+R toggles between the raw and formatted output
+	assert.Equal(t, `{"a":[1,2]}`, recorder.Toggle())
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ]\n}", recorder.Toggle())
+
+	out, err = runFormatted(t, recorder, enabledEnv, "cat "+yamlFile)
+	require.NoError(t, err)
+	assert.Equal(t, "name: web\n", out)
+	assert.Equal(t, "name: web", recorder.Toggle())
+
+	// Disabled by default
+	out, err = runFormatted(t, recorder, nil, "cat "+jsonFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[1,2]}`, out)
+
+	// Commands not in the list are left alone
+	out, err = runFormatted(t, recorder, append(enabledEnv, "BISH_FORMAT_OUTPUT_COMMANDS=curl"), "cat "+jsonFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[1,2]}`, out)
+
+	// Output going to a pipe is never reformatted
+	out, err = runFormatted(t, recorder, enabledEnv, "cat "+jsonFile+" | head -c 100")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[1,2]}`, out)
+}
+
+func TestFormatOutputHandlerPreservesExitStatus(t *testing.T) {
+	fakeTerminal(t)
+
+	_, err := runFormatted(t, &Recorder{}, []string{"BISH_FORMAT_OUTPUT=1"}, "cat /nonexistent/file")
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), status)
+}
+
+func TestFormatOutputHandlerHandsJobsOn(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	fakeTerminal(t)
+
+	jsonFile := filepath.Join(t.TempDir(), "data.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"a":1}`), 0o644))
+
+	var handed []string
+	spy := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			handed = args
+			return next(ctx, args)
+		}
+	}
+	var stdout bytes.Buffer
+	table := jobs.NewTable()
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"), "BISH_FORMAT_OUTPUT=1")),
+		interp.StdIO(nil, &stdout, io.Discard),
+		interp.ExecHandlers(NewFormatOutputHandler(&Recorder{}), spy, jobs.NewExecHandler(table)),
+	)
+	require.NoError(t, err)
+	file, err := syntax.NewParser().Parse(strings.NewReader("cat "+jsonFile), "")
+	require.NoError(t, err)
+
+	// Commands run as jobs are run by the job control handler, with their
+	// output still formatted
+	require.NoError(t, runner.Run(table.Foreground(context.Background(), "cat "+jsonFile), file))
+	assert.Equal(t, []string{"cat", jsonFile}, handed)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", stdout.String())
+}
+
+func TestFormatOutputHandlerLeavesInteractiveCommands(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	fakeTerminal(t)
+
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\necho '{\"a\":1}'\n"), 0o755))
+	env := []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "BISH_FORMAT_OUTPUT=1"}
+
+	out, err := runFormatted(t, &Recorder{}, env, "kubectl get pod web -o json")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", out)
+
+	// These need the terminal as their stdout, so they get it
+	for _, command := range []string{
+		"kubectl exec -it web -- sh",
+		"kubectl exec web -- cat data.json",
+		"kubectl attach web",
+		"kubectl edit deployment web",
+		"kubectl logs web --stdin",
+	} {
+		out, err := runFormatted(t, &Recorder{}, append(env, "BISH_FORMAT_OUTPUT_COMMANDS=kubectl"), command)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":1}`+"\n", out, command)
+	}
+
+	// Only the output subcommands are formatted by default
+	out, err = runFormatted(t, &Recorder{}, env, "kubectl logs web")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`+"\n", out)
+}
+
+func TestInteractive(t *testing.T) {
+	original := isInputTerminal
+	isInputTerminal = func(io.Reader) bool { return true }
+	t.Cleanup(func() { isInputTerminal = original })
+
+	assert.True(t, interactive([]string{"kubectl", "exec", "-it", "web", "--", "sh"}, nil))
+	assert.True(t, interactive([]string{"docker", "run", "--rm", "alpine"}, nil))
+	assert.True(t, interactive([]string{"kubectl", "-t", "logs", "web"}, nil))
+	assert.True(t, interactive([]string{"cat"}, nil))
+	assert.True(t, interactive([]string{"cat", "-n", "-"}, nil))
+	assert.False(t, interactive([]string{"cat", "-t", "data.json"}, nil))
+	assert.False(t, interactive([]string{"kubectl", "get", "pod", "exec"}, nil))
+	assert.False(t, interactive([]string{"terraform", "output", "-json"}, nil))
+	assert.False(t, interactive([]string{"curl", "-sS", "https://example.com/run"}, nil))
+}
+
+func TestRecorderToggleWithoutOutput(t *testing.T) {
+	assert.Equal(t, "", (&Recorder{}).Toggle())
+}
+
+func TestHasYAMLHint(t *testing.T) {
+	assert.True(t, hasYAMLHint([]string{"kubectl", "get", "pod", "-o", "yaml"}))
+	assert.True(t, hasYAMLHint([]string{"cat", "ci.yml"}))
+	assert.False(t, hasYAMLHint([]string{"yq", "."}))
+	assert.False(t, hasYAMLHint([]string{"cat", "data.json"}))
+}
+
+func TestTableOutputHandler(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	fakeTerminal(t)
+	originalInput, originalShow := isInputTerminal, showTable
+	isInputTerminal = func(r io.Reader) bool { return r == nil }
+	var shown []Table
+	showTable = func(table Table, raw string, in io.Reader, out io.Writer) (string, error) {
+		shown = append(shown, table)
+		return table.Sorted(1, true).String(), nil
+	}
+	t.Cleanup(func() { isInputTerminal, showTable = originalInput, originalShow })
+
+	dir := t.TempDir()
+	tableFile := filepath.Join(dir, "pods.txt")
+	raw := "NAME  RESTARTS\nweb   3\ndb    12\n"
+	require.NoError(t, os.WriteFile(tableFile, []byte(raw), 0o644))
+	textFile := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(textFile, []byte("just some notes\n"), 0o644))
+
+	recorder := &Recorder{}
+	env := []string{"BISH_TABLE_OUTPUT=1", "BISH_TABLE_OUTPUT_COMMANDS=cat"}
+
+	out, err := runFormatted(t, recorder, env, "cat "+tableFile)
+	require.NoError(t, err)
+	require.Len(t, shown, 1)
+	assert.Equal(t, []string{"NAME", "RESTARTS"}, shown[0].Header)
+	assert.Equal(t, "NAME  RESTARTS\ndb          12\nweb          3\n", out)
+
+	// Alt+R toggles back to the raw output
+	assert.Equal(t, strings.TrimRight(raw, "\n"), recorder.Toggle())
+
+	// Output that is not a table is shown as it is
+	out, err = runFormatted(t, recorder, env, "cat "+textFile)
+	require.NoError(t, err)
+	assert.Equal(t, "just some notes\n", out)
+
+	// Neither are watches, piped or redirected output nor other commands
+	out, err = runFormatted(t, recorder, env, "cat "+tableFile+" --watch 2>/dev/null; cat "+tableFile+" | cat; cat "+tableFile+" > /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, raw, out)
+	out, err = runFormatted(t, recorder, []string{"BISH_TABLE_OUTPUT=1"}, "cat "+tableFile)
+	require.NoError(t, err)
+	assert.Equal(t, raw, out)
+	assert.Len(t, shown, 1)
+}
+
+func TestTableCommandListed(t *testing.T) {
+	env := expand.ListEnviron()
+	assert.True(t, tableCommandListed(env, []string{"kubectl", "get", "pods"}))
+	assert.False(t, tableCommandListed(env, []string{"kubectl", "describe", "pod", "web"}))
+	assert.True(t, tableCommandListed(env, []string{"ps"}))
+	assert.False(t, tableCommandListed(env, []string{"docker"}))
+	assert.True(t, watching([]string{"kubectl", "get", "pods", "-w"}))
+	assert.False(t, watching([]string{"kubectl", "get", "pods"}))
+}
@@ -0,0 +1,222 @@
+package outputfmt
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxTableRows is how many rows a table may have to be shown as one; longer
+// output is shown unchanged.
+const maxTableRows = 5000
+
+var (
+	tableHeaderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+
+	sizePattern     = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)([KMGTP]i?B?|B|%)?$`)
+	durationPattern = regexp.MustCompile(`^(?:\d+(?:\.\d+)?[smhdwy])+$`)
+	durationPart    = regexp.MustCompile(`(\d+(?:\.\d+)?)([smhdwy])`)
+)
+
+// Table is columnar command output, such as that of ps, df, kubectl get or
+// docker ps, split into its header and cells.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// ParseTable splits data into a table if it looks like one: a header line
+// whose column names start with a capital letter, and rows whose cells line
+// up under it. Columns are found from the positions that are blank on every
+// line, so both left- and right-aligned columns and empty cells are handled.
+func ParseTable(data []byte) (Table, bool) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.ContainsAny(text, "\t\x1b") {
+		return Table{}, false
+	}
+	var lines [][]rune
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, []rune(strings.TrimRight(line, " ")))
+		}
+	}
+	if len(lines) < 2 || len(lines) > maxTableRows+1 {
+		return Table{}, false
+	}
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line))
+	}
+	blank := make([]bool, width)
+	for i := range blank {
+		blank[i] = true
+		for _, line := range lines {
+			if i < len(line) && line[i] != ' ' {
+				blank[i] = false
+				break
+			}
+		}
+	}
+
+	// Columns are the runs of positions used on some line. A run without a
+	// name, or named in lower case like the "on" of df's "Mounted on", is the
+	// rest of the column before it.
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < width; i++ {
+		if blank[i] {
+			continue
+		}
+		start := i
+		for i < width && !blank[i] {
+			i++
+		}
+		name := strings.TrimSpace(cell(lines[0], start, i))
+		if len(spans) > 0 && (name == "" || unicode.IsLower([]rune(name)[0])) {
+			spans[len(spans)-1].end = i
+			continue
+		}
+		if name == "" || !(unicode.IsUpper([]rune(name)[0]) || name[0] == '%' || name[0] == '#') {
+			return Table{}, false
+		}
+		spans = append(spans, span{start, i})
+	}
+	if len(spans) < 2 {
+		return Table{}, false
+	}
+
+	t := Table{}
+	for _, s := range spans {
+		t.Header = append(t.Header, strings.TrimSpace(cell(lines[0], s.start, s.end)))
+	}
+	for _, line := range lines[1:] {
+		row := make([]string, len(spans))
+		for i, s := range spans {
+			row[i] = strings.TrimSpace(cell(line, s.start, s.end))
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, true
+}
+
+func cell(line []rune, start, end int) string {
+	if start >= len(line) {
+		return ""
+	}
+	return string(line[start:min(end, len(line))])
+}
+
+// Sorted returns a copy of t with its rows sorted by column, numerically if
+// its cells are numbers, sizes such as 12G or 5%, or durations such as 3d4h.
+func (t Table) Sorted(column int, descending bool) Table {
+	rows := make([][]string, len(t.Rows))
+	copy(rows, t.Rows)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if descending {
+			return lessCell(rows[j][column], rows[i][column])
+		}
+		return lessCell(rows[i][column], rows[j][column])
+	})
+	return Table{Header: t.Header, Rows: rows}
+}
+
+func lessCell(a, b string) bool {
+	x, xok := cellValue(a)
+	y, yok := cellValue(b)
+	if xok && yok {
+		return x < y
+	}
+	if xok != yok {
+		// Numbers before text such as "<none>"
+		return xok
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// cellValue reads a cell as a number, scaling sizes and durations so that
+// they compare by magnitude.
+func cellValue(s string) (float64, bool) {
+	if m := sizePattern.FindStringSubmatch(s); m != nil {
+		value, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		if unit := strings.ToUpper(strings.TrimSuffix(strings.TrimSuffix(m[2], "B"), "i")); unit != "" && unit != "%" {
+			value *= float64(uint64(1) << (10 * (strings.Index("KMGTP", unit) + 1)))
+		}
+		return value, true
+	}
+	if durationPattern.MatchString(s) {
+		seconds := map[string]float64{"s": 1, "m": 60, "h": 3600, "d": 86400, "w": 7 * 86400, "y": 365 * 86400}
+		total := 0.0
+		for _, m := range durationPart.FindAllStringSubmatch(s, -1) {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			total += value * seconds[m[2]]
+		}
+		return total, true
+	}
+	return 0, false
+}
+
+// Render lays out t with aligned columns, numbers on the right, cutting lines
+// to width if it is positive. marks are appended to the column names, e.g.
+// to show the sort order.
+func (t Table) Render(width int, marks map[int]string) []string {
+	widths := make([]int, len(t.Header))
+	numeric := make([]bool, len(t.Header))
+	for i, name := range t.Header {
+		widths[i] = len([]rune(name + marks[i]))
+		numeric[i] = len(t.Rows) > 0
+		for _, row := range t.Rows {
+			widths[i] = max(widths[i], len([]rune(row[i])))
+			if _, ok := cellValue(row[i]); !ok && row[i] != "" {
+				numeric[i] = false
+			}
+		}
+	}
+
+	layout := func(cells []string) string {
+		var sb strings.Builder
+		for i, c := range cells {
+			pad := strings.Repeat(" ", widths[i]-len([]rune(c)))
+			switch {
+			case i == len(cells)-1 && !numeric[i]:
+				sb.WriteString(c)
+			case numeric[i]:
+				sb.WriteString(pad + c)
+			default:
+				sb.WriteString(c + pad)
+			}
+			if i < len(cells)-1 {
+				sb.WriteString("  ")
+			}
+		}
+		line := strings.TrimRight(sb.String(), " ")
+		if runes := []rune(line); width > 1 && len(runes) > width {
+			line = string(runes[:width-1]) + "…"
+		}
+		return line
+	}
+
+	header := make([]string, len(t.Header))
+	for i, name := range t.Header {
+		header[i] = name + marks[i]
+	}
+	lines := []string{layout(header)}
+	for _, row := range t.Rows {
+		lines = append(lines, layout(row))
+	}
+	return lines
+}
+
+// String renders t for printing, with a highlighted header.
+func (t Table) String() string {
+	lines := t.Render(0, nil)
+	lines[0] = tableHeaderStyle.Render(lines[0])
+	return strings.Join(lines, "\n")
+}
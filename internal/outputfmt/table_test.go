@@ -0,0 +1,118 @@
+package outputfmt
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		header []string
+		rows   [][]string
+	}{
+		{
+			name: "ps",
+			output: "    PID TTY          TIME CMD\n" +
+				"   4211 pts/0    00:00:00 bash\n" +
+				" 104233 pts/0    00:00:01 ps\n",
+			header: []string{"PID", "TTY", "TIME", "CMD"},
+			rows:   [][]string{{"4211", "pts/0", "00:00:00", "bash"}, {"104233", "pts/0", "00:00:01", "ps"}},
+		},
+		{
+			name: "df",
+			output: "Filesystem      Size  Used Avail Use% Mounted on\n" +
+				"/dev/sda1        98G   41G   53G  44% /\n" +
+				"tmpfs           7.8G     0  7.8G   0% /dev/shm\n",
+			header: []string{"Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on"},
+			rows: [][]string{
+				{"/dev/sda1", "98G", "41G", "53G", "44%", "/"},
+				{"tmpfs", "7.8G", "0", "7.8G", "0%", "/dev/shm"},
+			},
+		},
+		{
+			name: "docker ps with an empty cell",
+			output: "CONTAINER ID   IMAGE     COMMAND                  STATUS         PORTS      NAMES\n" +
+				"a1b2c3d4e5f6   nginx     \"/docker-entrypoint.…\"   Up 2 minutes   80/tcp     web\n" +
+				"0f9e8d7c6b5a   redis     \"docker-entrypoint.s…\"   Up 5 hours                cache\n",
+			header: []string{"CONTAINER ID", "IMAGE", "COMMAND", "STATUS", "PORTS", "NAMES"},
+			rows: [][]string{
+				{"a1b2c3d4e5f6", "nginx", "\"/docker-entrypoint.…\"", "Up 2 minutes", "80/tcp", "web"},
+				{"0f9e8d7c6b5a", "redis", "\"docker-entrypoint.s…\"", "Up 5 hours", "", "cache"},
+			},
+		},
+		{
+			name: "ps aux with spaces in the command",
+			output: "USER         PID %CPU COMMAND\n" +
+				"root           1  0.0 /sbin/init splash\n" +
+				"alice       4211  1.5 vim notes.txt\n",
+			header: []string{"USER", "PID", "%CPU", "COMMAND"},
+			rows:   [][]string{{"root", "1", "0.0", "/sbin/init splash"}, {"alice", "4211", "1.5", "vim notes.txt"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, ok := ParseTable([]byte(tt.output))
+			require.True(t, ok)
+			assert.Equal(t, tt.header, table.Header)
+			assert.Equal(t, tt.rows, table.Rows)
+		})
+	}
+}
+
+func TestParseTableRejectsOtherOutput(t *testing.T) {
+	for _, output := range []string{
+		"",
+		"hello world\n",
+		"total 8\n-rw-r--r-- 1 alice staff 12 Oct 16 notes.txt\n",
+		"NAME\tREADY\nweb\t1/1\n",
+		"just one line of TEXT\n",
+		`{"items": []}`,
+	} {
+		_, ok := ParseTable([]byte(output))
+		assert.False(t, ok, output)
+	}
+}
+
+func TestTableSorted(t *testing.T) {
+	table := Table{
+		Header: []string{"NAME", "SIZE", "AGE"},
+		Rows: [][]string{
+			{"b", "1.5G", "3d"},
+			{"a", "900M", "12m"},
+			{"c", "<none>", "2h5m"},
+		},
+	}
+	names := func(t Table) []string {
+		var names []string
+		for _, row := range t.Rows {
+			names = append(names, row[0])
+		}
+		return names
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names(table.Sorted(0, false)))
+	assert.Equal(t, []string{"a", "b", "c"}, names(table.Sorted(1, false)))
+	assert.Equal(t, []string{"b", "c", "a"}, names(table.Sorted(2, true)))
+	// The table itself is left as it was
+	assert.Equal(t, []string{"b", "a", "c"}, names(table))
+}
+
+func TestTableRender(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	table := Table{
+		Header: []string{"NAME", "RESTARTS", "STATUS"},
+		Rows:   [][]string{{"web-7f9c", "3", "Running"}, {"db", "12", "CrashLoopBackOff"}},
+	}
+	assert.Equal(t, []string{
+		"NAME      RESTARTS ▼  STATUS",
+		"web-7f9c           3  Running",
+		"db                12  CrashLoopBackOff",
+	}, table.Render(0, map[int]string{1: " ▼"}))
+	assert.Equal(t, "db              12  CrashLoo…", table.Render(29, nil)[2])
+	assert.Equal(t, "NAME      RESTARTS  STATUS\nweb-7f9c         3  Running\ndb              12  CrashLoopBackOff", table.String())
+}
@@ -0,0 +1,187 @@
+package outputfmt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultTableRows  = 20
+	defaultTableWidth = 100
+)
+
+var (
+	tableColumnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	tableHelpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+)
+
+// tableModel shows a table below the command that printed it, to scroll
+// through and sort by any column, or switch back to the raw output.
+type tableModel struct {
+	table  Table
+	sorted Table
+	raw    string
+
+	column     int
+	sortColumn int
+	descending bool
+	offset     int
+	showRaw    bool
+
+	width int
+	rows  int
+	done  bool
+}
+
+func newTableModel(t Table, raw string) tableModel {
+	return tableModel{
+		table:      t,
+		sorted:     t,
+		raw:        strings.TrimRight(raw, "\n"),
+		sortColumn: -1,
+		width:      defaultTableWidth,
+		rows:       defaultTableRows,
+	}
+}
+
+func (m tableModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		// Leave room for the header, help and the prompt
+		m.rows = max(3, msg.Height-4)
+		m.offset = min(m.offset, m.maxOffset())
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "enter", "ctrl+c", "ctrl+d":
+			m.done = true
+			return m, tea.Quit
+		case "up", "k":
+			m.offset = max(0, m.offset-1)
+		case "down", "j":
+			m.offset = min(m.maxOffset(), m.offset+1)
+		case "pgup", "b":
+			m.offset = max(0, m.offset-m.rows)
+		case "pgdown", " ", "f":
+			m.offset = min(m.maxOffset(), m.offset+m.rows)
+		case "home", "g":
+			m.offset = 0
+		case "end", "G":
+			m.offset = m.maxOffset()
+		case "left", "h":
+			m.column = (m.column + len(m.table.Header) - 1) % len(m.table.Header)
+		case "right", "l", "tab":
+			m.column = (m.column + 1) % len(m.table.Header)
+		case "s":
+			// Sort by the chosen column, then reverse the order
+			if m.sortColumn == m.column {
+				m.descending = !m.descending
+			} else {
+				m.sortColumn, m.descending = m.column, false
+			}
+			m.sorted = m.table.Sorted(m.sortColumn, m.descending)
+			m.offset = 0
+		case "r":
+			m.showRaw = !m.showRaw
+			m.offset = min(m.offset, m.maxOffset())
+		}
+	}
+	return m, nil
+}
+
+// lines returns what is shown: the table, or the raw output.
+func (m tableModel) lines(width int) []string {
+	if m.showRaw {
+		return strings.Split(m.raw, "\n")
+	}
+	marks := map[int]string{}
+	if m.sortColumn >= 0 {
+		marks[m.sortColumn] = " ▲"
+		if m.descending {
+			marks[m.sortColumn] = " ▼"
+		}
+	}
+	return m.sorted.Render(width, marks)
+}
+
+func (m tableModel) maxOffset() int {
+	// The table's header stays in place while its rows scroll
+	body := len(m.lines(0))
+	if !m.showRaw {
+		body--
+	}
+	return max(0, body-m.rows)
+}
+
+func (m tableModel) View() string {
+	if m.done {
+		return ""
+	}
+	lines := m.lines(m.width)
+	var sb strings.Builder
+	if !m.showRaw {
+		sb.WriteString(m.header(lines[0]) + "\n")
+		lines = lines[1:]
+	}
+	end := min(len(lines), m.offset+m.rows)
+	for _, line := range lines[m.offset:end] {
+		sb.WriteString(line + "\n")
+	}
+	position := fmt.Sprintf("%d-%d of %d", min(m.offset+1, end), end, len(lines))
+	help := "↑↓/pgup/pgdn: scroll • ←→: column • s: sort • r: raw text • q: done"
+	if m.showRaw {
+		help = "↑↓/pgup/pgdn: scroll • r: table • q: done"
+	}
+	sb.WriteString(tableHelpStyle.Render(position + " • " + help))
+	return sb.String()
+}
+
+// header highlights the name of the chosen column in the header line.
+func (m tableModel) header(line string) string {
+	// Names are laid out in order, so the chosen one is found after the
+	// ones before it
+	at := 0
+	for i, name := range m.table.Header[:m.column+1] {
+		index := strings.Index(line[at:], name)
+		if index < 0 {
+			// Cut off at the edge of the terminal
+			return tableHeaderStyle.Render(line)
+		}
+		at += index
+		if i == m.column {
+			return tableHeaderStyle.Render(line[:at]) + tableColumnStyle.Render(name) + tableHeaderStyle.Render(line[at+len(name):])
+		}
+		at += len(name)
+	}
+	return tableHeaderStyle.Render(line)
+}
+
+// final is what stays in the terminal once the view is closed: the table as
+// last sorted, or the raw output if that was shown.
+func (m tableModel) final() string {
+	if m.showRaw {
+		return m.raw
+	}
+	if m.sortColumn < 0 {
+		return m.table.String()
+	}
+	return m.sorted.String()
+}
+
+// showTable runs the table view on the terminal and returns what it leaves
+// there. Tests override it.
+var showTable = func(t Table, raw string, in io.Reader, out io.Writer) (string, error) {
+	result, err := tea.NewProgram(newTableModel(t, raw), tea.WithInput(in), tea.WithOutput(out)).Run()
+	if err != nil {
+		return "", err
+	}
+	return result.(tableModel).final(), nil
+}
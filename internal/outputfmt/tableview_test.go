@@ -0,0 +1,61 @@
+package outputfmt
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func press(t *testing.T, m tableModel, keys ...string) tableModel {
+	t.Helper()
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "down", "right", "esc":
+			msg = tea.KeyMsg{Type: map[string]tea.KeyType{"down": tea.KeyDown, "right": tea.KeyRight, "esc": tea.KeyEsc}[k]}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(tableModel)
+	}
+	return m
+}
+
+func TestTableView(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	raw := "NAME  RESTARTS\nweb   3\ndb    12\napi   0\n"
+	table, ok := ParseTable([]byte(raw))
+	require.True(t, ok)
+
+	m := newTableModel(table, raw)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 6})
+	m = updated.(tableModel)
+	assert.Equal(t, 3, m.rows)
+	assert.Contains(t, m.View(), "1-3 of 3")
+
+	// Sort by RESTARTS, then reverse it
+	m = press(t, m, "right", "s")
+	assert.Equal(t, "NAME  RESTARTS\napi          0\nweb          3\ndb          12", m.sorted.String())
+	m = press(t, m, "s")
+	assert.Contains(t, m.View(), "RESTARTS ▼")
+	assert.Equal(t, "db", m.sorted.Rows[0][0])
+
+	// Scrolling stops at the last rows
+	m.rows = 2
+	m = press(t, m, "down", "down", "down")
+	assert.Equal(t, 1, m.offset)
+
+	// r switches to the raw output and back
+	m = press(t, m, "r")
+	assert.Contains(t, m.View(), "web   3")
+	assert.Equal(t, "NAME  RESTARTS\nweb   3\ndb    12\napi   0", m.final())
+	m = press(t, m, "r", "esc")
+	assert.True(t, m.done)
+	assert.Empty(t, m.View())
+	assert.Equal(t, "NAME  RESTARTS\ndb          12\nweb          3\napi          0", m.final())
+}
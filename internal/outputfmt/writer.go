@@ -0,0 +1,81 @@
+package outputfmt
+
+import (
+	"bytes"
+	"io"
+)
+
+// Writer buffers a command's output while it may still turn out to be a JSON
+// or YAML document, and passes everything else straight through. Output that
+// cannot be structured data (it does not start with "{", "[" or "---" and no
+// YAML hint was given) or that grows past the size cap is streamed unchanged,
+// so long-running commands are only held back when they look like JSON.
+type Writer struct {
+	out      io.Writer
+	maxBytes int
+	yamlHint bool
+
+	buf         bytes.Buffer
+	passthrough bool
+	decided     bool
+}
+
+// NewWriter creates a Writer that forwards to out and buffers at most maxBytes.
+func NewWriter(out io.Writer, maxBytes int, yamlHint bool) *Writer {
+	return &Writer{out: out, maxBytes: maxBytes, yamlHint: yamlHint}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.passthrough {
+		return w.out.Write(p)
+	}
+
+	w.buf.Write(p)
+	if !w.decided {
+		trimmed := bytes.TrimLeft(w.buf.Bytes(), " \t\r\n")
+		switch {
+		case len(trimmed) == 0:
+			return len(p), nil
+		case trimmed[0] == '{' || trimmed[0] == '[' || w.yamlHint:
+			w.decided = true
+		case trimmed[0] == '-' && len(trimmed) < 3:
+			// Might be the start of a "---" document marker
+			return len(p), nil
+		case bytes.HasPrefix(trimmed, []byte("---")):
+			w.decided = true
+		default:
+			return len(p), w.flush()
+		}
+	}
+
+	if w.buf.Len() > w.maxBytes {
+		return len(p), w.flush()
+	}
+	return len(p), nil
+}
+
+// flush writes the buffered output unchanged and switches to passthrough.
+func (w *Writer) flush() error {
+	w.passthrough = true
+	_, err := w.out.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Finish writes out whatever is still buffered, pretty-printed if it is a
+// complete JSON or YAML document. It returns the raw and formatted output when
+// formatting was applied, or empty strings otherwise.
+func (w *Writer) Finish() (raw, formatted string, err error) {
+	if w.passthrough {
+		return "", "", nil
+	}
+	data := w.buf.Bytes()
+	if pretty, ok := Pretty(data, Detect(data, w.yamlHint)); ok {
+		w.passthrough = true
+		raw = w.buf.String()
+		w.buf.Reset()
+		_, err = io.WriteString(w.out, pretty+"\n")
+		return raw, pretty, err
+	}
+	return "", "", w.flush()
+}
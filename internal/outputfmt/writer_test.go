@@ -0,0 +1,89 @@
+package outputfmt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterPassesThroughPlainText(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, 1024, false)
+
+	_, err := w.Write([]byte("  \nhello"))
+	require.NoError(t, err)
+	// Streamed immediately rather than held back until the command exits
+	assert.Equal(t, "  \nhello", out.String())
+
+	_, err = w.Write([]byte(" world\n"))
+	require.NoError(t, err)
+	raw, formatted, err := w.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, "  \nhello world\n", out.String())
+	assert.Empty(t, raw)
+	assert.Empty(t, formatted)
+}
+
+func TestWriterFormatsJSONSplitAcrossWrites(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	var out bytes.Buffer
+	w := NewWriter(&out, 1024, false)
+	for _, chunk := range []string{`{"a":`, `1}`, "\n"} {
+		_, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	assert.Empty(t, out.String())
+
+	raw, formatted, err := w.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", out.String())
+	assert.Equal(t, "{\"a\":1}\n", raw)
+	assert.Equal(t, "{\n  \"a\": 1\n}", formatted)
+}
+
+func TestWriterFallsBackToRaw(t *testing.T) {
+	// Looks like JSON but is not
+	var out bytes.Buffer
+	w := NewWriter(&out, 1024, false)
+	_, _ = w.Write([]byte("[INFO] starting\n"))
+	_, formatted, err := w.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, "[INFO] starting\n", out.String())
+	assert.Empty(t, formatted)
+
+	// Exceeds the size cap
+	out.Reset()
+	w = NewWriter(&out, 8, false)
+	_, _ = w.Write([]byte(`{"a": "long value"}`))
+	assert.Equal(t, `{"a": "long value"}`, out.String())
+	_, _ = w.Write([]byte("\n"))
+	_, formatted, err = w.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\": \"long value\"}\n", out.String())
+	assert.Empty(t, formatted)
+}
+
+func TestWriterYAMLDocumentMarker(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	var out bytes.Buffer
+	w := NewWriter(&out, 1024, false)
+	_, _ = w.Write([]byte("-"))
+	_, _ = w.Write([]byte("--\na: 1\n"))
+	assert.Empty(t, out.String())
+	_, formatted, err := w.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, "---\na: 1", formatted)
+
+	// A leading dash that turns out not to be a document marker is streamed
+	out.Reset()
+	w = NewWriter(&out, 1024, false)
+	_, _ = w.Write([]byte("-"))
+	_, _ = w.Write([]byte("rw-r--r-- file\n"))
+	assert.Equal(t, "-rw-r--r-- file\n", out.String())
+}
@@ -0,0 +1,87 @@
+package pathfix
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Match kinds, from most to least likely to be what the user meant.
+const (
+	matchCase = iota + 1
+	matchExtension
+	matchOneEdit
+	matchTwoEdits
+	noMatch
+)
+
+// closest returns the entry that name most likely misspells. It gives up
+// when the best candidates are equally likely, since guessing wrong is worse
+// than not suggesting anything.
+func closest(name string, entries []string) (string, bool) {
+	best, bestKind, ties := "", noMatch, 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, ".") && !strings.HasPrefix(name, ".") {
+			continue
+		}
+		kind := matchKind(name, entry)
+		switch {
+		case kind < bestKind:
+			best, bestKind, ties = entry, kind, 1
+		case kind == bestKind:
+			ties++
+		}
+	}
+	if bestKind == noMatch || ties > 1 {
+		return "", false
+	}
+	return best, true
+}
+
+func matchKind(name, entry string) int {
+	if name == entry {
+		return noMatch
+	}
+	if strings.EqualFold(name, entry) {
+		return matchCase
+	}
+	if ext := filepath.Ext(entry); ext != "" && ext != entry && strings.TrimSuffix(entry, ext) == name {
+		return matchExtension
+	}
+	switch distance(strings.ToLower(name), strings.ToLower(entry)) {
+	case 1:
+		return matchOneEdit
+	case 2:
+		// Two edits in a short name could turn it into almost anything
+		if len([]rune(name)) >= 6 {
+			return matchTwoEdits
+		}
+	}
+	return noMatch
+}
+
+// distance returns the edit distance between a and b, counting an insertion,
+// deletion, substitution or transposition of adjacent characters as one edit.
+func distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
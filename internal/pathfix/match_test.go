@@ -0,0 +1,43 @@
+package pathfix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("abc", "abc"))
+	assert.Equal(t, 1, distance("abc", "acb"))
+	assert.Equal(t, 1, distance("abc", "abcd"))
+	assert.Equal(t, 1, distance("abc", "ab"))
+	assert.Equal(t, 1, distance("abc", "abx"))
+	assert.Equal(t, 2, distance("main.go", "mian.og"))
+	assert.Equal(t, 3, distance("", "abc"))
+}
+
+func TestClosest(t *testing.T) {
+	entries := []string{"README.md", "Makefile", "main.go", "main_test.go", "docs", ".git", "config.yaml", "config.yml"}
+	tests := []struct {
+		name  string
+		want  string
+		found bool
+	}{
+		{"readme.md", "README.md", true},
+		{"makefile", "Makefile", true},
+		{"README", "README.md", true},
+		{"mian.go", "main.go", true},
+		{"main_tset.go", "main_test.go", true},
+		{"dcs", "docs", true},
+		{"git", "", false},
+		{"config", "", false},
+		{"config.yaaml", "config.yaml", true},
+		{"xyz", "", false},
+		{"main.go", "", false},
+	}
+	for _, tt := range tests {
+		got, found := closest(tt.name, entries)
+		assert.Equal(t, tt.found, found, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,258 @@
+// Package pathfix suggests corrections for commands that failed because a
+// path did not exist, when an existing path is a near miss: a different case,
+// two swapped letters, a missing extension or a small typo.
+package pathfix
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const notFoundMessage = "no such file or directory"
+
+// changeDirCommands fail without an error message when the directory does not
+// exist, so their argument is corrected even if stderr does not mention it.
+var changeDirCommands = map[string]bool{"cd": true, "pushd": true}
+
+// replacement is a corrected word of the command.
+type replacement struct {
+	start, end int
+	text       string
+}
+
+// suggester corrects the words of one failed command.
+type suggester struct {
+	dir, home string
+	// notFound holds the lower-cased lines of stderr that report a missing path.
+	notFound     []string
+	replacements []replacement
+}
+
+// Suggest returns command with each path it failed to find replaced by the
+// existing path it most likely misspells. stderr is the command's error
+// output, which must name the missing path; dir and home resolve relative
+// paths and ~. It returns false if there is nothing to correct.
+func Suggest(command, stderr, dir, home string) (string, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return "", false
+	}
+
+	s := &suggester{dir: dir, home: home}
+	for _, line := range strings.Split(strings.ToLower(stderr), "\n") {
+		if strings.Contains(line, notFoundMessage) {
+			s.notFound = append(s.notFound, line)
+		}
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.CallExpr:
+			if len(node.Args) == 0 {
+				break
+			}
+			name := node.Args[0].Lit()
+			if strings.Contains(name, "/") {
+				s.correct(node.Args[0], false, false)
+			}
+			for _, arg := range node.Args[1:] {
+				s.correct(arg, false, changeDirCommands[name])
+			}
+		case *syntax.Redirect:
+			switch node.Op {
+			case syntax.RdrIn:
+				s.correct(node.Word, false, false)
+			case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut:
+				s.correct(node.Word, true, false)
+			}
+		}
+		return true
+	})
+	if len(s.replacements) == 0 {
+		return "", false
+	}
+
+	sort.Slice(s.replacements, func(i, j int) bool {
+		return s.replacements[i].start > s.replacements[j].start
+	})
+	for _, r := range s.replacements {
+		command = command[:r.start] + r.text + command[r.end:]
+	}
+	return command, true
+}
+
+// correct records a replacement for word if it names a missing path that has
+// a near miss. The last element of an output path is allowed not to exist.
+func (s *suggester) correct(word *syntax.Word, output, always bool) {
+	value, tilde, ok := literal(word)
+	if !ok || value == "" || strings.HasPrefix(value, "-") {
+		return
+	}
+	path := s.resolve(value, tilde)
+	if _, err := os.Lstat(path); err == nil {
+		return
+	}
+	if !always && !s.reported(value, path) {
+		return
+	}
+
+	fixed, ok := s.fix(value, tilde, output)
+	if !ok {
+		return
+	}
+	text := quote(fixed)
+	if tilde {
+		text = "~/" + quote(strings.TrimPrefix(fixed, "~/"))
+	}
+	s.replacements = append(s.replacements, replacement{
+		start: int(word.Pos().Offset()),
+		end:   int(word.End().Offset()),
+		text:  text,
+	})
+}
+
+func (s *suggester) resolve(value string, tilde bool) string {
+	switch {
+	case tilde:
+		return filepath.Join(s.home, strings.TrimPrefix(value, "~/"))
+	case filepath.IsAbs(value):
+		return value
+	}
+	return filepath.Join(s.dir, value)
+}
+
+// reported reports whether stderr names the path as missing, either as typed
+// or as an absolute path.
+func (s *suggester) reported(value, path string) bool {
+	value, path = strings.ToLower(value), strings.ToLower(path)
+	for _, line := range s.notFound {
+		if strings.Contains(line, value) || strings.Contains(line, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// fix corrects the elements of value that do not exist, one directory level
+// at a time.
+func (s *suggester) fix(value string, tilde, output bool) (string, bool) {
+	base, rest := s.dir, value
+	prefix := ""
+	switch {
+	case tilde:
+		base, rest, prefix = s.home, strings.TrimPrefix(value, "~/"), "~/"
+	case filepath.IsAbs(value):
+		base, rest, prefix = "/", strings.TrimPrefix(value, "/"), "/"
+	}
+
+	elements := strings.Split(rest, "/")
+	last := len(elements) - 1
+	for last > 0 && elements[last] == "" {
+		last--
+	}
+
+	current, changed := base, false
+	for i, element := range elements {
+		if element == "" || element == "." || element == ".." {
+			current = filepath.Join(current, element)
+			continue
+		}
+		next := filepath.Join(current, element)
+		if _, err := os.Lstat(next); err == nil {
+			current = next
+			continue
+		}
+		if i == last && output {
+			break
+		}
+
+		match, ok := closest(element, entryNames(current, i < last))
+		if !ok {
+			return "", false
+		}
+		elements[i] = match
+		current = filepath.Join(current, match)
+		changed = true
+	}
+	return prefix + strings.Join(elements, "/"), changed
+}
+
+// entryNames lists the names in dir, only directories if dirsOnly is set.
+func entryNames(dir string, dirsOnly bool) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if dirsOnly && !isDir(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// isDir follows symlinks, unlike os.DirEntry.IsDir.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// literal returns the value of a word made only of literal text and quotes,
+// and whether it starts with an unquoted ~/.
+func literal(word *syntax.Word) (string, bool, bool) {
+	var sb strings.Builder
+	tilde := false
+	for i, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			if i == 0 && strings.HasPrefix(part.Value, "~/") {
+				tilde = true
+			}
+			sb.WriteString(unescape(part.Value, ""))
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return "", false, false
+			}
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false, false
+				}
+				sb.WriteString(unescape(lit.Value, "$`\"\\\n"))
+			}
+		default:
+			return "", false, false
+		}
+	}
+	return sb.String(), tilde, true
+}
+
+// unescape removes backslashes before the characters in special, or before
+// any character if special is empty.
+func unescape(s, special string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (special == "" || strings.IndexByte(special, s[i+1]) >= 0) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// quote quotes value for the shell if it contains special characters.
+func quote(value string) string {
+	quoted, err := syntax.Quote(value, syntax.LangBash)
+	if err != nil {
+		return value
+	}
+	return quoted
+}
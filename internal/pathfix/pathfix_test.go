@@ -0,0 +1,82 @@
+package pathfix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTree creates a directory with a few files and returns it along with a
+// home directory inside it.
+func testTree(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	for _, path := range []string{
+		"README.md",
+		"src/main.go",
+		"My Notes/todo.txt",
+		"home/Documents/report.pdf",
+		"scripts/deploy.sh",
+	} {
+		full := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, nil, 0o644))
+	}
+	return dir, home
+}
+
+func TestSuggest(t *testing.T) {
+	dir, home := testTree(t)
+	tests := []struct {
+		name    string
+		command string
+		stderr  string
+		want    string
+	}{
+		{"case", "cat readme.md", "cat: readme.md: No such file or directory", "cat README.md"},
+		{"missing extension", "less README", "README: No such file or directory", "less README.md"},
+		{"transposed directory", "vim scr/mian.go", "open scr/mian.go: no such file or directory", "vim src/main.go"},
+		{"absolute path in error", "cat < REDME.md", "open " + filepath.Join(dir, "REDME.md") + ": no such file or directory", "cat < README.md"},
+		{"quoted path with spaces", `cat "my notes/todo.txt"`, "cat: 'my notes/todo.txt': No such file or directory", `cat 'My Notes/todo.txt'`},
+		{"escaped path", `cat my\ notes/todo.txt`, "cat: my notes/todo.txt: No such file or directory", `cat 'My Notes/todo.txt'`},
+		{"tilde", "open ~/documents/report.pdf", "open: " + filepath.Join(home, "documents/report.pdf") + ": No such file or directory", "open ~/Documents/report.pdf"},
+		{"script", "./scripts/deplyo.sh --prod", "stat " + filepath.Join(dir, "scripts/deplyo.sh") + ": no such file or directory", "./scripts/deploy.sh --prod"},
+		{"cd without an error message", "cd Scr", "", "cd src"},
+		{"output parent directory", "echo hi > scr/new.txt", "open " + filepath.Join(dir, "scr/new.txt") + ": no such file or directory", "echo hi > src/new.txt"},
+		{"several paths", "diff readme.md scr/main.go", "diff: readme.md: No such file or directory\ndiff: scr/main.go: No such file or directory", "diff README.md src/main.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Suggest(tt.command, tt.stderr, dir, home)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSuggestNothing(t *testing.T) {
+	dir, home := testTree(t)
+	tests := []struct {
+		name    string
+		command string
+		stderr  string
+	}{
+		{"path not mentioned in the error", "cp readme.md copy.md", "cp: copy.md: No such file or directory"},
+		{"other error", "cat readme.md", "cat: readme.md: Permission denied"},
+		{"no near miss", "cat nothing.txt", "cat: nothing.txt: No such file or directory"},
+		{"existing path", "cat README.md", "cat: README.md: No such file or directory"},
+		{"new output file", "echo hi > notes.txt", "open notes.txt: no such file or directory"},
+		{"expansion", "cat $FILE", "cat: readme.md: No such file or directory"},
+		{"flag", "ls -readme", "ls: -readme: No such file or directory"},
+		{"parse error", "cat 'readme.md", "cat: readme.md: No such file or directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Suggest(tt.command, tt.stderr, dir, home)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package pathfmt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// NewPathCommandHandler creates an ExecHandler for the bish_path builtin, which
+// prints an abbreviated form of a directory for use in BISH_UPDATE_PROMPT:
+//
+//	BISH_PROMPT="$(bish_path --style git) > "
+//
+// The style defaults to BISH_PATH_STYLE and the directory to the current one.
+func NewPathCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "bish_path" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			styleName := hc.Env.Get("BISH_PATH_STYLE").String()
+			path := hc.Dir
+
+			for i := 1; i < len(args); i++ {
+				arg := args[i]
+				switch {
+				case arg == "-h" || arg == "--help":
+					fmt.Fprintln(hc.Stdout, "Usage: bish_path [--style auto|full|home|fish|git] [path]")
+					return nil
+				case arg == "-s" || arg == "--style":
+					if i+1 >= len(args) {
+						return fmt.Errorf("bish_path: %s requires a style", arg)
+					}
+					i++
+					styleName = args[i]
+				case strings.HasPrefix(arg, "--style="):
+					styleName = strings.TrimPrefix(arg, "--style=")
+				default:
+					path = arg
+				}
+			}
+
+			style := StyleAuto
+			if styleName != "" {
+				var ok bool
+				if style, ok = ParseStyle(styleName); !ok {
+					return fmt.Errorf("bish_path: unknown style %q", styleName)
+				}
+			}
+
+			var gitRoot string
+			if style == StyleGit {
+				gitRoot = FindGitRoot(path)
+			}
+			fmt.Fprintln(hc.Stdout, Shorten(path, hc.Env.Get("HOME").String(), gitRoot, style))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package pathfmt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runBishPath(t *testing.T, dir string, env []string, script string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	runner, err := interp.New(
+		interp.Dir(dir),
+		interp.Env(expand.ListEnviron(env...)),
+		interp.StdIO(nil, &stdout, &stdout),
+		interp.ExecHandlers(NewPathCommandHandler()),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), err
+}
+
+func TestPathCommandUsesConfiguredStyle(t *testing.T) {
+	home := t.TempDir()
+	repo := filepath.Join(home, "projects", "myrepo")
+	dir := filepath.Join(repo, "src", "api")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+
+	out, err := runBishPath(t, dir, []string{"HOME=" + home, "BISH_PATH_STYLE=git"}, `echo "[$(bish_path)]"`)
+	require.NoError(t, err)
+	assert.Equal(t, "[myrepo:src/api]\n", out)
+
+	out, err = runBishPath(t, dir, []string{"HOME=" + home, "BISH_PATH_STYLE=git"}, "bish_path --style fish")
+	require.NoError(t, err)
+	assert.Equal(t, "~/p/m/s/api\n", out)
+
+	out, err = runBishPath(t, dir, []string{"HOME=" + home}, "bish_path -s home /tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp\n", out)
+}
+
+func TestPathCommandRejectsUnknownStyle(t *testing.T) {
+	_, err := runBishPath(t, t.TempDir(), nil, "bish_path --style=powerline")
+	assert.ErrorContains(t, err, "unknown style")
+
+	_, err = runBishPath(t, t.TempDir(), nil, "bish_path --style")
+	assert.ErrorContains(t, err, "requires a style")
+}
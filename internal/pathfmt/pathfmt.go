@@ -0,0 +1,151 @@
+package pathfmt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Style selects how a directory path is abbreviated for display.
+type Style string
+
+const (
+	// StyleAuto keeps short paths as-is, abbreviates long paths under the home
+	// directory with "~" and reduces other long paths to ".../basename".
+	StyleAuto Style = "auto"
+	// StyleFull shows the absolute path.
+	StyleFull Style = "full"
+	// StyleHome replaces the home directory prefix with "~".
+	StyleHome Style = "home"
+	// StyleFish truncates every component except the last to its first letter,
+	// e.g. ~/p/bishop/internal becomes ~/p/b/internal.
+	StyleFish Style = "fish"
+	// StyleGit shows paths inside a git repository relative to the repository
+	// root, e.g. bishop:internal/core. Outside a repository it behaves like
+	// StyleHome.
+	StyleGit Style = "git"
+)
+
+// autoMaxLength is the length above which StyleAuto starts abbreviating.
+const autoMaxLength = 20
+
+// Styles lists all supported styles.
+var Styles = []Style{StyleAuto, StyleFull, StyleHome, StyleFish, StyleGit}
+
+// ParseStyle converts s to a Style. It returns false for unknown styles.
+func ParseStyle(s string) (Style, bool) {
+	style := Style(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Styles {
+		if style == known {
+			return style, true
+		}
+	}
+	return StyleAuto, false
+}
+
+// Shorten abbreviates path using style. home is the user's home directory and
+// gitRoot the root of the repository containing path; either may be empty.
+func Shorten(path, home, gitRoot string, style Style) string {
+	if path == "" {
+		return ""
+	}
+
+	switch style {
+	case StyleFull:
+		return path
+	case StyleHome:
+		return abbreviateHome(path, home)
+	case StyleFish:
+		return fishify(abbreviateHome(path, home))
+	case StyleGit:
+		if rel, ok := gitRelative(path, gitRoot); ok {
+			return rel
+		}
+		return abbreviateHome(path, home)
+	default:
+		if len(path) <= autoMaxLength {
+			return path
+		}
+		if home != "" && isWithin(path, home) {
+			return abbreviateHome(path, home)
+		}
+		base := filepath.Base(path)
+		if base == string(filepath.Separator) {
+			return base
+		}
+		return ".../" + base
+	}
+}
+
+// FindGitRoot returns the closest ancestor of dir (including dir itself) that
+// contains a .git entry, or "" if dir is not inside a git repository.
+func FindGitRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// isWithin reports whether path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	prefix := strings.TrimSuffix(dir, string(filepath.Separator)) + string(filepath.Separator)
+	return strings.HasPrefix(path, prefix)
+}
+
+// abbreviateHome replaces a leading home directory in path with "~".
+func abbreviateHome(path, home string) string {
+	if home == "" || home == string(filepath.Separator) || !isWithin(path, home) {
+		return path
+	}
+	return "~" + path[len(home):]
+}
+
+// fishify truncates each path component except the last to its first
+// character. Hidden directories keep their leading dot, so ".config" becomes
+// ".c".
+func fishify(path string) string {
+	parts := strings.Split(path, string(filepath.Separator))
+	for i := 0; i < len(parts)-1; i++ {
+		parts[i] = firstLetter(parts[i])
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
+
+func firstLetter(component string) string {
+	runes := []rune(component)
+	switch {
+	case len(runes) <= 1 || component == "~":
+		return component
+	case runes[0] == '.' && len(runes) > 1:
+		return string(runes[:2])
+	default:
+		return string(runes[:1])
+	}
+}
+
+// gitRelative formats path as "repo:relative/path" when it lies inside
+// gitRoot. At the repository root only the repository name is shown.
+func gitRelative(path, gitRoot string) (string, bool) {
+	if gitRoot == "" || !isWithin(path, gitRoot) {
+		return "", false
+	}
+	name := filepath.Base(gitRoot)
+	rel := strings.TrimPrefix(path[len(gitRoot):], string(filepath.Separator))
+	if rel == "" {
+		return name, true
+	}
+	return name + ":" + filepath.ToSlash(rel), true
+}
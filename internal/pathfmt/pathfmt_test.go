@@ -0,0 +1,65 @@
+package pathfmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShorten(t *testing.T) {
+	const home = "/home/alice"
+	const repo = "/home/alice/projects/bishop"
+
+	tests := []struct {
+		name     string
+		path     string
+		gitRoot  string
+		style    Style
+		expected string
+	}{
+		{name: "auto short path", path: "/usr/bin", style: StyleAuto, expected: "/usr/bin"},
+		{name: "auto long home path", path: "/home/alice/projects/bishop", style: StyleAuto, expected: "~/projects/bishop"},
+		{name: "auto long path outside home", path: "/var/lib/docker/volumes", style: StyleAuto, expected: ".../volumes"},
+		{name: "full", path: "/home/alice/projects", style: StyleFull, expected: "/home/alice/projects"},
+		{name: "home", path: "/home/alice/src", style: StyleHome, expected: "~/src"},
+		{name: "home itself", path: "/home/alice", style: StyleHome, expected: "~"},
+		{name: "home prefix is not home", path: "/home/alicia/src", style: StyleHome, expected: "/home/alicia/src"},
+		{name: "fish", path: "/home/alice/projects/bishop/internal", style: StyleFish, expected: "~/p/b/internal"},
+		{name: "fish hidden directory", path: "/home/alice/.config/bish", style: StyleFish, expected: "~/.c/bish"},
+		{name: "fish outside home", path: "/usr/local/share", style: StyleFish, expected: "/u/l/share"},
+		{name: "git subdirectory", path: "/home/alice/projects/bishop/internal/core", gitRoot: repo, style: StyleGit, expected: "bishop:internal/core"},
+		{name: "git root", path: repo, gitRoot: repo, style: StyleGit, expected: "bishop"},
+		{name: "git outside repository", path: "/home/alice/notes", style: StyleGit, expected: "~/notes"},
+		{name: "empty path", path: "", style: StyleFish, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Shorten(tt.path, home, tt.gitRoot, tt.style))
+		})
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	style, ok := ParseStyle(" Fish ")
+	assert.True(t, ok)
+	assert.Equal(t, StyleFish, style)
+
+	style, ok = ParseStyle("powerline")
+	assert.False(t, ok)
+	assert.Equal(t, StyleAuto, style)
+}
+
+func TestFindGitRoot(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "api")
+	assert.NoError(t, os.MkdirAll(nested, 0o755))
+	assert.Empty(t, FindGitRoot(nested))
+
+	assert.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	assert.Equal(t, root, FindGitRoot(nested))
+	assert.Equal(t, root, FindGitRoot(root))
+	assert.Empty(t, FindGitRoot(""))
+}
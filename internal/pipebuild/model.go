@@ -0,0 +1,283 @@
+package pipebuild
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	cursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	stageStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	countStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	outputStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+const (
+	defaultHeight = 24
+	defaultWidth  = 100
+)
+
+// ranMsg carries the stages back from a run.
+type ranMsg struct {
+	generation int
+	stages     []Stage
+}
+
+// model lets the user edit the stages of a pipeline one at a time, and
+// shows the output of the current stage on the sample the stages before it
+// produced.
+type model struct {
+	stages  []Stage
+	current int
+	input   textinput.Model
+	run     RunFunc
+
+	// generation tells the latest run from those it replaced
+	generation int
+	running    bool
+
+	height int
+	width  int
+
+	// pipeline is set when the user asks to insert the pipeline
+	pipeline string
+	accepted bool
+}
+
+func newModel(line string, run RunFunc) model {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Focus()
+
+	m := model{input: input, run: run, height: defaultHeight, width: defaultWidth}
+	for _, command := range Split(line) {
+		m.stages = append(m.stages, Stage{Command: command})
+	}
+	// Start on a new stage after those typed, to add to the pipeline
+	m.stages = append(m.stages, Stage{})
+	m.current = len(m.stages) - 1
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		m.input.Width = max(10, msg.Width-6)
+		return m, nil
+
+	case ranMsg:
+		if msg.generation == m.generation {
+			m.running = false
+			m.stages = append(append([]Stage(nil), msg.stages...), m.stages[len(msg.stages):]...)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+x":
+			m = m.commit()
+			commands := make([]string, len(m.stages))
+			for i, stage := range m.stages {
+				commands[i] = stage.Command
+			}
+			m.pipeline = Join(commands)
+			m.accepted = true
+			return m, tea.Quit
+		case "enter":
+			return m.runCurrent()
+		case "up", "shift+tab":
+			return m.moveTo(m.current - 1), nil
+		case "down", "tab":
+			return m.moveTo(m.current + 1), nil
+		case "ctrl+d":
+			return m.deleteCurrent()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// commit saves the input to the current stage, which has to run again if it
+// changed, as do the stages after it.
+func (m model) commit() model {
+	command := strings.TrimSpace(m.input.Value())
+	if command == m.stages[m.current].Command {
+		return m
+	}
+	m.stages = append([]Stage(nil), m.stages...)
+	m.stages[m.current] = Stage{Command: command}
+	m.invalidateFrom(m.current + 1)
+	return m
+}
+
+// invalidateFrom marks the stages from index on as having to run again, and
+// drops the results of a run still going.
+func (m *model) invalidateFrom(index int) {
+	for i := index; i < len(m.stages); i++ {
+		m.stages[i] = Stage{Command: m.stages[i].Command}
+	}
+	m.generation++
+	m.running = false
+}
+
+// moveTo makes the stage at index the current one.
+func (m model) moveTo(index int) model {
+	if index < 0 || index >= len(m.stages) {
+		return m
+	}
+	m = m.commit()
+	m.current = index
+	m.input.SetValue(m.stages[index].Command)
+	m.input.CursorEnd()
+	return m
+}
+
+// runCurrent runs the stages up to the current one that did not run since
+// they changed, and adds a stage after the last one once it has a command.
+func (m model) runCurrent() (tea.Model, tea.Cmd) {
+	m = m.commit()
+	if m.current == len(m.stages)-1 && m.stages[m.current].Command != "" {
+		m.stages = append(m.stages, Stage{})
+		m = m.moveTo(m.current + 1)
+	}
+
+	from := len(m.stages)
+	for i, stage := range m.stages {
+		if !stage.Ran {
+			from = i
+			break
+		}
+	}
+	if from > m.current {
+		return m, nil
+	}
+
+	m.generation++
+	m.running = true
+	generation, run := m.generation, m.run
+	stages := append([]Stage(nil), m.stages[:m.current+1]...)
+	return m, func() tea.Msg {
+		return ranMsg{generation: generation, stages: runStages(context.Background(), run, stages, from)}
+	}
+}
+
+// deleteCurrent removes the current stage, unless it is the only one.
+func (m model) deleteCurrent() (tea.Model, tea.Cmd) {
+	if len(m.stages) == 1 {
+		m.input.SetValue("")
+		m.stages = []Stage{{}}
+		return m, nil
+	}
+	m.stages = append(append([]Stage(nil), m.stages[:m.current]...), m.stages[m.current+1:]...)
+	m.invalidateFrom(m.current)
+	index := min(m.current, len(m.stages)-1)
+	m.input.SetValue(m.stages[index].Command)
+	m.input.CursorEnd()
+	m.current = index
+	return m, nil
+}
+
+// shown is the stage whose output is shown: the current one, or the one
+// before a new stage, whose output it is going to read.
+func (m model) shown() int {
+	if m.current > 0 && strings.TrimSpace(m.input.Value()) == "" {
+		return m.current - 1
+	}
+	return m.current
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Pipeline builder") + "\n\n")
+
+	for i, stage := range m.stages {
+		prefix := "  "
+		if i > 0 {
+			prefix = "| "
+		}
+		if i == m.current {
+			sb.WriteString(cursorStyle.Render("> ") + prefix + m.input.View() + "\n")
+			continue
+		}
+		line := "  " + prefix + stageStyle.Render(stage.Command)
+		if stage.Ran && stage.Err == nil {
+			line += countStyle.Render(fmt.Sprintf("  %s", lineCount(stage)))
+		} else if stage.Err != nil {
+			line += errorStyle.Render("  " + stage.Err.Error())
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	shown := m.stages[m.shown()]
+	title := fmt.Sprintf("Output of stage %d", m.shown()+1)
+	switch {
+	case m.running:
+		title += ", running…"
+	case !shown.Ran:
+		title += ", press Enter to run"
+	case shown.Err != nil:
+		title += ": " + shown.Err.Error()
+	default:
+		title += ", " + lineCount(shown)
+	}
+	sb.WriteString("\n" + dividerStyle.Render("── ") + titleStyle.Render(title) + dividerStyle.Render(" ──") + "\n")
+
+	// Leave room for the title, stages, divider and help
+	room := max(3, m.height-len(m.stages)-6)
+	lines := shown.Lines()
+	for i, line := range lines {
+		if i == room {
+			sb.WriteString(countStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-room)) + "\n")
+			break
+		}
+		if width := max(10, m.width-1); len([]rune(line)) > width {
+			line = string([]rune(line)[:width-1]) + "…"
+		}
+		sb.WriteString(outputStyle.Render(line) + "\n")
+	}
+
+	sb.WriteString("\n" + helpStyle.Render("enter run and add a stage • ↑/↓ move • ctrl+d delete • ctrl+x insert pipeline • esc cancel"))
+	return sb.String()
+}
+
+// lineCount describes how many lines a stage printed.
+func lineCount(stage Stage) string {
+	count := len(stage.Lines())
+	if stage.Truncated {
+		return fmt.Sprintf("first %d lines", count)
+	}
+	if count == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", count)
+}
+
+// Run opens the builder on the pipeline on line, running its stages with
+// run, and returns the pipeline built if the user asked to insert it.
+func Run(line string, run RunFunc) (string, bool, error) {
+	result, err := tea.NewProgram(newModel(line, run), tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", false, err
+	}
+	m := result.(model)
+	return m.pipeline, m.accepted, nil
+}
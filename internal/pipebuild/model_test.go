@@ -0,0 +1,107 @@
+package pipebuild
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// press sends keys to m, finishing the runs Enter starts before the next
+// key.
+func press(t *testing.T, m model, keys ...string) model {
+	t.Helper()
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "ctrl+d":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlD}
+		case "ctrl+x":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlX}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		result, cmd := m.Update(msg)
+		m = result.(model)
+		if key == "enter" && cmd != nil {
+			if ran, ok := cmd().(ranMsg); ok {
+				result, _ = m.Update(ran)
+				m = result.(model)
+			}
+		}
+	}
+	return m
+}
+
+func commands(m model) []string {
+	var commands []string
+	for _, stage := range m.stages {
+		commands = append(commands, stage.Command)
+	}
+	return commands
+}
+
+func TestModelBuildsPipeline(t *testing.T) {
+	m := newModel("seq | upper", fakeRun)
+	assert.Equal(t, []string{"seq", "upper", ""}, commands(m))
+	assert.Equal(t, 2, m.current)
+	assert.Contains(t, m.View(), "Output of stage 2, press Enter to run")
+
+	m = press(t, m, "enter")
+	assert.False(t, m.running)
+	assert.Equal(t, "C\nB\nA\n", m.stages[1].Output)
+	assert.Contains(t, m.View(), "Output of stage 2, 3 lines")
+	assert.Contains(t, m.View(), "C\n")
+
+	m = press(t, m, "first", "enter")
+	assert.Equal(t, []string{"seq", "upper", "first", ""}, commands(m))
+	assert.Equal(t, 3, m.current)
+	assert.Equal(t, "C\n", m.stages[2].Output)
+
+	m = press(t, m, "ctrl+x")
+	assert.True(t, m.accepted)
+	assert.Equal(t, "seq | upper | first", m.pipeline)
+}
+
+func TestModelEditsStage(t *testing.T) {
+	m := press(t, newModel("seq | upper | first", fakeRun), "enter")
+	require.Equal(t, "C\n", m.stages[2].Output)
+
+	// Changing a stage makes it and those after it run again
+	m = press(t, m, "up", "up", "ctrl+d")
+	assert.Equal(t, []string{"seq", "first", ""}, commands(m))
+	assert.Equal(t, 1, m.current)
+	assert.False(t, m.stages[1].Ran)
+	assert.True(t, m.stages[0].Ran)
+
+	m = press(t, m, "enter")
+	assert.Equal(t, "c\n", m.stages[1].Output)
+	assert.False(t, m.stages[2].Ran)
+	assert.Equal(t, 1, m.current)
+}
+
+func TestModelCancel(t *testing.T) {
+	m := press(t, newModel("", fakeRun), "seq")
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, result.(model).accepted)
+}
+
+func TestModelDropsStaleRuns(t *testing.T) {
+	m := newModel("seq", fakeRun)
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(model)
+	require.True(t, m.running)
+
+	// The source changes before the run finishes
+	m = press(t, m, "up", "x", "down")
+	result, _ = m.Update(cmd())
+	m = result.(model)
+	assert.False(t, m.stages[0].Ran)
+}
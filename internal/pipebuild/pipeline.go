@@ -0,0 +1,194 @@
+// Package pipebuild is a builder for shell pipelines, which shows the output
+// of each stage on a sample of the data as stages are added, so that awk, jq
+// and sed programs can be written a step at a time.
+package pipebuild
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// SampleLines is how many lines of the output of each stage are kept
+	// and fed to the next stage.
+	SampleLines = 200
+
+	// stageTimeout bounds each stage, so that a command that waits for more
+	// input or never ends does not hang the builder.
+	stageTimeout = 5 * time.Second
+)
+
+// Stage is a command of a pipeline with what it printed when last run.
+type Stage struct {
+	Command string
+	Output  string
+	// Truncated is set when the output had more than SampleLines lines.
+	Truncated bool
+	Err       error
+	// Ran is set once the stage ran with the current command and input.
+	Ran bool
+}
+
+// Lines returns the lines of the output of the stage.
+func (s Stage) Lines() []string {
+	output := strings.TrimSuffix(s.Output, "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// Split returns the stages of the pipeline on line, as typed. A line that is
+// not a single pipeline, such as a list of commands, is one stage.
+func Split(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return []string{line}
+	}
+
+	var stages []string
+	var walk func(stmt *syntax.Stmt)
+	walk = func(stmt *syntax.Stmt) {
+		if pipe, ok := stmt.Cmd.(*syntax.BinaryCmd); ok && pipe.Op == syntax.Pipe &&
+			len(stmt.Redirs) == 0 && !stmt.Negated && !stmt.Background {
+			walk(pipe.X)
+			walk(pipe.Y)
+			return
+		}
+		stages = append(stages, strings.TrimSpace(line[stmt.Pos().Offset():stmt.End().Offset()]))
+	}
+	walk(file.Stmts[0])
+	return stages
+}
+
+// Join returns the pipeline of the commands, skipping empty ones.
+func Join(commands []string) string {
+	var parts []string
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			parts = append(parts, command)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// RunFunc runs command with input on its stdin and returns the first
+// maxLines lines of its output, and whether there were more.
+type RunFunc func(ctx context.Context, command, input string, maxLines int) (output string, truncated bool, err error)
+
+// errEnoughLines stops reading the output of a stage once it has printed
+// enough, which stops the command with SIGPIPE when it writes more.
+var errEnoughLines = errors.New("enough lines")
+
+// lineLimitWriter keeps the first max lines written to it.
+type lineLimitWriter struct {
+	buf       bytes.Buffer
+	max       int
+	lines     int
+	truncated bool
+}
+
+func (w *lineLimitWriter) Write(p []byte) (int, error) {
+	if w.truncated {
+		return 0, errEnoughLines
+	}
+	for i, b := range p {
+		if w.lines == w.max {
+			w.truncated = true
+			return i, errEnoughLines
+		}
+		w.buf.WriteByte(b)
+		if b == '\n' {
+			w.lines++
+		}
+	}
+	return len(p), nil
+}
+
+// ShellRunner returns a RunFunc that runs commands with sh -c in dir with the
+// exported variables of env.
+func ShellRunner(dir string, env expand.Environ) RunFunc {
+	environ := execEnv(env)
+	return func(ctx context.Context, command, input string, maxLines int) (string, bool, error) {
+		ctx, cancel := context.WithTimeout(ctx, stageTimeout)
+		defer cancel()
+
+		stdout := &lineLimitWriter{max: maxLines}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = dir
+		cmd.Env = environ
+		cmd.Stdin = strings.NewReader(input)
+		cmd.Stdout = stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+
+		switch {
+		case stdout.truncated:
+			// The command was stopped for printing more than was read
+			err = nil
+		case ctx.Err() == context.DeadlineExceeded:
+			err = fmt.Errorf("stopped after %s", stageTimeout)
+		case err != nil:
+			if message := lastLine(stderr.String()); message != "" {
+				err = errors.New(message)
+			}
+		}
+		return stdout.buf.String(), stdout.truncated, err
+	}
+}
+
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// execEnv returns the exported variables of env, for the commands run.
+func execEnv(env expand.Environ) []string {
+	if env == nil {
+		return nil
+	}
+	var list []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		if vr.IsSet() && vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+		return true
+	})
+	return list
+}
+
+// runStages runs the stages from index from on, each with the output of the
+// one before as its input. Empty stages pass their input through.
+func runStages(ctx context.Context, run RunFunc, stages []Stage, from int) []Stage {
+	stages = append([]Stage(nil), stages...)
+	for i := from; i < len(stages); i++ {
+		input := ""
+		if i > 0 {
+			input = stages[i-1].Output
+		}
+		stage := Stage{Command: stages[i].Command, Ran: true}
+		if strings.TrimSpace(stage.Command) == "" {
+			stage.Output = input
+			if i > 0 {
+				stage.Truncated = stages[i-1].Truncated
+			}
+		} else {
+			stage.Output, stage.Truncated, stage.Err = run(ctx, stage.Command, input, SampleLines)
+		}
+		stages[i] = stage
+	}
+	return stages
+}
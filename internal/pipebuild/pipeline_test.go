@@ -0,0 +1,79 @@
+package pipebuild
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+)
+
+func TestSplit(t *testing.T) {
+	assert.Nil(t, Split("  "))
+	assert.Equal(t, []string{"cat access.log"}, Split("cat access.log"))
+	assert.Equal(t, []string{"cat access.log", "awk '{print $1}'", "sort  -n"}, Split("cat access.log | awk '{print $1}' |sort  -n"))
+	assert.Equal(t, []string{"echo 'a | b'", "tr a-z A-Z"}, Split("echo 'a | b' | tr a-z A-Z"))
+	// Lists and negated pipelines are kept whole
+	assert.Equal(t, []string{"make && ls | wc -l"}, Split("make && ls | wc -l"))
+	assert.Equal(t, []string{"! ls | grep x"}, Split("! ls | grep x"))
+	assert.Equal(t, []string{"ls", "wc -l > count"}, Split("ls | wc -l > count"))
+	// As is a pipeline still being typed
+	assert.Equal(t, []string{"ls | 'wc"}, Split("ls | 'wc"))
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "ls | sort | uniq -c", Join([]string{"ls", " sort ", "", "uniq -c"}))
+	assert.Equal(t, "", Join(nil))
+}
+
+func TestShellRunner(t *testing.T) {
+	run := ShellRunner(t.TempDir(), expand.ListEnviron("PATH=/usr/bin:/bin"))
+
+	output, truncated, err := run(context.Background(), "tr a-z A-Z", "one\ntwo\n", 10)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, "ONE\nTWO\n", output)
+
+	// Output past the sample stops the command
+	output, truncated, err = run(context.Background(), "yes", "", 3)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, "y\ny\ny\n", output)
+
+	_, _, err = run(context.Background(), "echo oops >&2; exit 3", "", 10)
+	assert.EqualError(t, err, "oops")
+}
+
+// fakeRun runs the commands upper, first and fail on their input.
+func fakeRun(ctx context.Context, command, input string, maxLines int) (string, bool, error) {
+	switch command {
+	case "seq":
+		return "c\nb\na\n", false, nil
+	case "upper":
+		return strings.ToUpper(input), false, nil
+	case "first":
+		return strings.SplitAfter(input, "\n")[0], false, nil
+	case "fail":
+		return "", false, assert.AnError
+	}
+	return "", false, nil
+}
+
+func TestRunStages(t *testing.T) {
+	stages := runStages(context.Background(), fakeRun, []Stage{{Command: "seq"}, {Command: ""}, {Command: "upper"}, {Command: "first"}}, 0)
+	assert.Equal(t, []string{"c", "b", "a"}, stages[1].Lines())
+	assert.Equal(t, "C\nB\nA\n", stages[2].Output)
+	assert.Equal(t, []string{"C"}, stages[3].Lines())
+	for _, stage := range stages {
+		assert.True(t, stage.Ran)
+	}
+
+	// Running from a later stage reads the output of the one before
+	stages[2].Command = "fail"
+	stages = runStages(context.Background(), fakeRun, stages, 2)
+	assert.Equal(t, "c\nb\na\n", stages[0].Output)
+	assert.Equal(t, assert.AnError, stages[2].Err)
+	assert.Empty(t, stages[3].Output)
+}
@@ -0,0 +1,342 @@
+// Package pipelint spots pipelines that do more work than they need to, such
+// as cat file | grep pattern or grep pattern | wc -l, and rewrites them the
+// simpler way for the coach to teach.
+package pipelint
+
+import (
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Rules, by the name the coach remembers having taught them under.
+const (
+	UselessCat = "useless-cat"
+	GrepCount  = "grep-count"
+	LsGrep     = "ls-grep"
+	SortUniq   = "sort-uniq"
+)
+
+// Suggestion is the simpler way to run a pipeline.
+type Suggestion struct {
+	// Improved is the rewritten command.
+	Improved string
+	// Rules are the anti-patterns found, in the order they were rewritten,
+	// and Reasons why each rewrite is better.
+	Rules   []string
+	Reasons []string
+}
+
+// Message returns the suggestion as one line, e.g.
+// "Try: grep -c error log — grep reads files itself, so cat is not needed".
+func (s Suggestion) Message() string {
+	return "Try: " + s.Improved + " — " + strings.Join(s.Reasons, "; ")
+}
+
+// stage is a command of the pipeline.
+type stage struct {
+	// words are the words of the command as typed, and literals their text
+	// with quotes removed, or "" if they are not plain literals.
+	words    []string
+	literals []string
+}
+
+func (s stage) name() string {
+	return s.literals[0]
+}
+
+func (s stage) String() string {
+	return strings.Join(s.words, " ")
+}
+
+// rule rewrites two stages in a row into one when they match its
+// anti-pattern. A leading rule only applies to the first two stages, as the
+// first of them ignores its input.
+type rule struct {
+	name    string
+	leading bool
+	rewrite func(first, second stage) (stage, string, bool)
+}
+
+var rules = []rule{
+	{UselessCat, true, rewriteUselessCat},
+	{GrepCount, false, rewriteGrepCount},
+	{LsGrep, true, rewriteLsGrep},
+	{SortUniq, false, rewriteSortUniq},
+}
+
+// Analyze returns the simpler way to run command, if it is a plain pipeline
+// with a known anti-pattern. Pipelines with redirections, assignments or
+// substitutions are left alone, as their rewrite would be a guess.
+func Analyze(command string) (Suggestion, bool) {
+	stages, ok := parse(command)
+	if !ok || len(stages) < 2 {
+		return Suggestion{}, false
+	}
+
+	var suggestion Suggestion
+	for rewritten := true; rewritten; {
+		rewritten = false
+		for i := 0; i+1 < len(stages) && !rewritten; i++ {
+			for _, r := range rules {
+				if r.leading && i != 0 {
+					continue
+				}
+				merged, reason, ok := r.rewrite(stages[i], stages[i+1])
+				if !ok {
+					continue
+				}
+				stages = append(append(stages[:i:i], merged), stages[i+2:]...)
+				suggestion.Rules = append(suggestion.Rules, r.name)
+				suggestion.Reasons = append(suggestion.Reasons, reason)
+				rewritten = true
+				break
+			}
+		}
+	}
+	if len(suggestion.Rules) == 0 {
+		return Suggestion{}, false
+	}
+
+	parts := make([]string, len(stages))
+	for i, s := range stages {
+		parts[i] = s.String()
+	}
+	suggestion.Improved = strings.Join(parts, " | ")
+	return suggestion, true
+}
+
+// parse splits command into the stages of its pipeline.
+func parse(command string) ([]stage, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return nil, false
+	}
+	var stages []stage
+	var walk func(stmt *syntax.Stmt) bool
+	walk = func(stmt *syntax.Stmt) bool {
+		if stmt.Background || stmt.Coprocess || stmt.Negated || len(stmt.Redirs) > 0 {
+			return false
+		}
+		switch cmd := stmt.Cmd.(type) {
+		case *syntax.BinaryCmd:
+			return cmd.Op == syntax.Pipe && walk(cmd.X) && walk(cmd.Y)
+		case *syntax.CallExpr:
+			if len(cmd.Assigns) > 0 || len(cmd.Args) == 0 {
+				return false
+			}
+			s := stage{}
+			printer := syntax.NewPrinter()
+			for _, word := range cmd.Args {
+				var sb strings.Builder
+				if printer.Print(&sb, word) != nil {
+					return false
+				}
+				s.words = append(s.words, sb.String())
+				s.literals = append(s.literals, literal(word))
+			}
+			if s.name() == "" {
+				return false
+			}
+			stages = append(stages, s)
+			return true
+		default:
+			return false
+		}
+	}
+	if !walk(file.Stmts[0]) {
+		return nil, false
+	}
+	return stages, true
+}
+
+// literal returns the text of word with its quotes removed, or "" if it is
+// not a plain literal.
+func literal(word *syntax.Word) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return ""
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return ""
+		}
+	}
+	return sb.String()
+}
+
+// fileReader describes a command that reads the files named after its
+// operands, and its short options that take a value.
+type fileReader struct {
+	// operands is how many operands come before the files, such as the
+	// pattern of grep, unless one of the options in instead is passed.
+	operands int
+	instead  string
+	valued   string
+	// redirect is set for commands that print the names of the files they
+	// read, which are given the file on their input instead.
+	redirect bool
+}
+
+var fileReaders = map[string]fileReader{
+	"grep":  {operands: 1, instead: "ef", valued: "efABCm"},
+	"egrep": {operands: 1, instead: "ef", valued: "efABCm"},
+	"fgrep": {operands: 1, instead: "ef", valued: "efABCm"},
+	"rg":    {operands: 1, instead: "ef", valued: "efABCmgtT"},
+	"awk":   {operands: 1, instead: "f", valued: "Ffv"},
+	"sed":   {operands: 1, instead: "ef", valued: "ef"},
+	"head":  {valued: "nc"},
+	"tail":  {valued: "nc"},
+	"wc":    {redirect: true},
+	"sort":  {valued: "kotST"},
+	"cut":   {valued: "bcdf"},
+	"less":  {},
+}
+
+// args splits the arguments of s into its short options, and its operands,
+// as typed. ok is false when they cannot be told apart, as with long
+// options that may take a value.
+func (s stage) args(valued string) (options string, operands []string, ok bool) {
+	for i := 1; i < len(s.literals); i++ {
+		arg := s.literals[i]
+		switch {
+		case arg == "" && s.words[i] != "''" && s.words[i] != `""`:
+			// a substitution may expand to options
+			return "", nil, false
+		case arg == "--" || strings.HasPrefix(arg, "--") && !strings.Contains(arg, "="):
+			return "", nil, false
+		case strings.HasPrefix(arg, "--"):
+		case len(arg) > 1 && arg[0] == '-':
+			for j, c := range arg[1:] {
+				options += string(c)
+				if strings.ContainsRune(valued, c) {
+					// the value follows, in this word or the next
+					if j == len(arg)-2 {
+						i++
+					}
+					break
+				}
+			}
+		default:
+			operands = append(operands, s.words[i])
+		}
+	}
+	return options, operands, true
+}
+
+// rewriteUselessCat turns cat FILE | cmd ARGS into cmd ARGS FILE.
+func rewriteUselessCat(first, second stage) (stage, string, bool) {
+	if first.name() != "cat" || len(first.words) != 2 || first.literals[1] == "" || strings.HasPrefix(first.literals[1], "-") {
+		return stage{}, "", false
+	}
+	reader, known := fileReaders[second.name()]
+	if !known {
+		return stage{}, "", false
+	}
+	options, operands, ok := second.args(reader.valued)
+	if !ok || strings.Contains(options, "i") && second.name() == "sed" {
+		return stage{}, "", false
+	}
+	expected := reader.operands
+	if strings.ContainsAny(options, reader.instead) {
+		expected = 0
+	}
+	if len(operands) != expected {
+		return stage{}, "", false
+	}
+	file := []string{first.words[1]}
+	if reader.redirect {
+		file = []string{"<", first.words[1]}
+	}
+	merged := stage{
+		words:    append(append([]string{}, second.words...), file...),
+		literals: append(append([]string{}, second.literals...), file...),
+	}
+	return merged, second.name() + " reads files itself, so cat is not needed", true
+}
+
+// rewriteGrepCount turns grep ARGS | wc -l into grep -c ARGS.
+func rewriteGrepCount(first, second stage) (stage, string, bool) {
+	switch first.name() {
+	case "grep", "egrep", "fgrep":
+	default:
+		return stage{}, "", false
+	}
+	reader := fileReaders[first.name()]
+	if second.name() != "wc" || len(second.literals) != 2 || second.literals[1] != "-l" {
+		return stage{}, "", false
+	}
+	options, operands, ok := first.args(reader.valued)
+	// grep -c counts per file, and does not count context or each match
+	if !ok || strings.ContainsAny(options, "cloLABCrR") {
+		return stage{}, "", false
+	}
+	expected := 1
+	if strings.ContainsAny(options, reader.instead) {
+		expected = 0
+	}
+	if len(operands) > expected+1 {
+		return stage{}, "", false
+	}
+	merged := stage{
+		words:    append([]string{first.words[0], "-c"}, first.words[1:]...),
+		literals: append([]string{first.literals[0], "-c"}, first.literals[1:]...),
+	}
+	return merged, first.name() + " -c counts the matching lines without wc -l", true
+}
+
+// plainPattern matches grep patterns that mean the same as a glob.
+var plainPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// rewriteLsGrep turns ls [DIR] | grep NAME into ls -d [DIR/]*NAME*.
+func rewriteLsGrep(first, second stage) (stage, string, bool) {
+	if first.name() != "ls" || len(first.words) > 2 {
+		return stage{}, "", false
+	}
+	dir := ""
+	if len(first.words) == 2 {
+		dir = first.literals[1]
+		if dir == "" || strings.HasPrefix(dir, "-") || !plainPattern.MatchString(strings.ReplaceAll(strings.ReplaceAll(dir, "/", ""), ".", "")) {
+			return stage{}, "", false
+		}
+		dir = strings.TrimSuffix(dir, "/") + "/"
+	}
+	if second.name() != "grep" || len(second.words) != 2 || !plainPattern.MatchString(second.literals[1]) {
+		return stage{}, "", false
+	}
+	glob := dir + "*" + second.literals[1] + "*"
+	merged := stage{
+		words:    []string{"ls", "-d", glob},
+		literals: []string{"ls", "-d", glob},
+	}
+	return merged, "a glob picks the names without parsing the output of ls", true
+}
+
+// rewriteSortUniq turns sort ARGS | uniq into sort -u ARGS.
+func rewriteSortUniq(first, second stage) (stage, string, bool) {
+	if first.name() != "sort" || second.name() != "uniq" || len(second.words) != 1 {
+		return stage{}, "", false
+	}
+	// with other options, sort -u compares the lines by key, number or
+	// without case, which uniq does not
+	options, _, ok := first.args(fileReaders["sort"].valued)
+	if !ok || strings.Trim(options, "r") != "" {
+		return stage{}, "", false
+	}
+	merged := stage{
+		words:    append([]string{"sort", "-u"}, first.words[1:]...),
+		literals: append([]string{"sort", "-u"}, first.literals[1:]...),
+	}
+	return merged, "sort -u drops the duplicates as it sorts", true
+}
@@ -0,0 +1,75 @@
+package pipelint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		command  string
+		improved string
+		rules    []string
+	}{
+		{"cat log.txt | grep error", "grep error log.txt", []string{UselessCat}},
+		{"cat 'my log' | grep -i -A 2 \"disk full\"", "grep -i -A 2 \"disk full\" 'my log'", []string{UselessCat}},
+		{"cat log.txt | grep -e foo -e bar", "grep -e foo -e bar log.txt", []string{UselessCat}},
+		{"cat data.csv | head -n 5", "head -n 5 data.csv", []string{UselessCat}},
+		{"cat data.csv | wc -l", "wc -l < data.csv", []string{UselessCat}},
+		{"cat data.csv | awk -F, '{print $1}' | sort | uniq", "awk -F, '{print $1}' data.csv | sort -u", []string{UselessCat, SortUniq}},
+		{"grep TODO main.go | wc -l", "grep -c TODO main.go", []string{GrepCount}},
+		{"cat log.txt | grep error | wc -l", "grep -c error log.txt", []string{UselessCat, GrepCount}},
+		{"ps aux | grep -v root | wc -l", "ps aux | grep -c -v root", []string{GrepCount}},
+		{"ls | grep test", "ls -d *test*", []string{LsGrep}},
+		{"ls src/ | grep _test", "ls -d src/*_test*", []string{LsGrep}},
+		{"sort -r names.txt | uniq", "sort -u -r names.txt", []string{SortUniq}},
+	}
+	for _, tt := range tests {
+		s, ok := Analyze(tt.command)
+		if assert.True(t, ok, tt.command) {
+			assert.Equal(t, tt.improved, s.Improved, tt.command)
+			assert.Equal(t, tt.rules, s.Rules, tt.command)
+			assert.Len(t, s.Reasons, len(tt.rules))
+		}
+	}
+}
+
+func TestAnalyzeLeavesAlone(t *testing.T) {
+	for _, command := range []string{
+		"grep error log.txt",
+		"cat log.txt",
+		"cat a b | grep x",
+		"cat -n log.txt | grep x",
+		"cat log.txt | grep x other.txt",
+		"cat log.txt | sed -i s/a/b/",
+		"cat log.txt | grep --regexp x",
+		"cat $FILE | grep $PATTERN",
+		"cat log.txt | tr a b",
+		"cat log.txt | grep x > out.txt",
+		"echo hi | cat log.txt | grep x",
+		"grep -o x log.txt | wc -l",
+		"grep -c x log.txt | wc -l",
+		"grep x a.txt b.txt | wc -l",
+		"grep x log.txt | wc",
+		"ls -l | grep test",
+		"ls | grep -i test",
+		"ls | grep 'te.t'",
+		"sort -n nums | uniq",
+		"sort -k2 names | uniq",
+		"sort names | uniq -c",
+		"cat log.txt | grep x; echo done",
+		"cat log.txt |& grep x",
+		"LC_ALL=C cat log.txt | grep x",
+		"cat log.txt | grep 'unterminated",
+	} {
+		_, ok := Analyze(command)
+		assert.False(t, ok, command)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	s, ok := Analyze("cat log.txt | grep error | wc -l")
+	assert.True(t, ok)
+	assert.Equal(t, "Try: grep -c error log.txt — grep reads files itself, so cat is not needed; grep -c counts the matching lines without wc -l", s.Message())
+}
@@ -0,0 +1,118 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: pkg [-y] <install|remove|search|info|update|upgrade> [package...]\n" +
+	"       pkg which"
+
+// NewPkgCommandHandler creates an ExecHandler for the pkg builtin, which runs
+// package actions with the system's package manager, or the one named by
+// BISH_PACKAGE_MANAGER. Actions that change the system are confirmed first,
+// unless -y is given.
+func NewPkgCommandHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "pkg" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			args = args[1:]
+			yes := false
+			if len(args) > 0 && (args[0] == "-y" || args[0] == "--yes") {
+				yes = true
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+			if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+			}
+
+			m, err := Current(hc.Env.Get("BISH_PACKAGE_MANAGER").String())
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "pkg: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if args[0] == "which" {
+				fmt.Fprintln(hc.Stdout, m.Name)
+				return nil
+			}
+
+			action, packages := args[0], args[1:]
+			if (action == "install" || action == "remove" || action == "search" || action == "info") && len(packages) == 0 {
+				fmt.Fprintf(hc.Stderr, "pkg: %s needs a package\n", action)
+				return interp.NewExitStatus(2)
+			}
+			command, err := m.Command(action, packages, os.Geteuid() == 0)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "pkg: %s\n%s\n", err, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			if Changes(action) && !yes && !confirm(hc, strings.Join(command, " ")) {
+				fmt.Fprintln(hc.Stderr, "pkg: cancelled")
+				return interp.NewExitStatus(1)
+			}
+			return next(ctx, command)
+		}
+	}
+}
+
+// confirm asks whether to run command, honouring BISH_DEFAULT_TO_YES for an
+// empty answer.
+func confirm(hc interp.HandlerContext, command string) bool {
+	defaultToYes := strings.ToLower(hc.Env.Get("BISH_DEFAULT_TO_YES").String())
+	defaultYes := defaultToYes == "1" || defaultToYes == "true"
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(hc.Stdout, "Run %s? %s ", command, choices)
+
+	if hc.Stdin == nil {
+		return false
+	}
+	answer, err := readLine(hc.Stdin)
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "":
+		return defaultYes
+	default:
+		return false
+	}
+}
+
+// readLine reads a line from r a byte at a time, so that what follows the
+// answer is left for the commands run after pkg.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var buf [1]byte
+	for {
+		n, err := r.Read(buf[:])
+		if n == 1 {
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
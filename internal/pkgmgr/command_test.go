@@ -0,0 +1,93 @@
+package pkgmgr
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// runPkg runs script with the pkg builtin and returns the commands it would
+// have run.
+func runPkg(t *testing.T, script, stdin string) (ran [][]string, stdout, stderr string, err error) {
+	t.Helper()
+
+	record := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			ran = append(ran, args)
+			return nil
+		}
+	}
+	var out, errOut bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(strings.NewReader(stdin), &out, &errOut),
+		interp.ExecHandlers(NewPkgCommandHandler(), record),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return ran, out.String(), errOut.String(), err
+}
+
+func TestPkgInstallConfirmed(t *testing.T) {
+	ran, stdout, _, err := runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg install ripgrep", "y\n")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Run brew install ripgrep? [y/N]")
+	assert.Equal(t, [][]string{{"brew", "install", "ripgrep"}}, ran)
+}
+
+func TestPkgInstallDeclined(t *testing.T) {
+	ran, _, stderr, err := runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg install ripgrep", "\n")
+	require.Error(t, err)
+	assert.Contains(t, stderr, "pkg: cancelled")
+	assert.Empty(t, ran)
+}
+
+func TestPkgLeavesTheRestOfStdin(t *testing.T) {
+	ran, _, _, err := runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg install ripgrep; pkg install jq", "y\ny\n")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"brew", "install", "ripgrep"}, {"brew", "install", "jq"}}, ran)
+}
+
+func TestPkgDefaultToYes(t *testing.T) {
+	ran, stdout, _, err := runPkg(t, "BISH_PACKAGE_MANAGER=brew BISH_DEFAULT_TO_YES=1; pkg remove jq", "\n")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "[Y/n]")
+	assert.Equal(t, [][]string{{"brew", "uninstall", "jq"}}, ran)
+}
+
+func TestPkgYesAndSearch(t *testing.T) {
+	ran, stdout, _, err := runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg -y upgrade; pkg search ripgrep", "")
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "Run ")
+	assert.Equal(t, [][]string{{"brew", "upgrade"}, {"brew", "search", "ripgrep"}}, ran)
+}
+
+func TestPkgWhich(t *testing.T) {
+	_, stdout, _, err := runPkg(t, "BISH_PACKAGE_MANAGER=winget; pkg which", "")
+	require.NoError(t, err)
+	assert.Equal(t, "winget\n", stdout)
+}
+
+func TestPkgErrors(t *testing.T) {
+	_, _, stderr, err := runPkg(t, "BISH_PACKAGE_MANAGER=portage; pkg install vim", "")
+	_, isExit := interp.IsExitStatus(err)
+	assert.True(t, isExit)
+	assert.Contains(t, stderr, `unknown package manager "portage"`)
+
+	_, _, stderr, _ = runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg install", "")
+	assert.Contains(t, stderr, "pkg: install needs a package")
+
+	_, _, stderr, _ = runPkg(t, "BISH_PACKAGE_MANAGER=brew; pkg frobnicate", "")
+	assert.Contains(t, stderr, `unknown action "frobnicate"`)
+
+	ran, _, _, _ := runPkg(t, "ls /tmp", "")
+	assert.Equal(t, [][]string{{"ls", "/tmp"}}, ran)
+}
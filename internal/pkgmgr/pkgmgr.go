@@ -0,0 +1,240 @@
+// Package pkgmgr knows the package manager of the system, so that bish can
+// suggest and run installs with the right tool: apt, dnf, brew, pacman, apk
+// or winget.
+package pkgmgr
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Actions are what the pkg builtin can ask of a package manager.
+var Actions = []string{"install", "remove", "search", "info", "update", "upgrade"}
+
+// Manager is a package manager and the commands that perform each action.
+type Manager struct {
+	Name string
+	// Binaries are the commands that run the manager, e.g. apt and apt-get
+	Binaries []string
+	// Sudo is whether changing packages needs root
+	Sudo bool
+	// Commands maps each action to the command that performs it, to which
+	// package names are appended
+	Commands map[string][]string
+}
+
+// Managers are the supported package managers, in the order they are looked
+// for on Linux.
+var Managers = []Manager{
+	{
+		Name:     "apt",
+		Binaries: []string{"apt-get", "apt"},
+		Sudo:     true,
+		Commands: map[string][]string{
+			"install": {"apt-get", "install"},
+			"remove":  {"apt-get", "remove"},
+			"search":  {"apt-cache", "search"},
+			"info":    {"apt-cache", "show"},
+			"update":  {"apt-get", "update"},
+			"upgrade": {"apt-get", "upgrade"},
+		},
+	},
+	{
+		Name:     "dnf",
+		Binaries: []string{"dnf", "yum"},
+		Sudo:     true,
+		Commands: map[string][]string{
+			"install": {"dnf", "install"},
+			"remove":  {"dnf", "remove"},
+			"search":  {"dnf", "search"},
+			"info":    {"dnf", "info"},
+			"update":  {"dnf", "makecache"},
+			"upgrade": {"dnf", "upgrade"},
+		},
+	},
+	{
+		Name:     "pacman",
+		Binaries: []string{"pacman"},
+		Sudo:     true,
+		Commands: map[string][]string{
+			"install": {"pacman", "-S"},
+			"remove":  {"pacman", "-R"},
+			"search":  {"pacman", "-Ss"},
+			"info":    {"pacman", "-Si"},
+			"update":  {"pacman", "-Sy"},
+			"upgrade": {"pacman", "-Syu"},
+		},
+	},
+	{
+		Name:     "apk",
+		Binaries: []string{"apk"},
+		Sudo:     true,
+		Commands: map[string][]string{
+			"install": {"apk", "add"},
+			"remove":  {"apk", "del"},
+			"search":  {"apk", "search"},
+			"info":    {"apk", "info"},
+			"update":  {"apk", "update"},
+			"upgrade": {"apk", "upgrade"},
+		},
+	},
+	{
+		Name:     "brew",
+		Binaries: []string{"brew"},
+		Commands: map[string][]string{
+			"install": {"brew", "install"},
+			"remove":  {"brew", "uninstall"},
+			"search":  {"brew", "search"},
+			"info":    {"brew", "info"},
+			"update":  {"brew", "update"},
+			"upgrade": {"brew", "upgrade"},
+		},
+	},
+	{
+		Name:     "winget",
+		Binaries: []string{"winget"},
+		Commands: map[string][]string{
+			"install": {"winget", "install"},
+			"remove":  {"winget", "uninstall"},
+			"search":  {"winget", "search"},
+			"info":    {"winget", "show"},
+			"update":  {"winget", "source", "update"},
+			"upgrade": {"winget", "upgrade", "--all"},
+		},
+	},
+}
+
+// ByName returns the package manager called name.
+func ByName(name string) (Manager, bool) {
+	for _, m := range Managers {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+// Names lists the supported package managers.
+func Names() []string {
+	names := make([]string, len(Managers))
+	for i, m := range Managers {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// Detect returns the package manager of a system running goos, the first
+// one lookPath finds. macOS prefers brew and Windows winget; Linux takes the
+// distribution's own manager over a Linuxbrew install.
+func Detect(goos string, lookPath func(string) (string, error)) (Manager, bool) {
+	order := []string{"apt", "dnf", "pacman", "apk", "brew"}
+	switch goos {
+	case "darwin":
+		order = []string{"brew"}
+	case "windows":
+		order = []string{"winget"}
+	}
+	for _, name := range order {
+		m, _ := ByName(name)
+		if _, err := lookPath(m.Binaries[0]); err == nil {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+var detected = sync.OnceValues(func() (Manager, bool) {
+	return Detect(runtime.GOOS, exec.LookPath)
+})
+
+// Current returns the package manager bish uses: the one named by override,
+// usually BISH_PACKAGE_MANAGER, or else the detected one.
+func Current(override string) (Manager, error) {
+	if override = strings.TrimSpace(override); override != "" {
+		m, ok := ByName(override)
+		if !ok {
+			return Manager{}, fmt.Errorf("unknown package manager %q (supported: %s)", override, strings.Join(Names(), ", "))
+		}
+		return m, nil
+	}
+	m, ok := detected()
+	if !ok {
+		return Manager{}, fmt.Errorf("no supported package manager found (set BISH_PACKAGE_MANAGER to one of %s)", strings.Join(Names(), ", "))
+	}
+	return m, nil
+}
+
+// Command returns the command that performs action on packages. It runs
+// through sudo when the manager changes system packages and root is false;
+// search and info never need it.
+func (m Manager) Command(action string, packages []string, root bool) ([]string, error) {
+	base, ok := m.Commands[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q (one of %s)", action, strings.Join(Actions, ", "))
+	}
+	var command []string
+	if m.Sudo && !root && Changes(action) {
+		command = append(command, "sudo")
+	}
+	command = append(command, base...)
+	return append(command, packages...), nil
+}
+
+// Changes reports whether action changes the system, and so is confirmed
+// before it runs.
+func Changes(action string) bool {
+	return action != "search" && action != "info"
+}
+
+// Describe tells the agent which package manager to suggest installs with.
+func Describe(m Manager) string {
+	install, _ := m.Command("install", []string{"<package>"}, false)
+	return fmt.Sprintf("Package manager: %s. Install packages with `%s`, or with the pkg builtin: `pkg install <package>`.",
+		m.Name, strings.Join(install, " "))
+}
+
+// ForeignInstall returns the package that command installs with a package
+// manager other than m, e.g. brew install on a Debian system, or "".
+func ForeignInstall(m Manager, command string) (other Manager, pkg string) {
+	fields := strings.Fields(command)
+	if len(fields) > 0 && fields[0] == "sudo" {
+		fields = fields[1:]
+	}
+	if len(fields) < 3 {
+		return Manager{}, ""
+	}
+	for _, candidate := range Managers {
+		if candidate.Name == m.Name || !isBinary(candidate, fields[0]) || fields[1] != candidate.Commands["install"][1] {
+			continue
+		}
+		for _, field := range fields[2:] {
+			if !strings.HasPrefix(field, "-") {
+				return candidate, field
+			}
+		}
+	}
+	return Manager{}, ""
+}
+
+func isBinary(m Manager, name string) bool {
+	for _, binary := range m.Binaries {
+		if binary == name {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallHint suggests installing with m when command failed to install a
+// package with another package manager, or returns "".
+func InstallHint(m Manager, command string) string {
+	other, pkg := ForeignInstall(m, command)
+	if pkg == "" {
+		return ""
+	}
+	install, _ := m.Command("install", []string{pkg}, false)
+	return fmt.Sprintf("This system uses %s, not %s: try pkg install %s (runs %s)", m.Name, other.Name, pkg, strings.Join(install, " "))
+}
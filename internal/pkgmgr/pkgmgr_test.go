@@ -0,0 +1,90 @@
+package pkgmgr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookPathFor(binaries ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		for _, binary := range binaries {
+			if binary == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		goos     string
+		binaries []string
+		want     string
+	}{
+		{goos: "linux", binaries: []string{"apt-get", "brew"}, want: "apt"},
+		{goos: "linux", binaries: []string{"dnf"}, want: "dnf"},
+		{goos: "linux", binaries: []string{"pacman"}, want: "pacman"},
+		{goos: "linux", binaries: []string{"apk"}, want: "apk"},
+		{goos: "linux", binaries: []string{"brew"}, want: "brew"},
+		{goos: "darwin", binaries: []string{"brew", "apt-get"}, want: "brew"},
+		{goos: "windows", binaries: []string{"winget"}, want: "winget"},
+	}
+	for _, tt := range tests {
+		m, ok := Detect(tt.goos, lookPathFor(tt.binaries...))
+		require.True(t, ok, tt.want)
+		assert.Equal(t, tt.want, m.Name)
+	}
+
+	_, ok := Detect("darwin", lookPathFor("apt-get"))
+	assert.False(t, ok)
+}
+
+func TestCurrentOverride(t *testing.T) {
+	m, err := Current("pacman")
+	require.NoError(t, err)
+	assert.Equal(t, "pacman", m.Name)
+
+	_, err = Current("portage")
+	assert.ErrorContains(t, err, `unknown package manager "portage"`)
+}
+
+func TestCommand(t *testing.T) {
+	apt, _ := ByName("apt")
+	command, err := apt.Command("install", []string{"ripgrep", "fd-find"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sudo", "apt-get", "install", "ripgrep", "fd-find"}, command)
+
+	command, _ = apt.Command("install", []string{"ripgrep"}, true)
+	assert.Equal(t, []string{"apt-get", "install", "ripgrep"}, command)
+
+	command, _ = apt.Command("search", []string{"ripgrep"}, false)
+	assert.Equal(t, []string{"apt-cache", "search", "ripgrep"}, command)
+
+	brew, _ := ByName("brew")
+	command, _ = brew.Command("remove", []string{"ripgrep"}, false)
+	assert.Equal(t, []string{"brew", "uninstall", "ripgrep"}, command)
+
+	_, err = brew.Command("frobnicate", nil, false)
+	assert.ErrorContains(t, err, `unknown action "frobnicate"`)
+}
+
+func TestDescribe(t *testing.T) {
+	dnf, _ := ByName("dnf")
+	assert.Equal(t, "Package manager: dnf. Install packages with `sudo dnf install <package>`, or with the pkg builtin: `pkg install <package>`.", Describe(dnf))
+}
+
+func TestInstallHint(t *testing.T) {
+	apt, _ := ByName("apt")
+	assert.Equal(t, "This system uses apt, not brew: try pkg install ripgrep (runs sudo apt-get install ripgrep)", InstallHint(apt, "brew install ripgrep"))
+	assert.Equal(t, "This system uses apt, not pacman: try pkg install ripgrep (runs sudo apt-get install ripgrep)", InstallHint(apt, "sudo pacman -S --needed ripgrep"))
+	assert.Empty(t, InstallHint(apt, "sudo apt install ripgrep"))
+	assert.Empty(t, InstallHint(apt, "brew list"))
+	assert.Empty(t, InstallHint(apt, "npm install left-pad"))
+
+	brew, _ := ByName("brew")
+	assert.Equal(t, "This system uses brew, not apt: try pkg install jq (runs brew install jq)", InstallHint(brew, "sudo apt-get install -y jq"))
+}
@@ -0,0 +1,80 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/robottwo/bishop/internal/containers"
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: ports [port]"
+
+// NewPortsCommandHandler creates an ExecHandler for the ports builtin, which
+// lists the listening sockets with their processes and containers, or with a
+// port, tells what is on it and exits 1 if nothing is. run runs lsof, netstat
+// and the container runtimes.
+func NewPortsCommandHandler(run containers.RunFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "ports" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) > 2 {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+			port := 0
+			if len(args) == 2 {
+				if args[1] == "-h" || args[1] == "--help" {
+					fmt.Fprintln(hc.Stdout, usage)
+					return nil
+				}
+				var err error
+				if port, err = strconv.Atoi(args[1]); err != nil || port < 1 || port > 65535 {
+					fmt.Fprintf(hc.Stderr, "ports: invalid port %q\n", args[1])
+					return interp.NewExitStatus(2)
+				}
+			}
+
+			listeners, err := List(ctx, run)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "ports: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if port != 0 {
+				fmt.Fprintln(hc.Stdout, Describe(listeners, port))
+				if len(OnPort(listeners, port)) == 0 {
+					return interp.NewExitStatus(1)
+				}
+				return nil
+			}
+			printListeners(hc.Stdout, listeners)
+			return nil
+		}
+	}
+}
+
+func printListeners(out io.Writer, listeners []Listener) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PROTO\tADDRESS\tPORT\tPID\tPROCESS\tCONTAINER")
+	for _, l := range listeners {
+		pid, process, container := "-", l.Process, l.Container
+		if l.PID != 0 {
+			pid = strconv.Itoa(l.PID)
+		}
+		if process == "" {
+			process = "-"
+		}
+		if container == "" {
+			container = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", l.Proto, l.Address, l.Port, pid, process, container)
+	}
+	_ = w.Flush()
+}
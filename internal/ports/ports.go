@@ -0,0 +1,143 @@
+// Package ports finds the sockets listening on the system, with the process
+// and container behind each, to answer "what is on this port".
+package ports
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/containers"
+)
+
+// Listener is a socket listening for connections, or a bound UDP socket.
+type Listener struct {
+	Proto   string // tcp, tcp6, udp or udp6
+	Address string // the local address, e.g. 0.0.0.0, 127.0.0.1 or ::
+	Port    int
+	// PID and Process are 0 and "" when the owner isn't visible, e.g. a
+	// process of another user
+	PID     int
+	Process string
+	// Command is the command line of the process, if known
+	Command string
+	// Container is the identity of the container the port is published to,
+	// e.g. docker:web
+	Container string
+}
+
+// Owner describes what holds the port, e.g. "node (pid 812)" or
+// "container docker:web, via docker-proxy (pid 2001)".
+func (l Listener) Owner() string {
+	process := "unknown process"
+	if l.Process != "" && l.PID != 0 {
+		process = fmt.Sprintf("%s (pid %d)", l.Process, l.PID)
+	} else if l.PID != 0 {
+		process = fmt.Sprintf("pid %d", l.PID)
+	}
+	if l.Container == "" {
+		return process
+	}
+	if l.PID != 0 {
+		return fmt.Sprintf("container %s, via %s", l.Container, process)
+	}
+	return "container " + l.Container
+}
+
+// List returns the listening sockets, sorted by port. It reads /proc where
+// there is one and falls back to lsof, then netstat. Containers come from
+// the ports docker and podman publish.
+func List(ctx context.Context, run containers.RunFunc) ([]Listener, error) {
+	listeners, err := fromProc("/proc")
+	if err != nil {
+		listeners, err = fromLsof(ctx, run)
+	}
+	if err != nil {
+		listeners, err = fromNetstat(ctx, run)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list sockets: no /proc, lsof or netstat")
+	}
+	published := publishedPorts(ctx, run)
+	for i := range listeners {
+		listeners[i].Container = published[listeners[i].Port]
+	}
+	sort.SliceStable(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Proto < listeners[j].Proto
+	})
+	return dedupe(listeners), nil
+}
+
+// dedupe drops repeated sockets, which lsof reports once per process
+// sharing them.
+func dedupe(listeners []Listener) []Listener {
+	seen := map[Listener]bool{}
+	var unique []Listener
+	for _, l := range listeners {
+		if !seen[l] {
+			seen[l] = true
+			unique = append(unique, l)
+		}
+	}
+	return unique
+}
+
+// OnPort returns the listeners on port.
+func OnPort(listeners []Listener, port int) []Listener {
+	var matches []Listener
+	for _, l := range listeners {
+		if l.Port == port {
+			matches = append(matches, l)
+		}
+	}
+	return matches
+}
+
+// Describe answers what is on port, for people and for the agent.
+func Describe(listeners []Listener, port int) string {
+	matches := OnPort(listeners, port)
+	if len(matches) == 0 {
+		return fmt.Sprintf("Nothing is listening on port %d.", port)
+	}
+	lines := make([]string, len(matches))
+	for i, l := range matches {
+		lines[i] = fmt.Sprintf("Port %d/%s on %s is held by %s.", port, l.Proto, l.Address, l.Owner())
+		if l.Command != "" {
+			lines[i] += " Command line: " + l.Command
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	addressInUse = regexp.MustCompile(`(?i)address already in use|EADDRINUSE|port is already allocated|bind.*in use|port \d+ is in use`)
+	portNumber   = regexp.MustCompile(`(?:port[ :=]+|:)(\d{2,5})\b`)
+)
+
+// AddressInUsePort returns the port that a failed command could not bind,
+// from its error output or else its arguments, or 0 if the failure was not
+// an address in use.
+func AddressInUsePort(command, stderr string) int {
+	if !addressInUse.MatchString(stderr) {
+		return 0
+	}
+	for _, text := range []string{stderr, command} {
+		for _, match := range portNumber.FindAllStringSubmatch(text, -1) {
+			if port, err := strconv.Atoi(match[1]); err == nil && port > 0 && port <= 65535 {
+				return port
+			}
+		}
+	}
+	for _, field := range strings.Fields(command) {
+		if port, err := strconv.Atoi(field); err == nil && port >= 1024 && port <= 65535 {
+			return port
+		}
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 777 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 999 1 0000000000000000 20 4 30 10 -1
+`
+
+const procNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12346 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:01BB 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 555 1 0000000000000000 100 0 0 10 0
+`
+
+func writeFakeProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "net"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "net", "tcp"), []byte(procNetTCP), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "net", "tcp6"), []byte(procNetTCP6), 0o644))
+
+	pidDir := filepath.Join(root, "812")
+	require.NoError(t, os.MkdirAll(filepath.Join(pidDir, "fd"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pidDir, "comm"), []byte("node\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(pidDir, "cmdline"), []byte("node\x00server.js\x00"), 0o644))
+	require.NoError(t, os.Symlink("socket:[12345]", filepath.Join(pidDir, "fd", "3")))
+	require.NoError(t, os.Symlink("socket:[12346]", filepath.Join(pidDir, "fd", "4")))
+	require.NoError(t, os.Symlink("/dev/null", filepath.Join(pidDir, "fd", "0")))
+	return root
+}
+
+func TestFromProc(t *testing.T) {
+	listeners, err := fromProc(writeFakeProc(t))
+	require.NoError(t, err)
+	assert.Equal(t, []Listener{
+		{Proto: "tcp", Address: "127.0.0.1", Port: 8080, PID: 812, Process: "node", Command: "node server.js"},
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22},
+		{Proto: "tcp6", Address: "::", Port: 8080, PID: 812, Process: "node", Command: "node server.js"},
+		{Proto: "tcp6", Address: "::1", Port: 443},
+	}, listeners)
+
+	_, err = fromProc(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestParseLsof(t *testing.T) {
+	out := "p812\ncnode\nPTCP\nn*:8080\nPTCP\nn[::1]:8080\np900\ncpostgres\nPTCP\nn127.0.0.1:5432\np901\ncchrome\nPUDP\nn10.0.0.2:5353->10.0.0.1:53\n"
+	assert.Equal(t, []Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 8080, PID: 812, Process: "node"},
+		{Proto: "tcp6", Address: "::1", Port: 8080, PID: 812, Process: "node"},
+		{Proto: "tcp", Address: "127.0.0.1", Port: 5432, PID: 900, Process: "postgres"},
+	}, parseLsof(out))
+}
+
+func TestParseNetstat(t *testing.T) {
+	linux := "Proto Recv-Q Send-Q Local Address           Foreign Address         State\n" +
+		"tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN\n" +
+		"tcp        0      0 10.0.0.2:22             10.0.0.1:50000          ESTABLISHED\n" +
+		"udp        0      0 0.0.0.0:68              0.0.0.0:*\n"
+	assert.Equal(t, []Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22},
+		{Proto: "udp", Address: "0.0.0.0", Port: 68},
+	}, parseNetstat(linux))
+
+	bsd := "Active Internet connections (including servers)\n" +
+		"Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)\n" +
+		"tcp4       0      0  127.0.0.1.5432         *.*                    LISTEN\n" +
+		"tcp46      0      0  *.8080                 *.*                    LISTEN\n"
+	assert.Equal(t, []Listener{
+		{Proto: "tcp", Address: "127.0.0.1", Port: 5432},
+		{Proto: "tcp6", Address: "::", Port: 8080},
+	}, parseNetstat(bsd))
+}
+
+func TestParsePublished(t *testing.T) {
+	out := "web\t0.0.0.0:8080->80/tcp, :::8080->80/tcp\n" +
+		"db\t5432/tcp\n" +
+		"range\t127.0.0.1:9000-9002->9000-9002/tcp\n"
+	assert.Equal(t, map[int]string{8080: "web", 9000: "range", 9001: "range", 9002: "range"}, parsePublished(out))
+}
+
+func TestDescribe(t *testing.T) {
+	listeners := []Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 8080, PID: 2001, Process: "docker-proxy", Container: "docker:web"},
+		{Proto: "tcp", Address: "127.0.0.1", Port: 3000, PID: 812, Process: "node", Command: "node server.js"},
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22},
+	}
+	assert.Equal(t, "Port 8080/tcp on 0.0.0.0 is held by container docker:web, via docker-proxy (pid 2001).", Describe(listeners, 8080))
+	assert.Equal(t, "Port 3000/tcp on 127.0.0.1 is held by node (pid 812). Command line: node server.js", Describe(listeners, 3000))
+	assert.Equal(t, "Port 22/tcp on 0.0.0.0 is held by unknown process.", Describe(listeners, 22))
+	assert.Equal(t, "Nothing is listening on port 5000.", Describe(listeners, 5000))
+}
+
+func TestAddressInUsePort(t *testing.T) {
+	tests := []struct {
+		command, stderr string
+		want            int
+	}{
+		{"npm start", "Error: listen EADDRINUSE: address already in use :::3000", 3000},
+		{"python3 -m http.server 8000", "OSError: [Errno 98] Address already in use", 8000},
+		{"docker run -p 8080:80 nginx", "Bind for 0.0.0.0:8080 failed: port is already allocated", 8080},
+		{"rails s -p 4000", "Address already in use - bind(2) for \"127.0.0.1\" port 4000 (Errno::EADDRINUSE)", 4000},
+		{"ls missing", "ls: cannot access 'missing': No such file or directory", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, AddressInUsePort(tt.command, tt.stderr), tt.command)
+	}
+}
@@ -0,0 +1,306 @@
+package ports
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/containers"
+)
+
+// procStates are the socket states that count as listening in
+// /proc/net: LISTEN for TCP and an unconnected socket for UDP.
+var procStates = map[string]string{"tcp": "0A", "tcp6": "0A", "udp": "07", "udp6": "07"}
+
+// fromProc reads the sockets from /proc/net under root, and their owners
+// from the socket file descriptors of each process.
+func fromProc(root string) ([]Listener, error) {
+	var listeners []Listener
+	inodes := map[string][]int{}
+	found := false
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		file, err := os.Open(filepath.Join(root, "net", proto))
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, socket := range parseProcNet(file, proto) {
+			inodes[socket.inode] = append(inodes[socket.inode], len(listeners))
+			listeners = append(listeners, socket.Listener)
+		}
+		_ = file.Close()
+	}
+	if !found {
+		return nil, fmt.Errorf("no %s/net", root)
+	}
+
+	// Processes of other users can't be read without root; their sockets
+	// stay without an owner
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(root, entry.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(root, entry.Name(), "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+			indexes, ok := inodes[inode]
+			if !ok {
+				continue
+			}
+			name, command := procProcess(root, entry.Name())
+			for _, i := range indexes {
+				listeners[i].PID, listeners[i].Process, listeners[i].Command = pid, name, command
+			}
+			delete(inodes, inode)
+		}
+	}
+	return listeners, nil
+}
+
+func procProcess(root, pid string) (name, command string) {
+	comm, _ := os.ReadFile(filepath.Join(root, pid, "comm"))
+	cmdline, _ := os.ReadFile(filepath.Join(root, pid, "cmdline"))
+	return strings.TrimSpace(string(comm)), strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+}
+
+type procSocket struct {
+	Listener
+	inode string
+}
+
+// parseProcNet parses a /proc/net/tcp style table, whose addresses are hex
+// in host byte order, e.g. 0100007F:1F90 for 127.0.0.1:8080.
+func parseProcNet(file io.Reader, proto string) []procSocket {
+	var sockets []procSocket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != procStates[proto] {
+			continue
+		}
+		hexAddress, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		port, err := strconv.ParseUint(hexPort, 16, 16)
+		if err != nil {
+			continue
+		}
+		address, err := procAddress(hexAddress)
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, procSocket{
+			Listener: Listener{Proto: proto, Address: address, Port: int(port)},
+			inode:    fields[9],
+		})
+	}
+	return sockets
+}
+
+// procAddress decodes an address from /proc/net, stored as 32-bit words in
+// little-endian order.
+func procAddress(hexAddress string) (string, error) {
+	raw, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return "", fmt.Errorf("bad address %q", hexAddress)
+	}
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for i := 0; i < 4; i++ {
+			ip[word+i] = raw[word+3-i]
+		}
+	}
+	return ip.String(), nil
+}
+
+// fromLsof asks lsof for the listening sockets, in its field output: a p
+// line per process, then c (command), P (protocol) and n (name) lines per
+// socket.
+func fromLsof(ctx context.Context, run containers.RunFunc) ([]Listener, error) {
+	tcp, err := run(ctx, "lsof", "-nP", "-iTCP", "-sTCP:LISTEN", "-FpcPn")
+	if err != nil && tcp == "" {
+		return nil, err
+	}
+	udp, _ := run(ctx, "lsof", "-nP", "-iUDP", "-FpcPn")
+	return append(parseLsof(tcp), parseLsof(udp)...), nil
+}
+
+func parseLsof(out string) []Listener {
+	var listeners []Listener
+	var pid int
+	var process, proto string
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+		switch line[0] {
+		case 'p':
+			pid, _ = strconv.Atoi(value)
+		case 'c':
+			process = value
+		case 'P':
+			proto = strings.ToLower(value)
+		case 'n':
+			// UDP sockets with a peer are connections, not listeners
+			if strings.Contains(value, "->") {
+				continue
+			}
+			address, port, ok := splitHostPort(value)
+			if !ok {
+				continue
+			}
+			p := proto
+			if strings.Contains(address, ":") {
+				p += "6"
+			}
+			listeners = append(listeners, Listener{Proto: p, Address: address, Port: port, PID: pid, Process: process})
+		}
+	}
+	return listeners
+}
+
+// fromNetstat is the last resort: netstat lists the sockets but not their
+// owners.
+func fromNetstat(ctx context.Context, run containers.RunFunc) ([]Listener, error) {
+	out, err := run(ctx, "netstat", "-an")
+	if err != nil {
+		return nil, err
+	}
+	return parseNetstat(out), nil
+}
+
+// parseNetstat reads both the Linux form, 0.0.0.0:22, and the BSD one,
+// *.22, of the local address column.
+func parseNetstat(out string) []Listener {
+	var listeners []Listener
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		proto := strings.ToLower(fields[0])
+		switch {
+		case strings.HasPrefix(proto, "tcp"):
+			if fields[len(fields)-1] != "LISTEN" {
+				continue
+			}
+		case strings.HasPrefix(proto, "udp"):
+		default:
+			continue
+		}
+		local := fields[3]
+		address, port, ok := splitHostPort(local)
+		if !ok {
+			// BSD netstat separates the port with a dot
+			if i := strings.LastIndex(local, "."); i > 0 {
+				address = local[:i]
+				port, _ = strconv.Atoi(local[i+1:])
+				ok = port > 0
+			}
+		}
+		if !ok {
+			continue
+		}
+		proto = strings.TrimSuffix(proto, "4")
+		if proto == "tcp46" || proto == "udp46" {
+			proto = strings.TrimSuffix(proto, "46") + "6"
+		}
+		if address == "*" {
+			address = "0.0.0.0"
+			if strings.HasSuffix(proto, "6") {
+				address = "::"
+			}
+		}
+		listeners = append(listeners, Listener{Proto: proto, Address: address, Port: port})
+	}
+	return listeners
+}
+
+// splitHostPort splits addresses like *:8080, 127.0.0.1:80 and [::1]:443.
+func splitHostPort(address string) (string, int, bool) {
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return "", 0, false
+	}
+	if host == "*" {
+		host = "0.0.0.0"
+	}
+	return host, port, true
+}
+
+// publishedPorts maps the host ports docker and podman publish to the
+// containers they lead to.
+func publishedPorts(ctx context.Context, run containers.RunFunc) map[int]string {
+	published := map[int]string{}
+	for _, runtime := range []containers.Runtime{containers.Docker, containers.Podman} {
+		out, err := run(ctx, string(runtime), "ps", "--format", "{{.Names}}\t{{.Ports}}")
+		if err != nil {
+			continue
+		}
+		for port, name := range parsePublished(out) {
+			published[port] = containers.Container{Runtime: runtime, Name: name}.Identity()
+		}
+	}
+	return published
+}
+
+// parsePublished reads the Ports column of docker ps, e.g.
+// "0.0.0.0:8080->80/tcp, :::8080->80/tcp".
+func parsePublished(out string) map[int]string {
+	published := map[int]string{}
+	for _, line := range strings.Split(out, "\n") {
+		name, ports, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		for _, mapping := range strings.Split(ports, ",") {
+			host, _, ok := strings.Cut(strings.TrimSpace(mapping), "->")
+			if !ok {
+				continue
+			}
+			i := strings.LastIndex(host, ":")
+			if i < 0 {
+				continue
+			}
+			// A range such as 8000-8002 publishes each of its ports
+			first, last, isRange := strings.Cut(host[i+1:], "-")
+			from, err := strconv.Atoi(first)
+			if err != nil {
+				continue
+			}
+			to := from
+			if isRange {
+				if to, err = strconv.Atoi(last); err != nil {
+					continue
+				}
+			}
+			for port := from; port <= to; port++ {
+				published[port] = name
+			}
+		}
+	}
+	return published
+}
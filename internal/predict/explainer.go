@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/tldr"
 	"github.com/robottwo/bishop/internal/utils"
 	openai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
@@ -19,6 +20,7 @@ type LLMExplainer struct {
 	logger      *zap.Logger
 	modelId     string
 	temperature *float64
+	tldrStore   *tldr.Store
 }
 
 func NewLLMExplainer(
@@ -33,6 +35,7 @@ func NewLLMExplainer(
 		logger:      logger,
 		modelId:     modelConfig.ModelId,
 		temperature: modelConfig.Temperature,
+		tldrStore:   tldr.NewStore(tldr.DefaultCacheDir()),
 	}
 }
 
@@ -51,6 +54,15 @@ func (e *LLMExplainer) Explain(ctx context.Context, input string) (string, error
 		return "", err
 	}
 
+	examplesText := ""
+	if e.tldrStore != nil {
+		if examples := e.tldrStore.ContextFor(input); examples != "" {
+			examplesText = "\n# Command Examples\n" +
+				"Curated tldr pages for the commands used. Prefer them over guessing what an option does.\n" +
+				examples + "\n"
+		}
+	}
+
 	systemMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
 You will be given a bash command entered by me, enclosed in <command> tags.
 
@@ -61,10 +73,11 @@ You will be given a bash command entered by me, enclosed in <command> tags.
 
 # Latest Context
 %s
-
+%s
 # Response JSON Schema
 %s`,
 		e.contextText,
+		examplesText,
 		string(schema),
 	)
 
@@ -0,0 +1,64 @@
+package predict
+
+import (
+	"context"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+)
+
+// historyPredictionWindow is how many of the latest matching commands the
+// history predictor ranks.
+const historyPredictionWindow = 200
+
+// HistoryPredictor suggests commands from history without a model, as
+// zsh-autosuggestions does, so that a suggestion shows as soon as a key is
+// pressed: the command that starts with the input and was run most often,
+// the most recent one on ties. Commands that failed are left out, as they
+// are often typos.
+type HistoryPredictor struct {
+	historyManager *history.HistoryManager
+}
+
+func NewHistoryPredictor(historyManager *history.HistoryManager) *HistoryPredictor {
+	return &HistoryPredictor{historyManager: historyManager}
+}
+
+func (p *HistoryPredictor) Predict(ctx context.Context, input string) (string, string, error) {
+	if strings.TrimSpace(input) == "" || strings.HasPrefix(input, "#") {
+		return "", "", nil
+	}
+	entries, err := p.historyManager.GetRecentEntriesByPrefix(input, historyPredictionWindow)
+	if err != nil {
+		return "", "", err
+	}
+	return bestHistoryMatch(entries, input), "history", nil
+}
+
+// bestHistoryMatch returns the command of entries, newest first, that
+// completes input and was run most often, or "" if none does.
+func bestHistoryMatch(entries []history.HistoryEntry, input string) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, entry := range entries {
+		command := entry.Command
+		// The query matches without regard to case, and _ and % as any text
+		if !strings.HasPrefix(command, input) || command == input || strings.Contains(command, "\n") {
+			continue
+		}
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			continue
+		}
+		if counts[command] == 0 {
+			order = append(order, command)
+		}
+		counts[command]++
+	}
+	best := ""
+	for _, command := range order {
+		if counts[command] > counts[best] {
+			best = command
+		}
+	}
+	return best
+}
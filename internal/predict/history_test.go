@@ -0,0 +1,63 @@
+package predict
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(command string, exitCode int32) history.HistoryEntry {
+	return history.HistoryEntry{Command: command, ExitCode: sql.NullInt32{Int32: exitCode, Valid: true}}
+}
+
+func TestBestHistoryMatch(t *testing.T) {
+	// Newest first, as history returns them
+	entries := []history.HistoryEntry{
+		entry("git status", 0),
+		entry("git push", 0),
+		entry("git psuh", 1),
+		entry("git psuh", 1),
+		entry("git psuh", 1),
+		entry("git push", 0),
+		entry("git status", 0),
+		entry("git log", 0),
+		entry("GIT_DIR=x git log", 0),
+		entry("git", 0),
+	}
+	assert.Equal(t, "git status", bestHistoryMatch(entries, "git"), "the most recent of the most frequent")
+	assert.Equal(t, "git push", bestHistoryMatch(entries, "git p"), "failed commands are left out")
+	assert.Equal(t, "git log", bestHistoryMatch(entries, "git l"))
+	assert.Equal(t, "", bestHistoryMatch(entries, "git_"), "the prefix is matched exactly")
+	assert.Equal(t, "", bestHistoryMatch(entries, "git status"), "the input is not suggested again")
+	assert.Equal(t, "", bestHistoryMatch([]history.HistoryEntry{entry("cat <<EOF\nx\nEOF", 0)}, "cat"))
+}
+
+func TestHistoryPredictor(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	for _, command := range []string{"make test", "make build", "make test"} {
+		e, err := historyManager.StartCommand(command, "/tmp", "")
+		require.NoError(t, err)
+		_, err = historyManager.FinishCommand(e, 0)
+		require.NoError(t, err)
+	}
+
+	p := NewHistoryPredictor(historyManager)
+	prediction, _, err := p.Predict(context.Background(), "make")
+	require.NoError(t, err)
+	assert.Equal(t, "make test", prediction)
+
+	prediction, _, err = p.Predict(context.Background(), "make b")
+	require.NoError(t, err)
+	assert.Equal(t, "make build", prediction)
+
+	for _, input := range []string{"", "  ", "# make"} {
+		prediction, _, err = p.Predict(context.Background(), input)
+		require.NoError(t, err)
+		assert.Equal(t, "", prediction)
+	}
+}
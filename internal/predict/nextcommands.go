@@ -0,0 +1,130 @@
+package predict
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// LLMNextCommandRanker reorders the commands offered by the next command
+// menu, which are ranked from history statistics, using the shell context.
+type LLMNextCommandRanker struct {
+	runner      *interp.Runner
+	llmClient   *openai.Client
+	contextText string
+	logger      *zap.Logger
+	modelId     string
+	temperature *float64
+}
+
+func NewLLMNextCommandRanker(
+	runner *interp.Runner,
+	logger *zap.Logger,
+) *LLMNextCommandRanker {
+	llmClient, modelConfig := utils.GetLLMClient(runner, utils.FastModel)
+	return &LLMNextCommandRanker{
+		runner:      runner,
+		llmClient:   llmClient,
+		contextText: "",
+		logger:      logger,
+		modelId:     modelConfig.ModelId,
+		temperature: modelConfig.Temperature,
+	}
+}
+
+func (p *LLMNextCommandRanker) UpdateContext(context *map[string]string) {
+	contextTypes := environment.GetContextTypesForPredictionWithoutPrefix(p.runner, p.logger)
+	p.contextText = utils.ComposeContextText(context, contextTypes, p.logger)
+}
+
+// Rerank returns commands reordered by the LLM. Only the given commands are
+// returned, each once; any the LLM left out keep their order at the end.
+func (p *LLMNextCommandRanker) Rerank(ctx context.Context, commands []string) ([]string, error) {
+	if len(commands) < 2 {
+		return commands, nil
+	}
+
+	schema, err := RANKED_COMMANDS_SCHEMA.MarshalJSON()
+	if err != nil {
+		p.logger.Error("failed to marshal schema", zap.Error(err))
+		return nil, err
+	}
+
+	userMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+These are commands from my history that I often run in situations like this one:
+
+%s
+
+# Instructions
+* Based on the context, reorder them from the one I am most likely to run next to the least likely
+* Return exactly the given commands, unchanged
+
+# Latest Context
+%s
+
+# Response JSON Schema
+%s`,
+		"* "+strings.Join(commands, "\n* "),
+		p.contextText,
+		string(schema),
+	)
+
+	request := openai.ChatCompletionRequest{
+		Model: p.modelId,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    "user",
+				Content: userMessage,
+			},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if p.temperature != nil {
+		request.Temperature = float32(*p.temperature)
+	}
+
+	chatCompletion, err := p.llmClient.CreateChatCompletion(ctx, request)
+	if err != nil {
+		p.logger.Error("LLM API call failed", zap.Error(err))
+		return nil, err
+	}
+
+	ranked := RankedCommands{}
+	if err := json.Unmarshal([]byte(chatCompletion.Choices[0].Message.Content), &ranked); err != nil {
+		p.logger.Error("failed to unmarshal ranked commands", zap.Error(err), zap.String("content", chatCompletion.Choices[0].Message.Content))
+		return nil, err
+	}
+	return mergeRanking(commands, ranked.Commands), nil
+}
+
+// mergeRanking orders commands as in ranked, ignoring anything ranked that is
+// not one of them, followed by the commands ranked left out.
+func mergeRanking(commands, ranked []string) []string {
+	remaining := map[string]bool{}
+	for _, command := range commands {
+		remaining[command] = true
+	}
+	result := make([]string, 0, len(commands))
+	for _, command := range ranked {
+		command = strings.TrimSpace(command)
+		if remaining[command] {
+			result = append(result, command)
+			delete(remaining, command)
+		}
+	}
+	for _, command := range commands {
+		if remaining[command] {
+			result = append(result, command)
+		}
+	}
+	return result
+}
@@ -0,0 +1,16 @@
+package predict
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRanking(t *testing.T) {
+	commands := []string{"ls", "make test", "git status"}
+
+	assert.Equal(t, []string{"git status", "ls", "make test"}, mergeRanking(commands, []string{"git status", "ls", "make test"}))
+	// Made up and repeated commands are dropped, left out ones are kept
+	assert.Equal(t, []string{"make test", "ls", "git status"}, mergeRanking(commands, []string{"rm -rf /", " make test ", "make test"}))
+	assert.Equal(t, commands, mergeRanking(commands, nil))
+}
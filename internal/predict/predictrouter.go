@@ -27,3 +27,12 @@ func (p *PredictRouter) Predict(ctx context.Context, input string) (string, stri
 	}
 	return p.PrefixPredictor.Predict(ctx, input)
 }
+
+// PredictCandidates predicts up to n commands that complete input, the most
+// likely first.
+func (p *PredictRouter) PredictCandidates(ctx context.Context, input string, n int) ([]string, string, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, "", nil
+	}
+	return p.PrefixPredictor.PredictCandidates(ctx, input, n)
+}
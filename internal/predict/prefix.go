@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/fastsearch"
+	"github.com/robottwo/bishop/internal/flaghabits"
 	"github.com/robottwo/bishop/internal/history"
 	"github.com/robottwo/bishop/internal/utils"
 	openai "github.com/sashabaranov/go-openai"
@@ -55,12 +57,83 @@ func (p *LLMPrefixPredictor) Predict(ctx context.Context, input string) (string,
 		return "", "", nil
 	}
 
-	schema, err := PREDICTED_COMMAND_SCHEMA.MarshalJSON()
+	userMessage, err := p.prompt(input, 1)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := p.complete(ctx, userMessage)
 	if err != nil {
-		p.logger.Error("failed to marshal schema", zap.Error(err))
 		return "", "", err
 	}
 
+	prediction := PredictedCommand{}
+	err = json.Unmarshal([]byte(content), &prediction)
+	if err != nil {
+		p.logger.Error("failed to unmarshal prediction", zap.Error(err), zap.String("content", content))
+	}
+
+	return prediction.PredictedCommand, userMessage, nil
+}
+
+// PredictCandidates predicts up to n different complete commands for input,
+// the most likely first, in a single request.
+func (p *LLMPrefixPredictor) PredictCandidates(ctx context.Context, input string, n int) ([]string, string, error) {
+	if strings.HasPrefix(input, "#") {
+		p.logger.Debug("skipping prediction for agent chat message")
+		return nil, "", nil
+	}
+
+	userMessage, err := p.prompt(input, n)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := p.complete(ctx, userMessage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	predictions := PredictedCommands{}
+	if err := json.Unmarshal([]byte(content), &predictions); err != nil {
+		p.logger.Error("failed to unmarshal predictions", zap.Error(err), zap.String("content", content))
+	}
+
+	return distinctCandidates(predictions.PredictedCommands, input, n), userMessage, nil
+}
+
+// distinctCandidates returns the first n different candidates that start
+// with input, as the model may repeat itself or ignore the prefix.
+func distinctCandidates(candidates []string, input string, n int) []string {
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" || seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(input)) {
+			continue
+		}
+		seen[candidate] = true
+		distinct = append(distinct, candidate)
+		if len(distinct) == n {
+			break
+		}
+	}
+	return distinct
+}
+
+// prompt returns the request for n predictions of the command that starts
+// with input.
+func (p *LLMPrefixPredictor) prompt(input string, n int) (string, error) {
+	predictionSchema, task, rule := PREDICTED_COMMAND_SCHEMA, "predict what the complete bash command is", "Your prediction must be a valid, single-line, complete bash command"
+	if n > 1 {
+		predictionSchema = PREDICTED_COMMANDS_SCHEMA
+		task = fmt.Sprintf("predict the %d most likely complete bash commands, the most likely first", n)
+		rule = "Each prediction must be a valid, single-line, complete bash command, and different from the others"
+	}
+	schema, err := predictionSchema.MarshalJSON()
+	if err != nil {
+		p.logger.Error("failed to marshal schema", zap.Error(err))
+		return "", err
+	}
+
 	matchingHistoryEntries, err := p.historyManager.GetRecentEntriesByPrefix(
 		input,
 		p.numHistoryContext,
@@ -75,14 +148,28 @@ func (p *LLMPrefixPredictor) Predict(ctx context.Context, input string) (string,
 		}
 	}
 
-	userMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+	usualFlagsContext := ""
+	if environment.GetFlagLearning(p.runner) {
+		if habit, ok := flaghabits.Lookup(p.historyManager, flaghabits.CommandName(input)); ok {
+			usualFlagsContext = fmt.Sprintf("I almost always run `%s` as `%s`, so prefer these flags unless the prefix rules them out.\n", habit.Command, habit.String())
+		}
+	}
+
+	preferredToolsContext := strings.Builder{}
+	if environment.GetFastSearch(p.runner) != environment.FastSearchOff {
+		for _, preference := range fastsearch.Lookup(p.historyManager) {
+			preferredToolsContext.WriteString(preference.Note() + "\n")
+		}
+	}
+
+	return fmt.Sprintf(`You are Bishop, an intelligent shell program.
 You will be given a partial bash command prefix entered by me, enclosed in <prefix> tags.
-You are asked to predict what the complete bash command is.
+You are asked to %s.
 
 # Instructions
 * Based on the prefix and other context, analyze the my potential intent
 * Your prediction must start with the partial command as a prefix
-* Your prediction must be a valid, single-line, complete bash command
+* %s
 
 # Best Practices
 %s
@@ -93,17 +180,30 @@ You are asked to predict what the complete bash command is.
 # Previous Commands with Similar Prefix
 %s
 
+# My Usual Flags
+%s
+
+# My Preferred Tools
+%s
+
 # Response JSON Schema
 %s
 
 <prefix>%s</prefix>`,
+		task,
+		rule,
 		BEST_PRACTICES,
 		p.contextText,
 		matchingHistoryContext.String(),
+		usualFlagsContext,
+		preferredToolsContext.String(),
 		string(schema),
 		input,
-	)
+	), nil
+}
 
+// complete sends userMessage to the model and returns its JSON reply.
+func (p *LLMPrefixPredictor) complete(ctx context.Context, userMessage string) (string, error) {
 	p.logger.Debug(
 		"predicting using LLM",
 		zap.String("user", userMessage),
@@ -126,17 +226,12 @@ You are asked to predict what the complete bash command is.
 	}
 
 	chatCompletion, err := p.llmClient.CreateChatCompletion(ctx, request)
-
 	if err != nil {
 		p.logger.Error("LLM API call failed", zap.Error(err))
-		return "", "", err
+		return "", err
 	}
-
-	prediction := PredictedCommand{}
-	err = json.Unmarshal([]byte(chatCompletion.Choices[0].Message.Content), &prediction)
-	if err != nil {
-		p.logger.Error("failed to unmarshal prediction", zap.Error(err), zap.String("content", chatCompletion.Choices[0].Message.Content))
+	if len(chatCompletion.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
 	}
-
-	return prediction.PredictedCommand, userMessage, nil
+	return chatCompletion.Choices[0].Message.Content, nil
 }
@@ -0,0 +1,14 @@
+package predict
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistinctCandidates(t *testing.T) {
+	candidates := []string{"git status", " git status ", "", "ls -la", "Git diff", "git log", "git stash"}
+	assert.Equal(t, []string{"git status", "Git diff", "git log"}, distinctCandidates(candidates, "git", 3))
+	assert.Equal(t, []string{"git status"}, distinctCandidates(candidates, "git", 1))
+	assert.Nil(t, distinctCandidates(candidates, "docker", 3))
+}
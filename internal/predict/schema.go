@@ -10,6 +10,12 @@ type PredictedCommand struct {
 
 var PREDICTED_COMMAND_SCHEMA = utils.GenerateJsonSchema(PredictedCommand{})
 
+type PredictedCommands struct {
+	PredictedCommands []string `json:"predicted_commands" description:"The different full bash commands predicted by the model, the most likely first" required:"true"`
+}
+
+var PREDICTED_COMMANDS_SCHEMA = utils.GenerateJsonSchema(PredictedCommands{})
+
 type explainedCommand struct {
 	Explanation string `json:"explanation" description:"A concise explanation of what the command will do for me" required:"true"`
 }
@@ -21,3 +27,9 @@ type CompletionCandidates struct {
 }
 
 var COMPLETION_CANDIDATES_SCHEMA = utils.GenerateJsonSchema(CompletionCandidates{})
+
+type RankedCommands struct {
+	Commands []string `json:"commands" description:"The given commands, reordered from the one I am most likely to run next to the least likely" required:"true"`
+}
+
+var RANKED_COMMANDS_SCHEMA = utils.GenerateJsonSchema(RankedCommands{})
@@ -21,6 +21,14 @@ func TestPREDICTED_COMMAND_SCHEMA_Generated(t *testing.T) {
 	assert.Contains(t, jsonStr, "predicted_command")
 }
 
+func TestPREDICTED_COMMANDS_SCHEMA_Generated(t *testing.T) {
+	require.NotNil(t, PREDICTED_COMMANDS_SCHEMA)
+
+	jsonBytes, err := PREDICTED_COMMANDS_SCHEMA.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonBytes), "predicted_commands")
+}
+
 func TestEXPLAINED_COMMAND_SCHEMA_Generated(t *testing.T) {
 	// Verify schema is generated and not nil
 	require.NotNil(t, EXPLAINED_COMMAND_SCHEMA)
@@ -0,0 +1,69 @@
+// Package procpick implements pk, a picker that fuzzy-filters the running
+// processes and signals or renices the chosen ones.
+package procpick
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/system"
+	"github.com/sahilm/fuzzy"
+)
+
+// Action is what can be done to the picked processes.
+type Action struct {
+	Label string
+	// Args is the command that performs it, to which the PIDs are appended
+	Args []string
+}
+
+// Actions are offered in this order; the first is the default.
+var Actions = []Action{
+	{Label: "SIGTERM  ask to exit", Args: []string{"kill", "-TERM"}},
+	{Label: "SIGKILL  force to exit", Args: []string{"kill", "-KILL"}},
+	{Label: "SIGINT   interrupt, like Ctrl+C", Args: []string{"kill", "-INT"}},
+	{Label: "SIGHUP   hang up, many daemons reload", Args: []string{"kill", "-HUP"}},
+	{Label: "SIGSTOP  pause", Args: []string{"kill", "-STOP"}},
+	{Label: "SIGCONT  resume", Args: []string{"kill", "-CONT"}},
+	{Label: "renice   lower priority (nice 10)", Args: []string{"renice", "-n", "10", "-p"}},
+	{Label: "renice   lowest priority (nice 19)", Args: []string{"renice", "-n", "19", "-p"}},
+}
+
+// Command returns the command that performs action on pids.
+func Command(action Action, pids []int) []string {
+	command := append([]string{}, action.Args...)
+	for _, pid := range pids {
+		command = append(command, strconv.Itoa(pid))
+	}
+	return command
+}
+
+// Filter returns the processes matching query, best match first. An empty
+// query keeps them all, busiest first.
+func Filter(processes []system.Process, query string) []system.Process {
+	if strings.TrimSpace(query) == "" {
+		sorted := append([]system.Process{}, processes...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].CPUPercent > sorted[j].CPUPercent
+		})
+		return sorted
+	}
+	matches := fuzzy.FindFrom(query, processSource(processes))
+	filtered := make([]system.Process, len(matches))
+	for i, match := range matches {
+		filtered[i] = processes[match.Index]
+	}
+	return filtered
+}
+
+// processSource matches processes on their PID, user and command line.
+type processSource []system.Process
+
+func (s processSource) String(i int) string {
+	return strconv.Itoa(s[i].PID) + " " + s[i].User + " " + s[i].Command
+}
+
+func (s processSource) Len() int {
+	return len(s)
+}
@@ -0,0 +1,75 @@
+package procpick
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/system"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// PickFunc shows the picker and returns the confirmed command.
+type PickFunc func(processes []system.Process, query string) (command []string, ok bool, err error)
+
+// RecordFunc adds a command that pk ran to history.
+type RecordFunc func(command string, exitCode int)
+
+// listProcesses is replaced in tests.
+var listProcesses = system.GetProcesses
+
+// NewPkCommandHandler creates an ExecHandler for the pk builtin, which picks
+// processes with pick, runs the kill or renice command chosen for them, and
+// records that command with record, so history shows what was done rather
+// than pk. Arguments to pk are the initial filter.
+func NewPkCommandHandler(pick PickFunc, record RecordFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "pk" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			if len(args) == 2 && (args[1] == "-h" || args[1] == "--help") {
+				fmt.Fprintln(hc.Stdout, "Usage: pk [filter]")
+				return nil
+			}
+			processes, err := listProcesses()
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "pk: listing processes: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			// Don't offer to kill the shell itself
+			self := os.Getpid()
+			var others []system.Process
+			for _, p := range processes {
+				if p.PID != self {
+					others = append(others, p)
+				}
+			}
+
+			command, ok, err := pick(others, strings.Join(args[1:], " "))
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "pk: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if !ok {
+				return interp.NewExitStatus(1)
+			}
+
+			fmt.Fprintln(hc.Stdout, strings.Join(command, " "))
+			err = next(ctx, command)
+			exitCode := 0
+			if status, isExit := interp.IsExitStatus(err); isExit {
+				exitCode = int(status)
+			} else if err != nil {
+				exitCode = 1
+			}
+			if record != nil {
+				record(strings.Join(command, " "), exitCode)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package procpick
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+type recorded struct {
+	command  string
+	exitCode int
+}
+
+func runPk(t *testing.T, script string, pick PickFunc, exitStatus uint8) (ran [][]string, history []recorded, stdout string, err error) {
+	t.Helper()
+
+	original := listProcesses
+	listProcesses = func() ([]system.Process, error) { return testProcesses, nil }
+	t.Cleanup(func() { listProcesses = original })
+
+	fakeExec := func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			ran = append(ran, args)
+			if exitStatus != 0 {
+				return interp.NewExitStatus(exitStatus)
+			}
+			return nil
+		}
+	}
+	record := func(command string, exitCode int) {
+		history = append(history, recorded{command, exitCode})
+	}
+	var out bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &out, &out),
+		interp.ExecHandlers(NewPkCommandHandler(pick, record), fakeExec),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return ran, history, out.String(), err
+}
+
+func TestPkRunsAndRecordsCommand(t *testing.T) {
+	var gotQuery string
+	var gotCount int
+	pick := func(processes []system.Process, query string) ([]string, bool, error) {
+		gotQuery, gotCount = query, len(processes)
+		return []string{"kill", "-TERM", "913"}, true, nil
+	}
+	ran, history, stdout, err := runPk(t, "pk python train", pick, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "python train", gotQuery)
+	assert.Equal(t, len(testProcesses), gotCount)
+	assert.Equal(t, [][]string{{"kill", "-TERM", "913"}}, ran)
+	assert.Equal(t, []recorded{{"kill -TERM 913", 0}}, history)
+	assert.Equal(t, "kill -TERM 913\n", stdout)
+}
+
+func TestPkRecordsFailure(t *testing.T) {
+	pick := func([]system.Process, string) ([]string, bool, error) {
+		return []string{"kill", "-KILL", "1"}, true, nil
+	}
+	_, history, _, err := runPk(t, "pk", pick, 1)
+	assert.Error(t, err)
+	assert.Equal(t, []recorded{{"kill -KILL 1", 1}}, history)
+}
+
+func TestPkCancelled(t *testing.T) {
+	pick := func([]system.Process, string) ([]string, bool, error) {
+		return nil, false, nil
+	}
+	ran, history, _, err := runPk(t, "pk", pick, 0)
+	_, isExit := interp.IsExitStatus(err)
+	assert.True(t, isExit)
+	assert.Empty(t, ran)
+	assert.Empty(t, history)
+}
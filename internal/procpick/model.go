@@ -0,0 +1,273 @@
+package procpick
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/system"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	headerStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	commandStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+)
+
+// Picker steps, in order.
+const (
+	stepProcesses = iota
+	stepAction
+	stepConfirm
+)
+
+const (
+	defaultRows  = 15
+	defaultWidth = 100
+)
+
+// model lets the user filter and select processes, choose an action and
+// confirm the command that performs it.
+type model struct {
+	processes []system.Process
+	filtered  []system.Process
+	filter    textinput.Model
+	cursor    int
+	offset    int
+	selected  map[int]bool
+
+	step   int
+	action int
+
+	width     int
+	rows      int
+	confirmed bool
+	cancelled bool
+}
+
+func newModel(processes []system.Process, query string) model {
+	filter := textinput.New()
+	filter.Prompt = "› "
+	filter.Placeholder = "filter by name, command, user or PID"
+	filter.SetValue(query)
+	filter.Focus()
+	m := model{
+		processes: processes,
+		filter:    filter,
+		selected:  map[int]bool{},
+		width:     defaultWidth,
+		rows:      defaultRows,
+	}
+	m.filtered = Filter(processes, query)
+	return m
+}
+
+// pids returns the selected PIDs, or the one under the cursor if none is.
+func (m model) pids() []int {
+	var pids []int
+	for pid, ok := range m.selected {
+		if ok {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 && m.cursor < len(m.filtered) {
+		pids = append(pids, m.filtered[m.cursor].PID)
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+// command returns the command the picker will run.
+func (m model) command() []string {
+	return Command(Actions[m.action], m.pids())
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = size.Width
+		// Leave room for the title, filter, header and help
+		m.rows = max(3, min(defaultRows, size.Height-7))
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.filter, cmd = m.filter.Update(msg)
+		return m, cmd
+	}
+	if keyMsg.String() == "ctrl+c" {
+		m.cancelled = true
+		return m, tea.Quit
+	}
+
+	switch m.step {
+	case stepAction:
+		switch keyMsg.String() {
+		case "up", "k", "ctrl+p":
+			m.action = (m.action + len(Actions) - 1) % len(Actions)
+		case "down", "j", "ctrl+n":
+			m.action = (m.action + 1) % len(Actions)
+		case "enter":
+			m.step = stepConfirm
+		case "esc":
+			m.step = stepProcesses
+		}
+		return m, nil
+	case stepConfirm:
+		switch keyMsg.String() {
+		case "y", "Y", "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "n", "N", "esc":
+			m.step = stepAction
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		m.cancelled = true
+		return m, tea.Quit
+	case "up", "ctrl+p":
+		m = m.moveCursor(-1)
+		return m, nil
+	case "down", "ctrl+n":
+		m = m.moveCursor(1)
+		return m, nil
+	case "tab", " ":
+		if m.cursor < len(m.filtered) {
+			pid := m.filtered[m.cursor].PID
+			m.selected[pid] = !m.selected[pid]
+			m = m.moveCursor(1)
+		}
+		return m, nil
+	case "enter":
+		if len(m.pids()) > 0 {
+			m.step = stepAction
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	query := m.filter.Value()
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != query {
+		m.filtered = Filter(m.processes, m.filter.Value())
+		m.cursor, m.offset = 0, 0
+	}
+	return m, cmd
+}
+
+func (m model) moveCursor(delta int) model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	m.cursor = max(0, min(len(m.filtered)-1, m.cursor+delta))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.rows {
+		m.offset = m.cursor - m.rows + 1
+	}
+	return m
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+	switch m.step {
+	case stepAction:
+		sb.WriteString(titleStyle.Render(fmt.Sprintf("What should happen to %s?", describePIDs(m.pids()))) + "\n\n")
+		for i, action := range Actions {
+			if i == m.action {
+				sb.WriteString(cursorStyle.Render("› "+action.Label) + "\n")
+			} else {
+				sb.WriteString("  " + action.Label + "\n")
+			}
+		}
+		sb.WriteString("\n" + helpStyle.Render("↑↓: choose • enter: continue • esc: back • ctrl+c: cancel") + "\n")
+		return sb.String()
+	case stepConfirm:
+		sb.WriteString(titleStyle.Render("Run this command?") + "\n\n")
+		sb.WriteString(commandStyle.Render(strings.Join(m.command(), " ")) + "\n\n")
+		sb.WriteString(helpStyle.Render("y/enter: run • n/esc: back • ctrl+c: cancel") + "\n")
+		return sb.String()
+	}
+
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("Processes (%d of %d)", len(m.filtered), len(m.processes))) + "\n")
+	sb.WriteString(m.filter.View() + "\n")
+	sb.WriteString(headerStyle.Render(m.row(" ", "PID", "USER", "CPU%", "MEM", "COMMAND")) + "\n")
+	end := min(len(m.filtered), m.offset+m.rows)
+	for i := m.offset; i < end; i++ {
+		p := m.filtered[i]
+		mark := " "
+		if m.selected[p.PID] {
+			mark = "●"
+		}
+		line := m.row(mark, fmt.Sprint(p.PID), p.User, fmt.Sprintf("%.1f", p.CPUPercent), formatMemory(p.MemBytes), p.Command)
+		switch {
+		case i == m.cursor:
+			line = cursorStyle.Render(line)
+		case m.selected[p.PID]:
+			line = selectedStyle.Render(line)
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(helpStyle.Render("type: filter • ↑↓: move • tab/space: select • enter: choose action • esc: cancel") + "\n")
+	return sb.String()
+}
+
+// row lays out the columns of a process line, cutting the command to fit.
+func (m model) row(mark, pid, user, cpu, mem, command string) string {
+	if len(user) > 10 {
+		user = user[:9] + "…"
+	}
+	line := fmt.Sprintf("%s %7s  %-10s %5s %7s  ", mark, pid, user, cpu, mem)
+	room := m.width - lipgloss.Width(line) - 1
+	if runes := []rune(command); room > 0 && len(runes) > room {
+		command = string(runes[:room-1]) + "…"
+	}
+	return line + command
+}
+
+func formatMemory(bytes uint64) string {
+	const unit = 1024
+	switch {
+	case bytes >= unit*unit*unit:
+		return fmt.Sprintf("%.1fG", float64(bytes)/(unit*unit*unit))
+	case bytes >= unit*unit:
+		return fmt.Sprintf("%.0fM", float64(bytes)/(unit*unit))
+	default:
+		return fmt.Sprintf("%dK", bytes/unit)
+	}
+}
+
+func describePIDs(pids []int) string {
+	if len(pids) == 1 {
+		return fmt.Sprintf("process %d", pids[0])
+	}
+	return fmt.Sprintf("%d processes", len(pids))
+}
+
+// Run shows the picker, starting with query as the filter, and returns the
+// confirmed command, or false if the user cancelled.
+func Run(processes []system.Process, query string) ([]string, bool, error) {
+	program := tea.NewProgram(newModel(processes, query))
+	result, err := program.Run()
+	if err != nil {
+		return nil, false, err
+	}
+	m := result.(model)
+	if !m.confirmed {
+		return nil, false, nil
+	}
+	return m.command(), true, nil
+}
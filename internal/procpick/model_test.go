@@ -0,0 +1,92 @@
+package procpick
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/internal/system"
+	"github.com/stretchr/testify/assert"
+)
+
+var testProcesses = []system.Process{
+	{PID: 1, User: "root", CPUPercent: 0.1, MemBytes: 10 << 20, Name: "init", Command: "/sbin/init"},
+	{PID: 812, User: "alice", CPUPercent: 1.5, MemBytes: 200 << 20, Name: "node", Command: "node server.js"},
+	{PID: 913, User: "alice", CPUPercent: 97.0, MemBytes: 2 << 30, Name: "python3", Command: "python3 train.py"},
+	{PID: 914, User: "alice", CPUPercent: 3.0, MemBytes: 64 << 20, Name: "python3", Command: "python3 -m http.server"},
+}
+
+func sendKeys(m model, keys ...tea.KeyMsg) model {
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(model)
+	}
+	return m
+}
+
+func typeText(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+func TestFilter(t *testing.T) {
+	busiest := Filter(testProcesses, "")
+	assert.Equal(t, 913, busiest[0].PID)
+	assert.Equal(t, 1, busiest[3].PID)
+
+	matches := Filter(testProcesses, "pyth")
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "python3", matches[0].Name)
+
+	assert.Equal(t, 812, Filter(testProcesses, "812")[0].PID)
+	assert.Empty(t, Filter(testProcesses, "zzz"))
+}
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, []string{"kill", "-TERM", "913", "914"}, Command(Actions[0], []int{913, 914}))
+	assert.Equal(t, []string{"renice", "-n", "10", "-p", "812"}, Command(Actions[6], []int{812}))
+}
+
+func TestPickerKillsCursorProcess(t *testing.T) {
+	m := newModel(testProcesses, "")
+	view := m.View()
+	assert.Contains(t, view, "Processes (4 of 4)")
+	assert.Contains(t, view, "2.0G")
+	assert.Contains(t, view, "python3 train.py")
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.View(), "What should happen to process 913?")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.View(), "kill -KILL 913")
+	m = sendKeys(m, typeText("y"))
+	assert.True(t, m.confirmed)
+	assert.Equal(t, []string{"kill", "-KILL", "913"}, m.command())
+}
+
+func TestPickerMultiSelectWithFilter(t *testing.T) {
+	m := newModel(testProcesses, "")
+	m = sendKeys(m, typeText("p"), typeText("y"), typeText("t"))
+	assert.Contains(t, m.View(), "Processes (2 of 4)")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.View(), "What should happen to 2 processes?")
+
+	for range 6 {
+		m = sendKeys(m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.confirmed)
+	assert.Equal(t, []string{"renice", "-n", "10", "-p", "913", "914"}, m.command())
+}
+
+func TestPickerBackAndCancel(t *testing.T) {
+	m := newModel(testProcesses, "node")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.View(), "kill -TERM 812")
+	m = sendKeys(m, typeText("n"), tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Contains(t, m.View(), "Processes (1 of 4)")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.True(t, m.cancelled)
+	assert.False(t, m.confirmed)
+
+	m = newModel(testProcesses, "zzz")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.View(), "Processes (0 of 4)")
+}
@@ -0,0 +1,251 @@
+// Package progpreview previews the jq or awk program being typed in a
+// pipeline such as curl -s URL | jq '.items[]': the structure of the input
+// the program reads, and what it prints, on a sample of that input.
+package progpreview
+
+import (
+	"path"
+	"strings"
+)
+
+// Program is a jq or awk program being typed at the end of a pipeline.
+type Program struct {
+	// Tool is jq or awk.
+	Tool string
+	// Source is the commands whose output the program reads.
+	Source string
+	// Options are the words of the command before the program, as typed.
+	Options []string
+	// Text is the program, unquoted.
+	Text string
+	// WordStart and WordEnd are where the program is in the line, quotes
+	// included; WordEnd is the end of the line if its quote is not closed.
+	WordStart, WordEnd int
+}
+
+// token is a word or an operator of a command line.
+type token struct {
+	// raw is the token as typed; text is the word unquoted
+	raw, text  string
+	start, end int
+	operator   bool
+}
+
+// tokenize splits line into words and control operators. A quote left open
+// runs to the end of the line, as it does while a program is typed.
+func tokenize(line string) []token {
+	var tokens []token
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '|' || c == '&' || c == ';' || c == '\n':
+			start := i
+			i++
+			if i < len(line) && (c == '|' || c == '&') && line[i] == c {
+				i++
+			}
+			tokens = append(tokens, token{raw: line[start:i], text: line[start:i], start: start, end: i, operator: true})
+		default:
+			start := i
+			var text strings.Builder
+			for i < len(line) && !strings.ContainsRune(" \t|&;\n", rune(line[i])) {
+				switch line[i] {
+				case '\'':
+					end := strings.IndexByte(line[i+1:], '\'')
+					if end < 0 {
+						text.WriteString(line[i+1:])
+						i = len(line)
+						continue
+					}
+					text.WriteString(line[i+1 : i+1+end])
+					i += end + 2
+				case '"':
+					i++
+					for i < len(line) && line[i] != '"' {
+						if line[i] == '\\' && i+1 < len(line) && strings.ContainsRune("\"\\$`", rune(line[i+1])) {
+							i++
+						}
+						text.WriteByte(line[i])
+						i++
+					}
+					i++
+				case '\\':
+					if i+1 < len(line) {
+						text.WriteByte(line[i+1])
+					}
+					i += 2
+				default:
+					text.WriteByte(line[i])
+					i++
+				}
+			}
+			i = min(i, len(line))
+			tokens = append(tokens, token{raw: line[start:i], text: text.String(), start: start, end: i})
+		}
+	}
+	return tokens
+}
+
+// Word is a word of a command line.
+type Word struct {
+	// Raw is the word as typed; Text is the word unquoted
+	Raw, Text  string
+	Start, End int
+}
+
+// TextOffset returns the offset in the unquoted text of the word of the
+// offset in the line, which is in the word.
+func (w Word) TextOffset(offset int) int {
+	tokens := tokenize(w.Raw[:min(max(offset-w.Start, 0), len(w.Raw))])
+	if len(tokens) == 0 {
+		return 0
+	}
+	return len(tokens[0].text)
+}
+
+// Command is a command of a command line and what it reads.
+type Command struct {
+	// Source is the commands of the pipeline before it, whose output it
+	// reads, or "" if it is the first.
+	Source string
+	Words  []Word
+}
+
+// CommandAt returns the command of line the cursor is in, or right after.
+func CommandAt(line string, cursor int) (Command, bool) {
+	tokens := tokenize(line)
+
+	// The command the cursor is in, and the stages of its pipeline before it
+	commandStart, stageStart, stageEnd := 0, 0, len(tokens)
+	for i, t := range tokens {
+		if !t.operator {
+			continue
+		}
+		if t.start >= cursor {
+			stageEnd = i
+			break
+		}
+		if t.raw == "|" {
+			stageStart = i + 1
+		} else {
+			commandStart, stageStart = i+1, i+1
+		}
+	}
+	if stageStart >= stageEnd {
+		return Command{}, false
+	}
+
+	var command Command
+	if stageStart > commandStart {
+		command.Source = strings.TrimSpace(line[tokens[commandStart].start:tokens[stageStart-1].start])
+	}
+	for _, t := range tokens[stageStart:stageEnd] {
+		command.Words = append(command.Words, Word{Raw: t.raw, Text: t.text, Start: t.start, End: t.end})
+	}
+	return command, true
+}
+
+// Find returns the jq or awk program at cursor in line: the program of the
+// last command of a pipeline, or of a command that reads files, while the
+// cursor is in it or right after the command with no program yet.
+func Find(line string, cursor int) (Program, bool) {
+	command, ok := CommandAt(line, cursor)
+	if !ok {
+		return Program{}, false
+	}
+	words := command.Words
+	program := Program{Tool: toolName(words[0].Text), Source: command.Source}
+	if program.Tool == "" {
+		return Program{}, false
+	}
+
+	index := programIndex(program.Tool, words)
+	if index < 0 {
+		return Program{}, false
+	}
+	for _, word := range words[:min(index, len(words))] {
+		program.Options = append(program.Options, word.Raw)
+	}
+	if index == len(words) {
+		// No program yet: the cursor has to be past the command
+		last := words[len(words)-1]
+		if cursor <= last.End || strings.TrimSpace(line[last.End:cursor]) != "" {
+			return Program{}, false
+		}
+		program.WordStart, program.WordEnd = cursor, cursor
+	} else {
+		word := words[index]
+		if cursor < word.Start || cursor > word.End {
+			return Program{}, false
+		}
+		program.Text = word.Text
+		program.WordStart, program.WordEnd = word.Start, word.End
+
+		// The files a command that is not in a pipeline reads
+		if program.Source == "" {
+			if files := Files(words[index+1:]); files != "" {
+				program.Source = "cat -- " + files
+			}
+		}
+	}
+	if program.Source == "" {
+		return Program{}, false
+	}
+	return program, true
+}
+
+// Files returns the words, as typed, of the files a command reads.
+func Files(words []Word) string {
+	var files []string
+	for _, word := range words {
+		files = append(files, word.Raw)
+	}
+	return strings.Join(files, " ")
+}
+
+func toolName(command string) string {
+	switch name := path.Base(command); name {
+	case "jq", "gojq":
+		return "jq"
+	case "awk", "gawk", "mawk", "nawk":
+		return "awk"
+	}
+	return ""
+}
+
+// programIndex returns the index of the program in the words of a jq or
+// awk command, len(words) if it is yet to be typed, or -1 if the program is
+// read from a file.
+func programIndex(tool string, words []Word) int {
+	for i := 1; i < len(words); i++ {
+		word := words[i].Text
+		if word == "--" {
+			return i + 1
+		}
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			return i
+		}
+		switch tool {
+		case "jq":
+			switch word {
+			case "-f", "--from-file":
+				return -1
+			case "--arg", "--argjson", "--slurpfile", "--rawfile":
+				i += 2
+			case "--indent", "--tab-width":
+				i++
+			}
+		case "awk":
+			switch {
+			case word == "-f" || strings.HasPrefix(word, "--file"):
+				return -1
+			case word == "-F" || word == "-v":
+				i++
+			}
+		}
+	}
+	return len(words)
+}
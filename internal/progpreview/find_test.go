@@ -0,0 +1,58 @@
+package progpreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	line := `curl -s https://api.example.com/jobs | jq -r '.jobs[] | .name'`
+	program, ok := Find(line, len(line)-3)
+	require.True(t, ok)
+	assert.Equal(t, "jq", program.Tool)
+	assert.Equal(t, "curl -s https://api.example.com/jobs", program.Source)
+	assert.Equal(t, []string{"jq", "-r"}, program.Options)
+	assert.Equal(t, ".jobs[] | .name", program.Text)
+	assert.Equal(t, len(line), program.WordEnd)
+
+	// A quote still open runs to the end of the line
+	line = `cat access.log | awk -F: '{print $1`
+	program, ok = Find(line, len(line))
+	require.True(t, ok)
+	assert.Equal(t, "awk", program.Tool)
+	assert.Equal(t, "cat access.log", program.Source)
+	assert.Equal(t, "{print $1", program.Text)
+
+	// Right after the command, before the program is typed
+	line = "cat data.json | jq "
+	program, ok = Find(line, len(line))
+	require.True(t, ok)
+	assert.Equal(t, "", program.Text)
+	assert.Equal(t, len(line), program.WordStart)
+
+	// A command that reads files reads them
+	line = "jq '.name' package.json"
+	program, ok = Find(line, 5)
+	require.True(t, ok)
+	assert.Equal(t, "cat -- package.json", program.Source)
+
+	// Only the command the cursor is in counts
+	line = "cd /tmp && ls | jq . ; echo done"
+	program, ok = Find(line, 19)
+	require.True(t, ok)
+	assert.Equal(t, "ls", program.Source)
+
+	for _, line := range []string{
+		"cat data.json | sort",
+		"jq '.name'",                    // nothing to read
+		"cat data.json | jq -f prog.jq", // program in a file
+	} {
+		_, ok := Find(line, len(line))
+		assert.False(t, ok, line)
+	}
+	// The cursor outside the program
+	_, ok = Find("cat data.json | jq .name", 3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,253 @@
+package progpreview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/robottwo/bishop/internal/pipebuild"
+)
+
+const (
+	// jqSampleLines is how many lines of the source a jq program is tried
+	// on, more than for awk since JSON is often printed over many lines.
+	jqSampleLines = 2000
+	// outputLines is how many lines of what the program prints are shown.
+	outputLines = 5
+	// describeDepth and describeKeys bound the structure of the input shown.
+	describeDepth = 3
+	describeKeys  = 8
+)
+
+// sample is the output of a source, kept while its program is edited.
+type sample struct {
+	source    string
+	output    string
+	truncated bool
+	err       error
+}
+
+// Previewer previews the jq or awk programs typed at a prompt. The source
+// of a program runs once and its output is kept, so that only the program
+// runs as it is edited.
+type Previewer struct {
+	run pipebuild.RunFunc
+
+	mu     sync.Mutex
+	sample *sample
+}
+
+// New returns a Previewer that runs commands with run.
+func New(run pipebuild.RunFunc) *Previewer {
+	return &Previewer{run: run}
+}
+
+// Preview returns what to show while the cursor is in the jq or awk program
+// of line: the structure of the input it reads and what it prints on a
+// sample of it. It returns "" when the cursor is not in such a program.
+func (p *Previewer) Preview(ctx context.Context, line string, cursor int) string {
+	program, ok := Find(line, cursor)
+	if !ok {
+		return ""
+	}
+	if !ReadOnly(program.Source) {
+		return fmt.Sprintf("Not running %s to preview the %s program: it may change things.", program.Source, program.Tool)
+	}
+
+	s := p.sampleOf(ctx, program)
+	if s.err != nil && s.output == "" {
+		return fmt.Sprintf("%s failed: %v", program.Source, s.err)
+	}
+
+	var sb strings.Builder
+	lines := strings.Count(s.output, "\n")
+	if s.truncated {
+		fmt.Fprintf(&sb, "Input (first %d lines): ", lines)
+	} else {
+		fmt.Fprintf(&sb, "Input (%d lines): ", lines)
+	}
+	sb.WriteString(Describe(program.Tool, program.Options, s.output))
+
+	if strings.TrimSpace(program.Text) == "" || strings.HasPrefix(strings.TrimSpace(program.Text), "#") {
+		sb.WriteString("\nDescribe what you want as the program and press Alt+J to have it written.")
+		return sb.String()
+	}
+	output, more, err := p.run(ctx, command(program), s.output, outputLines)
+	if err != nil {
+		fmt.Fprintf(&sb, "\n%s: %v", program.Tool, err)
+		return sb.String()
+	}
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		sb.WriteString("\n(no output)")
+		return sb.String()
+	}
+	for _, line := range strings.Split(output, "\n") {
+		sb.WriteString("\n→ " + line)
+	}
+	if more {
+		sb.WriteString("\n…")
+	}
+	return sb.String()
+}
+
+// sampleOf returns the output of the source of program, running it only if
+// it is not the source of the last sample.
+func (p *Previewer) sampleOf(ctx context.Context, program Program) sample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sample != nil && p.sample.source == program.Source {
+		return *p.sample
+	}
+	maxLines := pipebuild.SampleLines
+	if program.Tool == "jq" {
+		maxLines = jqSampleLines
+	}
+	s := sample{source: program.Source}
+	s.output, s.truncated, s.err = p.run(ctx, program.Source, "", maxLines)
+	if ctx.Err() == nil {
+		// A run cut short by a newer preview is not kept
+		p.sample = &s
+	}
+	return s
+}
+
+// command returns the command that runs program as typed, with the
+// program quoted.
+func command(program Program) string {
+	return strings.Join(append(append([]string(nil), program.Options...), Quote(program.Text)), " ")
+}
+
+// Quote returns text single-quoted for the shell.
+func Quote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// Replace returns line with the program replaced with text, quoted.
+func Replace(line string, program Program, text string) string {
+	replacement := Quote(text)
+	if program.WordStart == program.WordEnd {
+		// A program yet to be typed goes after the command
+		if program.WordStart > 0 && line[program.WordStart-1] != ' ' {
+			replacement = " " + replacement
+		}
+	}
+	return line[:program.WordStart] + replacement + line[program.WordEnd:]
+}
+
+// Describe returns the structure of input as the tool sees it: the shape of
+// the JSON values for jq, and the fields of the first record for awk.
+func Describe(tool string, options []string, input string) string {
+	if strings.TrimSpace(input) == "" {
+		return "empty"
+	}
+	if tool == "awk" {
+		return describeFields(fieldSeparator(options), input)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(input))
+	var shapes []string
+	for len(shapes) < 3 {
+		var value any
+		if err := decoder.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			if len(shapes) == 0 {
+				return "not JSON in the sample (" + err.Error() + ")"
+			}
+			break
+		}
+		shapes = append(shapes, describeJSON(value, 0))
+	}
+	if decoder.More() || len(shapes) == 3 {
+		shapes = append(shapes, "…")
+	}
+	return strings.Join(shapes, ", ")
+}
+
+func describeJSON(value any, depth int) string {
+	switch value := value.(type) {
+	case map[string]any:
+		if len(value) == 0 {
+			return "{}"
+		}
+		if depth >= describeDepth {
+			return "{…}"
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for i, k := range keys {
+			if i == describeKeys {
+				fields = append(fields, "…")
+				break
+			}
+			fields = append(fields, k+": "+describeJSON(value[k], depth+1))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []any:
+		if len(value) == 0 {
+			return "[]"
+		}
+		if depth >= describeDepth {
+			return "[…]"
+		}
+		return fmt.Sprintf("[%s ×%d]", describeJSON(value[0], depth+1), len(value))
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// fieldSeparator returns the -F of awk options, or "" for the default.
+func fieldSeparator(options []string) string {
+	for i, option := range options {
+		unquoted := strings.Trim(option, `'"`)
+		switch {
+		case unquoted == "-F" && i+1 < len(options):
+			return strings.Trim(options[i+1], `'"`)
+		case strings.HasPrefix(unquoted, "-F"):
+			return strings.Trim(strings.TrimPrefix(unquoted, "-F"), `'"`)
+		}
+	}
+	return ""
+}
+
+func describeFields(separator, input string) string {
+	record, _, _ := strings.Cut(input, "\n")
+	var fields []string
+	switch separator {
+	case "":
+		fields = strings.Fields(record)
+	case `\t`:
+		fields = strings.Split(record, "\t")
+	default:
+		fields = strings.Split(record, separator)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "NF=%d", len(fields))
+	for i, field := range fields {
+		if i == describeKeys {
+			sb.WriteString(" …")
+			break
+		}
+		if len(field) > 20 {
+			field = field[:20] + "…"
+		}
+		fmt.Fprintf(&sb, " $%d=%s", i+1, field)
+	}
+	return sb.String()
+}
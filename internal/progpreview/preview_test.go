@@ -0,0 +1,87 @@
+package progpreview
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner prints output for the source and echoes the input back for
+// programs, counting the runs of the source.
+type fakeRunner struct {
+	source  string
+	output  string
+	sources int
+}
+
+func (f *fakeRunner) run(ctx context.Context, command, input string, maxLines int) (string, bool, error) {
+	if command == f.source {
+		f.sources++
+		return f.output, false, nil
+	}
+	if strings.Contains(command, "broken") {
+		return "", false, errors.New("jq: error: syntax error")
+	}
+	return input, false, nil
+}
+
+func TestPreview(t *testing.T) {
+	runner := &fakeRunner{source: "cat jobs.json", output: `{"jobs": [{"name": "build", "ok": true}], "total": 1}` + "\n"}
+	previewer := New(runner.run)
+
+	line := "cat jobs.json | jq '.jobs'"
+	preview := previewer.Preview(context.Background(), line, len(line)-1)
+	assert.Equal(t, "Input (1 lines): {jobs: [{name: string, ok: boolean} ×1], total: number}\n→ "+strings.TrimSpace(runner.output), preview)
+
+	// The source runs once while the program is edited
+	line = "cat jobs.json | jq 'broken'"
+	preview = previewer.Preview(context.Background(), line, len(line)-1)
+	assert.Contains(t, preview, "\njq: jq: error: syntax error")
+	assert.Equal(t, 1, runner.sources)
+
+	// Before the program, a description is asked for
+	line = "cat jobs.json | jq '# the names of the jobs"
+	preview = previewer.Preview(context.Background(), line, len(line))
+	assert.Contains(t, preview, "Alt+J")
+
+	// Sources that may change things are not run
+	line = "curl -X DELETE https://example.com/jobs | jq ."
+	preview = previewer.Preview(context.Background(), line, len(line))
+	assert.True(t, strings.HasPrefix(preview, "Not running"), preview)
+
+	assert.Equal(t, "", previewer.Preview(context.Background(), "ls -l", 3))
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, "empty", Describe("jq", nil, "\n"))
+	assert.Equal(t, "[], number", Describe("jq", nil, "[]\n3\n"))
+	assert.Equal(t, "{a: {b: {c: {…}}}}", Describe("jq", nil, `{"a": {"b": {"c": {"d": 1}}}}`))
+	assert.True(t, strings.HasPrefix(Describe("jq", nil, "GET /index.html"), "not JSON in the sample"))
+
+	assert.Equal(t, "NF=3 $1=GET $2=/index.html $3=200", Describe("awk", []string{"awk"}, "GET /index.html 200\nPOST /login 302\n"))
+	assert.Equal(t, "NF=2 $1=root $2=x", Describe("awk", []string{"awk", "-F:"}, "root:x\n"))
+	assert.Equal(t, "NF=2 $1=a $2=b", Describe("awk", []string{"awk", "-F", "','"}, "a,b\n"))
+}
+
+func TestReplace(t *testing.T) {
+	line := "cat jobs.json | jq '# the names'"
+	program, ok := Find(line, len(line)-2)
+	require.True(t, ok)
+	assert.Equal(t, "cat jobs.json | jq '.jobs[].name'", Replace(line, program, ".jobs[].name"))
+	assert.Equal(t, `cat jobs.json | jq '"it'\''s"'`, Replace(line, program, `"it's"`))
+
+	line = "cat jobs.json | jq "
+	program, ok = Find(line, len(line))
+	require.True(t, ok)
+	assert.Equal(t, "cat jobs.json | jq '.'", Replace(line, program, "."))
+}
+
+func TestCleanProgram(t *testing.T) {
+	assert.Equal(t, ".jobs[].name", cleanProgram("```jq\n.jobs[].name\n```"))
+	assert.Equal(t, "{print $1}", cleanProgram("'{print $1}'"))
+	assert.Equal(t, ".a | .b", cleanProgram(" .a | .b \n"))
+}
@@ -0,0 +1,94 @@
+package progpreview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// proposalSampleBytes is how much of the sample the model is shown.
+const proposalSampleBytes = 2000
+
+// Propose returns line with the jq or awk program at cursor replaced with
+// one the fast model writes to do what the program describes, such as
+// "# the names of the failed jobs", on the output of its source.
+func (p *Previewer) Propose(ctx context.Context, runner *interp.Runner, line string, cursor int) (string, error) {
+	program, ok := Find(line, cursor)
+	if !ok {
+		return "", fmt.Errorf("the cursor is not in a jq or awk program")
+	}
+	var sample string
+	if ReadOnly(program.Source) {
+		sample = p.sampleOf(ctx, program).output
+	}
+	text, err := propose(ctx, runner, program, sample)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("no program was written")
+	}
+	return Replace(line, program, text), nil
+}
+
+// propose asks the fast model for a program of the tool of program that
+// does what its text describes, on input such as sample.
+func propose(ctx context.Context, runner *interp.Runner, program Program, sample string) (string, error) {
+	goal := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(program.Text), "#"))
+	if goal == "" {
+		return "", fmt.Errorf("describe what the %s program should do first", program.Tool)
+	}
+	client, modelConfig := utils.GetLLMClient(runner, utils.FastModel)
+
+	if len(sample) > proposalSampleBytes {
+		sample = sample[:proposalSampleBytes] + "\n[…]"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Write a %s program for this command line: %s | %s PROGRAM\n\n", program.Tool, program.Source, strings.Join(program.Options, " "))
+	fmt.Fprintf(&sb, "The program should: %s\n\n", goal)
+	if sample != "" {
+		fmt.Fprintf(&sb, "The input it reads starts with:\n%s\n\n", sample)
+	}
+	sb.WriteString("Respond with the program only, unquoted, without explanation or code fences.")
+
+	request := openai.ChatCompletionRequest{
+		Model: modelConfig.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+		},
+	}
+	if modelConfig.Temperature != nil {
+		request.Temperature = float32(*modelConfig.Temperature)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to write the program: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	return cleanProgram(resp.Choices[0].Message.Content), nil
+}
+
+// cleanProgram strips the code fences and quotes models wrap programs in.
+func cleanProgram(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 && !strings.ContainsAny(text[:newline], " '{.") {
+			// The language of the fence
+			text = text[newline+1:]
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		text = text[1 : len(text)-1]
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,171 @@
+package progpreview
+
+import (
+	"path"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// readOnlyCommands are the commands that only read, and the subcommands
+// that only read of those that also write. An empty list allows every
+// subcommand.
+var readOnlyCommands = map[string][]string{
+	"base64": nil, "basename": nil, "cat": nil, "column": nil, "cut": nil,
+	"date": nil, "df": nil, "dig": nil, "dirname": nil, "du": nil, "echo": nil,
+	"env": nil, "file": nil, "free": nil, "grep": nil, "egrep": nil,
+	"fgrep": nil, "head": nil, "hostname": nil, "id": nil, "ip": nil,
+	"jq": nil, "gojq": nil, "ls": nil, "lsblk": nil, "lsof": nil,
+	"md5sum": nil, "nl": nil, "od": nil, "paste": nil, "printenv": nil,
+	"printf": nil, "ps": nil, "readlink": nil, "realpath": nil, "rev": nil,
+	"rg": nil, "seq": nil, "sha1sum": nil, "sha256sum": nil, "sort": nil,
+	"ss": nil, "stat": nil, "tac": nil, "tail": nil, "tr": nil, "uname": nil,
+	"uniq": nil, "uptime": nil, "wc": nil, "who": nil, "xxd": nil, "yq": nil,
+	"zcat": nil,
+
+	"docker":     {"images", "inspect", "logs", "ps", "version"},
+	"podman":     {"images", "inspect", "logs", "ps", "version"},
+	"gh":         {"api", "issue", "pr", "release", "repo", "run"},
+	"git":        {"diff", "log", "ls-files", "rev-parse", "show", "status"},
+	"journalctl": nil,
+	"kubectl":    {"describe", "get", "logs", "top", "version"},
+	"npm":        {"ls", "view"},
+	"systemctl":  {"list-units", "show", "status"},
+}
+
+// readOnlyGHActions are the actions of the gh subcommands that only read.
+var readOnlyGHActions = []string{"list", "view", "status", "diff", "checks"}
+
+// writingFlags are flags that make a command send data or write files.
+var writingFlags = map[string][]string{
+	"curl": {"-X", "--request", "-d", "--data", "--data-raw", "--data-binary", "--data-urlencode",
+		"-F", "--form", "-T", "--upload-file", "-o", "--output", "-O", "--remote-name"},
+	"gh":  {"-X", "--method", "-f", "--field", "-F", "--raw-field", "--input"},
+	"yq":  {"-i", "--inplace"},
+	"env": {"-S", "--split-string"},
+}
+
+// ReadOnly reports whether source only reads, so that it can run while the
+// program that reads its output is typed: each of its commands is one known
+// to only read, and nothing is written to files.
+func ReadOnly(source string) bool {
+	file, err := syntax.NewParser().Parse(strings.NewReader(source), "")
+	if err != nil || len(file.Stmts) == 0 {
+		return false
+	}
+	readOnly := true
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if !readOnly {
+			return false
+		}
+		switch node := node.(type) {
+		case *syntax.Redirect:
+			switch node.Op {
+			case syntax.RdrIn, syntax.DplIn, syntax.Hdoc, syntax.DashHdoc, syntax.WordHdoc:
+			case syntax.DplOut:
+				// 2>&1 writes to the output already there
+			default:
+				if node.Word == nil || node.Word.Lit() != "/dev/null" {
+					readOnly = false
+				}
+			}
+		case *syntax.CallExpr:
+			readOnly = readOnlyCall(node)
+		case *syntax.FuncDecl, *syntax.ProcSubst, *syntax.CoprocClause:
+			readOnly = false
+		}
+		return readOnly
+	})
+	return readOnly
+}
+
+func readOnlyCall(call *syntax.CallExpr) bool {
+	if len(call.Args) == 0 {
+		// Assignments only
+		return len(call.Assigns) == 0
+	}
+	var args []string
+	for _, word := range call.Args {
+		literal := word.Lit()
+		if literal == "" {
+			// Quoted or expanded: only the name of the command has to be known
+			if len(args) == 0 {
+				var sb strings.Builder
+				if err := syntax.NewPrinter().Print(&sb, word); err != nil {
+					return false
+				}
+				literal = strings.Trim(sb.String(), `"'`)
+			}
+		}
+		args = append(args, literal)
+	}
+
+	name := path.Base(args[0])
+	if name == "curl" || name == "wget" {
+		return name == "curl" && !hasFlag(args[1:], writingFlags["curl"])
+	}
+	subcommands, known := readOnlyCommands[name]
+	if !known || hasFlag(args[1:], writingFlags[name]) {
+		return false
+	}
+	switch name {
+	case "env":
+		// env runs the command after its variables
+		for i, arg := range args[1:] {
+			if !strings.Contains(arg, "=") && !strings.HasPrefix(arg, "-") {
+				return readOnlyCall(&syntax.CallExpr{Args: call.Args[i+1:]})
+			}
+		}
+	case "grep", "egrep", "fgrep", "rg":
+		return !hasFlag(args[1:], []string{"--pre"})
+	}
+	if subcommands == nil {
+		return true
+	}
+
+	subcommand := firstOperand(args[1:])
+	if !contains(subcommands, subcommand) {
+		return false
+	}
+	if name == "gh" && subcommand != "api" {
+		index := indexOf(args, subcommand)
+		return contains(readOnlyGHActions, firstOperand(args[index+1:]))
+	}
+	return true
+}
+
+// hasFlag reports whether args has any of flags, as a word of its own or
+// with its value attached.
+func hasFlag(args, flags []string) bool {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") ||
+				(len(flag) == 2 && !strings.HasPrefix(arg, "--") && strings.HasPrefix(arg, flag)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstOperand(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+func contains(list []string, value string) bool {
+	return indexOf(list, value) >= 0
+}
+
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,37 @@
+package progpreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	for _, source := range []string{
+		"cat access.log",
+		"curl -s https://api.example.com/jobs",
+		"kubectl get pods -o json",
+		"gh pr list --json number,title",
+		"git log --format=%H 2>/dev/null",
+		"grep -v DEBUG app.log | tail -n 100",
+		"env LC_ALL=C sort data.txt",
+	} {
+		assert.True(t, ReadOnly(source), source)
+	}
+	for _, source := range []string{
+		"curl -X POST https://api.example.com/jobs",
+		"curl -o out.json https://example.com",
+		"kubectl delete pod web",
+		"gh pr merge 12",
+		"cat a > b",
+		"rm -rf build; ls",
+		"make test",
+		"env make",
+		"git push",
+		"wget https://example.com",
+		"cat <(rm x)",
+		"",
+	} {
+		assert.False(t, ReadOnly(source), source)
+	}
+}
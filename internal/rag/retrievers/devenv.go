@@ -0,0 +1,22 @@
+package retrievers
+
+import (
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/devenv"
+)
+
+type DevEnvContextRetriever struct {
+	Envs []devenv.Env
+}
+
+func (r DevEnvContextRetriever) Name() string {
+	return "dev_environment"
+}
+
+func (r DevEnvContextRetriever) GetContext() (string, error) {
+	if len(r.Envs) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("<dev_environment>%s</dev_environment>", devenv.Describe(r.Envs)), nil
+}
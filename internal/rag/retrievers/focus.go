@@ -0,0 +1,24 @@
+package retrievers
+
+import (
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"mvdan.cc/sh/v3/interp"
+)
+
+type FocusContextRetriever struct {
+	Runner *interp.Runner
+}
+
+func (r FocusContextRetriever) Name() string {
+	return "focus"
+}
+
+func (r FocusContextRetriever) GetContext() (string, error) {
+	task := environment.GetFocus(r.Runner)
+	if task == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("<focus>I am currently working on: %s</focus>", task), nil
+}
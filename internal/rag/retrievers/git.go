@@ -6,8 +6,11 @@ import (
 	"strings"
 
 	"github.com/robottwo/bishop/internal/bash"
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/git"
 	"go.uber.org/zap"
 	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 type GitStatusContextRetriever struct {
@@ -20,16 +23,43 @@ func (r GitStatusContextRetriever) Name() string {
 }
 
 func (r GitStatusContextRetriever) GetContext() (string, error) {
-	revParseOut, _, err := bash.RunBashCommandInSubShell(context.Background(), r.Runner, "git rev-parse --show-toplevel")
-	if err != nil {
-		r.Logger.Debug("error running `git rev-parse --show-toplevel`", zap.Error(err))
+	repo := git.FindRepo(environment.GetPwd(r.Runner), func(name string) string {
+		return r.Runner.Vars[name].String()
+	})
+	if repo == nil {
 		return "<git_status>not in a git repository</git_status>", nil
 	}
-	statusOut, _, err := bash.RunBashCommandInSubShell(context.Background(), r.Runner, "git status")
+
+	command := "git"
+	for _, arg := range repo.GitArgs() {
+		quoted, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			return "", nil
+		}
+		command += " " + quoted
+	}
+	command += " status"
+	if repo.Kind == git.KindBare {
+		// The work tree is usually the home directory, full of untracked files
+		command += " --untracked-files=no"
+	}
+	statusOut, _, err := bash.RunBashCommandInSubShell(context.Background(), r.Runner, command)
 	if err != nil {
 		r.Logger.Debug("error running `git status`", zap.Error(err))
 		return "", nil
 	}
 
-	return fmt.Sprintf("<git_status>Project root: %s\n%s</git_status>", strings.TrimSpace(revParseOut), statusOut), nil
+	var header strings.Builder
+	fmt.Fprintf(&header, "Project root: %s\n", repo.Root)
+	fmt.Fprintf(&header, "Repository: %s\n", repo.Name)
+	switch repo.Kind {
+	case git.KindWorktree:
+		fmt.Fprintf(&header, "Worktree: %s\n", repo.Worktree)
+	case git.KindSubmodule:
+		fmt.Fprintf(&header, "Submodule of: %s\n", repo.Superproject)
+	case git.KindBare:
+		fmt.Fprintf(&header, "Bare repository: %s (work tree %s)\n", repo.GitDir, repo.Root)
+	}
+
+	return fmt.Sprintf("<git_status>%s%s</git_status>", header.String(), statusOut), nil
 }
@@ -0,0 +1,28 @@
+package retrievers
+
+import (
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/journal"
+	"github.com/robottwo/bishop/internal/todo"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// JournalContextRetriever provides the handoff note left by the last session
+// in the current project, see #!wrapup.
+type JournalContextRetriever struct {
+	Runner *interp.Runner
+}
+
+func (r JournalContextRetriever) Name() string {
+	return "journal"
+}
+
+func (r JournalContextRetriever) GetContext() (string, error) {
+	latest, err := journal.Latest(todo.ProjectFor(environment.GetPwd(r.Runner)))
+	if err != nil || latest == "" {
+		return "", err
+	}
+	return fmt.Sprintf("<last_session>Notes from the last session in this project:\n%s</last_session>", latest), nil
+}
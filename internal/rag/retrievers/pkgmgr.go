@@ -0,0 +1,25 @@
+package retrievers
+
+import (
+	"fmt"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/pkgmgr"
+	"mvdan.cc/sh/v3/interp"
+)
+
+type PackageManagerContextRetriever struct {
+	Runner *interp.Runner
+}
+
+func (r PackageManagerContextRetriever) Name() string {
+	return "package_manager"
+}
+
+func (r PackageManagerContextRetriever) GetContext() (string, error) {
+	m, err := pkgmgr.Current(environment.GetPackageManager(r.Runner))
+	if err != nil {
+		return "", nil
+	}
+	return fmt.Sprintf("<package_manager>%s</package_manager>", pkgmgr.Describe(m)), nil
+}
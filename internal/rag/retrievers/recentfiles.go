@@ -0,0 +1,33 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robottwo/bishop/internal/environment"
+	"github.com/robottwo/bishop/internal/recentfiles"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// recentFilesLimit is how many recently modified files are listed.
+const recentFilesLimit = 10
+
+// RecentFilesContextRetriever lists the files most recently modified under
+// the working directory, so that "the file I just edited" can be resolved.
+type RecentFilesContextRetriever struct {
+	Runner *interp.Runner
+}
+
+func (r RecentFilesContextRetriever) Name() string {
+	return "recent_files"
+}
+
+func (r RecentFilesContextRetriever) GetContext() (string, error) {
+	files, err := recentfiles.Recent(context.Background(), environment.GetPwd(r.Runner), recentFilesLimit)
+	if err != nil || len(files) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("<recent_files>Most recently modified files in the working directory, newest first:\n%s</recent_files>",
+		recentfiles.Describe(files, time.Now())), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/robottwo/bishop/internal/userland"
 	"mvdan.cc/sh/v3/interp"
 )
 
@@ -18,5 +19,5 @@ func (r SystemInfoContextRetriever) Name() string {
 func (r SystemInfoContextRetriever) GetContext() (string, error) {
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
-	return fmt.Sprintf("<system_info>OS: %s, Arch: %s</system_info>", osName, arch), nil
+	return fmt.Sprintf("<system_info>OS: %s, Arch: %s, Userland: %s</system_info>", osName, arch, userland.Current()), nil
 }
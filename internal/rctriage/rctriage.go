@@ -0,0 +1,282 @@
+// Package rctriage collects the errors found in config files at startup, so
+// that they can be triaged at the first prompt instead of scrolling away:
+// each one can be opened in $EDITOR, handed to the agent to fix, or ignored
+// for good.
+package rctriage
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/robottwo/bishop/internal/bash"
+)
+
+// contextLines is how many lines around an issue are shown to the agent.
+const contextLines = 5
+
+// Issue is an error in a config file.
+type Issue struct {
+	File string
+	// Line is 0 if the error is not tied to a line
+	Line    uint
+	Message string
+}
+
+// Location returns file:line, with the home directory shortened to ~.
+func (i Issue) Location() string {
+	location := i.File
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if rest, ok := strings.CutPrefix(location, home+string(filepath.Separator)); ok {
+			location = filepath.Join("~", rest)
+		}
+	}
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, i.Line)
+	}
+	return location
+}
+
+func (i Issue) String() string {
+	return i.Location() + ": " + i.Message
+}
+
+// Fingerprint identifies the issue across sessions. It includes the text of
+// the line, so that an ignored issue comes back once the line is changed.
+func (i Issue) Fingerprint() string {
+	_, lines := i.lines(0)
+	sum := sha256.Sum256([]byte(i.File + "\n" + i.Message + "\n" + strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lines returns the line of the issue and around lines around it, and the
+// number of the first one.
+func (i Issue) lines(around uint) (uint, []string) {
+	if i.Line == 0 {
+		return 0, nil
+	}
+	f, err := os.Open(i.File)
+	if err != nil {
+		return 0, nil
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	first := uint(1)
+	if i.Line > around {
+		first = i.Line - around
+	}
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for n := uint(1); scanner.Scan() && n <= i.Line+around; n++ {
+		if n >= first {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return first, lines
+}
+
+// FromError returns the issues err reports for the config file at file.
+func FromError(file string, err error) []Issue {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var issues []Issue
+		for _, err := range joined.Unwrap() {
+			issues = append(issues, FromError(file, err)...)
+		}
+		return issues
+	}
+
+	var timeoutErr *bash.RCTimeoutError
+	var configErr *bash.ConfigError
+	switch {
+	case errors.As(err, &timeoutErr):
+		return []Issue{{
+			File:    timeoutErr.File,
+			Line:    timeoutErr.Line,
+			Message: fmt.Sprintf("timed out after %s, the rest of the file was skipped", timeoutErr.Timeout),
+		}}
+	case errors.As(err, &configErr):
+		return []Issue{{File: configErr.File, Line: configErr.Line, Message: configErr.Err.Error()}}
+	default:
+		return []Issue{{File: file, Message: err.Error()}}
+	}
+}
+
+// FixPrompt returns the request to the agent to fix issue.
+func FixPrompt(issue Issue) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "My shell config file %s reported this error when bish started:\n%s\n", issue.File, issue)
+	if first, lines := issue.lines(contextLines); len(lines) > 0 {
+		prompt.WriteString("\nThese are the lines around it:\n```bash\n")
+		for n, line := range lines {
+			fmt.Fprintf(&prompt, "%d: %s\n", first+uint(n), line)
+		}
+		prompt.WriteString("```\n")
+	}
+	prompt.WriteString("\nbish runs config files with a bash-compatible interpreter. Explain what is wrong and how to change the file to fix it. Do not edit the file yourself.")
+	return prompt.String()
+}
+
+// EditorArgs returns the command that opens editor at the line of issue.
+func EditorArgs(editor string, issue Issue) []string {
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil
+	}
+	if issue.Line == 0 {
+		return append(args, issue.File)
+	}
+	switch filepath.Base(args[0]) {
+	case "code", "code-insiders", "codium", "cursor":
+		return append(args, "-g", fmt.Sprintf("%s:%d", issue.File, issue.Line))
+	case "subl", "zed":
+		return append(args, fmt.Sprintf("%s:%d", issue.File, issue.Line))
+	default:
+		// vi, vim, nvim, nano, emacs, micro and most others
+		return append(args, fmt.Sprintf("+%d", issue.Line), issue.File)
+	}
+}
+
+// Ignored is the set of issues the user chose to ignore for good, stored
+// one fingerprint per line.
+type Ignored struct {
+	path         string
+	fingerprints map[string]bool
+}
+
+// DefaultIgnoredPath returns where the ignored issues are stored.
+func DefaultIgnoredPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "ignored_rc_errors")
+}
+
+// LoadIgnored reads the ignored issues at path. A missing file is an empty
+// set.
+func LoadIgnored(path string) (*Ignored, error) {
+	ignored := &Ignored{path: path, fingerprints: map[string]bool{}}
+	if path == "" {
+		return ignored, nil
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ignored, nil
+	} else if err != nil {
+		return ignored, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ignored.fingerprints[line] = true
+		}
+	}
+	return ignored, nil
+}
+
+// Has reports whether issue is ignored.
+func (ig *Ignored) Has(issue Issue) bool {
+	return ig.fingerprints[issue.Fingerprint()]
+}
+
+// Add ignores issue from now on.
+func (ig *Ignored) Add(issue Issue) error {
+	fingerprint := issue.Fingerprint()
+	if ig.fingerprints[fingerprint] {
+		return nil
+	}
+	if ig.path == "" {
+		return fmt.Errorf("no place to store ignored errors")
+	}
+	if err := os.MkdirAll(filepath.Dir(ig.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(ig.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, fingerprint); err != nil {
+		_ = f.Close()
+		return err
+	}
+	ig.fingerprints[fingerprint] = true
+	return f.Close()
+}
+
+// Filter returns the issues that are not ignored.
+func (ig *Ignored) Filter(issues []Issue) []Issue {
+	var kept []Issue
+	for _, issue := range issues {
+		if !ig.Has(issue) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// pending holds the issues found at startup that were not dealt with yet.
+var (
+	pendingMu sync.Mutex
+	pending   []Issue
+)
+
+// Record adds issues to the ones to triage.
+func Record(issues ...Issue) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	pending = append(pending, issues...)
+}
+
+// Pending returns the issues to triage.
+func Pending() []Issue {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	return append([]Issue(nil), pending...)
+}
+
+// Get returns the nth pending issue, counting from 1.
+func Get(n int) (Issue, bool) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if n < 1 || n > len(pending) {
+		return Issue{}, false
+	}
+	return pending[n-1], true
+}
+
+// Resolve removes the nth pending issue, counting from 1, and returns it.
+func Resolve(n int) (Issue, bool) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if n < 1 || n > len(pending) {
+		return Issue{}, false
+	}
+	issue := pending[n-1]
+	pending = append(pending[:n-1:n-1], pending[n:]...)
+	return issue, true
+}
+
+// Panel renders the triage panel listing issues.
+func Panel(issues []Issue) string {
+	var panel strings.Builder
+	if len(issues) == 1 {
+		panel.WriteString("⚠ Your config files reported 1 error at startup:\n")
+	} else {
+		fmt.Fprintf(&panel, "⚠ Your config files reported %d errors at startup:\n", len(issues))
+	}
+	for n, issue := range issues {
+		fmt.Fprintf(&panel, "  %d. %s\n", n+1, issue)
+	}
+	panel.WriteString("#!triage edit N opens it in $EDITOR, #!triage fix N asks the agent to fix it, #!triage ignore N hides it for good\n")
+	return panel.String()
+}
@@ -0,0 +1,83 @@
+package rctriage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/bash"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRC(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".bishrc")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFromError(t *testing.T) {
+	err := errors.Join(
+		&bash.ConfigError{File: "/rc", Line: 3, Err: errors.New("bad")},
+		&bash.RCTimeoutError{File: "/rc", Line: 7, Timeout: 10 * time.Second, Source: "curl x"},
+		errors.New("exit status 1"),
+	)
+	assert.Equal(t, []Issue{
+		{File: "/rc", Line: 3, Message: "bad"},
+		{File: "/rc", Line: 7, Message: "timed out after 10s, the rest of the file was skipped"},
+		{File: "/rc", Message: "exit status 1"},
+	}, FromError("/rc", err))
+	assert.Nil(t, FromError("/rc", nil))
+}
+
+func TestFixPromptShowsSurroundingLines(t *testing.T) {
+	path := writeRC(t, "a=1\nb=2\nbroken here\nc=3\n")
+	prompt := FixPrompt(Issue{File: path, Line: 3, Message: "broken: command not found"})
+	assert.Contains(t, prompt, path+":3: broken: command not found")
+	assert.Contains(t, prompt, "```bash\n1: a=1\n2: b=2\n3: broken here\n4: c=3\n```")
+}
+
+func TestEditorArgs(t *testing.T) {
+	issue := Issue{File: "/home/u/.bishrc", Line: 12}
+	assert.Equal(t, []string{"vim", "+12", "/home/u/.bishrc"}, EditorArgs("vim", issue))
+	assert.Equal(t, []string{"code", "-w", "-g", "/home/u/.bishrc:12"}, EditorArgs("code -w", issue))
+	assert.Equal(t, []string{"nano", "/home/u/.bishrc"}, EditorArgs("nano", Issue{File: "/home/u/.bishrc"}))
+}
+
+func TestIgnoredUntilLineChanges(t *testing.T) {
+	rc := writeRC(t, "ok\nbroken\n")
+	issue := Issue{File: rc, Line: 2, Message: "broken: command not found"}
+	path := filepath.Join(t.TempDir(), "bish", "ignored_rc_errors")
+
+	ignored, err := LoadIgnored(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Issue{issue}, ignored.Filter([]Issue{issue}))
+	assert.NoError(t, ignored.Add(issue))
+
+	ignored, err = LoadIgnored(path)
+	assert.NoError(t, err)
+	assert.Empty(t, ignored.Filter([]Issue{issue}))
+
+	assert.NoError(t, os.WriteFile(rc, []byte("ok\nbroken again\n"), 0o644))
+	assert.Equal(t, []Issue{issue}, ignored.Filter([]Issue{issue}))
+}
+
+func TestPending(t *testing.T) {
+	t.Cleanup(func() { pending = nil })
+	Record(Issue{File: "/a", Message: "one"}, Issue{File: "/b", Message: "two"})
+
+	issue, ok := Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "/b", issue.File)
+
+	issue, ok = Resolve(1)
+	assert.True(t, ok)
+	assert.Equal(t, "/a", issue.File)
+	assert.Equal(t, []Issue{{File: "/b", Message: "two"}}, Pending())
+
+	_, ok = Get(2)
+	assert.False(t, ok)
+	assert.Contains(t, Panel(Pending()), "1 error at startup:\n  1. /b: two\n")
+}
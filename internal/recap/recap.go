@@ -0,0 +1,183 @@
+// Package recap builds a "resume where you left off" recap of the last
+// session in a project, shown in the assistant box at startup. The recap is
+// built from the history database without an LLM; #!recap asks the slow
+// model to turn it into a short summary.
+package recap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// sessionCommands is how many commands of the last session are looked at.
+const sessionCommands = 50
+
+// shownCommands is how many of the last commands are listed in the recap.
+const shownCommands = 3
+
+// shownTodos is how many pending TODOs are listed in the recap.
+const shownTodos = 2
+
+// Recap is what was going on in a project when it was last worked on.
+type Recap struct {
+	Project string
+	// Entries are the last commands of the last session in the project,
+	// oldest first
+	Entries []history.HistoryEntry
+	// Todos are the pending TODOs of the project
+	Todos []todo.Todo
+}
+
+// Build returns the recap of the last session other than sessionID in
+// project, or nil if there has not been one. todoStore may be nil.
+func Build(historyManager *history.HistoryManager, todoStore *todo.Store, project, sessionID string) (*Recap, error) {
+	entries, err := historyManager.GetLastSessionEntries(project, sessionID, sessionCommands)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	recap := &Recap{Project: project, Entries: entries}
+	if todoStore != nil {
+		recap.Todos, err = todoStore.List(project, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return recap, nil
+}
+
+// LastFailure returns the last command of the session that failed and was
+// not run again successfully afterwards.
+func (r *Recap) LastFailure() (history.HistoryEntry, bool) {
+	fixed := map[string]bool{}
+	for i := len(r.Entries) - 1; i >= 0; i-- {
+		entry := r.Entries[i]
+		if !entry.ExitCode.Valid {
+			continue
+		}
+		if entry.ExitCode.Int32 == 0 {
+			fixed[entry.Command] = true
+			continue
+		}
+		if !fixed[entry.Command] {
+			return entry, true
+		}
+	}
+	return history.HistoryEntry{}, false
+}
+
+// String renders the recap compactly for the assistant box.
+func (r *Recap) String(now time.Time) string {
+	last := r.Entries[len(r.Entries)-1]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "↩ Last session here, %s:", ago(now.Sub(last.CreatedAt)))
+
+	commands := r.Entries[max(0, len(r.Entries)-shownCommands):]
+	names := make([]string, len(commands))
+	for i, entry := range commands {
+		names[i] = entry.Command
+	}
+	sb.WriteString(" " + strings.Join(names, " · "))
+
+	if failure, ok := r.LastFailure(); ok {
+		fmt.Fprintf(&sb, "\n✗ Last failure: %s (exit %d)", failure.Command, failure.ExitCode.Int32)
+	}
+
+	if len(r.Todos) > 0 {
+		texts := make([]string, 0, shownTodos)
+		for _, t := range r.Todos[:min(len(r.Todos), shownTodos)] {
+			texts = append(texts, t.Text)
+		}
+		noun := "TODOs"
+		if len(r.Todos) == 1 {
+			noun = "TODO"
+		}
+		fmt.Fprintf(&sb, "\n📝 %d %s: %s", len(r.Todos), noun, strings.Join(texts, "; "))
+		if more := len(r.Todos) - len(texts); more > 0 {
+			fmt.Fprintf(&sb, " (+%d more)", more)
+		}
+	}
+
+	sb.WriteString("\n#!recap for a summary")
+	return sb.String()
+}
+
+// ago describes how long ago something happened, e.g. "3h ago".
+func ago(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	}
+}
+
+// Enrich asks the slow model to summarize the recap: what was being done,
+// whether it was finished and what to do next.
+func Enrich(ctx context.Context, runner *interp.Runner, recap *Recap, logger *zap.Logger) (string, error) {
+	client, modelConfig := utils.GetLLMClient(runner, utils.SlowModel)
+
+	systemPrompt := `You are a helpful assistant that helps me resume work in a project.
+You will be given the last commands I ran in the project, with their exit codes, and my pending TODOs there.
+In at most 4 short bullet points starting with "- ", say what I was doing, whether it looks finished, and what I should do next.
+Do not repeat the commands verbatim unless they matter. Do not add a heading or closing remarks.`
+
+	request := openai.ChatCompletionRequest{
+		Model: modelConfig.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: enrichPrompt(recap)},
+		},
+	}
+	if modelConfig.Temperature != nil {
+		request.Temperature = float32(*modelConfig.Temperature)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recap: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	logger.Debug("generated recap", zap.String("project", recap.Project), zap.String("summary", summary))
+	return summary, nil
+}
+
+func enrichPrompt(recap *Recap) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<project>%s</project>\n\nThe last commands I ran there:\n\n", recap.Project)
+	for _, entry := range recap.Entries {
+		exitStatus := "✓"
+		if entry.ExitCode.Valid && entry.ExitCode.Int32 != 0 {
+			exitStatus = fmt.Sprintf("✗(%d)", entry.ExitCode.Int32)
+		}
+		fmt.Fprintf(&sb, "[%s] %s %s\n", entry.CreatedAt.Format("2006-01-02 15:04"), exitStatus, entry.Command)
+	}
+	if len(recap.Todos) > 0 {
+		sb.WriteString("\nMy pending TODOs there:\n\n")
+		for _, t := range recap.Todos {
+			sb.WriteString("- " + t.Text + "\n")
+		}
+	}
+	return sb.String()
+}
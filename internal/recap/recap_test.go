@@ -0,0 +1,87 @@
+package recap
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/todo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func entry(command string, exitCode int32, ago time.Duration) history.HistoryEntry {
+	return history.HistoryEntry{
+		Command:   command,
+		CreatedAt: now.Add(-ago),
+		ExitCode:  sql.NullInt32{Int32: exitCode, Valid: true},
+	}
+}
+
+func TestString(t *testing.T) {
+	recap := &Recap{
+		Project: "/src/billing",
+		Entries: []history.HistoryEntry{
+			entry("git pull", 0, 4*time.Hour),
+			entry("make test", 2, 3*time.Hour+10*time.Minute),
+			entry("vim cron.go", 0, 3*time.Hour+5*time.Minute),
+			entry("make deploy", 1, 3*time.Hour),
+		},
+		Todos: []todo.Todo{{Text: "rotate certs"}, {Text: "update README"}, {Text: "bump Go"}},
+	}
+
+	assert.Equal(t, "↩ Last session here, 3h ago: make test · vim cron.go · make deploy\n"+
+		"✗ Last failure: make deploy (exit 1)\n"+
+		"📝 3 TODOs: rotate certs; update README (+1 more)\n"+
+		"#!recap for a summary", recap.String(now))
+
+	recap = &Recap{Project: "/src/billing", Entries: []history.HistoryEntry{entry("ls", 0, 30*time.Minute)}}
+	assert.Equal(t, "↩ Last session here, 30m ago: ls\n#!recap for a summary", recap.String(now))
+}
+
+func TestLastFailure(t *testing.T) {
+	recap := &Recap{Entries: []history.HistoryEntry{
+		entry("make deploy", 1, 3*time.Hour),
+		entry("make test", 2, 2*time.Hour),
+		entry("make test", 0, time.Hour),
+	}}
+	failure, ok := recap.LastFailure()
+	require.True(t, ok)
+	assert.Equal(t, "make deploy", failure.Command)
+
+	// A failure fixed by a later run does not count
+	recap.Entries = recap.Entries[1:]
+	_, ok = recap.LastFailure()
+	assert.False(t, ok)
+}
+
+func TestBuild(t *testing.T) {
+	historyManager, err := history.NewHistoryManager(":memory:")
+	require.NoError(t, err)
+	todoStore, err := todo.NewStore(historyManager.GetDB())
+	require.NoError(t, err)
+
+	recap, err := Build(historyManager, todoStore, "/src/billing", "current")
+	require.NoError(t, err)
+	assert.Nil(t, recap)
+
+	_, err = historyManager.StartCommand("make test", "/src/billing", "earlier")
+	require.NoError(t, err)
+	_, err = todoStore.Add("rotate certs", "/src/billing", todo.SourceUser)
+	require.NoError(t, err)
+
+	recap, err = Build(historyManager, todoStore, "/src/billing", "current")
+	require.NoError(t, err)
+	require.NotNil(t, recap)
+	require.Len(t, recap.Entries, 1)
+	assert.Equal(t, "make test", recap.Entries[0].Command)
+	require.Len(t, recap.Todos, 1)
+
+	prompt := enrichPrompt(recap)
+	assert.Contains(t, prompt, "<project>/src/billing</project>")
+	assert.Contains(t, prompt, "make test")
+	assert.Contains(t, prompt, "- rotate certs\n")
+}
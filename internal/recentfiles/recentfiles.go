@@ -0,0 +1,197 @@
+// Package recentfiles finds the files most recently modified under a
+// directory, leaving out what git ignores, so that predictions and the agent
+// know which files the user is working on.
+package recentfiles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robottwo/bishop/internal/git"
+)
+
+const (
+	// maxScanned caps how many files are looked at, so that a huge tree
+	// does not hold up predictions
+	maxScanned = 20000
+	// maxDepth is how deep directories are walked outside a git repository
+	maxDepth = 4
+	// gitTimeout bounds how long git may take to list the files
+	gitTimeout = 2 * time.Second
+	// cacheTTL is how long a listing is reused, as predictions ask for it
+	// while the user types
+	cacheTTL = 10 * time.Second
+)
+
+// skippedDirs are directories of dependencies and build output, skipped when
+// there is no .gitignore to say so.
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// File is a recently modified file.
+type File struct {
+	// Path is relative to the directory searched
+	Path     string
+	Modified time.Time
+}
+
+// List returns up to limit files under dir, the most recently modified
+// first. In a git repository, only files that git does not ignore are
+// considered.
+func List(ctx context.Context, dir string, limit int) ([]File, error) {
+	var paths []string
+	var err error
+	if repo := git.FindRepo(dir, os.Getenv); repo != nil && repo.Kind != git.KindBare {
+		paths, err = gitFiles(ctx, dir)
+	}
+	if paths == nil || err != nil {
+		paths, err = walkFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]File, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Lstat(filepath.Join(dir, path))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, File{Path: path, Modified: info.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].Modified.Equal(files[j].Modified) {
+			return files[i].Modified.After(files[j].Modified)
+		}
+		return files[i].Path < files[j].Path
+	})
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+// gitFiles lists the tracked and untracked files under dir that git does not
+// ignore.
+func gitFiles(ctx context.Context, dir string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard").Output()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, path := range bytes.Split(out, []byte{0}) {
+		if len(path) == 0 {
+			continue
+		}
+		if len(paths) == maxScanned {
+			break
+		}
+		paths = append(paths, string(path))
+	}
+	return paths, nil
+}
+
+// walkFiles lists the files under dir, leaving out hidden files and the
+// directories in skippedDirs.
+func walkFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		name := entry.Name()
+		if entry.IsDir() {
+			if strings.HasPrefix(name, ".") || skippedDirs[name] || strings.Count(rel, string(filepath.Separator)) >= maxDepth-1 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") {
+			return nil
+		}
+		paths = append(paths, rel)
+		if len(paths) == maxScanned {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// cache is the last listing Recent made.
+var (
+	cacheMu sync.Mutex
+	cache   cachedList
+)
+
+type cachedList struct {
+	dir   string
+	limit int
+	files []File
+	at    time.Time
+}
+
+// Recent is List with the result for dir reused for a few seconds.
+func Recent(ctx context.Context, dir string, limit int) ([]File, error) {
+	cacheMu.Lock()
+	cached := cache
+	cacheMu.Unlock()
+	if cached.dir == dir && cached.limit >= limit && time.Since(cached.at) < cacheTTL {
+		return cached.files[:min(limit, len(cached.files))], nil
+	}
+
+	files, err := List(ctx, dir, limit)
+	if err != nil {
+		return nil, err
+	}
+	cacheMu.Lock()
+	cache = cachedList{dir: dir, limit: limit, files: files, at: time.Now()}
+	cacheMu.Unlock()
+	return files, nil
+}
+
+// Describe lists files one per line with how long ago they were modified.
+func Describe(files []File, now time.Time) string {
+	var sb strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&sb, "%s (modified %s)\n", file.Path, ago(now.Sub(file.Modified)))
+	}
+	return sb.String()
+}
+
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
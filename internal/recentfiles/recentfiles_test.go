@@ -0,0 +1,87 @@
+package recentfiles
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile creates path under dir, modified age ago.
+func writeFile(t *testing.T, dir, path string, age time.Duration) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+	require.NoError(t, os.WriteFile(full, []byte("x"), 0o644))
+	modified := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(full, modified, modified))
+}
+
+func paths(files []File) []string {
+	var list []string
+	for _, file := range files {
+		list = append(list, file.Path)
+	}
+	return list
+}
+
+func TestListOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "old.go", time.Hour)
+	writeFile(t, dir, "pkg/new_test.go", time.Minute)
+	writeFile(t, dir, "pkg/mid.go", 10*time.Minute)
+	writeFile(t, dir, ".env", 0)
+	writeFile(t, dir, "node_modules/dep/index.js", 0)
+	writeFile(t, dir, ".cache/blob", 0)
+
+	files, err := List(context.Background(), dir, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg/new_test.go", "pkg/mid.go", "old.go"}, paths(files))
+
+	files, err = List(context.Background(), dir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg/new_test.go", "pkg/mid.go"}, paths(files))
+}
+
+func TestListRespectsGitignore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	require.NoError(t, cmd.Run())
+
+	writeFile(t, dir, ".gitignore", 2*time.Hour)
+	writeFile(t, dir, "main.go", time.Hour)
+	writeFile(t, dir, "cmd/tool.go", time.Minute)
+	writeFile(t, dir, "out/app.log", 0)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("out/\n"), 0o644))
+	modified := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, ".gitignore"), modified, modified))
+
+	files, err := List(context.Background(), dir, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cmd/tool.go", "main.go", ".gitignore"}, paths(files))
+
+	// Only files under the directory itself
+	files, err = List(context.Background(), filepath.Join(dir, "cmd"), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool.go"}, paths(files))
+}
+
+func TestDescribe(t *testing.T) {
+	now := time.Now()
+	files := []File{
+		{Path: "a.go", Modified: now.Add(-10 * time.Second)},
+		{Path: "b.go", Modified: now.Add(-5 * time.Minute)},
+		{Path: "c.go", Modified: now.Add(-3 * time.Hour)},
+		{Path: "d.go", Modified: now.Add(-49 * time.Hour)},
+	}
+	assert.Equal(t, "a.go (modified just now)\nb.go (modified 5m ago)\nc.go (modified 3h ago)\nd.go (modified 2d ago)\n", Describe(files, now))
+}
@@ -0,0 +1,64 @@
+// Package redact masks secrets in text shown on screen, for presentation mode.
+// Secrets are the values of environment variables whose names look like they
+// hold credentials, such as GITHUB_TOKEN or AWS_SECRET_ACCESS_KEY.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mask replaces each secret.
+const Mask = "••••••"
+
+// minSecretLength is the shortest value that is treated as a secret, so that
+// flags like FOO_TOKEN_ENABLED=1 do not mask every "1" on screen.
+const minSecretLength = 6
+
+// secretName matches the names of variables that hold secrets.
+var secretName = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|PASSPHRASE|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIAL|AUTH)`)
+
+// IsSecretName reports whether the variable name looks like it holds a secret.
+func IsSecretName(name string) bool {
+	return secretName.MatchString(name)
+}
+
+// Redactor masks a fixed set of secret values.
+type Redactor struct {
+	secrets []string
+}
+
+// New returns a Redactor for the values of the variables in vars whose names
+// look like they hold secrets.
+func New(vars map[string]string) *Redactor {
+	seen := map[string]bool{}
+	var secrets []string
+	for name, value := range vars {
+		value = strings.TrimSpace(value)
+		if !IsSecretName(name) || len(value) < minSecretLength || seen[value] {
+			continue
+		}
+		seen[value] = true
+		secrets = append(secrets, value)
+	}
+	// Longest first, so that a secret containing another is masked whole
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	return &Redactor{secrets: secrets}
+}
+
+// Redact returns s with every secret replaced by Mask.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecretName(t *testing.T) {
+	for _, name := range []string{"GITHUB_TOKEN", "AWS_SECRET_ACCESS_KEY", "OPENAI_API_KEY", "db_password", "BISH_FAST_MODEL_API_KEY"} {
+		assert.True(t, IsSecretName(name), name)
+	}
+	for _, name := range []string{"HOME", "PATH", "EDITOR", "BISH_PROMPT"} {
+		assert.False(t, IsSecretName(name), name)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := New(map[string]string{
+		"GITHUB_TOKEN":      "ghp_abcdef123456",
+		"DEPLOY_TOKEN":      "abcdef",
+		"TOKEN_REFRESH_ON":  "1",
+		"HOME":              "/home/alice",
+		"DATABASE_PASSWORD": "hunter2hunter2",
+	})
+
+	line := "curl -H 'Authorization: token ghp_abcdef123456' https://api.github.com"
+	assert.Equal(t, "curl -H 'Authorization: token "+Mask+"' https://api.github.com", r.Redact(line))
+	assert.Equal(t, "psql postgres://app:"+Mask+"@db", r.Redact("psql postgres://app:hunter2hunter2@db"))
+
+	// Short values and values of other variables are left alone
+	assert.Equal(t, "cd /home/alice && echo 1", r.Redact("cd /home/alice && echo 1"))
+
+	var none *Redactor
+	assert.Equal(t, line, none.Redact(line))
+}
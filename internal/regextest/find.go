@@ -0,0 +1,354 @@
+// Package regextest is a tester for the regular expression typed as an
+// argument of grep, sed or awk, which highlights what it matches in sample
+// lines, so that escaping mistakes show before the command runs.
+package regextest
+
+import (
+	"path"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/progpreview"
+)
+
+// Dialect is the syntax a tool reads a regular expression in.
+type Dialect int
+
+const (
+	// Basic is POSIX basic syntax, where ( ) { } | + ? are literal unless
+	// escaped, as in grep and sed by default.
+	Basic Dialect = iota
+	// Extended is POSIX extended syntax, as in grep -E, sed -E and awk.
+	Extended
+	// Perl is the syntax of grep -P and rg.
+	Perl
+	// Fixed is a literal string, as in grep -F.
+	Fixed
+)
+
+// Pattern is a regular expression typed as an argument of grep, sed or awk.
+type Pattern struct {
+	Tool    string
+	Dialect Dialect
+	// Regexp is the expression as the tool reads it, unquoted.
+	Regexp string
+	// IgnoreCase, WholeWord and WholeLine are set by grep's -i, -w and -x,
+	// and IgnoreCase by sed's I flag.
+	IgnoreCase, WholeWord, WholeLine bool
+
+	// Substitute is set for the s command of sed, with its replacement and
+	// whether it replaces every match.
+	Substitute  bool
+	Replacement string
+	Global      bool
+
+	// Sample is the command that prints what the expression is matched
+	// against: the head of the files read, or the commands before in the
+	// pipeline. It is "" if there is neither.
+	Sample string
+
+	// word is the argument the expression is in, at start to end of its text
+	word       progpreview.Word
+	start, end int
+}
+
+// Find returns the regular expression at cursor in line, an argument of
+// grep, sed or awk.
+func Find(line string, cursor int) (Pattern, bool) {
+	command, ok := progpreview.CommandAt(line, cursor)
+	if !ok {
+		return Pattern{}, false
+	}
+	words := command.Words
+
+	var pattern Pattern
+	var files []progpreview.Word
+	switch name := path.Base(words[0].Text); name {
+	case "grep", "egrep", "fgrep", "rg":
+		pattern, files, ok = findGrep(name, words, cursor)
+	case "sed", "gsed":
+		pattern, files, ok = findScript("sed", words, cursor)
+	case "awk", "gawk", "mawk", "nawk":
+		pattern, files, ok = findScript("awk", words, cursor)
+	}
+	if !ok {
+		return Pattern{}, false
+	}
+
+	switch {
+	case len(files) > 0 && !pattern.recursive(words):
+		pattern.Sample = "cat -- " + progpreview.Files(files)
+	case command.Source != "" && progpreview.ReadOnly(command.Source):
+		pattern.Sample = command.Source
+	}
+	return pattern, true
+}
+
+// recursive reports whether grep searches directories, which are not
+// sampled.
+func (p Pattern) recursive(words []progpreview.Word) bool {
+	if p.Tool == "rg" {
+		return true
+	}
+	for _, word := range words[1:] {
+		text := word.Text
+		if text == "--recursive" || text == "--dereference-recursive" ||
+			(strings.HasPrefix(text, "-") && !strings.HasPrefix(text, "--") && strings.ContainsAny(text, "rR")) {
+			return true
+		}
+	}
+	return false
+}
+
+// grepOptionsWithValue are the options of grep and rg that take the next
+// word as their value.
+var grepOptionsWithValue = map[string]bool{
+	"-A": true, "-B": true, "-C": true, "-m": true, "-f": true, "-g": true,
+	"-t": true, "-T": true, "-d": true, "-D": true, "--glob": true,
+	"--type": true, "--max-count": true, "--include": true, "--exclude": true,
+	"--context": true, "--after-context": true, "--before-context": true,
+}
+
+func findGrep(name string, words []progpreview.Word, cursor int) (Pattern, []progpreview.Word, bool) {
+	p := Pattern{Tool: "grep"}
+	switch name {
+	case "egrep":
+		p.Dialect = Extended
+	case "fgrep":
+		p.Dialect = Fixed
+	case "rg":
+		p.Tool, p.Dialect = "rg", Perl
+	}
+
+	var regexp *progpreview.Word
+	var operands []progpreview.Word
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		text := word.Text
+		switch {
+		case len(operands) > 0 || !strings.HasPrefix(text, "-") || text == "-":
+			operands = append(operands, word)
+		case text == "--":
+			operands = append(operands, words[i+1:]...)
+			i = len(words)
+		case text == "-e" || text == "--regexp":
+			if i+1 < len(words) {
+				i++
+				regexp = &words[i]
+			}
+		case strings.HasPrefix(text, "--"):
+			switch text {
+			case "--extended-regexp":
+				p.Dialect = Extended
+			case "--fixed-strings":
+				p.Dialect = Fixed
+			case "--perl-regexp":
+				p.Dialect = Perl
+			case "--ignore-case":
+				p.IgnoreCase = true
+			case "--word-regexp":
+				p.WholeWord = true
+			case "--line-regexp":
+				p.WholeLine = true
+			}
+			if grepOptionsWithValue[text] {
+				i++
+			}
+		default:
+			if grepOptionsWithValue[text[:2]] && len(text) == 2 {
+				i++
+				continue
+			}
+			for _, flag := range text[1:] {
+				switch flag {
+				case 'E':
+					p.Dialect = Extended
+				case 'F':
+					p.Dialect = Fixed
+				case 'G':
+					p.Dialect = Basic
+				case 'P':
+					p.Dialect = Perl
+				case 'i':
+					p.IgnoreCase = true
+				case 'w':
+					p.WholeWord = true
+				case 'x':
+					p.WholeLine = true
+				}
+			}
+		}
+	}
+	if regexp == nil {
+		if len(operands) == 0 {
+			return Pattern{}, nil, false
+		}
+		regexp, operands = &operands[0], operands[1:]
+	}
+	if cursor < regexp.Start || cursor > regexp.End {
+		return Pattern{}, nil, false
+	}
+	p.Regexp = regexp.Text
+	p.word, p.start, p.end = *regexp, 0, len(regexp.Text)
+	return p, operands, true
+}
+
+// findScript finds the expression at cursor in the script of sed or the
+// program of awk.
+func findScript(tool string, words []progpreview.Word, cursor int) (Pattern, []progpreview.Word, bool) {
+	p := Pattern{Tool: tool}
+	if tool == "awk" {
+		p.Dialect = Extended
+	}
+
+	// With -e, the words that are not options are all files
+	var script *progpreview.Word
+	var operands []progpreview.Word
+	scripts := false
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		text := word.Text
+		switch {
+		case script != nil || scripts || !strings.HasPrefix(text, "-") || text == "-":
+			if script == nil && !scripts {
+				script = &words[i]
+			} else {
+				operands = append(operands, word)
+			}
+		case text == "--regexp-extended" || (tool == "sed" && text != "-e" && !strings.HasPrefix(text, "--") &&
+			!strings.HasPrefix(text, "-i") && strings.ContainsAny(text[1:], "Er")):
+			p.Dialect = Extended
+		case text == "-e" && tool == "sed":
+			scripts = true
+			if i+1 < len(words) && cursor >= words[i+1].Start && cursor <= words[i+1].End {
+				script = &words[i+1]
+			}
+			i++
+		case text == "-f" || text == "--file":
+			return Pattern{}, nil, false
+		case tool == "awk" && (text == "-F" || text == "-v"):
+			i++
+		}
+	}
+	if script == nil || cursor < script.Start || cursor > script.End {
+		return Pattern{}, nil, false
+	}
+
+	offset := script.TextOffset(cursor)
+	var regions []region
+	if tool == "sed" {
+		regions = sedRegions(script.Text)
+	} else {
+		regions = awkRegions(script.Text)
+	}
+	for _, r := range regions {
+		if offset < r.start || offset > r.end {
+			continue
+		}
+		p.Regexp = script.Text[r.reStart:r.reEnd]
+		p.word, p.start, p.end = *script, r.reStart, r.reEnd
+		p.Substitute, p.Replacement = r.substitute, r.replacement
+		p.Global = strings.Contains(r.flags, "g")
+		p.IgnoreCase = strings.ContainsAny(r.flags, "iI")
+		return p, operands, true
+	}
+	return Pattern{}, nil, false
+}
+
+// region is where an expression is in a script: the whole of the command
+// or address it is part of, and the expression itself.
+type region struct {
+	start, end     int
+	reStart, reEnd int
+
+	substitute  bool
+	replacement string
+	flags       string
+}
+
+// delimited returns the end of the text delimited by delim from start, at
+// the delimiter or at the end of script if it is not closed.
+func delimited(script string, start int, delim byte) int {
+	for i := start; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			i++
+		case delim:
+			return i
+		}
+	}
+	return len(script)
+}
+
+// sedRegions returns the addresses and s commands of a sed script.
+func sedRegions(script string) []region {
+	var regions []region
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '/' || (c == '\\' && i+1 < len(script)):
+			// An address, such as /^#/d or \,^#,d
+			delim := byte('/')
+			start := i
+			if c == '\\' {
+				i++
+				delim = script[i]
+			}
+			end := delimited(script, i+1, delim)
+			regions = append(regions, region{start: start, end: end, reStart: i + 1, reEnd: end})
+			i = end
+		case c == 's' && i+1 < len(script) && !strings.ContainsRune(" \t\n;\\", rune(script[i+1])) &&
+			(i == 0 || strings.ContainsRune(" \t\n;{}!,/0123456789$", rune(script[i-1]))):
+			delim := script[i+1]
+			r := region{start: i, substitute: true, reStart: i + 2}
+			r.reEnd = delimited(script, r.reStart, delim)
+			if r.reEnd < len(script) {
+				replacementEnd := delimited(script, r.reEnd+1, delim)
+				r.replacement = script[r.reEnd+1 : replacementEnd]
+				r.end = replacementEnd
+				if replacementEnd < len(script) {
+					flagsEnd := replacementEnd + 1
+					for flagsEnd < len(script) && !strings.ContainsRune(" \t\n;}", rune(script[flagsEnd])) {
+						flagsEnd++
+					}
+					r.flags = script[replacementEnd+1 : flagsEnd]
+					r.end = flagsEnd
+				}
+			} else {
+				r.end = r.reEnd
+			}
+			regions = append(regions, r)
+			i = r.end
+		}
+	}
+	return regions
+}
+
+// awkRegions returns the regular expression literals of an awk program:
+// the text between slashes where a value starts, outside of strings.
+func awkRegions(program string) []region {
+	var regions []region
+	valueStarts := true
+	for i := 0; i < len(program); i++ {
+		c := program[i]
+		switch {
+		case c == '"':
+			i = delimited(program, i+1, '"')
+			valueStarts = false
+		case c == '/' && valueStarts:
+			end := delimited(program, i+1, '/')
+			regions = append(regions, region{start: i, end: end, reStart: i + 1, reEnd: end})
+			i = end
+			valueStarts = false
+		case c == ' ' || c == '\t':
+		default:
+			valueStarts = strings.ContainsRune("({[,;~!&|=\n", rune(c))
+		}
+	}
+	return regions
+}
+
+// Replace returns line with the expression of p replaced with regexp, the
+// argument it is in quoted again.
+func Replace(line string, p Pattern, regexp string) string {
+	text := p.word.Text[:p.start] + regexp + p.word.Text[p.end:]
+	return line[:p.word.Start] + progpreview.Quote(text) + line[p.word.End:]
+}
@@ -0,0 +1,91 @@
+package regextest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// at returns the offset of the first mark in line, and line without it.
+func at(line string) (string, int) {
+	cursor := strings.Index(line, "‸")
+	return strings.Replace(line, "‸", "", 1), cursor
+}
+
+func TestFindGrep(t *testing.T) {
+	line, cursor := at(`grep -iE 'err(or)?‸:' app.log other.log`)
+	p, ok := Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "grep", p.Tool)
+	assert.Equal(t, Extended, p.Dialect)
+	assert.True(t, p.IgnoreCase)
+	assert.Equal(t, "err(or)?:", p.Regexp)
+	assert.Equal(t, "cat -- app.log other.log", p.Sample)
+
+	line, cursor = at(`journalctl -u web | grep -A 2 -e 'time‸out'`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, Basic, p.Dialect)
+	assert.Equal(t, "timeout", p.Regexp)
+	assert.Equal(t, "journalctl -u web", p.Sample)
+
+	// Directories are not sampled, nor commands that may change things
+	line, cursor = at(`grep -rn 'TODO‸' src`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "", p.Sample)
+	line, cursor = at(`make | grep 'warn‸'`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "", p.Sample)
+
+	// The cursor on a file name
+	line, cursor = at(`grep 'warn' app‸.log`)
+	_, ok = Find(line, cursor)
+	assert.False(t, ok)
+}
+
+func TestFindScript(t *testing.T) {
+	line, cursor := at(`sed -E 's/([0-9]+)‸ms/\1 ms/g' timings.txt`)
+	p, ok := Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "sed", p.Tool)
+	assert.Equal(t, Extended, p.Dialect)
+	assert.Equal(t, "([0-9]+)ms", p.Regexp)
+	assert.True(t, p.Substitute)
+	assert.Equal(t, `\1 ms`, p.Replacement)
+	assert.True(t, p.Global)
+	assert.Equal(t, "cat -- timings.txt", p.Sample)
+
+	line, cursor = at(`sed -n '/^#/d; /start‸/,/end/p' notes`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "start", p.Regexp)
+	assert.False(t, p.Substitute)
+
+	line, cursor = at(`ps aux | awk '$3 > 1 && /pyth‸on/ {print $2}'`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, "awk", p.Tool)
+	assert.Equal(t, "python", p.Regexp)
+	assert.Equal(t, "ps aux", p.Sample)
+
+	// A division is not an expression
+	line, cursor = at(`awk '{print $1 / 2‸}' data`)
+	_, ok = Find(line, cursor)
+	assert.False(t, ok)
+}
+
+func TestReplace(t *testing.T) {
+	line, cursor := at(`sed 's/a‸(b)/x/' f`)
+	p, ok := Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, `sed 's/a\(b\)/x/' f`, Replace(line, p, `a\(b\)`))
+
+	line, cursor = at(`grep "it‸s" f`)
+	p, ok = Find(line, cursor)
+	require.True(t, ok)
+	assert.Equal(t, `grep 'it'\''s' f`, Replace(line, p, "it's"))
+}
@@ -0,0 +1,167 @@
+package regextest
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// Compile returns the expression of p as a Go regular expression, with the
+// options of the tool applied. Perl syntax the Go engine lacks, such as
+// lookarounds and backreferences, is an error.
+func (p Pattern) Compile() (*regexp.Regexp, error) {
+	expr := p.Regexp
+	switch p.Dialect {
+	case Basic:
+		expr = fromBasic(expr)
+	case Extended:
+		expr = fromExtended(expr)
+	case Fixed:
+		expr = regexp.QuoteMeta(expr)
+	}
+	if p.WholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if p.WholeLine {
+		expr = `^(?:` + expr + `)$`
+	}
+	if p.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		// The message of the Go engine names the part it could not parse
+		if syntaxErr, ok := err.(*syntax.Error); ok {
+			return nil, fmt.Errorf("%s: %s", syntaxErr.Code, syntaxErr.Expr)
+		}
+		return nil, err
+	}
+	return re, nil
+}
+
+// fromBasic rewrites POSIX basic syntax in Go syntax: \( \) \{ \} \| \+ \?
+// are operators, and ( ) { } | + ? are literal.
+func fromBasic(expr string) string {
+	var sb strings.Builder
+	inBracket := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case inBracket:
+			sb.WriteByte(c)
+			if c == ']' {
+				inBracket = false
+			}
+		case c == '[':
+			sb.WriteByte(c)
+			inBracket = true
+			// A ] right after [ or [^ is part of the set
+			if i+1 < len(expr) && expr[i+1] == '^' {
+				sb.WriteByte('^')
+				i++
+			}
+			if i+1 < len(expr) && expr[i+1] == ']' {
+				sb.WriteByte(']')
+				i++
+			}
+		case c == '\\' && i+1 < len(expr):
+			i++
+			switch next := expr[i]; next {
+			case '(', ')', '{', '}', '|', '+', '?':
+				sb.WriteByte(next)
+			case '<', '>':
+				sb.WriteString(`\b`)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(next)
+			}
+		case strings.IndexByte("(){}|+?", c) >= 0:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// fromExtended rewrites the GNU word boundaries \< and \> of extended
+// syntax, which is otherwise Go syntax.
+func fromExtended(expr string) string {
+	var sb strings.Builder
+	for i := 0; i < len(expr); i++ {
+		if expr[i] == '\\' && i+1 < len(expr) {
+			if expr[i+1] == '<' || expr[i+1] == '>' {
+				sb.WriteString(`\b`)
+			} else {
+				sb.WriteString(expr[i : i+2])
+			}
+			i++
+			continue
+		}
+		sb.WriteByte(expr[i])
+	}
+	return sb.String()
+}
+
+// Match is a line of the sample with what the expression matches in it.
+type Match struct {
+	Line string
+	// Spans are the byte offsets of the matches in the line.
+	Spans [][]int
+	// Replaced is the line after the s command of sed, if it is one.
+	Replaced string
+}
+
+// MatchLines matches re against each of lines, as the tool does.
+func (p Pattern) MatchLines(re *regexp.Regexp, lines []string) []Match {
+	replacement := sedReplacement(p.Replacement)
+	matches := make([]Match, len(lines))
+	for i, line := range lines {
+		m := Match{Line: line, Spans: re.FindAllStringIndex(line, -1)}
+		if p.Substitute {
+			m.Replaced = line
+			if len(m.Spans) > 0 {
+				if p.Global {
+					m.Replaced = re.ReplaceAllString(line, replacement)
+				} else {
+					span := re.FindStringSubmatchIndex(line)
+					m.Replaced = line[:span[0]] + string(re.ExpandString(nil, replacement, line, span)) + line[span[1]:]
+				}
+			}
+		}
+		matches[i] = m
+	}
+	return matches
+}
+
+// sedReplacement rewrites the replacement of an s command of sed as a Go
+// template: & and \1 to \9 are the match and its groups.
+func sedReplacement(replacement string) string {
+	var sb strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		switch {
+		case c == '&':
+			sb.WriteString("${0}")
+		case c == '$':
+			sb.WriteString("$$")
+		case c == '\\' && i+1 < len(replacement):
+			i++
+			switch next := replacement[i]; {
+			case next >= '0' && next <= '9':
+				sb.WriteString("${" + string(next) + "}")
+			case next == 'n':
+				sb.WriteByte('\n')
+			case next == 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(next)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+package regextest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	for _, test := range []struct {
+		pattern Pattern
+		line    string
+		match   string
+	}{
+		// In basic syntax ( and + are literal, and \( and \+ operators
+		{Pattern{Regexp: "f(x)+"}, "f(x)+ fxx", "f(x)+"},
+		{Pattern{Regexp: `f\(x\)\+`}, "f(x)+ fxx", "fxx"},
+		{Pattern{Regexp: `a\{2\}`}, "a aa", "aa"},
+		{Pattern{Regexp: `[]a]`}, "x]", "]"},
+		{Pattern{Regexp: `\<in\>`, Dialect: Extended}, "print in", "in"},
+		{Pattern{Regexp: "a.b", Dialect: Fixed}, "axb a.b", "a.b"},
+		{Pattern{Regexp: "error", IgnoreCase: true}, "ERROR", "ERROR"},
+		{Pattern{Regexp: "in", WholeWord: true}, "print in", "in"},
+	} {
+		re, err := test.pattern.Compile()
+		require.NoError(t, err, test.pattern.Regexp)
+		assert.Equal(t, test.match, re.FindString(test.line), test.pattern.Regexp)
+	}
+
+	_, err := Pattern{Regexp: `foo(?=bar)`, Dialect: Perl}.Compile()
+	assert.Error(t, err)
+}
+
+func TestMatchLines(t *testing.T) {
+	p := Pattern{Regexp: `([0-9]+)ms`, Dialect: Extended, Substitute: true, Replacement: `\1 ms (&)`}
+	re, err := p.Compile()
+	require.NoError(t, err)
+
+	matches := p.MatchLines(re, []string{"took 12ms and 3ms", "no timing"})
+	assert.Equal(t, [][]int{{5, 9}, {14, 17}}, matches[0].Spans)
+	assert.Equal(t, "took 12 ms (12ms) and 3ms", matches[0].Replaced)
+	assert.Empty(t, matches[1].Spans)
+	assert.Equal(t, "no timing", matches[1].Replaced)
+
+	p.Global = true
+	matches = p.MatchLines(re, []string{"took 12ms and 3ms"})
+	assert.Equal(t, "took 12 ms (12ms) and 3 ms (3ms)", matches[0].Replaced)
+}
@@ -0,0 +1,237 @@
+package regextest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/pipebuild"
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	cursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	countStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	lineStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	missStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	matchStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(lipgloss.Color("214"))
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+const (
+	defaultHeight = 24
+	defaultWidth  = 100
+)
+
+var dialectNames = map[Dialect]string{
+	Basic:    "basic syntax",
+	Extended: "extended syntax",
+	Perl:     "Perl syntax",
+	Fixed:    "fixed string",
+}
+
+// sampledMsg carries the sample lines back.
+type sampledMsg struct {
+	output string
+	err    error
+}
+
+// model lets the user edit a regular expression and shows what it matches
+// in the sample lines as it changes.
+type model struct {
+	pattern Pattern
+	input   textinput.Model
+	run     pipebuild.RunFunc
+
+	sample    []string
+	sampling  bool
+	sampleErr error
+	// pasted is set once the sample is text the user pasted
+	pasted bool
+
+	height int
+	width  int
+
+	accepted bool
+}
+
+func newModel(pattern Pattern, run pipebuild.RunFunc) model {
+	input := textinput.New()
+	input.Prompt = ""
+	input.SetValue(pattern.Regexp)
+	input.Focus()
+	return model{pattern: pattern, input: input, run: run, sampling: pattern.Sample != "",
+		height: defaultHeight, width: defaultWidth}
+}
+
+func (m model) Init() tea.Cmd {
+	if !m.sampling {
+		return textinput.Blink
+	}
+	run, source := m.run, m.pattern.Sample
+	return tea.Batch(textinput.Blink, func() tea.Msg {
+		output, _, err := run(context.Background(), source, "", pipebuild.SampleLines)
+		return sampledMsg{output: output, err: err}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		m.input.Width = max(10, msg.Width-6)
+		return m, nil
+
+	case sampledMsg:
+		m.sampling = false
+		// Lines pasted meanwhile are kept
+		if !m.pasted {
+			m.sample, m.sampleErr = splitLines(msg.output), msg.err
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Paste {
+			// Pasted text is the sample, rather than part of the expression
+			m.sample, m.sampleErr, m.pasted = splitLines(string(msg.Runes)), nil, true
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			m.accepted = true
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	return lines[:min(len(lines), pipebuild.SampleLines)]
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+	title := fmt.Sprintf("Regex tester: %s, %s", m.pattern.Tool, dialectNames[m.pattern.Dialect])
+	sb.WriteString(titleStyle.Render(title) + "\n\n")
+	sb.WriteString(cursorStyle.Render("> ") + m.input.View() + "\n")
+
+	pattern := m.pattern
+	pattern.Regexp = m.input.Value()
+	re, err := pattern.Compile()
+
+	var status string
+	var matches []Match
+	switch {
+	case m.sampling:
+		status = "reading sample lines from " + m.pattern.Sample + "…"
+	case m.sampleErr != nil && len(m.sample) == 0:
+		status = errorStyle.Render(m.sampleErr.Error())
+	case len(m.sample) == 0:
+		status = "no sample lines, paste some"
+	case err != nil:
+		status = errorStyle.Render(err.Error())
+	default:
+		matches = pattern.MatchLines(re, m.sample)
+		matching := 0
+		for _, match := range matches {
+			if len(match.Spans) > 0 {
+				matching++
+			}
+		}
+		status = fmt.Sprintf("%d of %d lines match", matching, len(m.sample))
+	}
+	sb.WriteString("\n" + dividerStyle.Render("── ") + titleStyle.Render(status) + dividerStyle.Render(" ──") + "\n")
+
+	// Leave room for the title, expression, divider and help
+	room := max(3, m.height-7)
+	width := max(10, m.width-1)
+	shown := 0
+	for i, match := range matches {
+		if shown >= room {
+			sb.WriteString(countStyle.Render(fmt.Sprintf("… %d more lines", len(matches)-i)) + "\n")
+			break
+		}
+		shown++
+		if len(match.Spans) == 0 {
+			sb.WriteString(missStyle.Render(truncate(match.Line, width)) + "\n")
+			continue
+		}
+		sb.WriteString(highlight(match.Line, match.Spans, width) + "\n")
+		if pattern.Substitute && shown < room {
+			shown++
+			sb.WriteString(countStyle.Render("→ ") + lineStyle.Render(truncate(match.Replaced, width-2)) + "\n")
+		}
+	}
+
+	sb.WriteString("\n" + helpStyle.Render("enter use expression • paste to test other lines • esc cancel"))
+	return sb.String()
+}
+
+// highlight renders line with the spans, byte offsets, highlighted, cut
+// to width runes.
+func highlight(line string, spans [][]int, width int) string {
+	cut, ellipsis := len(line), ""
+	if runes := []rune(line); len(runes) > width {
+		cut, ellipsis = len(string(runes[:width-1])), "…"
+	}
+	var sb strings.Builder
+	last := 0
+	for _, span := range spans {
+		start, end := min(span[0], cut), min(span[1], cut)
+		if start < last {
+			continue
+		}
+		sb.WriteString(lineStyle.Render(line[last:start]))
+		if end > start {
+			sb.WriteString(matchStyle.Render(line[start:end]))
+		}
+		last = end
+	}
+	sb.WriteString(lineStyle.Render(line[last:cut] + ellipsis))
+	return sb.String()
+}
+
+// truncate cuts line to width runes.
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// Run opens the tester on the regular expression at cursor in line,
+// sampling lines with run, and returns line with the expression edited if
+// the user asked to use it.
+func Run(line string, cursor int, run pipebuild.RunFunc) (string, bool, error) {
+	pattern, ok := Find(line, cursor)
+	if !ok {
+		return "", false, errors.New("the cursor is not in a regular expression of grep, sed or awk")
+	}
+	result, err := tea.NewProgram(newModel(pattern, run), tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", false, err
+	}
+	m := result.(model)
+	if !m.accepted {
+		return "", false, nil
+	}
+	return Replace(line, pattern, m.input.Value()), true, nil
+}
@@ -0,0 +1,38 @@
+package regextest
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeRun(ctx context.Context, command, input string, maxLines int) (string, bool, error) {
+	return "GET /index.html 200\nPOST /login 302\nGET /about 404\n", false, nil
+}
+
+func TestModel(t *testing.T) {
+	pattern, ok := Find("cat access.log | grep ' [0-9]{3}$'", 25)
+	require.True(t, ok)
+	m := newModel(pattern, fakeRun)
+	assert.Contains(t, m.View(), "reading sample lines from cat access.log")
+
+	result, _ := m.Update(m.Init()().(tea.BatchMsg)[1]())
+	m = result.(model)
+	// In basic syntax the braces are literal
+	assert.Contains(t, m.View(), "0 of 3 lines match")
+
+	m.input.SetValue(` [0-9]\{3\}$`)
+	assert.Contains(t, m.View(), "3 of 3 lines match")
+
+	// Pasted text replaces the sample
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ok 200\nnothing\n"), Paste: true})
+	m = result.(model)
+	assert.Equal(t, ` [0-9]\{3\}$`, m.input.Value())
+	assert.Contains(t, m.View(), "1 of 2 lines match")
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, result.(model).accepted)
+}
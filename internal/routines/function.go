@@ -0,0 +1,168 @@
+package routines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Proposal is how the function for a routine is named.
+type Proposal struct {
+	Name        string   `json:"name" description:"A short, memorable shell function name in kebab-case or snake_case, e.g. ship-branch" required:"true"`
+	Description string   `json:"description" description:"One line saying what the function does, for a comment above it" required:"true"`
+	Params      []string `json:"params" description:"A snake_case name for each placeholder, in order: the first names $1, the second $2 and so on" required:"true"`
+}
+
+var (
+	proposalSchema = utils.GenerateJsonSchema(Proposal{})
+	functionName   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]{0,39}$`)
+	paramName      = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,29}$`)
+)
+
+// DefaultProposal names the function for r after the commands it runs, for
+// when no name was proposed.
+func DefaultProposal(r Routine) Proposal {
+	var parts []string
+	for _, step := range r.Steps {
+		name := strings.Fields(step)[0]
+		if (len(parts) == 0 || parts[len(parts)-1] != name) && functionName.MatchString(name) {
+			parts = append(parts, name)
+		}
+	}
+	p := Proposal{
+		Name:        strings.Join(parts, "-") + "-routine",
+		Description: "Runs " + strings.Join(parts, ", then "),
+	}
+	for i := range r.Values {
+		p.Params = append(p.Params, fmt.Sprintf("arg%d", i+1))
+	}
+	return p
+}
+
+// Sanitize replaces what is unusable in p, such as a name that is not a
+// valid function name or the wrong number of parameters, with the defaults
+// for r. taken reports whether a name is already a command.
+func (p Proposal) Sanitize(r Routine, taken func(string) bool) Proposal {
+	defaults := DefaultProposal(r)
+	p.Params = slices.Clone(p.Params)
+	if !functionName.MatchString(p.Name) || taken(p.Name) {
+		p.Name = defaults.Name
+	}
+	p.Description = strings.Join(strings.Fields(p.Description), " ")
+	if p.Description == "" {
+		p.Description = defaults.Description
+	}
+	if len(p.Params) != len(r.Values) {
+		p.Params = defaults.Params
+	}
+	used := map[string]bool{}
+	for i, name := range p.Params {
+		if !paramName.MatchString(name) || used[name] {
+			p.Params[i] = defaults.Params[i]
+		}
+		used[p.Params[i]] = true
+	}
+	return p
+}
+
+// Function returns the shell function for r named by p, chaining the steps
+// with && so that it stops at the first failure, followed by a completion of
+// its first parameter with the values it took before.
+func Function(r Routine, p Proposal) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s (a sequence you typed %d times)\n", p.Description, r.Count)
+	if len(p.Params) > 0 {
+		usage := make([]string, len(p.Params))
+		for i, name := range p.Params {
+			usage[i] = "<" + name + ">"
+		}
+		fmt.Fprintf(&sb, "# Usage: %s %s\n", p.Name, strings.Join(usage, " "))
+	}
+	fmt.Fprintf(&sb, "%s() {\n", p.Name)
+	if len(p.Params) > 0 {
+		locals := make([]string, len(p.Params))
+		for i, name := range p.Params {
+			locals[i] = fmt.Sprintf(`%s="$%d"`, name, i+1)
+		}
+		fmt.Fprintf(&sb, "  local %s\n", strings.Join(locals, " "))
+	}
+	steps := make([]string, len(r.Steps))
+	for i, step := range r.Steps {
+		for j, name := range p.Params {
+			step = strings.ReplaceAll(step, fmt.Sprintf(`"$%d"`, j+1), `"$`+name+`"`)
+		}
+		steps[i] = step
+	}
+	sb.WriteString("  " + strings.Join(steps, " &&\n    ") + "\n}\n")
+
+	if len(r.Values) > 0 {
+		var values []string
+		for _, value := range r.Values[0] {
+			if completable.MatchString(value) {
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			fmt.Fprintf(&sb, "complete -W %q %s\n", strings.Join(values, " "), p.Name)
+		}
+	}
+	return sb.String()
+}
+
+// Propose asks the fast model to name the function for r and its
+// parameters. The result still needs Sanitize.
+func Propose(ctx context.Context, runner *interp.Runner, r Routine, logger *zap.Logger) (Proposal, error) {
+	client, modelConfig := utils.GetLLMClient(runner, utils.FastModel)
+
+	schema, err := proposalSchema.MarshalJSON()
+	if err != nil {
+		return Proposal{}, err
+	}
+	var sb strings.Builder
+	sb.WriteString("I keep typing these commands one after the other:\n\n")
+	for _, step := range r.Steps {
+		sb.WriteString(step + "\n")
+	}
+	if len(r.Values) > 0 {
+		sb.WriteString("\nThe placeholders took these values:\n")
+		for i, values := range r.Values {
+			fmt.Fprintf(&sb, "$%d: %s\n", i+1, strings.Join(values, ", "))
+		}
+	}
+	fmt.Fprintf(&sb, "\nName a shell function that runs them, and its parameters. Respond in JSON following this schema:\n%s", string(schema))
+
+	request := openai.ChatCompletionRequest{
+		Model: modelConfig.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if modelConfig.Temperature != nil {
+		request.Temperature = float32(*modelConfig.Temperature)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return Proposal{}, fmt.Errorf("failed to name the function: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return Proposal{}, fmt.Errorf("no response from LLM")
+	}
+	var p Proposal
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &p); err != nil {
+		return Proposal{}, fmt.Errorf("failed to parse the function name: %w", err)
+	}
+	logger.Debug("proposed routine function", zap.String("name", p.Name), zap.Strings("params", p.Params))
+	return p, nil
+}
@@ -0,0 +1,234 @@
+// Package routines detects sequences of commands a user keeps typing by hand,
+// such as creating a branch, pushing it and opening a pull request, and turns
+// them into shell functions with the parts that vary as parameters.
+package routines
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/history"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// SampleSize is how many recent history entries are searched.
+	SampleSize = 3000
+
+	// MinRepeats is how many times a sequence must have been typed before it
+	// is offered as a function.
+	MinRepeats = 4
+
+	// Sequences are minLength to maxLength commands long, and longer ones are
+	// preferred.
+	minLength = 3
+	maxLength = 4
+
+	// maxParams is how many parts may vary; beyond that the runs are too
+	// different to be one function.
+	maxParams = 3
+)
+
+// Routine is a sequence of commands typed together repeatedly.
+type Routine struct {
+	// Steps are the commands in order, with "$1", "$2", ... standing for
+	// the words that differed between runs
+	Steps []string
+	// Values are the words each parameter stood for, most recent first,
+	// without duplicates
+	Values [][]string
+	// Count is how many times the sequence was typed
+	Count int
+}
+
+// Key identifies the routine, so that it is offered only once.
+func (r Routine) Key() string {
+	return strings.Join(r.Steps, "\n")
+}
+
+// Latest returns the routine that the session just finished typing again,
+// if its last commands have been typed together at least MinRepeats times.
+// entries are ordered most recent first.
+func Latest(entries []history.HistoryEntry, sessionID string) (Routine, bool) {
+	if len(entries) > SampleSize {
+		entries = entries[:SampleSize]
+	}
+	sessions := map[string][]string{}
+	// Sessions by their last command, most recent first
+	var order []string
+	for _, entry := range entries {
+		if _, ok := sessions[entry.SessionID]; !ok && entry.SessionID != "" {
+			sessions[entry.SessionID] = nil
+			order = append(order, entry.SessionID)
+		}
+	}
+	// Chronological order, so that runs read as they were typed
+	for i := len(entries) - 1; i >= 0; i-- {
+		if id := entries[i].SessionID; id != "" {
+			sessions[id] = append(sessions[id], strings.TrimSpace(entries[i].Command))
+		}
+	}
+	current := sessions[sessionID]
+
+	for length := maxLength; length >= minLength; length-- {
+		if len(current) < length {
+			continue
+		}
+		tail := current[len(current)-length:]
+		if !eligible(tail) {
+			continue
+		}
+		shapes := shapesOf(tail)
+		// The session's own runs come first, being the most recent
+		runs := find(current, shapes)
+		for _, id := range order {
+			if id != sessionID {
+				runs = append(runs, find(sessions[id], shapes)...)
+			}
+		}
+		if len(runs) < MinRepeats {
+			continue
+		}
+		if r, ok := parameterize(runs); ok {
+			return r, true
+		}
+	}
+	return Routine{}, false
+}
+
+// eligible reports whether commands can form a routine: single-line shell
+// commands, not all the same.
+func eligible(commands []string) bool {
+	distinct := map[string]bool{}
+	for _, command := range commands {
+		if command == "" || strings.HasPrefix(command, "#") || strings.Contains(command, "\n") {
+			return false
+		}
+		distinct[command] = true
+	}
+	return len(distinct) > 1
+}
+
+// find returns the runs of consecutive commands matching shapes, without
+// overlaps, as the words of each command. The last run comes first.
+func find(commands []string, shapes []string) [][][]string {
+	var runs [][][]string
+	for end := len(commands); end >= len(shapes); {
+		window := commands[end-len(shapes) : end]
+		if !eligible(window) || !slices.Equal(shapesOf(window), shapes) {
+			end--
+			continue
+		}
+		run := make([][]string, len(window))
+		for i, command := range window {
+			run[i] = words(command)
+		}
+		runs = append(runs, run)
+		end -= len(shapes)
+	}
+	return runs
+}
+
+func shapesOf(commands []string) []string {
+	shapes := make([]string, len(commands))
+	for i, command := range commands {
+		shapes[i] = shape(command)
+	}
+	return shapes
+}
+
+// shape is what commands must share to be runs of the same step: the
+// command name, its subcommand if any, and the number of words. Commands
+// that are not simple must be identical.
+func shape(command string) string {
+	w := words(command)
+	if len(w) == 1 && w[0] == command && strings.ContainsAny(command, " \t") {
+		return command
+	}
+	stem := w[0]
+	if len(w) > 1 && plainWord.MatchString(w[1]) {
+		stem += " " + w[1]
+	}
+	return fmt.Sprintf("%s/%d", stem, len(w))
+}
+
+var (
+	plainWord = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_-]*$`)
+	// completable are values that can be offered by complete -W as they are
+	completable = regexp.MustCompile(`^[a-zA-Z0-9._/:@=+,-]+$`)
+)
+
+// words splits a simple command into its words as typed. Anything else,
+// such as a pipeline, is a single word.
+func words(command string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return []string{command}
+	}
+	stmt := file.Stmts[0]
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Assigns) > 0 || len(call.Args) == 0 || len(stmt.Redirs) > 0 || stmt.Background || stmt.Negated {
+		return []string{command}
+	}
+	result := make([]string, len(call.Args))
+	for i, word := range call.Args {
+		result[i] = command[word.Pos().Offset():word.End().Offset()]
+	}
+	return result
+}
+
+// parameterize turns runs of the same steps, most recent first, into a
+// routine whose parameters are the words that differed. Words that always
+// differed together, like a branch name given to two commands, share a
+// parameter.
+func parameterize(runs [][][]string) (Routine, bool) {
+	r := Routine{Count: len(runs)}
+	var seen []string // the values of each parameter in every run, joined
+	for step := range runs[0] {
+		template := make([]string, len(runs[0][step]))
+		for i := range template {
+			values := make([]string, len(runs))
+			same := true
+			for run := range runs {
+				values[run] = runs[run][step][i]
+				same = same && values[run] == values[0]
+			}
+			if same {
+				template[i] = values[0]
+				continue
+			}
+			joined := strings.Join(values, "\x00")
+			param := -1
+			for p, s := range seen {
+				if s == joined {
+					param = p
+				}
+			}
+			if param < 0 {
+				if len(seen) == maxParams {
+					return Routine{}, false
+				}
+				seen = append(seen, joined)
+				r.Values = append(r.Values, distinct(values))
+				param = len(seen) - 1
+			}
+			template[i] = fmt.Sprintf(`"$%d"`, param+1)
+		}
+		r.Steps = append(r.Steps, strings.Join(template, " "))
+	}
+	return r, true
+}
+
+func distinct(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
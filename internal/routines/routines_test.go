@@ -0,0 +1,124 @@
+package routines
+
+import (
+	"testing"
+
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessions returns entries, most recent first, for sessions that ran the
+// given commands in order. Later sessions are more recent.
+func sessions(all ...[]string) []history.HistoryEntry {
+	var entries []history.HistoryEntry
+	for s, commands := range all {
+		for _, command := range commands {
+			entry := history.HistoryEntry{Command: command, SessionID: string(rune('a' + s))}
+			entries = append([]history.HistoryEntry{entry}, entries...)
+		}
+	}
+	return entries
+}
+
+func ship(branch string) []string {
+	return []string{"git checkout -b " + branch, "git push -u origin " + branch, "gh pr create --fill"}
+}
+
+func TestLatest(t *testing.T) {
+	entries := sessions(
+		append([]string{"ls"}, ship("fix-a")...),
+		append(ship("fix-b"), "make test"),
+		append(ship("fix-c"), ship("fix-d")...),
+	)
+	r, ok := Latest(entries, "c")
+	require.True(t, ok)
+	assert.Equal(t, []string{
+		`git checkout -b "$1"`,
+		`git push -u origin "$1"`,
+		"gh pr create --fill",
+	}, r.Steps)
+	assert.Equal(t, [][]string{{"fix-d", "fix-c", "fix-b", "fix-a"}}, r.Values)
+	assert.Equal(t, 4, r.Count)
+
+	// Other sessions have not just typed it
+	_, ok = Latest(entries, "b")
+	assert.False(t, ok)
+}
+
+func TestLatestNeedsEnoughRepeats(t *testing.T) {
+	entries := sessions(ship("fix-a"), ship("fix-b"), ship("fix-c"))
+	_, ok := Latest(entries, "c")
+	assert.False(t, ok)
+}
+
+func TestLatestPrefersLongerRoutines(t *testing.T) {
+	var commands []string
+	for range MinRepeats {
+		commands = append(commands, "cd ~/api", "git pull", "make", "make test")
+	}
+	r, ok := Latest(sessions(commands), "a")
+	require.True(t, ok)
+	assert.Equal(t, []string{"cd ~/api", "git pull", "make", "make test"}, r.Steps)
+	assert.Empty(t, r.Values)
+}
+
+func TestLatestSkipsRepeatsOfOneCommand(t *testing.T) {
+	var commands []string
+	for range 12 {
+		commands = append(commands, "ls")
+	}
+	_, ok := Latest(sessions(commands), "a")
+	assert.False(t, ok)
+}
+
+func TestParameterizeGivesUpOnTooManyDifferences(t *testing.T) {
+	runs := [][][]string{
+		{{"cp", "a", "b"}, {"scp", "c", "d"}},
+		{{"cp", "e", "f"}, {"scp", "g", "h"}},
+	}
+	_, ok := parameterize(runs)
+	assert.False(t, ok)
+}
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"git", "commit", "-m", `"fix bug"`}, words(`git commit -m "fix bug"`))
+	assert.Equal(t, []string{"ls | wc -l"}, words("ls | wc -l"))
+	assert.Equal(t, []string{"FOO=1 make"}, words("FOO=1 make"))
+}
+
+func TestFunction(t *testing.T) {
+	r := Routine{
+		Steps:  []string{`git checkout -b "$1"`, `git push -u origin "$1"`, "gh pr create --fill"},
+		Values: [][]string{{"fix-b", "fix-a", "has space"}},
+		Count:  5,
+	}
+	p := Proposal{Name: "ship", Description: "Push a new branch and open a PR", Params: []string{"branch"}}
+	assert.Equal(t, `# Push a new branch and open a PR (a sequence you typed 5 times)
+# Usage: ship <branch>
+ship() {
+  local branch="$1"
+  git checkout -b "$branch" &&
+    git push -u origin "$branch" &&
+    gh pr create --fill
+}
+complete -W "fix-b fix-a" ship
+`, Function(r, p))
+}
+
+func TestSanitize(t *testing.T) {
+	r := Routine{Steps: []string{`git checkout -b "$1"`, `git push -u origin "$1"`, "gh pr create"}, Values: [][]string{{"x"}}}
+	taken := func(name string) bool { return name == "ls" }
+
+	p := Proposal{Name: "ship", Description: "  Ship \n it ", Params: []string{"branch"}}.Sanitize(r, taken)
+	assert.Equal(t, Proposal{Name: "ship", Description: "Ship it", Params: []string{"branch"}}, p)
+
+	p = Proposal{Name: "ls", Params: []string{"Bad-Name", "extra"}}.Sanitize(r, taken)
+	assert.Equal(t, "git-gh-routine", p.Name)
+	assert.Equal(t, "Runs git, then gh", p.Description)
+	assert.Equal(t, []string{"arg1"}, p.Params)
+
+	p = Proposal{Name: "rm -rf /", Params: []string{"Bad-Name"}}.Sanitize(r, taken)
+	assert.Equal(t, "git-gh-routine", p.Name)
+	assert.Equal(t, []string{"arg1"}, p.Params)
+}
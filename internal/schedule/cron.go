@@ -0,0 +1,255 @@
+// Package schedule turns a description of a recurring job into a validated
+// crontab entry or systemd timer unit, previews it and installs it.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes the valid range and names of one crontab field.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Cron is a parsed five-field crontab schedule.
+type Cron struct {
+	// Expr is the schedule as written, e.g. "0 2 * * *" or "@daily".
+	Expr string
+
+	// values[i] holds the allowed values of field i; any[i] is set when the
+	// field was "*" and so does not restrict the schedule.
+	values [5]map[int]bool
+	any    [5]bool
+}
+
+// ParseCron parses and validates a five-field crontab schedule or one of the
+// @hourly, @daily, @weekly, @monthly and @yearly macros.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	expanded := expr
+	if strings.HasPrefix(expr, "@") {
+		macro, ok := cronMacros[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported schedule %s", expr)
+		}
+		expanded = macro
+	}
+
+	parts := strings.Fields(expanded)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(parts))
+	}
+
+	c := &Cron{Expr: expr}
+	for i, part := range parts {
+		values, any, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cronFields[i].name, err)
+		}
+		c.values[i], c.any[i] = values, any
+	}
+	// Sunday may be written as 0 or 7
+	if c.values[4][7] {
+		c.values[4][0] = true
+		delete(c.values[4], 7)
+	}
+	return c, nil
+}
+
+func parseCronField(part string, field cronField) (map[int]bool, bool, error) {
+	values := map[int]bool{}
+	any := part == "*"
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return nil, false, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = field.min, field.max
+			if field.max == 7 {
+				end = 6
+			}
+		case strings.Contains(rangePart, "-"):
+			low, high, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(low, field); err != nil {
+				return nil, false, err
+			}
+			if end, err = parseCronValue(high, field); err != nil {
+				return nil, false, err
+			}
+			if start > end {
+				return nil, false, fmt.Errorf("range %s is backwards", rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, field)
+			if err != nil {
+				return nil, false, err
+			}
+			start, end = value, value
+			if hasStep {
+				end = field.max
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, any, nil
+}
+
+func parseCronValue(s string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if value < field.min || value > field.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", value, field.min, field.max)
+	}
+	return value, nil
+}
+
+// Next returns the first time after t that matches the schedule, or the zero
+// time if there is none within the next five years (e.g. "0 0 30 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.values[3][int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.values[1][t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.values[0][t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay applies cron's day rule: when both day of month and day of week
+// are restricted, a day matching either one is enough.
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.values[2][t.Day()]
+	dow := c.values[4][int(t.Weekday())]
+	switch {
+	case c.any[2] && c.any[4]:
+		return true
+	case c.any[2]:
+		return dow
+	case c.any[4]:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// OnCalendar converts the schedule to a systemd OnCalendar expression such as
+// "*-*-* 02:00:00". Schedules that restrict both the day of month and the day
+// of week cannot be converted, since systemd requires both to match.
+func (c *Cron) OnCalendar() (string, error) {
+	if !c.any[2] && !c.any[4] {
+		return "", errors.New("schedules that restrict both day of month and day of week cannot be expressed as a systemd timer")
+	}
+
+	var sb strings.Builder
+	if !c.any[4] {
+		sb.WriteString(weekdays(c.values[4]) + " ")
+	}
+	fmt.Fprintf(&sb, "*-%s-%s %s:%s:00",
+		calendarField(c.values[3], c.any[3], cronFields[3]),
+		calendarField(c.values[2], c.any[2], cronFields[2]),
+		calendarField(c.values[1], c.any[1], cronFields[1]),
+		calendarField(c.values[0], c.any[0], cronFields[0]))
+	return sb.String(), nil
+}
+
+// calendarField renders the values of a field as "*" or a comma separated
+// list of two-digit numbers.
+func calendarField(values map[int]bool, any bool, field cronField) string {
+	if any {
+		return "*"
+	}
+	var list []string
+	for value := field.min; value <= field.max; value++ {
+		if values[value] {
+			list = append(list, fmt.Sprintf("%02d", value))
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// weekdays renders days of the week as systemd weekday names, collapsing
+// consecutive days into ranges such as "Mon..Fri".
+func weekdays(values map[int]bool) string {
+	var parts []string
+	for day := 0; day < 7; day++ {
+		if !values[day] {
+			continue
+		}
+		end := day
+		for end+1 < 7 && values[end+1] {
+			end++
+		}
+		switch {
+		case end-day >= 2:
+			parts = append(parts, weekdayNames[day]+".."+weekdayNames[end])
+		case end > day:
+			parts = append(parts, weekdayNames[day], weekdayNames[end])
+		default:
+			parts = append(parts, weekdayNames[day])
+		}
+		day = end
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		message string
+	}{
+		{"0 2 * *", "expected 5 fields (minute hour day-of-month month day-of-week), got 4"},
+		{"60 * * * *", "minute: 60 is out of range 0-59"},
+		{"0 24 * * *", "hour: 24 is out of range 0-23"},
+		{"0 0 0 * *", "day of month: 0 is out of range 1-31"},
+		{"0 0 * foo *", `month: invalid value "foo"`},
+		{"*/0 * * * *", `minute: invalid step "0"`},
+		{"0 5-2 * * *", "hour: range 5-2 is backwards"},
+		{"@reboot", "unsupported schedule @reboot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	start := time.Date(2026, time.March, 10, 14, 30, 0, 0, time.UTC) // a Tuesday
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 2 * * *", time.Date(2026, time.March, 11, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 10, 14, 45, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2026, time.March, 11, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month OR day of week: the 13th or the next Friday
+		{"0 12 13 * fri", time.Date(2026, time.March, 13, 12, 0, 0, 0, time.UTC)},
+		{"0 12 20 * sat", time.Date(2026, time.March, 14, 12, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cron.Next(start))
+		})
+	}
+}
+
+func TestCronOnCalendar(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"0 2 * * *", "*-*-* 02:00:00"},
+		{"*/15 * * * *", "*-*-* *:00,15,30,45:00"},
+		{"30 9 * * 1-5", "Mon..Fri *-*-* 09:30:00"},
+		{"0 8 * * sat,sun", "Sun,Sat *-*-* 08:00:00"},
+		{"0 0 1,15 * *", "*-*-01,15 00:00:00"},
+		{"@yearly", "*-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			calendar, err := cron.OnCalendar()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, calendar)
+		})
+	}
+
+	cron, err := ParseCron("0 0 1 * mon")
+	require.NoError(t, err)
+	_, err = cron.OnCalendar()
+	assert.Error(t, err)
+}
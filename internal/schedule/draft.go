@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var specSchema = utils.GenerateJsonSchema(Spec{})
+
+// Draft asks the LLM to turn a request such as "run this backup every night at
+// 2am" into a spec. The result is only a starting point for the form and is
+// not validated.
+func Draft(ctx context.Context, client *openai.Client, config utils.LLMModelConfig, request string) (Spec, error) {
+	schema, err := specSchema.MarshalJSON()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	systemMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+You will be given a description of a job I want to run on a schedule, enclosed in <request> tags.
+
+# Instructions
+* Turn it into a job specification
+* The schedule must be a standard five-field crontab expression, never a macro such as @daily
+* Use systemd as the target only if I mention systemd or timers, otherwise use cron
+* Keep the command exactly as I wrote it if I included one
+
+# Response JSON Schema
+%s`, string(schema))
+
+	request = strings.TrimSpace(request)
+	completionRequest := openai.ChatCompletionRequest{
+		Model: config.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: fmt.Sprintf("<request>%s</request>", request)},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if config.Temperature != nil {
+		completionRequest.Temperature = float32(*config.Temperature)
+	}
+
+	completion, err := client.CreateChatCompletion(ctx, completionRequest)
+	if err != nil {
+		return Spec{}, err
+	}
+	if len(completion.Choices) == 0 {
+		return Spec{}, errors.New("empty response from LLM")
+	}
+	return parseDraft(completion.Choices[0].Message.Content, request)
+}
+
+// parseDraft decodes the LLM response, filling in defaults for anything it
+// left out.
+func parseDraft(content string, request string) (Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal([]byte(content), &spec); err != nil {
+		return Spec{}, fmt.Errorf("invalid response from LLM: %w", err)
+	}
+	spec.Target = Target(strings.ToLower(string(spec.Target)))
+	if spec.Target != TargetSystemd {
+		spec.Target = TargetCron
+	}
+	if spec.Description == "" {
+		spec.Description = request
+	}
+	spec.Name = strings.ReplaceAll(strings.TrimSpace(spec.Name), " ", "-")
+	return spec, nil
+}
@@ -0,0 +1,236 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	labelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true).Width(13)
+	focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true).Width(13)
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hintStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	previewStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+)
+
+// Form fields, in display order.
+const (
+	fieldName = iota
+	fieldCommand
+	fieldSchedule
+	fieldTarget
+	fieldDescription
+	fieldCount
+)
+
+var fieldLabels = [fieldCount]string{"Name", "Command", "Schedule", "Target", "Description"}
+
+// nextRunCount is how many upcoming runs are shown below the schedule.
+const nextRunCount = 3
+
+// formModel lets the user review and correct a drafted spec, then previews
+// the generated files and asks for confirmation.
+type formModel struct {
+	inputs     [fieldCount]textinput.Model
+	target     Target
+	focus      int
+	previewing bool
+	unitDir    string
+	now        func() time.Time
+
+	err       string
+	confirmed bool
+	cancelled bool
+}
+
+func newFormModel(spec Spec, unitDir string, now func() time.Time) formModel {
+	m := formModel{target: spec.Target, unitDir: unitDir, now: now}
+	if m.target != TargetSystemd {
+		m.target = TargetCron
+	}
+	values := [fieldCount]string{spec.Name, spec.Command, spec.Schedule, "", spec.Description}
+	for i := range m.inputs {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+		ti.SetValue(values[i])
+		m.inputs[i] = ti
+	}
+	m.inputs[fieldSchedule].Placeholder = "minute hour day-of-month month day-of-week"
+	m.inputs[fieldName].Focus()
+	return m
+}
+
+// spec returns the spec as currently entered.
+func (m formModel) spec() Spec {
+	return Spec{
+		Name:        strings.TrimSpace(m.inputs[fieldName].Value()),
+		Command:     strings.TrimSpace(m.inputs[fieldCommand].Value()),
+		Schedule:    strings.TrimSpace(m.inputs[fieldSchedule].Value()),
+		Target:      m.target,
+		Description: strings.TrimSpace(m.inputs[fieldDescription].Value()),
+	}
+}
+
+func (m formModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+		return m, cmd
+	}
+
+	if m.previewing {
+		switch keyMsg.String() {
+		case "y", "Y":
+			m.confirmed = true
+			return m, tea.Quit
+		case "ctrl+c":
+			m.cancelled = true
+			return m, tea.Quit
+		case "n", "N", "esc", "e":
+			m.previewing = false
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "esc":
+		m.cancelled = true
+		return m, tea.Quit
+	case "tab", "down":
+		return m.setFocus((m.focus + 1) % fieldCount), nil
+	case "shift+tab", "up":
+		return m.setFocus((m.focus + fieldCount - 1) % fieldCount), nil
+	case "enter":
+		if err := m.spec().Validate(); err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.err = ""
+		m.previewing = true
+		return m, nil
+	}
+
+	if m.focus == fieldTarget {
+		switch keyMsg.String() {
+		case "left", "right", " ", "h", "l":
+			if m.target == TargetCron {
+				m.target = TargetSystemd
+			} else {
+				m.target = TargetCron
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m formModel) setFocus(focus int) formModel {
+	m.inputs[m.focus].Blur()
+	m.focus = focus
+	m.inputs[m.focus].Focus()
+	return m
+}
+
+func (m formModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Schedule a job") + "\n\n")
+
+	if m.previewing {
+		preview, err := m.spec().Preview(m.unitDir)
+		if err != nil {
+			sb.WriteString(errorStyle.Render(err.Error()) + "\n")
+		} else {
+			sb.WriteString(previewStyle.Render(strings.TrimRight(preview, "\n")) + "\n\n")
+		}
+		sb.WriteString(helpStyle.Render("Install? y: install • n/e: edit • ctrl+c: cancel") + "\n")
+		return sb.String()
+	}
+
+	for i := 0; i < fieldCount; i++ {
+		label := labelStyle.Render(fieldLabels[i])
+		if i == m.focus {
+			label = focusedStyle.Render(fieldLabels[i])
+		}
+		value := m.inputs[i].View()
+		if i == fieldTarget {
+			value = m.targetView()
+		}
+		sb.WriteString(label + " " + value + "\n")
+		if i == fieldSchedule {
+			sb.WriteString(strings.Repeat(" ", 14) + m.scheduleHint() + "\n")
+		}
+	}
+
+	sb.WriteString("\n")
+	if m.err != "" {
+		sb.WriteString(errorStyle.Render(m.err) + "\n\n")
+	}
+	sb.WriteString(helpStyle.Render("tab/↑↓: move • ←/→: change target • enter: preview • esc: cancel") + "\n")
+	return sb.String()
+}
+
+func (m formModel) targetView() string {
+	options := []Target{TargetCron, TargetSystemd}
+	parts := make([]string, len(options))
+	for i, option := range options {
+		if option == m.target {
+			parts[i] = focusedStyle.UnsetWidth().Render("(•) " + string(option))
+		} else {
+			parts[i] = helpStyle.Render("( ) " + string(option))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// scheduleHint shows the next runs of a valid schedule, or why it is invalid.
+func (m formModel) scheduleHint() string {
+	cron, err := ParseCron(m.inputs[fieldSchedule].Value())
+	if err != nil {
+		return errorStyle.Render(err.Error())
+	}
+
+	var runs []string
+	next := m.now()
+	for i := 0; i < nextRunCount; i++ {
+		next = cron.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next.Format("Mon Jan 2 15:04"))
+	}
+	if len(runs) == 0 {
+		return errorStyle.Render("this schedule never runs")
+	}
+	return hintStyle.Render(fmt.Sprintf("next: %s", strings.Join(runs, ", ")))
+}
+
+// RunForm shows the form for spec and returns the confirmed spec, or false
+// if the user cancelled.
+func RunForm(spec Spec, unitDir string) (Spec, bool, error) {
+	program := tea.NewProgram(newFormModel(spec, unitDir, time.Now))
+	result, err := program.Run()
+	if err != nil {
+		return Spec{}, false, err
+	}
+	m := result.(formModel)
+	if !m.confirmed {
+		return Spec{}, false, nil
+	}
+	return m.spec(), true, nil
+}
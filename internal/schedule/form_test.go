@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func formNow() time.Time {
+	return time.Date(2026, time.March, 10, 14, 30, 0, 0, time.UTC)
+}
+
+func sendKeys(m formModel, keys ...tea.KeyMsg) formModel {
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(formModel)
+	}
+	return m
+}
+
+func TestFormShowsNextRuns(t *testing.T) {
+	m := newFormModel(backupSpec(TargetCron), "/units", formNow)
+	view := m.View()
+	assert.Contains(t, view, "nightly-backup")
+	assert.Contains(t, view, "next: Wed Mar 11 02:00, Thu Mar 12 02:00, Fri Mar 13 02:00")
+}
+
+func TestFormPreviewAndConfirm(t *testing.T) {
+	m := newFormModel(backupSpec(TargetCron), "/units", formNow)
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.previewing)
+	assert.Contains(t, m.View(), "Append to your crontab")
+
+	// Back to editing, switch to systemd and preview again
+	m = sendKeys(m,
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")},
+		tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab},
+		tea.KeyMsg{Type: tea.KeyRight},
+		tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, TargetSystemd, m.spec().Target)
+	assert.Contains(t, m.View(), "/units/nightly-backup.timer")
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	assert.True(t, m.confirmed)
+	assert.Equal(t, TargetSystemd, m.spec().Target)
+}
+
+func TestFormRejectsInvalidSpec(t *testing.T) {
+	spec := backupSpec(TargetCron)
+	spec.Schedule = "0 25 * * *"
+	m := newFormModel(spec, "/units", formNow)
+	assert.Contains(t, m.View(), "hour: 25 is out of range 0-23")
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.previewing)
+	assert.Equal(t, "schedule: hour: 25 is out of range 0-23", m.err)
+
+	// Fix the hour by editing the schedule field
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab})
+	m.inputs[fieldSchedule].SetValue("0 3 * * *")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.previewing)
+}
+
+func TestFormCancel(t *testing.T) {
+	m := newFormModel(backupSpec(TargetCron), "/units", formNow)
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.True(t, m.cancelled)
+	assert.False(t, m.confirmed)
+}
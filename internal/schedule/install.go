@@ -0,0 +1,88 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runCommand runs an external command with the given stdin and returns its
+// combined output. Tests override it.
+var runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// Install installs a validated spec and returns a short description of what
+// was done. Existing crontab entries and unit files with the same name are
+// never overwritten.
+func Install(ctx context.Context, spec Spec, unitDir string) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	if spec.Target == TargetCron {
+		return installCron(ctx, spec)
+	}
+	return installSystemd(ctx, spec, unitDir)
+}
+
+func installCron(ctx context.Context, spec Spec) (string, error) {
+	existing, err := runCommand(ctx, "", "crontab", "-l")
+	if err != nil {
+		// crontab -l fails when the user has no crontab yet
+		if !strings.Contains(strings.ToLower(existing), "no crontab") {
+			return "", fmt.Errorf("reading crontab: %s", strings.TrimSpace(existing))
+		}
+		existing = ""
+	}
+	for _, line := range strings.Split(existing, "\n") {
+		if line == strings.TrimSpace(entryMarker+spec.Name) || strings.HasPrefix(line, entryMarker+spec.Name+" ") {
+			return "", fmt.Errorf("a scheduled job named %s is already in your crontab", spec.Name)
+		}
+	}
+
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	if output, err := runCommand(ctx, existing+spec.CrontabEntry(), "crontab", "-"); err != nil {
+		return "", fmt.Errorf("installing crontab: %s", strings.TrimSpace(output))
+	}
+	return fmt.Sprintf("Added %s to your crontab", spec.Name), nil
+}
+
+func installSystemd(ctx context.Context, spec Spec, unitDir string) (string, error) {
+	files, err := spec.UnitFiles(unitDir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file.Path); err == nil {
+			return "", fmt.Errorf("%s already exists", file.Path)
+		}
+	}
+
+	if err := os.MkdirAll(unitDir, 0o755); err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file.Path, []byte(file.Content), 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	timer := spec.Name + ".timer"
+	for _, args := range [][]string{{"--user", "daemon-reload"}, {"--user", "enable", "--now", timer}} {
+		if output, err := runCommand(ctx, "", "systemctl", args...); err != nil {
+			return "", fmt.Errorf("systemctl %s: %s", strings.Join(args, " "), strings.TrimSpace(output))
+		}
+	}
+	return fmt.Sprintf("Installed and started %s", timer), nil
+}
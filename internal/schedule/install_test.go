@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCommand struct {
+	args  string
+	stdin string
+}
+
+// fakeCommands replaces runCommand, answering each command with the output
+// and error registered for it.
+func fakeCommands(t *testing.T, responses map[string]error, outputs map[string]string) *[]fakeCommand {
+	var calls []fakeCommand
+	original := runCommand
+	runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, fakeCommand{args: command, stdin: stdin})
+		return outputs[command], responses[command]
+	}
+	t.Cleanup(func() { runCommand = original })
+	return &calls
+}
+
+func TestInstallCron(t *testing.T) {
+	calls := fakeCommands(t, nil, map[string]string{"crontab -l": "MAILTO=me\n0 * * * * other"})
+
+	message, err := Install(context.Background(), backupSpec(TargetCron), t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "Added nightly-backup to your crontab", message)
+
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "crontab -", (*calls)[1].args)
+	assert.Equal(t, "MAILTO=me\n0 * * * * other\n"+backupSpec(TargetCron).CrontabEntry(), (*calls)[1].stdin)
+}
+
+func TestInstallCronWithoutCrontab(t *testing.T) {
+	calls := fakeCommands(t,
+		map[string]error{"crontab -l": errors.New("exit status 1")},
+		map[string]string{"crontab -l": "no crontab for me\n"})
+
+	_, err := Install(context.Background(), backupSpec(TargetCron), t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, backupSpec(TargetCron).CrontabEntry(), (*calls)[1].stdin)
+}
+
+func TestInstallCronRefusesDuplicates(t *testing.T) {
+	existing := backupSpec(TargetCron).CrontabEntry()
+	calls := fakeCommands(t, nil, map[string]string{"crontab -l": existing})
+
+	_, err := Install(context.Background(), backupSpec(TargetCron), t.TempDir())
+	require.Error(t, err)
+	assert.Equal(t, "a scheduled job named nightly-backup is already in your crontab", err.Error())
+	assert.Len(t, *calls, 1)
+}
+
+func TestInstallSystemd(t *testing.T) {
+	calls := fakeCommands(t, nil, nil)
+	unitDir := filepath.Join(t.TempDir(), "systemd", "user")
+
+	message, err := Install(context.Background(), backupSpec(TargetSystemd), unitDir)
+	require.NoError(t, err)
+	assert.Equal(t, "Installed and started nightly-backup.timer", message)
+
+	service, err := os.ReadFile(filepath.Join(unitDir, "nightly-backup.service"))
+	require.NoError(t, err)
+	assert.Equal(t, backupSpec(TargetSystemd).ServiceUnit(), string(service))
+	_, err = os.Stat(filepath.Join(unitDir, "nightly-backup.timer"))
+	require.NoError(t, err)
+
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "systemctl --user daemon-reload", (*calls)[0].args)
+	assert.Equal(t, "systemctl --user enable --now nightly-backup.timer", (*calls)[1].args)
+
+	// Existing units are never overwritten
+	_, err = Install(context.Background(), backupSpec(TargetSystemd), unitDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	assert.Len(t, *calls, 2)
+}
+
+func TestInstallRejectsInvalidSpec(t *testing.T) {
+	calls := fakeCommands(t, nil, nil)
+	spec := backupSpec(TargetCron)
+	spec.Schedule = "nightly"
+
+	_, err := Install(context.Background(), spec, t.TempDir())
+	assert.Error(t, err)
+	assert.Empty(t, *calls)
+}
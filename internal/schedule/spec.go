@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Target selects how a job is installed.
+type Target string
+
+const (
+	// TargetCron appends an entry to the user's crontab.
+	TargetCron Target = "cron"
+	// TargetSystemd writes a user service and timer unit.
+	TargetSystemd Target = "systemd"
+)
+
+// entryMarker prefixes the comment written above crontab entries so they can
+// be recognized later.
+const entryMarker = "# bish schedule: "
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Spec describes a recurring job.
+type Spec struct {
+	Name        string `json:"name" description:"Short identifier for the job using letters, digits, dashes and dots, e.g. nightly-backup" required:"true"`
+	Description string `json:"description" description:"One sentence describing what the job does" required:"true"`
+	Command     string `json:"command" description:"The shell command to run, on a single line" required:"true"`
+	Schedule    string `json:"schedule" description:"Five-field crontab schedule (minute hour day-of-month month day-of-week), e.g. 0 2 * * * for every night at 2am" required:"true"`
+	Target      Target `json:"target" description:"Either cron or systemd" required:"true"`
+}
+
+// Validate checks that the spec can be installed on its target.
+func (s Spec) Validate() error {
+	if !namePattern.MatchString(s.Name) {
+		return errors.New("name must start with a letter or digit and contain only letters, digits, '_', '-' and '.'")
+	}
+	if strings.TrimSpace(s.Command) == "" {
+		return errors.New("command is empty")
+	}
+	if strings.ContainsAny(s.Command, "\r\n") || strings.ContainsAny(s.Description, "\r\n") {
+		return errors.New("command and description must be a single line")
+	}
+	cron, err := ParseCron(s.Schedule)
+	if err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	if cron.Next(time.Now()).IsZero() {
+		return errors.New("schedule: never runs")
+	}
+	switch s.Target {
+	case TargetCron:
+		// cron treats an unescaped % in the command as a newline
+		if strings.Contains(strings.ReplaceAll(s.Command, `\%`, ""), "%") {
+			return errors.New(`command contains %, which cron turns into a newline; escape it as \%`)
+		}
+	case TargetSystemd:
+		if _, err := cron.OnCalendar(); err != nil {
+			return fmt.Errorf("schedule: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown target %q, expected cron or systemd", s.Target)
+	}
+	return nil
+}
+
+// CrontabEntry returns the lines appended to the crontab for the spec.
+func (s Spec) CrontabEntry() string {
+	comment := entryMarker + s.Name
+	if s.Description != "" {
+		comment += " - " + s.Description
+	}
+	return comment + "\n" + s.Schedule + " " + s.Command + "\n"
+}
+
+// ServiceUnit returns the systemd service unit that runs the command.
+func (s Spec) ServiceUnit() string {
+	return fmt.Sprintf(`[Unit]
+Description=%s
+
+[Service]
+Type=oneshot
+ExecStart=/bin/sh -c %s
+`, s.unitDescription(), systemdQuote(s.Command))
+}
+
+// TimerUnit returns the systemd timer unit that triggers the service.
+func (s Spec) TimerUnit() (string, error) {
+	cron, err := ParseCron(s.Schedule)
+	if err != nil {
+		return "", err
+	}
+	calendar, err := cron.OnCalendar()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`[Unit]
+Description=Timer for %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, s.unitDescription(), calendar), nil
+}
+
+func (s Spec) unitDescription() string {
+	if s.Description != "" {
+		return s.Description
+	}
+	return s.Name
+}
+
+// systemdQuote quotes s as a single ExecStart argument. systemd expands "$"
+// and "%" itself, so both are escaped to reach the shell unchanged.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "%", "%%")
+	s = strings.ReplaceAll(s, "$", "$$")
+	return `"` + s + `"`
+}
+
+// File is a file written when a spec is installed.
+type File struct {
+	Path    string
+	Content string
+}
+
+// UnitFiles returns the systemd units for the spec, placed in unitDir.
+func (s Spec) UnitFiles(unitDir string) ([]File, error) {
+	timer, err := s.TimerUnit()
+	if err != nil {
+		return nil, err
+	}
+	return []File{
+		{Path: filepath.Join(unitDir, s.Name+".service"), Content: s.ServiceUnit()},
+		{Path: filepath.Join(unitDir, s.Name+".timer"), Content: timer},
+	}, nil
+}
+
+// Preview describes what installing the spec will change.
+func (s Spec) Preview(unitDir string) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if s.Target == TargetCron {
+		sb.WriteString("Append to your crontab:\n\n")
+		sb.WriteString(s.CrontabEntry())
+		return sb.String(), nil
+	}
+
+	files, err := s.UnitFiles(unitDir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		fmt.Fprintf(&sb, "%s:\n\n%s\n", file.Path, file.Content)
+	}
+	fmt.Fprintf(&sb, "Then run:\n\nsystemctl --user daemon-reload\nsystemctl --user enable --now %s.timer\n", s.Name)
+	return sb.String(), nil
+}
+
+// DefaultUnitDir returns the directory for systemd user units,
+// $XDG_CONFIG_HOME/systemd/user or ~/.config/systemd/user.
+func DefaultUnitDir() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "systemd", "user")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".config", "systemd", "user")
+}
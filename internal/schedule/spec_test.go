@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func backupSpec(target Target) Spec {
+	return Spec{
+		Name:        "nightly-backup",
+		Description: "Back up the home directory",
+		Command:     `restic backup "$HOME"`,
+		Schedule:    "0 2 * * *",
+		Target:      target,
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	assert.NoError(t, backupSpec(TargetCron).Validate())
+	assert.NoError(t, backupSpec(TargetSystemd).Validate())
+
+	tests := []struct {
+		name    string
+		modify  func(*Spec)
+		message string
+	}{
+		{"bad name", func(s *Spec) { s.Name = "my backup" }, "name must start with a letter or digit and contain only letters, digits, '_', '-' and '.'"},
+		{"empty command", func(s *Spec) { s.Command = " " }, "command is empty"},
+		{"multi-line command", func(s *Spec) { s.Command = "a\nb" }, "command and description must be a single line"},
+		{"bad schedule", func(s *Spec) { s.Schedule = "every night" }, "schedule: expected 5 fields (minute hour day-of-month month day-of-week), got 2"},
+		{"never runs", func(s *Spec) { s.Schedule = "0 0 31 4 *" }, "schedule: never runs"},
+		{"unescaped percent", func(s *Spec) { s.Command = "date +%F" }, `command contains %, which cron turns into a newline; escape it as \%`},
+		{"unknown target", func(s *Spec) { s.Target = "launchd" }, `unknown target "launchd", expected cron or systemd`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := backupSpec(TargetCron)
+			tt.modify(&spec)
+			err := spec.Validate()
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+
+	escaped := backupSpec(TargetCron)
+	escaped.Command = `date +\%F`
+	assert.NoError(t, escaped.Validate())
+
+	systemd := backupSpec(TargetSystemd)
+	systemd.Schedule = "0 0 1 * mon"
+	assert.Error(t, systemd.Validate())
+}
+
+func TestSpecPreviewCron(t *testing.T) {
+	preview, err := backupSpec(TargetCron).Preview("/units")
+	require.NoError(t, err)
+	assert.Equal(t, "Append to your crontab:\n\n"+
+		"# bish schedule: nightly-backup - Back up the home directory\n"+
+		"0 2 * * * restic backup \"$HOME\"\n", preview)
+}
+
+func TestSpecUnitFiles(t *testing.T) {
+	spec := backupSpec(TargetSystemd)
+	spec.Command = `echo "100%" $USER`
+
+	files, err := spec.UnitFiles("/units")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, filepath.Join("/units", "nightly-backup.service"), files[0].Path)
+	assert.Equal(t, `[Unit]
+Description=Back up the home directory
+
+[Service]
+Type=oneshot
+ExecStart=/bin/sh -c "echo \"100%%\" $$USER"
+`, files[0].Content)
+
+	assert.Equal(t, filepath.Join("/units", "nightly-backup.timer"), files[1].Path)
+	assert.Equal(t, `[Unit]
+Description=Timer for Back up the home directory
+
+[Timer]
+OnCalendar=*-*-* 02:00:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, files[1].Content)
+
+	preview, err := spec.Preview("/units")
+	require.NoError(t, err)
+	assert.Contains(t, preview, "/units/nightly-backup.timer:")
+	assert.Contains(t, preview, "systemctl --user enable --now nightly-backup.timer")
+}
+
+func TestDefaultUnitDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	assert.Equal(t, filepath.Join("/config", "systemd", "user"), DefaultUnitDir())
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	assert.Equal(t, filepath.Join("/home/me", ".config", "systemd", "user"), DefaultUnitDir())
+}
+
+func TestParseDraft(t *testing.T) {
+	spec, err := parseDraft(`{"name":"nightly backup","command":"backup.sh","schedule":"0 2 * * *","target":"Systemd"}`, "run backup.sh nightly")
+	require.NoError(t, err)
+	assert.Equal(t, Spec{
+		Name:        "nightly-backup",
+		Description: "run backup.sh nightly",
+		Command:     "backup.sh",
+		Schedule:    "0 2 * * *",
+		Target:      TargetSystemd,
+	}, spec)
+
+	spec, err = parseDraft(`{"name":"x","target":"launchd"}`, "")
+	require.NoError(t, err)
+	assert.Equal(t, TargetCron, spec.Target)
+
+	_, err = parseDraft(`not json`, "")
+	assert.Error(t, err)
+}
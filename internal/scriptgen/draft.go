@@ -0,0 +1,111 @@
+package scriptgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var scriptSchema = utils.GenerateJsonSchema(Script{})
+
+// Draft asks the LLM to write a script for a task such as "archive the logs
+// older than a week into a tarball". When shellcheck finds issues in it,
+// the LLM is asked once to fix them; what shellcheck finds in the script
+// returned is for the preview to show.
+func Draft(ctx context.Context, client *openai.Client, config utils.LLMModelConfig, request string) (Script, LintState, error) {
+	schema, err := scriptSchema.MarshalJSON()
+	if err != nil {
+		return Script{}, LintState{}, err
+	}
+
+	systemMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+You will be given a task I want a standalone shell script for, enclosed in <request> tags.
+
+# Instructions
+* Write a complete bash script starting with #!/usr/bin/env bash and set -euo pipefail
+* Parse its arguments with getopts or a while/case loop, and print a usage message for -h and for invalid arguments
+* Check its inputs and dependencies, and print errors to stderr with a non-zero exit status
+* Quote every expansion, and make the script pass shellcheck without warnings
+* Write a small bats test that runs the script, at least for its usage message and one invalid argument, finding the script next to the test with "$BATS_TEST_DIRNAME"
+* Do not explain anything outside of the JSON
+
+# Response JSON Schema
+%s`, string(schema))
+
+	request = strings.TrimSpace(request)
+	messages := []openai.ChatCompletionMessage{
+		{Role: "system", Content: systemMessage},
+		{Role: "user", Content: fmt.Sprintf("<request>%s</request>", request)},
+	}
+
+	var script Script
+	var lint LintState
+	for attempt := 0; attempt < 2; attempt++ {
+		content, err := complete(ctx, client, config, messages)
+		if err != nil {
+			return Script{}, LintState{}, err
+		}
+		if script, err = parseDraft(content, request); err != nil {
+			return Script{}, LintState{}, err
+		}
+		if lint.Findings, lint.Checked, err = Lint(ctx, script.Script); err != nil {
+			return script, LintState{}, nil
+		}
+		if lint.Findings == "" {
+			return script, lint, nil
+		}
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: "assistant", Content: content},
+			openai.ChatCompletionMessage{Role: "user", Content: fmt.Sprintf(
+				"shellcheck reports these issues in the script; fix them and reply with the whole JSON again:\n%s", lint.Findings)},
+		)
+	}
+	return script, lint, nil
+}
+
+func complete(ctx context.Context, client *openai.Client, config utils.LLMModelConfig, messages []openai.ChatCompletionMessage) (string, error) {
+	completionRequest := openai.ChatCompletionRequest{
+		Model:    config.ModelId,
+		Messages: messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if config.Temperature != nil {
+		completionRequest.Temperature = float32(*config.Temperature)
+	}
+
+	completion, err := client.CreateChatCompletion(ctx, completionRequest)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("empty response from LLM")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// parseDraft decodes the LLM response, filling in defaults for anything it
+// left out.
+func parseDraft(content string, request string) (Script, error) {
+	var script Script
+	if err := json.Unmarshal([]byte(content), &script); err != nil {
+		return Script{}, fmt.Errorf("invalid response from LLM: %w", err)
+	}
+	if script.Description == "" {
+		script.Description = request
+	}
+	script.Name = strings.ReplaceAll(strings.TrimSpace(script.Name), " ", "-")
+	if script.Name != "" && !strings.Contains(script.Name, ".") {
+		script.Name += ".sh"
+	}
+	if err := script.Validate(); err != nil {
+		return Script{}, fmt.Errorf("the LLM wrote an invalid script: %w", err)
+	}
+	return script, nil
+}
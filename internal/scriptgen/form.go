@@ -0,0 +1,191 @@
+package scriptgen
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	labelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
+	tabStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	activeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hintStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	lineNoStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	previewStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+)
+
+const (
+	defaultHeight = 30
+	// maxFindings is how many lines of shellcheck findings are shown
+	maxFindings = 4
+)
+
+// LintState is what shellcheck found in a script.
+type LintState struct {
+	// Checked is false when shellcheck is not installed.
+	Checked bool
+	// Findings are what shellcheck still finds, one per line.
+	Findings string
+}
+
+// formModel previews the script and its test, and asks where to write them.
+type formModel struct {
+	script Script
+	lint   LintState
+	path   textinput.Model
+	// showTest shows the test instead of the script
+	showTest bool
+	offset   int
+	height   int
+
+	err       string
+	confirmed bool
+}
+
+func newFormModel(script Script, lint LintState, dir string) formModel {
+	path := textinput.New()
+	path.Prompt = ""
+	path.SetValue(script.DefaultPath(dir))
+	path.Focus()
+	return formModel{script: script, lint: lint, path: path, height: defaultHeight}
+}
+
+func (m formModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// lines returns the lines of the file shown.
+func (m formModel) lines() []string {
+	text := m.script.Script
+	if m.showTest {
+		text = m.script.Test
+	}
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// room is how many lines of the file fit, below the title, path, tabs and
+// lint status and above the help.
+func (m formModel) room() int {
+	return max(5, m.height-12)
+}
+
+func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.path.Width = max(10, msg.Width-8)
+		return m, nil
+	case tea.KeyMsg:
+		lastOffset := max(0, len(m.lines())-m.room())
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "tab", "shift+tab":
+			m.showTest = !m.showTest
+			m.offset = 0
+			return m, nil
+		case "down", "ctrl+n":
+			m.offset = min(m.offset+1, lastOffset)
+			return m, nil
+		case "up", "ctrl+p":
+			m.offset = max(m.offset-1, 0)
+			return m, nil
+		case "pgdown":
+			m.offset = min(m.offset+m.room(), lastOffset)
+			return m, nil
+		case "pgup":
+			m.offset = max(m.offset-m.room(), 0)
+			return m, nil
+		case "enter":
+			path := strings.TrimSpace(m.path.Value())
+			if path == "" {
+				m.err = "enter the path to write the script to"
+				return m, nil
+			}
+			for _, file := range m.script.Files(path) {
+				if _, err := os.Stat(file.Path); err == nil {
+					m.err = file.Path + " already exists"
+					return m, nil
+				}
+			}
+			m.confirmed = true
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.path, cmd = m.path.Update(msg)
+	m.err = ""
+	return m, cmd
+}
+
+func (m formModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("New script: "+m.script.Description) + "\n\n")
+	sb.WriteString(labelStyle.Render("Write to ") + m.path.View() + "\n")
+	sb.WriteString(labelStyle.Render("Test     ") + tabStyle.Render(TestPath(strings.TrimSpace(m.path.Value()))) + "\n\n")
+
+	scriptTab, testTab := activeStyle.Render("[script]"), tabStyle.Render(" test ")
+	if m.showTest {
+		scriptTab, testTab = tabStyle.Render(" script "), activeStyle.Render("[test]")
+	}
+	sb.WriteString(scriptTab + " " + testTab + "  " + m.lintView() + "\n")
+
+	lines := m.lines()
+	end := min(len(lines), m.offset+m.room())
+	var shown []string
+	for i := m.offset; i < end; i++ {
+		shown = append(shown, lineNoStyle.Render(fmt.Sprintf("%3d ", i+1))+lines[i])
+	}
+	if end < len(lines) {
+		shown = append(shown, helpStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-end)))
+	}
+	sb.WriteString(previewStyle.Render(strings.Join(shown, "\n")) + "\n")
+
+	if m.err != "" {
+		sb.WriteString(errorStyle.Render(m.err) + "\n")
+	}
+	sb.WriteString(helpStyle.Render("enter: write and make executable • tab: script/test • ↑↓ pgup/pgdn: scroll • esc: cancel"))
+	return sb.String()
+}
+
+func (m formModel) lintView() string {
+	switch {
+	case !m.lint.Checked:
+		return helpStyle.Render("shellcheck is not installed; the script was only parsed")
+	case m.lint.Findings == "":
+		return hintStyle.Render("shellcheck: no issues")
+	}
+	findings := strings.Split(m.lint.Findings, "\n")
+	count := fmt.Sprintf("%d issues", len(findings))
+	if len(findings) == 1 {
+		count = "1 issue"
+	}
+	view := errorStyle.Render("shellcheck: " + count)
+	for _, finding := range findings[:min(len(findings), maxFindings)] {
+		view += "\n  " + errorStyle.Render(finding)
+	}
+	return view
+}
+
+// RunForm previews script and returns the path the user chose to write it
+// to, or false if they cancelled. The path is in dir by default.
+func RunForm(script Script, lint LintState, dir string) (string, bool, error) {
+	result, err := tea.NewProgram(newFormModel(script, lint, dir), tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", false, err
+	}
+	m := result.(formModel)
+	if !m.confirmed {
+		return "", false, nil
+	}
+	return strings.TrimSpace(m.path.Value()), true, nil
+}
@@ -0,0 +1,55 @@
+package scriptgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendKeys(m formModel, keys ...tea.KeyMsg) formModel {
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(formModel)
+	}
+	return m
+}
+
+func TestFormPreviewsScriptAndTest(t *testing.T) {
+	m := newFormModel(cleanupScript(), LintState{Checked: true}, "/work")
+	view := m.View()
+	assert.Contains(t, view, "/work/cleanup.sh")
+	assert.Contains(t, view, "/work/cleanup.bats")
+	assert.Contains(t, view, "set -euo pipefail")
+	assert.Contains(t, view, "shellcheck: no issues")
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyTab})
+	assert.Contains(t, m.View(), `@test "usage"`)
+	assert.NotContains(t, m.View(), "set -euo pipefail")
+
+	m = newFormModel(cleanupScript(), LintState{Checked: true, Findings: "line 3:1: warning: x [SC2034]"}, "/work")
+	assert.Contains(t, m.View(), "shellcheck: 1 issue")
+	m = newFormModel(cleanupScript(), LintState{}, "/work")
+	assert.Contains(t, m.View(), "shellcheck is not installed")
+}
+
+func TestFormConfirmsNewPath(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cleanup.sh"), nil, 0o644))
+
+	m := newFormModel(cleanupScript(), LintState{}, dir)
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.confirmed)
+	assert.Contains(t, m.View(), "already exists")
+
+	// Another name is written
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace},
+		tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2.sh")})
+	assert.NotContains(t, m.View(), "already exists")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.confirmed)
+	assert.Equal(t, filepath.Join(dir, "cleanup2.sh"), m.path.Value())
+}
@@ -0,0 +1,133 @@
+// Package scriptgen turns a described task into a standalone shell script,
+// with argument parsing, error handling and a small bats test, which is
+// checked with shellcheck and previewed before it is written.
+package scriptgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Script is a generated script and its test.
+type Script struct {
+	Name        string `json:"name" description:"File name for the script, in lowercase with dashes and the .sh extension, e.g. rotate-logs.sh" required:"true"`
+	Description string `json:"description" description:"One sentence describing what the script does" required:"true"`
+	Script      string `json:"script" description:"The complete bash script, starting with #!/usr/bin/env bash" required:"true"`
+	Test        string `json:"test" description:"A bats test file for the script, starting with #!/usr/bin/env bats" required:"true"`
+}
+
+// Validate checks that the script and its test are shell that parses.
+func (s Script) Validate() error {
+	if !strings.HasPrefix(s.Script, "#!") {
+		return errors.New("the script has no #! line")
+	}
+	if _, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(s.Script), s.Name); err != nil {
+		return fmt.Errorf("the script does not parse: %w", err)
+	}
+	if strings.TrimSpace(s.Test) == "" {
+		return errors.New("the script has no test")
+	}
+	return nil
+}
+
+// File is a file to write.
+type File struct {
+	Path    string
+	Content string
+	Mode    os.FileMode
+}
+
+// TestPath returns where the test of the script at path is written: next to
+// it, with the .bats extension.
+func TestPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".bats"
+}
+
+// Files returns the script, executable, at path and its test.
+func (s Script) Files(path string) []File {
+	return []File{
+		{Path: path, Content: withNewline(s.Script), Mode: 0o755},
+		{Path: TestPath(path), Content: withNewline(s.Test), Mode: 0o644},
+	}
+}
+
+func withNewline(text string) string {
+	return strings.TrimRight(text, "\n") + "\n"
+}
+
+// DefaultPath returns where the script is written unless the user picks
+// another path: in dir, under its name.
+func (s Script) DefaultPath(dir string) string {
+	name := s.Name
+	if !namePattern.MatchString(name) {
+		name = "script.sh"
+	}
+	return filepath.Join(dir, name)
+}
+
+// Write writes the files of the script at path, which must not exist yet,
+// and returns a short description of what was written.
+func Write(s Script, path string) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	files := s.Files(path)
+	for _, file := range files {
+		if _, err := os.Stat(file.Path); err == nil {
+			return "", fmt.Errorf("%s already exists", file.Path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file.Path, []byte(file.Content), file.Mode); err != nil {
+			return "", err
+		}
+		// The umask may have dropped the executable bits
+		if err := os.Chmod(file.Path, file.Mode); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Wrote %s and its test %s; run the test with bats %s", path, files[1].Path, files[1].Path), nil
+}
+
+// lookPath and runCommand run shellcheck. Tests override them.
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = strings.NewReader(stdin)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		return out.String(), err
+	}
+)
+
+// Lint returns what shellcheck finds in script, one finding per line, or ""
+// if it finds nothing. checked is false when shellcheck is not installed.
+func Lint(ctx context.Context, script string) (findings string, checked bool, err error) {
+	if _, err := lookPath("shellcheck"); err != nil {
+		return "", false, nil
+	}
+	output, err := runCommand(ctx, script, "shellcheck", "--format=gcc", "--shell=bash", "-")
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", false, err
+	}
+	// Findings name the script -, as read from stdin
+	return strings.TrimSpace(strings.ReplaceAll(output, "-:", "line ")), true, nil
+}
@@ -0,0 +1,100 @@
+package scriptgen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanupScript() Script {
+	return Script{
+		Name:        "cleanup.sh",
+		Description: "Delete the logs older than a number of days",
+		Script:      "#!/usr/bin/env bash\nset -euo pipefail\nfind \"${1:?usage: cleanup.sh DIR}\" -name '*.log' -mtime +7 -delete\n",
+		Test:        "#!/usr/bin/env bats\n\n@test \"usage\" {\n  run \"$BATS_TEST_DIRNAME/cleanup.sh\"\n  [ \"$status\" -ne 0 ]\n}\n",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, cleanupScript().Validate())
+
+	script := cleanupScript()
+	script.Script = "echo hi"
+	assert.ErrorContains(t, script.Validate(), "#!")
+
+	script = cleanupScript()
+	script.Script = "#!/bin/bash\nif true; then\n"
+	assert.ErrorContains(t, script.Validate(), "does not parse")
+
+	script = cleanupScript()
+	script.Test = ""
+	assert.ErrorContains(t, script.Validate(), "no test")
+}
+
+func TestParseDraft(t *testing.T) {
+	script, err := parseDraft(`{"name": "clean logs", "script": "#!/usr/bin/env bash\necho hi", "test": "@test \"x\" { true; }"}`, "clean the logs")
+	require.NoError(t, err)
+	assert.Equal(t, "clean-logs.sh", script.Name)
+	assert.Equal(t, "clean the logs", script.Description)
+
+	_, err = parseDraft(`{"name": "x", "script": "echo hi", "test": "t"}`, "x")
+	assert.ErrorContains(t, err, "invalid script")
+	_, err = parseDraft(`not json`, "x")
+	assert.ErrorContains(t, err, "invalid response")
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	script := cleanupScript()
+	path := script.DefaultPath(filepath.Join(dir, "bin"))
+	assert.Equal(t, filepath.Join(dir, "bin", "cleanup.sh"), path)
+
+	message, err := Write(script, path)
+	require.NoError(t, err)
+	assert.Contains(t, message, "bats "+filepath.Join(dir, "bin", "cleanup.bats"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	test, err := os.ReadFile(filepath.Join(dir, "bin", "cleanup.bats"))
+	require.NoError(t, err)
+	assert.Equal(t, script.Test, string(test))
+
+	// Existing files are never overwritten
+	_, err = Write(script, path)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestLint(t *testing.T) {
+	defer func(saved func(string) (string, error)) { lookPath = saved }(lookPath)
+	defer func(saved func(context.Context, string, string, ...string) (string, error)) { runCommand = saved }(runCommand)
+
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	_, checked, err := Lint(context.Background(), "#!/bin/bash\necho $1\n")
+	require.NoError(t, err)
+	assert.False(t, checked)
+
+	lookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	var gotArgs []string
+	runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+		gotArgs = args
+		return "-:2:6: note: Double quote to prevent globbing and word splitting. [SC2086]\n", &exec.ExitError{}
+	}
+	findings, checked, err := Lint(context.Background(), "#!/bin/bash\necho $1\n")
+	require.NoError(t, err)
+	assert.True(t, checked)
+	assert.Equal(t, "line 2:6: note: Double quote to prevent globbing and word splitting. [SC2086]", findings)
+	assert.Equal(t, []string{"--format=gcc", "--shell=bash", "-"}, gotArgs)
+
+	runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+		return "", errors.New("killed")
+	}
+	_, _, err = Lint(context.Background(), "#!/bin/bash\n")
+	assert.Error(t, err)
+}
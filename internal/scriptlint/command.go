@@ -0,0 +1,197 @@
+package scriptlint
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: lint FILE...\n" +
+	"       lint --fix [-y] FILE [N...]"
+
+// PatchFunc returns source with findings fixed.
+type PatchFunc func(ctx context.Context, source string, findings []Finding) (string, error)
+
+// NewLintCommandHandler creates an ExecHandler for the lint builtin, which
+// lists the numbered findings in scripts and, with --fix, has patch fix the
+// findings picked by number, or all of them, showing the diff to confirm
+// before the script is changed.
+func NewLintCommandHandler(patch PatchFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "lint" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			args = args[1:]
+			fix, yes := false, false
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				switch args[0] {
+				case "--fix":
+					fix = true
+				case "-y", "--yes":
+					yes = true
+				case "-h", "--help":
+					fmt.Fprintln(hc.Stdout, usage)
+					return nil
+				default:
+					fmt.Fprintf(hc.Stderr, "lint: unknown option %s\n%s\n", args[0], usage)
+					return interp.NewExitStatus(2)
+				}
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				fmt.Fprintln(hc.Stderr, usage)
+				return interp.NewExitStatus(2)
+			}
+
+			if !fix {
+				status := 0
+				for _, path := range args {
+					findings, linter, err := lintFile(ctx, hc.Dir, path)
+					if err != nil {
+						fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+						status = 2
+						continue
+					}
+					if len(findings) == 0 {
+						fmt.Fprintf(hc.Stdout, "%s: no issues\n", path)
+						continue
+					}
+					fmt.Fprintf(hc.Stdout, "%s: %s (%s)\n", path, Summary(findings), linter)
+					for i, finding := range findings {
+						fmt.Fprintf(hc.Stdout, "%3d  %s\n", i+1, finding)
+					}
+					status = max(status, 1)
+				}
+				if status != 0 {
+					return interp.NewExitStatus(uint8(status))
+				}
+				return nil
+			}
+
+			path := args[0]
+			findings, _, err := lintFile(ctx, hc.Dir, path)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(2)
+			}
+			picked, err := pick(findings, args[1:])
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(2)
+			}
+			if len(picked) == 0 {
+				fmt.Fprintf(hc.Stdout, "%s: no issues\n", path)
+				return nil
+			}
+
+			full := resolve(hc.Dir, path)
+			source, err := os.ReadFile(full)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			patched, err := patch(ctx, string(source), picked)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: could not patch %s: %s\n", path, err)
+				return interp.NewExitStatus(1)
+			}
+			diff, err := Diff(path, string(source), patched)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if diff == "" {
+				fmt.Fprintf(hc.Stdout, "%s: the patch changes nothing\n", path)
+				return nil
+			}
+			fmt.Fprint(hc.Stdout, diff)
+			if !yes && !confirm(hc, path) {
+				fmt.Fprintln(hc.Stderr, "lint: cancelled")
+				return interp.NewExitStatus(1)
+			}
+
+			info, err := os.Stat(full)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			if err := os.WriteFile(full, []byte(patched), info.Mode().Perm()); err != nil {
+				fmt.Fprintf(hc.Stderr, "lint: %s\n", err)
+				return interp.NewExitStatus(1)
+			}
+			fmt.Fprintf(hc.Stdout, "Patched %s\n", path)
+			return nil
+		}
+	}
+}
+
+func resolve(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func lintFile(ctx context.Context, dir string, path string) ([]Finding, string, error) {
+	full := resolve(dir, path)
+	source, err := os.ReadFile(full)
+	if err != nil {
+		return nil, "", err
+	}
+	target := Target{Path: full, Sourced: !strings.HasPrefix(string(source), "#!")}
+	return Lint(ctx, target, source)
+}
+
+// pick returns the findings numbered, from 1, by numbers, or all of them if
+// there are no numbers.
+func pick(findings []Finding, numbers []string) ([]Finding, error) {
+	if len(numbers) == 0 {
+		return findings, nil
+	}
+	var picked []Finding
+	for _, number := range numbers {
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 || n > len(findings) {
+			return nil, fmt.Errorf("no finding %s; run lint FILE to list them", number)
+		}
+		picked = append(picked, findings[n-1])
+	}
+	return picked, nil
+}
+
+// confirm asks whether to write the patch to path, honouring
+// BISH_DEFAULT_TO_YES for an empty answer.
+func confirm(hc interp.HandlerContext, path string) bool {
+	defaultToYes := strings.ToLower(hc.Env.Get("BISH_DEFAULT_TO_YES").String())
+	defaultYes := defaultToYes == "1" || defaultToYes == "true"
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(hc.Stdout, "Apply the patch to %s? %s ", path, choices)
+
+	if hc.Stdin == nil {
+		return false
+	}
+	answer, err := bufio.NewReader(hc.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "":
+		return defaultYes
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+package scriptlint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const deployScript = "#!/bin/bash\ncd /srv\nrm -rf $1\n"
+
+// runLint runs script with the lint builtin in dir, patching with patch.
+func runLint(t *testing.T, dir string, script string, stdin string, patch PatchFunc) (stdout, stderr string, err error) {
+	t.Helper()
+	withoutShellcheck(t)
+	var out, errOut bytes.Buffer
+	runner, err := interp.New(
+		interp.Dir(dir),
+		interp.StdIO(strings.NewReader(stdin), &out, &errOut),
+		interp.ExecHandlers(NewLintCommandHandler(patch)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return out.String(), errOut.String(), err
+}
+
+func TestLintList(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy.sh"), []byte(deployScript), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "clean.sh"), []byte("#!/bin/bash\necho hi\n"), 0o755))
+
+	stdout, _, err := runLint(t, dir, "lint clean.sh deploy.sh", "", nil)
+	assert.Equal(t, "clean.sh: no issues\n"+
+		"deploy.sh: 1 warning, 1 info (bish)\n"+
+		"  1  line 2: warning SC2164: Use 'cd ... || exit' or 'cd ... || return' in case cd fails.\n"+
+		"  2  line 3: info SC2086: Double quote to prevent globbing and word splitting.\n", stdout)
+	status, ok := interp.IsExitStatus(err)
+	require.True(t, ok)
+	assert.EqualValues(t, 1, status)
+
+	_, stderr, err := runLint(t, dir, "lint missing.sh", "", nil)
+	assert.Contains(t, stderr, "lint: ")
+	assert.Error(t, err)
+	_, stderr, _ = runLint(t, dir, "lint", "", nil)
+	assert.Contains(t, stderr, "Usage: lint FILE")
+}
+
+func TestLintFix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.sh")
+	require.NoError(t, os.WriteFile(path, []byte(deployScript), 0o750))
+
+	var asked []Finding
+	patch := func(ctx context.Context, source string, findings []Finding) (string, error) {
+		asked = findings
+		return strings.Replace(source, "$1", `"$1"`, 1), nil
+	}
+
+	// Declined, the script is left alone
+	stdout, stderr, err := runLint(t, dir, "lint --fix deploy.sh 2", "n\n", patch)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "lint: cancelled")
+	assert.Equal(t, []string{"SC2086"}, codes(asked))
+	assert.Contains(t, stdout, "-rm -rf $1\n+rm -rf \"$1\"\n")
+	assert.Contains(t, stdout, "Apply the patch to deploy.sh? [y/N]")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, deployScript, string(content))
+
+	// Accepted, it is patched in place
+	stdout, _, err = runLint(t, dir, "lint --fix deploy.sh 2", "y\n", patch)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Patched deploy.sh")
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash\ncd /srv\nrm -rf \"$1\"\n", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+
+	// Without numbers, all the findings are fixed
+	_, _, err = runLint(t, dir, "lint --fix -y deploy.sh", "", patch)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SC2164"}, codes(asked))
+
+	_, stderr, err = runLint(t, dir, "lint --fix deploy.sh 7", "", patch)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "no finding 7")
+
+	failing := func(context.Context, string, []Finding) (string, error) { return "", errors.New("offline") }
+	_, stderr, err = runLint(t, dir, "lint --fix deploy.sh", "", failing)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "could not patch deploy.sh: offline")
+}
@@ -0,0 +1,95 @@
+package scriptlint
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// shells are the interpreters whose script argument is linted.
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true}
+
+var shebangPattern = regexp.MustCompile(`^#!\s*\S*/(env\s+)?(ba|da)?sh(\s|$)`)
+
+// Scripts returns the local scripts that line sources or runs in dir: the
+// file of source and ., the script passed to sh or bash, and commands run
+// by path, such as ./deploy.sh, that are shell scripts.
+func Scripts(line string, dir string) []Target {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil
+	}
+
+	var targets []Target
+	seen := map[string]bool{}
+	add := func(path string, sourced bool) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if seen[path] {
+			return
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			return
+		}
+		seen[path] = true
+		targets = append(targets, Target{Path: path, Sourced: sourced})
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		name := call.Args[0].Lit()
+		switch {
+		case name == "source" || name == ".":
+			if len(call.Args) > 1 && call.Args[1].Lit() != "" {
+				add(call.Args[1].Lit(), true)
+			}
+		case shells[name]:
+			for _, arg := range call.Args[1:] {
+				lit := arg.Lit()
+				if lit == "-c" || lit == "" {
+					break
+				}
+				if !strings.HasPrefix(lit, "-") {
+					add(lit, false)
+					break
+				}
+			}
+		case strings.Contains(name, "/"):
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if IsShellScript(path) {
+				add(path, false)
+			}
+		}
+		return true
+	})
+	return targets
+}
+
+// IsShellScript reports whether the file at path is a shell script, going
+// by its #! line or, without one, its .sh extension.
+func IsShellScript(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	first, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && first == "" {
+		return strings.HasSuffix(path, ".sh")
+	}
+	if strings.HasPrefix(first, "#!") {
+		return shebangPattern.MatchString(first)
+	}
+	return strings.HasSuffix(path, ".sh")
+}
@@ -0,0 +1,37 @@
+package scriptlint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o755))
+		return path
+	}
+	env := write("env.sh", "export A=1\n")
+	deploy := write("deploy", "#!/usr/bin/env bash\necho deploy\n")
+	build := write("bin/build", "#!/bin/sh\necho build\n")
+	write("tool.py", "#!/usr/bin/env python3\nprint(1)\n")
+	setup := write("setup.sh", "echo setup\n")
+
+	assert.Equal(t, []Target{{Path: env, Sourced: true}}, Scripts("source env.sh", dir))
+	assert.Equal(t, []Target{{Path: env, Sourced: true}, {Path: deploy}}, Scripts(". ./env.sh && ./deploy --prod", dir))
+	assert.Equal(t, []Target{{Path: build}}, Scripts("bin/build | tee log", dir))
+	assert.Equal(t, []Target{{Path: setup}}, Scripts("bash -x setup.sh", dir))
+	assert.Equal(t, []Target{{Path: deploy}}, Scripts("for i in 1 2; do "+deploy+"; done", dir))
+
+	assert.Empty(t, Scripts("./tool.py", dir))
+	assert.Empty(t, Scripts("bash -c 'echo setup.sh'", dir))
+	assert.Empty(t, Scripts("source missing.sh", dir))
+	assert.Empty(t, Scripts("deploy", dir))
+	assert.Empty(t, Scripts("if true; then", dir))
+}
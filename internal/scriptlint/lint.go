@@ -0,0 +1,208 @@
+// Package scriptlint lints the shell scripts that are sourced or run from
+// the prompt, with shellcheck when it is installed and with a few native
+// rules otherwise, and has the LLM patch the findings picked.
+package scriptlint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity is how serious a finding is, as shellcheck grades them.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityStyle   Severity = "style"
+)
+
+// rank orders severities, the most serious first.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	case SeverityInfo:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Finding is an issue found in a script.
+type Finding struct {
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"level"`
+	// Code is the shellcheck code of the issue, such as SC2086.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s %s: %s", f.Line, f.Severity, f.Code, f.Message)
+}
+
+// Target is a script to lint.
+type Target struct {
+	Path string
+	// Sourced is true for a script that is sourced rather than run, which
+	// needs no #! line.
+	Sourced bool
+}
+
+// lookPath and runCommand run shellcheck. Tests override them.
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		var out bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdout = &out
+		err := cmd.Run()
+		return out.Bytes(), err
+	}
+)
+
+// Lint returns the findings in the script source read from target, most
+// serious first, and the name of the linter that found them: shellcheck,
+// or bish for the native rules.
+func Lint(ctx context.Context, target Target, source []byte) ([]Finding, string, error) {
+	var findings []Finding
+	linter := "bish"
+	if _, err := lookPath("shellcheck"); err == nil {
+		args := []string{"--format=json1", "--external-sources"}
+		if target.Sourced {
+			args = append(args, "--shell=bash")
+		}
+		output, err := runCommand(ctx, "shellcheck", append(args, "--", target.Path)...)
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return nil, "", err
+		}
+		var result struct {
+			Comments []struct {
+				Finding
+				Code int `json:"code"`
+			} `json:"comments"`
+		}
+		if err := json.Unmarshal(output, &result); err != nil {
+			return nil, "", fmt.Errorf("failed to read the output of shellcheck: %w", err)
+		}
+		for _, comment := range result.Comments {
+			finding := comment.Finding
+			finding.Code = fmt.Sprintf("SC%d", comment.Code)
+			findings = append(findings, finding)
+		}
+		linter = "shellcheck"
+	} else {
+		findings = Native(string(source))
+		if target.Sourced {
+			findings = slices.DeleteFunc(findings, func(finding Finding) bool {
+				return finding.Code == "SC2148"
+			})
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if a.Severity != b.Severity {
+			return a.Severity.rank() - b.Severity.rank()
+		}
+		return a.Line - b.Line
+	})
+	return findings, linter, nil
+}
+
+// Summary returns how many findings there are of each severity, such as
+// "1 error, 2 warnings".
+func Summary(findings []Finding) string {
+	counts := map[Severity]int{}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	var parts []string
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityStyle} {
+		switch count := counts[severity]; count {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+string(severity))
+		default:
+			plural := string(severity) + "s"
+			if severity == SeverityInfo {
+				plural = "infos"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", count, plural))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Linter lints the scripts run from the prompt, each only once until it
+// changes, so that a script that is run often is not reported every time.
+type Linter struct {
+	mu      sync.Mutex
+	checked map[string]time.Time
+}
+
+// DefaultLinter is the linter of the interactive shell.
+var DefaultLinter = NewLinter()
+
+func NewLinter() *Linter {
+	return &Linter{checked: map[string]time.Time{}}
+}
+
+// Check returns the findings in the script of target, unless it was
+// checked since it last changed.
+func (l *Linter) Check(ctx context.Context, target Target) ([]Finding, string, bool) {
+	path := target.Path
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, "", false
+	}
+	l.mu.Lock()
+	if modified, ok := l.checked[path]; ok && modified.Equal(info.ModTime()) {
+		l.mu.Unlock()
+		return nil, "", false
+	}
+	l.checked[path] = info.ModTime()
+	l.mu.Unlock()
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", false
+	}
+	findings, linter, err := Lint(ctx, target, source)
+	if err != nil || len(findings) == 0 {
+		return nil, "", false
+	}
+	return findings, linter, true
+}
+
+// shownFindings is how many findings a report lists.
+const shownFindings = 3
+
+// Report describes the findings in the script at path, as shown when it is
+// sourced or run: how many there are of each severity and the most serious
+// ones, numbered for lint --fix.
+func Report(path string, findings []Finding, linter string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s (%s)\n", path, Summary(findings), linter)
+	for i, finding := range findings[:min(len(findings), shownFindings)] {
+		fmt.Fprintf(&sb, "%3d  %s\n", i+1, finding)
+	}
+	if len(findings) > shownFindings {
+		fmt.Fprintf(&sb, "     … and %d more; run lint %s to list them\n", len(findings)-shownFindings, path)
+	}
+	fmt.Fprintf(&sb, "Run lint --fix %s [N...] to patch them\n", path)
+	return sb.String()
+}
@@ -0,0 +1,137 @@
+package scriptlint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withoutShellcheck lints with the native rules for the rest of the test.
+func withoutShellcheck(t *testing.T) {
+	saved := lookPath
+	t.Cleanup(func() { lookPath = saved })
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+}
+
+func codes(findings []Finding) []string {
+	var codes []string
+	for _, finding := range findings {
+		codes = append(codes, finding.Code)
+	}
+	return codes
+}
+
+func TestNative(t *testing.T) {
+	findings := Native(`#!/bin/bash
+cd /tmp
+cd /var || exit 1
+read line
+read -r line
+echo $line "$line" $# ${#line}
+rm $(ls)
+now=` + "`date`" + `
+`)
+	assert.Equal(t, []string{"SC2164", "SC2162", "SC2086", "SC2046", "SC2006"}, codes(findings))
+	assert.Equal(t, Finding{Line: 2, Column: 1, Severity: SeverityWarning, Code: "SC2164",
+		Message: "Use 'cd ... || exit' or 'cd ... || return' in case cd fails."}, findings[0])
+	assert.Equal(t, 6, findings[2].Line)
+	assert.Equal(t, 6, findings[2].Column)
+
+	assert.Equal(t, []string{"SC2148"}, codes(Native("echo hi\n")))
+
+	findings = Native("#!/bin/sh\nif true; then\n")
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Equal(t, 2, findings[0].Line)
+}
+
+func TestLintSortsAndSkipsShebangOfSourced(t *testing.T) {
+	withoutShellcheck(t)
+	findings, linter, err := Lint(context.Background(), Target{Path: "env.sh", Sourced: true}, []byte("echo $HOME\ncd /tmp\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "bish", linter)
+	assert.Equal(t, []string{"SC2164", "SC2086"}, codes(findings))
+
+	findings, _, err = Lint(context.Background(), Target{Path: "run.sh"}, []byte("echo hi\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SC2148"}, codes(findings))
+}
+
+func TestLintWithShellcheck(t *testing.T) {
+	defer func(saved func(string) (string, error)) { lookPath = saved }(lookPath)
+	defer func(saved func(context.Context, string, ...string) ([]byte, error)) { runCommand = saved }(runCommand)
+
+	lookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	var gotArgs []string
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(`{"comments": [
+			{"file": "x.sh", "line": 4, "column": 6, "level": "info", "code": 2086, "message": "Double quote to prevent globbing and word splitting."},
+			{"file": "x.sh", "line": 9, "column": 1, "level": "error", "code": 1089, "message": "Parsing stopped here."}
+		]}`), &exec.ExitError{}
+	}
+	findings, linter, err := Lint(context.Background(), Target{Path: "x.sh", Sourced: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "shellcheck", linter)
+	assert.Equal(t, []string{"--format=json1", "--external-sources", "--shell=bash", "--", "x.sh"}, gotArgs)
+	assert.Equal(t, []Finding{
+		{Line: 9, Column: 1, Severity: SeverityError, Code: "SC1089", Message: "Parsing stopped here."},
+		{Line: 4, Column: 6, Severity: SeverityInfo, Code: "SC2086", Message: "Double quote to prevent globbing and word splitting."},
+	}, findings)
+
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("shellcheck: oops"), &exec.ExitError{}
+	}
+	_, _, err = Lint(context.Background(), Target{Path: "x.sh"}, nil)
+	assert.ErrorContains(t, err, "failed to read the output of shellcheck")
+}
+
+func TestSummaryAndReport(t *testing.T) {
+	findings := []Finding{
+		{Line: 1, Severity: SeverityError, Code: "SC2148", Message: "Add a shebang."},
+		{Line: 2, Severity: SeverityWarning, Code: "SC2164", Message: "Use cd || exit."},
+		{Line: 3, Severity: SeverityInfo, Code: "SC2086", Message: "Double quote."},
+		{Line: 4, Severity: SeverityInfo, Code: "SC2086", Message: "Double quote."},
+	}
+	assert.Equal(t, "1 error, 1 warning, 2 infos", Summary(findings))
+	assert.Equal(t, "deploy.sh: 1 error, 1 warning, 2 infos (bish)\n"+
+		"  1  line 1: error SC2148: Add a shebang.\n"+
+		"  2  line 2: warning SC2164: Use cd || exit.\n"+
+		"  3  line 3: info SC2086: Double quote.\n"+
+		"     … and 1 more; run lint deploy.sh to list them\n"+
+		"Run lint --fix deploy.sh [N...] to patch them\n", Report("deploy.sh", findings, "bish"))
+}
+
+func TestLinterChecksOnceUntilChanged(t *testing.T) {
+	withoutShellcheck(t)
+	path := filepath.Join(t.TempDir(), "deploy.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/bash\ncd /srv\n"), 0o755))
+
+	linter := NewLinter()
+	findings, _, ok := linter.Check(context.Background(), Target{Path: path})
+	require.True(t, ok)
+	assert.Equal(t, []string{"SC2164"}, codes(findings))
+	_, _, ok = linter.Check(context.Background(), Target{Path: path})
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/bash\ncd /srv\nls $1\n"), 0o755))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	findings, _, ok = linter.Check(context.Background(), Target{Path: path})
+	require.True(t, ok)
+	assert.Equal(t, []string{"SC2164", "SC2086"}, codes(findings))
+
+	// Clean scripts and missing files report nothing
+	clean := filepath.Join(t.TempDir(), "clean.sh")
+	require.NoError(t, os.WriteFile(clean, []byte("#!/bin/bash\necho hi\n"), 0o755))
+	_, _, ok = linter.Check(context.Background(), Target{Path: clean})
+	assert.False(t, ok)
+	_, _, ok = linter.Check(context.Background(), Target{Path: filepath.Join(t.TempDir(), "missing.sh")})
+	assert.False(t, ok)
+}
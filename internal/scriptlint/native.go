@@ -0,0 +1,97 @@
+package scriptlint
+
+import (
+	"errors"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// numericParams expand to something that needs no quoting.
+var numericParams = map[string]bool{"#": true, "?": true, "$": true, "!": true}
+
+// Native returns what a handful of the most common shellcheck rules find in
+// source, for when shellcheck is not installed.
+func Native(source string) []Finding {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(source), "")
+	if err != nil {
+		finding := Finding{Line: 1, Column: 1, Severity: SeverityError, Code: "SC1073", Message: err.Error()}
+		var parseErr syntax.ParseError
+		if errors.As(err, &parseErr) {
+			finding.Line, finding.Column = int(parseErr.Pos.Line()), int(parseErr.Pos.Col())
+			finding.Message = parseErr.Text
+		}
+		return []Finding{finding}
+	}
+
+	var findings []Finding
+	add := func(pos syntax.Pos, severity Severity, code, message string) {
+		findings = append(findings, Finding{Line: int(pos.Line()), Column: int(pos.Col()), Severity: severity, Code: code, Message: message})
+	}
+
+	if !strings.HasPrefix(source, "#!") {
+		add(syntax.NewPos(0, 1, 1), SeverityError, "SC2148",
+			"Tips depend on target shell and yours is unknown. Add a shebang or a 'shell' directive.")
+	}
+
+	// Commands that have a fallback, as in cd dir || exit
+	guarded := map[*syntax.Stmt]bool{}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.BinaryCmd:
+			if node.Op == syntax.OrStmt {
+				guarded[node.X] = true
+			}
+		case *syntax.Stmt:
+			call, ok := node.Cmd.(*syntax.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				break
+			}
+			switch call.Args[0].Lit() {
+			case "cd":
+				if !guarded[node] {
+					add(node.Pos(), SeverityWarning, "SC2164",
+						"Use 'cd ... || exit' or 'cd ... || return' in case cd fails.")
+				}
+			case "read":
+				if !hasFlag(call.Args[1:], 'r') {
+					add(node.Pos(), SeverityInfo, "SC2162", "read without -r will mangle backslashes.")
+				}
+			}
+			for _, arg := range call.Args[1:] {
+				for _, part := range arg.Parts {
+					switch part := part.(type) {
+					case *syntax.ParamExp:
+						if part.Param == nil || numericParams[part.Param.Value] || part.Length {
+							continue
+						}
+						add(part.Pos(), SeverityInfo, "SC2086", "Double quote to prevent globbing and word splitting.")
+					case *syntax.CmdSubst:
+						add(part.Pos(), SeverityWarning, "SC2046", "Quote this to prevent word splitting.")
+					}
+				}
+			}
+		case *syntax.CmdSubst:
+			if node.Backquotes {
+				add(node.Pos(), SeverityStyle, "SC2006", "Use $(...) notation instead of legacy backticks `...`.")
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// hasFlag reports whether args have the single-letter flag before any --,
+// alone or among others as in -rs.
+func hasFlag(args []*syntax.Word, flag rune) bool {
+	for _, arg := range args {
+		lit := arg.Lit()
+		if lit == "--" {
+			return false
+		}
+		if strings.HasPrefix(lit, "-") && strings.ContainsRune(lit[1:], flag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package scriptlint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/robottwo/bishop/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type patchResponse struct {
+	Script string `json:"script" description:"The whole fixed script" required:"true"`
+}
+
+var patchSchema = utils.GenerateJsonSchema(patchResponse{})
+
+// Patch asks the LLM to fix findings in the script source, changing nothing
+// else, and returns the fixed script.
+func Patch(ctx context.Context, client *openai.Client, config utils.LLMModelConfig, source string, findings []Finding) (string, error) {
+	schema, err := patchSchema.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	systemMessage := fmt.Sprintf(`You are Bishop, an intelligent shell program.
+You will be given a shell script enclosed in <script> tags and issues shellcheck found in it, enclosed in <findings> tags.
+
+# Instructions
+* Fix each of the issues, and only those issues
+* Keep everything else in the script as it is, including comments, blank lines and indentation
+* Reply with the whole fixed script
+* Do not explain anything outside of the JSON
+
+# Response JSON Schema
+%s`, string(schema))
+
+	var listed []string
+	for _, finding := range findings {
+		listed = append(listed, finding.String())
+	}
+	completionRequest := openai.ChatCompletionRequest{
+		Model: config.ModelId,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: fmt.Sprintf("<script>\n%s</script>\n<findings>\n%s\n</findings>", source, strings.Join(listed, "\n"))},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}
+	if config.Temperature != nil {
+		completionRequest.Temperature = float32(*config.Temperature)
+	}
+
+	completion, err := client.CreateChatCompletion(ctx, completionRequest)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("empty response from LLM")
+	}
+	var response patchResponse
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &response); err != nil {
+		return "", fmt.Errorf("invalid response from LLM: %w", err)
+	}
+	if strings.TrimSpace(response.Script) == "" {
+		return "", errors.New("the LLM returned an empty script")
+	}
+	// Keep the final newline of the script as it was
+	patched := strings.TrimRight(response.Script, "\n")
+	if strings.HasSuffix(source, "\n") {
+		patched += "\n"
+	}
+	return patched, nil
+}
+
+// Diff returns the unified diff from before to after of the script at path,
+// or "" if they are the same.
+func Diff(path string, before string, after string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
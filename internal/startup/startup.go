@@ -0,0 +1,133 @@
+// Package startup runs the steps bish initializes with, each as soon as the
+// steps it depends on are done, so that independent ones such as opening the
+// history and analytics databases overlap instead of adding up.
+package startup
+
+import (
+	"fmt"
+	"time"
+)
+
+// Step is one part of initialization.
+type Step struct {
+	Name string
+	// After names the steps that must be done before this one starts
+	After []string
+	Run   func() error
+}
+
+// Timing is when a step started, counting from the start of Run, and how
+// long it took.
+type Timing struct {
+	Name     string
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// Run runs steps, each in its own goroutine once those it comes after are
+// done, and returns how long each took, in the order they finished. When a
+// step fails, the steps that have not started yet are skipped, and its error
+// is returned once the running ones are done.
+func Run(steps []Step) ([]Timing, error) {
+	if err := check(steps); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		index  int
+		timing Timing
+		err    error
+	}
+	begin := time.Now()
+	results := make(chan result)
+	waiting := make([]int, len(steps))
+	dependents := map[string][]int{}
+	for i, step := range steps {
+		waiting[i] = len(step.After)
+		for _, name := range step.After {
+			dependents[name] = append(dependents[name], i)
+		}
+	}
+	start := func(i int) {
+		go func() {
+			started := time.Now()
+			err := steps[i].Run()
+			results <- result{i, Timing{steps[i].Name, started.Sub(begin), time.Since(started)}, err}
+		}()
+	}
+
+	running := 0
+	for i := range steps {
+		if waiting[i] == 0 {
+			start(i)
+			running++
+		}
+	}
+	var timings []Timing
+	var firstErr error
+	for running > 0 {
+		r := <-results
+		running--
+		timings = append(timings, r.timing)
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", r.timing.Name, r.err)
+			}
+			continue
+		}
+		if firstErr != nil {
+			continue
+		}
+		for _, i := range dependents[steps[r.index].Name] {
+			if waiting[i]--; waiting[i] == 0 {
+				start(i)
+				running++
+			}
+		}
+	}
+	return timings, firstErr
+}
+
+// check makes sure that step names are unique, that the steps they come
+// after exist and that none of them waits for itself.
+func check(steps []Step) error {
+	byName := map[string]Step{}
+	for _, step := range steps {
+		if _, ok := byName[step.Name]; ok {
+			return fmt.Errorf("startup step %s is defined twice", step.Name)
+		}
+		byName[step.Name] = step
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("startup step %s depends on itself", name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, after := range byName[name].After {
+			if _, ok := byName[after]; !ok {
+				return fmt.Errorf("startup step %s comes after unknown step %s", name, after)
+			}
+			if err := visit(after); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, step := range steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package startup
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOrdersByDependencies(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	step := func(name string, after ...string) Step {
+		return Step{Name: name, After: after, Run: func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}}
+	}
+
+	timings, err := Run([]Step{
+		step("runner", "history", "analytics"),
+		step("history"),
+		step("analytics"),
+		step("logger", "runner"),
+		step("coach", "history", "logger"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, timings, 5)
+	assert.ElementsMatch(t, []string{"history", "analytics"}, order[:2])
+	assert.Equal(t, []string{"runner", "logger", "coach"}, order[2:])
+}
+
+func TestRunOverlapsIndependentSteps(t *testing.T) {
+	// Each step waits for the other to start, which only finishes if they
+	// run at the same time
+	var started sync.WaitGroup
+	started.Add(2)
+	wait := func() error {
+		started.Done()
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("ran alone")
+		}
+	}
+	_, err := Run([]Step{{Name: "a", Run: wait}, {Name: "b", Run: wait}})
+	assert.NoError(t, err)
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	var ran atomic.Bool
+	_, err := Run([]Step{
+		{Name: "history", Run: func() error { return errors.New("disk full") }},
+		{Name: "runner", After: []string{"history"}, Run: func() error {
+			ran.Store(true)
+			return nil
+		}},
+		{Name: "analytics", Run: func() error { return nil }},
+	})
+	assert.EqualError(t, err, "history: disk full")
+	assert.False(t, ran.Load())
+}
+
+func TestRunRejectsBadGraphs(t *testing.T) {
+	noop := func() error { return nil }
+	for name, steps := range map[string][]Step{
+		"unknown": {{Name: "a", After: []string{"b"}, Run: noop}},
+		"cycle": {
+			{Name: "a", After: []string{"b"}, Run: noop},
+			{Name: "b", After: []string{"a"}, Run: noop},
+		},
+		"duplicate": {{Name: "a", Run: noop}, {Name: "a", Run: noop}},
+	} {
+		_, err := Run(steps)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,152 @@
+package statusline
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// maxFailures caps the backoff of a failing segment, which waits twice as
+// long after each failure: at most 16 intervals.
+const maxFailures = 4
+
+// ansiEscape matches the color and cursor sequences commands may print.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// RunFunc runs the command of a segment and returns its output.
+type RunFunc func(ctx context.Context, command string) (string, error)
+
+// state is what is known about a segment: its last output and when it ran.
+type state struct {
+	value    string
+	ranAt    time.Time
+	running  bool
+	failures int
+}
+
+// Provider runs the segments in the background and keeps their last output.
+type Provider struct {
+	mu       sync.Mutex
+	segments []Segment
+	// states is keyed by segment, so that a segment whose command or
+	// interval changed starts over
+	states map[Segment]*state
+	run    RunFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewProvider creates a provider that runs the commands of segments with
+// run.
+func NewProvider(run RunFunc) *Provider {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Provider{states: make(map[Segment]*state), run: run, ctx: ctx, cancel: cancel}
+}
+
+// DefaultProvider runs the segments of the interactive shell with sh.
+var DefaultProvider = NewProvider(RunShell)
+
+// Configure sets the segments to show, keeping the output of those that did
+// not change.
+func (p *Provider) Configure(segments []Segment) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.segments = segments
+	states := make(map[Segment]*state, len(segments))
+	for _, s := range segments {
+		if st, ok := p.states[s]; ok {
+			states[s] = st
+		} else {
+			states[s] = &state{}
+		}
+	}
+	p.states = states
+}
+
+// Values returns the last output of each segment that has one, in order,
+// and starts in the background those due to run again at now.
+func (p *Provider) Values(now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var values []string
+	for _, s := range p.segments {
+		st := p.states[s]
+		if !st.running && !now.Before(st.ranAt.Add(s.Interval<<st.failures)) {
+			st.running = true
+			go p.refresh(s, st)
+		}
+		if st.value != "" {
+			values = append(values, st.value)
+		}
+	}
+	return values
+}
+
+// refresh runs s and keeps its output. A segment that fails or times out
+// shows nothing and runs less often until it succeeds again.
+func (p *Provider) refresh(s Segment, st *state) {
+	ctx, cancel := context.WithTimeout(p.ctx, s.Timeout)
+	defer cancel()
+	output, err := p.run(ctx, s.Command)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st.running = false
+	st.ranAt = time.Now()
+	if err != nil {
+		st.value = ""
+		st.failures = min(st.failures+1, maxFailures)
+		return
+	}
+	st.value = Clean(output)
+	st.failures = 0
+}
+
+// Stop stops the segments that are running and keeps any from starting.
+func (p *Provider) Stop() {
+	p.cancel()
+}
+
+// RunShell runs command with sh, without input, and returns its output.
+func RunShell(ctx context.Context, command string) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// Do not wait on background processes that keep the output open
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	return string(output), err
+}
+
+// Clean returns the first line of output that is not blank, without escape
+// sequences or control characters and cut to MaxWidth.
+func Clean(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	line := ""
+	for scanner.Scan() {
+		if line = strings.TrimSpace(scanner.Text()); line != "" {
+			break
+		}
+	}
+	line = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, ansiEscape.ReplaceAllString(line, ""))
+
+	if runes := []rune(line); len(runes) > MaxWidth {
+		return string(runes[:MaxWidth-1]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,94 @@
+package statusline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRun answers the commands of segments from outputs, counting the runs.
+type fakeRun struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	runs    map[string]int
+}
+
+func (f *fakeRun) run(ctx context.Context, command string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs[command]++
+	switch command {
+	case "fail":
+		return "", errors.New("exit status 1")
+	case "hang":
+		f.mu.Unlock()
+		<-ctx.Done()
+		f.mu.Lock()
+		return "", ctx.Err()
+	}
+	return f.outputs[command], nil
+}
+
+func (f *fakeRun) count(command string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.runs[command]
+}
+
+func TestProvider(t *testing.T) {
+	f := &fakeRun{outputs: map[string]string{"vpn": "\n  on: work\nmore\n", "battery": "\x1b[32m87%\x1b[0m"}, runs: map[string]int{}}
+	p := NewProvider(f.run)
+	defer p.Stop()
+	segments := []Segment{
+		{Name: "vpn", Command: "vpn", Interval: time.Minute, Timeout: time.Second},
+		{Name: "slow", Command: "hang", Interval: time.Minute, Timeout: 20 * time.Millisecond},
+		{Name: "broken", Command: "fail", Interval: time.Minute, Timeout: time.Second},
+		{Name: "battery", Command: "battery", Interval: time.Minute, Timeout: time.Second},
+	}
+	p.Configure(segments)
+
+	// The first call starts the commands without waiting for them
+	now := time.Now()
+	assert.Empty(t, p.Values(now))
+	require.Eventually(t, func() bool { return len(p.Values(now)) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"on: work", "87%"}, p.Values(now))
+	require.Eventually(t, func() bool { return f.count("hang") == 1 }, time.Second, 5*time.Millisecond)
+
+	// Commands run again once their interval is over, failing ones later
+	p.Values(now.Add(30 * time.Second))
+	p.Values(now.Add(61 * time.Second))
+	require.Eventually(t, func() bool { return f.count("vpn") == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, f.count("fail"))
+
+	// Reconfiguring keeps the output of unchanged segments
+	p.Configure(segments[3:])
+	assert.Equal(t, []string{"87%"}, p.Values(now))
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "ok", Clean("\n\n  ok  \nnot shown\n"))
+	assert.Equal(t, "red", Clean("\x1b[31mred\x1b[0m\a"))
+	assert.Equal(t, "a very long status line…", Clean("a very long status line that goes on"))
+	assert.Equal(t, "", Clean(""))
+}
+
+func TestRunShell(t *testing.T) {
+	output, err := RunShell(context.Background(), "echo hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+
+	_, err = RunShell(context.Background(), "exit 3")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = RunShell(ctx, "sleep 5")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,103 @@
+// Package statusline runs the external commands configured as segments of
+// the border status, such as the VPN state, the battery or the CI status of
+// the branch. Each command runs in the background, on its own interval and
+// with a timeout, and its last output is shown until it runs again, so that
+// a slow or failing command never holds up the prompt or the other segments.
+package statusline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultInterval is how often a segment runs without an interval.
+	DefaultInterval = time.Minute
+	// DefaultTimeout is how long a segment may run without a timeout.
+	DefaultTimeout = 2 * time.Second
+	// minInterval is the shortest interval a segment can run on.
+	minInterval = time.Second
+	// MaxWidth is how many characters of its output a segment shows.
+	MaxWidth = 24
+)
+
+// Segment is a command whose output is shown in the border status.
+type Segment struct {
+	Name     string
+	Command  string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// statusFile is the format of the status file:
+//
+//	segments:
+//	  - name: vpn
+//	    command: nmcli -t -f NAME connection show --active | head -1
+//	    interval: 30s
+//	    timeout: 1s
+//	  - name: battery
+//	    command: cat /sys/class/power_supply/BAT0/capacity
+type statusFile struct {
+	Segments []struct {
+		Name     string `yaml:"name"`
+		Command  string `yaml:"command"`
+		Interval string `yaml:"interval"`
+		Timeout  string `yaml:"timeout"`
+	} `yaml:"segments"`
+}
+
+// DefaultPath returns where the segments are configured.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "status.yaml")
+}
+
+// Load returns the segments configured at path, in order. A missing file
+// configures none.
+func Load(path string) ([]Segment, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file statusFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	segments := make([]Segment, 0, len(file.Segments))
+	for i, s := range file.Segments {
+		segment := Segment{Name: s.Name, Command: s.Command, Interval: DefaultInterval, Timeout: DefaultTimeout}
+		if segment.Name == "" {
+			segment.Name = fmt.Sprintf("segment %d", i+1)
+		}
+		if segment.Command == "" {
+			return nil, fmt.Errorf("%s: %s: missing command", path, segment.Name)
+		}
+		if s.Interval != "" {
+			if segment.Interval, err = time.ParseDuration(s.Interval); err != nil || segment.Interval < minInterval {
+				return nil, fmt.Errorf("%s: %s: invalid interval %q", path, segment.Name, s.Interval)
+			}
+		}
+		if s.Timeout != "" {
+			if segment.Timeout, err = time.ParseDuration(s.Timeout); err != nil || segment.Timeout <= 0 {
+				return nil, fmt.Errorf("%s: %s: invalid timeout %q", path, segment.Name, s.Timeout)
+			}
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
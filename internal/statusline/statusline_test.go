@@ -0,0 +1,53 @@
+package statusline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`segments:
+  - name: vpn
+    command: vpn-status
+    interval: 30s
+    timeout: 500ms
+  - command: battery
+`), 0o644))
+
+	segments, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Segment{
+		{Name: "vpn", Command: "vpn-status", Interval: 30 * time.Second, Timeout: 500 * time.Millisecond},
+		{Name: "segment 2", Command: "battery", Interval: DefaultInterval, Timeout: DefaultTimeout},
+	}, segments)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	segments, err := Load(filepath.Join(t.TempDir(), "status.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+
+	segments, err = Load("")
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, content := range []string{
+		"segments:\n  - name: vpn\n",
+		"segments:\n  - command: vpn\n    interval: 10ms\n",
+		"segments:\n  - command: vpn\n    timeout: soon\n",
+		"segments: [",
+	} {
+		path := filepath.Join(t.TempDir(), "status.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		assert.Error(t, err, content)
+	}
+}
@@ -0,0 +1,58 @@
+package system
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Process struct {
+	PID        int
+	User       string
+	CPUPercent float64 // 0-100 per core, as ps reports it
+	MemBytes   uint64  // resident memory
+	Name       string
+	Command    string // full command line, or Name if unknown
+}
+
+// GetProcesses returns the processes running on the system.
+func GetProcesses() ([]Process, error) {
+	return getProcesses()
+}
+
+// parsePS parses the output of ps -o pid=,pcpu=,rss=,user=,args=, whose
+// resident memory is in kilobytes.
+func parsePS(out string) []Process {
+	var processes []Process
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[1], 64)
+		rss, _ := strconv.ParseUint(fields[2], 10, 64)
+		command := strings.Join(fields[4:], " ")
+		processes = append(processes, Process{
+			PID:        pid,
+			User:       fields[3],
+			CPUPercent: cpu,
+			MemBytes:   rss * 1024,
+			Name:       processName(fields[4]),
+			Command:    command,
+		})
+	}
+	return processes
+}
+
+// processName returns the name of the program argv0 runs; kernel threads
+// keep their bracketed names.
+func processName(argv0 string) string {
+	if strings.HasPrefix(argv0, "[") {
+		return argv0
+	}
+	return filepath.Base(strings.TrimPrefix(argv0, "-"))
+}
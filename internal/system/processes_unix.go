@@ -0,0 +1,13 @@
+//go:build !windows
+
+package system
+
+import "os/exec"
+
+func getProcesses() ([]Process, error) {
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,pcpu=,rss=,user=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePS(string(out)), nil
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package system
+
+import (
+	"encoding/csv"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func getProcesses() ([]Process, error) {
+	// tasklist /v /fo csv /nh prints one quoted line per process:
+	// "name","pid","session","session#","mem usage","status","user","cpu time","title"
+	out, err := exec.Command("tasklist", "/v", "/fo", "csv", "/nh").Output()
+	if err != nil {
+		return nil, err
+	}
+	records, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var processes []Process
+	for _, record := range records {
+		if len(record) < 7 {
+			continue
+		}
+		pid, err := strconv.Atoi(record[1])
+		if err != nil {
+			continue
+		}
+		// Memory is like "12,345 K"
+		mem := strings.NewReplacer(",", "", ".", "", " K", "", " ", "").Replace(record[4])
+		kb, _ := strconv.ParseUint(strings.TrimSpace(mem), 10, 64)
+		processes = append(processes, Process{
+			PID:      pid,
+			User:     record[6],
+			MemBytes: kb * 1024,
+			Name:     record[0],
+			Command:  record[0],
+		})
+	}
+	return processes, nil
+}
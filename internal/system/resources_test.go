@@ -1,6 +1,7 @@
 package system
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -77,3 +78,32 @@ func TestGetResources_MultipleCallsSucceed(t *testing.T) {
 		require.NotNil(t, res)
 	}
 }
+
+func TestParsePS(t *testing.T) {
+	out := "    1  0.0 10256 root     /sbin/init splash\n" +
+		"    2  0.0     0 root     [kthreadd]\n" +
+		"  812 12.5 204800 alice    -bash\n" +
+		"  913  1.5  4096 alice    /usr/bin/python3 server.py --port 8000\n" +
+		"garbage\n"
+	processes := parsePS(out)
+	require.Len(t, processes, 4)
+	assert.Equal(t, Process{PID: 1, User: "root", MemBytes: 10256 * 1024, Name: "init", Command: "/sbin/init splash"}, processes[0])
+	assert.Equal(t, "[kthreadd]", processes[1].Name)
+	assert.Equal(t, "bash", processes[2].Name)
+	assert.Equal(t, 12.5, processes[2].CPUPercent)
+	assert.Equal(t, "/usr/bin/python3 server.py --port 8000", processes[3].Command)
+}
+
+func TestGetProcesses_IncludesSelf(t *testing.T) {
+	processes, err := GetProcesses()
+	if err != nil {
+		t.Skipf("ps unavailable: %v", err)
+	}
+	pid := os.Getpid()
+	for _, p := range processes {
+		if p.PID == pid {
+			return
+		}
+	}
+	t.Errorf("process %d not listed", pid)
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package termreset
+
+import "golang.org/x/sys/unix"
+
+const (
+	getTermios = unix.TCGETS
+	setTermios = unix.TCSETS
+)
@@ -0,0 +1,60 @@
+package termreset
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// openPty opens a pseudo-terminal and returns its terminal end.
+func openPty(t *testing.T) *os.File {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	t.Cleanup(func() { ptmx.Close() })
+	require.NoError(t, unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0))
+	n, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { tty.Close() })
+	return tty
+}
+
+func TestRecover(t *testing.T) {
+	tty := openPty(t)
+	var out bytes.Buffer
+	assert.False(t, Broken(tty), "a new terminal is in cooked mode")
+	assert.False(t, Recover(tty, &out))
+	assert.Empty(t, out.String(), "a terminal that is fine is left alone")
+
+	// A full-screen program killed in raw mode
+	_, err := term.MakeRaw(int(tty.Fd()))
+	require.NoError(t, err)
+	assert.True(t, Broken(tty))
+	assert.True(t, Recover(tty, &out))
+	assert.Equal(t, fullReset, out.String())
+	assert.False(t, Broken(tty))
+
+	modes, err := unix.IoctlGetTermios(int(tty.Fd()), getTermios)
+	require.NoError(t, err)
+	assert.NotZero(t, modes.Lflag&unix.ISIG, "Ctrl+C interrupts again")
+	assert.NotZero(t, modes.Iflag&unix.ICRNL, "Enter ends a line again")
+}
+
+func TestRunCommandRestoresModes(t *testing.T) {
+	tty := openPty(t)
+	_, err := term.MakeRaw(int(tty.Fd()))
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, RunCommand(nil, tty, &stdout, &stderr), stderr.String())
+	assert.False(t, Broken(tty))
+}
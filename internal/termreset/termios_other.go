@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package termreset
+
+// The modes of the console are not read elsewhere; the escape sequences
+// still restore terminals that understand them, such as Windows Terminal.
+
+func broken(fd int) bool { return false }
+
+func makeSane(fd int) error { return nil }
@@ -0,0 +1,33 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package termreset
+
+import "golang.org/x/sys/unix"
+
+// broken reports whether the terminal fd has line editing or echo off, or
+// does not turn newlines into line breaks.
+func broken(fd int) bool {
+	modes, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return false
+	}
+	return modes.Lflag&(unix.ICANON|unix.ECHO) != unix.ICANON|unix.ECHO || modes.Oflag&unix.OPOST == 0
+}
+
+// makeSane turns back on what raw mode turns off, as stty sane does, and
+// leaves the rest, such as the control characters and flow control, as the
+// user set it.
+func makeSane(fd int) error {
+	modes, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return err
+	}
+	modes.Iflag |= unix.ICRNL | unix.BRKINT
+	modes.Iflag &^= unix.INLCR | unix.IGNCR
+	modes.Oflag |= unix.OPOST | unix.ONLCR
+	modes.Lflag |= unix.ICANON | unix.ECHO | unix.ECHOE | unix.ECHOK | unix.ISIG | unix.IEXTEN
+	modes.Lflag &^= unix.ECHONL
+	modes.Cc[unix.VMIN] = 1
+	modes.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, setTermios, modes)
+}
@@ -0,0 +1,122 @@
+// Package termreset puts the terminal back in a usable state after a
+// full-screen program left it broken, because it crashed or was killed: in
+// raw mode, in its alternate screen, reporting the mouse or with its colors
+// still set. It implements "bish fix-terminal".
+package termreset
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+const (
+	// mouseOff turns off the mouse reporting modes, X10 to SGR, and focus
+	// reporting.
+	mouseOff = "\x1b[?9l\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1005l\x1b[?1006l\x1b[?1015l\x1b[?1004l"
+	// softReset undoes what a program may leave behind, and changes nothing
+	// on a terminal that is fine: colors and styles are reset, the cursor is
+	// shown, the mouse is no longer reported, and the cursor keys and keypad
+	// send their usual sequences.
+	softReset = "\x1b[0m\x1b[?25h" + mouseOff + "\x1b[?1l\x1b>"
+	// fullReset also leaves the alternate screen, which would move the
+	// cursor on a terminal that is not in it, and resets the scrolling
+	// region, the character set, insert mode and line wrapping.
+	fullReset = "\x1b[?1049l" + softReset + "\x1b[r\x1b(B\x1b[4l\x1b[?7h"
+	// hardReset is the full reset of the terminal, as reset(1) does it,
+	// which also clears the screen.
+	hardReset = "\x1bc"
+)
+
+// Sanitize undoes, on out if it is a terminal, what a program that ran
+// before the prompt may have left on without breaking the terminal, such as
+// mouse reporting or colors. It is written before every prompt.
+func Sanitize(out *os.File) {
+	if term.IsTerminal(int(out.Fd())) {
+		_, _ = io.WriteString(out, softReset)
+	}
+}
+
+// Broken reports whether the terminal tty is in raw mode, without line
+// editing, echo or output processing, which the shell never leaves it in
+// between commands. A program that did so did not exit cleanly.
+func Broken(tty *os.File) bool {
+	fd := int(tty.Fd())
+	return term.IsTerminal(fd) && broken(fd)
+}
+
+// Recover restores the terminal tty if a program left it Broken, turning its
+// line editing and echo back on and writing the full reset to out, and
+// reports whether it did.
+func Recover(tty *os.File, out io.Writer) bool {
+	if !Broken(tty) {
+		return false
+	}
+	_ = makeSane(int(tty.Fd()))
+	_, _ = io.WriteString(out, fullReset)
+	return true
+}
+
+// Terminal returns the terminal of the process: stdin if it is one, or
+// /dev/tty. It is nil if there is none.
+func Terminal() *os.File {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return os.Stdin
+	}
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		return tty
+	}
+	return nil
+}
+
+// RunCommand implements "bish fix-terminal", which restores tty whatever
+// state it is in, and returns its exit code.
+func RunCommand(args []string, tty *os.File, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fix-terminal", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	hard := flags.Bool("hard", false, "also reset the terminal itself, which clears the screen, as reset does")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: bish fix-terminal [--hard]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Restores a terminal a program left broken: turns line editing and echo back on,")
+		fmt.Fprintln(stderr, "leaves the alternate screen, stops mouse reporting and resets colors.")
+		fmt.Fprintln(stderr, "If Enter does not run it, type Ctrl+J instead.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	if tty == nil {
+		fmt.Fprintln(stderr, "bish: fix-terminal: not connected to a terminal")
+		return 1
+	}
+
+	if fd := int(tty.Fd()); term.IsTerminal(fd) {
+		if err := makeSane(fd); err != nil {
+			fmt.Fprintf(stderr, "bish: fix-terminal: %v\n", err)
+			return 1
+		}
+	}
+	sequence := fullReset
+	if *hard {
+		sequence = hardReset + fullReset
+	}
+	if _, err := io.WriteString(tty, sequence); err != nil {
+		fmt.Fprintf(stderr, "bish: fix-terminal: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Terminal restored.")
+	return 0
+}
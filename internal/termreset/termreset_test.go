@@ -0,0 +1,53 @@
+package termreset
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand runs fix-terminal with a pipe as its terminal and returns its
+// exit code and what it wrote to the terminal.
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(args, w, &stdout, &stderr)
+	require.NoError(t, w.Close())
+	written, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return code, string(written), stdout.String() + stderr.String()
+}
+
+func TestRunCommand(t *testing.T) {
+	code, written, output := runCommand(t)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, fullReset, written)
+	assert.Contains(t, written, "\x1b[?1049l", "the alternate screen is left")
+	assert.Contains(t, written, "\x1b[?1000l", "the mouse is no longer reported")
+	assert.Contains(t, written, "\x1b[0m", "colors are reset")
+	assert.Equal(t, "Terminal restored.\n", output)
+
+	code, written, _ = runCommand(t, "--hard")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, hardReset+fullReset, written)
+
+	code, _, _ = runCommand(t, "extra")
+	assert.Equal(t, 2, code)
+}
+
+func TestRunCommandWithoutTerminal(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, RunCommand(nil, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "not connected to a terminal")
+}
+
+func TestSoftResetKeepsScreen(t *testing.T) {
+	assert.NotContains(t, softReset, "\x1b[?1049l", "leaving the alternate screen moves the cursor")
+	assert.NotContains(t, softReset, hardReset)
+}
@@ -0,0 +1,103 @@
+package ticket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshInterval is how often a ticket is read again, as its title
+	// rarely changes while the branch is worked on.
+	refreshInterval = 10 * time.Minute
+	// retryInterval is how long to wait after a failed read, twice as long
+	// after each further failure up to maxFailures.
+	retryInterval = 30 * time.Second
+	maxFailures   = 4
+	// requestTimeout bounds each read of a ticket.
+	requestTimeout = 15 * time.Second
+)
+
+// Target is a ticket to read and the provider that reads it.
+type Target struct {
+	Ref Ref
+	// Source is where the ticket is tracked, e.g. github.com/owner/name
+	Source   string
+	Provider Provider
+}
+
+func (t Target) key() string {
+	return t.Source + " " + t.Ref.String()
+}
+
+// cached is what is known about a ticket.
+type cached struct {
+	ticket    Ticket
+	found     bool
+	fetchedAt time.Time
+	fetching  bool
+	failures  int
+}
+
+// Cache keeps the tickets of the branches the shell is on, reading them in
+// the background so that the prompt never waits for them.
+type Cache struct {
+	mu      sync.Mutex
+	tickets map[string]*cached
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewCache creates a cache that knows no tickets yet.
+func NewCache() *Cache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Cache{tickets: make(map[string]*cached), ctx: ctx, cancel: cancel}
+}
+
+// DefaultCache holds the tickets of the interactive shell.
+var DefaultCache = NewCache()
+
+// Lookup returns the last read ticket of t without waiting, and false if it
+// has not been read yet. If it was last read long enough before now, it is
+// read again in the background.
+func (c *Cache) Lookup(t Target, now time.Time) (Ticket, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tickets[t.key()]
+	if !ok {
+		entry = &cached{}
+		c.tickets[t.key()] = entry
+	}
+	interval := refreshInterval
+	if entry.failures > 0 {
+		interval = retryInterval << (entry.failures - 1)
+	}
+	if !entry.fetching && !now.Before(entry.fetchedAt.Add(interval)) {
+		entry.fetching = true
+		go c.fetch(t, entry)
+	}
+	return entry.ticket, entry.found
+}
+
+// fetch reads the ticket of t into entry.
+func (c *Cache) fetch(t Target, entry *cached) {
+	ctx, cancel := context.WithTimeout(c.ctx, requestTimeout)
+	defer cancel()
+	ticket, err := t.Provider.Fetch(ctx, t.Ref)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.fetching = false
+	entry.fetchedAt = time.Now()
+	if err != nil {
+		entry.failures = min(entry.failures+1, maxFailures)
+		return
+	}
+	entry.failures = 0
+	entry.ticket, entry.found = ticket, true
+}
+
+// Stop stops the reads in progress and keeps new ones from succeeding.
+func (c *Cache) Stop() {
+	c.cancel()
+}
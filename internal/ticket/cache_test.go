@@ -0,0 +1,79 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider returns the ticket it is given, counting the reads.
+type fakeProvider struct {
+	mu      sync.Mutex
+	title   string
+	err     error
+	fetches int
+}
+
+func (f *fakeProvider) Fetch(ctx context.Context, ref Ref) (Ticket, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	return Ticket{Ref: ref, Title: f.title}, f.err
+}
+
+func (f *fakeProvider) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetches
+}
+
+func TestCache(t *testing.T) {
+	provider := &fakeProvider{title: "Add login"}
+	target := Target{Ref: Ref{Kind: KindJira, Key: "PROJ-1"}, Source: "jira", Provider: provider}
+	c := NewCache()
+	defer c.Stop()
+
+	now := time.Now()
+	_, ok := c.Lookup(target, now)
+	assert.False(t, ok, "the first lookup does not wait for the read")
+
+	var ticket Ticket
+	assert.Eventually(t, func() bool {
+		ticket, ok = c.Lookup(target, now)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "Add login", ticket.Title)
+	assert.Equal(t, 1, provider.count(), "a fresh ticket is not read again")
+
+	c.Lookup(target, now.Add(refreshInterval+time.Second))
+	assert.Eventually(t, func() bool { return provider.count() == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestCacheKeepsTicketAfterFailure(t *testing.T) {
+	provider := &fakeProvider{title: "Add login"}
+	target := Target{Ref: Ref{Kind: KindJira, Key: "PROJ-1"}, Source: "jira", Provider: provider}
+	c := NewCache()
+	defer c.Stop()
+
+	c.Lookup(target, time.Now())
+	assert.Eventually(t, func() bool {
+		_, ok := c.Lookup(target, time.Now())
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	provider.mu.Lock()
+	provider.err = errors.New("offline")
+	provider.mu.Unlock()
+	c.Lookup(target, time.Now().Add(refreshInterval+time.Second))
+	assert.Eventually(t, func() bool { return provider.count() == 2 }, time.Second, 5*time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	ticket, ok := c.Lookup(target, time.Now())
+	assert.True(t, ok)
+	assert.Equal(t, "Add login", ticket.Title)
+	assert.Equal(t, 2, provider.count(), "a failed read waits before it is retried")
+}
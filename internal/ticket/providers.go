@@ -0,0 +1,211 @@
+package ticket
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/robottwo/bishop/internal/ci"
+)
+
+// maxResponseBytes bounds what is read of a reply.
+const maxResponseBytes = 4 << 20
+
+// Settings of BISH_TICKET_PROVIDER.
+const (
+	ProviderOff    = "off"
+	ProviderAuto   = "auto"
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderJira   = "jira"
+)
+
+// ProviderFor returns the provider that reads ref, given the setting of
+// BISH_TICKET_PROVIDER and the remote of the repository, along with where
+// the ticket is tracked, e.g. github.com/owner/name. Jira keys are read
+// from $BISH_JIRA_URL with the token in $JIRA_API_TOKEN, issues of GitHub
+// repositories with gh and those of GitLab ones with $GITLAB_TOKEN, which
+// is only sent to the hosts ci.IsGitLabHost allows. Auto picks whichever of
+// them can read ref.
+func ProviderFor(setting string, ref Ref, remote ci.Remote, getenv func(string) string, lookPath func(string) (string, error)) (Provider, string, bool) {
+	if setting == ProviderOff || setting == "" {
+		return nil, "", false
+	}
+	if ref.Kind == KindJira {
+		baseURL := strings.TrimRight(getenv("BISH_JIRA_URL"), "/")
+		if (setting != ProviderAuto && setting != ProviderJira) || baseURL == "" {
+			return nil, "", false
+		}
+		return &jira{baseURL: baseURL, email: getenv("JIRA_EMAIL"), token: getenv("JIRA_API_TOKEN")}, baseURL, true
+	}
+	if remote.Host == "" {
+		return nil, "", false
+	}
+	source := remote.Host + "/" + remote.Path
+	switch {
+	case setting == ProviderGitHub || (setting == ProviderAuto && remote.Host == "github.com"):
+		if _, err := lookPath("gh"); err != nil {
+			return nil, "", false
+		}
+		return &github{repo: remote.Path, host: remote.Host, run: runCommand}, source, true
+	case (setting == ProviderGitLab || setting == ProviderAuto) && ci.IsGitLabHost(remote.Host, getenv):
+		token := getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, "", false
+		}
+		return &gitlab{baseURL: "https://" + remote.Host, project: remote.Path, token: token}, source, true
+	}
+	return nil, "", false
+}
+
+// commandFunc runs a program and returns its output.
+type commandFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand runs name, with what it printed on stderr as the error.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// github reads the issues of a GitHub repository with gh.
+type github struct {
+	// repo is owner/name
+	repo string
+	host string
+	run  commandFunc
+}
+
+// githubIssue is an issue as gh issue view --json prints it.
+type githubIssue struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+	URL   string `json:"url"`
+}
+
+func (g *github) Fetch(ctx context.Context, ref Ref) (Ticket, error) {
+	repo := g.repo
+	if g.host != "" && g.host != "github.com" {
+		repo = g.host + "/" + repo
+	}
+	out, err := g.run(ctx, "gh", "issue", "view", ref.Key, "--repo", repo, "--json", "title,body,state,url")
+	if err != nil {
+		return Ticket{}, fmt.Errorf("gh issue view: %w", err)
+	}
+	var issue githubIssue
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("gh issue view: %w", err)
+	}
+	return Ticket{Ref: ref, Title: issue.Title, Body: issue.Body, State: strings.ToLower(issue.State), URL: issue.URL}, nil
+}
+
+// gitlab reads the issues of a GitLab project through the API of the host.
+type gitlab struct {
+	baseURL string
+	// project is the path of the project, e.g. group/name
+	project string
+	token   string
+	client  *http.Client
+}
+
+// gitlabIssue is what the API returns for an issue.
+type gitlabIssue struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	WebURL      string `json:"web_url"`
+}
+
+func (g *gitlab) Fetch(ctx context.Context, ref Ref) (Ticket, error) {
+	endpoint := g.baseURL + "/api/v4/projects/" + url.PathEscape(g.project) + "/issues/" + url.PathEscape(ref.Key)
+	var issue gitlabIssue
+	if err := getJSON(ctx, g.client, endpoint, map[string]string{"PRIVATE-TOKEN": g.token}, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("gitlab: %w", err)
+	}
+	return Ticket{Ref: ref, Title: issue.Title, Body: issue.Description, State: issue.State, URL: issue.WebURL}, nil
+}
+
+// jira reads issues through the REST API of a Jira site. Jira Cloud takes
+// the email of the account with an API token, and Jira Server and Data
+// Center a personal access token alone.
+type jira struct {
+	baseURL string
+	email   string
+	token   string
+	client  *http.Client
+}
+
+// jiraIssue is what the API returns for an issue with the fields asked.
+type jiraIssue struct {
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Status      struct {
+			Name string `json:"name"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+func (j *jira) Fetch(ctx context.Context, ref Ref) (Ticket, error) {
+	endpoint := j.baseURL + "/rest/api/2/issue/" + url.PathEscape(ref.Key) + "?fields=summary,description,status"
+	headers := map[string]string{}
+	switch {
+	case j.token != "" && j.email != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(j.email+":"+j.token))
+	case j.token != "":
+		headers["Authorization"] = "Bearer " + j.token
+	}
+	var issue jiraIssue
+	if err := getJSON(ctx, j.client, endpoint, headers, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("jira: %w", err)
+	}
+	return Ticket{
+		Ref:   ref,
+		Title: issue.Fields.Summary,
+		Body:  issue.Fields.Description,
+		State: strings.ToLower(issue.Fields.Status.Name),
+		URL:   j.baseURL + "/browse/" + ref.Key,
+	}, nil
+}
+
+// getJSON requests endpoint with headers and decodes the JSON reply into v.
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
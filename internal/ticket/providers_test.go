@@ -0,0 +1,112 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/robottwo/bishop/internal/ci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderFor(t *testing.T) {
+	env := map[string]string{"BISH_JIRA_URL": "https://example.atlassian.net/", "GITLAB_TOKEN": "secret", "BISH_GITLAB_HOSTS": "gitlab.example.com"}
+	getenv := func(name string) string { return env[name] }
+	withGH := func(string) (string, error) { return "/usr/bin/gh", nil }
+	withoutGH := func(string) (string, error) { return "", errors.New("not found") }
+	jiraRef := Ref{Kind: KindJira, Key: "PROJ-1"}
+	issueRef := Ref{Kind: KindIssue, Key: "5"}
+	githubRemote := ci.Remote{Host: "github.com", Path: "o/n"}
+	gitlabRemote := ci.Remote{Host: "gitlab.example.com", Path: "g/n"}
+
+	_, _, ok := ProviderFor(ProviderOff, jiraRef, githubRemote, getenv, withGH)
+	assert.False(t, ok)
+
+	provider, source, ok := ProviderFor(ProviderAuto, jiraRef, githubRemote, getenv, withGH)
+	require.True(t, ok)
+	assert.IsType(t, &jira{}, provider)
+	assert.Equal(t, "https://example.atlassian.net", source)
+	_, _, ok = ProviderFor(ProviderGitHub, jiraRef, githubRemote, getenv, withGH)
+	assert.False(t, ok)
+
+	provider, source, ok = ProviderFor(ProviderAuto, issueRef, githubRemote, getenv, withGH)
+	require.True(t, ok)
+	assert.IsType(t, &github{}, provider)
+	assert.Equal(t, "github.com/o/n", source)
+	_, _, ok = ProviderFor(ProviderAuto, issueRef, githubRemote, getenv, withoutGH)
+	assert.False(t, ok)
+
+	provider, _, ok = ProviderFor(ProviderAuto, issueRef, gitlabRemote, getenv, withGH)
+	require.True(t, ok)
+	assert.IsType(t, &gitlab{}, provider)
+	_, _, ok = ProviderFor(ProviderJira, issueRef, gitlabRemote, getenv, withGH)
+	assert.False(t, ok)
+	// The token is only sent to the hosts listed
+	for _, setting := range []string{ProviderAuto, ProviderGitLab} {
+		_, _, ok = ProviderFor(setting, issueRef, ci.Remote{Host: "gitlab.attacker.test", Path: "g/n"}, getenv, withGH)
+		assert.False(t, ok)
+	}
+	_, _, ok = ProviderFor(ProviderAuto, issueRef, ci.Remote{}, getenv, withGH)
+	assert.False(t, ok)
+}
+
+func TestGitHubFetch(t *testing.T) {
+	var args []string
+	g := &github{repo: "o/n", run: func(ctx context.Context, name string, a ...string) ([]byte, error) {
+		args = append([]string{name}, a...)
+		return []byte(`{"title":"Fix crash","body":"It crashes.","state":"OPEN","url":"https://github.com/o/n/issues/5"}`), nil
+	}}
+	ticket, err := g.Fetch(context.Background(), Ref{Kind: KindIssue, Key: "5"})
+	require.NoError(t, err)
+	assert.Equal(t, "gh issue view 5 --repo o/n --json title,body,state,url", strings.Join(args, " "))
+	assert.Equal(t, Ticket{Ref: Ref{Kind: KindIssue, Key: "5"}, Title: "Fix crash", Body: "It crashes.", State: "open", URL: "https://github.com/o/n/issues/5"}, ticket)
+}
+
+func TestGitLabFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" || r.URL.RequestURI() != "/api/v4/projects/g%2Fn/issues/5" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"title":"Fix crash","description":"It crashes.","state":"opened","web_url":"https://gitlab.example.com/g/n/-/issues/5"}`))
+	}))
+	defer server.Close()
+	g := &gitlab{baseURL: server.URL, project: "g/n", token: "secret", client: server.Client()}
+
+	ticket, err := g.Fetch(context.Background(), Ref{Kind: KindIssue, Key: "5"})
+	require.NoError(t, err)
+	assert.Equal(t, "Fix crash", ticket.Title)
+	assert.Equal(t, "It crashes.", ticket.Body)
+	assert.Equal(t, "opened", ticket.State)
+
+	_, err = g.Fetch(context.Background(), Ref{Kind: KindIssue, Key: "6"})
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestJiraFetch(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/rest/api/2/issue/PROJ-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"fields":{"summary":"Add login","description":"Users sign in.","status":{"name":"In Progress"}}}`))
+	}))
+	defer server.Close()
+	j := &jira{baseURL: server.URL, email: "me@example.com", token: "secret", client: server.Client()}
+
+	ticket, err := j.Fetch(context.Background(), Ref{Kind: KindJira, Key: "PROJ-1"})
+	require.NoError(t, err)
+	assert.Equal(t, Ticket{Ref: Ref{Kind: KindJira, Key: "PROJ-1"}, Title: "Add login", Body: "Users sign in.", State: "in progress", URL: server.URL + "/browse/PROJ-1"}, ticket)
+	assert.Equal(t, "Basic bWVAZXhhbXBsZS5jb206c2VjcmV0", authorization)
+
+	j.email = ""
+	_, err = j.Fetch(context.Background(), Ref{Kind: KindJira, Key: "PROJ-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", authorization)
+}
@@ -0,0 +1,89 @@
+// Package ticket finds the ticket a branch is for, such as PROJ-1234 in
+// feature/PROJ-1234-login or issue 567 in 567-fix-crash, and reads it from
+// where it is tracked: GitHub or GitLab issues, or Jira.
+package ticket
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of reference to a ticket.
+type Kind string
+
+const (
+	// KindJira is a Jira key such as PROJ-1234.
+	KindJira Kind = "jira"
+	// KindIssue is the number of an issue of the repository, such as 567.
+	KindIssue Kind = "issue"
+)
+
+// Ref is a reference to a ticket.
+type Ref struct {
+	Kind Kind
+	Key  string
+}
+
+// String returns the reference as it is usually written: PROJ-1234 or #567.
+func (r Ref) String() string {
+	if r.Kind == KindIssue {
+		return "#" + r.Key
+	}
+	return r.Key
+}
+
+// Ticket is what the shell shows of a ticket.
+type Ticket struct {
+	Ref   Ref
+	Title string
+	// Body is the description of the ticket
+	Body  string
+	State string
+	URL   string
+}
+
+// Provider reads tickets.
+type Provider interface {
+	Fetch(ctx context.Context, ref Ref) (Ticket, error)
+}
+
+var (
+	// jiraKey matches keys as Jira writes them in the branches it creates,
+	// e.g. PROJ-1234-add-login; the upper case keeps words such as fix-2
+	// from being taken for keys
+	jiraKey = regexp.MustCompile(`(?:^|[^A-Za-z0-9])([A-Z][A-Z0-9]+-[0-9]+)(?:$|[^A-Za-z0-9])`)
+	// issueNumber matches #567, issue-567, issues/567 and gh-567
+	issueNumber = regexp.MustCompile(`(?i)(?:#|(?:^|[/_-])(?:issues?|gh|gl)[/_-]?)([0-9]+)(?:$|[^0-9])`)
+	// leadingNumber matches branches that start with the number, as GitHub
+	// names the branches it creates for issues, e.g. 567-fix-crash or
+	// feature/567-fix-crash
+	leadingNumber = regexp.MustCompile(`(?:^|/)([0-9]+)[-_][A-Za-z]`)
+)
+
+// Parse returns the ticket branch refers to, if any. Jira keys are
+// preferred over issue numbers.
+func Parse(branch string) (Ref, bool) {
+	if m := jiraKey.FindStringSubmatch(branch); m != nil {
+		return Ref{Kind: KindJira, Key: m[1]}, true
+	}
+	for _, re := range []*regexp.Regexp{issueNumber, leadingNumber} {
+		if m := re.FindStringSubmatch(branch); m != nil && strings.TrimLeft(m[1], "0") != "" {
+			return Ref{Kind: KindIssue, Key: strings.TrimLeft(m[1], "0")}, true
+		}
+	}
+	return Ref{}, false
+}
+
+// MaxSummaryWidth is how many characters of a ticket the border shows.
+const MaxSummaryWidth = 32
+
+// Summary returns the ticket as the border status shows it: the reference
+// and as much of the title as fits in MaxSummaryWidth.
+func (t Ticket) Summary() string {
+	summary := []rune(strings.Join(append([]string{t.Ref.String()}, strings.Fields(t.Title)...), " "))
+	if len(summary) > MaxSummaryWidth {
+		return string(summary[:MaxSummaryWidth-1]) + "…"
+	}
+	return string(summary)
+}
@@ -0,0 +1,48 @@
+package ticket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   Ref
+		ok     bool
+	}{
+		{"PROJ-1234-add-login", Ref{Kind: KindJira, Key: "PROJ-1234"}, true},
+		{"feature/PROJ-1234", Ref{Kind: KindJira, Key: "PROJ-1234"}, true},
+		{"bugfix/AB2-7_crash", Ref{Kind: KindJira, Key: "AB2-7"}, true},
+		{"567-fix-crash", Ref{Kind: KindIssue, Key: "567"}, true},
+		{"alice/567-fix-crash", Ref{Kind: KindIssue, Key: "567"}, true},
+		{"fix-#567", Ref{Kind: KindIssue, Key: "567"}, true},
+		{"issue-42", Ref{Kind: KindIssue, Key: "42"}, true},
+		{"issues/42", Ref{Kind: KindIssue, Key: "42"}, true},
+		{"gh-042-docs", Ref{Kind: KindIssue, Key: "42"}, true},
+		{"main", Ref{}, false},
+		{"fix-2-things", Ref{}, false},
+		{"release/2024-05", Ref{}, false},
+		{"v2", Ref{}, false},
+		{"issue-0", Ref{}, false},
+		{"xPROJ-12", Ref{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			ref, ok := Parse(tt.branch)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, ref)
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, "#567", Ticket{Ref: Ref{Kind: KindIssue, Key: "567"}}.Summary())
+	assert.Equal(t, "PROJ-1 Add login", Ticket{Ref: Ref{Kind: KindJira, Key: "PROJ-1"}, Title: "Add\n  login "}.Summary())
+
+	long := Ticket{Ref: Ref{Kind: KindJira, Key: "PROJ-1234"}, Title: "Let users sign in with their company account"}
+	summary := []rune(long.Summary())
+	assert.Len(t, summary, MaxSummaryWidth)
+	assert.Equal(t, "PROJ-1234 Let users sign in wit…", string(summary))
+}
@@ -0,0 +1,39 @@
+package timer
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/robottwo/bishop/internal/notify"
+)
+
+// commandTimeout is how long the command of a timer may run.
+const commandTimeout = 10 * time.Minute
+
+// Alert lets the user know t has ended with a desktop notification, when
+// the system has a way to show one, and runs the command of t. It returns
+// the error of the command.
+func Alert(t Timer) error {
+	title, body := "Timer done", t.Label
+	if body == "" {
+		body = FormatRemaining(t.Ends.Sub(t.Started)) + " are up"
+	}
+	if t.IsBreak {
+		title, body = "Break is over", "Back to work"
+	}
+	notify.Send(title, body)
+
+	if t.Command == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", t.Command)
+	cmd.Dir = t.Dir
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", t.Command, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package timer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: timer <duration> [label...] [-q | --quiet] [-r | --run <command>]\n" +
+	"       timer pomodoro [label...] [-q | --quiet] [-r | --run <command>]\n" +
+	"       timer [stop]"
+
+// NewTimerCommandHandler creates an ExecHandler for the timer builtin, which
+// starts a countdown on c, such as timer 25m "review PR", shows the time
+// left and stops it. timer pomodoro starts 25 minutes followed by a 5 minute
+// break. --quiet keeps quiet mode on until the timer ends and --run runs a
+// command when it does.
+func NewTimerCommandHandler(c *Clock) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "timer" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			args = args[1:]
+			if len(args) == 0 {
+				c.show(hc.Stdout, time.Now())
+				return nil
+			}
+			switch args[0] {
+			case "stop", "-s", "--stop":
+				if len(args) > 1 {
+					fmt.Fprintln(hc.Stderr, usage)
+					return interp.NewExitStatus(2)
+				}
+				stopped, ok := c.Stop()
+				if !ok {
+					fmt.Fprintln(hc.Stderr, "timer: no timer running")
+					return interp.NewExitStatus(1)
+				}
+				fmt.Fprintf(hc.Stdout, "Stopped %s with %s left\n", stopped.Name(), FormatRemaining(stopped.Remaining(time.Now())))
+				return nil
+			case "-h", "--help":
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+			}
+
+			t, err := parseArgs(args, time.Now())
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "timer: %v\n%s\n", err, usage)
+				return interp.NewExitStatus(2)
+			}
+			t.Dir = hc.Dir
+			if replaced, ok := c.Start(t); ok {
+				fmt.Fprintf(hc.Stdout, "Stopped %s\n", replaced.Name())
+			}
+			message := fmt.Sprintf("Started %s: %s, until %s", t.Name(), FormatRemaining(t.Ends.Sub(t.Started)), t.Ends.Format("15:04"))
+			if t.Break > 0 {
+				message += fmt.Sprintf(", then a %s break", FormatRemaining(t.Break))
+			}
+			if t.Quiet {
+				message += ", in quiet mode"
+			}
+			fmt.Fprintln(hc.Stdout, message)
+			return nil
+		}
+	}
+}
+
+// show prints the running timer and the time it has left.
+func (c *Clock) show(w io.Writer, now time.Time) {
+	t, ok := c.Current()
+	if !ok {
+		fmt.Fprintln(w, "No timer running")
+		return
+	}
+	fmt.Fprintf(w, "%s: %s left\n", t.Name(), FormatRemaining(t.Remaining(now)))
+}
+
+// parseArgs parses the arguments of timer into a timer starting at now.
+func parseArgs(args []string, now time.Time) (Timer, error) {
+	t := Timer{Started: now}
+	var length time.Duration
+	var label []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-q" || arg == "--quiet":
+			t.Quiet = true
+		case arg == "-r" || arg == "--run":
+			if i+1 == len(args) || args[i+1] == "" {
+				return Timer{}, fmt.Errorf("%s needs a command", arg)
+			}
+			i++
+			t.Command = args[i]
+		case strings.HasPrefix(arg, "--run="):
+			t.Command = strings.TrimPrefix(arg, "--run=")
+		case arg == "--":
+			label = append(label, args[i+1:]...)
+			i = len(args)
+		case length == 0 && arg == "pomodoro":
+			length, t.Break = PomodoroLength, PomodoroBreak
+		case length == 0:
+			parsed, err := ParseLength(arg)
+			if err != nil {
+				return Timer{}, err
+			}
+			length = parsed
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			return Timer{}, fmt.Errorf("unknown option %s", arg)
+		default:
+			label = append(label, arg)
+		}
+	}
+	if length == 0 {
+		return Timer{}, fmt.Errorf("missing duration")
+	}
+	t.Label = strings.Join(label, " ")
+	t.Ends = now.Add(length)
+	return t, nil
+}
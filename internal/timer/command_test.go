@@ -0,0 +1,85 @@
+package timer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runTimer(t *testing.T, c *Clock, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.Dir("/tmp"),
+		interp.ExecHandlers(NewTimerCommandHandler(c)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestTimerCommand(t *testing.T) {
+	c := NewClock()
+	defer c.Stop()
+
+	out, _, err := runTimer(t, c, `timer`)
+	require.NoError(t, err)
+	assert.Equal(t, "No timer running\n", out)
+
+	out, _, err = runTimer(t, c, `timer 25m "review PR" --quiet --run 'echo done'`)
+	require.NoError(t, err)
+	assert.Regexp(t, `^Started "review PR": 25:00, until \d\d:\d\d, in quiet mode\n$`, out)
+	current, ok := c.Current()
+	require.True(t, ok)
+	assert.Equal(t, "review PR", current.Label)
+	assert.Equal(t, "echo done", current.Command)
+	assert.Equal(t, "/tmp", current.Dir)
+	assert.True(t, current.Quiet)
+
+	out, _, err = runTimer(t, c, `timer`)
+	require.NoError(t, err)
+	assert.Regexp(t, `^"review PR": 2[45]:\d\d left\n$`, out)
+
+	out, _, err = runTimer(t, c, `timer pomodoro`)
+	require.NoError(t, err)
+	assert.Regexp(t, `^Stopped "review PR"\nStarted timer: 25:00, until \d\d:\d\d, then a 5:00 break\n$`, out)
+
+	out, _, err = runTimer(t, c, `timer stop`)
+	require.NoError(t, err)
+	assert.Regexp(t, `^Stopped timer with 2[45]:\d\d left\n$`, out)
+
+	_, stderr, err := runTimer(t, c, `timer stop`)
+	assert.Error(t, err)
+	assert.Equal(t, "timer: no timer running\n", stderr)
+}
+
+func TestTimerCommandErrors(t *testing.T) {
+	c := NewClock()
+	for _, script := range []string{`timer soon`, `timer 5m --run`, `timer 5m --bogus`, `timer --quiet`} {
+		_, stderr, err := runTimer(t, c, script)
+		assert.Error(t, err, script)
+		assert.Contains(t, stderr, "Usage: timer", script)
+	}
+	_, ok := c.Current()
+	assert.False(t, ok)
+}
+
+func TestParseArgs(t *testing.T) {
+	now := time.Now()
+	timer, err := parseArgs([]string{"10", "write", "docs", "--", "--draft"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "write docs --draft", timer.Label)
+	assert.Equal(t, now.Add(10*time.Minute), timer.Ends)
+}
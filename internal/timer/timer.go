@@ -0,0 +1,178 @@
+// Package timer implements the countdown timers started with the timer
+// builtin: one runs at a time, shown in the border status, and when it ends
+// the user is notified and its command, if any, runs. A pomodoro is a timer
+// followed by a break.
+package timer
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// PomodoroLength and PomodoroBreak are the lengths of a pomodoro and of
+	// the break that follows it.
+	PomodoroLength = 25 * time.Minute
+	PomodoroBreak  = 5 * time.Minute
+	// maxLength is the longest timer that can be started.
+	maxLength = 24 * time.Hour
+)
+
+// Timer is a countdown.
+type Timer struct {
+	Label   string
+	Started time.Time
+	Ends    time.Time
+	// Command runs when the timer ends, from Dir
+	Command string
+	Dir     string
+	// Quiet keeps quiet mode on while the timer runs
+	Quiet bool
+	// Break is the length of the break timer that starts when this one ends
+	Break time.Duration
+	// IsBreak is set for the break that follows a pomodoro
+	IsBreak bool
+	// Stopped is set for a timer stopped before its end
+	Stopped bool
+}
+
+// Remaining returns the time left at now, zero once the timer has ended.
+func (t Timer) Remaining(now time.Time) time.Duration {
+	return max(0, t.Ends.Sub(now))
+}
+
+// Name returns how messages call the timer: its quoted label, "timer" if it
+// has none, or "break".
+func (t Timer) Name() string {
+	switch {
+	case t.IsBreak:
+		return "break"
+	case t.Label != "":
+		return strconv.Quote(t.Label)
+	}
+	return "timer"
+}
+
+// Clock holds the timer running in the session and the ones that ended
+// since the shell last looked.
+type Clock struct {
+	mu      sync.Mutex
+	current *Timer
+	alarm   *time.Timer
+	ended   []Timer
+	// OnEnd is called, without the lock, when a timer reaches its end
+	OnEnd func(Timer)
+}
+
+// NewClock creates a clock without a timer.
+func NewClock() *Clock {
+	return &Clock{}
+}
+
+// DefaultClock holds the timer of the interactive shell.
+var DefaultClock = NewClock()
+
+// Start starts t, replacing and returning the timer that was running, if any.
+func (c *Clock) Start(t Timer) (Timer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	replaced, ok := c.stop()
+	c.start(t)
+	return replaced, ok
+}
+
+// start runs t until its end. c.mu must be held.
+func (c *Clock) start(t Timer) {
+	c.current = &t
+	c.alarm = time.AfterFunc(time.Until(t.Ends), func() { c.ring(&t) })
+}
+
+// ring ends t, if it is still the current timer, and starts its break.
+func (c *Clock) ring(t *Timer) {
+	c.mu.Lock()
+	if c.current != t {
+		c.mu.Unlock()
+		return
+	}
+	ended := *t
+	c.current, c.alarm = nil, nil
+	c.ended = append(c.ended, ended)
+	if ended.Break > 0 {
+		now := time.Now()
+		c.start(Timer{Label: "break", Started: now, Ends: now.Add(ended.Break), IsBreak: true})
+	}
+	onEnd := c.OnEnd
+	c.mu.Unlock()
+
+	if onEnd != nil {
+		onEnd(ended)
+	}
+}
+
+// Stop stops the running timer, if any, and returns it.
+func (c *Clock) Stop() (Timer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stop()
+}
+
+// stop stops the running timer. c.mu must be held.
+func (c *Clock) stop() (Timer, bool) {
+	if c.current == nil {
+		return Timer{}, false
+	}
+	c.alarm.Stop()
+	stopped := *c.current
+	stopped.Stopped = true
+	c.current, c.alarm = nil, nil
+	return stopped, true
+}
+
+// Current returns the running timer, if any.
+func (c *Clock) Current() (Timer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return Timer{}, false
+	}
+	return *c.current, true
+}
+
+// TakeEnded returns the timers that reached their end since it was last
+// called.
+func (c *Clock) TakeEnded() []Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ended := c.ended
+	c.ended = nil
+	return ended
+}
+
+// ParseLength parses the length of a timer: a duration such as "25m" or
+// "1h30m", or a number of minutes.
+func ParseLength(s string) (time.Duration, error) {
+	length, err := time.ParseDuration(s)
+	if err != nil {
+		minutes, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		length = time.Duration(minutes) * time.Minute
+	}
+	if length < time.Second || length > maxLength {
+		return 0, fmt.Errorf("duration must be between 1s and 24h")
+	}
+	return length, nil
+}
+
+// FormatRemaining formats a time left as a countdown, e.g. 24:13 or
+// 1:05:00, rounding up so that it reaches 0:00 only at the end.
+func FormatRemaining(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,72 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLength(t *testing.T) {
+	for arg, want := range map[string]time.Duration{"25m": 25 * time.Minute, "1h30m": 90 * time.Minute, "10": 10 * time.Minute, "90s": 90 * time.Second} {
+		length, err := ParseLength(arg)
+		require.NoError(t, err, arg)
+		assert.Equal(t, want, length, arg)
+	}
+	for _, arg := range []string{"soon", "0", "-5m", "25h"} {
+		_, err := ParseLength(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	assert.Equal(t, "25:00", FormatRemaining(25*time.Minute))
+	assert.Equal(t, "0:01", FormatRemaining(300*time.Millisecond))
+	assert.Equal(t, "0:00", FormatRemaining(0))
+	assert.Equal(t, "1:05:00", FormatRemaining(65*time.Minute))
+}
+
+func TestClock(t *testing.T) {
+	c := NewClock()
+	ended := make(chan Timer, 2)
+	c.OnEnd = func(t Timer) { ended <- t }
+
+	now := time.Now()
+	_, replaced := c.Start(Timer{Label: "first", Started: now, Ends: now.Add(time.Hour)})
+	assert.False(t, replaced)
+	stopped, replaced := c.Start(Timer{Label: "review", Started: now, Ends: now.Add(20 * time.Millisecond), Break: 20 * time.Millisecond})
+	assert.True(t, replaced)
+	assert.Equal(t, "first", stopped.Label)
+	assert.True(t, stopped.Stopped)
+
+	// The timer ends, then its break
+	assert.Equal(t, "review", (<-ended).Label)
+	current, ok := c.Current()
+	require.True(t, ok)
+	assert.True(t, current.IsBreak)
+	assert.True(t, (<-ended).IsBreak)
+
+	taken := c.TakeEnded()
+	require.Len(t, taken, 2)
+	assert.Equal(t, "review", taken[0].Label)
+	assert.Empty(t, c.TakeEnded())
+	_, ok = c.Current()
+	assert.False(t, ok)
+}
+
+func TestClockStop(t *testing.T) {
+	c := NewClock()
+	c.OnEnd = func(Timer) { t.Error("a stopped timer must not end") }
+
+	_, ok := c.Stop()
+	assert.False(t, ok)
+
+	now := time.Now()
+	c.Start(Timer{Started: now, Ends: now.Add(20 * time.Millisecond)})
+	stopped, ok := c.Stop()
+	require.True(t, ok)
+	assert.Equal(t, "timer", stopped.Name())
+	time.Sleep(40 * time.Millisecond)
+	assert.Empty(t, c.TakeEnded())
+}
@@ -0,0 +1,74 @@
+package tldr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// updateTimeout bounds how long `tldr --update` may take to download the archive.
+const updateTimeout = 2 * time.Minute
+
+// NewTldrCommandHandler creates an ExecHandler for the tldr builtin:
+//
+//	tldr tar            show the page for tar
+//	tldr git commit     show the page for git-commit
+//	tldr --list         list available pages
+//	tldr --update       download the full page set
+//
+// Pages are read from cacheDir when present and from the embedded pages
+// otherwise, so lookups work offline.
+func NewTldrCommandHandler(cacheDir string) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "tldr" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			store := NewStore(cacheDir)
+
+			if len(args) == 1 {
+				fmt.Fprintln(hc.Stderr, "Usage: tldr [--list | --update] <command> [subcommand]")
+				return interp.NewExitStatus(1)
+			}
+
+			switch args[1] {
+			case "-h", "--help":
+				fmt.Fprintln(hc.Stdout, "Usage: tldr [--list | --update] <command> [subcommand]")
+				fmt.Fprintln(hc.Stdout, "Show example-driven help for a command from the tldr pages.")
+				return nil
+			case "-l", "--list":
+				fmt.Fprintln(hc.Stdout, strings.Join(store.List(), "\n"))
+				return nil
+			case "-u", "--update":
+				archiveURL := hc.Env.Get("BISH_TLDR_ARCHIVE_URL").String()
+				if archiveURL == "" {
+					archiveURL = DefaultArchiveURL
+				}
+				updateCtx, cancel := context.WithTimeout(ctx, updateTimeout)
+				defer cancel()
+
+				fmt.Fprintf(hc.Stdout, "Downloading tldr pages from %s...\n", archiveURL)
+				count, err := Update(updateCtx, http.DefaultClient, archiveURL, cacheDir)
+				if err != nil {
+					return fmt.Errorf("tldr: update failed: %w", err)
+				}
+				fmt.Fprintf(hc.Stdout, "Installed %d pages into %s\n", count, cacheDir)
+				return nil
+			}
+
+			page, ok := store.Lookup(PageName(args[1:]...))
+			if !ok {
+				fmt.Fprintf(hc.Stderr, "tldr: no page for %q; run 'tldr --update' to download all pages\n", strings.Join(args[1:], " "))
+				return interp.NewExitStatus(1)
+			}
+			fmt.Fprint(hc.Stdout, Render(page))
+			return nil
+		}
+	}
+}
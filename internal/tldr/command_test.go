@@ -0,0 +1,75 @@
+package tldr
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runTldr(t *testing.T, cacheDir string, env []string, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron(env...)),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewTldrCommandHandler(cacheDir)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestTldrCommandShowsPage(t *testing.T) {
+	out, _, err := runTldr(t, t.TempDir(), nil, "tldr git commit")
+	require.NoError(t, err)
+	assert.Contains(t, out, "git commit")
+	assert.Contains(t, out, "--amend")
+	assert.NotContains(t, out, "{{")
+}
+
+func TestTldrCommandMissingPage(t *testing.T) {
+	_, stderr, err := runTldr(t, t.TempDir(), nil, "tldr no-such-command")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, `no page for "no-such-command"`)
+
+	_, stderr, err = runTldr(t, t.TempDir(), nil, "tldr")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "Usage: tldr")
+}
+
+func TestTldrCommandListAndUpdate(t *testing.T) {
+	cacheDir := t.TempDir() + "/tldr"
+	server := serveArchive(t, buildArchive(t, map[string]string{
+		"pages/common/mytool.md": "# mytool\n\n> A tool.\n\n- Run it:\n\n`mytool {{arg}}`\n",
+	}))
+
+	out, _, err := runTldr(t, cacheDir, []string{"BISH_TLDR_ARCHIVE_URL=" + server.URL}, "tldr --update")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Installed 1 pages")
+
+	out, _, err = runTldr(t, cacheDir, nil, "tldr --list")
+	require.NoError(t, err)
+	assert.Contains(t, strings.Split(out, "\n"), "mytool")
+	assert.Contains(t, strings.Split(out, "\n"), "tar")
+
+	out, _, err = runTldr(t, cacheDir, nil, "tldr mytool")
+	require.NoError(t, err)
+	assert.Contains(t, out, "mytool arg")
+}
+
+func TestTldrCommandPassesThroughOtherCommands(t *testing.T) {
+	out, _, err := runTldr(t, t.TempDir(), nil, "echo hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", out)
+}
@@ -0,0 +1,60 @@
+package tldr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle       = lipgloss.NewStyle().Bold(true)
+	descriptionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	exampleStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	commandStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	placeholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Underline(true)
+
+	placeholderPattern = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	linkPattern        = regexp.MustCompile(`<(https?://[^>]+)>`)
+)
+
+// Render formats a page's markdown source for display in the terminal.
+// Placeholders such as {{path/to/file}} are underlined instead of shown with
+// their braces.
+func Render(page string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(page), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			sb.WriteString("\n  " + titleStyle.Render(strings.TrimPrefix(line, "# ")) + "\n")
+		case strings.HasPrefix(line, "> "):
+			text := linkPattern.ReplaceAllString(strings.TrimPrefix(line, "> "), "$1")
+			sb.WriteString("  " + descriptionStyle.Render(text) + "\n")
+		case strings.HasPrefix(line, "- "):
+			sb.WriteString("\n  " + exampleStyle.Render(strings.TrimPrefix(line, "- ")) + "\n")
+		case strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") && len(line) > 1:
+			sb.WriteString("      " + renderCommand(line[1:len(line)-1]) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// renderCommand styles an example command, highlighting its placeholders.
+func renderCommand(command string) string {
+	var sb strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(command, -1) {
+		sb.WriteString(commandStyle.Render(command[last:match[0]]))
+		sb.WriteString(placeholderStyle.Render(command[match[2]:match[3]]))
+		last = match[1]
+	}
+	sb.WriteString(commandStyle.Render(command[last:]))
+	return sb.String()
+}
+
+// FormatForPrompt wraps a page's markdown source for inclusion in an LLM
+// prompt.
+func FormatForPrompt(name, page string) string {
+	return fmt.Sprintf("<tldr_page command=%q>\n%s\n</tldr_page>", name, strings.TrimSpace(page))
+}
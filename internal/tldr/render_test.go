@@ -0,0 +1,27 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	page := "# tar\n\n> Archiving utility.\n> More information: <https://www.gnu.org/software/tar>.\n\n" +
+		"- Extract an archive:\n\n`tar xf {{path/to/source.tar}}`\n"
+
+	expected := "\n  tar\n" +
+		"  Archiving utility.\n" +
+		"  More information: https://www.gnu.org/software/tar.\n" +
+		"\n  Extract an archive:\n" +
+		"      tar xf path/to/source.tar\n"
+	assert.Equal(t, expected, Render(page))
+}
+
+func TestFormatForPrompt(t *testing.T) {
+	assert.Equal(t, "<tldr_page command=\"git-commit\">\n# git commit\n</tldr_page>", FormatForPrompt("git-commit", "# git commit\n\n"))
+}
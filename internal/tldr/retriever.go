@@ -0,0 +1,53 @@
+package tldr
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxContextPages limits how many pages ContextFor includes for one command
+// line so a long pipeline does not flood the prompt.
+const maxContextPages = 3
+
+// ContextFor returns the pages for the commands used in commandLine, formatted
+// for an LLM prompt, or "" if none are available. Each simple command in a
+// pipeline or list is looked up by its name and, if present, its subcommand.
+func (s *Store) ContextFor(commandLine string) string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(commandLine), "")
+	if err != nil {
+		return ""
+	}
+
+	var pages []string
+	seen := make(map[string]bool)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if len(pages) >= maxContextPages {
+			return false
+		}
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		var words []string
+		for _, arg := range call.Args[:min(len(call.Args), 2)] {
+			word := arg.Lit()
+			if word == "" || strings.HasPrefix(word, "-") {
+				break
+			}
+			words = append(words, word)
+		}
+		if len(words) == 0 {
+			return true
+		}
+
+		name, page, ok := s.LookupCommand(words)
+		if ok && !seen[name] {
+			seen[name] = true
+			pages = append(pages, FormatForPrompt(name, page))
+		}
+		return true
+	})
+	return strings.Join(pages, "\n")
+}
@@ -0,0 +1,129 @@
+// Package tldr provides offline access to tldr pages, the community-maintained
+// collection of short, example-driven command help (https://tldr.sh).
+//
+// A small set of pages for common commands is embedded in the binary. Running
+// `tldr --update` downloads the complete English page set into the bish config
+// directory, which then takes precedence over the embedded pages. Lookups never
+// touch the network.
+package tldr
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// BundledPages contains the tldr pages shipped with bish, laid out like the
+// upstream archive: pages/<platform>/<command>.md.
+//
+//go:embed pages
+var BundledPages embed.FS
+
+// DefaultArchiveURL is the upstream archive that `tldr --update` downloads.
+const DefaultArchiveURL = "https://github.com/tldr-pages/tldr/releases/latest/download/tldr.zip"
+
+// Store looks up pages in a downloaded page set, falling back to the pages
+// embedded in the binary.
+type Store struct {
+	sources  []fs.FS
+	platform string
+}
+
+// NewStore creates a Store that prefers pages under cacheDir, which may be
+// empty or not exist yet, over the embedded pages.
+func NewStore(cacheDir string) *Store {
+	var sources []fs.FS
+	if cacheDir != "" {
+		sources = append(sources, os.DirFS(cacheDir))
+	}
+	if bundled, err := fs.Sub(BundledPages, "pages"); err == nil {
+		sources = append(sources, bundled)
+	}
+	return &Store{sources: sources, platform: Platform()}
+}
+
+// DefaultCacheDir returns the directory where downloaded pages are stored.
+func DefaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "bish", "tldr")
+}
+
+// Platform returns the tldr platform directory for the running OS.
+func Platform() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osx"
+	default:
+		return runtime.GOOS
+	}
+}
+
+// PageName converts command words to a page name, so that "git commit"
+// becomes "git-commit".
+func PageName(words ...string) string {
+	name := strings.ToLower(strings.Join(words, "-"))
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// Lookup returns the markdown source of the page for name, preferring the
+// platform-specific page over the common one.
+func (s *Store) Lookup(name string) (string, bool) {
+	name = PageName(name)
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	for _, source := range s.sources {
+		for _, dir := range []string{s.platform, "common"} {
+			data, err := fs.ReadFile(source, path.Join(dir, name+".md"))
+			if err == nil {
+				return string(data), true
+			}
+		}
+	}
+	return "", false
+}
+
+// LookupCommand finds the most specific page for a command line's words,
+// trying "git commit" before "git". It returns the matched page name.
+func (s *Store) LookupCommand(words []string) (string, string, bool) {
+	for n := min(len(words), 2); n > 0; n-- {
+		name := PageName(words[:n]...)
+		if page, ok := s.Lookup(name); ok {
+			return name, page, true
+		}
+	}
+	return "", "", false
+}
+
+// List returns the names of all pages available for the current platform.
+func (s *Store) List() []string {
+	seen := make(map[string]bool)
+	for _, source := range s.sources {
+		for _, dir := range []string{s.platform, "common"} {
+			entries, err := fs.ReadDir(source, dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if name, ok := strings.CutSuffix(entry.Name(), ".md"); ok && !entry.IsDir() {
+					seen[name] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,105 @@
+package tldr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePage(t *testing.T, dir, platform, name, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, platform), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, platform, name+".md"), []byte(content), 0o644))
+}
+
+func TestBundledPagesAreWellFormed(t *testing.T) {
+	store := NewStore("")
+	names := store.List()
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		page, ok := store.Lookup(name)
+		require.True(t, ok, name)
+		assert.True(t, strings.HasPrefix(page, "# "), "%s should start with a title", name)
+		assert.Contains(t, page, "\n> ", "%s should have a description", name)
+		assert.Contains(t, page, "\n- ", "%s should have examples", name)
+		assert.Equal(t, strings.Count(page, "{{"), strings.Count(page, "}}"), "%s has unbalanced placeholders", name)
+	}
+}
+
+func TestPageName(t *testing.T) {
+	assert.Equal(t, "tar", PageName("tar"))
+	assert.Equal(t, "git-commit", PageName("git", "commit"))
+	assert.Equal(t, "git-commit", PageName("Git Commit"))
+	assert.Equal(t, "", PageName())
+}
+
+func TestLookupPrefersCacheAndPlatform(t *testing.T) {
+	cache := t.TempDir()
+	writePage(t, cache, "common", "tar", "# tar\n\n> Downloaded page.\n")
+	writePage(t, cache, "common", "mytool", "# mytool\n\n> Common page.\n")
+	writePage(t, cache, Platform(), "mytool", "# mytool\n\n> Platform page.\n")
+
+	store := NewStore(cache)
+
+	page, ok := store.Lookup("tar")
+	require.True(t, ok)
+	assert.Contains(t, page, "Downloaded page.")
+
+	page, ok = store.Lookup("mytool")
+	require.True(t, ok)
+	assert.Contains(t, page, "Platform page.")
+
+	// Pages missing from the cache still come from the embedded set
+	page, ok = store.Lookup("grep")
+	require.True(t, ok)
+	assert.Contains(t, page, "# grep")
+
+	assert.Contains(t, store.List(), "mytool")
+	assert.Contains(t, store.List(), "grep")
+}
+
+func TestLookupRejectsPaths(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, name := range []string{"", "../common/tar", "common/tar", ".hidden", "no-such-command"} {
+		_, ok := store.Lookup(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestLookupCommandPrefersSubcommand(t *testing.T) {
+	store := NewStore("")
+
+	name, _, ok := store.LookupCommand([]string{"git", "commit"})
+	require.True(t, ok)
+	assert.Equal(t, "git-commit", name)
+
+	name, _, ok = store.LookupCommand([]string{"git", "frobnicate"})
+	require.True(t, ok)
+	assert.Equal(t, "git", name)
+
+	name, _, ok = store.LookupCommand([]string{"tar", "xzf", "archive.tar.gz"})
+	require.True(t, ok)
+	assert.Equal(t, "tar", name)
+
+	_, _, ok = store.LookupCommand(nil)
+	assert.False(t, ok)
+}
+
+func TestContextFor(t *testing.T) {
+	store := NewStore("")
+
+	context := store.ContextFor(`find . -name '*.go' | xargs grep -n TODO && git commit -m "wip"`)
+	assert.Contains(t, context, `<tldr_page command="find">`)
+	assert.Contains(t, context, `<tldr_page command="xargs">`)
+	assert.Contains(t, context, `<tldr_page command="git-commit">`)
+	assert.Equal(t, maxContextPages, strings.Count(context, "<tldr_page"))
+
+	assert.Equal(t, 1, strings.Count(store.ContextFor("grep a f; grep b f"), "<tldr_page"))
+	assert.Equal(t, "", store.ContextFor("no-such-command --flag"))
+	assert.Equal(t, "", store.ContextFor("echo 'unterminated"))
+}
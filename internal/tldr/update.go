@@ -0,0 +1,126 @@
+package tldr
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxPageSize guards against oversized entries in a downloaded archive.
+const maxPageSize = 1 << 20
+
+// Update downloads the tldr archive from archiveURL and replaces the pages in
+// cacheDir with its English pages. The previous pages are only removed once
+// the new set has been extracted successfully. It returns the number of pages
+// installed.
+func Update(ctx context.Context, client *http.Client, archiveURL, cacheDir string) (int, error) {
+	if cacheDir == "" {
+		return 0, fmt.Errorf("no tldr cache directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0700); err != nil {
+		return 0, err
+	}
+
+	archive, err := os.CreateTemp(filepath.Dir(cacheDir), "tldr-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("downloading %s: %s", archiveURL, resp.Status)
+	}
+
+	size, err := io.Copy(archive, resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(cacheDir), "tldr-staging-")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	count, err := extractPages(archive, size, staging)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("archive contains no English pages")
+	}
+
+	if err := os.RemoveAll(cacheDir); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(staging, cacheDir); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// extractPages copies pages/<platform>/<name>.md entries from the archive into
+// dir/<platform>/<name>.md. Archives with only the page directories at the
+// root, such as the per-language tldr-pages.en.zip, are accepted as well.
+func extractPages(r io.ReaderAt, size int64, dir string) (int, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return 0, fmt.Errorf("reading tldr archive: %w", err)
+	}
+
+	count := 0
+	for _, file := range zr.File {
+		name := strings.TrimPrefix(path.Clean(file.Name), "pages/")
+		platform, page, ok := strings.Cut(name, "/")
+		if !ok || strings.Contains(page, "/") || !strings.HasSuffix(page, ".md") ||
+			strings.HasPrefix(platform, ".") || strings.HasPrefix(page, ".") ||
+			strings.HasPrefix(file.Name, "pages.") || file.FileInfo().IsDir() {
+			continue
+		}
+
+		if err := extractFile(file, filepath.Join(dir, platform, page)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func extractFile(file *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, io.LimitReader(src, maxPageSize)); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
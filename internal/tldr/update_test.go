@@ -0,0 +1,98 @@
+package tldr
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func serveArchive(t *testing.T, archive []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpdateInstallsEnglishPages(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		"LICENSE.md":               "license",
+		"pages/common/tar.md":      "# tar\n\n> Downloaded.\n",
+		"pages/linux/ss.md":        "# ss\n\n> Downloaded.\n",
+		"pages.de/common/tar.md":   "# tar\n\n> Deutsch.\n",
+		"pages/common/../../x.md":  "escape",
+		"pages/common/nested/a.md": "nested",
+	})
+	server := serveArchive(t, archive)
+
+	cacheDir := filepath.Join(t.TempDir(), "tldr")
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "common"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "common", "stale.md"), []byte("# stale"), 0o644))
+
+	count, err := Update(context.Background(), server.Client(), server.URL, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, "common", "tar.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Downloaded.")
+	assert.FileExists(t, filepath.Join(cacheDir, "linux", "ss.md"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "common", "stale.md"))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(cacheDir), "x.md"))
+}
+
+func TestUpdateAcceptsLanguageArchive(t *testing.T) {
+	server := serveArchive(t, buildArchive(t, map[string]string{
+		"common/tar.md": "# tar\n",
+	}))
+
+	cacheDir := filepath.Join(t.TempDir(), "tldr")
+	count, err := Update(context.Background(), server.Client(), server.URL, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.FileExists(t, filepath.Join(cacheDir, "common", "tar.md"))
+}
+
+func TestUpdateKeepsExistingPagesOnFailure(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "tldr")
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "common"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "common", "tar.md"), []byte("# tar"), 0o644))
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	_, err := Update(context.Background(), notFound.Client(), notFound.URL, cacheDir)
+	assert.ErrorContains(t, err, "404")
+
+	empty := serveArchive(t, buildArchive(t, map[string]string{"README.md": "hi"}))
+	_, err = Update(context.Background(), empty.Client(), empty.URL, cacheDir)
+	assert.ErrorContains(t, err, "no English pages")
+
+	garbage := serveArchive(t, []byte("not a zip"))
+	_, err = Update(context.Background(), garbage.Client(), garbage.URL, cacheDir)
+	assert.Error(t, err)
+
+	assert.FileExists(t, filepath.Join(cacheDir, "common", "tar.md"))
+}
@@ -0,0 +1,137 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+const usage = "Usage: todo add <text>\n" +
+	"       todo [list] [--all] [--done]\n" +
+	"       todo done <id>..."
+
+// NewTodoCommandHandler creates an ExecHandler for the todo builtin. TODOs
+// are scoped to the project of the current directory unless --all is given.
+func NewTodoCommandHandler(store *Store) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 || args[0] != "todo" {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			project := ProjectFor(hc.Dir)
+			args = args[1:]
+			subcommand := "list"
+			if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+				subcommand, args = args[0], args[1:]
+			}
+
+			switch subcommand {
+			case "add":
+				todo, err := store.Add(strings.Join(args, " "), project, SourceUser)
+				if err != nil {
+					fmt.Fprintf(hc.Stderr, "todo: %s\n%s\n", err, usage)
+					return interp.NewExitStatus(2)
+				}
+				fmt.Fprintf(hc.Stdout, "Added TODO %d: %s\n", todo.ID, todo.Text)
+				return nil
+
+			case "list", "ls":
+				all, includeDone := false, false
+				for _, arg := range args {
+					switch arg {
+					case "-a", "--all":
+						all = true
+					case "-d", "--done":
+						includeDone = true
+					default:
+						fmt.Fprintf(hc.Stderr, "todo: unknown option %s\n%s\n", arg, usage)
+						return interp.NewExitStatus(2)
+					}
+				}
+				if all {
+					project = ""
+				}
+				todos, err := store.List(project, includeDone)
+				if err != nil {
+					fmt.Fprintf(hc.Stderr, "todo: %s\n", err)
+					return interp.NewExitStatus(1)
+				}
+				printTodos(hc.Stdout, todos, all)
+				return nil
+
+			case "done":
+				if len(args) == 0 {
+					fmt.Fprintf(hc.Stderr, "todo: done requires a TODO id\n%s\n", usage)
+					return interp.NewExitStatus(2)
+				}
+				status := uint8(0)
+				for _, arg := range args {
+					id, err := strconv.ParseUint(arg, 10, 0)
+					if err != nil {
+						fmt.Fprintf(hc.Stderr, "todo: invalid TODO id %q\n", arg)
+						status = 1
+						continue
+					}
+					if err := store.MarkDone(uint(id)); err != nil {
+						fmt.Fprintf(hc.Stderr, "todo: %s\n", err)
+						status = 1
+						continue
+					}
+					fmt.Fprintf(hc.Stdout, "Done: TODO %d\n", id)
+				}
+				if status != 0 {
+					return interp.NewExitStatus(status)
+				}
+				return nil
+
+			case "help", "-h", "--help":
+				fmt.Fprintln(hc.Stdout, usage)
+				return nil
+
+			default:
+				fmt.Fprintf(hc.Stderr, "todo: unknown command %s\n%s\n", subcommand, usage)
+				return interp.NewExitStatus(2)
+			}
+		}
+	}
+}
+
+// printTodos lists todos one per line, under a heading for each project if
+// they come from several.
+func printTodos(w io.Writer, todos []Todo, byProject bool) {
+	if len(todos) == 0 {
+		fmt.Fprintln(w, "No pending TODOs")
+		return
+	}
+
+	var projects []string
+	grouped := map[string][]Todo{}
+	for _, todo := range todos {
+		if _, ok := grouped[todo.Project]; !ok {
+			projects = append(projects, todo.Project)
+		}
+		grouped[todo.Project] = append(grouped[todo.Project], todo)
+	}
+
+	for i, project := range projects {
+		if byProject {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", project)
+		}
+		for _, todo := range grouped[project] {
+			status := ""
+			if todo.Done() {
+				status = "[done] "
+			}
+			fmt.Fprintf(w, "%4d  %s%s\n", todo.ID, status, todo.Text)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func runTodo(t *testing.T, store *Store, dir string, script string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	runner, err := interp.New(
+		interp.Dir(dir),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(NewTodoCommandHandler(store)),
+	)
+	require.NoError(t, err)
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	require.NoError(t, err)
+	err = runner.Run(context.Background(), file)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestTodoCommand(t *testing.T) {
+	store := newTestStore(t)
+	root := t.TempDir()
+	repo := filepath.Join(root, "infra")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "certs"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	other := filepath.Join(root, "notes")
+	require.NoError(t, os.Mkdir(other, 0o755))
+
+	out, _, err := runTodo(t, store, filepath.Join(repo, "certs"), `todo add "rotate prod certs"`)
+	require.NoError(t, err)
+	assert.Equal(t, "Added TODO 1: rotate prod certs\n", out)
+	_, _, err = runTodo(t, store, other, `todo add call the vendor`)
+	require.NoError(t, err)
+
+	// Scoped to the project of the current directory
+	out, _, err = runTodo(t, store, repo, "todo")
+	require.NoError(t, err)
+	assert.Equal(t, "   1  rotate prod certs\n", out)
+
+	out, _, err = runTodo(t, store, repo, "todo list --all")
+	require.NoError(t, err)
+	assert.Equal(t, repo+":\n   1  rotate prod certs\n\n"+other+":\n   2  call the vendor\n", out)
+
+	out, _, err = runTodo(t, store, repo, "todo done 1")
+	require.NoError(t, err)
+	assert.Equal(t, "Done: TODO 1\n", out)
+
+	out, _, err = runTodo(t, store, repo, "todo list")
+	require.NoError(t, err)
+	assert.Equal(t, "No pending TODOs\n", out)
+
+	out, _, err = runTodo(t, store, repo, "todo list --done")
+	require.NoError(t, err)
+	assert.Equal(t, "   1  [done] rotate prod certs\n", out)
+}
+
+func TestTodoCommandErrors(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+
+	_, stderr, err := runTodo(t, store, dir, "todo add")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "empty TODO")
+
+	_, stderr, err = runTodo(t, store, dir, "todo done 7 x")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "no pending TODO with id 7")
+	assert.Contains(t, stderr, `invalid TODO id "x"`)
+
+	_, stderr, err = runTodo(t, store, dir, "todo finish 1")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "unknown command finish")
+}
@@ -0,0 +1,49 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RepeatedFailures is how many times in a row a command must fail before a
+// TODO is suggested for it.
+const RepeatedFailures = 3
+
+// laterPattern matches advice to do something later, such as "You should
+// later rotate the certificates" or "you may want to rotate them later".
+var laterPattern = regexp.MustCompile(`(?i)\b(?:you should|you'll want to|you will want to|you may want to|you might want to|you will need to|you'll need to|remember to)\s+(?:later\s+|also\s+)?(.+?)(?:\s+later(?: on)?)?[.!]?$`)
+
+// sentenceEnd splits text into sentences.
+var sentenceEnd = regexp.MustCompile(`[.!?](?:\s+|$)|\n+`)
+
+// FailureText returns the text of a TODO to come back to a failing command.
+func FailureText(command string) string {
+	return fmt.Sprintf("fix failing command: %s", strings.TrimSpace(command))
+}
+
+// LaterTasks returns the things an agent response advises doing later, in
+// the order they appear. Only sentences that mention "later" count, so that
+// ordinary instructions for the task at hand are not captured.
+func LaterTasks(response string) []string {
+	var tasks []string
+	seen := map[string]bool{}
+	for _, sentence := range sentenceEnd.Split(response, -1) {
+		sentence = strings.TrimSpace(strings.TrimLeft(sentence, "-*•> \t"))
+		sentence = strings.NewReplacer("**", "", "`", "").Replace(sentence)
+		if !strings.Contains(strings.ToLower(sentence), "later") {
+			continue
+		}
+		match := laterPattern.FindStringSubmatch(sentence)
+		if match == nil {
+			continue
+		}
+		task := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[1]), ","))
+		if task == "" || strings.EqualFold(task, "later") || seen[task] {
+			continue
+		}
+		seen[task] = true
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
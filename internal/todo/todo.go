@@ -0,0 +1,119 @@
+// Package todo stores TODOs captured from the shell, scoped to the project
+// they were added in, in the same database as the command history.
+package todo
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SourceUser is the source of the TODOs added with the todo command, which
+// is also how the ones the shell suggests are added.
+const SourceUser = "user"
+
+// Todo is something to come back to later.
+type Todo struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	UpdatedAt time.Time
+
+	Text string
+	// Project is the root of the git repository the TODO was added in, or
+	// the directory if it was not in one.
+	Project string `gorm:"index"`
+	Source  string
+	DoneAt  sql.NullTime `gorm:"index"`
+}
+
+// Done reports whether the TODO has been marked done.
+func (t Todo) Done() bool {
+	return t.DoneAt.Valid
+}
+
+// Store reads and writes TODOs.
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore returns a store that keeps its TODOs in db.
+func NewStore(db *gorm.DB) (*Store, error) {
+	if err := db.AutoMigrate(&Todo{}); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Add records a new pending TODO for project.
+func (s *Store) Add(text, project, source string) (*Todo, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("empty TODO")
+	}
+	todo := &Todo{Text: text, Project: project, Source: source}
+	if err := s.db.Create(todo).Error; err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// List returns the TODOs of project, or of all projects if project is empty,
+// oldest first. Done TODOs are only included if includeDone is set.
+func (s *Store) List(project string, includeDone bool) ([]Todo, error) {
+	var todos []Todo
+	db := s.db
+	if project != "" {
+		db = db.Where("project = ?", project)
+	}
+	if !includeDone {
+		db = db.Where("done_at IS NULL")
+	}
+	if err := db.Order("created_at asc, id asc").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// HasPending reports whether project already has a pending TODO with text.
+func (s *Store) HasPending(project, text string) bool {
+	var count int64
+	s.db.Model(&Todo{}).
+		Where("project = ? AND text = ? AND done_at IS NULL", project, strings.TrimSpace(text)).
+		Count(&count)
+	return count > 0
+}
+
+// MarkDone marks the TODO with the given id as done.
+func (s *Store) MarkDone(id uint) error {
+	result := s.db.Model(&Todo{}).
+		Where("id = ? AND done_at IS NULL", id).
+		Update("done_at", sql.NullTime{Time: time.Now(), Valid: true})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no pending TODO with id %d", id)
+	}
+	return nil
+}
+
+// ProjectFor returns the project that dir belongs to: the root of its git
+// repository, or dir itself if it is not in one.
+func ProjectFor(dir string) string {
+	dir = filepath.Clean(dir)
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
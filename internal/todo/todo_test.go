@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) *Store {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	store, err := NewStore(db)
+	require.NoError(t, err)
+	return store
+}
+
+func TestStore(t *testing.T) {
+	store := newTestStore(t)
+
+	certs, err := store.Add(" rotate prod certs ", "/src/infra", SourceUser)
+	require.NoError(t, err)
+	assert.Equal(t, "rotate prod certs", certs.Text)
+	_, err = store.Add("update README", "/src/docs", SourceUser)
+	require.NoError(t, err)
+	_, err = store.Add("  ", "/src/infra", SourceUser)
+	assert.Error(t, err)
+
+	todos, err := store.List("/src/infra", false)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, "rotate prod certs", todos[0].Text)
+	assert.True(t, store.HasPending("/src/infra", "rotate prod certs"))
+	assert.False(t, store.HasPending("/src/docs", "rotate prod certs"))
+
+	todos, err = store.List("", false)
+	require.NoError(t, err)
+	assert.Len(t, todos, 2)
+
+	require.NoError(t, store.MarkDone(certs.ID))
+	assert.Error(t, store.MarkDone(certs.ID))
+	assert.Error(t, store.MarkDone(999))
+	assert.False(t, store.HasPending("/src/infra", "rotate prod certs"))
+
+	todos, err = store.List("/src/infra", false)
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+
+	todos, err = store.List("/src/infra", true)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.True(t, todos[0].Done())
+}
+
+func TestProjectFor(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	nested := filepath.Join(repo, "cmd", "tool")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	plain := filepath.Join(root, "plain")
+	require.NoError(t, os.Mkdir(plain, 0o755))
+
+	assert.Equal(t, repo, ProjectFor(nested))
+	assert.Equal(t, repo, ProjectFor(repo+"/"))
+	assert.Equal(t, plain, ProjectFor(plain))
+}
+
+func TestLaterTasks(t *testing.T) {
+	response := "The certificate expires in 3 days, so I renewed it.\n" +
+		"- You should later rotate the **prod** certs as well.\n" +
+		"You may want to add monitoring for expiry later on. Run `certbot renew` now.\n" +
+		"You should restart nginx.\n" +
+		"Remember to update the runbook later!"
+
+	assert.Equal(t, []string{
+		"rotate the prod certs as well",
+		"add monitoring for expiry",
+		"update the runbook",
+	}, LaterTasks(response))
+
+	assert.Empty(t, LaterTasks("I will check again later."))
+}
+
+func TestFailureText(t *testing.T) {
+	assert.Equal(t, "fix failing command: make deploy", FailureText(" make deploy "))
+}
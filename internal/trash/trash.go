@@ -0,0 +1,129 @@
+// Package trash moves files to the desktop trash instead of deleting them:
+// the freedesktop.org trash under ~/.local/share/Trash, or ~/.Trash on macOS.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Trash is a trash directory.
+type Trash struct {
+	// Dir holds the trashed files
+	Dir string
+	// InfoDir holds a .trashinfo file per trashed file that tells where it
+	// came from, so file managers can restore it. macOS has none.
+	InfoDir string
+}
+
+// For returns the trash of home on goos, honouring XDG_DATA_HOME on Linux
+// and the other Unix systems.
+func For(home, goos string, getenv func(string) string) Trash {
+	if goos == "darwin" {
+		return Trash{Dir: filepath.Join(home, ".Trash")}
+	}
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	root := filepath.Join(dataHome, "Trash")
+	return Trash{Dir: filepath.Join(root, "files"), InfoDir: filepath.Join(root, "info")}
+}
+
+// Default returns the trash of the current user.
+func Default() (Trash, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Trash{}, err
+	}
+	return For(home, runtime.GOOS, os.Getenv), nil
+}
+
+// Move moves path to the trash and returns where it went. Files on another
+// filesystem than the trash are left alone, since moving them would mean
+// copying.
+func (t Trash) Move(path string, now time.Time) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(t.Dir, 0o700); err != nil {
+		return "", err
+	}
+	if t.InfoDir != "" {
+		if err := os.MkdirAll(t.InfoDir, 0o700); err != nil {
+			return "", err
+		}
+	}
+
+	name, info, err := t.reserve(filepath.Base(abs), abs, now)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(t.Dir, name)
+	if err := os.Rename(abs, dest); err != nil {
+		if info != "" {
+			_ = os.Remove(info)
+		}
+		if errors.Is(err, syscall.EXDEV) {
+			return "", fmt.Errorf("%s is on another filesystem than the trash", path)
+		}
+		return "", err
+	}
+	return dest, nil
+}
+
+// reserve picks a name that is free in the trash, adding .2, .3 and so on
+// when needed, and claims it by writing its .trashinfo file.
+func (t Trash) reserve(base, original string, now time.Time) (name, info string, err error) {
+	for n := 1; ; n++ {
+		name = base
+		if n > 1 {
+			name = base + "." + strconv.Itoa(n)
+		}
+		if _, err := os.Lstat(filepath.Join(t.Dir, name)); err == nil {
+			continue
+		}
+		if t.InfoDir == "" {
+			return name, "", nil
+		}
+		info = filepath.Join(t.InfoDir, name+".trashinfo")
+		file, err := os.OpenFile(info, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		_, err = file.WriteString(trashInfo(original, now))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(info)
+			return "", "", err
+		}
+		return name, info, nil
+	}
+}
+
+// trashInfo is the .trashinfo content for a file, with its path
+// percent-encoded as the specification asks.
+func trashInfo(original string, now time.Time) string {
+	segments := strings.Split(original, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", strings.Join(segments, "/"), now.Format("2006-01-02T15:04:05"))
+}
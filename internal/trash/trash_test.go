@@ -0,0 +1,74 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noEnv(string) string { return "" }
+
+func TestFor(t *testing.T) {
+	assert.Equal(t, Trash{Dir: "/home/me/.local/share/Trash/files", InfoDir: "/home/me/.local/share/Trash/info"}, For("/home/me", "linux", noEnv))
+	assert.Equal(t, Trash{Dir: "/Users/me/.Trash"}, For("/Users/me", "darwin", noEnv))
+
+	xdg := func(name string) string {
+		if name == "XDG_DATA_HOME" {
+			return "/data"
+		}
+		return ""
+	}
+	assert.Equal(t, "/data/Trash/files", For("/home/me", "linux", xdg).Dir)
+}
+
+func TestMove(t *testing.T) {
+	home := t.TempDir()
+	trash := For(home, "linux", noEnv)
+	now := time.Date(2026, time.March, 10, 14, 30, 0, 0, time.UTC)
+
+	dir := filepath.Join(home, "my files")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	first := filepath.Join(dir, "big.iso")
+	require.NoError(t, os.WriteFile(first, []byte("data"), 0o644))
+
+	dest, err := trash.Move(first, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trash.Dir, "big.iso"), dest)
+	assert.NoFileExists(t, first)
+	assert.FileExists(t, dest)
+	info, err := os.ReadFile(filepath.Join(trash.InfoDir, "big.iso.trashinfo"))
+	require.NoError(t, err)
+	assert.Equal(t, "[Trash Info]\nPath="+filepath.Join(home, "my%20files", "big.iso")+"\nDeletionDate=2026-03-10T14:30:00\n", string(info))
+
+	// A second file with the same name gets a new one
+	require.NoError(t, os.WriteFile(first, []byte("more"), 0o644))
+	dest, err = trash.Move(first, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trash.Dir, "big.iso.2"), dest)
+	assert.FileExists(t, filepath.Join(trash.InfoDir, "big.iso.2.trashinfo"))
+
+	// Directories move whole
+	sub := filepath.Join(dir, "cache")
+	require.NoError(t, os.MkdirAll(filepath.Join(sub, "nested"), 0o755))
+	dest, err = trash.Move(sub, now)
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dest, "nested"))
+
+	_, err = trash.Move(filepath.Join(dir, "missing"), now)
+	assert.Error(t, err)
+}
+
+func TestMoveMacOS(t *testing.T) {
+	home := t.TempDir()
+	trash := For(home, "darwin", noEnv)
+	file := filepath.Join(home, "notes.txt")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	dest, err := trash.Move(file, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".Trash", "notes.txt"), dest)
+}
@@ -0,0 +1,241 @@
+// Package userland tells which flavor of the standard command line tools the
+// system has, and finds GNU-only constructs in commands meant for BSD tools,
+// such as macOS's.
+package userland
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Flavor is the family of the core utilities: sed, grep, date and so on.
+type Flavor string
+
+const (
+	GNU     Flavor = "GNU"
+	BSD     Flavor = "BSD"
+	BusyBox Flavor = "BusyBox"
+)
+
+// Userland describes the core utilities of the system.
+type Userland struct {
+	Flavor Flavor
+	// GNUPrefixed lists the GNU tools installed with a g prefix next to BSD
+	// ones, e.g. gsed from Homebrew's gnu-sed
+	GNUPrefixed []string
+}
+
+// probeTimeout bounds each tool run during detection.
+const probeTimeout = time.Second
+
+// Detect probes the tools with output, which runs a command and returns its
+// output. GNU tools answer --version; BSD ones reject it.
+func Detect(goos string, output func(name string, args ...string) (string, error)) Userland {
+	version, _ := output("sed", "--version")
+	switch {
+	case strings.Contains(version, "GNU"):
+		return Userland{Flavor: GNU}
+	case strings.Contains(version, "BusyBox"):
+		return Userland{Flavor: BusyBox}
+	}
+	if goos == "linux" {
+		// A Linux without a sed answering --version is unusual; GNU is the
+		// safest guess
+		return Userland{Flavor: GNU}
+	}
+
+	land := Userland{Flavor: BSD}
+	for _, tool := range []string{"gsed", "gdate", "gstat", "gls", "ggrep", "gfind"} {
+		if _, err := exec.LookPath(tool); err == nil {
+			land.GNUPrefixed = append(land.GNUPrefixed, tool)
+		}
+	}
+	return land
+}
+
+var current = sync.OnceValue(func() Userland {
+	return Detect(runtime.GOOS, func(name string, args ...string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+		return string(out), err
+	})
+})
+
+// Current returns the userland of the system bish runs on.
+func Current() Userland {
+	return current()
+}
+
+// String describes the userland for the system info given to the model.
+func (u Userland) String() string {
+	switch u.Flavor {
+	case BSD:
+		desc := "BSD (sed, grep, date, stat, find and friends take BSD flags, not GNU ones)"
+		if len(u.GNUPrefixed) > 0 {
+			desc += "; GNU versions installed as " + strings.Join(u.GNUPrefixed, ", ")
+		}
+		return desc
+	case BusyBox:
+		return "BusyBox (tools support only common flags)"
+	default:
+		return string(u.Flavor)
+	}
+}
+
+// Issue is a GNU-only construct in a command.
+type Issue struct {
+	// Construct is what the command uses, e.g. "sed -r"
+	Construct string
+	// Advice is what works with BSD tools instead
+	Advice string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Construct, i.Advice)
+}
+
+// gnuOnly lists GNU flags, per command, with what BSD tools take instead.
+var gnuOnly = map[string]map[string]string{
+	"sed":      {"-r": "use -E for extended regular expressions", "--in-place": "use -i ''", "--regexp-extended": "use -E"},
+	"grep":     {"-P": "BSD grep has no Perl regular expressions; use -E, or perl -ne", "--perl-regexp": "use -E, or perl -ne"},
+	"date":     {"-d": "BSD date parses dates with -j -f FORMAT and shifts them with -v (e.g. date -v-1d)", "--date": "use -j -f FORMAT or -v"},
+	"stat":     {"-c": "BSD stat takes a format with -f (e.g. stat -f %z for the size)", "--format": "use -f", "--printf": "use -f"},
+	"find":     {"-printf": "BSD find has no -printf; pipe to stat -f or use -exec", "-regextype": "use find -E"},
+	"du":       {"--max-depth": "use -d", "-b": "use -A (apparent size) with -k, or stat -f %z"},
+	"ls":       {"--color": "use -G", "--group-directories-first": "BSD ls cannot group directories first"},
+	"base64":   {"-w": "BSD base64 wraps with -b; it does not wrap by default"},
+	"cp":       {"--parents": "BSD cp has no --parents; use rsync -R or ditto"},
+	"readlink": {"-e": "use realpath", "-m": "use realpath"},
+	"xargs":    {"--no-run-if-empty": "BSD xargs does not run the command for empty input anyway; drop it"},
+}
+
+// missingTools are GNU tools that BSD systems don't have.
+var missingTools = map[string]string{
+	"tac":       "use tail -r",
+	"sha256sum": "use shasum -a 256",
+	"md5sum":    "use md5 -r",
+	"nproc":     "use sysctl -n hw.ncpu",
+	"timeout":   "install coreutils and use gtimeout, or perl -e 'alarm shift; exec @ARGV'",
+}
+
+// Check returns the GNU-only constructs in command, which would fail with
+// BSD tools. A command that doesn't parse has none.
+func Check(command string) []Issue {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil
+	}
+	var issues []Issue
+	seen := map[string]bool{}
+	add := func(issue Issue) {
+		if !seen[issue.Construct] {
+			seen[issue.Construct] = true
+			issues = append(issues, issue)
+		}
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, arg := range call.Args {
+			args[i] = literal(arg)
+		}
+		name := args[0]
+		if name == "sudo" || name == "command" || name == "env" {
+			args = args[1:]
+			if len(args) == 0 {
+				return true
+			}
+			name = args[0]
+		}
+
+		if advice, ok := missingTools[name]; ok {
+			add(Issue{Construct: name, Advice: advice})
+			return true
+		}
+		if name == "sed" {
+			checkSedInPlace(args[1:], add)
+		}
+		flags := gnuOnly[name]
+		for _, arg := range args[1:] {
+			if arg == "--" {
+				break
+			}
+			flag, _, _ := strings.Cut(arg, "=")
+			if advice, ok := flags[flag]; ok {
+				add(Issue{Construct: name + " " + flag, Advice: advice})
+			}
+		}
+		return true
+	})
+	return issues
+}
+
+// checkSedInPlace flags sed -i without the backup suffix argument BSD sed
+// requires: there, sed -i 's/a/b/' f takes the script as the suffix.
+func checkSedInPlace(args []string, add func(Issue)) {
+	for i, arg := range args {
+		switch {
+		case arg == "-i":
+			if i+1 >= len(args) || !isBackupSuffix(args[i+1]) {
+				add(Issue{Construct: "sed -i", Advice: "BSD sed needs a backup suffix after -i; use sed -i '' for none"})
+			}
+		case strings.HasPrefix(arg, "-i") && len(arg) > 2 && !strings.HasPrefix(arg, "-i."):
+			// -i.bak is fine everywhere; anything else glued to -i isn't a suffix
+			add(Issue{Construct: "sed " + arg, Advice: "BSD sed reads what follows -i as the backup suffix; use sed -i '' -" + arg[2:]})
+		}
+	}
+}
+
+// isBackupSuffix reports whether arg can be the backup suffix of sed -i,
+// such as an empty one or .bak, rather than a script or option.
+func isBackupSuffix(arg string) bool {
+	return arg == "" || arg == "~" || strings.HasPrefix(arg, ".") || strings.HasPrefix(arg, "_")
+}
+
+// literal returns the text of word with its quotes removed, or "" if it is
+// not a plain literal.
+func literal(word *syntax.Word) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return ""
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return ""
+		}
+	}
+	return sb.String()
+}
+
+// CorrectionPrompt asks the model to rewrite command without the GNU-only
+// constructs found in it.
+func CorrectionPrompt(command string, issues []Issue, land Userland) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "The command `%s` uses GNU-only constructs, but this system's userland is %s:\n", command, land)
+	for _, issue := range issues {
+		fmt.Fprintf(&prompt, "- %s\n", issue)
+	}
+	prompt.WriteString("\nRewrite the command so that it works here, and provide it in a markdown code block.")
+	return prompt.String()
+}
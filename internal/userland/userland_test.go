@@ -0,0 +1,58 @@
+package userland
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	sed := func(version string, err error) func(string, ...string) (string, error) {
+		return func(string, ...string) (string, error) { return version, err }
+	}
+	assert.Equal(t, GNU, Detect("linux", sed("sed (GNU sed) 4.9\n", nil)).Flavor)
+	assert.Equal(t, BusyBox, Detect("linux", sed("BusyBox v1.36.1 multi-call binary.", errors.New("exit status 1"))).Flavor)
+	assert.Equal(t, GNU, Detect("darwin", sed("sed (GNU sed) 4.9\n", nil)).Flavor)
+	assert.Equal(t, BSD, Detect("darwin", sed("sed: illegal option -- -\n", errors.New("exit status 1"))).Flavor)
+	assert.Equal(t, GNU, Detect("linux", sed("", errors.New("not found"))).Flavor)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		command    string
+		constructs []string
+	}{
+		{command: "sed -i 's/foo/bar/' config.yml", constructs: []string{"sed -i"}},
+		{command: "sed -i '' 's/foo/bar/' config.yml"},
+		{command: "sed -i.bak -e 's/a/b/' f"},
+		{command: "sed -i -e 's/a/b/' f", constructs: []string{"sed -i"}},
+		{command: "sed -ie 's/a/b/' f", constructs: []string{"sed -ie"}},
+		{command: "sed -r 's/(a+)/\\1/' f", constructs: []string{"sed -r"}},
+		{command: "grep -P '\\d+' log | sort", constructs: []string{"grep -P"}},
+		{command: "date -d yesterday +%F && stat -c %s file", constructs: []string{"date -d", "stat -c"}},
+		{command: "find . -name '*.go' -printf '%s %p\\n'", constructs: []string{"find -printf"}},
+		{command: "du -h --max-depth=1 .", constructs: []string{"du --max-depth"}},
+		{command: "sudo tac /var/log/system.log", constructs: []string{"tac"}},
+		{command: "echo $(nproc)", constructs: []string{"nproc"}},
+		{command: "grep -E 'a|b' f && ls -G"},
+		{command: "echo 'sed -r is GNU'"},
+		{command: "if [ unclosed"},
+	}
+	for _, tt := range tests {
+		var constructs []string
+		for _, issue := range Check(tt.command) {
+			constructs = append(constructs, issue.Construct)
+		}
+		assert.Equal(t, tt.constructs, constructs, tt.command)
+	}
+}
+
+func TestCorrectionPrompt(t *testing.T) {
+	land := Userland{Flavor: BSD, GNUPrefixed: []string{"gsed"}}
+	prompt := CorrectionPrompt("sed -r 's/a/b/' f", Check("sed -r 's/a/b/' f"), land)
+	assert.Contains(t, prompt, "userland is BSD")
+	assert.Contains(t, prompt, "GNU versions installed as gsed")
+	assert.Contains(t, prompt, "- sed -r: use -E for extended regular expressions")
+	assert.Contains(t, prompt, "markdown code block")
+}
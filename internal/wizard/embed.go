@@ -1,13 +1,14 @@
 package wizard
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	_ "embed"
+
+	"github.com/robottwo/bishop/internal/dotfiles"
 )
 
 //go:embed bishrc.template
@@ -20,7 +21,8 @@ func BishrcTemplate() []byte {
 }
 
 // EnsureBishrcConfigured ensures that ~/.bishrc exists and sources config_ui.
-// For fresh installs, writes the full template. For existing files, appends the source line.
+// For fresh installs, writes the full template. For existing files, adds the
+// source line in a managed section.
 func EnsureBishrcConfigured() error {
 	gshrcPath := filepath.Join(homeDir(), ".bishrc")
 
@@ -40,22 +42,9 @@ func EnsureBishrcConfigured() error {
 		return nil
 	}
 
-	sourceSnippet := "\n# Source UI configuration\n[ -f ~/.config/bish/config_ui ] && source ~/.config/bish/config_ui\n"
-	f, err := os.OpenFile(gshrcPath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open %s: %w", gshrcPath, err)
-	}
-
-	var writeErr error
-	if _, writeErr = f.WriteString(sourceSnippet); writeErr != nil {
-		writeErr = fmt.Errorf("failed to write to %s: %w", gshrcPath, writeErr)
+	sourceSnippet := "# Source UI configuration\n[ -f ~/.config/bish/config_ui ] && source ~/.config/bish/config_ui"
+	if _, err := dotfiles.SetBlock(gshrcPath, "config-ui", sourceSnippet); err != nil {
+		return fmt.Errorf("failed to update %s: %w", gshrcPath, err)
 	}
-
-	closeErr := f.Close()
-	if closeErr != nil {
-		closeErr = fmt.Errorf("failed to close %s: %w", gshrcPath, closeErr)
-	}
-
-	// Combine both errors if both occurred
-	return errors.Join(writeErr, closeErr)
+	return nil
 }
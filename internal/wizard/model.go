@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/history"
 	"mvdan.cc/sh/v3/interp"
 )
 
@@ -22,6 +23,7 @@ const (
 	stepSlowAPIKey
 	stepSlowModel
 	stepSlowTest
+	stepImportHistory
 	stepSummary
 	stepComplete
 )
@@ -41,14 +43,23 @@ type wizardConfig struct {
 	apiKeyCache map[string]string // Cache API keys by provider for reuse
 }
 
+// historySource is the history file of another shell, found to import.
+type historySource struct {
+	shell    string
+	path     string
+	commands []history.ImportedCommand
+}
+
 type wizardModel struct {
-	runner   *interp.Runner
-	step     wizardStep
-	config   wizardConfig
-	width    int
-	height   int
-	quitting bool
-	errorMsg string
+	runner *interp.Runner
+	// historyManager imports the history of other shells, if set
+	historyManager *history.HistoryManager
+	step           wizardStep
+	config         wizardConfig
+	width          int
+	height         int
+	quitting       bool
+	errorMsg       string
 
 	providerList list.Model
 	textInput    textinput.Model
@@ -57,6 +68,10 @@ type wizardModel struct {
 	progress     progress.Model
 
 	testingInProgress bool
+
+	historySources []historySource
+	// importResults tells what was imported from each source
+	importResults []string
 }
 
 func initialModel(runner *interp.Runner) wizardModel {
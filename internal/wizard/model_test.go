@@ -1,8 +1,14 @@
 package wizard
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/i18n"
 	"mvdan.cc/sh/v3/interp"
 )
 
@@ -32,3 +38,58 @@ func TestWizardConfigCacheReuse(t *testing.T) {
 		t.Errorf("Expected cached key 'sk-test-key', got '%s'", cachedKey)
 	}
 }
+
+func TestImportHistoryStep(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	if err := os.WriteFile(filepath.Join(home, ".zsh_history"), []byte(": 1700000000:0;git status\n: 1700000060:0;make\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	historyManager, err := history.NewHistoryManager(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner, _ := interp.New()
+	model := initialModel(runner)
+	if model.findHistorySources() {
+		t.Error("history should not be offered without a history manager")
+	}
+	model.historyManager = historyManager
+	if !model.findHistorySources() || len(model.historySources) != 1 || model.historySources[0].shell != "zsh" {
+		t.Fatalf("expected the zsh history to be found, got %+v", model.historySources)
+	}
+
+	model.step = stepImportHistory
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(wizardModel)
+	if model.step != stepSummary {
+		t.Errorf("expected the summary step, got %d", model.step)
+	}
+	if len(model.importResults) != 1 || model.importResults[0] != "zsh: 2 commands imported, 0 duplicates skipped" {
+		t.Errorf("unexpected import results %v", model.importResults)
+	}
+	entries, _ := historyManager.GetAllEntries()
+	if len(entries) != 2 {
+		t.Errorf("expected 2 imported entries, got %d", len(entries))
+	}
+}
+
+func TestViewIsTranslated(t *testing.T) {
+	i18n.SetLocale("es")
+	defer i18n.SetLocale(i18n.DefaultLocale)
+
+	runner, _ := interp.New()
+	model := initialModel(runner)
+	model.width, model.height = 100, 40
+	view := model.View()
+	if !strings.Contains(view, "Paso 1/") || !strings.Contains(view, "¡Bienvenido a Bishop!") {
+		t.Errorf("expected the welcome step in Spanish, got:\n%s", view)
+	}
+	// Untranslated messages fall back to English
+	if !strings.Contains(view, "Bishop is a modern, POSIX-compatible, generative shell.") {
+		t.Errorf("expected the English welcome text, got:\n%s", view)
+	}
+}
@@ -2,33 +2,35 @@ package wizard
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/robottwo/bishop/internal/i18n"
 	"github.com/sashabaranov/go-openai"
 )
 
 func validateAPIKeyFormat(apiKey, provider string) error {
 	if apiKey == "" {
-		return fmt.Errorf("API key cannot be empty")
+		return errors.New(i18n.T("wizard.error.empty_key"))
 	}
 
 	switch provider {
 	case "openai":
 		if !strings.HasPrefix(apiKey, "sk-") {
-			return fmt.Errorf("OpenAI API keys must start with 'sk-'")
+			return errors.New(i18n.T("wizard.error.key_prefix", "OpenAI", "sk-"))
 		}
 		if len(apiKey) < 20 {
-			return fmt.Errorf("API key appears to be too short")
+			return errors.New(i18n.T("wizard.error.short_key"))
 		}
 	case "openrouter":
 		if !strings.HasPrefix(apiKey, "sk-or-") {
-			return fmt.Errorf("OpenRouter API keys must start with 'sk-or-'")
+			return errors.New(i18n.T("wizard.error.key_prefix", "OpenRouter", "sk-or-"))
 		}
 		if len(apiKey) < 30 {
-			return fmt.Errorf("API key appears to be too short")
+			return errors.New(i18n.T("wizard.error.short_key"))
 		}
 	case "ollama":
 		if apiKey != "" && apiKey != "ollama" {
@@ -2,7 +2,6 @@ package wizard
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
@@ -13,6 +12,8 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/robottwo/bishop/internal/history"
+	"github.com/robottwo/bishop/internal/i18n"
 	"github.com/sashabaranov/go-openai"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -117,7 +118,7 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						} else {
 							m.step = m.step + 1
 							m.textInput.Reset()
-							m.textInput.Placeholder = fmt.Sprintf("Enter %s API key", item.provider)
+							m.textInput.Placeholder = i18n.T("wizard.api_key.placeholder", item.provider)
 							m.textInput.Focus()
 						}
 					}
@@ -137,7 +138,7 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case tea.KeyEnter:
 				apiKey := m.textInput.Value()
 				if err := validateAPIKeyFormat(apiKey, m.getCurrentProvider()); err != nil {
-					m.errorMsg = fmt.Sprintf("Invalid API key: %v", err)
+					m.errorMsg = i18n.T("wizard.invalid_api_key", err)
 					return m, nil
 				}
 
@@ -202,6 +203,8 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					if m.config.slowModel.testError != "" {
 						m.step = stepSlowAPIKey
+					} else if m.findHistorySources() {
+						m.step = stepImportHistory
 					} else {
 						m.step = stepSummary
 					}
@@ -209,16 +212,31 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.errorMsg = ""
 			}
 
+		case stepImportHistory:
+			switch {
+			case msg.Type == tea.KeyEnter:
+				m.importHistory()
+				m.step = stepSummary
+			case msg.String() == "s":
+				m.step = stepSummary
+			case msg.Type == tea.KeyEsc:
+				m.step = stepSlowTest
+			}
+
 		case stepSummary:
 			switch msg.Type {
 			case tea.KeyEnter:
 				if err := m.saveConfig(); err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save configuration: %v", err)
+					m.errorMsg = i18n.T("wizard.save_failed", err)
 					return m, nil
 				}
 				m.step = stepComplete
 			case tea.KeyEsc:
-				m.step = stepSlowTest
+				if len(m.historySources) > 0 {
+					m.step = stepImportHistory
+				} else {
+					m.step = stepSlowTest
+				}
 			}
 
 		case stepComplete:
@@ -290,65 +308,67 @@ func (m wizardModel) View() string {
 
 	switch m.step {
 	case stepWelcome:
-		title = "Welcome to Bishop Setup"
-		helpText = "Press Enter or Space to continue"
+		title = i18n.T("wizard.title.welcome")
+		helpText = i18n.T("wizard.help.continue")
 		content.WriteString(m.renderWelcome())
 
 	case stepFastProvider, stepSlowProvider:
-		modelType := "Fast"
+		title = i18n.T("wizard.title.fast_provider")
 		if m.step == stepSlowProvider {
-			modelType = "Slow"
+			title = i18n.T("wizard.title.slow_provider")
 		}
-		title = fmt.Sprintf("Configure %s Model Provider", modelType)
-		helpText = "↑/↓: Navigate | Enter: Select | Esc: Back"
+		helpText = i18n.T("wizard.help.provider")
 		content.WriteString(m.renderProviderSelection())
 
 	case stepFastAPIKey, stepSlowAPIKey:
 		provider := m.getCurrentProvider()
-		title = fmt.Sprintf("Enter %s API Key", cases.Title(language.English).String(provider))
-		helpText = "Enter: Save | Esc: Back"
+		title = i18n.T("wizard.title.api_key", cases.Title(language.English).String(provider))
+		helpText = i18n.T("wizard.help.api_key")
 		content.WriteString(m.renderAPIKeyEntry())
 
 	case stepFastModel, stepSlowModel:
-		modelType := "Fast"
+		title = i18n.T("wizard.title.fast_model")
 		if m.step == stepSlowModel {
-			modelType = "Slow"
+			title = i18n.T("wizard.title.slow_model")
 		}
-		title = fmt.Sprintf("Configure %s Model", modelType)
-		helpText = "Type to filter | ↑/↓: Navigate | Enter: Select | Esc: Back"
+		helpText = i18n.T("wizard.help.model")
 		content.WriteString(m.renderModelSelection())
 
 	case stepFastTest, stepSlowTest:
-		modelType := "Fast"
+		title = i18n.T("wizard.title.fast_test")
 		if m.step == stepSlowTest {
-			modelType = "Slow"
+			title = i18n.T("wizard.title.slow_test")
 		}
-		title = fmt.Sprintf("Testing %s Model Connection", modelType)
 		if m.testingInProgress {
-			helpText = "Testing connection..."
+			helpText = i18n.T("wizard.test.testing")
 		} else {
-			helpText = "Enter: Continue"
+			helpText = i18n.T("wizard.help.tested")
 		}
 		content.WriteString(m.renderTestResult())
 
+	case stepImportHistory:
+		title = i18n.T("wizard.title.import_history")
+		helpText = i18n.T("wizard.help.import_history")
+		content.WriteString(m.renderImportHistory())
+
 	case stepSummary:
-		title = "Configuration Summary"
-		helpText = "Enter: Save Configuration | Esc: Back"
+		title = i18n.T("wizard.title.summary")
+		helpText = i18n.T("wizard.help.summary")
 		content.WriteString(m.renderSummary())
 
 	case stepComplete:
-		title = "Setup Complete!"
-		helpText = "Press Enter or Esc to start using Bishop"
+		title = i18n.T("wizard.title.complete")
+		helpText = i18n.T("wizard.help.complete")
 		content.WriteString(m.renderComplete())
 	}
 
-	stepInfo := fmt.Sprintf("Step %d/%d", m.step+1, stepComplete+1)
+	stepInfo := i18n.T("wizard.step", m.step+1, stepComplete+1)
 	stepText := stepIndicator.Render(stepInfo)
 
 	var boxContent strings.Builder
 	boxContent.WriteString(stepText + "\n")
 
-	titlePadding := (availableWidth - len(title)) / 2
+	titlePadding := (availableWidth - lipgloss.Width(title)) / 2
 	if titlePadding < 0 {
 		titlePadding = 0
 	}
@@ -395,17 +415,17 @@ func (m *wizardModel) initProviderList() {
 	items := []list.Item{
 		providerItem{
 			title:       "Ollama",
-			description: "Local LLM (recommended for privacy, no API key needed)",
+			description: i18n.T("wizard.provider.ollama"),
 			provider:    "ollama",
 		},
 		providerItem{
 			title:       "OpenAI",
-			description: "GPT models from OpenAI (requires API key)",
+			description: i18n.T("wizard.provider.openai"),
 			provider:    "openai",
 		},
 		providerItem{
 			title:       "OpenRouter",
-			description: "Access many LLM providers (requires API key)",
+			description: i18n.T("wizard.provider.openrouter"),
 			provider:    "openrouter",
 		},
 	}
@@ -432,27 +452,27 @@ func (m *wizardModel) initModelList(provider string, apiKey string) {
 	models, err := client.ListModels(context.Background())
 	if err != nil {
 		items = []list.Item{
-			modelItem{title: "Error fetching models", description: err.Error(), modelID: ""},
+			modelItem{title: i18n.T("wizard.model.fetch_failed"), description: err.Error(), modelID: ""},
 		}
 	} else {
 		items = make([]list.Item, 0, len(models.Models))
 		for _, model := range models.Models {
 			title := model.ID
-			description := "Available model"
+			description := i18n.T("wizard.model.available")
 
 			switch provider {
 			case "openai":
 				switch model.ID {
 				case "gpt-4o":
-					description = "Latest GPT-4 (recommended)"
+					description = i18n.T("wizard.model.gpt4o")
 				case "gpt-4o-mini":
-					description = "Faster, more cost-effective"
+					description = i18n.T("wizard.model.gpt4o_mini")
 				}
 			case "openrouter":
 				if strings.Contains(model.ID, "gpt-4o") {
-					description = "Via OpenRouter"
+					description = i18n.T("wizard.model.via_openrouter")
 				} else if strings.Contains(model.ID, "claude") {
-					description = "High quality"
+					description = i18n.T("wizard.model.high_quality")
 				}
 			}
 
@@ -470,7 +490,7 @@ func (m *wizardModel) initModelList(provider string, apiKey string) {
 
 	if len(items) == 0 {
 		items = []list.Item{
-			modelItem{title: "No models found", description: "Check your API key and connection", modelID: ""},
+			modelItem{title: i18n.T("wizard.model.none"), description: i18n.T("wizard.model.none_hint"), modelID: ""},
 		}
 	}
 
@@ -478,6 +498,39 @@ func (m *wizardModel) initModelList(provider string, apiKey string) {
 	m.modelList.ResetFilter()
 }
 
+// findHistorySources looks for the history files of other shells, and
+// reports whether there is any to import.
+func (m *wizardModel) findHistorySources() bool {
+	m.historySources = nil
+	if m.historyManager == nil {
+		return false
+	}
+	for _, shell := range history.ImportShells {
+		path := history.DefaultHistoryFile(shell, homeDir())
+		commands, err := history.ReadHistoryFile(shell, path)
+		if err != nil || len(commands) == 0 {
+			continue
+		}
+		m.historySources = append(m.historySources, historySource{shell: shell, path: path, commands: commands})
+	}
+	return len(m.historySources) > 0
+}
+
+// importHistory imports the history files found, once.
+func (m *wizardModel) importHistory() {
+	if len(m.importResults) > 0 {
+		return
+	}
+	for _, source := range m.historySources {
+		result, err := m.historyManager.Import(source.shell, source.commands)
+		if err != nil {
+			m.importResults = append(m.importResults, i18n.T("wizard.import.failed", source.shell, err))
+			continue
+		}
+		m.importResults = append(m.importResults, i18n.T("wizard.import.result", source.shell, result.Imported, result.Skipped))
+	}
+}
+
 func (m wizardModel) saveConfig() error {
 	config := m.config
 
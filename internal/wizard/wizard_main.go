@@ -5,12 +5,16 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/internal/history"
 )
 
-func RunWizard(runner *interp.Runner) error {
+// RunWizard configures the models, and offers to import the history of
+// other shells into historyManager, which may be nil.
+func RunWizard(runner *interp.Runner, historyManager *history.HistoryManager) error {
 	clearScreen()
 
 	model := initialModel(runner)
+	model.historyManager = historyManager
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	_, err := p.Run()
 
@@ -0,0 +1,123 @@
+// Package wsl integrates bish with Windows when it runs under the Windows
+// Subsystem for Linux: Windows paths, the Windows clipboard and browser.
+package wsl
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Detect reports whether the kernel is a WSL one, whose /proc/version
+// mentions Microsoft.
+func Detect(readFile func(string) ([]byte, error)) bool {
+	version, err := readFile("/proc/version")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
+
+var detected = sync.OnceValue(func() bool {
+	return Detect(os.ReadFile)
+})
+
+// Detected reports whether bish runs under WSL.
+func Detected() bool {
+	return detected()
+}
+
+// mountRoot is where WSL mounts Windows drives, unless automount.root is
+// changed in /etc/wsl.conf.
+const mountRoot = "/mnt/"
+
+var (
+	drivePath = regexp.MustCompile(`^([A-Za-z]):(?:[\\/](.*))?$`)
+	// \\wsl$\Ubuntu\home\me and \\wsl.localhost\Ubuntu\home\me are the
+	// distribution's own files as Windows sees them
+	distroPath = regexp.MustCompile(`^\\\\wsl(?:\$|\.localhost)\\[^\\]+(\\.*)?$`)
+)
+
+// ToLinux converts a Windows path such as C:\Users\me\report.pdf to the path
+// WSL sees it at, /mnt/c/Users/me/report.pdf. ok is false for paths that are
+// not Windows paths.
+func ToLinux(windowsPath string) (linuxPath string, ok bool) {
+	if m := drivePath.FindStringSubmatch(windowsPath); m != nil {
+		rest := strings.ReplaceAll(m[2], `\`, "/")
+		return mountRoot + strings.ToLower(m[1]) + "/" + rest, true
+	}
+	if m := distroPath.FindStringSubmatch(windowsPath); m != nil {
+		if m[1] == "" {
+			return "/", true
+		}
+		return strings.ReplaceAll(m[1], `\`, "/"), true
+	}
+	return "", false
+}
+
+// ToWindows converts a Linux path to the path Windows sees it at: files on a
+// mounted drive such as /mnt/c/Users/me become C:\Users\me, and the
+// distribution's own files are reached through \\wsl.localhost\<distro>.
+func ToWindows(linuxPath, distro string) string {
+	if rest, ok := strings.CutPrefix(linuxPath, mountRoot); ok && len(rest) > 0 && (len(rest) == 1 || rest[1] == '/') {
+		return strings.ToUpper(rest[:1]) + `:\` + strings.ReplaceAll(strings.TrimPrefix(rest[1:], "/"), "/", `\`)
+	}
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(linuxPath, "/", `\`)
+}
+
+// ConvertPastedPath converts text that is a single Windows path, as a
+// terminal inserts when a file is dragged onto it, to a Linux path quoted for
+// the shell. Other text is returned unchanged.
+func ConvertPastedPath(text string) string {
+	trimmed := strings.TrimSpace(text)
+	unquoted := strings.Trim(trimmed, `"'`)
+	if unquoted == "" || strings.ContainsAny(unquoted, "\n\"'") {
+		return text
+	}
+	linuxPath, ok := ToLinux(unquoted)
+	if !ok {
+		return text
+	}
+	if strings.ContainsAny(linuxPath, " \t()&;$`\\|<>*?[]{}!#~") {
+		return "'" + linuxPath + "'"
+	}
+	return linuxPath
+}
+
+// WriteClipboard copies text to the Windows clipboard with clip.exe, which
+// reads UTF-16 when the input starts with a byte order mark.
+func WriteClipboard(text string) error {
+	encoded := utf16.Encode([]rune(text))
+	input := bytes.NewBuffer([]byte{0xff, 0xfe})
+	for _, unit := range encoded {
+		input.WriteByte(byte(unit))
+		input.WriteByte(byte(unit >> 8))
+	}
+	cmd := exec.Command("clip.exe")
+	cmd.Stdin = input
+	return cmd.Run()
+}
+
+// ReadClipboard returns the text on the Windows clipboard.
+func ReadClipboard() (string, error) {
+	out, err := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", "Get-Clipboard -Raw").Output()
+	if err != nil {
+		return "", err
+	}
+	text := strings.ReplaceAll(string(out), "\r\n", "\n")
+	// Get-Clipboard ends its output with a newline of its own
+	return strings.TrimSuffix(text, "\n"), nil
+}
+
+// Browser returns the command that opens URLs in the Windows browser, for
+// BROWSER: wslview from wslu if it is installed, explorer.exe otherwise.
+func Browser() string {
+	if _, err := exec.LookPath("wslview"); err == nil {
+		return "wslview"
+	}
+	return "explorer.exe"
+}
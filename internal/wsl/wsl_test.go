@@ -0,0 +1,58 @@
+package wsl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	version := func(content string) func(string) ([]byte, error) {
+		return func(string) ([]byte, error) { return []byte(content), nil }
+	}
+	assert.True(t, Detect(version("Linux version 5.15.153.1-microsoft-standard-WSL2 (root@941d701f84f1)")))
+	assert.True(t, Detect(version("Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com)")))
+	assert.False(t, Detect(version("Linux version 6.8.0-45-generic (buildd@lcy02-amd64-075)")))
+	assert.False(t, Detect(func(string) ([]byte, error) { return nil, errors.New("no /proc") }))
+}
+
+func TestToLinux(t *testing.T) {
+	tests := map[string]string{
+		`C:\Users\me\report.pdf`:           "/mnt/c/Users/me/report.pdf",
+		`d:/data/set.csv`:                  "/mnt/d/data/set.csv",
+		`C:`:                               "/mnt/c/",
+		`\\wsl$\Ubuntu\home\me\notes.md`:   "/home/me/notes.md",
+		`\\wsl.localhost\Debian\etc\hosts`: "/etc/hosts",
+		`\\wsl$\Ubuntu`:                    "/",
+	}
+	for windowsPath, expected := range tests {
+		linuxPath, ok := ToLinux(windowsPath)
+		assert.True(t, ok, windowsPath)
+		assert.Equal(t, expected, linuxPath, windowsPath)
+	}
+
+	for _, notWindows := range []string{"/home/me", "docs", `\\server\share\file`, "http://example.com"} {
+		_, ok := ToLinux(notWindows)
+		assert.False(t, ok, notWindows)
+	}
+}
+
+func TestToWindows(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/c/Users/me/report.pdf": `C:\Users\me\report.pdf`,
+		"/mnt/d":                     `D:\`,
+		"/home/me/notes.md":          `\\wsl.localhost\Ubuntu\home\me\notes.md`,
+		"/mnt/wsl/shared":            `\\wsl.localhost\Ubuntu\mnt\wsl\shared`,
+	}
+	for linuxPath, expected := range tests {
+		assert.Equal(t, expected, ToWindows(linuxPath, "Ubuntu"), linuxPath)
+	}
+}
+
+func TestConvertPastedPath(t *testing.T) {
+	assert.Equal(t, "/mnt/c/Users/me/report.pdf", ConvertPastedPath(`C:\Users\me\report.pdf`))
+	assert.Equal(t, "'/mnt/c/Users/me/My Documents/a.txt'", ConvertPastedPath(`"C:\Users\me\My Documents\a.txt"`))
+	assert.Equal(t, "echo hello", ConvertPastedPath("echo hello"))
+	assert.Equal(t, "C:\\a\nC:\\b", ConvertPastedPath("C:\\a\nC:\\b"))
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/cursor"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/robottwo/bishop/internal/git"
+	"github.com/robottwo/bishop/internal/redact"
 	"github.com/robottwo/bishop/internal/system"
 	"github.com/robottwo/bishop/pkg/shellinput"
 	"go.uber.org/zap"
@@ -23,6 +25,7 @@ type appModel struct {
 	options   Options
 
 	textInput           shellinput.Model
+	masked              maskedHistory // Commands of the history shown masked, by how they show
 	dirty               bool
 	prediction          string
 	explanation         string
@@ -31,11 +34,25 @@ type appModel struct {
 	lastPredictionInput string
 	lastPrediction      string
 	predictionStateId   int
+	// candidates are the predictions for the input, of which prediction
+	// is the one selected, and candidateExplanations their explanations
+	// as they arrive
+	candidates            []string
+	candidateExplanations map[string]string
+	// localPrediction is the prediction of the local predictor while it is
+	// the one shown
+	localPrediction string
 
 	historyValues []string
 	result        string
 	appState      appState
 	interrupted   bool
+	// ctrlXPending is set after Ctrl+X, which starts Ctrl+X Ctrl+E
+	ctrlXPending bool
+	// programPreview previews the jq or awk program the cursor is in, in
+	// place of the explanation
+	programPreview        string
+	programPreviewStateId int
 
 	explanationStyle lipgloss.Style
 	completionStyle  lipgloss.Style
@@ -53,6 +70,10 @@ type appModel struct {
 
 	// LLM status indicator
 	llmIndicator LLMIndicator
+	// tick schedules a message, on the clock of the test harness in tests
+	tick func(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd
+	// render is what the render profile changes
+	render renderSettings
 
 	// Border Status
 	borderStatus BorderStatusModel
@@ -71,9 +92,16 @@ type attemptPredictionMsg struct {
 	stateId int
 }
 
+// inputSettledMsg is sent once a burst of input, such as a paste or the text
+// an input method commits, has stopped coming in.
+type inputSettledMsg struct {
+	stateId int
+}
+
 type setPredictionMsg struct {
 	stateId      int
 	prediction   string
+	candidates   []string
 	inputContext string
 }
 
@@ -87,10 +115,28 @@ type resourceMsg struct {
 	resources *system.Resources
 }
 
+// gitStatusWait is how long the border waits for a git status refresh
+// before keeping the cached status.
+const gitStatusWait = 30 * time.Second
+
 type gitStatusMsg struct {
 	status *git.RepoStatus
 }
 
+// historyPollMsg carries history items recorded by other shells
+type historyPollMsg struct {
+	items []shellinput.HistoryItem
+}
+
+// historyPollTickMsg triggers the next history poll
+type historyPollTickMsg struct{}
+
+// timerTickMsg updates the countdown of the running timer
+type timerTickMsg struct{}
+
+// segmentsTickMsg updates the status segments
+type segmentsTickMsg struct{}
+
 type promptMsg struct { //nolint:unused // Will be used in subtask-1-2 (fetchPrompt) and subtask-1-3 (prompt message handler)
 	stateId int
 	prompt  string
@@ -104,6 +150,7 @@ type errorMsg struct {
 
 type setExplanationMsg struct {
 	stateId     int
+	prediction  string
 	explanation string
 }
 
@@ -149,11 +196,26 @@ func initialModel(
 	logger *zap.Logger,
 	options Options,
 ) appModel {
+	masked := maskedHistory{}
+	if options.Redact != nil {
+		prompt = options.Redact(prompt)
+		historyValues = masked.redactAll(historyValues, options.Redact)
+		options.RichHistory = masked.redactItems(options.RichHistory, options.Redact)
+		if pager := options.RichHistoryPager; pager != nil {
+			options.RichHistoryPager = func(offset, limit int) ([]shellinput.HistoryItem, int) {
+				items, total := pager(offset, limit)
+				return masked.redactItems(items, options.Redact), total
+			}
+		}
+	}
+
 	textInput := shellinput.New()
 	textInput.Prompt = prompt
 	textInput.SetHistoryValues(historyValues)
 	// Initialize rich history if available
-	if len(options.RichHistory) > 0 {
+	if options.RichHistoryPager != nil {
+		textInput.SetRichHistoryPager(options.RichHistoryPager)
+	} else if len(options.RichHistory) > 0 {
 		textInput.SetRichHistory(options.RichHistory)
 	}
 	if options.CurrentDirectory != "" {
@@ -162,19 +224,48 @@ func initialModel(
 	if options.CurrentSessionID != "" {
 		textInput.SetCurrentSessionID(options.CurrentSessionID)
 	}
+	if options.ScopedHistory != nil {
+		textInput.SetHistoryScope(options.HistoryScope)
+	}
 	// Set initial value if provided (e.g., for editing a suggested fix)
 	if options.InitialValue != "" {
 		textInput.SetValue(options.InitialValue)
 	}
 	textInput.Cursor.SetMode(cursor.CursorStatic)
 	textInput.ShowSuggestions = true
+	textInput.AutoPair = options.AutoPair
+	textInput.PasteFilter = options.PasteFilter
+	textInput.UsualFlags = options.UsualFlags
+	textInput.ArgHistory = options.ArgHistory
+	textInput.NextCommands = options.NextCommands
+	textInput.Abbreviations = options.Abbreviations
+	if options.KeyMap != nil {
+		textInput.KeyMap = *options.KeyMap
+	}
 	textInput.CompletionProvider = options.CompletionProvider
 	textInput.Focus()
 
 	borderStatus := NewBorderStatusModel()
+	if options.PathStyle != "" {
+		borderStatus.SetPathStyle(options.PathStyle)
+	}
 	borderStatus.UpdateContext(options.User, options.Host, options.CurrentDirectory)
+	borderStatus.SetFocus(options.Focus)
+	borderStatus.SetQuietUntil(options.QuietUntil)
+	if options.Timer != nil {
+		borderStatus.SetTimer(options.Timer())
+	}
+	if options.StatusSegments != nil {
+		borderStatus.SetSegments(options.StatusSegments())
+	}
+	if options.CurrentDirectory != "" {
+		// Show the cached git status right away; fetchGitStatus updates it
+		if status := git.DefaultStatusCache.Cached(options.CurrentDirectory); status != nil {
+			borderStatus.UpdateGit(status)
+		}
+	}
 
-	return appModel{
+	m := appModel{
 		predictor: predictor,
 		explainer: explainer,
 		analytics: analytics,
@@ -182,6 +273,7 @@ func initialModel(
 		options:   options,
 
 		textInput:          textInput,
+		masked:             masked,
 		dirty:              options.InitialValue != "", // Mark dirty if we have initial value
 		prediction:         "",
 		explanation:        explanation,
@@ -214,6 +306,8 @@ func initialModel(
 
 		llmIndicator: NewLLMIndicator(),
 		borderStatus: borderStatus,
+		render:       options.RenderProfile.settings(),
+		tick:         tea.Tick,
 
 		// Initialize idle summary tracking
 		lastInputTime:        time.Now(),
@@ -224,11 +318,15 @@ func initialModel(
 		idleSummaryStyle:     lipgloss.NewStyle().Foreground(lipgloss.Color("75")),  // Soft blue for summary
 		idleSummaryHintStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("241")), // Subtle gray for hint
 	}
+	if options.InitialValue == "" {
+		m.showFollowUp()
+	}
+	return m
 }
 
 func (m appModel) Init() tea.Cmd {
 	cmds := []tea.Cmd{
-		m.llmIndicator.Tick(),
+		m.llmTick(),
 		func() tea.Msg {
 			return attemptPredictionMsg{
 				stateId: m.predictionStateId,
@@ -248,17 +346,79 @@ func (m appModel) Init() tea.Cmd {
 		cmds = append(cmds, m.scheduleIdleCheck())
 	}
 
+	// Count down the running timer
+	if m.options.Timer != nil {
+		cmds = append(cmds, m.scheduleTimerTick())
+	}
+
+	if m.options.StatusSegments != nil {
+		cmds = append(cmds, m.scheduleSegmentsTick())
+	}
+
+	// Start polling for other shells' history if enabled
+	if m.options.HistoryPoller != nil && m.options.HistoryPollInterval > 0 {
+		cmds = append(cmds, m.scheduleHistoryPoll())
+	}
+
 	return tea.Batch(cmds...)
 }
 
+// llmTick advances the animation of the LLM indicator, unless the render
+// profile has no animations.
+func (m appModel) llmTick() tea.Cmd {
+	if !m.render.animate {
+		return nil
+	}
+	return m.tick(llmTickInterval, func(t time.Time) tea.Msg {
+		return LLMTickMsg{}
+	})
+}
+
 func (m appModel) scheduleIdleCheck() tea.Cmd {
 	stateId := m.idleSummaryStateId
 	timeout := time.Duration(m.options.IdleSummaryTimeout) * time.Second
-	return tea.Tick(timeout, func(t time.Time) tea.Msg {
+	return m.tick(timeout, func(t time.Time) tea.Msg {
 		return idleCheckMsg{stateId: stateId}
 	})
 }
 
+// scheduleTimerTick updates the countdown on the next second of the timer.
+func (m appModel) scheduleTimerTick() tea.Cmd {
+	_, ends := m.options.Timer()
+	if ends.IsZero() {
+		return nil
+	}
+	// Until the clock replaces an ended timer, check again shortly
+	next := 100 * time.Millisecond
+	if remaining := time.Until(ends); remaining > 0 {
+		next = remaining%m.render.statusInterval + time.Millisecond
+	}
+	return m.tick(next, func(t time.Time) tea.Msg {
+		return timerTickMsg{}
+	})
+}
+
+func (m appModel) scheduleSegmentsTick() tea.Cmd {
+	return m.tick(m.render.statusInterval, func(t time.Time) tea.Msg {
+		return segmentsTickMsg{}
+	})
+}
+
+func (m appModel) scheduleHistoryPoll() tea.Cmd {
+	return m.tick(m.options.HistoryPollInterval, func(t time.Time) tea.Msg {
+		return historyPollTickMsg{}
+	})
+}
+
+func (m appModel) pollHistory() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+
+		return historyPollMsg{items: m.options.HistoryPoller(ctx)}
+	}
+}
+
 func (m appModel) fetchResources() tea.Cmd {
 	return func() tea.Msg {
 		res := system.GetResources()
@@ -271,11 +431,13 @@ func (m appModel) fetchGitStatus() tea.Cmd {
 		if m.options.CurrentDirectory == "" {
 			return nil
 		}
-		// Create a context with timeout for git status check
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		// The status comes from a cache that is refreshed in the background,
+		// so that large repositories do not hold up the prompt; wait for the
+		// refresh that is in flight, if any
+		ctx, cancel := context.WithTimeout(context.Background(), gitStatusWait)
 		defer cancel()
 
-		status := git.GetStatusWithContext(ctx, m.options.CurrentDirectory)
+		status := git.DefaultStatusCache.Refreshed(ctx, m.options.CurrentDirectory)
 		return gitStatusMsg{status: status}
 	}
 }
@@ -289,11 +451,74 @@ func (m appModel) fetchPrompt() tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
 
-		prompt := m.options.PromptGenerator(ctx)
+		prompt := m.redact(m.options.PromptGenerator(ctx))
 		return promptMsg{stateId: m.promptStateId, prompt: prompt}
 	}
 }
 
+// redact masks secrets in s if Options.Redact is set.
+func (m appModel) redact(s string) string {
+	if m.options.Redact == nil {
+		return s
+	}
+	return m.options.Redact(s)
+}
+
+// cycleHistoryScope moves Up/Down and Ctrl+R search to the next history
+// scope, and says which it is in the assistant box.
+func (m appModel) cycleHistoryScope() (tea.Model, tea.Cmd) {
+	scope := m.textInput.HistoryScope().Next()
+	values := m.options.ScopedHistory(scope)
+	if m.options.Redact != nil {
+		values = m.masked.redactAll(values, m.options.Redact)
+	}
+	m.textInput.SetHistoryScope(scope)
+	m.textInput.SetHistoryValues(values)
+	m.explanation = "History: " + scope.String()
+	return m, nil
+}
+
+// maskedHistory maps the history entries shown with their secrets masked
+// back to the commands they are, so that a recalled command runs as it was
+// typed rather than with the mask in it.
+type maskedHistory map[string]string
+
+func (masked maskedHistory) redact(command string, redact func(string) string) string {
+	redacted := redact(command)
+	if redacted != command {
+		masked[redacted] = command
+	}
+	return redacted
+}
+
+func (masked maskedHistory) redactAll(values []string, redact func(string) string) []string {
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		redacted[i] = masked.redact(value, redact)
+	}
+	return redacted
+}
+
+func (masked maskedHistory) redactItems(items []shellinput.HistoryItem, redact func(string) string) []shellinput.HistoryItem {
+	redacted := make([]shellinput.HistoryItem, len(items))
+	for i, item := range items {
+		item.Command = masked.redact(item.Command, redact)
+		redacted[i] = item
+	}
+	return redacted
+}
+
+// unmask returns the command line is, if it was recalled from history with
+// its secrets masked, and whether it can run: a line with the mask in it
+// that is not such a command cannot.
+func (m appModel) unmask(line string) (string, bool) {
+	if m.options.Redact == nil || !strings.Contains(line, redact.Mask) {
+		return line, true
+	}
+	command, ok := m.masked[line]
+	return command, ok
+}
+
 func Gline(
 	prompt string,
 	historyValues []string,
@@ -304,8 +529,14 @@ func Gline(
 	logger *zap.Logger,
 	options Options,
 ) (string, string, error) {
+	if options.RenderProfile == RenderProfileAuto {
+		var latency time.Duration
+		options.RenderProfile, latency = DetectRenderProfile()
+		logger.Debug("detected render profile", zap.Stringer("profile", options.RenderProfile), zap.Duration("latency", latency))
+	}
 	p := tea.NewProgram(
 		initialModel(prompt, historyValues, explanation, predictor, explainer, analytics, logger, options),
+		tea.WithFPS(options.RenderProfile.settings().fps),
 	)
 
 	m, err := p.Run()
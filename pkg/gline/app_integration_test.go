@@ -2,10 +2,12 @@ package gline
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/internal/redact"
 	"github.com/robottwo/bishop/pkg/shellinput"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1278,3 +1280,87 @@ func TestAsyncPrompt_Integration(t *testing.T) {
 		assert.Nil(t, msg, "Expected nil message when no prompt generator is set")
 	})
 }
+
+func TestRedactMasksSecretsAndDropsRevealingPredictions(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	options := NewOptions()
+	options.Redact = func(s string) string {
+		return strings.ReplaceAll(s, "hunter2", "***")
+	}
+	options.RichHistory = []shellinput.HistoryItem{{Command: "mysql -phunter2"}}
+
+	model := initialModel(
+		"hunter2> ",
+		[]string{"mysql -phunter2", "ls"},
+		"",
+		newMockPredictor(),
+		newMockExplainer(),
+		nil,
+		logger,
+		options,
+	)
+
+	assert.Equal(t, "***> ", model.textInput.Prompt)
+	assert.Equal(t, []string{"mysql -p***", "ls"}, model.historyValues)
+
+	model.textInput.SetValue("mysql")
+	model.textInput.SetCursor(len("mysql"))
+	result, _ := model.setPrediction(model.predictionStateId, "mysql -phunter2", "mysql")
+	assert.Empty(t, result.prediction)
+
+	result, _ = model.setPrediction(model.predictionStateId, "mysql -u root", "mysql")
+	assert.Equal(t, "mysql -u root", result.prediction)
+}
+
+func TestAltHCyclesHistoryScope(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	options := NewOptions()
+	options.HistoryScope = shellinput.HistoryFilterDirectory
+	var requested []shellinput.HistoryFilterMode
+	options.ScopedHistory = func(scope shellinput.HistoryFilterMode) []string {
+		requested = append(requested, scope)
+		return []string{"echo " + scope.String()}
+	}
+
+	model := initialModel("test> ", []string{"echo Directory"}, "", newMockPredictor(), newMockExplainer(), nil, logger, options)
+	assert.Equal(t, shellinput.HistoryFilterDirectory, model.textInput.HistoryScope())
+
+	altH := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}, Alt: true}
+	updatedModel, _ := model.Update(altH)
+	model = updatedModel.(appModel)
+	assert.Equal(t, shellinput.HistoryFilterSession, model.textInput.HistoryScope())
+	assert.Equal(t, "History: Session", model.explanation)
+
+	updatedModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	model = updatedModel.(appModel)
+	assert.Equal(t, "echo Session", model.textInput.Value())
+
+	updatedModel, _ = model.Update(altH)
+	model = updatedModel.(appModel)
+	assert.Equal(t, shellinput.HistoryFilterAll, model.textInput.HistoryScope())
+	assert.Equal(t, []shellinput.HistoryFilterMode{shellinput.HistoryFilterSession, shellinput.HistoryFilterAll}, requested)
+}
+
+func TestRedactedHistoryRunsUnmasked(t *testing.T) {
+	options := NewOptions()
+	options.Redact = func(s string) string {
+		return strings.ReplaceAll(s, "hunter2", redact.Mask)
+	}
+	h := NewHarness(HarnessConfig{Prompt: "> ", History: []string{"mysql -phunter2"}, Options: options})
+
+	require.NoError(t, h.Press("up"))
+	assert.Equal(t, "mysql -p"+redact.Mask, h.Value(), "the secret is not shown")
+	assert.NotContains(t, h.Frame(), "hunter2")
+	require.NoError(t, h.Press("enter"))
+	line, done := h.Done()
+	assert.True(t, done)
+	assert.Equal(t, "mysql -phunter2", line, "the command runs as it was typed")
+
+	h = NewHarness(HarnessConfig{Prompt: "> ", History: []string{"mysql -phunter2"}, Options: options})
+	require.NoError(t, h.Press("up"))
+	h.Type(" -e 'select 1'")
+	require.NoError(t, h.Press("enter"))
+	_, done = h.Done()
+	assert.False(t, done, "an edited line with the mask in it does not run")
+	assert.Contains(t, h.Frame(), "masked secrets")
+}
@@ -3,9 +3,11 @@ package gline
 import (
 	"context"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -149,6 +151,20 @@ func TestAppModelUpdate(t *testing.T) {
 	}
 }
 
+func TestEnterExpandsAbbreviation(t *testing.T) {
+	options := NewOptions()
+	options.Abbreviations = func(word string) (string, bool) {
+		return "git checkout", word == "gco"
+	}
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), options)
+	model.textInput.SetValue("gco")
+
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(appModel)
+	assert.Equal(t, "git checkout", model.result)
+	assert.Equal(t, "git checkout", model.textInput.Value())
+}
+
 // Test window resize handling
 func TestAppModelWindowResize(t *testing.T) {
 	logger := zap.NewNop()
@@ -421,6 +437,259 @@ func TestPromptMessageHandling(t *testing.T) {
 		modelAfterUpdate, ok := updatedModel.(appModel)
 		assert.True(t, ok)
 		assert.Equal(t, updatedPrompt, modelAfterUpdate.cachedPrompt, "cachedPrompt should be updated")
-		assert.Equal(t, updatedPrompt+" ", modelAfterUpdate.textInput.Prompt, "textInput.Prompt should be updated with trailing space")
+		assert.Equal(t, updatedPrompt, modelAfterUpdate.textInput.Prompt, "textInput.Prompt should be updated as it is, like the initial prompt")
+		assert.Equal(t, updatedPrompt, modelAfterUpdate.originalPrompt, "the scrollback should show the updated prompt")
 	})
 }
+
+func TestNextCommandMenuDoesNotSubmit(t *testing.T) {
+	logger := zap.NewNop()
+	options := NewOptions()
+	options.NextCommands = func() []string {
+		return []string{"make test", "git status"}
+	}
+	options.RerankNextCommands = func(ctx context.Context, commands []string) ([]string, error) {
+		return []string{commands[1], commands[0]}, nil
+	}
+	model := initialModel("test> ", []string{}, "", newMockPredictor(), nil, nil, logger, options)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	model = updated.(appModel)
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	model = updated.(appModel)
+	assert.True(t, model.textInput.InNextCommandMenu())
+	assert.Contains(t, model.View(), "make test")
+
+	// The re-ranked order replaces the statistical one
+	assert.NotNil(t, cmd)
+	updated, _ = model.Update(model.rerankNextCommands()())
+	model = updated.(appModel)
+	assert.Equal(t, []string{"git status", "make test"}, model.textInput.NextCommandMenuItems())
+
+	// Enter picks a command instead of submitting the line
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(appModel)
+	assert.Equal(t, Active, model.appState)
+	assert.False(t, model.textInput.InNextCommandMenu())
+	assert.Equal(t, "git status", model.textInput.Value())
+}
+
+func TestFollowUpSuggestion(t *testing.T) {
+	logger := zap.NewNop()
+	options := NewOptions()
+	options.FollowUp = "git commit "
+	options.FollowUpNote = "From your history: after `git add -A` you usually run `git commit` next (3 of 4 times)"
+	model := initialModel("test> ", []string{}, "coach tip", newMockPredictor(), nil, nil, logger, options)
+
+	assert.Equal(t, "git commit ", model.prediction)
+	assert.Equal(t, options.FollowUpNote, model.explanation)
+	sized, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 24})
+	assert.Contains(t, sized.View(), "git commit")
+	assert.Contains(t, sized.View(), "From your history")
+
+	// An empty prediction for the blank line keeps it
+	updated, _ := model.setPrediction(model.predictionStateId, "", "")
+	assert.Equal(t, "git commit ", updated.prediction)
+	assert.Equal(t, options.FollowUpNote, updated.explanation)
+
+	// It comes back when the line is cleared
+	updated.clearPrediction()
+	updated.clearPredictionAndRestoreDefault()
+	assert.Equal(t, "git commit ", updated.prediction)
+
+	// Not shown when editing a prefilled line, or if it would reveal a secret
+	options.InitialValue = "ls"
+	model = initialModel("test> ", []string{}, "coach tip", newMockPredictor(), nil, nil, logger, options)
+	assert.Equal(t, "", model.prediction)
+	options.InitialValue = ""
+	options.Redact = func(s string) string { return "***" }
+	model = initialModel("test> ", []string{}, "coach tip", newMockPredictor(), nil, nil, logger, options)
+	assert.Equal(t, "", model.prediction)
+	assert.Equal(t, "coach tip", model.explanation)
+}
+
+// mockCandidatePredictor predicts several candidates for any input.
+type mockCandidatePredictor struct {
+	mockPredictor
+	candidates []string
+	requested  int
+}
+
+func (m *mockCandidatePredictor) PredictCandidates(ctx context.Context, input string, n int) ([]string, string, error) {
+	m.requested = n
+	return m.candidates, "candidates context", nil
+}
+
+func TestPredictionCandidates(t *testing.T) {
+	logger := zap.NewNop()
+	options := NewOptions()
+	options.PredictionCandidates = 3
+	predictor := &mockCandidatePredictor{candidates: []string{"git status", "git diff", "git log"}}
+	model := initialModel("test> ", []string{}, "", predictor, newMockExplainer(), nil, logger, options)
+	model.textInput.SetValue("git")
+
+	// runUntilIdle feeds the messages of cmd back into the model until
+	// there are no more
+	runUntilIdle := func(cmd tea.Cmd) {
+		for cmd != nil {
+			msg := cmd()
+			if msg == nil {
+				return
+			}
+			var updated tea.Model
+			updated, cmd = model.Update(msg)
+			model = updated.(appModel)
+		}
+	}
+	model, cmd := model.attemptPrediction(attemptPredictionMsg{stateId: model.predictionStateId})
+	runUntilIdle(cmd)
+	assert.Equal(t, 3, predictor.requested)
+	assert.Equal(t, "git status", model.prediction)
+	assert.Equal(t, "git status", model.textInput.CurrentSuggestion())
+	assert.Equal(t, "Shows the status of the working directory", model.explanation)
+	sized, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 24})
+	assert.Contains(t, sized.View(), "Prediction 1 of 3")
+
+	// Alt+] shows the next candidate and explains it
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}, Alt: true})
+	model = updated.(appModel)
+	assert.Equal(t, "git diff", model.prediction)
+	assert.Equal(t, "git diff", model.textInput.CurrentSuggestion())
+	assert.Equal(t, "git", model.textInput.Value())
+	runUntilIdle(cmd)
+	assert.Equal(t, "No explanation available", model.explanation)
+
+	// Alt+[ goes back, reusing the explanation, and wraps around
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'['}, Alt: true})
+	model = updated.(appModel)
+	assert.Nil(t, cmd)
+	assert.Equal(t, "git status", model.prediction)
+	assert.Equal(t, "Shows the status of the working directory", model.explanation)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'['}, Alt: true})
+	model = updated.(appModel)
+	assert.Equal(t, "git log", model.prediction)
+
+	// An explanation that arrives after cycling away is kept for later
+	model.candidateExplanations = map[string]string{}
+	updated, _ = model.Update(setExplanationMsg{stateId: model.predictionStateId, prediction: "git diff", explanation: "Shows changes"})
+	model = updated.(appModel)
+	assert.Equal(t, "git log", model.prediction)
+	assert.NotEqual(t, "Shows changes", model.explanation)
+	assert.Equal(t, "Shows changes", model.candidateExplanations["git diff"])
+
+	// Candidates that no longer complete the line are skipped
+	model.textInput.SetValue("git l")
+	assert.Equal(t, []string{"git log"}, model.matchingCandidates())
+	assert.Equal(t, "", model.candidateHint())
+}
+
+func TestPredictionCandidatesWithOne(t *testing.T) {
+	options := NewOptions()
+	options.PredictionCandidates = 1
+	predictor := &mockCandidatePredictor{mockPredictor: *newMockPredictor(), candidates: []string{"git status", "git diff"}}
+	model := initialModel("test> ", []string{}, "", predictor, nil, nil, zap.NewNop(), options)
+	model.textInput.SetValue("git")
+
+	model, cmd := model.attemptPrediction(attemptPredictionMsg{stateId: model.predictionStateId})
+	updated, _ := model.Update(cmd())
+	model = updated.(appModel)
+	assert.Equal(t, 0, predictor.requested)
+	assert.Equal(t, "git status", model.prediction)
+	assert.Empty(t, model.candidates)
+
+	// Without several candidates, the keys do nothing
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}, Alt: true})
+	assert.Nil(t, cmd)
+	assert.Equal(t, "git", updated.(appModel).textInput.Value())
+}
+
+func TestLocalPrediction(t *testing.T) {
+	typeText := func(model appModel, text string) appModel {
+		for _, r := range text {
+			updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			model = updated.(appModel)
+		}
+		return model
+	}
+	options := NewOptions()
+	options.LocalPredictor = &mockPredictor{predictions: map[string]string{"git": "git status", "git s": "git status"}}
+	predictor := &mockPredictor{predictions: map[string]string{"git": "git commit"}}
+	model := initialModel("test> ", []string{}, "", predictor, nil, nil, zap.NewNop(), options)
+
+	// The local prediction shows right away
+	model = typeText(model, "git")
+	assert.Equal(t, "git status", model.prediction)
+	assert.Equal(t, "git status", model.textInput.CurrentSuggestion())
+
+	// and gives way to the predictor's
+	updated, _ := model.setPrediction(model.predictionStateId, "git commit", "git")
+	assert.Equal(t, "git commit", updated.prediction)
+	assert.Equal(t, "", updated.localPrediction)
+
+	// unless it has none, or fails
+	updated, _ = model.setPrediction(model.predictionStateId, "", "git")
+	assert.Equal(t, "git status", updated.prediction)
+	next, _ := model.Update(errorMsg{stateId: model.predictionStateId, err: assert.AnError})
+	failed := next.(appModel)
+	assert.Equal(t, "git status", failed.prediction)
+	assert.Equal(t, "git status", failed.textInput.CurrentSuggestion())
+
+	// Typing on asks the predictor again, even if the local prediction
+	// still completes the line
+	stateID := model.predictionStateId
+	model = typeText(model, " s")
+	assert.Greater(t, model.predictionStateId, stateID)
+	assert.Equal(t, "git status", model.prediction)
+}
+
+func TestInputBurstHoldsBackPredictions(t *testing.T) {
+	options := NewOptions()
+	options.LocalPredictor = &mockPredictor{predictions: map[string]string{"git": "git status", "日本語": "日本語 テスト"}}
+	predictor := &mockPredictor{predictions: map[string]string{"git": "git commit"}}
+	model := initialModel("test> ", []string{}, "", predictor, nil, nil, zap.NewNop(), options)
+	var settled []tea.Msg
+	model.tick = func(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+		if d >= burstSettleDelay {
+			settled = append(settled, fn(time.Now()))
+		}
+		return nil
+	}
+
+	// An input method commits its text in parts; nothing is predicted for them
+	for _, part := range []string{"日本", "語"} {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(part), Paste: part == "語"})
+		model = updated.(appModel)
+		assert.Equal(t, "", model.prediction)
+		assert.Empty(t, model.textInput.CurrentSuggestion())
+	}
+	assert.Equal(t, "日本語", model.textInput.Value())
+	require.Len(t, settled, 2)
+
+	// Only once the input settles, for the last part, are predictions made
+	updated, cmd := model.Update(settled[0])
+	assert.Nil(t, cmd)
+	assert.Equal(t, "", updated.(appModel).prediction)
+	updated, cmd = model.Update(settled[1])
+	model = updated.(appModel)
+	assert.Equal(t, "日本語 テスト", model.prediction)
+	require.NotNil(t, cmd)
+	assert.Equal(t, attemptPredictionMsg{stateId: model.predictionStateId}, cmd())
+}
+
+func TestLocalPredictionWithoutPredictor(t *testing.T) {
+	options := NewOptions()
+	options.LocalPredictor = &mockPredictor{predictions: map[string]string{"ls": "ls -la"}}
+	model := initialModel("test> ", []string{}, "coach tip", nil, nil, nil, zap.NewNop(), options)
+
+	for _, r := range "ls" {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(appModel)
+	}
+	assert.Equal(t, "ls -la", model.prediction)
+	assert.Equal(t, "ls -la", model.textInput.CurrentSuggestion())
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	model = updated.(appModel)
+	assert.Equal(t, "", model.prediction)
+	assert.Empty(t, model.textInput.CurrentSuggestion())
+}
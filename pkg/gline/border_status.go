@@ -3,11 +3,12 @@ package gline
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/robottwo/bishop/internal/git"
+	"github.com/robottwo/bishop/internal/pathfmt"
 	"github.com/robottwo/bishop/internal/system"
 )
 
@@ -45,8 +46,26 @@ type BorderStatusModel struct {
 	user      string
 	host      string
 	cwd       string
+	gitRoot   string
 	gitStatus *git.RepoStatus
 
+	// pathStyle controls how the current directory is abbreviated
+	pathStyle pathfmt.Style
+
+	// focus is the task declared with #!focus
+	focus string
+
+	// quietUntil is when the quiet mode started with #!quiet ends
+	quietUntil time.Time
+
+	// timerLabel and timerEnds describe the timer started with the timer
+	// builtin
+	timerLabel string
+	timerEnds  time.Time
+
+	// segments are the outputs of the configured status commands
+	segments []string
+
 	// Resource State
 	resources *system.Resources
 
@@ -67,6 +86,7 @@ type BorderStyles struct {
 	ContextUser lipgloss.Style
 	ContextDir  lipgloss.Style
 	ContextGit  lipgloss.Style
+	ContextTask lipgloss.Style
 	Divider     lipgloss.Style
 
 	ResCool  lipgloss.Style
@@ -89,6 +109,7 @@ func NewBorderStatusModel() BorderStatusModel {
 		ContextUser: lipgloss.NewStyle().Foreground(lipgloss.Color("62")),  // match border color
 		ContextDir:  lipgloss.NewStyle().Foreground(lipgloss.Color("62")),  // match border color
 		ContextGit:  lipgloss.NewStyle().Foreground(lipgloss.Color("246")), // gray default
+		ContextTask: lipgloss.NewStyle().Foreground(lipgloss.Color("141")), // purple
 		Divider:     lipgloss.NewStyle().Foreground(lipgloss.Color("62")),  // match border color
 
 		ResCool:  lipgloss.NewStyle().Foreground(lipgloss.Color("42")),  // green
@@ -98,10 +119,41 @@ func NewBorderStatusModel() BorderStatusModel {
 	}
 
 	return BorderStatusModel{
-		styles: s,
+		styles:    s,
+		pathStyle: pathfmt.StyleAuto,
 	}
 }
 
+// SetPathStyle sets how the current directory is abbreviated in the border.
+func (m *BorderStatusModel) SetPathStyle(style pathfmt.Style) {
+	m.pathStyle = style
+	m.updateGitRoot()
+}
+
+// SetFocus sets the task shown next to the directory, or hides it if empty.
+func (m *BorderStatusModel) SetFocus(task string) {
+	m.focus = task
+}
+
+// SetQuietUntil sets when quiet mode ends. The remaining time is shown next to
+// the resources until then.
+func (m *BorderStatusModel) SetQuietUntil(until time.Time) {
+	m.quietUntil = until
+}
+
+// SetTimer sets the label of the running timer and when it ends. Its
+// countdown is shown next to the resources until then.
+func (m *BorderStatusModel) SetTimer(label string, ends time.Time) {
+	m.timerLabel = label
+	m.timerEnds = ends
+}
+
+// SetSegments sets the outputs of the status commands, shown next to the
+// resources.
+func (m *BorderStatusModel) SetSegments(segments []string) {
+	m.segments = segments
+}
+
 func (m *BorderStatusModel) SetWidth(w int) {
 	m.width = w
 }
@@ -119,6 +171,29 @@ func (m *BorderStatusModel) UpdateContext(user, host, cwd string) {
 	m.user = user
 	m.host = host
 	m.cwd = cwd
+	m.updateGitRoot()
+}
+
+// updateGitRoot looks up the repository root for git-relative path display.
+// The lookup is skipped for other styles since they do not need it.
+func (m *BorderStatusModel) updateGitRoot() {
+	m.gitRoot = ""
+	if m.pathStyle == pathfmt.StyleGit {
+		m.gitRoot = pathfmt.FindGitRoot(m.cwd)
+	}
+}
+
+// resolveHomeDir returns the home directory, first trying the HOME env var and
+// then os.UserHomeDir(). It returns "" if neither is available.
+func resolveHomeDir() string {
+	if homeEnv := os.Getenv("HOME"); homeEnv != "" {
+		return homeEnv
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return homeDir
 }
 
 func (m *BorderStatusModel) UpdateGit(status *git.RepoStatus) {
@@ -290,6 +365,9 @@ func (m BorderStatusModel) TopLeftWidth() int {
 	return 1 + badgeWidth + 1 + riskBarWidth + 1
 }
 
+// maxFocusWidth is the most characters of the focused task shown in the border.
+const maxFocusWidth = 30
+
 func (m BorderStatusModel) RenderTopContext(maxWidth int) string {
 	// Items to display: [Dir with Git icons], [optional: User@Host if space allows]
 	// Git status is now appended directly to the directory display
@@ -300,35 +378,7 @@ func (m BorderStatusModel) RenderTopContext(maxWidth int) string {
 	var styles []lipgloss.Style
 
 	// Dir with Git Status appended
-	dir := m.cwd
-	if len(dir) > 20 {
-		// Resolve home directory safely - first try HOME env var, then os.UserHomeDir()
-		var homeDir string
-		homeEnv := os.Getenv("HOME")
-		if homeEnv != "" {
-			homeDir = homeEnv
-		} else {
-			// HOME is empty, try os.UserHomeDir() as fallback
-			var err error
-			homeDir, err = os.UserHomeDir()
-			if err != nil {
-				homeDir = "" // Failed to resolve home, will use basename logic
-			}
-		}
-
-		// Only perform "~" substitution if we have a valid home directory and dir starts with it
-		if homeDir != "" && strings.HasPrefix(dir, homeDir) {
-			dir = "~" + dir[len(homeDir):]
-		} else {
-			// Fall back to basename logic for long paths
-			dir = filepath.Base(dir)
-			if dir == "/" {
-				dir = "/"
-			} else {
-				dir = ".../" + dir
-			}
-		}
-	}
+	dir := pathfmt.Shorten(m.cwd, resolveHomeDir(), m.gitRoot, m.pathStyle)
 
 	// Truncate directory if it's too long for available space
 	// Calculate max width for directory text (excluding styling, leading space, and git status)
@@ -376,6 +426,12 @@ func (m BorderStatusModel) RenderTopContext(maxWidth int) string {
 			// Add space + symbol
 			displayStr += " " + gitStyle.Render(symbol)
 
+			// Name linked worktrees, since the directory alone doesn't say
+			// which checkout of the repository this is
+			if m.gitStatus.Worktree != "" {
+				displayStr += gitStyle.Render(" ⎇" + m.gitStatus.Worktree)
+			}
+
 			// Arrows - use the same gitStyle for consistency
 			if m.gitStatus.Ahead > 0 {
 				displayStr += gitStyle.Render(fmt.Sprintf(" ⬆%d", m.gitStatus.Ahead))
@@ -392,6 +448,16 @@ func (m BorderStatusModel) RenderTopContext(maxWidth int) string {
 		styles = append(styles, lipgloss.NewStyle()) // Style embedded in string
 	}
 
+	// The focused task comes second so it is the first to go when space runs out
+	if m.focus != "" {
+		task := m.focus
+		if len([]rune(task)) > maxFocusWidth {
+			task = string([]rune(task)[:maxFocusWidth-3]) + "..."
+		}
+		items = append(items, " "+m.styles.ContextTask.Render("◎ "+task)+" ")
+		styles = append(styles, lipgloss.NewStyle())
+	}
+
 	if len(items) == 0 {
 		// Just fill
 		if maxWidth > 0 {
@@ -524,6 +590,9 @@ func (m BorderStatusModel) RenderTopContext(maxWidth int) string {
 
 func (m BorderStatusModel) RenderBottomLeft() string {
 	if m.resources == nil {
+		if status := m.renderTimer() + m.renderQuiet() + m.renderSegments(); status != "" {
+			return m.styles.ResLabel.Render("C: --% R: --%") + " " + status
+		}
 		return m.styles.ResLabel.Render("C: --% R: --%")
 	}
 
@@ -539,7 +608,54 @@ func (m BorderStatusModel) RenderBottomLeft() string {
 	ramStr := m.styles.ResLabel.Render("R:") + m.formatPercentage(ramRatio)
 
 	// Add spaces around the resource display to match lightning bolt formatting
-	return " " + cpuStr + " " + ramStr + " "
+	return " " + cpuStr + " " + ramStr + " " + m.renderTimer() + m.renderQuiet() + m.renderSegments()
+}
+
+// renderSegments returns the outputs of the status commands, or "" if there
+// are none.
+func (m BorderStatusModel) renderSegments() string {
+	if len(m.segments) == 0 {
+		return ""
+	}
+	return m.styles.ContextGit.Render(strings.Join(m.segments, " · ")) + " "
+}
+
+// maxTimerLabel is how many characters of a timer label the border shows.
+const maxTimerLabel = 20
+
+// renderTimer returns the countdown of the running timer, or "" without one.
+func (m BorderStatusModel) renderTimer() string {
+	remaining := time.Until(m.timerEnds)
+	if remaining <= 0 {
+		return ""
+	}
+	// Round up so that it reads 0:00 only once the timer has ended
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	text := fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	if seconds >= 3600 {
+		text = fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	if label := []rune(m.timerLabel); len(label) > maxTimerLabel {
+		text = string(label[:maxTimerLabel-1]) + "… " + text
+	} else if len(label) > 0 {
+		text = m.timerLabel + " " + text
+	}
+	return m.styles.ContextTask.Render("⏱ "+text) + " "
+}
+
+// renderQuiet returns the time left in quiet mode, or "" outside of it.
+func (m BorderStatusModel) renderQuiet() string {
+	remaining := time.Until(m.quietUntil)
+	if remaining <= 0 {
+		return ""
+	}
+	// Round up so that the last minute shows as 1m rather than 0m
+	minutes := int((remaining + time.Minute - 1) / time.Minute)
+	text := fmt.Sprintf("%dm", minutes)
+	if minutes >= 60 {
+		text = fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+	}
+	return m.styles.ResLabel.Render("quiet "+text) + " "
 }
 
 func (m BorderStatusModel) RenderBottomCenter() string {
@@ -0,0 +1,70 @@
+package gline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTopContextFocus(t *testing.T) {
+	m := NewBorderStatusModel()
+	m.UpdateContext("me", "host", "/srv/billing")
+
+	assert.NotContains(t, m.RenderTopContext(60), "◎")
+
+	m.SetFocus("fixing the billing cron")
+	rendered := m.RenderTopContext(60)
+	assert.Contains(t, rendered, "/srv/billing")
+	assert.Contains(t, rendered, "◎ fixing the billing cron")
+
+	m.SetFocus(strings.Repeat("a", 40))
+	assert.Contains(t, m.RenderTopContext(80), "◎ "+strings.Repeat("a", maxFocusWidth-3)+"...")
+
+	// The task is dropped before the directory when space runs out
+	m.SetFocus("fixing the billing cron")
+	rendered = m.RenderTopContext(20)
+	assert.Contains(t, rendered, "/srv/billing")
+	assert.NotContains(t, rendered, "◎")
+}
+
+func TestRenderBottomLeftQuiet(t *testing.T) {
+	m := NewBorderStatusModel()
+	assert.NotContains(t, m.RenderBottomLeft(), "quiet")
+
+	m.SetQuietUntil(time.Now().Add(45 * time.Minute))
+	assert.Contains(t, m.RenderBottomLeft(), "quiet 45m")
+
+	m.SetQuietUntil(time.Now().Add(90*time.Minute - time.Second))
+	assert.Contains(t, m.RenderBottomLeft(), "quiet 1h30m")
+
+	m.SetQuietUntil(time.Now().Add(-time.Minute))
+	assert.NotContains(t, m.RenderBottomLeft(), "quiet")
+}
+
+func TestRenderBottomLeftSegments(t *testing.T) {
+	m := NewBorderStatusModel()
+	m.SetSegments([]string{"vpn: on", "87%"})
+	assert.Contains(t, m.RenderBottomLeft(), "vpn: on · 87%")
+
+	m.SetSegments(nil)
+	assert.Equal(t, "C: --% R: --%", m.RenderBottomLeft())
+}
+
+func TestRenderBottomLeftTimer(t *testing.T) {
+	m := NewBorderStatusModel()
+	assert.NotContains(t, m.RenderBottomLeft(), "⏱")
+
+	m.SetTimer("review PR", time.Now().Add(25*time.Minute))
+	assert.Contains(t, m.RenderBottomLeft(), "⏱ review PR 25:00")
+
+	m.SetTimer("", time.Now().Add(61*time.Minute))
+	assert.Contains(t, m.RenderBottomLeft(), "⏱ 1:01:00")
+
+	m.SetTimer("a label that is far too long to show", time.Now().Add(time.Minute))
+	assert.Contains(t, m.RenderBottomLeft(), "⏱ a label that is far… 1:00")
+
+	m.SetTimer("review PR", time.Now().Add(-time.Second))
+	assert.NotContains(t, m.RenderBottomLeft(), "⏱")
+}
@@ -0,0 +1,122 @@
+package gline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/pkg/shellinput"
+)
+
+// editorFinishedMsg carries the line back from the editor Ctrl+X Ctrl+E
+// opened.
+type editorFinishedMsg struct {
+	text string
+	err  error
+}
+
+// FindEditor returns $EDITOR, $VISUAL or the first common editor installed.
+func FindEditor() (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		// Try common editors
+		for _, e := range []string{"vi", "vim", "nano"} {
+			if _, err := exec.LookPath(e); err == nil {
+				editor = e
+				break
+			}
+		}
+	}
+	if editor == "" {
+		return "", fmt.Errorf("no editor found (set $EDITOR)")
+	}
+	return editor, nil
+}
+
+// EditorCommand writes text to a temporary shell script and returns the
+// command that opens it in the editor, and read, which returns the edited
+// text without surrounding blank space and removes the file once the editor
+// has exited.
+func EditorCommand(text string) (*exec.Cmd, func() (string, error), error) {
+	editor, err := FindEditor()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpFile, err := os.CreateTemp("", "bish-edit-*.sh")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.WriteString(text); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return nil, nil, fmt.Errorf("failed to write to temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, nil, fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	// $EDITOR may carry arguments, as in "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], tmpPath)...)
+	read := func() (string, error) {
+		defer func() { _ = os.Remove(tmpPath) }()
+		content, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read edited file: %w", err)
+		}
+		// Remove trailing newlines but preserve internal structure
+		return strings.TrimSpace(string(content)), nil
+	}
+	return cmd, read, nil
+}
+
+// EditInEditor opens text in the editor on the terminal, waits for it to
+// exit and returns the edited text.
+func EditInEditor(text string) (string, error) {
+	cmd, read, err := EditorCommand(text)
+	if err != nil {
+		return "", err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		_, _ = read()
+		return "", fmt.Errorf("editor exited with error: %w", err)
+	}
+	return read()
+}
+
+// editLine opens the line in the editor, handing it the terminal, as
+// Ctrl+X Ctrl+E does in bash and zsh. The edited line replaces it once the
+// editor exits.
+func (m appModel) editLine() (tea.Model, tea.Cmd) {
+	cmd, read, err := EditorCommand(m.textInput.Value())
+	if err != nil {
+		return m, tea.Println("bish: " + err.Error())
+	}
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		text, readErr := read()
+		if err != nil {
+			return editorFinishedMsg{err: fmt.Errorf("editor exited with error: %w", err)}
+		}
+		return editorFinishedMsg{text: text, err: readErr}
+	})
+}
+
+// finishEditing puts the line back from the editor.
+func (m appModel) finishEditing(msg editorFinishedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, tea.Println("bish: " + msg.err.Error())
+	}
+	// The input is a single line, so continuation lines are joined
+	return m.updateTextInput(shellinput.ReplaceMsg(strings.ReplaceAll(msg.text, "\\\n", "")))
+}
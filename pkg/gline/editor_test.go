@@ -0,0 +1,88 @@
+//go:build !windows
+
+package gline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEditor sets $EDITOR to a script that replaces the file it opens with
+// content.
+func fakeEditor(t *testing.T, content string) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "editor")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s\\n' '"+content+"' > \"$1\"\n"), 0o755))
+	t.Setenv("EDITOR", script)
+}
+
+func TestEditInEditor(t *testing.T) {
+	fakeEditor(t, "git status --short")
+
+	edited, err := EditInEditor("git status")
+	require.NoError(t, err)
+	assert.Equal(t, "git status --short", edited)
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "vi -n")
+	cmd, read, err := EditorCommand("ls -l")
+	require.NoError(t, err)
+	require.Len(t, cmd.Args, 3)
+	assert.Equal(t, []string{"vi", "-n"}, cmd.Args[:2])
+
+	path := cmd.Args[2]
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ls -l", string(content))
+
+	text, err := read()
+	require.NoError(t, err)
+	assert.Equal(t, "ls -l", text)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCtrlXCtrlE(t *testing.T) {
+	fakeEditor(t, "echo edited")
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), NewOptions())
+	model.textInput.SetValue("echo")
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(appModel)
+	assert.Nil(t, cmd)
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	model = updated.(appModel)
+	require.NotNil(t, cmd)
+	assert.Equal(t, "echo", model.textInput.Value())
+
+	// The edited line replaces the input, with continuation lines joined
+	updated, _ = model.Update(editorFinishedMsg{text: "make \\\nbuild"})
+	model = updated.(appModel)
+	assert.Equal(t, "make build", model.textInput.Value())
+	assert.Equal(t, len("make build"), model.textInput.Position())
+}
+
+func TestCtrlXThenOtherKey(t *testing.T) {
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), NewOptions())
+	model.textInput.SetValue("echo")
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(appModel)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	model = updated.(appModel)
+	assert.Equal(t, "echo!", model.textInput.Value())
+
+	// Ctrl+E alone still moves to the end of the line
+	model.textInput.SetCursor(0)
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	model = updated.(appModel)
+	assert.Nil(t, cmd)
+	assert.Equal(t, len("echo!"), model.textInput.Position())
+}
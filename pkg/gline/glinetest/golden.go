@@ -0,0 +1,48 @@
+// Package glinetest helps test the line editor of package gline, and what
+// plugs into it, by comparing the frames a gline.Harness renders with
+// golden files:
+//
+//	h := gline.NewHarness(gline.HarnessConfig{Prompt: "> ", Predictor: p, Width: 60})
+//	h.Type("git st")
+//	h.Settle()
+//	glinetest.AssertFrame(t, "ghost_text", h.Frame())
+//
+// Golden files are kept in the testdata directory of the package under
+// test. Run the tests with BISH_UPDATE_GOLDEN=1 to write them from the
+// frames, and review the diff.
+package glinetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// UpdateEnv is the environment variable that, set to 1, has AssertFrame
+// write the golden files instead of comparing them.
+const UpdateEnv = "BISH_UPDATE_GOLDEN"
+
+// AssertFrame compares frame with the golden file testdata/name.golden and
+// fails t if they differ, showing how.
+func AssertFrame(t testing.TB, name string, frame string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("writing golden frame: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(frame+"\n"), 0o644); err != nil {
+			t.Fatalf("writing golden frame: %v", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden frame: %v (run with %s=1 to write it)", err, UpdateEnv)
+	}
+	assert.Equal(t, string(golden), frame+"\n",
+		"frame differs from %s (run with %s=1 to update it if the change is intended)", path, UpdateEnv)
+}
@@ -0,0 +1,238 @@
+package gline
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"go.uber.org/zap"
+)
+
+// Harness drives the line editor without a terminal, for tests of it and of
+// what plugs into it, such as predictors and completion providers. It feeds
+// it keys and messages, runs the commands they start at once, on a virtual
+// clock that only moves with Advance, and renders its frames as plain text.
+// The frames are the same on every run as long as what it is given is:
+// resource monitoring is off, and the git status is only read if
+// Options.CurrentDirectory is set.
+//
+// See the glinetest package to compare frames with golden files.
+type Harness struct {
+	model appModel
+	now   time.Time
+	// timers are the messages scheduled on the clock, by when they are due
+	timers []harnessTimer
+	quit   bool
+}
+
+// HarnessConfig is what the line editor starts with, as passed to Gline.
+type HarnessConfig struct {
+	Prompt      string
+	History     []string
+	Explanation string
+	Predictor   Predictor
+	Explainer   Explainer
+	// Options are usually those of NewOptions, with what is tested set
+	Options Options
+	// Width and Height are the size of the terminal, 80x24 if 0
+	Width, Height int
+}
+
+type harnessTimer struct {
+	due time.Time
+	fn  func(time.Time) tea.Msg
+}
+
+// harnessTickMsg is what a tick of the model returns in the harness, for it
+// to schedule fn on its clock.
+type harnessTickMsg struct {
+	d  time.Duration
+	fn func(time.Time) tea.Msg
+}
+
+// harnessEpoch is when the clock of the harness starts.
+var harnessEpoch = time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// NewHarness starts the line editor with config and runs what it starts
+// with.
+func NewHarness(config HarnessConfig) *Harness {
+	options := config.Options
+	options.ResourceUpdateInterval = 0
+	if options.RenderProfile == RenderProfileAuto {
+		options.RenderProfile = RenderProfileFull
+	}
+	width, height := config.Width, config.Height
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	h := &Harness{now: harnessEpoch}
+	h.model = initialModel(config.Prompt, config.History, config.Explanation,
+		config.Predictor, config.Explainer, nil, zap.NewNop(), options)
+	h.model.tick = func(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+		return func() tea.Msg {
+			return harnessTickMsg{d: d, fn: fn}
+		}
+	}
+	h.run(h.model.Init())
+	h.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	return h
+}
+
+// Type types text, one key per rune.
+func (h *Harness) Type(text string) {
+	for _, r := range text {
+		h.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// Paste pastes text at once, as a terminal with bracketed paste does.
+func (h *Harness) Paste(text string) {
+	h.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true})
+}
+
+// Press presses keys, named as bubbletea names them, such as "enter",
+// "tab", "ctrl+k", "alt+]" or "a".
+func (h *Harness) Press(keys ...string) error {
+	for _, name := range keys {
+		key, err := ParseKey(name)
+		if err != nil {
+			return err
+		}
+		h.Send(key)
+	}
+	return nil
+}
+
+// Resize resizes the terminal.
+func (h *Harness) Resize(width, height int) {
+	h.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Send sends msg to the line editor and runs the commands it starts.
+func (h *Harness) Send(msg tea.Msg) {
+	model, cmd := h.model.Update(msg)
+	h.model = model.(appModel)
+	h.run(cmd)
+}
+
+// Advance moves the clock d forward, delivering the messages that come due
+// on the way, such as predictions once typing pauses.
+func (h *Harness) Advance(d time.Duration) {
+	end := h.now.Add(d)
+	for len(h.timers) > 0 && !h.timers[0].due.After(end) {
+		timer := h.timers[0]
+		h.timers = h.timers[1:]
+		h.now = timer.due
+		h.deliver(timer.fn(h.now))
+	}
+	h.now = end
+}
+
+// Settle advances the clock a second, long enough for what typing started,
+// such as predictions, explanations and previews, to show.
+func (h *Harness) Settle() {
+	h.Advance(time.Second)
+}
+
+// Frame returns what the line editor shows, without colors or styles, and
+// without the spaces that pad its lines.
+func (h *Harness) Frame() string {
+	lines := strings.Split(ansi.Strip(h.model.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Value returns the line being edited.
+func (h *Harness) Value() string {
+	return h.model.textInput.Value()
+}
+
+// Done reports whether the line editor is done, with Enter or Ctrl+C, and
+// returns the line it returns.
+func (h *Harness) Done() (string, bool) {
+	return h.model.result, h.quit || h.model.appState == Terminated
+}
+
+// run runs cmd and what follows from it. Batches run in order and sequences
+// in sequence, which they are anyway since commands run at once.
+func (h *Harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	h.deliver(cmd())
+}
+
+func (h *Harness) deliver(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case nil:
+		return
+	case harnessTickMsg:
+		timer := harnessTimer{due: h.now.Add(msg.d), fn: msg.fn}
+		i, _ := slices.BinarySearchFunc(h.timers, timer.due, func(t harnessTimer, due time.Time) int {
+			// After the timers due at the same time, in the order they came
+			if t.due.After(due) {
+				return 1
+			}
+			return -1
+		})
+		h.timers = slices.Insert(h.timers, i, timer)
+		return
+	case tea.QuitMsg:
+		h.quit = true
+		return
+	}
+	// tea.Batch and tea.Sequence both return a slice of commands, the
+	// latter of an unexported type
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		for i := 0; i < v.Len(); i++ {
+			h.run(v.Index(i).Interface().(tea.Cmd))
+		}
+		return
+	}
+	h.Send(msg)
+}
+
+// keyTypes are the key types by the names bubbletea gives them.
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{}
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if t == tea.KeyRunes {
+			continue
+		}
+		if name := (tea.Key{Type: t}).String(); name != "" {
+			if _, ok := types[name]; !ok {
+				types[name] = t
+			}
+		}
+	}
+	return types
+}()
+
+// ParseKey returns the key named name, as bubbletea names keys in
+// tea.KeyMsg.String, such as "enter", "ctrl+k", "alt+]" or "a".
+func ParseKey(name string) (tea.KeyMsg, error) {
+	if t, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: t}, nil
+	}
+	alt := false
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		alt, name = true, rest
+		if t, ok := keyTypes[name]; ok {
+			return tea.KeyMsg{Type: t, Alt: true}, nil
+		}
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes, Alt: alt}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key: %q", name)
+}
@@ -0,0 +1,184 @@
+package gline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/pkg/gline/glinetest"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// goldenWidths are the terminal widths the golden frames are rendered at:
+// narrow enough to wrap and box the assistant tightly, and the usual one.
+var goldenWidths = []int{30, 50, 80}
+
+// goldenOptions are the options of the golden frames, with the completions
+// of goldenCompletions if completions is set.
+func goldenOptions(completions bool) Options {
+	options := NewOptions()
+	if completions {
+		options.CompletionProvider = goldenCompletions{}
+	}
+	return options
+}
+
+// goldenCompletions completes git subcommands.
+type goldenCompletions struct{}
+
+func (goldenCompletions) GetCompletions(line string, pos int) []shellinput.CompletionCandidate {
+	return []shellinput.CompletionCandidate{
+		{Value: "git status", Display: "status", Description: "Show the working tree status"},
+		{Value: "git stash", Display: "stash", Description: "Stash the changes in a dirty working directory"},
+		{Value: "git stage", Display: "stage", Description: "Add file contents to the index"},
+	}
+}
+
+func (goldenCompletions) GetHelpInfo(line string, pos int) string { return "" }
+
+func TestGoldenFrames(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		config HarnessConfig
+		script func(t *testing.T, h *Harness)
+	}{
+		{
+			name:   "empty",
+			config: HarnessConfig{Prompt: "bish> ", Explanation: "Tip: Ctrl+R searches history", Options: goldenOptions(false)},
+			script: func(t *testing.T, h *Harness) {},
+		},
+		{
+			name: "ghost_text",
+			config: HarnessConfig{
+				Prompt:    "bish> ",
+				Predictor: newMockPredictor(),
+				Explainer: newMockExplainer(),
+				Options:   goldenOptions(false),
+			},
+			script: func(t *testing.T, h *Harness) {
+				h.Type("git")
+				h.Settle()
+			},
+		},
+		{
+			name:   "wrapping",
+			config: HarnessConfig{Prompt: "~/src/bishop> ", Options: goldenOptions(false)},
+			script: func(t *testing.T, h *Harness) {
+				h.Type("find . -name '*.go' -newer go.mod -exec grep -l 'func Test' {} +")
+				h.Settle()
+			},
+		},
+		{
+			name: "completion_box",
+			config: HarnessConfig{
+				Prompt:  "bish> ",
+				Options: goldenOptions(true),
+			},
+			script: func(t *testing.T, h *Harness) {
+				h.Type("git st")
+				require.NoError(t, h.Press("tab"))
+				h.Settle()
+			},
+		},
+		{
+			name:   "multiline",
+			config: HarnessConfig{Prompt: "bish> ", Options: goldenOptions(false)},
+			script: func(t *testing.T, h *Harness) {
+				h.Type("docker run --rm \\")
+				require.NoError(t, h.Press("enter"))
+				h.Type("-it alpine sh")
+				h.Settle()
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		for _, width := range goldenWidths {
+			name := fmt.Sprintf("%s_%d", scenario.name, width)
+			t.Run(name, func(t *testing.T) {
+				config := scenario.config
+				config.Width, config.Height = width, 12
+				h := NewHarness(config)
+				scenario.script(t, h)
+				glinetest.AssertFrame(t, name, h.Frame())
+			})
+		}
+	}
+}
+
+func TestHarnessClock(t *testing.T) {
+	h := NewHarness(HarnessConfig{
+		Prompt:    "> ",
+		Predictor: newMockPredictor(),
+		Explainer: newMockExplainer(),
+		Options:   NewOptions(),
+	})
+
+	h.Type("git")
+	assert.NotContains(t, h.Frame(), "git status", "predictions wait for typing to pause")
+	h.Advance(predictionDelay - time.Millisecond)
+	assert.NotContains(t, h.Frame(), "git status")
+	h.Advance(time.Millisecond)
+	assert.Contains(t, h.Frame(), "git status")
+	assert.Contains(t, h.Frame(), "Shows the status of the working directory")
+	assert.Equal(t, "git", h.Value(), "ghost text is not part of the line")
+
+	require.NoError(t, h.Press("enter"))
+	line, done := h.Done()
+	assert.True(t, done)
+	assert.Equal(t, "git", line)
+}
+
+func TestParseKey(t *testing.T) {
+	for name, want := range map[string]tea.KeyMsg{
+		"enter":     {Type: tea.KeyEnter},
+		"ctrl+k":    {Type: tea.KeyCtrlK},
+		"tab":       {Type: tea.KeyTab},
+		"alt+]":     {Type: tea.KeyRunes, Runes: []rune{']'}, Alt: true},
+		"alt+enter": {Type: tea.KeyEnter, Alt: true},
+		"x":         {Type: tea.KeyRunes, Runes: []rune{'x'}},
+	} {
+		key, err := ParseKey(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, key, name)
+		assert.Equal(t, name, key.String())
+	}
+
+	_, err := ParseKey("hyper+x")
+	assert.Error(t, err)
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, width := range goldenWidths {
+		b.Run(fmt.Sprint(width), func(b *testing.B) {
+			h := NewHarness(HarnessConfig{
+				Prompt:    "bish> ",
+				Predictor: newMockPredictor(),
+				Explainer: newMockExplainer(),
+				Options:   NewOptions(),
+				Width:     width,
+			})
+			h.Type("git")
+			h.Settle()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = h.model.View()
+			}
+		})
+	}
+}
+
+func BenchmarkKeystroke(b *testing.B) {
+	h := NewHarness(HarnessConfig{Prompt: "bish> ", Predictor: newMockPredictor(), Options: NewOptions()})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Type("a")
+		_ = h.model.View()
+		if i%64 == 63 {
+			_ = h.Press("ctrl+u")
+		}
+	}
+}
@@ -32,6 +32,9 @@ var inFlightColors = []lipgloss.Color{
 // LLMTickMsg is sent to advance the color animation
 type LLMTickMsg struct{}
 
+// llmTickInterval is how often the color animation advances
+const llmTickInterval = time.Second / 2
+
 // LLMIndicator holds the state for an LLM status indicator
 type LLMIndicator struct {
 	status     LLMStatus
@@ -45,7 +48,7 @@ func NewLLMIndicator() LLMIndicator {
 
 // Tick returns a command that sends LLMTickMsg after the animation interval
 func (i LLMIndicator) Tick() tea.Cmd {
-	return tea.Tick(time.Second/2, func(t time.Time) tea.Msg {
+	return tea.Tick(llmTickInterval, func(t time.Time) tea.Msg {
 		return LLMTickMsg{}
 	})
 }
@@ -145,6 +145,33 @@ func (m *MultilineState) IsActive() bool {
 	return m.isContinuation || m.buffer.Len() > 0
 }
 
+// InLiteralContext returns true when the next line continues an open quote or
+// a here-document body, where typed characters must be taken literally.
+func (m *MultilineState) InLiteralContext() bool {
+	if !m.IsActive() {
+		return false
+	}
+	content := m.buffer.String()
+	return hasIncompleteQuotes(content) || hasHeredoc(content)
+}
+
+// hasHeredoc checks if the input contains a here-document operator (<< or <<-),
+// ignoring here-strings (<<<)
+func hasHeredoc(input string) bool {
+	for i := 0; i+1 < len(input); i++ {
+		if input[i] != '<' || input[i+1] != '<' {
+			continue
+		}
+		if i+2 < len(input) && input[i+2] == '<' {
+			// Skip the whole here-string operator
+			i += 2
+			continue
+		}
+		return true
+	}
+	return false
+}
+
 // GetAccumulatedLines returns the accumulated lines for display purposes
 func (m *MultilineState) GetAccumulatedLines() string {
 	return m.buffer.String()
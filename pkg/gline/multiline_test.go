@@ -674,3 +674,25 @@ func TestCommandSubstitutionEdgeCases(t *testing.T) {
 		}
 	}
 }
+
+func TestMultilineInLiteralContext(t *testing.T) {
+	state := NewMultilineState()
+	assert.False(t, state.InLiteralContext(), "inactive state is not literal")
+
+	complete, _ := state.AddLine(`echo "first`)
+	assert.False(t, complete)
+	assert.True(t, state.InLiteralContext(), "open double quote continues literally")
+	state.Reset()
+
+	complete, _ = state.AddLine("cat <<EOF")
+	assert.False(t, complete)
+	assert.True(t, state.InLiteralContext(), "here-document body is literal")
+	state.Reset()
+
+	complete, _ = state.AddLine("echo $(date")
+	assert.False(t, complete)
+	assert.False(t, state.InLiteralContext(), "command substitutions are not literal")
+
+	assert.False(t, hasHeredoc("cat <<< word"))
+	assert.True(t, hasHeredoc("cat <<-END"))
+}
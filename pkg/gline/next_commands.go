@@ -0,0 +1,33 @@
+package gline
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.uber.org/zap"
+)
+
+// nextCommandsMsg carries the commands of the next command menu, re-ranked
+type nextCommandsMsg struct {
+	commands []string
+}
+
+// rerankNextCommands re-ranks the commands of the menu that just opened in
+// the background, if a re-ranker is set. The menu is usable meanwhile.
+func (m appModel) rerankNextCommands() tea.Cmd {
+	if m.options.RerankNextCommands == nil {
+		return nil
+	}
+	commands := m.textInput.NextCommandMenuItems()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), predictionTimeout)
+		defer cancel()
+
+		reranked, err := m.options.RerankNextCommands(ctx, commands)
+		if err != nil {
+			m.logger.Debug("gline failed to re-rank next commands", zap.Error(err))
+			return nil
+		}
+		return nextCommandsMsg{commands: reranked}
+	}
+}
@@ -2,7 +2,9 @@ package gline
 
 import (
 	"context"
+	"time"
 
+	"github.com/robottwo/bishop/internal/pathfmt"
 	"github.com/robottwo/bishop/pkg/shellinput"
 )
 
@@ -12,6 +14,10 @@ type IdleSummaryGenerator func(ctx context.Context) (string, error)
 // PromptGenerator is a function that generates the prompt string
 type PromptGenerator func(ctx context.Context) string
 
+// HistoryPoller is a function that returns history items recorded since its
+// previous call, ordered newest first
+type HistoryPoller func(ctx context.Context) []shellinput.HistoryItem
+
 type Options struct {
 	// Deprecated: use AssistantHeight instead
 	MinHeight          int
@@ -23,6 +29,126 @@ type Options struct {
 	User               string
 	Host               string
 
+	// RichHistoryPager, if set, pages in the items of the history search
+	// instead of RichHistory, the newest page when the search opens and
+	// older ones as it needs them.
+	RichHistoryPager shellinput.HistoryPager
+
+	// PathStyle controls how CurrentDirectory is abbreviated in the border status.
+	// Defaults to pathfmt.StyleAuto when empty.
+	PathStyle pathfmt.Style
+
+	// Focus is the task declared with #!focus, shown in the border status.
+	Focus string
+
+	// QuietUntil is when the quiet mode started with #!quiet ends. While it
+	// lasts, the remaining time is shown in the border status.
+	QuietUntil time.Time
+
+	// Timer returns the label of the timer started with the timer builtin
+	// and when it ends, or a zero time if none is running. While one runs,
+	// its countdown is shown in the border status.
+	Timer func() (string, time.Time)
+
+	// StatusSegments returns the output of the commands configured as
+	// segments of the border status, which is updated every second while
+	// the prompt is shown, or less often with a slower RenderProfile.
+	StatusSegments func() []string
+
+	// LocalPredictor predicts from local data such as history, without a
+	// model. Its prediction is shown as soon as the input changes, until the
+	// predictor's arrives, and kept if the predictor has none or fails. It
+	// also works without a predictor.
+	LocalPredictor Predictor
+
+	// PredictionCandidates is how many predictions to request at once when
+	// the predictor is a CandidatePredictor. Alt+] and Alt+[ cycle through
+	// them. With 1 or less, a single prediction is requested.
+	PredictionCandidates int
+
+	// Redact, if set, masks secrets in what is shown: the prompt, history,
+	// the assistant box and predictions, which are dropped if they would
+	// reveal one. It is set in presentation mode, and masks with
+	// redact.Mask: a line with the mask in it only runs if it is a command
+	// recalled from history, as the command it is.
+	Redact func(string) string
+
+	// AutoPair enables automatic closing of quotes and brackets in the input line.
+	AutoPair bool
+
+	// PasteFilter rewrites pasted text before it is inserted, if set.
+	PasteFilter func(text string) string
+
+	// UsualFlags is called when Alt+U is pressed with the current line and
+	// returns it with the user's usual flags added. If nil, the key does nothing.
+	UsualFlags func(line string) (string, bool)
+
+	// ArgHistory is called when Alt+A is pressed with the current line and
+	// returns it completed with the arguments previously passed to its
+	// command, most recent first. If nil, the key does nothing.
+	ArgHistory func(line string) []string
+
+	// NextCommands is called when Ctrl+Space is pressed on an empty line and
+	// returns the commands the user is likely to run next, the likeliest
+	// first, for a menu. If nil, the key does nothing.
+	NextCommands func() []string
+
+	// Abbreviations returns the expansion of a word if it is an abbreviation,
+	// which replaces the word when it is typed as a command and followed by
+	// space or Enter. If nil, nothing expands.
+	Abbreviations func(word string) (string, bool)
+
+	// KeyMap, if set, replaces the default key bindings of the input line,
+	// e.g. with those the user configured.
+	KeyMap *shellinput.KeyMap
+
+	// RerankNextCommands, if set, reorders the commands of the menu once it
+	// is open, e.g. with the LLM. It must return the same commands.
+	RerankNextCommands func(ctx context.Context, commands []string) ([]string, error)
+
+	// FollowUp is the command that usually follows the last one, learned from
+	// history. It is suggested on an empty line, with FollowUpNote in place of
+	// an explanation to tell it apart from predictions.
+	FollowUp     string
+	FollowUpNote string
+
+	// OutputToggle is called when Alt+R is pressed and returns the text to print
+	// above the prompt, such as the raw form of the last pretty-printed command
+	// output. If nil or if it returns "", the key does nothing.
+	OutputToggle func() string
+
+	// PipelineBuilder is called when Alt+P is pressed with the current line,
+	// once the terminal is handed over, and returns the pipeline built from it
+	// and whether to insert it. If nil, the key does nothing.
+	PipelineBuilder func(line string) (string, bool, error)
+
+	// ProgramPreview is called in the background as the line changes, with
+	// the line and the byte offset of the cursor, and returns what to show
+	// in the assistant box in place of the explanation, such as what the jq
+	// program the cursor is in prints on a sample of its input. It returns
+	// "" for the usual content. If nil, nothing is previewed.
+	ProgramPreview func(ctx context.Context, line string, cursor int) string
+
+	// ProgramWriter is called when Alt+J is pressed with the line and the
+	// byte offset of the cursor, and returns the line with the program the
+	// cursor is in written from its description. If nil, the key does nothing.
+	ProgramWriter func(ctx context.Context, line string, cursor int) (string, error)
+
+	// RegexTester is called when Alt+G is pressed with the line and the byte
+	// offset of the cursor, once the terminal is handed over, and returns the
+	// line with the regular expression the cursor is in edited in the tester
+	// and whether to insert it. If nil, the key does nothing.
+	RegexTester func(line string, cursor int) (string, bool, error)
+
+	// HistoryScope is the scope of the history values Up/Down go through,
+	// which Ctrl+R search also starts filtered to, when ScopedHistory is set.
+	HistoryScope shellinput.HistoryFilterMode
+
+	// ScopedHistory is called when Alt+H is pressed with the scope after
+	// the current one, and returns the history values of that scope, newest
+	// first, for Up/Down. If nil, the key does nothing.
+	ScopedHistory func(scope shellinput.HistoryFilterMode) []string
+
 	// InitialValue is the initial text to populate in the input field.
 	// Used for features like editing a suggested fix before execution.
 	InitialValue string
@@ -41,6 +167,15 @@ type Options struct {
 	// PromptGenerator is called asynchronously to generate the prompt string.
 	// If nil, prompt fetching is disabled.
 	PromptGenerator PromptGenerator
+
+	// RenderProfile sets how often the line is redrawn. With
+	// RenderProfileAuto, it is picked from the latency of the terminal.
+	RenderProfile RenderProfile
+
+	// HistoryPoller is called every HistoryPollInterval while the prompt is open to
+	// pick up commands recorded by other shells. If nil, history polling is disabled.
+	HistoryPoller       HistoryPoller
+	HistoryPollInterval time.Duration
 }
 
 func NewOptions() Options {
@@ -0,0 +1,83 @@
+package gline
+
+import (
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/pkg/shellinput"
+)
+
+// pipelineBuiltMsg carries the pipeline back from the builder Alt+P opened.
+type pipelineBuiltMsg struct {
+	pipeline string
+	ok       bool
+	err      error
+}
+
+// funcExec runs a function as a tea.ExecCommand, so that a program of its
+// own can take over the terminal, as an editor does.
+type funcExec struct {
+	run func() error
+}
+
+func (f funcExec) Run() error          { return f.run() }
+func (f funcExec) SetStdin(io.Reader)  {}
+func (f funcExec) SetStdout(io.Writer) {}
+func (f funcExec) SetStderr(io.Writer) {}
+
+// buildPipeline opens the pipeline builder on the line, handing it the
+// terminal. The pipeline built replaces the line if the user inserts it.
+func (m appModel) buildPipeline() (tea.Model, tea.Cmd) {
+	line := m.textInput.Value()
+	var msg pipelineBuiltMsg
+	return m, tea.Exec(funcExec{run: func() error {
+		msg.pipeline, msg.ok, msg.err = m.options.PipelineBuilder(line)
+		return nil
+	}}, func(error) tea.Msg {
+		return msg
+	})
+}
+
+// regexTestedMsg carries the line back from the tester Alt+G opened.
+type regexTestedMsg struct {
+	line string
+	ok   bool
+	err  error
+}
+
+// testRegex opens the regex tester on the expression at the cursor, handing
+// it the terminal. The line with the expression edited replaces the line if
+// the user asks to use it.
+func (m appModel) testRegex() (tea.Model, tea.Cmd) {
+	line, cursor := m.textInput.Value(), m.cursorOffset()
+	var msg regexTestedMsg
+	return m, tea.Exec(funcExec{run: func() error {
+		msg.line, msg.ok, msg.err = m.options.RegexTester(line, cursor)
+		return nil
+	}}, func(error) tea.Msg {
+		return msg
+	})
+}
+
+// finishRegexTest puts the line with the expression tested on the prompt.
+func (m appModel) finishRegexTest(msg regexTestedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, tea.Println("bish: " + msg.err.Error())
+	}
+	if !msg.ok {
+		return m, nil
+	}
+	return m.updateTextInput(shellinput.ReplaceMsg(msg.line))
+}
+
+// finishPipeline puts the pipeline from the builder on the line.
+func (m appModel) finishPipeline(msg pipelineBuiltMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, tea.Println("bish: " + msg.err.Error())
+	}
+	if !msg.ok || strings.TrimSpace(msg.pipeline) == "" {
+		return m, nil
+	}
+	return m.updateTextInput(shellinput.ReplaceMsg(msg.pipeline))
+}
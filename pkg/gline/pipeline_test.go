@@ -0,0 +1,71 @@
+package gline
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAltPOpensPipelineBuilder(t *testing.T) {
+	altP := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}, Alt: true}
+
+	// Without a builder the key does nothing
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), NewOptions())
+	model.textInput.SetValue("cat log")
+	updated, _ := model.Update(altP)
+	assert.Equal(t, "cat log", updated.(appModel).textInput.Value())
+
+	options := NewOptions()
+	options.PipelineBuilder = func(line string) (string, bool, error) {
+		return line + " | sort", true, nil
+	}
+	model = initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), options)
+	model.textInput.SetValue("cat log")
+	updated, cmd := model.Update(altP)
+	require.NotNil(t, cmd)
+	model = updated.(appModel)
+
+	// The pipeline built replaces the line
+	updated, _ = model.Update(pipelineBuiltMsg{pipeline: "cat log | sort", ok: true})
+	model = updated.(appModel)
+	assert.Equal(t, "cat log | sort", model.textInput.Value())
+
+	// Unless the builder was cancelled or failed
+	updated, _ = model.Update(pipelineBuiltMsg{})
+	assert.Equal(t, "cat log | sort", updated.(appModel).textInput.Value())
+	updated, cmd = model.Update(pipelineBuiltMsg{err: errors.New("no terminal")})
+	assert.Equal(t, "cat log | sort", updated.(appModel).textInput.Value())
+	assert.NotNil(t, cmd)
+}
+
+func TestAltGOpensRegexTester(t *testing.T) {
+	altG := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}, Alt: true}
+
+	// Without a tester the key does nothing
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), NewOptions())
+	model.textInput.SetValue("grep 'a(b' f")
+	_, cmd := model.Update(altG)
+	assert.Nil(t, cmd)
+
+	options := NewOptions()
+	options.RegexTester = func(line string, cursor int) (string, bool, error) {
+		return "grep 'a\\(b' f", true, nil
+	}
+	model = initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), options)
+	model.textInput.SetValue("grep 'a(b' f")
+	updated, cmd := model.Update(altG)
+	require.NotNil(t, cmd)
+	model = updated.(appModel)
+
+	updated, _ = model.Update(regexTestedMsg{line: "grep 'a\\(b' f", ok: true})
+	model = updated.(appModel)
+	assert.Equal(t, "grep 'a\\(b' f", model.textInput.Value())
+
+	// Unless the tester was cancelled
+	updated, _ = model.Update(regexTestedMsg{line: "grep 'x' f"})
+	assert.Equal(t, "grep 'a\\(b' f", updated.(appModel).textInput.Value())
+}
@@ -6,6 +6,12 @@ type Predictor interface {
 	Predict(ctx context.Context, input string) (string, string, error)
 }
 
+// CandidatePredictor is a Predictor that can also predict several different
+// commands for an input at once, the most likely first.
+type CandidatePredictor interface {
+	PredictCandidates(ctx context.Context, input string, n int) ([]string, string, error)
+}
+
 type NoopPredictor struct{}
 
 func (p *NoopPredictor) Predict(ctx context.Context, input string) (string, string, error) {
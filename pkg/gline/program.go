@@ -0,0 +1,105 @@
+package gline
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/pkg/shellinput"
+	"go.uber.org/zap"
+)
+
+const (
+	// programPreviewDelay debounces previews, which run the program typed
+	programPreviewDelay = 200 * time.Millisecond
+	// programPreviewTimeout bounds a preview, source and program included
+	programPreviewTimeout = 10 * time.Second
+	// programWriteTimeout bounds writing a program with the LLM
+	programWriteTimeout = 20 * time.Second
+)
+
+// attemptProgramPreviewMsg asks for a preview once typing pauses.
+type attemptProgramPreviewMsg struct {
+	stateId int
+}
+
+// programPreviewMsg carries a preview back.
+type programPreviewMsg struct {
+	stateId int
+	preview string
+}
+
+// programWrittenMsg carries back the line with the program written.
+type programWrittenMsg struct {
+	line    string
+	written string
+	err     error
+}
+
+// cursorOffset returns the byte offset of the cursor in the line.
+func (m appModel) cursorOffset() int {
+	runes := []rune(m.textInput.Value())
+	return len(string(runes[:min(m.textInput.Position(), len(runes))]))
+}
+
+// scheduleProgramPreview asks for a preview of the line once typing pauses.
+func (m *appModel) scheduleProgramPreview() tea.Cmd {
+	if m.options.ProgramPreview == nil {
+		return nil
+	}
+	m.programPreviewStateId++
+	stateId := m.programPreviewStateId
+	return m.tick(programPreviewDelay+m.render.batch, func(time.Time) tea.Msg {
+		return attemptProgramPreviewMsg{stateId: stateId}
+	})
+}
+
+// attemptProgramPreview previews the line in the background, unless it
+// changed since the preview was asked for.
+func (m appModel) attemptProgramPreview(msg attemptProgramPreviewMsg) (appModel, tea.Cmd) {
+	if msg.stateId != m.programPreviewStateId {
+		return m, nil
+	}
+	line, cursor := m.textInput.Value(), m.cursorOffset()
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), programPreviewTimeout)
+		defer cancel()
+		return programPreviewMsg{stateId: msg.stateId, preview: m.options.ProgramPreview(ctx, line, cursor)}
+	}
+}
+
+// setProgramPreview shows the preview if the line has not changed since.
+func (m appModel) setProgramPreview(msg programPreviewMsg) (appModel, tea.Cmd) {
+	if msg.stateId != m.programPreviewStateId {
+		return m, nil
+	}
+	m.programPreview = msg.preview
+	return m, nil
+}
+
+// writeProgram has the program the cursor is in written from its
+// description, in the background.
+func (m appModel) writeProgram() (appModel, tea.Cmd) {
+	line, cursor := m.textInput.Value(), m.cursorOffset()
+	m.programPreview = "Writing the program…"
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), programWriteTimeout)
+		defer cancel()
+		written, err := m.options.ProgramWriter(ctx, line, cursor)
+		return programWrittenMsg{line: line, written: written, err: err}
+	}
+}
+
+// finishWritingProgram puts the program written on the line, unless the
+// line was edited meanwhile.
+func (m appModel) finishWritingProgram(msg programWrittenMsg) (appModel, tea.Cmd) {
+	if msg.line != m.textInput.Value() {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.logger.Debug("gline writing program failed", zap.Error(msg.err))
+		m.programPreview = "Could not write the program: " + msg.err.Error()
+		return m, nil
+	}
+	return m.updateTextInput(shellinput.ReplaceMsg(msg.written))
+}
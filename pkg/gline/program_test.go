@@ -0,0 +1,84 @@
+package gline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProgramPreview(t *testing.T) {
+	options := NewOptions()
+	var cursors []int
+	options.ProgramPreview = func(ctx context.Context, line string, cursor int) string {
+		cursors = append(cursors, cursor)
+		if strings.Contains(line, "| jq") {
+			return "→ " + line[strings.Index(line, "'"):]
+		}
+		return ""
+	}
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), options)
+	model.explanation = "explained"
+	model.textInput.Width = 80
+
+	updated, _ := model.updateTextInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("cat é.json | jq '.a'")})
+	model = updated
+	stateId := model.programPreviewStateId
+	require.Equal(t, 1, stateId)
+
+	// A preview asked for before the line changed is dropped
+	updated, cmd := model.attemptProgramPreview(attemptProgramPreviewMsg{stateId: stateId - 1})
+	assert.Nil(t, cmd)
+
+	updated, cmd = model.attemptProgramPreview(attemptProgramPreviewMsg{stateId: stateId})
+	require.NotNil(t, cmd)
+	msg := cmd().(programPreviewMsg)
+	assert.Equal(t, []int{len("cat é.json | jq '.a'")}, cursors)
+	model, _ = updated.setProgramPreview(msg)
+	assert.Equal(t, "→ '.a'", model.programPreview)
+	assert.Contains(t, model.View(), "→ '.a'")
+	assert.NotContains(t, model.View(), "explained")
+
+	// Stale previews are dropped too
+	model, _ = model.setProgramPreview(programPreviewMsg{stateId: stateId - 1, preview: "old"})
+	assert.Equal(t, "→ '.a'", model.programPreview)
+}
+
+func TestAltJWritesProgram(t *testing.T) {
+	altJ := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}, Alt: true}
+
+	// Without a writer the key does nothing
+	model := initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), NewOptions())
+	model.textInput.SetValue("cat a.json | jq '# names'")
+	updated, cmd := model.Update(altJ)
+	assert.Nil(t, cmd)
+	assert.Equal(t, "cat a.json | jq '# names'", updated.(appModel).textInput.Value())
+
+	options := NewOptions()
+	options.ProgramWriter = func(ctx context.Context, line string, cursor int) (string, error) {
+		return "cat a.json | jq '.[].name'", nil
+	}
+	model = initialModel("test> ", []string{}, "", nil, nil, nil, zap.NewNop(), options)
+	model.textInput.SetValue("cat a.json | jq '# names'")
+	updated, cmd = model.Update(altJ)
+	require.NotNil(t, cmd)
+	model = updated.(appModel)
+	msg := cmd().(programWrittenMsg)
+
+	// The program written replaces the line, unless it was edited meanwhile
+	stale, _ := model.finishWritingProgram(programWrittenMsg{line: "cat b.json | jq '# names'", written: "cat b.json | jq '.'"})
+	assert.Equal(t, "cat a.json | jq '# names'", stale.textInput.Value())
+
+	model, _ = model.finishWritingProgram(msg)
+	assert.Equal(t, "cat a.json | jq '.[].name'", model.textInput.Value())
+
+	// A failure is shown in the assistant box
+	model, _ = model.finishWritingProgram(programWrittenMsg{line: model.textInput.Value(), err: errors.New("no model")})
+	assert.Equal(t, "cat a.json | jq '.[].name'", model.textInput.Value())
+	assert.Contains(t, model.programPreview, "no model")
+}
@@ -0,0 +1,148 @@
+package gline
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// RenderProfile sets how often the line editor redraws. Over a slow link,
+// such as SSH across the world, every redraw of the boxes and the ghost
+// text takes a round trip, so slower profiles draw less often and batch
+// the updates.
+type RenderProfile int
+
+const (
+	// RenderProfileAuto picks the profile from the round-trip latency of
+	// the terminal, see DetectRenderProfile.
+	RenderProfileAuto RenderProfile = iota
+	// RenderProfileFull redraws as things change, with animations.
+	RenderProfileFull
+	// RenderProfileReduced draws fewer frames, without animations.
+	RenderProfileReduced
+	// RenderProfileMinimal draws the fewest frames and refreshes the
+	// status line rarely.
+	RenderProfileMinimal
+)
+
+// Latency budget of the terminal round trip for each profile: above
+// reducedLatency the profile is reduced, above minimalLatency minimal.
+const (
+	reducedLatency = 60 * time.Millisecond
+	minimalLatency = 200 * time.Millisecond
+	// latencyProbeTimeout is how long to wait for the terminal to answer.
+	// A terminal that does not answer keeps the full profile.
+	latencyProbeTimeout = time.Second
+)
+
+var renderProfileNames = map[RenderProfile]string{
+	RenderProfileAuto:    "auto",
+	RenderProfileFull:    "full",
+	RenderProfileReduced: "reduced",
+	RenderProfileMinimal: "minimal",
+}
+
+func (p RenderProfile) String() string {
+	return renderProfileNames[p]
+}
+
+// ParseRenderProfile returns the profile named s, case-insensitively. It
+// returns RenderProfileAuto and false for an unknown name.
+func ParseRenderProfile(s string) (RenderProfile, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for profile, name := range renderProfileNames {
+		if name == s {
+			return profile, true
+		}
+	}
+	return RenderProfileAuto, false
+}
+
+// renderSettings is what a profile changes.
+type renderSettings struct {
+	// fps caps the frames drawn per second
+	fps int
+	// animate cycles the colors of the LLM indicator
+	animate bool
+	// batch is added to the delays before predictions and previews, so
+	// that more keys are typed before they redraw the boxes
+	batch time.Duration
+	// statusInterval is how often the status line is refreshed
+	statusInterval time.Duration
+}
+
+func (p RenderProfile) settings() renderSettings {
+	switch p {
+	case RenderProfileReduced:
+		return renderSettings{fps: 20, batch: 200 * time.Millisecond, statusInterval: 2 * time.Second}
+	case RenderProfileMinimal:
+		return renderSettings{fps: 8, batch: 600 * time.Millisecond, statusInterval: 5 * time.Second}
+	default:
+		return renderSettings{fps: 60, animate: true, statusInterval: time.Second}
+	}
+}
+
+var (
+	detectOnce      sync.Once
+	detectedProfile RenderProfile
+	detectedLatency time.Duration
+)
+
+// DetectRenderProfile measures the round-trip latency of the terminal, once,
+// and returns the profile within its budget, with the latency it measured,
+// or 0 if the terminal did not answer.
+func DetectRenderProfile() (RenderProfile, time.Duration) {
+	detectOnce.Do(func() {
+		detectedProfile = RenderProfileFull
+		latency, ok := probeTerminalLatency()
+		if !ok {
+			return
+		}
+		detectedLatency = latency
+		switch {
+		case latency >= minimalLatency:
+			detectedProfile = RenderProfileMinimal
+		case latency >= reducedLatency:
+			detectedProfile = RenderProfileReduced
+		}
+	})
+	return detectedProfile, detectedLatency
+}
+
+// probeTerminalLatency times how long the terminal takes to answer a Device
+// Status Report, which includes the link to it.
+func probeTerminalLatency() (time.Duration, bool) {
+	stdinFd := int(os.Stdin.Fd())
+	stdoutFd := int(os.Stdout.Fd())
+	if !term.IsTerminal(stdinFd) || !term.IsTerminal(stdoutFd) {
+		return 0, false
+	}
+
+	oldState, err := term.MakeRaw(stdinFd)
+	if err != nil {
+		return 0, false
+	}
+	defer func() {
+		_ = term.Restore(stdinFd, oldState)
+	}()
+
+	start := time.Now()
+	if _, err := os.Stdout.WriteString("\x1b[6n"); err != nil {
+		return 0, false
+	}
+	_ = os.Stdout.Sync()
+
+	_ = os.Stdin.SetReadDeadline(start.Add(latencyProbeTimeout))
+	defer func() {
+		_ = os.Stdin.SetReadDeadline(time.Time{})
+	}()
+	response := make([]byte, 32)
+	n, err := os.Stdin.Read(response)
+	if err != nil || parseDSRResponse(response[:n]) <= 0 {
+		return 0, false
+	}
+	return time.Since(start), true
+}
@@ -0,0 +1,56 @@
+package gline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestParseRenderProfile(t *testing.T) {
+	for name, want := range map[string]RenderProfile{
+		"auto":      RenderProfileAuto,
+		"full":      RenderProfileFull,
+		" Reduced ": RenderProfileReduced,
+		"MINIMAL":   RenderProfileMinimal,
+	} {
+		profile, ok := ParseRenderProfile(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, profile, name)
+	}
+
+	profile, ok := ParseRenderProfile("fast")
+	assert.False(t, ok)
+	assert.Equal(t, RenderProfileAuto, profile)
+	assert.Equal(t, "minimal", RenderProfileMinimal.String())
+}
+
+func TestRenderProfileSettings(t *testing.T) {
+	full := RenderProfileFull.settings()
+	assert.Equal(t, full, RenderProfileAuto.settings(), "a profile that is not detected yet renders in full")
+	reduced := RenderProfileReduced.settings()
+	minimal := RenderProfileMinimal.settings()
+
+	assert.True(t, full.animate)
+	assert.False(t, reduced.animate)
+	assert.False(t, minimal.animate)
+	assert.Greater(t, full.fps, reduced.fps)
+	assert.Greater(t, reduced.fps, minimal.fps)
+	assert.Less(t, full.batch, reduced.batch)
+	assert.Less(t, reduced.batch, minimal.batch)
+	assert.Less(t, full.statusInterval, reduced.statusInterval)
+	assert.Less(t, reduced.statusInterval, minimal.statusInterval)
+}
+
+func TestRenderProfileStopsAnimation(t *testing.T) {
+	logger := zap.NewNop()
+
+	options := NewOptions()
+	options.RenderProfile = RenderProfileFull
+	model := initialModel("> ", nil, "", newMockPredictor(), nil, nil, logger, options)
+	assert.NotNil(t, model.llmTick())
+
+	options.RenderProfile = RenderProfileMinimal
+	model = initialModel("> ", nil, "", newMockPredictor(), nil, nil, logger, options)
+	assert.Nil(t, model.llmTick(), "the indicator does not animate")
+}
@@ -5,8 +5,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
 	"golang.org/x/term"
 )
 
@@ -83,6 +85,47 @@ func GetRuneWidth(r rune) int {
 	return width
 }
 
+// GetGraphemeWidth returns the display width of a grapheme cluster, such as a
+// letter with combining marks or an emoji ZWJ sequence. Terminals draw the
+// rest of a cluster (combining marks, joiners, skin tones, variation selectors)
+// within the glyph of its first rune, so only that rune takes up space.
+func GetGraphemeWidth(cluster string) int {
+	r, size := utf8.DecodeRuneInString(cluster)
+	// A pair of regional indicators is drawn as a single wide flag
+	if r >= 0x1F1E6 && r <= 0x1F1FF && size < len(cluster) {
+		return 2
+	}
+	return GetRuneWidth(r)
+}
+
+// forEachSegment splits s into ANSI escape sequences and grapheme clusters and
+// calls fn for each piece in order.
+func forEachSegment(s string, fn func(segment string, isEscape bool)) {
+	state := -1
+	for s != "" {
+		if s[0] == '\x1b' {
+			end := 1
+			for end < len(s) && !isEscapeTerminator(s[end]) {
+				end++
+			}
+			end = min(end+1, len(s))
+			fn(s[:end], true)
+			s = s[end:]
+			state = -1
+			continue
+		}
+
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		fn(cluster, false)
+	}
+}
+
+// isEscapeTerminator reports whether b ends an ANSI escape sequence.
+func isEscapeTerminator(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
 // probeTerminalCharWidth uses terminal cursor position reporting to detect
 // the actual rendered width of a character.
 func probeTerminalCharWidth(char rune) int {
@@ -158,7 +201,7 @@ func probeTerminalCharWidth(char rune) int {
 	return width
 }
 
-// WordwrapWithRuneWidth wraps text to the given width using GetRuneWidth for accurate
+// WordwrapWithRuneWidth wraps text to the given width using GetGraphemeWidth for accurate
 // Unicode character width calculation. This is needed because the standard ansi.Wordwrap
 // uses its own width calculation that may not match terminal-specific emoji rendering.
 // It preserves ANSI escape codes in the output.
@@ -171,7 +214,6 @@ func WordwrapWithRuneWidth(s string, width int) string {
 	var lineWidth int
 	var wordBuffer strings.Builder
 	var wordWidth int
-	inEscape := false
 	pendingSpace := false      // Track if we need to add a space before the next word
 	pendingSpaceWidth := 0     // Width of pending space (1 for space, 4 for tab)
 	pendingSpaceRune := ' '    // The actual space character
@@ -192,35 +234,26 @@ func WordwrapWithRuneWidth(s string, width int) string {
 			}
 			pendingSpace = false // Don't add space before broken word
 
-			// Break the word across multiple lines
+			// Break the word across multiple lines, keeping grapheme clusters intact
 			var charBuf strings.Builder
 			charWidth := 0
-			inCharEscape := false
 
-			for _, r := range word {
-				if r == '\x1b' {
-					inCharEscape = true
-					charBuf.WriteRune(r)
-					continue
-				}
-				if inCharEscape {
-					charBuf.WriteRune(r)
-					if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-						inCharEscape = false
-					}
-					continue
+			forEachSegment(word, func(segment string, isEscape bool) {
+				if isEscape {
+					charBuf.WriteString(segment)
+					return
 				}
 
-				rw := GetRuneWidth(r)
-				if charWidth+rw > width && charWidth > 0 {
+				cw := GetGraphemeWidth(segment)
+				if charWidth+cw > width && charWidth > 0 {
 					result.WriteString(charBuf.String())
 					result.WriteRune('\n')
 					charBuf.Reset()
 					charWidth = 0
 				}
-				charBuf.WriteRune(r)
-				charWidth += rw
-			}
+				charBuf.WriteString(segment)
+				charWidth += cw
+			})
 
 			if charBuf.Len() > 0 {
 				result.WriteString(charBuf.String())
@@ -256,49 +289,41 @@ func WordwrapWithRuneWidth(s string, width int) string {
 		wordWidth = 0
 	}
 
-	for _, r := range s {
-		// Handle ANSI escape sequences
-		if r == '\x1b' {
-			inEscape = true
-			wordBuffer.WriteRune(r)
-			continue
-		}
-		if inEscape {
-			wordBuffer.WriteRune(r)
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscape = false
-			}
-			continue
+	forEachSegment(s, func(segment string, isEscape bool) {
+		// ANSI escape sequences stay attached to the word they style
+		if isEscape {
+			wordBuffer.WriteString(segment)
+			return
 		}
 
 		// Handle newlines - they force a line break
-		if r == '\n' {
+		if strings.HasSuffix(segment, "\n") {
 			flushWord()
-			result.WriteRune('\n')
+			result.WriteString(segment)
 			lineWidth = 0
 			pendingSpace = false
-			continue
+			return
 		}
 
 		// Handle spaces - they're word boundaries
-		if r == ' ' || r == '\t' {
+		if segment == " " || segment == "\t" {
 			flushWord()
 
 			// Remember the space for later (we'll add it before the next word if it fits)
 			pendingSpace = true
-			pendingSpaceRune = r
-			if r == '\t' {
+			pendingSpaceRune = rune(segment[0])
+			if segment == "\t" {
 				pendingSpaceWidth = 4 // Approximate tab width
 			} else {
 				pendingSpaceWidth = 1
 			}
-			continue
+			return
 		}
 
 		// Regular character - add to word buffer
-		wordBuffer.WriteRune(r)
-		wordWidth += GetRuneWidth(r)
-	}
+		wordBuffer.WriteString(segment)
+		wordWidth += GetGraphemeWidth(segment)
+	})
 
 	// Flush any remaining word
 	flushWord()
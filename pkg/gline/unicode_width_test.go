@@ -281,6 +281,25 @@ func TestWordwrapWithRuneWidth(t *testing.T) {
 			width:    10,
 			expected: "one\ttwo\nthree",
 		},
+		{
+			name:     "CJK word broken at cell width",
+			input:    "漢字漢字",
+			width:    5,
+			expected: "漢字\n漢字",
+		},
+		{
+			name: "ZWJ sequence is never split",
+			// In test environment the family emoji is 1 cell wide, like its first rune
+			input:    "ab\U0001F468\u200d\U0001F469\u200d\U0001F467cd",
+			width:    3,
+			expected: "ab\U0001F468\u200d\U0001F469\u200d\U0001F467\ncd",
+		},
+		{
+			name:     "combining character stays with its base",
+			input:    "cafe\u0301s",
+			width:    4,
+			expected: "cafe\u0301\ns",
+		},
 	}
 
 	for _, tt := range tests {
@@ -292,3 +311,57 @@ func TestWordwrapWithRuneWidth(t *testing.T) {
 		})
 	}
 }
+
+func TestGetGraphemeWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		cluster  string
+		expected int
+	}{
+		{name: "ASCII", cluster: "a", expected: 1},
+		{name: "combining character", cluster: "e\u0301", expected: 1},
+		{name: "CJK", cluster: "漢", expected: 2},
+		{name: "hangul jamo sequence", cluster: "\u1100\u1161", expected: 2},
+		{name: "flag", cluster: "\U0001F1EF\U0001F1F5", expected: 2},
+		// Emoji widths come from terminal probing, which defaults to 1 in tests
+		{name: "ZWJ sequence", cluster: "\U0001F468\u200d\U0001F469\u200d\U0001F467", expected: GetRuneWidth(0x1F468)},
+		{name: "emoji modifier", cluster: "\U0001F44D\U0001F3FD", expected: GetRuneWidth(0x1F44D)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetGraphemeWidth(tt.cluster); got != tt.expected {
+				t.Errorf("GetGraphemeWidth(%q) = %d, want %d", tt.cluster, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStringWidthWithAnsiCountsGraphemeClusters(t *testing.T) {
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	expected := 2 + GetRuneWidth(0x1F468) + 2
+	if width := stringWidthWithAnsi("\x1b[1m漢\x1b[0m" + family + "字"); width != expected {
+		t.Errorf("stringWidthWithAnsi with ZWJ sequence = %d, want %d", width, expected)
+	}
+}
+
+func TestTruncateWithAnsiKeepsClustersWhole(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxWidth int
+		expected string
+	}{
+		{name: "wide character does not fit", input: "ab漢", maxWidth: 3, expected: "ab"},
+		{name: "combining mark kept", input: "cafe\u0301 au lait", maxWidth: 4, expected: "cafe\u0301"},
+		{name: "trailing reset kept", input: "\x1b[31m漢字漢\x1b[0m", maxWidth: 4, expected: "\x1b[31m漢字\x1b[0m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateWithAnsi(tt.input, tt.maxWidth); got != tt.expected {
+				t.Errorf("truncateWithAnsi(%q, %d) = %q, want %q", tt.input, tt.maxWidth, got, tt.expected)
+			}
+		})
+	}
+}
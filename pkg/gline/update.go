@@ -2,10 +2,14 @@ package gline
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robottwo/bishop/internal/redact"
 	"go.uber.org/zap"
 )
 
@@ -15,7 +19,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case LLMTickMsg:
 		m.llmIndicator.Update()
 		if m.llmIndicator.GetStatus() == LLMStatusInFlight {
-			return m, m.llmIndicator.Tick()
+			return m, m.llmTick()
 		}
 		return m, nil
 
@@ -23,7 +27,10 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.borderStatus.UpdateResources(msg.resources)
 		// Schedule next update based on configured interval
 		interval := time.Duration(m.options.ResourceUpdateInterval) * time.Second
-		return m, tea.Tick(interval, func(t time.Time) tea.Msg {
+		if interval < m.render.statusInterval {
+			interval = m.render.statusInterval
+		}
+		return m, m.tick(interval, func(t time.Time) tea.Msg {
 			// Instead of returning resourceMsg directly (which would block if done synchronously),
 			// we trigger another fetch command which runs in a goroutine
 			return "fetch_resources_trigger"
@@ -40,6 +47,25 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case timerTickMsg:
+		// The timer may have been followed by a break, which counts down too
+		m.borderStatus.SetTimer(m.options.Timer())
+		return m, m.scheduleTimerTick()
+
+	case segmentsTickMsg:
+		m.borderStatus.SetSegments(m.options.StatusSegments())
+		return m, m.scheduleSegmentsTick()
+
+	case historyPollTickMsg:
+		return m, m.pollHistory()
+
+	case historyPollMsg:
+		if m.options.Redact != nil {
+			msg.items = m.masked.redactItems(msg.items, m.options.Redact)
+		}
+		m.textInput.PrependHistory(msg.items)
+		return m, m.scheduleHistoryPoll()
+
 	case promptMsg:
 		// Discard stale prompt updates (similar to prediction state tracking)
 		if msg.stateId != m.promptStateId {
@@ -50,10 +76,16 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			)
 			return m, nil
 		}
-		// Only update if non-empty prompt was generated
+		// Only update if non-empty prompt was generated. The prompt replaces
+		// the one the line started with as it is, so that the line does not
+		// move, and is kept for the scrollback, but does not replace the
+		// prompt of a continuation line
 		if msg.prompt != "" {
 			m.cachedPrompt = msg.prompt
-			m.textInput.Prompt = msg.prompt + " "
+			m.originalPrompt = msg.prompt
+			if !m.multilineState.IsActive() {
+				m.textInput.Prompt = msg.prompt
+			}
 		}
 		return m, nil
 
@@ -74,12 +106,25 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.interrupted = true
 		return m, nil
 
+	case inputSettledMsg:
+		if msg.stateId != m.predictionStateId {
+			return m, nil
+		}
+		m.showLocalPrediction()
+		if m.predictor == nil {
+			return m, nil
+		}
+		return m, func() tea.Msg { return attemptPredictionMsg{stateId: msg.stateId} }
+
 	case attemptPredictionMsg:
 		m.llmIndicator.SetStatus(LLMStatusInFlight)
 		model, cmd := m.attemptPrediction(msg)
-		return model, tea.Batch(cmd, m.llmIndicator.Tick())
+		return model, tea.Batch(cmd, m.llmTick())
 
 	case setPredictionMsg:
+		if msg.candidates != nil {
+			return m.setCandidates(msg.stateId, msg.candidates, msg.inputContext)
+		}
 		return m.setPrediction(msg.stateId, msg.prediction, msg.inputContext)
 
 	case attemptExplanationMsg:
@@ -92,19 +137,102 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.stateId == m.predictionStateId {
 			m.lastError = msg.err
 			m.llmIndicator.SetStatus(LLMStatusError)
-			m.prediction = ""
 			m.explanation = ""
-			m.textInput.SetSuggestions([]string{})
+			// The local prediction stands in for the one that failed
+			if m.localPrediction == "" || m.prediction != m.localPrediction {
+				m.prediction = ""
+				m.textInput.SetSuggestions([]string{})
+			}
 		}
 		return m, nil
 
+	case editorFinishedMsg:
+		return m.finishEditing(msg)
+
+	case pipelineBuiltMsg:
+		return m.finishPipeline(msg)
+
+	case regexTestedMsg:
+		return m.finishRegexTest(msg)
+
+	case attemptProgramPreviewMsg:
+		return m.attemptProgramPreview(msg)
+
+	case programPreviewMsg:
+		return m.setProgramPreview(msg)
+
+	case programWrittenMsg:
+		return m.finishWritingProgram(msg)
+
 	case idleCheckMsg:
 		return m.handleIdleCheck(msg)
 
 	case setIdleSummaryMsg:
 		return m.handleSetIdleSummary(msg)
 
+	case nextCommandsMsg:
+		m.textInput.ReorderNextCommandMenu(msg.commands)
+		return m, nil
+
 	case tea.KeyMsg:
+		// The next command menu handles its own Enter, Esc and Ctrl+C
+		if m.textInput.InNextCommandMenu() {
+			return m.updateTextInput(msg)
+		}
+
+		if key.Matches(msg, m.textInput.KeyMap.ClearScreen) {
+			return m.handleClearScreen()
+		}
+
+		if !m.textInput.InReverseSearch() {
+			if key.Matches(msg, m.textInput.KeyMap.NextPrediction) {
+				return m.cycleCandidates(1)
+			}
+			if key.Matches(msg, m.textInput.KeyMap.PrevPrediction) {
+				return m.cycleCandidates(-1)
+			}
+		}
+
+		if key.Matches(msg, m.textInput.KeyMap.PipelineBuilder) && !m.textInput.InReverseSearch() {
+			if m.options.PipelineBuilder == nil {
+				return m, nil
+			}
+			return m.buildPipeline()
+		}
+
+		if key.Matches(msg, m.textInput.KeyMap.WriteProgram) && !m.textInput.InReverseSearch() {
+			if m.options.ProgramWriter == nil {
+				return m, nil
+			}
+			return m.writeProgram()
+		}
+
+		if key.Matches(msg, m.textInput.KeyMap.RegexTester) && !m.textInput.InReverseSearch() {
+			if m.options.RegexTester == nil {
+				return m, nil
+			}
+			return m.testRegex()
+		}
+
+		if key.Matches(msg, m.textInput.KeyMap.HistoryScope) && !m.textInput.InReverseSearch() {
+			if m.options.ScopedHistory == nil {
+				return m, nil
+			}
+			return m.cycleHistoryScope()
+		}
+
+		// Ctrl+X Ctrl+E opens the line in the editor; after Ctrl+X, any
+		// other key is handled as usual
+		if m.ctrlXPending {
+			m.ctrlXPending = false
+			if msg.String() == "ctrl+e" {
+				return m.editLine()
+			}
+		} else if msg.String() == "ctrl+x" && !m.textInput.InReverseSearch() {
+			m.ctrlXPending = true
+			return m, nil
+		}
+
 		switch msg.String() {
 
 		case "esc":
@@ -132,7 +260,13 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			input := m.textInput.Value()
+			// Expand an abbreviation at the cursor so the line shows what runs
+			m.textInput.ExpandAbbreviation()
+			input, ok := m.unmask(m.textInput.Value())
+			if !ok {
+				m.explanation = "The line has masked secrets in it (" + redact.Mask + "); type them out to run it"
+				return m, nil
+			}
 
 			// Handle multiline input with error handling
 			complete, prompt := m.multilineState.AddLine(input)
@@ -141,6 +275,8 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.textInput.Prompt = prompt + " "
 				// Clear the text input field but preserve the multiline buffer
 				m.textInput.SetValue("")
+				// Don't auto-pair inside an open quote or a here-document body
+				m.textInput.AutoPair = m.options.AutoPair && !m.multilineState.InLiteralContext()
 				return m, nil
 			}
 
@@ -151,6 +287,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Reset the multiline state and continue
 				m.multilineState.Reset()
 				m.textInput.SetValue("")
+				m.textInput.AutoPair = m.options.AutoPair
 				return m, nil
 			}
 
@@ -182,16 +319,29 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// If there's content, do nothing (standard behavior)
 			return m, nil
-		case "ctrl+l":
-			return m.handleClearScreen()
+		case "alt+r":
+			if m.options.OutputToggle != nil {
+				if output := m.options.OutputToggle(); output != "" {
+					return m, tea.Println(output)
+				}
+			}
+			return m, nil
 		}
 	}
 
-	return m.updateTextInput(msg)
+	wasInNextCommandMenu := m.textInput.InNextCommandMenu()
+	updated, cmd := m.updateTextInput(msg)
+	if !wasInNextCommandMenu && updated.textInput.InNextCommandMenu() {
+		cmd = tea.Batch(cmd, updated.rerankNextCommands())
+	}
+	return updated, cmd
 }
 
 func (m *appModel) clearPrediction() {
 	m.prediction = ""
+	m.candidates = nil
+	m.candidateExplanations = nil
+	m.localPrediction = ""
 	m.explanation = ""
 	m.lastError = nil
 	m.textInput.SetSuggestions([]string{})
@@ -201,9 +351,27 @@ func (m *appModel) clearPrediction() {
 // explanation (e.g., coach tips) - used when the input buffer becomes blank
 func (m *appModel) clearPredictionAndRestoreDefault() {
 	m.prediction = ""
+	m.candidates = nil
+	m.candidateExplanations = nil
+	m.localPrediction = ""
 	m.explanation = m.defaultExplanation
 	m.lastError = nil
 	m.textInput.SetSuggestions([]string{})
+	m.showFollowUp()
+}
+
+// showFollowUp suggests the usual follow-up to the last command, if there is
+// one, and notes that it comes from history rather than a prediction. It is
+// not shown if it would reveal a secret.
+func (m *appModel) showFollowUp() bool {
+	followUp := m.options.FollowUp
+	if followUp == "" || m.redact(followUp) != followUp {
+		return false
+	}
+	m.prediction = followUp
+	m.explanation = m.options.FollowUpNote
+	m.textInput.SetSuggestions([]string{followUp})
+	return true
 }
 
 func (m appModel) setPrediction(stateId int, prediction string, inputContext string) (appModel, tea.Cmd) {
@@ -216,15 +384,30 @@ func (m appModel) setPrediction(stateId int, prediction string, inputContext str
 		return m, nil
 	}
 
+	// A prediction that would reveal a secret is not shown at all, since
+	// accepting a masked one would insert the mask
+	if m.redact(prediction) != prediction {
+		prediction = ""
+	}
+
+	// Without a prediction, the local one shown meanwhile stays
+	if prediction == "" && m.localPrediction != "" && m.prediction == m.localPrediction {
+		prediction = m.localPrediction
+	} else {
+		m.localPrediction = ""
+	}
+
 	m.prediction = prediction
 	m.lastPredictionInput = inputContext
 	m.lastPrediction = prediction
 	m.textInput.SetSuggestions([]string{prediction})
 	m.textInput.UpdateHelpInfo()
 
-	// When input is blank and there's no prediction, preserve the default explanation (coach tips)
+	// When input is blank and there's no prediction, show the usual follow-up
+	// or else preserve the default explanation (coach tips)
 	if strings.TrimSpace(m.textInput.Value()) == "" && prediction == "" {
 		m.explanation = m.defaultExplanation
+		m.showFollowUp()
 		// Reset LLM status to prevent pulsing when showing coaching tips
 		m.llmIndicator.SetStatus(LLMStatusSuccess)
 		return m, nil
@@ -241,6 +424,132 @@ func (m appModel) setPrediction(stateId int, prediction string, inputContext str
 	})
 }
 
+// localPredictionTimeout bounds the local predictor, which is called as the
+// user types.
+const localPredictionTimeout = 50 * time.Millisecond
+
+// showLocalPrediction shows what the local predictor predicts for the input
+// right away, until the predictor's prediction arrives.
+func (m *appModel) showLocalPrediction() {
+	value := m.textInput.Value()
+	if m.options.LocalPredictor == nil || strings.TrimSpace(value) == "" || m.textInput.SuggestionsSuppressedUntilInput() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), localPredictionTimeout)
+	defer cancel()
+	prediction, inputContext, err := m.options.LocalPredictor.Predict(ctx, value)
+	if err != nil {
+		m.logger.Debug("gline local prediction failed", zap.Error(err))
+		return
+	}
+	if prediction == "" || m.redact(prediction) != prediction {
+		return
+	}
+	m.prediction = prediction
+	m.localPrediction = prediction
+	m.lastPredictionInput = inputContext
+	m.lastPrediction = prediction
+	m.textInput.SetSuggestions([]string{prediction})
+}
+
+// setCandidates shows the first of the candidates predicted for the input;
+// the others are shown as the user cycles through them.
+func (m appModel) setCandidates(stateId int, candidates []string, inputContext string) (appModel, tea.Cmd) {
+	if stateId != m.predictionStateId {
+		return m.setPrediction(stateId, "", inputContext)
+	}
+
+	// Candidates that would reveal a secret are dropped, as in setPrediction
+	var shown []string
+	for _, candidate := range candidates {
+		if m.redact(candidate) == candidate {
+			shown = append(shown, candidate)
+		}
+	}
+	m.candidates = shown
+	m.candidateExplanations = make(map[string]string)
+	prediction := ""
+	if len(shown) > 0 {
+		prediction = shown[0]
+	}
+	return m.setPrediction(stateId, prediction, inputContext)
+}
+
+// matchingCandidates returns the candidates that still complete the input.
+func (m appModel) matchingCandidates() []string {
+	value := strings.ToLower(m.textInput.Value())
+	var matching []string
+	for _, candidate := range m.candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), value) {
+			matching = append(matching, candidate)
+		}
+	}
+	return matching
+}
+
+// cycleCandidates selects the next candidate, or the previous one when step
+// is negative, and shows its explanation, which is requested the first time
+// it is selected.
+func (m appModel) cycleCandidates(step int) (appModel, tea.Cmd) {
+	matching := m.matchingCandidates()
+	if len(matching) < 2 || m.textInput.SuggestionsSuppressedUntilInput() {
+		return m, nil
+	}
+	index := 0
+	for i, candidate := range matching {
+		if candidate == m.prediction {
+			index = i
+			break
+		}
+	}
+	index = (index + step + len(matching)) % len(matching)
+
+	m.prediction = matching[index]
+	m.lastPrediction = m.prediction
+	m.textInput.SetSuggestions([]string{m.prediction})
+	m.textInput.UpdateHelpInfo()
+	if explanation, ok := m.candidateExplanations[m.prediction]; ok {
+		m.explanation = explanation
+		return m, nil
+	}
+	m.explanation = ""
+	prediction := m.prediction
+	return m, func() tea.Msg {
+		return attemptExplanationMsg{stateId: m.predictionStateId, prediction: prediction}
+	}
+}
+
+// candidateHint tells which of several candidates is shown and how to see
+// the others, or returns "" when there is only one.
+func (m appModel) candidateHint() string {
+	matching := m.matchingCandidates()
+	if len(matching) < 2 || m.textInput.SuggestionsSuppressedUntilInput() {
+		return ""
+	}
+	position := 0
+	for i, candidate := range matching {
+		if candidate == m.prediction {
+			position = i + 1
+		}
+	}
+	if position == 0 {
+		return ""
+	}
+	hint := fmt.Sprintf("Prediction %d of %d", position, len(matching))
+	next, prev := m.textInput.KeyMap.NextPrediction.Keys(), m.textInput.KeyMap.PrevPrediction.Keys()
+	if len(next) > 0 && len(prev) > 0 {
+		hint += fmt.Sprintf(" · %s / %s for the others", prev[0], next[0])
+	}
+	return hint
+}
+
+// Debounce of predictions as the input changes
+const predictionDelay = 200 * time.Millisecond
+
+// burstSettleDelay is how long input must pause after a burst, such as a
+// paste or the text an input method commits, before predictions resume.
+const burstSettleDelay = 500 * time.Millisecond
+
 // LLM call timeout for predictions
 const predictionTimeout = 10 * time.Second
 
@@ -270,7 +579,7 @@ func (m appModel) attemptExplanation(msg attemptExplanationMsg) (appModel, tea.C
 			zap.Int("stateId", msg.stateId),
 			zap.String("explanation", explanation),
 		)
-		return setExplanationMsg{stateId: msg.stateId, explanation: explanation}
+		return setExplanationMsg{stateId: msg.stateId, prediction: msg.prediction, explanation: explanation}
 	})
 }
 
@@ -284,6 +593,15 @@ func (m appModel) setExplanation(msg setExplanationMsg) (appModel, tea.Cmd) {
 		return m, nil
 	}
 
+	// Keep the explanations of candidates for when they are selected again,
+	// and drop that of a candidate the user cycled away from
+	if m.candidateExplanations != nil && slices.Contains(m.candidates, msg.prediction) {
+		m.candidateExplanations[msg.prediction] = msg.explanation
+		if msg.prediction != m.prediction {
+			return m, nil
+		}
+	}
+
 	m.explanation = msg.explanation
 	// Mark LLM as successful since explanation is the last step
 	m.llmIndicator.SetStatus(LLMStatusSuccess)
@@ -307,6 +625,23 @@ func (m appModel) attemptPrediction(msg attemptPredictionMsg) (appModel, tea.Cmd
 		ctx, cancel := context.WithTimeout(context.Background(), predictionTimeout)
 		defer cancel()
 
+		if candidatePredictor, ok := m.predictor.(CandidatePredictor); ok && m.options.PredictionCandidates > 1 {
+			candidates, inputContext, err := candidatePredictor.PredictCandidates(ctx, m.textInput.Value(), m.options.PredictionCandidates)
+			if err != nil {
+				m.logger.Error("gline prediction failed", zap.Error(err))
+				return errorMsg{stateId: msg.stateId, err: err}
+			}
+			m.logger.Debug(
+				"gline predicted candidates",
+				zap.Int("stateId", msg.stateId),
+				zap.Strings("candidates", candidates),
+			)
+			if candidates == nil {
+				candidates = []string{}
+			}
+			return setPredictionMsg{stateId: msg.stateId, candidates: candidates, inputContext: inputContext}
+		}
+
 		prediction, inputContext, err := m.predictor.Predict(ctx, m.textInput.Value())
 		if err != nil {
 			m.logger.Error("gline prediction failed", zap.Error(err))
@@ -335,6 +670,28 @@ func (m appModel) updateTextInput(msg tea.Msg) (appModel, tea.Cmd) {
 	suggestionsCleared := len(oldMatchedSuggestions) > 0 && len(newMatchedSuggestions) == 0
 	m.textInput = updatedTextInput
 
+	// Pastes and the text input methods for languages such as Japanese commit
+	// come in as several runes at once, often in a row. Predicting from each
+	// part would flash predictions for text that is not complete yet, so
+	// they are held back until the input settles.
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	burst := textUpdated && isKey && (keyMsg.Paste || len(keyMsg.Runes) > 1)
+	if burst && (m.predictor != nil || m.options.LocalPredictor != nil) {
+		m.predictionStateId++
+		m.borderStatus.UpdateInput(newVal)
+		m.lastError = nil
+		m.lastInputTime = time.Now()
+		m.idleSummaryShown = false
+		m.idleSummaryStateId++
+		m.dirty = m.dirty || newVal != ""
+		m.clearPrediction()
+		stateId := m.predictionStateId
+		cmd = tea.Batch(cmd, m.scheduleProgramPreview(), m.tick(burstSettleDelay+m.render.batch, func(t time.Time) tea.Msg {
+			return inputSettledMsg{stateId: stateId}
+		}))
+		return m, cmd
+	}
+
 	// if the text input has changed, we want to attempt a prediction
 	if textUpdated && m.predictor != nil {
 		m.predictionStateId++
@@ -371,25 +728,32 @@ func (m appModel) updateTextInput(msg tea.Msg) (appModel, tea.Cmd) {
 			// autocomplete hints hidden until new input arrives.
 			m.clearPrediction()
 			if len(userInput) > 0 {
-				cmd = tea.Batch(cmd, tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
+				cmd = tea.Batch(cmd, m.tick(predictionDelay+m.render.batch, func(t time.Time) tea.Msg {
 					return attemptPredictionMsg{
 						stateId: m.predictionStateId,
 					}
 				}))
 			}
-		case len(userInput) > 0 && strings.HasPrefix(m.prediction, userInput) && !suggestionsCleared && !suppressionLifted:
+		case len(userInput) > 0 && strings.HasPrefix(m.prediction, userInput) && !suggestionsCleared && !suppressionLifted && m.localPrediction == "":
 			// if the prediction already starts with the user input, we don't need to predict again
 			m.logger.Debug("gline existing predicted input already starts with user input", zap.String("userInput", userInput))
 		default:
-			// in other cases, we should kick off a debounced prediction after clearing the current one
+			// in other cases, we should kick off a debounced prediction after clearing the current one,
+			// showing the local prediction meanwhile
 			m.clearPrediction()
+			m.showLocalPrediction()
 
-			cmd = tea.Batch(cmd, tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
+			cmd = tea.Batch(cmd, m.tick(predictionDelay+m.render.batch, func(t time.Time) tea.Msg {
 				return attemptPredictionMsg{
 					stateId: m.predictionStateId,
 				}
 			}))
 		}
+	} else if textUpdated && m.options.LocalPredictor != nil {
+		// Without a predictor, the local prediction is the only one
+		m.prediction, m.localPrediction = "", ""
+		m.textInput.SetSuggestions([]string{})
+		m.showLocalPrediction()
 	} else if suggestionsCleared {
 		// User trimmed away ghost suggestions (e.g., via Ctrl+K) without changing
 		// the underlying input. Clear any pending prediction and explanation so the
@@ -400,13 +764,17 @@ func (m appModel) updateTextInput(msg tea.Msg) (appModel, tea.Cmd) {
 		if m.predictor != nil {
 			m.predictionStateId++
 			if len(m.textInput.Value()) > 0 {
-				cmd = tea.Batch(cmd, tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
+				cmd = tea.Batch(cmd, m.tick(predictionDelay+m.render.batch, func(t time.Time) tea.Msg {
 					return attemptPredictionMsg{stateId: m.predictionStateId}
 				}))
 			}
 		}
 	}
 
+	if textUpdated {
+		cmd = tea.Batch(cmd, m.scheduleProgramPreview())
+	}
+
 	return m, cmd
 }
 
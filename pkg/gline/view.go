@@ -45,6 +45,12 @@ func (m appModel) View() string {
 	if m.textInput.InReverseSearch() && m.height > 0 {
 		// Use most of terminal height, leaving room for prompt line (2) and borders (2)
 		availableHeight = max(m.options.AssistantHeight, m.height-4)
+	} else if m.textInput.InNextCommandMenu() {
+		// Room for every command, the header and help, within the screen
+		availableHeight = max(m.options.AssistantHeight, len(m.textInput.NextCommandMenuItems())+2)
+		if m.height > 0 {
+			availableHeight = min(availableHeight, max(m.options.AssistantHeight, m.height-4))
+		}
 	}
 
 	// Track if content is pre-formatted (completion/history boxes) and should skip word wrapping
@@ -66,10 +72,14 @@ func (m appModel) View() string {
 
 		completionBox := m.textInput.CompletionBoxView(availableHeight, completionWidth)
 		historyBox := m.textInput.HistorySearchBoxView(availableHeight, max(0, m.textInput.Width-2))
+		nextCommandBox := m.textInput.NextCommandMenuView(availableHeight, max(0, m.textInput.Width-2))
 
 		if historyBox != "" {
 			assistantContent = historyBox
 			isPreformatted = true
+		} else if nextCommandBox != "" {
+			assistantContent = m.redact(nextCommandBox)
+			isPreformatted = true
 		} else if completionBox != "" && helpBox != "" {
 			// Clean up help box text to avoid redundancy
 			// Remove headers like "**#name** - " or "**name** - " using regex
@@ -101,8 +111,13 @@ func (m appModel) View() string {
 			isPreformatted = true
 		} else if helpBox != "" {
 			assistantContent = helpBox
+		} else if m.programPreview != "" {
+			assistantContent = m.redact(m.programPreview)
 		} else {
-			assistantContent = m.explanation
+			assistantContent = m.redact(m.explanation)
+			if hint := m.candidateHint(); hint != "" {
+				assistantContent = strings.TrimSpace(assistantContent + "\n" + hint)
+			}
 		}
 	}
 
@@ -383,23 +398,11 @@ func (m appModel) View() string {
 // Uses terminal-specific probing for emoji characters to get accurate widths
 func stringWidthWithAnsi(s string) int {
 	width := 0
-	inEscape := false
-
-	for _, r := range s {
-		if r == '\x1b' {
-			inEscape = true
-			continue
-		}
-		if inEscape {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscape = false
-			}
-			continue
+	forEachSegment(s, func(segment string, isEscape bool) {
+		if !isEscape {
+			width += GetGraphemeWidth(segment)
 		}
-
-		width += GetRuneWidth(r)
-	}
-
+	})
 	return width
 }
 
@@ -412,30 +415,27 @@ func truncateWithAnsi(s string, maxWidth int) string {
 
 	var result strings.Builder
 	width := 0
-	inEscape := false
+	full := false
 
-	for _, r := range s {
-		if r == '\x1b' {
-			inEscape = true
-			result.WriteRune(r)
-			continue
+	forEachSegment(s, func(segment string, isEscape bool) {
+		if isEscape {
+			// Keep escape codes so styles are still reset after the cut
+			result.WriteString(segment)
+			return
 		}
-		if inEscape {
-			result.WriteRune(r)
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscape = false
-			}
-			continue
+		if full {
+			return
 		}
 
-		// Check if adding this rune would exceed maxWidth
-		runeWidth := GetRuneWidth(r)
-		if width+runeWidth > maxWidth {
-			break
+		// Check if adding this grapheme cluster would exceed maxWidth
+		clusterWidth := GetGraphemeWidth(segment)
+		if width+clusterWidth > maxWidth {
+			full = true
+			return
 		}
-		result.WriteRune(r)
-		width += runeWidth
-	}
+		result.WriteString(segment)
+		width += clusterWidth
+	})
 
 	return result.String()
 }
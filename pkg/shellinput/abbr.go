@@ -0,0 +1,56 @@
+package shellinput
+
+import "unicode"
+
+// isCommandSeparator returns true for the characters after which a new
+// command starts.
+func isCommandSeparator(r rune) bool {
+	return r == ';' || r == '|' || r == '&' || r == '(' || r == '{' || r == '\n'
+}
+
+// ExpandAbbreviation replaces the word before the cursor with its expansion
+// if it is an abbreviation typed where a command goes, e.g. "gco" with
+// "git checkout". It is called when space or Enter is typed, so that the
+// expansion is seen before the line runs. It returns whether the word was
+// expanded.
+func (m *Model) ExpandAbbreviation() bool {
+	if m.Abbreviations == nil || m.EchoMode != EchoNormal || m.inReverseSearch {
+		return false
+	}
+
+	value := m.values[m.selectedValueIndex]
+	if m.pos < len(value) && !unicode.IsSpace(value[m.pos]) {
+		return false
+	}
+	start := m.pos
+	for start > 0 && !unicode.IsSpace(value[start-1]) && !isCommandSeparator(value[start-1]) {
+		start--
+	}
+	if start == m.pos {
+		return false
+	}
+
+	// Only a word in command position expands, not arguments or quoted text
+	before := value[:start]
+	if ctx := scanShellContext(before); ctx.quote != 0 || ctx.escaped || ctx.comment {
+		return false
+	}
+	i := len(before)
+	for i > 0 && unicode.IsSpace(before[i-1]) && before[i-1] != '\n' {
+		i--
+	}
+	if i > 0 && !isCommandSeparator(before[i-1]) {
+		return false
+	}
+
+	expansion, ok := m.Abbreviations(string(value[start:m.pos]))
+	if !ok {
+		return false
+	}
+	newValue := append(append(append([]rune{}, value[:start]...), []rune(expansion)...), value[m.pos:]...)
+	m.Err = m.validate(newValue)
+	m.values[0] = newValue
+	m.selectedValueIndex = 0
+	m.SetCursor(start + len([]rune(expansion)))
+	return true
+}
@@ -0,0 +1,70 @@
+package shellinput
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAbbrModel() Model {
+	m := New()
+	m.Focus()
+	abbreviations := map[string]string{"gco": "git checkout", "k": "kubectl"}
+	m.Abbreviations = func(word string) (string, bool) {
+		expansion, ok := abbreviations[word]
+		return expansion, ok
+	}
+	return m
+}
+
+func TestAbbreviationExpandsOnSpace(t *testing.T) {
+	tests := []struct {
+		name     string
+		typed    string
+		expected string
+	}{
+		{name: "command", typed: "gco main", expected: "git checkout main"},
+		{name: "after pipe", typed: "ls | k get", expected: "ls | kubectl get"},
+		{name: "after and", typed: "make && gco -", expected: "make && git checkout -"},
+		{name: "after semicolon", typed: "true;gco x", expected: "true;git checkout x"},
+		{name: "argument", typed: "echo gco ", expected: "echo gco "},
+		{name: "unknown word", typed: "git co", expected: "git co"},
+		{name: "inside quotes", typed: `echo "gco `, expected: `echo "gco `},
+		{name: "longer word", typed: "gcom ", expected: "gcom "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeRunes(newAbbrModel(), tt.typed)
+			assert.Equal(t, tt.expected, m.Value())
+			assert.Equal(t, len([]rune(tt.expected)), m.Position())
+		})
+	}
+}
+
+func TestAbbreviationExpandsOnSpaceKey(t *testing.T) {
+	m := typeRunes(newAbbrModel(), "gco")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Equal(t, "git checkout ", m.Value())
+}
+
+func TestExpandAbbreviationAtEnd(t *testing.T) {
+	m := typeRunes(newAbbrModel(), "gco")
+	assert.True(t, m.ExpandAbbreviation())
+	assert.Equal(t, "git checkout", m.Value())
+
+	// Not in the middle of a word, nor without abbreviations
+	m = typeRunes(newAbbrModel(), "gco")
+	m.SetCursor(2)
+	assert.False(t, m.ExpandAbbreviation())
+
+	m = typeRunes(New(), "gco")
+	assert.False(t, m.ExpandAbbreviation())
+}
+
+func TestAbbreviationNotExpandedWhenPasted(t *testing.T) {
+	m := newAbbrModel()
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("gco "), Paste: true})
+	assert.Equal(t, "gco ", m.Value())
+}
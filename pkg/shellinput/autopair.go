@@ -0,0 +1,154 @@
+package shellinput
+
+import "unicode"
+
+// autoPairs maps characters that open a pair to the character that closes it.
+var autoPairs = map[rune]rune{
+	'(':  ')',
+	'[':  ']',
+	'{':  '}',
+	'"':  '"',
+	'\'': '\'',
+	'`':  '`',
+}
+
+// isQuoteRune returns true for the shell quoting characters handled by auto-pairing.
+func isQuoteRune(r rune) bool {
+	return r == '"' || r == '\'' || r == '`'
+}
+
+// isClosingBracket returns true for characters that close a bracket pair.
+func isClosingBracket(r rune) bool {
+	return r == ')' || r == ']' || r == '}'
+}
+
+// shellContext describes the lexical state at the end of a piece of shell input.
+type shellContext struct {
+	quote   rune // the open quote character, or 0 when not inside quotes
+	escaped bool // the input ends with an unescaped backslash
+	comment bool // the input ends inside a comment
+}
+
+// scanShellContext walks v using shell quoting rules and reports the state at its
+// end. Single quotes take everything literally, while double quotes and
+// backticks honor backslash escapes.
+func scanShellContext(v []rune) shellContext {
+	var ctx shellContext
+	for i, r := range v {
+		if ctx.escaped {
+			ctx.escaped = false
+			continue
+		}
+
+		switch ctx.quote {
+		case '\'':
+			if r == '\'' {
+				ctx.quote = 0
+			}
+		case '"', '`':
+			if r == '\\' {
+				ctx.escaped = true
+			} else if r == ctx.quote {
+				ctx.quote = 0
+			}
+		default:
+			switch {
+			case r == '\\':
+				ctx.escaped = true
+			case isQuoteRune(r):
+				ctx.quote = r
+			case r == '#' && (i == 0 || unicode.IsSpace(v[i-1])):
+				ctx.comment = true
+				return ctx
+			}
+		}
+	}
+	return ctx
+}
+
+// autoPairInsert handles typing r when AutoPair is enabled. Typing an opening
+// quote or bracket inserts its closing partner with the cursor in between, and
+// typing a closing character that is already under the cursor moves past it.
+// It returns false when r should be inserted normally instead, such as after a
+// backslash, inside a comment, inside quotes, or directly after a word.
+func (m *Model) autoPairInsert(r rune) bool {
+	if !m.AutoPair || m.EchoMode != EchoNormal {
+		return false
+	}
+
+	value := m.values[m.selectedValueIndex]
+	before := value[:m.pos]
+	ctx := scanShellContext(before)
+	if ctx.escaped || ctx.comment {
+		return false
+	}
+
+	var next rune
+	hasNext := m.pos < len(value)
+	if hasNext {
+		next = value[m.pos]
+	}
+
+	// Step over a closing character instead of inserting a duplicate
+	if hasNext && next == r {
+		if (isQuoteRune(r) && ctx.quote == r) || (isClosingBracket(r) && ctx.quote == 0) {
+			m.lastCommandWasKill = false
+			m.lastYankActive = false
+			m.SetCursor(m.pos + 1)
+			return true
+		}
+	}
+
+	closer, ok := autoPairs[r]
+	if !ok || ctx.quote != 0 {
+		return false
+	}
+
+	// Only pair when nothing but whitespace or a closing character follows, so
+	// that quoting an existing word does not leave a stray closing character
+	if hasNext && !unicode.IsSpace(next) && !isClosingBracket(next) && !isQuoteRune(next) {
+		return false
+	}
+
+	// Quotes directly after a word are usually literal (e.g. don't, --opt="...)
+	if isQuoteRune(r) && len(before) > 0 {
+		prev := before[len(before)-1]
+		if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			return false
+		}
+	}
+
+	m.insertRunesFromUserInput([]rune{r, closer})
+	m.SetCursor(m.pos - 1)
+	return true
+}
+
+// autoPairDelete handles backspace between an empty auto-inserted pair such as
+// "(|)" by deleting both characters. It returns false if there is no such pair.
+func (m *Model) autoPairDelete() bool {
+	if !m.AutoPair || m.EchoMode != EchoNormal {
+		return false
+	}
+
+	value := m.values[m.selectedValueIndex]
+	if m.pos == 0 || m.pos >= len(value) {
+		return false
+	}
+
+	opener := value[m.pos-1]
+	if closer, ok := autoPairs[opener]; !ok || value[m.pos] != closer {
+		return false
+	}
+
+	ctx := scanShellContext(value[:m.pos-1])
+	if ctx.escaped || ctx.comment || ctx.quote != 0 {
+		return false
+	}
+
+	newValue := cloneConcatRunes(value[:m.pos-1], value[m.pos+1:])
+	m.Err = m.validate(newValue)
+	m.values[0] = newValue
+	m.selectedValueIndex = 0
+	m.SetCursor(m.pos - 1)
+	return true
+}
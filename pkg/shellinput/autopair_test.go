@@ -0,0 +1,128 @@
+package shellinput
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func typeRunes(m Model, s string) Model {
+	for _, r := range s {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func newAutoPairModel() Model {
+	m := New()
+	m.Focus()
+	m.AutoPair = true
+	return m
+}
+
+func TestAutoPairInsertsClosingCharacter(t *testing.T) {
+	tests := []struct {
+		name     string
+		typed    string
+		expected string
+		pos      int
+	}{
+		{name: "double quote", typed: `echo "`, expected: `echo ""`, pos: 6},
+		{name: "single quote", typed: `echo '`, expected: `echo ''`, pos: 6},
+		{name: "backtick", typed: "echo `", expected: "echo ``", pos: 6},
+		{name: "parenthesis", typed: "echo $(", expected: "echo $()", pos: 7},
+		{name: "brace", typed: "{", expected: "{}", pos: 1},
+		{name: "bracket", typed: "[", expected: "[]", pos: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeRunes(newAutoPairModel(), tt.typed)
+			assert.Equal(t, tt.expected, m.Value())
+			assert.Equal(t, tt.pos, m.Position())
+		})
+	}
+}
+
+func TestAutoPairTypingThroughClosingCharacter(t *testing.T) {
+	m := typeRunes(newAutoPairModel(), `echo "hi"`)
+	assert.Equal(t, `echo "hi"`, m.Value())
+	assert.Equal(t, len(`echo "hi"`), m.Position())
+
+	m = typeRunes(newAutoPairModel(), "echo $(date)")
+	assert.Equal(t, "echo $(date)", m.Value())
+	assert.Equal(t, len("echo $(date)"), m.Position())
+}
+
+func TestAutoPairSkipsLiteralContexts(t *testing.T) {
+	tests := []struct {
+		name     string
+		typed    string
+		expected string
+	}{
+		{name: "escaped quote", typed: `echo \"`, expected: `echo \"`},
+		{name: "apostrophe in word", typed: `echo don't`, expected: `echo don't`},
+		{name: "comment", typed: `ls # "`, expected: `ls # "`},
+		{name: "bracket inside quotes", typed: `echo "$(`, expected: `echo "$("`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeRunes(newAutoPairModel(), tt.typed)
+			assert.Equal(t, tt.expected, m.Value())
+		})
+	}
+
+	// Opening a quote directly before existing text inserts a single character
+	m := newAutoPairModel()
+	m.SetValue("echo word")
+	m.SetCursor(5)
+	m = typeRunes(m, `"`)
+	assert.Equal(t, `echo "word`, m.Value())
+}
+
+func TestAutoPairBackspaceDeletesEmptyPair(t *testing.T) {
+	m := typeRunes(newAutoPairModel(), "echo (")
+	assert.Equal(t, "echo ()", m.Value())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	assert.Equal(t, "echo ", m.Value())
+	assert.Equal(t, 5, m.Position())
+}
+
+func TestAutoPairDisabledByDefault(t *testing.T) {
+	m := New()
+	m.Focus()
+	m = typeRunes(m, `echo "(`)
+	assert.Equal(t, `echo "(`, m.Value())
+}
+
+func TestAutoPairIgnoresPaste(t *testing.T) {
+	m := newAutoPairModel()
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(`"`), Paste: true})
+	assert.Equal(t, `"`, m.Value())
+}
+
+func TestScanShellContext(t *testing.T) {
+	assert.Equal(t, shellContext{}, scanShellContext([]rune(`echo "a" 'b'`)))
+	assert.Equal(t, shellContext{quote: '"'}, scanShellContext([]rune(`echo "it's`)))
+	assert.Equal(t, shellContext{quote: '\''}, scanShellContext([]rune(`echo 'a\`)))
+	assert.Equal(t, shellContext{escaped: true}, scanShellContext([]rune(`echo \`)))
+	assert.Equal(t, shellContext{comment: true}, scanShellContext([]rune(`ls # note "`)))
+	assert.Equal(t, shellContext{}, scanShellContext([]rune(`echo a#b`)))
+}
+
+func TestPasteFilter(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.PasteFilter = strings.ToUpper
+	m = typeRunes(m, "ls ")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("docs"), Paste: true})
+	assert.Equal(t, "ls DOCS", m.Value())
+
+	// Typed text is not filtered
+	m = typeRunes(m, " x")
+	assert.Equal(t, "ls DOCS x", m.Value())
+}
@@ -1,10 +1,12 @@
 package shellinput
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSwapCharacters(t *testing.T) {
@@ -108,3 +110,175 @@ func TestInsertLastArg(t *testing.T) {
 	updatedModel, _ = updatedModel.Update(msg)
 	assert.Equal(t, "one one", updatedModel.Value(), "Reset cycling failed")
 }
+
+func TestToggleSudo(t *testing.T) {
+	model := New()
+	model.Focus()
+	model.SetHistoryValues([]string{"apt install jq"})
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}, Alt: true}
+
+	// Empty line: recall the last command with sudo, like "sudo !!"
+	updatedModel, _ := model.Update(msg)
+	assert.Equal(t, "sudo apt install jq", updatedModel.Value())
+	assert.Equal(t, 19, updatedModel.Position())
+
+	// Pressing again removes it, keeping the cursor on the same character
+	updatedModel.SetCursor(9)
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "apt install jq", updatedModel.Value())
+	assert.Equal(t, 4, updatedModel.Position())
+
+	// A line being typed gets the prefix
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "sudo apt install jq", updatedModel.Value())
+	assert.Equal(t, 9, updatedModel.Position())
+
+	// The cursor stays at the start when the prefix is removed from under it
+	updatedModel.SetCursor(2)
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "apt install jq", updatedModel.Value())
+	assert.Equal(t, 0, updatedModel.Position())
+}
+
+func TestToggleSudoWithoutHistory(t *testing.T) {
+	model := New()
+	model.Focus()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}, Alt: true}
+	updatedModel, _ := model.Update(msg)
+	assert.Equal(t, "", updatedModel.Value())
+}
+
+func TestApplyUsualFlags(t *testing.T) {
+	model := New()
+	model.Focus()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}, Alt: true}
+
+	// Without a callback the key does nothing
+	model.SetValue("ls src")
+	updatedModel, _ := model.Update(msg)
+	assert.Equal(t, "ls src", updatedModel.Value())
+
+	model.UsualFlags = func(line string) (string, bool) {
+		if !strings.HasPrefix(line, "ls") || strings.Contains(line, "-la") {
+			return "", false
+		}
+		return "ls -la" + strings.TrimPrefix(line, "ls"), true
+	}
+
+	// The cursor at the end stays at the end
+	updatedModel, _ = model.Update(msg)
+	assert.Equal(t, "ls -la src", updatedModel.Value())
+	assert.Equal(t, 10, updatedModel.Position())
+
+	// Nothing left to add
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "ls -la src", updatedModel.Value())
+
+	// A cursor before the inserted flags does not move
+	model.SetValue("ls src")
+	model.SetCursor(1)
+	updatedModel, _ = model.Update(msg)
+	assert.Equal(t, "ls -la src", updatedModel.Value())
+	assert.Equal(t, 1, updatedModel.Position())
+}
+
+func TestCycleArgs(t *testing.T) {
+	model := New()
+	model.Focus()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}, Alt: true}
+
+	// Without a callback the key does nothing
+	model.SetValue("kubectl logs ")
+	updatedModel, _ := model.Update(msg)
+	assert.Equal(t, "kubectl logs ", updatedModel.Value())
+
+	model.ArgHistory = func(line string) []string {
+		if line != "kubectl logs " {
+			return nil
+		}
+		return []string{"kubectl logs -f web", "kubectl logs worker"}
+	}
+
+	// Each press shows the next previous arguments, then the typed line again
+	updatedModel, _ = model.Update(msg)
+	assert.Equal(t, "kubectl logs -f web", updatedModel.Value())
+	assert.Equal(t, len("kubectl logs -f web"), updatedModel.Position())
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "kubectl logs worker", updatedModel.Value())
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "kubectl logs ", updatedModel.Value())
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "kubectl logs -f web", updatedModel.Value())
+
+	// Another key ends the cycle, so the next press looks up the new line
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	updatedModel, _ = updatedModel.Update(msg)
+	assert.Equal(t, "kubectl logs -f webx", updatedModel.Value())
+
+	// No previous arguments
+	model.SetValue("git push ")
+	updatedModel, _ = model.Update(msg)
+	assert.Equal(t, "git push ", updatedModel.Value())
+}
+
+func TestNextCommandMenu(t *testing.T) {
+	model := New()
+	model.Focus()
+	open := tea.KeyMsg{Type: tea.KeyCtrlAt}
+
+	// Without a callback the key does nothing
+	updatedModel, _ := model.Update(open)
+	assert.False(t, updatedModel.InNextCommandMenu())
+
+	model.NextCommands = func() []string {
+		return []string{"make test", "git status", "ls"}
+	}
+
+	// Not on a line that has text
+	model.SetValue("git")
+	updatedModel, _ = model.Update(open)
+	assert.False(t, updatedModel.InNextCommandMenu())
+
+	model.SetValue("")
+	updatedModel, _ = model.Update(open)
+	require.True(t, updatedModel.InNextCommandMenu())
+	assert.Contains(t, updatedModel.NextCommandMenuView(10, 80), "1 make test")
+
+	// Arrows and Enter
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, updatedModel.InNextCommandMenu())
+	assert.Equal(t, "git status", updatedModel.Value())
+	assert.Equal(t, len("git status"), updatedModel.Position())
+
+	// A digit picks directly; one past the end does nothing
+	updatedModel.SetValue("")
+	updatedModel, _ = updatedModel.Update(open)
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'4'}})
+	assert.True(t, updatedModel.InNextCommandMenu())
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	assert.Equal(t, "ls", updatedModel.Value())
+
+	// Esc closes the menu and leaves the line empty
+	updatedModel.SetValue("")
+	updatedModel, _ = updatedModel.Update(open)
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, updatedModel.InNextCommandMenu())
+	assert.Equal(t, "", updatedModel.Value())
+}
+
+func TestReorderNextCommandMenu(t *testing.T) {
+	model := New()
+	model.Focus()
+	model.NextCommands = func() []string {
+		return []string{"make test", "git status"}
+	}
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+
+	// Other commands are ignored
+	updatedModel.ReorderNextCommandMenu([]string{"rm -rf /", "make test"})
+	assert.Equal(t, []string{"make test", "git status"}, updatedModel.NextCommandMenuItems())
+
+	updatedModel.ReorderNextCommandMenu([]string{"git status", "make test"})
+	assert.Equal(t, []string{"git status", "make test"}, updatedModel.NextCommandMenuItems())
+}
@@ -0,0 +1,104 @@
+package shellinput
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// The input value is stored as runes, but what the user sees as a single
+// character may span several runes: an accented letter written with a
+// combining mark, an emoji with a skin tone modifier, or a family emoji joined
+// with zero-width joiners. These helpers let cursor movement, deletion and
+// rendering treat such grapheme clusters as one unit.
+
+// nextGraphemeBoundary returns the rune index just past the grapheme cluster
+// that starts at or contains pos. It returns len(v) if pos is at or past the end.
+func nextGraphemeBoundary(v []rune, pos int) int {
+	if pos >= len(v) {
+		return len(v)
+	}
+	boundary := 0
+	state := -1
+	rest := string(v)
+	for rest != "" {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		boundary += len([]rune(cluster))
+		if boundary > pos {
+			return boundary
+		}
+	}
+	return len(v)
+}
+
+// prevGraphemeBoundary returns the rune index where the grapheme cluster that
+// ends at pos starts. It returns 0 if pos is at or before the start.
+func prevGraphemeBoundary(v []rune, pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	pos = min(pos, len(v))
+	boundary := 0
+	state := -1
+	rest := string(v)
+	for rest != "" {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		next := boundary + len([]rune(cluster))
+		if next >= pos {
+			return boundary
+		}
+		boundary = next
+	}
+	return boundary
+}
+
+// isEscapeTerminator reports whether b ends an ANSI escape sequence.
+func isEscapeTerminator(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// wrapGraphemes hard-wraps s so that no line is wider than width terminal
+// cells. Unlike rune-based wrapping it never splits a grapheme cluster across
+// lines and measures each cluster with its east asian width, so wide CJK
+// characters and emoji sequences are accounted for correctly. ANSI escape
+// sequences are copied through without taking up width.
+func wrapGraphemes(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	var result strings.Builder
+	lineWidth := 0
+	state := -1
+	for s != "" {
+		if s[0] == '\x1b' {
+			end := 1
+			for end < len(s) && !isEscapeTerminator(s[end]) {
+				end++
+			}
+			end = min(end+1, len(s))
+			result.WriteString(s[:end])
+			s = s[end:]
+			state = -1
+			continue
+		}
+
+		var cluster string
+		var clusterWidth int
+		cluster, s, clusterWidth, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if strings.HasSuffix(cluster, "\n") {
+			result.WriteString(cluster)
+			lineWidth = 0
+			continue
+		}
+		if lineWidth+clusterWidth > width && lineWidth > 0 {
+			result.WriteByte('\n')
+			lineWidth = 0
+		}
+		result.WriteString(cluster)
+		lineWidth += clusterWidth
+	}
+	return result.String()
+}
@@ -0,0 +1,136 @@
+package shellinput
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	combiningE  = "e\u0301"                                    // e + COMBINING ACUTE ACCENT
+	familyEmoji = "\U0001F468\u200d\U0001F469\u200d\U0001F467" // man ZWJ woman ZWJ girl
+	thumbsUp    = "\U0001F44D\U0001F3FD"                       // thumbs up + skin tone modifier
+)
+
+func TestGraphemeBoundaries(t *testing.T) {
+	v := []rune("a" + combiningE + familyEmoji + "漢")
+
+	// a | e+acute (2 runes) | family (5 runes) | 漢
+	assert.Equal(t, 1, nextGraphemeBoundary(v, 0))
+	assert.Equal(t, 3, nextGraphemeBoundary(v, 1))
+	assert.Equal(t, 8, nextGraphemeBoundary(v, 3))
+	assert.Equal(t, 8, nextGraphemeBoundary(v, 5), "a position inside a cluster moves to its end")
+	assert.Equal(t, 9, nextGraphemeBoundary(v, 8))
+	assert.Equal(t, 9, nextGraphemeBoundary(v, 9))
+
+	assert.Equal(t, 8, prevGraphemeBoundary(v, 9))
+	assert.Equal(t, 3, prevGraphemeBoundary(v, 8))
+	assert.Equal(t, 1, prevGraphemeBoundary(v, 3))
+	assert.Equal(t, 0, prevGraphemeBoundary(v, 1))
+	assert.Equal(t, 0, prevGraphemeBoundary(v, 0))
+}
+
+func TestCursorMovesByGraphemeCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+	}{
+		{name: "combining character", cluster: combiningE},
+		{name: "ZWJ sequence", cluster: familyEmoji},
+		{name: "emoji modifier", cluster: thumbsUp},
+		{name: "CJK", cluster: "漢"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.Focus()
+			m.SetValue("x" + tt.cluster + "y")
+			clusterLen := len([]rune(tt.cluster))
+
+			m.SetCursor(1)
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+			assert.Equal(t, 1+clusterLen, m.Position())
+
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+			assert.Equal(t, 1, m.Position())
+		})
+	}
+}
+
+func TestDeleteRemovesWholeGraphemeCluster(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.SetValue("echo " + familyEmoji + combiningE)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	assert.Equal(t, "echo "+familyEmoji, m.Value())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	assert.Equal(t, "echo ", m.Value())
+	assert.Equal(t, 5, m.Position())
+
+	m.SetValue(thumbsUp + "!")
+	m.SetCursor(0)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	assert.Equal(t, "!", m.Value())
+}
+
+func TestInputMethodCommitIsInsertedAtCursor(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.SetValue("echo ")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("日本語")})
+	assert.Equal(t, "echo 日本語", m.Value())
+	assert.Equal(t, len([]rune("echo 日本語")), m.Position())
+}
+
+func TestSwapCharactersKeepsCombiningMarks(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.SetValue("a" + combiningE)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	assert.Equal(t, combiningE+"a", m.Value())
+}
+
+func TestViewPadsWideCharactersToWidth(t *testing.T) {
+	for _, value := range []string{"echo 漢字", "echo " + familyEmoji, "echo " + combiningE} {
+		m := New()
+		m.Focus()
+		m.Prompt = "> "
+		m.Width = 30
+		m.SetValue(value)
+
+		view := m.View()
+		assert.NotContains(t, view, "\n", "value %q should not wrap", value)
+		assert.Equal(t, m.Width, lipgloss.Width(view), "value %q", value)
+	}
+}
+
+func TestViewCursorCoversWholeCluster(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.SetValue(familyEmoji + "x")
+	m.SetCursor(0)
+
+	// The cursor is rendered with the full sequence; the remaining text starts at x
+	assert.Contains(t, m.View(), familyEmoji)
+}
+
+func TestWrapGraphemes(t *testing.T) {
+	assert.Equal(t, "漢字\n漢字", wrapGraphemes("漢字漢字", 5))
+	assert.Equal(t, "ab"+familyEmoji+"\ncd", wrapGraphemes("ab"+familyEmoji+"cd", 4))
+	assert.Equal(t, "a"+combiningE+"b\nc", wrapGraphemes("a"+combiningE+"bc", 3))
+
+	// Escape sequences do not count towards the width
+	styled := "\x1b[1mabc\x1b[0mdef"
+	assert.Equal(t, "\x1b[1mabc\x1b[0m\ndef", wrapGraphemes(styled, 3))
+
+	for _, line := range strings.Split(wrapGraphemes(strings.Repeat("漢", 7), 4), "\n") {
+		assert.LessOrEqual(t, lipgloss.Width(line), 4)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dustin/go-humanize"
 	"github.com/muesli/ansi"
@@ -40,6 +41,19 @@ func (m HistoryFilterMode) String() string {
 	}
 }
 
+// Next returns the mode after m, cycling from All to Directory, Session and
+// back to All.
+func (m HistoryFilterMode) Next() HistoryFilterMode {
+	switch m {
+	case HistoryFilterAll:
+		return HistoryFilterDirectory
+	case HistoryFilterDirectory:
+		return HistoryFilterSession
+	default:
+		return HistoryFilterAll
+	}
+}
+
 // HistorySortMode defines the sort order of history search results
 type HistorySortMode int
 
@@ -47,6 +61,7 @@ const (
 	HistorySortRecent HistorySortMode = iota
 	HistorySortRelevance
 	HistorySortAlphabetical
+	HistorySortFrecency
 )
 
 func (m HistorySortMode) String() string {
@@ -55,6 +70,8 @@ func (m HistorySortMode) String() string {
 		return "Relevance"
 	case HistorySortAlphabetical:
 		return "Alphabetical"
+	case HistorySortFrecency:
+		return "Frecency"
 	default:
 		return "Recent"
 	}
@@ -68,11 +85,170 @@ type historySearchState struct {
 	sortMode         HistorySortMode
 	currentDir       string // used for filtering by directory
 	currentSessionID string // used for filtering by session
+	// valuesScope is the scope of the history values Up/Down go through
+	valuesScope HistoryFilterMode
+}
+
+// historyPageSize is how many history items the rich search loads when it
+// opens. Each page after that is as large as all those before, so that a
+// search going through all of a long history matches it only a few times.
+const historyPageSize = 1000
+
+// historyPageMargin is how many matches past the selected one the rich
+// search wants loaded, so that scrolling does not wait for the next page.
+const historyPageMargin = 50
+
+// HistoryPager returns up to limit history items from offset on, newest
+// first, and how many there are in all.
+type HistoryPager func(offset, limit int) (items []HistoryItem, total int)
+
+// historyPaging tracks the pages of history items loaded from a pager.
+type historyPaging struct {
+	pager HistoryPager
+	// loaded is how many items came from the pager, at the end of
+	// historyItems, out of total
+	loaded, total int
+	loading       bool
+}
+
+// historyPageMsg carries the page of history items from offset on.
+type historyPageMsg struct {
+	offset int
+	items  []HistoryItem
+	total  int
 }
 
 // SetRichHistory sets the history items for the rich search
 func (m *Model) SetRichHistory(items []HistoryItem) {
 	m.historyItems = items
+	m.historyPaging = historyPaging{}
+}
+
+// SetRichHistoryPager makes the rich search load its history items from
+// pager a page at a time: the newest page when it opens, and older ones in
+// the background while the matches loaded do not fill the list, or when the
+// order of the matches depends on all of them. Items added with
+// PrependHistory go in front of them.
+func (m *Model) SetRichHistoryPager(pager HistoryPager) {
+	m.historyItems = nil
+	m.historyPaging = historyPaging{pager: pager}
+}
+
+// loadFirstHistoryPage loads the newest page of the pager, unless a page
+// was loaded already.
+func (m *Model) loadFirstHistoryPage() {
+	paging := &m.historyPaging
+	if paging.pager == nil || paging.loaded > 0 || paging.loading {
+		return
+	}
+	items, total := paging.pager(0, historyPageSize)
+	m.addHistoryPage(historyPageMsg{offset: 0, items: items, total: total})
+}
+
+// addHistoryPage adds the items of a page after those loaded.
+func (m *Model) addHistoryPage(msg historyPageMsg) {
+	paging := &m.historyPaging
+	if msg.offset != paging.loaded {
+		return
+	}
+	paging.loading = false
+	m.historyItems = append(m.historyItems, msg.items...)
+	paging.loaded += len(msg.items)
+	paging.total = msg.total
+	if len(msg.items) == 0 {
+		paging.total = paging.loaded
+	}
+}
+
+// fetchHistoryPage returns a command that loads the next page of history
+// items if the search needs it.
+func (m *Model) fetchHistoryPage() tea.Cmd {
+	paging := &m.historyPaging
+	if !m.inReverseSearch || paging.pager == nil || paging.loading || paging.loaded >= paging.total {
+		return nil
+	}
+	// Matches in another order than the most recent first can be anywhere
+	// in history
+	state := m.historySearchState
+	if state.sortMode == HistorySortRecent && len(state.filteredIndices) >= state.selected+historyPageMargin {
+		return nil
+	}
+	paging.loading = true
+	pager, offset, limit := paging.pager, paging.loaded, max(historyPageSize, paging.loaded)
+	return func() tea.Msg {
+		items, total := pager(offset, limit)
+		return historyPageMsg{offset: offset, items: items, total: total}
+	}
+}
+
+// receiveHistoryPage adds a page loaded in the background and searches it
+// too, keeping the selected match, then loads the next if needed.
+func (m *Model) receiveHistoryPage(msg historyPageMsg) tea.Cmd {
+	selected := -1
+	if m.historySearchState.selected < len(m.historySearchState.filteredIndices) {
+		selected = m.historySearchState.filteredIndices[m.historySearchState.selected]
+	}
+	m.addHistoryPage(msg)
+	if !m.inReverseSearch {
+		return nil
+	}
+	m.updateHistorySearch()
+	for i, idx := range m.historySearchState.filteredIndices {
+		if idx == selected {
+			m.historySearchState.selected = i
+			break
+		}
+	}
+	return m.fetchHistoryPage()
+}
+
+// PrependHistory adds items that are newer than the existing history, such as
+// commands recorded by other shells while this prompt is open. Items must be
+// ordered newest first. Up/Down navigation only receives commands from the
+// scope of the history values, see SetHistoryScope, and an in-progress
+// navigation or reverse search keeps its current selection.
+func (m *Model) PrependHistory(items []HistoryItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	var newValues [][]rune
+	for _, item := range items {
+		if !m.inScope(item, m.historySearchState.valuesScope) {
+			continue
+		}
+		newValues = append(newValues, m.san().Sanitize([]rune(item.Command)))
+	}
+	if len(newValues) > 0 {
+		values := make([][]rune, 0, len(m.values)+len(newValues))
+		values = append(values, m.values[0])
+		values = append(values, newValues...)
+		values = append(values, m.values[1:]...)
+		m.values = values
+		if m.selectedValueIndex > 0 {
+			m.selectedValueIndex += len(newValues)
+		}
+		if m.lastCommandWasInsertArg {
+			m.lastArgInsertionIndex += len(newValues)
+		}
+	}
+
+	previouslySelected := -1
+	if m.inReverseSearch && m.historySearchState.selected < len(m.historySearchState.filteredIndices) {
+		previouslySelected = m.historySearchState.filteredIndices[m.historySearchState.selected] + len(items)
+	}
+
+	m.historyItems = append(append(make([]HistoryItem, 0, len(items)+len(m.historyItems)), items...), m.historyItems...)
+
+	if m.inReverseSearch {
+		m.updateHistorySearch()
+		for i, idx := range m.historySearchState.filteredIndices {
+			if idx == previouslySelected {
+				m.historySearchState.selected = i
+				break
+			}
+		}
+	}
 }
 
 // SetCurrentDirectory sets the current directory for filtering history
@@ -85,6 +261,31 @@ func (m *Model) SetCurrentSessionID(id string) {
 	m.historySearchState.currentSessionID = id
 }
 
+// SetHistoryScope sets the scope of the history values, which must be
+// loaded for it with SetHistoryValues, and the filter history search starts
+// with. The history values are those of the current directory by default.
+func (m *Model) SetHistoryScope(scope HistoryFilterMode) {
+	m.historySearchState.valuesScope = scope
+	m.historySearchState.filterMode = scope
+}
+
+// HistoryScope returns the scope of the history values.
+func (m Model) HistoryScope() HistoryFilterMode {
+	return m.historySearchState.valuesScope
+}
+
+// inScope reports whether item belongs in the history of scope.
+func (m Model) inScope(item HistoryItem, scope HistoryFilterMode) bool {
+	switch scope {
+	case HistoryFilterDirectory:
+		return m.historySearchState.currentDir == "" || item.Directory == m.historySearchState.currentDir
+	case HistoryFilterSession:
+		return m.historySearchState.currentSessionID == "" || item.SessionID == m.historySearchState.currentSessionID
+	default:
+		return true
+	}
+}
+
 // HistorySearchBoxView renders the history search box
 func (m Model) HistorySearchBoxView(height, width int) string {
 	if !m.inReverseSearch {
@@ -118,10 +319,16 @@ func (m Model) HistorySearchBoxView(height, width int) string {
 	filterText := fmt.Sprintf("Filter: %s", m.historySearchState.filterMode.String())
 	sortText := fmt.Sprintf("Sort: %s", m.historySearchState.sortMode.String())
 	matchCount := len(m.historySearchState.filteredIndices)
-	header := headerStyle.Render(fmt.Sprintf("%s | %s | %d matches",
+	countText := fmt.Sprintf("%d matches", matchCount)
+	if m.historyPaging.loaded < m.historyPaging.total {
+		// Older pages are still being searched
+		countText = fmt.Sprintf("%d matches in newest %s of %s", matchCount,
+			humanize.Comma(int64(m.historyPaging.loaded)), humanize.Comma(int64(m.historyPaging.total)))
+	}
+	header := headerStyle.Render(fmt.Sprintf("%s | %s | %s",
 		filterStyle.Render(filterText),
 		filterStyle.Render(sortText),
-		matchCount))
+		countText))
 	content.WriteString(header + "\n")
 
 	if matchCount == 0 {
@@ -244,26 +451,20 @@ func (m *Model) updateHistorySearch() {
 	// We keep track of seen commands to only include the first (most recent) occurrence
 	seen := make(map[string]bool)
 	var candidates []int // indices into historyItems
+	// Frecency adds up the weight of every run of a command in scope
+	scores := make(map[string]float64)
+	now := time.Now()
 
 	for i, item := range m.historyItems {
-		// Skip duplicates - keep only the first (most recent) occurrence of each command
-		if seen[item.Command] {
+		if !m.inScope(item, m.historySearchState.filterMode) {
 			continue
 		}
-
-		match := true
-		switch m.historySearchState.filterMode {
-		case HistoryFilterDirectory:
-			if m.historySearchState.currentDir != "" && item.Directory != m.historySearchState.currentDir {
-				match = false
-			}
-		case HistoryFilterSession:
-			if m.historySearchState.currentSessionID != "" && item.SessionID != m.historySearchState.currentSessionID {
-				match = false
-			}
+		if m.historySearchState.sortMode == HistorySortFrecency {
+			scores[item.Command] += frecencyWeight(item, m.historySearchState.currentDir, now)
 		}
 
-		if match {
+		// Skip duplicates - keep only the first (most recent) occurrence of each command
+		if !seen[item.Command] {
 			seen[item.Command] = true
 			candidates = append(candidates, i)
 		}
@@ -280,6 +481,10 @@ func (m *Model) updateHistorySearch() {
 			})
 		case HistorySortRelevance:
 			// Relevance implies query relevance, but with empty query, fallback to Recent
+		case HistorySortFrecency:
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return scores[m.historyItems[candidates[i]].Command] > scores[m.historyItems[candidates[j]].Command]
+			})
 		}
 
 		m.historySearchState.filteredIndices = candidates
@@ -311,6 +516,14 @@ func (m *Model) updateHistorySearch() {
 		})
 	case HistorySortRelevance:
 		// Already sorted by fuzzy score
+	case HistorySortFrecency:
+		// Most frecent first, the most recent first on ties
+		sort.SliceStable(matches, func(i, j int) bool {
+			if scores[matches[i].Str] != scores[matches[j].Str] {
+				return scores[matches[i].Str] > scores[matches[j].Str]
+			}
+			return matches[i].Index < matches[j].Index
+		})
 	}
 
 	m.historySearchState.filteredIndices = make([]int, len(matches))
@@ -321,6 +534,27 @@ func (m *Model) updateHistorySearch() {
 	m.historySearchState.selected = 0
 }
 
+// frecencyWeight weighs a run of a command by how long ago it was, as atuin
+// and zoxide do, doubled when it ran in the current directory.
+func frecencyWeight(item HistoryItem, currentDir string, now time.Time) float64 {
+	age := now.Sub(item.Timestamp)
+	weight := 0.25
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 1
+	case age < 30*24*time.Hour:
+		weight = 0.5
+	}
+	if currentDir != "" && item.Directory == currentDir {
+		weight *= 2
+	}
+	return weight
+}
+
 // historySourceSubset adapts a subset of HistoryItems for fuzzy matching
 type historySourceSubset struct {
 	indices []int
@@ -351,16 +585,7 @@ func (m *Model) historySearchDown() {
 
 // toggleHistoryFilter cycles through filter modes
 func (m *Model) toggleHistoryFilter() {
-	switch m.historySearchState.filterMode {
-	case HistoryFilterAll:
-		m.historySearchState.filterMode = HistoryFilterDirectory
-	case HistoryFilterDirectory:
-		m.historySearchState.filterMode = HistoryFilterSession
-	case HistoryFilterSession:
-		m.historySearchState.filterMode = HistoryFilterAll
-	default:
-		m.historySearchState.filterMode = HistoryFilterAll
-	}
+	m.historySearchState.filterMode = m.historySearchState.filterMode.Next()
 	m.updateHistorySearch()
 }
 
@@ -368,6 +593,8 @@ func (m *Model) toggleHistoryFilter() {
 func (m *Model) toggleHistorySort() {
 	switch m.historySearchState.sortMode {
 	case HistorySortRecent:
+		m.historySearchState.sortMode = HistorySortFrecency
+	case HistorySortFrecency:
 		m.historySearchState.sortMode = HistorySortRelevance
 	case HistorySortRelevance:
 		m.historySearchState.sortMode = HistorySortAlphabetical
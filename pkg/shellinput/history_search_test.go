@@ -1,11 +1,13 @@
 package shellinput
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRichHistorySearch(t *testing.T) {
@@ -143,3 +145,175 @@ func TestRichHistorySearchCancel(t *testing.T) {
 	updatedModel, _ = updatedModel.Update(msg)
 	assert.False(t, updatedModel.inReverseSearch)
 }
+
+func TestPrependHistory(t *testing.T) {
+	model := New()
+	model.Focus()
+	model.SetCurrentDirectory("/project")
+	model.SetHistoryValues([]string{"make test", "make build"})
+
+	now := time.Now()
+	model.SetRichHistory([]HistoryItem{
+		{Command: "make test", Timestamp: now.Add(-1 * time.Minute), Directory: "/project"},
+		{Command: "make build", Timestamp: now.Add(-2 * time.Minute), Directory: "/project"},
+	})
+
+	// Navigate up to the most recent command before new entries arrive
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "make test", model.Value())
+
+	model.PrependHistory([]HistoryItem{
+		{Command: "git pull", Timestamp: now, Directory: "/project", SessionID: "other"},
+		{Command: "ls /tmp", Timestamp: now, Directory: "/tmp", SessionID: "other"},
+	})
+
+	// The current selection is preserved
+	assert.Equal(t, "make test", model.Value())
+
+	// Only the command from the current directory joins Up/Down navigation
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, "git pull", model.Value())
+
+	// All new items are available to Ctrl+R
+	assert.Len(t, model.historyItems, 4)
+	assert.Equal(t, "git pull", model.historyItems[0].Command)
+}
+
+func TestHistoryScope(t *testing.T) {
+	model := New()
+	model.Focus()
+	model.SetCurrentDirectory("/project")
+	model.SetCurrentSessionID("session-1")
+	model.SetHistoryScope(HistoryFilterSession)
+	model.SetHistoryValues([]string{"make test"})
+
+	now := time.Now()
+	model.PrependHistory([]HistoryItem{
+		{Command: "git pull", Timestamp: now, Directory: "/project", SessionID: "other"},
+		{Command: "ls /tmp", Timestamp: now, Directory: "/tmp", SessionID: "session-1"},
+	})
+
+	// Only the command from this session joins Up/Down navigation
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "ls /tmp", model.Value())
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "make test", model.Value())
+
+	// Ctrl+R starts filtered to the scope
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	assert.Equal(t, HistoryFilterSession, model.historySearchState.filterMode)
+	assert.Len(t, model.historySearchState.filteredIndices, 1)
+}
+
+func TestPrependHistoryDuringReverseSearch(t *testing.T) {
+	model := New()
+	model.Focus()
+
+	now := time.Now()
+	model.SetRichHistory([]HistoryItem{
+		{Command: "echo one", Timestamp: now.Add(-2 * time.Minute)},
+		{Command: "echo two", Timestamp: now.Add(-3 * time.Minute)},
+	})
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	selected := model.historyItems[model.historySearchState.filteredIndices[model.historySearchState.selected]]
+	assert.Equal(t, "echo two", selected.Command)
+
+	model.PrependHistory([]HistoryItem{{Command: "echo three", Timestamp: now}})
+
+	assert.Len(t, model.historySearchState.filteredIndices, 3)
+	selected = model.historyItems[model.historySearchState.filteredIndices[model.historySearchState.selected]]
+	assert.Equal(t, "echo two", selected.Command, "selection should follow the previously selected item")
+}
+
+func TestRichHistorySearchFrecency(t *testing.T) {
+	model := New()
+	model.Focus()
+
+	now := time.Now()
+	model.SetRichHistory([]HistoryItem{
+		{Command: "ls", Timestamp: now.Add(-90 * time.Minute), Directory: "/tmp"},
+		{Command: "make test", Timestamp: now.Add(-2 * time.Hour), Directory: "/home/user/project"},
+		{Command: "git status", Timestamp: now.Add(-3 * time.Hour), Directory: "/tmp"},
+		{Command: "git status", Timestamp: now.Add(-4 * time.Hour), Directory: "/tmp"},
+		{Command: "git status", Timestamp: now.Add(-5 * time.Hour), Directory: "/tmp"},
+		{Command: "make build", Timestamp: now.Add(-60 * 24 * time.Hour), Directory: "/home/user/project"},
+	})
+	model.SetCurrentDirectory("/home/user/project")
+
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	assert.Equal(t, HistorySortFrecency, updatedModel.historySearchState.sortMode)
+
+	commands := func(m Model) []string {
+		var result []string
+		for _, idx := range m.historySearchState.filteredIndices {
+			result = append(result, m.historyItems[idx].Command)
+		}
+		return result
+	}
+	// git status ran 3 times today, make test once in this directory, and
+	// ls once elsewhere, though last
+	assert.Equal(t, []string{"git status", "make test", "ls", "make build"}, commands(updatedModel))
+
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("make")})
+	assert.Equal(t, []string{"make test", "make build"}, commands(updatedModel))
+
+	// Ctrl+O moves on to relevance
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	assert.Equal(t, HistorySortRelevance, updatedModel.historySearchState.sortMode)
+}
+
+func TestRichHistoryPager(t *testing.T) {
+	model := New()
+	model.Focus()
+
+	now := time.Now()
+	history := make([]HistoryItem, historyPageSize*3)
+	for i := range history {
+		history[i] = HistoryItem{Command: fmt.Sprintf("echo %d", i), Timestamp: now.Add(-time.Duration(i) * time.Second)}
+	}
+	history[len(history)-1].Command = "make rare"
+	var pages [][2]int
+	model.SetRichHistoryPager(func(offset, limit int) ([]HistoryItem, int) {
+		pages = append(pages, [2]int{offset, limit})
+		return history[offset:min(offset+limit, len(history))], len(history)
+	})
+	model.PrependHistory([]HistoryItem{{Command: "echo new", Timestamp: now}})
+	assert.Empty(t, pages, "nothing is loaded before the search opens")
+
+	// The search opens on the newest page, which has enough matches
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	assert.Nil(t, cmd)
+	assert.Equal(t, [][2]int{{0, historyPageSize}}, pages)
+	assert.Len(t, model.historyItems, historyPageSize+1)
+	assert.Equal(t, "echo new", model.historyItems[0].Command)
+	assert.Contains(t, model.HistorySearchBoxView(10, 80), "in newest 1,000 of 3,000")
+
+	// A query with few matches loads older pages until there are no more
+	var cmds []tea.Cmd
+	for _, r := range "rare" {
+		model, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	for len(cmds) > 0 {
+		model, cmd = model.Update(cmds[0]())
+		if cmds = cmds[1:]; cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	assert.Equal(t, [][2]int{{0, 1000}, {1000, 1000}, {2000, 2000}}, pages)
+	assert.Len(t, model.historyItems, len(history)+1)
+	require.NotEmpty(t, model.historySearchState.filteredIndices)
+	assert.Equal(t, "make rare", model.historyItems[model.historySearchState.filteredIndices[0]].Command)
+	assert.Contains(t, model.HistorySearchBoxView(10, 80), "| 1 matches")
+
+	// Pages are not loaded again
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, cmd = model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	assert.Nil(t, cmd)
+	assert.Len(t, pages, 3)
+}
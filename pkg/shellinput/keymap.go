@@ -0,0 +1,97 @@
+package shellinput
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMapActions names the bindings of a KeyMap, for configuration.
+var keyMapActions = map[string]func(km *KeyMap) *key.Binding{
+	"character_forward":         func(km *KeyMap) *key.Binding { return &km.CharacterForward },
+	"character_backward":        func(km *KeyMap) *key.Binding { return &km.CharacterBackward },
+	"word_forward":              func(km *KeyMap) *key.Binding { return &km.WordForward },
+	"word_backward":             func(km *KeyMap) *key.Binding { return &km.WordBackward },
+	"delete_word_backward":      func(km *KeyMap) *key.Binding { return &km.DeleteWordBackward },
+	"delete_word_forward":       func(km *KeyMap) *key.Binding { return &km.DeleteWordForward },
+	"delete_after_cursor":       func(km *KeyMap) *key.Binding { return &km.DeleteAfterCursor },
+	"delete_before_cursor":      func(km *KeyMap) *key.Binding { return &km.DeleteBeforeCursor },
+	"delete_character_backward": func(km *KeyMap) *key.Binding { return &km.DeleteCharacterBackward },
+	"delete_character_forward":  func(km *KeyMap) *key.Binding { return &km.DeleteCharacterForward },
+	"line_start":                func(km *KeyMap) *key.Binding { return &km.LineStart },
+	"line_end":                  func(km *KeyMap) *key.Binding { return &km.LineEnd },
+	"paste":                     func(km *KeyMap) *key.Binding { return &km.Paste },
+	"yank":                      func(km *KeyMap) *key.Binding { return &km.Yank },
+	"yank_pop":                  func(km *KeyMap) *key.Binding { return &km.YankPop },
+	"next_value":                func(km *KeyMap) *key.Binding { return &km.NextValue },
+	"prev_value":                func(km *KeyMap) *key.Binding { return &km.PrevValue },
+	"complete":                  func(km *KeyMap) *key.Binding { return &km.Complete },
+	"prev_suggestion":           func(km *KeyMap) *key.Binding { return &km.PrevSuggestion },
+	"clear_screen":              func(km *KeyMap) *key.Binding { return &km.ClearScreen },
+	"reverse_search":            func(km *KeyMap) *key.Binding { return &km.ReverseSearch },
+	"history_sort":              func(km *KeyMap) *key.Binding { return &km.HistorySort },
+	"swap_characters":           func(km *KeyMap) *key.Binding { return &km.SwapCharacters },
+	"swap_words":                func(km *KeyMap) *key.Binding { return &km.SwapWords },
+	"insert_last_arg":           func(km *KeyMap) *key.Binding { return &km.InsertLastArg },
+	"toggle_sudo":               func(km *KeyMap) *key.Binding { return &km.ToggleSudo },
+	"apply_usual_flags":         func(km *KeyMap) *key.Binding { return &km.ApplyUsualFlags },
+	"cycle_args":                func(km *KeyMap) *key.Binding { return &km.CycleArgs },
+	"next_command_menu":         func(km *KeyMap) *key.Binding { return &km.NextCommandMenu },
+	"next_prediction":           func(km *KeyMap) *key.Binding { return &km.NextPrediction },
+	"prev_prediction":           func(km *KeyMap) *key.Binding { return &km.PrevPrediction },
+	"pipeline_builder":          func(km *KeyMap) *key.Binding { return &km.PipelineBuilder },
+	"write_program":             func(km *KeyMap) *key.Binding { return &km.WriteProgram },
+	"regex_tester":              func(km *KeyMap) *key.Binding { return &km.RegexTester },
+	"history_scope":             func(km *KeyMap) *key.Binding { return &km.HistoryScope },
+}
+
+// KeyMapActions returns the names of the actions Bind accepts, in order.
+func KeyMapActions() []string {
+	actions := make([]string, 0, len(keyMapActions))
+	for action := range keyMapActions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Bind makes keys, such as "ctrl+r" or "alt+f", the keys of action, one of
+// KeyMapActions. The keys no longer trigger the actions they were bound to
+// before, so that a key does only what it was last bound to. Without keys,
+// the action is unbound.
+func (km *KeyMap) Bind(action string, keys ...string) error {
+	binding, ok := keyMapActions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	keys = slices.Clone(keys)
+	for i, k := range keys {
+		keys[i] = strings.TrimSpace(k)
+		if keys[i] == "" {
+			return fmt.Errorf("%s: empty key", action)
+		}
+	}
+
+	for _, other := range keyMapActions {
+		b := other(km)
+		remaining := slices.DeleteFunc(slices.Clone(b.Keys()), func(k string) bool {
+			return slices.Contains(keys, k)
+		})
+		if len(remaining) != len(b.Keys()) {
+			*b = newBinding(remaining)
+		}
+	}
+	*binding(km) = newBinding(keys)
+	return nil
+}
+
+// newBinding returns a binding of keys, which is disabled if there are none.
+func newBinding(keys []string) key.Binding {
+	if len(keys) == 0 {
+		return key.NewBinding(key.WithDisabled())
+	}
+	return key.NewBinding(key.WithKeys(keys...))
+}
@@ -0,0 +1,55 @@
+package shellinput
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyMapBind(t *testing.T) {
+	keyMap := DefaultKeyMap
+	require.NoError(t, keyMap.Bind("history_sort", "ctrl+r"))
+	require.NoError(t, keyMap.Bind("reverse_search", "ctrl+s", " alt+r "))
+
+	assert.Equal(t, []string{"ctrl+r"}, keyMap.HistorySort.Keys())
+	assert.Equal(t, []string{"ctrl+s", "alt+r"}, keyMap.ReverseSearch.Keys())
+	// The defaults are left alone
+	assert.Equal(t, []string{"ctrl+r"}, DefaultKeyMap.ReverseSearch.Keys())
+
+	// A key moves to the action it is bound to
+	require.NoError(t, keyMap.Bind("line_start", "ctrl+b"))
+	assert.Equal(t, []string{"left"}, keyMap.CharacterBackward.Keys())
+
+	// Without keys, the action is unbound
+	require.NoError(t, keyMap.Bind("yank_pop"))
+	assert.False(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}, Alt: true}, keyMap.YankPop))
+
+	assert.EqualError(t, keyMap.Bind("rewind", "ctrl+z"), `unknown action "rewind"`)
+	assert.EqualError(t, keyMap.Bind("complete", ""), "complete: empty key")
+}
+
+func TestKeyMapActions(t *testing.T) {
+	actions := KeyMapActions()
+	assert.Len(t, actions, 35)
+	assert.Contains(t, actions, "reverse_search")
+	assert.IsIncreasing(t, actions)
+}
+
+func TestReboundKeys(t *testing.T) {
+	model := New()
+	model.Focus()
+	require.NoError(t, model.KeyMap.Bind("line_start", "ctrl+b"))
+	model.SetValue("echo hi")
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	assert.Equal(t, 0, updated.Position())
+
+	// Ctrl+A does nothing once line_start is bound to Home alone
+	require.NoError(t, model.KeyMap.Bind("line_start", "home"))
+	model.SetCursor(3)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.Equal(t, 3, updated.Position())
+}
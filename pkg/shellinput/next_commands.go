@@ -0,0 +1,128 @@
+package shellinput
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/ansi"
+)
+
+// nextCommandMenuState tracks the menu of likely next commands
+type nextCommandMenuState struct {
+	active   bool
+	commands []string
+	selected int
+}
+
+// InNextCommandMenu returns true if the menu of likely next commands is open.
+func (m Model) InNextCommandMenu() bool {
+	return m.nextCommandMenu.active
+}
+
+// NextCommandMenuItems returns the commands offered by the open menu.
+func (m Model) NextCommandMenuItems() []string {
+	return m.nextCommandMenu.commands
+}
+
+// ReorderNextCommandMenu replaces the commands of the open menu with the same
+// commands in another order, e.g. once they have been re-ranked. It does
+// nothing if the menu was closed or offers other commands by now.
+func (m *Model) ReorderNextCommandMenu(commands []string) {
+	if !m.nextCommandMenu.active {
+		return
+	}
+	current := slices.Clone(m.nextCommandMenu.commands)
+	reordered := slices.Clone(commands)
+	slices.Sort(current)
+	slices.Sort(reordered)
+	if !slices.Equal(current, reordered) {
+		return
+	}
+	m.nextCommandMenu.commands = commands
+	m.nextCommandMenu.selected = 0
+}
+
+// openNextCommandMenu opens the menu of likely next commands, if the line is
+// empty and there are any.
+func (m *Model) openNextCommandMenu() {
+	if m.NextCommands == nil || strings.TrimSpace(m.Value()) != "" {
+		return
+	}
+	commands := m.NextCommands()
+	if len(commands) == 0 {
+		return
+	}
+	m.nextCommandMenu = nextCommandMenuState{active: true, commands: commands}
+}
+
+// handleNextCommandMenuKey moves the selection with the arrows, or puts the
+// chosen command on the line with Enter or its digit.
+func (m *Model) handleNextCommandMenuKey(msg tea.KeyMsg) {
+	menu := &m.nextCommandMenu
+	switch {
+	case key.Matches(msg, m.KeyMap.PrevValue):
+		if menu.selected > 0 {
+			menu.selected--
+		}
+	case key.Matches(msg, m.KeyMap.NextValue):
+		if menu.selected < len(menu.commands)-1 {
+			menu.selected++
+		}
+	case msg.String() == "enter":
+		m.acceptNextCommand(menu.selected)
+	case len(msg.Runes) == 1 && msg.Runes[0] >= '1' && msg.Runes[0] <= '9':
+		if index := int(msg.Runes[0] - '1'); index < len(menu.commands) {
+			m.acceptNextCommand(index)
+		}
+	case msg.String() == "esc" || msg.String() == "ctrl+c" || msg.String() == "ctrl+g" ||
+		key.Matches(msg, m.KeyMap.NextCommandMenu):
+		menu.active = false
+	}
+}
+
+func (m *Model) acceptNextCommand(index int) {
+	m.SetValue(m.nextCommandMenu.commands[index])
+	m.CursorEnd()
+	m.nextCommandMenu.active = false
+}
+
+// NextCommandMenuView renders the menu of likely next commands if it is open.
+func (m Model) NextCommandMenuView(height, width int) string {
+	if !m.nextCommandMenu.active {
+		return ""
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14"))
+	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+
+	var content strings.Builder
+	content.WriteString(headerStyle.Render("Run next") + "\n")
+
+	// Leave room for the header and help
+	rows := max(1, height-2)
+	start := max(0, min(m.nextCommandMenu.selected-rows+1, len(m.nextCommandMenu.commands)-rows))
+	end := min(len(m.nextCommandMenu.commands), start+rows)
+	for i := start; i < end; i++ {
+		command := m.nextCommandMenu.commands[i]
+		prefix := fmt.Sprintf("  %d ", i+1)
+		if i == m.nextCommandMenu.selected {
+			prefix = fmt.Sprintf("> %d ", i+1)
+		}
+		if runes, room := []rune(command), width-len(prefix); room > 1 && ansi.PrintableRuneWidth(command) > room && len(runes) >= room {
+			command = string(runes[:room-1]) + "…"
+		}
+		if i == m.nextCommandMenu.selected {
+			content.WriteString(selectedStyle.Render(prefix+command) + "\n")
+		} else {
+			content.WriteString(normalStyle.Render(prefix+command) + "\n")
+		}
+	}
+	content.WriteString(helpStyle.Render("↑↓: Move | 1-9/Enter: Select | Esc: Cancel"))
+	return content.String()
+}
@@ -40,7 +40,6 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/ansi"
-	"github.com/muesli/reflow/wrap"
 	"github.com/rivo/uniseg"
 	"mvdan.cc/sh/v3/syntax"
 )
@@ -51,6 +50,10 @@ type (
 	pasteErrMsg struct{ error }
 )
 
+// ReplaceMsg replaces the value of the input, as an edit of the user's, e.g.
+// with the line edited in an external editor. The cursor goes to the end.
+type ReplaceMsg string
+
 // EchoMode sets the input behavior of the text input field.
 type EchoMode int
 
@@ -97,6 +100,16 @@ type KeyMap struct {
 	SwapCharacters          key.Binding
 	SwapWords               key.Binding
 	InsertLastArg           key.Binding
+	ToggleSudo              key.Binding
+	ApplyUsualFlags         key.Binding
+	CycleArgs               key.Binding
+	NextCommandMenu         key.Binding
+	NextPrediction          key.Binding
+	PrevPrediction          key.Binding
+	PipelineBuilder         key.Binding
+	WriteProgram            key.Binding
+	RegexTester             key.Binding
+	HistoryScope            key.Binding
 }
 
 // DefaultKeyMap is the default set of key bindings for navigating and acting
@@ -127,6 +140,16 @@ var DefaultKeyMap = KeyMap{
 	SwapCharacters:          key.NewBinding(key.WithKeys("ctrl+t")),
 	SwapWords:               key.NewBinding(key.WithKeys("alt+t")),
 	InsertLastArg:           key.NewBinding(key.WithKeys("alt+.")),
+	ToggleSudo:              key.NewBinding(key.WithKeys("alt+s")),
+	ApplyUsualFlags:         key.NewBinding(key.WithKeys("alt+u")),
+	CycleArgs:               key.NewBinding(key.WithKeys("alt+a")),
+	NextCommandMenu:         key.NewBinding(key.WithKeys("ctrl+@")), // Ctrl+Space arrives as NUL, i.e. ctrl+@
+	NextPrediction:          key.NewBinding(key.WithKeys("alt+]")),
+	PrevPrediction:          key.NewBinding(key.WithKeys("alt+[")),
+	PipelineBuilder:         key.NewBinding(key.WithKeys("alt+p")),
+	WriteProgram:            key.NewBinding(key.WithKeys("alt+j")),
+	RegexTester:             key.NewBinding(key.WithKeys("alt+g")),
+	HistoryScope:            key.NewBinding(key.WithKeys("alt+h")),
 }
 
 const (
@@ -207,6 +230,11 @@ type Model struct {
 	lastCommandWasInsertArg bool
 	lastInsertedArgLen      int
 
+	// State for Alt+A (Cycle Arguments): the typed line followed by the
+	// lines offered for it, and which one is shown. nil when not cycling.
+	argCycle      []string
+	argCycleIndex int
+
 	// Validate is a function that checks whether or not the text within the
 	// input is valid. If it is not valid, the `Err` field will be set to the
 	// error returned by the function. If the function is not defined, all
@@ -219,6 +247,33 @@ type Model struct {
 	// Should the input suggest to complete
 	ShowSuggestions bool
 
+	// AutoPair enables automatic closing of quotes and brackets as they are typed
+	AutoPair bool
+
+	// PasteFilter, if set, rewrites pasted text before it is inserted, e.g. to
+	// convert the path of a file dragged onto the terminal.
+	PasteFilter func(text string) string
+
+	// UsualFlags returns the line with the flags the user usually passes to
+	// the command being typed added, or false if there are none to add. It is
+	// called when ApplyUsualFlags is pressed; if nil, the key does nothing.
+	UsualFlags func(line string) (string, bool)
+
+	// ArgHistory returns the line completed with each set of arguments
+	// previously passed to the command it starts with, most recent first. It
+	// is called when CycleArgs is pressed; if nil, the key does nothing.
+	ArgHistory func(line string) []string
+
+	// NextCommands returns the commands the user is likely to run next, the
+	// likeliest first. It is called when NextCommandMenu is pressed on an
+	// empty line; if nil, the key does nothing.
+	NextCommands func() []string
+
+	// Abbreviations returns the expansion of a word if it is an abbreviation.
+	// Abbreviations typed as a command expand when followed by a space, see
+	// ExpandAbbreviation; if nil, nothing expands.
+	Abbreviations func(word string) (string, bool)
+
 	// suppressSuggestionsUntilInput temporarily disables autocomplete hints
 	// until the user enters more text. This is used, for example, when the
 	// user trims the line with Ctrl+K so that ghost text and help reflect
@@ -242,7 +297,11 @@ type Model struct {
 
 	// Rich history search
 	historyItems       []HistoryItem
+	historyPaging      historyPaging
 	historySearchState historySearchState
+
+	// Menu of likely next commands, opened on an empty line
+	nextCommandMenu nextCommandMenuState
 }
 
 // New creates a new model with default settings.
@@ -263,6 +322,7 @@ func New() Model {
 
 		values:             [][]rune{{}},
 		selectedValueIndex: 0,
+		historySearchState: historySearchState{valuesScope: HistoryFilterDirectory},
 	}
 }
 
@@ -481,6 +541,10 @@ func (m *Model) recordKill(killed []rune, direction killDirection) {
 			m.killRingIndex = 0
 		}
 		m.lastCommandWasKill = true
+		if WriteClipboard != nil {
+			// Don't hold up the input for a slow clipboard
+			go func(text string) { _ = WriteClipboard(text) }(string(m.killRing[0]))
+		}
 	} else {
 		m.lastCommandWasKill = false
 	}
@@ -706,24 +770,30 @@ func (m *Model) wordForward() {
 
 // swapCharacters swaps the character before the cursor with the one before that.
 func (m *Model) swapCharacters() {
-	if m.pos == 0 || len(m.values[m.selectedValueIndex]) < 2 {
+	v := m.values[m.selectedValueIndex]
+	if m.pos == 0 || len(v) < 2 {
 		return
 	}
 
-	// If at end of line, swap the two characters before the cursor
-	idx := m.pos
-	if idx == len(m.values[m.selectedValueIndex]) {
-		// Swap idx-1 and idx-2
-		m.values[m.selectedValueIndex][idx-1], m.values[m.selectedValueIndex][idx-2] = m.values[m.selectedValueIndex][idx-2], m.values[m.selectedValueIndex][idx-1]
-		m.values[0] = m.values[m.selectedValueIndex]
-		// Cursor stays at end
+	// At end of line, swap the two characters before the cursor. Otherwise swap
+	// the character before the cursor with the one under it. Characters are
+	// whole grapheme clusters so combining marks stay with their base.
+	end := m.pos
+	if end < len(v) {
+		end = nextGraphemeBoundary(v, m.pos)
+	}
+	mid := prevGraphemeBoundary(v, end)
+	start := prevGraphemeBoundary(v, mid)
+	if start == mid {
 		return
 	}
 
-	// Swap idx-1 and idx
-	m.values[m.selectedValueIndex][idx-1], m.values[m.selectedValueIndex][idx] = m.values[m.selectedValueIndex][idx], m.values[m.selectedValueIndex][idx-1]
-	m.values[0] = m.values[m.selectedValueIndex]
-	m.SetCursor(m.pos + 1)
+	swapped := cloneConcatRunes(v[:start], v[mid:end])
+	swapped = append(swapped, v[start:mid]...)
+	swapped = append(swapped, v[end:]...)
+	m.values[0] = swapped
+	m.selectedValueIndex = 0
+	m.SetCursor(end)
 }
 
 // swapWords swaps the word before the cursor with the word before that.
@@ -887,6 +957,88 @@ func (m *Model) insertLastArg() {
 	m.lastCommandWasInsertArg = true
 }
 
+const sudoPrefix = "sudo "
+
+// toggleSudo prepends "sudo " to the line, or removes it if the line already
+// starts with it. On an empty line it recalls the last command with sudo,
+// like typing "sudo !!".
+func (m *Model) toggleSudo() {
+	v := m.values[m.selectedValueIndex]
+	if strings.TrimSpace(string(v)) == "" {
+		if len(m.values) <= 1 || len(m.values[1]) == 0 {
+			return
+		}
+		m.values[0] = []rune(sudoPrefix + string(m.values[1]))
+		m.selectedValueIndex = 0
+		m.SetCursor(len(m.values[0]))
+		return
+	}
+
+	prefix := []rune(sudoPrefix)
+	pos := m.pos
+	var newValue []rune
+	if strings.HasPrefix(string(v), sudoPrefix) {
+		newValue = cloneConcatRunes(v[len(prefix):], nil)
+		pos = max(pos-len(prefix), 0)
+	} else {
+		newValue = cloneConcatRunes(prefix, v)
+		pos += len(prefix)
+	}
+	m.Err = m.validate(newValue)
+	m.values[0] = newValue
+	m.selectedValueIndex = 0
+	m.SetCursor(pos)
+}
+
+// cycleArgs replaces the line with the next one returned by ArgHistory for
+// the line as typed, and after the last one goes back to what was typed.
+func (m *Model) cycleArgs() {
+	if m.ArgHistory == nil {
+		return
+	}
+	if m.argCycle == nil {
+		typed := string(m.values[m.selectedValueIndex])
+		lines := m.ArgHistory(typed)
+		if len(lines) == 0 {
+			return
+		}
+		m.argCycle = append([]string{typed}, lines...)
+		m.argCycleIndex = 0
+	}
+	m.argCycleIndex = (m.argCycleIndex + 1) % len(m.argCycle)
+	newValue := []rune(m.argCycle[m.argCycleIndex])
+	m.Err = m.validate(newValue)
+	m.values[0] = newValue
+	m.selectedValueIndex = 0
+	m.SetCursor(len(newValue))
+}
+
+// applyUsualFlags replaces the line with the one returned by UsualFlags. The
+// cursor keeps its place relative to the text around it.
+func (m *Model) applyUsualFlags() {
+	if m.UsualFlags == nil {
+		return
+	}
+	v := m.values[m.selectedValueIndex]
+	line, ok := m.UsualFlags(string(v))
+	if !ok {
+		return
+	}
+	newValue := []rune(line)
+	common := 0
+	for common < len(v) && common < len(newValue) && v[common] == newValue[common] {
+		common++
+	}
+	pos := m.pos
+	if pos >= common {
+		pos += len(newValue) - len(v)
+	}
+	m.Err = m.validate(newValue)
+	m.values[0] = newValue
+	m.selectedValueIndex = 0
+	m.SetCursor(pos)
+}
+
 func GetLastArgument(line string) string {
 	p := syntax.NewParser()
 	f, err := p.Parse(strings.NewReader(line), "")
@@ -939,11 +1091,18 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	oldPos := m.pos
 
 	switch msg := msg.(type) {
+	case historyPageMsg:
+		return m, m.receiveHistoryPage(msg)
+
 	case tea.KeyMsg:
 		// Reset lastCommandWasInsertArg unless InsertLastArg was pressed
 		if !key.Matches(msg, m.KeyMap.InsertLastArg) {
 			m.lastCommandWasInsertArg = false
 		}
+		// Likewise, any other key ends cycling through previous arguments
+		if !key.Matches(msg, m.KeyMap.CycleArgs) {
+			m.argCycle = nil
+		}
 
 		// Handle reverse search specific keys
 		if m.inReverseSearch {
@@ -964,15 +1123,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, nil
 			case key.Matches(msg, m.KeyMap.NextValue): // Down
 				m.historySearchDown()
-				return m, nil
+				return m, m.fetchHistoryPage()
 			// Toggle Filter with Ctrl+F
 			case msg.String() == "ctrl+f":
 				m.toggleHistoryFilter()
-				return m, nil
+				return m, m.fetchHistoryPage()
 			// Toggle Sort with Ctrl+O
 			case key.Matches(msg, m.KeyMap.HistorySort):
 				m.toggleHistorySort()
-				return m, nil
+				return m, m.fetchHistoryPage()
 			// Left/Right: Accept and edit?
 			case key.Matches(msg, m.KeyMap.CharacterBackward), key.Matches(msg, m.KeyMap.CharacterForward):
 				m.acceptRichReverseSearch()
@@ -989,17 +1148,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 					m.reverseSearchQuery = string(runes[:len(runes)-1])
 					m.updateHistorySearch()
 				}
-				return m, nil
+				return m, m.fetchHistoryPage()
 			case len(msg.Runes) > 0 && unicode.IsPrint(msg.Runes[0]):
 				m.reverseSearchQuery += string(msg.Runes)
 				m.updateHistorySearch()
-				return m, nil
+				return m, m.fetchHistoryPage()
 			default:
 				// Ignore other keys in reverse search mode
 				return m, nil
 			}
 		}
 
+		if m.nextCommandMenu.active {
+			m.handleNextCommandMenuKey(msg)
+			return m, nil
+		}
+
 		// Handle completion-specific keys first
 		if m.completion.active {
 			switch msg.String() {
@@ -1036,7 +1200,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		switch {
 		case key.Matches(msg, m.KeyMap.ReverseSearch):
 			m.toggleReverseSearch()
-			return m, nil
+			return m, m.fetchHistoryPage()
 		case key.Matches(msg, m.KeyMap.Complete):
 			m.handleCompletion()
 			return m, nil
@@ -1049,30 +1213,40 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.swapWords()
 		case key.Matches(msg, m.KeyMap.InsertLastArg):
 			m.insertLastArg()
+		case key.Matches(msg, m.KeyMap.ToggleSudo):
+			m.toggleSudo()
+		case key.Matches(msg, m.KeyMap.ApplyUsualFlags):
+			m.applyUsualFlags()
+		case key.Matches(msg, m.KeyMap.CycleArgs):
+			m.cycleArgs()
+		case key.Matches(msg, m.KeyMap.NextCommandMenu):
+			m.openNextCommandMenu()
 		case key.Matches(msg, m.KeyMap.DeleteWordBackward):
 			m.deleteWordBackward()
 		case key.Matches(msg, m.KeyMap.DeleteCharacterBackward):
 			m.Err = nil
+			if m.autoPairDelete() {
+				break
+			}
 			if len(m.values[m.selectedValueIndex]) > 0 {
-				newValue := cloneConcatRunes(m.values[m.selectedValueIndex][:max(0, m.pos-1)], m.values[m.selectedValueIndex][m.pos:])
+				start := prevGraphemeBoundary(m.values[m.selectedValueIndex], m.pos)
+				newValue := cloneConcatRunes(m.values[m.selectedValueIndex][:start], m.values[m.selectedValueIndex][m.pos:])
 				m.Err = m.validate(newValue)
 				m.values[0] = newValue
 				m.selectedValueIndex = 0
-				if m.pos > 0 {
-					m.SetCursor(m.pos - 1)
-				}
+				m.SetCursor(start)
 			}
 		case key.Matches(msg, m.KeyMap.WordBackward):
 			m.wordBackward()
 		case key.Matches(msg, m.KeyMap.CharacterBackward):
 			if m.pos > 0 {
-				m.SetCursor(m.pos - 1)
+				m.SetCursor(prevGraphemeBoundary(m.values[m.selectedValueIndex], m.pos))
 			}
 		case key.Matches(msg, m.KeyMap.WordForward):
 			m.wordForward()
 		case key.Matches(msg, m.KeyMap.CharacterForward):
 			if m.pos < len(m.values[m.selectedValueIndex]) {
-				m.SetCursor(m.pos + 1)
+				m.SetCursor(nextGraphemeBoundary(m.values[m.selectedValueIndex], m.pos))
 			} else if m.canAcceptSuggestion() {
 				newValue := cloneConcatRunes(
 					m.values[m.selectedValueIndex],
@@ -1087,7 +1261,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.CursorStart()
 		case key.Matches(msg, m.KeyMap.DeleteCharacterForward):
 			if len(m.values[m.selectedValueIndex]) > 0 && m.pos < len(m.values[m.selectedValueIndex]) {
-				newValue := cloneConcatRunes(m.values[m.selectedValueIndex][:m.pos], m.values[m.selectedValueIndex][m.pos+1:])
+				end := nextGraphemeBoundary(m.values[m.selectedValueIndex], m.pos)
+				newValue := cloneConcatRunes(m.values[m.selectedValueIndex][:m.pos], m.values[m.selectedValueIndex][end:])
 				m.Err = m.validate(newValue)
 				m.values[0] = newValue
 				m.selectedValueIndex = 0
@@ -1117,6 +1292,19 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 		default:
 			// Input one or more regular characters.
+			if len(msg.Runes) == 1 && msg.Runes[0] == ' ' && !msg.Paste {
+				m.ExpandAbbreviation()
+			}
+			if len(msg.Runes) == 1 && !msg.Paste && m.autoPairInsert(msg.Runes[0]) {
+				break
+			}
+			if msg.Paste && m.PasteFilter != nil {
+				m.insertRunesFromUserInput([]rune(m.PasteFilter(string(msg.Runes))))
+				break
+			}
+			// Input methods for languages such as Japanese are drawn by the
+			// terminal while composing, and the text they commit arrives
+			// here as one event
 			m.insertRunesFromUserInput(msg.Runes)
 		}
 
@@ -1136,10 +1324,21 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.updateHelpInfo()
 
 	case pasteMsg:
-		m.insertRunesFromUserInput([]rune(msg))
+		text := string(msg)
+		if m.PasteFilter != nil {
+			text = m.PasteFilter(text)
+		}
+		m.insertRunesFromUserInput([]rune(text))
 
 	case pasteErrMsg:
 		m.Err = msg
+
+	case ReplaceMsg:
+		m.resetCompletion()
+		m.SetValue(string(msg))
+		m.CursorEnd()
+		m.updateSuggestions()
+		m.updateHelpInfo()
 	}
 
 	var cmds []tea.Cmd
@@ -1186,19 +1385,23 @@ func (m Model) View() string {
 	v := m.PromptStyle.Render(m.Prompt) + styleText(m.echoTransform(string(value[:pos])))
 
 	if pos < len(value) { //nolint:nestif
-		char := m.echoTransform(string(value[pos]))
+		// The cursor covers the whole grapheme cluster so that combining marks
+		// and emoji sequences are not split by the cursor's escape codes
+		end := nextGraphemeBoundary(value, pos)
+		char := m.echoTransform(string(value[pos:end]))
 		m.Cursor.SetChar(char)
-		v += m.Cursor.View()                                   // cursor and text under it
-		v += styleText(m.echoTransform(string(value[pos+1:]))) // text after cursor
-		v += m.completionView(0)                               // suggested completion
+		v += m.Cursor.View()                                 // cursor and text under it
+		v += styleText(m.echoTransform(string(value[end:]))) // text after cursor
+		v += m.completionView(0)                             // suggested completion
 	} else {
 		if m.canAcceptSuggestion() {
 			suggestion := m.matchedSuggestions[m.currentSuggestionIndex]
 			if len(value) < len(suggestion) {
+				end := nextGraphemeBoundary(suggestion, pos)
 				m.Cursor.TextStyle = m.CompletionStyle
-				m.Cursor.SetChar(m.echoTransform(string(suggestion[pos])))
+				m.Cursor.SetChar(m.echoTransform(string(suggestion[pos:end])))
 				v += m.Cursor.View()
-				v += m.completionView(1)
+				v += m.completionView(end - pos)
 			} else {
 				m.Cursor.SetChar(" ")
 				v += m.Cursor.View()
@@ -1210,7 +1413,9 @@ func (m Model) View() string {
 		v += m.completionSuffixView() // suffix from active completion (e.g., "/" for directories)
 	}
 
-	totalWidth := uniseg.StringWidth(v)
+	// Measure printable cells only: the prompt, cursor and completion carry
+	// styling escape codes that take up no space on screen
+	totalWidth := lipgloss.Width(v)
 
 	// If a max width is set, we need to respect the horizontal boundary
 	if m.Width > 0 {
@@ -1222,7 +1427,7 @@ func (m Model) View() string {
 			}
 			v += styleText(strings.Repeat(" ", padding))
 		} else {
-			v = wrap.String(v, m.Width)
+			v = wrapGraphemes(v, m.Width)
 		}
 	}
 
@@ -1234,9 +1439,15 @@ func Blink() tea.Msg {
 	return cursor.Blink()
 }
 
+// ReadClipboard reads the system clipboard for Paste.
+var ReadClipboard = clipboard.ReadAll
+
+// WriteClipboard, if set, also copies killed text to the system clipboard.
+var WriteClipboard func(text string) error
+
 // Paste is a command for pasting from the clipboard into the text input.
 func Paste() tea.Msg {
-	str, err := clipboard.ReadAll()
+	str, err := ReadClipboard()
 	if err != nil {
 		return pasteErrMsg{err}
 	}
@@ -1609,6 +1820,7 @@ func (m *Model) toggleReverseSearch() {
 	} else {
 		m.inReverseSearch = true
 		m.reverseSearchQuery = ""
+		m.loadFirstHistoryPage()
 		m.updateHistorySearch()
 	}
 }